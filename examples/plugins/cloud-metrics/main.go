@@ -12,8 +12,10 @@
 // Configuration:
 //   cloud_provider: aws|gcp|azure
 //   cloud_region: us-east-1
-//   aws_access_key_id: <access-key>
-//   aws_secret_access_key: <secret-key>
+//
+// Secrets:
+//   forge secret set cloud-metrics/aws_access_key_id
+//   forge secret set cloud-metrics/aws_secret_access_key
 
 package main
 
@@ -11,10 +11,12 @@
 //
 // Configuration:
 //   kubernetes_api_url: https://kubernetes.default.svc
-//   kubernetes_token: <service-account-token>
 //   collect_nodes: true
 //   collect_pods: true
 //   collect_deployments: true
+//
+// Secrets:
+//   forge secret set kubernetes-monitor/kubernetes_token
 
 package main
 
@@ -54,7 +56,7 @@ func (p *KubernetesMonitorPlugin) Init() error {
 		p.apiURL = "https://kubernetes.default.svc"
 	}
 
-	if token, ok := sdk.GetConfig("kubernetes_token"); ok {
+	if token, ok := sdk.GetSecret("kubernetes_token"); ok {
 		p.token = token
 	}
 
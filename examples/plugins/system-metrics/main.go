@@ -119,9 +119,10 @@ func (p *SystemMetricsPlugin) ConfigSchema() string {
 }`
 }
 
-// Configure applies the plugin configuration.
+// Configure applies the plugin configuration. The runtime has already
+// validated it against ConfigSchema, so interval is an integer of at least 1.
 func (p *SystemMetricsPlugin) Configure(config []byte) error {
-	sdk.Debug("Received configuration")
+	sdk.Debug("Received configuration: " + string(config))
 	// In a real plugin, parse JSON config here
 	return nil
 }
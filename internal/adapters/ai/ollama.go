@@ -90,6 +90,10 @@ func (p *OllamaProvider) ChatStream(ctx context.Context, conv *domain.Conversati
 	_, err := p.llm.GenerateContent(ctx, messages,
 		llms.WithTemperature(p.temperature),
 		llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
+			// Stop reading from the model as soon as the caller gives up
+			if err := ctx.Err(); err != nil {
+				return err
+			}
 			text := string(chunk)
 			fullResponse.WriteString(text)
 			callback(text)
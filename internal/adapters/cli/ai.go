@@ -4,14 +4,21 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"strings"
+	"text/tabwriter"
 	"time"
 
+	"github.com/forge-platform/forge/internal/adapters/daemon"
 	"github.com/spf13/cobra"
 )
 
+// aiStreamTimeout bounds a single streamed chat reply.
+const aiStreamTimeout = 5 * time.Minute
+
 var aiCmd = &cobra.Command{
 	Use:   "ai",
 	Short: "Interact with the AI assistant",
@@ -47,6 +54,13 @@ var aiAnalyzeCmd = &cobra.Command{
 	RunE:  runAIAnalyze,
 }
 
+var aiInsightsCmd = &cobra.Command{
+	Use:   "insights",
+	Short: "Show past analysis results",
+	Long:  `List the results of previous 'forge ai analyze' runs to see how system health evolved.`,
+	RunE:  runAIInsights,
+}
+
 var aiExplainCmd = &cobra.Command{
 	Use:   "explain [metric]",
 	Short: "Explain metric behavior or anomalies",
@@ -76,6 +90,7 @@ var (
 	aiTimeRange   string
 	aiMetricName  string
 	aiOutputJSON  bool
+	aiResumeID    string
 )
 
 func init() {
@@ -83,6 +98,7 @@ func init() {
 	aiCmd.AddCommand(aiAskCmd)
 	aiCmd.AddCommand(aiModelsCmd)
 	aiCmd.AddCommand(aiAnalyzeCmd)
+	aiCmd.AddCommand(aiInsightsCmd)
 	aiCmd.AddCommand(aiExplainCmd)
 	aiCmd.AddCommand(aiSuggestCmd)
 	aiCmd.AddCommand(aiAutomateCmd)
@@ -92,9 +108,16 @@ func init() {
 	aiCmd.PersistentFlags().Float64Var(&aiTemperature, "temperature", 0.7, "Temperature for generation")
 	aiCmd.PersistentFlags().BoolVar(&aiOutputJSON, "json", false, "Output results as JSON")
 
+	// Chat flags
+	aiChatCmd.Flags().StringVar(&aiResumeID, "resume", "", "Continue a previous conversation by ID")
+
 	// Analyze flags
 	aiAnalyzeCmd.Flags().StringVar(&aiTimeRange, "range", "1h", "Time range to analyze")
 
+	// Insights flags
+	aiInsightsCmd.Flags().Duration("since", 7*24*time.Hour, "Show analyses from this far back")
+	aiInsightsCmd.Flags().Int("limit", 20, "Maximum number of analyses to show")
+
 	// Explain flags
 	aiExplainCmd.Flags().StringVar(&aiMetricName, "metric", "", "Specific metric to explain")
 	aiExplainCmd.Flags().StringVar(&aiTimeRange, "range", "1h", "Time range to analyze")
@@ -106,6 +129,9 @@ func init() {
 func runAIChat(cmd *cobra.Command, args []string) error {
 	fmt.Println("🤖 Forge AI Assistant")
 	fmt.Printf("   Model: %s\n", aiModel)
+	if aiResumeID != "" {
+		fmt.Printf("   Resuming conversation %s\n", aiResumeID)
+	}
 	fmt.Println("   Type 'exit' or 'quit' to end the session")
 	fmt.Println("   Type 'clear' to clear conversation history")
 	fmt.Println()
@@ -118,7 +144,8 @@ func runAIChat(cmd *cobra.Command, args []string) error {
 	}
 
 	reader := bufio.NewReader(os.Stdin)
-	var history []string
+	// The daemon keeps the history; we only track which conversation we're in
+	conversationID := aiResumeID
 
 	for {
 		fmt.Print("You: ")
@@ -134,36 +161,36 @@ func runAIChat(cmd *cobra.Command, args []string) error {
 
 		switch strings.ToLower(input) {
 		case "exit", "quit":
+			if conversationID != "" {
+				fmt.Printf("Resume with: forge ai chat --resume %s\n", conversationID)
+			}
 			fmt.Println("Goodbye!")
 			return nil
 		case "clear":
-			history = nil
+			conversationID = ""
 			fmt.Println("Conversation cleared.")
 			continue
 		}
 
-		history = append(history, input)
-
-		// Try to get response from daemon
+		// Stream the response from the daemon
 		if client != nil {
-			ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-			resp, err := client.Call(ctx, "ai.chat", map[string]interface{}{
+			params := map[string]interface{}{
 				"message":     input,
 				"model":       aiModel,
 				"temperature": aiTemperature,
-				"history":     history,
-			})
-			cancel()
-
-			if err == nil && resp != nil {
-				if content, ok := resp.(map[string]interface{})["content"].(string); ok {
-					fmt.Println()
-					fmt.Printf("Assistant: %s\n", content)
-					fmt.Println()
-					history = append(history, content)
-					continue
-				}
 			}
+			if conversationID != "" {
+				params["conversation_id"] = conversationID
+			}
+			id, err := streamChatTurn(client, params)
+			if err != nil {
+				fmt.Printf("\nError: %v\n\n", err)
+				continue
+			}
+			if id != "" {
+				conversationID = id
+			}
+			continue
 		}
 
 		fmt.Println()
@@ -172,6 +199,34 @@ func runAIChat(cmd *cobra.Command, args []string) error {
 	}
 }
 
+// streamChatTurn prints the assistant's reply as it is generated and
+// returns the conversation ID. Ctrl+C stops the reply without ending the
+// chat session; the interrupted turn is not saved.
+func streamChatTurn(client *daemon.Client, params map[string]interface{}) (string, error) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, aiStreamTimeout)
+	defer cancel()
+
+	fmt.Print("\nAssistant: ")
+	result, err := client.ChatStream(ctx, params, func(chunk string) {
+		fmt.Print(chunk)
+	})
+	fmt.Println()
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			fmt.Println("(response cancelled)")
+			fmt.Println()
+			return "", nil
+		}
+		return "", err
+	}
+	fmt.Println()
+
+	id, _ := result["conversation_id"].(string)
+	return id, nil
+}
+
 func runAIAsk(cmd *cobra.Command, args []string) error {
 	question := strings.Join(args, " ")
 
@@ -312,6 +367,56 @@ func runAIAnalyze(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func runAIInsights(cmd *cobra.Command, args []string) error {
+	since, _ := cmd.Flags().GetDuration("since")
+	limit, _ := cmd.Flags().GetInt("limit")
+
+	client, err := newDaemonClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	resp, err := client.Call(context.Background(), "ai.insights.list", map[string]interface{}{
+		"since": since.String(),
+		"limit": limit,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list insights: %w", err)
+	}
+
+	if aiOutputJSON {
+		output, _ := json.MarshalIndent(resp, "", "  ")
+		fmt.Println(string(output))
+		return nil
+	}
+
+	insights, _ := resp.(map[string]interface{})["insights"].([]interface{})
+	if len(insights) == 0 {
+		fmt.Printf("No analyses in the last %s. Run 'forge ai analyze' to create one.\n", since)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tRANGE\tISSUES\tWORST\tSUMMARY")
+	fmt.Fprintln(w, "----\t-----\t------\t-----\t-------")
+	for _, i := range insights {
+		insight := i.(map[string]interface{})
+		issues, _ := insight["issues"].([]interface{})
+		worst := getString(insight, "max_severity")
+		if worst == "" {
+			worst = "-"
+		}
+		timestamp := getString(insight, "timestamp")
+		if t, err := time.Parse(time.RFC3339, timestamp); err == nil {
+			timestamp = t.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n",
+			timestamp, getString(insight, "time_range"), len(issues), worst, getString(insight, "summary"))
+	}
+	return w.Flush()
+}
+
 func runAIExplain(cmd *cobra.Command, args []string) error {
 	var metric string
 	if len(args) > 0 {
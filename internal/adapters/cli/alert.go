@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
+	"strings"
 	"text/tabwriter"
 	"time"
 
@@ -71,12 +73,63 @@ var alertSilenceCreateCmd = &cobra.Command{
 	RunE:  runAlertSilenceCreate,
 }
 
+var alertSilenceDeleteCmd = &cobra.Command{
+	Use:   "delete <silence-id>",
+	Short: "Delete a silence",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runAlertSilenceDelete,
+}
+
+var alertSilenceExpireCmd = &cobra.Command{
+	Use:   "expire <silence-id>",
+	Short: "End a silence now, keeping it in the list until it is purged",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runAlertSilenceExpire,
+}
+
 var alertSilenceListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List active silences",
 	RunE:  runAlertSilenceList,
 }
 
+var alertMaintenanceCmd = &cobra.Command{
+	Use:   "maintenance",
+	Short: "Manage recurring maintenance windows",
+	Long: `Manage recurring maintenance windows. While a window is open, alerts
+matching its matchers are created silenced. Each occurrence starts at
+--start on the given --days in --timezone and lasts --duration, so a window
+may run past midnight; start times follow daylight saving changes.`,
+}
+
+var alertMaintenanceCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a maintenance window",
+	Example: `  forge alert maintenance create --name deploys --matchers service=api --days mon-fri --start 22:00 --duration 3h --timezone Europe/Berlin
+  forge alert maintenance create --name backups --days sun --start 01:00 --duration 2h`,
+	RunE: runAlertMaintenanceCreate,
+}
+
+var alertMaintenanceListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List maintenance windows",
+	RunE:  runAlertMaintenanceList,
+}
+
+var alertMaintenanceUpdateCmd = &cobra.Command{
+	Use:   "update <window-id|name>",
+	Short: "Update a maintenance window",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runAlertMaintenanceUpdate,
+}
+
+var alertMaintenanceDeleteCmd = &cobra.Command{
+	Use:   "delete <window-id|name>",
+	Short: "Delete a maintenance window",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runAlertMaintenanceDelete,
+}
+
 var alertChannelCmd = &cobra.Command{
 	Use:   "channel",
 	Short: "Manage notification channels",
@@ -88,27 +141,102 @@ var alertChannelListCmd = &cobra.Command{
 	RunE:  runAlertChannelList,
 }
 
+var alertChannelCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a notification channel",
+	Long: `Create a notification channel. Required config depends on the type:
+
+  slack      url (incoming webhook URL), optional channel
+  webhook    url, optional auth_token, headers, template, timeout, max_retries
+  email      smtp_host, from, to, optional smtp_port, username, password
+  pagerduty  routing_key`,
+	Example: `  forge alert channel create --type slack --name oncall --config url=https://hooks.slack.com/services/...
+  forge alert channel create --type email --name ops --config smtp_host=smtp.example.com,from=forge@example.com,to=ops@example.com`,
+	RunE: runAlertChannelCreate,
+}
+
+var alertChannelUpdateCmd = &cobra.Command{
+	Use:   "update <channel-id|name>",
+	Short: "Update a notification channel",
+	Long: `Update a notification channel. Config entries are merged into the
+existing config; an entry with an empty value removes that key.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runAlertChannelUpdate,
+}
+
+var alertChannelDeleteCmd = &cobra.Command{
+	Use:   "delete <channel-id|name>",
+	Short: "Delete a notification channel",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runAlertChannelDelete,
+}
+
+var alertChannelTestCmd = &cobra.Command{
+	Use:   "test <channel-id|name>",
+	Short: "Send a test notification through a channel",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runAlertChannelTest,
+}
+
 func init() {
 	// Rule commands
 	alertRuleCreateCmd.Flags().String("name", "", "Rule name (required)")
-	alertRuleCreateCmd.Flags().String("metric", "", "Metric name to monitor (required)")
+	alertRuleCreateCmd.Flags().String("metric", "", "Metric name to monitor (required unless --expr is set)")
+	alertRuleCreateCmd.Flags().String("expr", "", "Expression over named metric queries (e.g. \"a = avg(cpu.usage{host=web}), b = avg(memory.usage{host=web}); a > 90 && b > 80\")")
 	alertRuleCreateCmd.Flags().String("condition", "threshold_above", "Condition type")
 	alertRuleCreateCmd.Flags().Float64("threshold", 0, "Threshold value")
 	alertRuleCreateCmd.Flags().String("severity", "warning", "Alert severity (info, warning, critical)")
-	alertRuleCreateCmd.Flags().Duration("duration", time.Minute, "How long condition must be true")
+	alertRuleCreateCmd.Flags().Duration("duration", time.Minute, "How long condition must be true before firing (0 fires on the first breach)")
 	alertRuleCreateCmd.Flags().Duration("interval", time.Minute, "Evaluation interval")
+	alertRuleCreateCmd.Flags().StringToString("labels", nil, "Rule labels (key=value)")
+	alertRuleCreateCmd.Flags().StringSlice("group-by", nil, "Labels whose alerts are notified together (e.g. service)")
+	alertRuleCreateCmd.Flags().Duration("group-wait", 0, "How long a group collects alerts before notifying (default 30s)")
+	alertRuleCreateCmd.Flags().Duration("resolve-after", 0, "How long the condition must stay clear before resolving (default 5m for anomaly and rate of change, else immediate)")
+	alertRuleCreateCmd.Flags().Duration("repeat-interval", 0, "How often to notify again while an alert keeps firing (default 4h)")
+	alertRuleCreateCmd.Flags().Bool("notify-on-resolve", true, "Notify when a firing alert resolves")
+	alertRuleCreateCmd.Flags().String("for-agg", "", "Combine threshold points within the duration before comparing (last, avg, min, max)")
+	alertRuleCreateCmd.Flags().Float64("for-percent", 0, "Percentage of threshold points within the duration that must breach (100 = all)")
 
 	alertRuleCmd.AddCommand(alertRuleListCmd, alertRuleCreateCmd, alertRuleDeleteCmd)
 
 	// Silence commands
 	alertSilenceCreateCmd.Flags().StringToString("matchers", nil, "Label matchers (key=value)")
-	alertSilenceCreateCmd.Flags().Duration("duration", time.Hour, "Silence duration")
+	alertSilenceCreateCmd.Flags().Duration("duration", time.Hour, "Silence duration (window length for recurring silences)")
 	alertSilenceCreateCmd.Flags().String("comment", "", "Comment for the silence")
-
-	alertSilenceCmd.AddCommand(alertSilenceCreateCmd, alertSilenceListCmd)
+	alertSilenceCreateCmd.Flags().String("schedule", "", "Cron schedule for a recurring maintenance window (e.g. \"0 2 * * 0\")")
+	alertSilenceCreateCmd.Flags().String("timezone", "", "Time zone the schedule is evaluated in (default UTC)")
+
+	alertSilenceCmd.AddCommand(alertSilenceCreateCmd, alertSilenceListCmd, alertSilenceDeleteCmd, alertSilenceExpireCmd)
+
+	// Maintenance window commands
+	alertMaintenanceCreateCmd.Flags().String("name", "", "Window name (required)")
+	alertMaintenanceCreateCmd.Flags().StringToString("matchers", nil, "Label matchers (key=value, none matches every alert)")
+	alertMaintenanceCreateCmd.Flags().String("days", "daily", "Days occurrences start on (e.g. mon,wed or mon-fri, weekdays, weekends, daily)")
+	alertMaintenanceCreateCmd.Flags().String("start", "", "Start time of day, HH:MM (required)")
+	alertMaintenanceCreateCmd.Flags().Duration("duration", time.Hour, "How long each occurrence lasts")
+	alertMaintenanceCreateCmd.Flags().String("timezone", "", "Time zone the window is evaluated in (default UTC)")
+	alertMaintenanceCreateCmd.Flags().String("comment", "", "Comment for the window")
+	alertMaintenanceUpdateCmd.Flags().String("name", "", "New window name")
+	alertMaintenanceUpdateCmd.Flags().StringToString("matchers", nil, "Replace the label matchers (key=value)")
+	alertMaintenanceUpdateCmd.Flags().String("days", "", "Days occurrences start on")
+	alertMaintenanceUpdateCmd.Flags().String("start", "", "Start time of day, HH:MM")
+	alertMaintenanceUpdateCmd.Flags().Duration("duration", 0, "How long each occurrence lasts")
+	alertMaintenanceUpdateCmd.Flags().String("timezone", "", "Time zone the window is evaluated in")
+	alertMaintenanceUpdateCmd.Flags().String("comment", "", "Comment for the window")
+	alertMaintenanceUpdateCmd.Flags().Bool("enabled", true, "Enable or disable the window")
+
+	alertMaintenanceCmd.AddCommand(alertMaintenanceCreateCmd, alertMaintenanceListCmd, alertMaintenanceUpdateCmd, alertMaintenanceDeleteCmd)
 
 	// Channel commands
-	alertChannelCmd.AddCommand(alertChannelListCmd)
+	alertChannelCreateCmd.Flags().String("name", "", "Channel name (required)")
+	alertChannelCreateCmd.Flags().String("type", "", "Channel type: slack, webhook, email, pagerduty (required)")
+	alertChannelCreateCmd.Flags().StringToString("config", nil, "Channel config (key=value, quote values containing commas)")
+	alertChannelCreateCmd.Flags().Bool("disabled", false, "Create the channel disabled")
+	alertChannelUpdateCmd.Flags().String("name", "", "New channel name")
+	alertChannelUpdateCmd.Flags().StringToString("config", nil, "Config entries to set (key=value, empty value removes the key)")
+	alertChannelUpdateCmd.Flags().Bool("enabled", true, "Enable or disable the channel")
+
+	alertChannelCmd.AddCommand(alertChannelListCmd, alertChannelCreateCmd, alertChannelUpdateCmd, alertChannelDeleteCmd, alertChannelTestCmd)
 
 	// Ack command
 	alertAckCmd.Flags().String("comment", "", "Acknowledgement comment")
@@ -119,7 +247,7 @@ func init() {
 	alertHistoryCmd.Flags().Int("limit", 50, "Maximum number of alerts to show")
 
 	// Add all subcommands
-	alertCmd.AddCommand(alertRuleCmd, alertListCmd, alertHistoryCmd, alertAckCmd, alertSilenceCmd, alertChannelCmd)
+	alertCmd.AddCommand(alertRuleCmd, alertListCmd, alertHistoryCmd, alertAckCmd, alertSilenceCmd, alertMaintenanceCmd, alertChannelCmd)
 	rootCmd.AddCommand(alertCmd)
 }
 
@@ -170,9 +298,21 @@ func runAlertRuleCreate(cmd *cobra.Command, args []string) error {
 	severity, _ := cmd.Flags().GetString("severity")
 	duration, _ := cmd.Flags().GetDuration("duration")
 	interval, _ := cmd.Flags().GetDuration("interval")
+	labels, _ := cmd.Flags().GetStringToString("labels")
+	groupBy, _ := cmd.Flags().GetStringSlice("group-by")
+	groupWait, _ := cmd.Flags().GetDuration("group-wait")
+	resolveAfter, _ := cmd.Flags().GetDuration("resolve-after")
+	repeatInterval, _ := cmd.Flags().GetDuration("repeat-interval")
+	notifyOnResolve, _ := cmd.Flags().GetBool("notify-on-resolve")
+	forAgg, _ := cmd.Flags().GetString("for-agg")
+	forPercent, _ := cmd.Flags().GetFloat64("for-percent")
+	expr, _ := cmd.Flags().GetString("expr")
 
-	if name == "" || metric == "" {
-		return fmt.Errorf("--name and --metric are required")
+	if name == "" || metric == "" && expr == "" {
+		return fmt.Errorf("--name and --metric (or --expr) are required")
+	}
+	if expr != "" && !cmd.Flags().Changed("condition") {
+		condition = "expression"
 	}
 
 	client, err := newDaemonClient()
@@ -190,6 +330,32 @@ func runAlertRuleCreate(cmd *cobra.Command, args []string) error {
 		"severity":    severity,
 		"duration":    duration.String(),
 		"interval":    interval.String(),
+
+		"notify_on_resolve": notifyOnResolve,
+	}
+	if len(labels) > 0 {
+		params["labels"] = labels
+	}
+	if len(groupBy) > 0 {
+		params["group_by"] = groupBy
+	}
+	if groupWait > 0 {
+		params["group_wait"] = groupWait.String()
+	}
+	if resolveAfter > 0 {
+		params["resolve_after"] = resolveAfter.String()
+	}
+	if repeatInterval > 0 {
+		params["repeat_interval"] = repeatInterval.String()
+	}
+	if forAgg != "" {
+		params["for_aggregation"] = forAgg
+	}
+	if forPercent > 0 {
+		params["for_percent"] = forPercent
+	}
+	if expr != "" {
+		params["expression"] = expr
 	}
 
 	resp, err := client.Call(ctx, "alert.rule.create", params)
@@ -240,18 +406,23 @@ func runAlertList(cmd *cobra.Command, args []string) error {
 	}
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "ID\tRULE\tSTATE\tSEVERITY\tVALUE\tSTARTED")
-	fmt.Fprintln(w, "--\t----\t-----\t--------\t-----\t-------")
+	fmt.Fprintln(w, "ID\tRULE\tSTATE\tSEVERITY\tVALUE\tSTARTED\tSILENCED BY")
+	fmt.Fprintln(w, "--\t----\t-----\t--------\t-----\t-------\t-----------")
 
 	for _, a := range alerts {
 		alert := a.(map[string]interface{})
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%.2f\t%s\n",
+		silencedBy, _ := alert["silenced_by"].(string)
+		if silencedBy == "" {
+			silencedBy = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%.2f\t%s\t%s\n",
 			alertTruncateID(alert["id"].(string)),
 			alert["rule_name"],
 			getStateIcon(alert["state"].(string)),
 			alert["severity"],
 			alert["value"],
 			alertFormatTime(alert["starts_at"].(string)),
+			silencedBy,
 		)
 	}
 	w.Flush()
@@ -343,6 +514,8 @@ func runAlertSilenceCreate(cmd *cobra.Command, args []string) error {
 	matchers, _ := cmd.Flags().GetStringToString("matchers")
 	duration, _ := cmd.Flags().GetDuration("duration")
 	comment, _ := cmd.Flags().GetString("comment")
+	schedule, _ := cmd.Flags().GetString("schedule")
+	timezone, _ := cmd.Flags().GetString("timezone")
 
 	if len(matchers) == 0 {
 		return fmt.Errorf("--matchers is required")
@@ -360,6 +533,10 @@ func runAlertSilenceCreate(cmd *cobra.Command, args []string) error {
 		"duration": duration.String(),
 		"comment":  comment,
 	}
+	if schedule != "" {
+		params["schedule"] = schedule
+		params["timezone"] = timezone
+	}
 
 	resp, err := client.Call(ctx, "alert.silence.create", params)
 	if err != nil {
@@ -370,6 +547,44 @@ func runAlertSilenceCreate(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func runAlertSilenceDelete(cmd *cobra.Command, args []string) error {
+	silenceID := args[0]
+
+	client, err := newDaemonClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	_, err = client.Call(ctx, "alert.silence.delete", map[string]interface{}{"id": silenceID})
+	if err != nil {
+		return fmt.Errorf("failed to delete silence: %w", err)
+	}
+
+	fmt.Printf("✅ Silence deleted: %s\n", silenceID)
+	return nil
+}
+
+func runAlertSilenceExpire(cmd *cobra.Command, args []string) error {
+	silenceID := args[0]
+
+	client, err := newDaemonClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	_, err = client.Call(ctx, "alert.silence.expire", map[string]interface{}{"id": silenceID})
+	if err != nil {
+		return fmt.Errorf("failed to expire silence: %w", err)
+	}
+
+	fmt.Printf("✅ Silence expired: %s\n", silenceID)
+	return nil
+}
+
 func runAlertSilenceList(cmd *cobra.Command, args []string) error {
 	client, err := newDaemonClient()
 	if err != nil {
@@ -390,17 +605,29 @@ func runAlertSilenceList(cmd *cobra.Command, args []string) error {
 	}
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "ID\tMATCHERS\tSTARTS\tENDS\tCOMMENT")
-	fmt.Fprintln(w, "--\t--------\t------\t----\t-------")
+	fmt.Fprintln(w, "ID\tMATCHERS\tSTARTS\tENDS\tSCHEDULE\tCOMMENT")
+	fmt.Fprintln(w, "--\t--------\t------\t----\t--------\t-------")
 
 	for _, s := range silences {
 		silence := s.(map[string]interface{})
 		matchersJSON, _ := json.Marshal(silence["matchers"])
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+		ends, _ := silence["ends_at"].(string)
+		if ends == "" {
+			ends = "-"
+		}
+		schedule := "-"
+		if spec, ok := silence["schedule"].(string); ok && spec != "" {
+			schedule = fmt.Sprintf("%s for %v", spec, silence["duration"])
+			if tz, _ := silence["timezone"].(string); tz != "" {
+				schedule += " " + tz
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
 			alertTruncateID(silence["id"].(string)),
 			string(matchersJSON),
 			alertFormatTime(silence["starts_at"].(string)),
-			alertFormatTime(silence["ends_at"].(string)),
+			alertFormatTime(ends),
+			schedule,
 			silence["comment"],
 		)
 	}
@@ -408,6 +635,149 @@ func runAlertSilenceList(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func runAlertMaintenanceCreate(cmd *cobra.Command, args []string) error {
+	name, _ := cmd.Flags().GetString("name")
+	matchers, _ := cmd.Flags().GetStringToString("matchers")
+	days, _ := cmd.Flags().GetString("days")
+	start, _ := cmd.Flags().GetString("start")
+	duration, _ := cmd.Flags().GetDuration("duration")
+	timezone, _ := cmd.Flags().GetString("timezone")
+	comment, _ := cmd.Flags().GetString("comment")
+
+	if name == "" || start == "" {
+		return fmt.Errorf("--name and --start are required")
+	}
+
+	client, err := newDaemonClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	resp, err := client.Call(ctx, "alert.maintenance.create", map[string]interface{}{
+		"name":       name,
+		"matchers":   matchers,
+		"days":       days,
+		"start_time": start,
+		"duration":   duration.String(),
+		"timezone":   timezone,
+		"comment":    comment,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create maintenance window: %w", err)
+	}
+
+	fmt.Printf("✅ Maintenance window created: %s (ID: %s)\n", name, resp.(map[string]interface{})["id"])
+	return nil
+}
+
+func runAlertMaintenanceList(cmd *cobra.Command, args []string) error {
+	client, err := newDaemonClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	resp, err := client.Call(ctx, "alert.maintenance.list", nil)
+	if err != nil {
+		return fmt.Errorf("failed to list maintenance windows: %w", err)
+	}
+
+	windows, ok := resp.(map[string]interface{})["windows"].([]interface{})
+	if !ok || len(windows) == 0 {
+		fmt.Println("No maintenance windows configured.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tMATCHERS\tSCHEDULE\tENABLED\tACTIVE\tCOMMENT")
+	fmt.Fprintln(w, "--\t----\t--------\t--------\t-------\t------\t-------")
+
+	for _, item := range windows {
+		window := item.(map[string]interface{})
+		matchersJSON, _ := json.Marshal(window["matchers"])
+		schedule := fmt.Sprintf("%v %v for %v", window["days"], window["start_time"], window["duration"])
+		if tz, _ := window["timezone"].(string); tz != "" {
+			schedule += " " + tz
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%v\t%v\t%s\n",
+			alertTruncateID(window["id"].(string)),
+			window["name"],
+			string(matchersJSON),
+			schedule,
+			window["enabled"],
+			window["active"],
+			window["comment"],
+		)
+	}
+	w.Flush()
+	return nil
+}
+
+func runAlertMaintenanceUpdate(cmd *cobra.Command, args []string) error {
+	params := map[string]interface{}{"id": args[0]}
+	if cmd.Flags().Changed("name") {
+		params["name"], _ = cmd.Flags().GetString("name")
+	}
+	if cmd.Flags().Changed("matchers") {
+		params["matchers"], _ = cmd.Flags().GetStringToString("matchers")
+	}
+	if cmd.Flags().Changed("days") {
+		params["days"], _ = cmd.Flags().GetString("days")
+	}
+	if cmd.Flags().Changed("start") {
+		params["start_time"], _ = cmd.Flags().GetString("start")
+	}
+	if cmd.Flags().Changed("duration") {
+		duration, _ := cmd.Flags().GetDuration("duration")
+		params["duration"] = duration.String()
+	}
+	if cmd.Flags().Changed("timezone") {
+		params["timezone"], _ = cmd.Flags().GetString("timezone")
+	}
+	if cmd.Flags().Changed("comment") {
+		params["comment"], _ = cmd.Flags().GetString("comment")
+	}
+	if cmd.Flags().Changed("enabled") {
+		params["enabled"], _ = cmd.Flags().GetBool("enabled")
+	}
+	if len(params) == 1 {
+		return fmt.Errorf("nothing to update")
+	}
+
+	client, err := newDaemonClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	if _, err := client.Call(ctx, "alert.maintenance.update", params); err != nil {
+		return fmt.Errorf("failed to update maintenance window: %w", err)
+	}
+
+	fmt.Printf("✅ Maintenance window updated: %s\n", args[0])
+	return nil
+}
+
+func runAlertMaintenanceDelete(cmd *cobra.Command, args []string) error {
+	client, err := newDaemonClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	if _, err := client.Call(ctx, "alert.maintenance.delete", map[string]interface{}{"id": args[0]}); err != nil {
+		return fmt.Errorf("failed to delete maintenance window: %w", err)
+	}
+
+	fmt.Printf("✅ Maintenance window deleted: %s\n", args[0])
+	return nil
+}
+
 func runAlertChannelList(cmd *cobra.Command, args []string) error {
 	client, err := newDaemonClient()
 	if err != nil {
@@ -428,22 +798,136 @@ func runAlertChannelList(cmd *cobra.Command, args []string) error {
 	}
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "ID\tNAME\tTYPE\tENABLED")
-	fmt.Fprintln(w, "--\t----\t----\t-------")
+	fmt.Fprintln(w, "ID\tNAME\tTYPE\tENABLED\tCONFIG")
+	fmt.Fprintln(w, "--\t----\t----\t-------\t------")
 
 	for _, c := range channels {
 		channel := c.(map[string]interface{})
-		fmt.Fprintf(w, "%s\t%s\t%s\t%v\n",
+		config, _ := channel["config"].(map[string]interface{})
+		fmt.Fprintf(w, "%s\t%s\t%s\t%v\t%s\n",
 			alertTruncateID(channel["id"].(string)),
 			channel["name"],
 			channel["type"],
 			channel["enabled"],
+			formatChannelConfig(config),
 		)
 	}
 	w.Flush()
 	return nil
 }
 
+// formatChannelConfig renders channel config as sorted key=value pairs.
+func formatChannelConfig(config map[string]interface{}) string {
+	keys := make([]string, 0, len(config))
+	for k := range config {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = fmt.Sprintf("%s=%v", k, config[k])
+	}
+	return strings.Join(pairs, " ")
+}
+
+func runAlertChannelCreate(cmd *cobra.Command, args []string) error {
+	name, _ := cmd.Flags().GetString("name")
+	channelType, _ := cmd.Flags().GetString("type")
+	config, _ := cmd.Flags().GetStringToString("config")
+	disabled, _ := cmd.Flags().GetBool("disabled")
+
+	if name == "" || channelType == "" {
+		return fmt.Errorf("--name and --type are required")
+	}
+
+	client, err := newDaemonClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	resp, err := client.Call(ctx, "alert.channel.create", map[string]interface{}{
+		"name":    name,
+		"type":    channelType,
+		"config":  config,
+		"enabled": !disabled,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create channel: %w", err)
+	}
+
+	fmt.Printf("✅ Notification channel created: %s (ID: %s)\n", name, resp.(map[string]interface{})["id"])
+	return nil
+}
+
+func runAlertChannelUpdate(cmd *cobra.Command, args []string) error {
+	params := map[string]interface{}{"id": args[0]}
+	if cmd.Flags().Changed("name") {
+		params["name"], _ = cmd.Flags().GetString("name")
+	}
+	if cmd.Flags().Changed("config") {
+		params["config"], _ = cmd.Flags().GetStringToString("config")
+	}
+	if cmd.Flags().Changed("enabled") {
+		params["enabled"], _ = cmd.Flags().GetBool("enabled")
+	}
+	if len(params) == 1 {
+		return fmt.Errorf("nothing to update: set --name, --config or --enabled")
+	}
+
+	client, err := newDaemonClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	if _, err := client.Call(ctx, "alert.channel.update", params); err != nil {
+		return fmt.Errorf("failed to update channel: %w", err)
+	}
+
+	fmt.Printf("✅ Notification channel updated: %s\n", args[0])
+	return nil
+}
+
+func runAlertChannelDelete(cmd *cobra.Command, args []string) error {
+	client, err := newDaemonClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	if _, err := client.Call(ctx, "alert.channel.delete", map[string]interface{}{"id": args[0]}); err != nil {
+		return fmt.Errorf("failed to delete channel: %w", err)
+	}
+
+	fmt.Printf("✅ Notification channel deleted: %s\n", args[0])
+	return nil
+}
+
+func runAlertChannelTest(cmd *cobra.Command, args []string) error {
+	client, err := newDaemonClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	resp, err := client.Call(ctx, "alert.channel.test", map[string]interface{}{"id": args[0]})
+	if err != nil {
+		return fmt.Errorf("failed to test channel: %w", err)
+	}
+
+	result := resp.(map[string]interface{})
+	if delivered, _ := result["delivered"].(bool); !delivered {
+		return fmt.Errorf("test notification to %s failed: %v", result["name"], result["error"])
+	}
+	fmt.Printf("✅ Test notification delivered to %s\n", result["name"])
+	return nil
+}
+
 func getStateIcon(state string) string {
 	switch state {
 	case "firing":
@@ -0,0 +1,194 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/forge-platform/forge/internal/adapters/daemon"
+	"github.com/forge-platform/forge/internal/core/services"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var loginCmd = &cobra.Command{
+	Use:   "login [username]",
+	Short: "Log in to the Forge daemon",
+	Long: `Log in with a username and password and save the session in
+~/.forge/credentials, readable only by you. Later commands present it to the
+daemon unless an API key is given with --api-key or FORGE_API_KEY.
+
+Users with MFA enabled are asked for their authenticator code.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runLogin,
+}
+
+var logoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Log out and remove the saved session",
+	RunE:  runLogout,
+}
+
+var whoamiCmd = &cobra.Command{
+	Use:   "whoami",
+	Short: "Show the authenticated user and role",
+	RunE:  runWhoami,
+}
+
+var loginTOTP string
+
+func init() {
+	loginCmd.Flags().StringVar(&loginTOTP, "totp", "", "MFA code from your authenticator app")
+}
+
+func runLogin(cmd *cobra.Command, args []string) error {
+	forgeDir, err := getForgeDir()
+	if err != nil {
+		return err
+	}
+
+	stdin := bufio.NewReader(os.Stdin)
+	username := ""
+	if len(args) > 0 {
+		username = args[0]
+	} else {
+		fmt.Print("Username: ")
+		line, err := stdin.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("failed to read username: %w", err)
+		}
+		username = strings.TrimSpace(line)
+	}
+	if username == "" {
+		return fmt.Errorf("username is required")
+	}
+
+	fmt.Print("Password: ")
+	passwordBytes, err := term.ReadPassword(int(os.Stdin.Fd()))
+	if err != nil {
+		return fmt.Errorf("failed to read password: %w", err)
+	}
+	fmt.Println()
+
+	// Log in on an unauthenticated connection, so a stale saved session
+	// can't get in the way
+	client, err := daemon.NewClient(forgeDir)
+	if err != nil {
+		return fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+	client.SetSession(nil)
+	if err := client.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+	defer client.Close()
+
+	params := map[string]interface{}{
+		"username":   username,
+		"password":   string(passwordBytes),
+		"totp_code":  loginTOTP,
+		"user_agent": "forge-cli/" + Version,
+	}
+	resp, err := client.Call(context.Background(), "auth.login", params)
+	if err != nil && loginTOTP == "" && strings.Contains(err.Error(), services.ErrMFARequired.Error()) {
+		fmt.Print("MFA code: ")
+		line, readErr := stdin.ReadString('\n')
+		if readErr != nil {
+			return fmt.Errorf("failed to read MFA code: %w", readErr)
+		}
+		params["totp_code"] = strings.TrimSpace(line)
+		resp, err = client.Call(context.Background(), "auth.login", params)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to log in: %w", err)
+	}
+
+	result, _ := resp.(map[string]interface{})
+	creds := &daemon.Credentials{
+		SessionToken: getString(result, "session_token"),
+		Username:     getString(result, "username"),
+	}
+	if creds.SessionToken == "" {
+		return fmt.Errorf("failed to log in: daemon returned no session")
+	}
+	if expires, err := time.Parse(time.RFC3339, getString(result, "expires_at")); err == nil {
+		creds.ExpiresAt = expires
+	}
+	if err := daemon.SaveCredentials(forgeDir, creds); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Logged in as %s (%s)\n", creds.Username, getString(result, "role"))
+	if !creds.ExpiresAt.IsZero() {
+		fmt.Printf("  Session expires %s\n", creds.ExpiresAt.Local().Format("2006-01-02 15:04"))
+	}
+	return nil
+}
+
+func runLogout(cmd *cobra.Command, args []string) error {
+	forgeDir, err := getForgeDir()
+	if err != nil {
+		return err
+	}
+	creds, err := daemon.LoadCredentials(forgeDir)
+	if err != nil {
+		return err
+	}
+	if creds == nil {
+		fmt.Println("Not logged in")
+		return nil
+	}
+
+	// Revoke the session on the daemon, then forget it locally either way
+	var revokeErr error
+	client, err := daemon.NewClient(forgeDir)
+	if err == nil {
+		_, revokeErr = client.Call(context.Background(), "auth.logout", nil)
+		client.Close()
+	} else {
+		revokeErr = err
+	}
+	if err := daemon.RemoveCredentials(forgeDir); err != nil {
+		return err
+	}
+
+	if revokeErr != nil && !creds.Expired() {
+		fmt.Printf("✓ Logged out %s locally; the daemon could not revoke the session: %v\n", creds.Username, revokeErr)
+		return nil
+	}
+	fmt.Printf("✓ Logged out %s\n", creds.Username)
+	return nil
+}
+
+func runWhoami(cmd *cobra.Command, args []string) error {
+	client, err := newDaemonClient()
+	if err != nil {
+		return fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+	defer client.Close()
+
+	resp, err := client.Call(context.Background(), "auth.whoami", nil)
+	if err != nil {
+		if strings.Contains(err.Error(), "not authenticated") {
+			return daemon.ErrLoginRequired
+		}
+		return fmt.Errorf("failed to get identity: %w", err)
+	}
+
+	result, _ := resp.(map[string]interface{})
+	fmt.Printf("Username: %s\n", getString(result, "username"))
+	fmt.Printf("Role:     %s\n", getString(result, "role"))
+	switch {
+	case getString(result, "key_id") != "":
+		fmt.Printf("Auth:     API key %s\n", truncateID(getString(result, "key_id")))
+	case getString(result, "session_id") != "":
+		expires := getString(result, "expires_at")
+		if t, err := time.Parse(time.RFC3339, expires); err == nil {
+			expires = t.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Printf("Auth:     session, expires %s\n", expires)
+	}
+	return nil
+}
@@ -10,7 +10,6 @@ import (
 	"path/filepath"
 	"time"
 
-	"github.com/forge-platform/forge/internal/adapters/daemon"
 	"github.com/spf13/cobra"
 )
 
@@ -57,7 +56,7 @@ func init() {
 }
 
 func runBackupCreate(cmd *cobra.Command, args []string) error {
-	client, err := daemon.NewClient("")
+	client, err := newDaemonClient()
 	if err != nil {
 		return fmt.Errorf("failed to connect to daemon: %w", err)
 	}
@@ -149,7 +148,7 @@ func runBackupRestore(cmd *cobra.Command, args []string) error {
 	}
 
 	// Connect to daemon to get database path and stop it
-	client, err := daemon.NewClient("")
+	client, err := newDaemonClient()
 	if err != nil {
 		return fmt.Errorf("failed to connect to daemon: %w", err)
 	}
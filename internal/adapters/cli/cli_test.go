@@ -2,7 +2,16 @@
 package cli
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/forge-platform/forge/internal/adapters/daemon"
+	"github.com/forge-platform/forge/internal/adapters/tui"
+	"github.com/forge-platform/forge/internal/core/services"
+	"github.com/spf13/viper"
 )
 
 func TestVersionVariables(t *testing.T) {
@@ -125,7 +134,7 @@ func TestRootCmd_HasSubcommands(t *testing.T) {
 	}
 
 	// Check that expected subcommands are registered
-	expectedCommands := []string{"version", "health", "task", "metric", "plugin", "workflow", "alert", "ai"}
+	expectedCommands := []string{"version", "health", "task", "metric", "plugin", "workflow", "alert", "ai", "logout", "whoami", "secret"}
 	for _, expected := range expectedCommands {
 		found := false
 		for _, cmd := range subcommands {
@@ -140,3 +149,231 @@ func TestRootCmd_HasSubcommands(t *testing.T) {
 	}
 }
 
+func TestLogTailCmd_Flags(t *testing.T) {
+	for _, name := range []string{"follow", "service", "min-level", "attr", "no-color"} {
+		if logTailCmd.Flags().Lookup(name) == nil {
+			t.Errorf("expected log tail flag --%s", name)
+		}
+	}
+	if logTailCmd.Flags().ShorthandLookup("f") == nil {
+		t.Error("expected log tail to accept -f")
+	}
+
+	attrs, err := parseAttrFlags([]string{"region=eu", "path=/a=b"})
+	if err != nil || attrs["region"] != "eu" || attrs["path"] != "/a=b" {
+		t.Errorf("unexpected attributes %v, %v", attrs, err)
+	}
+	if _, err := parseAttrFlags([]string{"region"}); err == nil {
+		t.Error("expected error for attribute without value")
+	}
+
+	if got := colorLevel("error", "ERROR"); got != "\033[31mERROR\033[0m" {
+		t.Errorf("expected red error level, got %q", got)
+	}
+}
+
+func TestTraceListCmd_Flags(t *testing.T) {
+	for _, name := range []string{"service", "status", "min-duration", "max-duration", "attr"} {
+		if traceListCmd.Flags().Lookup(name) == nil {
+			t.Errorf("expected trace list flag --%s", name)
+		}
+	}
+
+	equals, exists := parseAttributeMatchers([]string{"http.route=/checkout", "user.id", "query=a=b"})
+	if len(equals) != 2 || equals["http.route"] != "/checkout" || equals["query"] != "a=b" {
+		t.Errorf("unexpected exact matchers %v", equals)
+	}
+	if len(exists) != 1 || exists[0] != "user.id" {
+		t.Errorf("unexpected presence matchers %v", exists)
+	}
+}
+
+func TestLogIngestCmd_Flags(t *testing.T) {
+	for _, name := range []string{"file", "source", "batch-size"} {
+		if logIngestCmd.Flags().Lookup(name) == nil {
+			t.Errorf("expected log ingest flag --%s", name)
+		}
+	}
+	if size, _ := logIngestCmd.Flags().GetInt("batch-size"); size != 500 {
+		t.Errorf("expected default batch size 500, got %d", size)
+	}
+	for _, name := range []string{"type", "pattern", "priority", "source-filter", "map"} {
+		if logParserAddCmd.Flags().Lookup(name) == nil {
+			t.Errorf("expected log parser add flag --%s", name)
+		}
+	}
+}
+
+func TestLogMetricRuleCmd_Flags(t *testing.T) {
+	for _, name := range []string{"match", "field", "metric", "type", "value", "min-level", "service", "tag", "tag-field"} {
+		if logMetricRuleAddCmd.Flags().Lookup(name) == nil {
+			t.Errorf("expected log metricrule add flag --%s", name)
+		}
+	}
+	if typ, _ := logMetricRuleAddCmd.Flags().GetString("type"); typ != "counter" {
+		t.Errorf("expected counter rules by default, got %s", typ)
+	}
+}
+
+func TestUICmd_TabFlag(t *testing.T) {
+	defer func() { uiTab = "dashboard" }()
+
+	tests := []struct {
+		args []string
+		want tui.Tab
+	}{
+		{nil, tui.TabDashboard},
+		{[]string{"--tab", "alerts"}, tui.TabAlerts},
+		{[]string{"--tab=Plugins"}, tui.TabPlugins},
+	}
+	for _, tt := range tests {
+		uiTab = "dashboard"
+		if err := uiCmd.ParseFlags(tt.args); err != nil {
+			t.Fatalf("ParseFlags(%v) failed: %v", tt.args, err)
+		}
+		model, err := newUIModel(uiTab)
+		if err != nil {
+			t.Fatalf("newUIModel(%q) failed: %v", uiTab, err)
+		}
+		if model.ActiveTab() != tt.want {
+			t.Errorf("%v: expected initial tab %v, got %v", tt.args, tt.want, model.ActiveTab())
+		}
+	}
+
+	if err := uiCmd.ParseFlags([]string{"--tab", "settings"}); err != nil {
+		t.Fatalf("ParseFlags failed: %v", err)
+	}
+	if _, err := newUIModel(uiTab); err == nil || !strings.Contains(err.Error(), "alerts") {
+		t.Errorf("expected an error listing the valid tabs, got %v", err)
+	}
+}
+
+func TestApplyRetentionConfig(t *testing.T) {
+	cfg := viper.New()
+	cfg.Set("metrics.raw_retention", "3d")
+	cfg.Set("metrics.downsample_interval", "30m")
+	cfg.Set("metrics.long_retention", "90d")
+	cfg.Set("metrics.retention_tiers", map[string]interface{}{"5m": "60d", "1h": "120d"})
+
+	config := daemon.DefaultConfig(t.TempDir())
+	if err := applyRetentionConfig(cfg, &config); err != nil {
+		t.Fatalf("applyRetentionConfig failed: %v", err)
+	}
+	if config.RawRetention != 72*time.Hour || config.DownsampleInterval != 30*time.Minute {
+		t.Errorf("unexpected raw retention %v or interval %v", config.RawRetention, config.DownsampleInterval)
+	}
+	retention := map[string]time.Duration{}
+	for _, tier := range config.RetentionTiers {
+		retention[tier.Resolution] = tier.Retention
+	}
+	// retention_tiers adds 5m and overrides long_retention
+	if len(config.RetentionTiers) != 3 || retention["1m"] != 30*24*time.Hour || retention["5m"] != 60*24*time.Hour || retention["1h"] != 120*24*time.Hour {
+		t.Errorf("unexpected tiers %+v", config.RetentionTiers)
+	}
+
+	cfg.Set("metrics.medium_retention", "soon")
+	if err := applyRetentionConfig(cfg, &config); err == nil {
+		t.Error("expected an invalid retention to be rejected")
+	}
+}
+
+func TestApplyTraceConfig(t *testing.T) {
+	cfg := viper.New()
+	cfg.Set("traces.sample_rate", 0.1)
+	cfg.Set("traces.latency_threshold", "1500ms")
+	cfg.Set("traces.retention", "14d")
+
+	config := services.DefaultTraceConfig()
+	if err := applyTraceConfig(cfg, &config); err != nil {
+		t.Fatalf("applyTraceConfig failed: %v", err)
+	}
+	if config.SampleRate != 0.1 || config.LatencyThreshold != 1500*time.Millisecond || config.Retention != 14*24*time.Hour {
+		t.Errorf("unexpected config %+v", config)
+	}
+	if !config.KeepErrors || config.DecisionWait != 30*time.Second {
+		t.Errorf("expected unset keys to keep their defaults, got %+v", config)
+	}
+
+	cfg.Set("traces.sample_rate", 3)
+	if err := applyTraceConfig(cfg, &config); err == nil {
+		t.Error("expected a sample rate above 1 to be rejected")
+	}
+}
+
+func TestValidateConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		return path
+	}
+
+	valid := write("valid.yaml", `core:
+  data_dir: `+dir+`
+metrics:
+  raw_retention: 3d
+  long_retention: 90d
+`)
+	diags, err := validateConfigFile(valid)
+	if err != nil || len(diags) != 0 {
+		t.Fatalf("expected a valid config, got %v (%v)", diags, err)
+	}
+
+	invalid := write("invalid.yaml", `core:
+  data_dir: `+dir+`
+ai:
+  ollama_url: "not a url"
+daemon:
+  idle_timeout: -5m
+metrics:
+  raw_retention: soon
+  system:
+    interval: 0s
+  transforms:
+    - metric: "cpu.*"
+      stage: sometime
+  metadata:
+    - description: no metric
+alerts:
+  quiet_hours:
+    start: "25:00"
+    end: "07:00"
+prometheus:
+  exporter_addr: 9464
+`)
+	diags, err = validateConfigFile(invalid)
+	if err != nil {
+		t.Fatalf("validateConfigFile failed: %v", err)
+	}
+	want := []string{"ai.ollama_url", "daemon.idle_timeout", "metrics.raw_retention", "alerts.quiet_hours", "metrics.system.interval", "metrics.transforms[0]", "metrics.metadata[0]", "prometheus.exporter_addr"}
+	var got []string
+	for _, d := range diags {
+		got = append(got, d.Key)
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("expected diagnostics for %v, got %v", want, diags)
+	}
+
+	// Retention tiers are checked together once their durations parse
+	tiers := write("tiers.yaml", `core:
+  data_dir: `+dir+`
+metrics:
+  raw_retention: 60d
+  medium_retention: 30d
+`)
+	diags, err = validateConfigFile(tiers)
+	if err != nil || len(diags) != 1 || diags[0].Key != "metrics" {
+		t.Errorf("expected one retention diagnostic, got %v (%v)", diags, err)
+	}
+
+	if _, err := validateConfigFile(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+	broken := write("broken.yaml", "core: [\n")
+	if _, err := validateConfigFile(broken); err == nil {
+		t.Error("expected an error for unparsable YAML")
+	}
+}
@@ -0,0 +1,227 @@
+package cli
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/forge-platform/forge/internal/adapters/daemon"
+	"github.com/forge-platform/forge/internal/config"
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/services"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect Forge configuration",
+	Long:  `Commands for working with the Forge configuration file.`,
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [path]",
+	Short: "Check a configuration file for mistakes",
+	Long: `Load a configuration file and report every problem found before the
+daemon is started with it: unwritable data directories, non-positive
+durations, malformed URLs, invalid metric rules and settings that depend on
+others which are missing.
+
+Without a path, the file given by --config or $HOME/.forge/config.yaml is
+checked.`,
+	Example: `  forge config validate
+  forge config validate ./staging.yaml`,
+	Args: cobra.MaximumNArgs(1),
+	// The file is loaded by the command itself, so that a file the root
+	// command cannot read is reported instead of failing before it runs
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+	RunE:              runConfigValidate,
+}
+
+func init() {
+	configCmd.AddCommand(configValidateCmd)
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	path := cfgFile
+	if len(args) > 0 {
+		path = args[0]
+	}
+	if path == "" {
+		dir, err := getForgeDir()
+		if err != nil {
+			return err
+		}
+		path = filepath.Join(dir, "config.yaml")
+	}
+
+	fmt.Printf("Validating %s\n\n", path)
+	diags, err := validateConfigFile(path)
+	if err != nil {
+		fmt.Printf("  ✗ %v\n", err)
+		return fmt.Errorf("configuration is invalid")
+	}
+	if len(diags) == 0 {
+		fmt.Println("✅ Configuration is valid")
+		return nil
+	}
+
+	for _, d := range diags {
+		fmt.Printf("  ✗ %s\n", d)
+	}
+	fmt.Println()
+	return fmt.Errorf("configuration has %d problem(s)", len(diags))
+}
+
+// validateConfigFile loads the configuration file at path and returns its
+// problems. An error means the file could not be loaded at all.
+func validateConfigFile(path string) ([]config.Diagnostic, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("config file not found: %s", path)
+	}
+	cfg, err := config.LoadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	diags := cfg.Diagnose()
+
+	fv := viper.New()
+	fv.SetConfigFile(path)
+	if err := fv.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+	return append(diags, daemonDiagnostics(fv)...), nil
+}
+
+// daemonDiagnostics checks the settings forge start reads from the
+// configuration file.
+func daemonDiagnostics(fv *viper.Viper) []config.Diagnostic {
+	var diags []config.Diagnostic
+	add := func(key, format string, args ...interface{}) {
+		diags = append(diags, config.Diagnostic{Key: key, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// daemon.* durations are read with viper, metrics.* and alerts.* ones accept days too
+	type durationKey struct {
+		key   string
+		parse func(string) (time.Duration, error)
+	}
+	durations := []durationKey{
+		{"daemon.idle_timeout", time.ParseDuration},
+		{"daemon.stream_heartbeat", time.ParseDuration},
+		{"metrics.raw_retention", parseDuration},
+		{"metrics.downsample_interval", parseDuration},
+		{"metrics.medium_retention", parseDuration},
+		{"metrics.long_retention", parseDuration},
+		{"alerts.silence_retention", parseDuration},
+	}
+	var tierKeys []string
+	for resolution := range fv.GetStringMapString("metrics.retention_tiers") {
+		tierKeys = append(tierKeys, "metrics.retention_tiers."+resolution)
+	}
+	sort.Strings(tierKeys)
+	for _, key := range tierKeys {
+		durations = append(durations, durationKey{key, parseDuration})
+	}
+	durationsOK := true
+	for _, d := range durations {
+		if !fv.IsSet(d.key) {
+			continue
+		}
+		value, err := d.parse(fv.GetString(d.key))
+		switch {
+		case err != nil:
+			add(d.key, "invalid duration %q", fv.GetString(d.key))
+		case value <= 0:
+			add(d.key, "must be positive, got %s", fv.GetString(d.key))
+		default:
+			continue
+		}
+		durationsOK = false
+	}
+
+	// The tiers only make sense as a whole once each duration is valid
+	if durationsOK {
+		dc := daemon.DefaultConfig("")
+		if err := applyRetentionConfig(fv, &dc); err != nil {
+			add("metrics", "%v", err)
+		} else if err := (services.RetentionPolicy{RawRetention: dc.RawRetention, Tiers: dc.RetentionTiers, Interval: dc.DownsampleInterval}).Validate(); err != nil {
+			add("metrics", "%v", err)
+		}
+	}
+
+	if fv.IsSet("traces") {
+		tc := services.DefaultTraceConfig()
+		if err := applyTraceConfig(fv, &tc); err != nil {
+			add("traces", "%v", err)
+		}
+	}
+
+	if fv.IsSet("alerts.quiet_hours") {
+		if _, err := quietHoursConfig(fv); err != nil {
+			add("alerts.quiet_hours", "%v", err)
+		}
+	}
+
+	if fv.IsSet("metrics.max_series_per_name") && fv.GetInt("metrics.max_series_per_name") < 0 {
+		add("metrics.max_series_per_name", "must not be negative")
+	}
+
+	if fv.IsSet("metrics.system.interval") {
+		if d, err := parseDuration(fv.GetString("metrics.system.interval")); err != nil {
+			add("metrics.system.interval", "%v", err)
+		} else if d <= 0 {
+			add("metrics.system.interval", "must be positive")
+		}
+	}
+
+	if fv.IsSet("metrics.transforms") {
+		var rules []domain.MetricTransformRule
+		if err := fv.UnmarshalKey("metrics.transforms", &rules); err != nil {
+			add("metrics.transforms", "failed to parse: %v", err)
+		}
+		for i := range rules {
+			if err := rules[i].Validate(); err != nil {
+				add(fmt.Sprintf("metrics.transforms[%d]", i), "%v", err)
+			}
+		}
+	}
+	if fv.IsSet("metrics.relabel") {
+		var rules []domain.MetricRelabelRule
+		if err := fv.UnmarshalKey("metrics.relabel", &rules); err != nil {
+			add("metrics.relabel", "failed to parse: %v", err)
+		}
+		for i := range rules {
+			if err := rules[i].Validate(); err != nil {
+				add(fmt.Sprintf("metrics.relabel[%d]", i), "%v", err)
+			}
+		}
+	}
+	if fv.IsSet("metrics.metadata") {
+		var entries []domain.MetricMetadata
+		if err := fv.UnmarshalKey("metrics.metadata", &entries); err != nil {
+			add("metrics.metadata", "failed to parse: %v", err)
+		}
+		for i := range entries {
+			if err := entries[i].Validate(); err != nil {
+				add(fmt.Sprintf("metrics.metadata[%d]", i), "%v", err)
+			}
+		}
+	}
+
+	if port := fv.GetString("otlp.port"); port != "" {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			add("otlp.port", "must be a port between 1 and 65535, got %q", port)
+		}
+	}
+	if addr := fv.GetString("prometheus.exporter_addr"); addr != "" {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			add("prometheus.exporter_addr", "must be host:port, got %q", addr)
+		}
+	}
+	return diags
+}
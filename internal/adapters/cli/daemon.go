@@ -3,15 +3,20 @@ package cli
 import (
 	"context"
 	"fmt"
+	"net"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"sort"
 	"syscall"
 	"time"
 
 	"github.com/forge-platform/forge/internal/adapters/daemon"
+	"github.com/forge-platform/forge/internal/core/domain"
 	"github.com/forge-platform/forge/internal/core/ports"
+	"github.com/forge-platform/forge/internal/core/services"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 // newDaemonClient creates a new daemon client connected to the default socket.
@@ -25,14 +30,34 @@ func newDaemonClient() (*daemon.Client, error) {
 	if err != nil {
 		return nil, err
 	}
+	client.SetAPIKey(resolveAPIKey())
 
 	if err := client.Connect(); err != nil {
 		return nil, err
 	}
 
+	// Learn what the daemon supports, so commands it can't serve fail with
+	// an upgrade message instead of a bare unknown method error
+	if _, err := client.Capabilities(context.Background()); err != nil {
+		client.Close()
+		return nil, err
+	}
+
 	return client, nil
 }
 
+// resolveAPIKey returns the API key from --api-key, FORGE_API_KEY or the
+// api_key config setting, in that order.
+func resolveAPIKey() string {
+	if apiKey != "" {
+		return apiKey
+	}
+	if v != nil {
+		return v.GetString("api_key")
+	}
+	return os.Getenv("FORGE_API_KEY")
+}
+
 var startCmd = &cobra.Command{
 	Use:   "start",
 	Short: "Start the Forge daemon",
@@ -61,6 +86,28 @@ var statusCmd = &cobra.Command{
 	RunE:  runStatus,
 }
 
+var (
+	startSelfTracing  bool
+	startWatchPlugins bool
+	startOTLP         bool
+	startOTLPHost     string
+	startOTLPPort     string
+	startRemoteWrite  bool
+	startExporter     bool
+	startMetricsAddr  string
+)
+
+func init() {
+	startCmd.Flags().BoolVar(&startSelfTracing, "self-tracing", true, "Record a trace span for every daemon RPC (service forge-daemon)")
+	startCmd.Flags().BoolVar(&startWatchPlugins, "watch-plugins", false, "Reload plugins automatically when their .wasm file changes (config: plugins.watch)")
+	startCmd.Flags().BoolVar(&startOTLP, "otlp", true, "Accept traces and logs over OTLP/HTTP (config: otlp.enabled)")
+	startCmd.Flags().StringVar(&startOTLPHost, "otlp-host", "", "Interface for the OTLP/HTTP receiver, which does not authenticate exporters; 0.0.0.0 accepts remote ones (config: otlp.host, default 127.0.0.1)")
+	startCmd.Flags().StringVar(&startOTLPPort, "otlp-port", "", "Port for the OTLP/HTTP receiver (config: otlp.port, default 4318)")
+	startCmd.Flags().BoolVar(&startRemoteWrite, "remote-write", false, "Accept Prometheus remote_write on the HTTP server at /api/v1/write, without authentication (config: prometheus.remote_write)")
+	startCmd.Flags().BoolVar(&startExporter, "metrics-exporter", false, "Expose stored series and daemon stats for Prometheus to scrape (config: prometheus.exporter)")
+	startCmd.Flags().StringVar(&startMetricsAddr, "metrics-addr", "", "Listen address for the metrics exporter, e.g. 127.0.0.1:9464; empty serves /metrics on the HTTP server (config: prometheus.exporter_addr)")
+}
+
 func runStart(cmd *cobra.Command, args []string) error {
 	forgeDir, err := ensureForgeDir()
 	if err != nil {
@@ -69,6 +116,86 @@ func runStart(cmd *cobra.Command, args []string) error {
 
 	// Use default configuration from daemon package
 	config := daemon.DefaultConfig(forgeDir)
+	config.SelfTracing = startSelfTracing
+	config.WatchPlugins = startWatchPlugins || (v != nil && v.GetBool("plugins.watch"))
+	if v != nil {
+		config.MaxSeriesPerName = v.GetInt("metrics.max_series_per_name")
+	}
+	config.SystemMetrics = v == nil || !v.IsSet("metrics.system.enabled") || v.GetBool("metrics.system.enabled")
+	if v != nil && v.IsSet("metrics.system.interval") {
+		interval, err := parseDuration(v.GetString("metrics.system.interval"))
+		if err != nil {
+			return fmt.Errorf("invalid metrics.system.interval: %w", err)
+		}
+		config.SystemMetricsInterval = interval
+	}
+	config.OTLPEnabled = startOTLP && (v == nil || !v.IsSet("otlp.enabled") || v.GetBool("otlp.enabled"))
+	if startOTLPHost != "" {
+		config.OTLPHost = startOTLPHost
+	} else if v != nil && v.GetString("otlp.host") != "" {
+		config.OTLPHost = v.GetString("otlp.host")
+	}
+	if startOTLPPort != "" {
+		config.OTLPPort = startOTLPPort
+	} else if v != nil && v.GetString("otlp.port") != "" {
+		config.OTLPPort = v.GetString("otlp.port")
+	}
+	config.RemoteWrite = startRemoteWrite || (v != nil && v.GetBool("prometheus.remote_write"))
+	if v != nil && v.GetInt("prometheus.max_body_bytes") > 0 {
+		config.RemoteWriteLimit = v.GetInt("prometheus.max_body_bytes")
+	}
+	config.MetricsExporter = startExporter || (v != nil && v.GetBool("prometheus.exporter"))
+	if startMetricsAddr != "" {
+		config.MetricsAddr = startMetricsAddr
+	} else if v != nil {
+		config.MetricsAddr = v.GetString("prometheus.exporter_addr")
+	}
+	if v != nil && v.IsSet("daemon.idle_timeout") {
+		config.IdleTimeout = v.GetDuration("daemon.idle_timeout")
+	}
+	if v != nil && v.IsSet("daemon.stream_heartbeat") {
+		config.StreamHeartbeat = v.GetDuration("daemon.stream_heartbeat")
+	}
+	config.AuthDisabled = v != nil && v.GetBool("daemon.auth_disabled")
+	if v != nil {
+		if err := applyRetentionConfig(v, &config); err != nil {
+			return err
+		}
+	}
+	if v != nil {
+		if err := applyTraceConfig(v, &config.Traces); err != nil {
+			return err
+		}
+	}
+	if v != nil && v.IsSet("alerts.silence_retention") {
+		retention, err := parseDuration(v.GetString("alerts.silence_retention"))
+		if err != nil {
+			return fmt.Errorf("invalid alerts.silence_retention: %w", err)
+		}
+		config.SilenceRetention = retention
+	}
+	if v != nil && v.IsSet("alerts.quiet_hours") {
+		quietHours, err := quietHoursConfig(v)
+		if err != nil {
+			return fmt.Errorf("invalid alerts.quiet_hours: %w", err)
+		}
+		config.QuietHours = quietHours
+	}
+	if v != nil && v.IsSet("metrics.transforms") {
+		if err := v.UnmarshalKey("metrics.transforms", &config.MetricTransforms); err != nil {
+			return fmt.Errorf("failed to parse metrics.transforms: %w", err)
+		}
+	}
+	if v != nil && v.IsSet("metrics.relabel") {
+		if err := v.UnmarshalKey("metrics.relabel", &config.MetricRelabels); err != nil {
+			return fmt.Errorf("failed to parse metrics.relabel: %w", err)
+		}
+	}
+	if v != nil && v.IsSet("metrics.metadata") {
+		if err := v.UnmarshalKey("metrics.metadata", &config.MetricMetadata); err != nil {
+			return fmt.Errorf("failed to parse metrics.metadata: %w", err)
+		}
+	}
 
 	// Check if already running
 	if _, err := os.Stat(config.SocketPath); err == nil {
@@ -86,6 +213,12 @@ func runStart(cmd *cobra.Command, args []string) error {
 	fmt.Printf("🚀 Forge daemon started\n")
 	fmt.Printf("   Socket: %s\n", config.SocketPath)
 	fmt.Printf("   PID: %d\n", os.Getpid())
+	if config.OTLPEnabled {
+		fmt.Printf("   OTLP: %s\n", net.JoinHostPort(config.OTLPHost, config.OTLPPort))
+	}
+	if config.MetricsExporter && config.MetricsAddr != "" {
+		fmt.Printf("   Metrics: %s/metrics\n", config.MetricsAddr)
+	}
 	fmt.Println("   Press Ctrl+C to stop")
 
 	// Setup graceful shutdown
@@ -224,3 +357,127 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// applyRetentionConfig reads the downsampling settings under metrics:
+// raw_retention, downsample_interval, the retention of the 1-minute
+// (medium_retention) and 1-hour (long_retention) tiers, and retention_tiers,
+// a map from any resolution to its retention.
+// quietHoursConfig reads the alerts.quiet_hours section: start and end
+// times, optional days (e.g. "mon-fri") and time zone.
+func quietHoursConfig(v *viper.Viper) (*domain.QuietHours, error) {
+	days, err := domain.ParseWeekdays(v.GetString("alerts.quiet_hours.days"))
+	if err != nil {
+		return nil, err
+	}
+	quietHours := &domain.QuietHours{
+		Start:    v.GetString("alerts.quiet_hours.start"),
+		End:      v.GetString("alerts.quiet_hours.end"),
+		Days:     days,
+		Timezone: v.GetString("alerts.quiet_hours.timezone"),
+	}
+	if err := quietHours.Validate(); err != nil {
+		return nil, err
+	}
+	return quietHours, nil
+}
+
+func applyRetentionConfig(v *viper.Viper, config *daemon.Config) error {
+	durations := []struct {
+		key    string
+		target *time.Duration
+	}{
+		{"metrics.raw_retention", &config.RawRetention},
+		{"metrics.downsample_interval", &config.DownsampleInterval},
+	}
+	for _, d := range durations {
+		if !v.IsSet(d.key) {
+			continue
+		}
+		value, err := parseDuration(v.GetString(d.key))
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", d.key, err)
+		}
+		*d.target = value
+	}
+
+	tiers := []struct {
+		key        string
+		resolution string
+	}{
+		{"metrics.medium_retention", "1m"},
+		{"metrics.long_retention", "1h"},
+	}
+	for _, t := range tiers {
+		if !v.IsSet(t.key) {
+			continue
+		}
+		retention, err := parseDuration(v.GetString(t.key))
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", t.key, err)
+		}
+		config.RetentionTiers = setRetentionTier(config.RetentionTiers, services.RetentionTier{Resolution: t.resolution, Retention: retention})
+	}
+
+	// Any tier, including ones without a named key such as 5m, can be set
+	// by resolution under retention_tiers
+	extra := v.GetStringMapString("metrics.retention_tiers")
+	resolutions := make([]string, 0, len(extra))
+	for resolution := range extra {
+		resolutions = append(resolutions, resolution)
+	}
+	sort.Strings(resolutions)
+	for _, resolution := range resolutions {
+		retention, err := parseDuration(extra[resolution])
+		if err != nil {
+			return fmt.Errorf("invalid metrics.retention_tiers.%s: %w", resolution, err)
+		}
+		config.RetentionTiers = setRetentionTier(config.RetentionTiers, services.RetentionTier{Resolution: resolution, Retention: retention})
+	}
+	return nil
+}
+
+// applyTraceConfig reads trace sampling and retention from the traces.*
+// keys. Durations accept days, e.g. "14d"; a zero retention or latency
+// threshold turns that rule off.
+func applyTraceConfig(v *viper.Viper, config *services.TraceConfig) error {
+	if v.IsSet("traces.sample_rate") {
+		config.SampleRate = v.GetFloat64("traces.sample_rate")
+	}
+	if v.IsSet("traces.keep_errors") {
+		config.KeepErrors = v.GetBool("traces.keep_errors")
+	}
+	durations := []struct {
+		key    string
+		target *time.Duration
+	}{
+		{"traces.latency_threshold", &config.LatencyThreshold},
+		{"traces.decision_wait", &config.DecisionWait},
+		{"traces.retention", &config.Retention},
+		{"traces.retention_interval", &config.RetentionInterval},
+	}
+	for _, d := range durations {
+		if !v.IsSet(d.key) {
+			continue
+		}
+		value, err := parseDuration(v.GetString(d.key))
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", d.key, err)
+		}
+		*d.target = value
+	}
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid traces config: %w", err)
+	}
+	return nil
+}
+
+// setRetentionTier replaces the tier with the same resolution or appends it.
+func setRetentionTier(tiers []services.RetentionTier, tier services.RetentionTier) []services.RetentionTier {
+	for i := range tiers {
+		if tiers[i].Resolution == tier.Resolution {
+			tiers[i] = tier
+			return tiers
+		}
+	}
+	return append(tiers, tier)
+}
+
@@ -84,6 +84,17 @@ metrics:
   medium_retention: 30d  # Keep 1-minute aggregates for 30 days
   long_retention: 365d   # Keep 1-hour aggregates for 1 year
   downsample_interval: 1h  # Run downsampling every hour
+  # retention_tiers:       # Other rollup resolutions (1m, 5m, 1h, 1d)
+  #   5m: 90d
+
+# Alerting
+alerts:
+  silence_retention: 7d  # Delete silences this long after they end
+  # quiet_hours:           # Hold non-critical notifications, sent as a digest afterwards
+  #   start: "22:00"
+  #   end: "07:00"
+  #   days: mon-fri        # Days quiet hours start on (default every day)
+  #   timezone: Europe/Berlin
 
 # Daemon settings
 daemon:
@@ -91,6 +102,9 @@ daemon:
   pid_file: ~/.forge/forge.pid
   shutdown_timeout: 10s
   worker_count: 4
+  idle_timeout: 5m       # Close client connections idle this long (0 = never)
+  stream_heartbeat: 30s  # Keep-alive interval for streaming connections
+  auth_disabled: false   # Serve every request without an API key (single-user setups)
 
 # AI settings
 ai:
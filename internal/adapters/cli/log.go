@@ -1,12 +1,21 @@
 package cli
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
 	"text/tabwriter"
 	"time"
 
+	"github.com/forge-platform/forge/internal/adapters/daemon"
+	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 )
 
@@ -16,24 +25,66 @@ func init() {
 	logCmd.AddCommand(logSearchCmd)
 	logCmd.AddCommand(logTailCmd)
 	logCmd.AddCommand(logStatsCmd)
+	logCmd.AddCommand(logIngestCmd)
 	logCmd.AddCommand(logParserCmd)
 	logParserCmd.AddCommand(logParserListCmd)
+	logParserCmd.AddCommand(logParserAddCmd)
+	logParserCmd.AddCommand(logParserRemoveCmd)
+	logCmd.AddCommand(logMetricRuleCmd)
+	logMetricRuleCmd.AddCommand(logMetricRuleListCmd)
+	logMetricRuleCmd.AddCommand(logMetricRuleAddCmd)
+	logMetricRuleCmd.AddCommand(logMetricRuleRemoveCmd)
 
 	// Flags
 	logListCmd.Flags().StringP("level", "l", "", "filter by level (trace, debug, info, warning, error, fatal)")
 	logListCmd.Flags().StringP("service", "s", "", "filter by service name")
 	logListCmd.Flags().StringP("source", "", "", "filter by source")
 	logListCmd.Flags().StringP("trace-id", "t", "", "filter by trace ID")
+	logListCmd.Flags().String("min-level", "", "only show entries at or above this level")
+	logListCmd.Flags().StringArray("attr", nil, "filter by attribute (key=value, repeatable)")
 	logListCmd.Flags().DurationP("since", "", time.Hour, "show logs since duration ago")
+	logListCmd.Flags().String("start", "", "start time (e.g. -2h, 2024-01-01T14:00:00Z); overrides --since")
+	logListCmd.Flags().String("end", "", "end time (e.g. -1h, now, 2024-01-01T15:00:00Z)")
 	logListCmd.Flags().IntP("limit", "n", 50, "limit number of results")
+	logListCmd.Flags().Int("offset", 0, "skip this many results")
+	logListCmd.Flags().BoolP("follow", "f", false, "keep streaming new log entries after listing")
 
 	logSearchCmd.Flags().DurationP("since", "", time.Hour, "search logs since duration ago")
 	logSearchCmd.Flags().IntP("limit", "n", 50, "limit number of results")
 
 	logTailCmd.Flags().StringP("level", "l", "", "filter by level")
 	logTailCmd.Flags().StringP("service", "s", "", "filter by service name")
+	logTailCmd.Flags().StringP("source", "", "", "filter by source")
+	logTailCmd.Flags().String("min-level", "", "only show entries at or above this level")
+	logTailCmd.Flags().String("grep", "", "only show entries whose message contains this text")
+	logTailCmd.Flags().StringArray("attr", nil, "filter by attribute (key=value, repeatable)")
+	logTailCmd.Flags().BoolP("follow", "f", true, "keep streaming until interrupted (tail always follows)")
+	logTailCmd.Flags().Bool("no-color", false, "disable colorized levels")
 
 	logStatsCmd.Flags().DurationP("since", "", time.Hour, "stats for duration")
+
+	logIngestCmd.Flags().String("file", "", "file to ingest, or - for stdin")
+	logIngestCmd.Flags().String("source", "", "source name for the lines (default: file name)")
+	logIngestCmd.Flags().Int("batch-size", 500, "lines sent per request")
+	_ = logIngestCmd.MarkFlagRequired("file")
+
+	logParserAddCmd.Flags().String("type", "regex", "parser type (regex, grok, json, key_value)")
+	logParserAddCmd.Flags().String("pattern", "", "regex with named groups, e.g. (?P<level>\\w+) (?P<message>.*)")
+	logParserAddCmd.Flags().Int("priority", 0, "parsers with higher priority are tried first")
+	logParserAddCmd.Flags().String("source-filter", "", "only apply to sources containing this text")
+	logParserAddCmd.Flags().StringArray("map", nil, "rename a parsed field (from=to, repeatable)")
+
+	logMetricRuleAddCmd.Flags().String("match", "", "regex matched against the field")
+	logMetricRuleAddCmd.Flags().String("field", "message", "field to match (message, level, source, service or an attribute)")
+	logMetricRuleAddCmd.Flags().String("metric", "", "name of the metric to record")
+	logMetricRuleAddCmd.Flags().String("type", "counter", "metric type (counter, gauge)")
+	logMetricRuleAddCmd.Flags().String("value", "", "capture group or field holding the value (required for gauges)")
+	logMetricRuleAddCmd.Flags().String("min-level", "", "only count entries at or above this level")
+	logMetricRuleAddCmd.Flags().String("service", "", "only count entries from this service")
+	logMetricRuleAddCmd.Flags().String("source", "", "only count entries from this source")
+	logMetricRuleAddCmd.Flags().StringArray("tag", nil, "tag added to the metric (key=value, repeatable)")
+	logMetricRuleAddCmd.Flags().StringArray("tag-field", nil, "attribute copied into a metric tag (repeatable)")
+	_ = logMetricRuleAddCmd.MarkFlagRequired("metric")
 }
 
 var logCmd = &cobra.Command{
@@ -78,6 +129,65 @@ var logParserListCmd = &cobra.Command{
 	RunE:  runLogParserList,
 }
 
+var logParserAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Add a log parser",
+	Long: `Add a parser to the ingestion pipeline. Parsers are tried in priority
+order and the first match wins. Fields named level, timestamp, service,
+message, trace_id and span_id are promoted onto the entry; any other
+fields become attributes.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runLogParserAdd,
+}
+
+var logParserRemoveCmd = &cobra.Command{
+	Use:   "remove <id|name>",
+	Short: "Remove a log parser",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runLogParserRemove,
+}
+
+var logMetricRuleCmd = &cobra.Command{
+	Use:     "metricrule",
+	Aliases: []string{"metric-rule"},
+	Short:   "Manage log-to-metric rules",
+}
+
+var logMetricRuleListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List log-to-metric rules",
+	RunE:  runLogMetricRuleList,
+}
+
+var logMetricRuleAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Add a log-to-metric rule",
+	Long: `Record a metric for every ingested log entry that matches. Counter
+rules count matching entries (or sum the --value capture); gauge rules
+record the number captured by --value.`,
+	Example: `  forge log metricrule add app-errors --match ERROR --metric app.error.count
+  forge log metricrule add latency --match 'took (?P<ms>\d+)ms' --type gauge --value ms --metric app.latency_ms`,
+	Args: cobra.ExactArgs(1),
+	RunE: runLogMetricRuleAdd,
+}
+
+var logMetricRuleRemoveCmd = &cobra.Command{
+	Use:   "remove <id|name>",
+	Short: "Remove a log-to-metric rule",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runLogMetricRuleRemove,
+}
+
+var logIngestCmd = &cobra.Command{
+	Use:   "ingest",
+	Short: "Ingest log lines from a file or stdin",
+	Long: `Send raw log lines to the daemon, which runs them through the
+configured parsers. Lines no parser matches are stored with level unknown.`,
+	Example: `  forge log ingest --file app.log --source myapp
+  tail -f app.log | forge log ingest --file - --source myapp --batch-size 1`,
+	RunE: runLogIngest,
+}
+
 func runLogList(cmd *cobra.Command, args []string) error {
 	client, err := newDaemonClient()
 	if err != nil {
@@ -89,16 +199,46 @@ func runLogList(cmd *cobra.Command, args []string) error {
 	service, _ := cmd.Flags().GetString("service")
 	source, _ := cmd.Flags().GetString("source")
 	traceID, _ := cmd.Flags().GetString("trace-id")
+	minLevel, _ := cmd.Flags().GetString("min-level")
+	attrs, _ := cmd.Flags().GetStringArray("attr")
 	since, _ := cmd.Flags().GetDuration("since")
+	start, _ := cmd.Flags().GetString("start")
+	end, _ := cmd.Flags().GetString("end")
 	limit, _ := cmd.Flags().GetInt("limit")
+	offset, _ := cmd.Flags().GetInt("offset")
+
+	if level != "" && minLevel != "" {
+		return fmt.Errorf("--level and --min-level cannot be combined")
+	}
+
+	startTime := time.Now().Add(-since)
+	if start != "" {
+		if startTime, err = parseTimeSpec(start); err != nil {
+			return fmt.Errorf("invalid --start: %w", err)
+		}
+	}
 
 	params := map[string]interface{}{
 		"level":        level,
+		"min_level":    minLevel,
 		"service_name": service,
 		"source":       source,
 		"trace_id":     traceID,
-		"start_time":   time.Now().Add(-since).Format(time.RFC3339),
+		"start_time":   startTime.Format(time.RFC3339),
 		"limit":        limit,
+		"offset":       offset,
+	}
+	if end != "" {
+		endTime, err := parseTimeSpec(end)
+		if err != nil {
+			return fmt.Errorf("invalid --end: %w", err)
+		}
+		params["end_time"] = endTime.Format(time.RFC3339)
+	}
+	if len(attrs) > 0 {
+		if params["attributes"], err = parseAttrFlags(attrs); err != nil {
+			return err
+		}
 	}
 
 	ctx := context.Background()
@@ -107,27 +247,40 @@ func runLogList(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to list logs: %w", err)
 	}
 
+	follow, _ := cmd.Flags().GetBool("follow")
+
 	logs, ok := resp.(map[string]interface{})["logs"].([]interface{})
 	if !ok || len(logs) == 0 {
-		fmt.Println("No logs found.")
-		return nil
+		if !follow {
+			fmt.Println("No logs found.")
+			return nil
+		}
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TIME\tLEVEL\tSERVICE\tMESSAGE")
+		fmt.Fprintln(w, "----\t-----\t-------\t-------")
+
+		for _, l := range logs {
+			log := l.(map[string]interface{})
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+				logFormatTime(getString(log, "timestamp")),
+				getLevelIcon(getString(log, "level")),
+				getString(log, "service_name"),
+				truncateString(getString(log, "message"), 60),
+			)
+		}
+		w.Flush()
 	}
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "TIME\tLEVEL\tSERVICE\tMESSAGE")
-	fmt.Fprintln(w, "----\t-----\t-------\t-------")
-
-	for _, l := range logs {
-		log := l.(map[string]interface{})
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
-			logFormatTime(getString(log, "timestamp")),
-			getLevelIcon(getString(log, "level")),
-			getString(log, "service_name"),
-			truncateString(getString(log, "message"), 60),
-		)
+	if !follow {
+		return nil
 	}
-	w.Flush()
-	return nil
+
+	delete(params, "start_time")
+	delete(params, "end_time")
+	delete(params, "limit")
+	delete(params, "offset")
+	return followLogs(params, useColor(cmd))
 }
 
 func runLogSearch(cmd *cobra.Command, args []string) error {
@@ -176,11 +329,130 @@ func runLogSearch(cmd *cobra.Command, args []string) error {
 }
 
 func runLogTail(cmd *cobra.Command, args []string) error {
-	fmt.Println("Log tailing requires a running daemon with streaming support.")
-	fmt.Println("Use 'forge log list --since 1m' to see recent logs.")
+	level, _ := cmd.Flags().GetString("level")
+	service, _ := cmd.Flags().GetString("service")
+	source, _ := cmd.Flags().GetString("source")
+	minLevel, _ := cmd.Flags().GetString("min-level")
+	search, _ := cmd.Flags().GetString("grep")
+	attrs, _ := cmd.Flags().GetStringArray("attr")
+
+	if level != "" && minLevel != "" {
+		return fmt.Errorf("--level and --min-level cannot be combined")
+	}
+
+	params := map[string]interface{}{
+		"level":        level,
+		"min_level":    minLevel,
+		"service_name": service,
+		"source":       source,
+		"search":       search,
+	}
+	if len(attrs) > 0 {
+		attributes, err := parseAttrFlags(attrs)
+		if err != nil {
+			return err
+		}
+		params["attributes"] = attributes
+	}
+
+	fmt.Println("Tailing logs (Ctrl+C to stop)...")
+	return followLogs(params, useColor(cmd))
+}
+
+// parseAttrFlags converts repeated key=value flags into log filter attributes.
+func parseAttrFlags(attrs []string) (map[string]interface{}, error) {
+	attributes := make(map[string]interface{}, len(attrs))
+	for _, attr := range attrs {
+		k, v, ok := strings.Cut(attr, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid --attr %q, expected key=value", attr)
+		}
+		attributes[k] = v
+	}
+	return attributes, nil
+}
+
+// useColor reports whether output should be colorized: stdout must be a
+// terminal and neither --no-color nor NO_COLOR may be set.
+func useColor(cmd *cobra.Command) bool {
+	if noColor, _ := cmd.Flags().GetBool("no-color"); noColor {
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// followLogs streams new log entries matching params until interrupted.
+func followLogs(params map[string]interface{}, color bool) error {
+	client, err := newDaemonClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	printLog := func(log map[string]interface{}) {
+		level := fmt.Sprintf("%-5s", getLevelIcon(getString(log, "level")))
+		if color {
+			level = colorLevel(getString(log, "level"), level)
+		}
+		fmt.Printf("%s  %s  %s  %s\n",
+			logFormatTime(getString(log, "timestamp")),
+			level,
+			getString(log, "service_name"),
+			getString(log, "message"),
+		)
+	}
+
+	// Keep following across daemon restarts and hangs
+	for {
+		err = client.TailLogs(ctx, params, printLog)
+		if !errors.Is(err, daemon.ErrDaemonUnresponsive) && !errors.Is(err, io.EOF) {
+			break
+		}
+		fmt.Fprintf(os.Stderr, "Lost connection to daemon (%v), reconnecting...\n", err)
+		if err = reconnectDaemon(ctx, client); err != nil {
+			break
+		}
+	}
+	if err != nil && ctx.Err() == nil {
+		return fmt.Errorf("failed to tail logs: %w", err)
+	}
 	return nil
 }
 
+// maxReconnectDelay caps the backoff between reconnection attempts, each of
+// which must get a ping answered within reconnectTimeout.
+const (
+	maxReconnectDelay = 30 * time.Second
+	reconnectTimeout  = 5 * time.Second
+)
+
+// reconnectDaemon re-establishes client's connection, backing off between
+// attempts, until it succeeds or ctx is cancelled.
+func reconnectDaemon(ctx context.Context, client *daemon.Client) error {
+	delay := time.Second
+	for {
+		attemptCtx, cancel := context.WithTimeout(ctx, reconnectTimeout)
+		err := client.Reconnect(attemptCtx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = min(2*delay, maxReconnectDelay)
+	}
+}
+
 func runLogStats(cmd *cobra.Command, args []string) error {
 	client, err := newDaemonClient()
 	if err != nil {
@@ -247,8 +519,8 @@ func runLogParserList(cmd *cobra.Command, args []string) error {
 	}
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "ID\tNAME\tTYPE\tENABLED\tSOURCE FILTER")
-	fmt.Fprintln(w, "--\t----\t----\t-------\t-------------")
+	fmt.Fprintln(w, "ID\tNAME\tTYPE\tPRIORITY\tENABLED\tMATCHES\tSOURCE FILTER")
+	fmt.Fprintln(w, "--\t----\t----\t--------\t-------\t-------\t-------------")
 
 	for _, p := range parsers {
 		parser := p.(map[string]interface{})
@@ -256,15 +528,281 @@ func runLogParserList(cmd *cobra.Command, args []string) error {
 		if e, ok := parser["enabled"].(bool); ok && e {
 			enabled = "✓"
 		}
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+		fmt.Fprintf(w, "%s\t%s\t%s\t%v\t%s\t%v\t%s\n",
 			traceTruncateID(getString(parser, "id")),
 			getString(parser, "name"),
 			getString(parser, "type"),
+			parser["priority"],
 			enabled,
+			parser["matches"],
 			getString(parser, "source_filter"),
 		)
 	}
 	w.Flush()
+	fmt.Printf("\nLines matched by no parser: %v\n", resp.(map[string]interface{})["unparsed"])
+	return nil
+}
+
+func runLogParserAdd(cmd *cobra.Command, args []string) error {
+	parserType, _ := cmd.Flags().GetString("type")
+	pattern, _ := cmd.Flags().GetString("pattern")
+	priority, _ := cmd.Flags().GetInt("priority")
+	sourceFilter, _ := cmd.Flags().GetString("source-filter")
+	maps, _ := cmd.Flags().GetStringArray("map")
+
+	mappings := make(map[string]interface{}, len(maps))
+	for _, m := range maps {
+		from, to, ok := strings.Cut(m, "=")
+		if !ok || from == "" || to == "" {
+			return fmt.Errorf("invalid --map %q, expected from=to", m)
+		}
+		mappings[from] = to
+	}
+
+	client, err := newDaemonClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	resp, err := client.Call(context.Background(), "log.parser.create", map[string]interface{}{
+		"name":           args[0],
+		"type":           parserType,
+		"pattern":        pattern,
+		"priority":       priority,
+		"source_filter":  sourceFilter,
+		"field_mappings": mappings,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add parser: %w", err)
+	}
+	fmt.Printf("Added parser %s (%s)\n", args[0], getString(resp.(map[string]interface{}), "id"))
+	return nil
+}
+
+func runLogParserRemove(cmd *cobra.Command, args []string) error {
+	client, err := newDaemonClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	id, err := resolveLogItemID(ctx, client, "log.parser.list", "parsers", args[0])
+	if err != nil {
+		return err
+	}
+
+	if _, err := client.Call(ctx, "log.parser.delete", map[string]interface{}{"id": id}); err != nil {
+		return fmt.Errorf("failed to remove parser: %w", err)
+	}
+	fmt.Printf("Removed parser %s\n", args[0])
+	return nil
+}
+
+func runLogMetricRuleList(cmd *cobra.Command, args []string) error {
+	client, err := newDaemonClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	resp, err := client.Call(context.Background(), "log.metric.rule.list", nil)
+	if err != nil {
+		return fmt.Errorf("failed to list rules: %w", err)
+	}
+
+	rules, _ := resp.(map[string]interface{})["rules"].([]interface{})
+	if len(rules) == 0 {
+		fmt.Println("No log-to-metric rules configured")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tMETRIC\tTYPE\tMATCH\tSTATUS")
+	fmt.Fprintln(w, "--\t----\t------\t----\t-----\t------")
+	for _, r := range rules {
+		rule := r.(map[string]interface{})
+		match := getString(rule, "match_field") + " ~ " + getString(rule, "match_pattern")
+		status := getString(rule, "status")
+		if lastErr := getString(rule, "last_error"); lastErr != "" {
+			status += ": " + lastErr
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			traceTruncateID(getString(rule, "id")),
+			getString(rule, "name"),
+			getString(rule, "metric_name"),
+			getString(rule, "metric_type"),
+			match,
+			status,
+		)
+	}
+	return w.Flush()
+}
+
+func runLogMetricRuleAdd(cmd *cobra.Command, args []string) error {
+	match, _ := cmd.Flags().GetString("match")
+	field, _ := cmd.Flags().GetString("field")
+	metric, _ := cmd.Flags().GetString("metric")
+	metricType, _ := cmd.Flags().GetString("type")
+	value, _ := cmd.Flags().GetString("value")
+	minLevel, _ := cmd.Flags().GetString("min-level")
+	service, _ := cmd.Flags().GetString("service")
+	source, _ := cmd.Flags().GetString("source")
+	tagFlags, _ := cmd.Flags().GetStringArray("tag")
+	tagFields, _ := cmd.Flags().GetStringArray("tag-field")
+
+	tags := make(map[string]interface{}, len(tagFlags))
+	for _, tag := range tagFlags {
+		k, v, ok := strings.Cut(tag, "=")
+		if !ok || k == "" {
+			return fmt.Errorf("invalid --tag %q, expected key=value", tag)
+		}
+		tags[k] = v
+	}
+
+	client, err := newDaemonClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	params := map[string]interface{}{
+		"name":          args[0],
+		"match_field":   field,
+		"match_pattern": match,
+		"metric_name":   metric,
+		"metric_type":   metricType,
+		"value_field":   value,
+		"min_level":     minLevel,
+		"service_name":  service,
+		"source":        source,
+		"tags":          tags,
+		"tag_fields":    tagFields,
+	}
+
+	resp, err := client.Call(context.Background(), "log.metric.rule.create", params)
+	if err != nil {
+		return fmt.Errorf("failed to add rule: %w", err)
+	}
+	fmt.Printf("Added rule %s (%s)\n", args[0], getString(resp.(map[string]interface{}), "id"))
+	return nil
+}
+
+func runLogMetricRuleRemove(cmd *cobra.Command, args []string) error {
+	client, err := newDaemonClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	id, err := resolveLogItemID(ctx, client, "log.metric.rule.list", "rules", args[0])
+	if err != nil {
+		return err
+	}
+	if _, err := client.Call(ctx, "log.metric.rule.delete", map[string]interface{}{"id": id}); err != nil {
+		return fmt.Errorf("failed to remove rule: %w", err)
+	}
+	fmt.Printf("Removed rule %s\n", args[0])
+	return nil
+}
+
+// resolveLogItemID returns nameOrID unchanged if it is an ID, or looks up
+// the ID of the item with that name in the listMethod response.
+func resolveLogItemID(ctx context.Context, client *daemon.Client, listMethod, key, nameOrID string) (string, error) {
+	if _, err := uuid.Parse(nameOrID); err == nil {
+		return nameOrID, nil
+	}
+
+	resp, err := client.Call(ctx, listMethod, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to list %s: %w", key, err)
+	}
+	items, _ := resp.(map[string]interface{})[key].([]interface{})
+	for _, i := range items {
+		item := i.(map[string]interface{})
+		if getString(item, "name") == nameOrID {
+			return getString(item, "id"), nil
+		}
+	}
+	return "", fmt.Errorf("%q not found", nameOrID)
+}
+
+func runLogIngest(cmd *cobra.Command, args []string) error {
+	file, _ := cmd.Flags().GetString("file")
+	source, _ := cmd.Flags().GetString("source")
+	batchSize, _ := cmd.Flags().GetInt("batch-size")
+	if batchSize <= 0 {
+		return fmt.Errorf("--batch-size must be positive")
+	}
+
+	input := os.Stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", file, err)
+		}
+		defer f.Close()
+		input = f
+		if source == "" {
+			source = filepath.Base(file)
+		}
+	} else if source == "" {
+		source = "stdin"
+	}
+
+	client, err := newDaemonClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var ingested, parsed, unparsed int
+	send := func(lines []interface{}) error {
+		resp, err := client.Call(ctx, "log.ingest", map[string]interface{}{"source": source, "lines": lines})
+		if err != nil {
+			return fmt.Errorf("failed to ingest logs: %w", err)
+		}
+		result, _ := resp.(map[string]interface{})
+		n, _ := result["ingested"].(float64)
+		p, _ := result["parsed"].(float64)
+		u, _ := result["unparsed"].(float64)
+		ingested += int(n)
+		parsed += int(p)
+		unparsed += int(u)
+		return nil
+	}
+
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	batch := make([]interface{}, 0, batchSize)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		batch = append(batch, line)
+		if len(batch) == batchSize {
+			if err := send(batch); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read input: %w", err)
+	}
+	if len(batch) > 0 {
+		if err := send(batch); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Ingested %d lines from %s (%d parsed, %d unparsed)\n", ingested, source, parsed, unparsed)
 	return nil
 }
 
@@ -277,6 +815,24 @@ func logFormatTime(ts string) string {
 	return t.Format("15:04:05.000")
 }
 
+// colorLevel wraps text in the ANSI color for level.
+func colorLevel(level, text string) string {
+	switch level {
+	case "trace", "debug":
+		return "\033[90m" + text + "\033[0m" // Gray
+	case "info":
+		return "\033[36m" + text + "\033[0m" // Cyan
+	case "warning", "warn":
+		return "\033[33m" + text + "\033[0m" // Yellow
+	case "error":
+		return "\033[31m" + text + "\033[0m" // Red
+	case "fatal":
+		return "\033[1;31m" + text + "\033[0m" // Bold red
+	default:
+		return text
+	}
+}
+
 func getLevelIcon(level string) string {
 	switch level {
 	case "trace":
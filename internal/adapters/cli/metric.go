@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -34,8 +35,10 @@ var metricQueryCmd = &cobra.Command{
 var metricListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List metric series",
-	Long:  `List all unique metric series in the database.`,
-	RunE:  runMetricList,
+	Long: `List all unique metric series in the database.
+
+With --limit, only the most recently written series are shown, newest first.`,
+	RunE: runMetricList,
 }
 
 var metricStatsCmd = &cobra.Command{
@@ -67,12 +70,32 @@ var metricAggregateCmd = &cobra.Command{
 
 Aggregation types: avg, sum, min, max, count, first, last
 
+With --merged, buckets older than the raw data are filled from downsampled
+rollups so long ranges return one continuous series.
+
 Example:
-  forge metric aggregate cpu.usage --agg avg --step 5m --start -1h`,
+  forge metric aggregate cpu.usage --agg avg --step 5m --start -1h
+  forge metric aggregate cpu.usage --step 1h --start -720h --merged`,
 	Args: cobra.ExactArgs(1),
 	RunE: runMetricAggregate,
 }
 
+var metricExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export raw metric points to CSV or JSON",
+	Long: `Export the raw points of every series of a metric in a time range.
+
+CSV output has a header and one row per point (name, type, timestamp, value,
+tags). JSON output is an array of series objects, each with its tags and
+points. Points are streamed from the daemon and written as they arrive, so
+large ranges do not need to fit in memory.
+
+Example:
+  forge metric export --name cpu.usage --from -24h --format csv -o cpu.csv
+  forge metric export --name http.latency --tags env=prod --from 2026-01-01 --to 2026-01-02 --format json`,
+	RunE: runMetricExport,
+}
+
 var (
 	metricTags       string
 	metricType       string
@@ -83,6 +106,12 @@ var (
 	metricResolution string
 	metricAggType    string
 	metricStep       string
+	metricListLimit  int
+	metricMerged     bool
+	metricQueryRes   string
+	metricLimit      int
+	metricGroupBy    string
+	metricShowIDs    bool
 )
 
 func init() {
@@ -92,16 +121,24 @@ func init() {
 	metricCmd.AddCommand(metricStatsCmd)
 	metricCmd.AddCommand(metricDownsampleCmd)
 	metricCmd.AddCommand(metricAggregateCmd)
+	metricCmd.AddCommand(metricExportCmd)
 
 	// Record flags
 	metricRecordCmd.Flags().StringVar(&metricTags, "tags", "", "Metric tags (key=value,key2=value2)")
 	metricRecordCmd.Flags().StringVar(&metricType, "type", "gauge", "Metric type (gauge, counter, histogram)")
 
+	// List flags
+	metricListCmd.Flags().IntVar(&metricListLimit, "limit", 0, "Show only the N most recently written series (0 = all)")
+
 	// Query flags
 	metricQueryCmd.Flags().StringVar(&metricTags, "tags", "", "Filter by tags")
 	metricQueryCmd.Flags().StringVar(&metricStart, "start", "-1h", "Start time (e.g., -1h, -24h, 2024-01-01)")
 	metricQueryCmd.Flags().StringVar(&metricEnd, "end", "now", "End time")
 	metricQueryCmd.Flags().StringVar(&metricInterval, "interval", "", "Aggregation interval (1m, 5m, 1h)")
+	metricQueryCmd.Flags().StringVar(&metricQueryRes, "resolution", "auto", "Data to read: auto (raw and downsampled), raw, 1m, 5m, 1h or 1d")
+	metricQueryCmd.Flags().IntVar(&metricLimit, "limit", 0, "Maximum number of points (0 = daemon default)")
+	metricQueryCmd.Flags().StringVar(&metricGroupBy, "group-by", "", "Aggregate per value of these tags (host,region or * for every series)")
+	metricQueryCmd.Flags().BoolVar(&metricShowIDs, "ids", false, "Show the stored ID and type of each raw point")
 
 	// Downsample flags
 	metricDownsampleCmd.Flags().StringVar(&metricOlderThan, "older-than", "7d", "Age threshold for downsampling (e.g., 7d, 24h)")
@@ -113,6 +150,16 @@ func init() {
 	metricAggregateCmd.Flags().StringVar(&metricStart, "start", "-1h", "Start time")
 	metricAggregateCmd.Flags().StringVar(&metricEnd, "end", "now", "End time")
 	metricAggregateCmd.Flags().StringVar(&metricTags, "tags", "", "Filter by tags")
+	metricAggregateCmd.Flags().BoolVar(&metricMerged, "merged", false, "Include downsampled history older than the raw data")
+
+	// Export flags
+	metricExportCmd.Flags().String("name", "", "Metric name to export")
+	metricExportCmd.Flags().String("from", "-1h", "Start time (e.g., -1h, -24h, 2024-01-01)")
+	metricExportCmd.Flags().String("to", "now", "End time")
+	metricExportCmd.Flags().String("tags", "", "Only export series with these tags (key=value,key2=value2)")
+	metricExportCmd.Flags().String("format", "csv", "Output format (csv or json)")
+	metricExportCmd.Flags().StringP("output", "o", "", "Output file (default stdout)")
+	_ = metricExportCmd.MarkFlagRequired("name")
 }
 
 func runMetricRecord(cmd *cobra.Command, args []string) error {
@@ -179,7 +226,31 @@ func runMetricQuery(cmd *cobra.Command, args []string) error {
 		"start": start.Format(time.RFC3339),
 		"end":   end.Format(time.RFC3339),
 		"tags":  parseTags(metricTags),
-		"limit": 100, // default limit
+	}
+	if metricQueryRes != "" {
+		params["resolution"] = metricQueryRes
+	}
+	if metricInterval != "" {
+		step, err := parseDuration(metricInterval)
+		if err != nil {
+			return fmt.Errorf("invalid interval: %w", err)
+		}
+		params["step"] = step.String()
+	}
+	if metricLimit > 0 {
+		params["limit"] = metricLimit
+	}
+	if metricShowIDs {
+		params["include_ids"] = true
+	}
+	if metricGroupBy != "" {
+		var groupBy []string
+		for _, key := range strings.Split(metricGroupBy, ",") {
+			if key = strings.TrimSpace(key); key != "" {
+				groupBy = append(groupBy, key)
+			}
+		}
+		params["group_by"] = groupBy
 	}
 
 	resp, err := client.Call(cmd.Context(), "metric.query", params)
@@ -195,10 +266,23 @@ func runMetricQuery(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("unexpected response type")
 	}
 
+	if groups, ok := resMap["groups"].([]interface{}); ok {
+		printMetricGroups(groups)
+		return nil
+	}
+
 	if points, ok := resMap["points"].([]interface{}); ok {
 		fmt.Printf("\nFound %d points:\n", len(points))
 		for _, p := range points {
 			pt := p.(map[string]interface{})
+			if res, _ := pt["resolution"].(string); res != "" && res != "raw" {
+				fmt.Printf("  %s: %v (%s)\n", pt["timestamp"], pt["value"], res)
+				continue
+			}
+			if id, _ := pt["id"].(string); id != "" {
+				fmt.Printf("  %s: %v [%s %s]\n", pt["timestamp"], pt["value"], pt["type"], id)
+				continue
+			}
 			fmt.Printf("  %s: %v\n", pt["timestamp"], pt["value"])
 		}
 	} else {
@@ -208,6 +292,31 @@ func runMetricQuery(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// printMetricGroups prints each group of a grouped query under its tag values.
+func printMetricGroups(groups []interface{}) {
+	fmt.Printf("\nFound %d groups:\n", len(groups))
+	for _, g := range groups {
+		group := g.(map[string]interface{})
+		tags, _ := group["tags"].(map[string]interface{})
+		keys := make([]string, 0, len(tags))
+		for k := range tags {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		labels := make([]string, len(keys))
+		for i, k := range keys {
+			labels[i] = fmt.Sprintf("%s=%v", k, tags[k])
+		}
+		fmt.Printf("\n  %s:\n", strings.Join(labels, ","))
+
+		points, _ := group["points"].([]interface{})
+		for _, p := range points {
+			pt := p.(map[string]interface{})
+			fmt.Printf("    %s: %v (%v points)\n", pt["timestamp"], pt["value"], pt["count"])
+		}
+	}
+}
+
 func runMetricList(cmd *cobra.Command, args []string) error {
 	client, err := newDaemonClient()
 	if err != nil {
@@ -215,7 +324,11 @@ func runMetricList(cmd *cobra.Command, args []string) error {
 	}
 	defer client.Close()
 
-	resp, err := client.Call(cmd.Context(), "metric.list", nil)
+	var params map[string]interface{}
+	if metricListLimit > 0 {
+		params = map[string]interface{}{"limit": metricListLimit}
+	}
+	resp, err := client.Call(cmd.Context(), "metric.list", params)
 	if err != nil {
 		return fmt.Errorf("failed to list series: %w", err)
 	}
@@ -262,6 +375,12 @@ func runMetricStats(cmd *cobra.Command, args []string) error {
 	fmt.Printf("  Total series: %v\n", resMap["TotalSeries"])
 	fmt.Printf("  Storage space: %v bytes\n", resMap["StorageBytes"])
 	fmt.Printf("  Time range: %v to %v\n", resMap["OldestPoint"], resMap["NewestPoint"])
+	if dropped, ok := resMap["DroppedSeries"].(float64); ok && dropped > 0 {
+		fmt.Printf("  Dropped by series limit: %v points\n", dropped)
+	}
+	if dropped, ok := resMap["DroppedNonFinite"].(float64); ok && dropped > 0 {
+		fmt.Printf("  Dropped as NaN/Inf: %v points\n", dropped)
+	}
 	
 	if agg, ok := resMap["AggregatedPoints"].(map[string]interface{}); ok {
 		fmt.Println("  Aggregated points:")
@@ -301,12 +420,19 @@ func runMetricDownsample(cmd *cobra.Command, args []string) error {
 		"resolution": metricResolution,
 	}
 
-	_, err = client.Call(cmd.Context(), "metric.downsample", params)
+	resp, err := client.Call(cmd.Context(), "metric.downsample", params)
 	if err != nil {
 		return fmt.Errorf("failed to downsample metrics: %w", err)
 	}
 
-	fmt.Printf("✓ Downsampling started/completed for metrics older than %s to %s resolution.\n", metricOlderThan, metricResolution)
+	fmt.Printf("✓ Downsampling completed for metrics older than %s to %s resolution.\n", metricOlderThan, metricResolution)
+	if result, ok := resp.(map[string]interface{}); ok {
+		fmt.Printf("  Series: %v, aggregates written: %v, raw points deleted: %v\n",
+			result["series"], result["aggregated"], result["deleted_raw"])
+		if failed, _ := result["failed"].(float64); failed > 0 {
+			fmt.Printf("  ⚠️  %v series kept their raw points after a failure; run again to resume\n", failed)
+		}
+	}
 	return nil
 }
 
@@ -356,6 +482,9 @@ func runMetricAggregate(cmd *cobra.Command, args []string) error {
 		"start": start.Format(time.RFC3339),
 		"end":   end.Format(time.RFC3339),
 	}
+	if metricMerged {
+		params["merged"] = true
+	}
 
 	resp, err := client.Call(cmd.Context(), "metric.aggregate", params)
 	if err != nil {
@@ -0,0 +1,293 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// metricExportWriter writes the chunks of a metric.export stream in one
+// output format. Chunks of the same series arrive consecutively.
+type metricExportWriter interface {
+	WriteChunk(series map[string]interface{}, points []interface{}) error
+	// Close finishes the output; it does not close the underlying writer.
+	Close() error
+}
+
+// newMetricExportWriter returns a writer for format, csv or json.
+func newMetricExportWriter(format string, out io.Writer) (metricExportWriter, error) {
+	switch format {
+	case "csv":
+		return newCSVMetricWriter(out)
+	case "json":
+		return &jsonMetricWriter{out: bufio.NewWriter(out)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported format %q (use csv or json)", format)
+	}
+}
+
+// exportPoint is a [unix_ms, value] pair from a metric.export message.
+type exportPoint struct {
+	Timestamp time.Time
+	Value     float64
+}
+
+func parseExportPoint(raw interface{}) (exportPoint, error) {
+	pair, ok := raw.([]interface{})
+	if !ok || len(pair) != 2 {
+		return exportPoint{}, fmt.Errorf("unexpected point in export: %v", raw)
+	}
+	ms, ok1 := pair[0].(float64)
+	value, ok2 := pair[1].(float64)
+	if !ok1 || !ok2 {
+		return exportPoint{}, fmt.Errorf("unexpected point in export: %v", raw)
+	}
+	return exportPoint{Timestamp: time.UnixMilli(int64(ms)).UTC(), Value: value}, nil
+}
+
+// exportTags returns a series' tags as a string map.
+func exportTags(series map[string]interface{}) map[string]string {
+	tags := make(map[string]string)
+	if raw, ok := series["tags"].(map[string]interface{}); ok {
+		for k := range raw {
+			tags[k] = getString(raw, k)
+		}
+	}
+	return tags
+}
+
+// formatExportTags renders tags as sorted key=value pairs separated by
+// commas, the syntax --tags accepts.
+func formatExportTags(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + tags[k]
+	}
+	return strings.Join(pairs, ",")
+}
+
+// csvMetricWriter writes a header and then one row per point.
+type csvMetricWriter struct {
+	w *csv.Writer
+}
+
+func newCSVMetricWriter(out io.Writer) (*csvMetricWriter, error) {
+	w := csv.NewWriter(out)
+	if err := w.Write([]string{"name", "type", "timestamp", "value", "tags"}); err != nil {
+		return nil, err
+	}
+	return &csvMetricWriter{w: w}, nil
+}
+
+func (c *csvMetricWriter) WriteChunk(series map[string]interface{}, points []interface{}) error {
+	name, metricType := getString(series, "name"), getString(series, "type")
+	tags := formatExportTags(exportTags(series))
+	for _, raw := range points {
+		p, err := parseExportPoint(raw)
+		if err != nil {
+			return err
+		}
+		if err := c.w.Write([]string{
+			name,
+			metricType,
+			p.Timestamp.Format(time.RFC3339Nano),
+			strconv.FormatFloat(p.Value, 'g', -1, 64),
+			tags,
+		}); err != nil {
+			return err
+		}
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvMetricWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// jsonMetricWriter writes an array of series objects, opening a new object
+// whenever the series changes so points never have to be held back.
+type jsonMetricWriter struct {
+	out     *bufio.Writer
+	current string // name and tags of the open series
+	started bool   // whether the array has been opened
+	points  int    // points written to the open series
+}
+
+// jsonExportSeries is the header of a series object in JSON output.
+type jsonExportSeries struct {
+	Name string            `json:"name"`
+	Type string            `json:"type,omitempty"`
+	Unit string            `json:"unit,omitempty"`
+	Tags map[string]string `json:"tags"`
+}
+
+// jsonExportPoint is a point in JSON output.
+type jsonExportPoint struct {
+	Timestamp string  `json:"timestamp"`
+	Value     float64 `json:"value"`
+}
+
+func (j *jsonMetricWriter) WriteChunk(series map[string]interface{}, points []interface{}) error {
+	tags := exportTags(series)
+	key := getString(series, "name") + "{" + formatExportTags(tags) + "}"
+	if !j.started || key != j.current {
+		j.closeSeries()
+		if j.started {
+			j.out.WriteString(",\n  ")
+		} else {
+			j.out.WriteString("[\n  ")
+			j.started = true
+		}
+		header, err := json.Marshal(jsonExportSeries{
+			Name: getString(series, "name"),
+			Type: getString(series, "type"),
+			Unit: getString(series, "unit"),
+			Tags: tags,
+		})
+		if err != nil {
+			return err
+		}
+		// Reopen the header object to append the points array
+		j.out.Write(header[:len(header)-1])
+		j.out.WriteString(`,"points":[`)
+		j.current, j.points = key, 0
+	}
+
+	for _, raw := range points {
+		p, err := parseExportPoint(raw)
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(jsonExportPoint{Timestamp: p.Timestamp.Format(time.RFC3339Nano), Value: p.Value})
+		if err != nil {
+			return err
+		}
+		if j.points > 0 {
+			j.out.WriteString(",")
+		}
+		j.out.WriteString("\n    ")
+		j.out.Write(data)
+		j.points++
+	}
+	return j.out.Flush()
+}
+
+// closeSeries ends the open series object, if any.
+func (j *jsonMetricWriter) closeSeries() {
+	if !j.started {
+		return
+	}
+	if j.points > 0 {
+		j.out.WriteString("\n  ")
+	}
+	j.out.WriteString("]}")
+}
+
+func (j *jsonMetricWriter) Close() error {
+	if j.started {
+		j.closeSeries()
+		j.out.WriteString("\n]\n")
+	} else {
+		j.out.WriteString("[]\n")
+	}
+	return j.out.Flush()
+}
+
+func runMetricExport(cmd *cobra.Command, args []string) error {
+	name, _ := cmd.Flags().GetString("name")
+	from, _ := cmd.Flags().GetString("from")
+	to, _ := cmd.Flags().GetString("to")
+	tags, _ := cmd.Flags().GetString("tags")
+	format, _ := cmd.Flags().GetString("format")
+	output, _ := cmd.Flags().GetString("output")
+
+	start, err := parseTimeSpec(from)
+	if err != nil {
+		return fmt.Errorf("invalid --from: %w", err)
+	}
+	end, err := parseTimeSpec(to)
+	if err != nil {
+		return fmt.Errorf("invalid --to: %w", err)
+	}
+	if end.Before(start) {
+		return fmt.Errorf("--to must not be before --from")
+	}
+	// Fail on a bad format before anything is written
+	if _, err := newMetricExportWriter(format, io.Discard); err != nil {
+		return err
+	}
+
+	client, err := newDaemonClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	out := io.Writer(os.Stdout)
+	var tmp *os.File
+	if output != "" {
+		// Write to a temporary file so a failed export never leaves a partial file behind
+		tmp, err = os.CreateTemp(filepath.Dir(output), ".forge-metrics-*")
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer os.Remove(tmp.Name())
+		out = tmp
+	}
+
+	writer, err := newMetricExportWriter(format, out)
+	if err != nil {
+		return err
+	}
+	params := map[string]interface{}{
+		"name":  name,
+		"start": start.Format(time.RFC3339),
+		"end":   end.Format(time.RFC3339),
+		"tags":  parseTags(tags),
+	}
+	summary, err := client.ExportMetrics(ctx, params, writer.WriteChunk)
+	if err == nil {
+		err = writer.Close()
+	}
+	if tmp != nil {
+		if closeErr := tmp.Close(); err == nil && closeErr != nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to export metrics: %w", err)
+	}
+
+	if tmp == nil {
+		return nil
+	}
+	if err := os.Rename(tmp.Name(), output); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	fmt.Printf("✓ Exported %v points from %v series of %s to %s\n",
+		summary["points"], summary["series"], name, output)
+	return nil
+}
@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+var exportEpoch = time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+
+// exportChunk builds a metric.export message as decoded by the client.
+func exportChunk(host string, values ...float64) (map[string]interface{}, []interface{}) {
+	series := map[string]interface{}{
+		"name": "cpu.usage",
+		"type": "gauge",
+		"tags": map[string]interface{}{"host": host, "env": "prod"},
+	}
+	points := make([]interface{}, len(values))
+	for i, v := range values {
+		ms := float64(exportEpoch.Add(time.Duration(v * float64(time.Second))).UnixMilli())
+		points[i] = []interface{}{ms, v}
+	}
+	return series, points
+}
+
+// writeExport feeds chunks of (host, values) through a writer for format.
+func writeExport(t *testing.T, format string, chunks ...[]interface{}) string {
+	t.Helper()
+	var out bytes.Buffer
+	w, err := newMetricExportWriter(format, &out)
+	if err != nil {
+		t.Fatalf("newMetricExportWriter failed: %v", err)
+	}
+	for _, c := range chunks {
+		values := make([]float64, 0, len(c)-1)
+		for _, v := range c[1:] {
+			values = append(values, v.(float64))
+		}
+		if err := w.WriteChunk(exportChunk(c[0].(string), values...)); err != nil {
+			t.Fatalf("WriteChunk failed: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	return out.String()
+}
+
+func TestMetricExportCSV(t *testing.T) {
+	out := writeExport(t, "csv",
+		[]interface{}{"a", 1.0, 2.5},
+		[]interface{}{"a", 3.0},
+		[]interface{}{"b", 4.0},
+	)
+
+	rows, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v\n%s", err, out)
+	}
+	if len(rows) != 5 {
+		t.Fatalf("expected a header and 4 rows, got %d:\n%s", len(rows), out)
+	}
+	if strings.Join(rows[0], ",") != "name,type,timestamp,value,tags" {
+		t.Errorf("unexpected header %v", rows[0])
+	}
+	want := []string{"cpu.usage", "gauge", exportEpoch.Add(2500 * time.Millisecond).Format(time.RFC3339Nano), "2.5", "env=prod,host=a"}
+	if strings.Join(rows[2], "|") != strings.Join(want, "|") {
+		t.Errorf("expected row %v, got %v", want, rows[2])
+	}
+	if rows[4][4] != "env=prod,host=b" || rows[4][3] != "4" {
+		t.Errorf("expected host b's point last, got %v", rows[4])
+	}
+}
+
+func TestMetricExportJSON(t *testing.T) {
+	out := writeExport(t, "json",
+		[]interface{}{"a", 1.0, 2.0},
+		[]interface{}{"a", 3.0},
+		[]interface{}{"b", 4.0},
+	)
+
+	var series []struct {
+		Name   string            `json:"name"`
+		Type   string            `json:"type"`
+		Tags   map[string]string `json:"tags"`
+		Points []struct {
+			Timestamp time.Time `json:"timestamp"`
+			Value     float64   `json:"value"`
+		} `json:"points"`
+	}
+	if err := json.Unmarshal([]byte(out), &series); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, out)
+	}
+	if len(series) != 2 {
+		t.Fatalf("expected chunks of one series to be merged into 2 series, got %d:\n%s", len(series), out)
+	}
+	a := series[0]
+	if a.Name != "cpu.usage" || a.Type != "gauge" || a.Tags["host"] != "a" || a.Tags["env"] != "prod" {
+		t.Errorf("unexpected first series %+v", a)
+	}
+	if len(a.Points) != 3 || a.Points[2].Value != 3 || !a.Points[2].Timestamp.Equal(exportEpoch.Add(3*time.Second)) {
+		t.Errorf("expected host a's 3 points in order, got %+v", a.Points)
+	}
+	if series[1].Tags["host"] != "b" || len(series[1].Points) != 1 {
+		t.Errorf("unexpected second series %+v", series[1])
+	}
+}
+
+func TestMetricExportEmpty(t *testing.T) {
+	if out := writeExport(t, "json"); strings.TrimSpace(out) != "[]" {
+		t.Errorf("expected an empty JSON array, got %q", out)
+	}
+	if out := writeExport(t, "csv"); out != "name,type,timestamp,value,tags\n" {
+		t.Errorf("expected only the CSV header, got %q", out)
+	}
+	if _, err := newMetricExportWriter("xml", &bytes.Buffer{}); err == nil {
+		t.Error("expected an unsupported format to be rejected")
+	}
+}
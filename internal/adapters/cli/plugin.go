@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"path/filepath"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -29,7 +30,11 @@ Examples:
   forge plugin install system-metrics       # From registry
   forge plugin install system-metrics@1.2.0 # Specific version
   forge plugin install ./my-plugin.wasm     # Local file
-  forge plugin install https://example.com/plugin.wasm`,
+  forge plugin install https://example.com/plugin.wasm --sha256 <checksum>
+
+Plugins installed from a URL are downloaded to ~/.forge/plugins and only
+loaded once they match --sha256 and, if given, --signature (hex-encoded
+ed25519, checked against the trusted registry keys).`,
 	Args: cobra.ExactArgs(1),
 	RunE: runPluginInstall,
 }
@@ -58,6 +63,35 @@ var pluginDisableCmd = &cobra.Command{
 	RunE:  runPluginDisable,
 }
 
+var pluginReloadCmd = &cobra.Command{
+	Use:   "reload [name]",
+	Short: "Reload a plugin from disk",
+	Long: `Reload a running plugin from its .wasm file without restarting the daemon.
+
+The old instance is cleaned up and the new binary initialized; other plugins
+keep running. If the new binary fails to load, the old one stays active.
+Start the daemon with --watch-plugins to reload automatically on change.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPluginReload,
+}
+
+var pluginReloadHash string
+
+var pluginConfigureCmd = &cobra.Command{
+	Use:   "configure [name] [key=value...]",
+	Short: "Configure a running plugin",
+	Long: `Replace the configuration of a running plugin.
+
+The configuration is checked against the schema the plugin declares; if any
+value has the wrong type, is out of range or a required key is missing, every
+problem is reported and the plugin keeps its previous configuration.
+
+Examples:
+  forge plugin configure system-metrics interval=30 collect_disk=false`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: runPluginConfigure,
+}
+
 var pluginInfoCmd = &cobra.Command{
 	Use:   "info [name]",
 	Short: "Show plugin information",
@@ -97,16 +131,51 @@ var pluginRegistryRefreshCmd = &cobra.Command{
 	RunE:  runPluginRegistryRefresh,
 }
 
+var pluginStorageCmd = &cobra.Command{
+	Use:   "storage [name]",
+	Short: "Show or purge a plugin's data storage",
+	Long: `Show how much of its storage quota a plugin is using.
+
+Each plugin stores its files in a directory named after it under the
+daemon's plugin data directory. Use --purge to have the daemon delete
+everything the plugin has stored.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPluginStorage,
+}
+
+var (
+	pluginInstallHash      string
+	pluginInstallSignature string
+	pluginInstallConfig    []string
+)
+
+var (
+	pluginStoragePurge bool
+	pluginStorageForce bool
+)
+
 func init() {
 	pluginCmd.AddCommand(pluginListCmd)
 	pluginCmd.AddCommand(pluginInstallCmd)
 	pluginCmd.AddCommand(pluginUninstallCmd)
 	pluginCmd.AddCommand(pluginEnableCmd)
 	pluginCmd.AddCommand(pluginDisableCmd)
+	pluginCmd.AddCommand(pluginReloadCmd)
+	pluginCmd.AddCommand(pluginConfigureCmd)
 	pluginCmd.AddCommand(pluginInfoCmd)
 	pluginCmd.AddCommand(pluginSearchCmd)
 	pluginCmd.AddCommand(pluginUpdateCmd)
 	pluginCmd.AddCommand(pluginRegistryCmd)
+	pluginCmd.AddCommand(pluginStorageCmd)
+
+	pluginStorageCmd.Flags().BoolVar(&pluginStoragePurge, "purge", false, "Delete all data stored by the plugin")
+	pluginStorageCmd.Flags().BoolVarP(&pluginStorageForce, "force", "f", false, "Purge without confirmation")
+
+	pluginInstallCmd.Flags().StringVar(&pluginInstallHash, "sha256", "", "Expected SHA-256 of the binary (required for URLs)")
+	pluginInstallCmd.Flags().StringVar(&pluginInstallSignature, "signature", "", "Hex-encoded ed25519 signature of the binary")
+	pluginInstallCmd.Flags().StringArrayVar(&pluginInstallConfig, "config", nil, "Plugin configuration as key=value (repeatable)")
+
+	pluginReloadCmd.Flags().StringVar(&pluginReloadHash, "sha256", "", "Expected SHA-256 of the new binary")
 
 	pluginRegistryCmd.AddCommand(pluginRegistryRefreshCmd)
 }
@@ -147,7 +216,35 @@ func runPluginList(cmd *cobra.Command, args []string) error {
 }
 
 func runPluginInstall(cmd *cobra.Command, args []string) error {
-	path := args[0]
+	source := args[0]
+	params := map[string]interface{}{}
+	if pluginInstallHash != "" {
+		params["sha256"] = pluginInstallHash
+	}
+	if pluginInstallSignature != "" {
+		params["signature"] = pluginInstallSignature
+	}
+	if len(pluginInstallConfig) > 0 {
+		config, err := parsePluginConfig(pluginInstallConfig)
+		if err != nil {
+			return err
+		}
+		params["config"] = config
+	}
+
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		if pluginInstallHash == "" {
+			return fmt.Errorf("--sha256 is required to install from a URL")
+		}
+		params["url"] = source
+	} else {
+		// The daemon resolves paths from its own working directory
+		path, err := filepath.Abs(source)
+		if err != nil {
+			return err
+		}
+		params["path"] = path
+	}
 
 	client, err := newDaemonClient()
 	if err != nil {
@@ -155,19 +252,22 @@ func runPluginInstall(cmd *cobra.Command, args []string) error {
 	}
 	defer client.Close()
 
-	fmt.Printf("Installing plugin from: %s\n", path)
-	fmt.Println("  Validating WASM binary...")
-	fmt.Println("  Reading manifest...")
-	fmt.Println("  Verifying permissions...")
+	if params["url"] != nil {
+		if err := client.RequireFeature(cmd.Context(), "plugin.install.url", 1); err != nil {
+			return err
+		}
+	}
 
-	_, err = client.Call(cmd.Context(), "plugin.install", map[string]interface{}{
-		"path": path,
-	})
+	fmt.Printf("Installing plugin from: %s\n", source)
+	resp, err := client.Call(cmd.Context(), "plugin.install", params)
 	if err != nil {
 		return fmt.Errorf("failed to install plugin: %w", err)
 	}
 
-	fmt.Println("✓ Plugin installed successfully")
+	result, _ := resp.(map[string]interface{})
+	fmt.Printf("✓ Plugin '%s' installed\n", getString(result, "name"))
+	fmt.Printf("  Path:   %s\n", getString(result, "path"))
+	fmt.Printf("  SHA256: %s\n", getString(result, "hash"))
 	return nil
 }
 
@@ -231,6 +331,77 @@ func runPluginDisable(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func runPluginConfigure(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	config, err := parsePluginConfig(args[1:])
+	if err != nil {
+		return err
+	}
+
+	client, err := newDaemonClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	_, err = client.Call(cmd.Context(), "plugin.configure", map[string]interface{}{
+		"name":   name,
+		"config": config,
+	})
+	if err != nil {
+		// Schema errors already name the plugin and every invalid key
+		return err
+	}
+
+	fmt.Printf("✓ Plugin '%s' configured\n", name)
+	return nil
+}
+
+// parsePluginConfig parses key=value arguments into plugin configuration.
+func parsePluginConfig(pairs []string) (map[string]string, error) {
+	config := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid configuration %q, expected key=value", pair)
+		}
+		config[key] = value
+	}
+	return config, nil
+}
+
+func runPluginReload(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	client, err := newDaemonClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	params := map[string]interface{}{"name": name}
+	if pluginReloadHash != "" {
+		params["sha256"] = pluginReloadHash
+	}
+	result, err := client.Call(cmd.Context(), "plugin.reload", params)
+	if err != nil {
+		return fmt.Errorf("failed to reload plugin: %w", err)
+	}
+
+	resp, _ := result.(map[string]interface{})
+	if resp["status"] == "unchanged" {
+		fmt.Printf("Plugin '%s' is already up to date\n", name)
+		return nil
+	}
+	hash, _ := resp["hash"].(string)
+	if len(hash) > 12 {
+		hash = hash[:12]
+	}
+	fmt.Printf("✓ Plugin '%s' reloaded (%s)\n", name, hash)
+	return nil
+}
+
 func runPluginInfo(cmd *cobra.Command, args []string) error {
 	name := args[0]
 
@@ -342,3 +513,58 @@ func runPluginRegistryRefresh(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+func runPluginStorage(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	client, err := newDaemonClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	// The daemon knows the data directory and quota its plugins run with
+	params := map[string]interface{}{"name": name}
+	result, err := client.Call(cmd.Context(), "plugin.storage", params)
+	if err != nil {
+		return fmt.Errorf("failed to get plugin storage: %w", err)
+	}
+	usage, _ := result.(map[string]interface{})
+	files, _ := usage["files"].(float64)
+	used, _ := usage["used"].(float64)
+	quota, _ := usage["quota"].(float64)
+
+	fmt.Printf("Plugin:    %s\n", name)
+	fmt.Printf("Directory: %s\n", usage["dir"])
+	fmt.Printf("Files:     %.0f\n", files)
+	if quota < 0 {
+		fmt.Printf("Usage:     %.2f MB (no quota)\n", used/1024/1024)
+	} else {
+		fmt.Printf("Usage:     %.2f MB / %.2f MB (%.1f%%)\n", used/1024/1024, quota/1024/1024, used/quota*100)
+	}
+
+	if !pluginStoragePurge {
+		return nil
+	}
+
+	if files == 0 {
+		fmt.Println("Nothing to purge.")
+		return nil
+	}
+
+	if !pluginStorageForce {
+		fmt.Printf("This will delete all %.0f files stored by '%s'. Continue? [y/N]: ", files, name)
+		var confirm string
+		_, _ = fmt.Scanln(&confirm)
+		if confirm != "y" && confirm != "Y" {
+			fmt.Println("Purge cancelled.")
+			return nil
+		}
+	}
+
+	if _, err := client.Call(cmd.Context(), "plugin.storage.purge", params); err != nil {
+		return fmt.Errorf("failed to purge plugin storage: %w", err)
+	}
+	fmt.Printf("✓ Purged storage for plugin '%s'\n", name)
+	return nil
+}
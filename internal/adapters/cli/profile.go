@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
 	"text/tabwriter"
 	"time"
 
@@ -20,6 +23,7 @@ func init() {
 	profileCmd.AddCommand(profileGetCmd)
 	profileCmd.AddCommand(profileStopCmd)
 	profileCmd.AddCommand(profileDeleteCmd)
+	profileCmd.AddCommand(profileExportCmd)
 	profileCmd.AddCommand(profileStatsCmd)
 	profileCmd.AddCommand(profileMemoryCmd)
 
@@ -36,6 +40,8 @@ func init() {
 
 	profileListCmd.Flags().StringP("type", "t", "", "filter by type (cpu, heap, goroutine)")
 	profileListCmd.Flags().IntP("limit", "n", 20, "limit number of results")
+
+	profileExportCmd.Flags().StringP("output", "o", "", "output file (default <profile-id>.pb.gz)")
 }
 
 var profileCmd = &cobra.Command{
@@ -94,6 +100,14 @@ var profileDeleteCmd = &cobra.Command{
 	RunE:  runProfileDelete,
 }
 
+var profileExportCmd = &cobra.Command{
+	Use:   "export <profile-id>",
+	Short: "Export raw pprof data",
+	Long:  `Write the raw pprof data of a completed profile to a file for analysis with 'go tool pprof'.`,
+	Args:  cobra.ExactArgs(1),
+	RunE:  runProfileExport,
+}
+
 var profileStatsCmd = &cobra.Command{
 	Use:   "stats",
 	Short: "Show profiling statistics",
@@ -291,6 +305,45 @@ func runProfileGet(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func runProfileExport(cmd *cobra.Command, args []string) error {
+	output, _ := cmd.Flags().GetString("output")
+	if output == "" {
+		output = args[0] + ".pb.gz"
+	}
+
+	client, err := newDaemonClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Write to a temporary file so a failed export never leaves a partial profile behind
+	tmp, err := os.CreateTemp(filepath.Dir(output), ".forge-profile-*")
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	summary, err := client.ExportProfile(ctx, args[0], tmp)
+	if closeErr := tmp.Close(); err == nil && closeErr != nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to export profile: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), output); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+
+	fmt.Printf("✓ Exported %s profile %s to %s (%v)\n",
+		getString(summary, "type"), args[0], output, formatBytes(summary["size"]))
+	fmt.Printf("  Analyze with: go tool pprof %s\n", output)
+	return nil
+}
+
 func runProfileStop(cmd *cobra.Command, args []string) error {
 	client, err := newDaemonClient()
 	if err != nil {
@@ -14,6 +14,7 @@ import (
 var (
 	cfgFile string
 	verbose bool
+	apiKey  string
 	v       *viper.Viper
 )
 
@@ -44,6 +45,7 @@ func init() {
 	// Global flags
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.forge/config.yaml)")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "enable verbose output")
+	rootCmd.PersistentFlags().StringVar(&apiKey, "api-key", "", "API key for daemon calls that need permissions (default $FORGE_API_KEY)")
 
 	// Add subcommands
 	rootCmd.AddCommand(versionCmd)
@@ -52,6 +54,7 @@ func init() {
 	rootCmd.AddCommand(stopCmd)
 	rootCmd.AddCommand(statusCmd)
 	rootCmd.AddCommand(taskCmd)
+	rootCmd.AddCommand(scheduleCmd)
 	rootCmd.AddCommand(metricCmd)
 	rootCmd.AddCommand(pluginCmd)
 	rootCmd.AddCommand(aiCmd)
@@ -62,9 +65,14 @@ func init() {
 	rootCmd.AddCommand(logCmd)
 	rootCmd.AddCommand(profileCmd)
 	rootCmd.AddCommand(userCmd)
+	rootCmd.AddCommand(loginCmd)
+	rootCmd.AddCommand(logoutCmd)
+	rootCmd.AddCommand(whoamiCmd)
+	rootCmd.AddCommand(secretCmd)
 	rootCmd.AddCommand(healthCmd)
 	rootCmd.AddCommand(backupCmd)
 	rootCmd.AddCommand(cloudCmd)
+	rootCmd.AddCommand(configCmd)
 }
 
 // initializeConfig reads in config file and ENV variables if set.
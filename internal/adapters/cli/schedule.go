@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Inspect scheduled jobs",
+	Long:  `View recurring daemon jobs, deferred tasks, and their next fire times.`,
+}
+
+var scheduleListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List upcoming scheduled runs",
+	Long:  `List scheduled runs ordered by next fire time, with the status of the last run.`,
+	RunE:  runScheduleList,
+}
+
+var (
+	scheduleNoTasks bool
+	scheduleJSON    bool
+)
+
+func init() {
+	scheduleCmd.AddCommand(scheduleListCmd)
+
+	scheduleListCmd.Flags().BoolVar(&scheduleNoTasks, "no-tasks", false, "Hide deferred one-off tasks")
+	scheduleListCmd.Flags().BoolVar(&scheduleJSON, "json", false, "Output as JSON")
+}
+
+func runScheduleList(cmd *cobra.Command, args []string) error {
+	client, err := newDaemonClient()
+	if err != nil {
+		return fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+	defer client.Close()
+
+	result, err := client.Call(cmd.Context(), "schedule.list", map[string]interface{}{
+		"include_tasks": !scheduleNoTasks,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list schedules: %w", err)
+	}
+
+	if scheduleJSON {
+		out, _ := json.MarshalIndent(result, "", "  ")
+		fmt.Println(string(out))
+		return nil
+	}
+
+	resMap, _ := result.(map[string]interface{})
+	schedules, _ := resMap["schedules"].([]interface{})
+
+	fmt.Println("Name                           | Kind     | Spec         | Next Run             | Last Status")
+	fmt.Println("-------------------------------|----------|--------------|----------------------|------------")
+
+	if len(schedules) == 0 {
+		fmt.Println("(no scheduled runs)")
+		return nil
+	}
+
+	for _, item := range schedules {
+		sched, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := sched["name"].(string)
+		kind, _ := sched["kind"].(string)
+		spec, _ := sched["spec"].(string)
+		status, _ := sched["last_status"].(string)
+
+		nextRun, _ := sched["next_run"].(string)
+		if t, err := time.Parse(time.RFC3339, nextRun); err == nil {
+			nextRun = t.Local().Format("2006-01-02 15:04:05")
+		}
+
+		if len(name) > 30 {
+			name = name[:27] + "..."
+		}
+		if len(spec) > 12 {
+			spec = spec[:9] + "..."
+		}
+
+		fmt.Printf("%-30s | %-8s | %-12s | %-20s | %s\n", name, kind, spec, nextRun, status)
+		if lastErr, ok := sched["last_error"].(string); ok && lastErr != "" {
+			fmt.Printf("  └─ last error: %s\n", lastErr)
+		}
+	}
+
+	return nil
+}
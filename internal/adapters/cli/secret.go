@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/forge-platform/forge/internal/adapters/wasm"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var secretCmd = &cobra.Command{
+	Use:   "secret",
+	Short: "Manage secrets available to plugins",
+	Long: `Secrets are stored encrypted in ~/.forge/plugins, apart from plugin
+configuration, and are read by plugins with sdk.GetSecret. Each secret is
+named <plugin>/<key> and only the named plugin can read it, as <key>.
+
+The encryption key is generated in ~/.forge/plugins/secrets.key unless
+FORGE_SECRETS_KEY holds a base64 encoded 32 byte key, which keeps the key
+apart from the secrets it protects.`,
+}
+
+var secretSetCmd = &cobra.Command{
+	Use:   "set <plugin>/<key>",
+	Short: "Set a plugin secret",
+	Long: `Set a plugin secret. The value is prompted for without echo, or read
+from standard input when it is not a terminal, so it never appears in the
+shell history.`,
+	Example: `  forge secret set kubernetes-monitor/kubernetes_token
+  cat token.txt | forge secret set kubernetes-monitor/kubernetes_token`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSecretSet,
+}
+
+var secretListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List plugin secret names",
+	RunE:  runSecretList,
+}
+
+var secretDeleteCmd = &cobra.Command{
+	Use:   "delete <plugin>/<key>",
+	Short: "Delete a plugin secret",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runSecretDelete,
+}
+
+func init() {
+	secretCmd.AddCommand(secretSetCmd)
+	secretCmd.AddCommand(secretListCmd)
+	secretCmd.AddCommand(secretDeleteCmd)
+}
+
+func runSecretSet(cmd *cobra.Command, args []string) error {
+	if err := wasm.ValidateSecretName(args[0]); err != nil {
+		return err
+	}
+	store, err := wasm.OpenSecretStore(wasm.DefaultSecretsDir())
+	if err != nil {
+		return err
+	}
+
+	var value string
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		fmt.Printf("Value for %s: ", args[0])
+		data, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Println()
+		if err != nil {
+			return fmt.Errorf("failed to read secret: %w", err)
+		}
+		value = string(data)
+	} else {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read secret: %w", err)
+		}
+		value = strings.TrimRight(string(data), "\r\n")
+	}
+	if value == "" {
+		return fmt.Errorf("secret value is required")
+	}
+
+	if err := store.Set(args[0], value); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Secret %s set\n", args[0])
+	return nil
+}
+
+func runSecretList(cmd *cobra.Command, args []string) error {
+	store, err := wasm.OpenSecretStore(wasm.DefaultSecretsDir())
+	if err != nil {
+		return err
+	}
+	keys, err := store.Keys()
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		fmt.Println("No secrets set")
+		return nil
+	}
+	for _, key := range keys {
+		fmt.Println(key)
+	}
+	return nil
+}
+
+func runSecretDelete(cmd *cobra.Command, args []string) error {
+	store, err := wasm.OpenSecretStore(wasm.DefaultSecretsDir())
+	if err != nil {
+		return err
+	}
+	deleted, err := store.Delete(args[0])
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("secret %s not found", args[0])
+	}
+	fmt.Printf("✓ Secret %s deleted\n", args[0])
+	return nil
+}
@@ -48,6 +48,7 @@ var (
 	taskFilterStatus string
 	taskFilterType   string
 	taskLimit        int
+	taskOffset       int
 	taskPayload      string
 	taskPriority     int
 )
@@ -62,6 +63,7 @@ func init() {
 	taskListCmd.Flags().StringVar(&taskFilterStatus, "status", "", "Filter by status (PENDING, RUNNING, COMPLETED, FAILED, DEAD)")
 	taskListCmd.Flags().StringVar(&taskFilterType, "type", "", "Filter by task type")
 	taskListCmd.Flags().IntVar(&taskLimit, "limit", 20, "Maximum number of tasks to show")
+	taskListCmd.Flags().IntVar(&taskOffset, "offset", 0, "Number of tasks to skip")
 
 	// Create flags
 	taskCreateCmd.Flags().StringVar(&taskPayload, "payload", "{}", "Task payload as JSON")
@@ -85,6 +87,9 @@ func runTaskList(cmd *cobra.Command, args []string) error {
 	if taskLimit > 0 {
 		params["limit"] = taskLimit
 	}
+	if taskOffset > 0 {
+		params["offset"] = taskOffset
+	}
 
 	result, err := client.Call(cmd.Context(), "task.list", params)
 	if err != nil {
@@ -104,8 +109,8 @@ func runTaskList(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	fmt.Println("ID                                   | Type           | Status    | Created")
-	fmt.Println("-------------------------------------|----------------|-----------|--------------------")
+	fmt.Println("ID                                   | Type           | Status    | Priority | Created")
+	fmt.Println("-------------------------------------|----------------|-----------|----------|--------------------")
 	
 	if len(tasks) == 0 {
 		fmt.Println("(no tasks found)")
@@ -120,9 +125,10 @@ func runTaskList(cmd *cobra.Command, args []string) error {
 		id, _ := t["id"].(string)
 		tType, _ := t["type"].(string)
 		status, _ := t["status"].(string)
+		priority, _ := t["priority"].(float64)
 		createdStr, _ := t["created_at"].(string)
 		
-		fmt.Printf("%-36s | %-14s | %-9s | %s\n", id, tType, status, createdStr)
+		fmt.Printf("%-36s | %-14s | %-9s | %8d | %s\n", id, tType, status, int(priority), createdStr)
 	}
 	return nil
 }
@@ -142,8 +148,9 @@ func runTaskCreate(cmd *cobra.Command, args []string) error {
 	defer client.Close()
 
 	params := map[string]interface{}{
-		"type":    taskType,
-		"payload": payload,
+		"type":     taskType,
+		"payload":  payload,
+		"priority": taskPriority,
 	}
 
 	result, err := client.Call(cmd.Context(), "task.create", params)
@@ -191,6 +198,8 @@ func runTaskStatus(cmd *cobra.Command, args []string) error {
 	fmt.Printf("Task: %s\n", taskID)
 	fmt.Printf("Type: %v\n", resMap["type"])
 	fmt.Printf("Status: %v\n", resMap["status"])
+	fmt.Printf("Priority: %v\n", resMap["priority"])
+	fmt.Printf("Retries: %v/%v\n", resMap["retry_count"], resMap["max_retries"])
 	fmt.Printf("Created: %v\n", resMap["created_at"])
 	fmt.Printf("Updated: %v\n", resMap["updated_at"])
 	
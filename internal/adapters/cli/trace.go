@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"text/tabwriter"
 	"time"
 
@@ -14,17 +15,54 @@ func init() {
 	rootCmd.AddCommand(traceCmd)
 	traceCmd.AddCommand(traceListCmd)
 	traceCmd.AddCommand(traceGetCmd)
+	traceCmd.AddCommand(traceShowCmd)
 	traceCmd.AddCommand(traceSpansCmd)
+	traceCmd.AddCommand(traceSearchCmd)
+	traceCmd.AddCommand(traceSlowestCmd)
 	traceCmd.AddCommand(traceServiceMapCmd)
+	traceCmd.AddCommand(traceMapCmd)
 	traceCmd.AddCommand(traceStatsCmd)
+	traceCmd.AddCommand(traceConfigCmd)
 
 	// Flags
 	traceListCmd.Flags().StringP("service", "s", "", "filter by service name")
 	traceListCmd.Flags().StringP("status", "", "", "filter by status (ok, error)")
 	traceListCmd.Flags().DurationP("since", "", 24*time.Hour, "show traces since duration ago")
 	traceListCmd.Flags().IntP("limit", "n", 20, "limit number of results")
+	traceListCmd.Flags().Duration("min-duration", 0, "only traces at least this long")
+	traceListCmd.Flags().Duration("max-duration", 0, "only traces at most this long")
+	traceListCmd.Flags().StringArray("attr", nil, "span attribute some span must have: key=value, or key for any value (repeatable)")
+
+	traceShowCmd.Flags().Bool("waterfall", false, "show spans as a timed tree (--verbose adds attributes)")
+
+	traceSearchCmd.Flags().StringP("service", "s", "", "filter by service name")
+	traceSearchCmd.Flags().String("name", "", "filter by span name")
+	traceSearchCmd.Flags().String("kind", "", "filter by span kind (internal, server, client, producer, consumer)")
+	traceSearchCmd.Flags().String("status", "", "filter by status (ok, error)")
+	traceSearchCmd.Flags().Duration("min-duration", 0, "only spans at least this long")
+	traceSearchCmd.Flags().Duration("max-duration", 0, "only spans at most this long")
+	traceSearchCmd.Flags().StringToString("attr", nil, "required attributes (key=value,key2=value2)")
+	traceSearchCmd.Flags().DurationP("since", "", 24*time.Hour, "search spans started since duration ago")
+	traceSearchCmd.Flags().IntP("limit", "n", 50, "limit number of results")
+
+	traceSlowestCmd.Flags().StringP("service", "s", "", "filter by service name")
+	traceSlowestCmd.Flags().String("name", "", "filter by operation name")
+	traceSlowestCmd.Flags().DurationP("since", "", time.Hour, "consider traces started since duration ago")
+	traceSlowestCmd.Flags().IntP("limit", "n", 10, "number of traces to show")
 
 	traceServiceMapCmd.Flags().DurationP("since", "", 24*time.Hour, "time range for service map")
+
+	traceMapCmd.Flags().Duration("since", 24*time.Hour, "time range for the service graph")
+	traceMapCmd.Flags().String("format", "text", "output format: text or dot")
+
+	traceStatsCmd.Flags().Duration("since", time.Hour, "time range for stored and estimated trace counts")
+
+	traceConfigCmd.Flags().Float64("sample-rate", 1, "fraction of ordinary traces kept, 0 to 1")
+	traceConfigCmd.Flags().Bool("keep-errors", true, "always keep traces with an error span")
+	traceConfigCmd.Flags().String("latency-threshold", "", "always keep traces at least this long (0 disables)")
+	traceConfigCmd.Flags().String("decision-wait", "", "longest a trace waits for its root span before it is decided")
+	traceConfigCmd.Flags().String("retention", "", "delete traces older than this, e.g. 14d (0 keeps them)")
+	traceConfigCmd.Flags().String("retention-interval", "", "how often old traces are deleted")
 }
 
 var traceCmd = &cobra.Command{
@@ -36,7 +74,11 @@ var traceCmd = &cobra.Command{
 var traceListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List traces",
-	RunE:  runTraceList,
+	Long: `List traces, newest first. Traces can be narrowed by duration and by the
+attributes of their spans, e.g. checkout requests slower than two seconds:
+
+  forge trace list --min-duration 2s --attr http.route=/checkout`,
+	RunE: runTraceList,
 }
 
 var traceGetCmd = &cobra.Command{
@@ -46,6 +88,18 @@ var traceGetCmd = &cobra.Command{
 	RunE:  runTraceGet,
 }
 
+var traceShowCmd = &cobra.Command{
+	Use:   "show <trace-id>",
+	Short: "Show a trace",
+	Long: `Show a trace's summary. With --waterfall, its spans are drawn as a tree
+with a bar per span showing when it ran relative to the whole trace, which
+makes the critical path and slow children easy to spot:
+
+  forge trace show 4bf92f3577b34da6 --waterfall --verbose`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTraceShow,
+}
+
 var traceSpansCmd = &cobra.Command{
 	Use:   "spans <trace-id>",
 	Short: "List spans in a trace",
@@ -53,18 +107,63 @@ var traceSpansCmd = &cobra.Command{
 	RunE:  runTraceSpans,
 }
 
+var traceSearchCmd = &cobra.Command{
+	Use:   "search",
+	Short: "Search spans across all traces",
+	Long: `Search spans across all traces by service, name, kind, status, duration
+and attributes, e.g. all database spans slower than 500ms:
+
+  forge trace search --attr db.system=postgres --min-duration 500ms`,
+	RunE: runTraceSearch,
+}
+
+var traceSlowestCmd = &cobra.Command{
+	Use:   "slowest",
+	Short: "Show the slowest traces",
+	Long: `Show the slowest traces of a service or operation over a time window,
+longest first, e.g. the ten slowest checkout requests of the last day:
+
+  forge trace slowest --service checkout --name "POST /orders" --since 24h`,
+	RunE: runTraceSlowest,
+}
+
 var traceServiceMapCmd = &cobra.Command{
 	Use:   "service-map",
 	Short: "Show service dependency map",
 	RunE:  runTraceServiceMap,
 }
 
+var traceMapCmd = &cobra.Command{
+	Use:   "map",
+	Short: "Show the service graph with per-edge call stats",
+	Long: `Show which services call which, with the call count, error rate and
+p50/p95/p99 latency of each edge. Calls to untraced peers, named by a client
+span's peer.service or server.address attribute, appear as external nodes.
+
+With --format dot the graph is written as Graphviz, e.g.:
+
+  forge trace map --since 1h --format dot | dot -Tsvg > services.svg`,
+	RunE: runTraceMap,
+}
+
 var traceStatsCmd = &cobra.Command{
 	Use:   "stats",
 	Short: "Show tracing statistics",
 	RunE:  runTraceStats,
 }
 
+var traceConfigCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show or change trace sampling and retention",
+	Long: `Show the daemon's trace sampling and retention settings, or change them
+without a restart. Only the flags given are changed, and the change lasts
+until the daemon restarts; set the traces.* keys in the config file to keep
+it. For example, keep 10% of ordinary traces but every failed or slow one:
+
+  forge trace config --sample-rate 0.1 --keep-errors --latency-threshold 2s`,
+	RunE: runTraceConfig,
+}
+
 func runTraceList(cmd *cobra.Command, args []string) error {
 	client, err := newDaemonClient()
 	if err != nil {
@@ -76,6 +175,9 @@ func runTraceList(cmd *cobra.Command, args []string) error {
 	status, _ := cmd.Flags().GetString("status")
 	since, _ := cmd.Flags().GetDuration("since")
 	limit, _ := cmd.Flags().GetInt("limit")
+	minDuration, _ := cmd.Flags().GetDuration("min-duration")
+	maxDuration, _ := cmd.Flags().GetDuration("max-duration")
+	attrs, _ := cmd.Flags().GetStringArray("attr")
 
 	params := map[string]interface{}{
 		"service_name": service,
@@ -83,6 +185,19 @@ func runTraceList(cmd *cobra.Command, args []string) error {
 		"start_time":   time.Now().Add(-since).Format(time.RFC3339),
 		"limit":        limit,
 	}
+	if minDuration > 0 {
+		params["min_duration"] = minDuration.String()
+	}
+	if maxDuration > 0 {
+		params["max_duration"] = maxDuration.String()
+	}
+	equals, exists := parseAttributeMatchers(attrs)
+	if len(equals) > 0 {
+		params["attributes"] = equals
+	}
+	if len(exists) > 0 {
+		params["has_attributes"] = exists
+	}
 
 	ctx := context.Background()
 	resp, err := client.Call(ctx, "trace.list", params)
@@ -116,6 +231,57 @@ func runTraceList(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func runTraceSlowest(cmd *cobra.Command, args []string) error {
+	client, err := newDaemonClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	service, _ := cmd.Flags().GetString("service")
+	name, _ := cmd.Flags().GetString("name")
+	since, _ := cmd.Flags().GetDuration("since")
+	limit, _ := cmd.Flags().GetInt("limit")
+
+	params := map[string]interface{}{
+		"service_name": service,
+		"name":         name,
+		"since":        since.String(),
+		"limit":        limit,
+	}
+
+	ctx := context.Background()
+	resp, err := client.Call(ctx, "trace.slowest", params)
+	if err != nil {
+		return fmt.Errorf("failed to get slowest traces: %w", err)
+	}
+
+	traces, ok := resp.(map[string]interface{})["traces"].([]interface{})
+	if !ok || len(traces) == 0 {
+		fmt.Println("No traces found.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DURATION\tTRACE ID\tSERVICE\tNAME\tSPANS\tSTATUS\tSTARTED")
+	fmt.Fprintln(w, "--------\t--------\t-------\t----\t-----\t------\t-------")
+
+	for _, t := range traces {
+		trace := t.(map[string]interface{})
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%v\t%s\t%s\n",
+			getString(trace, "duration"),
+			traceTruncateID(getString(trace, "trace_id")),
+			getString(trace, "service_name"),
+			truncateString(getString(trace, "name"), 30),
+			trace["span_count"],
+			getStatusIcon(getString(trace, "status")),
+			traceFormatTime(getString(trace, "start_time")),
+		)
+	}
+	w.Flush()
+	return nil
+}
+
 func runTraceGet(cmd *cobra.Command, args []string) error {
 	client, err := newDaemonClient()
 	if err != nil {
@@ -134,6 +300,58 @@ func runTraceGet(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("trace not found")
 	}
 
+	printTraceSummary(trace)
+	return nil
+}
+
+func runTraceShow(cmd *cobra.Command, args []string) error {
+	client, err := newDaemonClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	waterfall, _ := cmd.Flags().GetBool("waterfall")
+
+	ctx := context.Background()
+	resp, err := client.Call(ctx, "trace.get", map[string]interface{}{"trace_id": args[0]})
+	if err != nil {
+		return fmt.Errorf("failed to get trace: %w", err)
+	}
+	trace, ok := resp.(map[string]interface{})["trace"].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("trace not found")
+	}
+	printTraceSummary(trace)
+	if !waterfall {
+		return nil
+	}
+
+	resp, err = client.Call(ctx, "trace.spans", map[string]interface{}{"trace_id": args[0]})
+	if err != nil {
+		return fmt.Errorf("failed to get spans: %w", err)
+	}
+	raw, ok := resp.(map[string]interface{})["spans"].([]interface{})
+	if !ok || len(raw) == 0 {
+		fmt.Println("\nNo spans found.")
+		return nil
+	}
+	spans, err := parseWaterfallSpans(raw)
+	if err != nil {
+		return err
+	}
+
+	roots, warnings := buildWaterfall(spans)
+	fmt.Println()
+	renderWaterfall(os.Stdout, roots, verbose)
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	return nil
+}
+
+// printTraceSummary prints a trace as returned by trace.get.
+func printTraceSummary(trace map[string]interface{}) {
 	fmt.Printf("Trace ID:     %s\n", getString(trace, "trace_id"))
 	fmt.Printf("Service:      %s\n", getString(trace, "service_name"))
 	fmt.Printf("Name:         %s\n", getString(trace, "name"))
@@ -142,8 +360,6 @@ func runTraceGet(cmd *cobra.Command, args []string) error {
 	fmt.Printf("Span Count:   %v\n", trace["span_count"])
 	fmt.Printf("Error Count:  %v\n", trace["error_count"])
 	fmt.Printf("Started At:   %s\n", getString(trace, "start_time"))
-
-	return nil
 }
 
 func runTraceSpans(cmd *cobra.Command, args []string) error {
@@ -184,6 +400,74 @@ func runTraceSpans(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func runTraceSearch(cmd *cobra.Command, args []string) error {
+	client, err := newDaemonClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	service, _ := cmd.Flags().GetString("service")
+	name, _ := cmd.Flags().GetString("name")
+	kind, _ := cmd.Flags().GetString("kind")
+	status, _ := cmd.Flags().GetString("status")
+	minDuration, _ := cmd.Flags().GetDuration("min-duration")
+	maxDuration, _ := cmd.Flags().GetDuration("max-duration")
+	attrs, _ := cmd.Flags().GetStringToString("attr")
+	since, _ := cmd.Flags().GetDuration("since")
+	limit, _ := cmd.Flags().GetInt("limit")
+
+	params := map[string]interface{}{
+		"service_name": service,
+		"name":         name,
+		"kind":         kind,
+		"status":       status,
+		"start_time":   time.Now().Add(-since).Format(time.RFC3339),
+		"limit":        limit,
+	}
+	if minDuration > 0 {
+		params["min_duration"] = minDuration.String()
+	}
+	if maxDuration > 0 {
+		params["max_duration"] = maxDuration.String()
+	}
+	if len(attrs) > 0 {
+		params["attributes"] = attrs
+	}
+
+	ctx := context.Background()
+	resp, err := client.Call(ctx, "span.search", params)
+	if err != nil {
+		return fmt.Errorf("failed to search spans: %w", err)
+	}
+
+	spans, ok := resp.(map[string]interface{})["spans"].([]interface{})
+	if !ok || len(spans) == 0 {
+		fmt.Println("No spans found.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TRACE ID\tSPAN ID\tNAME\tKIND\tDURATION\tSTATUS\tSERVICE\tSTARTED")
+	fmt.Fprintln(w, "--------\t-------\t----\t----\t--------\t------\t-------\t-------")
+
+	for _, s := range spans {
+		span := s.(map[string]interface{})
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			traceTruncateID(getString(span, "trace_id")),
+			traceTruncateID(getString(span, "span_id")),
+			truncateString(getString(span, "name"), 30),
+			getString(span, "kind"),
+			getString(span, "duration"),
+			getStatusIcon(getString(span, "status")),
+			getString(span, "service_name"),
+			traceFormatTime(getString(span, "start_time")),
+		)
+	}
+	w.Flush()
+	return nil
+}
+
 func runTraceServiceMap(cmd *cobra.Command, args []string) error {
 	client, err := newDaemonClient()
 	if err != nil {
@@ -210,8 +494,8 @@ func runTraceServiceMap(cmd *cobra.Command, args []string) error {
 	}
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "SERVICE\tSPAN COUNT\tERROR COUNT\tAVG DURATION\tDEPENDENCIES")
-	fmt.Fprintln(w, "-------\t----------\t-----------\t------------\t------------")
+	fmt.Fprintln(w, "SERVICE\tSPAN COUNT\tERROR COUNT\tAVG DURATION\tP50\tP95\tP99\tDEPENDENCIES")
+	fmt.Fprintln(w, "-------\t----------\t-----------\t------------\t---\t---\t---\t------------")
 
 	for _, n := range nodes {
 		node := n.(map[string]interface{})
@@ -227,15 +511,42 @@ func runTraceServiceMap(cmd *cobra.Command, args []string) error {
 		if deps == "" {
 			deps = "-"
 		}
-		fmt.Fprintf(w, "%s\t%v\t%v\t%.2fms\t%s\n",
+		fmt.Fprintf(w, "%s\t%v\t%v\t%.2fms\t%.2fms\t%.2fms\t%.2fms\t%s\n",
 			getString(node, "service_name"),
 			node["span_count"],
 			node["error_count"],
 			node["avg_duration_ms"],
+			node["p50_duration_ms"],
+			node["p95_duration_ms"],
+			node["p99_duration_ms"],
 			deps,
 		)
 	}
 	w.Flush()
+
+	edges, _ := resp.(map[string]interface{})["edges"].([]interface{})
+	if len(edges) == 0 {
+		return nil
+	}
+	fmt.Println()
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SOURCE\tTARGET\tCALLS\tERRORS\tERROR RATE\tP50\tP95\tP99")
+	fmt.Fprintln(w, "------\t------\t-----\t------\t----------\t---\t---\t---")
+	for _, e := range edges {
+		edge := e.(map[string]interface{})
+		rate, _ := edge["error_rate"].(float64)
+		fmt.Fprintf(w, "%s\t%s\t%v\t%v\t%.1f%%\t%s\t%s\t%s\n",
+			getString(edge, "source"),
+			getString(edge, "target"),
+			edge["call_count"],
+			edge["error_count"],
+			rate*100,
+			formatMillis(edge["p50_duration_ms"]),
+			formatMillis(edge["p95_duration_ms"]),
+			formatMillis(edge["p99_duration_ms"]),
+		)
+	}
+	w.Flush()
 	return nil
 }
 
@@ -246,18 +557,110 @@ func runTraceStats(cmd *cobra.Command, args []string) error {
 	}
 	defer client.Close()
 
+	since, _ := cmd.Flags().GetDuration("since")
+	params := map[string]interface{}{
+		"start_time": time.Now().Add(-since).Format(time.RFC3339),
+	}
+
 	ctx := context.Background()
-	resp, err := client.Call(ctx, "trace.stats", nil)
+	resp, err := client.Call(ctx, "trace.stats", params)
 	if err != nil {
 		return fmt.Errorf("failed to get trace stats: %w", err)
 	}
 
+	stats, _ := resp.(map[string]interface{})
 	fmt.Println("=== Trace Statistics ===")
-	fmt.Printf("Active Traces: %v\n", resp.(map[string]interface{})["active_traces"])
+	fmt.Printf("Active Traces: %v\n", stats["active_traces"])
+	if stored, ok := stats["stored_traces"]; ok {
+		fmt.Printf("Stored (%s): %v\n", since, stored)
+	}
+	if sampling, ok := stats["sampling"].(map[string]interface{}); ok && sampling["enabled"] == true {
+		// Stored counts are only part of the traffic; show the estimate
+		estimate := "unknown at sample rate 0"
+		if est, ok := stats["estimated_total_traces"]; ok && est != nil {
+			estimate = fmt.Sprint(est)
+		}
+		fmt.Printf("Estimated Total: %s\n", estimate)
+		fmt.Printf("Sampling:      %v of ordinary traces (%.3g kept overall)\n", sampling["sample_rate"], sampling["effective_rate"])
+		fmt.Printf("  Kept:        %v\n", sampling["kept"])
+		fmt.Printf("  Dropped:     %v\n", sampling["dropped"])
+		fmt.Printf("  Pending:     %v traces (%v spans)\n", sampling["pending_traces"], sampling["pending_spans"])
+	}
+	return nil
+}
+
+func runTraceConfig(cmd *cobra.Command, args []string) error {
+	params, err := traceConfigParams(cmd)
+	if err != nil {
+		return err
+	}
+
+	client, err := newDaemonClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	resp, err := client.Call(context.Background(), "trace.config.update", params)
+	if err != nil {
+		return fmt.Errorf("failed to update trace config: %w", err)
+	}
+	cfg, ok := resp.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("unexpected response format")
+	}
+
+	if len(params) > 0 {
+		fmt.Println("✓ Trace config updated")
+	}
+	fmt.Printf("Sample rate:        %v\n", cfg["sample_rate"])
+	fmt.Printf("Keep errors:        %v\n", cfg["keep_errors"])
+	fmt.Printf("Latency threshold:  %v\n", cfg["latency_threshold"])
+	fmt.Printf("Decision wait:      %v\n", cfg["decision_wait"])
+	fmt.Printf("Retention:          %v\n", cfg["retention"])
+	fmt.Printf("Retention interval: %v\n", cfg["retention_interval"])
 	return nil
 }
 
+// traceConfigParams builds trace.config.update params from the flags that
+// were set. Durations accept days and are sent as Go duration strings.
+func traceConfigParams(cmd *cobra.Command) (map[string]interface{}, error) {
+	params := make(map[string]interface{})
+	if cmd.Flags().Changed("sample-rate") {
+		params["sample_rate"], _ = cmd.Flags().GetFloat64("sample-rate")
+	}
+	if cmd.Flags().Changed("keep-errors") {
+		params["keep_errors"], _ = cmd.Flags().GetBool("keep-errors")
+	}
+	for _, flag := range []string{"latency-threshold", "decision-wait", "retention", "retention-interval"} {
+		if !cmd.Flags().Changed(flag) {
+			continue
+		}
+		value, _ := cmd.Flags().GetString(flag)
+		d, err := parseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --%s: %w", flag, err)
+		}
+		params[strings.ReplaceAll(flag, "-", "_")] = d.String()
+	}
+	return params, nil
+}
+
 // Helper functions for trace CLI
+// parseAttributeMatchers splits --attr values into exact matches (key=value)
+// and keys that only need to be present.
+func parseAttributeMatchers(attrs []string) (equals map[string]string, exists []string) {
+	equals = make(map[string]string)
+	for _, attr := range attrs {
+		if key, value, ok := strings.Cut(attr, "="); ok {
+			equals[key] = value
+		} else {
+			exists = append(exists, attr)
+		}
+	}
+	return equals, exists
+}
+
 func traceTruncateID(id string) string {
 	if len(id) > 12 {
 		return id[:12] + "..."
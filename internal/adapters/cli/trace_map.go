@@ -0,0 +1,168 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func runTraceMap(cmd *cobra.Command, args []string) error {
+	since, _ := cmd.Flags().GetDuration("since")
+	format, _ := cmd.Flags().GetString("format")
+	if format != "text" && format != "dot" {
+		return fmt.Errorf("unsupported format %q (use text or dot)", format)
+	}
+
+	client, err := newDaemonClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	params := map[string]interface{}{
+		"start_time": time.Now().Add(-since).Format(time.RFC3339),
+		"end_time":   time.Now().Format(time.RFC3339),
+	}
+	resp, err := client.Call(context.Background(), "trace.service-map", params)
+	if err != nil {
+		return fmt.Errorf("failed to get service map: %w", err)
+	}
+	serviceMap, ok := resp.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("unexpected response format")
+	}
+
+	if format == "dot" {
+		return writeServiceMapDOT(os.Stdout, serviceMap)
+	}
+	return writeServiceMapText(os.Stdout, serviceMap)
+}
+
+// serviceMapGraph splits a trace.service-map response into its nodes and
+// the edges leaving each node, in response order. A caller whose own spans
+// fall outside the time range gets a node without stats.
+func serviceMapGraph(serviceMap map[string]interface{}) (nodes []map[string]interface{}, edges map[string][]map[string]interface{}) {
+	edges = make(map[string][]map[string]interface{})
+	known := make(map[string]bool)
+	rawNodes, _ := serviceMap["nodes"].([]interface{})
+	for _, n := range rawNodes {
+		if node, ok := n.(map[string]interface{}); ok {
+			nodes = append(nodes, node)
+			known[getString(node, "service_name")] = true
+		}
+	}
+	rawEdges, _ := serviceMap["edges"].([]interface{})
+	for _, e := range rawEdges {
+		edge, ok := e.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		source := getString(edge, "source")
+		if !known[source] {
+			known[source] = true
+			nodes = append(nodes, map[string]interface{}{"service_name": source})
+		}
+		edges[source] = append(edges[source], edge)
+	}
+	return nodes, edges
+}
+
+// formatNodeStats renders a node's span stats, or "" for a node without.
+func formatNodeStats(node map[string]interface{}) string {
+	if _, ok := node["span_count"]; !ok {
+		return ""
+	}
+	return fmt.Sprintf("%v spans, %v errors, p50 %s p95 %s p99 %s",
+		node["span_count"], node["error_count"],
+		formatMillis(node["p50_duration_ms"]), formatMillis(node["p95_duration_ms"]), formatMillis(node["p99_duration_ms"]))
+}
+
+// formatEdgeStats renders an edge's calls, errors and latency percentiles.
+func formatEdgeStats(edge map[string]interface{}) string {
+	rate, _ := edge["error_rate"].(float64)
+	return fmt.Sprintf("%v calls, %v errors (%.1f%%), p50 %s p95 %s p99 %s",
+		edge["call_count"], edge["error_count"], rate*100,
+		formatMillis(edge["p50_duration_ms"]), formatMillis(edge["p95_duration_ms"]), formatMillis(edge["p99_duration_ms"]))
+}
+
+// formatMillis renders a duration in milliseconds from a JSON number.
+func formatMillis(v interface{}) string {
+	ms, _ := v.(float64)
+	return fmt.Sprintf("%.2fms", ms)
+}
+
+// writeServiceMapText writes the service graph as an adjacency list: each
+// service with its span stats, followed by the services it calls.
+func writeServiceMapText(w io.Writer, serviceMap map[string]interface{}) error {
+	nodes, edges := serviceMapGraph(serviceMap)
+	if len(nodes) == 0 {
+		_, err := fmt.Fprintln(w, "No services found in traces.")
+		return err
+	}
+
+	for _, node := range nodes {
+		name := getString(node, "service_name")
+		label := name
+		if node["external"] == true {
+			label += " [external]"
+		}
+		if stats := formatNodeStats(node); stats != "" {
+			label += "  " + stats
+		}
+		fmt.Fprintln(w, label)
+		for _, edge := range edges[name] {
+			fmt.Fprintf(w, "  -> %s  %s\n", getString(edge, "target"), formatEdgeStats(edge))
+		}
+	}
+	return nil
+}
+
+// dotQuote quotes lines as a Graphviz string, one label line each.
+func dotQuote(lines ...string) string {
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", " ")
+	for i, line := range lines {
+		lines[i] = escaper.Replace(line)
+	}
+	return `"` + strings.Join(lines, `\n`) + `"`
+}
+
+// writeServiceMapDOT writes the service graph in Graphviz DOT. External
+// nodes are dashed boxes and edges with errors are red.
+func writeServiceMapDOT(w io.Writer, serviceMap map[string]interface{}) error {
+	nodes, edges := serviceMapGraph(serviceMap)
+
+	var b strings.Builder
+	b.WriteString("digraph services {\n  rankdir=LR;\n  node [shape=ellipse];\n")
+	for _, node := range nodes {
+		name := getString(node, "service_name")
+		attrs := "label=" + dotQuote(name)
+		if _, ok := node["span_count"]; ok {
+			attrs = "label=" + dotQuote(name, fmt.Sprintf("%v spans, %v errors", node["span_count"], node["error_count"]))
+		}
+		if node["external"] == true {
+			attrs += ", shape=box, style=dashed"
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", dotQuote(name), attrs)
+	}
+	for _, node := range nodes {
+		for _, edge := range edges[getString(node, "service_name")] {
+			rate, _ := edge["error_rate"].(float64)
+			attrs := "label=" + dotQuote(
+				fmt.Sprintf("%v calls, %.1f%% errors", edge["call_count"], rate*100),
+				fmt.Sprintf("p50 %s p95 %s p99 %s", formatMillis(edge["p50_duration_ms"]),
+					formatMillis(edge["p95_duration_ms"]), formatMillis(edge["p99_duration_ms"])))
+			if rate > 0 {
+				attrs += ", color=red"
+			}
+			fmt.Fprintf(&b, "  %s -> %s [%s];\n", dotQuote(getString(edge, "source")), dotQuote(getString(edge, "target")), attrs)
+		}
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// testServiceMap is a trace.service-map response as decoded by the client.
+func testServiceMap() map[string]interface{} {
+	node := func(name string, spans float64, external bool) interface{} {
+		return map[string]interface{}{
+			"service_name": name, "span_count": spans, "error_count": 0.0, "external": external,
+			"p50_duration_ms": 5.0, "p95_duration_ms": 9.0, "p99_duration_ms": 12.0,
+		}
+	}
+	edge := func(source, target string, calls, errors float64) interface{} {
+		return map[string]interface{}{
+			"source": source, "target": target, "call_count": calls, "error_count": errors,
+			"error_rate": errors / calls, "p50_duration_ms": 4.0, "p95_duration_ms": 8.5, "p99_duration_ms": 20.0,
+		}
+	}
+	return map[string]interface{}{
+		"nodes": []interface{}{node("api", 40, false), node("db", 20, false), node(`pay "v2"`, 5, true)},
+		"edges": []interface{}{
+			edge("api", "db", 20, 5),
+			edge("api", `pay "v2"`, 5, 0),
+			edge("cron", "api", 2, 0), // cron's own spans are outside the range
+		},
+	}
+}
+
+func TestWriteServiceMapText(t *testing.T) {
+	var out bytes.Buffer
+	if err := writeServiceMapText(&out, testServiceMap()); err != nil {
+		t.Fatalf("writeServiceMapText failed: %v", err)
+	}
+	want := `api  40 spans, 0 errors, p50 5.00ms p95 9.00ms p99 12.00ms
+  -> db  20 calls, 5 errors (25.0%), p50 4.00ms p95 8.50ms p99 20.00ms
+  -> pay "v2"  5 calls, 0 errors (0.0%), p50 4.00ms p95 8.50ms p99 20.00ms
+db  20 spans, 0 errors, p50 5.00ms p95 9.00ms p99 12.00ms
+pay "v2" [external]  5 spans, 0 errors, p50 5.00ms p95 9.00ms p99 12.00ms
+cron
+  -> api  2 calls, 0 errors (0.0%), p50 4.00ms p95 8.50ms p99 20.00ms
+`
+	if out.String() != want {
+		t.Errorf("unexpected adjacency list:\n%s\nwant:\n%s", out.String(), want)
+	}
+
+	out.Reset()
+	if err := writeServiceMapText(&out, map[string]interface{}{}); err != nil || !strings.Contains(out.String(), "No services") {
+		t.Errorf("expected a message for an empty map, got %q (%v)", out.String(), err)
+	}
+}
+
+func TestWriteServiceMapDOT(t *testing.T) {
+	var out bytes.Buffer
+	if err := writeServiceMapDOT(&out, testServiceMap()); err != nil {
+		t.Fatalf("writeServiceMapDOT failed: %v", err)
+	}
+	dot := out.String()
+
+	if !strings.HasPrefix(dot, "digraph services {\n") || !strings.HasSuffix(dot, "}\n") {
+		t.Fatalf("expected a digraph, got:\n%s", dot)
+	}
+	for _, want := range []string{
+		`"api" [label="api\n40 spans, 0 errors"];`,
+		`"pay \"v2\"" [label="pay \"v2\"\n5 spans, 0 errors", shape=box, style=dashed];`,
+		`"cron" [label="cron"];`,
+		`"api" -> "db" [label="20 calls, 25.0% errors\np50 4.00ms p95 8.50ms p99 20.00ms", color=red];`,
+		`"api" -> "pay \"v2\"" [label="5 calls, 0.0% errors\np50 4.00ms p95 8.50ms p99 20.00ms"];`,
+		`"cron" -> "api"`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("expected DOT output to contain %s, got:\n%s", want, dot)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Waterfall rendering defaults.
+const (
+	waterfallBarWidth   = 40
+	waterfallAttributes = 3
+	missingParentName   = "(missing parent)"
+)
+
+// waterfallSpan is a span from trace.spans placed in the trace's tree.
+type waterfallSpan struct {
+	SpanID       string
+	ParentSpanID string
+	Name         string
+	ServiceName  string
+	Status       string
+	Start        time.Time
+	Duration     time.Duration
+	Attributes   map[string]string
+
+	// Synthetic marks a placeholder for a parent absent from the trace.
+	Synthetic bool
+	Children  []*waterfallSpan
+}
+
+// End returns when the span finished.
+func (s *waterfallSpan) End() time.Time {
+	return s.Start.Add(s.Duration)
+}
+
+// parseWaterfallSpans converts the spans of a trace.spans response.
+func parseWaterfallSpans(raw []interface{}) ([]*waterfallSpan, error) {
+	spans := make([]*waterfallSpan, 0, len(raw))
+	for _, r := range raw {
+		m, ok := r.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unexpected span in response: %v", r)
+		}
+		span := &waterfallSpan{
+			SpanID:       getString(m, "span_id"),
+			ParentSpanID: getString(m, "parent_span_id"),
+			Name:         getString(m, "name"),
+			ServiceName:  getString(m, "service_name"),
+			Status:       getString(m, "status"),
+			Attributes:   make(map[string]string),
+		}
+		start, err := time.Parse(time.RFC3339Nano, getString(m, "start_time"))
+		if err != nil {
+			return nil, fmt.Errorf("span %s has an invalid start time: %w", span.SpanID, err)
+		}
+		span.Start = start
+		duration, err := time.ParseDuration(getString(m, "duration"))
+		if err != nil {
+			return nil, fmt.Errorf("span %s has an invalid duration: %w", span.SpanID, err)
+		}
+		span.Duration = duration
+		if attrs, ok := m["attributes"].(map[string]interface{}); ok {
+			for k := range attrs {
+				span.Attributes[k] = getString(attrs, k)
+			}
+		}
+		spans = append(spans, span)
+	}
+	return spans, nil
+}
+
+// buildWaterfall arranges spans into trees by their parent span ID and
+// returns the roots in start order. Spans whose parent is not in the trace
+// are grouped under a synthetic "(missing parent)" node per absent parent
+// rather than dropped. Children that start before or end after their
+// parent are reported as warnings, as their clocks cannot both be right.
+func buildWaterfall(spans []*waterfallSpan) (roots []*waterfallSpan, warnings []string) {
+	byID := make(map[string]*waterfallSpan, len(spans))
+	for _, span := range spans {
+		span.Children = nil
+		byID[span.SpanID] = span
+	}
+
+	missing := make(map[string]*waterfallSpan)
+	for _, span := range spans {
+		if span.ParentSpanID == "" || span.ParentSpanID == span.SpanID {
+			roots = append(roots, span)
+			continue
+		}
+		parent, ok := byID[span.ParentSpanID]
+		if !ok {
+			parent, ok = missing[span.ParentSpanID]
+			if !ok {
+				parent = &waterfallSpan{
+					SpanID:    span.ParentSpanID,
+					Name:      missingParentName,
+					Start:     span.Start,
+					Synthetic: true,
+				}
+				missing[span.ParentSpanID] = parent
+				roots = append(roots, parent)
+			}
+			// The placeholder covers the spans it adopts
+			end := parent.End()
+			if span.Start.Before(parent.Start) {
+				parent.Start = span.Start
+			}
+			if span.End().After(end) {
+				end = span.End()
+			}
+			parent.Duration = end.Sub(parent.Start)
+		}
+		parent.Children = append(parent.Children, span)
+	}
+
+	sortWaterfall(roots)
+	for _, span := range spans {
+		parent, ok := byID[span.ParentSpanID]
+		if !ok || span.ParentSpanID == span.SpanID {
+			continue
+		}
+		if span.Start.Before(parent.Start) {
+			warnings = append(warnings, fmt.Sprintf("span %s (%s) starts %s before its parent %s (%s)",
+				span.SpanID, span.Name, parent.Start.Sub(span.Start), parent.SpanID, parent.Name))
+		}
+		if span.End().After(parent.End()) {
+			warnings = append(warnings, fmt.Sprintf("span %s (%s) ends %s after its parent %s (%s)",
+				span.SpanID, span.Name, span.End().Sub(parent.End()), parent.SpanID, parent.Name))
+		}
+	}
+	return roots, warnings
+}
+
+// sortWaterfall orders spans and their descendants by start time.
+func sortWaterfall(spans []*waterfallSpan) {
+	sort.SliceStable(spans, func(i, j int) bool {
+		return spans[i].Start.Before(spans[j].Start)
+	})
+	for _, span := range spans {
+		sortWaterfall(span.Children)
+	}
+}
+
+// renderWaterfall writes the trees as an indented table with a bar per span
+// showing when it ran, scaled to the trace's overall duration. With verbose,
+// up to three attributes are listed under each span.
+func renderWaterfall(out io.Writer, roots []*waterfallSpan, verbose bool) {
+	var traceStart, traceEnd time.Time
+	var walk func([]*waterfallSpan)
+	walk = func(spans []*waterfallSpan) {
+		for _, span := range spans {
+			if traceStart.IsZero() || span.Start.Before(traceStart) {
+				traceStart = span.Start
+			}
+			if span.End().After(traceEnd) {
+				traceEnd = span.End()
+			}
+			walk(span.Children)
+		}
+	}
+	walk(roots)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "SPAN\tWATERFALL (%s)\tDURATION\tSTATUS\n", traceEnd.Sub(traceStart))
+	fmt.Fprintln(w, "----\t---------\t--------\t------")
+
+	var render func(spans []*waterfallSpan, indent string, top bool)
+	render = func(spans []*waterfallSpan, indent string, top bool) {
+		for i, span := range spans {
+			last := i == len(spans)-1
+			branch, continuation := "├─ ", "│  "
+			if last {
+				branch, continuation = "└─ ", "   "
+			}
+			if top {
+				branch, continuation = "", ""
+			}
+
+			label := span.Name
+			if span.ServiceName != "" {
+				label += " [" + span.ServiceName + "]"
+			}
+			status := getStatusIcon(span.Status)
+			if span.Synthetic {
+				label = fmt.Sprintf("%s %s", missingParentName, traceTruncateID(span.SpanID))
+				status = "?"
+			}
+			fmt.Fprintf(w, "%s%s\t|%s|\t%s\t%s\n",
+				indent+branch, label,
+				waterfallBar(span, traceStart, traceEnd.Sub(traceStart), waterfallBarWidth),
+				span.Duration, status)
+
+			if verbose && !span.Synthetic {
+				for _, attr := range topAttributes(span.Attributes, waterfallAttributes) {
+					fmt.Fprintf(w, "%s%s  %s\t\t\t\n", indent+continuation, childGuide(span), attr)
+				}
+			}
+			render(span.Children, indent+continuation, false)
+		}
+	}
+	render(roots, "", true)
+	w.Flush()
+}
+
+// childGuide continues the tree line below a span into its children.
+func childGuide(span *waterfallSpan) string {
+	if len(span.Children) > 0 {
+		return "│"
+	}
+	return " "
+}
+
+// waterfallBar draws a span as a run of blocks positioned within width
+// columns spanning the whole trace. Every span gets at least one block.
+func waterfallBar(span *waterfallSpan, traceStart time.Time, total time.Duration, width int) string {
+	offset, length := 0, width
+	if total > 0 {
+		offset = int(float64(span.Start.Sub(traceStart)) / float64(total) * float64(width))
+		length = int(float64(span.Duration)/float64(total)*float64(width) + 0.5)
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= width {
+		offset = width - 1
+	}
+	if length < 1 {
+		length = 1
+	}
+	if offset+length > width {
+		length = width - offset
+	}
+	return strings.Repeat(" ", offset) + strings.Repeat("█", length) + strings.Repeat(" ", width-offset-length)
+}
+
+// topAttributes returns up to n attributes as key=value, in key order,
+// noting how many more there are.
+func topAttributes(attrs map[string]string, n int) []string {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var out []string
+	for i, k := range keys {
+		if i == n {
+			out[n-1] += fmt.Sprintf(" (+%d more)", len(keys)-n)
+			break
+		}
+		out = append(out, fmt.Sprintf("%s=%s", k, attrs[k]))
+	}
+	return out
+}
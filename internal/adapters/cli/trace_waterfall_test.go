@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+var waterfallEpoch = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// fixtureSpan builds a span as returned by trace.spans, starting offset
+// after waterfallEpoch.
+func fixtureSpan(id, parent, name, status string, offset, duration time.Duration, attrs map[string]interface{}) map[string]interface{} {
+	span := map[string]interface{}{
+		"span_id":      id,
+		"name":         name,
+		"status":       status,
+		"service_name": "checkout",
+		"start_time":   waterfallEpoch.Add(offset).Format(time.RFC3339Nano),
+		"end_time":     waterfallEpoch.Add(offset + duration).Format(time.RFC3339Nano),
+		"duration":     duration.String(),
+	}
+	if parent != "" {
+		span["parent_span_id"] = parent
+	}
+	if attrs != nil {
+		span["attributes"] = attrs
+	}
+	return span
+}
+
+func waterfallFixture(t *testing.T, raw ...interface{}) ([]*waterfallSpan, []string) {
+	t.Helper()
+	spans, err := parseWaterfallSpans(raw)
+	if err != nil {
+		t.Fatalf("parseWaterfallSpans failed: %v", err)
+	}
+	return buildWaterfall(spans)
+}
+
+func TestBuildWaterfall(t *testing.T) {
+	ms := time.Millisecond
+	// Returned out of order, as storage does not promise parents first
+	roots, warnings := waterfallFixture(t,
+		fixtureSpan("c2", "a", "charge card", "error", 60*ms, 40*ms, nil),
+		fixtureSpan("a", "", "POST /orders", "ok", 0, 100*ms, nil),
+		fixtureSpan("c1", "a", "load cart", "ok", 10*ms, 30*ms, nil),
+		fixtureSpan("d1", "c1", "SELECT carts", "ok", 15*ms, 10*ms, nil),
+	)
+	if len(warnings) != 0 {
+		t.Errorf("expected no warnings, got %v", warnings)
+	}
+	if len(roots) != 1 || roots[0].SpanID != "a" {
+		t.Fatalf("expected a single root a, got %d roots", len(roots))
+	}
+	children := roots[0].Children
+	if len(children) != 2 || children[0].SpanID != "c1" || children[1].SpanID != "c2" {
+		t.Fatalf("expected children ordered by start, got %+v", children)
+	}
+	if len(children[0].Children) != 1 || children[0].Children[0].SpanID != "d1" {
+		t.Errorf("expected d1 under c1, got %+v", children[0].Children)
+	}
+}
+
+func TestBuildWaterfall_MissingParent(t *testing.T) {
+	ms := time.Millisecond
+	roots, _ := waterfallFixture(t,
+		fixtureSpan("a", "", "POST /orders", "ok", 0, 100*ms, nil),
+		fixtureSpan("o1", "gone", "send email", "ok", 120*ms, 20*ms, nil),
+		fixtureSpan("o2", "gone", "render email", "ok", 110*ms, 5*ms, nil),
+	)
+	if len(roots) != 2 {
+		t.Fatalf("expected the root and a placeholder, got %d roots", len(roots))
+	}
+	placeholder := roots[1]
+	if !placeholder.Synthetic || placeholder.SpanID != "gone" || len(placeholder.Children) != 2 {
+		t.Fatalf("expected orphans under a placeholder for their parent, got %+v", placeholder)
+	}
+	if placeholder.Children[0].SpanID != "o2" {
+		t.Errorf("expected orphans ordered by start, got %s first", placeholder.Children[0].SpanID)
+	}
+	if !placeholder.Start.Equal(waterfallEpoch.Add(110*ms)) || placeholder.Duration != 30*ms {
+		t.Errorf("expected the placeholder to cover its children, got %s for %s", placeholder.Start, placeholder.Duration)
+	}
+
+	var out bytes.Buffer
+	renderWaterfall(&out, roots, false)
+	if !strings.Contains(out.String(), "(missing parent) gone") {
+		t.Errorf("expected the placeholder to be rendered, got:\n%s", out.String())
+	}
+}
+
+func TestBuildWaterfall_ClockOverlap(t *testing.T) {
+	ms := time.Millisecond
+	_, warnings := waterfallFixture(t,
+		fixtureSpan("a", "", "POST /orders", "ok", 0, 100*ms, nil),
+		fixtureSpan("b", "a", "publish", "ok", 90*ms, 30*ms, nil),
+	)
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "span b (publish) ends 20ms after its parent a") {
+		t.Errorf("expected a warning for the child outliving its parent, got %v", warnings)
+	}
+}
+
+func TestRenderWaterfall(t *testing.T) {
+	ms := time.Millisecond
+	roots, _ := waterfallFixture(t,
+		fixtureSpan("a", "", "POST /orders", "ok", 0, 100*ms, map[string]interface{}{
+			"http.method": "POST", "http.route": "/orders", "http.status_code": "500", "user.id": "42",
+		}),
+		fixtureSpan("b", "a", "load cart", "ok", 0, 50*ms, nil),
+		fixtureSpan("c", "a", "charge card", "error", 50*ms, 50*ms, nil),
+	)
+
+	var out bytes.Buffer
+	renderWaterfall(&out, roots, true)
+	lines := strings.Split(out.String(), "\n")
+	if len(lines) < 8 {
+		t.Fatalf("expected a header, three spans and three attributes, got:\n%s", out.String())
+	}
+	if !strings.Contains(lines[0], "WATERFALL (100ms)") {
+		t.Errorf("expected the trace duration in the header, got %q", lines[0])
+	}
+
+	full, half := strings.Repeat("█", 40), strings.Repeat("█", 20)
+	if !strings.Contains(lines[2], "POST /orders [checkout]") || !strings.Contains(lines[2], "|"+full+"|") {
+		t.Errorf("expected the root to span the whole bar, got %q", lines[2])
+	}
+	if !strings.Contains(lines[3], "http.method=POST") || !strings.Contains(lines[5], "http.status_code=500 (+1 more)") {
+		t.Errorf("expected the first three attributes under the root, got:\n%s", out.String())
+	}
+	if !strings.HasPrefix(lines[6], "├─ load cart") || !strings.Contains(lines[6], "|"+half+strings.Repeat(" ", 20)+"|") {
+		t.Errorf("expected load cart in the first half, got %q", lines[6])
+	}
+	if !strings.HasPrefix(lines[7], "└─ charge card") || !strings.Contains(lines[7], "|"+strings.Repeat(" ", 20)+half+"|") || !strings.Contains(lines[7], "✗ error") {
+		t.Errorf("expected a failed charge card in the second half, got %q", lines[7])
+	}
+}
+
+func TestWaterfallBar_MinimumWidth(t *testing.T) {
+	span := &waterfallSpan{Start: waterfallEpoch.Add(time.Second), Duration: time.Microsecond}
+	bar := waterfallBar(span, waterfallEpoch, time.Second+time.Microsecond, 10)
+	if bar != strings.Repeat(" ", 9)+"█" {
+		t.Errorf("expected a single block at the end, got %q", bar)
+	}
+}
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/forge-platform/forge/internal/adapters/tui"
@@ -19,21 +20,38 @@ The TUI provides:
   • Log viewer with filtering
   • Task queue management
   • Plugin management
-  • AI chat interface`,
+  • AI chat interface
+
+Use --tab to open a specific view, e.g. forge tui --tab alerts.`,
 	RunE: runUI,
 }
 
 var (
 	uiTheme string
+	uiTab   string
 )
 
 func init() {
 	uiCmd.Flags().StringVar(&uiTheme, "theme", "dark", "UI theme (dark, light)")
+	uiCmd.Flags().StringVar(&uiTab, "tab", "dashboard", "Initial tab ("+strings.Join(tui.TabNames(), ", ")+")")
+}
+
+// newUIModel creates the TUI model opened on the named tab.
+func newUIModel(tab string) (tui.Model, error) {
+	initial, err := tui.ParseTab(tab)
+	if err != nil {
+		return tui.Model{}, err
+	}
+	return tui.NewModel().WithTab(initial), nil
 }
 
 func runUI(cmd *cobra.Command, args []string) error {
-	// Create the TUI model
-	model := tui.NewModel()
+	// Create the TUI model, validating --tab before taking over the terminal
+	model, err := newUIModel(uiTab)
+	if err != nil {
+		return err
+	}
+	tui.SetAPIKey(resolveAPIKey())
 
 	// Create and run the Bubble Tea program
 	p := tea.NewProgram(
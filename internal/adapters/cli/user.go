@@ -5,12 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"text/tabwriter"
 	"time"
 
-	"github.com/forge-platform/forge/internal/adapters/daemon"
 	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/services"
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
 )
@@ -48,6 +49,19 @@ var userDeleteCmd = &cobra.Command{
 	RunE:  runUserDelete,
 }
 
+var userImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Create users in bulk from a CSV or JSON file",
+	Long: `Create users in bulk. CSV files need a header row with username and
+email columns, and may add role, password and invite columns. JSON files
+hold an array of objects with the same fields.
+
+Users without a password, or with invite set, get a generated temporary
+password that is printed once. Users that already exist are skipped.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runUserImport,
+}
+
 var userAPIKeyCmd = &cobra.Command{
 	Use:   "apikey",
 	Short: "API key management",
@@ -79,23 +93,35 @@ var userAuditCmd = &cobra.Command{
 	RunE:  runAuditLogs,
 }
 
+var userAuditVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify the audit log hash chain",
+	Long: `Verify that no audit log entry has been modified, removed or reordered.
+Each entry stores a hash of its contents chained to the previous entry.`,
+	RunE: runAuditVerify,
+}
+
 var (
 	userRole        string
 	userPermissions []string
 	auditLimit      int
 	auditAction     string
+	importFormat    string
 )
 
 func init() {
 	userCreateCmd.Flags().StringVar(&userRole, "role", "viewer", "User role (admin, operator, viewer)")
 
+	userImportCmd.Flags().StringVar(&importFormat, "format", "", "File format: csv or json (default from file extension)")
+
 	userAPIKeyCreateCmd.Flags().StringSliceVar(&userPermissions, "permissions", []string{"*"}, "API key permissions")
 
 	userAuditCmd.Flags().IntVar(&auditLimit, "limit", 50, "Maximum number of entries")
 	userAuditCmd.Flags().StringVar(&auditAction, "action", "", "Filter by action")
 
+	userAuditCmd.AddCommand(userAuditVerifyCmd)
 	userAPIKeyCmd.AddCommand(userAPIKeyCreateCmd, userAPIKeyListCmd, userAPIKeyRevokeCmd)
-	userCmd.AddCommand(userCreateCmd, userListCmd, userGetCmd, userDeleteCmd, userAPIKeyCmd, userAuditCmd)
+	userCmd.AddCommand(userCreateCmd, userListCmd, userGetCmd, userDeleteCmd, userImportCmd, userAPIKeyCmd, userAuditCmd)
 }
 
 func runUserCreate(cmd *cobra.Command, args []string) error {
@@ -121,7 +147,7 @@ func runUserCreate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("passwords do not match")
 	}
 
-	client, err := daemon.NewClient("")
+	client, err := newDaemonClient()
 	if err != nil {
 		return fmt.Errorf("failed to connect to daemon: %w", err)
 	}
@@ -137,12 +163,21 @@ func runUserCreate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create user: %w", err)
 	}
 
-	fmt.Printf("✓ User created: %s (%s)\n", username, resp.(map[string]interface{})["id"])
+	result := resp.(map[string]interface{})
+	fmt.Printf("✓ User created: %s (%s)\n", username, result["id"])
+	if key, ok := result["api_key"].(string); ok {
+		fmt.Println()
+		fmt.Println("  This is the first user, so it was made an administrator.")
+		fmt.Printf("  API key: %s\n", key)
+		fmt.Println()
+		fmt.Println("⚠️  Store this key securely - it will not be shown again!")
+		fmt.Println("   Set FORGE_API_KEY or pass --api-key to manage users.")
+	}
 	return nil
 }
 
 func runUserList(cmd *cobra.Command, args []string) error {
-	client, err := daemon.NewClient("")
+	client, err := newDaemonClient()
 	if err != nil {
 		return fmt.Errorf("failed to connect to daemon: %w", err)
 	}
@@ -188,7 +223,7 @@ func runUserList(cmd *cobra.Command, args []string) error {
 func runUserGet(cmd *cobra.Command, args []string) error {
 	username := args[0]
 
-	client, err := daemon.NewClient("")
+	client, err := newDaemonClient()
 	if err != nil {
 		return fmt.Errorf("failed to connect to daemon: %w", err)
 	}
@@ -217,7 +252,7 @@ func runUserDelete(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	client, err := daemon.NewClient("")
+	client, err := newDaemonClient()
 	if err != nil {
 		return fmt.Errorf("failed to connect to daemon: %w", err)
 	}
@@ -234,10 +269,79 @@ func runUserDelete(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func runUserImport(cmd *cobra.Command, args []string) error {
+	path := args[0]
+	format := importFormat
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open import file: %w", err)
+	}
+	defer f.Close()
+
+	entries, err := services.ParseUserImport(f, format)
+	if err != nil {
+		return err
+	}
+
+	client, err := newDaemonClient()
+	if err != nil {
+		return fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+	defer client.Close()
+
+	resp, err := client.Call(context.Background(), "user.import", map[string]interface{}{
+		"users": entries,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to import users: %w", err)
+	}
+
+	result, _ := resp.(map[string]interface{})
+	created, _ := result["created"].([]interface{})
+	skipped, _ := result["skipped"].([]interface{})
+
+	fmt.Printf("✓ Imported %d users, skipped %d\n", len(created), len(skipped))
+	if len(created) > 0 {
+		fmt.Println()
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "USERNAME\tEMAIL\tROLE\tTEMP PASSWORD")
+		for _, c := range created {
+			user := c.(map[string]interface{})
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+				getString(user, "username"),
+				getString(user, "email"),
+				getString(user, "role"),
+				getString(user, "temp_password"),
+			)
+		}
+		w.Flush()
+	}
+	if len(skipped) > 0 {
+		fmt.Println()
+		fmt.Println("Skipped:")
+		for _, s := range skipped {
+			entry := s.(map[string]interface{})
+			fmt.Printf("  #%v %s: %s\n", entry["entry"], getString(entry, "username"), getString(entry, "reason"))
+		}
+	}
+	for _, c := range created {
+		if getString(c.(map[string]interface{}), "temp_password") != "" {
+			fmt.Println()
+			fmt.Println("⚠️  Temporary passwords will not be shown again and must be changed at first login.")
+			break
+		}
+	}
+	return nil
+}
+
 func runAPIKeyCreate(cmd *cobra.Command, args []string) error {
 	name := args[0]
 
-	client, err := daemon.NewClient("")
+	client, err := newDaemonClient()
 	if err != nil {
 		return fmt.Errorf("failed to connect to daemon: %w", err)
 	}
@@ -262,7 +366,7 @@ func runAPIKeyCreate(cmd *cobra.Command, args []string) error {
 }
 
 func runAPIKeyList(cmd *cobra.Command, args []string) error {
-	client, err := daemon.NewClient("")
+	client, err := newDaemonClient()
 	if err != nil {
 		return fmt.Errorf("failed to connect to daemon: %w", err)
 	}
@@ -321,7 +425,7 @@ func runAPIKeyList(cmd *cobra.Command, args []string) error {
 func runAPIKeyRevoke(cmd *cobra.Command, args []string) error {
 	keyID := args[0]
 
-	client, err := daemon.NewClient("")
+	client, err := newDaemonClient()
 	if err != nil {
 		return fmt.Errorf("failed to connect to daemon: %w", err)
 	}
@@ -339,7 +443,7 @@ func runAPIKeyRevoke(cmd *cobra.Command, args []string) error {
 }
 
 func runAuditLogs(cmd *cobra.Command, args []string) error {
-	client, err := daemon.NewClient("")
+	client, err := newDaemonClient()
 	if err != nil {
 		return fmt.Errorf("failed to connect to daemon: %w", err)
 	}
@@ -393,6 +497,35 @@ func runAuditLogs(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func runAuditVerify(cmd *cobra.Command, args []string) error {
+	client, err := newDaemonClient()
+	if err != nil {
+		return fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+	defer client.Close()
+
+	resp, err := client.Call(context.Background(), "audit.verify", nil)
+	if err != nil {
+		return fmt.Errorf("failed to verify audit log: %w", err)
+	}
+
+	result, _ := resp.(map[string]interface{})
+	checked, _ := result["checked"].(float64)
+	if valid, _ := result["valid"].(bool); valid {
+		fmt.Printf("✓ Audit log intact (%d entries verified)\n", int(checked))
+		return nil
+	}
+
+	issues, _ := result["issues"].([]interface{})
+	fmt.Printf("✗ Audit log verification failed: %d issue(s) in %d entries\n", len(issues), int(checked))
+	for _, i := range issues {
+		issue := i.(map[string]interface{})
+		index, _ := issue["index"].(float64)
+		fmt.Printf("  #%d %s: %s\n", int(index), truncateID(getString(issue, "id")), getString(issue, "reason"))
+	}
+	return fmt.Errorf("audit log chain is broken")
+}
+
 func truncateID(id string) string {
 	if len(id) > 8 {
 		return id[:8]
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"runtime"
 
@@ -25,6 +26,27 @@ var versionCmd = &cobra.Command{
 		fmt.Printf("  Built:      %s\n", BuildDate)
 		fmt.Printf("  Go version: %s\n", runtime.Version())
 		fmt.Printf("  OS/Arch:    %s/%s\n", runtime.GOOS, runtime.GOARCH)
+		printDaemonVersion()
 	},
 }
 
+// printDaemonVersion shows the running daemon's version and protocol, if
+// one is reachable.
+func printDaemonVersion() {
+	client, err := newDaemonClient()
+	if err != nil {
+		return
+	}
+	defer client.Close()
+
+	caps, err := client.Capabilities(context.Background())
+	if err != nil {
+		return
+	}
+	if caps == nil {
+		fmt.Printf("  Daemon:     running, predates capability negotiation (upgrade recommended)\n")
+		return
+	}
+	fmt.Printf("  Daemon:     %s (protocol %d, %d methods)\n", caps.Version, caps.Protocol, len(caps.Methods))
+}
+
@@ -0,0 +1,405 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+	"github.com/forge-platform/forge/internal/core/services"
+)
+
+// authHandshakeMethod authenticates a connection. Its api_key or
+// session_token param is validated once and the resolved user is attached to
+// every later request on the connection.
+const authHandshakeMethod = "auth.handshake"
+
+// Caller is the authenticated identity behind a request.
+type Caller struct {
+	User    *domain.User
+	APIKey  *domain.APIKey  // Set when authenticated with an API key
+	Session *domain.Session // Set when authenticated with a session token
+}
+
+type callerKey struct{}
+
+// WithCaller returns a context carrying the authenticated caller.
+func WithCaller(ctx context.Context, caller *Caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// CallerFromContext returns the authenticated caller, or nil for an
+// unauthenticated request.
+func CallerFromContext(ctx context.Context) *Caller {
+	caller, _ := ctx.Value(callerKey{}).(*Caller)
+	return caller
+}
+
+// methodPermission is the permission a method requires.
+type methodPermission struct {
+	resource   domain.ResourceType
+	permission domain.Permission
+}
+
+// publicMethods can be called without authentication: the handshake and
+// login, the methods that act on the caller's own identity, and the checks
+// used by supervisors and health probes.
+var publicMethods = map[string]bool{
+	authHandshakeMethod: true,
+	capabilitiesMethod:  true,
+	"auth.login":        true,
+	"auth.logout":       true,
+	"auth.whoami":       true,
+	"ping":              true,
+	"status":            true,
+	"health":            true,
+	"health.liveness":   true,
+	"health.readiness":  true,
+}
+
+// methodPermissions maps every other method to the permission it requires.
+// Methods missing from the table require system:admin, so a new method is
+// closed until it is listed. Users manage their own API keys;
+// handleAPIKeyRevoke also requires apikeys:admin to revoke another user's
+// key.
+var methodPermissions = map[string]methodPermission{
+	"health.metrics": {domain.ResourceSystem, domain.PermissionRead},
+	"backup.info":    {domain.ResourceSystem, domain.PermissionRead},
+
+	"schedule.list": {domain.ResourceTasks, domain.PermissionRead},
+	"task.list":     {domain.ResourceTasks, domain.PermissionRead},
+	"task.create":   {domain.ResourceTasks, domain.PermissionWrite},
+	"task.status":   {domain.ResourceTasks, domain.PermissionRead},
+	"task.cancel":   {domain.ResourceTasks, domain.PermissionWrite},
+
+	"metric.record":         {domain.ResourceMetrics, domain.PermissionWrite},
+	"metric.query":          {domain.ResourceMetrics, domain.PermissionRead},
+	"metric.compare":        {domain.ResourceMetrics, domain.PermissionRead},
+	"metric.export":         {domain.ResourceMetrics, domain.PermissionRead},
+	"metric.list":           {domain.ResourceMetrics, domain.PermissionRead},
+	"metric.aggregate":      {domain.ResourceMetrics, domain.PermissionRead},
+	"metric.transform.list": {domain.ResourceMetrics, domain.PermissionRead},
+	"metric.relabel.list":   {domain.ResourceMetrics, domain.PermissionRead},
+	"metric.downsample":     {domain.ResourceMetrics, domain.PermissionWrite},
+	"metric.stats":          {domain.ResourceMetrics, domain.PermissionRead},
+
+	"event.publish":        {domain.ResourcePlugins, domain.PermissionWrite},
+	"plugin.list":          {domain.ResourcePlugins, domain.PermissionRead},
+	"plugin.install":       {domain.ResourcePlugins, domain.PermissionAdmin},
+	"plugin.reload":        {domain.ResourcePlugins, domain.PermissionWrite},
+	"plugin.configure":     {domain.ResourcePlugins, domain.PermissionWrite},
+	"plugin.storage":       {domain.ResourcePlugins, domain.PermissionRead},
+	"plugin.storage.purge": {domain.ResourcePlugins, domain.PermissionDelete},
+
+	"ai.chat":          {domain.ResourceSystem, domain.PermissionRead},
+	"ai.chat.stream":   {domain.ResourceSystem, domain.PermissionRead},
+	"ai.ask":           {domain.ResourceSystem, domain.PermissionRead},
+	"ai.models":        {domain.ResourceSystem, domain.PermissionRead},
+	"ai.analyze":       {domain.ResourceSystem, domain.PermissionRead},
+	"ai.insights.list": {domain.ResourceSystem, domain.PermissionRead},
+	"ai.explain":       {domain.ResourceSystem, domain.PermissionRead},
+	"ai.suggest":       {domain.ResourceSystem, domain.PermissionRead},
+	"ai.automate":      {domain.ResourceSystem, domain.PermissionRead},
+
+	"workflow.run":     {domain.ResourceWorkflows, domain.PermissionWrite},
+	"workflow.list":    {domain.ResourceWorkflows, domain.PermissionRead},
+	"workflow.status":  {domain.ResourceWorkflows, domain.PermissionRead},
+	"workflow.cancel":  {domain.ResourceWorkflows, domain.PermissionWrite},
+	"workflow.history": {domain.ResourceWorkflows, domain.PermissionRead},
+
+	"alert.rule.list":          {domain.ResourceAlerts, domain.PermissionRead},
+	"alert.rule.create":        {domain.ResourceAlerts, domain.PermissionWrite},
+	"alert.rule.delete":        {domain.ResourceAlerts, domain.PermissionDelete},
+	"alert.list.active":        {domain.ResourceAlerts, domain.PermissionRead},
+	"alert.history":            {domain.ResourceAlerts, domain.PermissionRead},
+	"alert.ack":                {domain.ResourceAlerts, domain.PermissionWrite},
+	"alert.silence.create":     {domain.ResourceAlerts, domain.PermissionWrite},
+	"alert.silence.list":       {domain.ResourceAlerts, domain.PermissionRead},
+	"alert.silence.delete":     {domain.ResourceAlerts, domain.PermissionDelete},
+	"alert.silence.expire":     {domain.ResourceAlerts, domain.PermissionWrite},
+	"alert.maintenance.create": {domain.ResourceAlerts, domain.PermissionWrite},
+	"alert.maintenance.list":   {domain.ResourceAlerts, domain.PermissionRead},
+	"alert.maintenance.update": {domain.ResourceAlerts, domain.PermissionWrite},
+	"alert.maintenance.delete": {domain.ResourceAlerts, domain.PermissionDelete},
+	"alert.channel.list":       {domain.ResourceAlerts, domain.PermissionRead},
+	"alert.channel.create":     {domain.ResourceAlerts, domain.PermissionWrite},
+	"alert.channel.update":     {domain.ResourceAlerts, domain.PermissionWrite},
+	"alert.channel.delete":     {domain.ResourceAlerts, domain.PermissionDelete},
+	"alert.channel.test":       {domain.ResourceAlerts, domain.PermissionWrite},
+
+	"trace.list":        {domain.ResourceTraces, domain.PermissionRead},
+	"trace.get":         {domain.ResourceTraces, domain.PermissionRead},
+	"trace.spans":       {domain.ResourceTraces, domain.PermissionRead},
+	"trace.slowest":     {domain.ResourceTraces, domain.PermissionRead},
+	"span.search":       {domain.ResourceTraces, domain.PermissionRead},
+	"trace.service-map": {domain.ResourceTraces, domain.PermissionRead},
+	"trace.stats":       {domain.ResourceTraces, domain.PermissionRead},
+
+	"trace.config.update": {domain.ResourceTraces, domain.PermissionAdmin},
+
+	"log.list":               {domain.ResourceLogs, domain.PermissionRead},
+	"log.search":             {domain.ResourceLogs, domain.PermissionRead},
+	"log.tail":               {domain.ResourceLogs, domain.PermissionRead},
+	"log.stats":              {domain.ResourceLogs, domain.PermissionRead},
+	"log.ingest":             {domain.ResourceLogs, domain.PermissionWrite},
+	"log.parser.list":        {domain.ResourceLogs, domain.PermissionRead},
+	"log.parser.create":      {domain.ResourceLogs, domain.PermissionWrite},
+	"log.parser.delete":      {domain.ResourceLogs, domain.PermissionDelete},
+	"log.metric.rule.create": {domain.ResourceLogs, domain.PermissionWrite},
+	"log.metricrule.create":  {domain.ResourceLogs, domain.PermissionWrite},
+	"log.metric.rule.list":   {domain.ResourceLogs, domain.PermissionRead},
+	"log.metricrule.list":    {domain.ResourceLogs, domain.PermissionRead},
+	"log.metric.rule.delete": {domain.ResourceLogs, domain.PermissionDelete},
+	"log.metricrule.delete":  {domain.ResourceLogs, domain.PermissionDelete},
+
+	"profile.start.cpu":       {domain.ResourceProfiles, domain.PermissionWrite},
+	"profile.start.heap":      {domain.ResourceProfiles, domain.PermissionWrite},
+	"profile.start.goroutine": {domain.ResourceProfiles, domain.PermissionWrite},
+	"profile.list":            {domain.ResourceProfiles, domain.PermissionRead},
+	"profile.get":             {domain.ResourceProfiles, domain.PermissionRead},
+	"profile.stop":            {domain.ResourceProfiles, domain.PermissionWrite},
+	"profile.delete":          {domain.ResourceProfiles, domain.PermissionDelete},
+	"profile.export":          {domain.ResourceProfiles, domain.PermissionRead},
+	"profile.flamegraph":      {domain.ResourceProfiles, domain.PermissionRead},
+	"profile.stats":           {domain.ResourceProfiles, domain.PermissionRead},
+	"profile.memory":          {domain.ResourceProfiles, domain.PermissionRead},
+
+	"user.create":   {domain.ResourceUsers, domain.PermissionWrite},
+	"user.list":     {domain.ResourceUsers, domain.PermissionRead},
+	"user.get":      {domain.ResourceUsers, domain.PermissionRead},
+	"user.delete":   {domain.ResourceUsers, domain.PermissionDelete},
+	"user.import":   {domain.ResourceUsers, domain.PermissionWrite},
+	"apikey.create": {domain.ResourceAPIKeys, domain.PermissionWrite},
+	"apikey.list":   {domain.ResourceAPIKeys, domain.PermissionRead},
+	"apikey.revoke": {domain.ResourceAPIKeys, domain.PermissionWrite},
+
+	"audit.list":   {domain.ResourceAudit, domain.PermissionRead},
+	"audit.verify": {domain.ResourceAudit, domain.PermissionRead},
+}
+
+// requiredPermission returns the permission a method requires.
+func requiredPermission(method string) methodPermission {
+	if required, ok := methodPermissions[method]; ok {
+		return required
+	}
+	return methodPermission{domain.ResourceSystem, domain.PermissionAdmin}
+}
+
+// authenticate validates the API key or session token presented in a
+// handshake.
+func (s *Server) authenticate(ctx context.Context, params map[string]interface{}) (*Caller, error) {
+	key, _ := params["api_key"].(string)
+	token, _ := params["session_token"].(string)
+	if key == "" && token == "" {
+		return nil, fmt.Errorf("api_key or session_token is required")
+	}
+	if s.authSvc == nil {
+		return nil, fmt.Errorf("auth service not configured")
+	}
+
+	caller := &Caller{}
+	var err error
+	if key != "" {
+		caller.User, caller.APIKey, err = s.authSvc.ValidateAPIKey(ctx, key)
+	} else {
+		caller.User, caller.Session, err = s.authSvc.ValidateSession(ctx, token)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("authentication failed: %w", err)
+	}
+	if caller.User.Status != domain.UserStatusActive {
+		return nil, fmt.Errorf("authentication failed: user %s is %s", caller.User.Username, caller.User.Status)
+	}
+	return caller, nil
+}
+
+// handleAuthHandshake answers a handshake with the authenticated identity.
+// Connections remember the caller; see handleConnection.
+func (s *Server) handleAuthHandshake(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	caller, err := s.authenticate(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return callerToMap(caller), nil
+}
+
+// handleAuthLogin checks a username and password, plus a TOTP code for
+// users with MFA, and starts a session. The returned session_token is what
+// clients present in later handshakes.
+func (s *Server) handleAuthLogin(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if s.authSvc == nil {
+		return nil, fmt.Errorf("auth service not configured")
+	}
+	username, _ := params["username"].(string)
+	password, _ := params["password"].(string)
+	if username == "" || password == "" {
+		return nil, fmt.Errorf("username and password are required")
+	}
+	totpCode, _ := params["totp_code"].(string)
+	userAgent, _ := params["user_agent"].(string)
+
+	address := services.AuditSourceFromContext(ctx).IPAddress
+	session, token, err := s.authSvc.LoginWithMFA(ctx, username, password, totpCode, address, userAgent)
+	if err != nil {
+		return nil, fmt.Errorf("login failed: %w", err)
+	}
+	user, err := s.authSvc.GetUser(ctx, session.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("login failed: %w", err)
+	}
+
+	result := callerToMap(&Caller{User: user, Session: session})
+	result["session_token"] = token
+	return result, nil
+}
+
+// handleAuthLogout revokes the session the connection authenticated with.
+// Later requests on the connection are refused; see authorize.
+func (s *Server) handleAuthLogout(ctx context.Context) (interface{}, error) {
+	if s.authSvc == nil {
+		return nil, fmt.Errorf("auth service not configured")
+	}
+	caller := CallerFromContext(ctx)
+	if caller == nil || caller.Session == nil {
+		return nil, fmt.Errorf("not logged in with a session")
+	}
+	if err := s.authSvc.Logout(ctx, caller.Session.ID); err != nil {
+		return nil, fmt.Errorf("logout failed: %w", err)
+	}
+	caller.Session.Revoke()
+	return map[string]string{"status": "logged_out", "session_id": caller.Session.ID.String()}, nil
+}
+
+// handleAuthWhoami describes the authenticated caller.
+func (s *Server) handleAuthWhoami(ctx context.Context) (interface{}, error) {
+	caller := CallerFromContext(ctx)
+	if caller == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+	return callerToMap(caller), nil
+}
+
+// authorize checks that the caller may invoke a method. The user's role
+// must allow the method's permission and, for API key callers, the key must
+// grant it too, so a wildcard key never gives more access than its owner
+// has. A method's "id" param names the resource acted on, so instance grants
+// such as "alerts:delete:<id>" apply to it.
+//
+// Until the first user exists, or when the daemon runs with AuthDisabled,
+// every method is open so that an administrator can be created.
+func (s *Server) authorize(ctx context.Context, method string, params map[string]interface{}) error {
+	if publicMethods[method] || s.authSvc == nil || s.config.AuthDisabled {
+		return nil
+	}
+	required := requiredPermission(method)
+
+	caller := CallerFromContext(ctx)
+	if caller == nil {
+		bootstrapping, err := s.bootstrapping(ctx)
+		if err != nil {
+			return err
+		}
+		if bootstrapping {
+			return nil
+		}
+		return fmt.Errorf("%w: %s requires authentication", services.ErrPermissionDenied, method)
+	}
+	// The session was valid at the handshake but may have run out or been
+	// logged out since
+	if caller.Session != nil {
+		if caller.Session.RevokedAt != nil {
+			return fmt.Errorf("%w: %s requires authentication: %v", services.ErrPermissionDenied, method, services.ErrSessionRevoked)
+		}
+		if time.Now().After(caller.Session.ExpiresAt) {
+			return fmt.Errorf("%w: %s requires authentication: %v", services.ErrPermissionDenied, method, services.ErrSessionExpired)
+		}
+	}
+
+	resourceID, _ := params["id"].(string)
+	var err error
+	if caller.APIKey != nil {
+		err = s.authSvc.CheckAPIKeyInstancePermission(ctx, caller.APIKey, required.resource, required.permission, resourceID)
+	}
+	if err == nil && !caller.User.CanAccessInstance(required.resource, required.permission, resourceID) {
+		err = services.ErrPermissionDenied
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s requires %s:%s", services.ErrPermissionDenied, method, required.resource, required.permission)
+	}
+	return nil
+}
+
+// callerName names the caller in records such as acknowledgements and
+// silences, falling back to "daemon-user" for unauthenticated requests.
+func callerName(ctx context.Context) string {
+	if caller := CallerFromContext(ctx); caller != nil {
+		return caller.User.Username
+	}
+	return "daemon-user"
+}
+
+// bootstrapping reports whether no users have been created yet.
+func (s *Server) bootstrapping(ctx context.Context) (bool, error) {
+	users, err := s.authSvc.ListUsers(ctx, ports.UserFilter{Limit: 1})
+	if err != nil {
+		return false, fmt.Errorf("failed to check for users: %w", err)
+	}
+	return len(users) == 0, nil
+}
+
+func callerToMap(caller *Caller) map[string]interface{} {
+	m := map[string]interface{}{
+		"user_id":  caller.User.ID.String(),
+		"username": caller.User.Username,
+		"role":     string(caller.User.Role),
+	}
+	if caller.APIKey != nil {
+		m["key_id"] = caller.APIKey.ID.String()
+		m["permissions"] = caller.APIKey.Permissions
+	}
+	if caller.Session != nil {
+		m["session_id"] = caller.Session.ID.String()
+		m["expires_at"] = caller.Session.ExpiresAt.Format(time.RFC3339)
+	}
+	return m
+}
+
+// auditSource attributes the audit entries of a request to its caller and
+// the client address of its connection. peer identifies the client for
+// rate limiting; see peerIdentity.
+func auditSource(ctx context.Context, address, peer string) context.Context {
+	source := services.AuditSource{IPAddress: address, Peer: peer}
+	if caller := CallerFromContext(ctx); caller != nil {
+		source.UserID = &caller.User.ID
+	}
+	return services.WithAuditSource(ctx, source)
+}
+
+// peerIdentity identifies the client of a connection for rate limiting:
+// the IP address, without the port, for TCP clients and the user ID from
+// the peer credentials for unix socket clients where available.
+func peerIdentity(conn net.Conn) string {
+	switch c := conn.(type) {
+	case *net.TCPConn:
+		if addr, ok := c.RemoteAddr().(*net.TCPAddr); ok {
+			return "tcp:" + addr.IP.String()
+		}
+	case *net.UnixConn:
+		if id, ok := unixPeerIdentity(c); ok {
+			return id
+		}
+	}
+	return clientAddress(conn)
+}
+
+// clientAddress describes the peer of a connection for audit logs. Clients
+// of the unix socket are usually unnamed and recorded as "local".
+func clientAddress(conn net.Conn) string {
+	addr := conn.RemoteAddr()
+	if addr == nil || addr.String() == "" || addr.String() == "@" {
+		return "local"
+	}
+	return addr.Network() + ":" + addr.String()
+}
@@ -0,0 +1,191 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// capabilitiesMethod lists the methods and features the daemon supports.
+const capabilitiesMethod = "capabilities"
+
+// ProtocolVersion is the version of the RPC protocol spoken by the daemon.
+// It changes only when requests or responses change incompatibly.
+const ProtocolVersion = 1
+
+// daemonFeatures are optional behaviours of the daemon by name and version.
+// A feature's version is raised when it gains something clients may depend on.
+var daemonFeatures = map[string]int{
+	"auth.session":       1, // Login sessions through auth.login and session_token handshakes
+	"plugin.install.url": 1, // plugin.install accepts url, sha256 and signature
+	"rpc.batch":          1, // A line may hold an array of up to 100 requests
+	"rpc.json_numbers":   1, // Integer params keep full 64-bit precision
+	"stream.heartbeat":   1, // Streams send heartbeats with interval_ms
+}
+
+// ErrUpgradeRequired is returned when the daemon lacks a method or feature
+// the client needs.
+var ErrUpgradeRequired = errors.New("upgrade required")
+
+// UpgradeRequiredError names what the daemon is missing.
+type UpgradeRequiredError struct {
+	Method        string // Missing method, if any
+	Feature       string // Missing feature, if any
+	Version       int    // Feature version needed
+	DaemonVersion string // Empty when the daemon predates capabilities
+}
+
+func (e *UpgradeRequiredError) Error() string {
+	daemon := "the daemon"
+	if e.DaemonVersion != "" {
+		daemon = "the daemon (version " + e.DaemonVersion + ")"
+	}
+	what := e.Method
+	if e.Feature != "" {
+		what = fmt.Sprintf("%s v%d", e.Feature, e.Version)
+	}
+	return fmt.Sprintf("%s: %s does not support %s, upgrade the Forge daemon and restart it", ErrUpgradeRequired, daemon, what)
+}
+
+// Is makes errors.Is(err, ErrUpgradeRequired) true.
+func (e *UpgradeRequiredError) Is(target error) bool {
+	return target == ErrUpgradeRequired
+}
+
+// Capabilities describes what a daemon supports.
+type Capabilities struct {
+	Version  string
+	Protocol int
+	Methods  map[string]bool
+	Features map[string]int
+}
+
+// Supports reports whether the daemon handles method.
+func (c *Capabilities) Supports(method string) bool {
+	return c.Methods[method]
+}
+
+// HasFeature reports whether the daemon has at least the given version of
+// a feature.
+func (c *Capabilities) HasFeature(name string, version int) bool {
+	return c.Features[name] >= version
+}
+
+// supportedMethods returns every method the daemon handles, sorted. Every
+// method needs an entry in methodPermissions or publicMethods, so together
+// they list them all.
+func supportedMethods() []string {
+	methods := make([]string, 0, len(methodPermissions)+len(publicMethods))
+	for method := range methodPermissions {
+		methods = append(methods, method)
+	}
+	for method := range publicMethods {
+		if _, ok := methodPermissions[method]; !ok {
+			methods = append(methods, method)
+		}
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// handleCapabilities lists the daemon's version, methods and features.
+func (s *Server) handleCapabilities() (interface{}, error) {
+	features := make(map[string]interface{}, len(daemonFeatures))
+	for name, version := range daemonFeatures {
+		features[name] = version
+	}
+	return map[string]interface{}{
+		"version":  Version,
+		"protocol": ProtocolVersion,
+		"methods":  supportedMethods(),
+		"features": features,
+	}, nil
+}
+
+// Capabilities asks the daemon what it supports and caches the answer, so
+// later calls to methods it lacks fail with an UpgradeRequiredError before
+// being sent. It returns nil without an error for daemons that predate
+// capability negotiation.
+func (c *Client) Capabilities(ctx context.Context) (*Capabilities, error) {
+	if c.caps != nil || c.legacy {
+		return c.caps, nil
+	}
+
+	resp, err := c.Call(ctx, capabilitiesMethod, nil)
+	if errors.Is(err, ErrUpgradeRequired) {
+		c.legacy = true
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	result, ok := resp.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected capabilities response")
+	}
+
+	caps := &Capabilities{
+		Methods:  make(map[string]bool),
+		Features: make(map[string]int),
+	}
+	caps.Version, _ = result["version"].(string)
+	if protocol, ok := result["protocol"].(float64); ok {
+		caps.Protocol = int(protocol)
+	}
+	methods, _ := result["methods"].([]interface{})
+	for _, m := range methods {
+		if name, ok := m.(string); ok {
+			caps.Methods[name] = true
+		}
+	}
+	features, _ := result["features"].(map[string]interface{})
+	for name, v := range features {
+		if version, ok := v.(float64); ok {
+			caps.Features[name] = int(version)
+		}
+	}
+	c.caps = caps
+	return caps, nil
+}
+
+// RequireFeature returns an UpgradeRequiredError unless the daemon has at
+// least the given version of a feature. Daemons that predate capability
+// negotiation have no features.
+func (c *Client) RequireFeature(ctx context.Context, name string, version int) error {
+	caps, err := c.Capabilities(ctx)
+	if err != nil {
+		return err
+	}
+	if caps != nil && caps.HasFeature(name, version) {
+		return nil
+	}
+	e := &UpgradeRequiredError{Feature: name, Version: version}
+	if caps != nil {
+		e.DaemonVersion = caps.Version
+	}
+	return e
+}
+
+// checkMethod fails with an UpgradeRequiredError if the cached capabilities
+// show the daemon lacks method.
+func (c *Client) checkMethod(method string) error {
+	if c.caps == nil || method == capabilitiesMethod || method == authHandshakeMethod || c.caps.Supports(method) {
+		return nil
+	}
+	return &UpgradeRequiredError{Method: method, DaemonVersion: c.caps.Version}
+}
+
+// unknownMethodError maps the daemon's unknown method error, from daemons
+// that were not asked for their capabilities, to an UpgradeRequiredError.
+func (c *Client) unknownMethodError(method, message string) error {
+	if !strings.HasPrefix(message, "unknown method: ") {
+		return nil
+	}
+	e := &UpgradeRequiredError{Method: method}
+	if c.caps != nil {
+		e.DaemonVersion = c.caps.Version
+	}
+	return e
+}
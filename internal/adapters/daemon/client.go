@@ -3,11 +3,18 @@ package daemon
 import (
 	"bufio"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"path/filepath"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/google/uuid"
@@ -19,6 +26,11 @@ type Client struct {
 	conn       net.Conn
 	reader     *bufio.Reader
 	timeout    time.Duration
+	apiKey     string
+	session    *Credentials  // Saved login, used when no API key is set
+	sessionErr error         // Why the session was rejected, if it was
+	caps       *Capabilities // What the daemon supports, once asked
+	legacy     bool          // The daemon predates capability negotiation
 }
 
 // NewClient creates a new daemon client.
@@ -30,13 +42,32 @@ func NewClient(forgeDir string) (*Client, error) {
 		return nil, fmt.Errorf("daemon not running (socket not found)")
 	}
 
+	// A saved login is optional; an unreadable one is treated as absent
+	session, _ := LoadCredentials(forgeDir)
+
 	return &Client{
 		socketPath: socketPath,
 		timeout:    120 * time.Second,
+		session:    session,
 	}, nil
 }
 
-// Connect establishes a connection to the daemon.
+// SetAPIKey sets the API key presented to the daemon on every new
+// connection. Methods that require permissions fail without one.
+func (c *Client) SetAPIKey(key string) {
+	c.apiKey = key
+}
+
+// SetSession sets the login session presented to the daemon when no API
+// key is set. NewClient loads the one saved by forge login; nil clears it.
+func (c *Client) SetSession(creds *Credentials) {
+	c.session = creds
+}
+
+// Connect establishes a connection to the daemon, authenticating it with
+// the API key or, failing that, the login session if either is set. A
+// rejected session leaves the connection unauthenticated, so methods that
+// need no permissions keep working; the others fail with ErrLoginRequired.
 func (c *Client) Connect() error {
 	conn, err := net.DialTimeout("unix", c.socketPath, c.timeout)
 	if err != nil {
@@ -45,6 +76,56 @@ func (c *Client) Connect() error {
 
 	c.conn = conn
 	c.reader = bufio.NewReader(conn)
+
+	if c.apiKey == "" && c.session == nil {
+		return nil
+	}
+	c.sessionErr = nil
+	if err := c.handshake(); err != nil {
+		if c.apiKey == "" && errors.Is(err, ErrLoginRequired) {
+			c.sessionErr = err
+			return nil
+		}
+		_ = conn.Close()
+		c.conn = nil
+		return err
+	}
+	return nil
+}
+
+// handshake authenticates the connection with the client's API key or
+// login session. A session that has expired or been revoked is reported as
+// ErrLoginRequired.
+func (c *Client) handshake() error {
+	params := map[string]interface{}{"api_key": c.apiKey}
+	if c.apiKey == "" {
+		if c.session.Expired() {
+			return ErrLoginRequired
+		}
+		params = map[string]interface{}{"session_token": c.session.SessionToken}
+	}
+	reqBytes, err := json.Marshal(Request{
+		Method: authHandshakeMethod,
+		Params: params,
+		ID:     uuid.New().String(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal handshake: %w", err)
+	}
+	reqBytes = append(reqBytes, '\n')
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	resp, err := c.roundTrip(ctx, reqBytes)
+	if err != nil {
+		return fmt.Errorf("failed to authenticate: %w", err)
+	}
+	if resp.Error != "" {
+		if c.apiKey == "" {
+			return fmt.Errorf("%w (%s)", ErrLoginRequired, resp.Error)
+		}
+		return fmt.Errorf("daemon error: %s", resp.Error)
+	}
 	return nil
 }
 
@@ -56,12 +137,12 @@ func (c *Client) Close() error {
 	return nil
 }
 
-// Call makes an RPC call to the daemon.
+// Call makes an RPC call to the daemon. A call on a connection the daemon
+// closed for being idle is retried once on a new connection. Methods the
+// daemon lacks fail with an UpgradeRequiredError.
 func (c *Client) Call(ctx context.Context, method string, params map[string]interface{}) (interface{}, error) {
-	if c.conn == nil {
-		if err := c.Connect(); err != nil {
-			return nil, err
-		}
+	if err := c.checkMethod(method); err != nil {
+		return nil, err
 	}
 
 	// Create request
@@ -78,12 +159,56 @@ func (c *Client) Call(ctx context.Context, method string, params map[string]inte
 	}
 	reqBytes = append(reqBytes, '\n')
 
+	reused := c.conn != nil
+	resp, err := c.roundTrip(ctx, reqBytes)
+	if reused && errors.Is(err, errIdleClosed) {
+		_ = c.conn.Close()
+		c.conn = nil
+		resp, err = c.roundTrip(ctx, reqBytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Error != "" {
+		if c.apiKey == "" && strings.Contains(resp.Error, "requires authentication") {
+			if c.sessionErr != nil {
+				return nil, c.sessionErr
+			}
+			return nil, fmt.Errorf("%w (%s)", ErrLoginRequired, resp.Error)
+		}
+		if err := c.unknownMethodError(method, resp.Error); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("daemon error: %s", resp.Error)
+	}
+
+	return resp.Result, nil
+}
+
+
+// errIdleClosed means the daemon closed the connection for being idle
+// without reading the request.
+var errIdleClosed = errors.New(idleClosedMessage)
+
+// roundTrip sends an encoded request and reads its response, connecting
+// first if needed.
+func (c *Client) roundTrip(ctx context.Context, reqBytes []byte) (*Response, error) {
+	if c.conn == nil {
+		if err := c.Connect(); err != nil {
+			return nil, err
+		}
+	}
+
 	// Respect context deadline
 	if deadline, ok := ctx.Deadline(); ok {
 		_ = c.conn.SetWriteDeadline(deadline)
 	}
 
 	if _, err := c.conn.Write(reqBytes); err != nil {
+		if errors.Is(err, syscall.EPIPE) {
+			return nil, errIdleClosed
+		}
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 
@@ -103,12 +228,279 @@ func (c *Client) Call(ctx context.Context, method string, params map[string]inte
 	if err := json.Unmarshal(line, &resp); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
+	if resp.ID == "" && resp.Error == idleClosedMessage {
+		return nil, errIdleClosed
+	}
+	return &resp, nil
+}
 
-	if resp.Error != "" {
-		return nil, fmt.Errorf("daemon error: %s", resp.Error)
+// Stream makes a streaming RPC call. After the initial response, every
+// message pushed by the daemon is passed to onMessage until ctx is cancelled,
+// the daemon closes the stream, or onMessage returns an error. Heartbeat
+// messages are not passed on.
+func (c *Client) Stream(ctx context.Context, method string, params map[string]interface{}, onMessage func(result interface{}) error) error {
+	if _, err := c.Call(ctx, method, params); err != nil {
+		return err
 	}
 
-	return resp.Result, nil
+	// Streams are open-ended; unblock the reader on cancellation instead
+	_ = c.conn.SetReadDeadline(time.Time{})
+	stop := context.AfterFunc(ctx, func() {
+		_ = c.conn.SetReadDeadline(time.Now())
+	})
+	defer stop()
+
+	// Once the daemon announces its heartbeat interval, a stream that stays
+	// silent for several intervals means the daemon is gone
+	var liveness time.Duration
+	for {
+		if liveness > 0 {
+			_ = c.conn.SetReadDeadline(time.Now().Add(liveness))
+			if ctx.Err() != nil {
+				return nil
+			}
+		}
+		line, err := c.reader.ReadBytes('\n')
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			var netErr net.Error
+			if liveness > 0 && errors.As(err, &netErr) && netErr.Timeout() {
+				return fmt.Errorf("%w: no heartbeat for %s", ErrDaemonUnresponsive, liveness)
+			}
+			return fmt.Errorf("stream closed: %w", err)
+		}
+
+		var resp Response
+		if err := json.Unmarshal(line, &resp); err != nil {
+			return fmt.Errorf("failed to parse stream message: %w", err)
+		}
+		if resp.Error != "" {
+			return fmt.Errorf("daemon error: %s", resp.Error)
+		}
+		if m, ok := resp.Result.(map[string]interface{}); ok && m["heartbeat"] == true {
+			if ms, ok := m["interval_ms"].(float64); ok && ms > 0 {
+				liveness = heartbeatMisses * time.Duration(ms) * time.Millisecond
+			}
+			continue
+		}
+		if err := onMessage(resp.Result); err != nil {
+			if errors.Is(err, errStreamDone) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// errStreamDone is returned by a stream callback to end a finite stream.
+var errStreamDone = errors.New("stream done")
+
+// heartbeatMisses is how many heartbeat intervals a stream may stay silent
+// before Stream gives up on the daemon.
+const heartbeatMisses = 3
+
+// ErrDaemonUnresponsive is returned by Stream when the daemon stops sending
+// heartbeats. The connection should be re-established with Reconnect.
+var ErrDaemonUnresponsive = errors.New("daemon stopped responding")
+
+// PingResult is the daemon's answer to a ping.
+type PingResult struct {
+	Seq  uint64        // increases with every ping and heartbeat the daemon sends
+	Time time.Time     // daemon clock when it answered
+	RTT  time.Duration // round-trip time seen by the client
+}
+
+// Ping checks that the daemon answers on this connection.
+func (c *Client) Ping(ctx context.Context) (*PingResult, error) {
+	start := time.Now()
+	res, err := c.Call(ctx, "ping", nil)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := res.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected response type")
+	}
+
+	result := &PingResult{RTT: time.Since(start)}
+	if seq, ok := m["seq"].(float64); ok {
+		result.Seq = uint64(seq)
+	}
+	if ts, ok := m["time"].(string); ok {
+		result.Time, _ = time.Parse(time.RFC3339Nano, ts)
+	}
+	return result, nil
+}
+
+// Reconnect drops the current connection, dials the daemon again and checks
+// the new connection with a ping.
+func (c *Client) Reconnect(ctx context.Context) error {
+	if c.conn != nil {
+		_ = c.conn.Close()
+		c.conn = nil
+	}
+	if err := c.Connect(); err != nil {
+		return err
+	}
+	if _, err := c.Ping(ctx); err != nil {
+		_ = c.Close()
+		c.conn = nil
+		return fmt.Errorf("daemon not responding: %w", err)
+	}
+	return nil
+}
+
+// ExportProfile streams the raw pprof data of a completed profile into w and
+// returns the daemon's summary (profile_id, type, size, sha256). The data is
+// checked against the reported size and checksum.
+func (c *Client) ExportProfile(ctx context.Context, id string, w io.Writer) (map[string]interface{}, error) {
+	hash := sha256.New()
+	out := io.MultiWriter(w, hash)
+	var (
+		written int64
+		summary map[string]interface{}
+	)
+
+	err := c.Stream(ctx, "profile.export", map[string]interface{}{"id": id}, func(result interface{}) error {
+		m, ok := result.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("unexpected stream message")
+		}
+		if chunk, ok := m["data"].(string); ok {
+			data, err := base64.StdEncoding.DecodeString(chunk)
+			if err != nil {
+				return fmt.Errorf("failed to decode profile data: %w", err)
+			}
+			n, err := out.Write(data)
+			written += int64(n)
+			if err != nil {
+				return fmt.Errorf("failed to write profile data: %w", err)
+			}
+			return nil
+		}
+		if done, _ := m["done"].(bool); done {
+			summary = m
+			return errStreamDone
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if summary == nil {
+		return nil, fmt.Errorf("profile export ended before completion")
+	}
+
+	if size, _ := summary["size"].(float64); int64(size) != written {
+		return nil, fmt.Errorf("profile export size mismatch: expected %d bytes, received %d", int64(size), written)
+	}
+	if sum, _ := summary["sha256"].(string); sum != hex.EncodeToString(hash.Sum(nil)) {
+		return nil, fmt.Errorf("profile export checksum mismatch")
+	}
+	return summary, nil
+}
+
+// ExportMetrics sends a metric.export request and passes each chunk of
+// points to onChunk along with the series it belongs to. Points arrive as
+// [unix_ms, value] pairs, series by series in time order. It returns the
+// daemon's summary with the series and point counts, checked against what
+// was received. An error from onChunk aborts the export.
+func (c *Client) ExportMetrics(ctx context.Context, params map[string]interface{}, onChunk func(series map[string]interface{}, points []interface{}) error) (map[string]interface{}, error) {
+	// The daemon closes a connection once its stream ends, so the next call
+	// has to dial again
+	defer func() {
+		_ = c.Close()
+		c.conn = nil
+	}()
+
+	var (
+		received int
+		summary  map[string]interface{}
+	)
+	err := c.Stream(ctx, "metric.export", params, func(result interface{}) error {
+		m, ok := result.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("unexpected stream message")
+		}
+		if done, _ := m["done"].(bool); done {
+			summary = m
+			return errStreamDone
+		}
+		series, ok := m["series"].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		points, _ := m["points"].([]interface{})
+		received += len(points)
+		return onChunk(series, points)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if summary == nil {
+		return nil, fmt.Errorf("metric export ended before completion")
+	}
+	if n, _ := summary["points"].(float64); int(n) != received {
+		return nil, fmt.Errorf("metric export point mismatch: expected %d points, received %d", int(n), received)
+	}
+	return summary, nil
+}
+
+// TailLogs streams newly ingested log entries matching params to onLog.
+func (c *Client) TailLogs(ctx context.Context, params map[string]interface{}, onLog func(entry map[string]interface{})) error {
+	return c.Stream(ctx, "log.tail", params, func(result interface{}) error {
+		if m, ok := result.(map[string]interface{}); ok {
+			if entry, ok := m["log"].(map[string]interface{}); ok {
+				onLog(entry)
+			}
+		}
+		return nil
+	})
+}
+
+// ChatStream sends an ai.chat.stream request and passes each piece of the
+// reply to onChunk as it arrives. It returns the final message, which holds
+// the full content and the conversation_id. Cancelling ctx stops the
+// generation on the daemon.
+func (c *Client) ChatStream(ctx context.Context, params map[string]interface{}, onChunk func(chunk string)) (map[string]interface{}, error) {
+	// The daemon closes a connection once its stream ends, so the next call
+	// has to dial again
+	defer func() {
+		_ = c.Close()
+		c.conn = nil
+	}()
+
+	var final map[string]interface{}
+	err := c.Stream(ctx, "ai.chat.stream", params, func(result interface{}) error {
+		m, ok := result.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("unexpected stream message")
+		}
+		if chunk, ok := m["chunk"].(string); ok {
+			onChunk(chunk)
+			return nil
+		}
+		if done, _ := m["done"].(bool); done {
+			final = m
+			return errStreamDone
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if final == nil {
+		return nil, fmt.Errorf("chat stream ended before completion")
+	}
+	return final, nil
 }
 
 // Status gets the daemon status.
@@ -123,6 +515,15 @@ func (c *Client) Status(ctx context.Context) (map[string]interface{}, error) {
 	return nil, fmt.Errorf("unexpected response type")
 }
 
+// PublishEvent injects an event into the daemon's plugin event bus.
+func (c *Client) PublishEvent(ctx context.Context, eventType string, payload interface{}) error {
+	_, err := c.Call(ctx, "event.publish", map[string]interface{}{
+		"type":    eventType,
+		"payload": payload,
+	})
+	return err
+}
+
 // RecordMetric records a metric.
 func (c *Client) RecordMetric(ctx context.Context, name string, value float64, tags map[string]string) error {
 	params := map[string]interface{}{
@@ -197,6 +598,27 @@ func (c *Client) QueryMetric(ctx context.Context, name string, limit int) ([]map
 	return metrics, nil
 }
 
+// ListRecentSeries returns at most limit metric series, most recently
+// written first.
+func (c *Client) ListRecentSeries(ctx context.Context, limit int) ([]map[string]interface{}, error) {
+	res, err := c.Call(ctx, "metric.list", map[string]interface{}{"limit": limit})
+	if err != nil {
+		return nil, err
+	}
+	m, ok := res.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected response type")
+	}
+	list, _ := m["series"].([]interface{})
+	series := make([]map[string]interface{}, 0, len(list))
+	for _, item := range list {
+		if info, ok := item.(map[string]interface{}); ok {
+			series = append(series, info)
+		}
+	}
+	return series, nil
+}
+
 // GetMetricStats returns TSDB statistics.
 func (c *Client) GetMetricStats(ctx context.Context) (map[string]interface{}, error) {
 	res, err := c.Call(ctx, "metric.stats", nil)
@@ -0,0 +1,94 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CredentialsFile is the name of the file, in the Forge directory, that
+// holds the session saved by forge login.
+const CredentialsFile = "credentials"
+
+// ErrLoginRequired is returned when the daemon needs an authenticated
+// caller and the client has no usable session.
+var ErrLoginRequired = errors.New("not logged in or session expired, please run forge login")
+
+// Credentials is a saved login session.
+type Credentials struct {
+	SessionToken string    `json:"session_token"`
+	Username     string    `json:"username"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// Expired reports whether the session has run out.
+func (c *Credentials) Expired() bool {
+	return !c.ExpiresAt.IsZero() && time.Now().After(c.ExpiresAt)
+}
+
+// LoadCredentials reads the saved session from forgeDir. It returns nil
+// without an error when there is none.
+func LoadCredentials(forgeDir string) (*Credentials, error) {
+	data, err := os.ReadFile(filepath.Join(forgeDir, CredentialsFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials: %w", err)
+	}
+
+	var creds Credentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return nil, fmt.Errorf("invalid credentials file: %w", err)
+	}
+	if creds.SessionToken == "" {
+		return nil, nil
+	}
+	return &creds, nil
+}
+
+// SaveCredentials writes a session to forgeDir, readable only by the owner.
+func SaveCredentials(forgeDir string, creds *Credentials) error {
+	data, err := json.MarshalIndent(creds, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode credentials: %w", err)
+	}
+	if err := os.MkdirAll(forgeDir, 0700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", forgeDir, err)
+	}
+
+	// Write to a private temporary file first so the token is never
+	// readable by others, even briefly
+	tmp, err := os.CreateTemp(forgeDir, CredentialsFile+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write credentials: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write credentials: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write credentials: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write credentials: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(forgeDir, CredentialsFile)); err != nil {
+		return fmt.Errorf("failed to write credentials: %w", err)
+	}
+	return nil
+}
+
+// RemoveCredentials deletes the saved session, if any.
+func RemoveCredentials(forgeDir string) error {
+	err := os.Remove(filepath.Join(forgeDir, CredentialsFile))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove credentials: %w", err)
+	}
+	return nil
+}
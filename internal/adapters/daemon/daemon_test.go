@@ -2,11 +2,38 @@
 package daemon
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/forge-platform/forge/internal/adapters/otlp"
+	"github.com/forge-platform/forge/internal/adapters/storage"
+	"github.com/forge-platform/forge/internal/adapters/wasm"
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+	"github.com/forge-platform/forge/internal/core/services"
+	"github.com/google/uuid"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -39,6 +66,14 @@ func TestDefaultConfig(t *testing.T) {
 	if cfg.HTTPPort != "" {
 		t.Errorf("expected HTTPPort empty, got %s", cfg.HTTPPort)
 	}
+
+	if !cfg.OTLPEnabled || cfg.OTLPHost != "127.0.0.1" || cfg.OTLPPort != "4318" {
+		t.Errorf("expected OTLP receiver enabled on 127.0.0.1:4318, got %v on %q:%q", cfg.OTLPEnabled, cfg.OTLPHost, cfg.OTLPPort)
+	}
+
+	if cfg.RemoteWrite || cfg.RemoteWriteLimit != 10<<20 {
+		t.Errorf("expected remote write disabled with a 10MB limit, got %v, %d", cfg.RemoteWrite, cfg.RemoteWriteLimit)
+	}
 }
 
 func TestRequest_JSON(t *testing.T) {
@@ -162,3 +197,4107 @@ func TestNewClient_MissingSocket(t *testing.T) {
 	}
 }
 
+func TestHandleScheduleList(t *testing.T) {
+	tmpDir := t.TempDir()
+	server, err := NewServer(DefaultConfig(tmpDir), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+
+	ctx := context.Background()
+	startedAt := time.Now().Add(-time.Second)
+	if err := server.scheduleSvc.RecordRun(services.RetentionScheduleName, startedAt, errors.New("database is locked")); err != nil {
+		t.Fatalf("RecordRun failed: %v", err)
+	}
+
+	task, err := server.taskSvc.CreateTask(ctx, domain.TaskTypeMaintenance, nil)
+	if err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+
+	result, err := server.handleRequest(ctx, &Request{Method: "schedule.list"})
+	if err != nil {
+		t.Fatalf("schedule.list failed: %v", err)
+	}
+
+	resMap, ok := result.(map[string]interface{})
+	if !ok {
+		t.Fatalf("unexpected result type %T", result)
+	}
+	schedules, _ := resMap["schedules"].([]map[string]interface{})
+	if len(schedules) != 2 {
+		t.Fatalf("expected 2 schedules, got %d", len(schedules))
+	}
+
+	found := map[string]map[string]interface{}{}
+	for _, sched := range schedules {
+		found[sched["kind"].(string)] = sched
+	}
+
+	cron := found["cron"]
+	if cron == nil {
+		t.Fatal("expected downsampling schedule in list")
+	}
+	if cron["last_status"] != "failed" {
+		t.Errorf("expected last_status failed, got %v", cron["last_status"])
+	}
+	if cron["last_error"] != "database is locked" {
+		t.Errorf("expected last_error to be reported, got %v", cron["last_error"])
+	}
+	wantNext := startedAt.Add(time.Hour).UTC().Format(time.RFC3339)
+	if cron["next_run"] != wantNext {
+		t.Errorf("expected next_run %s, got %v", wantNext, cron["next_run"])
+	}
+
+	pending := found["task"]
+	if pending == nil {
+		t.Fatal("expected pending task in list")
+	}
+	if pending["name"] != "maintenance:"+task.ID.String() {
+		t.Errorf("unexpected task entry name %v", pending["name"])
+	}
+	if pending["last_status"] != "never" {
+		t.Errorf("expected last_status never, got %v", pending["last_status"])
+	}
+
+	result, err = server.handleRequest(ctx, &Request{
+		Method: "schedule.list",
+		Params: map[string]interface{}{"include_tasks": false},
+	})
+	if err != nil {
+		t.Fatalf("schedule.list failed: %v", err)
+	}
+	if count := result.(map[string]interface{})["count"]; count != 1 {
+		t.Errorf("expected 1 schedule without tasks, got %v", count)
+	}
+}
+
+func TestDispatchRequest_SelfTracing(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+
+	ctx := context.Background()
+
+	if _, err := server.dispatchRequest(ctx, &Request{Method: "status", ID: "req-ok"}); err != nil {
+		t.Fatalf("status failed: %v", err)
+	}
+	if _, err := server.dispatchRequest(ctx, &Request{Method: "task.status", ID: "req-err"}); err == nil {
+		t.Fatal("expected task.status without id to fail")
+	}
+
+	spans, err := server.traceSvc.ListSpans(ctx, ports.SpanFilter{ServiceName: selfTraceService})
+	if err != nil {
+		t.Fatalf("ListSpans failed: %v", err)
+	}
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 daemon spans, got %d", len(spans))
+	}
+
+	byMethod := map[string]*domain.Span{}
+	for _, span := range spans {
+		byMethod[span.Attributes["rpc.method"]] = span
+	}
+
+	okSpan := byMethod["status"]
+	if okSpan == nil {
+		t.Fatal("expected span for status RPC")
+	}
+	if okSpan.Status != domain.SpanStatusOK {
+		t.Errorf("expected ok status, got %s", okSpan.Status)
+	}
+	if okSpan.Kind != domain.SpanKindServer {
+		t.Errorf("expected server span, got %s", okSpan.Kind)
+	}
+	if okSpan.Attributes["rpc.request_id"] != "req-ok" {
+		t.Errorf("expected request id attribute, got %q", okSpan.Attributes["rpc.request_id"])
+	}
+
+	errSpan := byMethod["task.status"]
+	if errSpan == nil {
+		t.Fatal("expected span for failed RPC")
+	}
+	if errSpan.Status != domain.SpanStatusError {
+		t.Errorf("expected error status, got %s", errSpan.Status)
+	}
+	if errSpan.StatusMessage != "task id is required" {
+		t.Errorf("expected error message on span, got %q", errSpan.StatusMessage)
+	}
+
+	traces, err := server.traceSvc.ListTraces(ctx, ports.TraceFilter{ServiceName: selfTraceService, Status: string(domain.SpanStatusError)})
+	if err != nil {
+		t.Fatalf("ListTraces failed: %v", err)
+	}
+	if len(traces) != 1 || traces[0].Name != "task.status" {
+		t.Errorf("expected one failed task.status trace, got %d", len(traces))
+	}
+}
+
+func TestDispatchRequest_SelfTracingSkipsUnauthorized(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+
+	ctx := context.Background()
+	// Once a user exists, calls without a caller are rejected
+	if _, err := server.authSvc.CreateUser(ctx, "admin", "admin@example.com", "secret123", domain.RoleAdmin); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	if _, err := server.dispatchRequest(ctx, &Request{Method: "task.list"}); !errors.Is(err, services.ErrPermissionDenied) {
+		t.Fatalf("expected an unauthenticated call to be denied, got %v", err)
+	}
+
+	spans, err := server.traceSvc.ListSpans(ctx, ports.SpanFilter{ServiceName: selfTraceService})
+	if err != nil {
+		t.Fatalf("ListSpans failed: %v", err)
+	}
+	if len(spans) != 0 {
+		t.Errorf("expected no spans for a rejected call, got %d", len(spans))
+	}
+}
+
+func TestDispatchRequest_SelfTracingDisabled(t *testing.T) {
+	cfg := DefaultConfig(t.TempDir())
+	cfg.SelfTracing = false
+	server, err := NewServer(cfg, &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+
+	ctx := context.Background()
+	if _, err := server.dispatchRequest(ctx, &Request{Method: "status"}); err != nil {
+		t.Fatalf("status failed: %v", err)
+	}
+
+	spans, err := server.traceSvc.ListSpans(ctx, ports.SpanFilter{ServiceName: selfTraceService})
+	if err != nil {
+		t.Fatalf("ListSpans failed: %v", err)
+	}
+	if len(spans) != 0 {
+		t.Errorf("expected no spans with self-tracing disabled, got %d", len(spans))
+	}
+}
+
+func TestLogTailStreaming(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+
+	serverConn, clientConn := net.Pipe()
+	done := make(chan struct{})
+	server.wg.Add(1)
+	go func() {
+		defer close(done)
+		server.handleConnection(context.Background(), serverConn)
+	}()
+
+	client := &Client{conn: clientConn, reader: bufio.NewReader(clientConn), timeout: 5 * time.Second}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	received := make(chan map[string]interface{}, 1)
+	streamErr := make(chan error, 1)
+	go func() {
+		streamErr <- client.TailLogs(ctx, map[string]interface{}{"service_name": "api"}, func(entry map[string]interface{}) {
+			select {
+			case received <- entry:
+			default:
+			}
+		})
+	}()
+
+	// Publish until the subscription is registered and an entry comes through
+	want := domain.NewLogEntry(domain.LogLevelError, "upstream timeout", "stdout", "api")
+	deadline := time.After(5 * time.Second)
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+
+	var got map[string]interface{}
+	for got == nil {
+		select {
+		case <-ticker.C:
+			_ = server.logSvc.Ingest(context.Background(), domain.NewLogEntry(domain.LogLevelInfo, "ignored", "stdout", "worker"))
+			_ = server.logSvc.Ingest(context.Background(), want)
+		case got = <-received:
+		case err := <-streamErr:
+			t.Fatalf("stream ended early: %v", err)
+		case <-deadline:
+			t.Fatal("timed out waiting for tailed log entry")
+		}
+	}
+
+	if got["id"] != want.ID.String() {
+		t.Errorf("expected entry %s, got %v", want.ID, got["id"])
+	}
+	if got["service_name"] != "api" {
+		t.Errorf("expected filtered service 'api', got %v", got["service_name"])
+	}
+
+	// Cancelling the client ends the stream; closing the connection ends the handler
+	cancel()
+	if err := <-streamErr; err != nil {
+		t.Errorf("expected clean stream shutdown, got %v", err)
+	}
+	clientConn.Close()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("handleConnection did not return after client disconnect")
+	}
+}
+
+func TestLogTailRequiresStreamingConnection(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+
+	_, err = server.handleRequest(context.Background(), &Request{Method: "log.tail"})
+	if err == nil {
+		t.Error("expected log.tail outside a connection to fail")
+	}
+}
+
+func TestBatchRequests(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	server.wg.Add(1)
+	go server.handleConnection(context.Background(), serverConn)
+	reader := bufio.NewReader(clientConn)
+	_ = clientConn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	send := func(line string) []byte {
+		t.Helper()
+		if _, err := clientConn.Write([]byte(line + "\n")); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+		resp, err := reader.ReadBytes('\n')
+		if err != nil {
+			t.Fatalf("read failed: %v", err)
+		}
+		return resp
+	}
+
+	line := ` [
+		{"id":"1","method":"metric.record","params":{"name":"batch.a","value":1}},
+		{"id":"2","method":"no.such.method"},
+		42,
+		{"id":"4","method":"metric.record","params":{"name":"batch.a","value":2}},
+		{"id":"5","method":"log.tail"},
+		{"id":"6","method":"ping"}
+	]`
+	var responses []Response
+	if err := json.Unmarshal(send(strings.Join(strings.Fields(line), " ")), &responses); err != nil {
+		t.Fatalf("expected an array of responses: %v", err)
+	}
+	if len(responses) != 6 {
+		t.Fatalf("expected 6 responses, got %d: %+v", len(responses), responses)
+	}
+	wantIDs := []string{"1", "2", "", "4", "5", "6"}
+	wantErr := []bool{false, true, true, false, true, false}
+	for i, resp := range responses {
+		if resp.ID != wantIDs[i] {
+			t.Errorf("response %d: expected ID %q, got %q", i, wantIDs[i], resp.ID)
+		}
+		if (resp.Error != "") != wantErr[i] {
+			t.Errorf("response %d: unexpected error %q", i, resp.Error)
+		}
+	}
+	if !strings.Contains(responses[4].Error, "cannot be batched") {
+		t.Errorf("expected a stream in a batch to be rejected, got %q", responses[4].Error)
+	}
+
+	// Both records were executed, in order
+	series, err := server.metricSvc.Query(context.Background(), ports.MetricQuery{
+		Name:      "batch.a",
+		StartTime: time.Now().Add(-time.Hour),
+		EndTime:   time.Now().Add(time.Hour),
+	})
+	if err != nil || len(series.Points) != 2 || series.Points[0].Value != 1 || series.Points[1].Value != 2 {
+		t.Errorf("expected both points recorded in order, got %+v (%v)", series, err)
+	}
+
+	// Single requests still work on the same connection
+	var single Response
+	if err := json.Unmarshal(send(`{"id":"7","method":"ping"}`), &single); err != nil || single.ID != "7" || single.Error != "" {
+		t.Errorf("unexpected single response %+v (%v)", single, err)
+	}
+
+	// Oversized and empty batches are refused as a whole
+	big := make([]string, maxBatchRequests+1)
+	for i := range big {
+		big[i] = `{"method":"ping"}`
+	}
+	for _, bad := range []string{"[" + strings.Join(big, ",") + "]", "[]"} {
+		var resp Response
+		if err := json.Unmarshal(send(bad), &resp); err != nil || !strings.HasPrefix(resp.Error, "invalid batch") {
+			t.Errorf("expected a single invalid batch error, got %+v (%v)", resp, err)
+		}
+	}
+}
+
+func TestIdleConnectionClosed(t *testing.T) {
+	config := DefaultConfig(t.TempDir())
+	config.IdleTimeout = 100 * time.Millisecond
+	server, err := NewServer(config, &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	done := make(chan struct{})
+	server.wg.Add(1)
+	go func() {
+		defer close(done)
+		server.handleConnection(context.Background(), serverConn)
+	}()
+
+	// Requests arriving within the timeout keep the connection open
+	client := &Client{conn: clientConn, reader: bufio.NewReader(clientConn), timeout: 5 * time.Second}
+	for i := 0; i < 3; i++ {
+		if _, err := client.Call(context.Background(), "status", nil); err != nil {
+			t.Fatalf("call %d failed: %v", i, err)
+		}
+		time.Sleep(40 * time.Millisecond)
+	}
+
+	// Then the daemon gives notice and hangs up
+	_ = clientConn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	line, err := client.reader.ReadBytes('\n')
+	if err != nil {
+		t.Fatalf("expected a close notice, got %v", err)
+	}
+	var notice Response
+	if err := json.Unmarshal(line, &notice); err != nil || notice.Error != idleClosedMessage {
+		t.Errorf("unexpected close notice %q", line)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("handleConnection did not return after the idle timeout")
+	}
+}
+
+func TestClientRetriesAfterIdleClose(t *testing.T) {
+	config := DefaultConfig(t.TempDir())
+	config.IdleTimeout = 50 * time.Millisecond
+	server, err := NewServer(config, &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+
+	socketPath := filepath.Join(t.TempDir(), "forge.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	defer listener.Close()
+	var accepted sync.WaitGroup
+	connections := 0
+	accepted.Add(1)
+	go func() {
+		defer accepted.Done()
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			connections++
+			server.wg.Add(1)
+			go server.handleConnection(context.Background(), conn)
+		}
+	}()
+
+	client := &Client{socketPath: socketPath, timeout: 5 * time.Second}
+	defer client.Close()
+	if _, err := client.Call(context.Background(), "status", nil); err != nil {
+		t.Fatalf("first call failed: %v", err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if _, err := client.Call(context.Background(), "status", nil); err != nil {
+		t.Fatalf("call after idle close failed: %v", err)
+	}
+
+	client.Close()
+	listener.Close()
+	accepted.Wait()
+	if connections != 2 {
+		t.Errorf("expected the client to reconnect once, got %d connections", connections)
+	}
+}
+
+func TestLogTailHeartbeat(t *testing.T) {
+	config := DefaultConfig(t.TempDir())
+	config.IdleTimeout = 50 * time.Millisecond
+	config.StreamHeartbeat = 20 * time.Millisecond
+	server, err := NewServer(config, &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+
+	serverConn, clientConn := net.Pipe()
+	done := make(chan struct{})
+	server.wg.Add(1)
+	go func() {
+		defer close(done)
+		server.handleConnection(context.Background(), serverConn)
+	}()
+
+	if _, err := clientConn.Write([]byte(`{"method":"log.tail","id":"tail-1"}` + "\n")); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	reader := bufio.NewReader(clientConn)
+	_ = clientConn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := reader.ReadBytes('\n'); err != nil {
+		t.Fatalf("expected the stream to start: %v", err)
+	}
+
+	// A quiet tail outlives the idle timeout on heartbeats alone
+	heartbeats := 0
+	deadline := time.Now().Add(250 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			t.Fatalf("stream ended after %d heartbeats: %v", heartbeats, err)
+		}
+		var msg Response
+		if err := json.Unmarshal(line, &msg); err != nil {
+			t.Fatalf("invalid stream message %q", line)
+		}
+		result, _ := msg.Result.(map[string]interface{})
+		if msg.ID != "tail-1" || msg.Error != "" || result["heartbeat"] != true {
+			t.Fatalf("unexpected stream message %q", line)
+		}
+		heartbeats++
+	}
+	if heartbeats < 5 {
+		t.Errorf("expected regular heartbeats, got %d", heartbeats)
+	}
+
+	clientConn.Close()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("handleConnection did not return after client disconnect")
+	}
+}
+
+func TestPing(t *testing.T) {
+	config := DefaultConfig(t.TempDir())
+	config.SelfTracing = true
+	server, err := NewServer(config, &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+	ctx := context.Background()
+
+	var last uint64
+	for i := 0; i < 3; i++ {
+		resp, err := server.dispatchRequest(ctx, &Request{Method: "ping", ID: "p"})
+		if err != nil {
+			t.Fatalf("ping %d failed: %v", i, err)
+		}
+		result := resp.(map[string]interface{})
+		seq := result["seq"].(uint64)
+		if seq <= last {
+			t.Errorf("ping %d: expected sequence above %d, got %d", i, last, seq)
+		}
+		last = seq
+		ts, err := time.Parse(time.RFC3339Nano, result["time"].(string))
+		if err != nil || time.Since(ts) > time.Minute {
+			t.Errorf("ping %d: expected the current server time, got %v", i, result["time"])
+		}
+	}
+
+	// Heartbeats share the sequence
+	if hb := server.heartbeat(); hb["seq"].(uint64) != last+1 || hb["heartbeat"] != true {
+		t.Errorf("expected heartbeat %d, got %v", last+1, hb)
+	}
+
+	// Pings are not traced and never touch storage
+	traces, err := server.traceSvc.ListTraces(ctx, ports.TraceFilter{Limit: 10})
+	if err != nil {
+		t.Fatalf("ListTraces failed: %v", err)
+	}
+	if len(traces) != 0 {
+		t.Errorf("expected pings not to be traced, got %d traces", len(traces))
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		_, _ = server.dispatchRequest(ctx, &Request{Method: "ping"})
+	})
+	if allocs > 20 {
+		t.Errorf("expected ping to be cheap, got %.0f allocations", allocs)
+	}
+}
+
+func TestClientPingReconnect(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+
+	socketPath := filepath.Join(t.TempDir(), "forge.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	defer listener.Close()
+	var accepted sync.WaitGroup
+	var conns []net.Conn
+	accepted.Add(1)
+	go func() {
+		defer accepted.Done()
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conns = append(conns, conn)
+			server.wg.Add(1)
+			go server.handleConnection(context.Background(), conn)
+		}
+	}()
+
+	ctx := context.Background()
+	client := &Client{socketPath: socketPath, timeout: 5 * time.Second}
+	defer client.Close()
+	first, err := client.Ping(ctx)
+	if err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	second, err := client.Ping(ctx)
+	if err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	if second.Seq <= first.Seq || second.Time.IsZero() {
+		t.Errorf("expected increasing sequences and a server time, got %+v then %+v", first, second)
+	}
+
+	if err := client.Reconnect(ctx); err != nil {
+		t.Fatalf("Reconnect failed: %v", err)
+	}
+	third, err := client.Ping(ctx)
+	if err != nil || third.Seq <= second.Seq {
+		t.Errorf("expected a working connection after Reconnect, got %+v, %v", third, err)
+	}
+
+	client.Close()
+	listener.Close()
+	accepted.Wait()
+	if len(conns) != 2 {
+		t.Errorf("expected Reconnect to dial once more, got %d connections", len(conns))
+	}
+}
+
+func TestClientStreamDetectsSilentDaemon(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	// A daemon that announces heartbeats every 10ms and then hangs
+	go func() {
+		reader := bufio.NewReader(serverConn)
+		if _, err := reader.ReadBytes('\n'); err != nil {
+			return
+		}
+		_ = writeResponse(serverConn, Response{ID: "tail", Result: map[string]interface{}{"streaming": true}})
+		_ = writeResponse(serverConn, Response{ID: "tail", Result: map[string]interface{}{"heartbeat": true, "interval_ms": 10}})
+	}()
+
+	client := &Client{conn: clientConn, reader: bufio.NewReader(clientConn), timeout: 5 * time.Second}
+	done := make(chan error, 1)
+	go func() {
+		done <- client.Stream(context.Background(), "log.tail", nil, func(interface{}) error { return nil })
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrDaemonUnresponsive) {
+			t.Errorf("expected ErrDaemonUnresponsive, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stream did not notice the missing heartbeats")
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+
+	if !publicMethods[capabilitiesMethod] {
+		t.Error("expected capabilities to need no authentication")
+	}
+	resp, err := server.handleRequest(context.Background(), &Request{Method: capabilitiesMethod})
+	if err != nil {
+		t.Fatalf("capabilities failed: %v", err)
+	}
+	result := resp.(map[string]interface{})
+	if result["version"] != Version || result["protocol"] != ProtocolVersion {
+		t.Errorf("unexpected version: %v", result)
+	}
+	methods := result["methods"].([]string)
+	if !sort.StringsAreSorted(methods) {
+		t.Error("expected methods to be sorted")
+	}
+	// Every advertised method must be handled
+	for _, method := range methods {
+		if isStreamingMethod(method) {
+			continue
+		}
+		_, err := server.handleRequest(context.Background(), &Request{Method: method, Params: map[string]interface{}{}})
+		if err != nil && strings.HasPrefix(err.Error(), "unknown method") {
+			t.Errorf("advertised method %s is not handled", method)
+		}
+	}
+	if features := result["features"].(map[string]interface{}); features["plugin.install.url"] != 1 {
+		t.Errorf("expected plugin.install.url v1, got %v", features)
+	}
+}
+
+// fakeDaemon answers requests on a pipe with answer and returns a client
+// connected to it along with the methods it received.
+func fakeDaemon(t *testing.T, answer func(req Request) Response) (*Client, func() []string) {
+	t.Helper()
+	serverConn, clientConn := net.Pipe()
+	t.Cleanup(func() { serverConn.Close(); clientConn.Close() })
+
+	var mu sync.Mutex
+	var received []string
+	go func() {
+		reader := bufio.NewReader(serverConn)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if err != nil {
+				return
+			}
+			var req Request
+			if err := json.Unmarshal(line, &req); err != nil {
+				return
+			}
+			mu.Lock()
+			received = append(received, req.Method)
+			mu.Unlock()
+			resp := answer(req)
+			resp.ID = req.ID
+			if writeResponse(serverConn, resp) != nil {
+				return
+			}
+		}
+	}()
+
+	client := &Client{conn: clientConn, reader: bufio.NewReader(clientConn), timeout: 5 * time.Second}
+	return client, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), received...)
+	}
+}
+
+func TestClientCapabilityGating(t *testing.T) {
+	ctx := context.Background()
+
+	// An older daemon without plugin.install or the URL install feature
+	client, received := fakeDaemon(t, func(req Request) Response {
+		if req.Method == capabilitiesMethod {
+			return Response{Result: map[string]interface{}{
+				"version":  "1.0.0",
+				"protocol": 1,
+				"methods":  []string{"capabilities", "ping", "plugin.list"},
+				"features": map[string]int{"stream.heartbeat": 1},
+			}}
+		}
+		return Response{Result: map[string]interface{}{"ok": true}}
+	})
+
+	caps, err := client.Capabilities(ctx)
+	if err != nil {
+		t.Fatalf("Capabilities failed: %v", err)
+	}
+	if caps.Version != "1.0.0" || !caps.Supports("plugin.list") || caps.Supports("plugin.install") || !caps.HasFeature("stream.heartbeat", 1) {
+		t.Errorf("unexpected capabilities: %+v", caps)
+	}
+
+	_, err = client.Call(ctx, "plugin.install", map[string]interface{}{"path": "/tmp/p.wasm"})
+	var upgrade *UpgradeRequiredError
+	if !errors.As(err, &upgrade) || upgrade.Method != "plugin.install" || upgrade.DaemonVersion != "1.0.0" {
+		t.Fatalf("expected an upgrade required error, got %v", err)
+	}
+	if !errors.Is(err, ErrUpgradeRequired) || !strings.Contains(err.Error(), "upgrade the Forge daemon") {
+		t.Errorf("expected a clear upgrade message, got %v", err)
+	}
+	if err := client.RequireFeature(ctx, "plugin.install.url", 1); !errors.Is(err, ErrUpgradeRequired) {
+		t.Errorf("expected the missing feature to require an upgrade, got %v", err)
+	}
+	if _, err := client.Call(ctx, "plugin.list", nil); err != nil {
+		t.Errorf("expected a supported method to be called, got %v", err)
+	}
+
+	// The gated call never reached the daemon, and capabilities were cached
+	if got := received(); !reflect.DeepEqual(got, []string{"capabilities", "plugin.list"}) {
+		t.Errorf("unexpected requests: %v", got)
+	}
+}
+
+func TestClientCapabilitiesLegacyDaemon(t *testing.T) {
+	ctx := context.Background()
+
+	// A daemon that predates capabilities only knows ping
+	client, _ := fakeDaemon(t, func(req Request) Response {
+		if req.Method == "ping" {
+			return Response{Result: map[string]interface{}{"pong": true}}
+		}
+		return Response{Error: "unknown method: " + req.Method}
+	})
+
+	caps, err := client.Capabilities(ctx)
+	if err != nil || caps != nil {
+		t.Fatalf("expected no capabilities from a legacy daemon, got %+v, %v", caps, err)
+	}
+	if _, err := client.Call(ctx, "ping", nil); err != nil {
+		t.Errorf("expected calls to pass through, got %v", err)
+	}
+	_, err = client.Call(ctx, "trace.slowest", nil)
+	var upgrade *UpgradeRequiredError
+	if !errors.As(err, &upgrade) || upgrade.Method != "trace.slowest" || upgrade.DaemonVersion != "" {
+		t.Errorf("expected the unknown method to require an upgrade, got %v", err)
+	}
+	if err := client.RequireFeature(ctx, "auth.session", 1); !errors.Is(err, ErrUpgradeRequired) {
+		t.Errorf("expected features to be missing on a legacy daemon, got %v", err)
+	}
+}
+
+func TestLogListFilters(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+	ctx := context.Background()
+
+	base := time.Date(2024, 1, 1, 14, 0, 0, 0, time.UTC)
+	for i, level := range []domain.LogLevel{domain.LogLevelInfo, domain.LogLevelWarning, domain.LogLevelError, domain.LogLevelFatal} {
+		entry := domain.NewLogEntry(level, "msg", "stdout", "api")
+		entry.Timestamp = base.Add(time.Duration(i) * 20 * time.Minute)
+		entry.SetAttribute("pod", fmt.Sprintf("api-%d", i%2))
+		if err := server.logSvc.Ingest(ctx, entry); err != nil {
+			t.Fatalf("Ingest failed: %v", err)
+		}
+	}
+
+	list := func(params map[string]interface{}) []interface{} {
+		t.Helper()
+		resp, err := server.handleRequest(ctx, &Request{Method: "log.list", Params: params})
+		if err != nil {
+			t.Fatalf("log.list failed: %v", err)
+		}
+		return resp.(map[string]interface{})["logs"].([]interface{})
+	}
+
+	// Warning or above between 14:00 and 14:45 excludes info and the 15:00 fatal
+	logs := list(map[string]interface{}{
+		"min_level":  "warn",
+		"start_time": "2024-01-01T14:00:00Z",
+		"end_time":   "2024-01-01T14:45:00Z",
+	})
+	if len(logs) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(logs))
+	}
+	if level := logs[0].(map[string]interface{})["level"]; level != "error" {
+		t.Errorf("expected newest entry to be the error, got %v", level)
+	}
+
+	logs = list(map[string]interface{}{"attributes": map[string]interface{}{"pod": "api-1"}, "offset": float64(1)})
+	if len(logs) != 1 || logs[0].(map[string]interface{})["level"] != "warning" {
+		t.Errorf("expected second api-1 entry to be the warning, got %v", logs)
+	}
+
+	for _, params := range []map[string]interface{}{
+		{"level": "error", "min_level": "warning"},
+		{"min_level": "loud"},
+		{"end_time": "yesterday"},
+		{"start_time": "2024-01-01T15:00:00Z", "end_time": "2024-01-01T14:00:00Z"},
+		{"offset": float64(-1)},
+	} {
+		if _, err := server.handleRequest(ctx, &Request{Method: "log.list", Params: params}); err == nil {
+			t.Errorf("expected log.list to reject %v", params)
+		}
+	}
+}
+
+func TestLogIngestAndParsers(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+	ctx := context.Background()
+
+	call := func(method string, params map[string]interface{}) map[string]interface{} {
+		t.Helper()
+		resp, err := server.handleRequest(ctx, &Request{Method: method, Params: params})
+		if err != nil {
+			t.Fatalf("%s failed: %v", method, err)
+		}
+		return resp.(map[string]interface{})
+	}
+
+	created := call("log.parser.create", map[string]interface{}{
+		"name":     "app",
+		"type":     "regex",
+		"pattern":  `^(?P<level>\w+): (?P<message>.*)$`,
+		"priority": float64(3),
+	})
+
+	resp := call("log.ingest", map[string]interface{}{
+		"source": "myapp",
+		"lines":  []interface{}{"ERROR: disk full", "free text"},
+	})
+	if resp["ingested"] != 2 || resp["parsed"] != 1 || resp["unparsed"] != 1 {
+		t.Errorf("unexpected ingest result: %v", resp)
+	}
+
+	resp = call("log.ingest", map[string]interface{}{
+		"entry": map[string]interface{}{
+			"message":    "hello",
+			"level":      "info",
+			"timestamp":  "2024-01-01T10:00:00Z",
+			"attributes": map[string]interface{}{"pod": "a"},
+		},
+	})
+	if resp["ingested"] != 1 {
+		t.Errorf("unexpected single entry result: %v", resp)
+	}
+
+	logs := call("log.list", map[string]interface{}{"source": "myapp", "level": "error"})["logs"].([]interface{})
+	if len(logs) != 1 || logs[0].(map[string]interface{})["message"] != "disk full" {
+		t.Errorf("expected parsed error entry, got %v", logs)
+	}
+	logs = call("log.list", map[string]interface{}{"level": "unknown"})["logs"].([]interface{})
+	if len(logs) != 1 || logs[0].(map[string]interface{})["message"] != "free text" {
+		t.Errorf("expected unparsed line stored as unknown, got %v", logs)
+	}
+
+	list := call("log.parser.list", nil)
+	parsers := list["parsers"].([]interface{})
+	if len(parsers) != 1 || parsers[0].(map[string]interface{})["matches"] != int64(1) || list["unparsed"] != int64(1) {
+		t.Errorf("unexpected parser list: %v", list)
+	}
+
+	call("log.parser.delete", map[string]interface{}{"id": created["id"]})
+	if parsers := call("log.parser.list", nil)["parsers"].([]interface{}); len(parsers) != 0 {
+		t.Errorf("expected parser to be deleted, got %v", parsers)
+	}
+
+	for _, tc := range []struct {
+		method string
+		params map[string]interface{}
+	}{
+		{"log.ingest", map[string]interface{}{}},
+		{"log.ingest", map[string]interface{}{"lines": []interface{}{float64(1)}}},
+		{"log.ingest", map[string]interface{}{"entry": map[string]interface{}{"level": "info"}}},
+		{"log.ingest", map[string]interface{}{"entry": map[string]interface{}{"message": "x", "level": "loud"}}},
+		{"log.parser.create", map[string]interface{}{"name": "bad", "type": "regex", "pattern": "("}},
+		{"log.parser.create", map[string]interface{}{"name": "bad", "type": "xml"}},
+		{"log.parser.delete", map[string]interface{}{"id": "not-a-uuid"}},
+	} {
+		if _, err := server.handleRequest(ctx, &Request{Method: tc.method, Params: tc.params}); err == nil {
+			t.Errorf("expected %s to reject %v", tc.method, tc.params)
+		}
+	}
+}
+
+func TestProfileExportRoundTrip(t *testing.T) {
+	dataDir := t.TempDir()
+	server, err := NewServer(DefaultConfig(dataDir), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+
+	// Larger than one chunk so the export spans several messages
+	want := make([]byte, 2*profileExportChunkSize+123)
+	if _, err := rand.Read(want); err != nil {
+		t.Fatalf("rand.Read failed: %v", err)
+	}
+	path := filepath.Join(dataDir, "cpu.pprof")
+	if err := os.WriteFile(path, want, 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	profile := domain.NewProfile("export", domain.ProfileTypeCPU, "api", time.Second)
+	profile.Start()
+	profile.Complete(int64(len(want)), path)
+	repo := storage.NewProfileRepository(server.db)
+	if err := repo.Create(context.Background(), profile); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	export := func(id string) ([]byte, map[string]interface{}, error) {
+		serverConn, clientConn := net.Pipe()
+		defer clientConn.Close()
+		server.wg.Add(1)
+		go server.handleConnection(context.Background(), serverConn)
+
+		client := &Client{conn: clientConn, reader: bufio.NewReader(clientConn), timeout: 5 * time.Second}
+		var buf bytes.Buffer
+		summary, err := client.ExportProfile(context.Background(), id, &buf)
+		return buf.Bytes(), summary, err
+	}
+
+	got, summary, err := export(profile.ID.String())
+	if err != nil {
+		t.Fatalf("ExportProfile failed: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("expected %d exported bytes to match the stored profile, got %d", len(want), len(got))
+	}
+	if summary["type"] != "cpu" || summary["profile_id"] != profile.ID.String() {
+		t.Errorf("unexpected export summary: %v", summary)
+	}
+
+	// Profiles that have not completed cannot be exported
+	pending := domain.NewProfile("pending", domain.ProfileTypeCPU, "api", time.Second)
+	if err := repo.Create(context.Background(), pending); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, _, err := export(pending.ID.String()); err == nil {
+		t.Error("expected export of incomplete profile to fail")
+	}
+
+	// Missing data is reported rather than exporting an empty file
+	os.Remove(path)
+	if _, _, err := export(profile.ID.String()); err == nil || !strings.Contains(err.Error(), "profile data missing") {
+		t.Errorf("expected missing data error, got %v", err)
+	}
+}
+
+func TestMetricExportStreaming(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+
+	// More points than fit one message for host a, so its series is split
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	var metrics []*domain.Metric
+	for i := 0; i < metricExportChunkSize+10; i++ {
+		m := domain.NewMetric("cpu", domain.MetricTypeGauge, float64(i), map[string]string{"host": "a"})
+		m.Timestamp = start.Add(time.Duration(i) * time.Second)
+		metrics = append(metrics, m)
+	}
+	other := domain.NewMetric("cpu", domain.MetricTypeGauge, 42, map[string]string{"host": "b"})
+	other.Timestamp = start
+	metrics = append(metrics, other)
+	if err := storage.NewMetricRepository(server.db).RecordBatch(context.Background(), metrics); err != nil {
+		t.Fatalf("RecordBatch failed: %v", err)
+	}
+
+	export := func(params map[string]interface{}) (map[string]int, int, map[string]interface{}, error) {
+		serverConn, clientConn := net.Pipe()
+		server.wg.Add(1)
+		go server.handleConnection(context.Background(), serverConn)
+
+		client := &Client{conn: clientConn, reader: bufio.NewReader(clientConn), timeout: 5 * time.Second}
+		perHost := make(map[string]int)
+		chunks := 0
+		summary, err := client.ExportMetrics(context.Background(), params, func(series map[string]interface{}, points []interface{}) error {
+			tags, _ := series["tags"].(map[string]interface{})
+			host, _ := tags["host"].(string)
+			perHost[host] += len(points)
+			chunks++
+			return nil
+		})
+		return perHost, chunks, summary, err
+	}
+
+	params := map[string]interface{}{
+		"name":  "cpu",
+		"start": start.Add(-time.Minute).Format(time.RFC3339),
+		"end":   time.Now().Format(time.RFC3339),
+	}
+	perHost, chunks, summary, err := export(params)
+	if err != nil {
+		t.Fatalf("ExportMetrics failed: %v", err)
+	}
+	if perHost["a"] != metricExportChunkSize+10 || perHost["b"] != 1 {
+		t.Errorf("expected every point of both series, got %v", perHost)
+	}
+	if chunks != 3 {
+		t.Errorf("expected host a split across two messages plus one for host b, got %d messages", chunks)
+	}
+	if summary["series"] != float64(2) || summary["points"] != float64(metricExportChunkSize+11) {
+		t.Errorf("unexpected export summary: %v", summary)
+	}
+
+	// Tags narrow the export to matching series
+	params["tags"] = map[string]interface{}{"host": "b"}
+	perHost, _, _, err = export(params)
+	if err != nil {
+		t.Fatalf("ExportMetrics with tags failed: %v", err)
+	}
+	if len(perHost) != 1 || perHost["b"] != 1 {
+		t.Errorf("expected only host b, got %v", perHost)
+	}
+
+	if _, _, _, err := export(map[string]interface{}{}); err == nil || !strings.Contains(err.Error(), "name is required") {
+		t.Errorf("expected an error without a name, got %v", err)
+	}
+
+	if _, err := server.handleRequest(context.Background(), &Request{Method: "metric.export", Params: params}); err == nil || !strings.Contains(err.Error(), "requires a streaming connection") {
+		t.Errorf("expected metric.export to require streaming, got %v", err)
+	}
+}
+
+// fakePluginRuntime records events published through the daemon. Its
+// plugins all export on_tick, which fails for plugins listed in failing;
+// reloading a failing plugin fails too. Configuration is checked against
+// schema when it is set.
+type fakePluginRuntime struct {
+	events   []publishedEvent
+	mu       sync.Mutex
+	plugins  []*domain.Plugin
+	failing  map[string]bool
+	reloaded []string
+	schema   *domain.PluginConfigSchema
+}
+
+// publishedEvent is an event captured by fakePluginRuntime.
+type publishedEvent struct {
+	Type    string
+	Payload string
+}
+
+func (f *fakePluginRuntime) LoadPlugin(ctx context.Context, plugin *domain.Plugin) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.plugins = append(f.plugins, plugin)
+	return nil
+}
+func (f *fakePluginRuntime) UnloadPlugin(ctx context.Context, pluginID string) error { return nil }
+func (f *fakePluginRuntime) CallFunction(ctx context.Context, pluginID, funcName string, args ...interface{}) (interface{}, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failing[pluginID] {
+		return nil, fmt.Errorf("plugin trapped")
+	}
+	return uint64(0), nil
+}
+func (f *fakePluginRuntime) Close() error { return nil }
+
+func (f *fakePluginRuntime) ReloadPlugin(ctx context.Context, pluginID, expectedHash string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failing[pluginID] {
+		return false, fmt.Errorf("failed to instantiate new plugin binary: invalid magic number")
+	}
+	for _, p := range f.plugins {
+		if p.ID.String() != pluginID {
+			continue
+		}
+		if expectedHash == p.Hash {
+			return false, nil
+		}
+		p.Hash = expectedHash
+		f.reloaded = append(f.reloaded, pluginID)
+		return true, nil
+	}
+	return false, fmt.Errorf("plugin not loaded: %s", pluginID)
+}
+
+func (f *fakePluginRuntime) ListLoadedPlugins() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var ids []string
+	for _, p := range f.plugins {
+		ids = append(ids, p.ID.String())
+	}
+	return ids
+}
+
+func (f *fakePluginRuntime) HasFunction(pluginID, funcName string) bool {
+	return funcName == "on_tick"
+}
+
+func (f *fakePluginRuntime) GetPlugin(pluginID string) (*domain.Plugin, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, p := range f.plugins {
+		if p.ID.String() == pluginID {
+			copied := *p
+			return &copied, true
+		}
+	}
+	return nil, false
+}
+
+func (f *fakePluginRuntime) ConfigurePlugin(ctx context.Context, pluginID string, config map[string]string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.schema != nil {
+		if _, err := f.schema.Validate(config); err != nil {
+			return err
+		}
+	}
+	for _, p := range f.plugins {
+		if p.ID.String() == pluginID {
+			p.Config = config
+			return nil
+		}
+	}
+	return fmt.Errorf("plugin not loaded: %s", pluginID)
+}
+
+func (f *fakePluginRuntime) PublishEvent(eventType string, payload []byte) error {
+	f.events = append(f.events, publishedEvent{Type: eventType, Payload: string(payload)})
+	return nil
+}
+
+func (f *fakePluginRuntime) StorageUsage(name string) (*ports.PluginStorageUsage, error) {
+	return &ports.PluginStorageUsage{}, nil
+}
+
+func (f *fakePluginRuntime) PurgeStorage(ctx context.Context, name string) error { return nil }
+
+func TestEventPublish(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+	ctx := context.Background()
+
+	if _, err := server.handleRequest(ctx, &Request{Method: "event.publish", Params: map[string]interface{}{"type": "alert.fired"}}); err == nil {
+		t.Error("expected error without a plugin runtime")
+	}
+
+	rt := &fakePluginRuntime{}
+	server.SetPluginRuntime(rt)
+
+	_, err = server.handleRequest(ctx, &Request{Method: "event.publish", Params: map[string]interface{}{
+		"type":    "alert.fired",
+		"payload": map[string]interface{}{"rule": "cpu"},
+	}})
+	if err != nil {
+		t.Fatalf("event.publish failed: %v", err)
+	}
+	_, err = server.handleRequest(ctx, &Request{Method: "event.publish", Params: map[string]interface{}{
+		"type":    "deploy.finished",
+		"payload": "v1.2.3",
+	}})
+	if err != nil {
+		t.Fatalf("event.publish failed: %v", err)
+	}
+	if _, err := server.handleRequest(ctx, &Request{Method: "event.publish", Params: map[string]interface{}{}}); err == nil {
+		t.Error("expected error for missing type")
+	}
+
+	want := []publishedEvent{
+		{Type: "alert.fired", Payload: `{"rule":"cpu"}`},
+		{Type: "deploy.finished", Payload: "v1.2.3"},
+	}
+	if len(rt.events) != len(want) {
+		t.Fatalf("expected %d events, got %d", len(want), len(rt.events))
+	}
+	for i := range want {
+		if rt.events[i] != want[i] {
+			t.Errorf("event %d: expected %+v, got %+v", i, want[i], rt.events[i])
+		}
+	}
+}
+
+func TestPluginList(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+
+	listPlugins := func() []interface{} {
+		t.Helper()
+		resp, err := server.handleRequest(context.Background(), &Request{Method: "plugin.list"})
+		if err != nil {
+			t.Fatalf("plugin.list failed: %v", err)
+		}
+		return resp.(map[string]interface{})["plugins"].([]interface{})
+	}
+
+	if plugins := listPlugins(); len(plugins) != 0 {
+		t.Errorf("expected no plugins without a runtime, got %d", len(plugins))
+	}
+
+	healthy := domain.NewPlugin("healthy", "1.0.0", "healthy.wasm")
+	healthy.MarkLoaded()
+	broken := domain.NewPlugin("broken", "2.0.0", "broken.wasm")
+	broken.MarkLoaded()
+	rt := &fakePluginRuntime{
+		plugins: []*domain.Plugin{healthy, broken},
+		failing: map[string]bool{broken.ID.String(): true},
+	}
+	server.SetPluginRuntime(rt)
+	server.pluginSched = services.NewPluginScheduler(rt, &services.NopLogger{}, services.PluginSchedulerConfig{
+		DefaultInterval: 5 * time.Millisecond,
+		MaxBackoff:      10 * time.Millisecond,
+		DegradedAfter:   2,
+	})
+	server.pluginSched.Start(context.Background())
+	defer server.pluginSched.Stop()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status, _ := server.pluginSched.Status(broken.ID.String())
+		ok, _ := server.pluginSched.Status(healthy.ID.String())
+		if status.Degraded && ok.LastTick != nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for plugin ticks")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	byName := make(map[string]map[string]interface{})
+	for _, p := range listPlugins() {
+		entry := p.(map[string]interface{})
+		byName[entry["name"].(string)] = entry
+	}
+	if len(byName) != 2 {
+		t.Fatalf("expected 2 plugins, got %d", len(byName))
+	}
+
+	if got := byName["healthy"]; got["status"] != domain.PluginStatusActive || got["last_tick"] == nil || got["tick_error_count"] != int64(0) {
+		t.Errorf("unexpected healthy plugin entry: %v", got)
+	}
+	got := byName["broken"]
+	if got["status"] != domain.PluginStatusDegraded {
+		t.Errorf("expected broken plugin to be degraded, got %v", got["status"])
+	}
+	if n, _ := got["tick_error_count"].(int64); n < 2 {
+		t.Errorf("expected at least 2 tick errors, got %v", got["tick_error_count"])
+	}
+	if got["last_tick_error"] == nil || got["last_tick_duration_ms"] == nil {
+		t.Errorf("expected tick error and duration, got %v", got)
+	}
+}
+
+func TestPluginReload(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+	ctx := context.Background()
+
+	reload := func(params map[string]interface{}) (map[string]interface{}, error) {
+		resp, err := server.handleRequest(ctx, &Request{Method: "plugin.reload", Params: params})
+		if err != nil {
+			return nil, err
+		}
+		return resp.(map[string]interface{}), nil
+	}
+
+	if _, err := reload(map[string]interface{}{"name": "dev"}); err == nil {
+		t.Error("expected error without a plugin runtime")
+	}
+
+	dev := domain.NewPlugin("dev", "1.0.0", "dev.wasm")
+	dev.Hash = "aaa"
+	broken := domain.NewPlugin("broken", "1.0.0", "broken.wasm")
+	rt := &fakePluginRuntime{
+		plugins: []*domain.Plugin{dev, broken},
+		failing: map[string]bool{broken.ID.String(): true},
+	}
+	server.SetPluginRuntime(rt)
+
+	resp, err := reload(map[string]interface{}{"name": "dev", "sha256": "bbb"})
+	if err != nil {
+		t.Fatalf("plugin.reload failed: %v", err)
+	}
+	if resp["status"] != "reloaded" || resp["hash"] != "bbb" || resp["id"] != dev.ID.String() {
+		t.Errorf("unexpected response: %v", resp)
+	}
+
+	// Plugins can be selected by ID as well as by name
+	resp, err = reload(map[string]interface{}{"name": dev.ID.String(), "sha256": "bbb"})
+	if err != nil || resp["status"] != "unchanged" {
+		t.Errorf("expected unchanged reload by ID, got %v (%v)", resp, err)
+	}
+
+	if _, err := reload(map[string]interface{}{"name": "broken"}); err == nil || !strings.Contains(err.Error(), "failed to instantiate") {
+		t.Errorf("expected instantiate error to be reported, got %v", err)
+	}
+	if _, err := reload(map[string]interface{}{"name": "missing"}); err == nil {
+		t.Error("expected error for unknown plugin")
+	}
+	if _, err := reload(map[string]interface{}{}); err == nil {
+		t.Error("expected error without a name")
+	}
+	if len(rt.reloaded) != 1 {
+		t.Errorf("expected one reload, got %v", rt.reloaded)
+	}
+}
+
+func TestPluginConfigure(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+	ctx := context.Background()
+
+	schema, err := domain.ParsePluginConfigSchema(`{
+		"type": "object",
+		"properties": {
+			"interval": {"type": "integer", "minimum": 1},
+			"collect_cpu": {"type": "boolean"}
+		},
+		"required": ["interval"]
+	}`)
+	if err != nil {
+		t.Fatalf("ParsePluginConfigSchema failed: %v", err)
+	}
+	metrics := domain.NewPlugin("system-metrics", "1.0.0", "system-metrics.wasm")
+	metrics.Config["interval"] = "10"
+	rt := &fakePluginRuntime{plugins: []*domain.Plugin{metrics}, schema: schema}
+	server.SetPluginRuntime(rt)
+
+	configure := func(config map[string]interface{}) (interface{}, error) {
+		return server.handleRequest(ctx, &Request{Method: "plugin.configure", Params: map[string]interface{}{
+			"name":   "system-metrics",
+			"config": config,
+		}})
+	}
+
+	_, err = configure(map[string]interface{}{"interval": json.Number("0"), "collect_cpu": "maybe"})
+	if err == nil || !strings.Contains(err.Error(), "interval must be >= 1") || !strings.Contains(err.Error(), "collect_cpu must be a boolean") {
+		t.Fatalf("expected every schema problem in the error, got %v", err)
+	}
+	if metrics.Config["interval"] != "10" {
+		t.Errorf("expected the previous configuration to be kept, got %v", metrics.Config)
+	}
+
+	resp, err := configure(map[string]interface{}{"interval": json.Number("30"), "collect_cpu": false})
+	if err != nil {
+		t.Fatalf("plugin.configure failed: %v", err)
+	}
+	if resp.(map[string]interface{})["status"] != "configured" {
+		t.Errorf("unexpected response: %v", resp)
+	}
+	if metrics.Config["interval"] != "30" || metrics.Config["collect_cpu"] != "false" {
+		t.Errorf("expected numbers and booleans as strings, got %v", metrics.Config)
+	}
+
+	if _, err := configure(map[string]interface{}{"interval": []interface{}{1}}); err == nil {
+		t.Error("expected a nested config value to be rejected")
+	}
+	if _, err := server.handleRequest(ctx, &Request{Method: "plugin.configure", Params: map[string]interface{}{
+		"name": "missing", "config": map[string]interface{}{"interval": "1"},
+	}}); err == nil {
+		t.Error("expected an error for a plugin that is not loaded")
+	}
+}
+
+func TestPluginInstallFromURL(t *testing.T) {
+	wasmBytes := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	sum := sha256.Sum256(wasmBytes)
+	checksum := hex.EncodeToString(sum[:])
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(wasmBytes)
+	}))
+	defer srv.Close()
+
+	cfg := DefaultConfig(t.TempDir())
+	server, err := NewServer(cfg, &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+	ctx := context.Background()
+
+	install := func(params map[string]interface{}) (map[string]interface{}, error) {
+		resp, err := server.handleRequest(ctx, &Request{Method: "plugin.install", Params: params})
+		if err != nil {
+			return nil, err
+		}
+		return resp.(map[string]interface{}), nil
+	}
+
+	if _, err := install(map[string]interface{}{"url": srv.URL + "/hello.wasm", "sha256": checksum}); err == nil {
+		t.Error("expected error without a plugin runtime")
+	}
+	rt := &fakePluginRuntime{}
+	server.SetPluginRuntime(rt)
+
+	if _, err := install(map[string]interface{}{"url": srv.URL + "/hello.wasm"}); err == nil || !strings.Contains(err.Error(), "sha256 is required") {
+		t.Errorf("expected a checksum to be required, got %v", err)
+	}
+	if _, err := install(map[string]interface{}{"url": srv.URL + "/hello.wasm", "sha256": strings.Repeat("0", 64)}); err == nil || !strings.Contains(err.Error(), "hash mismatch") {
+		t.Errorf("expected a mismatching checksum to be rejected, got %v", err)
+	}
+	if len(rt.ListLoadedPlugins()) != 0 {
+		t.Fatal("expected nothing to be loaded after a failed install")
+	}
+
+	resp, err := install(map[string]interface{}{"url": srv.URL + "/hello.wasm", "sha256": checksum})
+	if err != nil {
+		t.Fatalf("plugin.install failed: %v", err)
+	}
+	want := filepath.Join(cfg.PluginDir, "hello.wasm")
+	if resp["name"] != "hello" || resp["path"] != want || resp["hash"] != checksum {
+		t.Errorf("unexpected response: %v", resp)
+	}
+	if data, err := os.ReadFile(want); err != nil || !bytes.Equal(data, wasmBytes) {
+		t.Errorf("expected the plugin to be saved to %s: %v", want, err)
+	}
+	if ids := rt.ListLoadedPlugins(); len(ids) != 1 {
+		t.Errorf("expected the plugin to be loaded, got %v", ids)
+	}
+
+	if _, err := install(map[string]interface{}{}); err == nil {
+		t.Error("expected error without a path or url")
+	}
+}
+
+// leb128 encodes a non-negative n as a signed LEB128 integer, which is also
+// a valid unsigned encoding.
+func leb128(n int) []byte {
+	var out []byte
+	for {
+		b := byte(n & 0x7f)
+		n >>= 7
+		if n == 0 && b&0x40 == 0 {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+// fetcherModule returns a guest whose forge_manifest export returns
+// manifest and whose fetch export GETs target through forge_http_request
+// and returns the status.
+func fetcherModule(manifest []byte, target string) []byte {
+	const methodAt, urlAt = 4096, 4104
+	section := func(id byte, content []byte) []byte {
+		return append(append([]byte{id}, leb128(len(content))...), content...)
+	}
+	name := func(s string) []byte { return append(leb128(len(s)), s...) }
+
+	module := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	// Types: 0 = () -> i64, 1 = (i32 x6) -> (i32, i32, i32), 2 = () -> i32
+	module = append(module, section(1, []byte{
+		0x03,
+		0x60, 0x00, 0x01, 0x7e,
+		0x60, 0x06, 0x7f, 0x7f, 0x7f, 0x7f, 0x7f, 0x7f, 0x03, 0x7f, 0x7f, 0x7f,
+		0x60, 0x00, 0x01, 0x7f,
+	})...)
+	imp := append([]byte{0x01}, name("forge")...)
+	imp = append(imp, name("forge_http_request")...)
+	module = append(module, section(2, append(imp, 0x00, 0x01))...)
+	// Functions: 1 = forge_manifest, 2 = fetch
+	module = append(module, section(3, []byte{0x02, 0x00, 0x02})...)
+	module = append(module, section(5, []byte{0x01, 0x00, 0x01})...)
+	exp := append([]byte{0x03}, name("memory")...)
+	exp = append(exp, 0x02, 0x00)
+	exp = append(exp, name("forge_manifest")...)
+	exp = append(exp, 0x00, 0x01)
+	exp = append(exp, name("fetch")...)
+	exp = append(exp, 0x00, 0x02)
+	module = append(module, section(7, exp)...)
+
+	// forge_manifest: i64.const len (the pointer is 0)
+	manifestBody := append([]byte{0x00, 0x42}, leb128(len(manifest))...)
+	manifestBody = append(manifestBody, 0x0b)
+	// fetch: call forge_http_request(method, url, no body); drop the response
+	fetchBody := []byte{0x00}
+	for _, arg := range []int{methodAt, 3, urlAt, len(target), 0, 0} {
+		fetchBody = append(append(fetchBody, 0x41), leb128(arg)...)
+	}
+	fetchBody = append(fetchBody, 0x10, 0x00, 0x1a, 0x1a, 0x0b)
+	code := []byte{0x02}
+	code = append(append(code, leb128(len(manifestBody))...), manifestBody...)
+	code = append(append(code, leb128(len(fetchBody))...), fetchBody...)
+	module = append(module, section(10, code)...)
+
+	data := []byte{0x03}
+	for _, segment := range []struct {
+		at    int
+		bytes []byte
+	}{{0, manifest}, {methodAt, []byte("GET")}, {urlAt, []byte(target)}} {
+		data = append(append(data, 0x00, 0x41), leb128(segment.at)...)
+		data = append(append(data, 0x0b), leb128(len(segment.bytes))...)
+		data = append(data, segment.bytes...)
+	}
+	return append(module, section(11, data)...)
+}
+
+func TestPluginInstallGrantsManifestPermissions(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer target.Close()
+
+	dir := t.TempDir()
+	cfg := DefaultConfig(dir)
+	ctx := context.Background()
+	start := func() (*Server, *wasm.Runtime) {
+		t.Helper()
+		server, err := NewServer(cfg, &services.NopLogger{})
+		if err != nil {
+			t.Fatalf("NewServer failed: %v", err)
+		}
+		t.Cleanup(func() { server.db.Close() })
+		rt, err := wasm.NewRuntimeWithOptions(ctx, &services.NopLogger{}, wasm.RuntimeOptions{
+			DataDir: filepath.Join(dir, "plugin-data"),
+		})
+		if err != nil {
+			t.Fatalf("NewRuntimeWithOptions failed: %v", err)
+		}
+		t.Cleanup(func() { rt.Close() })
+		server.SetPluginRuntime(rt)
+		return server, rt
+	}
+	install := func(server *Server, name string, manifest map[string]interface{}) {
+		t.Helper()
+		data, err := json.Marshal(manifest)
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+		path := filepath.Join(dir, name+".wasm")
+		if err := os.WriteFile(path, fetcherModule(data, target.URL), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := server.handleRequest(ctx, &Request{Method: "plugin.install", Params: map[string]interface{}{"path": path}}); err != nil {
+			t.Fatalf("plugin.install %s failed: %v", name, err)
+		}
+	}
+	fetch := func(server *Server, rt *wasm.Runtime, name string) int32 {
+		t.Helper()
+		plugin, ok := server.loadedPlugin(name)
+		if !ok {
+			t.Fatalf("plugin %s not loaded", name)
+		}
+		result, err := rt.CallFunction(ctx, plugin.ID.String(), "fetch")
+		if err != nil {
+			t.Fatalf("fetch failed: %v", err)
+		}
+		return int32(result.(uint64))
+	}
+
+	server, rt := start()
+	install(server, "fetcher", map[string]interface{}{
+		"name":          "fetcher",
+		"version":       "1.0.0",
+		"permissions":   []string{"network"},
+		"allowed_hosts": []string{"127.0.0.1"},
+	})
+	install(server, "offline", map[string]interface{}{
+		"name":          "offline",
+		"version":       "1.0.0",
+		"allowed_hosts": []string{"127.0.0.1"},
+	})
+	if status := fetch(server, rt, "fetcher"); status != http.StatusOK {
+		t.Errorf("expected the permitted host to be reached, got %d", status)
+	}
+	if status := fetch(server, rt, "offline"); status != wasm.ErrCodeHostNotAllowed {
+		t.Errorf("expected a plugin without the network permission to be denied, got %d", status)
+	}
+
+	// The permissions are stored with the plugin and apply after a restart
+	server.db.Close()
+	server, rt = start()
+	server.loadStoredPlugins(ctx)
+	if status := fetch(server, rt, "fetcher"); status != http.StatusOK {
+		t.Errorf("expected the reloaded plugin to reach the permitted host, got %d", status)
+	}
+	plugin, _ := server.loadedPlugin("fetcher")
+	if !plugin.HasPermission(domain.PermissionNetwork) || len(plugin.AllowedHosts) != 1 {
+		t.Errorf("expected the stored permissions, got %v %v", plugin.Permissions, plugin.AllowedHosts)
+	}
+}
+
+func TestPluginStorage(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+	ctx := context.Background()
+
+	// The runtime's data directory and quota apply, not the defaults
+	dataDir := t.TempDir()
+	rt, err := wasm.NewRuntimeWithOptions(ctx, &services.NopLogger{}, wasm.RuntimeOptions{DataDir: dataDir, StorageQuota: 1024})
+	if err != nil {
+		t.Fatalf("NewRuntimeWithOptions failed: %v", err)
+	}
+	defer rt.Close()
+	server.SetPluginRuntime(rt)
+
+	dir := filepath.Join(dataDir, "collector")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.json", "b.json"} {
+		if err := os.WriteFile(filepath.Join(dir, name), make([]byte, 100), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	call := func(method string) map[string]interface{} {
+		t.Helper()
+		resp, err := server.handleRequest(ctx, &Request{Method: method, Params: map[string]interface{}{"name": "collector"}})
+		if err != nil {
+			t.Fatalf("%s failed: %v", method, err)
+		}
+		return resp.(map[string]interface{})
+	}
+
+	usage := call("plugin.storage")
+	if usage["dir"] != dir || usage["used"] != int64(200) || usage["files"] != 2 || usage["quota"] != int64(1024) {
+		t.Errorf("unexpected usage: %v", usage)
+	}
+	if usage := call("plugin.storage.purge"); usage["files"] != 0 {
+		t.Errorf("expected no files after a purge, got %v", usage)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("expected the data directory to be removed, got %v", err)
+	}
+
+	if _, err := server.handleRequest(ctx, &Request{Method: "plugin.storage", Params: map[string]interface{}{"name": "../etc"}}); err == nil {
+		t.Error("expected a name outside the data directory to be rejected")
+	}
+}
+
+func TestTaskCreateAndList(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+	ctx := context.Background()
+
+	create := func(params map[string]interface{}) string {
+		t.Helper()
+		resp, err := server.handleRequest(ctx, &Request{Method: "task.create", Params: params})
+		if err != nil {
+			t.Fatalf("task.create failed: %v", err)
+		}
+		return resp.(map[string]interface{})["id"].(string)
+	}
+	list := func(params map[string]interface{}) []map[string]interface{} {
+		t.Helper()
+		resp, err := server.handleRequest(ctx, &Request{Method: "task.list", Params: params})
+		if err != nil {
+			t.Fatalf("task.list failed: %v", err)
+		}
+		return resp.([]map[string]interface{})
+	}
+
+	low := create(map[string]interface{}{"type": "maintenance", "payload": map[string]interface{}{"job": "vacuum"}})
+	high := create(map[string]interface{}{"type": "maintenance", "priority": float64(10)})
+	other := create(map[string]interface{}{"type": "ai_analysis", "priority": float64(5)})
+
+	tasks := list(map[string]interface{}{"status": "pending"})
+	if len(tasks) != 3 {
+		t.Fatalf("expected 3 tasks, got %d", len(tasks))
+	}
+	if tasks[0]["id"] != high || tasks[1]["id"] != other || tasks[2]["id"] != low {
+		t.Errorf("expected tasks ordered by priority, got %v, %v, %v", tasks[0]["id"], tasks[1]["id"], tasks[2]["id"])
+	}
+	if payload, _ := tasks[2]["payload"].(map[string]interface{}); payload["job"] != "vacuum" {
+		t.Errorf("expected payload to round-trip, got %v", tasks[2]["payload"])
+	}
+
+	if tasks := list(map[string]interface{}{"type": "maintenance", "limit": float64(1), "offset": float64(1)}); len(tasks) != 1 || tasks[0]["id"] != low {
+		t.Errorf("expected second maintenance task, got %v", tasks)
+	}
+	if tasks := list(map[string]interface{}{"status": "COMPLETED"}); len(tasks) != 0 {
+		t.Errorf("expected no completed tasks, got %d", len(tasks))
+	}
+
+	for _, params := range []map[string]interface{}{
+		{"status": "done"},
+		{"offset": float64(-1)},
+	} {
+		if _, err := server.handleRequest(ctx, &Request{Method: "task.list", Params: params}); err == nil {
+			t.Errorf("expected error for %v", params)
+		}
+	}
+	if _, err := server.handleRequest(ctx, &Request{Method: "task.create", Params: map[string]interface{}{"type": "maintenance", "payload": "echo"}}); err == nil {
+		t.Error("expected error for non-object payload")
+	}
+}
+
+// echoAIProvider replies with how many user turns the conversation holds,
+// appending the reply to the conversation like the Ollama provider does.
+type echoAIProvider struct {
+	model string
+}
+
+func (p *echoAIProvider) Chat(ctx context.Context, conv *domain.Conversation) (*domain.Message, error) {
+	turns := 0
+	for _, m := range conv.Messages {
+		if m.Role == domain.RoleUser {
+			turns++
+		}
+	}
+	return conv.AddMessage(domain.RoleAssistant, fmt.Sprintf("turn %d", turns)), nil
+}
+
+func (p *echoAIProvider) ChatStream(ctx context.Context, conv *domain.Conversation, callback func(chunk string)) (*domain.Message, error) {
+	return p.Chat(ctx, conv)
+}
+
+func (p *echoAIProvider) ListModels(ctx context.Context) ([]string, error) {
+	return []string{p.model}, nil
+}
+
+func (p *echoAIProvider) GetModel() string      { return p.model }
+func (p *echoAIProvider) SetModel(model string) { p.model = model }
+
+func TestAIChatConversationPersistence(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+	server.SetAIProvider(&echoAIProvider{model: "test"})
+	ctx := context.Background()
+
+	chat := func(params map[string]interface{}) map[string]interface{} {
+		t.Helper()
+		resp, err := server.handleRequest(ctx, &Request{Method: "ai.chat", Params: params})
+		if err != nil {
+			t.Fatalf("ai.chat failed: %v", err)
+		}
+		return resp.(map[string]interface{})
+	}
+
+	first := chat(map[string]interface{}{"message": "hello"})
+	id, _ := first["conversation_id"].(string)
+	if id == "" || first["content"] != "turn 1" {
+		t.Fatalf("unexpected first reply: %v", first)
+	}
+
+	second := chat(map[string]interface{}{"message": "and again", "conversation_id": id})
+	if second["conversation_id"] != id || second["content"] != "turn 2" {
+		t.Errorf("expected second turn in the same conversation, got %v", second)
+	}
+
+	convID, _ := uuid.Parse(id)
+	conv, err := server.convRepo.GetByID(ctx, convID)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	// System prompt plus two user and two assistant turns, without duplicates
+	roles := make([]domain.MessageRole, len(conv.Messages))
+	for i, m := range conv.Messages {
+		roles[i] = m.Role
+	}
+	want := []domain.MessageRole{domain.RoleSystem, domain.RoleUser, domain.RoleAssistant, domain.RoleUser, domain.RoleAssistant}
+	if len(roles) != len(want) {
+		t.Fatalf("expected roles %v, got %v", want, roles)
+	}
+	for i := range want {
+		if roles[i] != want[i] {
+			t.Errorf("expected roles %v, got %v", want, roles)
+			break
+		}
+	}
+	if conv.Title != "hello" {
+		t.Errorf("expected title from first message, got %q", conv.Title)
+	}
+
+	// A call without an id starts over
+	if fresh := chat(map[string]interface{}{"message": "new topic"}); fresh["conversation_id"] == id || fresh["content"] != "turn 1" {
+		t.Errorf("expected a new conversation, got %v", fresh)
+	}
+
+	for _, params := range []map[string]interface{}{
+		{"message": "hi", "conversation_id": "nope"},
+		{"message": "hi", "conversation_id": uuid.NewString()},
+		{},
+	} {
+		if _, err := server.handleRequest(ctx, &Request{Method: "ai.chat", Params: params}); err == nil {
+			t.Errorf("expected ai.chat to reject %v", params)
+		}
+	}
+}
+
+func TestAIInsightsList(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		resp, err := server.handleRequest(ctx, &Request{Method: "ai.analyze", Params: map[string]interface{}{"time_range": "30m"}})
+		if err != nil {
+			t.Fatalf("ai.analyze failed: %v", err)
+		}
+		if id, _ := resp.(map[string]interface{})["insight_id"].(string); id == "" {
+			t.Errorf("expected analysis to be stored as an insight, got %v", resp)
+		}
+	}
+
+	resp, err := server.handleRequest(ctx, &Request{Method: "ai.insights.list", Params: map[string]interface{}{"since": "1h"}})
+	if err != nil {
+		t.Fatalf("ai.insights.list failed: %v", err)
+	}
+	insights := resp.(map[string]interface{})["insights"].([]interface{})
+	if len(insights) != 2 {
+		t.Fatalf("expected 2 insights, got %d", len(insights))
+	}
+	if r := insights[0].(map[string]interface{})["time_range"]; r != "30m0s" {
+		t.Errorf("expected time range 30m0s, got %v", r)
+	}
+
+	resp, _ = server.handleRequest(ctx, &Request{Method: "ai.insights.list", Params: map[string]interface{}{
+		"end_time": time.Now().Add(-time.Hour).Format(time.RFC3339),
+	}})
+	if n := resp.(map[string]interface{})["count"]; n != 0 {
+		t.Errorf("expected no insights before an hour ago, got %v", n)
+	}
+
+	for _, params := range []map[string]interface{}{
+		{"since": "soon"},
+		{"since": "1h", "start_time": "2024-01-01T00:00:00Z"},
+		{"start_time": "2024-01-02T00:00:00Z", "end_time": "2024-01-01T00:00:00Z"},
+	} {
+		if _, err := server.handleRequest(ctx, &Request{Method: "ai.insights.list", Params: params}); err == nil {
+			t.Errorf("expected ai.insights.list to reject %v", params)
+		}
+	}
+}
+
+func TestLogMetricRules(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+	ctx := context.Background()
+
+	resp, err := server.handleRequest(ctx, &Request{Method: "log.metricrule.create", Params: map[string]interface{}{
+		"name":          "errors",
+		"match_pattern": "ERROR",
+		"metric_name":   "app.error.count",
+		"tags":          map[string]interface{}{"team": "core"},
+	}})
+	if err != nil {
+		t.Fatalf("log.metricrule.create failed: %v", err)
+	}
+	id := resp.(map[string]interface{})["id"].(string)
+
+	if _, err := server.handleRequest(ctx, &Request{Method: "log.ingest", Params: map[string]interface{}{
+		"source": "app",
+		"lines":  []interface{}{"ERROR one", "fine", "ERROR two"},
+	}}); err != nil {
+		t.Fatalf("log.ingest failed: %v", err)
+	}
+
+	series, err := server.metricSvc.QueryRange(ctx, "app.error.count", time.Now().Add(-time.Minute), time.Now().Add(time.Minute), nil)
+	if err != nil {
+		t.Fatalf("QueryRange failed: %v", err)
+	}
+	if len(series.Points) != 2 || series.Points[1].Value != 2 {
+		t.Errorf("expected counter to reach 2, got %+v", series.Points)
+	}
+
+	resp, err = server.handleRequest(ctx, &Request{Method: "log.metricrule.list"})
+	if err != nil {
+		t.Fatalf("log.metricrule.list failed: %v", err)
+	}
+	rules := resp.(map[string]interface{})["rules"].([]interface{})
+	if len(rules) != 1 || rules[0].(map[string]interface{})["status"] != "active" {
+		t.Errorf("unexpected rules: %v", rules)
+	}
+
+	if _, err := server.handleRequest(ctx, &Request{Method: "log.metricrule.delete", Params: map[string]interface{}{"id": id}}); err != nil {
+		t.Fatalf("log.metricrule.delete failed: %v", err)
+	}
+
+	for _, params := range []map[string]interface{}{
+		{"name": "bad", "match_pattern": "(", "metric_name": "x"},
+		{"name": "gauge", "match_pattern": "took", "metric_name": "x", "metric_type": "gauge"},
+		{"name": "nometric", "match_pattern": "ERROR"},
+	} {
+		if _, err := server.handleRequest(ctx, &Request{Method: "log.metricrule.create", Params: params}); err == nil {
+			t.Errorf("expected log.metricrule.create to reject %v", params)
+		}
+	}
+}
+
+func TestLogMetricRuleGaugeBySource(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+	ctx := context.Background()
+
+	if _, err := server.handleRequest(ctx, &Request{Method: "log.metric.rule.create", Params: map[string]interface{}{
+		"name":          "latency",
+		"match_pattern": `took (?P<ms>\d+)ms`,
+		"metric_name":   "api.latency_ms",
+		"metric_type":   "gauge",
+		"value_field":   "ms",
+		"source":        "api",
+	}}); err != nil {
+		t.Fatalf("log.metric.rule.create failed: %v", err)
+	}
+
+	for source, lines := range map[string][]interface{}{
+		"api":    {"request took 120ms", "request took 80ms", "no timing here"},
+		"worker": {"job took 5000ms"},
+	} {
+		if _, err := server.handleRequest(ctx, &Request{Method: "log.ingest", Params: map[string]interface{}{
+			"source": source,
+			"lines":  lines,
+		}}); err != nil {
+			t.Fatalf("log.ingest failed: %v", err)
+		}
+	}
+
+	series, err := server.metricSvc.QueryRange(ctx, "api.latency_ms", time.Now().Add(-time.Minute), time.Now().Add(time.Minute), nil)
+	if err != nil {
+		t.Fatalf("QueryRange failed: %v", err)
+	}
+	var values []float64
+	for _, p := range series.Points {
+		values = append(values, p.Value)
+	}
+	sort.Float64s(values)
+	if len(values) != 2 || values[0] != 80 || values[1] != 120 {
+		t.Errorf("expected gauges 80 and 120 from the api source only, got %v", values)
+	}
+
+	resp, err := server.handleRequest(ctx, &Request{Method: "log.metric.rule.list"})
+	if err != nil {
+		t.Fatalf("log.metric.rule.list failed: %v", err)
+	}
+	rules := resp.(map[string]interface{})["rules"].([]interface{})
+	if len(rules) != 1 || rules[0].(map[string]interface{})["source"] != "api" {
+		t.Errorf("unexpected rules: %v", rules)
+	}
+}
+
+func TestOTLPIngestion(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+	ctx := context.Background()
+	handler := otlp.NewReceiver("", "", server.traceSvc, server.logSvc, server.logger).Handler()
+
+	send := func(path, body string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", path, rec.Code, rec.Body.String())
+		}
+	}
+
+	const traceID = "5b8efff798038103d269b633813fc60c"
+	spanJSON := func(service, spanID, parent, name string, start, end, code int) string {
+		return fmt.Sprintf(`{"resourceSpans": [{"resource": {"attributes": [{"key": "service.name", "value": {"stringValue": %q}}]},
+			"scopeSpans": [{"spans": [{"traceId": %q, "spanId": %q, "parentSpanId": %q, "name": %q, "kind": 2,
+			"startTimeUnixNano": "%d", "endTimeUnixNano": "%d", "status": {"code": %d}}]}]}]}`,
+			service, traceID, spanID, parent, name, start, end, code)
+	}
+
+	// The downstream service exports before the gateway does
+	base := 1700000000000000000
+	send("/v1/traces", spanJSON("payments", "eee19b7ec3c1b175", "eee19b7ec3c1b174", "charge", base+10e6, base+90e6, 2))
+	send("/v1/traces", spanJSON("gateway", "eee19b7ec3c1b174", "", "POST /checkout", base, base+100e6, 1))
+	send("/v1/logs", fmt.Sprintf(`{"resourceLogs": [{"resource": {"attributes": [{"key": "service.name", "value": {"stringValue": "payments"}}]},
+		"scopeLogs": [{"logRecords": [{"severityNumber": 17, "body": {"stringValue": "card declined"}, "traceId": %q, "spanId": "eee19b7ec3c1b175"}]}]}]}`, traceID))
+
+	result, err := server.handleRequest(ctx, &Request{Method: "trace.get", Params: map[string]interface{}{"trace_id": traceID}})
+	if err != nil {
+		t.Fatalf("trace.get failed: %v", err)
+	}
+	trace := result.(map[string]interface{})["trace"].(map[string]interface{})
+	if trace["name"] != "POST /checkout" || trace["service_name"] != "gateway" {
+		t.Errorf("expected the root span to name the trace, got %v", trace)
+	}
+	if trace["span_count"] != 2 || trace["error_count"] != 1 || trace["status"] != "error" {
+		t.Errorf("unexpected trace summary: %v", trace)
+	}
+
+	logs, err := server.logSvc.GetLogsByTraceID(ctx, traceID)
+	if err != nil {
+		t.Fatalf("GetLogsByTraceID failed: %v", err)
+	}
+	if len(logs) != 1 || logs[0].Message != "card declined" || logs[0].Level != domain.LogLevelError {
+		t.Errorf("expected the correlated error log, got %v", logs)
+	}
+}
+
+// chunkAIProvider streams a fixed reply in pieces. With failAfter set it
+// fails once that many chunks were sent; with blockAfter set it waits for
+// cancellation instead and reports it on cancelled.
+type chunkAIProvider struct {
+	echoAIProvider
+	chunks     []string
+	failAfter  int
+	blockAfter int
+	cancelled  chan struct{}
+}
+
+func (p *chunkAIProvider) ChatStream(ctx context.Context, conv *domain.Conversation, callback func(chunk string)) (*domain.Message, error) {
+	var full strings.Builder
+	for i, chunk := range p.chunks {
+		if p.failAfter > 0 && i == p.failAfter {
+			return nil, fmt.Errorf("model crashed")
+		}
+		if p.blockAfter > 0 && i == p.blockAfter {
+			<-ctx.Done()
+			close(p.cancelled)
+			return nil, ctx.Err()
+		}
+		full.WriteString(chunk)
+		callback(chunk)
+	}
+	return conv.AddMessage(domain.RoleAssistant, full.String()), nil
+}
+
+func TestAIChatStream(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+
+	chat := func(ctx context.Context, onChunk func(string)) (map[string]interface{}, error) {
+		serverConn, clientConn := net.Pipe()
+		server.wg.Add(1)
+		go server.handleConnection(context.Background(), serverConn)
+		client := &Client{conn: clientConn, reader: bufio.NewReader(clientConn), timeout: 5 * time.Second}
+		return client.ChatStream(ctx, map[string]interface{}{"message": "how is the disk?"}, onChunk)
+	}
+
+	t.Run("reassembles chunks", func(t *testing.T) {
+		server.SetAIProvider(&chunkAIProvider{echoAIProvider: echoAIProvider{model: "test"}, chunks: []string{"Disk ", "usage ", "is ", "fine."}})
+
+		var received []string
+		final, err := chat(context.Background(), func(chunk string) { received = append(received, chunk) })
+		if err != nil {
+			t.Fatalf("ChatStream failed: %v", err)
+		}
+		if len(received) != 4 {
+			t.Errorf("expected 4 chunks, got %v", received)
+		}
+		if got := strings.Join(received, ""); got != "Disk usage is fine." || final["content"] != got {
+			t.Errorf("expected chunks to reassemble the reply, got %q and final %v", got, final["content"])
+		}
+
+		id, err := uuid.Parse(final["conversation_id"].(string))
+		if err != nil {
+			t.Fatalf("invalid conversation_id: %v", err)
+		}
+		conv, err := server.convRepo.GetByID(context.Background(), id)
+		if err != nil {
+			t.Fatalf("conversation not saved: %v", err)
+		}
+		if last := conv.GetLastMessage(); last == nil || last.Content != "Disk usage is fine." {
+			t.Errorf("expected streamed reply to be persisted, got %v", last)
+		}
+	})
+
+	t.Run("mid-stream error", func(t *testing.T) {
+		server.SetAIProvider(&chunkAIProvider{echoAIProvider: echoAIProvider{model: "test"}, chunks: []string{"a", "b", "c"}, failAfter: 2})
+
+		var received []string
+		_, err := chat(context.Background(), func(chunk string) { received = append(received, chunk) })
+		if err == nil || !strings.Contains(err.Error(), "model crashed") {
+			t.Errorf("expected the provider error, got %v", err)
+		}
+		if len(received) != 2 {
+			t.Errorf("expected the chunks sent before the failure, got %v", received)
+		}
+	})
+
+	t.Run("cancellation", func(t *testing.T) {
+		provider := &chunkAIProvider{echoAIProvider: echoAIProvider{model: "test"}, chunks: []string{"x", "y"}, blockAfter: 1, cancelled: make(chan struct{})}
+		server.SetAIProvider(provider)
+		before, _ := server.convRepo.List(context.Background(), 0, 0)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		_, err := chat(ctx, func(chunk string) { cancel() })
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+
+		select {
+		case <-provider.cancelled:
+		case <-time.After(5 * time.Second):
+			t.Fatal("provider was not cancelled after the client left")
+		}
+		after, _ := server.convRepo.List(context.Background(), 0, 0)
+		if len(after) != len(before) {
+			t.Errorf("expected the cancelled turn not to be saved")
+		}
+	})
+}
+
+func TestMetricDownsampleTiers(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+	ctx := context.Background()
+	repo := storage.NewMetricRepository(server.db)
+
+	// Three hours of per-minute points for two hosts; the first two hours
+	// are past retention
+	base := time.Now().Truncate(time.Hour).Add(-3 * time.Hour)
+	var metrics []*domain.Metric
+	for _, host := range []string{"a", "b"} {
+		for i := 0; i < 180; i++ {
+			m := domain.NewMetric("cpu.usage", domain.MetricTypeGauge, float64(i%60), map[string]string{"host": host})
+			m.Timestamp = base.Add(time.Duration(i)*time.Minute + 30*time.Second)
+			metrics = append(metrics, m)
+		}
+	}
+	if err := repo.RecordBatch(ctx, metrics); err != nil {
+		t.Fatalf("RecordBatch failed: %v", err)
+	}
+	hashA := metrics[0].SeriesHash
+	cutoff := base.Add(2 * time.Hour)
+
+	// An earlier run was interrupted after writing one window for host a
+	partial := &domain.AggregatedMetric{
+		ID: domain.NewUUIDv7(), Name: "cpu.usage", Tags: map[string]string{"host": "a"}, SeriesHash: hashA,
+		WindowStart: base, WindowEnd: base.Add(time.Hour), Count: 1, Sum: 1, Min: 1, Max: 1, Avg: 1, Resolution: "1h",
+	}
+	if err := repo.RecordAggregatedBatch(ctx, []*domain.AggregatedMetric{partial}); err != nil {
+		t.Fatalf("RecordAggregatedBatch failed: %v", err)
+	}
+
+	countAggregates := func() int {
+		t.Helper()
+		var n int
+		if err := server.db.Conn().QueryRow("SELECT COUNT(*) FROM metrics_aggregated").Scan(&n); err != nil {
+			t.Fatalf("count failed: %v", err)
+		}
+		return n
+	}
+
+	for run := 1; run <= 2; run++ {
+		result, err := server.metricSvc.DownsampleTiers(ctx, time.Since(cutoff), []string{"1m", "1h"})
+		if err != nil {
+			t.Fatalf("run %d: DownsampleTiers failed: %v", run, err)
+		}
+		if result.Failed != 0 {
+			t.Fatalf("run %d: unexpected failures: %+v", run, result)
+		}
+		if run == 1 && (result.Series != 2 || result.Aggregated != 2*(120+2) || result.Deleted != 2*120) {
+			t.Errorf("run 1: unexpected result %+v", result)
+		}
+		if run == 2 && (result.Series != 0 || result.Deleted != 0) {
+			t.Errorf("run 2: expected nothing left to downsample, got %+v", result)
+		}
+		// The interrupted window is replaced, not duplicated
+		if n := countAggregates(); n != 2*(120+2) {
+			t.Errorf("run %d: expected %d aggregates, got %d", run, 2*(120+2), n)
+		}
+	}
+
+	hourly, err := repo.QueryAggregated(ctx, ports.MetricQuery{
+		Name: "cpu.usage", SeriesHash: &hashA, StartTime: base, EndTime: cutoff,
+	}, "1h")
+	if err != nil {
+		t.Fatalf("QueryAggregated failed: %v", err)
+	}
+	if len(hourly) != 2 {
+		t.Fatalf("expected 2 hourly windows, got %d", len(hourly))
+	}
+	for i, agg := range hourly {
+		if !agg.WindowStart.Equal(base.Add(time.Duration(i)*time.Hour)) || agg.Count != 60 ||
+			agg.Sum != 1770 || agg.Min != 0 || agg.Max != 59 {
+			t.Errorf("unexpected hourly window %d: %+v", i, agg)
+		}
+	}
+
+	// Raw points inside retention are untouched
+	series, err := repo.GetDistinctSeries(ctx)
+	if err != nil {
+		t.Fatalf("GetDistinctSeries failed: %v", err)
+	}
+	for _, info := range series {
+		if info.PointCount != 60 || info.FirstTime.Before(cutoff) {
+			t.Errorf("expected the last hour of raw points for %v, got %d from %s", info.Tags, info.PointCount, info.FirstTime)
+		}
+	}
+
+	if _, err := server.metricSvc.DownsampleTiers(ctx, time.Hour, []string{"2m"}); err == nil {
+		t.Error("expected unsupported resolution to be rejected")
+	}
+}
+
+func TestMetricRetentionPolicy(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+	ctx := context.Background()
+	repo := storage.NewMetricRepository(server.db)
+
+	// Three hours of per-minute points and a 1m rollup from 40 days ago
+	base := time.Now().Truncate(time.Hour).Add(-3 * time.Hour)
+	var metrics []*domain.Metric
+	for i := 0; i < 180; i++ {
+		m := domain.NewMetric("mem.used", domain.MetricTypeGauge, 4, map[string]string{"host": "a"})
+		m.Timestamp = base.Add(time.Duration(i)*time.Minute + 30*time.Second)
+		metrics = append(metrics, m)
+	}
+	if err := repo.RecordBatch(ctx, metrics); err != nil {
+		t.Fatalf("RecordBatch failed: %v", err)
+	}
+	stale := base.Add(-40 * 24 * time.Hour)
+	if err := repo.RecordAggregated(ctx, &domain.AggregatedMetric{
+		ID: domain.NewUUIDv7(), Name: "mem.used", SeriesHash: metrics[0].SeriesHash,
+		WindowStart: stale, WindowEnd: stale.Add(time.Minute), Count: 1, Sum: 4, Min: 4, Max: 4, Avg: 4, Resolution: "1m",
+	}); err != nil {
+		t.Fatalf("RecordAggregated failed: %v", err)
+	}
+
+	policy := services.RetentionPolicy{
+		RawRetention: time.Since(base.Add(2 * time.Hour)),
+		Interval:     time.Hour,
+		Tiers: []services.RetentionTier{
+			{Resolution: "1m", Retention: 30 * 24 * time.Hour},
+			{Resolution: "5m", Retention: 90 * 24 * time.Hour},
+			{Resolution: "1h", Retention: 365 * 24 * time.Hour},
+		},
+	}
+	result, err := server.metricSvc.ApplyRetention(ctx, policy)
+	if err != nil {
+		t.Fatalf("ApplyRetention failed: %v", err)
+	}
+	if result.Downsample == nil || result.Downsample.Deleted != 120 {
+		t.Errorf("expected 120 raw points rolled up and deleted, got %+v", result.Downsample)
+	}
+
+	// Each tier holds the two downsampled hours, and the stale 1m rollup
+	// past its tier's retention is gone
+	rollups := map[string]int{}
+	rows, err := server.db.Conn().Query("SELECT resolution, COUNT(*) FROM metrics_aggregated GROUP BY resolution")
+	if err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	for rows.Next() {
+		var resolution string
+		var n int
+		if err := rows.Scan(&resolution, &n); err != nil {
+			t.Fatalf("scan failed: %v", err)
+		}
+		rollups[resolution] = n
+	}
+	rows.Close()
+	if rollups["1m"] != 120 || rollups["5m"] != 24 || rollups["1h"] != 2 {
+		t.Errorf("expected 120 1m, 24 5m and 2 1h rollups, got %v", rollups)
+	}
+
+	var raw int
+	if err := server.db.Conn().QueryRow("SELECT COUNT(*) FROM metrics WHERE name = 'mem.used'").Scan(&raw); err != nil {
+		t.Fatalf("count failed: %v", err)
+	}
+	if raw != 60 {
+		t.Errorf("expected only the last hour of raw points to remain, got %d", raw)
+	}
+
+	// Without tiers raw points past retention are pruned outright
+	result, err = server.metricSvc.ApplyRetention(ctx, services.RetentionPolicy{
+		RawRetention: time.Since(base.Add(150 * time.Minute)),
+		Interval:     time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("ApplyRetention failed: %v", err)
+	}
+	if result.PrunedRaw != 30 {
+		t.Errorf("expected 30 raw points pruned, got %d", result.PrunedRaw)
+	}
+}
+
+func TestNewServerRejectsInvalidRetentionTier(t *testing.T) {
+	config := DefaultConfig(t.TempDir())
+	config.RetentionTiers = []services.RetentionTier{{Resolution: "2m", Retention: time.Hour}}
+	if _, err := NewServer(config, &services.NopLogger{}); err == nil {
+		t.Error("expected NewServer to reject an unsupported tier resolution")
+	}
+}
+
+func TestMetricAggregateMerged(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+	ctx := context.Background()
+
+	// Four hours of per-minute points, the first two of which get downsampled
+	base := time.Now().Truncate(time.Hour).Add(-4 * time.Hour)
+	var metrics []*domain.Metric
+	for i := 0; i < 240; i++ {
+		m := domain.NewMetric("disk.io", domain.MetricTypeGauge, 1, nil)
+		m.Timestamp = base.Add(time.Duration(i)*time.Minute + 30*time.Second)
+		metrics = append(metrics, m)
+	}
+	if err := storage.NewMetricRepository(server.db).RecordBatch(ctx, metrics); err != nil {
+		t.Fatalf("RecordBatch failed: %v", err)
+	}
+	if err := server.metricSvc.Downsample(ctx, time.Since(base.Add(2*time.Hour)), "5m"); err != nil {
+		t.Fatalf("Downsample failed: %v", err)
+	}
+
+	aggregate := func(merged bool) []interface{} {
+		t.Helper()
+		resp, err := server.handleRequest(ctx, &Request{Method: "metric.aggregate", Params: map[string]interface{}{
+			"name":   "disk.io",
+			"agg":    "sum",
+			"step":   "1h",
+			"start":  base.Format(time.RFC3339),
+			"end":    base.Add(4 * time.Hour).Format(time.RFC3339),
+			"merged": merged,
+		}})
+		if err != nil {
+			t.Fatalf("metric.aggregate failed: %v", err)
+		}
+		points, _ := resp.(map[string]interface{})["points"].([]interface{})
+		return points
+	}
+
+	if points := aggregate(false); len(points) != 2 {
+		t.Fatalf("expected 2 raw buckets after downsampling, got %d", len(points))
+	}
+
+	points := aggregate(true)
+	if len(points) != 4 {
+		t.Fatalf("expected 4 merged buckets, got %d: %v", len(points), points)
+	}
+	for i, p := range points {
+		pt := p.(map[string]interface{})
+		if want := base.Add(time.Duration(i) * time.Hour).Format(time.RFC3339); pt["timestamp"] != want {
+			t.Errorf("bucket %d: expected timestamp %s, got %v", i, want, pt["timestamp"])
+		}
+		if pt["count"] != int64(60) || pt["sum"] != float64(60) {
+			t.Errorf("bucket %d: expected 60 points, got count %v sum %v", i, pt["count"], pt["sum"])
+		}
+	}
+}
+
+func TestAlertChannelLifecycle(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+	ctx := context.Background()
+
+	var received atomic.Int32
+	status := http.StatusOK
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Add(1)
+		w.WriteHeader(status)
+		fmt.Fprint(w, "no_service")
+	}))
+	defer hook.Close()
+
+	if _, err := server.handleRequest(ctx, &Request{Method: "alert.channel.create", Params: map[string]interface{}{
+		"name": "oncall",
+		"type": "slack",
+	}}); err == nil {
+		t.Error("expected slack channel without a URL to be rejected")
+	}
+
+	if _, err := server.handleRequest(ctx, &Request{Method: "alert.channel.create", Params: map[string]interface{}{
+		"name":   "oncall",
+		"type":   "slack",
+		"config": map[string]interface{}{"url": hook.URL + "/services/secret", "channel": "#oncall"},
+	}}); err != nil {
+		t.Fatalf("alert.channel.create failed: %v", err)
+	}
+
+	result, err := server.handleRequest(ctx, &Request{Method: "alert.channel.list"})
+	if err != nil {
+		t.Fatalf("alert.channel.list failed: %v", err)
+	}
+	channels := result.(map[string]interface{})["channels"].([]interface{})
+	if len(channels) != 1 {
+		t.Fatalf("expected 1 channel, got %d", len(channels))
+	}
+	config := channels[0].(map[string]interface{})["config"].(map[string]string)
+	if config["url"] != "****" || config["channel"] != "#oncall" {
+		t.Errorf("expected webhook URL redacted in list output, got %v", config)
+	}
+
+	result, err = server.handleRequest(ctx, &Request{Method: "alert.channel.test", Params: map[string]interface{}{"id": "oncall"}})
+	if err != nil {
+		t.Fatalf("alert.channel.test failed: %v", err)
+	}
+	if delivered := result.(map[string]interface{})["delivered"]; delivered != true || received.Load() != 1 {
+		t.Errorf("expected test notification delivered once, got %v (%d requests)", result, received.Load())
+	}
+
+	status = http.StatusNotFound
+	result, err = server.handleRequest(ctx, &Request{Method: "alert.channel.test", Params: map[string]interface{}{"id": "oncall"}})
+	if err != nil {
+		t.Fatalf("alert.channel.test failed: %v", err)
+	}
+	if m := result.(map[string]interface{}); m["delivered"] != false || !strings.Contains(fmt.Sprint(m["error"]), "404") {
+		t.Errorf("expected HTTP error to be reported, got %v", m)
+	}
+
+	if _, err := server.handleRequest(ctx, &Request{Method: "alert.channel.update", Params: map[string]interface{}{
+		"id":      "oncall",
+		"enabled": false,
+		"config":  map[string]interface{}{"channel": ""},
+	}}); err != nil {
+		t.Fatalf("alert.channel.update failed: %v", err)
+	}
+	channel, err := server.alertSvc.GetChannel(ctx, "oncall")
+	if err != nil {
+		t.Fatalf("GetChannel failed: %v", err)
+	}
+	if channel.Enabled || channel.Config["url"] == "" || channel.Config["channel"] != "" {
+		t.Errorf("unexpected channel after update: %+v", channel)
+	}
+
+	if _, err := server.handleRequest(ctx, &Request{Method: "alert.channel.delete", Params: map[string]interface{}{"id": channel.ID.String()}}); err != nil {
+		t.Fatalf("alert.channel.delete failed: %v", err)
+	}
+	if _, err := server.alertSvc.GetChannel(ctx, "oncall"); err == nil {
+		t.Error("expected deleted channel to be gone")
+	}
+}
+
+func TestAlertMaintenanceLifecycle(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+	ctx := context.Background()
+
+	if _, err := server.handleRequest(ctx, &Request{Method: "alert.maintenance.create", Params: map[string]interface{}{
+		"name":       "deploys",
+		"days":       "someday",
+		"start_time": "22:00",
+		"duration":   "3h",
+	}}); err == nil {
+		t.Error("expected invalid days to be rejected")
+	}
+
+	if _, err := server.handleRequest(ctx, &Request{Method: "alert.maintenance.create", Params: map[string]interface{}{
+		"name":       "deploys",
+		"matchers":   map[string]interface{}{"service": "api"},
+		"days":       "mon-fri",
+		"start_time": "22:00",
+		"duration":   "3h",
+		"timezone":   "Europe/Berlin",
+	}}); err != nil {
+		t.Fatalf("alert.maintenance.create failed: %v", err)
+	}
+
+	result, err := server.handleRequest(ctx, &Request{Method: "alert.maintenance.list"})
+	if err != nil {
+		t.Fatalf("alert.maintenance.list failed: %v", err)
+	}
+	windows := result.(map[string]interface{})["windows"].([]interface{})
+	if len(windows) != 1 {
+		t.Fatalf("expected 1 window, got %d", len(windows))
+	}
+	if w := windows[0].(map[string]interface{}); w["days"] != "mon,tue,wed,thu,fri" || w["duration"] != "3h0m0s" {
+		t.Errorf("unexpected window in list output: %v", w)
+	}
+
+	if _, err := server.handleRequest(ctx, &Request{Method: "alert.maintenance.update", Params: map[string]interface{}{
+		"id":      "deploys",
+		"enabled": false,
+		"days":    "weekends",
+	}}); err != nil {
+		t.Fatalf("alert.maintenance.update failed: %v", err)
+	}
+	window, err := server.alertSvc.GetMaintenanceWindow(ctx, "deploys")
+	if err != nil {
+		t.Fatalf("GetMaintenanceWindow failed: %v", err)
+	}
+	if window.Enabled || window.DaysString() != "sun,sat" || window.Timezone != "Europe/Berlin" {
+		t.Errorf("unexpected window after update: %+v", window)
+	}
+
+	if _, err := server.handleRequest(ctx, &Request{Method: "alert.maintenance.delete", Params: map[string]interface{}{"id": window.ID.String()}}); err != nil {
+		t.Fatalf("alert.maintenance.delete failed: %v", err)
+	}
+	if _, err := server.alertSvc.GetMaintenanceWindow(ctx, "deploys"); err == nil {
+		t.Error("expected deleted window to be gone")
+	}
+}
+
+func TestMetricQueryResolution(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+	ctx := context.Background()
+
+	// Four hours of per-minute points, the first two of which get downsampled
+	base := time.Now().Truncate(time.Hour).Add(-4 * time.Hour)
+	var metrics []*domain.Metric
+	for i := 0; i < 240; i++ {
+		m := domain.NewMetric("net.rx", domain.MetricTypeGauge, 2, nil)
+		m.Timestamp = base.Add(time.Duration(i)*time.Minute + 30*time.Second)
+		metrics = append(metrics, m)
+	}
+	if err := storage.NewMetricRepository(server.db).RecordBatch(ctx, metrics); err != nil {
+		t.Fatalf("RecordBatch failed: %v", err)
+	}
+	if _, err := server.metricSvc.DownsampleTiers(ctx, time.Since(base.Add(2*time.Hour)), []string{"1m", "1h"}); err != nil {
+		t.Fatalf("DownsampleTiers failed: %v", err)
+	}
+
+	query := func(params map[string]interface{}) []interface{} {
+		t.Helper()
+		params["name"] = "net.rx"
+		params["start"] = base.Format(time.RFC3339)
+		params["end"] = base.Add(4 * time.Hour).Format(time.RFC3339)
+		resp, err := server.handleRequest(ctx, &Request{Method: "metric.query", Params: params})
+		if err != nil {
+			t.Fatalf("metric.query failed: %v", err)
+		}
+		points, _ := resp.(map[string]interface{})["points"].([]interface{})
+		return points
+	}
+	countBy := func(points []interface{}) map[string]int {
+		counts := make(map[string]int)
+		for _, p := range points {
+			counts[p.(map[string]interface{})["resolution"].(string)]++
+		}
+		return counts
+	}
+
+	// auto is the default: 1m rollups for the downsampled hours, then raw
+	if counts := countBy(query(map[string]interface{}{})); counts["1m"] != 120 || counts["raw"] != 120 {
+		t.Errorf("expected 120 1m and 120 raw points, got %v", counts)
+	}
+	if counts := countBy(query(map[string]interface{}{"resolution": "raw", "limit": float64(1000)})); counts["raw"] != 120 || len(counts) != 1 {
+		t.Errorf("expected only the 120 remaining raw points, got %v", counts)
+	}
+
+	points := query(map[string]interface{}{"resolution": "auto", "step": "1h"})
+	if len(points) != 4 {
+		t.Fatalf("expected 4 hourly points, got %d: %v", len(points), points)
+	}
+	for i, p := range points {
+		pt := p.(map[string]interface{})
+		want := "raw"
+		if i < 2 {
+			want = "1h"
+		}
+		if pt["resolution"] != want || pt["value"] != float64(2) {
+			t.Errorf("point %d: expected 2 from %s, got %v", i, want, pt)
+		}
+	}
+
+	if _, err := server.handleRequest(ctx, &Request{Method: "metric.query", Params: map[string]interface{}{
+		"name": "net.rx", "resolution": "10s",
+	}}); err == nil {
+		t.Error("expected an unsupported resolution to be rejected")
+	}
+}
+
+func TestMetricQueryGroupBy(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+	ctx := context.Background()
+
+	base := time.Now().Add(-time.Hour).Truncate(time.Minute)
+	var metrics []*domain.Metric
+	for i, tags := range []map[string]string{{"host": "a"}, {"host": "a"}, {"host": "b"}, nil} {
+		m := domain.NewMetric("disk.used", domain.MetricTypeGauge, float64(10*(i+1)), tags)
+		m.Timestamp = base.Add(time.Duration(i) * time.Second)
+		metrics = append(metrics, m)
+	}
+	if err := storage.NewMetricRepository(server.db).RecordBatch(ctx, metrics); err != nil {
+		t.Fatalf("RecordBatch failed: %v", err)
+	}
+
+	resp, err := server.handleRequest(ctx, &Request{Method: "metric.query", Params: map[string]interface{}{
+		"name":     "disk.used",
+		"start":    base.Format(time.RFC3339),
+		"end":      base.Add(time.Hour).Format(time.RFC3339),
+		"group_by": []interface{}{"host"},
+		"agg":      "max",
+	}})
+	if err != nil {
+		t.Fatalf("metric.query failed: %v", err)
+	}
+	groups, _ := resp.(map[string]interface{})["groups"].([]map[string]interface{})
+	maxByHost := make(map[string]interface{})
+	for _, g := range groups {
+		points := g["points"].([]map[string]interface{})
+		if len(points) != 1 {
+			t.Fatalf("expected one point per group without a step, got %v", points)
+		}
+		maxByHost[g["tags"].(map[string]string)["host"]] = points[0]["value"]
+	}
+	want := map[string]interface{}{"a": float64(20), "b": float64(30), ports.GroupNone: float64(40)}
+	if len(maxByHost) != len(want) {
+		t.Fatalf("expected groups %v, got %v", want, maxByHost)
+	}
+	for host, value := range want {
+		if maxByHost[host] != value {
+			t.Errorf("group %s: expected max %v, got %v", host, value, maxByHost[host])
+		}
+	}
+
+	if _, err := server.handleRequest(ctx, &Request{Method: "metric.query", Params: map[string]interface{}{
+		"name": "disk.used", "group_by": []interface{}{42},
+	}}); err == nil {
+		t.Error("expected a non-string group_by to be rejected")
+	}
+}
+
+func TestMetricQueryIncludeIDs(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+	ctx := context.Background()
+
+	m := domain.NewMetric("jobs.done", domain.MetricTypeCounter, 7, map[string]string{"queue": "mail"})
+	m.Timestamp = time.Now().Add(-time.Minute).Truncate(time.Second)
+	if err := storage.NewMetricRepository(server.db).RecordBatch(ctx, []*domain.Metric{m}); err != nil {
+		t.Fatalf("RecordBatch failed: %v", err)
+	}
+
+	params := map[string]interface{}{
+		"name":       "jobs.done",
+		"resolution": "raw",
+		"start":      m.Timestamp.Add(-time.Minute).Format(time.RFC3339),
+		"end":        time.Now().Format(time.RFC3339),
+	}
+	for _, includeIDs := range []bool{false, true} {
+		params["include_ids"] = includeIDs
+		resp, err := server.handleRequest(ctx, &Request{Method: "metric.query", Params: params})
+		if err != nil {
+			t.Fatalf("metric.query failed: %v", err)
+		}
+		points, _ := resp.(map[string]interface{})["points"].([]interface{})
+		if len(points) != 1 {
+			t.Fatalf("expected 1 point, got %v", points)
+		}
+		point := points[0].(map[string]interface{})
+		if !includeIDs {
+			if _, ok := point["id"]; ok {
+				t.Errorf("expected no id without include_ids, got %v", point)
+			}
+			continue
+		}
+		if point["id"] != m.ID.String() || point["type"] != "counter" {
+			t.Errorf("expected id %s and type counter, got %v", m.ID, point)
+		}
+	}
+}
+
+func TestTraceSlowest(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+	ctx := context.Background()
+
+	seed := func(service, name string, d time.Duration, age time.Duration) {
+		t.Helper()
+		sp := domain.NewSpan(domain.NewTraceID(), name, domain.SpanKindServer, service)
+		sp.StartTime = time.Now().Add(-age)
+		sp.EndTime = sp.StartTime.Add(d)
+		sp.Duration = d
+		if err := server.traceSvc.ImportSpans(ctx, []*domain.Span{sp}); err != nil {
+			t.Fatalf("ImportSpans failed: %v", err)
+		}
+	}
+	for _, d := range []time.Duration{300, 50, 900, 120, 700} {
+		seed("checkout", "POST /orders", d*time.Millisecond, time.Minute)
+	}
+	seed("checkout", "GET /cart", 5*time.Second, time.Minute)
+	seed("search", "GET /search", 10*time.Second, time.Minute)
+	seed("checkout", "POST /orders", 20*time.Second, 3*time.Hour)
+
+	slowest := func(params map[string]interface{}) []string {
+		t.Helper()
+		resp, err := server.handleRequest(ctx, &Request{Method: "trace.slowest", Params: params})
+		if err != nil {
+			t.Fatalf("trace.slowest failed: %v", err)
+		}
+		var durations []string
+		for _, tr := range resp.(map[string]interface{})["traces"].([]interface{}) {
+			durations = append(durations, tr.(map[string]interface{})["duration"].(string))
+		}
+		return durations
+	}
+
+	got := slowest(map[string]interface{}{"service_name": "checkout", "name": "POST /orders", "limit": float64(3)})
+	if want := []string{"900ms", "700ms", "300ms"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// The default window is the last hour; a wider one reaches the old trace
+	got = slowest(map[string]interface{}{"service_name": "checkout"})
+	if len(got) != 6 || got[0] != "5s" || got[5] != "50ms" {
+		t.Errorf("expected the 6 recent checkout traces slowest first, got %v", got)
+	}
+	got = slowest(map[string]interface{}{"service_name": "checkout", "since": "4h", "limit": float64(1)})
+	if want := []string{"20s"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	for _, params := range []map[string]interface{}{
+		{"since": "soon"},
+		{"since": "1h", "start_time": time.Now().Format(time.RFC3339)},
+		{"start_time": time.Now().Format(time.RFC3339), "end_time": time.Now().Add(-time.Hour).Format(time.RFC3339)},
+	} {
+		if _, err := server.handleRequest(ctx, &Request{Method: "trace.slowest", Params: params}); err == nil {
+			t.Errorf("expected %v to be rejected", params)
+		}
+	}
+}
+
+func TestTraceConfigUpdate(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+	ctx := context.Background()
+
+	result, err := server.handleRequest(ctx, &Request{Method: "trace.config.update", Params: map[string]interface{}{
+		"sample_rate":       0.0,
+		"latency_threshold": "2s",
+	}})
+	if err != nil {
+		t.Fatalf("trace.config.update failed: %v", err)
+	}
+	cfg := result.(map[string]interface{})
+	if cfg["sample_rate"] != 0.0 || cfg["latency_threshold"] != "2s" || cfg["keep_errors"] != true {
+		t.Errorf("unexpected config %v", cfg)
+	}
+
+	// The change applies to traces imported from then on
+	root := domain.NewSpan(domain.NewTraceID(), "GET /", domain.SpanKindServer, "web")
+	root.EndTime = root.StartTime.Add(10 * time.Millisecond)
+	root.Duration = 10 * time.Millisecond
+	if err := server.traceSvc.ImportSpans(ctx, []*domain.Span{root}); err != nil {
+		t.Fatalf("ImportSpans failed: %v", err)
+	}
+	if trace, err := server.traceSvc.GetTraceByTraceID(ctx, root.TraceID); err == nil && trace != nil {
+		t.Error("expected an ordinary trace to be dropped at sample rate 0")
+	}
+	stats, err := server.handleRequest(ctx, &Request{Method: "trace.stats"})
+	if err != nil {
+		t.Fatalf("trace.stats failed: %v", err)
+	}
+	sampling := stats.(map[string]interface{})["sampling"].(map[string]interface{})
+	if sampling["enabled"] != true || sampling["dropped"] != int64(1) {
+		t.Errorf("unexpected sampling stats %v", sampling)
+	}
+
+	for _, params := range []map[string]interface{}{
+		{"sample_rate": 2.0},
+		{"retention": "forever"},
+		{"keep_errors": "yes"},
+		{"decision_wait": "0s"},
+	} {
+		if _, err := server.handleRequest(ctx, &Request{Method: "trace.config.update", Params: params}); err == nil {
+			t.Errorf("expected %v to be rejected", params)
+		}
+	}
+	if server.traceSvc.Config().SampleRate != 0 {
+		t.Error("expected a rejected update to leave the config unchanged")
+	}
+}
+
+func TestStatsEstimateSampledTotals(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+	ctx := context.Background()
+
+	// Stored while every trace is kept: 4 ordinary and 1 failed
+	for i := 0; i < 5; i++ {
+		sp := domain.NewSpan(domain.NewTraceID(), "GET /", domain.SpanKindServer, "web")
+		sp.EndTime = sp.StartTime.Add(10 * time.Millisecond)
+		sp.Duration = 10 * time.Millisecond
+		if i == 0 {
+			sp.SetStatus(domain.SpanStatusError, "boom")
+		}
+		if err := server.traceSvc.ImportSpans(ctx, []*domain.Span{sp}); err != nil {
+			t.Fatalf("ImportSpans failed: %v", err)
+		}
+	}
+
+	traceStats := func() map[string]interface{} {
+		t.Helper()
+		result, err := server.handleRequest(ctx, &Request{Method: "trace.stats"})
+		if err != nil {
+			t.Fatalf("trace.stats failed: %v", err)
+		}
+		return result.(map[string]interface{})
+	}
+	stats := traceStats()
+	if stats["stored_traces"] != int64(5) || stats["estimated_total_traces"] != int64(5) {
+		t.Errorf("expected 5 stored and estimated traces without sampling, got %v", stats)
+	}
+
+	// Ordinary traces now stand for 1/rate traces each; the failed one for itself
+	for rate, want := range map[float64]int64{0.5: 9, 0.25: 17} {
+		if _, err := server.handleRequest(ctx, &Request{Method: "trace.config.update", Params: map[string]interface{}{"sample_rate": rate}}); err != nil {
+			t.Fatalf("trace.config.update failed: %v", err)
+		}
+		stats = traceStats()
+		if stats["stored_traces"] != int64(5) || stats["estimated_total_traces"] != want {
+			t.Errorf("rate %v: expected 5 stored and %d estimated traces, got %v / %v",
+				rate, want, stats["stored_traces"], stats["estimated_total_traces"])
+		}
+		if sampling := stats["sampling"].(map[string]interface{}); sampling["effective_rate"] != rate {
+			t.Errorf("expected the configured rate before any decision, got %v", sampling["effective_rate"])
+		}
+	}
+	if _, err := server.handleRequest(ctx, &Request{Method: "trace.config.update", Params: map[string]interface{}{"sample_rate": 0.0}}); err != nil {
+		t.Fatalf("trace.config.update failed: %v", err)
+	}
+	if stats = traceStats(); stats["estimated_total_traces"] != nil {
+		t.Errorf("expected no estimate at sample rate 0, got %v", stats["estimated_total_traces"])
+	}
+
+	// Logs are never sampled, so the estimate is the stored count
+	entry := domain.NewLogEntry(domain.LogLevelInfo, "hello", "test", "web")
+	if err := server.logSvc.Ingest(ctx, entry); err != nil {
+		t.Fatalf("Ingest failed: %v", err)
+	}
+	result, err := server.handleRequest(ctx, &Request{Method: "log.stats"})
+	if err != nil {
+		t.Fatalf("log.stats failed: %v", err)
+	}
+	logStats := result.(map[string]interface{})
+	if logStats["estimated_total_count"] != logStats["total_count"] {
+		t.Errorf("expected the log estimate to equal the stored count, got %v", logStats)
+	}
+	if sampling := logStats["sampling"].(map[string]interface{}); sampling["sample_rate"] != 1.0 {
+		t.Errorf("expected logs to report a sample rate of 1, got %v", sampling)
+	}
+}
+
+func TestTraceListFilters(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+	ctx := context.Background()
+
+	seed := func(name, route string, d time.Duration) {
+		t.Helper()
+		sp := domain.NewSpan(domain.NewTraceID(), name, domain.SpanKindServer, "shop")
+		if route != "" {
+			sp.SetAttribute("http.route", route)
+		}
+		sp.EndTime = sp.StartTime.Add(d)
+		sp.Duration = d
+		if err := server.traceSvc.ImportSpans(ctx, []*domain.Span{sp}); err != nil {
+			t.Fatalf("ImportSpans failed: %v", err)
+		}
+	}
+	seed("slow checkout", "/checkout", 3*time.Second)
+	seed("fast checkout", "/checkout", 100*time.Millisecond)
+	seed("slow cart", "/cart", 5*time.Second)
+	seed("healthz", "", 4*time.Second)
+
+	list := func(params map[string]interface{}) []string {
+		t.Helper()
+		resp, err := server.handleRequest(ctx, &Request{Method: "trace.list", Params: params})
+		if err != nil {
+			t.Fatalf("trace.list failed: %v", err)
+		}
+		var names []string
+		for _, tr := range resp.(map[string]interface{})["traces"].([]interface{}) {
+			names = append(names, tr.(map[string]interface{})["name"].(string))
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	got := list(map[string]interface{}{
+		"min_duration": "2s",
+		"attributes":   map[string]interface{}{"http.route": "/checkout"},
+	})
+	if want := []string{"slow checkout"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	got = list(map[string]interface{}{"min_duration": "1s", "has_attributes": []interface{}{"http.route"}})
+	if want := []string{"slow cart", "slow checkout"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	got = list(map[string]interface{}{"max_duration": "4s"})
+	if want := []string{"fast checkout", "healthz", "slow checkout"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	for _, params := range []map[string]interface{}{
+		{"max_duration": "forever"},
+		{"min_duration": "5s", "max_duration": "1s"},
+		{"attributes": map[string]interface{}{"http.route": true}},
+		{"has_attributes": []interface{}{""}},
+	} {
+		if _, err := server.handleRequest(ctx, &Request{Method: "trace.list", Params: params}); err == nil {
+			t.Errorf("expected %v to be rejected", params)
+		}
+	}
+}
+
+func TestSpanSearch(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+	ctx := context.Background()
+
+	var spans []*domain.Span
+	for i, d := range []time.Duration{100 * time.Millisecond, 600 * time.Millisecond, 3 * time.Second} {
+		sp := domain.NewSpan(domain.NewTraceID(), fmt.Sprintf("query %d", i), domain.SpanKindClient, "billing")
+		sp.SetAttribute("db.system", "postgres")
+		sp.EndTime = sp.StartTime.Add(d)
+		sp.Duration = d
+		spans = append(spans, sp)
+	}
+	spans[2].Attributes["db.system"] = "redis"
+	if err := server.traceSvc.ImportSpans(ctx, spans); err != nil {
+		t.Fatalf("ImportSpans failed: %v", err)
+	}
+
+	search := func(params map[string]interface{}) []interface{} {
+		t.Helper()
+		params["service_name"] = "billing"
+		resp, err := server.handleRequest(ctx, &Request{Method: "span.search", Params: params})
+		if err != nil {
+			t.Fatalf("span.search failed: %v", err)
+		}
+		return resp.(map[string]interface{})["spans"].([]interface{})
+	}
+
+	found := search(map[string]interface{}{
+		"min_duration": "500ms",
+		"attributes":   map[string]interface{}{"db.system": "postgres"},
+	})
+	if len(found) != 1 || found[0].(map[string]interface{})["name"] != "query 1" {
+		t.Errorf("expected only the slow postgres span, got %v", found)
+	}
+	if found := search(map[string]interface{}{"max_duration": "1s", "kind": "client"}); len(found) != 2 {
+		t.Errorf("expected the two spans under 1s, got %d", len(found))
+	}
+	if found := search(map[string]interface{}{"limit": float64(1)}); len(found) != 1 {
+		t.Errorf("expected the limit to apply, got %d spans", len(found))
+	}
+
+	for _, params := range []map[string]interface{}{
+		{"min_duration": "slow"},
+		{"min_duration": "2s", "max_duration": "1s"},
+		{"attributes": map[string]interface{}{"db.system": 5}},
+	} {
+		if _, err := server.handleRequest(ctx, &Request{Method: "span.search", Params: params}); err == nil {
+			t.Errorf("expected %v to be rejected", params)
+		}
+	}
+}
+
+// expositionSample is a sample read back from the text exposition format.
+type expositionSample struct {
+	name   string
+	labels map[string]string
+	value  float64
+}
+
+// parseExposition strictly parses the Prometheus text exposition format:
+// metric and label names must be in their charsets, label values correctly
+// escaped, each family typed once before its samples and never split, and
+// every sample must belong to the family it follows.
+func parseExposition(t *testing.T, text string) []expositionSample {
+	t.Helper()
+	isName := func(s string, colon bool) bool {
+		for i, c := range s {
+			ok := c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') ||
+				(i > 0 && c >= '0' && c <= '9') || (colon && c == ':')
+			if !ok {
+				return false
+			}
+		}
+		return s != ""
+	}
+
+	types := map[string]string{}
+	done := map[string]bool{}
+	var family string
+	var samples []expositionSample
+	for n, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		fail := func(format string, args ...interface{}) {
+			t.Fatalf("line %d %q: %s", n+1, line, fmt.Sprintf(format, args...))
+		}
+		if strings.HasPrefix(line, "# ") {
+			fields := strings.SplitN(line[2:], " ", 3)
+			if len(fields) < 3 || !isName(fields[1], true) {
+				fail("malformed comment")
+			}
+			name := fields[1]
+			if name != family {
+				if done[name] {
+					fail("family %s is split", name)
+				}
+				done[family] = true
+				family = name
+			}
+			if fields[0] == "TYPE" {
+				if _, dup := types[name]; dup {
+					fail("second TYPE for %s", name)
+				}
+				switch fields[2] {
+				case "counter", "gauge", "histogram", "summary", "untyped":
+				default:
+					fail("unknown type %s", fields[2])
+				}
+				types[name] = fields[2]
+			}
+			continue
+		}
+
+		end := strings.IndexAny(line, "{ ")
+		if end < 0 {
+			fail("missing value")
+		}
+		s := expositionSample{name: line[:end], labels: map[string]string{}}
+		if !isName(s.name, true) {
+			fail("invalid metric name %q", s.name)
+		}
+		rest := line[end:]
+		if strings.HasPrefix(rest, "{") {
+			rest = rest[1:]
+			for !strings.HasPrefix(rest, "}") {
+				eq := strings.Index(rest, `="`)
+				if eq < 0 || !isName(rest[:eq], false) || strings.HasPrefix(rest[:eq], "__") {
+					fail("invalid label in %q", rest)
+				}
+				key := rest[:eq]
+				rest = rest[eq+2:]
+				var value strings.Builder
+				for {
+					if rest == "" {
+						fail("unterminated label value")
+					}
+					c := rest[0]
+					rest = rest[1:]
+					if c == '"' {
+						break
+					}
+					if c == '\n' {
+						fail("raw newline in label value")
+					}
+					if c == '\\' {
+						if rest == "" {
+							fail("dangling escape")
+						}
+						switch rest[0] {
+						case '\\':
+							value.WriteByte('\\')
+						case '"':
+							value.WriteByte('"')
+						case 'n':
+							value.WriteByte('\n')
+						default:
+							fail("invalid escape \\%c", rest[0])
+						}
+						rest = rest[1:]
+						continue
+					}
+					value.WriteByte(c)
+				}
+				if _, dup := s.labels[key]; dup {
+					fail("duplicate label %s", key)
+				}
+				s.labels[key] = value.String()
+				rest = strings.TrimPrefix(rest, ",")
+			}
+			rest = rest[1:]
+		}
+
+		fields := strings.Fields(rest)
+		if len(fields) < 1 || len(fields) > 2 {
+			fail("expected a value and optional timestamp")
+		}
+		v, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			fail("invalid value: %v", err)
+		}
+		s.value = v
+		if len(fields) == 2 {
+			if _, err := strconv.ParseInt(fields[1], 10, 64); err != nil {
+				fail("invalid timestamp: %v", err)
+			}
+		}
+
+		base := s.name
+		if types[family] == "histogram" || types[family] == "summary" {
+			for _, suffix := range []string{"_bucket", "_sum", "_count"} {
+				base = strings.TrimSuffix(base, suffix)
+			}
+		}
+		if base != family {
+			if done[s.name] || types[s.name] != "" {
+				fail("sample outside its family")
+			}
+			done[family] = true
+			family = s.name
+		}
+		samples = append(samples, s)
+	}
+	return samples
+}
+
+func TestMetricsExposition(t *testing.T) {
+	config := DefaultConfig(t.TempDir())
+	config.MetricsExporter = true
+	config.MetricMetadata = []domain.MetricMetadata{
+		{Metric: "requests.*", Description: "Requests served.", Type: domain.MetricTypeCounter},
+	}
+	server, err := NewServer(config, &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+	server.startedAt = time.Now()
+	ctx := context.Background()
+
+	metrics := []*domain.Metric{
+		domain.NewMetric("cpu.usage", domain.MetricTypeGauge, 10, map[string]string{"host": "web-1", "k8s.pod": "api"}),
+		domain.NewMetric("cpu.usage", domain.MetricTypeGauge, 42, map[string]string{"host": "web-1", "k8s.pod": "api"}),
+		domain.NewMetric("cpu-usage", domain.MetricTypeGauge, 7, map[string]string{"note": "say \"hi\"\nC:\\tmp", "__name__": "x"}),
+		domain.NewMetric("requests.total", domain.MetricTypeCounter, 99, nil),
+	}
+	for i, m := range metrics {
+		m.Timestamp = time.Now().Add(time.Duration(i-len(metrics)) * time.Second)
+	}
+	if err := storage.NewMetricRepository(server.db).RecordBatch(ctx, metrics); err != nil {
+		t.Fatalf("RecordBatch failed: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := server.dispatchRequest(ctx, &Request{Method: "status"}); err != nil {
+			t.Fatalf("status failed: %v", err)
+		}
+	}
+	if _, err := server.dispatchRequest(ctx, &Request{Method: "no.such.method"}); err == nil {
+		t.Fatal("expected an unknown method error")
+	}
+
+	// Served from the health HTTP server's /metrics when no address is set
+	httpServer := NewHTTPServer("0", nil, Version)
+	server.httpServer = httpServer
+	server.startMetricsExporter()
+	ts := httptest.NewServer(httpServer.mux)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", resp.StatusCode, body)
+	}
+
+	values := map[string]float64{}
+	for _, s := range parseExposition(t, string(body)) {
+		keys := make([]string, 0, len(s.labels))
+		for k, v := range s.labels {
+			keys = append(keys, k+"="+v)
+		}
+		sort.Strings(keys)
+		values[s.name+"{"+strings.Join(keys, ",")+"}"] = s.value
+	}
+
+	want := map[string]float64{
+		`cpu_usage_2{host=web-1,k8s_pod=api}`:                      42,
+		"cpu_usage{note=say \"hi\"\nC:\\tmp,tag__name__=x}":        7,
+		`requests_total{}`:                                         99,
+		`forge_rpc_requests_total{method=status}`:                  2,
+		`forge_rpc_errors_total{method=no.such.method}`:            1,
+		`forge_rpc_duration_seconds_count{method=status}`:          2,
+		`forge_rpc_duration_seconds_bucket{le=+Inf,method=status}`: 2,
+		`forge_build_info{version=` + Version + `}`:                1,
+		`forge_tasks_processed_total{status=completed}`:            0,
+		`forge_tasks_processed_total{status=failed}`:               0,
+		`forge_plugins_loaded{}`:                                   0,
+	}
+	for key, v := range want {
+		got, ok := values[key]
+		if !ok {
+			t.Errorf("missing sample %s in:\n%s", key, body)
+			continue
+		}
+		if got != v {
+			t.Errorf("%s: expected %v, got %v", key, v, got)
+		}
+	}
+	for _, name := range []string{"go_goroutines{}", "go_memstats_heap_alloc_bytes{}", "forge_uptime_seconds{}"} {
+		if values[name] <= 0 {
+			t.Errorf("expected a positive %s, got %v", name, values[name])
+		}
+	}
+
+	// Stored series are described only when they have metadata
+	for _, line := range []string{"# HELP requests_total Requests served.\n", "# TYPE requests_total counter\n"} {
+		if !strings.Contains(string(body), line) {
+			t.Errorf("expected %q in:\n%s", line, body)
+		}
+	}
+	if strings.Contains(string(body), "# HELP cpu_usage") || strings.Contains(string(body), "# TYPE cpu_usage") {
+		t.Errorf("expected no HELP or TYPE for cpu.usage without metadata:\n%s", body)
+	}
+
+	// The name filter matches Forge names and exposed names alike
+	resp, err = http.Get(ts.URL + "/metrics?name=cpu.*&name=forge_tasks_*")
+	if err != nil {
+		t.Fatalf("GET /metrics with a name filter failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ = io.ReadAll(resp.Body)
+	names := map[string]bool{}
+	for _, s := range parseExposition(t, string(body)) {
+		names[s.name] = true
+	}
+	if len(names) != 2 || !names["cpu_usage_2"] || !names["forge_tasks_processed_total"] {
+		t.Errorf("expected only cpu.usage and the task counter, got %v", names)
+	}
+
+	resp, err = http.Get(ts.URL + "/metrics?name=%5B")
+	if err != nil {
+		t.Fatalf("GET /metrics with a bad filter failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("invalid name pattern: expected 400, got %d", resp.StatusCode)
+	}
+}
+
+func TestRPCPermissions(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+	ctx := context.Background()
+
+	call := func(ctx context.Context, method string, params map[string]interface{}) (interface{}, error) {
+		return server.handleRequest(ctx, &Request{Method: method, Params: params})
+	}
+	login := func(key string) context.Context {
+		t.Helper()
+		caller, err := server.authenticate(ctx, map[string]interface{}{"api_key": key})
+		if err != nil {
+			t.Fatalf("authenticate failed: %v", err)
+		}
+		return WithCaller(ctx, caller)
+	}
+
+	// The first user can be created without credentials and becomes an admin
+	result, err := call(ctx, "user.create", map[string]interface{}{
+		"username": "root", "email": "root@example.com", "password": "secret123", "role": "viewer",
+	})
+	if err != nil {
+		t.Fatalf("bootstrap user.create failed: %v", err)
+	}
+	created := result.(map[string]interface{})
+	if created["role"] != string(domain.RoleAdmin) {
+		t.Errorf("expected bootstrap user to be an admin, got %v", created["role"])
+	}
+	adminKey, _ := created["api_key"].(string)
+	if adminKey == "" {
+		t.Fatal("expected bootstrap user.create to return an API key")
+	}
+
+	// From then on sensitive methods need a caller
+	if _, err := call(ctx, "user.list", nil); !errors.Is(err, services.ErrPermissionDenied) {
+		t.Errorf("expected unauthenticated user.list to be denied, got %v", err)
+	}
+	if _, err := call(ctx, "ping", nil); err != nil {
+		t.Errorf("expected unauthenticated ping to be allowed, got %v", err)
+	}
+	if _, err := server.authenticate(ctx, map[string]interface{}{"api_key": "forge_bogus"}); err == nil {
+		t.Error("expected an unknown API key to be rejected")
+	}
+
+	admin := login(adminKey)
+	if _, err := call(admin, "user.create", map[string]interface{}{
+		"username": "viewer", "email": "viewer@example.com", "password": "secret123", "role": "viewer",
+	}); err != nil {
+		t.Fatalf("admin user.create failed: %v", err)
+	}
+	result, err = call(admin, "user.list", nil)
+	if err != nil {
+		t.Fatalf("admin user.list failed: %v", err)
+	}
+	if users := result.(map[string]interface{})["users"].([]interface{}); len(users) != 2 {
+		t.Errorf("expected 2 users, got %d", len(users))
+	}
+
+	// A key can't grant more than its owner's role allows
+	viewerUser, err := server.authSvc.ListUsers(ctx, ports.UserFilter{Username: "viewer"})
+	if err != nil || len(viewerUser) != 1 {
+		t.Fatalf("failed to look up viewer: %v", err)
+	}
+	_, viewerKey, err := server.authSvc.CreateAPIKey(ctx, viewerUser[0].ID, "cli", []string{"*"}, nil)
+	if err != nil {
+		t.Fatalf("CreateAPIKey failed: %v", err)
+	}
+	viewer := login(viewerKey)
+	for _, method := range []string{"user.list", "user.delete", "alert.rule.delete", "alert.silence.delete", "apikey.create"} {
+		if _, err := call(viewer, method, map[string]interface{}{"username": "root", "id": uuid.NewString(), "name": "x"}); !errors.Is(err, services.ErrPermissionDenied) {
+			t.Errorf("expected viewer %s to be denied, got %v", method, err)
+		}
+	}
+
+	if _, err := call(admin, "apikey.create", map[string]interface{}{"name": "automation"}); err != nil {
+		t.Fatalf("apikey.create failed: %v", err)
+	}
+
+	// API keys belong to the caller
+	result, err = call(admin, "apikey.list", nil)
+	if err != nil {
+		t.Fatalf("apikey.list failed: %v", err)
+	}
+	if keys := result.(map[string]interface{})["keys"].([]interface{}); len(keys) != 2 {
+		t.Errorf("expected the admin's 2 keys, got %d", len(keys))
+	}
+
+	if _, err := call(admin, "user.delete", map[string]interface{}{"username": "viewer"}); err != nil {
+		t.Errorf("admin user.delete failed: %v", err)
+	}
+	if _, err := server.authenticate(ctx, map[string]interface{}{"api_key": viewerKey}); err == nil {
+		t.Error("expected a deleted user's key to be rejected")
+	}
+}
+
+func TestPeerIdentity(t *testing.T) {
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("tcp unavailable: %v", err)
+	}
+	defer tcp.Close()
+	go func() {
+		if conn, err := net.Dial("tcp", tcp.Addr().String()); err == nil {
+			defer conn.Close()
+			time.Sleep(100 * time.Millisecond)
+		}
+	}()
+	conn, err := tcp.Accept()
+	if err != nil {
+		t.Fatalf("Accept failed: %v", err)
+	}
+	// The port changes per connection, so it is left out
+	if got := peerIdentity(conn); got != "tcp:127.0.0.1" {
+		t.Errorf("expected tcp:127.0.0.1, got %q", got)
+	}
+	conn.Close()
+
+	socketPath := filepath.Join(t.TempDir(), "peer.sock")
+	unix, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	defer unix.Close()
+	go func() {
+		if conn, err := net.Dial("unix", socketPath); err == nil {
+			defer conn.Close()
+			time.Sleep(100 * time.Millisecond)
+		}
+	}()
+	conn, err = unix.Accept()
+	if err != nil {
+		t.Fatalf("Accept failed: %v", err)
+	}
+	defer conn.Close()
+	want := "local"
+	if runtime.GOOS == "linux" {
+		want = fmt.Sprintf("unix:uid=%d", os.Getuid())
+	}
+	if got := peerIdentity(conn); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestClientAuthHandshake(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+
+	socketPath := filepath.Join(t.TempDir(), "forge.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			server.wg.Add(1)
+			go server.handleConnection(context.Background(), conn)
+		}
+	}()
+
+	ctx := context.Background()
+	anonymous := &Client{socketPath: socketPath, timeout: 5 * time.Second}
+	defer anonymous.Close()
+	result, err := anonymous.Call(ctx, "user.create", map[string]interface{}{
+		"username": "root", "email": "root@example.com", "password": "secret123",
+	})
+	if err != nil {
+		t.Fatalf("bootstrap user.create failed: %v", err)
+	}
+	key := result.(map[string]interface{})["api_key"].(string)
+
+	if _, err := anonymous.Call(ctx, "user.list", nil); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("expected anonymous user.list to be denied, got %v", err)
+	}
+
+	authed := &Client{socketPath: socketPath, timeout: 5 * time.Second}
+	authed.SetAPIKey(key)
+	defer authed.Close()
+	if _, err := authed.Call(ctx, "user.list", nil); err != nil {
+		t.Errorf("expected authenticated user.list to succeed, got %v", err)
+	}
+
+	bad := &Client{socketPath: socketPath, timeout: 5 * time.Second}
+	bad.SetAPIKey("forge_not-a-real-key")
+	defer bad.Close()
+	if err := bad.Connect(); err == nil || !strings.Contains(err.Error(), "authentication failed") {
+		t.Errorf("expected handshake with a bad key to fail, got %v", err)
+	}
+}
+
+func TestRPCSessionAuthAndRBAC(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+	ctx := context.Background()
+
+	for _, u := range []struct {
+		name string
+		role domain.UserRole
+	}{{"admin", domain.RoleAdmin}, {"ops", domain.RoleOperator}, {"val", domain.RoleViewer}} {
+		if _, err := server.authSvc.CreateUser(ctx, u.name, u.name+"@example.com", "secret123", u.role); err != nil {
+			t.Fatalf("CreateUser failed: %v", err)
+		}
+	}
+	login := func(username string) (context.Context, *Caller, string) {
+		t.Helper()
+		_, token, err := server.authSvc.Login(ctx, username, "secret123", "", "")
+		if err != nil {
+			t.Fatalf("Login failed: %v", err)
+		}
+		caller, err := server.authenticate(ctx, map[string]interface{}{"session_token": token})
+		if err != nil {
+			t.Fatalf("authenticate failed: %v", err)
+		}
+		if caller.Session == nil || caller.APIKey != nil {
+			t.Fatalf("expected a session caller, got %+v", caller)
+		}
+		return WithCaller(ctx, caller), caller, token
+	}
+	admin, _, _ := login("admin")
+	ops, opsCaller, opsToken := login("ops")
+	viewer, _, _ := login("val")
+
+	tests := []struct {
+		ctx     context.Context
+		who     string
+		method  string
+		allowed bool
+	}{
+		{ctx, "anonymous", "metric.query", false},
+		{ctx, "anonymous", "log.tail", false},
+		{ctx, "anonymous", "health", true},
+		{ctx, "anonymous", "ping", true},
+		{viewer, "viewer", "metric.query", true},
+		{viewer, "viewer", "alert.rule.create", false},
+		{viewer, "viewer", "task.create", false},
+		{ops, "operator", "alert.rule.create", true},
+		{ops, "operator", "plugin.reload", true},
+		{ops, "operator", "user.delete", false},
+		{ops, "operator", "no.such.method", false},
+		{admin, "admin", "user.delete", true},
+		{admin, "admin", "no.such.method", true},
+	}
+	for _, tt := range tests {
+		err := server.authorize(tt.ctx, tt.method, nil)
+		if tt.allowed && err != nil {
+			t.Errorf("expected %s to be allowed %s, got %v", tt.who, tt.method, err)
+		}
+		if !tt.allowed && !errors.Is(err, services.ErrPermissionDenied) {
+			t.Errorf("expected %s to be denied %s, got %v", tt.who, tt.method, err)
+		}
+	}
+
+	// A session that runs out stops authorizing its connection
+	opsCaller.Session.ExpiresAt = time.Now().Add(-time.Second)
+	if err := server.authorize(ops, "metric.query", nil); !errors.Is(err, services.ErrPermissionDenied) {
+		t.Errorf("expected an expired session to be denied, got %v", err)
+	}
+
+	// Logged-out sessions can't authenticate again
+	if err := server.authSvc.Logout(ctx, opsCaller.Session.ID); err != nil {
+		t.Fatalf("Logout failed: %v", err)
+	}
+	if _, err := server.authenticate(ctx, map[string]interface{}{"session_token": opsToken}); !errors.Is(err, services.ErrSessionRevoked) {
+		t.Errorf("expected a revoked session to be rejected, got %v", err)
+	}
+
+	// With auth disabled every method is open
+	server.config.AuthDisabled = true
+	if err := server.authorize(ctx, "user.delete", nil); err != nil {
+		t.Errorf("expected auth disabled to allow anonymous calls, got %v", err)
+	}
+}
+
+func TestRPCAuditRecordsCaller(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+
+	socketPath := filepath.Join(t.TempDir(), "forge.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			server.wg.Add(1)
+			go server.handleConnection(context.Background(), conn)
+		}
+	}()
+
+	ctx := context.Background()
+	anonymous := &Client{socketPath: socketPath, timeout: 5 * time.Second}
+	defer anonymous.Close()
+	result, err := anonymous.Call(ctx, "user.create", map[string]interface{}{
+		"username": "root", "email": "root@example.com", "password": "secret123",
+	})
+	if err != nil {
+		t.Fatalf("bootstrap user.create failed: %v", err)
+	}
+	created := result.(map[string]interface{})
+	key := created["api_key"].(string)
+
+	// Streams are authorized like any other method
+	err = anonymous.Stream(ctx, "log.tail", nil, func(interface{}) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("expected anonymous log.tail to be denied, got %v", err)
+	}
+
+	authed := &Client{socketPath: socketPath, timeout: 5 * time.Second}
+	authed.SetAPIKey(key)
+	defer authed.Close()
+	if _, err := authed.Call(ctx, "user.create", map[string]interface{}{
+		"username": "alice", "email": "alice@example.com", "password": "secret123",
+	}); err != nil {
+		t.Fatalf("user.create failed: %v", err)
+	}
+	if _, err := authed.Call(ctx, "user.delete", map[string]interface{}{"username": "alice"}); err != nil {
+		t.Fatalf("user.delete failed: %v", err)
+	}
+
+	logs, err := server.authSvc.GetAuditLogs(ctx, ports.AuditLogFilter{Action: "user.delete"})
+	if err != nil {
+		t.Fatalf("GetAuditLogs failed: %v", err)
+	}
+	if len(logs) != 1 {
+		t.Fatalf("expected 1 user.delete entry, got %d", len(logs))
+	}
+	if logs[0].UserID == nil || logs[0].UserID.String() != created["id"] {
+		t.Errorf("expected the delete to be attributed to root, got %v", logs[0].UserID)
+	}
+	if logs[0].IPAddress == "" {
+		t.Error("expected the client address to be recorded")
+	}
+
+	report, err := server.authSvc.VerifyAuditLog(ctx)
+	if err != nil || !report.Valid {
+		t.Errorf("expected an intact audit chain, got %+v (%v)", report, err)
+	}
+}
+
+func TestAPIKeysScopedToCaller(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+	ctx := context.Background()
+
+	call := func(ctx context.Context, method string, params map[string]interface{}) (interface{}, error) {
+		return server.handleRequest(ctx, &Request{Method: method, Params: params})
+	}
+	// newCaller creates a user with a wildcard key and returns its context
+	newCaller := func(username string, role domain.UserRole) context.Context {
+		t.Helper()
+		user, err := server.authSvc.CreateUser(ctx, username, username+"@example.com", "secret123", role)
+		if err != nil {
+			t.Fatalf("CreateUser failed: %v", err)
+		}
+		_, key, err := server.authSvc.CreateAPIKey(ctx, user.ID, "login", []string{"*"}, nil)
+		if err != nil {
+			t.Fatalf("CreateAPIKey failed: %v", err)
+		}
+		caller, err := server.authenticate(ctx, map[string]interface{}{"api_key": key})
+		if err != nil {
+			t.Fatalf("authenticate failed: %v", err)
+		}
+		return WithCaller(ctx, caller)
+	}
+	admin := newCaller("admin", domain.RoleAdmin)
+	alice := newCaller("alice", domain.RoleOperator)
+	bob := newCaller("bob", domain.RoleOperator)
+
+	createKey := func(ctx context.Context, name string) string {
+		t.Helper()
+		result, err := call(ctx, "apikey.create", map[string]interface{}{"name": name})
+		if err != nil {
+			t.Fatalf("apikey.create failed: %v", err)
+		}
+		return result.(map[string]interface{})["id"].(string)
+	}
+	listKeys := func(ctx context.Context) []string {
+		t.Helper()
+		result, err := call(ctx, "apikey.list", nil)
+		if err != nil {
+			t.Fatalf("apikey.list failed: %v", err)
+		}
+		var names []string
+		for _, k := range result.(map[string]interface{})["keys"].([]interface{}) {
+			names = append(names, k.(map[string]interface{})["name"].(string))
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	aliceKey := createKey(alice, "alice-ci")
+	bobKey := createKey(bob, "bob-ci")
+	if got := listKeys(alice); strings.Join(got, ",") != "alice-ci,login" {
+		t.Errorf("expected alice to see only her keys, got %v", got)
+	}
+	if got := listKeys(bob); strings.Join(got, ",") != "bob-ci,login" {
+		t.Errorf("expected bob to see only his keys, got %v", got)
+	}
+
+	if _, err := call(alice, "apikey.revoke", map[string]interface{}{"id": bobKey}); !errors.Is(err, services.ErrPermissionDenied) {
+		t.Errorf("expected alice to be denied revoking bob's key, got %v", err)
+	}
+	if _, err := call(alice, "apikey.revoke", map[string]interface{}{"id": aliceKey}); err != nil {
+		t.Errorf("expected alice to revoke her own key, got %v", err)
+	}
+	if _, err := call(admin, "apikey.revoke", map[string]interface{}{"id": bobKey}); err != nil {
+		t.Errorf("expected admin to revoke bob's key, got %v", err)
+	}
+
+	for _, id := range []string{aliceKey, bobKey} {
+		stored, err := storage.NewAPIKeyRepository(server.db).GetByID(ctx, uuid.MustParse(id))
+		if err != nil {
+			t.Fatalf("GetByID failed: %v", err)
+		}
+		if stored.RevokedAt == nil {
+			t.Errorf("expected key %s to be revoked", stored.Name)
+		}
+	}
+}
+
+func TestUserImport(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+	ctx := context.Background()
+
+	admin, err := server.authSvc.CreateUser(ctx, "admin", "admin@example.com", "secret123", domain.RoleAdmin)
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	_, key, err := server.authSvc.CreateAPIKey(ctx, admin.ID, "login", []string{"*"}, nil)
+	if err != nil {
+		t.Fatalf("CreateAPIKey failed: %v", err)
+	}
+	caller, err := server.authenticate(ctx, map[string]interface{}{"api_key": key})
+	if err != nil {
+		t.Fatalf("authenticate failed: %v", err)
+	}
+	if _, err := server.authSvc.CreateUser(ctx, "alice", "alice@example.com", "secret123", domain.RoleOperator); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+
+	// Params arrive decoded from JSON
+	params := map[string]interface{}{
+		"users": []interface{}{
+			map[string]interface{}{"username": "alice", "email": "alice@example.com"},
+			map[string]interface{}{"username": "bob", "email": "bob@example.com", "role": "operator", "invite": true},
+			map[string]interface{}{"username": "carol", "email": "carol@example.com", "password": "carolpass1"},
+		},
+	}
+	if _, err := server.handleRequest(ctx, &Request{Method: "user.import", Params: params}); !errors.Is(err, services.ErrPermissionDenied) {
+		t.Fatalf("expected an unauthenticated import to be denied, got %v", err)
+	}
+
+	result, err := server.handleRequest(WithCaller(ctx, caller), &Request{Method: "user.import", Params: params})
+	if err != nil {
+		t.Fatalf("user.import failed: %v", err)
+	}
+	report := result.(*services.UserImportReport)
+	if len(report.Created) != 2 || len(report.Skipped) != 1 {
+		t.Fatalf("expected 2 created and 1 skipped, got %+v", report)
+	}
+	if report.Skipped[0].Username != "alice" {
+		t.Errorf("expected alice to be skipped, got %+v", report.Skipped[0])
+	}
+	if report.Created[0].Username != "bob" || report.Created[0].TempPassword == "" {
+		t.Errorf("expected bob to get a temporary password, got %+v", report.Created[0])
+	}
+
+	users, err := server.authSvc.ListUsers(ctx, ports.UserFilter{Username: "bob"})
+	if err != nil || len(users) != 1 {
+		t.Fatalf("expected bob to be stored, got %v (%v)", users, err)
+	}
+	if users[0].Role != domain.RoleOperator {
+		t.Errorf("expected bob to be an operator, got %s", users[0].Role)
+	}
+}
+
+func TestMetricCompare(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+	ctx := context.Background()
+
+	// prod and staging sample at different offsets within each 10m step
+	base := time.Now().Truncate(time.Hour).Add(-time.Hour)
+	samples := []struct {
+		env    string
+		offset time.Duration
+		value  float64
+	}{
+		{"prod", 1 * time.Minute, 10},
+		{"prod", 11 * time.Minute, 20},
+		{"prod", 21 * time.Minute, 30},
+		{"prod", 23 * time.Minute, 40},
+		{"staging", 7 * time.Minute, 4},
+		{"staging", 14 * time.Minute, 5},
+		{"staging", 45 * time.Minute, 1},
+	}
+	var metrics []*domain.Metric
+	for _, s := range samples {
+		m := domain.NewMetric("latency", domain.MetricTypeGauge, s.value, map[string]string{"env": s.env})
+		m.Timestamp = base.Add(s.offset)
+		metrics = append(metrics, m)
+	}
+	if err := storage.NewMetricRepository(server.db).RecordBatch(ctx, metrics); err != nil {
+		t.Fatalf("RecordBatch failed: %v", err)
+	}
+
+	resp, err := server.handleRequest(ctx, &Request{Method: "metric.compare", Params: map[string]interface{}{
+		"name":       "latency",
+		"left_tags":  map[string]interface{}{"env": "prod"},
+		"right_tags": map[string]interface{}{"env": "staging"},
+		"step":       "10m",
+		"start":      base.Format(time.RFC3339),
+		"end":        base.Add(time.Hour).Format(time.RFC3339),
+	}})
+	if err != nil {
+		t.Fatalf("metric.compare failed: %v", err)
+	}
+	points := resp.(map[string]interface{})["points"].([]map[string]interface{})
+
+	expected := []struct {
+		offset            time.Duration
+		left, right, diff interface{}
+	}{
+		{0, 10.0, 4.0, 6.0},
+		{10 * time.Minute, 20.0, 5.0, 15.0},
+		{20 * time.Minute, 35.0, nil, nil},
+		{40 * time.Minute, nil, 1.0, nil},
+	}
+	if len(points) != len(expected) {
+		t.Fatalf("expected %d buckets, got %d: %v", len(expected), len(points), points)
+	}
+	for i, want := range expected {
+		p := points[i]
+		if ts := base.Add(want.offset).Format(time.RFC3339); p["timestamp"] != ts {
+			t.Errorf("bucket %d: expected timestamp %s, got %v", i, ts, p["timestamp"])
+		}
+		if p["left"] != want.left || p["right"] != want.right || p["diff"] != want.diff {
+			t.Errorf("bucket %d: expected left %v right %v diff %v, got %v", i, want.left, want.right, want.diff, p)
+		}
+	}
+
+	if _, err := server.handleRequest(ctx, &Request{Method: "metric.compare", Params: map[string]interface{}{}}); err == nil {
+		t.Error("expected metric.compare without a name to fail")
+	}
+}
+
+func TestRequestNumberParams(t *testing.T) {
+	var req Request
+	line := `{"method":"metric.record","params":{"value":75.5,"counter":9007199254740993,"timestamp":1700000000123456789,"limit":"10"}}`
+	if err := decodeRequest([]byte(line), &req); err != nil {
+		t.Fatalf("decodeRequest failed: %v", err)
+	}
+
+	if v, ok := floatParam(req.Params, "value"); !ok || v != 75.5 {
+		t.Errorf("expected value 75.5, got %v (%v)", v, ok)
+	}
+	if n, ok := int64Param(req.Params, "counter"); !ok || n != 9007199254740993 {
+		t.Errorf("expected counter 9007199254740993, got %d (%v)", n, ok)
+	}
+	if n, ok := int64Param(req.Params, "timestamp"); !ok || n != 1700000000123456789 {
+		t.Errorf("expected timestamp 1700000000123456789, got %d (%v)", n, ok)
+	}
+	if _, ok := intParam(req.Params, "limit"); ok {
+		t.Error("expected a string limit to be ignored")
+	}
+
+	// In-process callers pass Go numbers
+	params := map[string]interface{}{"limit": float64(10), "offset": 5}
+	if n, ok := intParam(params, "limit"); !ok || n != 10 {
+		t.Errorf("expected limit 10, got %d (%v)", n, ok)
+	}
+	if n, ok := intParam(params, "offset"); !ok || n != 5 {
+		t.Errorf("expected offset 5, got %d (%v)", n, ok)
+	}
+}
+
+func TestTaskPayloadPreservesLargeIntegers(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	server.wg.Add(1)
+	go server.handleConnection(context.Background(), serverConn)
+
+	reader := bufio.NewReader(clientConn)
+	call := func(line string) string {
+		t.Helper()
+		_ = clientConn.SetDeadline(time.Now().Add(5 * time.Second))
+		if _, err := clientConn.Write([]byte(line + "\n")); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+		resp, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read failed: %v", err)
+		}
+		return resp
+	}
+
+	created := call(`{"method":"task.create","id":"1","params":{"type":"maintenance","priority":3,` +
+		`"payload":{"counter":9007199254740993,"timestamp":1700000000123456789,"ratio":0.25}}}`)
+	if !strings.Contains(created, `"priority":3`) {
+		t.Fatalf("unexpected task.create response %q", created)
+	}
+
+	listed := call(`{"method":"task.list","id":"2","params":{"limit":1}}`)
+	for _, want := range []string{`"counter":9007199254740993`, `"timestamp":1700000000123456789`, `"ratio":0.25`} {
+		if !strings.Contains(listed, want) {
+			t.Errorf("expected %s to round-trip, got %q", want, listed)
+		}
+	}
+}
+
+func TestClientLoginSession(t *testing.T) {
+	server, err := NewServer(DefaultConfig(t.TempDir()), &services.NopLogger{})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.db.Close()
+
+	forgeDir := t.TempDir()
+	listener, err := net.Listen("unix", filepath.Join(forgeDir, "forge.sock"))
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			server.wg.Add(1)
+			go server.handleConnection(context.Background(), conn)
+		}
+	}()
+
+	ctx := context.Background()
+	newClient := func() *Client {
+		t.Helper()
+		client, err := NewClient(forgeDir)
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+		client.timeout = 5 * time.Second
+		t.Cleanup(func() { client.Close() })
+		return client
+	}
+
+	result, err := newClient().Call(ctx, "user.create", map[string]interface{}{
+		"username": "root", "email": "root@example.com", "password": "secret123",
+	})
+	if err != nil {
+		t.Fatalf("bootstrap user.create failed: %v", err)
+	}
+	admin := newClient()
+	admin.SetAPIKey(result.(map[string]interface{})["api_key"].(string))
+	if _, err := admin.Call(ctx, "user.create", map[string]interface{}{
+		"username": "alice", "email": "alice@example.com", "password": "alice-pass", "role": "viewer",
+	}); err != nil {
+		t.Fatalf("user.create failed: %v", err)
+	}
+
+	if _, err := newClient().Call(ctx, "metric.list", nil); !errors.Is(err, ErrLoginRequired) {
+		t.Errorf("expected an anonymous call to ask for a login, got %v", err)
+	}
+	if _, err := newClient().Call(ctx, "auth.login", map[string]interface{}{
+		"username": "alice", "password": "wrong",
+	}); err == nil || !strings.Contains(err.Error(), "invalid credentials") {
+		t.Errorf("expected a wrong password to be rejected, got %v", err)
+	}
+
+	result, err = newClient().Call(ctx, "auth.login", map[string]interface{}{
+		"username": "alice", "password": "alice-pass", "user_agent": "forge-cli/test",
+	})
+	if err != nil {
+		t.Fatalf("auth.login failed: %v", err)
+	}
+	login := result.(map[string]interface{})
+	if login["username"] != "alice" || login["role"] != "viewer" || login["session_token"] == "" {
+		t.Fatalf("unexpected login result: %v", login)
+	}
+	expires, _ := time.Parse(time.RFC3339, login["expires_at"].(string))
+	creds := &Credentials{SessionToken: login["session_token"].(string), Username: "alice", ExpiresAt: expires}
+	if err := SaveCredentials(forgeDir, creds); err != nil {
+		t.Fatalf("SaveCredentials failed: %v", err)
+	}
+	if info, err := os.Stat(filepath.Join(forgeDir, CredentialsFile)); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("expected credentials with 0600 permissions, got %v (%v)", info.Mode(), err)
+	}
+
+	// New clients present the saved session
+	client := newClient()
+	result, err = client.Call(ctx, "auth.whoami", nil)
+	if err != nil {
+		t.Fatalf("auth.whoami failed: %v", err)
+	}
+	if who := result.(map[string]interface{}); who["username"] != "alice" || who["role"] != "viewer" || who["session_id"] == nil {
+		t.Errorf("unexpected identity: %v", who)
+	}
+	if _, err := client.Call(ctx, "metric.list", nil); err != nil {
+		t.Errorf("expected the session to authorize metric.list, got %v", err)
+	}
+
+	// Logging out revokes the session, on this connection and later ones
+	if _, err := client.Call(ctx, "auth.logout", nil); err != nil {
+		t.Fatalf("auth.logout failed: %v", err)
+	}
+	if _, err := client.Call(ctx, "metric.list", nil); !errors.Is(err, ErrLoginRequired) {
+		t.Errorf("expected a login prompt after logout, got %v", err)
+	}
+	revoked := newClient()
+	if _, err := revoked.Call(ctx, "ping", nil); err != nil {
+		t.Errorf("expected public methods to work with a revoked session, got %v", err)
+	}
+	if _, err := revoked.Call(ctx, "metric.list", nil); !errors.Is(err, ErrLoginRequired) || !strings.Contains(err.Error(), "revoked") {
+		t.Errorf("expected a revoked session to ask for a login, got %v", err)
+	}
+
+	// A session saved past its expiry isn't presented at all
+	creds.ExpiresAt = time.Now().Add(-time.Minute)
+	if err := SaveCredentials(forgeDir, creds); err != nil {
+		t.Fatalf("SaveCredentials failed: %v", err)
+	}
+	if _, err := newClient().Call(ctx, "metric.list", nil); !errors.Is(err, ErrLoginRequired) {
+		t.Errorf("expected an expired session to ask for a login, got %v", err)
+	}
+
+	if err := RemoveCredentials(forgeDir); err != nil {
+		t.Fatalf("RemoveCredentials failed: %v", err)
+	}
+	if loaded, err := LoadCredentials(forgeDir); err != nil || loaded != nil {
+		t.Errorf("expected no credentials after removal, got %v (%v)", loaded, err)
+	}
+}
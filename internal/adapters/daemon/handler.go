@@ -2,12 +2,19 @@ package daemon
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
+	"os"
+	"path/filepath"
 	"runtime"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/forge-platform/forge/internal/core/domain"
@@ -23,6 +30,15 @@ type Request struct {
 	ID     string                 `json:"id"`
 }
 
+// decodeRequest parses a request line. Numbers in params are kept as
+// json.Number so handlers convert each field explicitly and integers beyond
+// float64 precision, such as large counters or timestamps, survive intact.
+func decodeRequest(line []byte, req *Request) error {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	return dec.Decode(req)
+}
+
 // Response represents a daemon RPC response.
 type Response struct {
 	Result interface{} `json:"result,omitempty"`
@@ -30,6 +46,32 @@ type Response struct {
 	ID     string      `json:"id"`
 }
 
+// maxBatchRequests caps the requests in one batch, so a single line can't
+// tie up the connection's handler indefinitely.
+const maxBatchRequests = 100
+
+// isBatch reports whether a request line holds a JSON array of requests.
+func isBatch(line []byte) bool {
+	trimmed := bytes.TrimLeft(line, " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
+
+// decodeBatch splits a batch line into its raw requests, so each can be
+// decoded, and fail, on its own.
+func decodeBatch(line []byte) ([]json.RawMessage, error) {
+	var batch []json.RawMessage
+	if err := json.Unmarshal(line, &batch); err != nil {
+		return nil, err
+	}
+	if len(batch) == 0 {
+		return nil, errors.New("empty batch")
+	}
+	if len(batch) > maxBatchRequests {
+		return nil, fmt.Errorf("batch of %d requests exceeds the limit of %d", len(batch), maxBatchRequests)
+	}
+	return batch, nil
+}
+
 // acceptConnections accepts incoming connections.
 func (s *Server) acceptConnections(ctx context.Context) {
 	defer s.wg.Done()
@@ -59,12 +101,22 @@ func (s *Server) acceptConnections(ctx context.Context) {
 	}
 }
 
-// handleConnection handles a single client connection.
+// idleClosedMessage is sent as an error response before an idle connection
+// is closed. The daemon had not read a request, so clients may retry.
+const idleClosedMessage = "connection closed after idle timeout"
+
+// handleConnection handles a single client connection. Connections that
+// send no request for the configured idle timeout are closed.
 func (s *Server) handleConnection(ctx context.Context, conn net.Conn) {
 	defer s.wg.Done()
 	defer conn.Close()
 
 	reader := bufio.NewReader(conn)
+	address := clientAddress(conn)
+	peer := peerIdentity(conn)
+
+	// Set by a successful auth.handshake and attached to later requests
+	var caller *Caller
 
 	for {
 		select {
@@ -76,39 +128,129 @@ func (s *Server) handleConnection(ctx context.Context, conn net.Conn) {
 		}
 
 		// Read request
+		if s.config.IdleTimeout > 0 {
+			_ = conn.SetReadDeadline(time.Now().Add(s.config.IdleTimeout))
+		}
 		line, err := reader.ReadBytes('\n')
 		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				s.logger.Debug("Closing idle connection", "idle_timeout", s.config.IdleTimeout)
+				_ = conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+				_ = writeResponse(conn, Response{Error: idleClosedMessage})
+				return
+			}
 			if err != io.EOF {
 				s.logger.Debug("Connection closed", "error", err)
 			}
 			return
 		}
+		// Handlers and streams may run past the idle timeout
+		_ = conn.SetReadDeadline(time.Time{})
+
+		requestContext := func() context.Context {
+			reqCtx := ctx
+			if caller != nil {
+				reqCtx = WithCaller(ctx, caller)
+			}
+			return auditSource(reqCtx, address, peer)
+		}
+
+		// A batch is answered with an array of responses in request order
+		if isBatch(line) {
+			batch, err := decodeBatch(line)
+			if err != nil {
+				s.sendError(conn, "", fmt.Sprintf("invalid batch: %v", err))
+				continue
+			}
+			responses := make([]Response, len(batch))
+			for i, raw := range batch {
+				var req Request
+				if err := decodeRequest(raw, &req); err != nil {
+					responses[i] = Response{Error: fmt.Sprintf("invalid request: %v", err)}
+					continue
+				}
+				if isStreamingMethod(req.Method) {
+					responses[i] = Response{ID: req.ID, Error: fmt.Sprintf("%s requires a streaming connection and cannot be batched", req.Method)}
+					continue
+				}
+				responses[i] = s.respond(requestContext(), &req, &caller)
+			}
+			respBytes, _ := json.Marshal(responses)
+			respBytes = append(respBytes, '\n')
+			_, _ = conn.Write(respBytes)
+			continue
+		}
 
 		var req Request
-		if err := json.Unmarshal(line, &req); err != nil {
+		if err := decodeRequest(line, &req); err != nil {
 			s.sendError(conn, "", fmt.Sprintf("invalid request: %v", err))
 			continue
 		}
+		reqCtx := requestContext()
+
+		// Streaming methods take over the connection until the client leaves
+		if isStreamingMethod(req.Method) {
+			if err := s.authorize(reqCtx, req.Method, req.Params); err != nil {
+				s.sendError(conn, req.ID, err.Error())
+				continue
+			}
+			s.handleStream(reqCtx, conn, reader, &req)
+			return
+		}
+
+		_ = writeResponse(conn, s.respond(reqCtx, &req, &caller))
+	}
+}
 
-		// Handle request
-		result, err := s.handleRequest(ctx, &req)
-		resp := Response{ID: req.ID}
+// respond handles a non-streaming request on a connection whose
+// authenticated caller is *caller.
+func (s *Server) respond(ctx context.Context, req *Request, caller **Caller) Response {
+	resp := Response{ID: req.ID}
+
+	// A handshake replaces the connection's identity, or clears it
+	// if the key is rejected
+	if req.Method == authHandshakeMethod {
+		*caller = nil
+		authenticated, err := s.authenticate(ctx, req.Params)
 		if err != nil {
 			resp.Error = err.Error()
 		} else {
-			resp.Result = result
+			*caller = authenticated
+			resp.Result = callerToMap(authenticated)
 		}
+		return resp
+	}
 
-		// Send response
-		respBytes, _ := json.Marshal(resp)
-		respBytes = append(respBytes, '\n')
-		_, _ = conn.Write(respBytes)
+	result, err := s.dispatchRequest(ctx, req)
+	if err != nil {
+		resp.Error = err.Error()
+	} else {
+		resp.Result = result
 	}
+	return resp
 }
 
-// handleRequest routes and handles a request.
+// handleRequest authorizes, routes and handles a request.
 func (s *Server) handleRequest(ctx context.Context, req *Request) (interface{}, error) {
+	if err := s.authorize(ctx, req.Method, req.Params); err != nil {
+		return nil, err
+	}
+	return s.routeRequest(ctx, req)
+}
+
+// routeRequest routes and handles an authorized request.
+func (s *Server) routeRequest(ctx context.Context, req *Request) (interface{}, error) {
 	switch req.Method {
+	case authHandshakeMethod:
+		return s.handleAuthHandshake(ctx, req.Params)
+
+	case capabilitiesMethod:
+		return s.handleCapabilities()
+
+	case "ping":
+		return s.ping(), nil
+
 	case "status":
 		return s.GetStatus(), nil
 
@@ -127,63 +269,14 @@ func (s *Server) handleRequest(ctx context.Context, req *Request) (interface{},
 	case "backup.info":
 		return s.handleBackupInfo(ctx, req.Params)
 
-	case "task.list":
-		// Parse filters if provided
-		filter := ports.TaskFilter{}
-		if statusStr, ok := req.Params["status"].(string); ok && statusStr != "" {
-			status := domain.TaskStatus(statusStr)
-			filter.Status = &status
-		}
-		if typeStr, ok := req.Params["type"].(string); ok && typeStr != "" {
-			tType := domain.TaskType(typeStr)
-			filter.Type = &tType
-		}
-		if limit, ok := req.Params["limit"].(float64); ok && limit > 0 {
-			filter.Limit = int(limit)
-		} else {
-			filter.Limit = 50 // default
-		}
+	case "schedule.list":
+		return s.handleScheduleList(ctx, req.Params)
 
-		tasks, err := s.taskSvc.ListTasks(ctx, filter)
-		if err != nil {
-			return nil, err
-		}
-		
-		// Convert to map for JSON serialization
-		result := make([]map[string]interface{}, len(tasks))
-		for i, t := range tasks {
-			result[i] = map[string]interface{}{
-				"id":         t.ID.String(),
-				"type":       string(t.Type),
-				"status":     string(t.Status),
-				"created_at": t.CreatedAt.Format(time.RFC3339),
-				"updated_at": t.UpdatedAt.Format(time.RFC3339),
-				"error":      t.Error,
-			}
-		}
-		return result, nil
+	case "task.list":
+		return s.handleTaskList(ctx, req.Params)
 
 	case "task.create":
-		taskTypeStr, ok := req.Params["type"].(string)
-		if !ok || taskTypeStr == "" {
-			return nil, fmt.Errorf("task type is required")
-		}
-		
-		payload, _ := req.Params["payload"].(map[string]interface{})
-		if payload == nil {
-			payload = make(map[string]interface{})
-		}
-
-		task, err := s.taskSvc.CreateTask(ctx, domain.TaskType(taskTypeStr), payload)
-		if err != nil {
-			return nil, err
-		}
-
-		return map[string]interface{}{
-			"id":     task.ID.String(),
-			"status": "created",
-			"type":   string(task.Type),
-		}, nil
+		return s.handleTaskCreate(ctx, req.Params)
 
 	case "task.status":
 		idStr, ok := req.Params["id"].(string)
@@ -201,14 +294,7 @@ func (s *Server) handleRequest(ctx context.Context, req *Request) (interface{},
 			return nil, err
 		}
 		
-		return map[string]interface{}{
-			"id":         task.ID.String(),
-			"type":       string(task.Type),
-			"status":     string(task.Status),
-			"error":      task.Error,
-			"created_at": task.CreatedAt.Format(time.RFC3339),
-			"updated_at": task.UpdatedAt.Format(time.RFC3339),
-		}, nil
+		return taskToMap(task), nil
 
 	case "task.cancel":
 		idStr, ok := req.Params["id"].(string)
@@ -229,7 +315,7 @@ func (s *Server) handleRequest(ctx context.Context, req *Request) (interface{},
 
 	case "metric.record":
 		name, _ := req.Params["name"].(string)
-		value, _ := req.Params["value"].(float64)
+		value, _ := floatParam(req.Params, "value")
 		tags := make(map[string]string)
 		if tagsInterface, ok := req.Params["tags"].(map[string]interface{}); ok {
 			for k, v := range tagsInterface {
@@ -252,15 +338,29 @@ func (s *Server) handleRequest(ctx context.Context, req *Request) (interface{},
 
 	case "metric.query":
 		name, _ := req.Params["name"].(string)
-		limitF, _ := req.Params["limit"].(float64)
-		limit := int(limitF)
-		if limit <= 0 {
+		// resolution is auto, raw, or a rollup resolution such as 1m or 1h
+		resolution, _ := req.Params["resolution"].(string)
+		if resolution == "" {
+			resolution = services.ResolutionAuto
+		}
+		limit, _ := intParam(req.Params, "limit")
+		if limit <= 0 && resolution == services.ResolutionRaw {
 			limit = 100
 		}
 		
 		q := ports.MetricQuery{
 			Name: name,
 			Limit: limit,
+			Resolution: resolution,
+		}
+		// include_ids adds each raw point's stored ID and type
+		q.IncludeIDs, _ = req.Params["include_ids"].(bool)
+		if stepStr, ok := req.Params["step"].(string); ok && stepStr != "" {
+			step, err := time.ParseDuration(stepStr)
+			if err != nil || step <= 0 {
+				return nil, fmt.Errorf("invalid step: %s", stepStr)
+			}
+			q.Step = step
 		}
 
 		if startStr, ok := req.Params["start"].(string); ok && startStr != "" {
@@ -283,6 +383,20 @@ func (s *Server) handleRequest(ctx context.Context, req *Request) (interface{},
 			}
 			q.Tags = tags
 		}
+
+		// group_by returns one aggregated series per combination of tag values
+		if groupBy, ok := req.Params["group_by"].([]interface{}); ok && len(groupBy) > 0 {
+			for _, key := range groupBy {
+				k, ok := key.(string)
+				if !ok || k == "" {
+					return nil, fmt.Errorf("group_by must be tag names")
+				}
+				q.GroupBy = append(q.GroupBy, k)
+			}
+			agg, _ := req.Params["agg"].(string)
+			q.Aggregation = ports.AggregationType(agg)
+			return s.handleMetricQueryGrouped(ctx, q)
+		}
 		
 		series, err := s.metricSvc.Query(ctx, q)
 		if err != nil {
@@ -292,17 +406,40 @@ func (s *Server) handleRequest(ctx context.Context, req *Request) (interface{},
 		var points []interface{}
 		if series != nil {
 			for _, p := range series.Points {
-				points = append(points, map[string]interface{}{
-					"timestamp": p.Timestamp.Format(time.RFC3339),
-					"value": p.Value,
-				})
+				point := map[string]interface{}{
+					"timestamp":  p.Timestamp.Format(time.RFC3339),
+					"value":      p.Value,
+					"resolution": p.Resolution,
+				}
+				if p.ID != nil {
+					point["id"] = p.ID.String()
+					point["type"] = string(p.Type)
+				}
+				points = append(points, point)
 			}
 		}
 		
-		return map[string]interface{}{"points": points}, nil
+		result := map[string]interface{}{"points": points}
+		if series != nil && series.Unit != "" {
+			result["unit"] = series.Unit
+		}
+		return result, nil
+
+	case "metric.export":
+		return nil, fmt.Errorf("metric.export requires a streaming connection")
+
+	case "metric.compare":
+		return s.handleMetricCompare(ctx, req.Params)
 
 	case "metric.list":
-		series, err := s.metricSvc.GetDistinctSeries(ctx)
+		// With a limit, return the most recently written series first
+		var series []ports.SeriesInfo
+		var err error
+		if limit, ok := intParam(req.Params, "limit"); ok && limit > 0 {
+			series, err = s.metricSvc.GetRecentSeries(ctx, limit)
+		} else {
+			series, err = s.metricSvc.GetDistinctSeries(ctx)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -311,6 +448,7 @@ func (s *Server) handleRequest(ctx context.Context, req *Request) (interface{},
 			list = append(list, map[string]interface{}{
 				"name": info.Name,
 				"tags": info.Tags,
+				"points": info.PointCount,
 				"first_time": info.FirstTime.Format(time.RFC3339),
 				"last_time": info.LastTime.Format(time.RFC3339),
 			})
@@ -341,7 +479,14 @@ func (s *Server) handleRequest(ctx context.Context, req *Request) (interface{},
 			Name: name, StartTime: start, EndTime: end, Tags: tags,
 			Aggregation: ports.AggregationType(agg), Step: step,
 		}
-		results, err := s.metricSvc.QueryWithAggregation(ctx, q)
+		// merged also reads downsampled history older than raw retention
+		var results []ports.AggregatedResult
+		var err error
+		if merged, _ := req.Params["merged"].(bool); merged {
+			results, err = s.metricSvc.QueryMerged(ctx, q)
+		} else {
+			results, err = s.metricSvc.QueryWithAggregation(ctx, q)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -353,7 +498,34 @@ func (s *Server) handleRequest(ctx context.Context, req *Request) (interface{},
 				"sum": r.Sum, "avg": r.Avg, "min": r.Min, "max": r.Max, "count": r.Count,
 			})
 		}
-		return map[string]interface{}{"points": list}, nil
+		result := map[string]interface{}{"points": list}
+		if unit := s.metricSvc.UnitFor(name, tags); unit != "" {
+			result["unit"] = unit
+		}
+		return result, nil
+
+	case "metric.transform.list":
+		rules := s.metricSvc.TransformRules()
+		list := make([]interface{}, 0, len(rules))
+		for _, r := range rules {
+			list = append(list, map[string]interface{}{
+				"name": r.Name, "metric": r.Metric, "tags": r.Tags,
+				"scale": r.Scale, "offset": r.Offset, "unit": r.Unit, "stage": string(r.Stage),
+			})
+		}
+		return map[string]interface{}{"rules": list}, nil
+
+	case "metric.relabel.list":
+		rules := s.metricSvc.RelabelRules()
+		list := make([]interface{}, 0, len(rules))
+		for _, r := range rules {
+			list = append(list, map[string]interface{}{
+				"name": r.Name, "metric": r.Metric, "tags": r.Tags, "action": string(r.Action),
+				"source_tag": r.SourceTag, "target_tag": r.TargetTag,
+				"regex": r.Regex, "replacement": r.Replacement,
+			})
+		}
+		return map[string]interface{}{"rules": list}, nil
 
 	case "metric.downsample":
 		olderThanStr, _ := req.Params["older_than"].(string)
@@ -362,10 +534,17 @@ func (s *Server) handleRequest(ctx context.Context, req *Request) (interface{},
 		if err != nil {
 			return nil, err
 		}
-		if err := s.metricSvc.Downsample(ctx, olderThan, resolution); err != nil {
+		result, err := s.metricSvc.DownsampleTiers(ctx, olderThan, []string{resolution})
+		if err != nil {
 			return nil, err
 		}
-		return map[string]string{"status": "downsampling completed"}, nil
+		return map[string]interface{}{
+			"status":      "downsampling completed",
+			"series":      result.Series,
+			"aggregated":  result.Aggregated,
+			"deleted_raw": result.Deleted,
+			"failed":      result.Failed,
+		}, nil
 
 	case "metric.stats":
 		stats, err := s.metricSvc.GetStats(ctx)
@@ -376,11 +555,26 @@ func (s *Server) handleRequest(ctx context.Context, req *Request) (interface{},
 
 
 
+	case "event.publish":
+		return s.handleEventPublish(ctx, req.Params)
+
 	case "plugin.list":
-		// Plugin listing returns loaded WASM plugins.
-		// Currently returns empty as plugins are loaded on-demand via
-		// the WASM runtime and not yet persisted in the daemon server.
-		return map[string]interface{}{"plugins": []interface{}{}}, nil
+		return s.handlePluginList()
+
+	case "plugin.install":
+		return s.handlePluginInstall(ctx, req.Params)
+
+	case "plugin.reload":
+		return s.handlePluginReload(ctx, req.Params)
+
+	case "plugin.configure":
+		return s.handlePluginConfigure(ctx, req.Params)
+
+	case "plugin.storage":
+		return s.handlePluginStorage(ctx, req.Params, false)
+
+	case "plugin.storage.purge":
+		return s.handlePluginStorage(ctx, req.Params, true)
 
 	case "ai.chat":
 		return s.handleAIChat(ctx, req.Params)
@@ -394,6 +588,9 @@ func (s *Server) handleRequest(ctx context.Context, req *Request) (interface{},
 	case "ai.analyze":
 		return s.handleAIAnalyze(ctx, req.Params)
 
+	case "ai.insights.list":
+		return s.handleAIInsightsList(ctx, req.Params)
+
 	case "ai.explain":
 		return s.handleAIExplain(ctx, req.Params)
 
@@ -443,9 +640,39 @@ func (s *Server) handleRequest(ctx context.Context, req *Request) (interface{},
 	case "alert.silence.list":
 		return s.handleAlertSilenceList(ctx)
 
+	case "alert.silence.delete":
+		return s.handleAlertSilenceDelete(ctx, req.Params)
+
+	case "alert.silence.expire":
+		return s.handleAlertSilenceExpire(ctx, req.Params)
+
+	case "alert.maintenance.create":
+		return s.handleAlertMaintenanceCreate(ctx, req.Params)
+
+	case "alert.maintenance.list":
+		return s.handleAlertMaintenanceList(ctx)
+
+	case "alert.maintenance.update":
+		return s.handleAlertMaintenanceUpdate(ctx, req.Params)
+
+	case "alert.maintenance.delete":
+		return s.handleAlertMaintenanceDelete(ctx, req.Params)
+
 	case "alert.channel.list":
 		return s.handleAlertChannelList(ctx)
 
+	case "alert.channel.create":
+		return s.handleAlertChannelCreate(ctx, req.Params)
+
+	case "alert.channel.update":
+		return s.handleAlertChannelUpdate(ctx, req.Params)
+
+	case "alert.channel.delete":
+		return s.handleAlertChannelDelete(ctx, req.Params)
+
+	case "alert.channel.test":
+		return s.handleAlertChannelTest(ctx, req.Params)
+
 	// Trace handlers
 	case "trace.list":
 		return s.handleTraceList(ctx, req.Params)
@@ -456,11 +683,20 @@ func (s *Server) handleRequest(ctx context.Context, req *Request) (interface{},
 	case "trace.spans":
 		return s.handleTraceSpans(ctx, req.Params)
 
+	case "trace.slowest":
+		return s.handleTraceSlowest(ctx, req.Params)
+
+	case "span.search":
+		return s.handleSpanSearch(ctx, req.Params)
+
 	case "trace.service-map":
 		return s.handleTraceServiceMap(ctx, req.Params)
 
 	case "trace.stats":
-		return s.handleTraceStats(ctx)
+		return s.handleTraceStats(ctx, req.Params)
+
+	case "trace.config.update":
+		return s.handleTraceConfigUpdate(req.Params)
 
 	// Log handlers
 	case "log.list":
@@ -469,12 +705,33 @@ func (s *Server) handleRequest(ctx context.Context, req *Request) (interface{},
 	case "log.search":
 		return s.handleLogSearch(ctx, req.Params)
 
+	case "log.tail":
+		return nil, fmt.Errorf("log.tail requires a streaming connection")
+
 	case "log.stats":
 		return s.handleLogStats(ctx, req.Params)
 
+	case "log.ingest":
+		return s.handleLogIngest(ctx, req.Params)
+
 	case "log.parser.list":
 		return s.handleLogParserList(ctx)
 
+	case "log.parser.create":
+		return s.handleLogParserCreate(ctx, req.Params)
+
+	case "log.parser.delete":
+		return s.handleLogParserDelete(ctx, req.Params)
+
+	case "log.metric.rule.create", "log.metricrule.create":
+		return s.handleLogMetricRuleCreate(ctx, req.Params)
+
+	case "log.metric.rule.list", "log.metricrule.list":
+		return s.handleLogMetricRuleList(ctx)
+
+	case "log.metric.rule.delete", "log.metricrule.delete":
+		return s.handleLogMetricRuleDelete(ctx, req.Params)
+
 	// Profile handlers
 	case "profile.start.cpu":
 		return s.handleProfileStartCPU(ctx, req.Params)
@@ -497,6 +754,12 @@ func (s *Server) handleRequest(ctx context.Context, req *Request) (interface{},
 	case "profile.delete":
 		return s.handleProfileDelete(ctx, req.Params)
 
+	case "profile.export":
+		return nil, fmt.Errorf("profile.export requires a streaming connection")
+
+	case "profile.flamegraph":
+		return s.handleProfileFlameGraph(ctx, req.Params)
+
 	case "profile.stats":
 		return s.handleProfileStats(ctx)
 
@@ -516,6 +779,9 @@ func (s *Server) handleRequest(ctx context.Context, req *Request) (interface{},
 	case "user.delete":
 		return s.handleUserDelete(ctx, req.Params)
 
+	case "user.import":
+		return s.handleUserImport(ctx, req.Params)
+
 	case "apikey.create":
 		return s.handleAPIKeyCreate(ctx, req.Params)
 
@@ -528,63 +794,234 @@ func (s *Server) handleRequest(ctx context.Context, req *Request) (interface{},
 	case "audit.list":
 		return s.handleAuditList(ctx, req.Params)
 
+	case "audit.verify":
+		return s.handleAuditVerify(ctx)
+
+	case "auth.login":
+		return s.handleAuthLogin(ctx, req.Params)
+
+	case "auth.logout":
+		return s.handleAuthLogout(ctx)
+
+	case "auth.whoami":
+		return s.handleAuthWhoami(ctx)
+
 	default:
 		return nil, fmt.Errorf("unknown method: %s", req.Method)
 	}
 }
 
-// handleAIChat handles AI chat requests.
+// handleMetricQueryGrouped answers a metric.query with group_by, returning
+// one aggregated series per group along with the group's tag values.
+func (s *Server) handleMetricQueryGrouped(ctx context.Context, q ports.MetricQuery) (interface{}, error) {
+	groups, err := s.metricSvc.QueryGrouped(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]map[string]interface{}, 0, len(groups))
+	for _, g := range groups {
+		points := make([]map[string]interface{}, 0, len(g.Results))
+		for _, r := range g.Results {
+			points = append(points, map[string]interface{}{
+				"timestamp": r.Timestamp.Format(time.RFC3339),
+				"value":     r.Value,
+				"count":     r.Count,
+			})
+		}
+		result = append(result, map[string]interface{}{
+			"tags":   g.Tags,
+			"points": points,
+		})
+	}
+
+	response := map[string]interface{}{"groups": result}
+	if unit := s.metricSvc.UnitFor(q.Name, q.Tags); unit != "" {
+		response["unit"] = unit
+	}
+	return response, nil
+}
+
+// handleMetricCompare aligns two series by time bucket and returns their
+// difference, e.g. the same metric with left_tags {"env": "prod"} and
+// right_tags {"env": "staging"}. right_name compares against another metric.
+func (s *Server) handleMetricCompare(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	name, _ := params["name"].(string)
+	if name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	rightName, _ := params["right_name"].(string)
+
+	start, err := parseTimeParam(params, "start")
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseTimeParam(params, "end")
+	if err != nil {
+		return nil, err
+	}
+	if start.IsZero() {
+		end = time.Now()
+		start = end.Add(-time.Hour)
+	}
+	var step time.Duration
+	if stepStr, ok := params["step"].(string); ok && stepStr != "" {
+		step, err = time.ParseDuration(stepStr)
+		if err != nil || step <= 0 {
+			return nil, fmt.Errorf("invalid step: %s", stepStr)
+		}
+	}
+	agg, _ := params["agg"].(string)
+
+	tagParam := func(key string) map[string]string {
+		tags := make(map[string]string)
+		if m, ok := params[key].(map[string]interface{}); ok {
+			for k, v := range m {
+				if strV, ok := v.(string); ok {
+					tags[k] = strV
+				}
+			}
+		}
+		return tags
+	}
+	left := ports.MetricQuery{
+		Name: name, Tags: tagParam("left_tags"), StartTime: start, EndTime: end,
+		Step: step, Aggregation: ports.AggregationType(agg),
+	}
+	right := ports.MetricQuery{Name: rightName, Tags: tagParam("right_tags")}
+
+	comparison, err := s.metricSvc.CompareSeries(ctx, left, right)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]map[string]interface{}, 0, len(comparison.Points))
+	for _, p := range comparison.Points {
+		point := map[string]interface{}{"timestamp": p.Timestamp.Format(time.RFC3339)}
+		if p.Left != nil {
+			point["left"] = *p.Left
+		}
+		if p.Right != nil {
+			point["right"] = *p.Right
+		}
+		if p.Diff != nil {
+			point["diff"] = *p.Diff
+		}
+		points = append(points, point)
+	}
+
+	result := map[string]interface{}{
+		"step":   comparison.Step.String(),
+		"points": points,
+	}
+	if unit := s.metricSvc.UnitFor(name, left.Tags); unit != "" {
+		result["unit"] = unit
+	}
+	return result, nil
+}
+
+// Default system prompts for conversations started by ai.chat and ai.ask.
+const (
+	aiChatSystemPrompt = "You are a helpful assistant for system administration and DevOps."
+	aiAskSystemPrompt  = "You are a helpful assistant for system administration and DevOps. Provide concise, actionable answers."
+)
+
+// handleAIChat handles AI chat requests. Passing the conversation_id
+// returned by a previous call continues that conversation.
 func (s *Server) handleAIChat(ctx context.Context, params map[string]interface{}) (interface{}, error) {
 	if s.aiProvider == nil {
 		return map[string]interface{}{"content": "AI provider not configured. Start Ollama and restart the daemon."}, nil
 	}
 
-	message, _ := params["message"].(string)
-	model, _ := params["model"].(string)
+	return s.aiConversationTurn(ctx, params, "message", aiChatSystemPrompt)
+}
 
-	if model != "" && model != s.aiProvider.GetModel() {
-		s.aiProvider.SetModel(model)
+// handleAIAsk handles single AI question requests.
+func (s *Server) handleAIAsk(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if s.aiProvider == nil {
+		return map[string]interface{}{"content": "AI provider not configured"}, nil
 	}
 
-	// Create a conversation with the user message
-	conv := domain.NewConversation(s.aiProvider.GetModel(), "You are a helpful assistant for system administration and DevOps.")
-	conv.AddMessage(domain.RoleUser, message)
+	return s.aiConversationTurn(ctx, params, "question", aiAskSystemPrompt)
+}
+
+// aiConversationTurn sends params[inputKey] to the AI provider as the next
+// user turn of the conversation named by the conversation_id param, or of a
+// new conversation when none is given, and persists both turns.
+func (s *Server) aiConversationTurn(ctx context.Context, params map[string]interface{}, inputKey, systemPrompt string) (interface{}, error) {
+	conv, isNew, err := s.startConversationTurn(ctx, params, inputKey, systemPrompt)
+	if err != nil {
+		return nil, err
+	}
 
-	// Get response from AI
 	response, err := s.aiProvider.Chat(ctx, conv)
 	if err != nil {
 		return nil, fmt.Errorf("AI error: %w", err)
 	}
+	if err := s.finishConversationTurn(ctx, conv, response, isNew); err != nil {
+		return nil, err
+	}
 
 	return map[string]interface{}{
-		"content": response.Content,
+		"content":         response.Content,
+		"conversation_id": conv.ID.String(),
 	}, nil
 }
 
-// handleAIAsk handles single AI question requests.
-func (s *Server) handleAIAsk(ctx context.Context, params map[string]interface{}) (interface{}, error) {
-	if s.aiProvider == nil {
-		return map[string]interface{}{"content": "AI provider not configured"}, nil
+// startConversationTurn loads the conversation named by the conversation_id
+// param, or starts a new one, and appends params[inputKey] as a user turn.
+// It reports whether the conversation is new.
+func (s *Server) startConversationTurn(ctx context.Context, params map[string]interface{}, inputKey, systemPrompt string) (*domain.Conversation, bool, error) {
+	input, _ := params[inputKey].(string)
+	if input == "" {
+		return nil, false, fmt.Errorf("%s is required", inputKey)
 	}
 
-	question, _ := params["question"].(string)
 	model, _ := params["model"].(string)
-
 	if model != "" && model != s.aiProvider.GetModel() {
 		s.aiProvider.SetModel(model)
 	}
 
-	conv := domain.NewConversation(s.aiProvider.GetModel(), "You are a helpful assistant for system administration and DevOps. Provide concise, actionable answers.")
-	conv.AddMessage(domain.RoleUser, question)
+	var conv *domain.Conversation
+	isNew := true
+	if idStr, _ := params["conversation_id"].(string); idStr != "" {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid conversation_id: %w", err)
+		}
+		conv, err = s.convRepo.GetByID(ctx, id)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to load conversation %s: %w", idStr, err)
+		}
+		conv.Model = s.aiProvider.GetModel()
+		isNew = false
+	} else {
+		conv = domain.NewConversation(s.aiProvider.GetModel(), systemPrompt)
+	}
+	conv.AddMessage(domain.RoleUser, input)
+	return conv, isNew, nil
+}
 
-	response, err := s.aiProvider.Chat(ctx, conv)
-	if err != nil {
-		return nil, fmt.Errorf("AI error: %w", err)
+// finishConversationTurn records the provider's reply and persists the
+// conversation.
+func (s *Server) finishConversationTurn(ctx context.Context, conv *domain.Conversation, response *domain.Message, isNew bool) error {
+	// Providers may already have appended the reply to the conversation
+	if last := conv.GetLastMessage(); last == nil || last.ID != response.ID {
+		conv.Messages = append(conv.Messages, *response)
+		conv.UpdatedAt = time.Now()
 	}
 
-	return map[string]interface{}{
-		"content": response.Content,
-	}, nil
+	var err error
+	if isNew {
+		conv.GenerateTitle()
+		err = s.convRepo.Create(ctx, conv)
+	} else {
+		err = s.convRepo.Update(ctx, conv)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save conversation: %w", err)
+	}
+	return nil
 }
 
 // handleAIModels returns available AI models.
@@ -633,10 +1070,73 @@ func (s *Server) handleAIAnalyze(ctx context.Context, params map[string]interfac
 		}
 	}
 
-	return map[string]interface{}{
+	resp := map[string]interface{}{
 		"issues":  issues,
 		"summary": result.Summary,
-	}, nil
+	}
+	if result.ID != uuid.Nil {
+		resp["insight_id"] = result.ID.String()
+	}
+	return resp, nil
+}
+
+// handleAIInsightsList returns past analysis results, newest first. The
+// window is either start_time/end_time (RFC3339) or a since duration.
+func (s *Server) handleAIInsightsList(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	filter := ports.InsightFilter{Limit: 50}
+	if limit, ok := intParam(params, "limit"); ok && limit > 0 {
+		filter.Limit = limit
+	}
+
+	var err error
+	if filter.StartTime, err = parseTimeParam(params, "start_time"); err != nil {
+		return nil, err
+	}
+	if filter.EndTime, err = parseTimeParam(params, "end_time"); err != nil {
+		return nil, err
+	}
+	if since, _ := params["since"].(string); since != "" {
+		if !filter.StartTime.IsZero() {
+			return nil, fmt.Errorf("since and start_time cannot be combined")
+		}
+		d, err := time.ParseDuration(since)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid since: %q", since)
+		}
+		filter.StartTime = time.Now().Add(-d)
+	}
+	if !filter.StartTime.IsZero() && !filter.EndTime.IsZero() && filter.EndTime.Before(filter.StartTime) {
+		return nil, fmt.Errorf("end_time must not be before start_time")
+	}
+
+	insights, err := s.ragSvc.ListInsights(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]interface{}, len(insights))
+	for i, insight := range insights {
+		issues := make([]interface{}, len(insight.Issues))
+		for j, issue := range insight.Issues {
+			issues[j] = map[string]interface{}{
+				"severity":    issue.Severity,
+				"component":   issue.Component,
+				"description": issue.Description,
+				"suggestion":  issue.Suggestion,
+			}
+		}
+		result[i] = map[string]interface{}{
+			"id":           insight.ID.String(),
+			"timestamp":    insight.Timestamp.Format(time.RFC3339),
+			"time_range":   insight.TimeRange.String(),
+			"metric_count": insight.MetricCount,
+			"task_count":   insight.TaskCount,
+			"max_severity": insight.MaxSeverity(),
+			"summary":      insight.Summary,
+			"issues":       issues,
+		}
+	}
+	return map[string]interface{}{"insights": result, "count": len(result)}, nil
 }
 
 // handleAIExplain explains metric behavior using AI.
@@ -658,7 +1158,7 @@ func (s *Server) handleAIExplain(ctx context.Context, params map[string]interfac
 		TimeRange:      timeRange,
 		IncludeMetrics: true,
 		IncludeTasks:   false,
-		IncludeLogs:    false,
+		IncludeLogs:    true,
 	}
 
 	if metricName != "" {
@@ -674,10 +1174,17 @@ func (s *Server) handleAIExplain(ctx context.Context, params map[string]interfac
 	if s.aiProvider == nil {
 		explanation := "## Metric Analysis\n\n"
 		for _, m := range contextResult.Metrics {
+			unit := ""
+			if m.Rate {
+				unit = "/s"
+			}
 			explanation += fmt.Sprintf("### %s\n", m.Name)
-			explanation += fmt.Sprintf("- Current: %.2f\n", m.Latest)
-			explanation += fmt.Sprintf("- Range: %.2f - %.2f\n", m.Min, m.Max)
-			explanation += fmt.Sprintf("- Average: %.2f\n", m.Avg)
+			explanation += fmt.Sprintf("- Current: %.2f%s\n", m.Latest, unit)
+			explanation += fmt.Sprintf("- Range: %.2f%s - %.2f%s\n", m.Min, unit, m.Max, unit)
+			explanation += fmt.Sprintf("- Average: %.2f%s\n", m.Avg, unit)
+			if m.Resets > 0 {
+				explanation += fmt.Sprintf("- Counter resets: %d\n", m.Resets)
+			}
 			explanation += fmt.Sprintf("- Trend: %s\n", m.Trend)
 			if len(m.Anomalies) > 0 {
 				explanation += fmt.Sprintf("- Anomalies: %v\n", m.Anomalies)
@@ -722,7 +1229,7 @@ func (s *Server) handleAISuggest(ctx context.Context, params map[string]interfac
 		TimeRange:      timeRange,
 		IncludeMetrics: true,
 		IncludeTasks:   true,
-		IncludeLogs:    false,
+		IncludeLogs:    true,
 	}
 
 	contextResult, err := s.ragSvc.BuildContext(ctx, contextReq)
@@ -918,8 +1425,7 @@ func (s *Server) handleWorkflowCancel(ctx context.Context, params map[string]int
 // handleWorkflowHistory gets workflow execution history.
 func (s *Server) handleWorkflowHistory(ctx context.Context, params map[string]interface{}) (interface{}, error) {
 	workflowName, _ := params["workflow_name"].(string)
-	limitF, _ := params["limit"].(float64)
-	limit := int(limitF)
+	limit, _ := intParam(params, "limit")
 	if limit <= 0 {
 		limit = 10
 	}
@@ -996,7 +1502,7 @@ func (s *Server) handleAlertRuleList(ctx context.Context) (interface{}, error) {
 
 	result := make([]interface{}, len(rules))
 	for i, r := range rules {
-		result[i] = map[string]interface{}{
+		entry := map[string]interface{}{
 			"id":          r.ID.String(),
 			"name":        r.Name,
 			"metric_name": r.MetricName,
@@ -1008,7 +1514,18 @@ func (s *Server) handleAlertRuleList(ctx context.Context) (interface{}, error) {
 			"enabled":     r.Enabled,
 			"channels":    r.Channels,
 			"labels":      r.Labels,
+
+			"repeat_interval":   r.RepeatEvery().String(),
+			"notify_on_resolve": r.NotifyOnResolve,
 		}
+		if r.Expression != "" {
+			entry["expression"] = r.Expression
+		}
+		if len(r.GroupBy) > 0 {
+			entry["group_by"] = r.GroupBy
+			entry["group_wait"] = r.GroupWait.String()
+		}
+		result[i] = entry
 	}
 	return map[string]interface{}{"rules": result}, nil
 }
@@ -1022,18 +1539,27 @@ func (s *Server) handleAlertRuleCreate(ctx context.Context, params map[string]in
 	name, _ := params["name"].(string)
 	metricName, _ := params["metric_name"].(string)
 	conditionStr, _ := params["condition"].(string)
-	threshold, _ := params["threshold"].(float64)
+	threshold, _ := floatParam(params, "threshold")
 	severityStr, _ := params["severity"].(string)
 	durationStr, _ := params["duration"].(string)
 	intervalStr, _ := params["interval"].(string)
+	expression, _ := params["expression"].(string)
 
-	if name == "" || metricName == "" {
-		return nil, fmt.Errorf("name and metric_name are required")
+	if expression != "" && conditionStr == "" {
+		conditionStr = string(domain.ConditionExpression)
+	}
+	if name == "" || metricName == "" && conditionStr != string(domain.ConditionExpression) {
+		return nil, fmt.Errorf("name and metric_name (or expression) are required")
 	}
 
-	duration, _ := time.ParseDuration(durationStr)
-	if duration == 0 {
-		duration = time.Minute
+	// An explicit zero duration fires on the first breach
+	duration := time.Minute
+	if durationStr != "" {
+		d, err := time.ParseDuration(durationStr)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid duration %q", durationStr)
+		}
+		duration = d
 	}
 
 	interval, _ := time.ParseDuration(intervalStr)
@@ -1054,6 +1580,53 @@ func (s *Server) handleAlertRuleCreate(ctx context.Context, params map[string]in
 	rule := domain.NewAlertRule(name, metricName, condition, threshold, severity)
 	rule.Duration = duration
 	rule.Interval = interval
+	rule.Expression = expression
+
+	if labels, ok := params["labels"].(map[string]interface{}); ok {
+		for k, v := range labels {
+			rule.Labels[k] = fmt.Sprintf("%v", v)
+		}
+	}
+	if groupBy, ok := params["group_by"].([]interface{}); ok {
+		for _, g := range groupBy {
+			label, ok := g.(string)
+			if !ok || label == "" {
+				return nil, fmt.Errorf("group_by must be label names")
+			}
+			rule.GroupBy = append(rule.GroupBy, label)
+		}
+	}
+	if waitStr, _ := params["group_wait"].(string); waitStr != "" {
+		wait, err := time.ParseDuration(waitStr)
+		if err != nil || wait <= 0 {
+			return nil, fmt.Errorf("invalid group_wait %q", waitStr)
+		}
+		rule.GroupWait = wait
+	}
+	if resolveStr, _ := params["resolve_after"].(string); resolveStr != "" {
+		resolveAfter, err := time.ParseDuration(resolveStr)
+		if err != nil || resolveAfter <= 0 {
+			return nil, fmt.Errorf("invalid resolve_after %q", resolveStr)
+		}
+		rule.ResolveAfter = resolveAfter
+	}
+	if repeatStr, _ := params["repeat_interval"].(string); repeatStr != "" {
+		repeat, err := time.ParseDuration(repeatStr)
+		if err != nil || repeat <= 0 {
+			return nil, fmt.Errorf("invalid repeat_interval %q", repeatStr)
+		}
+		rule.RepeatInterval = repeat
+	}
+	if notify, ok := params["notify_on_resolve"].(bool); ok {
+		rule.NotifyOnResolve = notify
+	}
+	if agg, _ := params["for_aggregation"].(string); agg != "" {
+		rule.ForAggregation = domain.WindowAggregation(agg)
+	}
+	rule.ForPercent, _ = floatParam(params, "for_percent")
+	if err := rule.ValidateWindow(); err != nil {
+		return nil, err
+	}
 
 	err := s.alertSvc.CreateRule(ctx, rule)
 	if err != nil {
@@ -1115,7 +1688,7 @@ func (s *Server) handleAlertHistory(ctx context.Context, params map[string]inter
 		return map[string]interface{}{"alerts": []interface{}{}}, nil
 	}
 
-	limit, _ := params["limit"].(float64)
+	limit, _ := intParam(params, "limit")
 	if limit == 0 {
 		limit = 50
 	}
@@ -1123,7 +1696,7 @@ func (s *Server) handleAlertHistory(ctx context.Context, params map[string]inter
 	severityStr, _ := params["severity"].(string)
 
 	filter := ports.AlertFilter{
-		Limit: int(limit),
+		Limit: limit,
 	}
 	if stateStr != "" {
 		filter.State = (*domain.AlertState)(&stateStr)
@@ -1161,7 +1734,7 @@ func (s *Server) handleAlertAck(ctx context.Context, params map[string]interface
 		return nil, fmt.Errorf("invalid id: %w", err)
 	}
 
-	err = s.alertSvc.AcknowledgeAlert(ctx, id, "daemon-user", comment)
+	err = s.alertSvc.AcknowledgeAlert(ctx, id, callerName(ctx), comment)
 	if err != nil {
 		return nil, err
 	}
@@ -1178,6 +1751,8 @@ func (s *Server) handleAlertSilenceCreate(ctx context.Context, params map[string
 	matchersRaw, _ := params["matchers"].(map[string]interface{})
 	durationStr, _ := params["duration"].(string)
 	comment, _ := params["comment"].(string)
+	schedule, _ := params["schedule"].(string)
+	timezone, _ := params["timezone"].(string)
 
 	matchers := make(map[string]string)
 	for k, v := range matchersRaw {
@@ -1196,9 +1771,17 @@ func (s *Server) handleAlertSilenceCreate(ctx context.Context, params map[string
 		StartsAt:  now,
 		EndsAt:    now.Add(duration),
 		Comment:   comment,
-		CreatedBy: "daemon-user",
+		CreatedBy: callerName(ctx),
 		CreatedAt: now,
 	}
+	if schedule != "" {
+		// A recurring silence mutes for duration each time the schedule
+		// fires and stays in effect until deleted
+		silence.Schedule = schedule
+		silence.Duration = duration
+		silence.Timezone = timezone
+		silence.EndsAt = time.Time{}
+	}
 
 	err := s.alertSvc.CreateSilence(ctx, silence)
 	if err != nil {
@@ -1208,10 +1791,18 @@ func (s *Server) handleAlertSilenceCreate(ctx context.Context, params map[string
 	return map[string]interface{}{
 		"id":        silence.ID.String(),
 		"starts_at": silence.StartsAt.Format(time.RFC3339),
-		"ends_at":   silence.EndsAt.Format(time.RFC3339),
+		"ends_at":   formatSilenceEnd(silence.EndsAt),
 	}, nil
 }
 
+// formatSilenceEnd formats a silence end time; open-ended silences have none.
+func formatSilenceEnd(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
 // handleAlertSilenceList lists active silences.
 func (s *Server) handleAlertSilenceList(ctx context.Context) (interface{}, error) {
 	if s.alertSvc == nil {
@@ -1229,14 +1820,209 @@ func (s *Server) handleAlertSilenceList(ctx context.Context) (interface{}, error
 			"id":         sil.ID.String(),
 			"matchers":   sil.Matchers,
 			"starts_at":  sil.StartsAt.Format(time.RFC3339),
-			"ends_at":    sil.EndsAt.Format(time.RFC3339),
+			"ends_at":    formatSilenceEnd(sil.EndsAt),
 			"comment":    sil.Comment,
 			"created_by": sil.CreatedBy,
 		}
+		if sil.IsRecurring() {
+			entry := result[i].(map[string]interface{})
+			entry["schedule"] = sil.Schedule
+			entry["duration"] = sil.Duration.String()
+			entry["timezone"] = sil.Timezone
+		}
 	}
 	return map[string]interface{}{"silences": result}, nil
 }
 
+// silenceIDParam parses the required silence id param.
+func silenceIDParam(params map[string]interface{}) (uuid.UUID, error) {
+	idStr, _ := params["id"].(string)
+	if idStr == "" {
+		return uuid.Nil, fmt.Errorf("id is required")
+	}
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid id: %w", err)
+	}
+	return id, nil
+}
+
+// handleAlertSilenceDelete removes a silence.
+func (s *Server) handleAlertSilenceDelete(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if s.alertSvc == nil {
+		return nil, fmt.Errorf("alert service not available")
+	}
+	id, err := silenceIDParam(params)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.alertSvc.DeleteSilence(ctx, id); err != nil {
+		return nil, err
+	}
+	return map[string]string{"status": "deleted"}, nil
+}
+
+// handleAlertSilenceExpire ends a silence now.
+func (s *Server) handleAlertSilenceExpire(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if s.alertSvc == nil {
+		return nil, fmt.Errorf("alert service not available")
+	}
+	id, err := silenceIDParam(params)
+	if err != nil {
+		return nil, err
+	}
+	silence, err := s.alertSvc.ExpireSilence(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"id":      silence.ID.String(),
+		"ends_at": formatSilenceEnd(silence.EndsAt),
+		"status":  "expired",
+	}, nil
+}
+
+// handleAlertMaintenanceCreate creates a recurring maintenance window.
+func (s *Server) handleAlertMaintenanceCreate(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if s.alertSvc == nil {
+		return nil, fmt.Errorf("alert service not available")
+	}
+
+	name, _ := params["name"].(string)
+	startTime, _ := params["start_time"].(string)
+	timezone, _ := params["timezone"].(string)
+	window := domain.NewMaintenanceWindow(name, nil, nil, startTime, 0, timezone)
+	window.CreatedBy = callerName(ctx)
+	if err := applyMaintenanceParams(window, params); err != nil {
+		return nil, err
+	}
+
+	if err := s.alertSvc.CreateMaintenanceWindow(ctx, window); err != nil {
+		return nil, err
+	}
+	return maintenanceWindowToMap(window, time.Now()), nil
+}
+
+// applyMaintenanceParams sets the optional maintenance window fields present
+// in params.
+func applyMaintenanceParams(window *domain.MaintenanceWindow, params map[string]interface{}) error {
+	if raw, ok := params["matchers"].(map[string]interface{}); ok {
+		window.Matchers = make(map[string]string, len(raw))
+		for k, v := range raw {
+			window.Matchers[k] = fmt.Sprintf("%v", v)
+		}
+	}
+	if daysStr, ok := params["days"].(string); ok {
+		days, err := domain.ParseWeekdays(daysStr)
+		if err != nil {
+			return err
+		}
+		window.Days = days
+	}
+	if durationStr, ok := params["duration"].(string); ok && durationStr != "" {
+		duration, err := time.ParseDuration(durationStr)
+		if err != nil {
+			return fmt.Errorf("invalid duration: %w", err)
+		}
+		window.Duration = duration
+	}
+	if enabled, ok := params["enabled"].(bool); ok {
+		window.Enabled = enabled
+	}
+	if comment, ok := params["comment"].(string); ok {
+		window.Comment = comment
+	}
+	return nil
+}
+
+// maintenanceWindowToMap converts a maintenance window to a map for JSON
+// serialization, noting whether it is open at now.
+func maintenanceWindowToMap(w *domain.MaintenanceWindow, now time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"id":         w.ID.String(),
+		"name":       w.Name,
+		"matchers":   w.Matchers,
+		"days":       w.DaysString(),
+		"start_time": w.StartTime,
+		"duration":   w.Duration.String(),
+		"timezone":   w.Timezone,
+		"enabled":    w.Enabled,
+		"active":     w.ActiveAt(now),
+		"comment":    w.Comment,
+		"created_by": w.CreatedBy,
+	}
+}
+
+// handleAlertMaintenanceList lists maintenance windows.
+func (s *Server) handleAlertMaintenanceList(ctx context.Context) (interface{}, error) {
+	if s.alertSvc == nil {
+		return map[string]interface{}{"windows": []interface{}{}}, nil
+	}
+
+	windows, err := s.alertSvc.ListMaintenanceWindows(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result := make([]interface{}, len(windows))
+	for i, w := range windows {
+		result[i] = maintenanceWindowToMap(w, now)
+	}
+	return map[string]interface{}{"windows": result}, nil
+}
+
+// handleAlertMaintenanceUpdate updates a maintenance window.
+func (s *Server) handleAlertMaintenanceUpdate(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	window, err := s.lookupMaintenanceWindow(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if name, ok := params["name"].(string); ok && name != "" {
+		window.Name = name
+	}
+	if startTime, ok := params["start_time"].(string); ok && startTime != "" {
+		window.StartTime = startTime
+	}
+	if timezone, ok := params["timezone"].(string); ok {
+		window.Timezone = timezone
+	}
+	if err := applyMaintenanceParams(window, params); err != nil {
+		return nil, err
+	}
+	window.UpdatedAt = time.Now()
+
+	if err := s.alertSvc.UpdateMaintenanceWindow(ctx, window); err != nil {
+		return nil, err
+	}
+	return maintenanceWindowToMap(window, time.Now()), nil
+}
+
+// handleAlertMaintenanceDelete deletes a maintenance window.
+func (s *Server) handleAlertMaintenanceDelete(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	window, err := s.lookupMaintenanceWindow(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.alertSvc.DeleteMaintenanceWindow(ctx, window.ID); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"deleted": true}, nil
+}
+
+// lookupMaintenanceWindow resolves the "id" param, a window ID or name.
+func (s *Server) lookupMaintenanceWindow(ctx context.Context, params map[string]interface{}) (*domain.MaintenanceWindow, error) {
+	if s.alertSvc == nil {
+		return nil, fmt.Errorf("alert service not available")
+	}
+	ref, _ := params["id"].(string)
+	if ref == "" {
+		return nil, fmt.Errorf("maintenance window id or name is required")
+	}
+	return s.alertSvc.GetMaintenanceWindow(ctx, ref)
+}
+
 // handleAlertChannelList lists notification channels.
 func (s *Server) handleAlertChannelList(ctx context.Context) (interface{}, error) {
 	if s.alertSvc == nil {
@@ -1250,16 +2036,133 @@ func (s *Server) handleAlertChannelList(ctx context.Context) (interface{}, error
 
 	result := make([]interface{}, len(channels))
 	for i, ch := range channels {
-		result[i] = map[string]interface{}{
-			"id":      ch.ID.String(),
-			"name":    ch.Name,
-			"type":    string(ch.Type),
-			"enabled": ch.Enabled,
-		}
+		result[i] = channelToMap(ch)
 	}
 	return map[string]interface{}{"channels": result}, nil
 }
 
+// channelToMap converts a notification channel to a map for JSON
+// serialization, with secrets in its config redacted.
+func channelToMap(ch *domain.NotificationChannel) map[string]interface{} {
+	return map[string]interface{}{
+		"id":      ch.ID.String(),
+		"name":    ch.Name,
+		"type":    string(ch.Type),
+		"enabled": ch.Enabled,
+		"config":  ch.RedactedConfig(),
+	}
+}
+
+// handleAlertChannelCreate creates a notification channel.
+func (s *Server) handleAlertChannelCreate(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if s.alertSvc == nil {
+		return nil, fmt.Errorf("alert service not available")
+	}
+
+	name, _ := params["name"].(string)
+	channelType, _ := params["type"].(string)
+	config := make(map[string]string)
+	if raw, ok := params["config"].(map[string]interface{}); ok {
+		for k, v := range raw {
+			config[k] = fmt.Sprintf("%v", v)
+		}
+	}
+
+	channel := domain.NewNotificationChannel(name, domain.NotificationChannelType(channelType), config)
+	if enabled, ok := params["enabled"].(bool); ok {
+		channel.Enabled = enabled
+	}
+	if err := s.alertSvc.CreateChannel(ctx, channel); err != nil {
+		return nil, err
+	}
+	return channelToMap(channel), nil
+}
+
+// handleAlertChannelUpdate updates a notification channel. Config entries
+// are merged into the existing config; an empty value removes the key.
+func (s *Server) handleAlertChannelUpdate(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	channel, err := s.lookupChannel(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if name, ok := params["name"].(string); ok && name != "" {
+		channel.Name = name
+	}
+	if channelType, ok := params["type"].(string); ok && channelType != "" {
+		channel.Type = domain.NotificationChannelType(channelType)
+	}
+	if enabled, ok := params["enabled"].(bool); ok {
+		channel.Enabled = enabled
+	}
+	if raw, ok := params["config"].(map[string]interface{}); ok {
+		if channel.Config == nil {
+			channel.Config = make(map[string]string)
+		}
+		for k, v := range raw {
+			value := fmt.Sprintf("%v", v)
+			if value == "" {
+				delete(channel.Config, k)
+			} else {
+				channel.Config[k] = value
+			}
+		}
+	}
+
+	if err := s.alertSvc.UpdateChannel(ctx, channel); err != nil {
+		return nil, err
+	}
+	return channelToMap(channel), nil
+}
+
+// handleAlertChannelDelete deletes a notification channel.
+func (s *Server) handleAlertChannelDelete(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	channel, err := s.lookupChannel(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.alertSvc.DeleteChannel(ctx, channel.ID); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"deleted": true}, nil
+}
+
+// handleAlertChannelTest sends a synthetic alert through a channel. A
+// failed delivery is reported in the result rather than as an error.
+func (s *Server) handleAlertChannelTest(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	channel, err := s.lookupChannel(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]interface{}{
+		"id":        channel.ID.String(),
+		"name":      channel.Name,
+		"delivered": true,
+	}
+	if err := s.alertSvc.TestChannel(ctx, channel); err != nil {
+		result["delivered"] = false
+		result["error"] = err.Error()
+	}
+	return result, nil
+}
+
+// lookupChannel resolves the "id" param, a channel ID or name.
+func (s *Server) lookupChannel(ctx context.Context, params map[string]interface{}) (*domain.NotificationChannel, error) {
+	if s.alertSvc == nil {
+		return nil, fmt.Errorf("alert service not available")
+	}
+	ref, _ := params["id"].(string)
+	if ref == "" {
+		return nil, fmt.Errorf("channel id or name is required")
+	}
+	channel, err := s.alertSvc.GetChannel(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	return channel, nil
+}
+
 // alertToMap converts an alert to a map for JSON serialization.
 func (s *Server) alertToMap(a *domain.Alert) map[string]interface{} {
 	result := map[string]interface{}{
@@ -1282,9 +2185,106 @@ func (s *Server) alertToMap(a *domain.Alert) map[string]interface{} {
 		result["acknowledged_at"] = a.AcknowledgedAt.Format(time.RFC3339)
 		result["acknowledged_by"] = a.AcknowledgedBy
 	}
+	if a.SilencedBy != "" {
+		result["silenced_by"] = a.SilencedBy
+	}
 	return result
 }
 
+// ============================================================================
+// Task Handlers
+// ============================================================================
+
+// handleTaskList lists queued tasks filtered by status and type, highest
+// priority first. The result is a plain array of tasks.
+func (s *Server) handleTaskList(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	filter := ports.TaskFilter{}
+	if statusStr, ok := params["status"].(string); ok && statusStr != "" {
+		status, err := domain.ParseTaskStatus(statusStr)
+		if err != nil {
+			return nil, err
+		}
+		filter.Status = &status
+	}
+	if typeStr, ok := params["type"].(string); ok && typeStr != "" {
+		taskType := domain.TaskType(typeStr)
+		filter.Type = &taskType
+	}
+	if limit, ok := intParam(params, "limit"); ok {
+		filter.Limit = limit
+	}
+	if offset, ok := intParam(params, "offset"); ok {
+		filter.Offset = offset
+	}
+
+	tasks, err := s.taskSvc.ListTasks(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]map[string]interface{}, len(tasks))
+	for i, t := range tasks {
+		result[i] = taskToMap(t)
+	}
+	return result, nil
+}
+
+// handleTaskCreate enqueues a task of the given type with an optional
+// payload object and priority.
+func (s *Server) handleTaskCreate(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	taskType, _ := params["type"].(string)
+	if taskType == "" {
+		return nil, fmt.Errorf("task type is required")
+	}
+
+	var payload map[string]interface{}
+	switch p := params["payload"].(type) {
+	case nil:
+	case map[string]interface{}:
+		payload = p
+	default:
+		return nil, fmt.Errorf("payload must be an object")
+	}
+
+	priority := 0
+	if p, ok := intParam(params, "priority"); ok {
+		priority = p
+	}
+
+	task, err := s.taskSvc.CreateTaskWithPriority(ctx, domain.TaskType(taskType), payload, priority)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"id":       task.ID.String(),
+		"status":   "created",
+		"type":     string(task.Type),
+		"priority": task.Priority,
+	}, nil
+}
+
+// taskToMap serializes a task for RPC responses.
+func taskToMap(t *domain.Task) map[string]interface{} {
+	m := map[string]interface{}{
+		"id":          t.ID.String(),
+		"type":        string(t.Type),
+		"status":      string(t.Status),
+		"payload":     t.Payload,
+		"priority":    t.Priority,
+		"retry_count": t.RetryCount,
+		"max_retries": t.MaxRetries,
+		"run_at":      t.RunAt.Format(time.RFC3339),
+		"created_at":  t.CreatedAt.Format(time.RFC3339),
+		"updated_at":  t.UpdatedAt.Format(time.RFC3339),
+		"error":       t.Error,
+	}
+	if t.CompletedAt != nil {
+		m["completed_at"] = t.CompletedAt.Format(time.RFC3339)
+	}
+	return m
+}
+
 // ============================================================================
 // Trace Handlers
 // ============================================================================
@@ -1310,8 +2310,25 @@ func (s *Server) handleTraceList(ctx context.Context, params map[string]interfac
 			filter.StartTime = t
 		}
 	}
-	if limit, ok := params["limit"].(float64); ok && limit > 0 {
-		filter.Limit = int(limit)
+	if limit, ok := intParam(params, "limit"); ok && limit > 0 {
+		filter.Limit = limit
+	}
+
+	var err error
+	if filter.MinDuration, filter.MaxDuration, err = durationRangeParams(params); err != nil {
+		return nil, err
+	}
+	if filter.Attributes, err = attributeParams(params); err != nil {
+		return nil, err
+	}
+	if keys, ok := params["has_attributes"].([]interface{}); ok {
+		for _, k := range keys {
+			key, ok := k.(string)
+			if !ok || key == "" {
+				return nil, fmt.Errorf("has_attributes must be a list of attribute keys")
+			}
+			filter.HasAttributes = append(filter.HasAttributes, key)
+		}
 	}
 
 	traces, err := s.traceSvc.ListTraces(ctx, filter)
@@ -1378,10 +2395,168 @@ func (s *Server) handleTraceSpans(ctx context.Context, params map[string]interfa
 	return map[string]interface{}{"spans": result}, nil
 }
 
+// Defaults and maximum for trace.slowest.
+const (
+	defaultSlowestTraces      = 10
+	maxSlowestTraces          = 1000
+	defaultSlowestTraceWindow = time.Hour
+)
+
+// handleTraceSlowest returns the slowest traces of a service or operation,
+// longest first. The window is either start_time/end_time (RFC3339) or a
+// since duration, and defaults to the last hour.
+func (s *Server) handleTraceSlowest(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if s.traceSvc == nil {
+		return map[string]interface{}{"traces": []interface{}{}}, nil
+	}
+
+	serviceName, _ := params["service_name"].(string)
+	name, _ := params["name"].(string)
+
+	n := defaultSlowestTraces
+	if limit, ok := intParam(params, "limit"); ok && limit > 0 {
+		n = min(limit, maxSlowestTraces)
+	}
+
+	startTime, err := parseTimeParam(params, "start_time")
+	if err != nil {
+		return nil, err
+	}
+	endTime, err := parseTimeParam(params, "end_time")
+	if err != nil {
+		return nil, err
+	}
+	if since, _ := params["since"].(string); since != "" {
+		if !startTime.IsZero() {
+			return nil, fmt.Errorf("since and start_time cannot be combined")
+		}
+		d, err := time.ParseDuration(since)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid since: %q", since)
+		}
+		startTime = time.Now().Add(-d)
+	}
+	if startTime.IsZero() {
+		startTime = time.Now().Add(-defaultSlowestTraceWindow)
+	}
+	if !endTime.IsZero() && endTime.Before(startTime) {
+		return nil, fmt.Errorf("end_time must not be before start_time")
+	}
+
+	traces, err := s.traceSvc.SlowestTraces(ctx, serviceName, name, startTime, endTime, n)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]interface{}, len(traces))
+	for i, t := range traces {
+		result[i] = s.traceToMap(t)
+	}
+	return map[string]interface{}{"traces": result}, nil
+}
+
+// Default and maximum number of spans returned by span.search.
+const (
+	defaultSpanSearchLimit = 50
+	maxSpanSearchLimit     = 1000
+)
+
+// durationRangeParams reads the optional min_duration and max_duration
+// params, given as Go durations.
+func durationRangeParams(params map[string]interface{}) (minDuration, maxDuration time.Duration, err error) {
+	for key, dst := range map[string]*time.Duration{"min_duration": &minDuration, "max_duration": &maxDuration} {
+		str, _ := params[key].(string)
+		if str == "" {
+			continue
+		}
+		d, err := time.ParseDuration(str)
+		if err != nil || d < 0 {
+			return 0, 0, fmt.Errorf("invalid %s %q", key, str)
+		}
+		*dst = d
+	}
+	if maxDuration > 0 && minDuration > maxDuration {
+		return 0, 0, fmt.Errorf("min_duration must not exceed max_duration")
+	}
+	return minDuration, maxDuration, nil
+}
+
+// attributeParams reads the optional attributes param, an object of
+// attribute values to match exactly.
+func attributeParams(params map[string]interface{}) (map[string]string, error) {
+	attrs, ok := params["attributes"].(map[string]interface{})
+	if !ok || len(attrs) == 0 {
+		return nil, nil
+	}
+	matched := make(map[string]string, len(attrs))
+	for k, v := range attrs {
+		str, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("attribute %s must be a string", k)
+		}
+		matched[k] = str
+	}
+	return matched, nil
+}
+
+// handleSpanSearch finds spans across all traces by service, name, kind,
+// status, duration range and attributes.
+func (s *Server) handleSpanSearch(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if s.traceSvc == nil {
+		return map[string]interface{}{"spans": []interface{}{}}, nil
+	}
+
+	filter := ports.SpanFilter{Limit: defaultSpanSearchLimit}
+	filter.ServiceName, _ = params["service_name"].(string)
+	filter.Name, _ = params["name"].(string)
+	if kind, _ := params["kind"].(string); kind != "" {
+		filter.Kind = domain.SpanKind(kind)
+	}
+	if status, _ := params["status"].(string); status != "" {
+		filter.Status = domain.SpanStatus(status)
+	}
+
+	var err error
+	if filter.MinDuration, filter.MaxDuration, err = durationRangeParams(params); err != nil {
+		return nil, err
+	}
+
+	for key, dst := range map[string]*time.Time{"start_time": &filter.StartTime, "end_time": &filter.EndTime} {
+		str, _ := params[key].(string)
+		if str == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, str)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", key, err)
+		}
+		*dst = t
+	}
+
+	if filter.Attributes, err = attributeParams(params); err != nil {
+		return nil, err
+	}
+
+	if limit, ok := intParam(params, "limit"); ok && limit > 0 {
+		filter.Limit = min(limit, maxSpanSearchLimit)
+	}
+
+	spans, err := s.traceSvc.ListSpans(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]interface{}, len(spans))
+	for i, sp := range spans {
+		result[i] = s.spanToMap(sp)
+	}
+	return map[string]interface{}{"spans": result}, nil
+}
+
 // handleTraceServiceMap gets the service dependency map.
 func (s *Server) handleTraceServiceMap(ctx context.Context, params map[string]interface{}) (interface{}, error) {
 	if s.traceSvc == nil {
-		return map[string]interface{}{"nodes": []interface{}{}}, nil
+		return map[string]interface{}{"nodes": []interface{}{}, "edges": []interface{}{}}, nil
 	}
 
 	startTime := time.Now().Add(-24 * time.Hour)
@@ -1410,25 +2585,126 @@ func (s *Server) handleTraceServiceMap(ctx context.Context, params map[string]in
 			"span_count":      n.SpanCount,
 			"error_count":     n.ErrorCount,
 			"avg_duration_ms": n.AvgDuration,
+			"p50_duration_ms": n.P50Duration,
+			"p95_duration_ms": n.P95Duration,
+			"p99_duration_ms": n.P99Duration,
 			"dependencies":    n.Dependencies,
+			"external":        n.External,
+		}
+	}
+	edges := make([]interface{}, len(serviceMap.Edges))
+	for i, e := range serviceMap.Edges {
+		edges[i] = map[string]interface{}{
+			"source":          e.Source,
+			"target":          e.Target,
+			"call_count":      e.CallCount,
+			"error_count":     e.ErrorCount,
+			"error_rate":      e.ErrorRate,
+			"p50_duration_ms": e.P50Duration,
+			"p95_duration_ms": e.P95Duration,
+			"p99_duration_ms": e.P99Duration,
 		}
 	}
-	return map[string]interface{}{"nodes": nodes}, nil
+	return map[string]interface{}{"nodes": nodes, "edges": edges}, nil
 }
 
 // handleTraceStats gets trace statistics.
-func (s *Server) handleTraceStats(ctx context.Context) (interface{}, error) {
+func (s *Server) handleTraceStats(ctx context.Context, params map[string]interface{}) (interface{}, error) {
 	if s.traceSvc == nil {
 		return map[string]interface{}{"active_traces": 0}, nil
 	}
 
-	stats, err := s.traceSvc.GetTraceStats(ctx)
+	// Stored and estimated counts cover start_time to end_time, by
+	// default the last hour
+	startTime, err := parseTimeParam(params, "start_time")
+	if err != nil {
+		return nil, err
+	}
+	endTime, err := parseTimeParam(params, "end_time")
+	if err != nil {
+		return nil, err
+	}
+	if endTime.IsZero() {
+		endTime = time.Now()
+	}
+	if startTime.IsZero() {
+		startTime = endTime.Add(-time.Hour)
+	}
+
+	stats, err := s.traceSvc.GetTraceStats(ctx, startTime, endTime)
 	if err != nil {
 		return nil, err
 	}
 	return stats, nil
 }
 
+// handleTraceConfigUpdate changes trace sampling and retention without a
+// restart. Only the params given change; durations are Go duration strings.
+// It returns the resulting configuration, so an empty request reads it. The
+// change is not written back to the config file.
+func (s *Server) handleTraceConfigUpdate(params map[string]interface{}) (interface{}, error) {
+	if s.traceSvc == nil {
+		return nil, fmt.Errorf("trace service not available")
+	}
+
+	cfg := s.traceSvc.Config()
+	if _, ok := params["sample_rate"]; ok {
+		rate, ok := floatParam(params, "sample_rate")
+		if !ok {
+			return nil, fmt.Errorf("sample_rate must be a number")
+		}
+		cfg.SampleRate = rate
+	}
+	if v, ok := params["keep_errors"]; ok {
+		keep, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("keep_errors must be a boolean")
+		}
+		cfg.KeepErrors = keep
+	}
+	durations := []struct {
+		key    string
+		target *time.Duration
+	}{
+		{"latency_threshold", &cfg.LatencyThreshold},
+		{"decision_wait", &cfg.DecisionWait},
+		{"retention", &cfg.Retention},
+		{"retention_interval", &cfg.RetentionInterval},
+	}
+	for _, d := range durations {
+		str, ok := params[d.key].(string)
+		if !ok {
+			if _, set := params[d.key]; set {
+				return nil, fmt.Errorf("%s must be a duration string", d.key)
+			}
+			continue
+		}
+		value, err := time.ParseDuration(str)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q", d.key, str)
+		}
+		*d.target = value
+	}
+
+	if err := s.traceSvc.SetConfig(cfg); err != nil {
+		return nil, err
+	}
+	s.logger.Info("Trace config updated", "sample_rate", cfg.SampleRate, "retention", cfg.Retention)
+	return traceConfigToMap(cfg), nil
+}
+
+// traceConfigToMap converts a trace configuration for JSON serialization.
+func traceConfigToMap(cfg services.TraceConfig) map[string]interface{} {
+	return map[string]interface{}{
+		"sample_rate":        cfg.SampleRate,
+		"keep_errors":        cfg.KeepErrors,
+		"latency_threshold":  cfg.LatencyThreshold.String(),
+		"decision_wait":      cfg.DecisionWait.String(),
+		"retention":          cfg.Retention.String(),
+		"retention_interval": cfg.RetentionInterval.String(),
+	}
+}
+
 // traceToMap converts a trace to a map for JSON serialization.
 func (s *Server) traceToMap(t *domain.Trace) map[string]interface{} {
 	return map[string]interface{}{
@@ -1447,7 +2723,7 @@ func (s *Server) traceToMap(t *domain.Trace) map[string]interface{} {
 
 // spanToMap converts a span to a map for JSON serialization.
 func (s *Server) spanToMap(sp *domain.Span) map[string]interface{} {
-	return map[string]interface{}{
+	m := map[string]interface{}{
 		"id":           sp.ID.String(),
 		"trace_id":     sp.TraceID.String(),
 		"span_id":      sp.SpanID.String(),
@@ -1456,10 +2732,14 @@ func (s *Server) spanToMap(sp *domain.Span) map[string]interface{} {
 		"status":       string(sp.Status),
 		"duration":     sp.Duration.String(),
 		"service_name": sp.ServiceName,
-		"start_time":   sp.StartTime.Format(time.RFC3339),
-		"end_time":     sp.EndTime.Format(time.RFC3339),
+		"start_time":   sp.StartTime.Format(time.RFC3339Nano),
+		"end_time":     sp.EndTime.Format(time.RFC3339Nano),
 		"attributes":   sp.Attributes,
 	}
+	if sp.ParentSpanID != nil {
+		m["parent_span_id"] = sp.ParentSpanID.String()
+	}
+	return m
 }
 
 // ============================================================================
@@ -1472,29 +2752,29 @@ func (s *Server) handleLogList(ctx context.Context, params map[string]interface{
 		return map[string]interface{}{"logs": []interface{}{}}, nil
 	}
 
-	filter := ports.LogFilter{
-		Limit: 50,
+	filter, err := logFilterFromParams(params)
+	if err != nil {
+		return nil, err
 	}
+	filter.Limit = 50
 
-	if level, ok := params["level"].(string); ok && level != "" {
-		filter.Level = domain.LogLevel(level)
+	if filter.StartTime, err = parseTimeParam(params, "start_time"); err != nil {
+		return nil, err
 	}
-	if service, ok := params["service_name"].(string); ok && service != "" {
-		filter.ServiceName = service
+	if filter.EndTime, err = parseTimeParam(params, "end_time"); err != nil {
+		return nil, err
 	}
-	if source, ok := params["source"].(string); ok && source != "" {
-		filter.Source = source
+	if !filter.StartTime.IsZero() && !filter.EndTime.IsZero() && filter.EndTime.Before(filter.StartTime) {
+		return nil, fmt.Errorf("end_time must not be before start_time")
 	}
-	if traceID, ok := params["trace_id"].(string); ok && traceID != "" {
-		filter.TraceID = traceID
+	if limit, ok := intParam(params, "limit"); ok && limit > 0 {
+		filter.Limit = limit
 	}
-	if startTime, ok := params["start_time"].(string); ok && startTime != "" {
-		if t, err := time.Parse(time.RFC3339, startTime); err == nil {
-			filter.StartTime = t
+	if offset, ok := intParam(params, "offset"); ok {
+		if offset < 0 {
+			return nil, fmt.Errorf("offset must not be negative")
 		}
-	}
-	if limit, ok := params["limit"].(float64); ok && limit > 0 {
-		filter.Limit = int(limit)
+		filter.Offset = offset
 	}
 
 	logs, err := s.logSvc.Query(ctx, filter)
@@ -1509,6 +2789,66 @@ func (s *Server) handleLogList(ctx context.Context, params map[string]interface{
 	return map[string]interface{}{"logs": result}, nil
 }
 
+// parseTimeParam parses an optional RFC3339 time parameter.
+func parseTimeParam(params map[string]interface{}, key string) (time.Time, error) {
+	value, ok := params[key].(string)
+	if !ok || value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return t, nil
+}
+
+// int64Param returns an integer parameter. It accepts the json.Number values
+// of decoded requests as well as Go numbers from in-process callers; a
+// fractional value is truncated.
+func int64Param(params map[string]interface{}, key string) (int64, bool) {
+	switch v := params[key].(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n, true
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return 0, false
+		}
+		return int64(f), true
+	case float64:
+		return int64(v), true
+	case int:
+		return int64(v), true
+	case int64:
+		return v, true
+	}
+	return 0, false
+}
+
+// intParam is int64Param for parameters such as limits and priorities.
+func intParam(params map[string]interface{}, key string) (int, bool) {
+	n, ok := int64Param(params, key)
+	return int(n), ok
+}
+
+// floatParam returns a floating-point parameter, accepting the same types
+// as int64Param.
+func floatParam(params map[string]interface{}, key string) (float64, bool) {
+	switch v := params[key].(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
 // handleLogSearch searches log entries.
 func (s *Server) handleLogSearch(ctx context.Context, params map[string]interface{}) (interface{}, error) {
 	if s.logSvc == nil {
@@ -1528,8 +2868,8 @@ func (s *Server) handleLogSearch(ctx context.Context, params map[string]interfac
 			filter.StartTime = t
 		}
 	}
-	if limit, ok := params["limit"].(float64); ok && limit > 0 {
-		filter.Limit = int(limit)
+	if limit, ok := intParam(params, "limit"); ok && limit > 0 {
+		filter.Limit = limit
 	}
 
 	logs, err := s.logSvc.Search(ctx, query, filter)
@@ -1568,43 +2908,309 @@ func (s *Server) handleLogStats(ctx context.Context, params map[string]interface
 		}
 	}
 
-	stats, err := s.logSvc.GetStats(ctx, startTime, endTime)
-	if err != nil {
+	stats, err := s.logSvc.GetStats(ctx, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	// Logs are not sampled, so the stored count is the total. The fields
+	// match trace.stats so clients can read both the same way.
+	return map[string]interface{}{
+		"total_count":           stats.TotalCount,
+		"estimated_total_count": stats.TotalCount,
+		"sampling":              map[string]interface{}{"enabled": false, "sample_rate": 1.0, "effective_rate": 1.0},
+		"by_level":              stats.ByLevel,
+		"by_service":            stats.ByService,
+		"by_source":             stats.BySource,
+		"first_log_time":        stats.FirstLogTime.Format(time.RFC3339),
+		"last_log_time":         stats.LastLogTime.Format(time.RFC3339),
+	}, nil
+}
+
+// handleLogParserList lists log parsers.
+func (s *Server) handleLogParserList(ctx context.Context) (interface{}, error) {
+	if s.logSvc == nil {
+		return map[string]interface{}{"parsers": []interface{}{}}, nil
+	}
+
+	parsers, err := s.logSvc.ListParsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	matches, unparsed := s.logSvc.ParserStats()
+
+	result := make([]interface{}, len(parsers))
+	for i, p := range parsers {
+		result[i] = map[string]interface{}{
+			"id":             p.ID.String(),
+			"name":           p.Name,
+			"type":           string(p.Type),
+			"pattern":        p.Pattern,
+			"priority":       p.Priority,
+			"enabled":        p.Enabled,
+			"source_filter":  p.SourceFilter,
+			"field_mappings": p.FieldMappings,
+			"matches":        matches[p.ID],
+		}
+	}
+	return map[string]interface{}{"parsers": result, "unparsed": unparsed}, nil
+}
+
+// maxLogIngestLines bounds the number of raw lines accepted per log.ingest call.
+const maxLogIngestLines = 10000
+
+// handleLogIngest ingests either a batch of raw lines, which are run through
+// the parser pipeline, or a single structured entry.
+func (s *Server) handleLogIngest(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if s.logSvc == nil {
+		return nil, fmt.Errorf("log service not available")
+	}
+	source, _ := params["source"].(string)
+
+	if rawLines, ok := params["lines"].([]interface{}); ok {
+		if len(rawLines) > maxLogIngestLines {
+			return nil, fmt.Errorf("too many lines: %d (max %d per call)", len(rawLines), maxLogIngestLines)
+		}
+		lines := make([]string, len(rawLines))
+		for i, l := range rawLines {
+			line, ok := l.(string)
+			if !ok {
+				return nil, fmt.Errorf("lines[%d] must be a string", i)
+			}
+			lines[i] = line
+		}
+		result, err := s.logSvc.IngestLines(ctx, source, lines)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"ingested": result.Ingested,
+			"parsed":   result.Parsed,
+			"unparsed": result.Unparsed,
+		}, nil
+	}
+
+	entryParams, ok := params["entry"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("either entry or lines is required")
+	}
+	entry, err := logEntryFromParams(entryParams, source)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.logSvc.IngestBatch(ctx, []*domain.LogEntry{entry}); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"ingested": 1, "id": entry.ID.String()}, nil
+}
+
+// logEntryFromParams builds a log entry from a log.ingest entry object.
+// The entry's own source takes precedence over the request's.
+func logEntryFromParams(params map[string]interface{}, source string) (*domain.LogEntry, error) {
+	message, _ := params["message"].(string)
+	if message == "" {
+		return nil, fmt.Errorf("entry message is required")
+	}
+	if entrySource, ok := params["source"].(string); ok && entrySource != "" {
+		source = entrySource
+	}
+	service, _ := params["service_name"].(string)
+
+	var level domain.LogLevel
+	if levelStr, ok := params["level"].(string); ok && levelStr != "" {
+		parsed, err := domain.ParseLogLevel(levelStr)
+		if err != nil {
+			return nil, err
+		}
+		level = parsed
+	}
+
+	entry := domain.NewLogEntry(level, message, source, service)
+	entry.Raw = message
+	timestamp, err := parseTimeParam(params, "timestamp")
+	if err != nil {
+		return nil, err
+	}
+	if !timestamp.IsZero() {
+		entry.Timestamp = timestamp
+	}
+	traceID, _ := params["trace_id"].(string)
+	spanID, _ := params["span_id"].(string)
+	entry.SetTraceContext(traceID, spanID)
+
+	if attrs, ok := params["attributes"].(map[string]interface{}); ok {
+		for k, v := range attrs {
+			str, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("attribute %q must be a string", k)
+			}
+			entry.SetAttribute(k, str)
+		}
+	}
+	return entry, nil
+}
+
+// handleLogParserCreate creates a log parser and reloads the pipeline.
+func (s *Server) handleLogParserCreate(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if s.logSvc == nil {
+		return nil, fmt.Errorf("log service not available")
+	}
+
+	name, _ := params["name"].(string)
+	parserType, _ := params["type"].(string)
+	pattern, _ := params["pattern"].(string)
+	parser := domain.NewLogParser(name, domain.LogParserType(parserType), pattern)
+	parser.Description, _ = params["description"].(string)
+	parser.SourceFilter, _ = params["source_filter"].(string)
+	if priority, ok := intParam(params, "priority"); ok {
+		parser.Priority = priority
+	}
+	if enabled, ok := params["enabled"].(bool); ok {
+		parser.Enabled = enabled
+	}
+	if mappings, ok := params["field_mappings"].(map[string]interface{}); ok {
+		for k, v := range mappings {
+			str, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("field mapping %q must be a string", k)
+			}
+			parser.FieldMappings[k] = str
+		}
+	}
+
+	if err := s.logSvc.CreateParser(ctx, parser); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"id": parser.ID.String(), "name": parser.Name}, nil
+}
+
+// handleLogParserDelete deletes a log parser and reloads the pipeline.
+func (s *Server) handleLogParserDelete(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if s.logSvc == nil {
+		return nil, fmt.Errorf("log service not available")
+	}
+
+	idStr, _ := params["id"].(string)
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id: %w", err)
+	}
+	if err := s.logSvc.DeleteParser(ctx, id); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"deleted": true}, nil
+}
+
+// handleLogMetricRuleCreate creates a log-to-metric rule.
+func (s *Server) handleLogMetricRuleCreate(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if s.logSvc == nil {
+		return nil, fmt.Errorf("log service not available")
+	}
+
+	name, _ := params["name"].(string)
+	matchField, _ := params["match_field"].(string)
+	if matchField == "" {
+		matchField = "message"
+	}
+	pattern, _ := params["match_pattern"].(string)
+	metricName, _ := params["metric_name"].(string)
+	metricType, _ := params["metric_type"].(string)
+	if metricType == "" {
+		metricType = string(domain.MetricTypeCounter)
+	}
+
+	rule := domain.NewLogToMetricRule(name, matchField, pattern, metricName, domain.MetricType(metricType))
+	rule.Description, _ = params["description"].(string)
+	rule.ServiceName, _ = params["service_name"].(string)
+	rule.Source, _ = params["source"].(string)
+	rule.ValueField, _ = params["value_field"].(string)
+	if minLevel, _ := params["min_level"].(string); minLevel != "" {
+		rule.MinLevel = domain.LogLevel(minLevel)
+	}
+	if values, ok := params["match_values"].([]interface{}); ok {
+		for _, v := range values {
+			str, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("match_values must be strings")
+			}
+			rule.MatchValues = append(rule.MatchValues, str)
+		}
+	}
+	if tags, ok := params["tags"].(map[string]interface{}); ok {
+		for k, v := range tags {
+			str, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("tag %q must be a string", k)
+			}
+			rule.Tags[k] = str
+		}
+	}
+	if fields, ok := params["tag_fields"].([]interface{}); ok {
+		for _, f := range fields {
+			str, ok := f.(string)
+			if !ok {
+				return nil, fmt.Errorf("tag_fields must be strings")
+			}
+			rule.TagFields = append(rule.TagFields, str)
+		}
+	}
+
+	if err := s.logSvc.CreateMetricRule(ctx, rule); err != nil {
 		return nil, err
 	}
-
-	return map[string]interface{}{
-		"total_count":    stats.TotalCount,
-		"by_level":       stats.ByLevel,
-		"by_service":     stats.ByService,
-		"by_source":      stats.BySource,
-		"first_log_time": stats.FirstLogTime.Format(time.RFC3339),
-		"last_log_time":  stats.LastLogTime.Format(time.RFC3339),
-	}, nil
+	return map[string]interface{}{"id": rule.ID.String(), "name": rule.Name}, nil
 }
 
-// handleLogParserList lists log parsers.
-func (s *Server) handleLogParserList(ctx context.Context) (interface{}, error) {
+// handleLogMetricRuleList lists log-to-metric rules with their status.
+func (s *Server) handleLogMetricRuleList(ctx context.Context) (interface{}, error) {
 	if s.logSvc == nil {
-		return map[string]interface{}{"parsers": []interface{}{}}, nil
+		return map[string]interface{}{"rules": []interface{}{}}, nil
 	}
 
-	parsers, err := s.logSvc.ListParsers(ctx)
+	rules, err := s.logSvc.ListMetricRules(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	result := make([]interface{}, len(parsers))
-	for i, p := range parsers {
+	result := make([]interface{}, len(rules))
+	for i, r := range rules {
 		result[i] = map[string]interface{}{
-			"id":            p.ID.String(),
-			"name":          p.Name,
-			"type":          string(p.Type),
-			"enabled":       p.Enabled,
-			"source_filter": p.SourceFilter,
+			"id":            r.ID.String(),
+			"name":          r.Name,
+			"match_field":   r.MatchField,
+			"match_pattern": r.MatchPattern,
+			"match_values":  r.MatchValues,
+			"min_level":     string(r.MinLevel),
+			"service_name":  r.ServiceName,
+			"source":        r.Source,
+			"metric_name":   r.MetricName,
+			"metric_type":   string(r.MetricType),
+			"value_field":   r.ValueField,
+			"tags":          r.Tags,
+			"tag_fields":    r.TagFields,
+			"enabled":       r.Enabled,
+			"status":        string(r.Status),
+			"last_error":    r.LastError,
 		}
 	}
-	return map[string]interface{}{"parsers": result}, nil
+	return map[string]interface{}{"rules": result}, nil
+}
+
+// handleLogMetricRuleDelete deletes a log-to-metric rule.
+func (s *Server) handleLogMetricRuleDelete(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if s.logSvc == nil {
+		return nil, fmt.Errorf("log service not available")
+	}
+
+	idStr, _ := params["id"].(string)
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id: %w", err)
+	}
+	if err := s.logSvc.DeleteMetricRule(ctx, id); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"deleted": true}, nil
 }
 
 // logEntryToMap converts a log entry to a map for JSON serialization.
@@ -1698,8 +3304,8 @@ func (s *Server) handleProfileList(ctx context.Context, params map[string]interf
 	if profileType, ok := params["type"].(string); ok && profileType != "" {
 		filter.Type = domain.ProfileType(profileType)
 	}
-	if limit, ok := params["limit"].(float64); ok && limit > 0 {
-		filter.Limit = int(limit)
+	if limit, ok := intParam(params, "limit"); ok && limit > 0 {
+		filter.Limit = limit
 	}
 
 	profiles, err := s.profileSvc.ListProfiles(ctx, filter)
@@ -1738,6 +3344,41 @@ func (s *Server) handleProfileGet(ctx context.Context, params map[string]interfa
 	return map[string]interface{}{"profile": s.profileToMap(profile)}, nil
 }
 
+// handleProfileFlameGraph returns the flame graph tree for a completed profile.
+func (s *Server) handleProfileFlameGraph(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if s.profileSvc == nil {
+		return nil, fmt.Errorf("profile service not configured")
+	}
+
+	idStr, _ := params["id"].(string)
+	if idStr == "" {
+		return nil, fmt.Errorf("id is required")
+	}
+
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id: %w", err)
+	}
+
+	var fg *domain.FlameGraph
+	if rebuild, _ := params["rebuild"].(bool); rebuild {
+		fg, err = s.profileSvc.BuildFlameGraph(ctx, id)
+	} else {
+		fg, err = s.profileSvc.GetFlameGraph(ctx, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"profile_id":  fg.ProfileID.String(),
+		"type":        string(fg.Type),
+		"total_value": fg.TotalValue,
+		"max_depth":   fg.MaxDepth,
+		"root":        fg.Root,
+	}, nil
+}
+
 // handleProfileStop stops an active profile.
 func (s *Server) handleProfileStop(ctx context.Context, params map[string]interface{}) (interface{}, error) {
 	if s.profileSvc == nil {
@@ -1865,12 +3506,33 @@ func (s *Server) handleUserCreate(ctx context.Context, params map[string]interfa
 		role = domain.RoleViewer
 	}
 
+	// The first user is always an administrator and gets an API key, since
+	// every later user management call needs one
+	bootstrap := false
+	if CallerFromContext(ctx) == nil {
+		var err error
+		if bootstrap, err = s.bootstrapping(ctx); err != nil {
+			return nil, err
+		}
+		if bootstrap {
+			role = domain.RoleAdmin
+		}
+	}
+
 	user, err := s.authSvc.CreateUser(ctx, username, email, password, role)
 	if err != nil {
 		return nil, err
 	}
 
-	return s.userToMap(user), nil
+	result := s.userToMap(user)
+	if bootstrap {
+		_, key, err := s.authSvc.CreateAPIKey(ctx, user.ID, "bootstrap", []string{"*"}, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create initial API key: %w", err)
+		}
+		result["api_key"] = key // Only returned once!
+	}
+	return result, nil
 }
 
 // handleUserList lists all users.
@@ -1952,6 +3614,33 @@ func (s *Server) handleUserDelete(ctx context.Context, params map[string]interfa
 	return map[string]interface{}{"status": "deleted", "username": username}, nil
 }
 
+// handleUserImport provisions the users in the "users" param, each an
+// object with username, email and optionally role, password and invite.
+func (s *Server) handleUserImport(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if s.authSvc == nil {
+		return nil, fmt.Errorf("auth service not configured")
+	}
+
+	raw, ok := params["users"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("users is required")
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid users: %w", err)
+	}
+	entries, err := services.ParseUserImport(bytes.NewReader(data), "json")
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := s.authSvc.ImportUsers(ctx, entries)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
 // handleAPIKeyCreate creates a new API key.
 func (s *Server) handleAPIKeyCreate(ctx context.Context, params map[string]interface{}) (interface{}, error) {
 	if s.authSvc == nil {
@@ -1976,9 +3665,12 @@ func (s *Server) handleAPIKeyCreate(ctx context.Context, params map[string]inter
 		permissions = []string{"*"}
 	}
 
-	// For now, we'll use a default user ID (in production, get from session)
-	// This is a placeholder - real implementation would use authenticated user
-	apiKey, key, err := s.authSvc.CreateAPIKey(ctx, [16]byte{}, name, permissions, nil)
+	caller := CallerFromContext(ctx)
+	if caller == nil {
+		return nil, fmt.Errorf("%w: apikey.create requires authentication", services.ErrPermissionDenied)
+	}
+
+	apiKey, key, err := s.authSvc.CreateAPIKey(ctx, caller.User.ID, name, permissions, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -1999,8 +3691,12 @@ func (s *Server) handleAPIKeyList(ctx context.Context, params map[string]interfa
 		return map[string]interface{}{"keys": []interface{}{}}, nil
 	}
 
-	// For now, return empty list - real implementation would use authenticated user
-	keys, err := s.authSvc.ListAPIKeys(ctx, [16]byte{})
+	caller := CallerFromContext(ctx)
+	if caller == nil {
+		return map[string]interface{}{"keys": []interface{}{}}, nil
+	}
+
+	keys, err := s.authSvc.ListAPIKeys(ctx, caller.User.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -2028,6 +3724,24 @@ func (s *Server) handleAPIKeyRevoke(ctx context.Context, params map[string]inter
 		return nil, fmt.Errorf("invalid id: %w", err)
 	}
 
+	// Only administrators may revoke other users' keys
+	if caller := CallerFromContext(ctx); caller != nil && !caller.User.CanAccess(domain.ResourceAPIKeys, domain.PermissionAdmin) {
+		keys, err := s.authSvc.ListAPIKeys(ctx, caller.User.ID)
+		if err != nil {
+			return nil, err
+		}
+		owned := false
+		for _, k := range keys {
+			if k.ID == id {
+				owned = true
+				break
+			}
+		}
+		if !owned {
+			return nil, fmt.Errorf("%w: API key %s belongs to another user", services.ErrPermissionDenied, idStr)
+		}
+	}
+
 	if err := s.authSvc.RevokeAPIKey(ctx, id); err != nil {
 		return nil, err
 	}
@@ -2045,8 +3759,8 @@ func (s *Server) handleAuditList(ctx context.Context, params map[string]interfac
 		Limit: 50,
 	}
 
-	if limit, ok := params["limit"].(float64); ok && limit > 0 {
-		filter.Limit = int(limit)
+	if limit, ok := intParam(params, "limit"); ok && limit > 0 {
+		filter.Limit = limit
 	}
 	if action, ok := params["action"].(string); ok && action != "" {
 		filter.Action = action
@@ -2064,6 +3778,33 @@ func (s *Server) handleAuditList(ctx context.Context, params map[string]interfac
 	return map[string]interface{}{"logs": result}, nil
 }
 
+// handleAuditVerify checks the audit log hash chain for gaps or tampering.
+func (s *Server) handleAuditVerify(ctx context.Context) (interface{}, error) {
+	if s.authSvc == nil {
+		return nil, fmt.Errorf("auth service not configured")
+	}
+
+	report, err := s.authSvc.VerifyAuditLog(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	issues := make([]interface{}, len(report.Issues))
+	for i, issue := range report.Issues {
+		issues[i] = map[string]interface{}{
+			"index":  issue.Index,
+			"id":     issue.ID.String(),
+			"reason": issue.Reason,
+		}
+	}
+	return map[string]interface{}{
+		"valid":   report.Valid,
+		"checked": report.Checked,
+		"head":    report.Head,
+		"issues":  issues,
+	}, nil
+}
+
 // userToMap converts a user to a map for JSON serialization.
 func (s *Server) userToMap(u *domain.User) map[string]interface{} {
 	m := map[string]interface{}{
@@ -2130,6 +3871,10 @@ func (s *Server) auditLogToMap(l *domain.AuditLog) map[string]interface{} {
 	if l.IPAddress != "" {
 		m["ip_address"] = l.IPAddress
 	}
+	if l.Hash != "" {
+		m["hash"] = l.Hash
+		m["prev_hash"] = l.PrevHash
+	}
 	return m
 }
 
@@ -2239,4 +3984,403 @@ func (s *Server) handleBackupInfo(ctx context.Context, params map[string]interfa
 		"version":    Version,
 		"started_at": s.startedAt.Format(time.RFC3339),
 	}, nil
-}
\ No newline at end of file
+}
+
+// ============================================================================
+// Schedule Handlers
+// ============================================================================
+
+// handleScheduleList returns upcoming scheduled runs: recurring daemon jobs
+// plus pending tasks that are deferred to a future time.
+func (s *Server) handleScheduleList(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	entries := s.scheduleSvc.List()
+
+	schedules := make([]map[string]interface{}, 0, len(entries))
+	for _, e := range entries {
+		schedules = append(schedules, scheduleEntryToMap(e))
+	}
+
+	includeTasks := true
+	if v, ok := params["include_tasks"].(bool); ok {
+		includeTasks = v
+	}
+
+	if includeTasks {
+		status := domain.TaskStatusPending
+		tasks, err := s.taskSvc.ListTasks(ctx, ports.TaskFilter{Status: &status, Limit: 100})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pending tasks: %w", err)
+		}
+		for _, t := range tasks {
+			schedules = append(schedules, pendingTaskToScheduleMap(t))
+		}
+
+		sort.SliceStable(schedules, func(i, j int) bool {
+			return schedules[i]["next_run"].(string) < schedules[j]["next_run"].(string)
+		})
+	}
+
+	return map[string]interface{}{
+		"schedules": schedules,
+		"count":     len(schedules),
+	}, nil
+}
+
+// scheduleEntryToMap converts a schedule entry to a map for JSON serialization.
+func scheduleEntryToMap(e services.ScheduleEntry) map[string]interface{} {
+	m := map[string]interface{}{
+		"name":        e.Name,
+		"kind":        string(e.Kind),
+		"spec":        e.Spec,
+		"next_run":    e.NextRun.UTC().Format(time.RFC3339),
+		"last_status": string(e.LastStatus),
+	}
+	if e.LastRun != nil {
+		m["last_run"] = e.LastRun.UTC().Format(time.RFC3339)
+		m["duration_ms"] = e.Duration.Milliseconds()
+	}
+	if e.LastError != "" {
+		m["last_error"] = e.LastError
+	}
+	return m
+}
+
+// pendingTaskToScheduleMap presents a queued task as a one-off schedule entry.
+// A task that has been retried reports its previous failure as the last status.
+func pendingTaskToScheduleMap(t *domain.Task) map[string]interface{} {
+	m := map[string]interface{}{
+		"name":        string(t.Type) + ":" + t.ID.String(),
+		"kind":        string(services.ScheduleKindTask),
+		"spec":        "once",
+		"next_run":    t.RunAt.UTC().Format(time.RFC3339),
+		"last_status": string(services.ScheduleStatusNever),
+	}
+	if t.RetryCount > 0 {
+		m["last_status"] = string(services.ScheduleStatusFailed)
+		m["last_error"] = t.Error
+		m["last_run"] = t.UpdatedAt.UTC().Format(time.RFC3339)
+	}
+	return m
+}
+
+// ============================================================================
+// Plugin Event Handlers
+// ============================================================================
+
+// handlePluginList returns the plugins loaded in the plugin runtime along
+// with their tick state. Without a runtime the list is empty.
+func (s *Server) handlePluginList() (interface{}, error) {
+	plugins := []interface{}{}
+	if s.pluginRT == nil {
+		return map[string]interface{}{"plugins": plugins}, nil
+	}
+
+	ids := s.pluginRT.ListLoadedPlugins()
+	sort.Strings(ids)
+	for _, id := range ids {
+		plugin, ok := s.pluginRT.GetPlugin(id)
+		if !ok {
+			continue
+		}
+
+		entry := map[string]interface{}{
+			"id":          id,
+			"name":        plugin.Name,
+			"version":     plugin.Version,
+			"status":      plugin.Status,
+			"permissions": plugin.Permissions,
+		}
+		if plugin.LoadedAt != nil {
+			entry["loaded_at"] = plugin.LoadedAt.Format(time.RFC3339)
+		}
+		if plugin.Error != "" {
+			entry["error"] = plugin.Error
+		}
+		if plugin.Violations > 0 {
+			entry["violations"] = plugin.Violations
+		}
+
+		if s.pluginSched != nil {
+			if tick, ok := s.pluginSched.Status(id); ok {
+				entry["tick_interval_ms"] = tick.Interval.Milliseconds()
+				entry["tick_error_count"] = tick.ErrorCount
+				if tick.LastTick != nil {
+					entry["last_tick"] = tick.LastTick.Format(time.RFC3339)
+					entry["last_tick_duration_ms"] = float64(tick.LastDuration.Microseconds()) / 1000
+				}
+				if tick.LastError != "" {
+					entry["last_tick_error"] = tick.LastError
+				}
+				if tick.Degraded {
+					entry["status"] = domain.PluginStatusDegraded
+				}
+			}
+		}
+		plugins = append(plugins, entry)
+	}
+
+	return map[string]interface{}{"plugins": plugins}, nil
+}
+
+// handlePluginReload replaces a loaded plugin with the current contents of
+// its .wasm file. The plugin is selected by name or ID; an optional sha256
+// must match the new file. If the new binary fails, the old one keeps running.
+func (s *Server) handlePluginReload(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if s.pluginRT == nil {
+		return nil, fmt.Errorf("plugin runtime not available")
+	}
+
+	name, _ := params["name"].(string)
+	if name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	expectedHash, _ := params["sha256"].(string)
+
+	plugin, ok := s.loadedPlugin(name)
+	if !ok {
+		return nil, fmt.Errorf("plugin not loaded: %s", name)
+	}
+
+	id := plugin.ID.String()
+	changed, err := s.pluginRT.ReloadPlugin(ctx, id, expectedHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reload plugin %s: %w", plugin.Name, err)
+	}
+
+	status := "unchanged"
+	if changed {
+		status = "reloaded"
+		if reloaded, ok := s.pluginRT.GetPlugin(id); ok {
+			plugin = reloaded
+		}
+		s.updateStoredPlugin(ctx, plugin)
+	}
+	return map[string]interface{}{
+		"status": status,
+		"id":     id,
+		"name":   plugin.Name,
+		"hash":   plugin.Hash,
+	}, nil
+}
+
+// loadedPlugin finds a loaded plugin by name or ID.
+func (s *Server) loadedPlugin(name string) (*domain.Plugin, bool) {
+	for _, id := range s.pluginRT.ListLoadedPlugins() {
+		p, ok := s.pluginRT.GetPlugin(id)
+		if ok && (id == name || p.Name == name) {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// pluginConfigParam returns the "config" parameter as plugin configuration.
+// Numbers and booleans are converted to strings; the plugin's schema decides
+// whether they are valid.
+func pluginConfigParam(params map[string]interface{}) (map[string]string, error) {
+	raw, ok := params["config"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	values, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("config must be an object")
+	}
+	config := make(map[string]string, len(values))
+	for key, v := range values {
+		switch v := v.(type) {
+		case string:
+			config[key] = v
+		case json.Number:
+			config[key] = v.String()
+		case float64:
+			config[key] = strconv.FormatFloat(v, 'f', -1, 64)
+		case int:
+			config[key] = strconv.Itoa(v)
+		case bool:
+			config[key] = strconv.FormatBool(v)
+		default:
+			return nil, fmt.Errorf("config value for %s must be a string, number or boolean", key)
+		}
+	}
+	return config, nil
+}
+
+// handlePluginConfigure replaces the configuration of a loaded plugin,
+// selected by name or ID. Configuration that does not match the plugin's
+// schema is rejected with every problem listed, and the plugin keeps its
+// previous configuration.
+func (s *Server) handlePluginConfigure(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if s.pluginRT == nil {
+		return nil, fmt.Errorf("plugin runtime not available")
+	}
+
+	name, _ := params["name"].(string)
+	if name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	config, err := pluginConfigParam(params)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return nil, fmt.Errorf("config is required")
+	}
+
+	plugin, ok := s.loadedPlugin(name)
+	if !ok {
+		return nil, fmt.Errorf("plugin not loaded: %s", name)
+	}
+	id := plugin.ID.String()
+	if err := s.pluginRT.ConfigurePlugin(ctx, id, config); err != nil {
+		return nil, err
+	}
+	if configured, ok := s.pluginRT.GetPlugin(id); ok {
+		s.updateStoredPlugin(ctx, configured)
+	}
+	return map[string]interface{}{
+		"status": "configured",
+		"id":     id,
+		"name":   plugin.Name,
+		"config": config,
+	}, nil
+}
+
+// handlePluginStorage reports the data a plugin has stored under the
+// runtime's data directory and quota, deleting it first when purge is set.
+// Plugins need not be loaded.
+func (s *Server) handlePluginStorage(ctx context.Context, params map[string]interface{}, purge bool) (interface{}, error) {
+	if s.pluginRT == nil {
+		return nil, fmt.Errorf("plugin runtime not available")
+	}
+
+	name, _ := params["name"].(string)
+	if name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+
+	if purge {
+		if err := s.pluginRT.PurgeStorage(ctx, name); err != nil {
+			return nil, err
+		}
+		s.logger.Info("Plugin storage purged", "name", name)
+	}
+	usage, err := s.pluginRT.StorageUsage(name)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"name":  name,
+		"dir":   usage.Dir,
+		"used":  usage.Used,
+		"files": usage.Files,
+		"quota": usage.Quota,
+	}, nil
+}
+
+// handlePluginInstall loads a plugin from a local path, or downloads it
+// from a URL into the plugin directory first. Downloads require a sha256
+// checksum and may carry an ed25519 signature; the plugin is only loaded
+// once both are verified. An optional config is validated against the
+// plugin's schema before it is initialized.
+func (s *Server) handlePluginInstall(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if s.pluginRT == nil {
+		return nil, fmt.Errorf("plugin runtime not available")
+	}
+
+	rawURL, _ := params["url"].(string)
+	path, _ := params["path"].(string)
+	expectedHash, _ := params["sha256"].(string)
+	signature, _ := params["signature"].(string)
+	config, err := pluginConfigParam(params)
+	if err != nil {
+		return nil, err
+	}
+
+	var plugin *domain.Plugin
+	downloaded := false
+	switch {
+	case rawURL != "":
+		if expectedHash == "" {
+			return nil, fmt.Errorf("sha256 is required to install from a URL")
+		}
+		installed, err := s.pluginReg.InstallFromURL(ctx, rawURL, expectedHash, signature)
+		if err != nil {
+			return nil, fmt.Errorf("failed to install plugin: %w", err)
+		}
+		plugin = installed
+		downloaded = true
+	case path != "":
+		if signature != "" {
+			return nil, fmt.Errorf("signatures are only checked for plugins installed from a URL")
+		}
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("failed to install plugin: %w", err)
+		}
+		plugin = domain.NewPlugin(strings.TrimSuffix(filepath.Base(path), ".wasm"), "", path)
+		// The runtime rejects a binary that doesn't match the expected hash
+		plugin.Hash = strings.ToLower(expectedHash)
+	default:
+		return nil, fmt.Errorf("path or url is required")
+	}
+	for key, value := range config {
+		plugin.Config[key] = value
+	}
+
+	if err := s.pluginRT.LoadPlugin(ctx, plugin); err != nil {
+		if downloaded {
+			os.Remove(plugin.Path)
+		}
+		return nil, fmt.Errorf("failed to load plugin %s: %w", plugin.Name, err)
+	}
+
+	// Record the plugin with the permissions from its manifest, so it is
+	// loaded again when the daemon restarts
+	if existing, err := s.pluginRepo.GetByName(ctx, plugin.Name); err == nil {
+		if err := s.pluginRepo.Delete(ctx, existing.ID); err != nil {
+			return nil, fmt.Errorf("failed to replace plugin %s: %w", plugin.Name, err)
+		}
+	}
+	if err := s.pluginRepo.Create(ctx, plugin); err != nil {
+		return nil, fmt.Errorf("failed to save plugin %s: %w", plugin.Name, err)
+	}
+	return map[string]interface{}{
+		"id":          plugin.ID.String(),
+		"name":        plugin.Name,
+		"path":        plugin.Path,
+		"hash":        plugin.Hash,
+		"permissions": plugin.Permissions,
+	}, nil
+}
+
+// handleEventPublish injects a synthetic event (e.g. alert.fired) into the
+// plugin event bus. A string payload is sent as-is; any other payload is
+// encoded as JSON.
+func (s *Server) handleEventPublish(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	if s.pluginRT == nil {
+		return nil, fmt.Errorf("plugin runtime not available")
+	}
+
+	eventType, _ := params["type"].(string)
+	if eventType == "" {
+		return nil, fmt.Errorf("type is required")
+	}
+
+	var payload []byte
+	switch p := params["payload"].(type) {
+	case nil:
+	case string:
+		payload = []byte(p)
+	default:
+		data, err := json.Marshal(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid payload: %w", err)
+		}
+		payload = data
+	}
+
+	if err := s.pluginRT.PublishEvent(eventType, payload); err != nil {
+		return nil, fmt.Errorf("failed to publish event: %w", err)
+	}
+	return map[string]interface{}{"status": "published", "type": eventType}, nil
+}
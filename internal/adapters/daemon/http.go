@@ -15,7 +15,9 @@ import (
 // HTTPServer provides HTTP endpoints for Cloud Run and Kubernetes health checks.
 type HTTPServer struct {
 	server    *http.Server
+	mux       *http.ServeMux
 	healthSvc *services.HealthService
+	metrics   http.Handler
 	version   string
 	startTime time.Time
 }
@@ -43,6 +45,7 @@ func NewHTTPServer(port string, healthSvc *services.HealthService, version strin
 	mux.HandleFunc("/health/readiness", h.handleReadiness)
 	mux.HandleFunc("/metrics", h.handleMetrics)
 
+	h.mux = mux
 	h.server = &http.Server{
 		Addr:         ":" + port,
 		Handler:      mux,
@@ -54,6 +57,17 @@ func NewHTTPServer(port string, healthSvc *services.HealthService, version strin
 	return h
 }
 
+// Handle registers an additional handler. It must be called before Start.
+func (h *HTTPServer) Handle(pattern string, handler http.Handler) {
+	h.mux.Handle(pattern, handler)
+}
+
+// SetMetricsHandler replaces the built-in /metrics output. It must be called
+// before Start.
+func (h *HTTPServer) SetMetricsHandler(handler http.Handler) {
+	h.metrics = handler
+}
+
 // Start starts the HTTP server.
 func (h *HTTPServer) Start() error {
 	return h.server.ListenAndServe()
@@ -139,6 +153,10 @@ func (h *HTTPServer) handleReadiness(w http.ResponseWriter, r *http.Request) {
 
 // handleMetrics handles Prometheus-style metrics endpoint.
 func (h *HTTPServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if h.metrics != nil {
+		h.metrics.ServeHTTP(w, r)
+		return
+	}
 	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	uptime := time.Since(h.startTime).Seconds()
 	fmt.Fprintf(w, "# HELP forge_uptime_seconds Uptime in seconds\n")
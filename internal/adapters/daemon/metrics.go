@@ -0,0 +1,224 @@
+package daemon
+
+import (
+	"context"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/forge-platform/forge/internal/adapters/prometheus"
+)
+
+// rpcDurationBuckets are the upper bounds, in seconds, of the RPC latency
+// histogram.
+var rpcDurationBuckets = []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5}
+
+// maxRPCMethods bounds the methods tracked individually. Clients choose the
+// method name, so anything past the bound is counted under "other".
+const maxRPCMethods = 256
+
+// rpcStats counts RPC requests and their latencies per method.
+type rpcStats struct {
+	mu      sync.Mutex
+	methods map[string]*methodStats
+}
+
+type methodStats struct {
+	requests uint64
+	errors   uint64
+	buckets  []uint64 // cumulative counts, one per rpcDurationBuckets entry
+	sum      float64
+}
+
+func newRPCStats() *rpcStats {
+	return &rpcStats{methods: make(map[string]*methodStats)}
+}
+
+// observe records one request.
+func (r *rpcStats) observe(method string, d time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m, ok := r.methods[method]
+	if !ok {
+		if len(r.methods) >= maxRPCMethods {
+			method = "other"
+			m = r.methods[method]
+		}
+		if m == nil {
+			m = &methodStats{buckets: make([]uint64, len(rpcDurationBuckets))}
+			r.methods[method] = m
+		}
+	}
+
+	m.requests++
+	if err != nil {
+		m.errors++
+	}
+	secs := d.Seconds()
+	m.sum += secs
+	for i, bound := range rpcDurationBuckets {
+		if secs <= bound {
+			m.buckets[i]++
+		}
+	}
+}
+
+// Collect returns the request, error and latency families.
+func (r *rpcStats) Collect() []prometheus.Family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	methods := make([]string, 0, len(r.methods))
+	for method := range r.methods {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	requests := prometheus.Family{
+		Name: "forge_rpc_requests_total",
+		Help: "RPC requests handled by the daemon.",
+		Type: prometheus.TypeCounter,
+	}
+	failures := prometheus.Family{
+		Name: "forge_rpc_errors_total",
+		Help: "RPC requests that returned an error.",
+		Type: prometheus.TypeCounter,
+	}
+	durations := prometheus.Family{
+		Name: "forge_rpc_duration_seconds",
+		Help: "RPC request latency.",
+		Type: prometheus.TypeHistogram,
+	}
+	for _, method := range methods {
+		m := r.methods[method]
+		labels := map[string]string{"method": method}
+		requests.Samples = append(requests.Samples, prometheus.Sample{Labels: labels, Value: float64(m.requests)})
+		failures.Samples = append(failures.Samples, prometheus.Sample{Labels: labels, Value: float64(m.errors)})
+
+		for i, bound := range rpcDurationBuckets {
+			durations.Samples = append(durations.Samples, prometheus.Sample{
+				Name:   "forge_rpc_duration_seconds_bucket",
+				Labels: map[string]string{"method": method, "le": strconv.FormatFloat(bound, 'g', -1, 64)},
+				Value:  float64(m.buckets[i]),
+			})
+		}
+		durations.Samples = append(durations.Samples,
+			prometheus.Sample{Name: "forge_rpc_duration_seconds_bucket", Labels: map[string]string{"method": method, "le": "+Inf"}, Value: float64(m.requests)},
+			prometheus.Sample{Name: "forge_rpc_duration_seconds_sum", Labels: labels, Value: m.sum},
+			prometheus.Sample{Name: "forge_rpc_duration_seconds_count", Labels: labels, Value: float64(m.requests)},
+		)
+	}
+	return []prometheus.Family{requests, failures, durations}
+}
+
+// collectRuntime returns Go runtime and daemon process families.
+func (s *Server) collectRuntime() []prometheus.Family {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	gauge := func(name, help string, value float64) prometheus.Family {
+		return prometheus.Family{
+			Name:    name,
+			Help:    help,
+			Type:    prometheus.TypeGauge,
+			Samples: []prometheus.Sample{{Value: value}},
+		}
+	}
+	return []prometheus.Family{
+		gauge("go_goroutines", "Number of goroutines that currently exist.", float64(runtime.NumGoroutine())),
+		gauge("go_memstats_heap_alloc_bytes", "Heap bytes allocated and still in use.", float64(mem.HeapAlloc)),
+		gauge("go_memstats_heap_inuse_bytes", "Heap bytes in in-use spans.", float64(mem.HeapInuse)),
+		gauge("go_memstats_sys_bytes", "Bytes obtained from the system.", float64(mem.Sys)),
+		{
+			Name:    "go_gc_cycles_total",
+			Help:    "Completed GC cycles.",
+			Type:    prometheus.TypeCounter,
+			Samples: []prometheus.Sample{{Value: float64(mem.NumGC)}},
+		},
+		gauge("forge_uptime_seconds", "Seconds since the daemon started.", time.Since(s.startedAt).Seconds()),
+		{
+			Name:    "forge_build_info",
+			Help:    "Daemon version, always 1.",
+			Type:    prometheus.TypeGauge,
+			Samples: []prometheus.Sample{{Labels: map[string]string{"version": Version}, Value: 1}},
+		},
+	}
+}
+
+// collectInternal returns counters for the daemon's own work.
+func (s *Server) collectInternal() []prometheus.Family {
+	stats := s.taskSvc.Stats()
+	plugins := 0
+	if s.pluginRT != nil {
+		plugins = len(s.pluginRT.ListLoadedPlugins())
+	}
+	return []prometheus.Family{
+		{
+			Name: "forge_tasks_processed_total",
+			Help: "Task runs finished by workers, by outcome.",
+			Type: prometheus.TypeCounter,
+			Samples: []prometheus.Sample{
+				{Labels: map[string]string{"status": "completed"}, Value: float64(stats.Completed)},
+				{Labels: map[string]string{"status": "failed"}, Value: float64(stats.Failed)},
+			},
+		},
+		{
+			Name:    "forge_plugins_loaded",
+			Help:    "Plugins currently loaded in the runtime.",
+			Type:    prometheus.TypeGauge,
+			Samples: []prometheus.Sample{{Value: float64(plugins)}},
+		},
+	}
+}
+
+// newExporter creates the exposition handler for stored series, runtime
+// stats and RPC stats.
+func (s *Server) newExporter() *prometheus.Exporter {
+	exporter := prometheus.NewExporter(s.metricSvc, s.logger)
+	exporter.SetMetadata(s.metricSvc)
+	exporter.AddCollector(prometheus.CollectorFunc(s.collectRuntime))
+	exporter.AddCollector(prometheus.CollectorFunc(s.collectInternal))
+	exporter.AddCollector(s.rpcStats)
+	return exporter
+}
+
+// startMetricsExporter serves the exposition on the configured address, or
+// on the HTTP server when no address is set.
+func (s *Server) startMetricsExporter() {
+	exporter := s.newExporter()
+	if s.config.MetricsAddr == "" {
+		s.httpServer.SetMetricsHandler(exporter)
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(prometheus.MetricsPath, exporter)
+	s.metricsHTTP = &http.Server{
+		Addr:         s.config.MetricsAddr,
+		Handler:      mux,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 30 * time.Second,
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.logger.Info("Metrics exporter starting", "addr", s.config.MetricsAddr)
+		if err := s.metricsHTTP.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			s.logger.Error("Metrics exporter error", "error", err)
+		}
+	}()
+}
+
+// shutdownMetricsExporter stops the exporter's own listener, if any.
+func (s *Server) shutdownMetricsExporter(ctx context.Context) {
+	if s.metricsHTTP == nil {
+		return
+	}
+	if err := s.metricsHTTP.Shutdown(ctx); err != nil {
+		s.logger.Error("Metrics exporter shutdown error", "error", err)
+	}
+}
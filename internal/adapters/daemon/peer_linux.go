@@ -0,0 +1,26 @@
+//go:build linux
+
+package daemon
+
+import (
+	"fmt"
+	"net"
+	"syscall"
+)
+
+// unixPeerIdentity names the user on the other end of a unix socket from
+// its peer credentials.
+func unixPeerIdentity(conn *net.UnixConn) (string, bool) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return "", false
+	}
+	var cred *syscall.Ucred
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	}); err != nil || credErr != nil {
+		return "", false
+	}
+	return fmt.Sprintf("unix:uid=%d", cred.Uid), true
+}
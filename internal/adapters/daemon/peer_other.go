@@ -0,0 +1,11 @@
+//go:build !linux
+
+package daemon
+
+import "net"
+
+// unixPeerIdentity is unavailable without SO_PEERCRED; unix socket clients
+// share the "local" identity.
+func unixPeerIdentity(conn *net.UnixConn) (string, bool) {
+	return "", false
+}
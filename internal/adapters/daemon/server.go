@@ -9,9 +9,14 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/forge-platform/forge/internal/adapters/notifications"
+	"github.com/forge-platform/forge/internal/adapters/otlp"
+	"github.com/forge-platform/forge/internal/adapters/prometheus"
 	"github.com/forge-platform/forge/internal/adapters/storage"
+	"github.com/forge-platform/forge/internal/adapters/wasm"
 	"github.com/forge-platform/forge/internal/core/domain"
 	"github.com/forge-platform/forge/internal/core/ports"
 	"github.com/forge-platform/forge/internal/core/services"
@@ -20,11 +25,17 @@ import (
 // Version is the current Forge version.
 const Version = "1.1.0"
 
+// logRuleRefreshInterval is how often cached log parsers and log-to-metric
+// rules are reloaded from the database.
+const logRuleRefreshInterval = 30 * time.Second
+
 // Server represents the Forge daemon server.
 type Server struct {
 	config      Config
 	listener    net.Listener
 	httpServer  *HTTPServer
+	metricsHTTP *http.Server
+	otlpServer  *otlp.Receiver
 	db          *storage.DB
 	logger      ports.Logger
 	taskSvc     *services.TaskService
@@ -37,8 +48,18 @@ type Server struct {
 	profileSvc  *services.ProfileService
 	authSvc     *services.AuthService
 	healthSvc   *services.HealthService
+	scheduleSvc *services.ScheduleService
 	aiProvider  ports.AIProvider
+	convRepo    ports.ConversationRepository
+	pluginRT    ports.WasmRuntime
+	pluginSched *services.PluginScheduler
+	pluginWatch *wasm.PluginWatcher
+	pluginReg   *services.PluginRegistry
+	pluginRepo  ports.PluginRepository
+	systemColl  *services.SystemCollector
+	rpcStats    *rpcStats
 	startedAt   time.Time
+	pingSeq     atomic.Uint64
 	stopCh      chan struct{}
 	wg          sync.WaitGroup
 	mu          sync.RWMutex
@@ -47,43 +68,152 @@ type Server struct {
 
 // Config holds daemon configuration.
 type Config struct {
-	SocketPath      string
-	PIDFile         string
-	DataDir         string
-	ShutdownTimeout time.Duration
-	WorkerCount     int
-	HTTPPort        string // Port for HTTP health check server (for Cloud Run/K8s)
+	SocketPath       string
+	PIDFile          string
+	DataDir          string
+	PluginDir        string // Directory plugins installed from a URL are saved to
+	ShutdownTimeout  time.Duration
+	WorkerCount      int
+	HTTPPort         string // Port for HTTP health check server (for Cloud Run/K8s)
+	SelfTracing      bool   // Record a span per RPC under the forge-daemon service
+	WatchPlugins     bool   // Reload plugins automatically when their .wasm file changes
+	MaxSeriesPerName int    // Distinct series allowed per metric name (0 = unlimited)
+	OTLPEnabled      bool   // Accept traces and logs over OTLP/HTTP
+	OTLPHost         string // Interface for the OTLP/HTTP receiver, which is unauthenticated
+	OTLPPort         string // Port for the OTLP/HTTP receiver
+	RemoteWrite      bool   // Accept unauthenticated Prometheus remote_write on the HTTP server
+	RemoteWriteLimit int    // Largest remote_write payload in bytes, compressed or not
+	MetricsExporter  bool   // Serve stored series and daemon stats in Prometheus format
+	MetricsAddr      string // Listen address for the exporter; empty serves /metrics on the HTTP server
+
+	// SystemMetrics records the host's CPU, memory, disk and network stats
+	// from /proc every SystemMetricsInterval (Linux only)
+	SystemMetrics         bool
+	SystemMetricsInterval time.Duration
+
+	// RPC connections without a request for IdleTimeout are closed; streams
+	// are kept alive with a heartbeat every StreamHeartbeat (0 disables either)
+	IdleTimeout     time.Duration
+	StreamHeartbeat time.Duration
+
+	// MetricTransforms rescale and label metrics at ingestion or query time
+	MetricTransforms []domain.MetricTransformRule
+
+	// MetricRelabels rename metrics and rename, drop or derive tags at
+	// ingestion, before transforms apply
+	MetricRelabels []domain.MetricRelabelRule
+
+	// MetricMetadata describes metrics in the Prometheus exposition
+	MetricMetadata []domain.MetricMetadata
+
+	// Every DownsampleInterval, raw points older than RawRetention are
+	// rolled up into each tier's resolution and deleted
+	RawRetention       time.Duration
+	DownsampleInterval time.Duration
+	RetentionTiers     []services.RetentionTier
+
+	// Traces controls trace sampling and retention; trace.config.update
+	// changes it at runtime
+	Traces services.TraceConfig
+
+	// Silences that ended more than SilenceRetention ago are deleted
+	SilenceRetention time.Duration
+
+	// During QuietHours only critical alerts notify; others are delivered
+	// as a digest when quiet hours end (nil disables them)
+	QuietHours *domain.QuietHours
+
+	// AuthDisabled serves every RPC without authentication, for single-user
+	// local setups. Otherwise only health checks are open once a user exists.
+	AuthDisabled bool
 }
 
 // DefaultConfig returns the default daemon configuration.
 func DefaultConfig(forgeDir string) Config {
 	return Config{
-		SocketPath:      filepath.Join(forgeDir, "forge.sock"),
-		PIDFile:         filepath.Join(forgeDir, "forge.pid"),
-		DataDir:         filepath.Join(forgeDir, "data"),
-		ShutdownTimeout: 10 * time.Second,
-		WorkerCount:     4,
-		HTTPPort:        "", // Empty means use PORT env var or default to 8080
+		SocketPath:       filepath.Join(forgeDir, "forge.sock"),
+		PIDFile:          filepath.Join(forgeDir, "forge.pid"),
+		DataDir:          filepath.Join(forgeDir, "data"),
+		PluginDir:        filepath.Join(forgeDir, "plugins"),
+		ShutdownTimeout:  10 * time.Second,
+		WorkerCount:      4,
+		HTTPPort:         "", // Empty means use PORT env var or default to 8080
+		SelfTracing:      true,
+		OTLPEnabled:      true,
+		OTLPHost:         otlp.DefaultHost,
+		OTLPPort:         otlp.DefaultPort,
+		RemoteWriteLimit: prometheus.DefaultMaxBodySize,
+		IdleTimeout:      5 * time.Minute,
+		StreamHeartbeat:  30 * time.Second,
+
+		SystemMetrics:         true,
+		SystemMetricsInterval: services.DefaultSystemCollectorInterval,
+
+		RawRetention:       7 * 24 * time.Hour,
+		DownsampleInterval: time.Hour,
+		RetentionTiers:     services.DefaultRetentionTiers(),
+
+		Traces: services.DefaultTraceConfig(),
+
+		SilenceRetention: services.DefaultSilenceRetention,
+	}
+}
+
+// retentionPolicy is the metric retention policy the config describes.
+func (c Config) retentionPolicy() services.RetentionPolicy {
+	return services.RetentionPolicy{
+		RawRetention: c.RawRetention,
+		Tiers:        c.RetentionTiers,
+		Interval:     c.DownsampleInterval,
 	}
 }
 
 // NewServer creates a new daemon server.
 func NewServer(config Config, logger ports.Logger) (*Server, error) {
+	retention := config.retentionPolicy()
+	if err := retention.Validate(); err != nil {
+		return nil, err
+	}
+
 	// Initialize database
 	dbConfig := storage.DefaultConfig(config.DataDir)
 	db, err := storage.New(dbConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize database: %w", err)
 	}
+	if features := db.Features(); features.JSON1 {
+		logger.Info("SQLite json1 available, filtering JSON in queries", "fts5", features.FTS5)
+	} else {
+		logger.Warn("SQLite json1 unavailable, falling back to text matching and grouping tags in the application", "fts5", features.FTS5)
+	}
 
 	// Initialize repositories
 	taskRepo := storage.NewTaskRepository(db)
 	metricRepo := storage.NewMetricRepository(db)
+	metricRepo.SetMaxSeriesPerName(config.MaxSeriesPerName)
+	traceRepo := storage.NewTraceRepository(db)
+	spanRepo := storage.NewSpanRepository(db)
+	profileRepo := storage.NewProfileRepository(db)
+	logRepo := storage.NewLogRepository(db)
+	logParserRepo := storage.NewLogParserRepository(db)
+	logMetricRuleRepo := storage.NewLogToMetricRuleRepository(db)
+	convRepo := storage.NewConversationRepository(db)
 
 	// Initialize services
 	taskSvc := services.NewTaskService(taskRepo, logger)
 	metricSvc := services.NewMetricService(metricRepo, logger, services.DefaultMetricServiceConfig())
+	if err := metricSvc.SetTransformRules(config.MetricTransforms); err != nil {
+		return nil, fmt.Errorf("failed to configure metric transforms: %w", err)
+	}
+	if err := metricSvc.SetRelabelRules(config.MetricRelabels); err != nil {
+		return nil, fmt.Errorf("failed to configure metric relabeling: %w", err)
+	}
+	if err := metricSvc.SetMetadata(config.MetricMetadata); err != nil {
+		return nil, fmt.Errorf("failed to configure metric metadata: %w", err)
+	}
 	ragSvc := services.NewRAGService(metricRepo, taskRepo, logger, services.RAGConfig{})
+	ragSvc.SetInsightRepository(storage.NewInsightRepository(db))
+	ragSvc.SetLogRepository(logRepo)
 	workflowSvc := services.NewWorkflowService(nil, nil, logger)
 
 	// Register built-in workflow actions
@@ -93,19 +223,58 @@ func NewServer(config Config, logger ports.Logger) (*Server, error) {
 	workflowSvc.RegisterAction(domain.StepTypeTask, services.NewTaskAction(taskRepo))
 
 	// Initialize alert service (with nil repos for now - can be enhanced later)
-	alertSvc := services.NewAlertService(nil, nil, nil, nil, metricRepo, logger)
+	alertSvc := services.NewAlertService(nil, nil, storage.NewNotificationChannelRepository(db), nil, metricRepo, logger)
+	alertSvc.SetMaintenanceWindowRepository(storage.NewMaintenanceWindowRepository(db))
+	alertSvc.SetMetricService(metricSvc)
+	alertSvc.RegisterNotifier(notifications.NewWebhookNotifier())
+	alertSvc.RegisterNotifier(notifications.NewSlackNotifier())
+	alertSvc.RegisterNotifier(notifications.NewEmailNotifier())
+	alertSvc.RegisterNotifier(notifications.NewPagerDutyNotifier())
+	if config.SilenceRetention > 0 {
+		alertSvc.SetSilenceRetention(config.SilenceRetention)
+	}
+	if config.QuietHours != nil {
+		alertSvc.SetQuietHours(config.QuietHours)
+	}
 
 	// Initialize observability services
-	traceSvc := services.NewTraceService(nil, nil, logger)
-	logSvc := services.NewLogService(nil, nil, nil, metricRepo, logger)
-	profileSvc := services.NewProfileService(nil, filepath.Join(config.DataDir, "profiles"), logger)
+	traceSvc := services.NewTraceService(traceRepo, spanRepo, logger)
+	if err := traceSvc.SetConfig(config.Traces); err != nil {
+		return nil, fmt.Errorf("invalid trace config: %w", err)
+	}
+	traceSvc.SetMetricRecorder(metricSvc)
+	logSvc := services.NewLogService(logRepo, logParserRepo, logMetricRuleRepo, metricSvc, logger)
+	if err := logSvc.RefreshParsers(context.Background()); err != nil {
+		return nil, fmt.Errorf("failed to load log parsers: %w", err)
+	}
+	if err := logSvc.RefreshMetricRules(context.Background()); err != nil {
+		return nil, fmt.Errorf("failed to load log-to-metric rules: %w", err)
+	}
+	profileSvc := services.NewProfileService(profileRepo, filepath.Join(config.DataDir, "profiles"), logger)
 
 	// Initialize auth service
-	authSvc := services.NewAuthService(nil, nil, nil, nil, services.DefaultAuthConfig(), logger)
+	authSvc := services.NewAuthService(storage.NewUserRepository(db), storage.NewSessionRepository(db), storage.NewAPIKeyRepository(db), storage.NewAuditLogRepository(db), services.DefaultAuthConfig(), logger)
+	authSvc.SetPasswordResetRepository(storage.NewPasswordResetRepository(db))
+	authSvc.SetMetricService(metricSvc)
+
+	// Initialize plugin registry for installs from a URL
+	pluginReg, err := services.NewPluginRegistry(services.RegistryConfig{
+		CacheDir:   filepath.Join(config.DataDir, "cache"),
+		PluginsDir: config.PluginDir,
+	}, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize plugin registry: %w", err)
+	}
 
 	// Initialize health service
 	healthSvc := services.NewHealthService(Version, logger)
 
+	// Initialize schedule service and register built-in recurring jobs
+	scheduleSvc := services.NewScheduleService(logger)
+	if err := metricSvc.SetRetentionPolicy(retention, scheduleSvc); err != nil {
+		return nil, fmt.Errorf("failed to set retention policy: %w", err)
+	}
+
 	// Register health checkers
 	healthSvc.RegisterChecker("database", func(ctx context.Context) services.ComponentHealth {
 		start := time.Now()
@@ -126,6 +295,11 @@ func NewServer(config Config, logger ports.Logger) (*Server, error) {
 		}
 	})
 
+	var systemColl *services.SystemCollector
+	if config.SystemMetrics {
+		systemColl = services.NewSystemCollector(metricSvc, logger, config.SystemMetricsInterval)
+	}
+
 	return &Server{
 		config:      config,
 		db:          db,
@@ -140,6 +314,12 @@ func NewServer(config Config, logger ports.Logger) (*Server, error) {
 		profileSvc:  profileSvc,
 		authSvc:     authSvc,
 		healthSvc:   healthSvc,
+		scheduleSvc: scheduleSvc,
+		pluginReg:   pluginReg,
+		pluginRepo:  storage.NewPluginRepository(db),
+		systemColl:  systemColl,
+		convRepo:    convRepo,
+		rpcStats:    newRPCStats(),
 		stopCh:      make(chan struct{}),
 	}, nil
 }
@@ -149,6 +329,51 @@ func (s *Server) SetAIProvider(provider ports.AIProvider) {
 	s.aiProvider = provider
 }
 
+// SetPluginRuntime sets the plugin runtime that receives events injected
+// through event.publish. Plugins exporting on_tick are ticked by a
+// scheduler that runs while the daemon is started.
+func (s *Server) SetPluginRuntime(rt ports.WasmRuntime) {
+	s.pluginRT = rt
+	s.pluginSched = services.NewPluginScheduler(rt, s.logger, services.PluginSchedulerConfig{})
+}
+
+// loadStoredPlugins loads the active plugins recorded by earlier installs,
+// with the permissions and allowed hosts they were installed with. A plugin
+// that fails to load is logged and skipped.
+func (s *Server) loadStoredPlugins(ctx context.Context) {
+	plugins, err := s.pluginRepo.ListActive(ctx)
+	if err != nil {
+		s.logger.Warn("Failed to list installed plugins", "error", err)
+		return
+	}
+	for _, plugin := range plugins {
+		if err := s.pluginRT.LoadPlugin(ctx, plugin); err != nil {
+			s.logger.Warn("Failed to load installed plugin", "name", plugin.Name, "error", err)
+		}
+	}
+}
+
+// syncStoredPlugins records the current state of every loaded plugin, so a
+// plugin disabled or reloaded while running keeps that state on restart.
+func (s *Server) syncStoredPlugins(ctx context.Context) {
+	for _, id := range s.pluginRT.ListLoadedPlugins() {
+		if plugin, ok := s.pluginRT.GetPlugin(id); ok {
+			s.updateStoredPlugin(ctx, plugin)
+		}
+	}
+}
+
+// updateStoredPlugin records changes to an installed plugin. Plugins loaded
+// without plugin.install have no record and are left alone.
+func (s *Server) updateStoredPlugin(ctx context.Context, plugin *domain.Plugin) {
+	if _, err := s.pluginRepo.GetByID(ctx, plugin.ID); err != nil {
+		return
+	}
+	if err := s.pluginRepo.Update(ctx, plugin); err != nil {
+		s.logger.Warn("Failed to save plugin", "name", plugin.Name, "error", err)
+	}
+}
+
 // Start starts the daemon server.
 func (s *Server) Start(ctx context.Context) error {
 	s.mu.Lock()
@@ -180,6 +405,12 @@ func (s *Server) Start(ctx context.Context) error {
 
 	// Start HTTP server for health checks (Cloud Run / Kubernetes)
 	s.httpServer = NewHTTPServer(s.config.HTTPPort, s.healthSvc, Version)
+	if s.config.RemoteWrite {
+		s.httpServer.Handle(prometheus.WritePath, prometheus.NewRemoteWriteHandler(s.metricSvc, s.config.RemoteWriteLimit, s.logger))
+	}
+	if s.config.MetricsExporter {
+		s.startMetricsExporter()
+	}
 	s.wg.Add(1)
 	go func() {
 		defer s.wg.Done()
@@ -189,68 +420,66 @@ func (s *Server) Start(ctx context.Context) error {
 		}
 	}()
 
+	// Start OTLP/HTTP receiver for traces and logs
+	if s.config.OTLPEnabled {
+		s.otlpServer = otlp.NewReceiver(s.config.OTLPHost, s.config.OTLPPort, s.traceSvc, s.logSvc, s.logger)
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.logger.Info("OTLP receiver starting", "addr", s.otlpServer.Addr())
+			if err := s.otlpServer.Start(); err != nil && err != http.ErrServerClosed {
+				s.logger.Error("OTLP receiver error", "error", err)
+			}
+		}()
+	}
+
 	// Start task workers
 	s.taskSvc.StartWorkers(ctx, s.config.WorkerCount)
 
 	// Start metric flusher
 	s.metricSvc.Start(ctx, time.Second)
 
-	// Start accepting connections
-	s.wg.Add(1)
-	go s.acceptConnections(ctx)
+	// Record host metrics
+	if s.systemColl != nil {
+		s.systemColl.Start(ctx)
+	}
 
-	// Start background downsampling job
-	s.wg.Add(1)
-	go s.runDownsamplingJob(ctx)
+	// Reload log parsers and log-to-metric rules periodically
+	s.logSvc.Start(ctx, logRuleRefreshInterval)
 
-	return nil
-}
+	// Decide sampled traces and enforce trace retention
+	s.traceSvc.Start(ctx)
 
-// runDownsamplingJob runs periodic downsampling of old metrics.
-// Retention policies from ForgePlatform.md:
-// - Raw data: 7 days -> downsample to 1m
-// - 1-minute aggregates: 30 days -> downsample to 1h
-// - 1-hour aggregates: 1 year (no further downsampling)
-func (s *Server) runDownsamplingJob(ctx context.Context) {
-	defer s.wg.Done()
-
-	// Run downsampling every hour
-	ticker := time.NewTicker(time.Hour)
-	defer ticker.Stop()
-
-	// Run once at startup after a short delay
-	startupDelay := time.NewTimer(5 * time.Minute)
-	defer startupDelay.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-s.stopCh:
-			return
-		case <-startupDelay.C:
-			s.runDownsampling(ctx)
-		case <-ticker.C:
-			s.runDownsampling(ctx)
-		}
+	// Load the plugins installed before the last shutdown
+	if s.pluginRT != nil {
+		s.loadStoredPlugins(ctx)
 	}
-}
-
-// runDownsampling performs the actual downsampling operations.
-func (s *Server) runDownsampling(ctx context.Context) {
-	s.logger.Info("Starting scheduled downsampling...")
 
-	// Downsample raw metrics older than 7 days to 1-minute resolution
-	if err := s.metricSvc.Downsample(ctx, 7*24*time.Hour, "1m"); err != nil {
-		s.logger.Error("Failed to downsample raw metrics", "error", err)
+	// Start plugin tick scheduler
+	if s.pluginSched != nil {
+		s.pluginSched.Start(ctx)
 	}
 
-	// Clean up old aggregated metrics based on retention policies
-	if err := s.metricSvc.CleanupAggregated(ctx); err != nil {
-		s.logger.Error("Failed to cleanup aggregated metrics", "error", err)
+	// Watch plugin binaries for changes
+	if s.config.WatchPlugins && s.pluginRT != nil {
+		watcher, err := wasm.NewPluginWatcher(s.pluginRT, s.logger, wasm.DefaultReloadDebounce)
+		if err != nil {
+			s.logger.Warn("Plugin watch mode disabled", "error", err)
+		} else {
+			s.pluginWatch = watcher
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				watcher.Run(ctx, 5*time.Second)
+			}()
+		}
 	}
 
-	s.logger.Info("Scheduled downsampling completed")
+	// Start accepting connections
+	s.wg.Add(1)
+	go s.acceptConnections(ctx)
+
+	return nil
 }
 
 // Stop gracefully stops the daemon.
@@ -274,10 +503,30 @@ func (s *Server) Stop(ctx context.Context) error {
 			s.logger.Error("HTTP server shutdown error", "error", err)
 		}
 	}
+	if s.otlpServer != nil {
+		if err := s.otlpServer.Shutdown(ctx); err != nil {
+			s.logger.Error("OTLP receiver shutdown error", "error", err)
+		}
+	}
+	s.shutdownMetricsExporter(ctx)
 
 	// Stop services
 	s.taskSvc.StopWorkers()
+	s.traceSvc.Stop(ctx)
+	if s.systemColl != nil {
+		s.systemColl.Stop()
+	}
 	s.metricSvc.Stop(ctx)
+	s.logSvc.Stop()
+	if s.pluginSched != nil {
+		s.pluginSched.Stop()
+	}
+	if s.pluginWatch != nil {
+		_ = s.pluginWatch.Close()
+	}
+	if s.pluginRT != nil {
+		s.syncStoredPlugins(ctx)
+	}
 
 	// Close listener
 	if s.listener != nil {
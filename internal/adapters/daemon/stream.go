@@ -0,0 +1,494 @@
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+	"github.com/google/uuid"
+)
+
+// logTailBufferSize is the number of entries buffered per tail subscriber
+// before new entries are dropped.
+const logTailBufferSize = 256
+
+// streamWriteTimeout bounds how long a push may block on a stalled client.
+const streamWriteTimeout = 10 * time.Second
+
+// profileExportChunkSize is the number of raw pprof bytes sent per message.
+const profileExportChunkSize = 64 * 1024
+
+// metricExportChunkSize is the number of points sent per metric.export
+// message. A series with more points is split across several messages.
+const metricExportChunkSize = 1000
+
+// ping answers the ping method: the server time and a sequence number that
+// increases with every ping and heartbeat the daemon sends.
+func (s *Server) ping() map[string]interface{} {
+	return map[string]interface{}{
+		"time": time.Now().UTC().Format(time.RFC3339Nano),
+		"seq":  s.pingSeq.Add(1),
+	}
+}
+
+// heartbeat returns a keep-alive message for a streaming connection. It is
+// a ping that also tells the client how often to expect one.
+func (s *Server) heartbeat() map[string]interface{} {
+	msg := s.ping()
+	msg["heartbeat"] = true
+	msg["interval_ms"] = s.config.StreamHeartbeat.Milliseconds()
+	return msg
+}
+
+// heartbeatTicker returns a channel that fires every StreamHeartbeat, or a
+// nil channel when heartbeats are disabled, and a function to stop it.
+func (s *Server) heartbeatTicker() (<-chan time.Time, func()) {
+	if s.config.StreamHeartbeat <= 0 {
+		return nil, func() {}
+	}
+	ticker := time.NewTicker(s.config.StreamHeartbeat)
+	return ticker.C, ticker.Stop
+}
+
+// isStreamingMethod reports whether method switches the connection into
+// streaming mode.
+func isStreamingMethod(method string) bool {
+	switch method {
+	case "log.tail", "profile.export", "metric.export", "ai.chat.stream":
+		return true
+	}
+	return false
+}
+
+// handleStream serves a streaming request on conn.
+func (s *Server) handleStream(ctx context.Context, conn net.Conn, reader *bufio.Reader, req *Request) {
+	switch req.Method {
+	case "log.tail":
+		s.streamLogTail(ctx, conn, reader, req)
+	case "profile.export":
+		s.streamProfileExport(ctx, conn, req)
+	case "metric.export":
+		s.streamMetricExport(ctx, conn, req)
+	case "ai.chat.stream":
+		s.streamAIChat(ctx, conn, reader, req)
+	}
+}
+
+// streamLogTail answers a log.tail request and then pushes matching log
+// entries as newline-delimited Response messages carrying the request ID
+// until the client disconnects or the server stops.
+func (s *Server) streamLogTail(ctx context.Context, conn net.Conn, reader *bufio.Reader, req *Request) {
+	if s.logSvc == nil {
+		s.sendError(conn, req.ID, "log service not available")
+		return
+	}
+
+	filter, err := logFilterFromParams(req.Params)
+	if err != nil {
+		s.sendError(conn, req.ID, err.Error())
+		return
+	}
+
+	entries, cancel := s.logSvc.Subscribe(filter, logTailBufferSize)
+	defer cancel()
+
+	if err := writeResponse(conn, Response{ID: req.ID, Result: map[string]interface{}{"streaming": true}}); err != nil {
+		return
+	}
+
+	// Any further input (or EOF) from the client ends the stream
+	disconnected := make(chan struct{})
+	go func() {
+		defer close(disconnected)
+		_, _ = reader.ReadBytes('\n')
+	}()
+
+	s.logger.Debug("log tail started", "request_id", req.ID)
+	defer s.logger.Debug("log tail stopped", "request_id", req.ID)
+
+	// Heartbeats keep quiet tails alive and detect clients that went away
+	heartbeat, stopHeartbeat := s.heartbeatTicker()
+	defer stopHeartbeat()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.stopCh:
+			return
+		case <-disconnected:
+			return
+		case <-heartbeat:
+			_ = conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+			if err := writeResponse(conn, Response{ID: req.ID, Result: s.heartbeat()}); err != nil {
+				return
+			}
+		case entry, ok := <-entries:
+			if !ok {
+				return
+			}
+			msg := Response{ID: req.ID, Result: map[string]interface{}{"log": s.logEntryToMap(entry)}}
+			_ = conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+			if err := writeResponse(conn, msg); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// streamProfileExport answers a profile.export request by sending the raw
+// pprof bytes of a completed profile as a sequence of {"data": <base64>}
+// messages, followed by a final message with "done" set, the total size and
+// a SHA-256 of the data so the client can verify what it wrote.
+func (s *Server) streamProfileExport(ctx context.Context, conn net.Conn, req *Request) {
+	if s.profileSvc == nil {
+		s.sendError(conn, req.ID, "profile service not configured")
+		return
+	}
+
+	idStr, _ := req.Params["id"].(string)
+	if idStr == "" {
+		s.sendError(conn, req.ID, "id is required")
+		return
+	}
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		s.sendError(conn, req.ID, fmt.Sprintf("invalid id: %v", err))
+		return
+	}
+
+	profile, data, err := s.profileSvc.OpenProfileData(ctx, id)
+	if err != nil {
+		s.sendError(conn, req.ID, err.Error())
+		return
+	}
+	defer data.Close()
+
+	if err := writeResponse(conn, Response{ID: req.ID, Result: map[string]interface{}{"streaming": true}}); err != nil {
+		return
+	}
+
+	hash := sha256.New()
+	buf := make([]byte, profileExportChunkSize)
+	var size int64
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+		n, readErr := data.Read(buf)
+		if n > 0 {
+			hash.Write(buf[:n])
+			size += int64(n)
+			_ = conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+			if err := writeResponse(conn, Response{ID: req.ID, Result: map[string]interface{}{"data": buf[:n]}}); err != nil {
+				return
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			s.sendError(conn, req.ID, fmt.Sprintf("failed to read profile data: %v", readErr))
+			return
+		}
+	}
+
+	_ = conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+	_ = writeResponse(conn, Response{ID: req.ID, Result: map[string]interface{}{
+		"done":       true,
+		"profile_id": profile.ID.String(),
+		"type":       string(profile.Type),
+		"size":       size,
+		"sha256":     hex.EncodeToString(hash.Sum(nil)),
+	}})
+	s.logger.Debug("exported profile", "profile_id", profile.ID, "size", size)
+}
+
+// streamMetricExport answers a metric.export request by streaming the raw
+// points of every series named name (optionally restricted by tags) between
+// start and end. Each message carries {"series": {name, type, tags, unit},
+// "points": [[unix_ms, value], ...]} for up to metricExportChunkSize points
+// of one series; a long series spans several messages with the same series.
+// A final message with "done" set reports the series and point counts.
+// Points are read from storage as they are sent, so large ranges are never
+// held in memory.
+func (s *Server) streamMetricExport(ctx context.Context, conn net.Conn, req *Request) {
+	if s.metricSvc == nil {
+		s.sendError(conn, req.ID, "metric service not available")
+		return
+	}
+
+	name, _ := req.Params["name"].(string)
+	if name == "" {
+		s.sendError(conn, req.ID, "name is required")
+		return
+	}
+	start, err := parseTimeParam(req.Params, "start")
+	if err != nil {
+		s.sendError(conn, req.ID, err.Error())
+		return
+	}
+	end, err := parseTimeParam(req.Params, "end")
+	if err != nil {
+		s.sendError(conn, req.ID, err.Error())
+		return
+	}
+	if end.IsZero() {
+		end = time.Now()
+	}
+	if start.IsZero() {
+		start = end.Add(-time.Hour)
+	}
+	if end.Before(start) {
+		s.sendError(conn, req.ID, "end must not be before start")
+		return
+	}
+	q := ports.MetricQuery{Name: name, StartTime: start, EndTime: end}
+	if tags, ok := req.Params["tags"].(map[string]interface{}); ok && len(tags) > 0 {
+		q.Tags = make(map[string]string, len(tags))
+		for k, v := range tags {
+			str, ok := v.(string)
+			if !ok {
+				s.sendError(conn, req.ID, fmt.Sprintf("tag %s must be a string", k))
+				return
+			}
+			q.Tags[k] = str
+		}
+	}
+
+	if err := writeResponse(conn, Response{ID: req.ID, Result: map[string]interface{}{"streaming": true}}); err != nil {
+		return
+	}
+
+	var (
+		current     *domain.MetricSeries
+		points      [][2]interface{}
+		seriesCount int
+		pointCount  int
+	)
+	flush := func() error {
+		if len(points) == 0 {
+			return nil
+		}
+		msg := map[string]interface{}{
+			"series": metricExportSeries(current),
+			"points": points,
+		}
+		points = nil
+		_ = conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		return writeResponse(conn, Response{ID: req.ID, Result: msg})
+	}
+
+	err = s.metricSvc.Export(ctx, q, func(series *domain.MetricSeries, point domain.MetricPoint) error {
+		if series != current {
+			if err := flush(); err != nil {
+				return err
+			}
+			current = series
+			seriesCount++
+		}
+		points = append(points, [2]interface{}{point.Timestamp.UnixMilli(), point.Value})
+		pointCount++
+		if len(points) >= metricExportChunkSize {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		s.sendError(conn, req.ID, fmt.Sprintf("failed to export metrics: %v", err))
+		return
+	}
+
+	_ = conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+	_ = writeResponse(conn, Response{ID: req.ID, Result: map[string]interface{}{
+		"done":   true,
+		"series": seriesCount,
+		"points": pointCount,
+	}})
+	s.logger.Debug("exported metrics", "name", name, "series", seriesCount, "points", pointCount)
+}
+
+// metricExportSeries describes a series in a metric.export message.
+func metricExportSeries(series *domain.MetricSeries) map[string]interface{} {
+	tags := series.Tags
+	if tags == nil {
+		tags = map[string]string{}
+	}
+	m := map[string]interface{}{
+		"name": series.Name,
+		"type": string(series.Type),
+		"tags": tags,
+	}
+	if series.Unit != "" {
+		m["unit"] = series.Unit
+	}
+	return m
+}
+
+// streamAIChat answers an ai.chat.stream request. It takes the same params
+// as ai.chat and pushes each piece of the reply as a {"chunk": ...} message
+// as the model produces it, then a final message with "done" set, the full
+// content and the conversation_id. If the client disconnects or sends
+// anything, generation is cancelled and the turn is not saved.
+func (s *Server) streamAIChat(ctx context.Context, conn net.Conn, reader *bufio.Reader, req *Request) {
+	if s.aiProvider == nil {
+		s.sendError(conn, req.ID, "AI provider not configured")
+		return
+	}
+
+	conv, isNew, err := s.startConversationTurn(ctx, req.Params, "message", aiChatSystemPrompt)
+	if err != nil {
+		s.sendError(conn, req.ID, err.Error())
+		return
+	}
+
+	if err := writeResponse(conn, Response{ID: req.ID, Result: map[string]interface{}{"streaming": true}}); err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		_, _ = reader.ReadBytes('\n')
+		cancel()
+	}()
+	go func() {
+		select {
+		case <-s.stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	// A failed write means the client is gone; stop generating. Heartbeats
+	// cover a model that is slow to produce its first chunk.
+	var (
+		writeMu  sync.Mutex
+		writeErr error
+	)
+	push := func(result map[string]interface{}) {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		if writeErr != nil {
+			return
+		}
+		_ = conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		if writeErr = writeResponse(conn, Response{ID: req.ID, Result: result}); writeErr != nil {
+			cancel()
+		}
+	}
+	heartbeat, stopHeartbeat := s.heartbeatTicker()
+	generated := make(chan struct{})
+	heartbeatDone := make(chan struct{})
+	go func() {
+		defer close(heartbeatDone)
+		defer stopHeartbeat()
+		for {
+			select {
+			case <-heartbeat:
+				push(s.heartbeat())
+			case <-generated:
+				return
+			}
+		}
+	}()
+
+	response, err := s.aiProvider.ChatStream(ctx, conv, func(chunk string) {
+		if chunk != "" {
+			push(map[string]interface{}{"chunk": chunk})
+		}
+	})
+	close(generated)
+	<-heartbeatDone
+	if ctx.Err() != nil {
+		s.logger.Debug("AI chat stream cancelled", "request_id", req.ID)
+		return
+	}
+	if err != nil {
+		s.sendError(conn, req.ID, fmt.Sprintf("AI error: %v", err))
+		return
+	}
+
+	if err := s.finishConversationTurn(ctx, conv, response, isNew); err != nil {
+		s.sendError(conn, req.ID, err.Error())
+		return
+	}
+
+	_ = conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+	_ = writeResponse(conn, Response{ID: req.ID, Result: map[string]interface{}{
+		"done":            true,
+		"content":         response.Content,
+		"conversation_id": conv.ID.String(),
+	}})
+}
+
+// logFilterFromParams builds the non-time fields of a log filter from
+// request params. It is shared by live tails and log queries.
+func logFilterFromParams(params map[string]interface{}) (ports.LogFilter, error) {
+	var filter ports.LogFilter
+
+	if level, ok := params["level"].(string); ok && level != "" {
+		parsed, err := domain.ParseLogLevel(level)
+		if err != nil {
+			return filter, err
+		}
+		filter.Level = parsed
+	}
+	if minLevel, ok := params["min_level"].(string); ok && minLevel != "" {
+		parsed, err := domain.ParseLogLevel(minLevel)
+		if err != nil {
+			return filter, err
+		}
+		filter.MinLevel = parsed
+	}
+	if filter.Level != "" && filter.MinLevel != "" {
+		return filter, fmt.Errorf("level and min_level cannot be combined")
+	}
+	if service, ok := params["service_name"].(string); ok && service != "" {
+		filter.ServiceName = service
+	}
+	if source, ok := params["source"].(string); ok && source != "" {
+		filter.Source = source
+	}
+	if traceID, ok := params["trace_id"].(string); ok && traceID != "" {
+		filter.TraceID = traceID
+	}
+	if search, ok := params["search"].(string); ok && search != "" {
+		filter.Search = search
+	}
+	if attrs, ok := params["attributes"].(map[string]interface{}); ok {
+		filter.Attributes = make(map[string]string, len(attrs))
+		for k, v := range attrs {
+			str, ok := v.(string)
+			if !ok {
+				return filter, fmt.Errorf("attribute %q must be a string", k)
+			}
+			filter.Attributes[k] = str
+		}
+	}
+
+	return filter, nil
+}
+
+// writeResponse writes a single newline-delimited response.
+func writeResponse(conn net.Conn, resp Response) error {
+	respBytes, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("failed to marshal response: %w", err)
+	}
+	respBytes = append(respBytes, '\n')
+	_, err = conn.Write(respBytes)
+	return err
+}
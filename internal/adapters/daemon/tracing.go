@@ -0,0 +1,66 @@
+package daemon
+
+import (
+	"context"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+)
+
+// selfTraceService is the service name under which the daemon records its own RPC spans.
+const selfTraceService = "forge-daemon"
+
+// dispatchRequest handles a request, counting it in the RPC stats.
+func (s *Server) dispatchRequest(ctx context.Context, req *Request) (interface{}, error) {
+	start := time.Now()
+	result, err := s.traceRequest(ctx, req)
+	if s.rpcStats != nil {
+		s.rpcStats.observe(req.Method, time.Since(start), err)
+	}
+	return result, err
+}
+
+// traceRequest handles a request, recording a server span for it in the
+// trace store when self-tracing is enabled. Keep-alive pings are not traced,
+// nor are calls that fail authorization, so unauthenticated callers cannot
+// write to the trace store.
+func (s *Server) traceRequest(ctx context.Context, req *Request) (interface{}, error) {
+	if !s.config.SelfTracing || s.traceSvc == nil || req.Method == "ping" {
+		return s.handleRequest(ctx, req)
+	}
+	if err := s.authorize(ctx, req.Method, req.Params); err != nil {
+		return nil, err
+	}
+
+	trace, err := s.traceSvc.StartTrace(ctx, selfTraceService, req.Method)
+	if err != nil {
+		s.logger.Debug("Failed to start self trace", "method", req.Method, "error", err)
+		return s.routeRequest(ctx, req)
+	}
+
+	span, err := s.traceSvc.StartSpan(ctx, trace.TraceID, req.Method, domain.SpanKindServer, selfTraceService, nil)
+	if err != nil {
+		s.logger.Debug("Failed to start self span", "method", req.Method, "error", err)
+		_ = s.traceSvc.EndTrace(ctx, trace.TraceID)
+		return s.routeRequest(ctx, req)
+	}
+	span.ServiceVersion = Version
+	span.SetAttribute("rpc.system", "forge")
+	span.SetAttribute("rpc.method", req.Method)
+	if req.ID != "" {
+		span.SetAttribute("rpc.request_id", req.ID)
+	}
+
+	result, handleErr := s.routeRequest(ctx, req)
+	if handleErr != nil {
+		span.SetError(handleErr)
+	} else {
+		span.SetStatus(domain.SpanStatusOK, "")
+	}
+
+	// Recording must never affect the RPC outcome
+	_ = s.traceSvc.EndSpan(ctx, span)
+	_ = s.traceSvc.EndTrace(ctx, trace.TraceID)
+
+	return result, handleErr
+}
@@ -0,0 +1,125 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+)
+
+// Delivery defaults, overridable per channel.
+const (
+	defaultDeliveryTimeout    = 30 * time.Second
+	defaultDeliveryMaxRetries = 3
+	defaultDeliveryBackoff    = 500 * time.Millisecond
+	maxDeliveryBackoff        = 30 * time.Second
+)
+
+// deliveryPolicy is the timeout and retry behaviour of a channel, read from
+// its config keys:
+//   - timeout: per-attempt timeout, e.g. "10s" (default 30s)
+//   - max_retries: retries on transient failures (default 3)
+//   - retry_backoff: initial backoff, doubled per retry (default 500ms)
+type deliveryPolicy struct {
+	timeout    time.Duration
+	backoff    time.Duration
+	maxRetries int
+}
+
+// channelDeliveryPolicy reads the delivery policy from channel config.
+func channelDeliveryPolicy(channel *domain.NotificationChannel) (deliveryPolicy, error) {
+	timeout, err := durationConfig(channel.Config, "timeout", defaultDeliveryTimeout)
+	if err != nil {
+		return deliveryPolicy{}, err
+	}
+	backoff, err := durationConfig(channel.Config, "retry_backoff", defaultDeliveryBackoff)
+	if err != nil {
+		return deliveryPolicy{}, err
+	}
+	maxRetries := defaultDeliveryMaxRetries
+	if v := channel.Config["max_retries"]; v != "" {
+		maxRetries, err = strconv.Atoi(v)
+		if err != nil || maxRetries < 0 {
+			return deliveryPolicy{}, fmt.Errorf("invalid %s max_retries %q", channel.Type, v)
+		}
+	}
+	return deliveryPolicy{timeout: timeout, backoff: backoff, maxRetries: maxRetries}, nil
+}
+
+// durationConfig reads a positive duration from channel config.
+func durationConfig(config map[string]string, key string, def time.Duration) (time.Duration, error) {
+	v := config[key]
+	if v == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s %q", key, v)
+	}
+	return d, nil
+}
+
+// run calls attempt, each time with the policy's timeout, until it succeeds,
+// fails with a permanent error or the retries run out, backing off
+// exponentially in between. name prefixes the error after retries.
+func (p deliveryPolicy) run(ctx context.Context, name string, attempt func(ctx context.Context) (retryable bool, err error)) error {
+	backoff := p.backoff
+	for i := 0; ; i++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, p.timeout)
+		retryable, err := attempt(attemptCtx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if !retryable || i >= p.maxRetries {
+			if i > 0 {
+				return fmt.Errorf("%s failed after %d attempts: %w", name, i+1, err)
+			}
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s retry cancelled: %w", name, ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > maxDeliveryBackoff {
+			backoff = maxDeliveryBackoff
+		}
+	}
+}
+
+// postJSON makes a single JSON POST and reports whether a failure is worth
+// retrying: network errors, 5xx responses and 429 rate limiting are.
+func postJSON(ctx context.Context, client *http.Client, name, url string, body []byte, headers map[string]string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create %s request: %w", name, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("%s request failed: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		retryable := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return retryable, fmt.Errorf("%s returned error: %d - %s", name, resp.StatusCode, string(body))
+	}
+	// Drain so the connection can be reused
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	return false, nil
+}
@@ -4,26 +4,76 @@ package notifications
 import (
 	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	htmltemplate "html/template"
 	"io"
+	"mime"
+	"mime/multipart"
+	"net"
 	"net/http"
 	"net/smtp"
+	"net/textproto"
+	"sort"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/forge-platform/forge/internal/core/domain"
 )
 
+// WebhookSignatureHeader carries the HMAC-SHA256 of the request body, as
+// "sha256=<hex>", when a webhook channel configures hmac_secret.
+const WebhookSignatureHeader = "X-Forge-Signature"
+
 // WebhookNotifier sends alerts via HTTP webhooks.
+//
+// Channel config keys:
+//   - url: endpoint to POST to (required)
+//   - template: Go text/template rendering the JSON body, see WebhookTemplateData
+//   - headers: extra headers as "Name: value,Name2: value2" or a JSON object
+//   - auth_token: sent as a Bearer Authorization header
+//   - hmac_secret: signs the body, see WebhookSignatureHeader
+//   - timeout, max_retries, retry_backoff: see deliveryPolicy
 type WebhookNotifier struct {
 	client *http.Client
 }
 
+// WebhookTemplateData is the data available to a webhook body template.
+type WebhookTemplateData struct {
+	Alert   *domain.Alert
+	Rule    WebhookRule
+	Channel string
+}
+
+// WebhookRule identifies the rule that fired an alert.
+type WebhookRule struct {
+	ID   string
+	Name string
+}
+
+// webhookTemplateFuncs are available to webhook body templates. Use json to
+// embed strings safely, e.g. {"text": {{json .Alert.Message}}}.
+var webhookTemplateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"upper":   func(v interface{}) string { return strings.ToUpper(fmt.Sprint(v)) },
+	"lower":   func(v interface{}) string { return strings.ToLower(fmt.Sprint(v)) },
+	"rfc3339": func(t time.Time) string { return t.Format(time.RFC3339) },
+}
+
 // NewWebhookNotifier creates a new webhook notifier.
 func NewWebhookNotifier() *WebhookNotifier {
+	// Timeouts are applied per attempt from the channel config.
 	return &WebhookNotifier{
-		client: &http.Client{Timeout: 30 * time.Second},
+		client: &http.Client{},
 	}
 }
 
@@ -32,77 +82,129 @@ func (n *WebhookNotifier) Type() domain.NotificationChannelType {
 	return domain.ChannelWebhook
 }
 
-// Send sends an alert notification via webhook.
+// Send sends an alert notification via webhook, retrying with exponential
+// backoff when the receiver fails with a 5xx status or is unreachable.
 func (n *WebhookNotifier) Send(ctx context.Context, alert *domain.Alert, channel *domain.NotificationChannel) error {
 	url := channel.Config["url"]
 	if url == "" {
 		return fmt.Errorf("webhook URL not configured")
 	}
 
-	payload := map[string]interface{}{
-		"id":         alert.ID.String(),
-		"rule_id":    alert.RuleID.String(),
-		"rule_name":  alert.RuleName,
-		"state":      alert.State,
-		"severity":   alert.Severity,
-		"message":    alert.Message,
-		"value":      alert.Value,
-		"threshold":  alert.Threshold,
-		"labels":     alert.Labels,
-		"starts_at":  alert.StartsAt.Format(time.RFC3339),
-		"fingerprint": alert.Fingerprint,
+	policy, err := channelDeliveryPolicy(channel)
+	if err != nil {
+		return err
 	}
-
-	body, err := json.Marshal(payload)
+	body, err := n.buildBody(alert, channel)
 	if err != nil {
-		return fmt.Errorf("failed to marshal payload: %w", err)
+		return err
 	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	headers, err := parseWebhookHeaders(channel.Config["headers"])
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return err
+	}
+	if token := channel.Config["auth_token"]; token != "" {
+		headers["Authorization"] = "Bearer " + token
+	}
+	if secret := channel.Config["hmac_secret"]; secret != "" {
+		headers[WebhookSignatureHeader] = SignWebhookBody(secret, body)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	return policy.run(ctx, "webhook", func(ctx context.Context) (bool, error) {
+		return postJSON(ctx, n.client, "webhook", url, body, headers)
+	})
+}
 
-	// Add custom headers if configured
-	if headers := channel.Config["headers"]; headers != "" {
-		for _, h := range strings.Split(headers, ",") {
-			parts := strings.SplitN(h, ":", 2)
-			if len(parts) == 2 {
-				req.Header.Set(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
-			}
-		}
-	}
+// SignWebhookBody returns the WebhookSignatureHeader value for body, so
+// receivers can verify it with the shared secret.
+func SignWebhookBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
 
-	// Add auth if configured
-	if token := channel.Config["auth_token"]; token != "" {
-		req.Header.Set("Authorization", "Bearer "+token)
+// buildBody renders the channel template for the alert's severity, or the
+// default payload when the channel has none.
+func (n *WebhookNotifier) buildBody(alert *domain.Alert, channel *domain.NotificationChannel) ([]byte, error) {
+	text := channel.TemplateFor(alert.Severity)
+	if text == "" {
+		payload := map[string]interface{}{
+			"id":          alert.ID.String(),
+			"rule_id":     alert.RuleID.String(),
+			"rule_name":   alert.RuleName,
+			"state":       alert.State,
+			"severity":    alert.Severity,
+			"message":     alert.Message,
+			"value":       alert.Value,
+			"threshold":   alert.Threshold,
+			"labels":      alert.Labels,
+			"starts_at":   alert.StartsAt.Format(time.RFC3339),
+			"fingerprint": alert.Fingerprint,
+		}
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal payload: %w", err)
+		}
+		return body, nil
 	}
 
-	resp, err := n.client.Do(req)
+	tmpl, err := template.New("webhook").Funcs(webhookTemplateFuncs).Option("missingkey=error").Parse(text)
 	if err != nil {
-		return fmt.Errorf("webhook request failed: %w", err)
+		return nil, fmt.Errorf("failed to parse webhook template: %w", err)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("webhook returned error: %d - %s", resp.StatusCode, string(body))
+	data := WebhookTemplateData{
+		Alert:   alert,
+		Rule:    WebhookRule{ID: alert.RuleID.String(), Name: alert.RuleName},
+		Channel: channel.Name,
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render webhook template: %w", err)
 	}
+	if !json.Valid(buf.Bytes()) {
+		return nil, fmt.Errorf("webhook template did not render valid JSON: %s", buf.String())
+	}
+	return buf.Bytes(), nil
+}
 
-	return nil
+// parseWebhookHeaders accepts either "Name: value" pairs separated by commas
+// or a JSON object, which allows values containing commas.
+func parseWebhookHeaders(spec string) (map[string]string, error) {
+	headers := make(map[string]string)
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return headers, nil
+	}
+	if strings.HasPrefix(spec, "{") {
+		if err := json.Unmarshal([]byte(spec), &headers); err != nil {
+			return nil, fmt.Errorf("invalid webhook headers: %w", err)
+		}
+		return headers, nil
+	}
+	for _, h := range strings.Split(spec, ",") {
+		parts := strings.SplitN(h, ":", 2)
+		if len(parts) == 2 {
+			headers[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+	}
+	return headers, nil
 }
 
-// SlackNotifier sends alerts to Slack.
+// SlackNotifier sends alerts to Slack incoming webhooks as Block Kit
+// messages, colored by severity.
+//
+// Channel config keys:
+//   - webhook_url (or url): incoming webhook URL (required)
+//   - channel: overrides the webhook's default channel
+//   - timeout, max_retries, retry_backoff: see deliveryPolicy
 type SlackNotifier struct {
 	client *http.Client
 }
 
 // NewSlackNotifier creates a new Slack notifier.
 func NewSlackNotifier() *SlackNotifier {
+	// Timeouts are applied per attempt from the channel config.
 	return &SlackNotifier{
-		client: &http.Client{Timeout: 30 * time.Second},
+		client: &http.Client{},
 	}
 }
 
@@ -111,57 +213,68 @@ func (n *SlackNotifier) Type() domain.NotificationChannelType {
 	return domain.ChannelSlack
 }
 
-// Send sends an alert notification to Slack.
+// Send sends an alert notification to Slack, retrying like the webhook
+// notifier.
 func (n *SlackNotifier) Send(ctx context.Context, alert *domain.Alert, channel *domain.NotificationChannel) error {
-	webhookURL := channel.Config["webhook_url"]
+	webhookURL := channel.SlackWebhookURL()
 	if webhookURL == "" {
 		return fmt.Errorf("Slack webhook URL not configured")
 	}
-
-	// Build Slack message
-	color := n.getSeverityColor(alert.Severity)
-	payload := map[string]interface{}{
-		"attachments": []map[string]interface{}{
-			{
-				"color":  color,
-				"title":  fmt.Sprintf("[%s] %s", strings.ToUpper(string(alert.Severity)), alert.RuleName),
-				"text":   alert.Message,
-				"fields": n.buildFields(alert),
-				"ts":     alert.StartsAt.Unix(),
-			},
-		},
-	}
-
-	if alertChannel := channel.Config["channel"]; alertChannel != "" {
-		payload["channel"] = alertChannel
+	policy, err := channelDeliveryPolicy(channel)
+	if err != nil {
+		return err
 	}
 
-	body, err := json.Marshal(payload)
+	body, err := json.Marshal(n.buildPayload(alert, channel))
 	if err != nil {
 		return fmt.Errorf("failed to marshal Slack payload: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", webhookURL, bytes.NewReader(body))
-	if err != nil {
-		return fmt.Errorf("failed to create Slack request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
+	return policy.run(ctx, "Slack", func(ctx context.Context) (bool, error) {
+		return postJSON(ctx, n.client, "Slack", webhookURL, body, nil)
+	})
+}
 
-	resp, err := n.client.Do(req)
-	if err != nil {
-		return fmt.Errorf("Slack request failed: %w", err)
+// buildPayload builds the Slack message. The blocks sit in an attachment so
+// that Slack draws the severity color beside them; text is the fallback
+// shown in notifications.
+func (n *SlackNotifier) buildPayload(alert *domain.Alert, channel *domain.NotificationChannel) map[string]interface{} {
+	title := fmt.Sprintf("[%s] %s", strings.ToUpper(string(alert.Severity)), alert.RuleName)
+	blocks := []map[string]interface{}{
+		{"type": "header", "text": map[string]interface{}{"type": "plain_text", "text": title}},
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("Slack returned error: %d - %s", resp.StatusCode, string(body))
+	if alert.Message != "" {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "section",
+			"text": map[string]interface{}{"type": "mrkdwn", "text": alert.Message},
+		})
+	}
+	blocks = append(blocks, map[string]interface{}{"type": "section", "fields": n.buildFields(alert)})
+	if labels := formatLabels(alert.Labels, "`%s=%s`"); labels != "" {
+		blocks = append(blocks, map[string]interface{}{
+			"type":     "context",
+			"elements": []map[string]interface{}{{"type": "mrkdwn", "text": labels}},
+		})
 	}
 
-	return nil
+	payload := map[string]interface{}{
+		"text": title + ": " + alert.Message,
+		"attachments": []map[string]interface{}{
+			{"color": n.getSeverityColor(alert.Severity), "blocks": blocks},
+		},
+	}
+	if alertChannel := channel.Config["channel"]; alertChannel != "" {
+		payload["channel"] = alertChannel
+	}
+	return payload
 }
 
 func (n *SlackNotifier) getSeverityColor(severity domain.AlertSeverity) string {
+	return severityColor(severity)
+}
+
+// severityColor returns the display color for an alert severity.
+func severityColor(severity domain.AlertSeverity) string {
 	switch severity {
 	case domain.AlertSeverityCritical:
 		return "#dc3545" // red
@@ -173,16 +286,41 @@ func (n *SlackNotifier) getSeverityColor(severity domain.AlertSeverity) string {
 }
 
 func (n *SlackNotifier) buildFields(alert *domain.Alert) []map[string]interface{} {
-	fields := []map[string]interface{}{
-		{"title": "State", "value": string(alert.State), "short": true},
-		{"title": "Severity", "value": string(alert.Severity), "short": true},
-		{"title": "Value", "value": fmt.Sprintf("%.2f", alert.Value), "short": true},
-		{"title": "Threshold", "value": fmt.Sprintf("%.2f", alert.Threshold), "short": true},
+	field := func(title, value string) map[string]interface{} {
+		return map[string]interface{}{"type": "mrkdwn", "text": "*" + title + "*\n" + value}
+	}
+	return []map[string]interface{}{
+		field("State", string(alert.State)),
+		field("Severity", string(alert.Severity)),
+		field("Value", fmt.Sprintf("%.2f", alert.Value)),
+		field("Threshold", fmt.Sprintf("%.2f", alert.Threshold)),
 	}
-	return fields
 }
 
-// EmailNotifier sends alerts via email.
+// formatLabels renders labels sorted by name, each with format, separated
+// by spaces.
+func formatLabels(labels map[string]string, format string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf(format, name, labels[name])
+	}
+	return strings.Join(parts, " ")
+}
+
+// EmailNotifier sends alerts via SMTP as a message with plain-text and HTML
+// alternatives. STARTTLS is used when the server offers it.
+//
+// Channel config keys:
+//   - smtp_host, from, to: server and addresses, to comma-separated (required)
+//   - smtp_port: server port (default 587)
+//   - username, password: PLAIN auth credentials
+//   - timeout, max_retries, retry_backoff: see deliveryPolicy; connection
+//     errors and 4xx replies are retried, 5xx replies are not
 type EmailNotifier struct{}
 
 // NewEmailNotifier creates a new email notifier.
@@ -207,13 +345,98 @@ func (n *EmailNotifier) Send(ctx context.Context, alert *domain.Alert, channel *
 	if smtpHost == "" || from == "" || to == "" {
 		return fmt.Errorf("email configuration incomplete: need smtp_host, from, to")
 	}
-
 	if smtpPort == "" {
 		smtpPort = "587"
 	}
+	policy, err := channelDeliveryPolicy(channel)
+	if err != nil {
+		return err
+	}
 
-	subject := fmt.Sprintf("[%s] Alert: %s", strings.ToUpper(string(alert.Severity)), alert.RuleName)
-	body := fmt.Sprintf(`Alert Notification
+	var recipients []string
+	for _, addr := range strings.Split(to, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			recipients = append(recipients, addr)
+		}
+	}
+	msg, err := buildEmailMessage(alert, from, recipients)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if username != "" && password != "" {
+		auth = smtp.PlainAuth("", username, password, smtpHost)
+	}
+
+	addr := net.JoinHostPort(smtpHost, smtpPort)
+	return policy.run(ctx, "email", func(ctx context.Context) (bool, error) {
+		return sendMail(ctx, addr, smtpHost, auth, from, recipients, msg)
+	})
+}
+
+// sendMail makes a single delivery attempt, like smtp.SendMail but bounded
+// by ctx, and reports whether a failure is worth retrying.
+func sendMail(ctx context.Context, addr, host string, auth smtp.Auth, from string, to []string, msg []byte) (bool, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return true, fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return smtpRetryable(err), fmt.Errorf("SMTP handshake failed: %w", err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return false, fmt.Errorf("SMTP STARTTLS failed: %w", err)
+		}
+	}
+	if auth != nil {
+		if err := c.Auth(auth); err != nil {
+			return smtpRetryable(err), fmt.Errorf("SMTP auth failed: %w", err)
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return smtpRetryable(err), fmt.Errorf("SMTP MAIL FROM failed: %w", err)
+	}
+	for _, rcpt := range to {
+		if err := c.Rcpt(rcpt); err != nil {
+			return smtpRetryable(err), fmt.Errorf("SMTP RCPT TO %s failed: %w", rcpt, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return smtpRetryable(err), fmt.Errorf("SMTP DATA failed: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return true, fmt.Errorf("failed to write email: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return smtpRetryable(err), fmt.Errorf("SMTP server rejected email: %w", err)
+	}
+	_ = c.Quit()
+	return false, nil
+}
+
+// smtpRetryable reports whether an SMTP failure is transient: a 4xx reply
+// or a connection problem rather than a permanent 5xx rejection.
+func smtpRetryable(err error) bool {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		return protoErr.Code >= 400 && protoErr.Code < 500
+	}
+	return true
+}
+
+const emailTextBody = `Alert Notification
 
 Rule: %s
 State: %s
@@ -226,27 +449,79 @@ Threshold: %.2f
 
 Started At: %s
 Fingerprint: %s
-`,
+`
+
+var emailHTMLBody = htmltemplate.Must(htmltemplate.New("email").Parse(`<html><body>
+<h2 style="color: {{.Color}}">[{{.Severity}}] {{.Alert.RuleName}}</h2>
+<p>{{.Alert.Message}}</p>
+<table>
+<tr><td><b>State</b></td><td>{{.Alert.State}}</td></tr>
+<tr><td><b>Value</b></td><td>{{printf "%.2f" .Alert.Value}}</td></tr>
+<tr><td><b>Threshold</b></td><td>{{printf "%.2f" .Alert.Threshold}}</td></tr>
+<tr><td><b>Started At</b></td><td>{{.StartsAt}}</td></tr>
+{{range $name, $value := .Alert.Labels}}<tr><td><b>{{$name}}</b></td><td>{{$value}}</td></tr>
+{{end}}</table>
+<p style="color: #888">Fingerprint: {{.Alert.Fingerprint}}</p>
+</body></html>
+`))
+
+// buildEmailMessage builds a multipart/alternative message with plain-text
+// and HTML bodies.
+func buildEmailMessage(alert *domain.Alert, from string, to []string) ([]byte, error) {
+	startsAt := alert.StartsAt.Format(time.RFC3339)
+	text := fmt.Sprintf(emailTextBody,
 		alert.RuleName,
 		alert.State,
 		alert.Severity,
 		alert.Message,
 		alert.Value,
 		alert.Threshold,
-		alert.StartsAt.Format(time.RFC3339),
+		startsAt,
 		alert.Fingerprint,
 	)
+	if labels := formatLabels(alert.Labels, "%s=%s"); labels != "" {
+		text += "Labels: " + labels + "\n"
+	}
 
-	msg := []byte(fmt.Sprintf("To: %s\r\nSubject: %s\r\n\r\n%s", to, subject, body))
-
-	addr := smtpHost + ":" + smtpPort
+	var html bytes.Buffer
+	err := emailHTMLBody.Execute(&html, map[string]interface{}{
+		"Alert":    alert,
+		"Severity": strings.ToUpper(string(alert.Severity)),
+		"Color":    severityColor(alert.Severity),
+		"StartsAt": startsAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render email: %w", err)
+	}
 
-	var auth smtp.Auth
-	if username != "" && password != "" {
-		auth = smtp.PlainAuth("", username, password, smtpHost)
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", text},
+		{"text/html; charset=utf-8", html.String()},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {part.contentType}})
+		if err != nil {
+			return nil, fmt.Errorf("failed to build email: %w", err)
+		}
+		if _, err := io.WriteString(w, part.content); err != nil {
+			return nil, fmt.Errorf("failed to build email: %w", err)
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to build email: %w", err)
 	}
 
-	return smtp.SendMail(addr, auth, from, strings.Split(to, ","), msg)
+	subject := fmt.Sprintf("[%s] Alert: %s", strings.ToUpper(string(alert.Severity)), alert.RuleName)
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", mw.Boundary())
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
 }
 
 // PagerDutyNotifier sends alerts to PagerDuty.
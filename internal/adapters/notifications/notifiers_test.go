@@ -2,7 +2,22 @@
 package notifications
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/forge-platform/forge/internal/core/domain"
 )
@@ -24,6 +39,190 @@ func TestWebhookNotifier_Type(t *testing.T) {
 	}
 }
 
+func webhookAlert() *domain.Alert {
+	rule := domain.NewAlertRule("high-cpu", "cpu_usage", domain.ConditionThresholdAbove, 90.0, domain.AlertSeverityCritical)
+	return domain.NewAlert(rule, 97.5, `CPU "hot" on web-1`)
+}
+
+func webhookChannel(url string, config map[string]string) *domain.NotificationChannel {
+	channel := &domain.NotificationChannel{Name: "ops", Type: domain.ChannelWebhook, Config: map[string]string{"url": url}}
+	for k, v := range config {
+		channel.Config[k] = v
+	}
+	return channel
+}
+
+func TestWebhookNotifier_Template(t *testing.T) {
+	var body []byte
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		header = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	alert := webhookAlert()
+	channel := webhookChannel(server.URL, map[string]string{
+		"template":   `{"title": "{{upper .Alert.Severity}}: {{.Rule.Name}}", "text": {{json .Alert.Message}}, "value": {{.Alert.Value}}, "channel": "{{.Channel}}"}`,
+		"headers":    `{"X-Api-Key": "k1,k2"}`,
+		"auth_token": "secret",
+	})
+	if err := NewWebhookNotifier().Send(t.Context(), alert, channel); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("expected JSON body, got %s", body)
+	}
+	if got["title"] != "CRITICAL: high-cpu" || got["text"] != alert.Message || got["value"] != 97.5 || got["channel"] != "ops" {
+		t.Errorf("unexpected rendered body: %s", body)
+	}
+	if header.Get("X-Api-Key") != "k1,k2" || header.Get("Authorization") != "Bearer secret" {
+		t.Errorf("expected custom headers, got %v", header)
+	}
+	if header.Get("Content-Type") != "application/json" {
+		t.Errorf("expected JSON content type, got %q", header.Get("Content-Type"))
+	}
+}
+
+func TestWebhookNotifier_SeverityTemplate(t *testing.T) {
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = nil
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer server.Close()
+
+	channel := webhookChannel(server.URL, map[string]string{
+		"template.critical": `{"text": "PAGE: {{.Rule.Name}} at {{.Alert.Value}}"}`,
+		"template.info":     `{"text": "fyi {{.Rule.Name}}"}`,
+	})
+
+	critical := webhookAlert()
+	if err := NewWebhookNotifier().Send(t.Context(), critical, channel); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if got["text"] != "PAGE: high-cpu at 97.5" {
+		t.Errorf("expected critical template, got %v", got)
+	}
+
+	infoRule := domain.NewAlertRule("deploy", "deploys", domain.ConditionThresholdAbove, 0, domain.AlertSeverityInfo)
+	info := domain.NewAlert(infoRule, 1, "deploy finished")
+	if err := NewWebhookNotifier().Send(t.Context(), info, channel); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if got["text"] != "fyi deploy" {
+		t.Errorf("expected info template, got %v", got)
+	}
+
+	// Severities without a template of their own fall back to the default payload
+	warnRule := domain.NewAlertRule("mem", "mem", domain.ConditionThresholdAbove, 80, domain.AlertSeverityWarning)
+	if err := NewWebhookNotifier().Send(t.Context(), domain.NewAlert(warnRule, 85, "mem high"), channel); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if got["rule_name"] != "mem" {
+		t.Errorf("expected default payload for warning, got %v", got)
+	}
+}
+
+func TestWebhookNotifier_DefaultPayload(t *testing.T) {
+	var got map[string]interface{}
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer server.Close()
+
+	alert := webhookAlert()
+	channel := webhookChannel(server.URL, map[string]string{"headers": "X-Team: infra, X-Env: prod"})
+	if err := NewWebhookNotifier().Send(t.Context(), alert, channel); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if got["rule_name"] != "high-cpu" || got["fingerprint"] != alert.Fingerprint {
+		t.Errorf("unexpected default payload: %v", got)
+	}
+	if header.Get("X-Team") != "infra" || header.Get("X-Env") != "prod" {
+		t.Errorf("expected comma separated headers, got %v", header)
+	}
+}
+
+func TestWebhookNotifier_Retry(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	channel := webhookChannel(server.URL, map[string]string{"retry_backoff": "1ms"})
+	if err := NewWebhookNotifier().Send(t.Context(), webhookAlert(), channel); err != nil {
+		t.Fatalf("expected success after retries, got %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls.Load())
+	}
+
+	// Retries are bounded
+	calls.Store(-10)
+	channel.Config["max_retries"] = "2"
+	err := NewWebhookNotifier().Send(t.Context(), webhookAlert(), channel)
+	if err == nil || !strings.Contains(err.Error(), "after 3 attempts") {
+		t.Errorf("expected failure after 3 attempts, got %v", err)
+	}
+	if calls.Load() != -7 {
+		t.Errorf("expected 3 more attempts, got %d", calls.Load()+10)
+	}
+}
+
+func TestWebhookNotifier_NoRetryOnClientError(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "bad payload", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	channel := webhookChannel(server.URL, map[string]string{"retry_backoff": "1ms"})
+	err := NewWebhookNotifier().Send(t.Context(), webhookAlert(), channel)
+	if err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("expected 400 error, got %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected a single attempt, got %d", calls.Load())
+	}
+}
+
+func TestWebhookNotifier_InvalidConfig(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer server.Close()
+
+	tests := map[string]map[string]string{
+		"parse error":  {"template": `{"a": {{.Alert.Message}`},
+		"missing key":  {"template": `{"a": "{{.Nope}}"}`},
+		"invalid JSON": {"template": `{"a": {{.Alert.Message}}}`},
+		"timeout":      {"timeout": "soon"},
+		"max retries":  {"max_retries": "-1"},
+		"headers":      {"headers": "{not json"},
+	}
+	for name, config := range tests {
+		if err := NewWebhookNotifier().Send(t.Context(), webhookAlert(), webhookChannel(server.URL, config)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	if calls.Load() != 0 {
+		t.Errorf("expected no requests for invalid config, got %d", calls.Load())
+	}
+	if err := NewWebhookNotifier().Send(t.Context(), webhookAlert(), webhookChannel("", nil)); err == nil {
+		t.Error("expected error for missing URL")
+	}
+}
+
 func TestNewSlackNotifier(t *testing.T) {
 	notifier := NewSlackNotifier()
 	if notifier == nil {
@@ -104,3 +303,270 @@ func TestSlackNotifier_buildFields(t *testing.T) {
 	}
 }
 
+func TestWebhookNotifier_HMACSignature(t *testing.T) {
+	var body []byte
+	var signature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		signature = r.Header.Get(WebhookSignatureHeader)
+	}))
+	defer server.Close()
+
+	channel := webhookChannel(server.URL, map[string]string{"hmac_secret": "s3cret"})
+	if err := NewWebhookNotifier().Send(t.Context(), webhookAlert(), channel); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(body)
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); signature != want {
+		t.Errorf("expected signature %q, got %q", want, signature)
+	}
+
+	signature = ""
+	if err := NewWebhookNotifier().Send(t.Context(), webhookAlert(), webhookChannel(server.URL, nil)); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if signature != "" {
+		t.Errorf("expected no signature without a secret, got %q", signature)
+	}
+}
+
+func TestWebhookNotifier_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	channel := webhookChannel(server.URL, map[string]string{"timeout": "20ms", "max_retries": "0"})
+	start := time.Now()
+	if err := NewWebhookNotifier().Send(t.Context(), webhookAlert(), channel); err == nil {
+		t.Error("expected timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected the per-channel timeout to apply, took %v", elapsed)
+	}
+}
+
+func TestSlackNotifier_Send(t *testing.T) {
+	var calls atomic.Int32
+	var payload map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	alert := webhookAlert()
+	alert.Labels = map[string]string{"service": "web", "env": "prod"}
+	channel := &domain.NotificationChannel{Name: "oncall", Type: domain.ChannelSlack, Config: map[string]string{
+		"url":           server.URL,
+		"channel":       "#oncall",
+		"retry_backoff": "1ms",
+	}}
+	if err := NewSlackNotifier().Send(t.Context(), alert, channel); err != nil {
+		t.Fatalf("expected success after a retry, got %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected 2 attempts, got %d", calls.Load())
+	}
+
+	if payload["channel"] != "#oncall" || !strings.Contains(payload["text"].(string), "high-cpu") {
+		t.Errorf("unexpected payload: %v", payload)
+	}
+	attachment := payload["attachments"].([]interface{})[0].(map[string]interface{})
+	if attachment["color"] != "#dc3545" {
+		t.Errorf("expected critical color, got %v", attachment["color"])
+	}
+	var types []string
+	var labels string
+	for _, b := range attachment["blocks"].([]interface{}) {
+		block := b.(map[string]interface{})
+		types = append(types, block["type"].(string))
+		if block["type"] == "context" {
+			labels = block["elements"].([]interface{})[0].(map[string]interface{})["text"].(string)
+		}
+	}
+	if strings.Join(types, ",") != "header,section,section,context" {
+		t.Errorf("unexpected blocks: %v", types)
+	}
+	if labels != "`env=prod` `service=web`" {
+		t.Errorf("expected sorted labels, got %q", labels)
+	}
+}
+
+func TestSlackNotifier_NoRetryOnClientError(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "invalid_token", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	channel := &domain.NotificationChannel{Type: domain.ChannelSlack, Config: map[string]string{"webhook_url": server.URL}}
+	err := NewSlackNotifier().Send(t.Context(), webhookAlert(), channel)
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("expected 403 error, got %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected a single attempt, got %d", calls.Load())
+	}
+}
+
+// fakeSMTPServer accepts SMTP sessions, answering MAIL FROM with the
+// scripted replies in turn and recording accepted messages.
+type fakeSMTPServer struct {
+	listener net.Listener
+	replies  chan string
+	messages chan string
+}
+
+func newFakeSMTPServer(t *testing.T, mailReplies ...string) *fakeSMTPServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	s := &fakeSMTPServer{
+		listener: listener,
+		replies:  make(chan string, len(mailReplies)),
+		messages: make(chan string, 4),
+	}
+	for _, r := range mailReplies {
+		s.replies <- r
+	}
+	go s.serve()
+	t.Cleanup(func() { listener.Close() })
+	return s
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.session(textproto.NewConn(conn))
+	}
+}
+
+func (s *fakeSMTPServer) session(c *textproto.Conn) {
+	defer c.Close()
+	c.PrintfLine("220 localhost ESMTP")
+	for {
+		line, err := c.ReadLine()
+		if err != nil {
+			return
+		}
+		switch cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); cmd {
+		case "EHLO", "HELO":
+			c.PrintfLine("250 localhost")
+		case "MAIL":
+			reply := "250 OK"
+			select {
+			case reply = <-s.replies:
+			default:
+			}
+			c.PrintfLine("%s", reply)
+		case "RCPT":
+			c.PrintfLine("250 OK")
+		case "DATA":
+			c.PrintfLine("354 Go ahead")
+			data, err := c.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.messages <- string(data)
+			c.PrintfLine("250 Queued")
+		case "QUIT":
+			c.PrintfLine("221 Bye")
+			return
+		default:
+			c.PrintfLine("250 OK")
+		}
+	}
+}
+
+func emailChannel(addr string, config map[string]string) *domain.NotificationChannel {
+	host, port, _ := net.SplitHostPort(addr)
+	channel := &domain.NotificationChannel{Name: "mail", Type: domain.ChannelEmail, Config: map[string]string{
+		"smtp_host":     host,
+		"smtp_port":     port,
+		"from":          "forge@example.com",
+		"to":            "ops@example.com, oncall@example.com",
+		"retry_backoff": "1ms",
+	}}
+	for k, v := range config {
+		channel.Config[k] = v
+	}
+	return channel
+}
+
+func TestEmailNotifier_Send(t *testing.T) {
+	server := newFakeSMTPServer(t, "451 Try again later")
+
+	alert := webhookAlert()
+	alert.Labels = map[string]string{"host": "web-1"}
+	if err := NewEmailNotifier().Send(t.Context(), alert, emailChannel(server.listener.Addr().String(), nil)); err != nil {
+		t.Fatalf("expected success after a transient failure, got %v", err)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(<-server.messages))
+	if err != nil {
+		t.Fatalf("failed to parse message: %v", err)
+	}
+	if subject := msg.Header.Get("Subject"); subject != "[CRITICAL] Alert: high-cpu" {
+		t.Errorf("unexpected subject %q", subject)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("expected multipart/alternative, got %q (%v)", mediaType, err)
+	}
+
+	parts := map[string]string{}
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read part: %v", err)
+		}
+		data, _ := io.ReadAll(part)
+		parts[strings.SplitN(part.Header.Get("Content-Type"), ";", 2)[0]] = string(data)
+	}
+	if text := parts["text/plain"]; !strings.Contains(text, "Rule: high-cpu") || !strings.Contains(text, "host=web-1") {
+		t.Errorf("unexpected plain-text body: %q", text)
+	}
+	if html := parts["text/html"]; !strings.Contains(html, "CPU &#34;hot&#34; on web-1") || !strings.Contains(html, "#dc3545") {
+		t.Errorf("expected escaped HTML body, got %q", html)
+	}
+}
+
+func TestEmailNotifier_PermanentFailure(t *testing.T) {
+	server := newFakeSMTPServer(t, "550 Mailbox unavailable", "550 Mailbox unavailable")
+
+	err := NewEmailNotifier().Send(t.Context(), webhookAlert(), emailChannel(server.listener.Addr().String(), nil))
+	if err == nil || !strings.Contains(err.Error(), "550") {
+		t.Fatalf("expected 550 error, got %v", err)
+	}
+	if len(server.replies) != 1 {
+		t.Errorf("expected a permanent failure not to be retried, %d replies left", len(server.replies))
+	}
+}
+
+func TestEmailNotifier_InvalidConfig(t *testing.T) {
+	if err := NewEmailNotifier().Send(t.Context(), webhookAlert(), emailChannel("127.0.0.1:1", map[string]string{"from": ""})); err == nil {
+		t.Error("expected error for missing from address")
+	}
+	if err := NewEmailNotifier().Send(t.Context(), webhookAlert(), emailChannel("127.0.0.1:1", map[string]string{"timeout": "-1s"})); err == nil {
+		t.Error("expected error for invalid timeout")
+	}
+}
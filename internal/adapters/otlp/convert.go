@@ -0,0 +1,245 @@
+package otlp
+
+import (
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/google/uuid"
+)
+
+// unknownService is the service name OpenTelemetry SDKs report when none is
+// configured; it is also used when the resource has no service.name.
+const unknownService = "unknown_service"
+
+// logSource is the Source recorded on log entries received over OTLP.
+const logSource = "otlp"
+
+// spanKinds maps the OTLP SpanKind enum.
+var spanKinds = map[enumValue]domain.SpanKind{
+	0: domain.SpanKindUnspecified,
+	1: domain.SpanKindInternal,
+	2: domain.SpanKindServer,
+	3: domain.SpanKindClient,
+	4: domain.SpanKindProducer,
+	5: domain.SpanKindConsumer,
+}
+
+// convertSpans converts the spans in a trace export request. Spans without
+// a valid trace or span ID are counted as rejected.
+func convertSpans(req *exportTraceRequest) ([]*domain.Span, int) {
+	var spans []*domain.Span
+	rejected := 0
+	now := time.Now()
+
+	for _, rs := range req.ResourceSpans {
+		resAttrs := attributeMap(rs.Resource.Attributes)
+		serviceName, serviceVersion := serviceInfo(resAttrs)
+
+		for _, ss := range rs.ScopeSpans {
+			for _, sp := range ss.Spans {
+				traceID, ok := toTraceID(sp.TraceID)
+				if !ok {
+					rejected++
+					continue
+				}
+				spanID, ok := toSpanID(sp.SpanID)
+				if !ok {
+					rejected++
+					continue
+				}
+
+				span := &domain.Span{
+					ID:             uuid.Must(uuid.NewV7()),
+					TraceID:        traceID,
+					SpanID:         spanID,
+					Name:           sp.Name,
+					Kind:           spanKind(sp.Kind),
+					StartTime:      unixNano(sp.StartTimeUnixNano),
+					EndTime:        unixNano(sp.EndTimeUnixNano),
+					Status:         spanStatus(sp.Status.Code),
+					StatusMessage:  sp.Status.Message,
+					Attributes:     spanAttributes(resAttrs, ss.Scope, sp.Attributes),
+					Events:         []domain.SpanEvent{},
+					Links:          []domain.SpanLink{},
+					ServiceName:    serviceName,
+					ServiceVersion: serviceVersion,
+					CreatedAt:      now,
+				}
+				if span.StartTime.IsZero() {
+					span.StartTime = now
+				}
+				if !span.EndTime.IsZero() {
+					span.Duration = span.EndTime.Sub(span.StartTime)
+				}
+				if parent, ok := toSpanID(sp.ParentSpanID); ok {
+					span.SetParent(parent)
+				}
+				for _, ev := range sp.Events {
+					span.Events = append(span.Events, domain.SpanEvent{
+						Name:       ev.Name,
+						Timestamp:  unixNano(ev.TimeUnixNano),
+						Attributes: attributeMap(ev.Attributes),
+					})
+				}
+				for _, l := range sp.Links {
+					linkTrace, ok := toTraceID(l.TraceID)
+					if !ok {
+						continue
+					}
+					linkSpan, ok := toSpanID(l.SpanID)
+					if !ok {
+						continue
+					}
+					span.Links = append(span.Links, domain.SpanLink{
+						TraceID:    linkTrace,
+						SpanID:     linkSpan,
+						Attributes: attributeMap(l.Attributes),
+					})
+				}
+				spans = append(spans, span)
+			}
+		}
+	}
+	return spans, rejected
+}
+
+// convertLogs converts the records in a logs export request. Trace and span
+// IDs are kept, hex encoded, so entries can be correlated with their trace.
+func convertLogs(req *exportLogsRequest) []*domain.LogEntry {
+	var entries []*domain.LogEntry
+
+	for _, rl := range req.ResourceLogs {
+		resAttrs := attributeMap(rl.Resource.Attributes)
+		serviceName, _ := serviceInfo(resAttrs)
+
+		for _, sl := range rl.ScopeLogs {
+			for _, rec := range sl.LogRecords {
+				entry := domain.NewLogEntry(logLevel(rec.SeverityNumber, rec.SeverityText), rec.Body.String(), logSource, serviceName)
+				switch {
+				case rec.TimeUnixNano != 0:
+					entry.Timestamp = unixNano(rec.TimeUnixNano)
+				case rec.ObservedTimeUnixNano != 0:
+					entry.Timestamp = unixNano(rec.ObservedTimeUnixNano)
+				}
+				if traceID, ok := toTraceID(rec.TraceID); ok {
+					entry.TraceID = traceID.String()
+				}
+				if spanID, ok := toSpanID(rec.SpanID); ok {
+					entry.SpanID = spanID.String()
+				}
+				for _, kv := range rec.Attributes {
+					entry.SetAttribute(kv.Key, kv.Value.String())
+				}
+				if sl.Scope.Name != "" {
+					entry.SetAttribute("otel.scope.name", sl.Scope.Name)
+				}
+				for k, v := range resAttrs {
+					entry.Resource[k] = v
+				}
+				entries = append(entries, entry)
+			}
+		}
+	}
+	return entries
+}
+
+// logLevel maps an OTLP SeverityNumber onto a LogLevel, falling back to the
+// severity text when the number is unspecified. Each level spans four
+// numbers, e.g. 9-12 are INFO through INFO4.
+func logLevel(severity enumValue, text string) domain.LogLevel {
+	switch {
+	case severity >= 21:
+		return domain.LogLevelFatal
+	case severity >= 17:
+		return domain.LogLevelError
+	case severity >= 13:
+		return domain.LogLevelWarning
+	case severity >= 9:
+		return domain.LogLevelInfo
+	case severity >= 5:
+		return domain.LogLevelDebug
+	case severity >= 1:
+		return domain.LogLevelTrace
+	}
+	return domain.NormalizeLogLevel(text)
+}
+
+// spanAttributes merges resource, scope and span attributes. Span
+// attributes win over resource attributes with the same key.
+func spanAttributes(resAttrs map[string]string, sc scope, attrs []keyValue) map[string]string {
+	merged := make(map[string]string, len(resAttrs)+len(attrs)+2)
+	for k, v := range resAttrs {
+		if k == "service.name" || k == "service.version" {
+			continue
+		}
+		merged[k] = v
+	}
+	if sc.Name != "" {
+		merged["otel.scope.name"] = sc.Name
+	}
+	if sc.Version != "" {
+		merged["otel.scope.version"] = sc.Version
+	}
+	for _, kv := range attrs {
+		merged[kv.Key] = kv.Value.String()
+	}
+	return merged
+}
+
+func serviceInfo(resAttrs map[string]string) (string, string) {
+	name := resAttrs["service.name"]
+	if name == "" {
+		name = unknownService
+	}
+	return name, resAttrs["service.version"]
+}
+
+func attributeMap(attrs []keyValue) map[string]string {
+	m := make(map[string]string, len(attrs))
+	for _, kv := range attrs {
+		m[kv.Key] = kv.Value.String()
+	}
+	return m
+}
+
+func spanKind(kind enumValue) domain.SpanKind {
+	if k, ok := spanKinds[kind]; ok {
+		return k
+	}
+	return domain.SpanKindUnspecified
+}
+
+func spanStatus(code enumValue) domain.SpanStatus {
+	switch code {
+	case 1:
+		return domain.SpanStatusOK
+	case 2:
+		return domain.SpanStatusError
+	}
+	return domain.SpanStatusUnset
+}
+
+func toTraceID(b []byte) (domain.TraceID, bool) {
+	var id domain.TraceID
+	if len(b) != len(id) {
+		return id, false
+	}
+	copy(id[:], b)
+	return id, id.IsValid()
+}
+
+func toSpanID(b []byte) (domain.SpanID, bool) {
+	var id domain.SpanID
+	if len(b) != len(id) {
+		return id, false
+	}
+	copy(id[:], b)
+	return id, id.IsValid()
+}
+
+func unixNano(ns jsonUint64) time.Time {
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, int64(ns))
+}
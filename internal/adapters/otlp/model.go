@@ -0,0 +1,262 @@
+package otlp
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The types below mirror the OTLP export request messages closely enough to
+// decode both encodings into them: JSON through encoding/json using the
+// field names of the OTLP/JSON mapping, and protobuf through the hand-written
+// decoder in protobuf.go. Only the fields Forge stores are kept.
+
+type exportTraceRequest struct {
+	ResourceSpans []resourceSpans `json:"resourceSpans"`
+}
+
+type resourceSpans struct {
+	Resource   resource     `json:"resource"`
+	ScopeSpans []scopeSpans `json:"scopeSpans"`
+}
+
+type scopeSpans struct {
+	Scope scope  `json:"scope"`
+	Spans []span `json:"spans"`
+}
+
+type exportLogsRequest struct {
+	ResourceLogs []resourceLogs `json:"resourceLogs"`
+}
+
+type resourceLogs struct {
+	Resource  resource    `json:"resource"`
+	ScopeLogs []scopeLogs `json:"scopeLogs"`
+}
+
+type scopeLogs struct {
+	Scope      scope       `json:"scope"`
+	LogRecords []logRecord `json:"logRecords"`
+}
+
+type resource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+type scope struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type span struct {
+	TraceID           hexBytes    `json:"traceId"`
+	SpanID            hexBytes    `json:"spanId"`
+	ParentSpanID      hexBytes    `json:"parentSpanId"`
+	Name              string      `json:"name"`
+	Kind              enumValue   `json:"kind"`
+	StartTimeUnixNano jsonUint64  `json:"startTimeUnixNano"`
+	EndTimeUnixNano   jsonUint64  `json:"endTimeUnixNano"`
+	Attributes        []keyValue  `json:"attributes"`
+	Events            []spanEvent `json:"events"`
+	Links             []spanLink  `json:"links"`
+	Status            status      `json:"status"`
+}
+
+type spanEvent struct {
+	TimeUnixNano jsonUint64 `json:"timeUnixNano"`
+	Name         string     `json:"name"`
+	Attributes   []keyValue `json:"attributes"`
+}
+
+type spanLink struct {
+	TraceID    hexBytes   `json:"traceId"`
+	SpanID     hexBytes   `json:"spanId"`
+	Attributes []keyValue `json:"attributes"`
+}
+
+type status struct {
+	Message string    `json:"message"`
+	Code    enumValue `json:"code"`
+}
+
+type logRecord struct {
+	TimeUnixNano         jsonUint64 `json:"timeUnixNano"`
+	ObservedTimeUnixNano jsonUint64 `json:"observedTimeUnixNano"`
+	SeverityNumber       enumValue  `json:"severityNumber"`
+	SeverityText         string     `json:"severityText"`
+	Body                 anyValue   `json:"body"`
+	Attributes           []keyValue `json:"attributes"`
+	TraceID              hexBytes   `json:"traceId"`
+	SpanID               hexBytes   `json:"spanId"`
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+// anyValue is the OTLP AnyValue oneof; at most one field is set.
+type anyValue struct {
+	StringValue *string      `json:"stringValue,omitempty"`
+	BoolValue   *bool        `json:"boolValue,omitempty"`
+	IntValue    *jsonInt64   `json:"intValue,omitempty"`
+	DoubleValue *float64     `json:"doubleValue,omitempty"`
+	ArrayValue  *arrayValue  `json:"arrayValue,omitempty"`
+	KvlistValue *kvlistValue `json:"kvlistValue,omitempty"`
+	BytesValue  []byte       `json:"bytesValue,omitempty"`
+}
+
+type arrayValue struct {
+	Values []anyValue `json:"values"`
+}
+
+type kvlistValue struct {
+	Values []keyValue `json:"values"`
+}
+
+// String flattens the value for Forge's string attributes. Arrays and maps
+// are rendered as JSON.
+func (v anyValue) String() string {
+	switch {
+	case v.StringValue != nil:
+		return *v.StringValue
+	case v.BoolValue != nil:
+		return strconv.FormatBool(*v.BoolValue)
+	case v.IntValue != nil:
+		return strconv.FormatInt(int64(*v.IntValue), 10)
+	case v.DoubleValue != nil:
+		return strconv.FormatFloat(*v.DoubleValue, 'g', -1, 64)
+	case v.BytesValue != nil:
+		return base64.StdEncoding.EncodeToString(v.BytesValue)
+	case v.ArrayValue != nil, v.KvlistValue != nil:
+		data, _ := json.Marshal(v.native())
+		return string(data)
+	}
+	return ""
+}
+
+// native converts the value to plain Go values for JSON rendering.
+func (v anyValue) native() interface{} {
+	switch {
+	case v.ArrayValue != nil:
+		values := make([]interface{}, len(v.ArrayValue.Values))
+		for i, item := range v.ArrayValue.Values {
+			values[i] = item.native()
+		}
+		return values
+	case v.KvlistValue != nil:
+		values := make(map[string]interface{}, len(v.KvlistValue.Values))
+		for _, kv := range v.KvlistValue.Values {
+			values[kv.Key] = kv.Value.native()
+		}
+		return values
+	case v.BoolValue != nil:
+		return *v.BoolValue
+	case v.IntValue != nil:
+		return int64(*v.IntValue)
+	case v.DoubleValue != nil:
+		return *v.DoubleValue
+	}
+	return v.String()
+}
+
+// hexBytes holds trace and span IDs, which OTLP/JSON encodes as hex strings
+// rather than the base64 protobuf JSON normally uses for bytes.
+type hexBytes []byte
+
+func (h *hexBytes) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("invalid id: %w", err)
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return fmt.Errorf("invalid id %q: %w", s, err)
+	}
+	*h = b
+	return nil
+}
+
+// jsonUint64 accepts 64-bit integers encoded either as JSON numbers or, as
+// the protobuf JSON mapping specifies, as decimal strings.
+type jsonUint64 uint64
+
+func (u *jsonUint64) UnmarshalJSON(data []byte) error {
+	n, err := strconv.ParseUint(strings.Trim(string(data), `"`), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid integer %s: %w", data, err)
+	}
+	*u = jsonUint64(n)
+	return nil
+}
+
+// jsonInt64 is the signed counterpart of jsonUint64.
+type jsonInt64 int64
+
+func (i *jsonInt64) UnmarshalJSON(data []byte) error {
+	n, err := strconv.ParseInt(strings.Trim(string(data), `"`), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid integer %s: %w", data, err)
+	}
+	*i = jsonInt64(n)
+	return nil
+}
+
+// enumValue accepts enums as numbers or by their protobuf value name.
+type enumValue int32
+
+// enumNames maps the enum value names that may appear in OTLP/JSON.
+var enumNames = map[string]enumValue{
+	"SPAN_KIND_UNSPECIFIED": 0,
+	"SPAN_KIND_INTERNAL":    1,
+	"SPAN_KIND_SERVER":      2,
+	"SPAN_KIND_CLIENT":      3,
+	"SPAN_KIND_PRODUCER":    4,
+	"SPAN_KIND_CONSUMER":    5,
+	"STATUS_CODE_UNSET":     0,
+	"STATUS_CODE_OK":        1,
+	"STATUS_CODE_ERROR":     2,
+}
+
+func (e *enumValue) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var name string
+		if err := json.Unmarshal(data, &name); err != nil {
+			return err
+		}
+		if v, ok := enumNames[name]; ok {
+			*e = v
+			return nil
+		}
+		if v, ok := severityNames[name]; ok {
+			*e = v
+			return nil
+		}
+		return fmt.Errorf("unknown enum value %q", name)
+	}
+	n, err := strconv.ParseInt(string(data), 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid enum value %s: %w", data, err)
+	}
+	*e = enumValue(n)
+	return nil
+}
+
+// severityNames maps SeverityNumber names, e.g. SEVERITY_NUMBER_WARN2.
+var severityNames = func() map[string]enumValue {
+	names := make(map[string]enumValue)
+	names["SEVERITY_NUMBER_UNSPECIFIED"] = 0
+	for i, level := range []string{"TRACE", "DEBUG", "INFO", "WARN", "ERROR", "FATAL"} {
+		for j := 0; j < 4; j++ {
+			name := "SEVERITY_NUMBER_" + level
+			if j > 0 {
+				name += strconv.Itoa(j + 1)
+			}
+			names[name] = enumValue(i*4 + j + 1)
+		}
+	}
+	return names
+}()
@@ -0,0 +1,401 @@
+package otlp
+
+import (
+	"fmt"
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// wireField is a single decoded protobuf field.
+type wireField struct {
+	num   protowire.Number
+	typ   protowire.Type
+	value uint64 // varint, fixed32 and fixed64 values
+	bytes []byte // length-delimited values
+}
+
+// walkFields calls fn for every field in a protobuf message. Unknown and
+// group fields are skipped.
+func walkFields(b []byte, fn func(f wireField) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		f := wireField{num: num, typ: typ}
+		switch typ {
+		case protowire.VarintType:
+			f.value, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed64Type:
+			f.value, n = protowire.ConsumeFixed64(b)
+		case protowire.Fixed32Type:
+			var v uint32
+			v, n = protowire.ConsumeFixed32(b)
+			f.value = uint64(v)
+		case protowire.BytesType:
+			f.bytes, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isBytes and isScalar guard against fields whose wire type doesn't match
+// the schema, which are ignored rather than misread.
+func (f wireField) isBytes() bool { return f.typ == protowire.BytesType }
+
+func (f wireField) isScalar() bool {
+	return f.typ != protowire.BytesType && f.typ != protowire.StartGroupType
+}
+
+// decodeTraceRequest decodes an ExportTraceServiceRequest.
+func decodeTraceRequest(b []byte) (*exportTraceRequest, error) {
+	req := &exportTraceRequest{}
+	err := walkFields(b, func(f wireField) error {
+		if f.num == 1 && f.isBytes() {
+			rs, err := decodeResourceSpans(f.bytes)
+			if err != nil {
+				return err
+			}
+			req.ResourceSpans = append(req.ResourceSpans, rs)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode trace request: %w", err)
+	}
+	return req, nil
+}
+
+func decodeResourceSpans(b []byte) (resourceSpans, error) {
+	var rs resourceSpans
+	err := walkFields(b, func(f wireField) error {
+		if !f.isBytes() {
+			return nil
+		}
+		switch f.num {
+		case 1:
+			r, err := decodeResource(f.bytes)
+			rs.Resource = r
+			return err
+		case 2:
+			ss, err := decodeScopeSpans(f.bytes)
+			rs.ScopeSpans = append(rs.ScopeSpans, ss)
+			return err
+		}
+		return nil
+	})
+	return rs, err
+}
+
+func decodeScopeSpans(b []byte) (scopeSpans, error) {
+	var ss scopeSpans
+	err := walkFields(b, func(f wireField) error {
+		if !f.isBytes() {
+			return nil
+		}
+		switch f.num {
+		case 1:
+			sc, err := decodeScope(f.bytes)
+			ss.Scope = sc
+			return err
+		case 2:
+			sp, err := decodeSpan(f.bytes)
+			ss.Spans = append(ss.Spans, sp)
+			return err
+		}
+		return nil
+	})
+	return ss, err
+}
+
+func decodeSpan(b []byte) (span, error) {
+	var sp span
+	err := walkFields(b, func(f wireField) error {
+		var err error
+		switch {
+		case f.num == 1 && f.isBytes():
+			sp.TraceID = f.bytes
+		case f.num == 2 && f.isBytes():
+			sp.SpanID = f.bytes
+		case f.num == 4 && f.isBytes():
+			sp.ParentSpanID = f.bytes
+		case f.num == 5 && f.isBytes():
+			sp.Name = string(f.bytes)
+		case f.num == 6 && f.isScalar():
+			sp.Kind = enumValue(f.value)
+		case f.num == 7 && f.isScalar():
+			sp.StartTimeUnixNano = jsonUint64(f.value)
+		case f.num == 8 && f.isScalar():
+			sp.EndTimeUnixNano = jsonUint64(f.value)
+		case f.num == 9 && f.isBytes():
+			var kv keyValue
+			kv, err = decodeKeyValue(f.bytes)
+			sp.Attributes = append(sp.Attributes, kv)
+		case f.num == 11 && f.isBytes():
+			var ev spanEvent
+			ev, err = decodeSpanEvent(f.bytes)
+			sp.Events = append(sp.Events, ev)
+		case f.num == 13 && f.isBytes():
+			var link spanLink
+			link, err = decodeSpanLink(f.bytes)
+			sp.Links = append(sp.Links, link)
+		case f.num == 15 && f.isBytes():
+			sp.Status, err = decodeStatus(f.bytes)
+		}
+		return err
+	})
+	return sp, err
+}
+
+func decodeSpanEvent(b []byte) (spanEvent, error) {
+	var ev spanEvent
+	err := walkFields(b, func(f wireField) error {
+		switch {
+		case f.num == 1 && f.isScalar():
+			ev.TimeUnixNano = jsonUint64(f.value)
+		case f.num == 2 && f.isBytes():
+			ev.Name = string(f.bytes)
+		case f.num == 3 && f.isBytes():
+			kv, err := decodeKeyValue(f.bytes)
+			ev.Attributes = append(ev.Attributes, kv)
+			return err
+		}
+		return nil
+	})
+	return ev, err
+}
+
+func decodeSpanLink(b []byte) (spanLink, error) {
+	var link spanLink
+	err := walkFields(b, func(f wireField) error {
+		if !f.isBytes() {
+			return nil
+		}
+		switch f.num {
+		case 1:
+			link.TraceID = f.bytes
+		case 2:
+			link.SpanID = f.bytes
+		case 4:
+			kv, err := decodeKeyValue(f.bytes)
+			link.Attributes = append(link.Attributes, kv)
+			return err
+		}
+		return nil
+	})
+	return link, err
+}
+
+func decodeStatus(b []byte) (status, error) {
+	var st status
+	err := walkFields(b, func(f wireField) error {
+		switch {
+		case f.num == 2 && f.isBytes():
+			st.Message = string(f.bytes)
+		case f.num == 3 && f.isScalar():
+			st.Code = enumValue(f.value)
+		}
+		return nil
+	})
+	return st, err
+}
+
+// decodeLogsRequest decodes an ExportLogsServiceRequest.
+func decodeLogsRequest(b []byte) (*exportLogsRequest, error) {
+	req := &exportLogsRequest{}
+	err := walkFields(b, func(f wireField) error {
+		if f.num == 1 && f.isBytes() {
+			rl, err := decodeResourceLogs(f.bytes)
+			if err != nil {
+				return err
+			}
+			req.ResourceLogs = append(req.ResourceLogs, rl)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode logs request: %w", err)
+	}
+	return req, nil
+}
+
+func decodeResourceLogs(b []byte) (resourceLogs, error) {
+	var rl resourceLogs
+	err := walkFields(b, func(f wireField) error {
+		if !f.isBytes() {
+			return nil
+		}
+		switch f.num {
+		case 1:
+			r, err := decodeResource(f.bytes)
+			rl.Resource = r
+			return err
+		case 2:
+			sl, err := decodeScopeLogs(f.bytes)
+			rl.ScopeLogs = append(rl.ScopeLogs, sl)
+			return err
+		}
+		return nil
+	})
+	return rl, err
+}
+
+func decodeScopeLogs(b []byte) (scopeLogs, error) {
+	var sl scopeLogs
+	err := walkFields(b, func(f wireField) error {
+		if !f.isBytes() {
+			return nil
+		}
+		switch f.num {
+		case 1:
+			sc, err := decodeScope(f.bytes)
+			sl.Scope = sc
+			return err
+		case 2:
+			rec, err := decodeLogRecord(f.bytes)
+			sl.LogRecords = append(sl.LogRecords, rec)
+			return err
+		}
+		return nil
+	})
+	return sl, err
+}
+
+func decodeLogRecord(b []byte) (logRecord, error) {
+	var rec logRecord
+	err := walkFields(b, func(f wireField) error {
+		var err error
+		switch {
+		case f.num == 1 && f.isScalar():
+			rec.TimeUnixNano = jsonUint64(f.value)
+		case f.num == 11 && f.isScalar():
+			rec.ObservedTimeUnixNano = jsonUint64(f.value)
+		case f.num == 2 && f.isScalar():
+			rec.SeverityNumber = enumValue(f.value)
+		case f.num == 3 && f.isBytes():
+			rec.SeverityText = string(f.bytes)
+		case f.num == 5 && f.isBytes():
+			rec.Body, err = decodeAnyValue(f.bytes)
+		case f.num == 6 && f.isBytes():
+			var kv keyValue
+			kv, err = decodeKeyValue(f.bytes)
+			rec.Attributes = append(rec.Attributes, kv)
+		case f.num == 9 && f.isBytes():
+			rec.TraceID = f.bytes
+		case f.num == 10 && f.isBytes():
+			rec.SpanID = f.bytes
+		}
+		return err
+	})
+	return rec, err
+}
+
+func decodeResource(b []byte) (resource, error) {
+	var r resource
+	err := walkFields(b, func(f wireField) error {
+		if f.num == 1 && f.isBytes() {
+			kv, err := decodeKeyValue(f.bytes)
+			r.Attributes = append(r.Attributes, kv)
+			return err
+		}
+		return nil
+	})
+	return r, err
+}
+
+func decodeScope(b []byte) (scope, error) {
+	var sc scope
+	err := walkFields(b, func(f wireField) error {
+		if !f.isBytes() {
+			return nil
+		}
+		switch f.num {
+		case 1:
+			sc.Name = string(f.bytes)
+		case 2:
+			sc.Version = string(f.bytes)
+		}
+		return nil
+	})
+	return sc, err
+}
+
+func decodeKeyValue(b []byte) (keyValue, error) {
+	var kv keyValue
+	err := walkFields(b, func(f wireField) error {
+		if !f.isBytes() {
+			return nil
+		}
+		switch f.num {
+		case 1:
+			kv.Key = string(f.bytes)
+		case 2:
+			v, err := decodeAnyValue(f.bytes)
+			kv.Value = v
+			return err
+		}
+		return nil
+	})
+	return kv, err
+}
+
+func decodeAnyValue(b []byte) (anyValue, error) {
+	var v anyValue
+	err := walkFields(b, func(f wireField) error {
+		switch {
+		case f.num == 1 && f.isBytes():
+			s := string(f.bytes)
+			v.StringValue = &s
+		case f.num == 2 && f.isScalar():
+			bv := f.value != 0
+			v.BoolValue = &bv
+		case f.num == 3 && f.isScalar():
+			iv := jsonInt64(int64(f.value))
+			v.IntValue = &iv
+		case f.num == 4 && f.typ == protowire.Fixed64Type:
+			dv := math.Float64frombits(f.value)
+			v.DoubleValue = &dv
+		case f.num == 5 && f.isBytes():
+			arr := &arrayValue{}
+			err := walkFields(f.bytes, func(item wireField) error {
+				if item.num == 1 && item.isBytes() {
+					iv, err := decodeAnyValue(item.bytes)
+					arr.Values = append(arr.Values, iv)
+					return err
+				}
+				return nil
+			})
+			v.ArrayValue = arr
+			return err
+		case f.num == 6 && f.isBytes():
+			list := &kvlistValue{}
+			err := walkFields(f.bytes, func(item wireField) error {
+				if item.num == 1 && item.isBytes() {
+					kv, err := decodeKeyValue(item.bytes)
+					list.Values = append(list.Values, kv)
+					return err
+				}
+				return nil
+			})
+			v.KvlistValue = list
+			return err
+		case f.num == 7 && f.isBytes():
+			v.BytesValue = append([]byte{}, f.bytes...)
+		}
+		return nil
+	})
+	return v, err
+}
@@ -0,0 +1,243 @@
+// Package otlp implements an OTLP/HTTP receiver so applications instrumented
+// with OpenTelemetry SDKs can send traces and logs to Forge.
+package otlp
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// DefaultPort is the standard OTLP/HTTP port.
+const DefaultPort = "4318"
+
+// DefaultHost keeps the receiver, which does not authenticate exporters, to
+// applications on the same machine.
+const DefaultHost = "127.0.0.1"
+
+// maxBodySize bounds a single export request after decompression.
+const maxBodySize = 32 << 20
+
+const (
+	contentTypeProtobuf = "application/x-protobuf"
+	contentTypeJSON     = "application/json"
+)
+
+// SpanSink stores spans received over OTLP.
+type SpanSink interface {
+	ImportSpans(ctx context.Context, spans []*domain.Span) error
+}
+
+// LogSink stores log entries received over OTLP.
+type LogSink interface {
+	IngestBatch(ctx context.Context, entries []*domain.LogEntry) error
+}
+
+// Receiver serves the OTLP/HTTP /v1/traces and /v1/logs endpoints. Each
+// export request is converted and written in a single batch.
+type Receiver struct {
+	server *http.Server
+	spans  SpanSink
+	logs   LogSink
+	logger ports.Logger
+}
+
+// NewReceiver creates a receiver listening on the given host and port. Use
+// host "0.0.0.0" to accept exports from other machines.
+func NewReceiver(host, port string, spans SpanSink, logs LogSink, logger ports.Logger) *Receiver {
+	if host == "" {
+		host = DefaultHost
+	}
+	if port == "" {
+		port = DefaultPort
+	}
+
+	r := &Receiver{
+		spans:  spans,
+		logs:   logs,
+		logger: logger,
+	}
+	r.server = &http.Server{
+		Addr:         net.JoinHostPort(host, port),
+		Handler:      r.Handler(),
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: 30 * time.Second,
+		IdleTimeout:  2 * time.Minute,
+	}
+	return r
+}
+
+// Handler returns the HTTP handler serving the OTLP endpoints.
+func (r *Receiver) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/traces", r.handleTraces)
+	mux.HandleFunc("/v1/logs", r.handleLogs)
+	return mux
+}
+
+// Start starts the receiver. It blocks until the server stops.
+func (r *Receiver) Start() error {
+	return r.server.ListenAndServe()
+}
+
+// Shutdown gracefully shuts down the receiver.
+func (r *Receiver) Shutdown(ctx context.Context) error {
+	return r.server.Shutdown(ctx)
+}
+
+// Addr returns the receiver address.
+func (r *Receiver) Addr() string {
+	return r.server.Addr
+}
+
+// handleTraces handles POST /v1/traces.
+func (r *Receiver) handleTraces(w http.ResponseWriter, req *http.Request) {
+	body, contentType, ok := r.readRequest(w, req)
+	if !ok {
+		return
+	}
+
+	export := &exportTraceRequest{}
+	var err error
+	if contentType == contentTypeProtobuf {
+		export, err = decodeTraceRequest(body)
+	} else {
+		err = json.Unmarshal(body, export)
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid trace export request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	spans, rejected := convertSpans(export)
+	if err := r.spans.ImportSpans(req.Context(), spans); err != nil {
+		r.logger.Error("Failed to store OTLP spans", "spans", len(spans), "error", err)
+		http.Error(w, "failed to store spans", http.StatusServiceUnavailable)
+		return
+	}
+	r.logger.Debug("Received OTLP spans", "spans", len(spans), "rejected", rejected)
+
+	var message string
+	if rejected > 0 {
+		message = "spans without a valid trace or span id were dropped"
+	}
+	writeResponse(w, contentType, "rejectedSpans", rejected, message)
+}
+
+// handleLogs handles POST /v1/logs.
+func (r *Receiver) handleLogs(w http.ResponseWriter, req *http.Request) {
+	body, contentType, ok := r.readRequest(w, req)
+	if !ok {
+		return
+	}
+
+	export := &exportLogsRequest{}
+	var err error
+	if contentType == contentTypeProtobuf {
+		export, err = decodeLogsRequest(body)
+	} else {
+		err = json.Unmarshal(body, export)
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid logs export request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	entries := convertLogs(export)
+	if len(entries) > 0 {
+		if err := r.logs.IngestBatch(req.Context(), entries); err != nil {
+			r.logger.Error("Failed to store OTLP logs", "records", len(entries), "error", err)
+			http.Error(w, "failed to store logs", http.StatusServiceUnavailable)
+			return
+		}
+	}
+	r.logger.Debug("Received OTLP logs", "records", len(entries))
+
+	writeResponse(w, contentType, "rejectedLogRecords", 0, "")
+}
+
+// readRequest validates an export request and returns its decompressed
+// body and content type. On failure the error response has been written.
+func (r *Receiver) readRequest(w http.ResponseWriter, req *http.Request) ([]byte, string, bool) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return nil, "", false
+	}
+
+	contentType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil || (contentType != contentTypeProtobuf && contentType != contentTypeJSON) {
+		http.Error(w, "content type must be application/x-protobuf or application/json", http.StatusUnsupportedMediaType)
+		return nil, "", false
+	}
+
+	var reader io.Reader = http.MaxBytesReader(w, req.Body, maxBodySize)
+	switch req.Header.Get("Content-Encoding") {
+	case "", "identity":
+	case "gzip":
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid gzip body: %v", err), http.StatusBadRequest)
+			return nil, "", false
+		}
+		defer gz.Close()
+		reader = io.LimitReader(gz, maxBodySize+1)
+	default:
+		http.Error(w, "unsupported content encoding", http.StatusUnsupportedMediaType)
+		return nil, "", false
+	}
+
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read body: %v", err), http.StatusBadRequest)
+		return nil, "", false
+	}
+	if len(body) > maxBodySize {
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return nil, "", false
+	}
+	return body, contentType, true
+}
+
+// writeResponse writes an export response in the request's encoding,
+// reporting rejected items as a partial success.
+func writeResponse(w http.ResponseWriter, contentType, rejectedField string, rejected int, message string) {
+	w.Header().Set("Content-Type", contentType)
+
+	if contentType == contentTypeProtobuf {
+		var body []byte
+		if rejected > 0 {
+			// ExportXServiceResponse.partial_success (1) holds
+			// rejected_<items> (1) and error_message (2)
+			var partial []byte
+			partial = protowire.AppendTag(partial, 1, protowire.VarintType)
+			partial = protowire.AppendVarint(partial, uint64(rejected))
+			partial = protowire.AppendTag(partial, 2, protowire.BytesType)
+			partial = protowire.AppendString(partial, message)
+			body = protowire.AppendTag(body, 1, protowire.BytesType)
+			body = protowire.AppendBytes(body, partial)
+		}
+		_, _ = w.Write(body)
+		return
+	}
+
+	resp := map[string]interface{}{}
+	if rejected > 0 {
+		resp["partialSuccess"] = map[string]interface{}{
+			rejectedField:  strconv.Itoa(rejected),
+			"errorMessage": message,
+		}
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,339 @@
+package otlp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/services"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+type captureSpans struct {
+	batches [][]*domain.Span
+}
+
+func (c *captureSpans) ImportSpans(ctx context.Context, spans []*domain.Span) error {
+	c.batches = append(c.batches, spans)
+	return nil
+}
+
+type captureLogs struct {
+	batches [][]*domain.LogEntry
+}
+
+func (c *captureLogs) IngestBatch(ctx context.Context, entries []*domain.LogEntry) error {
+	c.batches = append(c.batches, entries)
+	return nil
+}
+
+func newTestReceiver() (*Receiver, *captureSpans, *captureLogs) {
+	spans := &captureSpans{}
+	logs := &captureLogs{}
+	return NewReceiver("", "", spans, logs, &services.NopLogger{}), spans, logs
+}
+
+func post(t *testing.T, h http.Handler, path, contentType string, body []byte, headers ...string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+const traceJSON = `{
+  "resourceSpans": [{
+    "resource": {"attributes": [
+      {"key": "service.name", "value": {"stringValue": "checkout"}},
+      {"key": "service.version", "value": {"stringValue": "1.4.0"}},
+      {"key": "host.name", "value": {"stringValue": "web-1"}}
+    ]},
+    "scopeSpans": [{
+      "scope": {"name": "io.opentelemetry.http", "version": "0.1"},
+      "spans": [
+        {
+          "traceId": "5b8efff798038103d269b633813fc60c",
+          "spanId": "eee19b7ec3c1b174",
+          "name": "POST /checkout",
+          "kind": 2,
+          "startTimeUnixNano": "1700000000000000000",
+          "endTimeUnixNano": "1700000000250000000",
+          "attributes": [
+            {"key": "http.status_code", "value": {"intValue": "500"}},
+            {"key": "retry", "value": {"boolValue": true}}
+          ],
+          "events": [{"timeUnixNano": "1700000000100000000", "name": "exception"}],
+          "status": {"code": 2, "message": "card declined"}
+        },
+        {
+          "traceId": "5b8efff798038103d269b633813fc60c",
+          "spanId": "eee19b7ec3c1b175",
+          "parentSpanId": "eee19b7ec3c1b174",
+          "name": "charge",
+          "kind": "SPAN_KIND_CLIENT",
+          "startTimeUnixNano": 1700000000010000000,
+          "endTimeUnixNano": 1700000000200000000,
+          "status": {"code": "STATUS_CODE_OK"}
+        },
+        {"traceId": "", "spanId": "eee19b7ec3c1b176", "name": "orphan"}
+      ]
+    }]
+  }]
+}`
+
+func TestNewReceiver_Addr(t *testing.T) {
+	if addr := NewReceiver("", "", nil, nil, &services.NopLogger{}).Addr(); addr != "127.0.0.1:4318" {
+		t.Errorf("expected the receiver to default to localhost, got %q", addr)
+	}
+	if addr := NewReceiver("0.0.0.0", "14318", nil, nil, &services.NopLogger{}).Addr(); addr != "0.0.0.0:14318" {
+		t.Errorf("expected the configured address, got %q", addr)
+	}
+}
+
+func TestReceiver_TracesJSON(t *testing.T) {
+	r, sink, _ := newTestReceiver()
+
+	rec := post(t, r.Handler(), "/v1/traces", "application/json", []byte(traceJSON))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(sink.batches) != 1 || len(sink.batches[0]) != 2 {
+		t.Fatalf("expected one batch of 2 spans, got %v", sink.batches)
+	}
+
+	var resp map[string]map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if resp["partialSuccess"]["rejectedSpans"] != "1" {
+		t.Errorf("expected the orphan span to be rejected, got %s", rec.Body.String())
+	}
+
+	root, child := sink.batches[0][0], sink.batches[0][1]
+	if root.TraceID.String() != "5b8efff798038103d269b633813fc60c" || root.SpanID.String() != "eee19b7ec3c1b174" {
+		t.Errorf("unexpected ids: %s/%s", root.TraceID, root.SpanID)
+	}
+	if root.ServiceName != "checkout" || root.ServiceVersion != "1.4.0" {
+		t.Errorf("expected service from resource, got %s %s", root.ServiceName, root.ServiceVersion)
+	}
+	if root.Kind != domain.SpanKindServer || root.Status != domain.SpanStatusError || root.StatusMessage != "card declined" {
+		t.Errorf("unexpected kind/status: %s %s %q", root.Kind, root.Status, root.StatusMessage)
+	}
+	if root.Duration != 250*time.Millisecond {
+		t.Errorf("expected 250ms duration, got %v", root.Duration)
+	}
+	if root.Attributes["http.status_code"] != "500" || root.Attributes["retry"] != "true" ||
+		root.Attributes["host.name"] != "web-1" || root.Attributes["otel.scope.name"] != "io.opentelemetry.http" {
+		t.Errorf("unexpected attributes: %v", root.Attributes)
+	}
+	if len(root.Events) != 1 || root.Events[0].Name != "exception" {
+		t.Errorf("expected exception event, got %v", root.Events)
+	}
+	if root.ParentSpanID != nil {
+		t.Error("root span should have no parent")
+	}
+	if child.ParentSpanID == nil || *child.ParentSpanID != root.SpanID {
+		t.Errorf("expected child to reference root, got %v", child.ParentSpanID)
+	}
+	if child.Kind != domain.SpanKindClient || child.Status != domain.SpanStatusOK {
+		t.Errorf("expected enum names to decode, got %s %s", child.Kind, child.Status)
+	}
+}
+
+// Protobuf encoding helpers mirroring the OTLP schema.
+
+func pbBytes(b []byte, num protowire.Number, v []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+func pbString(b []byte, num protowire.Number, v string) []byte {
+	return pbBytes(b, num, []byte(v))
+}
+
+func pbVarint(b []byte, num protowire.Number, v uint64) []byte {
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+func pbFixed64(b []byte, num protowire.Number, v uint64) []byte {
+	b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, v)
+}
+
+func pbKeyValue(key string, value []byte) []byte {
+	return pbBytes(pbString(nil, 1, key), 2, value)
+}
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	id, err := domain.ParseTraceID(s)
+	if err == nil {
+		return id[:]
+	}
+	sid, err := domain.ParseSpanID(s)
+	if err != nil {
+		t.Fatalf("bad id %q", s)
+	}
+	return sid[:]
+}
+
+func TestReceiver_TracesProtobuf(t *testing.T) {
+	r, sink, _ := newTestReceiver()
+
+	var sp []byte
+	sp = pbBytes(sp, 1, mustHex(t, "0af7651916cd43dd8448eb211c80319c"))
+	sp = pbBytes(sp, 2, mustHex(t, "b7ad6b7169203331"))
+	sp = pbString(sp, 5, "GET /items")
+	sp = pbVarint(sp, 6, 3)
+	sp = pbFixed64(sp, 7, 1700000000000000000)
+	sp = pbFixed64(sp, 8, 1700000000040000000)
+	sp = pbBytes(sp, 9, pbKeyValue("latency", pbFixed64(nil, 4, math.Float64bits(1.5))))
+	sp = pbBytes(sp, 9, pbKeyValue("tags", pbBytes(nil, 5, pbBytes(nil, 1, pbString(nil, 1, "a")))))
+	sp = pbBytes(sp, 15, pbVarint(pbString(nil, 2, "boom"), 3, 2))
+	sp = pbVarint(sp, 99, 7) // unknown fields are skipped
+
+	scopeSpans := pbBytes(pbBytes(nil, 1, pbString(nil, 1, "grpc")), 2, sp)
+	res := pbBytes(nil, 1, pbKeyValue("service.name", pbString(nil, 1, "inventory")))
+	body := pbBytes(nil, 1, pbBytes(pbBytes(nil, 1, res), 2, scopeSpans))
+
+	rec := post(t, r.Handler(), "/v1/traces", "application/x-protobuf", body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("Content-Type") != "application/x-protobuf" || rec.Body.Len() != 0 {
+		t.Errorf("expected empty protobuf response, got %q %x", rec.Header().Get("Content-Type"), rec.Body.Bytes())
+	}
+	if len(sink.batches) != 1 || len(sink.batches[0]) != 1 {
+		t.Fatalf("expected one span, got %v", sink.batches)
+	}
+
+	span := sink.batches[0][0]
+	if span.Name != "GET /items" || span.ServiceName != "inventory" || span.Kind != domain.SpanKindClient {
+		t.Errorf("unexpected span: %+v", span)
+	}
+	if span.Status != domain.SpanStatusError || span.StatusMessage != "boom" {
+		t.Errorf("unexpected status: %s %q", span.Status, span.StatusMessage)
+	}
+	if span.Duration != 40*time.Millisecond {
+		t.Errorf("expected 40ms, got %v", span.Duration)
+	}
+	if span.Attributes["latency"] != "1.5" || span.Attributes["tags"] != `["a"]` {
+		t.Errorf("unexpected attributes: %v", span.Attributes)
+	}
+}
+
+func TestReceiver_LogsWithTraceCorrelation(t *testing.T) {
+	r, _, sink := newTestReceiver()
+
+	payload := `{"resourceLogs": [{
+	  "resource": {"attributes": [{"key": "service.name", "value": {"stringValue": "checkout"}}]},
+	  "scopeLogs": [{"scope": {"name": "app"}, "logRecords": [
+	    {"timeUnixNano": "1700000000000000000", "severityNumber": 17, "severityText": "ERROR",
+	     "body": {"stringValue": "payment failed"},
+	     "attributes": [{"key": "order", "value": {"intValue": 42}}],
+	     "traceId": "5b8efff798038103d269b633813fc60c", "spanId": "eee19b7ec3c1b174"},
+	    {"observedTimeUnixNano": "1700000001000000000", "severityText": "warn",
+	     "body": {"kvlistValue": {"values": [{"key": "k", "value": {"stringValue": "v"}}]}}}
+	  ]}]
+	}]}`
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, _ = zw.Write([]byte(payload))
+	_ = zw.Close()
+
+	rec := post(t, r.Handler(), "/v1/logs", "application/json; charset=utf-8", gz.Bytes(), "Content-Encoding", "gzip")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if strings.TrimSpace(rec.Body.String()) != "{}" {
+		t.Errorf("expected empty JSON response, got %s", rec.Body.String())
+	}
+	if len(sink.batches) != 1 || len(sink.batches[0]) != 2 {
+		t.Fatalf("expected one batch of 2 records, got %v", sink.batches)
+	}
+
+	first, second := sink.batches[0][0], sink.batches[0][1]
+	if first.Level != domain.LogLevelError || first.Message != "payment failed" || first.ServiceName != "checkout" {
+		t.Errorf("unexpected entry: %+v", first)
+	}
+	if first.TraceID != "5b8efff798038103d269b633813fc60c" || first.SpanID != "eee19b7ec3c1b174" {
+		t.Errorf("expected trace correlation, got %q/%q", first.TraceID, first.SpanID)
+	}
+	if first.Attributes["order"] != "42" || first.Resource["service.name"] != "checkout" || first.Source != "otlp" {
+		t.Errorf("unexpected attributes %v resource %v source %q", first.Attributes, first.Resource, first.Source)
+	}
+	if !first.Timestamp.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("unexpected timestamp %v", first.Timestamp)
+	}
+	if second.Level != domain.LogLevelWarning || second.Message != `{"k":"v"}` {
+		t.Errorf("expected level from severity text and JSON body, got %s %q", second.Level, second.Message)
+	}
+	if !second.Timestamp.Equal(time.Unix(1700000001, 0)) {
+		t.Errorf("expected observed time fallback, got %v", second.Timestamp)
+	}
+}
+
+func TestReceiver_LogsProtobuf(t *testing.T) {
+	r, _, sink := newTestReceiver()
+
+	var lr []byte
+	lr = pbFixed64(lr, 1, 1700000000000000000)
+	lr = pbVarint(lr, 2, 10)
+	lr = pbBytes(lr, 5, pbString(nil, 1, "started"))
+	lr = pbBytes(lr, 9, mustHex(t, "0af7651916cd43dd8448eb211c80319c"))
+	body := pbBytes(nil, 1, pbBytes(nil, 2, pbBytes(nil, 2, lr)))
+
+	rec := post(t, r.Handler(), "/v1/logs", "application/x-protobuf", body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(sink.batches) != 1 || len(sink.batches[0]) != 1 {
+		t.Fatalf("expected one record, got %v", sink.batches)
+	}
+	entry := sink.batches[0][0]
+	if entry.Level != domain.LogLevelInfo || entry.Message != "started" || entry.ServiceName != unknownService {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+	if entry.TraceID != "0af7651916cd43dd8448eb211c80319c" {
+		t.Errorf("expected trace id, got %q", entry.TraceID)
+	}
+}
+
+func TestReceiver_RejectsBadRequests(t *testing.T) {
+	r, _, _ := newTestReceiver()
+	h := r.Handler()
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/traces", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for GET, got %d", rec.Code)
+	}
+
+	if rec := post(t, h, "/v1/traces", "text/plain", []byte("x")); rec.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("expected 415 for text/plain, got %d", rec.Code)
+	}
+	if rec := post(t, h, "/v1/traces", "application/json", []byte("{")); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for malformed JSON, got %d", rec.Code)
+	}
+	if rec := post(t, h, "/v1/logs", "application/x-protobuf", []byte{0x0a, 0xff}); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for truncated protobuf, got %d", rec.Code)
+	}
+	if rec := post(t, h, "/v1/logs", "application/json", []byte("{}"), "Content-Encoding", "br"); rec.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("expected 415 for unsupported encoding, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,422 @@
+package prometheus
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+)
+
+// MetricsPath is where the exposition endpoint is served.
+const MetricsPath = "/metrics"
+
+// ContentType is the content type of the text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Metric family types in the text exposition format.
+const (
+	TypeCounter   = "counter"
+	TypeGauge     = "gauge"
+	TypeHistogram = "histogram"
+	TypeUntyped   = "untyped"
+)
+
+// Family is a group of samples exposed under one metric name. An empty Help
+// or Type omits the HELP or TYPE line; Prometheus treats a family without a
+// TYPE line as untyped.
+type Family struct {
+	Name    string
+	Help    string
+	Type    string
+	Samples []Sample
+}
+
+// Sample is a single exposed value. Name defaults to the family name;
+// histograms set it to the _bucket, _sum and _count series. A zero
+// Timestamp is omitted.
+type Sample struct {
+	Name      string
+	Labels    map[string]string
+	Value     float64
+	Timestamp time.Time
+}
+
+// Collector supplies families rendered alongside the stored series.
+type Collector interface {
+	Collect() []Family
+}
+
+// CollectorFunc adapts a function to a Collector.
+type CollectorFunc func() []Family
+
+// Collect calls f.
+func (f CollectorFunc) Collect() []Family { return f() }
+
+// LatestSource returns the most recent point of every stored series.
+type LatestSource interface {
+	LatestValues(ctx context.Context) ([]*domain.Metric, error)
+}
+
+// MetadataSource describes stored metrics by their Forge name.
+type MetadataSource interface {
+	MetadataFor(name string) (domain.MetricMetadata, bool)
+}
+
+// Exporter serves stored series and collector output in the Prometheus
+// text exposition format so Forge can be scraped by a Prometheus server.
+type Exporter struct {
+	source     LatestSource
+	metadata   MetadataSource
+	collectors []Collector
+	logger     ports.Logger
+}
+
+// NewExporter creates an exporter for the series in source.
+func NewExporter(source LatestSource, logger ports.Logger) *Exporter {
+	return &Exporter{source: source, logger: logger}
+}
+
+// AddCollector registers families to expose with every scrape. Collector
+// family names take precedence over stored series that sanitize to the
+// same name. It must be called before the exporter serves requests.
+func (e *Exporter) AddCollector(c Collector) {
+	e.collectors = append(e.collectors, c)
+}
+
+// SetMetadata sets where HELP and TYPE lines for stored series come from.
+// Stored series without metadata are exposed without either line. It must
+// be called before the exporter serves requests.
+func (e *Exporter) SetMetadata(m MetadataSource) {
+	e.metadata = m
+}
+
+// ServeHTTP renders the exposition.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Each name parameter is a glob matched against exposed family names and
+	// the original Forge names of stored series
+	patterns := r.URL.Query()["name"]
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			http.Error(w, fmt.Sprintf("invalid name pattern %q", p), http.StatusBadRequest)
+			return
+		}
+	}
+
+	families, err := e.GatherMatching(r.Context(), patterns)
+	if err != nil {
+		e.logger.Error("Failed to gather metrics for exposition", "error", err)
+		http.Error(w, "failed to gather metrics", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	if err := WriteText(w, families); err != nil {
+		e.logger.Debug("Failed to write exposition", "error", err)
+	}
+}
+
+// Gather returns the collector families followed by one family per stored
+// metric name.
+func (e *Exporter) Gather(ctx context.Context) ([]Family, error) {
+	return e.GatherMatching(ctx, nil)
+}
+
+// GatherMatching is Gather restricted to families whose name, or whose
+// stored metric's Forge name, matches one of the glob patterns. No patterns
+// matches everything. Sanitized names are assigned before filtering so a
+// series keeps its exposed name whatever the filter.
+func (e *Exporter) GatherMatching(ctx context.Context, patterns []string) ([]Family, error) {
+	var families []Family
+	for _, c := range e.collectors {
+		families = append(families, c.Collect()...)
+	}
+
+	metrics, err := e.source.LatestValues(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reserved := make([]string, 0, len(families))
+	for _, f := range families {
+		reserved = append(reserved, f.Name)
+	}
+	names := make([]string, 0, len(metrics))
+	for _, m := range metrics {
+		names = append(names, m.Name)
+	}
+	nameMap := sanitizeNames(names, reserved, SanitizeMetricName)
+
+	if len(patterns) > 0 {
+		kept := families[:0]
+		for _, f := range families {
+			if matchesAny(patterns, f.Name) {
+				kept = append(kept, f)
+			}
+		}
+		families = kept
+	}
+
+	byName := make(map[string]*Family)
+	declared := make(map[string]bool) // families typed by their metadata
+	var order []string
+	for _, m := range metrics {
+		name := nameMap[m.Name]
+		if len(patterns) > 0 && !matchesAny(patterns, m.Name, name) {
+			continue
+		}
+		f, ok := byName[name]
+		if !ok {
+			f = &Family{Name: name}
+			if meta, found := e.lookupMetadata(m.Name); found {
+				f.Help = meta.Description
+				f.Type = familyType(m.Type)
+				if meta.Type != "" {
+					f.Type = familyType(meta.Type)
+					declared[name] = true
+				}
+			}
+			byName[name] = f
+			order = append(order, name)
+		} else if f.Type != "" && !declared[name] && f.Type != familyType(m.Type) {
+			f.Type = TypeUntyped
+		}
+		f.Samples = append(f.Samples, Sample{
+			Labels:    sanitizeLabels(m.Tags),
+			Value:     m.Value,
+			Timestamp: m.Timestamp,
+		})
+	}
+
+	sort.Strings(order)
+	for _, name := range order {
+		f := byName[name]
+		sort.SliceStable(f.Samples, func(i, j int) bool {
+			return labelString(f.Samples[i].Labels) < labelString(f.Samples[j].Labels)
+		})
+		families = append(families, *f)
+	}
+	return families, nil
+}
+
+func (e *Exporter) lookupMetadata(name string) (domain.MetricMetadata, bool) {
+	if e.metadata == nil {
+		return domain.MetricMetadata{}, false
+	}
+	return e.metadata.MetadataFor(name)
+}
+
+// matchesAny reports whether any of names matches any of the glob patterns.
+func matchesAny(patterns []string, names ...string) bool {
+	for _, p := range patterns {
+		for _, n := range names {
+			if ok, _ := path.Match(p, n); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// familyType maps a Forge metric type to an exposition type. Forge
+// histograms are stored as individual observations, so their latest value
+// is exposed as a gauge.
+func familyType(t domain.MetricType) string {
+	if t == domain.MetricTypeCounter {
+		return TypeCounter
+	}
+	return TypeGauge
+}
+
+// WriteText writes families in the text exposition format.
+func WriteText(w io.Writer, families []Family) error {
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		if f.Help != "" {
+			fmt.Fprintf(bw, "# HELP %s %s\n", f.Name, escapeHelp(f.Help))
+		}
+		if f.Type != "" {
+			fmt.Fprintf(bw, "# TYPE %s %s\n", f.Name, f.Type)
+		}
+
+		for _, s := range f.Samples {
+			name := s.Name
+			if name == "" {
+				name = f.Name
+			}
+			bw.WriteString(name)
+			if len(s.Labels) > 0 {
+				bw.WriteString(labelString(s.Labels))
+			}
+			bw.WriteByte(' ')
+			bw.WriteString(formatValue(s.Value))
+			if !s.Timestamp.IsZero() {
+				bw.WriteByte(' ')
+				bw.WriteString(strconv.FormatInt(s.Timestamp.UnixMilli(), 10))
+			}
+			bw.WriteByte('\n')
+		}
+	}
+	return bw.Flush()
+}
+
+// labelString renders labels as {a="1",b="2"} with keys sorted.
+func labelString(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(k)
+		b.WriteString(`="`)
+		b.WriteString(escapeLabelValue(labels[k]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+var (
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabelValue(v string) string { return labelValueEscaper.Replace(v) }
+
+func escapeHelp(v string) string { return helpEscaper.Replace(v) }
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// SanitizeMetricName maps a Forge metric name into the Prometheus metric
+// name charset [a-zA-Z_:][a-zA-Z0-9_:]*. Other characters, including the
+// dots in names such as cpu.usage, become underscores.
+func SanitizeMetricName(name string) string {
+	return sanitize(name, true)
+}
+
+// SanitizeLabelName maps a tag key into the label name charset
+// [a-zA-Z_][a-zA-Z0-9_]*. Names starting with __ are reserved by
+// Prometheus and are prefixed with "tag".
+func SanitizeLabelName(name string) string {
+	s := sanitize(name, false)
+	if strings.HasPrefix(s, "__") {
+		s = "tag" + s
+	}
+	return s
+}
+
+func sanitize(name string, allowColon bool) string {
+	if name == "" {
+		return "_"
+	}
+	b := []byte(name)
+	for i, c := range b {
+		valid := c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') ||
+			(i > 0 && c >= '0' && c <= '9') || (allowColon && c == ':')
+		if !valid {
+			b[i] = '_'
+		}
+	}
+	s := string(b)
+	if name[0] >= '0' && name[0] <= '9' {
+		// Keep the digit rather than replacing it
+		s = "_" + name[:1] + s[1:]
+	}
+	return s
+}
+
+// sanitizeNames maps each distinct name to a sanitized name that is unique
+// among the results and differs from every reserved name. Names that are
+// already valid keep their spelling; the rest are taken in sorted order and
+// get a _2, _3, ... suffix on collision, so the mapping does not depend on
+// input order.
+func sanitizeNames(names, reserved []string, fn func(string) string) map[string]string {
+	taken := make(map[string]bool, len(names)+len(reserved))
+	for _, r := range reserved {
+		taken[r] = true
+	}
+
+	distinct := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, n := range names {
+		if !seen[n] {
+			seen[n] = true
+			distinct = append(distinct, n)
+		}
+	}
+	sort.Strings(distinct)
+
+	result := make(map[string]string, len(distinct))
+	for _, n := range distinct {
+		if fn(n) == n && !taken[n] {
+			result[n] = n
+			taken[n] = true
+		}
+	}
+	for _, n := range distinct {
+		if _, ok := result[n]; ok {
+			continue
+		}
+		base := fn(n)
+		s := base
+		for i := 2; taken[s]; i++ {
+			s = base + "_" + strconv.Itoa(i)
+		}
+		result[n] = s
+		taken[s] = true
+	}
+	return result
+}
+
+// sanitizeLabels converts tags to labels. Empty values are dropped since
+// Prometheus treats them as absent.
+func sanitizeLabels(tags map[string]string) map[string]string {
+	if len(tags) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(tags))
+	for k, v := range tags {
+		if v != "" {
+			keys = append(keys, k)
+		}
+	}
+	keyMap := sanitizeNames(keys, nil, SanitizeLabelName)
+
+	labels := make(map[string]string, len(keys))
+	for _, k := range keys {
+		labels[keyMap[k]] = tags[k]
+	}
+	return labels
+}
@@ -0,0 +1,265 @@
+package prometheus
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/services"
+)
+
+type mockLatestSource struct {
+	metrics []*domain.Metric
+	err     error
+}
+
+func (m *mockLatestSource) LatestValues(ctx context.Context) ([]*domain.Metric, error) {
+	return m.metrics, m.err
+}
+
+type mockMetadataSource map[string]domain.MetricMetadata
+
+func (m mockMetadataSource) MetadataFor(name string) (domain.MetricMetadata, bool) {
+	meta, ok := m[name]
+	return meta, ok
+}
+
+func TestSanitizeMetricName(t *testing.T) {
+	tests := map[string]string{
+		"cpu.usage":           "cpu_usage",
+		"http_requests_total": "http_requests_total",
+		"rule:errors:rate5m":  "rule:errors:rate5m",
+		"disk-io/sda":         "disk_io_sda",
+		"5xx.count":           "_5xx_count",
+		"temp°c":              "temp__c",
+		"":                    "_",
+	}
+	for in, want := range tests {
+		if got := SanitizeMetricName(in); got != want {
+			t.Errorf("SanitizeMetricName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSanitizeLabelName(t *testing.T) {
+	tests := map[string]string{
+		"host":         "host",
+		"k8s.pod":      "k8s_pod",
+		"rule:name":    "rule_name",
+		"__name__":     "tag__name__",
+		"1st":          "_1st",
+		"region-zone":  "region_zone",
+		"Content-Type": "Content_Type",
+	}
+	for in, want := range tests {
+		if got := SanitizeLabelName(in); got != want {
+			t.Errorf("SanitizeLabelName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSanitizeNamesCollisions(t *testing.T) {
+	names := []string{"cpu.usage", "cpu-usage", "cpu_usage", "forge.uptime.seconds"}
+	want := map[string]string{
+		"cpu_usage":            "cpu_usage", // already valid, keeps its name
+		"cpu-usage":            "cpu_usage_2",
+		"cpu.usage":            "cpu_usage_3",
+		"forge.uptime.seconds": "forge_uptime_seconds_2",
+	}
+
+	// The mapping must not depend on the order names arrive in
+	for _, order := range [][]string{names, {names[3], names[2], names[1], names[0]}} {
+		got := sanitizeNames(order, []string{"forge_uptime_seconds"}, SanitizeMetricName)
+		for in, w := range want {
+			if got[in] != w {
+				t.Errorf("order %v: %q mapped to %q, want %q", order, in, got[in], w)
+			}
+		}
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	ts := time.UnixMilli(1700000000123)
+	families := []Family{
+		{
+			Name: "queue_depth",
+			Help: "Depth of the queue.\nMultiline \\ help.",
+			Type: TypeGauge,
+			Samples: []Sample{
+				{Labels: map[string]string{"queue": "jobs", "path": `C:\tmp`}, Value: 3, Timestamp: ts},
+				{Labels: map[string]string{"queue": "say \"hi\"\nnow"}, Value: math.Inf(1)},
+			},
+		},
+		{
+			Name: "latency_seconds",
+			Type: TypeHistogram,
+			Samples: []Sample{
+				{Name: "latency_seconds_bucket", Labels: map[string]string{"le": "0.5"}, Value: 1},
+				{Name: "latency_seconds_bucket", Labels: map[string]string{"le": "+Inf"}, Value: 2},
+				{Name: "latency_seconds_sum", Value: 0.75},
+				{Name: "latency_seconds_count", Value: 2},
+			},
+		},
+		{Name: "stale", Samples: []Sample{{Value: math.NaN()}}},
+	}
+
+	var b strings.Builder
+	if err := WriteText(&b, families); err != nil {
+		t.Fatalf("WriteText failed: %v", err)
+	}
+
+	want := `# HELP queue_depth Depth of the queue.\nMultiline \\ help.
+# TYPE queue_depth gauge
+queue_depth{path="C:\\tmp",queue="jobs"} 3 1700000000123
+queue_depth{queue="say \"hi\"\nnow"} +Inf
+# TYPE latency_seconds histogram
+latency_seconds_bucket{le="0.5"} 1
+latency_seconds_bucket{le="+Inf"} 2
+latency_seconds_sum 0.75
+latency_seconds_count 2
+stale NaN
+`
+	if b.String() != want {
+		t.Errorf("unexpected exposition:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+func TestExporter(t *testing.T) {
+	ts := time.UnixMilli(1700000000000)
+	newMetric := func(name string, typ domain.MetricType, value float64, tags map[string]string) *domain.Metric {
+		m := domain.NewMetric(name, typ, value, tags)
+		m.Timestamp = ts
+		return m
+	}
+	source := &mockLatestSource{metrics: []*domain.Metric{
+		newMetric("cpu.usage", domain.MetricTypeGauge, 40, map[string]string{"host": "b", "k8s.pod": "api-1"}),
+		newMetric("cpu.usage", domain.MetricTypeGauge, 20, map[string]string{"host": "a", "empty": ""}),
+		newMetric("requests", domain.MetricTypeCounter, 7, nil),
+		newMetric("forge.up", domain.MetricTypeGauge, 1, nil),
+	}}
+
+	exporter := NewExporter(source, &services.NopLogger{})
+	exporter.AddCollector(CollectorFunc(func() []Family {
+		return []Family{{Name: "forge_up", Type: TypeGauge, Samples: []Sample{{Value: 1}}}}
+	}))
+	exporter.SetMetadata(mockMetadataSource{
+		"cpu.usage": {Metric: "cpu.usage", Description: "CPU usage in percent."},
+		"requests":  {Metric: "requests", Description: "Requests served.\nIncludes retries."},
+	})
+
+	rec := httptest.NewRecorder()
+	exporter.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, MetricsPath, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != ContentType {
+		t.Errorf("unexpected content type %q", ct)
+	}
+
+	want := `# TYPE forge_up gauge
+forge_up 1
+# HELP cpu_usage CPU usage in percent.
+# TYPE cpu_usage gauge
+cpu_usage{host="a"} 20 1700000000000
+cpu_usage{host="b",k8s_pod="api-1"} 40 1700000000000
+forge_up_2 1 1700000000000
+# HELP requests Requests served.\nIncludes retries.
+# TYPE requests counter
+requests 7 1700000000000
+`
+	if got := rec.Body.String(); got != want {
+		t.Errorf("unexpected exposition:\n%s\nwant:\n%s", got, want)
+	}
+
+	rec = httptest.NewRecorder()
+	exporter.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, MetricsPath, nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: expected 405, got %d", rec.Code)
+	}
+
+	source.err = fmt.Errorf("database is locked")
+	rec = httptest.NewRecorder()
+	exporter.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, MetricsPath, nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("source failure: expected 500, got %d", rec.Code)
+	}
+}
+
+func TestExporterNameFilter(t *testing.T) {
+	source := &mockLatestSource{metrics: []*domain.Metric{
+		domain.NewMetric("cpu.usage", domain.MetricTypeGauge, 1, map[string]string{"path": `C:\tmp "x"`}),
+		domain.NewMetric("mem.used", domain.MetricTypeGauge, 2, nil),
+	}}
+	exporter := NewExporter(source, &services.NopLogger{})
+	exporter.AddCollector(CollectorFunc(func() []Family {
+		return []Family{{Name: "forge_up", Type: TypeGauge, Samples: []Sample{{Value: 1}}}}
+	}))
+
+	tests := map[string][]string{
+		"":                            {"forge_up", "cpu_usage", "mem_used"},
+		"?name=cpu.*":                 {"cpu_usage"},
+		"?name=mem_used":              {"mem_used"},
+		"?name=forge_*&name=mem.used": {"forge_up", "mem_used"},
+		"?name=disk.*":                nil,
+	}
+	for query, want := range tests {
+		families, err := exporter.GatherMatching(context.Background(), httptest.NewRequest(http.MethodGet, MetricsPath+query, nil).URL.Query()["name"])
+		if err != nil {
+			t.Fatalf("%q: GatherMatching failed: %v", query, err)
+		}
+		var got []string
+		for _, f := range families {
+			got = append(got, f.Name)
+		}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("%q: got families %v, want %v", query, got, want)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	exporter.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, MetricsPath+"?name=cpu.*", nil))
+	if want := `cpu_usage{path="C:\\tmp \"x\""} 1`; !strings.Contains(rec.Body.String(), want) {
+		t.Errorf("expected escaped sample %s in:\n%s", want, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	exporter.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, MetricsPath+"?name=%5B", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid pattern: expected 400, got %d", rec.Code)
+	}
+}
+
+func TestExporterMetadataType(t *testing.T) {
+	source := &mockLatestSource{metrics: []*domain.Metric{
+		domain.NewMetric("jobs.done", domain.MetricTypeGauge, 3, map[string]string{"queue": "a"}),
+		domain.NewMetric("jobs.done", domain.MetricTypeCounter, 4, map[string]string{"queue": "b"}),
+		domain.NewMetric("jobs.mixed", domain.MetricTypeGauge, 1, map[string]string{"queue": "a"}),
+		domain.NewMetric("jobs.mixed", domain.MetricTypeCounter, 2, map[string]string{"queue": "b"}),
+	}}
+	exporter := NewExporter(source, &services.NopLogger{})
+	exporter.SetMetadata(mockMetadataSource{
+		"jobs.done":  {Metric: "jobs.done", Description: "Jobs done.", Type: domain.MetricTypeCounter},
+		"jobs.mixed": {Metric: "jobs.mixed", Description: "Jobs, typed by their points."},
+	})
+
+	families, err := exporter.Gather(context.Background())
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+	types := map[string]string{}
+	for _, f := range families {
+		types[f.Name] = f.Type
+	}
+	if types["jobs_done"] != TypeCounter {
+		t.Errorf("expected the metadata type to win, got %q", types["jobs_done"])
+	}
+	if types["jobs_mixed"] != TypeUntyped {
+		t.Errorf("expected mixed point types without a declared type to be untyped, got %q", types["jobs_mixed"])
+	}
+}
@@ -0,0 +1,173 @@
+package prometheus
+
+import (
+	"fmt"
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The types below hold the parts of a prometheus.WriteRequest (remote_write
+// 1.0) that Forge stores. Native histograms and exemplars are skipped.
+
+type writeRequest struct {
+	Series   []timeSeries
+	Metadata []metricMetadata
+}
+
+type timeSeries struct {
+	Labels  []label
+	Samples []sample
+}
+
+type label struct {
+	Name  string
+	Value string
+}
+
+type sample struct {
+	Value     float64
+	Timestamp int64 // unix milliseconds
+}
+
+type metricMetadata struct {
+	Type       int32
+	FamilyName string
+}
+
+// MetricMetadata.MetricType values.
+const (
+	metadataCounter   = 1
+	metadataGauge     = 2
+	metadataHistogram = 3
+	metadataSummary   = 5
+)
+
+// decodeWriteRequest decodes a WriteRequest message.
+func decodeWriteRequest(b []byte) (*writeRequest, error) {
+	req := &writeRequest{}
+	err := walkFields(b, func(f wireField) error {
+		if !f.isBytes() {
+			return nil
+		}
+		switch f.num {
+		case 1:
+			ts, err := decodeTimeSeries(f.bytes)
+			if err != nil {
+				return err
+			}
+			req.Series = append(req.Series, ts)
+		case 3:
+			md, err := decodeMetadata(f.bytes)
+			if err != nil {
+				return err
+			}
+			req.Metadata = append(req.Metadata, md)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode write request: %w", err)
+	}
+	return req, nil
+}
+
+func decodeTimeSeries(b []byte) (timeSeries, error) {
+	var ts timeSeries
+	err := walkFields(b, func(f wireField) error {
+		if !f.isBytes() {
+			return nil
+		}
+		switch f.num {
+		case 1:
+			var l label
+			err := walkFields(f.bytes, func(f wireField) error {
+				switch {
+				case f.num == 1 && f.isBytes():
+					l.Name = string(f.bytes)
+				case f.num == 2 && f.isBytes():
+					l.Value = string(f.bytes)
+				}
+				return nil
+			})
+			ts.Labels = append(ts.Labels, l)
+			return err
+		case 2:
+			var s sample
+			err := walkFields(f.bytes, func(f wireField) error {
+				switch {
+				case f.num == 1 && f.typ == protowire.Fixed64Type:
+					s.Value = math.Float64frombits(f.value)
+				case f.num == 2 && f.typ == protowire.VarintType:
+					s.Timestamp = int64(f.value)
+				}
+				return nil
+			})
+			ts.Samples = append(ts.Samples, s)
+			return err
+		}
+		return nil
+	})
+	return ts, err
+}
+
+func decodeMetadata(b []byte) (metricMetadata, error) {
+	var md metricMetadata
+	err := walkFields(b, func(f wireField) error {
+		switch {
+		case f.num == 1 && f.typ == protowire.VarintType:
+			md.Type = int32(f.value)
+		case f.num == 2 && f.isBytes():
+			md.FamilyName = string(f.bytes)
+		}
+		return nil
+	})
+	return md, err
+}
+
+// wireField is a single decoded protobuf field.
+type wireField struct {
+	num   protowire.Number
+	typ   protowire.Type
+	value uint64 // varint, fixed32 and fixed64 values
+	bytes []byte // length-delimited values
+}
+
+func (f wireField) isBytes() bool { return f.typ == protowire.BytesType }
+
+// walkFields calls fn for every field in a protobuf message. Group fields
+// are skipped.
+func walkFields(b []byte, fn func(f wireField) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		f := wireField{num: num, typ: typ}
+		switch typ {
+		case protowire.VarintType:
+			f.value, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed64Type:
+			f.value, n = protowire.ConsumeFixed64(b)
+		case protowire.Fixed32Type:
+			var v uint32
+			v, n = protowire.ConsumeFixed32(b)
+			f.value = uint64(v)
+		case protowire.BytesType:
+			f.bytes, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+// Package prometheus accepts Prometheus remote_write requests so existing
+// exporters and Prometheus servers can send metrics to Forge.
+//
+// Metric names are stored exactly as Prometheus sends them, e.g.
+// http_requests_total stays http_requests_total, and are queried in Forge
+// under that name. Prometheus names never contain dots, so they do not
+// collide with Forge's dotted names such as cpu.usage. All labels except
+// __name__ become tags with the same name; labels with an empty value are
+// absent in Prometheus and are dropped. Histograms and summaries arrive as
+// their component series (_bucket, _sum, _count and quantiles) and are
+// stored that way.
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+)
+
+// WritePath is where Prometheus remote_write requests are served.
+const WritePath = "/api/v1/write"
+
+// DefaultMaxBodySize bounds a remote_write request, both as sent and after
+// decompression.
+const DefaultMaxBodySize = 10 << 20
+
+// MetricSink stores metrics received over remote_write.
+type MetricSink interface {
+	RecordBatch(ctx context.Context, metrics []*domain.Metric) error
+}
+
+// RemoteWriteHandler handles Prometheus remote_write 1.0 requests. Each
+// request is converted and written in a single batch.
+type RemoteWriteHandler struct {
+	sink        MetricSink
+	maxBodySize int
+	logger      ports.Logger
+}
+
+// NewRemoteWriteHandler creates a handler that rejects payloads larger than
+// maxBodySize bytes. A non-positive size uses DefaultMaxBodySize.
+func NewRemoteWriteHandler(sink MetricSink, maxBodySize int, logger ports.Logger) *RemoteWriteHandler {
+	if maxBodySize <= 0 {
+		maxBodySize = DefaultMaxBodySize
+	}
+	return &RemoteWriteHandler{
+		sink:        sink,
+		maxBodySize: maxBodySize,
+		logger:      logger,
+	}
+}
+
+// ServeHTTP handles POST /api/v1/write. Malformed and oversized payloads get
+// a 4xx status so senders drop them instead of retrying; store failures get
+// a 5xx status so they are retried.
+func (h *RemoteWriteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		mediaType, params, err := mime.ParseMediaType(ct)
+		if err != nil || mediaType != "application/x-protobuf" {
+			http.Error(w, "content type must be application/x-protobuf", http.StatusUnsupportedMediaType)
+			return
+		}
+		if proto := params["proto"]; proto != "" && proto != "prometheus.WriteRequest" {
+			http.Error(w, fmt.Sprintf("unsupported remote write message %q", proto), http.StatusUnsupportedMediaType)
+			return
+		}
+	}
+	if enc := r.Header.Get("Content-Encoding"); enc != "" && !strings.EqualFold(enc, "snappy") {
+		http.Error(w, "content encoding must be snappy", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	compressed, err := io.ReadAll(http.MaxBytesReader(w, r.Body, int64(h.maxBodySize)))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			http.Error(w, fmt.Sprintf("request body exceeds %d bytes", h.maxBodySize), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, fmt.Sprintf("failed to read body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	body, err := decodeSnappy(compressed, h.maxBodySize)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid snappy payload: %v", err), http.StatusBadRequest)
+		return
+	}
+	req, err := decodeWriteRequest(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	metrics, dropped := convertWriteRequest(req)
+	if len(metrics) > 0 {
+		err := h.sink.RecordBatch(r.Context(), metrics)
+		if errors.Is(err, ports.ErrSeriesLimitExceeded) || errors.Is(err, ports.ErrNonFiniteValue) {
+			// The accepted samples were written; a retry would drop the rest again
+			h.logger.Warn("Dropped remote write samples", "samples", len(metrics), "error", err)
+		} else if err != nil {
+			h.logger.Error("Failed to store remote write samples", "samples", len(metrics), "error", err)
+			http.Error(w, "failed to store samples", http.StatusInternalServerError)
+			return
+		}
+	}
+	h.logger.Debug("Received remote write samples", "samples", len(metrics), "dropped", dropped)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// convertWriteRequest converts the samples in a write request. Series
+// without a metric name and NaN samples, which include Prometheus staleness
+// markers, are counted as dropped.
+func convertWriteRequest(req *writeRequest) ([]*domain.Metric, int) {
+	families := make(map[string]int32, len(req.Metadata))
+	for _, md := range req.Metadata {
+		families[md.FamilyName] = md.Type
+	}
+
+	var metrics []*domain.Metric
+	dropped := 0
+	for _, ts := range req.Series {
+		name, tags := seriesLabels(ts.Labels)
+		if name == "" {
+			dropped += len(ts.Samples)
+			continue
+		}
+		metricType := inferType(name, families)
+
+		for _, s := range ts.Samples {
+			if math.IsNaN(s.Value) {
+				dropped++
+				continue
+			}
+			m := domain.NewMetric(name, metricType, s.Value, tags)
+			m.Timestamp = time.UnixMilli(s.Timestamp)
+			metrics = append(metrics, m)
+		}
+	}
+	return metrics, dropped
+}
+
+// seriesLabels splits labels into the metric name and tags.
+func seriesLabels(labels []label) (string, map[string]string) {
+	var name string
+	tags := make(map[string]string, len(labels))
+	for _, l := range labels {
+		switch {
+		case l.Name == "__name__":
+			name = l.Value
+		case l.Value != "":
+			tags[l.Name] = l.Value
+		}
+	}
+	return name, tags
+}
+
+// componentSuffixes are the series a histogram or summary is sent as.
+var componentSuffixes = []string{"_bucket", "_sum", "_count"}
+
+// inferType picks the Forge metric type for a series. Metadata, when the
+// sender includes it, names the type of each family; otherwise the
+// Prometheus naming conventions are used. Histogram and summary components
+// are cumulative and stored as counters, summary quantiles as gauges.
+func inferType(name string, families map[string]int32) domain.MetricType {
+	if t, ok := families[name]; ok {
+		switch t {
+		case metadataCounter:
+			return domain.MetricTypeCounter
+		case metadataHistogram, metadataSummary, metadataGauge:
+			return domain.MetricTypeGauge
+		}
+	}
+	for _, suffix := range componentSuffixes {
+		base, ok := strings.CutSuffix(name, suffix)
+		if !ok {
+			continue
+		}
+		if t, ok := families[base]; ok && t != metadataHistogram && t != metadataSummary {
+			return domain.MetricTypeGauge
+		}
+		return domain.MetricTypeCounter
+	}
+	if strings.HasSuffix(name, "_total") {
+		return domain.MetricTypeCounter
+	}
+	return domain.MetricTypeGauge
+}
@@ -0,0 +1,254 @@
+package prometheus
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+	"github.com/forge-platform/forge/internal/core/services"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+type mockSink struct {
+	metrics []*domain.Metric
+	err     error
+}
+
+func (m *mockSink) RecordBatch(ctx context.Context, metrics []*domain.Metric) error {
+	m.metrics = append(m.metrics, metrics...)
+	return m.err
+}
+
+// testSeries describes a series to encode into a WriteRequest.
+type testSeries struct {
+	labels  []string // name, value pairs
+	samples []sample
+}
+
+func encodeWriteRequest(series []testSeries, metadata []metricMetadata) []byte {
+	var b []byte
+	for _, s := range series {
+		var ts []byte
+		for i := 0; i+1 < len(s.labels); i += 2 {
+			var l []byte
+			l = protowire.AppendTag(l, 1, protowire.BytesType)
+			l = protowire.AppendString(l, s.labels[i])
+			l = protowire.AppendTag(l, 2, protowire.BytesType)
+			l = protowire.AppendString(l, s.labels[i+1])
+			ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, l)
+		}
+		for _, smp := range s.samples {
+			var sb []byte
+			sb = protowire.AppendTag(sb, 1, protowire.Fixed64Type)
+			sb = protowire.AppendFixed64(sb, math.Float64bits(smp.Value))
+			sb = protowire.AppendTag(sb, 2, protowire.VarintType)
+			sb = protowire.AppendVarint(sb, uint64(smp.Timestamp))
+			ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, sb)
+		}
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, ts)
+	}
+	for _, md := range metadata {
+		var mb []byte
+		mb = protowire.AppendTag(mb, 1, protowire.VarintType)
+		mb = protowire.AppendVarint(mb, uint64(md.Type))
+		mb = protowire.AppendTag(mb, 2, protowire.BytesType)
+		mb = protowire.AppendString(mb, md.FamilyName)
+		b = protowire.AppendTag(b, 3, protowire.BytesType)
+		b = protowire.AppendBytes(b, mb)
+	}
+	return b
+}
+
+// encodeSnappy produces a valid snappy block made only of literals.
+func encodeSnappy(data []byte) []byte {
+	out := binary.AppendUvarint(nil, uint64(len(data)))
+	for len(data) > 0 {
+		n := len(data)
+		if n > 60 {
+			n = 60
+		}
+		out = append(out, byte(n-1)<<2)
+		out = append(out, data[:n]...)
+		data = data[n:]
+	}
+	return out
+}
+
+func postWrite(t *testing.T, h http.Handler, body []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, WritePath, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestDecodeSnappy(t *testing.T) {
+	// "abcd" as a literal, then 11- and 5-byte copies at offset 4
+	block := []byte{20, 0x0c, 'a', 'b', 'c', 'd', 0x1d, 4, 0x05, 4}
+	got, err := decodeSnappy(block, 100)
+	if err != nil {
+		t.Fatalf("decodeSnappy failed: %v", err)
+	}
+	if string(got) != "abcdabcdabcdabcdabcd" {
+		t.Errorf("unexpected output %q", got)
+	}
+
+	// 2-byte offset copy
+	block = []byte{8, 0x0c, 'w', 'x', 'y', 'z', 0x0e, 4, 0}
+	if got, err := decodeSnappy(block, 100); err != nil || string(got) != "wxyzwxyz" {
+		t.Errorf("expected wxyzwxyz, got %q, %v", got, err)
+	}
+
+	long := bytes.Repeat([]byte("0123456789"), 30)
+	if got, err := decodeSnappy(encodeSnappy(long), 1000); err != nil || !bytes.Equal(got, long) {
+		t.Errorf("literal round trip failed: %v", err)
+	}
+
+	if _, err := decodeSnappy(block, 4); err == nil {
+		t.Error("expected an error when the decoded length exceeds the limit")
+	}
+	for _, bad := range [][]byte{
+		{},
+		{5, 0x0c, 'a'},           // truncated literal
+		{8, 0x05, 9},             // copy before any output
+		{4, 0x0c, 'a', 'b', 'c'}, // shorter than the header claims
+	} {
+		if _, err := decodeSnappy(bad, 100); err == nil {
+			t.Errorf("expected an error for %v", bad)
+		}
+	}
+}
+
+func TestRemoteWriteHandler(t *testing.T) {
+	sink := &mockSink{}
+	h := NewRemoteWriteHandler(sink, 0, &services.NopLogger{})
+
+	ts := time.Now().Add(-time.Minute).Truncate(time.Millisecond)
+	payload := encodeWriteRequest([]testSeries{
+		{
+			labels:  []string{"__name__", "http_requests_total", "method", "GET", "code", "200", "empty", ""},
+			samples: []sample{{Value: 10, Timestamp: ts.UnixMilli()}, {Value: 12, Timestamp: ts.Add(15 * time.Second).UnixMilli()}},
+		},
+		{
+			labels:  []string{"__name__", "request_duration_seconds_bucket", "le", "0.5"},
+			samples: []sample{{Value: 7, Timestamp: ts.UnixMilli()}},
+		},
+		{
+			labels:  []string{"__name__", "rpc_latency_seconds", "quantile", "0.99"},
+			samples: []sample{{Value: 0.25, Timestamp: ts.UnixMilli()}},
+		},
+		{
+			labels:  []string{"__name__", "node_load1"},
+			samples: []sample{{Value: math.NaN(), Timestamp: ts.UnixMilli()}, {Value: 1.5, Timestamp: ts.UnixMilli()}},
+		},
+		{
+			labels:  []string{"job", "orphan"},
+			samples: []sample{{Value: 1, Timestamp: ts.UnixMilli()}},
+		},
+	}, []metricMetadata{{Type: metadataSummary, FamilyName: "rpc_latency_seconds"}})
+
+	rec := postWrite(t, h, encodeSnappy(payload))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(sink.metrics) != 5 {
+		t.Fatalf("expected 5 metrics, got %d", len(sink.metrics))
+	}
+
+	first := sink.metrics[0]
+	if first.Name != "http_requests_total" || first.Type != domain.MetricTypeCounter || first.Value != 10 {
+		t.Errorf("unexpected first metric: %+v", first)
+	}
+	if !first.Timestamp.Equal(ts) {
+		t.Errorf("expected timestamp %v, got %v", ts, first.Timestamp)
+	}
+	if len(first.Tags) != 2 || first.Tags["method"] != "GET" || first.Tags["code"] != "200" {
+		t.Errorf("expected method and code tags only, got %v", first.Tags)
+	}
+
+	wantTypes := map[string]domain.MetricType{
+		"request_duration_seconds_bucket": domain.MetricTypeCounter,
+		"rpc_latency_seconds":             domain.MetricTypeGauge,
+		"node_load1":                      domain.MetricTypeGauge,
+	}
+	for _, m := range sink.metrics[2:] {
+		if m.Type != wantTypes[m.Name] {
+			t.Errorf("%s: expected type %s, got %s", m.Name, wantTypes[m.Name], m.Type)
+		}
+	}
+	if last := sink.metrics[4]; last.Name != "node_load1" || last.Value != 1.5 {
+		t.Errorf("expected the NaN sample to be dropped, got %+v", last)
+	}
+}
+
+func TestRemoteWriteHandler_Rejects(t *testing.T) {
+	sink := &mockSink{}
+	h := NewRemoteWriteHandler(sink, 1024, &services.NopLogger{})
+
+	req := httptest.NewRequest(http.MethodGet, WritePath, nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET: expected 405, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, WritePath, bytes.NewReader(encodeSnappy(nil)))
+	req.Header.Set("Content-Type", "application/x-protobuf;proto=io.prometheus.write.v2.Request")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("remote write 2.0: expected 415, got %d", rec.Code)
+	}
+
+	if rec := postWrite(t, h, bytes.Repeat([]byte{0}, 2048)); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized body: expected 413, got %d", rec.Code)
+	}
+
+	// Small on the wire but claiming a large decoded size
+	bomb := binary.AppendUvarint(nil, 1<<30)
+	if rec := postWrite(t, h, bomb); rec.Code != http.StatusBadRequest {
+		t.Errorf("oversized decoded payload: expected 400, got %d", rec.Code)
+	}
+
+	if rec := postWrite(t, h, []byte("not snappy")); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid snappy: expected 400, got %d", rec.Code)
+	}
+	if rec := postWrite(t, h, encodeSnappy([]byte{0x0a, 0x05, 0x01})); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid protobuf: expected 400, got %d", rec.Code)
+	}
+	if len(sink.metrics) != 0 {
+		t.Errorf("expected nothing stored, got %d metrics", len(sink.metrics))
+	}
+}
+
+func TestRemoteWriteHandler_StoreErrors(t *testing.T) {
+	payload := encodeSnappy(encodeWriteRequest([]testSeries{{
+		labels:  []string{"__name__", "up"},
+		samples: []sample{{Value: 1, Timestamp: time.Now().UnixMilli()}},
+	}}, nil))
+
+	sink := &mockSink{err: fmt.Errorf("database is locked")}
+	if rec := postWrite(t, NewRemoteWriteHandler(sink, 0, &services.NopLogger{}), payload); rec.Code != http.StatusInternalServerError {
+		t.Errorf("store failure: expected 500, got %d", rec.Code)
+	}
+
+	// Samples over the series limit are dropped rather than retried
+	sink = &mockSink{err: fmt.Errorf("write: %w", ports.ErrSeriesLimitExceeded)}
+	if rec := postWrite(t, NewRemoteWriteHandler(sink, 0, &services.NopLogger{}), payload); rec.Code != http.StatusNoContent {
+		t.Errorf("series limit: expected 204, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,91 @@
+package prometheus
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+var errCorruptSnappy = errors.New("corrupt snappy block")
+
+// decodeSnappy decodes a snappy block, the framing-less format remote_write
+// payloads use. The decoded length is read from the block header and checked
+// against maxLen before anything is allocated.
+func decodeSnappy(src []byte, maxLen int) ([]byte, error) {
+	n, k := binary.Uvarint(src)
+	if k <= 0 {
+		return nil, errCorruptSnappy
+	}
+	if n > uint64(maxLen) {
+		return nil, fmt.Errorf("decoded payload of %d bytes exceeds limit of %d", n, maxLen)
+	}
+
+	dst := make([]byte, 0, n)
+	s := k
+	for s < len(src) {
+		tag := src[s]
+		var length, offset int
+
+		switch tag & 0x03 {
+		case 0x00: // literal
+			x := int(tag >> 2)
+			s++
+			if x >= 60 {
+				extra := x - 59
+				if s+extra > len(src) {
+					return nil, errCorruptSnappy
+				}
+				x = 0
+				for i := extra - 1; i >= 0; i-- {
+					x = x<<8 | int(src[s+i])
+				}
+				s += extra
+			}
+			length = x + 1
+			if length <= 0 || s+length > len(src) || len(dst)+length > int(n) {
+				return nil, errCorruptSnappy
+			}
+			dst = append(dst, src[s:s+length]...)
+			s += length
+			continue
+
+		case 0x01: // copy with 1-byte offset
+			if s+2 > len(src) {
+				return nil, errCorruptSnappy
+			}
+			length = 4 + int(tag>>2)&0x07
+			offset = int(tag&0xe0)<<3 | int(src[s+1])
+			s += 2
+
+		case 0x02: // copy with 2-byte offset
+			if s+3 > len(src) {
+				return nil, errCorruptSnappy
+			}
+			length = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint16(src[s+1:]))
+			s += 3
+
+		case 0x03: // copy with 4-byte offset
+			if s+5 > len(src) {
+				return nil, errCorruptSnappy
+			}
+			length = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint32(src[s+1:]))
+			s += 5
+		}
+
+		if offset <= 0 || offset > len(dst) || len(dst)+length > int(n) {
+			return nil, errCorruptSnappy
+		}
+		// Copies may overlap their own output, so go byte by byte
+		start := len(dst) - offset
+		for i := 0; i < length; i++ {
+			dst = append(dst, dst[start+i])
+		}
+	}
+
+	if len(dst) != int(n) {
+		return nil, errCorruptSnappy
+	}
+	return dst, nil
+}
@@ -0,0 +1,193 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+	"github.com/google/uuid"
+)
+
+// APIKeyRepository implements ports.APIKeyRepository using SQLite.
+type APIKeyRepository struct {
+	db *DB
+}
+
+// NewAPIKeyRepository creates a new API key repository.
+func NewAPIKeyRepository(db *DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+const apiKeyColumns = `id, user_id, name, key_hash, key_prefix, permissions, expires_at, last_used_at, created_at, revoked_at`
+
+// Create persists a new API key.
+func (r *APIKeyRepository) Create(ctx context.Context, key *domain.APIKey) error {
+	permissionsJSON, err := json.Marshal(key.Permissions)
+	if err != nil {
+		return fmt.Errorf("failed to marshal API key permissions: %w", err)
+	}
+	idBytes, _ := key.ID.MarshalBinary()
+	userIDBytes, _ := key.UserID.MarshalBinary()
+
+	query := `
+		INSERT INTO api_keys (` + apiKeyColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err = r.db.conn.ExecContext(ctx, query,
+		idBytes,
+		userIDBytes,
+		key.Name,
+		key.KeyHash,
+		key.KeyPrefix,
+		permissionsJSON,
+		nullableMillis(key.ExpiresAt),
+		nullableMillis(key.LastUsedAt),
+		key.CreatedAt.UnixMilli(),
+		nullableMillis(key.RevokedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert API key: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves an API key by its ID.
+func (r *APIKeyRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.APIKey, error) {
+	idBytes, _ := id.MarshalBinary()
+	row := r.db.conn.QueryRowContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE id = ?", idBytes)
+	return scanAPIKey(row)
+}
+
+// GetByPrefix retrieves API keys with the given key prefix.
+func (r *APIKeyRepository) GetByPrefix(ctx context.Context, prefix string) ([]*domain.APIKey, error) {
+	return r.list(ctx, " WHERE key_prefix = ?", prefix)
+}
+
+// GetByUserID retrieves all API keys for a user.
+func (r *APIKeyRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.APIKey, error) {
+	userIDBytes, _ := userID.MarshalBinary()
+	return r.list(ctx, " WHERE user_id = ?", userIDBytes)
+}
+
+// Update updates an existing API key.
+func (r *APIKeyRepository) Update(ctx context.Context, key *domain.APIKey) error {
+	permissionsJSON, err := json.Marshal(key.Permissions)
+	if err != nil {
+		return fmt.Errorf("failed to marshal API key permissions: %w", err)
+	}
+	idBytes, _ := key.ID.MarshalBinary()
+
+	query := `
+		UPDATE api_keys SET
+			name = ?, permissions = ?, expires_at = ?, last_used_at = ?, revoked_at = ?
+		WHERE id = ?
+	`
+	result, err := r.db.conn.ExecContext(ctx, query,
+		key.Name,
+		permissionsJSON,
+		nullableMillis(key.ExpiresAt),
+		nullableMillis(key.LastUsedAt),
+		nullableMillis(key.RevokedAt),
+		idBytes,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update API key: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("API key not found")
+	}
+	return nil
+}
+
+// Delete removes an API key.
+func (r *APIKeyRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	idBytes, _ := id.MarshalBinary()
+	result, err := r.db.conn.ExecContext(ctx, "DELETE FROM api_keys WHERE id = ?", idBytes)
+	if err != nil {
+		return fmt.Errorf("failed to delete API key: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("API key not found")
+	}
+	return nil
+}
+
+// DeleteByUserID removes all API keys for a user.
+func (r *APIKeyRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	userIDBytes, _ := userID.MarshalBinary()
+	if _, err := r.db.conn.ExecContext(ctx, "DELETE FROM api_keys WHERE user_id = ?", userIDBytes); err != nil {
+		return fmt.Errorf("failed to delete API keys: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired removes API keys whose expiry has passed.
+func (r *APIKeyRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	result, err := r.db.conn.ExecContext(ctx,
+		"DELETE FROM api_keys WHERE expires_at IS NOT NULL AND expires_at < ?", time.Now().UnixMilli())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired API keys: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+func (r *APIKeyRepository) list(ctx context.Context, where string, args ...interface{}) ([]*domain.APIKey, error) {
+	query := "SELECT " + apiKeyColumns + " FROM api_keys" + where + " ORDER BY created_at"
+	rows, err := r.db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []*domain.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func scanAPIKey(row rowScanner) (*domain.APIKey, error) {
+	var (
+		idBytes         []byte
+		userIDBytes     []byte
+		permissionsJSON sql.NullString
+		expiresAt       sql.NullInt64
+		lastUsedAt      sql.NullInt64
+		createdAt       int64
+		revokedAt       sql.NullInt64
+		key             domain.APIKey
+	)
+
+	err := row.Scan(&idBytes, &userIDBytes, &key.Name, &key.KeyHash, &key.KeyPrefix,
+		&permissionsJSON, &expiresAt, &lastUsedAt, &createdAt, &revokedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("API key not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan API key: %w", err)
+	}
+
+	key.ID, _ = uuid.FromBytes(idBytes)
+	key.UserID, _ = uuid.FromBytes(userIDBytes)
+	key.ExpiresAt = millisTime(expiresAt)
+	key.LastUsedAt = millisTime(lastUsedAt)
+	key.RevokedAt = millisTime(revokedAt)
+	key.CreatedAt = time.UnixMilli(createdAt)
+
+	if permissionsJSON.Valid && permissionsJSON.String != "" && permissionsJSON.String != "null" {
+		_ = json.Unmarshal([]byte(permissionsJSON.String), &key.Permissions)
+	}
+
+	return &key, nil
+}
+
+// Ensure APIKeyRepository implements the interface
+var _ ports.APIKeyRepository = (*APIKeyRepository)(nil)
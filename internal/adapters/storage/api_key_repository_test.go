@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/google/uuid"
+)
+
+func TestAPIKeyRepository_CRUD(t *testing.T) {
+	repo := NewAPIKeyRepository(setupTestDB(t))
+	ctx := context.Background()
+	userID := uuid.New()
+
+	apiKey, key, err := domain.GenerateAPIKey(userID, "ci", []string{"metrics:write"}, nil)
+	if err != nil {
+		t.Fatalf("GenerateAPIKey failed: %v", err)
+	}
+	if err := repo.Create(ctx, apiKey); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	matches, err := repo.GetByPrefix(ctx, key[:8])
+	if err != nil {
+		t.Fatalf("GetByPrefix failed: %v", err)
+	}
+	if len(matches) != 1 || !matches[0].ValidateKey(key) || matches[0].UserID != userID {
+		t.Fatalf("expected the key to be found by prefix, got %d", len(matches))
+	}
+	if !matches[0].HasPermission("metrics:write") || matches[0].ExpiresAt != nil {
+		t.Errorf("unexpected key: %+v", matches[0])
+	}
+
+	matches[0].Revoke()
+	if err := repo.Update(ctx, matches[0]); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if got, _ := repo.GetByID(ctx, apiKey.ID); got == nil || got.RevokedAt == nil {
+		t.Error("expected revocation to be persisted")
+	}
+
+	past := time.Now().Add(-time.Hour)
+	expired, _, _ := domain.GenerateAPIKey(userID, "old", nil, &past)
+	if err := repo.Create(ctx, expired); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if keys, _ := repo.GetByUserID(ctx, userID); len(keys) != 2 {
+		t.Errorf("expected 2 keys for user, got %d", len(keys))
+	}
+	if n, err := repo.DeleteExpired(ctx); err != nil || n != 1 {
+		t.Errorf("expected 1 expired key deleted, got %d (%v)", n, err)
+	}
+
+	if err := repo.DeleteByUserID(ctx, userID); err != nil {
+		t.Fatalf("DeleteByUserID failed: %v", err)
+	}
+	if keys, _ := repo.GetByUserID(ctx, userID); len(keys) != 0 {
+		t.Errorf("expected no keys left, got %d", len(keys))
+	}
+	if err := repo.Delete(ctx, apiKey.ID); err == nil {
+		t.Error("expected error deleting a missing key")
+	}
+}
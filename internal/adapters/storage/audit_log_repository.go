@@ -0,0 +1,194 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+	"github.com/google/uuid"
+)
+
+// AuditLogRepository implements ports.AuditLogRepository using SQLite.
+// Entries are append-only; the rowid records the order they were written
+// in, which is the order of the hash chain.
+type AuditLogRepository struct {
+	db *DB
+}
+
+// NewAuditLogRepository creates a new audit log repository.
+func NewAuditLogRepository(db *DB) *AuditLogRepository {
+	return &AuditLogRepository{db: db}
+}
+
+const auditLogColumns = `id, user_id, action, resource, resource_id, details, ip_address, user_agent, success, error, timestamp, prev_hash, hash`
+
+// Create persists a new audit log entry.
+func (r *AuditLogRepository) Create(ctx context.Context, log *domain.AuditLog) error {
+	detailsJSON, err := json.Marshal(log.Details)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit details: %w", err)
+	}
+	idBytes, _ := log.ID.MarshalBinary()
+	var userIDBytes []byte
+	if log.UserID != nil {
+		userIDBytes, _ = log.UserID.MarshalBinary()
+	}
+
+	query := `
+		INSERT INTO audit_logs (` + auditLogColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err = r.db.conn.ExecContext(ctx, query,
+		idBytes,
+		userIDBytes,
+		log.Action,
+		log.Resource,
+		log.ResourceID,
+		detailsJSON,
+		log.IPAddress,
+		log.UserAgent,
+		log.Success,
+		log.Error,
+		log.Timestamp.UnixNano(),
+		log.PrevHash,
+		log.Hash,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert audit log: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves an audit log entry by its ID.
+func (r *AuditLogRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.AuditLog, error) {
+	idBytes, _ := id.MarshalBinary()
+	row := r.db.conn.QueryRowContext(ctx, "SELECT "+auditLogColumns+" FROM audit_logs WHERE id = ?", idBytes)
+	return scanAuditLog(row)
+}
+
+// List retrieves audit log entries, newest first.
+func (r *AuditLogRepository) List(ctx context.Context, filter ports.AuditLogFilter) ([]*domain.AuditLog, error) {
+	where := " WHERE 1=1"
+	var args []interface{}
+
+	if filter.UserID != nil {
+		userIDBytes, _ := filter.UserID.MarshalBinary()
+		where += " AND user_id = ?"
+		args = append(args, userIDBytes)
+	}
+	if filter.Action != "" {
+		where += " AND action = ?"
+		args = append(args, filter.Action)
+	}
+	if filter.Resource != "" {
+		where += " AND resource = ?"
+		args = append(args, filter.Resource)
+	}
+	if filter.Success != nil {
+		where += " AND success = ?"
+		args = append(args, *filter.Success)
+	}
+	if !filter.StartTime.IsZero() {
+		where += " AND timestamp >= ?"
+		args = append(args, filter.StartTime.UnixNano())
+	}
+	if !filter.EndTime.IsZero() {
+		where += " AND timestamp <= ?"
+		args = append(args, filter.EndTime.UnixNano())
+	}
+
+	return r.list(ctx, where+" ORDER BY rowid DESC"+limitOffset(filter.Limit, filter.Offset), args...)
+}
+
+// Latest returns the most recently written entry, or nil if there are none.
+func (r *AuditLogRepository) Latest(ctx context.Context) (*domain.AuditLog, error) {
+	logs, err := r.list(ctx, " ORDER BY rowid DESC LIMIT 1")
+	if err != nil || len(logs) == 0 {
+		return nil, err
+	}
+	return logs[0], nil
+}
+
+// ListChain returns all entries in the order they were written.
+func (r *AuditLogRepository) ListChain(ctx context.Context) ([]*domain.AuditLog, error) {
+	return r.list(ctx, " ORDER BY rowid")
+}
+
+// DeleteBefore removes audit log entries older than the given timestamp.
+func (r *AuditLogRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.conn.ExecContext(ctx, "DELETE FROM audit_logs WHERE timestamp < ?", before.UnixNano())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete audit logs: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+func (r *AuditLogRepository) list(ctx context.Context, clause string, args ...interface{}) ([]*domain.AuditLog, error) {
+	rows, err := r.db.conn.QueryContext(ctx, "SELECT "+auditLogColumns+" FROM audit_logs"+clause, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit logs: %w", err)
+	}
+	defer rows.Close()
+
+	var logs []*domain.AuditLog
+	for rows.Next() {
+		log, err := scanAuditLog(rows)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, log)
+	}
+	return logs, rows.Err()
+}
+
+func scanAuditLog(row rowScanner) (*domain.AuditLog, error) {
+	var (
+		idBytes     []byte
+		userIDBytes []byte
+		detailsJSON sql.NullString
+		resourceID  sql.NullString
+		ipAddress   sql.NullString
+		userAgent   sql.NullString
+		errMsg      sql.NullString
+		timestamp   int64
+		prevHash    sql.NullString
+		hash        sql.NullString
+		log         domain.AuditLog
+	)
+
+	err := row.Scan(&idBytes, &userIDBytes, &log.Action, &log.Resource, &resourceID, &detailsJSON,
+		&ipAddress, &userAgent, &log.Success, &errMsg, &timestamp, &prevHash, &hash)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("audit log not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan audit log: %w", err)
+	}
+
+	log.ID, _ = uuid.FromBytes(idBytes)
+	if len(userIDBytes) > 0 {
+		userID, _ := uuid.FromBytes(userIDBytes)
+		log.UserID = &userID
+	}
+	log.ResourceID = resourceID.String
+	log.IPAddress = ipAddress.String
+	log.UserAgent = userAgent.String
+	log.Error = errMsg.String
+	log.Timestamp = time.Unix(0, timestamp)
+	log.PrevHash = prevHash.String
+	log.Hash = hash.String
+
+	log.Details = make(map[string]string)
+	if detailsJSON.Valid && detailsJSON.String != "" && detailsJSON.String != "null" {
+		_ = json.Unmarshal([]byte(detailsJSON.String), &log.Details)
+	}
+
+	return &log, nil
+}
+
+// Ensure AuditLogRepository implements the interface
+var _ ports.AuditLogRepository = (*AuditLogRepository)(nil)
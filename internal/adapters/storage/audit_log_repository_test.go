@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+	"github.com/google/uuid"
+)
+
+func TestAuditLogRepository(t *testing.T) {
+	repo := NewAuditLogRepository(setupTestDB(t))
+	ctx := context.Background()
+	userID := uuid.New()
+
+	if latest, err := repo.Latest(ctx); err != nil || latest != nil {
+		t.Fatalf("expected no latest entry, got %v (%v)", latest, err)
+	}
+
+	prev := ""
+	var written []*domain.AuditLog
+	for i, action := range []string{"user.login", "apikey.create", "user.delete"} {
+		log := domain.NewAuditLog(&userID, action, "user", userID.String()).
+			WithDetails(map[string]string{"step": string(rune('a' + i))}).
+			WithContext("unix:@client", "")
+		if action == "user.delete" {
+			log.UserID = nil
+			log.WithError(errors.New("permission denied"))
+		}
+		log.Seal(prev)
+		prev = log.Hash
+		if err := repo.Create(ctx, log); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		written = append(written, log)
+	}
+
+	chain, err := repo.ListChain(ctx)
+	if err != nil {
+		t.Fatalf("ListChain failed: %v", err)
+	}
+	if len(chain) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(chain))
+	}
+	for i, log := range chain {
+		if log.ID != written[i].ID {
+			t.Errorf("entry %d: expected %s, got %s", i, written[i].Action, log.Action)
+		}
+		if log.ComputeHash() != log.Hash {
+			t.Errorf("entry %d: hash does not match its stored contents", i)
+		}
+	}
+	if chain[0].IPAddress != "unix:@client" || chain[0].Details["step"] != "a" || *chain[0].UserID != userID {
+		t.Errorf("unexpected first entry: %+v", chain[0])
+	}
+	if chain[2].UserID != nil || chain[2].Success || chain[2].Error != "permission denied" {
+		t.Errorf("unexpected failed entry: %+v", chain[2])
+	}
+
+	latest, err := repo.Latest(ctx)
+	if err != nil || latest == nil || latest.ID != written[2].ID {
+		t.Errorf("expected the last entry as latest, got %v (%v)", latest, err)
+	}
+
+	failed := false
+	logs, err := repo.List(ctx, ports.AuditLogFilter{Success: &failed})
+	if err != nil || len(logs) != 1 || logs[0].Action != "user.delete" {
+		t.Errorf("expected only the failed entry, got %v (%v)", logs, err)
+	}
+	logs, err = repo.List(ctx, ports.AuditLogFilter{UserID: &userID, Limit: 1})
+	if err != nil || len(logs) != 1 || logs[0].Action != "apikey.create" {
+		t.Errorf("expected the user's newest entry, got %v (%v)", logs, err)
+	}
+
+	deleted, err := repo.DeleteBefore(ctx, time.Now().Add(time.Minute))
+	if err != nil || deleted != 3 {
+		t.Errorf("expected 3 entries deleted, got %d (%v)", deleted, err)
+	}
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/forge-platform/forge/internal/core/ports"
+)
+
+// bestEffortBatch calls insert for each of n entries inside one
+// transaction. Every entry runs under its own savepoint, so a failed insert
+// rolls back only that entry and is reported by index while the rest are
+// committed together.
+func bestEffortBatch(ctx context.Context, db *DB, n int, insert func(tx *sql.Tx, i int) error) (*ports.BatchReport, error) {
+	report := &ports.BatchReport{}
+	if n == 0 {
+		return report, nil
+	}
+
+	tx, err := db.BeginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for i := 0; i < n; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT batch_entry"); err != nil {
+			return nil, fmt.Errorf("failed to create savepoint: %w", err)
+		}
+		if err := insert(tx, i); err != nil {
+			if _, rbErr := tx.ExecContext(ctx, "ROLLBACK TO batch_entry"); rbErr != nil {
+				return nil, fmt.Errorf("failed to roll back entry %d: %w", i, rbErr)
+			}
+			report.Failed = append(report.Failed, ports.BatchFailure{Index: i, Reason: err.Error()})
+		} else {
+			report.Written++
+		}
+		if _, err := tx.ExecContext(ctx, "RELEASE batch_entry"); err != nil {
+			return nil, fmt.Errorf("failed to release savepoint: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return report, nil
+}
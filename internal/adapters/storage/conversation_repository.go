@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+	"github.com/google/uuid"
+)
+
+// ConversationRepository implements ports.ConversationRepository using SQLite.
+type ConversationRepository struct {
+	db *DB
+}
+
+// NewConversationRepository creates a new conversation repository.
+func NewConversationRepository(db *DB) *ConversationRepository {
+	return &ConversationRepository{db: db}
+}
+
+const conversationColumns = `id, title, model, messages, created_at, updated_at`
+
+// Create persists a new conversation.
+func (r *ConversationRepository) Create(ctx context.Context, conv *domain.Conversation) error {
+	messagesJSON, err := json.Marshal(conv.Messages)
+	if err != nil {
+		return fmt.Errorf("failed to marshal messages: %w", err)
+	}
+	idBytes, _ := conv.ID.MarshalBinary()
+
+	query := `
+		INSERT INTO conversations (` + conversationColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	_, err = r.db.conn.ExecContext(ctx, query,
+		idBytes,
+		conv.Title,
+		conv.Model,
+		messagesJSON,
+		conv.CreatedAt.UnixMilli(),
+		conv.UpdatedAt.UnixMilli(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert conversation: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a conversation by its ID.
+func (r *ConversationRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Conversation, error) {
+	idBytes, _ := id.MarshalBinary()
+	row := r.db.conn.QueryRowContext(ctx, "SELECT "+conversationColumns+" FROM conversations WHERE id = ?", idBytes)
+	return scanConversation(row)
+}
+
+// Update replaces the title, model and messages of a conversation.
+func (r *ConversationRepository) Update(ctx context.Context, conv *domain.Conversation) error {
+	messagesJSON, err := json.Marshal(conv.Messages)
+	if err != nil {
+		return fmt.Errorf("failed to marshal messages: %w", err)
+	}
+	idBytes, _ := conv.ID.MarshalBinary()
+
+	result, err := r.db.conn.ExecContext(ctx, `
+		UPDATE conversations SET title = ?, model = ?, messages = ?, updated_at = ?
+		WHERE id = ?
+	`, conv.Title, conv.Model, messagesJSON, conv.UpdatedAt.UnixMilli(), idBytes)
+	if err != nil {
+		return fmt.Errorf("failed to update conversation: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("conversation not found")
+	}
+	return nil
+}
+
+// Delete removes a conversation.
+func (r *ConversationRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	idBytes, _ := id.MarshalBinary()
+	if _, err := r.db.conn.ExecContext(ctx, "DELETE FROM conversations WHERE id = ?", idBytes); err != nil {
+		return fmt.Errorf("failed to delete conversation: %w", err)
+	}
+	return nil
+}
+
+// List retrieves conversations, most recently updated first.
+func (r *ConversationRepository) List(ctx context.Context, limit, offset int) ([]*domain.Conversation, error) {
+	query := "SELECT " + conversationColumns + " FROM conversations ORDER BY updated_at DESC" + limitOffset(limit, offset)
+	rows, err := r.db.conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query conversations: %w", err)
+	}
+	defer rows.Close()
+
+	var convs []*domain.Conversation
+	for rows.Next() {
+		conv, err := scanConversation(rows)
+		if err != nil {
+			return nil, err
+		}
+		convs = append(convs, conv)
+	}
+	return convs, rows.Err()
+}
+
+func scanConversation(row rowScanner) (*domain.Conversation, error) {
+	var (
+		idBytes      []byte
+		messagesJSON string
+		createdAt    int64
+		updatedAt    int64
+		conv         domain.Conversation
+	)
+
+	err := row.Scan(&idBytes, &conv.Title, &conv.Model, &messagesJSON, &createdAt, &updatedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("conversation not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan conversation: %w", err)
+	}
+
+	conv.ID, _ = uuid.FromBytes(idBytes)
+	conv.CreatedAt = time.UnixMilli(createdAt)
+	conv.UpdatedAt = time.UnixMilli(updatedAt)
+	if err := json.Unmarshal([]byte(messagesJSON), &conv.Messages); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal messages: %w", err)
+	}
+	return &conv, nil
+}
+
+// Ensure ConversationRepository implements the interface
+var _ ports.ConversationRepository = (*ConversationRepository)(nil)
@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+)
+
+func TestConversationRepository_RoundTrip(t *testing.T) {
+	repo := NewConversationRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	conv := domain.NewConversation("llama3.2", "be brief")
+	conv.AddMessage(domain.RoleUser, "why is the disk full?")
+	conv.GenerateTitle()
+	if err := repo.Create(ctx, conv); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	conv.AddMessage(domain.RoleAssistant, "logs")
+	if err := repo.Update(ctx, conv); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	got, err := repo.GetByID(ctx, conv.ID)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if got.Title != "why is the disk full?" || got.Model != "llama3.2" || len(got.Messages) != 3 {
+		t.Fatalf("unexpected conversation: %+v", got)
+	}
+	if got.Messages[2].Role != domain.RoleAssistant || got.Messages[2].Content != "logs" {
+		t.Errorf("unexpected last message: %+v", got.Messages[2])
+	}
+
+	other := domain.NewConversation("llama3.2", "")
+	other.UpdatedAt = conv.UpdatedAt.Add(1e9)
+	if err := repo.Create(ctx, other); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	convs, err := repo.List(ctx, 1, 0)
+	if err != nil || len(convs) != 1 || convs[0].ID != other.ID {
+		t.Errorf("expected most recently updated conversation first, got %v, %v", convs, err)
+	}
+
+	if err := repo.Delete(ctx, conv.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := repo.Update(ctx, conv); err == nil {
+		t.Error("expected update of a deleted conversation to fail")
+	}
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Features records which optional SQLite extensions the linked library
+// provides. Queries that would use an extension fall back to a plainer
+// form when it is missing.
+type Features struct {
+	// JSON1 enables json_extract and json_each for tag and attribute
+	// filters. Without it those filters match the encoded JSON text, and
+	// grouping by tag is done in the application.
+	JSON1 bool
+	// FTS5 reports whether full-text indexes could be created. Log search
+	// uses LIKE either way.
+	FTS5 bool
+}
+
+// detectFeatures probes the connection for optional extensions. json1 is
+// probed by calling one of its functions, since recent SQLite builds include
+// it without a compile option; FTS5 is read from the compile options.
+func detectFeatures(conn *sql.DB) Features {
+	var f Features
+
+	var v sql.NullString
+	if err := conn.QueryRow(`SELECT json_extract('{"a":"b"}', '$.a')`).Scan(&v); err == nil && v.String == "b" {
+		f.JSON1 = true
+	}
+
+	rows, err := conn.Query("PRAGMA compile_options")
+	if err != nil {
+		return f
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var opt string
+		if rows.Scan(&opt) == nil && opt == "ENABLE_FTS5" {
+			f.FTS5 = true
+		}
+	}
+	return f
+}
+
+// Features returns the optional SQLite extensions detected at open.
+func (db *DB) Features() Features {
+	return db.features
+}
+
+// jsonFieldEquals returns a condition matching rows whose JSON object column
+// has key set to the string value. With json1, keys that cannot be written
+// as a JSON path, those containing a double quote or backslash, are matched
+// with json_each. Without it the condition looks for the encoded
+// "key":"value" pair in the column text; columns are written with
+// json.Marshal, so the pair is encoded the same way and cannot match
+// across a key and a neighbouring value.
+func (f Features) jsonFieldEquals(column, key, value string) (string, []interface{}) {
+	if !f.JSON1 {
+		k, _ := json.Marshal(key)
+		v, _ := json.Marshal(value)
+		return fmt.Sprintf("instr(%s, ?) > 0", column), []interface{}{string(k) + ":" + string(v)}
+	}
+	if strings.ContainsAny(key, `"\`) {
+		return fmt.Sprintf("EXISTS (SELECT 1 FROM json_each(%s) WHERE key = ? AND value = ?)", column), []interface{}{key, value}
+	}
+	return fmt.Sprintf("json_extract(%s, ?) = ?", column), []interface{}{`$."` + key + `"`, value}
+}
@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+)
+
+func TestDetectFeatures(t *testing.T) {
+	db := setupTestDB(t)
+
+	if !db.Features().JSON1 {
+		t.Error("expected json1 to be detected")
+	}
+	var fts5 bool
+	if err := db.conn.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&fts5); err != nil {
+		t.Fatalf("failed to read compile options: %v", err)
+	}
+	if db.Features().FTS5 != fts5 {
+		t.Errorf("FTS5 = %v, compile options say %v", db.Features().FTS5, fts5)
+	}
+}
+
+// capabilitySets are the feature combinations the query fallbacks are
+// exercised with, whatever the linked SQLite actually provides.
+var capabilitySets = []Features{
+	{JSON1: true, FTS5: true},
+	{JSON1: true},
+	{FTS5: true},
+	{},
+}
+
+func TestJSONFilters_CapabilitySets(t *testing.T) {
+	for _, features := range capabilitySets {
+		features := features
+		t.Run(featureName(features), func(t *testing.T) {
+			db := setupTestDB(t)
+			db.features = features
+			ctx := context.Background()
+
+			metrics := NewMetricRepository(db)
+			start := time.Now().Add(-time.Hour).Truncate(time.Minute)
+			record := func(tags map[string]string, offset time.Duration, value float64) {
+				m := domain.NewMetric("cpu", domain.MetricTypeGauge, value, tags)
+				m.Timestamp = start.Add(offset)
+				if err := metrics.Record(ctx, m); err != nil {
+					t.Fatalf("Record failed: %v", err)
+				}
+			}
+			record(map[string]string{"host": "a", "env": "prod"}, 0, 10)
+			record(map[string]string{"host": "a", "env": "dev"}, 90*time.Second, 30)
+			record(map[string]string{"host": "b", "env": "prod"}, 0, 5)
+			record(map[string]string{"note": `"host":"a"`, "env": "prod"}, 0, 100)
+			record(map[string]string{`quoted "key"`: "<x>", "env": "prod"}, 0, 7)
+
+			query := ports.MetricQuery{Name: "cpu", StartTime: start, EndTime: start.Add(time.Hour)}
+			values := func(tags map[string]string) []float64 {
+				t.Helper()
+				q := query
+				q.Tags = tags
+				series, err := metrics.Query(ctx, q)
+				if err != nil {
+					t.Fatalf("Query failed: %v", err)
+				}
+				var got []float64
+				for _, p := range series.Points {
+					got = append(got, p.Value)
+				}
+				sort.Float64s(got)
+				return got
+			}
+			for _, tc := range []struct {
+				tags map[string]string
+				want []float64
+			}{
+				{map[string]string{"host": "a"}, []float64{10, 30}},
+				{map[string]string{"host": "a", "env": "prod"}, []float64{10}},
+				{map[string]string{`quoted "key"`: "<x>"}, []float64{7}},
+				{map[string]string{"host": "c"}, nil},
+			} {
+				if got := values(tc.tags); !floatsEqual(got, tc.want) {
+					t.Errorf("tags %v: got %v, want %v", tc.tags, got, tc.want)
+				}
+			}
+
+			grouped := query
+			grouped.GroupBy = []string{"host"}
+			grouped.Aggregation = ports.AggregationSum
+			groups, err := metrics.QueryGrouped(ctx, grouped)
+			if err != nil {
+				t.Fatalf("QueryGrouped failed: %v", err)
+			}
+			sums := map[string]float64{}
+			for _, g := range groups {
+				if len(g.Results) != 1 {
+					t.Fatalf("expected one result per group without a step, got %+v", g)
+				}
+				sums[g.Tags["host"]] = g.Results[0].Value
+			}
+			if len(sums) != 3 || sums["a"] != 40 || sums["b"] != 5 || sums[ports.GroupNone] != 107 {
+				t.Errorf("grouped sums = %v", sums)
+			}
+			if len(groups) > 0 && !groups[0].Results[0].Timestamp.Equal(start) {
+				t.Errorf("expected groups to be stamped with their first point, got %v", groups[0].Results[0].Timestamp)
+			}
+
+			grouped.Step = time.Minute
+			grouped.Aggregation = ports.AggregationAvg
+			grouped.Tags = map[string]string{"env": "prod"}
+			groups, err = metrics.QueryGrouped(ctx, grouped)
+			if err != nil {
+				t.Fatalf("QueryGrouped with step failed: %v", err)
+			}
+			for _, g := range groups {
+				if g.Tags["host"] == ports.GroupNone && (len(g.Results) != 1 || g.Results[0].Value != 53.5 || g.Results[0].Count != 2) {
+					t.Errorf("expected the untagged prod series to average 53.5 in one bucket, got %+v", g.Results)
+				}
+			}
+
+			logs := NewLogRepository(db)
+			for i, attrs := range []map[string]string{{"region": "us"}, {"region": "eu"}, {"other": `"region":"us"`}} {
+				entry := domain.NewLogEntry(domain.LogLevelInfo, "request", "api", "api")
+				entry.Attributes = attrs
+				entry.Timestamp = start.Add(time.Duration(i) * time.Second)
+				if err := logs.Create(ctx, entry); err != nil {
+					t.Fatalf("Create log failed: %v", err)
+				}
+			}
+			found, err := logs.List(ctx, ports.LogFilter{Attributes: map[string]string{"region": "us"}})
+			if err != nil || len(found) != 1 || found[0].Attributes["region"] != "us" {
+				t.Errorf("expected one log in region us, got %d entries, %v", len(found), err)
+			}
+
+			spans := NewSpanRepository(db)
+			traceID := domain.NewTraceID()
+			for _, system := range []string{"postgres", "mysql"} {
+				s := domain.NewSpan(traceID, "query", domain.SpanKindClient, "api")
+				s.SetAttribute("db.system", system)
+				if err := spans.Create(ctx, s); err != nil {
+					t.Fatalf("Create span failed: %v", err)
+				}
+			}
+			got, err := spans.List(ctx, ports.SpanFilter{Attributes: map[string]string{"db.system": "mysql"}})
+			if err != nil || len(got) != 1 || got[0].Attributes["db.system"] != "mysql" {
+				t.Errorf("expected one mysql span, got %d spans, %v", len(got), err)
+			}
+		})
+	}
+}
+
+func featureName(f Features) string {
+	name := "json1"
+	if !f.JSON1 {
+		name = "nojson1"
+	}
+	if f.FTS5 {
+		return name + "+fts5"
+	}
+	return name
+}
+
+func floatsEqual(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+	"github.com/google/uuid"
+)
+
+// InsightRepository implements ports.InsightRepository using SQLite.
+type InsightRepository struct {
+	db *DB
+}
+
+// NewInsightRepository creates a new insight repository.
+func NewInsightRepository(db *DB) *InsightRepository {
+	return &InsightRepository{db: db}
+}
+
+const insightColumns = `id, timestamp, time_range_ms, metric_count, task_count, summary, issues`
+
+// Create persists a new insight.
+func (r *InsightRepository) Create(ctx context.Context, insight *domain.Insight) error {
+	issues := insight.Issues
+	if issues == nil {
+		issues = []domain.InsightIssue{}
+	}
+	issuesJSON, err := json.Marshal(issues)
+	if err != nil {
+		return fmt.Errorf("failed to marshal issues: %w", err)
+	}
+	idBytes, _ := insight.ID.MarshalBinary()
+
+	query := `
+		INSERT INTO insights (` + insightColumns + `, max_severity)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err = r.db.conn.ExecContext(ctx, query,
+		idBytes,
+		insight.Timestamp.UnixMilli(),
+		insight.TimeRange.Milliseconds(),
+		insight.MetricCount,
+		insight.TaskCount,
+		insight.Summary,
+		issuesJSON,
+		insight.MaxSeverity(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert insight: %w", err)
+	}
+	return nil
+}
+
+// List retrieves insights in the filter's time range, newest first.
+func (r *InsightRepository) List(ctx context.Context, filter ports.InsightFilter) ([]*domain.Insight, error) {
+	var conds []string
+	var args []interface{}
+	if !filter.StartTime.IsZero() {
+		conds = append(conds, "timestamp >= ?")
+		args = append(args, filter.StartTime.UnixMilli())
+	}
+	if !filter.EndTime.IsZero() {
+		conds = append(conds, "timestamp <= ?")
+		args = append(args, filter.EndTime.UnixMilli())
+	}
+
+	query := "SELECT " + insightColumns + " FROM insights"
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += " ORDER BY timestamp DESC" + limitOffset(filter.Limit, 0)
+
+	rows, err := r.db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query insights: %w", err)
+	}
+	defer rows.Close()
+
+	var insights []*domain.Insight
+	for rows.Next() {
+		insight, err := scanInsight(rows)
+		if err != nil {
+			return nil, err
+		}
+		insights = append(insights, insight)
+	}
+	return insights, rows.Err()
+}
+
+// DeleteBefore removes insights recorded before the given time.
+func (r *InsightRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.conn.ExecContext(ctx, "DELETE FROM insights WHERE timestamp < ?", before.UnixMilli())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete insights: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+func scanInsight(row rowScanner) (*domain.Insight, error) {
+	var (
+		idBytes     []byte
+		timestamp   int64
+		timeRangeMs int64
+		issuesJSON  string
+		insight     domain.Insight
+	)
+
+	err := row.Scan(&idBytes, &timestamp, &timeRangeMs, &insight.MetricCount, &insight.TaskCount,
+		&insight.Summary, &issuesJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan insight: %w", err)
+	}
+
+	insight.ID, _ = uuid.FromBytes(idBytes)
+	insight.Timestamp = time.UnixMilli(timestamp)
+	insight.TimeRange = time.Duration(timeRangeMs) * time.Millisecond
+	if err := json.Unmarshal([]byte(issuesJSON), &insight.Issues); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal issues: %w", err)
+	}
+	return &insight, nil
+}
+
+// Ensure InsightRepository implements the interface
+var _ ports.InsightRepository = (*InsightRepository)(nil)
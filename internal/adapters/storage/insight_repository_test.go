@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+)
+
+func TestInsightRepository_TimeRange(t *testing.T) {
+	repo := NewInsightRepository(setupTestDB(t))
+	ctx := context.Background()
+	base := time.Now().Add(-24 * time.Hour).Truncate(time.Millisecond)
+
+	for i, severity := range []string{"", "warning", "critical"} {
+		var issues []domain.InsightIssue
+		if severity != "" {
+			issues = append(issues, domain.InsightIssue{Severity: severity, Component: "cpu", Description: "hot"})
+		}
+		insight := domain.NewInsight(time.Hour, "run "+string(rune('a'+i)), issues)
+		insight.Timestamp = base.Add(time.Duration(i) * time.Hour)
+		insight.MetricCount = 10 + i
+		if err := repo.Create(ctx, insight); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	all, err := repo.List(ctx, ports.InsightFilter{})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(all) != 3 || all[0].Summary != "run c" || all[2].Summary != "run a" {
+		t.Fatalf("expected 3 insights newest first, got %+v", all)
+	}
+	latest := all[0]
+	if latest.MetricCount != 12 || latest.TimeRange != time.Hour || !latest.Timestamp.Equal(base.Add(2*time.Hour)) {
+		t.Errorf("unexpected insight: %+v", latest)
+	}
+	if len(latest.Issues) != 1 || latest.Issues[0].Severity != "critical" || latest.Issues[0].Description != "hot" {
+		t.Errorf("expected issues to round-trip, got %+v", latest.Issues)
+	}
+	if len(all[2].Issues) != 0 {
+		t.Errorf("expected no issues for the healthy run, got %+v", all[2].Issues)
+	}
+
+	window, err := repo.List(ctx, ports.InsightFilter{StartTime: base.Add(30 * time.Minute), EndTime: base.Add(time.Hour)})
+	if err != nil || len(window) != 1 || window[0].Summary != "run b" {
+		t.Errorf("expected only run b in window, got %+v, %v", window, err)
+	}
+
+	limited, _ := repo.List(ctx, ports.InsightFilter{Limit: 2})
+	if len(limited) != 2 {
+		t.Errorf("expected 2 insights with limit, got %d", len(limited))
+	}
+
+	deleted, err := repo.DeleteBefore(ctx, base.Add(90*time.Minute))
+	if err != nil || deleted != 2 {
+		t.Errorf("expected 2 insights deleted, got %d, %v", deleted, err)
+	}
+}
@@ -0,0 +1,244 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+	"github.com/google/uuid"
+)
+
+// LogToMetricRuleRepository implements ports.LogToMetricRuleRepository using SQLite.
+type LogToMetricRuleRepository struct {
+	db *DB
+}
+
+// NewLogToMetricRuleRepository creates a new log-to-metric rule repository.
+func NewLogToMetricRuleRepository(db *DB) *LogToMetricRuleRepository {
+	return &LogToMetricRuleRepository{db: db}
+}
+
+const logMetricRuleColumns = `id, name, description, match_field, match_pattern, match_values, min_level,
+	service_name, source, metric_name, metric_type, value_field, tags, tag_fields, enabled, status, last_error,
+	created_at, updated_at`
+
+// logMetricRuleJSON holds the JSON-encoded collection columns of a rule.
+type logMetricRuleJSON struct {
+	matchValues, tags, tagFields []byte
+}
+
+func marshalLogMetricRule(rule *domain.LogToMetricRule) (*logMetricRuleJSON, error) {
+	var enc logMetricRuleJSON
+	var err error
+	if enc.matchValues, err = json.Marshal(rule.MatchValues); err != nil {
+		return nil, fmt.Errorf("failed to marshal match values: %w", err)
+	}
+	if enc.tags, err = json.Marshal(rule.Tags); err != nil {
+		return nil, fmt.Errorf("failed to marshal tags: %w", err)
+	}
+	if enc.tagFields, err = json.Marshal(rule.TagFields); err != nil {
+		return nil, fmt.Errorf("failed to marshal tag fields: %w", err)
+	}
+	return &enc, nil
+}
+
+// Create persists a new rule.
+func (r *LogToMetricRuleRepository) Create(ctx context.Context, rule *domain.LogToMetricRule) error {
+	enc, err := marshalLogMetricRule(rule)
+	if err != nil {
+		return err
+	}
+	idBytes, _ := rule.ID.MarshalBinary()
+	if rule.Status == "" {
+		rule.Status = domain.LogToMetricRuleActive
+	}
+
+	query := `
+		INSERT INTO log_metric_rules (` + logMetricRuleColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err = r.db.conn.ExecContext(ctx, query,
+		idBytes,
+		rule.Name,
+		rule.Description,
+		rule.MatchField,
+		rule.MatchPattern,
+		enc.matchValues,
+		string(rule.MinLevel),
+		rule.ServiceName,
+		rule.Source,
+		rule.MetricName,
+		string(rule.MetricType),
+		rule.ValueField,
+		enc.tags,
+		enc.tagFields,
+		rule.Enabled,
+		string(rule.Status),
+		rule.LastError,
+		rule.CreatedAt.UnixMilli(),
+		rule.UpdatedAt.UnixMilli(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert log-to-metric rule: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a rule by its ID.
+func (r *LogToMetricRuleRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.LogToMetricRule, error) {
+	idBytes, _ := id.MarshalBinary()
+	row := r.db.conn.QueryRowContext(ctx, "SELECT "+logMetricRuleColumns+" FROM log_metric_rules WHERE id = ?", idBytes)
+	return scanLogMetricRule(row)
+}
+
+// Update updates an existing rule.
+func (r *LogToMetricRuleRepository) Update(ctx context.Context, rule *domain.LogToMetricRule) error {
+	enc, err := marshalLogMetricRule(rule)
+	if err != nil {
+		return err
+	}
+	idBytes, _ := rule.ID.MarshalBinary()
+	rule.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE log_metric_rules SET
+			name = ?, description = ?, match_field = ?, match_pattern = ?, match_values = ?,
+			min_level = ?, service_name = ?, source = ?, metric_name = ?, metric_type = ?, value_field = ?,
+			tags = ?, tag_fields = ?, enabled = ?, status = ?, last_error = ?, updated_at = ?
+		WHERE id = ?
+	`
+	result, err := r.db.conn.ExecContext(ctx, query,
+		rule.Name,
+		rule.Description,
+		rule.MatchField,
+		rule.MatchPattern,
+		enc.matchValues,
+		string(rule.MinLevel),
+		rule.ServiceName,
+		rule.Source,
+		rule.MetricName,
+		string(rule.MetricType),
+		rule.ValueField,
+		enc.tags,
+		enc.tagFields,
+		rule.Enabled,
+		string(rule.Status),
+		rule.LastError,
+		rule.UpdatedAt.UnixMilli(),
+		idBytes,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update log-to-metric rule: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("log-to-metric rule not found")
+	}
+	return nil
+}
+
+// Delete removes a rule.
+func (r *LogToMetricRuleRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	idBytes, _ := id.MarshalBinary()
+	result, err := r.db.conn.ExecContext(ctx, "DELETE FROM log_metric_rules WHERE id = ?", idBytes)
+	if err != nil {
+		return fmt.Errorf("failed to delete log-to-metric rule: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("log-to-metric rule not found")
+	}
+	return nil
+}
+
+// List retrieves all rules ordered by name.
+func (r *LogToMetricRuleRepository) List(ctx context.Context) ([]*domain.LogToMetricRule, error) {
+	return r.list(ctx, "")
+}
+
+// ListEnabled retrieves all enabled rules ordered by name.
+func (r *LogToMetricRuleRepository) ListEnabled(ctx context.Context) ([]*domain.LogToMetricRule, error) {
+	return r.list(ctx, " WHERE enabled = 1")
+}
+
+func (r *LogToMetricRuleRepository) list(ctx context.Context, where string) ([]*domain.LogToMetricRule, error) {
+	query := "SELECT " + logMetricRuleColumns + " FROM log_metric_rules" + where + " ORDER BY name"
+	rows, err := r.db.conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query log-to-metric rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []*domain.LogToMetricRule
+	for rows.Next() {
+		rule, err := scanLogMetricRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+func scanLogMetricRule(row rowScanner) (*domain.LogToMetricRule, error) {
+	var (
+		idBytes         []byte
+		description     sql.NullString
+		matchPattern    sql.NullString
+		matchValuesJSON sql.NullString
+		minLevel        sql.NullString
+		serviceName     sql.NullString
+		source          sql.NullString
+		metricType      string
+		valueField      sql.NullString
+		tagsJSON        sql.NullString
+		tagFieldsJSON   sql.NullString
+		status          string
+		lastError       sql.NullString
+		createdAt       int64
+		updatedAt       int64
+		rule            domain.LogToMetricRule
+	)
+
+	err := row.Scan(&idBytes, &rule.Name, &description, &rule.MatchField, &matchPattern, &matchValuesJSON,
+		&minLevel, &serviceName, &source, &rule.MetricName, &metricType, &valueField, &tagsJSON, &tagFieldsJSON,
+		&rule.Enabled, &status, &lastError, &createdAt, &updatedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("log-to-metric rule not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan log-to-metric rule: %w", err)
+	}
+
+	rule.ID, _ = uuid.FromBytes(idBytes)
+	rule.Description = description.String
+	rule.MatchPattern = matchPattern.String
+	rule.MinLevel = domain.LogLevel(minLevel.String)
+	rule.ServiceName = serviceName.String
+	rule.Source = source.String
+	rule.MetricType = domain.MetricType(metricType)
+	rule.ValueField = valueField.String
+	rule.Status = domain.LogToMetricRuleStatus(status)
+	rule.LastError = lastError.String
+	rule.CreatedAt = time.UnixMilli(createdAt)
+	rule.UpdatedAt = time.UnixMilli(updatedAt)
+
+	rule.Tags = make(map[string]string)
+	if tagsJSON.Valid && tagsJSON.String != "" && tagsJSON.String != "null" {
+		_ = json.Unmarshal([]byte(tagsJSON.String), &rule.Tags)
+	}
+	if matchValuesJSON.Valid && matchValuesJSON.String != "" && matchValuesJSON.String != "null" {
+		_ = json.Unmarshal([]byte(matchValuesJSON.String), &rule.MatchValues)
+	}
+	rule.TagFields = []string{}
+	if tagFieldsJSON.Valid && tagFieldsJSON.String != "" && tagFieldsJSON.String != "null" {
+		_ = json.Unmarshal([]byte(tagFieldsJSON.String), &rule.TagFields)
+	}
+
+	return &rule, nil
+}
+
+// Ensure LogToMetricRuleRepository implements the interface
+var _ ports.LogToMetricRuleRepository = (*LogToMetricRuleRepository)(nil)
@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+)
+
+func TestLogToMetricRuleRepository_CRUD(t *testing.T) {
+	repo := NewLogToMetricRuleRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	rule := domain.NewLogToMetricRule("latency", "message", `took (?P<ms>\d+)ms`, "app.latency_ms", domain.MetricTypeGauge)
+	rule.ValueField = "ms"
+	rule.MinLevel = domain.LogLevelInfo
+	rule.ServiceName = "api"
+	rule.Source = "nginx"
+	rule.MatchValues = []string{"slow"}
+	rule.Tags["team"] = "core"
+	rule.TagFields = []string{"pod"}
+	if err := repo.Create(ctx, rule); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	got, err := repo.GetByID(ctx, rule.ID)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if got.MatchPattern != rule.MatchPattern || got.ValueField != "ms" || got.MinLevel != domain.LogLevelInfo ||
+		got.ServiceName != "api" || got.Source != "nginx" || got.MetricType != domain.MetricTypeGauge || got.Status != domain.LogToMetricRuleActive {
+		t.Errorf("unexpected rule: %+v", got)
+	}
+	if len(got.MatchValues) != 1 || got.Tags["team"] != "core" || len(got.TagFields) != 1 || got.TagFields[0] != "pod" {
+		t.Errorf("expected collections to round-trip, got %+v", got)
+	}
+
+	// Disabling with an error status removes the rule from the enabled set
+	got.Enabled = false
+	got.Status = domain.LogToMetricRuleError
+	got.LastError = "missing closing )"
+	if err := repo.Update(ctx, got); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if enabled, _ := repo.ListEnabled(ctx); len(enabled) != 0 {
+		t.Errorf("expected no enabled rules, got %d", len(enabled))
+	}
+	all, err := repo.List(ctx)
+	if err != nil || len(all) != 1 || all[0].Status != domain.LogToMetricRuleError || all[0].LastError != "missing closing )" {
+		t.Errorf("expected disabled rule with its error, got %+v, %v", all, err)
+	}
+
+	if err := repo.Delete(ctx, rule.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := repo.Delete(ctx, rule.ID); err == nil {
+		t.Error("expected error deleting a missing rule")
+	}
+}
@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+	"github.com/google/uuid"
+)
+
+// LogParserRepository implements ports.LogParserRepository using SQLite.
+type LogParserRepository struct {
+	db *DB
+}
+
+// NewLogParserRepository creates a new log parser repository.
+func NewLogParserRepository(db *DB) *LogParserRepository {
+	return &LogParserRepository{db: db}
+}
+
+const logParserColumns = `id, name, description, type, pattern, field_mappings, source_filter,
+	priority, enabled, created_at, updated_at`
+
+// Create persists a new log parser.
+func (r *LogParserRepository) Create(ctx context.Context, parser *domain.LogParser) error {
+	mappingsJSON, err := json.Marshal(parser.FieldMappings)
+	if err != nil {
+		return fmt.Errorf("failed to marshal field mappings: %w", err)
+	}
+	idBytes, _ := parser.ID.MarshalBinary()
+
+	query := `
+		INSERT INTO log_parsers (` + logParserColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err = r.db.conn.ExecContext(ctx, query,
+		idBytes,
+		parser.Name,
+		parser.Description,
+		string(parser.Type),
+		parser.Pattern,
+		mappingsJSON,
+		parser.SourceFilter,
+		parser.Priority,
+		parser.Enabled,
+		parser.CreatedAt.UnixMilli(),
+		parser.UpdatedAt.UnixMilli(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert log parser: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a parser by its ID.
+func (r *LogParserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.LogParser, error) {
+	idBytes, _ := id.MarshalBinary()
+	row := r.db.conn.QueryRowContext(ctx, "SELECT "+logParserColumns+" FROM log_parsers WHERE id = ?", idBytes)
+	return scanLogParser(row)
+}
+
+// Update updates an existing parser.
+func (r *LogParserRepository) Update(ctx context.Context, parser *domain.LogParser) error {
+	mappingsJSON, err := json.Marshal(parser.FieldMappings)
+	if err != nil {
+		return fmt.Errorf("failed to marshal field mappings: %w", err)
+	}
+	idBytes, _ := parser.ID.MarshalBinary()
+	parser.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE log_parsers SET
+			name = ?, description = ?, type = ?, pattern = ?, field_mappings = ?,
+			source_filter = ?, priority = ?, enabled = ?, updated_at = ?
+		WHERE id = ?
+	`
+	result, err := r.db.conn.ExecContext(ctx, query,
+		parser.Name,
+		parser.Description,
+		string(parser.Type),
+		parser.Pattern,
+		mappingsJSON,
+		parser.SourceFilter,
+		parser.Priority,
+		parser.Enabled,
+		parser.UpdatedAt.UnixMilli(),
+		idBytes,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update log parser: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("log parser not found")
+	}
+	return nil
+}
+
+// Delete removes a parser.
+func (r *LogParserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	idBytes, _ := id.MarshalBinary()
+	result, err := r.db.conn.ExecContext(ctx, "DELETE FROM log_parsers WHERE id = ?", idBytes)
+	if err != nil {
+		return fmt.Errorf("failed to delete log parser: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("log parser not found")
+	}
+	return nil
+}
+
+// List retrieves all parsers, highest priority first.
+func (r *LogParserRepository) List(ctx context.Context) ([]*domain.LogParser, error) {
+	return r.list(ctx, "")
+}
+
+// ListEnabled retrieves all enabled parsers, highest priority first.
+func (r *LogParserRepository) ListEnabled(ctx context.Context) ([]*domain.LogParser, error) {
+	return r.list(ctx, " WHERE enabled = 1")
+}
+
+func (r *LogParserRepository) list(ctx context.Context, where string) ([]*domain.LogParser, error) {
+	query := "SELECT " + logParserColumns + " FROM log_parsers" + where + " ORDER BY priority DESC, name"
+	rows, err := r.db.conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query log parsers: %w", err)
+	}
+	defer rows.Close()
+
+	var parsers []*domain.LogParser
+	for rows.Next() {
+		parser, err := scanLogParser(rows)
+		if err != nil {
+			return nil, err
+		}
+		parsers = append(parsers, parser)
+	}
+	return parsers, rows.Err()
+}
+
+func scanLogParser(row rowScanner) (*domain.LogParser, error) {
+	var (
+		idBytes      []byte
+		parserType   string
+		description  sql.NullString
+		pattern      sql.NullString
+		mappingsJSON sql.NullString
+		sourceFilter sql.NullString
+		createdAt    int64
+		updatedAt    int64
+		parser       domain.LogParser
+	)
+
+	err := row.Scan(&idBytes, &parser.Name, &description, &parserType, &pattern, &mappingsJSON,
+		&sourceFilter, &parser.Priority, &parser.Enabled, &createdAt, &updatedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("log parser not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan log parser: %w", err)
+	}
+
+	parser.ID, _ = uuid.FromBytes(idBytes)
+	parser.Type = domain.LogParserType(parserType)
+	parser.Description = description.String
+	parser.Pattern = pattern.String
+	parser.SourceFilter = sourceFilter.String
+	parser.CreatedAt = time.UnixMilli(createdAt)
+	parser.UpdatedAt = time.UnixMilli(updatedAt)
+
+	parser.FieldMappings = make(map[string]string)
+	if mappingsJSON.Valid && mappingsJSON.String != "" && mappingsJSON.String != "null" {
+		_ = json.Unmarshal([]byte(mappingsJSON.String), &parser.FieldMappings)
+	}
+
+	return &parser, nil
+}
+
+// Ensure LogParserRepository implements the interface
+var _ ports.LogParserRepository = (*LogParserRepository)(nil)
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+)
+
+func TestLogParserRepository_CRUD(t *testing.T) {
+	repo := NewLogParserRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	parser := domain.NewLogParser("access", domain.ParserTypeRegex, `(?P<level>\w+) (?P<message>.*)`)
+	parser.FieldMappings["lvl"] = "level"
+	parser.SourceFilter = "nginx"
+	parser.Priority = 7
+	if err := repo.Create(ctx, parser); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := repo.Create(ctx, domain.NewLogParser("access", domain.ParserTypeJSON, "")); err == nil {
+		t.Error("expected duplicate parser name to be rejected")
+	}
+
+	got, err := repo.GetByID(ctx, parser.ID)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if got.Name != "access" || got.Type != domain.ParserTypeRegex || got.Pattern != parser.Pattern ||
+		got.SourceFilter != "nginx" || got.Priority != 7 || !got.Enabled || got.FieldMappings["lvl"] != "level" {
+		t.Errorf("unexpected parser: %+v", got)
+	}
+
+	got.Enabled = false
+	if err := repo.Update(ctx, got); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if enabled, _ := repo.ListEnabled(ctx); len(enabled) != 0 {
+		t.Errorf("expected disabled parser to be excluded, got %d", len(enabled))
+	}
+
+	if err := repo.Delete(ctx, parser.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := repo.GetByID(ctx, parser.ID); err == nil {
+		t.Error("expected deleted parser to be gone")
+	}
+	if err := repo.Delete(ctx, parser.ID); err == nil {
+		t.Error("expected error deleting a missing parser")
+	}
+}
+
+func TestLogParserRepository_ListOrder(t *testing.T) {
+	repo := NewLogParserRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	for _, p := range []struct {
+		name     string
+		priority int
+	}{{"low", 1}, {"high-b", 10}, {"high-a", 10}, {"mid", 5}} {
+		parser := domain.NewLogParser(p.name, domain.ParserTypeKeyValue, "")
+		parser.Priority = p.priority
+		if err := repo.Create(ctx, parser); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	parsers, err := repo.ListEnabled(ctx)
+	if err != nil {
+		t.Fatalf("ListEnabled failed: %v", err)
+	}
+	want := []string{"high-a", "high-b", "mid", "low"}
+	if len(parsers) != len(want) {
+		t.Fatalf("expected %d parsers, got %d", len(want), len(parsers))
+	}
+	for i, name := range want {
+		if parsers[i].Name != name {
+			t.Errorf("position %d: expected %s, got %s", i, name, parsers[i].Name)
+		}
+	}
+}
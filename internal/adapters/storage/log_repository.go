@@ -0,0 +1,355 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+	"github.com/google/uuid"
+)
+
+// LogRepository implements ports.LogRepository using SQLite.
+type LogRepository struct {
+	db *DB
+}
+
+// NewLogRepository creates a new log repository.
+func NewLogRepository(db *DB) *LogRepository {
+	return &LogRepository{db: db}
+}
+
+const logColumns = `id, timestamp, level, severity, message, source, service_name,
+	trace_id, span_id, attributes, resource, parsed_fields, raw, created_at`
+
+// execer is satisfied by both *sql.DB and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Create persists a new log entry.
+func (r *LogRepository) Create(ctx context.Context, entry *domain.LogEntry) error {
+	return r.insert(ctx, r.db.conn, entry)
+}
+
+// CreateBatch persists multiple log entries in a single transaction.
+func (r *LogRepository) CreateBatch(ctx context.Context, entries []*domain.LogEntry) error {
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, entry := range entries {
+		if err := r.insert(ctx, tx, entry); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// CreateBatchBestEffort persists log entries in a single transaction,
+// skipping and reporting the ones that cannot be written.
+func (r *LogRepository) CreateBatchBestEffort(ctx context.Context, entries []*domain.LogEntry) (*ports.BatchReport, error) {
+	return bestEffortBatch(ctx, r.db, len(entries), func(tx *sql.Tx, i int) error {
+		if entries[i] == nil {
+			return fmt.Errorf("log entry is nil")
+		}
+		return r.insert(ctx, tx, entries[i])
+	})
+}
+
+func (r *LogRepository) insert(ctx context.Context, exec execer, entry *domain.LogEntry) error {
+	attrsJSON, err := json.Marshal(entry.Attributes)
+	if err != nil {
+		return fmt.Errorf("failed to marshal attributes: %w", err)
+	}
+	resourceJSON, err := json.Marshal(entry.Resource)
+	if err != nil {
+		return fmt.Errorf("failed to marshal resource: %w", err)
+	}
+	parsedJSON, err := json.Marshal(entry.ParsedFields)
+	if err != nil {
+		return fmt.Errorf("failed to marshal parsed fields: %w", err)
+	}
+	idBytes, _ := entry.ID.MarshalBinary()
+
+	createdAt := entry.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	query := `
+		INSERT INTO logs (` + logColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err = exec.ExecContext(ctx, query,
+		idBytes,
+		entry.Timestamp.UnixNano(),
+		string(entry.Level),
+		domain.LogLevelPriority(entry.Level),
+		entry.Message,
+		entry.Source,
+		entry.ServiceName,
+		entry.TraceID,
+		entry.SpanID,
+		attrsJSON,
+		resourceJSON,
+		parsedJSON,
+		entry.Raw,
+		createdAt.UnixMilli(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert log entry: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a log entry by its ID.
+func (r *LogRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.LogEntry, error) {
+	idBytes, _ := id.MarshalBinary()
+	row := r.db.conn.QueryRowContext(ctx, "SELECT "+logColumns+" FROM logs WHERE id = ?", idBytes)
+	return scanLogEntry(row)
+}
+
+// List retrieves log entries with optional filtering, newest first.
+func (r *LogRepository) List(ctx context.Context, filter ports.LogFilter) ([]*domain.LogEntry, error) {
+	where, args, err := logFilterClause(r.db.features, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT " + logColumns + " FROM logs" + where
+	query += " ORDER BY timestamp DESC"
+	query += limitOffset(filter.Limit, filter.Offset)
+
+	rows, err := r.db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query logs: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*domain.LogEntry
+	for rows.Next() {
+		entry, err := scanLogEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// Search finds log entries whose message contains query, case-insensitively.
+func (r *LogRepository) Search(ctx context.Context, query string, filter ports.LogFilter) ([]*domain.LogEntry, error) {
+	filter.Search = query
+	return r.List(ctx, filter)
+}
+
+// GetStats retrieves log statistics for entries in the time range.
+func (r *LogRepository) GetStats(ctx context.Context, startTime, endTime time.Time) (*domain.LogStats, error) {
+	stats := &domain.LogStats{
+		ByLevel:   make(map[string]int64),
+		ByService: make(map[string]int64),
+		BySource:  make(map[string]int64),
+	}
+	start, end := startTime.UnixNano(), endTime.UnixNano()
+
+	var first, last sql.NullInt64
+	err := r.db.conn.QueryRowContext(ctx,
+		"SELECT COUNT(*), MIN(timestamp), MAX(timestamp) FROM logs WHERE timestamp >= ? AND timestamp <= ?",
+		start, end,
+	).Scan(&stats.TotalCount, &first, &last)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get log stats: %w", err)
+	}
+	if first.Valid {
+		stats.FirstLogTime = time.Unix(0, first.Int64)
+	}
+	if last.Valid {
+		stats.LastLogTime = time.Unix(0, last.Int64)
+		stats.TimeRange = stats.LastLogTime.Sub(stats.FirstLogTime)
+	}
+
+	groups := []struct {
+		column string
+		counts map[string]int64
+	}{
+		{"level", stats.ByLevel},
+		{"service_name", stats.ByService},
+		{"source", stats.BySource},
+	}
+	for _, g := range groups {
+		if err := r.countBy(ctx, g.column, start, end, g.counts); err != nil {
+			return nil, err
+		}
+	}
+
+	if stats.TotalCount > 0 {
+		errCount := stats.ByLevel[string(domain.LogLevelError)] + stats.ByLevel[string(domain.LogLevelFatal)]
+		stats.ErrorRate = float64(errCount) / float64(stats.TotalCount)
+	}
+	return stats, nil
+}
+
+// countBy counts entries in the time range grouped by column.
+func (r *LogRepository) countBy(ctx context.Context, column string, start, end int64, counts map[string]int64) error {
+	query := fmt.Sprintf(
+		"SELECT COALESCE(%s, ''), COUNT(*) FROM logs WHERE timestamp >= ? AND timestamp <= ? GROUP BY 1", column)
+	rows, err := r.db.conn.QueryContext(ctx, query, start, end)
+	if err != nil {
+		return fmt.Errorf("failed to count logs by %s: %w", column, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		var count int64
+		if err := rows.Scan(&key, &count); err != nil {
+			return fmt.Errorf("failed to scan log count: %w", err)
+		}
+		counts[key] = count
+	}
+	return rows.Err()
+}
+
+// Delete removes a log entry.
+func (r *LogRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	idBytes, _ := id.MarshalBinary()
+	_, err := r.db.conn.ExecContext(ctx, "DELETE FROM logs WHERE id = ?", idBytes)
+	return err
+}
+
+// DeleteBefore removes log entries older than the given timestamp.
+func (r *LogRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.conn.ExecContext(ctx, "DELETE FROM logs WHERE timestamp < ?", before.UnixNano())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete logs: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// logFilterClause renders the WHERE clause for a log filter. Levels are
+// compared by severity so that MinLevel follows the level ordering rather
+// than string order.
+func logFilterClause(f Features, filter ports.LogFilter) (string, []interface{}, error) {
+	if filter.Level != "" && filter.MinLevel != "" {
+		return "", nil, fmt.Errorf("level and min_level cannot be combined")
+	}
+
+	var conds []string
+	var args []interface{}
+
+	if filter.Level != "" {
+		conds = append(conds, "level = ?")
+		args = append(args, string(filter.Level))
+	}
+	if filter.MinLevel != "" {
+		conds = append(conds, "severity >= ?")
+		args = append(args, domain.LogLevelPriority(filter.MinLevel))
+	}
+	if filter.Source != "" {
+		conds = append(conds, "source = ?")
+		args = append(args, filter.Source)
+	}
+	if filter.ServiceName != "" {
+		conds = append(conds, "service_name = ?")
+		args = append(args, filter.ServiceName)
+	}
+	if filter.TraceID != "" {
+		conds = append(conds, "trace_id = ?")
+		args = append(args, filter.TraceID)
+	}
+	if filter.Search != "" {
+		conds = append(conds, `message LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+escapeLike(filter.Search)+"%")
+	}
+	for k, v := range filter.Attributes {
+		if strings.ContainsAny(k, `"\`) {
+			return "", nil, fmt.Errorf("invalid attribute key %q", k)
+		}
+		cond, condArgs := f.jsonFieldEquals("attributes", k, v)
+		conds = append(conds, cond)
+		args = append(args, condArgs...)
+	}
+	if !filter.StartTime.IsZero() {
+		conds = append(conds, "timestamp >= ?")
+		args = append(args, filter.StartTime.UnixNano())
+	}
+	if !filter.EndTime.IsZero() {
+		conds = append(conds, "timestamp <= ?")
+		args = append(args, filter.EndTime.UnixNano())
+	}
+
+	if len(conds) == 0 {
+		return "", nil, nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args, nil
+}
+
+// escapeLike escapes LIKE wildcards so s matches literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func scanLogEntry(row rowScanner) (*domain.LogEntry, error) {
+	var (
+		idBytes      []byte
+		tsNanos      int64
+		level        string
+		severity     int
+		source       sql.NullString
+		serviceName  sql.NullString
+		traceID      sql.NullString
+		spanID       sql.NullString
+		attrsJSON    sql.NullString
+		resourceJSON sql.NullString
+		parsedJSON   sql.NullString
+		raw          sql.NullString
+		createdAt    int64
+		entry        domain.LogEntry
+	)
+
+	err := row.Scan(&idBytes, &tsNanos, &level, &severity, &entry.Message, &source, &serviceName,
+		&traceID, &spanID, &attrsJSON, &resourceJSON, &parsedJSON, &raw, &createdAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("log entry not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan log entry: %w", err)
+	}
+
+	entry.ID, _ = uuid.FromBytes(idBytes)
+	entry.Timestamp = time.Unix(0, tsNanos)
+	entry.Level = domain.LogLevel(level)
+	entry.Source = source.String
+	entry.ServiceName = serviceName.String
+	entry.TraceID = traceID.String
+	entry.SpanID = spanID.String
+	entry.Raw = raw.String
+	entry.CreatedAt = time.UnixMilli(createdAt)
+
+	entry.Attributes = make(map[string]string)
+	if attrsJSON.Valid && attrsJSON.String != "" {
+		_ = json.Unmarshal([]byte(attrsJSON.String), &entry.Attributes)
+	}
+	entry.Resource = make(map[string]string)
+	if resourceJSON.Valid && resourceJSON.String != "" {
+		_ = json.Unmarshal([]byte(resourceJSON.String), &entry.Resource)
+	}
+	if parsedJSON.Valid && parsedJSON.String != "" && parsedJSON.String != "null" {
+		_ = json.Unmarshal([]byte(parsedJSON.String), &entry.ParsedFields)
+	}
+
+	return &entry, nil
+}
+
+// Ensure LogRepository implements the interface
+var _ ports.LogRepository = (*LogRepository)(nil)
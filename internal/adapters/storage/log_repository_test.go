@@ -0,0 +1,192 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+)
+
+// seedLogs stores one entry per level, a minute apart, oldest first.
+func seedLogs(t *testing.T, repo *LogRepository, base time.Time) []*domain.LogEntry {
+	t.Helper()
+	levels := []domain.LogLevel{
+		domain.LogLevelDebug, domain.LogLevelInfo, domain.LogLevelWarning,
+		domain.LogLevelError, domain.LogLevelFatal,
+	}
+	var entries []*domain.LogEntry
+	for i, level := range levels {
+		entry := domain.NewLogEntry(level, "request "+string(level), "stdout", "api")
+		entry.Timestamp = base.Add(time.Duration(i) * time.Minute)
+		entry.SetAttribute("region", "eu")
+		if i%2 == 0 {
+			entry.SetAttribute("region", "us")
+		}
+		entries = append(entries, entry)
+	}
+	if err := repo.CreateBatch(context.Background(), entries); err != nil {
+		t.Fatalf("CreateBatch failed: %v", err)
+	}
+	return entries
+}
+
+func levelsOf(entries []*domain.LogEntry) []domain.LogLevel {
+	levels := make([]domain.LogLevel, len(entries))
+	for i, e := range entries {
+		levels[i] = e.Level
+	}
+	return levels
+}
+
+func TestLogRepository_CreateAndGet(t *testing.T) {
+	repo := NewLogRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	entry := domain.NewLogEntry(domain.LogLevelError, "disk full", "syslog", "storage")
+	entry.SetTraceContext("abc123", "def456")
+	entry.SetAttribute("device", "sda1")
+	if err := repo.Create(ctx, entry); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	got, err := repo.GetByID(ctx, entry.ID)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if got.Message != "disk full" || got.Level != domain.LogLevelError || got.ServiceName != "storage" {
+		t.Errorf("unexpected entry: %+v", got)
+	}
+	if got.TraceID != "abc123" || got.SpanID != "def456" || got.Attributes["device"] != "sda1" {
+		t.Errorf("expected trace context and attributes to round-trip, got %+v", got)
+	}
+	if !got.Timestamp.Equal(entry.Timestamp) {
+		t.Errorf("expected timestamp %v, got %v", entry.Timestamp, got.Timestamp)
+	}
+}
+
+func TestLogRepository_ListFilters(t *testing.T) {
+	repo := NewLogRepository(setupTestDB(t))
+	ctx := context.Background()
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	seedLogs(t, repo, base)
+
+	// MinLevel follows severity, not string order ("error" < "warning")
+	logs, err := repo.List(ctx, ports.LogFilter{MinLevel: domain.LogLevelWarning})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	got := levelsOf(logs)
+	want := []domain.LogLevel{domain.LogLevelFatal, domain.LogLevelError, domain.LogLevelWarning}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("expected %v, got %v", want, got)
+			break
+		}
+	}
+
+	// Time window is inclusive on both ends
+	logs, err = repo.List(ctx, ports.LogFilter{
+		StartTime: base.Add(time.Minute),
+		EndTime:   base.Add(3 * time.Minute),
+	})
+	if err != nil || len(logs) != 3 {
+		t.Errorf("expected 3 entries in window, got %v, %v", levelsOf(logs), err)
+	}
+
+	// Combined min level and window
+	logs, _ = repo.List(ctx, ports.LogFilter{MinLevel: domain.LogLevelWarning, EndTime: base.Add(3 * time.Minute)})
+	if got := levelsOf(logs); len(got) != 2 || got[0] != domain.LogLevelError {
+		t.Errorf("expected [error warning], got %v", got)
+	}
+
+	logs, _ = repo.List(ctx, ports.LogFilter{Attributes: map[string]string{"region": "us"}})
+	if len(logs) != 3 {
+		t.Errorf("expected 3 entries in region us, got %v", levelsOf(logs))
+	}
+
+	logs, _ = repo.List(ctx, ports.LogFilter{Limit: 2, Offset: 1})
+	if got := levelsOf(logs); len(got) != 2 || got[0] != domain.LogLevelError || got[1] != domain.LogLevelWarning {
+		t.Errorf("expected page [error warning], got %v", got)
+	}
+
+	logs, _ = repo.Search(ctx, "REQUEST WARN", ports.LogFilter{})
+	if len(logs) != 1 || logs[0].Level != domain.LogLevelWarning {
+		t.Errorf("expected case-insensitive search to find the warning, got %v", levelsOf(logs))
+	}
+
+	if _, err := repo.List(ctx, ports.LogFilter{Level: domain.LogLevelError, MinLevel: domain.LogLevelWarning}); err == nil {
+		t.Error("expected error when combining level and min level")
+	}
+}
+
+func TestLogRepository_StatsAndDelete(t *testing.T) {
+	repo := NewLogRepository(setupTestDB(t))
+	ctx := context.Background()
+	base := time.Now().Add(-time.Hour)
+	seedLogs(t, repo, base)
+
+	stats, err := repo.GetStats(ctx, base, base.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GetStats failed: %v", err)
+	}
+	if stats.TotalCount != 5 || stats.ByLevel["error"] != 1 || stats.ByService["api"] != 5 || stats.BySource["stdout"] != 5 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if stats.ErrorRate != 0.4 {
+		t.Errorf("expected error rate 0.4, got %v", stats.ErrorRate)
+	}
+	if stats.TimeRange != 4*time.Minute {
+		t.Errorf("expected time range 4m, got %v", stats.TimeRange)
+	}
+
+	deleted, err := repo.DeleteBefore(ctx, base.Add(2*time.Minute))
+	if err != nil || deleted != 2 {
+		t.Errorf("expected 2 entries deleted, got %d, %v", deleted, err)
+	}
+}
+
+func TestLogRepository_CreateBatchModes(t *testing.T) {
+	repo := NewLogRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	batch := func() []*domain.LogEntry {
+		entries := []*domain.LogEntry{
+			domain.NewLogEntry(domain.LogLevelInfo, "first", "stdout", "api"),
+			domain.NewLogEntry(domain.LogLevelInfo, "duplicate", "stdout", "api"),
+			domain.NewLogEntry(domain.LogLevelInfo, "third", "stdout", "api"),
+		}
+		entries[1].ID = entries[0].ID
+		return entries
+	}
+
+	if err := repo.CreateBatch(ctx, batch()); err == nil {
+		t.Fatal("expected the duplicate ID to fail the batch")
+	}
+	if entries, _ := repo.List(ctx, ports.LogFilter{}); len(entries) != 0 {
+		t.Fatalf("expected the strict batch to be rolled back, got %d entries", len(entries))
+	}
+
+	report, err := repo.CreateBatchBestEffort(ctx, batch())
+	if err != nil {
+		t.Fatalf("CreateBatchBestEffort failed: %v", err)
+	}
+	if report.Written != 2 || len(report.Failed) != 1 || report.Failed[0].Index != 1 {
+		t.Fatalf("expected entry 1 to fail and 2 to be written, got %+v", report)
+	}
+	if !strings.Contains(report.Failed[0].Reason, "UNIQUE") {
+		t.Errorf("expected the constraint in the reason, got %q", report.Failed[0].Reason)
+	}
+	entries, err := repo.List(ctx, ports.LogFilter{})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("expected 2 stored entries, got %d", len(entries))
+	}
+}
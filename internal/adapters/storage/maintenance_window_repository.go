@@ -0,0 +1,195 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+	"github.com/google/uuid"
+)
+
+// MaintenanceWindowRepository implements ports.MaintenanceWindowRepository using SQLite.
+type MaintenanceWindowRepository struct {
+	db *DB
+}
+
+// NewMaintenanceWindowRepository creates a new maintenance window repository.
+func NewMaintenanceWindowRepository(db *DB) *MaintenanceWindowRepository {
+	return &MaintenanceWindowRepository{db: db}
+}
+
+const maintenanceWindowColumns = `id, name, matchers, days, start_time, duration, timezone, enabled, comment, created_by, created_at, updated_at`
+
+// Create persists a new maintenance window.
+func (r *MaintenanceWindowRepository) Create(ctx context.Context, window *domain.MaintenanceWindow) error {
+	matchersJSON, daysJSON, err := marshalMaintenanceWindow(window)
+	if err != nil {
+		return err
+	}
+	idBytes, _ := window.ID.MarshalBinary()
+
+	query := `
+		INSERT INTO maintenance_windows (` + maintenanceWindowColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err = r.db.conn.ExecContext(ctx, query,
+		idBytes,
+		window.Name,
+		matchersJSON,
+		daysJSON,
+		window.StartTime,
+		int64(window.Duration),
+		window.Timezone,
+		window.Enabled,
+		window.Comment,
+		window.CreatedBy,
+		window.CreatedAt.UnixMilli(),
+		window.UpdatedAt.UnixMilli(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert maintenance window: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a maintenance window by its ID.
+func (r *MaintenanceWindowRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.MaintenanceWindow, error) {
+	idBytes, _ := id.MarshalBinary()
+	row := r.db.conn.QueryRowContext(ctx, "SELECT "+maintenanceWindowColumns+" FROM maintenance_windows WHERE id = ?", idBytes)
+	return scanMaintenanceWindow(row)
+}
+
+// GetByName retrieves a maintenance window by its name.
+func (r *MaintenanceWindowRepository) GetByName(ctx context.Context, name string) (*domain.MaintenanceWindow, error) {
+	row := r.db.conn.QueryRowContext(ctx, "SELECT "+maintenanceWindowColumns+" FROM maintenance_windows WHERE name = ?", name)
+	return scanMaintenanceWindow(row)
+}
+
+// Update updates an existing maintenance window.
+func (r *MaintenanceWindowRepository) Update(ctx context.Context, window *domain.MaintenanceWindow) error {
+	matchersJSON, daysJSON, err := marshalMaintenanceWindow(window)
+	if err != nil {
+		return err
+	}
+	idBytes, _ := window.ID.MarshalBinary()
+	window.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE maintenance_windows SET
+			name = ?, matchers = ?, days = ?, start_time = ?, duration = ?, timezone = ?,
+			enabled = ?, comment = ?, updated_at = ?
+		WHERE id = ?
+	`
+	result, err := r.db.conn.ExecContext(ctx, query,
+		window.Name,
+		matchersJSON,
+		daysJSON,
+		window.StartTime,
+		int64(window.Duration),
+		window.Timezone,
+		window.Enabled,
+		window.Comment,
+		window.UpdatedAt.UnixMilli(),
+		idBytes,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update maintenance window: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("maintenance window not found")
+	}
+	return nil
+}
+
+// Delete removes a maintenance window.
+func (r *MaintenanceWindowRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	idBytes, _ := id.MarshalBinary()
+	result, err := r.db.conn.ExecContext(ctx, "DELETE FROM maintenance_windows WHERE id = ?", idBytes)
+	if err != nil {
+		return fmt.Errorf("failed to delete maintenance window: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("maintenance window not found")
+	}
+	return nil
+}
+
+// List retrieves all maintenance windows ordered by name.
+func (r *MaintenanceWindowRepository) List(ctx context.Context) ([]*domain.MaintenanceWindow, error) {
+	rows, err := r.db.conn.QueryContext(ctx, "SELECT "+maintenanceWindowColumns+" FROM maintenance_windows ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query maintenance windows: %w", err)
+	}
+	defer rows.Close()
+
+	var windows []*domain.MaintenanceWindow
+	for rows.Next() {
+		window, err := scanMaintenanceWindow(rows)
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, window)
+	}
+	return windows, rows.Err()
+}
+
+func marshalMaintenanceWindow(window *domain.MaintenanceWindow) ([]byte, []byte, error) {
+	matchersJSON, err := json.Marshal(window.Matchers)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal maintenance window matchers: %w", err)
+	}
+	daysJSON, err := json.Marshal(window.Days)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal maintenance window days: %w", err)
+	}
+	return matchersJSON, daysJSON, nil
+}
+
+func scanMaintenanceWindow(row rowScanner) (*domain.MaintenanceWindow, error) {
+	var (
+		idBytes      []byte
+		matchersJSON sql.NullString
+		daysJSON     sql.NullString
+		duration     int64
+		timezone     sql.NullString
+		comment      sql.NullString
+		createdBy    sql.NullString
+		createdAt    int64
+		updatedAt    int64
+		window       domain.MaintenanceWindow
+	)
+
+	err := row.Scan(&idBytes, &window.Name, &matchersJSON, &daysJSON, &window.StartTime, &duration,
+		&timezone, &window.Enabled, &comment, &createdBy, &createdAt, &updatedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("maintenance window not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan maintenance window: %w", err)
+	}
+
+	window.ID, _ = uuid.FromBytes(idBytes)
+	window.Duration = time.Duration(duration)
+	window.Timezone = timezone.String
+	window.Comment = comment.String
+	window.CreatedBy = createdBy.String
+	window.CreatedAt = time.UnixMilli(createdAt)
+	window.UpdatedAt = time.UnixMilli(updatedAt)
+
+	window.Matchers = make(map[string]string)
+	if matchersJSON.Valid && matchersJSON.String != "" && matchersJSON.String != "null" {
+		_ = json.Unmarshal([]byte(matchersJSON.String), &window.Matchers)
+	}
+	if daysJSON.Valid && daysJSON.String != "" && daysJSON.String != "null" {
+		_ = json.Unmarshal([]byte(daysJSON.String), &window.Days)
+	}
+
+	return &window, nil
+}
+
+// Ensure MaintenanceWindowRepository implements the interface
+var _ ports.MaintenanceWindowRepository = (*MaintenanceWindowRepository)(nil)
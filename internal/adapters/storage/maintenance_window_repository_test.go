@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+)
+
+func TestMaintenanceWindowRepository_CRUD(t *testing.T) {
+	repo := NewMaintenanceWindowRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	window := domain.NewMaintenanceWindow("deploys", map[string]string{"env": "staging"},
+		[]time.Weekday{time.Monday, time.Friday}, "18:00", time.Hour, "Europe/Berlin")
+	window.Comment = "weekday deploys"
+	if err := repo.Create(ctx, window); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := repo.Create(ctx, domain.NewMaintenanceWindow("deploys", nil, nil, "02:00", time.Hour, "")); err == nil {
+		t.Error("expected duplicate window name to be rejected")
+	}
+
+	got, err := repo.GetByName(ctx, "deploys")
+	if err != nil {
+		t.Fatalf("GetByName failed: %v", err)
+	}
+	if got.ID != window.ID || got.Matchers["env"] != "staging" || len(got.Days) != 2 || got.Days[1] != time.Friday ||
+		got.StartTime != "18:00" || got.Duration != time.Hour || got.Timezone != "Europe/Berlin" || !got.Enabled || got.Comment != "weekday deploys" {
+		t.Errorf("unexpected window: %+v", got)
+	}
+
+	got.Enabled = false
+	got.Days = nil
+	got.Duration = 90 * time.Minute
+	if err := repo.Update(ctx, got); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if got, _ = repo.GetByID(ctx, window.ID); got.Enabled || got.Days != nil || got.Duration != 90*time.Minute {
+		t.Errorf("expected updated window, got %+v", got)
+	}
+
+	if all, _ := repo.List(ctx); len(all) != 1 {
+		t.Errorf("expected 1 window, got %d", len(all))
+	}
+	if err := repo.Delete(ctx, window.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := repo.GetByID(ctx, window.ID); err == nil {
+		t.Error("expected deleted window to be gone")
+	}
+	if err := repo.Delete(ctx, window.ID); err == nil {
+		t.Error("expected deleting a missing window to fail")
+	}
+}
@@ -2,8 +2,16 @@ package storage
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/forge-platform/forge/internal/core/domain"
@@ -14,20 +22,97 @@ import (
 // MetricRepository implements ports.MetricRepository using SQLite.
 type MetricRepository struct {
 	db *DB
+
+	// Series limit state, guarded by seriesMu. knownSeries caches the
+	// series of each metric name and is loaded lazily on first write.
+	seriesMu         sync.Mutex
+	maxSeriesPerName int
+	knownSeries      map[string]map[uint64]struct{}
+	droppedSeries    atomic.Int64
+	droppedNonFinite atomic.Int64
 }
 
+// finiteValue restricts a query on the metrics table to finite values, so
+// infinities written before values were validated can't poison aggregates.
+const finiteValue = " AND abs(value) <= 1.7976931348623157e308"
+
 // NewMetricRepository creates a new metric repository.
 func NewMetricRepository(db *DB) *MetricRepository {
 	return &MetricRepository{db: db}
 }
 
+// SetMaxSeriesPerName limits how many distinct series a single metric name
+// may have. Writes that would create a series beyond the limit are rejected
+// with a *ports.SeriesLimitError. A limit of zero or less means unlimited.
+func (r *MetricRepository) SetMaxSeriesPerName(limit int) {
+	r.seriesMu.Lock()
+	defer r.seriesMu.Unlock()
+	r.maxSeriesPerName = limit
+	r.knownSeries = make(map[string]map[uint64]struct{})
+}
+
+// seriesQuerier is satisfied by both *sql.DB and *sql.Tx.
+type seriesQuerier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// admitSeries reports whether a point of the given series may be written
+// and registers the series if it is new. The caller holds r.seriesMu.
+func (r *MetricRepository) admitSeries(ctx context.Context, q seriesQuerier, name string, hash uint64) (bool, error) {
+	known, ok := r.knownSeries[name]
+	if !ok {
+		rows, err := q.QueryContext(ctx, "SELECT DISTINCT series_hash FROM metrics WHERE name = ?", name)
+		if err != nil {
+			return false, fmt.Errorf("failed to load series: %w", err)
+		}
+		known = make(map[uint64]struct{})
+		for rows.Next() {
+			var h int64
+			if err := rows.Scan(&h); err != nil {
+				rows.Close()
+				return false, fmt.Errorf("failed to scan series: %w", err)
+			}
+			known[int64ToHash(h)] = struct{}{}
+		}
+		rows.Close()
+		r.knownSeries[name] = known
+	}
+
+	if _, ok := known[hash]; ok {
+		return true, nil
+	}
+	if len(known) >= r.maxSeriesPerName {
+		return false, nil
+	}
+	known[hash] = struct{}{}
+	return true, nil
+}
+
 // Record persists a new metric.
 func (r *MetricRepository) Record(ctx context.Context, metric *domain.Metric) error {
+	if !domain.IsFiniteValue(metric.Value) {
+		r.droppedNonFinite.Add(1)
+		return &ports.NonFiniteValueError{Name: metric.Name, Dropped: 1}
+	}
+
 	tagsJSON, err := json.Marshal(metric.Tags)
 	if err != nil {
 		return fmt.Errorf("failed to marshal tags: %w", err)
 	}
 
+	r.seriesMu.Lock()
+	defer r.seriesMu.Unlock()
+	if r.maxSeriesPerName > 0 {
+		ok, err := r.admitSeries(ctx, r.db.conn, metric.Name, metric.SeriesHash)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			r.droppedSeries.Add(1)
+			return &ports.SeriesLimitError{Name: metric.Name, Limit: r.maxSeriesPerName, Dropped: 1}
+		}
+	}
+
 	query := `
 		INSERT INTO metrics (id, name, type, value, timestamp, series_hash, tags)
 		VALUES (?, ?, ?, ?, ?, ?, ?)
@@ -45,19 +130,34 @@ func (r *MetricRepository) Record(ctx context.Context, metric *domain.Metric) er
 	)
 
 	if err != nil {
+		// The series may have been registered without being written
+		r.knownSeries = make(map[string]map[uint64]struct{})
 		return fmt.Errorf("failed to insert metric: %w", err)
 	}
 
 	return nil
 }
 
-// RecordBatch persists multiple metrics in a single transaction.
-func (r *MetricRepository) RecordBatch(ctx context.Context, metrics []*domain.Metric) error {
+// RecordBatch persists multiple metrics in a single transaction. Points
+// rejected by the series limit or for a NaN or infinite value are skipped;
+// the others are still written and a *ports.SeriesLimitError or
+// *ports.NonFiniteValueError describing the drops is returned, joined if
+// there are both.
+func (r *MetricRepository) RecordBatch(ctx context.Context, metrics []*domain.Metric) (err error) {
+	r.seriesMu.Lock()
+	defer r.seriesMu.Unlock()
+
 	tx, err := r.db.BeginTx(ctx)
 	if err != nil {
 		return err
 	}
 	defer func() { _ = tx.Rollback() }()
+	defer func() {
+		if err != nil && r.maxSeriesPerName > 0 && !errors.Is(err, ports.ErrSeriesLimitExceeded) {
+			// Series registered by a rolled back batch were never written
+			r.knownSeries = make(map[string]map[uint64]struct{})
+		}
+	}()
 
 	stmt, err := tx.PrepareContext(ctx, `
 		INSERT INTO metrics (id, name, type, value, timestamp, series_hash, tags)
@@ -68,7 +168,30 @@ func (r *MetricRepository) RecordBatch(ctx context.Context, metrics []*domain.Me
 	}
 	defer stmt.Close()
 
+	var limitErr *ports.SeriesLimitError
+	var nonFiniteErr *ports.NonFiniteValueError
 	for _, metric := range metrics {
+		if !domain.IsFiniteValue(metric.Value) {
+			if nonFiniteErr == nil {
+				nonFiniteErr = &ports.NonFiniteValueError{Name: metric.Name}
+			}
+			nonFiniteErr.Dropped++
+			continue
+		}
+		if r.maxSeriesPerName > 0 {
+			ok, err := r.admitSeries(ctx, tx, metric.Name, metric.SeriesHash)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				if limitErr == nil {
+					limitErr = &ports.SeriesLimitError{Name: metric.Name, Limit: r.maxSeriesPerName}
+				}
+				limitErr.Dropped++
+				continue
+			}
+		}
+
 		tagsJSON, _ := json.Marshal(metric.Tags)
 		idBytes, _ := metric.ID.MarshalBinary()
 
@@ -86,22 +209,111 @@ func (r *MetricRepository) RecordBatch(ctx context.Context, metrics []*domain.Me
 		}
 	}
 
-	return tx.Commit()
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	switch {
+	case limitErr != nil && nonFiniteErr != nil:
+		r.droppedSeries.Add(int64(limitErr.Dropped))
+		r.droppedNonFinite.Add(int64(nonFiniteErr.Dropped))
+		return errors.Join(limitErr, nonFiniteErr)
+	case limitErr != nil:
+		r.droppedSeries.Add(int64(limitErr.Dropped))
+		return limitErr
+	case nonFiniteErr != nil:
+		r.droppedNonFinite.Add(int64(nonFiniteErr.Dropped))
+		return nonFiniteErr
+	}
+	return nil
 }
 
-// Query retrieves metrics matching the given criteria.
+// RecordBatchBestEffort persists metrics in a single transaction, skipping
+// the ones that cannot be written. Points rejected by the series limit, for
+// a NaN or infinite value or by the database are listed in the report; the
+// rest are committed.
+func (r *MetricRepository) RecordBatchBestEffort(ctx context.Context, metrics []*domain.Metric) (*ports.BatchReport, error) {
+	r.seriesMu.Lock()
+	defer r.seriesMu.Unlock()
+
+	dropped, nonFinite := 0, 0
+	report, err := bestEffortBatch(ctx, r.db, len(metrics), func(tx *sql.Tx, i int) error {
+		metric := metrics[i]
+		if metric == nil {
+			return fmt.Errorf("metric is nil")
+		}
+		if !domain.IsFiniteValue(metric.Value) {
+			nonFinite++
+			return &ports.NonFiniteValueError{Name: metric.Name, Dropped: 1}
+		}
+		if r.maxSeriesPerName > 0 {
+			ok, err := r.admitSeries(ctx, tx, metric.Name, metric.SeriesHash)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				dropped++
+				return &ports.SeriesLimitError{Name: metric.Name, Limit: r.maxSeriesPerName, Dropped: 1}
+			}
+		}
+
+		tagsJSON, err := json.Marshal(metric.Tags)
+		if err != nil {
+			return fmt.Errorf("failed to marshal tags: %w", err)
+		}
+		idBytes, _ := metric.ID.MarshalBinary()
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO metrics (id, name, type, value, timestamp, series_hash, tags)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`,
+			idBytes,
+			metric.Name,
+			string(metric.Type),
+			metric.Value,
+			metric.Timestamp.UnixMilli(),
+			hashToInt64(metric.SeriesHash),
+			tagsJSON,
+		)
+		if err != nil {
+			// The series may have been registered without being written
+			r.knownSeries = make(map[string]map[uint64]struct{})
+			return fmt.Errorf("failed to insert metric: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		r.knownSeries = make(map[string]map[uint64]struct{})
+		return nil, err
+	}
+	r.droppedSeries.Add(int64(dropped))
+	r.droppedNonFinite.Add(int64(nonFinite))
+	return report, nil
+}
+
+// Query retrieves metrics matching the given criteria. A query must name
+// the metric, select a series by SeriesHash, or both.
 func (r *MetricRepository) Query(ctx context.Context, query ports.MetricQuery) (*domain.MetricSeries, error) {
+	if query.Name == "" && query.SeriesHash == nil {
+		return nil, ports.ErrMetricNameRequired
+	}
+
 	sqlQuery := `
 		SELECT id, name, type, value, timestamp, series_hash, tags
 		FROM metrics
-		WHERE name = ? AND timestamp >= ? AND timestamp <= ?
+		WHERE timestamp >= ? AND timestamp <= ?` + finiteValue + `
 	`
-	args := []interface{}{query.Name, query.StartTime.UnixMilli(), query.EndTime.UnixMilli()}
+	args := []interface{}{query.StartTime.UnixMilli(), query.EndTime.UnixMilli()}
 
+	if query.Name != "" {
+		sqlQuery += " AND name = ?"
+		args = append(args, query.Name)
+	}
 	if query.SeriesHash != nil {
 		sqlQuery += " AND series_hash = ?"
 		args = append(args, hashToInt64(*query.SeriesHash))
 	}
+	tagSQL, tagArgs := tagFilter(r.db.features, "metrics.tags", query.Tags)
+	sqlQuery += tagSQL
+	args = append(args, tagArgs...)
 
 	sqlQuery += " ORDER BY timestamp ASC"
 
@@ -136,11 +348,17 @@ func (r *MetricRepository) Query(ctx context.Context, query ports.MetricQuery) (
 			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
 
+		series.Name = name
 		series.SeriesHash = int64ToHash(seriesHash)
-		series.Points = append(series.Points, domain.MetricPoint{
+		series.Type = domain.MetricType(metricType)
+		point := domain.MetricPoint{
 			Value:     value,
 			Timestamp: time.UnixMilli(timestamp),
-		})
+		}
+		if query.IncludeIDs {
+			setPointIdentity(&point, idBytes, metricType)
+		}
+		series.Points = append(series.Points, point)
 
 		if series.Tags == nil && len(tagsJSON) > 0 {
 			_ = json.Unmarshal(tagsJSON, &series.Tags)
@@ -150,14 +368,198 @@ func (r *MetricRepository) Query(ctx context.Context, query ports.MetricQuery) (
 	return series, nil
 }
 
-// QueryMultiple retrieves multiple series matching the criteria.
+// QueryMultiple retrieves every series matching the criteria, one
+// MetricSeries per series hash with that series' own tags. An empty Name
+// matches all metrics in the time range. Limit caps the points returned for
+// each series, oldest first.
 func (r *MetricRepository) QueryMultiple(ctx context.Context, query ports.MetricQuery) ([]*domain.MetricSeries, error) {
-	// For now, delegate to Query - can be optimized later
-	series, err := r.Query(ctx, query)
+	where := "timestamp >= ? AND timestamp <= ?" + finiteValue
+	args := []interface{}{query.StartTime.UnixMilli(), query.EndTime.UnixMilli()}
+	if query.Name != "" {
+		where += " AND name = ?"
+		args = append(args, query.Name)
+	}
+	if query.SeriesHash != nil {
+		where += " AND series_hash = ?"
+		args = append(args, hashToInt64(*query.SeriesHash))
+	}
+	tagSQL, tagArgs := tagFilter(r.db.features, "metrics.tags", query.Tags)
+	where += tagSQL
+	args = append(args, tagArgs...)
+
+	sqlQuery := `
+		SELECT id, name, type, value, timestamp, series_hash, tags
+		FROM metrics
+		WHERE ` + where + `
+		ORDER BY name, series_hash, timestamp ASC
+	`
+	if query.Limit > 0 {
+		sqlQuery = `
+			SELECT id, name, type, value, timestamp, series_hash, tags FROM (
+				SELECT id, name, type, value, timestamp, series_hash, tags,
+					ROW_NUMBER() OVER (PARTITION BY series_hash ORDER BY timestamp ASC) AS rn
+				FROM metrics
+				WHERE ` + where + `
+			)
+			WHERE rn <= ?
+			ORDER BY name, series_hash, timestamp ASC
+		`
+		args = append(args, query.Limit)
+	}
+
+	rows, err := r.db.conn.QueryContext(ctx, sqlQuery, args...)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to query metrics: %w", err)
+	}
+	defer rows.Close()
+
+	var result []*domain.MetricSeries
+	var current *domain.MetricSeries
+	for rows.Next() {
+		var (
+			idBytes    []byte
+			name       string
+			metricType string
+			value      float64
+			timestamp  int64
+			seriesHash int64
+			tagsJSON   []byte
+		)
+		if err := rows.Scan(&idBytes, &name, &metricType, &value, &timestamp, &seriesHash, &tagsJSON); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		hash := int64ToHash(seriesHash)
+		if current == nil || current.SeriesHash != hash || current.Name != name {
+			current = &domain.MetricSeries{
+				Name:       name,
+				Type:       domain.MetricType(metricType),
+				SeriesHash: hash,
+				Points:     []domain.MetricPoint{},
+			}
+			if len(tagsJSON) > 0 {
+				_ = json.Unmarshal(tagsJSON, &current.Tags)
+			}
+			result = append(result, current)
+		}
+		point := domain.MetricPoint{
+			Value:     value,
+			Timestamp: time.UnixMilli(timestamp),
+		}
+		if query.IncludeIDs {
+			setPointIdentity(&point, idBytes, metricType)
+		}
+		current.Points = append(current.Points, point)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read metrics: %w", err)
+	}
+
+	return result, nil
+}
+
+// Export streams every raw point matching the criteria to fn, one row at a
+// time, ordered by name, series and timestamp. Limit is ignored.
+func (r *MetricRepository) Export(ctx context.Context, query ports.MetricQuery, fn func(series *domain.MetricSeries, point domain.MetricPoint) error) error {
+	where := "timestamp >= ? AND timestamp <= ?" + finiteValue
+	args := []interface{}{query.StartTime.UnixMilli(), query.EndTime.UnixMilli()}
+	if query.Name != "" {
+		where += " AND name = ?"
+		args = append(args, query.Name)
+	}
+	if query.SeriesHash != nil {
+		where += " AND series_hash = ?"
+		args = append(args, hashToInt64(*query.SeriesHash))
+	}
+	tagSQL, tagArgs := tagFilter(r.db.features, "metrics.tags", query.Tags)
+	where += tagSQL
+	args = append(args, tagArgs...)
+
+	rows, err := r.db.conn.QueryContext(ctx, `
+		SELECT id, name, type, value, timestamp, series_hash, tags
+		FROM metrics
+		WHERE `+where+`
+		ORDER BY name, series_hash, timestamp ASC
+	`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query metrics: %w", err)
+	}
+	defer rows.Close()
+
+	var current *domain.MetricSeries
+	for rows.Next() {
+		var (
+			idBytes    []byte
+			name       string
+			metricType string
+			value      float64
+			timestamp  int64
+			seriesHash int64
+			tagsJSON   []byte
+		)
+		if err := rows.Scan(&idBytes, &name, &metricType, &value, &timestamp, &seriesHash, &tagsJSON); err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		hash := int64ToHash(seriesHash)
+		if current == nil || current.SeriesHash != hash || current.Name != name {
+			current = &domain.MetricSeries{
+				Name:       name,
+				Type:       domain.MetricType(metricType),
+				SeriesHash: hash,
+			}
+			if len(tagsJSON) > 0 {
+				_ = json.Unmarshal(tagsJSON, &current.Tags)
+			}
+		}
+		point := domain.MetricPoint{
+			Value:     value,
+			Timestamp: time.UnixMilli(timestamp),
+		}
+		if query.IncludeIDs {
+			setPointIdentity(&point, idBytes, metricType)
+		}
+		if err := fn(current, point); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read metrics: %w", err)
 	}
-	return []*domain.MetricSeries{series}, nil
+	return nil
+}
+
+// setPointIdentity sets a point's ID and Type from its stored row.
+func setPointIdentity(point *domain.MetricPoint, idBytes []byte, metricType string) {
+	if id, err := uuid.FromBytes(idBytes); err == nil {
+		point.ID = &id
+	}
+	point.Type = domain.MetricType(metricType)
+}
+
+// tagFilter returns SQL conditions restricting rows to series that carry
+// every tag in tags, to be appended to a WHERE clause. Tags are compared
+// against the stored tags column (see Features.jsonFieldEquals), so a query
+// may name any subset of a series' tags; the series hash only identifies
+// complete tag sets and is not used.
+func tagFilter(f Features, column string, tags map[string]string) (string, []interface{}) {
+	if len(tags) == 0 {
+		return "", nil
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	args := make([]interface{}, 0, 2*len(keys))
+	for _, k := range keys {
+		cond, condArgs := f.jsonFieldEquals(column, k, tags[k])
+		b.WriteString(" AND " + cond)
+		args = append(args, condArgs...)
+	}
+	return b.String(), args
 }
 
 // QueryWithAggregation retrieves metrics with time-bucket aggregation.
@@ -167,27 +569,7 @@ func (r *MetricRepository) QueryWithAggregation(ctx context.Context, query ports
 	}
 
 	stepMs := query.Step.Milliseconds()
-
-	// Build aggregation SQL based on type
-	var aggExpr string
-	switch query.Aggregation {
-	case ports.AggregationAvg:
-		aggExpr = "AVG(value)"
-	case ports.AggregationSum:
-		aggExpr = "SUM(value)"
-	case ports.AggregationMin:
-		aggExpr = "MIN(value)"
-	case ports.AggregationMax:
-		aggExpr = "MAX(value)"
-	case ports.AggregationCount:
-		aggExpr = "COUNT(*)"
-	case ports.AggregationLast:
-		aggExpr = "value" // Will use ORDER BY timestamp DESC LIMIT 1 per bucket
-	case ports.AggregationFirst:
-		aggExpr = "value" // Will use ORDER BY timestamp ASC LIMIT 1 per bucket
-	default:
-		aggExpr = "AVG(value)"
-	}
+	aggExpr := aggregationExpr(query.Aggregation)
 
 	sqlQuery := fmt.Sprintf(`
 		SELECT
@@ -199,7 +581,7 @@ func (r *MetricRepository) QueryWithAggregation(ctx context.Context, query ports
 			SUM(value) as sum_val,
 			AVG(value) as avg_val
 		FROM metrics
-		WHERE name = ? AND timestamp >= ? AND timestamp <= ?
+		WHERE name = ? AND timestamp >= ? AND timestamp <= ?` + finiteValue + `
 	`, stepMs, stepMs, aggExpr)
 
 	args := []interface{}{query.Name, query.StartTime.UnixMilli(), query.EndTime.UnixMilli()}
@@ -208,6 +590,9 @@ func (r *MetricRepository) QueryWithAggregation(ctx context.Context, query ports
 		sqlQuery += " AND series_hash = ?"
 		args = append(args, hashToInt64(*query.SeriesHash))
 	}
+	tagSQL, tagArgs := tagFilter(r.db.features, "metrics.tags", query.Tags)
+	sqlQuery += tagSQL
+	args = append(args, tagArgs...)
 
 	sqlQuery += " GROUP BY bucket ORDER BY bucket ASC"
 
@@ -251,6 +636,262 @@ func (r *MetricRepository) QueryWithAggregation(ctx context.Context, query ports
 	return results, nil
 }
 
+// aggregationExpr returns the SQL expression computing an aggregation of
+// the value column.
+func aggregationExpr(agg ports.AggregationType) string {
+	switch agg {
+	case ports.AggregationAvg:
+		return "AVG(value)"
+	case ports.AggregationSum:
+		return "SUM(value)"
+	case ports.AggregationMin:
+		return "MIN(value)"
+	case ports.AggregationMax:
+		return "MAX(value)"
+	case ports.AggregationCount:
+		return "COUNT(*)"
+	case ports.AggregationLast:
+		return "value" // Will use ORDER BY timestamp DESC LIMIT 1 per bucket
+	case ports.AggregationFirst:
+		return "value" // Will use ORDER BY timestamp ASC LIMIT 1 per bucket
+	default:
+		return "AVG(value)"
+	}
+}
+
+// QueryGrouped aggregates metrics per combination of GroupBy tag values.
+// Tags are read from the JSON tags column with json_extract, so the name and
+// time range still narrow the scan through idx_metrics_name_time. Series
+// without a grouping tag are grouped under ports.GroupNone. GroupBy "*"
+// groups by series hash instead, one group per series with all its tags.
+//
+// Without a step each group is aggregated over the whole range into a single
+// result stamped with the group's first timestamp.
+//
+// Without json1 the rows are aggregated per series and the series are merged
+// into groups by queryGroupedBySeries.
+func (r *MetricRepository) QueryGrouped(ctx context.Context, query ports.MetricQuery) ([]ports.GroupedResult, error) {
+	if len(query.GroupBy) == 0 {
+		return nil, fmt.Errorf("at least one group by tag is required")
+	}
+	allTags := len(query.GroupBy) == 1 && query.GroupBy[0] == ports.GroupAllTags
+	if !allTags {
+		for _, key := range query.GroupBy {
+			if key == "" || key == ports.GroupAllTags || strings.ContainsAny(key, `"\`) {
+				return nil, fmt.Errorf("invalid group by tag %q", key)
+			}
+		}
+		if !r.db.features.JSON1 {
+			return r.queryGroupedBySeries(ctx, query)
+		}
+	}
+
+	bucketExpr := "0"
+	if query.Step > 0 {
+		stepMs := query.Step.Milliseconds()
+		bucketExpr = fmt.Sprintf("(timestamp / %d) * %d", stepMs, stepMs)
+	}
+
+	var selectCols, groupCols []string
+	var args []interface{}
+	if allTags {
+		selectCols = []string{"series_hash", "MAX(tags)"}
+		groupCols = []string{"series_hash"}
+	} else {
+		for i, key := range query.GroupBy {
+			col := fmt.Sprintf("g%d", i)
+			selectCols = append(selectCols, fmt.Sprintf("COALESCE(CAST(json_extract(tags, ?) AS TEXT), '%s') AS %s", ports.GroupNone, col))
+			groupCols = append(groupCols, col)
+			args = append(args, `$."`+key+`"`)
+		}
+	}
+
+	sqlQuery := fmt.Sprintf(`
+		SELECT
+			%s,
+			%s as bucket,
+			%s as agg_value,
+			COUNT(*) as cnt,
+			MIN(value) as min_val,
+			MAX(value) as max_val,
+			SUM(value) as sum_val,
+			AVG(value) as avg_val,
+			MIN(timestamp) as first_ts
+		FROM metrics
+		WHERE name = ? AND timestamp >= ? AND timestamp <= ?` + finiteValue + `
+	`, strings.Join(selectCols, ", "), bucketExpr, aggregationExpr(query.Aggregation))
+	args = append(args, query.Name, query.StartTime.UnixMilli(), query.EndTime.UnixMilli())
+
+	if query.SeriesHash != nil {
+		sqlQuery += " AND series_hash = ?"
+		args = append(args, hashToInt64(*query.SeriesHash))
+	}
+	tagSQL, tagArgs := tagFilter(r.db.features, "metrics.tags", query.Tags)
+	sqlQuery += tagSQL
+	args = append(args, tagArgs...)
+
+	order := strings.Join(groupCols, ", ") + ", bucket"
+	sqlQuery += " GROUP BY " + order + " ORDER BY " + order
+
+	rows, err := r.db.conn.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query grouped metrics: %w", err)
+	}
+	defer rows.Close()
+
+	var groups []ports.GroupedResult
+	var lastKey string
+	for rows.Next() {
+		var (
+			result     ports.AggregatedResult
+			bucket     int64
+			firstTS    int64
+			seriesHash int64
+			tagsJSON   []byte
+			dest       []interface{}
+		)
+		groupVals := make([]string, len(query.GroupBy))
+		if allTags {
+			dest = append(dest, &seriesHash, &tagsJSON)
+		} else {
+			for i := range groupVals {
+				dest = append(dest, &groupVals[i])
+			}
+		}
+		dest = append(dest, &bucket, &result.Value, &result.Count, &result.Min, &result.Max, &result.Sum, &result.Avg, &firstTS)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		result.Timestamp = time.UnixMilli(bucket)
+		if query.Step <= 0 {
+			result.Timestamp = time.UnixMilli(firstTS)
+		}
+
+		key := strings.Join(groupVals, "\x00")
+		if allTags {
+			key = strconv.FormatInt(seriesHash, 10)
+		}
+		if len(groups) == 0 || key != lastKey {
+			tags := make(map[string]string, len(groupVals))
+			if allTags {
+				if len(tagsJSON) > 0 {
+					_ = json.Unmarshal(tagsJSON, &tags)
+				}
+			} else {
+				for i, k := range query.GroupBy {
+					tags[k] = groupVals[i]
+				}
+			}
+			groups = append(groups, ports.GroupedResult{Tags: tags})
+			lastKey = key
+		}
+		g := &groups[len(groups)-1]
+		if query.Limit > 0 && len(g.Results) >= query.Limit {
+			continue
+		}
+		g.Results = append(g.Results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read grouped metrics: %w", err)
+	}
+
+	return groups, nil
+}
+
+// queryGroupedBySeries groups by tag in the application: it aggregates each
+// series with GroupBy "*" and merges the series' buckets into their groups.
+// Count, sum, min, max and avg merge exactly; first and last keep the value
+// of one of the merged series, as the SQL form does.
+func (r *MetricRepository) queryGroupedBySeries(ctx context.Context, query ports.MetricQuery) ([]ports.GroupedResult, error) {
+	perSeries := query
+	perSeries.GroupBy = []string{ports.GroupAllTags}
+	perSeries.Limit = 0
+	series, err := r.QueryGrouped(ctx, perSeries)
+	if err != nil {
+		return nil, err
+	}
+
+	type group struct {
+		tags    map[string]string
+		buckets map[int64]*ports.AggregatedResult
+	}
+	groups := make(map[string]*group)
+	for _, s := range series {
+		tags := make(map[string]string, len(query.GroupBy))
+		vals := make([]string, len(query.GroupBy))
+		for i, k := range query.GroupBy {
+			v, ok := s.Tags[k]
+			if !ok {
+				v = ports.GroupNone
+			}
+			tags[k], vals[i] = v, v
+		}
+		key := strings.Join(vals, "\x00")
+		g, ok := groups[key]
+		if !ok {
+			g = &group{tags: tags, buckets: make(map[int64]*ports.AggregatedResult)}
+			groups[key] = g
+		}
+		for _, res := range s.Results {
+			var bucket int64
+			if query.Step > 0 {
+				bucket = res.Timestamp.UnixMilli()
+			}
+			merged, ok := g.buckets[bucket]
+			if !ok {
+				res := res
+				g.buckets[bucket] = &res
+				continue
+			}
+			if res.Timestamp.Before(merged.Timestamp) {
+				merged.Timestamp = res.Timestamp
+			}
+			merged.Count += res.Count
+			merged.Sum += res.Sum
+			merged.Min = math.Min(merged.Min, res.Min)
+			merged.Max = math.Max(merged.Max, res.Max)
+			merged.Avg = merged.Sum / float64(merged.Count)
+			switch query.Aggregation {
+			case ports.AggregationSum:
+				merged.Value = merged.Sum
+			case ports.AggregationMin:
+				merged.Value = merged.Min
+			case ports.AggregationMax:
+				merged.Value = merged.Max
+			case ports.AggregationCount:
+				merged.Value = float64(merged.Count)
+			case ports.AggregationLast, ports.AggregationFirst:
+				// keep the value already taken from one series
+			default:
+				merged.Value = merged.Avg
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	results := make([]ports.GroupedResult, 0, len(keys))
+	for _, k := range keys {
+		g := groups[k]
+		buckets := make([]ports.AggregatedResult, 0, len(g.buckets))
+		for _, b := range g.buckets {
+			buckets = append(buckets, *b)
+		}
+		sort.Slice(buckets, func(i, j int) bool {
+			return buckets[i].Timestamp.Before(buckets[j].Timestamp)
+		})
+		if query.Limit > 0 && len(buckets) > query.Limit {
+			buckets = buckets[:query.Limit]
+		}
+		results = append(results, ports.GroupedResult{Tags: g.tags, Results: buckets})
+	}
+	return results, nil
+}
+
 // Aggregate performs aggregation on metrics.
 func (r *MetricRepository) Aggregate(ctx context.Context, query ports.MetricQuery, resolution string) (*domain.AggregatedMetric, error) {
 	sqlQuery := `
@@ -263,7 +904,7 @@ func (r *MetricRepository) Aggregate(ctx context.Context, query ports.MetricQuer
 			MIN(timestamp) as first_ts,
 			MAX(timestamp) as last_ts
 		FROM metrics
-		WHERE name = ? AND timestamp >= ? AND timestamp <= ?
+		WHERE name = ? AND timestamp >= ? AND timestamp <= ?` + finiteValue + `
 	`
 	args := []interface{}{query.Name, query.StartTime.UnixMilli(), query.EndTime.UnixMilli()}
 
@@ -271,6 +912,9 @@ func (r *MetricRepository) Aggregate(ctx context.Context, query ports.MetricQuer
 		sqlQuery += " AND series_hash = ?"
 		args = append(args, hashToInt64(*query.SeriesHash))
 	}
+	tagSQL, tagArgs := tagFilter(r.db.features, "metrics.tags", query.Tags)
+	sqlQuery += tagSQL
+	args = append(args, tagArgs...)
 
 	var (
 		count   int64
@@ -316,23 +960,41 @@ func (r *MetricRepository) DeleteBefore(ctx context.Context, before time.Time) (
 	if err != nil {
 		return 0, fmt.Errorf("failed to delete metrics: %w", err)
 	}
+
+	// Deleted points may have removed whole series
+	r.seriesMu.Lock()
+	r.knownSeries = make(map[string]map[uint64]struct{})
+	r.seriesMu.Unlock()
+
 	return result.RowsAffected()
 }
 
-// RecordAggregated persists an aggregated metric.
+// insertAggregatedSQL replaces the aggregate already stored for the same
+// series, resolution and window, so re-aggregating a window never
+// duplicates it.
+const insertAggregatedSQL = `
+		INSERT INTO metrics_aggregated (id, name, series_hash, window_start, window_end, resolution, count, sum, min, max, avg, tags)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(series_hash, resolution, window_start) DO UPDATE SET
+			window_end = excluded.window_end,
+			count = excluded.count,
+			sum = excluded.sum,
+			min = excluded.min,
+			max = excluded.max,
+			avg = excluded.avg,
+			tags = excluded.tags
+	`
+
+// RecordAggregated persists an aggregated metric. An aggregate for the same
+// series, resolution and window is replaced.
 func (r *MetricRepository) RecordAggregated(ctx context.Context, agg *domain.AggregatedMetric) error {
 	tagsJSON, err := json.Marshal(agg.Tags)
 	if err != nil {
 		return fmt.Errorf("failed to marshal tags: %w", err)
 	}
 
-	query := `
-		INSERT INTO metrics_aggregated (id, name, series_hash, window_start, window_end, resolution, count, sum, min, max, avg, tags)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`
-
 	idBytes, _ := agg.ID.MarshalBinary()
-	_, err = r.db.conn.ExecContext(ctx, query,
+	_, err = r.db.conn.ExecContext(ctx, insertAggregatedSQL,
 		idBytes,
 		agg.Name,
 		hashToInt64(agg.SeriesHash),
@@ -354,7 +1016,8 @@ func (r *MetricRepository) RecordAggregated(ctx context.Context, agg *domain.Agg
 	return nil
 }
 
-// RecordAggregatedBatch persists multiple aggregated metrics.
+// RecordAggregatedBatch persists multiple aggregated metrics, replacing any
+// stored for the same series, resolution and window.
 func (r *MetricRepository) RecordAggregatedBatch(ctx context.Context, aggs []*domain.AggregatedMetric) error {
 	tx, err := r.db.BeginTx(ctx)
 	if err != nil {
@@ -362,10 +1025,7 @@ func (r *MetricRepository) RecordAggregatedBatch(ctx context.Context, aggs []*do
 	}
 	defer func() { _ = tx.Rollback() }()
 
-	stmt, err := tx.PrepareContext(ctx, `
-		INSERT INTO metrics_aggregated (id, name, series_hash, window_start, window_end, resolution, count, sum, min, max, avg, tags)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`)
+	stmt, err := tx.PrepareContext(ctx, insertAggregatedSQL)
 	if err != nil {
 		return fmt.Errorf("failed to prepare statement: %w", err)
 	}
@@ -410,6 +1070,9 @@ func (r *MetricRepository) QueryAggregated(ctx context.Context, query ports.Metr
 		sqlQuery += " AND series_hash = ?"
 		args = append(args, hashToInt64(*query.SeriesHash))
 	}
+	tagSQL, tagArgs := tagFilter(r.db.features, "metrics_aggregated.tags", query.Tags)
+	sqlQuery += tagSQL
+	args = append(args, tagArgs...)
 
 	sqlQuery += " ORDER BY window_start ASC"
 
@@ -468,6 +1131,25 @@ func (r *MetricRepository) QueryAggregated(ctx context.Context, query ports.Metr
 	return results, nil
 }
 
+// DeleteSeriesBefore removes one series' metrics older than the given
+// timestamp.
+func (r *MetricRepository) DeleteSeriesBefore(ctx context.Context, seriesHash uint64, before time.Time) (int64, error) {
+	result, err := r.db.conn.ExecContext(ctx,
+		"DELETE FROM metrics WHERE series_hash = ? AND timestamp < ?",
+		hashToInt64(seriesHash), before.UnixMilli(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete series metrics: %w", err)
+	}
+
+	// The series may be gone entirely
+	r.seriesMu.Lock()
+	r.knownSeries = make(map[string]map[uint64]struct{})
+	r.seriesMu.Unlock()
+
+	return result.RowsAffected()
+}
+
 // DeleteAggregatedBefore removes aggregated metrics older than the given timestamp.
 func (r *MetricRepository) DeleteAggregatedBefore(ctx context.Context, before time.Time, resolution string) (int64, error) {
 	result, err := r.db.conn.ExecContext(ctx,
@@ -483,6 +1165,20 @@ func (r *MetricRepository) DeleteAggregatedBefore(ctx context.Context, before ti
 
 // GetDistinctSeries returns all distinct series.
 func (r *MetricRepository) GetDistinctSeries(ctx context.Context) ([]ports.SeriesInfo, error) {
+	return r.querySeries(ctx, "ORDER BY name, series_hash")
+}
+
+// GetRecentSeries returns at most limit series ordered by their latest
+// point, newest first. Ties are broken by name so results are stable.
+func (r *MetricRepository) GetRecentSeries(ctx context.Context, limit int) ([]ports.SeriesInfo, error) {
+	if limit <= 0 {
+		return nil, fmt.Errorf("limit must be positive, got %d", limit)
+	}
+	return r.querySeries(ctx, "ORDER BY last_time DESC, name, series_hash LIMIT ?", limit)
+}
+
+// querySeries lists series grouped by hash with the given ordering clause.
+func (r *MetricRepository) querySeries(ctx context.Context, orderBy string, args ...interface{}) ([]ports.SeriesInfo, error) {
 	sqlQuery := `
 		SELECT
 			name,
@@ -493,10 +1189,9 @@ func (r *MetricRepository) GetDistinctSeries(ctx context.Context) ([]ports.Serie
 			MAX(timestamp) as last_time
 		FROM metrics
 		GROUP BY series_hash
-		ORDER BY name, series_hash
-	`
+	` + orderBy
 
-	rows, err := r.db.conn.QueryContext(ctx, sqlQuery)
+	rows, err := r.db.conn.QueryContext(ctx, sqlQuery, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query distinct series: %w", err)
 	}
@@ -541,14 +1236,21 @@ func (r *MetricRepository) GetStats(ctx context.Context) (*ports.MetricStats, er
 		AggregatedPoints: make(map[string]int64),
 	}
 
-	// Get raw metrics stats
+	// Get raw metrics stats; timestamps are stored as unix milliseconds
+	var oldest, newest sql.NullInt64
 	err := r.db.conn.QueryRowContext(ctx, `
 		SELECT COUNT(*), COUNT(DISTINCT series_hash), MIN(timestamp), MAX(timestamp)
 		FROM metrics
-	`).Scan(&stats.TotalPoints, &stats.TotalSeries, &stats.OldestPoint, &stats.NewestPoint)
+	`).Scan(&stats.TotalPoints, &stats.TotalSeries, &oldest, &newest)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get metrics stats: %w", err)
 	}
+	if oldest.Valid {
+		stats.OldestPoint = time.UnixMilli(oldest.Int64)
+	}
+	if newest.Valid {
+		stats.NewestPoint = time.UnixMilli(newest.Int64)
+	}
 
 	// Get aggregated metrics stats by resolution
 	rows, err := r.db.conn.QueryContext(ctx, `
@@ -573,6 +1275,8 @@ func (r *MetricRepository) GetStats(ctx context.Context) (*ports.MetricStats, er
 	_ = r.db.conn.QueryRowContext(ctx, "PRAGMA page_count").Scan(&pageCount)
 	_ = r.db.conn.QueryRowContext(ctx, "PRAGMA page_size").Scan(&pageSize)
 	stats.StorageBytes = pageCount * pageSize
+	stats.DroppedSeries = r.droppedSeries.Load()
+	stats.DroppedNonFinite = r.droppedNonFinite.Load()
 
 	return stats, nil
 }
@@ -0,0 +1,806 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+)
+
+func hostMetric(name, host string) *domain.Metric {
+	return domain.NewMetric(name, domain.MetricTypeGauge, 1, map[string]string{"host": host})
+}
+
+func TestMetricRepository_UnlimitedSeriesByDefault(t *testing.T) {
+	repo := NewMetricRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	for _, host := range []string{"a", "b", "c", "d", "e"} {
+		if err := repo.Record(ctx, hostMetric("cpu", host)); err != nil {
+			t.Fatalf("Record failed for host %s: %v", host, err)
+		}
+	}
+	stats, err := repo.GetStats(ctx)
+	if err != nil {
+		t.Fatalf("GetStats failed: %v", err)
+	}
+	if stats.TotalSeries != 5 || stats.DroppedSeries != 0 {
+		t.Errorf("expected 5 series and none dropped, got %d and %d", stats.TotalSeries, stats.DroppedSeries)
+	}
+}
+
+func TestMetricRepository_SeriesLimit(t *testing.T) {
+	repo := NewMetricRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	// Series written before the limit is set count towards it
+	if err := repo.Record(ctx, hostMetric("cpu", "a")); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	repo.SetMaxSeriesPerName(2)
+
+	if err := repo.Record(ctx, hostMetric("cpu", "b")); err != nil {
+		t.Fatalf("expected second series to fit the limit, got %v", err)
+	}
+
+	err := repo.Record(ctx, hostMetric("cpu", "c"))
+	if !errors.Is(err, ports.ErrSeriesLimitExceeded) {
+		t.Fatalf("expected series limit error, got %v", err)
+	}
+	var limitErr *ports.SeriesLimitError
+	if !errors.As(err, &limitErr) || limitErr.Name != "cpu" || limitErr.Limit != 2 || limitErr.Dropped != 1 {
+		t.Errorf("unexpected limit error: %+v", limitErr)
+	}
+
+	// Existing series and other names are unaffected
+	if err := repo.Record(ctx, hostMetric("cpu", "a")); err != nil {
+		t.Errorf("expected existing series to be accepted, got %v", err)
+	}
+	if err := repo.Record(ctx, hostMetric("mem", "c")); err != nil {
+		t.Errorf("expected other metric name to be accepted, got %v", err)
+	}
+
+	stats, err := repo.GetStats(ctx)
+	if err != nil {
+		t.Fatalf("GetStats failed: %v", err)
+	}
+	if stats.TotalSeries != 3 || stats.DroppedSeries != 1 {
+		t.Errorf("expected 3 series and 1 dropped point, got %d and %d", stats.TotalSeries, stats.DroppedSeries)
+	}
+}
+
+func TestMetricRepository_SeriesLimitBatch(t *testing.T) {
+	repo := NewMetricRepository(setupTestDB(t))
+	repo.SetMaxSeriesPerName(2)
+	ctx := context.Background()
+
+	batch := []*domain.Metric{
+		hostMetric("cpu", "a"),
+		hostMetric("cpu", "b"),
+		hostMetric("cpu", "c"),
+		hostMetric("cpu", "a"),
+		hostMetric("cpu", "d"),
+	}
+	err := repo.RecordBatch(ctx, batch)
+	var limitErr *ports.SeriesLimitError
+	if !errors.As(err, &limitErr) || limitErr.Dropped != 2 {
+		t.Fatalf("expected 2 points dropped by the limit, got %v", err)
+	}
+
+	// Accepted points are still committed
+	stats, err := repo.GetStats(ctx)
+	if err != nil {
+		t.Fatalf("GetStats failed: %v", err)
+	}
+	if stats.TotalPoints != 3 || stats.TotalSeries != 2 || stats.DroppedSeries != 2 {
+		t.Errorf("expected 3 points in 2 series with 2 dropped, got %+v", stats)
+	}
+
+	if err := repo.RecordBatch(ctx, []*domain.Metric{hostMetric("cpu", "b")}); err != nil {
+		t.Errorf("expected batch within the limit to succeed, got %v", err)
+	}
+}
+
+// batchWithInvalidEntry returns three metrics where the second reuses the
+// first one's ID and so violates the primary key.
+func batchWithInvalidEntry() []*domain.Metric {
+	batch := []*domain.Metric{hostMetric("cpu", "a"), hostMetric("cpu", "b"), hostMetric("cpu", "c")}
+	batch[1].ID = batch[0].ID
+	return batch
+}
+
+func TestMetricRepository_RecordBatchStrict(t *testing.T) {
+	repo := NewMetricRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	if err := repo.RecordBatch(ctx, batchWithInvalidEntry()); err == nil {
+		t.Fatal("expected the duplicate ID to fail the batch")
+	}
+	stats, err := repo.GetStats(ctx)
+	if err != nil {
+		t.Fatalf("GetStats failed: %v", err)
+	}
+	if stats.TotalPoints != 0 {
+		t.Errorf("expected the whole batch to be rolled back, got %d points", stats.TotalPoints)
+	}
+}
+
+func TestMetricRepository_RecordBatchBestEffort(t *testing.T) {
+	repo := NewMetricRepository(setupTestDB(t))
+	repo.SetMaxSeriesPerName(2)
+	ctx := context.Background()
+
+	batch := append(batchWithInvalidEntry(), hostMetric("cpu", "d"), nil)
+	report, err := repo.RecordBatchBestEffort(ctx, batch)
+	if err != nil {
+		t.Fatalf("RecordBatchBestEffort failed: %v", err)
+	}
+	if report.Written != 2 || len(report.Failed) != 3 {
+		t.Fatalf("expected 2 written and 3 failed, got %+v", report)
+	}
+	// The rolled back entry does not use up a series slot, so only entry 3
+	// is over the limit
+	wantIndices := []int{1, 3, 4}
+	for i, f := range report.Failed {
+		if f.Index != wantIndices[i] || f.Reason == "" {
+			t.Errorf("failure %d: expected index %d with a reason, got %+v", i, wantIndices[i], f)
+		}
+	}
+	if !strings.Contains(report.Failed[1].Reason, "series limit") {
+		t.Errorf("expected a series limit reason, got %q", report.Failed[1].Reason)
+	}
+
+	stats, err := repo.GetStats(ctx)
+	if err != nil {
+		t.Fatalf("GetStats failed: %v", err)
+	}
+	if stats.TotalPoints != 2 || stats.DroppedSeries != 1 {
+		t.Errorf("expected 2 points stored and 1 dropped, got %+v", stats)
+	}
+}
+
+func TestMetricRepository_RejectsNonFinite(t *testing.T) {
+	repo := NewMetricRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	value := func(v float64) *domain.Metric {
+		return domain.NewMetric("cpu", domain.MetricTypeGauge, v, map[string]string{"host": "a"})
+	}
+
+	for _, v := range []float64{math.NaN(), math.Inf(1)} {
+		err := repo.Record(ctx, value(v))
+		var nonFiniteErr *ports.NonFiniteValueError
+		if !errors.As(err, &nonFiniteErr) || nonFiniteErr.Name != "cpu" || nonFiniteErr.Dropped != 1 {
+			t.Errorf("Record(%v): expected a non-finite error, got %v", v, err)
+		}
+	}
+
+	// Finite points in a batch are still written
+	err := repo.RecordBatch(ctx, []*domain.Metric{value(1), value(math.Inf(-1)), value(3)})
+	var nonFiniteErr *ports.NonFiniteValueError
+	if !errors.As(err, &nonFiniteErr) || nonFiniteErr.Dropped != 1 {
+		t.Fatalf("expected 1 point dropped as non-finite, got %v", err)
+	}
+
+	report, err := repo.RecordBatchBestEffort(ctx, []*domain.Metric{value(math.NaN()), value(5)})
+	if err != nil {
+		t.Fatalf("RecordBatchBestEffort failed: %v", err)
+	}
+	if report.Written != 1 || len(report.Failed) != 1 || report.Failed[0].Index != 0 ||
+		!strings.Contains(report.Failed[0].Reason, "NaN or infinite") {
+		t.Errorf("expected the NaN entry to be reported, got %+v", report)
+	}
+
+	stats, err := repo.GetStats(ctx)
+	if err != nil {
+		t.Fatalf("GetStats failed: %v", err)
+	}
+	if stats.TotalPoints != 3 || stats.DroppedNonFinite != 4 {
+		t.Errorf("expected 3 points stored and 4 dropped, got %+v", stats)
+	}
+}
+
+func TestMetricRepository_AggregatesSkipNonFinite(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewMetricRepository(db)
+	ctx := context.Background()
+
+	now := time.Now()
+	for _, v := range []float64{1, 3} {
+		m := hostMetric("cpu", "a")
+		m.Value = v
+		m.Timestamp = now
+		if err := repo.Record(ctx, m); err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+	}
+	// An infinity written before values were validated
+	legacy := hostMetric("cpu", "a")
+	idBytes, _ := legacy.ID.MarshalBinary()
+	_, err := db.conn.ExecContext(ctx, `
+		INSERT INTO metrics (id, name, type, value, timestamp, series_hash, tags)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, idBytes, legacy.Name, string(legacy.Type), math.Inf(1), now.UnixMilli(), hashToInt64(legacy.SeriesHash), `{"host":"a"}`)
+	if err != nil {
+		t.Fatalf("insert failed: %v", err)
+	}
+
+	query := ports.MetricQuery{
+		Name:        "cpu",
+		StartTime:   now.Add(-time.Minute),
+		EndTime:     now.Add(time.Minute),
+		Step:        time.Hour,
+		Aggregation: ports.AggregationSum,
+	}
+	results, err := repo.QueryWithAggregation(ctx, query)
+	if err != nil {
+		t.Fatalf("QueryWithAggregation failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Value != 4 || results[0].Count != 2 || results[0].Max != 3 {
+		t.Errorf("expected a finite sum of 4 over 2 points, got %+v", results)
+	}
+
+	agg, err := repo.Aggregate(ctx, query, "1h")
+	if err != nil {
+		t.Fatalf("Aggregate failed: %v", err)
+	}
+	if agg == nil || agg.Sum != 4 || agg.Avg != 2 {
+		t.Errorf("expected sum 4 and avg 2, got %+v", agg)
+	}
+
+	query.GroupBy = []string{"host"}
+	groups, err := repo.QueryGrouped(ctx, query)
+	if err != nil {
+		t.Fatalf("QueryGrouped failed: %v", err)
+	}
+	if len(groups) != 1 || len(groups[0].Results) != 1 || groups[0].Results[0].Sum != 4 {
+		t.Errorf("expected a finite grouped sum of 4, got %+v", groups)
+	}
+
+	series, err := repo.Query(ctx, ports.MetricQuery{Name: "cpu", StartTime: query.StartTime, EndTime: query.EndTime})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(series.Points) != 2 {
+		t.Errorf("expected the infinite point to be skipped, got %+v", series.Points)
+	}
+}
+
+func TestMetricRepository_SeriesLimitAfterDelete(t *testing.T) {
+	repo := NewMetricRepository(setupTestDB(t))
+	repo.SetMaxSeriesPerName(1)
+	ctx := context.Background()
+
+	old := hostMetric("cpu", "a")
+	old.Timestamp = time.Now().Add(-48 * time.Hour)
+	if err := repo.Record(ctx, old); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	if err := repo.Record(ctx, hostMetric("cpu", "b")); !errors.Is(err, ports.ErrSeriesLimitExceeded) {
+		t.Fatalf("expected series limit error, got %v", err)
+	}
+
+	// Once the old series is gone there is room for a new one
+	if _, err := repo.DeleteBefore(ctx, time.Now().Add(-24*time.Hour)); err != nil {
+		t.Fatalf("DeleteBefore failed: %v", err)
+	}
+	if err := repo.Record(ctx, hostMetric("cpu", "b")); err != nil {
+		t.Errorf("expected new series after delete, got %v", err)
+	}
+}
+
+func TestMetricRepository_GetRecentSeries(t *testing.T) {
+	repo := NewMetricRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	now := time.Now()
+	points := []struct {
+		host string
+		age  time.Duration
+	}{
+		{"a", 3 * time.Hour},
+		{"b", time.Minute},
+		{"c", time.Hour},
+		{"a", 0},
+	}
+	for _, p := range points {
+		m := hostMetric("cpu", p.host)
+		m.Timestamp = now.Add(-p.age)
+		if err := repo.Record(ctx, m); err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+	}
+
+	// Series are ordered by their latest point, not their first
+	series, err := repo.GetRecentSeries(ctx, 2)
+	if err != nil {
+		t.Fatalf("GetRecentSeries failed: %v", err)
+	}
+	if len(series) != 2 || series[0].Tags["host"] != "a" || series[1].Tags["host"] != "b" {
+		t.Fatalf("expected hosts [a b], got %+v", series)
+	}
+	if series[0].PointCount != 2 {
+		t.Errorf("expected 2 points for host a, got %d", series[0].PointCount)
+	}
+
+	series, err = repo.GetRecentSeries(ctx, 10)
+	if err != nil || len(series) != 3 || series[2].Tags["host"] != "c" {
+		t.Errorf("expected all 3 series ending with c, got %+v, %v", series, err)
+	}
+
+	if _, err := repo.GetRecentSeries(ctx, 0); err == nil {
+		t.Error("expected error for non-positive limit")
+	}
+}
+
+func TestMetricRepository_RecordAggregatedReplacesWindow(t *testing.T) {
+	repo := NewMetricRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	start := time.Now().Truncate(time.Hour).Add(-2 * time.Hour)
+	agg := func(count int64, sum float64) *domain.AggregatedMetric {
+		return &domain.AggregatedMetric{
+			ID: domain.NewUUIDv7(), Name: "cpu", SeriesHash: 42,
+			WindowStart: start, WindowEnd: start.Add(time.Hour),
+			Count: count, Sum: sum, Min: 1, Max: 1, Avg: sum / float64(count),
+			Resolution: "1h",
+		}
+	}
+	if err := repo.RecordAggregatedBatch(ctx, []*domain.AggregatedMetric{agg(2, 2)}); err != nil {
+		t.Fatalf("RecordAggregatedBatch failed: %v", err)
+	}
+	if err := repo.RecordAggregatedBatch(ctx, []*domain.AggregatedMetric{agg(3, 3)}); err != nil {
+		t.Fatalf("RecordAggregatedBatch failed on rerun: %v", err)
+	}
+	if err := repo.RecordAggregated(ctx, agg(4, 4)); err != nil {
+		t.Fatalf("RecordAggregated failed: %v", err)
+	}
+
+	seriesHash := uint64(42)
+	aggs, err := repo.QueryAggregated(ctx, ports.MetricQuery{
+		Name: "cpu", SeriesHash: &seriesHash, StartTime: start, EndTime: start.Add(time.Hour),
+	}, "1h")
+	if err != nil {
+		t.Fatalf("QueryAggregated failed: %v", err)
+	}
+	if len(aggs) != 1 || aggs[0].Count != 4 {
+		t.Fatalf("expected the window's aggregate to be replaced, got %+v", aggs)
+	}
+}
+
+func TestMetricRepository_DeleteSeriesBefore(t *testing.T) {
+	repo := NewMetricRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	now := time.Now()
+	var hashA uint64
+	for _, host := range []string{"a", "b"} {
+		for _, age := range []time.Duration{2 * time.Hour, time.Minute} {
+			m := hostMetric("cpu", host)
+			m.Timestamp = now.Add(-age)
+			if err := repo.Record(ctx, m); err != nil {
+				t.Fatalf("Record failed: %v", err)
+			}
+			if host == "a" {
+				hashA = m.SeriesHash
+			}
+		}
+	}
+
+	deleted, err := repo.DeleteSeriesBefore(ctx, hashA, now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("DeleteSeriesBefore failed: %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("expected 1 point deleted, got %d", deleted)
+	}
+
+	series, err := repo.GetDistinctSeries(ctx)
+	if err != nil {
+		t.Fatalf("GetDistinctSeries failed: %v", err)
+	}
+	points := map[string]int64{}
+	for _, s := range series {
+		points[s.Tags["host"]] = s.PointCount
+	}
+	if points["a"] != 1 || points["b"] != 2 {
+		t.Errorf("expected only host a's old point to be deleted, got %v", points)
+	}
+}
+
+func TestMetricRepository_QueryGrouped(t *testing.T) {
+	repo := NewMetricRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	start := time.Now().Add(-time.Hour).Truncate(time.Minute)
+	record := func(tags map[string]string, offset time.Duration, value float64) {
+		m := domain.NewMetric("cpu", domain.MetricTypeGauge, value, tags)
+		m.Timestamp = start.Add(offset)
+		if err := repo.Record(ctx, m); err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+	}
+	record(map[string]string{"host": "a"}, 0, 10)
+	record(map[string]string{"host": "a"}, 30*time.Second, 20)
+	record(map[string]string{"host": "a"}, 90*time.Second, 30)
+	record(map[string]string{"host": "b"}, 0, 5)
+	record(map[string]string{"region": "eu"}, 0, 1)
+
+	query := ports.MetricQuery{
+		Name:        "cpu",
+		StartTime:   start,
+		EndTime:     start.Add(time.Hour),
+		Aggregation: ports.AggregationAvg,
+		GroupBy:     []string{"host"},
+	}
+
+	groups, err := repo.QueryGrouped(ctx, query)
+	if err != nil {
+		t.Fatalf("QueryGrouped failed: %v", err)
+	}
+	if len(groups) != 3 {
+		t.Fatalf("expected 3 groups, got %d: %+v", len(groups), groups)
+	}
+	byHost := map[string]ports.GroupedResult{}
+	for _, g := range groups {
+		byHost[g.Tags["host"]] = g
+	}
+	if g := byHost["a"]; len(g.Results) != 1 || g.Results[0].Value != 20 || g.Results[0].Count != 3 {
+		t.Errorf("expected host a to average 20 over 3 points, got %+v", g.Results)
+	}
+	if g := byHost["a"]; len(g.Results) == 1 && !g.Results[0].Timestamp.Equal(start) {
+		t.Errorf("expected the group to be stamped with its first point, got %v", g.Results[0].Timestamp)
+	}
+	if g := byHost["b"]; len(g.Results) != 1 || g.Results[0].Value != 5 {
+		t.Errorf("expected host b to average 5, got %+v", g.Results)
+	}
+	if g, ok := byHost[ports.GroupNone]; !ok || len(g.Results) != 1 || g.Results[0].Value != 1 {
+		t.Errorf("expected the series without a host in the %s group, got %+v", ports.GroupNone, groups)
+	}
+
+	query.Step = time.Minute
+	groups, err = repo.QueryGrouped(ctx, query)
+	if err != nil {
+		t.Fatalf("QueryGrouped with step failed: %v", err)
+	}
+	for _, g := range groups {
+		if g.Tags["host"] == "a" && (len(g.Results) != 2 || g.Results[0].Value != 15 || g.Results[1].Value != 30) {
+			t.Errorf("expected host a in two 1m buckets of 15 and 30, got %+v", g.Results)
+		}
+	}
+}
+
+func TestMetricRepository_QueryGroupedAllTags(t *testing.T) {
+	repo := NewMetricRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	for _, host := range []string{"a", "b"} {
+		if err := repo.Record(ctx, hostMetric("cpu", host)); err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+	}
+
+	groups, err := repo.QueryGrouped(ctx, ports.MetricQuery{
+		Name:      "cpu",
+		StartTime: time.Now().Add(-time.Hour),
+		EndTime:   time.Now().Add(time.Hour),
+		GroupBy:   []string{ports.GroupAllTags},
+	})
+	if err != nil {
+		t.Fatalf("QueryGrouped failed: %v", err)
+	}
+	if len(groups) != 2 {
+		t.Fatalf("expected one group per series, got %d", len(groups))
+	}
+	hosts := map[string]bool{}
+	for _, g := range groups {
+		hosts[g.Tags["host"]] = true
+	}
+	if !hosts["a"] || !hosts["b"] {
+		t.Errorf("expected groups to carry each series' tags, got %+v", groups)
+	}
+}
+
+func TestMetricRepository_QueryGroupedInvalidKey(t *testing.T) {
+	repo := NewMetricRepository(setupTestDB(t))
+
+	for _, groupBy := range [][]string{nil, {""}, {`ho"st`}, {"host", ports.GroupAllTags}} {
+		_, err := repo.QueryGrouped(context.Background(), ports.MetricQuery{
+			Name:    "cpu",
+			EndTime: time.Now(),
+			GroupBy: groupBy,
+		})
+		if err == nil {
+			t.Errorf("expected group by %q to be rejected", groupBy)
+		}
+	}
+}
+
+func TestMetricRepository_QueryMultipleSeparatesSeries(t *testing.T) {
+	repo := NewMetricRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	start := time.Now().Add(-time.Hour)
+	for i, host := range []string{"a", "b", "a", "b", "a"} {
+		m := domain.NewMetric("cpu", domain.MetricTypeGauge, float64(i), map[string]string{"host": host})
+		m.Timestamp = start.Add(time.Duration(i) * time.Minute)
+		if err := repo.Record(ctx, m); err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+	}
+	mem := domain.NewMetric("mem", domain.MetricTypeGauge, 9, map[string]string{"host": "a"})
+	mem.Timestamp = start
+	if err := repo.Record(ctx, mem); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+
+	query := ports.MetricQuery{Name: "cpu", StartTime: start, EndTime: start.Add(time.Hour)}
+	series, err := repo.QueryMultiple(ctx, query)
+	if err != nil {
+		t.Fatalf("QueryMultiple failed: %v", err)
+	}
+	if len(series) != 2 {
+		t.Fatalf("expected one series per host, got %d", len(series))
+	}
+	values := map[string][]float64{}
+	for _, s := range series {
+		for _, p := range s.Points {
+			values[s.Tags["host"]] = append(values[s.Tags["host"]], p.Value)
+		}
+	}
+	if got := values["a"]; len(got) != 3 || got[0] != 0 || got[1] != 2 || got[2] != 4 {
+		t.Errorf("expected host a points [0 2 4], got %v", got)
+	}
+	if got := values["b"]; len(got) != 2 || got[0] != 1 || got[1] != 3 {
+		t.Errorf("expected host b points [1 3], got %v", got)
+	}
+
+	// Limit applies to each series
+	query.Limit = 1
+	series, err = repo.QueryMultiple(ctx, query)
+	if err != nil {
+		t.Fatalf("QueryMultiple with limit failed: %v", err)
+	}
+	for _, s := range series {
+		if len(s.Points) != 1 {
+			t.Errorf("expected 1 point for host %s, got %d", s.Tags["host"], len(s.Points))
+		}
+	}
+
+	// No name matches every metric in the range
+	series, err = repo.QueryMultiple(ctx, ports.MetricQuery{StartTime: start, EndTime: start.Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("QueryMultiple without a name failed: %v", err)
+	}
+	if len(series) != 3 || series[2].Name != "mem" {
+		t.Errorf("expected cpu for two hosts and mem, got %d series", len(series))
+	}
+
+	query = ports.MetricQuery{StartTime: start, EndTime: start.Add(time.Hour), Tags: map[string]string{"host": "b"}}
+	series, err = repo.QueryMultiple(ctx, query)
+	if err != nil {
+		t.Fatalf("QueryMultiple with tags failed: %v", err)
+	}
+	if len(series) != 1 || series[0].Tags["host"] != "b" || len(series[0].Points) != 2 {
+		t.Errorf("expected only host b's series, got %+v", series)
+	}
+}
+
+func TestMetricRepository_Export(t *testing.T) {
+	repo := NewMetricRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	start := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+	for i, host := range []string{"b", "a", "b", "a"} {
+		m := domain.NewMetric("cpu", domain.MetricTypeGauge, float64(i), map[string]string{"host": host})
+		m.Timestamp = start.Add(time.Duration(i) * time.Minute)
+		if err := repo.Record(ctx, m); err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+	}
+
+	type exported struct {
+		series *domain.MetricSeries
+		point  domain.MetricPoint
+	}
+	var got []exported
+	query := ports.MetricQuery{Name: "cpu", StartTime: start, EndTime: start.Add(time.Hour)}
+	err := repo.Export(ctx, query, func(series *domain.MetricSeries, point domain.MetricPoint) error {
+		got = append(got, exported{series, point})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if len(got) != 4 {
+		t.Fatalf("expected 4 points, got %d", len(got))
+	}
+	// Each series' points are contiguous, in time order, and share a series
+	for i := 1; i < len(got); i++ {
+		prev, cur := got[i-1], got[i]
+		if prev.series.Tags["host"] == cur.series.Tags["host"] {
+			if prev.series != cur.series || !prev.point.Timestamp.Before(cur.point.Timestamp) {
+				t.Errorf("expected points %d and %d to be one series in time order", i-1, i)
+			}
+		}
+	}
+	if got[0].series == got[3].series || got[0].series.Tags["host"] != got[1].series.Tags["host"] {
+		t.Errorf("expected two contiguous series, got hosts %s %s %s %s",
+			got[0].series.Tags["host"], got[1].series.Tags["host"], got[2].series.Tags["host"], got[3].series.Tags["host"])
+	}
+
+	// An error from the callback stops the export
+	stop := errors.New("stop")
+	calls := 0
+	err = repo.Export(ctx, query, func(*domain.MetricSeries, domain.MetricPoint) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Errorf("expected the callback error after one point, got %v after %d", err, calls)
+	}
+}
+
+func TestMetricRepository_QueryFiltersTags(t *testing.T) {
+	repo := NewMetricRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	start := time.Now().Add(-time.Hour).Truncate(time.Minute)
+	series := []map[string]string{
+		{"host": "web-1", "region": "eu"},
+		{"host": "web-2", "region": "eu"},
+		{"host": "web-1", "region": "us"},
+		{"host": `db "primary"`, `path\key`: `C:\data`, "env.name": "prod"},
+	}
+	for i, tags := range series {
+		m := domain.NewMetric("cpu.usage", domain.MetricTypeGauge, float64(i+1), tags)
+		m.Timestamp = start.Add(time.Duration(i) * time.Second)
+		if err := repo.Record(ctx, m); err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		tags map[string]string
+		want []float64
+	}{
+		{"no tags", nil, []float64{1, 2, 3, 4}},
+		{"partial match", map[string]string{"host": "web-1"}, []float64{1, 3}},
+		{"multiple tags", map[string]string{"host": "web-1", "region": "eu"}, []float64{1}},
+		{"quoted value", map[string]string{"host": `db "primary"`}, []float64{4}},
+		{"key with dot", map[string]string{"env.name": "prod"}, []float64{4}},
+		{"key with backslash", map[string]string{`path\key`: `C:\data`}, []float64{4}},
+		{"unknown tag", map[string]string{"zone": "a"}, nil},
+		{"value mismatch", map[string]string{"host": "web-1", "region": "ap"}, nil},
+	}
+	for _, tt := range tests {
+		query := ports.MetricQuery{Name: "cpu.usage", StartTime: start, EndTime: start.Add(time.Minute), Tags: tt.tags}
+		got, err := repo.Query(ctx, query)
+		if err != nil {
+			t.Fatalf("%s: Query failed: %v", tt.name, err)
+		}
+		var values []float64
+		for _, p := range got.Points {
+			values = append(values, p.Value)
+		}
+		if len(values) != len(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, values, tt.want)
+			continue
+		}
+		for i := range values {
+			if values[i] != tt.want[i] {
+				t.Errorf("%s: got %v, want %v", tt.name, values, tt.want)
+				break
+			}
+		}
+
+		query.Step = time.Minute
+		query.Aggregation = ports.AggregationCount
+		buckets, err := repo.QueryWithAggregation(ctx, query)
+		if err != nil {
+			t.Fatalf("%s: QueryWithAggregation failed: %v", tt.name, err)
+		}
+		var count int64
+		for _, b := range buckets {
+			count += b.Count
+		}
+		if count != int64(len(tt.want)) {
+			t.Errorf("%s: aggregated %d points, want %d", tt.name, count, len(tt.want))
+		}
+	}
+}
+
+func TestMetricRepository_QueryIncludeIDs(t *testing.T) {
+	repo := NewMetricRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	start := time.Now().Add(-time.Hour).Truncate(time.Minute)
+	gauge := domain.NewMetric("queue.depth", domain.MetricTypeGauge, 3, map[string]string{"queue": "jobs"})
+	gauge.Timestamp = start
+	counter := domain.NewMetric("queue.depth", domain.MetricTypeCounter, 5, map[string]string{"queue": "jobs"})
+	counter.Timestamp = start.Add(time.Second)
+	if err := repo.RecordBatch(ctx, []*domain.Metric{gauge, counter}); err != nil {
+		t.Fatalf("RecordBatch failed: %v", err)
+	}
+
+	query := ports.MetricQuery{Name: "queue.depth", StartTime: start, EndTime: start.Add(time.Minute)}
+	series, err := repo.Query(ctx, query)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	for _, p := range series.Points {
+		if p.ID != nil || p.Type != "" {
+			t.Errorf("expected lean points by default, got id=%v type=%q", p.ID, p.Type)
+		}
+	}
+
+	query.IncludeIDs = true
+	series, err = repo.Query(ctx, query)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	want := []*domain.Metric{gauge, counter}
+	if len(series.Points) != len(want) {
+		t.Fatalf("expected %d points, got %d", len(want), len(series.Points))
+	}
+	for i, p := range series.Points {
+		if p.ID == nil || *p.ID != want[i].ID {
+			t.Errorf("point %d: expected id %s, got %v", i, want[i].ID, p.ID)
+		}
+		if p.Type != want[i].Type {
+			t.Errorf("point %d: expected type %s, got %s", i, want[i].Type, p.Type)
+		}
+	}
+
+	multi, err := repo.QueryMultiple(ctx, query)
+	if err != nil {
+		t.Fatalf("QueryMultiple failed: %v", err)
+	}
+	if len(multi) != 1 || len(multi[0].Points) != 2 {
+		t.Fatalf("expected one series of 2 points, got %v", multi)
+	}
+	if p := multi[0].Points[1]; p.ID == nil || *p.ID != counter.ID || p.Type != domain.MetricTypeCounter {
+		t.Errorf("expected counter point identity, got id=%v type=%s", p.ID, p.Type)
+	}
+}
+
+func TestMetricRepository_QueryRequiresNameOrSeries(t *testing.T) {
+	repo := NewMetricRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	a, b := hostMetric("cpu", "a"), hostMetric("cpu", "b")
+	for _, m := range []*domain.Metric{a, b, hostMetric("mem", "a")} {
+		if err := repo.Record(ctx, m); err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+	}
+	start, end := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+
+	_, err := repo.Query(ctx, ports.MetricQuery{StartTime: start, EndTime: end})
+	if !errors.Is(err, ports.ErrMetricNameRequired) {
+		t.Fatalf("expected empty name to be rejected, got %v", err)
+	}
+
+	// A series hash alone selects the series and reports its name
+	series, err := repo.Query(ctx, ports.MetricQuery{SeriesHash: &b.SeriesHash, StartTime: start, EndTime: end})
+	if err != nil {
+		t.Fatalf("Query by series hash failed: %v", err)
+	}
+	if series.Name != "cpu" || series.SeriesHash != b.SeriesHash || len(series.Points) != 1 || series.Tags["host"] != "b" {
+		t.Errorf("unexpected series: %+v", series)
+	}
+
+	series, err = repo.Query(ctx, ports.MetricQuery{Name: "cpu", StartTime: start, EndTime: end})
+	if err != nil {
+		t.Fatalf("Query by name failed: %v", err)
+	}
+	if len(series.Points) != 2 {
+		t.Errorf("expected both cpu series' points, got %d", len(series.Points))
+	}
+}
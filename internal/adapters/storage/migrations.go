@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+)
+
+// migration is a one-off data fix. Each is applied once per database, in
+// order, and recorded in schema_migrations with the transaction it ran in.
+type migration struct {
+	name  string
+	json1 bool // Needs json1; without it the migration waits, unrecorded
+	apply func(tx *sql.Tx) error
+}
+
+var migrations = []migration{
+	// Series hashes used to depend on map iteration order
+	{name: "rehash_metric_series", apply: rehashMetricSeries},
+	// Databases created before plugins had allowed hosts
+	{name: "plugins_allowed_hosts", apply: addPluginAllowedHosts},
+	// Spans stored before span_attributes existed
+	{name: "index_span_attributes", json1: true, apply: indexSpanAttributes},
+}
+
+// runMigrations applies the migrations not yet recorded.
+func (db *DB) runMigrations() error {
+	for _, m := range migrations {
+		if m.json1 && !db.features.JSON1 {
+			continue
+		}
+		if err := db.runMigration(m); err != nil {
+			return fmt.Errorf("migration %s failed: %w", m.name, err)
+		}
+	}
+	return nil
+}
+
+func (db *DB) runMigration(m migration) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var applied bool
+	if err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE name = ?)", m.name).Scan(&applied); err != nil {
+		return err
+	}
+	if applied {
+		return nil
+	}
+	if err := m.apply(tx); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("INSERT INTO schema_migrations (name, applied_at) VALUES (?, ?)", m.name, time.Now().UnixMilli()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// rehashMetricSeries recomputes the series hash of stored raw and rolled-up
+// points from their name and tags. A rollup whose window already exists
+// under the new hash is left under its old one rather than overwritten.
+func rehashMetricSeries(tx *sql.Tx) error {
+	for _, table := range []string{"metrics", "metrics_aggregated"} {
+		rows, err := tx.Query("SELECT DISTINCT name, tags FROM " + table)
+		if err != nil {
+			return err
+		}
+		type series struct {
+			name string
+			tags interface{} // Compared back as stored, text or blob
+		}
+		var all []series
+		for rows.Next() {
+			var s series
+			if err := rows.Scan(&s.name, &s.tags); err != nil {
+				rows.Close()
+				return err
+			}
+			all = append(all, s)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		update := "UPDATE metrics SET series_hash = ? WHERE name = ? AND tags IS ?"
+		if table == "metrics_aggregated" {
+			update = "UPDATE OR IGNORE metrics_aggregated SET series_hash = ? WHERE name = ? AND tags IS ?"
+		}
+		for _, s := range all {
+			var tags map[string]string
+			switch raw := s.tags.(type) {
+			case []byte:
+				if err := json.Unmarshal(raw, &tags); err != nil {
+					continue // Leave unparseable rows as they are
+				}
+			case string:
+				if err := json.Unmarshal([]byte(raw), &tags); err != nil {
+					continue
+				}
+			}
+			hash := hashToInt64(domain.SeriesHash(s.name, tags))
+			if _, err := tx.Exec(update, hash, s.name, s.tags); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// addPluginAllowedHosts adds the allowed_hosts column to a plugins table
+// created without it.
+func addPluginAllowedHosts(tx *sql.Tx) error {
+	var exists bool
+	if err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM pragma_table_info('plugins') WHERE name = 'allowed_hosts')").Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+	_, err := tx.Exec("ALTER TABLE plugins ADD COLUMN allowed_hosts JSON")
+	return err
+}
+
+// indexSpanAttributes fills span_attributes from spans stored before the
+// table existed. Spans already indexed are skipped. Without json1 older
+// spans are not found by attribute search.
+func indexSpanAttributes(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+		INSERT INTO span_attributes (trace_id, span_id, key, value)
+		SELECT s.trace_id, s.span_id, a.key, a.value
+		FROM spans s, json_each(s.attributes) a
+		WHERE json_valid(s.attributes) AND json_type(s.attributes) = 'object'
+			AND NOT EXISTS (
+				SELECT 1 FROM span_attributes sa
+				WHERE sa.trace_id = s.trace_id AND sa.span_id = s.span_id
+			)`)
+	return err
+}
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+)
+
+func TestMigrations_RehashMetricSeries(t *testing.T) {
+	dir := t.TempDir()
+	db, err := New(DefaultConfig(dir))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	ctx := context.Background()
+
+	// Store a point under a stale hash, as older versions could, and
+	// forget that the migration ran
+	tags := map[string]string{"host": "web-1", "region": "eu", "service": "api"}
+	m := domain.NewMetric("cpu.usage", domain.MetricTypeGauge, 1, tags)
+	m.SeriesHash = 12345
+	if err := NewMetricRepository(db).RecordBatch(ctx, []*domain.Metric{m}); err != nil {
+		t.Fatalf("RecordBatch failed: %v", err)
+	}
+	if _, err := db.conn.Exec("DELETE FROM schema_migrations"); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	db, err = New(DefaultConfig(dir))
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer db.Close()
+
+	var hash int64
+	if err := db.conn.QueryRow("SELECT series_hash FROM metrics WHERE name = 'cpu.usage'").Scan(&hash); err != nil {
+		t.Fatal(err)
+	}
+	if want := domain.SeriesHash("cpu.usage", tags); int64ToHash(hash) != want {
+		t.Errorf("expected the point rehashed to %d, got %d", want, int64ToHash(hash))
+	}
+
+	var applied int
+	if err := db.conn.QueryRow("SELECT COUNT(*) FROM schema_migrations").Scan(&applied); err != nil {
+		t.Fatal(err)
+	}
+	want := 0
+	for _, m := range migrations {
+		if !m.json1 || db.Features().JSON1 {
+			want++
+		}
+	}
+	if applied != want {
+		t.Errorf("expected %d migrations recorded, got %d", want, applied)
+	}
+
+	// Recorded migrations don't run again
+	if _, err := db.conn.Exec("UPDATE metrics SET series_hash = 1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.runMigrations(); err != nil {
+		t.Fatalf("runMigrations failed: %v", err)
+	}
+	if err := db.conn.QueryRow("SELECT series_hash FROM metrics").Scan(&hash); err != nil || hash != 1 {
+		t.Errorf("expected an applied migration to be skipped, got hash %d (%v)", hash, err)
+	}
+}
@@ -0,0 +1,172 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+	"github.com/google/uuid"
+)
+
+// NotificationChannelRepository implements ports.NotificationChannelRepository using SQLite.
+type NotificationChannelRepository struct {
+	db *DB
+}
+
+// NewNotificationChannelRepository creates a new notification channel repository.
+func NewNotificationChannelRepository(db *DB) *NotificationChannelRepository {
+	return &NotificationChannelRepository{db: db}
+}
+
+const notificationChannelColumns = `id, name, type, enabled, config, created_at, updated_at`
+
+// Create persists a new notification channel.
+func (r *NotificationChannelRepository) Create(ctx context.Context, channel *domain.NotificationChannel) error {
+	configJSON, err := json.Marshal(channel.Config)
+	if err != nil {
+		return fmt.Errorf("failed to marshal channel config: %w", err)
+	}
+	idBytes, _ := channel.ID.MarshalBinary()
+
+	query := `
+		INSERT INTO notification_channels (` + notificationChannelColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err = r.db.conn.ExecContext(ctx, query,
+		idBytes,
+		channel.Name,
+		string(channel.Type),
+		channel.Enabled,
+		configJSON,
+		channel.CreatedAt.UnixMilli(),
+		channel.UpdatedAt.UnixMilli(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert notification channel: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a channel by its ID.
+func (r *NotificationChannelRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.NotificationChannel, error) {
+	idBytes, _ := id.MarshalBinary()
+	row := r.db.conn.QueryRowContext(ctx, "SELECT "+notificationChannelColumns+" FROM notification_channels WHERE id = ?", idBytes)
+	return scanNotificationChannel(row)
+}
+
+// GetByName retrieves a channel by its name.
+func (r *NotificationChannelRepository) GetByName(ctx context.Context, name string) (*domain.NotificationChannel, error) {
+	row := r.db.conn.QueryRowContext(ctx, "SELECT "+notificationChannelColumns+" FROM notification_channels WHERE name = ?", name)
+	return scanNotificationChannel(row)
+}
+
+// Update updates an existing channel.
+func (r *NotificationChannelRepository) Update(ctx context.Context, channel *domain.NotificationChannel) error {
+	configJSON, err := json.Marshal(channel.Config)
+	if err != nil {
+		return fmt.Errorf("failed to marshal channel config: %w", err)
+	}
+	idBytes, _ := channel.ID.MarshalBinary()
+	channel.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE notification_channels SET
+			name = ?, type = ?, enabled = ?, config = ?, updated_at = ?
+		WHERE id = ?
+	`
+	result, err := r.db.conn.ExecContext(ctx, query,
+		channel.Name,
+		string(channel.Type),
+		channel.Enabled,
+		configJSON,
+		channel.UpdatedAt.UnixMilli(),
+		idBytes,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update notification channel: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("notification channel not found")
+	}
+	return nil
+}
+
+// Delete removes a channel.
+func (r *NotificationChannelRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	idBytes, _ := id.MarshalBinary()
+	result, err := r.db.conn.ExecContext(ctx, "DELETE FROM notification_channels WHERE id = ?", idBytes)
+	if err != nil {
+		return fmt.Errorf("failed to delete notification channel: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("notification channel not found")
+	}
+	return nil
+}
+
+// List retrieves all notification channels ordered by name.
+func (r *NotificationChannelRepository) List(ctx context.Context) ([]*domain.NotificationChannel, error) {
+	return r.list(ctx, "")
+}
+
+// ListEnabled retrieves all enabled channels ordered by name.
+func (r *NotificationChannelRepository) ListEnabled(ctx context.Context) ([]*domain.NotificationChannel, error) {
+	return r.list(ctx, " WHERE enabled = 1")
+}
+
+func (r *NotificationChannelRepository) list(ctx context.Context, where string) ([]*domain.NotificationChannel, error) {
+	query := "SELECT " + notificationChannelColumns + " FROM notification_channels" + where + " ORDER BY name"
+	rows, err := r.db.conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notification channels: %w", err)
+	}
+	defer rows.Close()
+
+	var channels []*domain.NotificationChannel
+	for rows.Next() {
+		channel, err := scanNotificationChannel(rows)
+		if err != nil {
+			return nil, err
+		}
+		channels = append(channels, channel)
+	}
+	return channels, rows.Err()
+}
+
+func scanNotificationChannel(row rowScanner) (*domain.NotificationChannel, error) {
+	var (
+		idBytes     []byte
+		channelType string
+		configJSON  sql.NullString
+		createdAt   int64
+		updatedAt   int64
+		channel     domain.NotificationChannel
+	)
+
+	err := row.Scan(&idBytes, &channel.Name, &channelType, &channel.Enabled, &configJSON, &createdAt, &updatedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("notification channel not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan notification channel: %w", err)
+	}
+
+	channel.ID, _ = uuid.FromBytes(idBytes)
+	channel.Type = domain.NotificationChannelType(channelType)
+	channel.CreatedAt = time.UnixMilli(createdAt)
+	channel.UpdatedAt = time.UnixMilli(updatedAt)
+
+	channel.Config = make(map[string]string)
+	if configJSON.Valid && configJSON.String != "" && configJSON.String != "null" {
+		_ = json.Unmarshal([]byte(configJSON.String), &channel.Config)
+	}
+
+	return &channel, nil
+}
+
+// Ensure NotificationChannelRepository implements the interface
+var _ ports.NotificationChannelRepository = (*NotificationChannelRepository)(nil)
@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+)
+
+func TestNotificationChannelRepository_CRUD(t *testing.T) {
+	repo := NewNotificationChannelRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	channel := domain.NewNotificationChannel("oncall", domain.ChannelSlack, map[string]string{
+		"webhook_url": "https://hooks.slack.com/services/T/B/X",
+		"channel":     "#oncall",
+	})
+	if err := repo.Create(ctx, channel); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := repo.Create(ctx, domain.NewNotificationChannel("oncall", domain.ChannelWebhook, nil)); err == nil {
+		t.Error("expected duplicate channel name to be rejected")
+	}
+
+	got, err := repo.GetByName(ctx, "oncall")
+	if err != nil {
+		t.Fatalf("GetByName failed: %v", err)
+	}
+	if got.ID != channel.ID || got.Type != domain.ChannelSlack || !got.Enabled || got.Config["channel"] != "#oncall" {
+		t.Errorf("unexpected channel: %+v", got)
+	}
+
+	got.Enabled = false
+	got.Config["channel"] = "#alerts"
+	if err := repo.Update(ctx, got); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if enabled, _ := repo.ListEnabled(ctx); len(enabled) != 0 {
+		t.Errorf("expected disabled channel to be excluded, got %d", len(enabled))
+	}
+	if got, _ = repo.GetByID(ctx, channel.ID); got.Config["channel"] != "#alerts" {
+		t.Errorf("expected updated config, got %v", got.Config)
+	}
+
+	if err := repo.Delete(ctx, channel.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := repo.GetByID(ctx, channel.ID); err == nil {
+		t.Error("expected deleted channel to be gone")
+	}
+	if err := repo.Delete(ctx, channel.ID); err == nil {
+		t.Error("expected error deleting a missing channel")
+	}
+}
@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+	"github.com/google/uuid"
+)
+
+// PasswordResetRepository implements ports.PasswordResetRepository using SQLite.
+type PasswordResetRepository struct {
+	db *DB
+}
+
+// NewPasswordResetRepository creates a new password reset token repository.
+func NewPasswordResetRepository(db *DB) *PasswordResetRepository {
+	return &PasswordResetRepository{db: db}
+}
+
+const passwordResetColumns = `id, user_id, token_hash, expires_at, created_at, used_at`
+
+// Create persists a new reset token.
+func (r *PasswordResetRepository) Create(ctx context.Context, token *domain.PasswordResetToken) error {
+	idBytes, _ := token.ID.MarshalBinary()
+	userIDBytes, _ := token.UserID.MarshalBinary()
+
+	query := `
+		INSERT INTO password_resets (` + passwordResetColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	_, err := r.db.conn.ExecContext(ctx, query,
+		idBytes,
+		userIDBytes,
+		token.TokenHash,
+		token.ExpiresAt.UnixMilli(),
+		token.CreatedAt.UnixMilli(),
+		nullableMillis(token.UsedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert password reset token: %w", err)
+	}
+	return nil
+}
+
+// GetByTokenHash retrieves a reset token by the hash of its token.
+func (r *PasswordResetRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*domain.PasswordResetToken, error) {
+	row := r.db.conn.QueryRowContext(ctx,
+		"SELECT "+passwordResetColumns+" FROM password_resets WHERE token_hash = ?", tokenHash)
+
+	var (
+		idBytes     []byte
+		userIDBytes []byte
+		expiresAt   int64
+		createdAt   int64
+		usedAt      sql.NullInt64
+		token       domain.PasswordResetToken
+	)
+	err := row.Scan(&idBytes, &userIDBytes, &token.TokenHash, &expiresAt, &createdAt, &usedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("password reset token not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan password reset token: %w", err)
+	}
+
+	token.ID, _ = uuid.FromBytes(idBytes)
+	token.UserID, _ = uuid.FromBytes(userIDBytes)
+	token.ExpiresAt = time.UnixMilli(expiresAt)
+	token.CreatedAt = time.UnixMilli(createdAt)
+	token.UsedAt = millisTime(usedAt)
+	return &token, nil
+}
+
+// MarkUsed consumes a reset token unless it was already used.
+func (r *PasswordResetRepository) MarkUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	idBytes, _ := id.MarshalBinary()
+	result, err := r.db.conn.ExecContext(ctx,
+		"UPDATE password_resets SET used_at = ? WHERE id = ? AND used_at IS NULL", usedAt.UnixMilli(), idBytes)
+	if err != nil {
+		return fmt.Errorf("failed to update password reset token: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ports.ErrResetTokenUsed
+	}
+	return nil
+}
+
+// DeleteByUserID removes all reset tokens for a user.
+func (r *PasswordResetRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	userIDBytes, _ := userID.MarshalBinary()
+	if _, err := r.db.conn.ExecContext(ctx, "DELETE FROM password_resets WHERE user_id = ?", userIDBytes); err != nil {
+		return fmt.Errorf("failed to delete password reset tokens: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired removes reset tokens whose expiry has passed.
+func (r *PasswordResetRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	result, err := r.db.conn.ExecContext(ctx,
+		"DELETE FROM password_resets WHERE expires_at < ?", time.Now().UnixMilli())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired password reset tokens: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// Ensure PasswordResetRepository implements the interface
+var _ ports.PasswordResetRepository = (*PasswordResetRepository)(nil)
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+	"github.com/google/uuid"
+)
+
+func TestPasswordResetRepository(t *testing.T) {
+	repo := NewPasswordResetRepository(setupTestDB(t))
+	ctx := context.Background()
+	userID := uuid.New()
+
+	reset, token, err := domain.GeneratePasswordResetToken(userID, time.Hour)
+	if err != nil {
+		t.Fatalf("GeneratePasswordResetToken failed: %v", err)
+	}
+	if err := repo.Create(ctx, reset); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	got, err := repo.GetByTokenHash(ctx, domain.HashToken(token))
+	if err != nil {
+		t.Fatalf("GetByTokenHash failed: %v", err)
+	}
+	if got.ID != reset.ID || got.UserID != userID || got.IsUsed() || got.IsExpired() {
+		t.Errorf("unexpected token: %+v", got)
+	}
+	if _, err := repo.GetByTokenHash(ctx, token); err == nil {
+		t.Error("expected the plain token not to match the stored hash")
+	}
+
+	if err := repo.MarkUsed(ctx, reset.ID, time.Now()); err != nil {
+		t.Fatalf("MarkUsed failed: %v", err)
+	}
+	if err := repo.MarkUsed(ctx, reset.ID, time.Now()); !errors.Is(err, ports.ErrResetTokenUsed) {
+		t.Errorf("expected ErrResetTokenUsed on second use, got %v", err)
+	}
+	if got, _ := repo.GetByTokenHash(ctx, domain.HashToken(token)); got == nil || !got.IsUsed() {
+		t.Error("expected the token to be marked used")
+	}
+
+	expired, expiredToken, _ := domain.GeneratePasswordResetToken(userID, -time.Minute)
+	if err := repo.Create(ctx, expired); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if n, err := repo.DeleteExpired(ctx); err != nil || n != 1 {
+		t.Errorf("expected 1 expired token deleted, got %d (%v)", n, err)
+	}
+	if _, err := repo.GetByTokenHash(ctx, domain.HashToken(expiredToken)); err == nil {
+		t.Error("expected the expired token to be gone")
+	}
+
+	if err := repo.DeleteByUserID(ctx, userID); err != nil {
+		t.Fatalf("DeleteByUserID failed: %v", err)
+	}
+	if _, err := repo.GetByTokenHash(ctx, domain.HashToken(token)); err == nil {
+		t.Error("expected no tokens left for the user")
+	}
+}
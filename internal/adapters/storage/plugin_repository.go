@@ -0,0 +1,222 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+	"github.com/google/uuid"
+)
+
+// PluginRepository implements ports.PluginRepository using SQLite.
+type PluginRepository struct {
+	db *DB
+}
+
+// NewPluginRepository creates a new plugin repository.
+func NewPluginRepository(db *DB) *PluginRepository {
+	return &PluginRepository{db: db}
+}
+
+const pluginColumns = `id, name, version, description, author, path, hash, status,
+	permissions, allowed_hosts, config, created_at, updated_at, loaded_at, error`
+
+// Create persists a new plugin.
+func (r *PluginRepository) Create(ctx context.Context, plugin *domain.Plugin) error {
+	permissions, hosts, config, err := marshalPluginAccess(plugin)
+	if err != nil {
+		return err
+	}
+	idBytes, _ := plugin.ID.MarshalBinary()
+
+	query := `
+		INSERT INTO plugins (` + pluginColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err = r.db.conn.ExecContext(ctx, query,
+		idBytes,
+		plugin.Name,
+		plugin.Version,
+		plugin.Description,
+		plugin.Author,
+		plugin.Path,
+		plugin.Hash,
+		string(plugin.Status),
+		permissions,
+		hosts,
+		config,
+		plugin.CreatedAt.UnixMilli(),
+		plugin.UpdatedAt.UnixMilli(),
+		nullableMillis(plugin.LoadedAt),
+		plugin.Error,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert plugin: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a plugin by its ID.
+func (r *PluginRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Plugin, error) {
+	idBytes, _ := id.MarshalBinary()
+	row := r.db.conn.QueryRowContext(ctx, "SELECT "+pluginColumns+" FROM plugins WHERE id = ?", idBytes)
+	return scanPlugin(row)
+}
+
+// GetByName retrieves a plugin by its name.
+func (r *PluginRepository) GetByName(ctx context.Context, name string) (*domain.Plugin, error) {
+	row := r.db.conn.QueryRowContext(ctx, "SELECT "+pluginColumns+" FROM plugins WHERE name = ?", name)
+	return scanPlugin(row)
+}
+
+// Update updates an existing plugin.
+func (r *PluginRepository) Update(ctx context.Context, plugin *domain.Plugin) error {
+	permissions, hosts, config, err := marshalPluginAccess(plugin)
+	if err != nil {
+		return err
+	}
+	idBytes, _ := plugin.ID.MarshalBinary()
+
+	query := `
+		UPDATE plugins SET
+			name = ?, version = ?, description = ?, author = ?, path = ?, hash = ?, status = ?,
+			permissions = ?, allowed_hosts = ?, config = ?, updated_at = ?, loaded_at = ?, error = ?
+		WHERE id = ?
+	`
+	result, err := r.db.conn.ExecContext(ctx, query,
+		plugin.Name,
+		plugin.Version,
+		plugin.Description,
+		plugin.Author,
+		plugin.Path,
+		plugin.Hash,
+		string(plugin.Status),
+		permissions,
+		hosts,
+		config,
+		plugin.UpdatedAt.UnixMilli(),
+		nullableMillis(plugin.LoadedAt),
+		plugin.Error,
+		idBytes,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update plugin: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("plugin not found")
+	}
+	return nil
+}
+
+// Delete removes a plugin.
+func (r *PluginRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	idBytes, _ := id.MarshalBinary()
+	result, err := r.db.conn.ExecContext(ctx, "DELETE FROM plugins WHERE id = ?", idBytes)
+	if err != nil {
+		return fmt.Errorf("failed to delete plugin: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("plugin not found")
+	}
+	return nil
+}
+
+// List retrieves all plugins by name.
+func (r *PluginRepository) List(ctx context.Context) ([]*domain.Plugin, error) {
+	return r.list(ctx, "SELECT "+pluginColumns+" FROM plugins ORDER BY name")
+}
+
+// ListActive retrieves all active plugins by name.
+func (r *PluginRepository) ListActive(ctx context.Context) ([]*domain.Plugin, error) {
+	return r.list(ctx, "SELECT "+pluginColumns+" FROM plugins WHERE status = ? ORDER BY name",
+		string(domain.PluginStatusActive))
+}
+
+func (r *PluginRepository) list(ctx context.Context, query string, args ...interface{}) ([]*domain.Plugin, error) {
+	rows, err := r.db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query plugins: %w", err)
+	}
+	defer rows.Close()
+
+	var plugins []*domain.Plugin
+	for rows.Next() {
+		plugin, err := scanPlugin(rows)
+		if err != nil {
+			return nil, err
+		}
+		plugins = append(plugins, plugin)
+	}
+	return plugins, rows.Err()
+}
+
+// marshalPluginAccess encodes a plugin's permissions, allowed hosts and
+// configuration.
+func marshalPluginAccess(plugin *domain.Plugin) (permissions, hosts, config []byte, err error) {
+	if permissions, err = json.Marshal(plugin.Permissions); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to marshal permissions: %w", err)
+	}
+	if hosts, err = json.Marshal(plugin.AllowedHosts); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to marshal allowed hosts: %w", err)
+	}
+	if config, err = json.Marshal(plugin.Config); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	return permissions, hosts, config, nil
+}
+
+func scanPlugin(row rowScanner) (*domain.Plugin, error) {
+	var (
+		idBytes     []byte
+		description sql.NullString
+		author      sql.NullString
+		status      sql.NullString
+		permissions sql.NullString
+		hosts       sql.NullString
+		config      sql.NullString
+		createdAt   int64
+		updatedAt   int64
+		loadedAt    sql.NullInt64
+		pluginErr   sql.NullString
+		plugin      domain.Plugin
+	)
+
+	err := row.Scan(&idBytes, &plugin.Name, &plugin.Version, &description, &author, &plugin.Path,
+		&plugin.Hash, &status, &permissions, &hosts, &config, &createdAt, &updatedAt, &loadedAt, &pluginErr)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("plugin not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan plugin: %w", err)
+	}
+
+	plugin.ID, _ = uuid.FromBytes(idBytes)
+	plugin.Description = description.String
+	plugin.Author = author.String
+	plugin.Status = domain.PluginStatus(status.String)
+	plugin.CreatedAt = time.UnixMilli(createdAt)
+	plugin.UpdatedAt = time.UnixMilli(updatedAt)
+	plugin.LoadedAt = millisTime(loadedAt)
+	plugin.Error = pluginErr.String
+
+	plugin.Permissions = []domain.PluginPermission{}
+	if permissions.Valid && permissions.String != "" && permissions.String != "null" {
+		_ = json.Unmarshal([]byte(permissions.String), &plugin.Permissions)
+	}
+	if hosts.Valid && hosts.String != "" && hosts.String != "null" {
+		_ = json.Unmarshal([]byte(hosts.String), &plugin.AllowedHosts)
+	}
+	plugin.Config = make(map[string]string)
+	if config.Valid && config.String != "" && config.String != "null" {
+		_ = json.Unmarshal([]byte(config.String), &plugin.Config)
+	}
+
+	return &plugin, nil
+}
+
+// Ensure PluginRepository implements the interface
+var _ ports.PluginRepository = (*PluginRepository)(nil)
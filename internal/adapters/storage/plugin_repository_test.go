@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+)
+
+func TestPluginRepository_RoundTripsPermissions(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewPluginRepository(db)
+	ctx := context.Background()
+
+	plugin := domain.NewPlugin("fetcher", "1.0.0", "/plugins/fetcher.wasm")
+	plugin.Permissions = []domain.PluginPermission{domain.PermissionNetwork}
+	plugin.AllowedHosts = []string{"api.example.com", "*.internal"}
+	plugin.Config = map[string]string{"region": "eu"}
+	if err := repo.Create(ctx, plugin); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	got, err := repo.GetByName(ctx, "fetcher")
+	if err != nil {
+		t.Fatalf("GetByName failed: %v", err)
+	}
+	if got.ID != plugin.ID || got.Path != plugin.Path {
+		t.Errorf("unexpected plugin: %+v", got)
+	}
+	if !got.HasPermission(domain.PermissionNetwork) {
+		t.Errorf("expected the network permission, got %v", got.Permissions)
+	}
+	if len(got.AllowedHosts) != 2 || got.AllowedHosts[1] != "*.internal" {
+		t.Errorf("unexpected allowed hosts: %v", got.AllowedHosts)
+	}
+	if got.Config["region"] != "eu" {
+		t.Errorf("unexpected config: %v", got.Config)
+	}
+
+	got.AllowedHosts = []string{"10.0.0.0/8"}
+	got.MarkLoaded()
+	if err := repo.Update(ctx, got); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	active, err := repo.ListActive(ctx)
+	if err != nil {
+		t.Fatalf("ListActive failed: %v", err)
+	}
+	if len(active) != 1 || active[0].AllowedHosts[0] != "10.0.0.0/8" || active[0].LoadedAt == nil {
+		t.Errorf("unexpected active plugins: %+v", active)
+	}
+
+	if err := repo.Delete(ctx, plugin.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := repo.GetByID(ctx, plugin.ID); err == nil {
+		t.Error("expected the deleted plugin to be gone")
+	}
+}
@@ -0,0 +1,361 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+	"github.com/google/uuid"
+)
+
+// ProfileRepository implements ports.ProfileRepository using SQLite.
+type ProfileRepository struct {
+	db *DB
+}
+
+// NewProfileRepository creates a new profile repository.
+func NewProfileRepository(db *DB) *ProfileRepository {
+	return &ProfileRepository{db: db}
+}
+
+const profileColumns = `id, name, description, type, status, service_name, process_id, duration,
+	sample_rate, labels, data_size, data_format, file_path, started_at, completed_at, created_at, error`
+
+// Create persists a new profile.
+func (r *ProfileRepository) Create(ctx context.Context, profile *domain.Profile) error {
+	labelsJSON, err := json.Marshal(profile.Labels)
+	if err != nil {
+		return fmt.Errorf("failed to marshal labels: %w", err)
+	}
+	idBytes, _ := profile.ID.MarshalBinary()
+
+	query := `
+		INSERT INTO profiles (` + profileColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err = r.db.conn.ExecContext(ctx, query,
+		idBytes,
+		profile.Name,
+		profile.Description,
+		string(profile.Type),
+		string(profile.Status),
+		profile.ServiceName,
+		profile.ProcessID,
+		int64(profile.Duration),
+		profile.SampleRate,
+		labelsJSON,
+		profile.DataSize,
+		profile.DataFormat,
+		profile.FilePath,
+		nullableNano(profile.StartedAt),
+		completedNanos(profile.CompletedAt),
+		profile.CreatedAt.UnixMilli(),
+		profile.Error,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert profile: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a profile by its ID.
+func (r *ProfileRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Profile, error) {
+	idBytes, _ := id.MarshalBinary()
+	row := r.db.conn.QueryRowContext(ctx, "SELECT "+profileColumns+" FROM profiles WHERE id = ?", idBytes)
+	return scanProfile(row)
+}
+
+// Update updates an existing profile.
+func (r *ProfileRepository) Update(ctx context.Context, profile *domain.Profile) error {
+	labelsJSON, err := json.Marshal(profile.Labels)
+	if err != nil {
+		return fmt.Errorf("failed to marshal labels: %w", err)
+	}
+	idBytes, _ := profile.ID.MarshalBinary()
+
+	query := `
+		UPDATE profiles SET name = ?, description = ?, status = ?, labels = ?, data_size = ?,
+			data_format = ?, file_path = ?, started_at = ?, completed_at = ?, error = ?
+		WHERE id = ?
+	`
+	result, err := r.db.conn.ExecContext(ctx, query,
+		profile.Name,
+		profile.Description,
+		string(profile.Status),
+		labelsJSON,
+		profile.DataSize,
+		profile.DataFormat,
+		profile.FilePath,
+		nullableNano(profile.StartedAt),
+		completedNanos(profile.CompletedAt),
+		profile.Error,
+		idBytes,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update profile: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("profile not found")
+	}
+	return nil
+}
+
+// Delete removes a profile along with its data and flame graph.
+func (r *ProfileRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	idBytes, _ := id.MarshalBinary()
+
+	tx, err := r.db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range []string{
+		"DELETE FROM flame_graphs WHERE profile_id = ?",
+		"DELETE FROM profile_data WHERE profile_id = ?",
+		"DELETE FROM profiles WHERE id = ?",
+	} {
+		if _, err := tx.ExecContext(ctx, stmt, idBytes); err != nil {
+			return fmt.Errorf("failed to delete profile: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// List retrieves profiles with optional filtering, newest first.
+func (r *ProfileRepository) List(ctx context.Context, filter ports.ProfileFilter) ([]*domain.Profile, error) {
+	query := "SELECT " + profileColumns + " FROM profiles WHERE 1=1"
+	var args []interface{}
+
+	if filter.Type != "" {
+		query += " AND type = ?"
+		args = append(args, string(filter.Type))
+	}
+	if filter.Status != "" {
+		query += " AND status = ?"
+		args = append(args, string(filter.Status))
+	}
+	if filter.ServiceName != "" {
+		query += " AND service_name = ?"
+		args = append(args, filter.ServiceName)
+	}
+	if !filter.StartTime.IsZero() {
+		query += " AND created_at >= ?"
+		args = append(args, filter.StartTime.UnixMilli())
+	}
+	if !filter.EndTime.IsZero() {
+		query += " AND created_at <= ?"
+		args = append(args, filter.EndTime.UnixMilli())
+	}
+
+	query += " ORDER BY created_at DESC"
+	query += limitOffset(filter.Limit, filter.Offset)
+
+	rows, err := r.db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query profiles: %w", err)
+	}
+	defer rows.Close()
+
+	var profiles []*domain.Profile
+	for rows.Next() {
+		profile, err := scanProfile(rows)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles, rows.Err()
+}
+
+// SaveProfileData saves the parsed profile samples.
+func (r *ProfileRepository) SaveProfileData(ctx context.Context, data *domain.ProfileData) error {
+	samplesJSON, err := json.Marshal(data.Samples)
+	if err != nil {
+		return fmt.Errorf("failed to marshal samples: %w", err)
+	}
+	idBytes, _ := data.ProfileID.MarshalBinary()
+
+	query := `
+		INSERT OR REPLACE INTO profile_data (profile_id, type, samples, total_value, sample_count)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	_, err = r.db.conn.ExecContext(ctx, query, idBytes, string(data.Type), samplesJSON, data.TotalValue, data.SampleCount)
+	if err != nil {
+		return fmt.Errorf("failed to save profile data: %w", err)
+	}
+	return nil
+}
+
+// GetProfileData retrieves the parsed profile samples.
+func (r *ProfileRepository) GetProfileData(ctx context.Context, profileID uuid.UUID) (*domain.ProfileData, error) {
+	idBytes, _ := profileID.MarshalBinary()
+
+	var (
+		data        domain.ProfileData
+		profileType string
+		samplesJSON sql.NullString
+	)
+	err := r.db.conn.QueryRowContext(ctx,
+		"SELECT type, samples, total_value, sample_count FROM profile_data WHERE profile_id = ?", idBytes,
+	).Scan(&profileType, &samplesJSON, &data.TotalValue, &data.SampleCount)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("profile data not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get profile data: %w", err)
+	}
+
+	data.ProfileID = profileID
+	data.Type = domain.ProfileType(profileType)
+	if samplesJSON.Valid && samplesJSON.String != "" {
+		if err := json.Unmarshal([]byte(samplesJSON.String), &data.Samples); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal samples: %w", err)
+		}
+	}
+	return &data, nil
+}
+
+// SaveFlameGraph saves a flame graph, replacing any previous one for the profile.
+func (r *ProfileRepository) SaveFlameGraph(ctx context.Context, fg *domain.FlameGraph) error {
+	rootJSON, err := json.Marshal(fg.Root)
+	if err != nil {
+		return fmt.Errorf("failed to marshal flame graph: %w", err)
+	}
+	idBytes, _ := fg.ProfileID.MarshalBinary()
+
+	query := `
+		INSERT OR REPLACE INTO flame_graphs (profile_id, type, root, total_value, max_depth, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	_, err = r.db.conn.ExecContext(ctx, query,
+		idBytes, string(fg.Type), rootJSON, fg.TotalValue, fg.MaxDepth, fg.CreatedAt.UnixMilli())
+	if err != nil {
+		return fmt.Errorf("failed to save flame graph: %w", err)
+	}
+	return nil
+}
+
+// GetFlameGraph retrieves the flame graph for a profile.
+func (r *ProfileRepository) GetFlameGraph(ctx context.Context, profileID uuid.UUID) (*domain.FlameGraph, error) {
+	idBytes, _ := profileID.MarshalBinary()
+
+	var (
+		fg          domain.FlameGraph
+		profileType string
+		rootJSON    string
+		createdAt   int64
+	)
+	err := r.db.conn.QueryRowContext(ctx,
+		"SELECT type, root, total_value, max_depth, created_at FROM flame_graphs WHERE profile_id = ?", idBytes,
+	).Scan(&profileType, &rootJSON, &fg.TotalValue, &fg.MaxDepth, &createdAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("flame graph not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get flame graph: %w", err)
+	}
+
+	fg.ProfileID = profileID
+	fg.Type = domain.ProfileType(profileType)
+	fg.CreatedAt = time.UnixMilli(createdAt)
+	if err := json.Unmarshal([]byte(rootJSON), &fg.Root); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal flame graph: %w", err)
+	}
+	return &fg, nil
+}
+
+// DeleteBefore removes profiles created before the given time, with their data.
+func (r *ProfileRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	tx, err := r.db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	cutoff := before.UnixMilli()
+	for _, table := range []string{"flame_graphs", "profile_data"} {
+		stmt := "DELETE FROM " + table + " WHERE profile_id IN (SELECT id FROM profiles WHERE created_at < ?)"
+		if _, err := tx.ExecContext(ctx, stmt, cutoff); err != nil {
+			return 0, fmt.Errorf("failed to delete %s: %w", table, err)
+		}
+	}
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM profiles WHERE created_at < ?", cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete profiles: %w", err)
+	}
+	deleted, _ := result.RowsAffected()
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit: %w", err)
+	}
+	return deleted, nil
+}
+
+func scanProfile(row rowScanner) (*domain.Profile, error) {
+	var (
+		idBytes     []byte
+		description sql.NullString
+		profileType string
+		status      string
+		serviceName sql.NullString
+		duration    int64
+		labelsJSON  sql.NullString
+		dataFormat  sql.NullString
+		filePath    sql.NullString
+		startedAt   sql.NullInt64
+		completedAt sql.NullInt64
+		createdAt   int64
+		errMsg      sql.NullString
+		profile     domain.Profile
+	)
+
+	err := row.Scan(&idBytes, &profile.Name, &description, &profileType, &status, &serviceName,
+		&profile.ProcessID, &duration, &profile.SampleRate, &labelsJSON, &profile.DataSize,
+		&dataFormat, &filePath, &startedAt, &completedAt, &createdAt, &errMsg)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("profile not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan profile: %w", err)
+	}
+
+	profile.ID, _ = uuid.FromBytes(idBytes)
+	profile.Description = description.String
+	profile.Type = domain.ProfileType(profileType)
+	profile.Status = domain.ProfileStatus(status)
+	profile.ServiceName = serviceName.String
+	profile.Duration = time.Duration(duration)
+	profile.Labels = make(map[string]string)
+	if labelsJSON.Valid && labelsJSON.String != "" {
+		_ = json.Unmarshal([]byte(labelsJSON.String), &profile.Labels)
+	}
+	profile.DataFormat = dataFormat.String
+	profile.FilePath = filePath.String
+	if startedAt.Valid {
+		profile.StartedAt = time.Unix(0, startedAt.Int64)
+	}
+	if completedAt.Valid {
+		t := time.Unix(0, completedAt.Int64)
+		profile.CompletedAt = &t
+	}
+	profile.CreatedAt = time.UnixMilli(createdAt)
+	profile.Error = errMsg.String
+
+	return &profile, nil
+}
+
+// completedNanos converts an optional completion time to nanoseconds.
+func completedNanos(t *time.Time) *int64 {
+	if t == nil {
+		return nil
+	}
+	return nullableNano(*t)
+}
+
+var _ ports.ProfileRepository = (*ProfileRepository)(nil)
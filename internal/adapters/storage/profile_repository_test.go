@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+)
+
+func TestProfileRepository_CRUD(t *testing.T) {
+	repo := NewProfileRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	profile := domain.NewProfile("cpu-1", domain.ProfileTypeCPU, "api", 30*time.Second)
+	profile.Labels["env"] = "dev"
+	profile.Start()
+	if err := repo.Create(ctx, profile); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	profile.Complete(2048, "/tmp/cpu-1.pprof")
+	if err := repo.Update(ctx, profile); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	got, err := repo.GetByID(ctx, profile.ID)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if got.Status != domain.ProfileStatusCompleted || got.CompletedAt == nil {
+		t.Errorf("expected completed profile, got status %s", got.Status)
+	}
+	if got.FilePath != "/tmp/cpu-1.pprof" || got.DataSize != 2048 {
+		t.Errorf("unexpected file info: %s (%d bytes)", got.FilePath, got.DataSize)
+	}
+	if got.Duration != 30*time.Second || got.Labels["env"] != "dev" {
+		t.Errorf("expected duration and labels to round-trip, got %v %v", got.Duration, got.Labels)
+	}
+
+	profiles, err := repo.List(ctx, ports.ProfileFilter{Type: domain.ProfileTypeHeap})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(profiles) != 0 {
+		t.Errorf("expected no heap profiles, got %d", len(profiles))
+	}
+}
+
+func TestProfileRepository_FlameGraph(t *testing.T) {
+	repo := NewProfileRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	profile := domain.NewProfile("cpu-1", domain.ProfileTypeCPU, "api", time.Second)
+	if err := repo.Create(ctx, profile); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	if _, err := repo.GetFlameGraph(ctx, profile.ID); err == nil {
+		t.Error("expected error before a flame graph is saved")
+	}
+
+	fg := domain.NewFlameGraph(profile.ID, profile.Type)
+	fg.Root.Value = 5
+	fg.Root.Children = []*domain.FlameGraphNode{{Name: "main", Value: 5, Self: 2}}
+	fg.TotalValue = 5
+	fg.MaxDepth = 1
+	if err := repo.SaveFlameGraph(ctx, fg); err != nil {
+		t.Fatalf("SaveFlameGraph failed: %v", err)
+	}
+
+	got, err := repo.GetFlameGraph(ctx, profile.ID)
+	if err != nil {
+		t.Fatalf("GetFlameGraph failed: %v", err)
+	}
+	if got.TotalValue != 5 || len(got.Root.Children) != 1 || got.Root.Children[0].Self != 2 {
+		t.Errorf("flame graph did not round-trip: %+v", got.Root)
+	}
+
+	deleted, err := repo.DeleteBefore(ctx, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("DeleteBefore failed: %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("expected 1 deleted profile, got %d", deleted)
+	}
+	if _, err := repo.GetFlameGraph(ctx, profile.ID); err == nil {
+		t.Error("expected flame graph to be deleted with its profile")
+	}
+}
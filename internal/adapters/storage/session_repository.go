@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+	"github.com/google/uuid"
+)
+
+// SessionRepository implements ports.SessionRepository using SQLite.
+type SessionRepository struct {
+	db *DB
+}
+
+// NewSessionRepository creates a new session repository.
+func NewSessionRepository(db *DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+const sessionColumns = `id, user_id, token_hash, ip_address, user_agent, expires_at, created_at, last_active_at, revoked_at`
+
+// Create persists a new session.
+func (r *SessionRepository) Create(ctx context.Context, session *domain.Session) error {
+	idBytes, _ := session.ID.MarshalBinary()
+	userIDBytes, _ := session.UserID.MarshalBinary()
+
+	query := `
+		INSERT INTO sessions (` + sessionColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := r.db.conn.ExecContext(ctx, query,
+		idBytes,
+		userIDBytes,
+		session.TokenHash,
+		session.IPAddress,
+		session.UserAgent,
+		session.ExpiresAt.UnixMilli(),
+		session.CreatedAt.UnixMilli(),
+		session.LastActiveAt.UnixMilli(),
+		nullableMillis(session.RevokedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert session: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a session by its ID.
+func (r *SessionRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Session, error) {
+	idBytes, _ := id.MarshalBinary()
+	row := r.db.conn.QueryRowContext(ctx, "SELECT "+sessionColumns+" FROM sessions WHERE id = ?", idBytes)
+	return scanSession(row)
+}
+
+// GetByTokenHash retrieves a session by the hash of its token.
+func (r *SessionRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*domain.Session, error) {
+	row := r.db.conn.QueryRowContext(ctx, "SELECT "+sessionColumns+" FROM sessions WHERE token_hash = ?", tokenHash)
+	return scanSession(row)
+}
+
+// GetByUserID retrieves all sessions for a user.
+func (r *SessionRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.Session, error) {
+	userIDBytes, _ := userID.MarshalBinary()
+	rows, err := r.db.conn.QueryContext(ctx,
+		"SELECT "+sessionColumns+" FROM sessions WHERE user_id = ? ORDER BY created_at", userIDBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*domain.Session
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// Update updates an existing session.
+func (r *SessionRepository) Update(ctx context.Context, session *domain.Session) error {
+	idBytes, _ := session.ID.MarshalBinary()
+
+	query := `
+		UPDATE sessions SET
+			expires_at = ?, last_active_at = ?, revoked_at = ?
+		WHERE id = ?
+	`
+	result, err := r.db.conn.ExecContext(ctx, query,
+		session.ExpiresAt.UnixMilli(),
+		session.LastActiveAt.UnixMilli(),
+		nullableMillis(session.RevokedAt),
+		idBytes,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("session not found")
+	}
+	return nil
+}
+
+// Delete removes a session.
+func (r *SessionRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	idBytes, _ := id.MarshalBinary()
+	result, err := r.db.conn.ExecContext(ctx, "DELETE FROM sessions WHERE id = ?", idBytes)
+	if err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("session not found")
+	}
+	return nil
+}
+
+// DeleteByUserID removes all sessions for a user.
+func (r *SessionRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	userIDBytes, _ := userID.MarshalBinary()
+	if _, err := r.db.conn.ExecContext(ctx, "DELETE FROM sessions WHERE user_id = ?", userIDBytes); err != nil {
+		return fmt.Errorf("failed to delete sessions: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired removes sessions whose expiry has passed.
+func (r *SessionRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	result, err := r.db.conn.ExecContext(ctx,
+		"DELETE FROM sessions WHERE expires_at < ?", time.Now().UnixMilli())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired sessions: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+func scanSession(row rowScanner) (*domain.Session, error) {
+	var (
+		idBytes      []byte
+		userIDBytes  []byte
+		ipAddress    sql.NullString
+		userAgent    sql.NullString
+		expiresAt    int64
+		createdAt    int64
+		lastActiveAt int64
+		revokedAt    sql.NullInt64
+		session      domain.Session
+	)
+
+	err := row.Scan(&idBytes, &userIDBytes, &session.TokenHash, &ipAddress, &userAgent,
+		&expiresAt, &createdAt, &lastActiveAt, &revokedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("session not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan session: %w", err)
+	}
+
+	session.ID, _ = uuid.FromBytes(idBytes)
+	session.UserID, _ = uuid.FromBytes(userIDBytes)
+	session.IPAddress = ipAddress.String
+	session.UserAgent = userAgent.String
+	session.ExpiresAt = time.UnixMilli(expiresAt)
+	session.CreatedAt = time.UnixMilli(createdAt)
+	session.LastActiveAt = time.UnixMilli(lastActiveAt)
+	session.RevokedAt = millisTime(revokedAt)
+	return &session, nil
+}
+
+// Ensure SessionRepository implements the interface
+var _ ports.SessionRepository = (*SessionRepository)(nil)
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/google/uuid"
+)
+
+func TestSessionRepository(t *testing.T) {
+	repo := NewSessionRepository(setupTestDB(t))
+	ctx := context.Background()
+	userID := uuid.New()
+
+	session, token, err := domain.GenerateSession(userID, "127.0.0.1", "TestAgent", time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateSession failed: %v", err)
+	}
+	if err := repo.Create(ctx, session); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	got, err := repo.GetByTokenHash(ctx, domain.HashToken(token))
+	if err != nil {
+		t.Fatalf("GetByTokenHash failed: %v", err)
+	}
+	if got.ID != session.ID || got.UserID != userID || got.IPAddress != "127.0.0.1" || got.UserAgent != "TestAgent" || !got.IsValid() {
+		t.Errorf("unexpected session: %+v", got)
+	}
+	if _, err := repo.GetByTokenHash(ctx, token); err == nil {
+		t.Error("expected the plain token not to match the stored hash")
+	}
+
+	got.Extend(2 * time.Hour)
+	got.Revoke()
+	if err := repo.Update(ctx, got); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	updated, err := repo.GetByID(ctx, session.ID)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if updated.RevokedAt == nil || updated.ExpiresAt.UnixMilli() != got.ExpiresAt.UnixMilli() {
+		t.Errorf("expected revocation and new expiry to persist, got %+v", updated)
+	}
+
+	expired, _, _ := domain.GenerateSession(userID, "", "", -time.Minute)
+	if err := repo.Create(ctx, expired); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if sessions, err := repo.GetByUserID(ctx, userID); err != nil || len(sessions) != 2 {
+		t.Errorf("expected 2 sessions for the user, got %d (%v)", len(sessions), err)
+	}
+	if n, err := repo.DeleteExpired(ctx); err != nil || n != 1 {
+		t.Errorf("expected 1 expired session deleted, got %d (%v)", n, err)
+	}
+
+	if err := repo.DeleteByUserID(ctx, userID); err != nil {
+		t.Fatalf("DeleteByUserID failed: %v", err)
+	}
+	if _, err := repo.GetByID(ctx, session.ID); err == nil {
+		t.Error("expected no sessions left for the user")
+	}
+}
@@ -35,8 +35,9 @@ func DefaultConfig(dataDir string) Config {
 
 // DB wraps the SQLite database connection.
 type DB struct {
-	conn   *sql.DB
-	config Config
+	conn     *sql.DB
+	config   Config
+	features Features
 }
 
 // New creates a new SQLite database connection with TSDB optimizations.
@@ -70,6 +71,7 @@ func New(config Config) (*DB, error) {
 		conn.Close()
 		return nil, err
 	}
+	db.features = detectFeatures(conn)
 
 	// Initialize schema
 	if err := db.initSchema(); err != nil {
@@ -101,6 +103,12 @@ func (db *DB) applyPragmas() error {
 // initSchema creates the database tables if they don't exist.
 func (db *DB) initSchema() error {
 	schema := `
+	-- One-off data migrations already applied (see migrations.go)
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		name TEXT PRIMARY KEY,
+		applied_at INTEGER NOT NULL
+	);
+
 	-- Metrics table (TSDB)
 	CREATE TABLE IF NOT EXISTS metrics (
 		id BLOB(16) PRIMARY KEY,
@@ -130,6 +138,12 @@ func (db *DB) initSchema() error {
 		tags JSON
 	);
 	CREATE INDEX IF NOT EXISTS idx_metrics_agg_series ON metrics_aggregated(series_hash, resolution, window_start);
+	-- One row per series, resolution and window so downsampling can be re-run;
+	-- duplicates written before the index existed are dropped first
+	DELETE FROM metrics_aggregated WHERE rowid NOT IN (
+		SELECT MAX(rowid) FROM metrics_aggregated GROUP BY series_hash, resolution, window_start
+	);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_metrics_agg_window ON metrics_aggregated(series_hash, resolution, window_start);
 
 	-- Tasks table (Durable Queue)
 	CREATE TABLE IF NOT EXISTS tasks (
@@ -161,6 +175,7 @@ func (db *DB) initSchema() error {
 		hash TEXT NOT NULL,
 		status TEXT DEFAULT 'inactive',
 		permissions JSON,
+		allowed_hosts JSON,
 		config JSON,
 		created_at INTEGER NOT NULL,
 		updated_at INTEGER NOT NULL,
@@ -179,6 +194,19 @@ func (db *DB) initSchema() error {
 	);
 	CREATE INDEX IF NOT EXISTS idx_conversations_updated ON conversations(updated_at DESC);
 
+	-- Insights (persisted AI analysis results, timestamps in ms)
+	CREATE TABLE IF NOT EXISTS insights (
+		id BLOB(16) PRIMARY KEY,
+		timestamp INTEGER NOT NULL,
+		time_range_ms INTEGER NOT NULL,
+		metric_count INTEGER NOT NULL,
+		task_count INTEGER NOT NULL,
+		max_severity TEXT,
+		summary TEXT NOT NULL,
+		issues JSON NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_insights_timestamp ON insights(timestamp DESC);
+
 	-- Workflows table
 	CREATE TABLE IF NOT EXISTS workflows (
 		id BLOB(16) PRIMARY KEY,
@@ -195,6 +223,271 @@ func (db *DB) initSchema() error {
 		error TEXT
 	);
 	CREATE INDEX IF NOT EXISTS idx_workflows_status ON workflows(status);
+
+	-- Traces table (timestamps in nanoseconds)
+	CREATE TABLE IF NOT EXISTS traces (
+		id BLOB(16) PRIMARY KEY,
+		trace_id TEXT UNIQUE NOT NULL,
+		service_name TEXT NOT NULL,
+		name TEXT NOT NULL,
+		start_time INTEGER NOT NULL,
+		end_time INTEGER,
+		duration INTEGER DEFAULT 0,
+		span_count INTEGER DEFAULT 0,
+		error_count INTEGER DEFAULT 0,
+		status TEXT DEFAULT 'unset',
+		attributes JSON,
+		created_at INTEGER NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_traces_service_time ON traces(service_name, start_time);
+	CREATE INDEX IF NOT EXISTS idx_traces_start ON traces(start_time);
+
+	-- Spans table (timestamps in nanoseconds)
+	CREATE TABLE IF NOT EXISTS spans (
+		id BLOB(16) PRIMARY KEY,
+		trace_id TEXT NOT NULL,
+		span_id TEXT NOT NULL,
+		parent_span_id TEXT,
+		name TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		service_name TEXT NOT NULL,
+		service_version TEXT,
+		start_time INTEGER NOT NULL,
+		end_time INTEGER,
+		duration INTEGER DEFAULT 0,
+		status TEXT DEFAULT 'unset',
+		status_message TEXT,
+		attributes JSON,
+		events JSON,
+		links JSON,
+		created_at INTEGER NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_spans_trace ON spans(trace_id, span_id);
+	CREATE INDEX IF NOT EXISTS idx_spans_service_time ON spans(service_name, start_time);
+
+	-- Span attributes, one row per key, so traces can be found by attribute
+	-- through an index rather than by scanning span JSON
+	CREATE TABLE IF NOT EXISTS span_attributes (
+		trace_id TEXT NOT NULL,
+		span_id TEXT NOT NULL,
+		key TEXT NOT NULL,
+		value TEXT NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_span_attributes_kv ON span_attributes(key, value, trace_id);
+	CREATE INDEX IF NOT EXISTS idx_span_attributes_trace ON span_attributes(trace_id, span_id);
+
+	-- Logs table (timestamps in nanoseconds, severity orders levels)
+	CREATE TABLE IF NOT EXISTS logs (
+		id BLOB(16) PRIMARY KEY,
+		timestamp INTEGER NOT NULL,
+		level TEXT NOT NULL,
+		severity INTEGER NOT NULL,
+		message TEXT NOT NULL,
+		source TEXT,
+		service_name TEXT,
+		trace_id TEXT,
+		span_id TEXT,
+		attributes JSON,
+		resource JSON,
+		parsed_fields JSON,
+		raw TEXT,
+		created_at INTEGER NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_logs_timestamp ON logs(timestamp);
+	CREATE INDEX IF NOT EXISTS idx_logs_service_time ON logs(service_name, timestamp);
+	CREATE INDEX IF NOT EXISTS idx_logs_severity_time ON logs(severity, timestamp);
+	CREATE INDEX IF NOT EXISTS idx_logs_trace ON logs(trace_id);
+
+	-- Log parsers, applied to ingested lines in priority order
+	CREATE TABLE IF NOT EXISTS log_parsers (
+		id BLOB(16) PRIMARY KEY,
+		name TEXT UNIQUE NOT NULL,
+		description TEXT,
+		type TEXT NOT NULL,
+		pattern TEXT,
+		field_mappings JSON,
+		source_filter TEXT,
+		priority INTEGER DEFAULT 0,
+		enabled INTEGER DEFAULT 1,
+		created_at INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL
+	);
+
+	-- Log-to-metric rules, evaluated against every ingested entry
+	CREATE TABLE IF NOT EXISTS log_metric_rules (
+		id BLOB(16) PRIMARY KEY,
+		name TEXT UNIQUE NOT NULL,
+		description TEXT,
+		match_field TEXT NOT NULL,
+		match_pattern TEXT,
+		match_values JSON,
+		min_level TEXT,
+		service_name TEXT,
+		source TEXT,
+		metric_name TEXT NOT NULL,
+		metric_type TEXT NOT NULL,
+		value_field TEXT,
+		tags JSON,
+		tag_fields JSON,
+		enabled INTEGER DEFAULT 1,
+		status TEXT NOT NULL DEFAULT 'active',
+		last_error TEXT,
+		created_at INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL
+	);
+
+	-- Alert notification channels
+	CREATE TABLE IF NOT EXISTS notification_channels (
+		id BLOB(16) PRIMARY KEY,
+		name TEXT UNIQUE NOT NULL,
+		type TEXT NOT NULL,
+		enabled INTEGER DEFAULT 1,
+		config JSON,
+		created_at INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL
+	);
+
+	-- Recurring alert maintenance windows
+	CREATE TABLE IF NOT EXISTS maintenance_windows (
+		id BLOB(16) PRIMARY KEY,
+		name TEXT UNIQUE NOT NULL,
+		matchers JSON,
+		days JSON,
+		start_time TEXT NOT NULL,
+		duration INTEGER NOT NULL,
+		timezone TEXT,
+		enabled INTEGER DEFAULT 1,
+		comment TEXT,
+		created_by TEXT,
+		created_at INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL
+	);
+
+	-- Users and their API keys
+	CREATE TABLE IF NOT EXISTS users (
+		id BLOB(16) PRIMARY KEY,
+		username TEXT UNIQUE NOT NULL,
+		email TEXT UNIQUE NOT NULL,
+		password_hash TEXT NOT NULL,
+		role TEXT NOT NULL,
+		status TEXT NOT NULL,
+		display_name TEXT,
+		metadata JSON,
+		last_login_at INTEGER,
+		failed_logins INTEGER DEFAULT 0,
+		locked_until INTEGER,
+		mfa_enabled INTEGER DEFAULT 0,
+		mfa_secret TEXT,
+		mfa_last_step INTEGER DEFAULT 0,
+		grants JSON,
+		created_at INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS api_keys (
+		id BLOB(16) PRIMARY KEY,
+		user_id BLOB(16) NOT NULL,
+		name TEXT NOT NULL,
+		key_hash TEXT NOT NULL,
+		key_prefix TEXT NOT NULL,
+		permissions JSON,
+		expires_at INTEGER,
+		last_used_at INTEGER,
+		created_at INTEGER NOT NULL,
+		revoked_at INTEGER
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_api_keys_prefix ON api_keys(key_prefix);
+	CREATE INDEX IF NOT EXISTS idx_api_keys_user ON api_keys(user_id);
+
+	-- Login sessions, looked up by the hash of their token
+	CREATE TABLE IF NOT EXISTS sessions (
+		id BLOB(16) PRIMARY KEY,
+		user_id BLOB(16) NOT NULL,
+		token_hash TEXT UNIQUE NOT NULL,
+		ip_address TEXT,
+		user_agent TEXT,
+		expires_at INTEGER NOT NULL,
+		created_at INTEGER NOT NULL,
+		last_active_at INTEGER NOT NULL,
+		revoked_at INTEGER
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_sessions_user ON sessions(user_id);
+
+	-- Single-use password reset tokens, stored as hashes
+	CREATE TABLE IF NOT EXISTS password_resets (
+		id BLOB(16) PRIMARY KEY,
+		user_id BLOB(16) NOT NULL,
+		token_hash TEXT UNIQUE NOT NULL,
+		expires_at INTEGER NOT NULL,
+		created_at INTEGER NOT NULL,
+		used_at INTEGER
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_password_resets_user ON password_resets(user_id);
+
+	-- Append-only audit trail (timestamp in nanoseconds, as hashed); rowid is
+	-- the order of the hash chain
+	CREATE TABLE IF NOT EXISTS audit_logs (
+		id BLOB(16) PRIMARY KEY,
+		user_id BLOB(16),
+		action TEXT NOT NULL,
+		resource TEXT NOT NULL,
+		resource_id TEXT,
+		details JSON,
+		ip_address TEXT,
+		user_agent TEXT,
+		success INTEGER NOT NULL,
+		error TEXT,
+		timestamp INTEGER NOT NULL,
+		prev_hash TEXT,
+		hash TEXT
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_audit_logs_timestamp ON audit_logs(timestamp);
+	CREATE INDEX IF NOT EXISTS idx_audit_logs_user ON audit_logs(user_id);
+
+	-- Profiles table (started/completed in nanoseconds, created_at in ms)
+	CREATE TABLE IF NOT EXISTS profiles (
+		id BLOB(16) PRIMARY KEY,
+		name TEXT NOT NULL,
+		description TEXT,
+		type TEXT NOT NULL,
+		status TEXT NOT NULL,
+		service_name TEXT,
+		process_id INTEGER DEFAULT 0,
+		duration INTEGER DEFAULT 0,
+		sample_rate INTEGER DEFAULT 0,
+		labels JSON,
+		data_size INTEGER DEFAULT 0,
+		data_format TEXT,
+		file_path TEXT,
+		started_at INTEGER,
+		completed_at INTEGER,
+		created_at INTEGER NOT NULL,
+		error TEXT
+	);
+	CREATE INDEX IF NOT EXISTS idx_profiles_created ON profiles(created_at);
+
+	-- Parsed profile samples
+	CREATE TABLE IF NOT EXISTS profile_data (
+		profile_id BLOB(16) PRIMARY KEY,
+		type TEXT NOT NULL,
+		samples JSON,
+		total_value INTEGER DEFAULT 0,
+		sample_count INTEGER DEFAULT 0
+	);
+
+	-- Flame graphs built from profiles
+	CREATE TABLE IF NOT EXISTS flame_graphs (
+		profile_id BLOB(16) PRIMARY KEY,
+		type TEXT NOT NULL,
+		root JSON NOT NULL,
+		total_value INTEGER DEFAULT 0,
+		max_depth INTEGER DEFAULT 0,
+		created_at INTEGER NOT NULL
+	);
 	`
 
 	_, err := db.conn.Exec(schema)
@@ -202,7 +495,7 @@ func (db *DB) initSchema() error {
 		return fmt.Errorf("failed to initialize schema: %w", err)
 	}
 
-	return nil
+	return db.runMigrations()
 }
 
 // Close closes the database connection.
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"encoding/json"
@@ -222,7 +223,7 @@ func (r *TaskRepository) scanTask(row *sql.Row) (*domain.Task, error) {
 	task.ID = uuidFromBytes(idBytes)
 	task.Type = domain.TaskType(taskType)
 	task.Status = domain.TaskStatus(status)
-	_ = json.Unmarshal(payloadJSON, &task.Payload)
+	_ = decodePayload(payloadJSON, &task.Payload)
 	task.RunAt = time.UnixMilli(runAt)
 	task.CreatedAt = time.UnixMilli(createdAt)
 	task.UpdatedAt = time.UnixMilli(updatedAt)
@@ -260,7 +261,7 @@ func (r *TaskRepository) scanTaskRows(rows *sql.Rows) (*domain.Task, error) {
 	task.ID = uuidFromBytes(idBytes)
 	task.Type = domain.TaskType(taskType)
 	task.Status = domain.TaskStatus(status)
-	_ = json.Unmarshal(payloadJSON, &task.Payload)
+	_ = decodePayload(payloadJSON, &task.Payload)
 	task.RunAt = time.UnixMilli(runAt)
 	task.CreatedAt = time.UnixMilli(createdAt)
 	task.UpdatedAt = time.UnixMilli(updatedAt)
@@ -280,5 +281,13 @@ func (r *TaskRepository) scanTaskRows(rows *sql.Rows) (*domain.Task, error) {
 	return &task, nil
 }
 
+// decodePayload unmarshals a task payload, keeping numbers as json.Number
+// so large integers are not rounded to float64.
+func decodePayload(data []byte, payload *map[string]interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(payload)
+}
+
 var _ ports.TaskRepository = (*TaskRepository)(nil)
 
@@ -0,0 +1,972 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+	"github.com/google/uuid"
+)
+
+// TraceRepository implements ports.TraceRepository using SQLite.
+type TraceRepository struct {
+	db *DB
+}
+
+// NewTraceRepository creates a new trace repository.
+func NewTraceRepository(db *DB) *TraceRepository {
+	return &TraceRepository{db: db}
+}
+
+const traceColumns = `id, trace_id, service_name, name, start_time, end_time, duration,
+	span_count, error_count, status, attributes, created_at`
+
+// Create persists a new trace.
+func (r *TraceRepository) Create(ctx context.Context, trace *domain.Trace) error {
+	attrsJSON, err := json.Marshal(trace.Attributes)
+	if err != nil {
+		return fmt.Errorf("failed to marshal attributes: %w", err)
+	}
+	idBytes, _ := trace.ID.MarshalBinary()
+
+	query := `
+		INSERT INTO traces (` + traceColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err = r.db.conn.ExecContext(ctx, query,
+		idBytes,
+		trace.TraceID.String(),
+		trace.ServiceName,
+		trace.Name,
+		trace.StartTime.UnixNano(),
+		nullableNano(trace.EndTime),
+		int64(trace.Duration),
+		trace.SpanCount,
+		trace.ErrorCount,
+		string(trace.Status),
+		attrsJSON,
+		trace.CreatedAt.UnixMilli(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert trace: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a trace by its UUID.
+func (r *TraceRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Trace, error) {
+	idBytes, _ := id.MarshalBinary()
+	row := r.db.conn.QueryRowContext(ctx, "SELECT "+traceColumns+" FROM traces WHERE id = ?", idBytes)
+	trace, err := scanTrace(row)
+	if err != nil {
+		return nil, err
+	}
+	return trace, r.loadSpans(ctx, trace)
+}
+
+// GetByTraceID retrieves a trace by its TraceID.
+func (r *TraceRepository) GetByTraceID(ctx context.Context, traceID domain.TraceID) (*domain.Trace, error) {
+	row := r.db.conn.QueryRowContext(ctx, "SELECT "+traceColumns+" FROM traces WHERE trace_id = ?", traceID.String())
+	trace, err := scanTrace(row)
+	if err != nil {
+		return nil, err
+	}
+	return trace, r.loadSpans(ctx, trace)
+}
+
+// loadSpans attaches the stored spans to a trace.
+func (r *TraceRepository) loadSpans(ctx context.Context, trace *domain.Trace) error {
+	spans, err := NewSpanRepository(r.db).ListByTraceID(ctx, trace.TraceID)
+	if err != nil {
+		return err
+	}
+	trace.Spans = spans
+	for _, span := range spans {
+		if span.ParentSpanID == nil {
+			trace.RootSpan = span
+			break
+		}
+	}
+	return nil
+}
+
+// Update updates an existing trace.
+func (r *TraceRepository) Update(ctx context.Context, trace *domain.Trace) error {
+	attrsJSON, _ := json.Marshal(trace.Attributes)
+	idBytes, _ := trace.ID.MarshalBinary()
+
+	query := `
+		UPDATE traces SET
+			service_name = ?, name = ?, start_time = ?, end_time = ?, duration = ?,
+			span_count = ?, error_count = ?, status = ?, attributes = ?
+		WHERE id = ?
+	`
+	_, err := r.db.conn.ExecContext(ctx, query,
+		trace.ServiceName,
+		trace.Name,
+		trace.StartTime.UnixNano(),
+		nullableNano(trace.EndTime),
+		int64(trace.Duration),
+		trace.SpanCount,
+		trace.ErrorCount,
+		string(trace.Status),
+		attrsJSON,
+		idBytes,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update trace: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a trace.
+func (r *TraceRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	idBytes, _ := id.MarshalBinary()
+	_, err := r.db.conn.ExecContext(ctx, "DELETE FROM traces WHERE id = ?", idBytes)
+	return err
+}
+
+// List retrieves traces with optional filtering, newest first.
+func (r *TraceRepository) List(ctx context.Context, filter ports.TraceFilter) ([]*domain.Trace, error) {
+	where, args := traceFilterWhere(filter)
+	query := "SELECT " + traceColumns + " FROM traces WHERE " + where
+
+	if filter.SlowestFirst {
+		query += " ORDER BY duration DESC, start_time DESC"
+	} else {
+		query += " ORDER BY start_time DESC"
+	}
+	query += limitOffset(filter.Limit, filter.Offset)
+
+	rows, err := r.db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query traces: %w", err)
+	}
+	defer rows.Close()
+
+	var traces []*domain.Trace
+	for rows.Next() {
+		trace, err := scanTrace(rows)
+		if err != nil {
+			return nil, err
+		}
+		traces = append(traces, trace)
+	}
+	return traces, rows.Err()
+}
+
+// Count returns the number of traces matching filter, ignoring its limit,
+// offset and ordering.
+func (r *TraceRepository) Count(ctx context.Context, filter ports.TraceFilter) (int64, error) {
+	where, args := traceFilterWhere(filter)
+	var count int64
+	err := r.db.conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM traces WHERE "+where, args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count traces: %w", err)
+	}
+	return count, nil
+}
+
+// traceFilterWhere builds the WHERE clause selecting the traces filter
+// matches.
+func traceFilterWhere(filter ports.TraceFilter) (string, []interface{}) {
+	query := "1=1"
+	var args []interface{}
+
+	if filter.ServiceName != "" {
+		query += " AND service_name = ?"
+		args = append(args, filter.ServiceName)
+	}
+	if filter.Name != "" {
+		query += " AND name = ?"
+		args = append(args, filter.Name)
+	}
+	if filter.Status != "" {
+		query += " AND status = ?"
+		args = append(args, filter.Status)
+	}
+	if filter.MinDuration > 0 {
+		query += " AND duration >= ?"
+		args = append(args, int64(filter.MinDuration))
+	}
+	if filter.MaxDuration > 0 {
+		query += " AND duration <= ?"
+		args = append(args, int64(filter.MaxDuration))
+	}
+	if !filter.StartTime.IsZero() {
+		query += " AND start_time >= ?"
+		args = append(args, filter.StartTime.UnixNano())
+	}
+	if !filter.EndTime.IsZero() {
+		query += " AND start_time <= ?"
+		args = append(args, filter.EndTime.UnixNano())
+	}
+
+	// Attribute matchers are resolved through the span_attributes index,
+	// each to the set of traces with a matching span
+	keys := make([]string, 0, len(filter.Attributes))
+	for k := range filter.Attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		query += " AND trace_id IN (SELECT trace_id FROM span_attributes WHERE key = ? AND value = ?)"
+		args = append(args, k, filter.Attributes[k])
+	}
+	for _, k := range filter.HasAttributes {
+		query += " AND trace_id IN (SELECT trace_id FROM span_attributes WHERE key = ?)"
+		args = append(args, k)
+	}
+	return query, args
+}
+
+// peerAttributes name the remote end of a client span, most specific first.
+// Calls to services that aren't traced become edges to a pseudo-node named
+// by the first of these the span has.
+var peerAttributes = []string{"peer.service", "server.address", "net.peer.name"}
+
+// GetServiceMap builds the service dependency map from spans in the time range.
+// A dependency is recorded when a span's parent belongs to a different service,
+// or when a client span with a peer attribute has no child in another service.
+func (r *TraceRepository) GetServiceMap(ctx context.Context, startTime, endTime time.Time) (*domain.ServiceMap, error) {
+	query := `
+		SELECT service_name, COUNT(*),
+		       SUM(CASE WHEN status = 'error' THEN 1 ELSE 0 END),
+		       AVG(duration)
+		FROM spans
+		WHERE start_time >= ? AND start_time <= ?
+		GROUP BY service_name
+		ORDER BY service_name
+	`
+	rows, err := r.db.conn.QueryContext(ctx, query, startTime.UnixNano(), endTime.UnixNano())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query service map: %w", err)
+	}
+
+	nodes := make(map[string]*domain.ServiceMapNode)
+	var order []string
+	for rows.Next() {
+		var node domain.ServiceMapNode
+		var avgNanos float64
+		if err := rows.Scan(&node.ServiceName, &node.SpanCount, &node.ErrorCount, &avgNanos); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan service map node: %w", err)
+		}
+		node.AvgDuration = avgNanos / float64(time.Millisecond)
+		node.Dependencies = []string{}
+		nodes[node.ServiceName] = &node
+		order = append(order, node.ServiceName)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.fillLatencyPercentiles(ctx, nodes, startTime, endTime); err != nil {
+		return nil, err
+	}
+
+	calls := make(map[[2]string]*edgeCalls)
+	if err := r.collectServiceCalls(ctx, calls, startTime, endTime); err != nil {
+		return nil, err
+	}
+	if err := r.collectPeerCalls(ctx, calls, startTime, endTime); err != nil {
+		return nil, err
+	}
+
+	keys := make([][2]string, 0, len(calls))
+	for key := range calls {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+
+	edges := make([]domain.ServiceMapEdge, 0, len(keys))
+	external := make(map[string]*edgeCalls)
+	for _, key := range keys {
+		c := calls[key]
+		edge := c.edge(key[0], key[1])
+		edges = append(edges, edge)
+		if node, ok := nodes[edge.Source]; ok {
+			node.Dependencies = append(node.Dependencies, edge.Target)
+		}
+		// Peers that aren't traced services become pseudo-nodes, with
+		// the calls made to them as their spans
+		if _, traced := nodes[edge.Target]; !traced {
+			if external[edge.Target] == nil {
+				external[edge.Target] = &edgeCalls{}
+			}
+			external[edge.Target].merge(c)
+		}
+	}
+
+	peers := make([]string, 0, len(external))
+	for peer := range external {
+		peers = append(peers, peer)
+	}
+	sort.Strings(peers)
+	for _, peer := range peers {
+		node := external[peer].node(peer)
+		nodes[peer] = &node
+		order = append(order, peer)
+	}
+
+	serviceMap := &domain.ServiceMap{
+		Nodes:     make([]domain.ServiceMapNode, 0, len(order)),
+		Edges:     edges,
+		UpdatedAt: time.Now(),
+	}
+	for _, name := range order {
+		node := nodes[name]
+		sort.Strings(node.Dependencies)
+		serviceMap.Nodes = append(serviceMap.Nodes, *node)
+	}
+	return serviceMap, nil
+}
+
+// edgeCalls accumulates the calls along one edge of the service map.
+type edgeCalls struct {
+	errors    int64
+	durations []int64
+}
+
+func (c *edgeCalls) add(duration int64, failed bool) {
+	c.durations = append(c.durations, duration)
+	if failed {
+		c.errors++
+	}
+}
+
+func (c *edgeCalls) merge(other *edgeCalls) {
+	c.errors += other.errors
+	c.durations = append(c.durations, other.durations...)
+}
+
+// edge summarizes the calls from source to target.
+func (c *edgeCalls) edge(source, target string) domain.ServiceMapEdge {
+	sort.Slice(c.durations, func(i, j int) bool { return c.durations[i] < c.durations[j] })
+	edge := domain.ServiceMapEdge{
+		Source:      source,
+		Target:      target,
+		CallCount:   int64(len(c.durations)),
+		ErrorCount:  c.errors,
+		P50Duration: percentile(c.durations, 50) / float64(time.Millisecond),
+		P95Duration: percentile(c.durations, 95) / float64(time.Millisecond),
+		P99Duration: percentile(c.durations, 99) / float64(time.Millisecond),
+	}
+	if edge.CallCount > 0 {
+		edge.ErrorRate = float64(edge.ErrorCount) / float64(edge.CallCount)
+	}
+	return edge
+}
+
+// node summarizes the calls made to an untraced peer as a pseudo-node.
+func (c *edgeCalls) node(peer string) domain.ServiceMapNode {
+	sort.Slice(c.durations, func(i, j int) bool { return c.durations[i] < c.durations[j] })
+	var total int64
+	for _, d := range c.durations {
+		total += d
+	}
+	node := domain.ServiceMapNode{
+		ServiceName:  peer,
+		External:     true,
+		SpanCount:    int64(len(c.durations)),
+		ErrorCount:   c.errors,
+		Dependencies: []string{},
+		P50Duration:  percentile(c.durations, 50) / float64(time.Millisecond),
+		P95Duration:  percentile(c.durations, 95) / float64(time.Millisecond),
+		P99Duration:  percentile(c.durations, 99) / float64(time.Millisecond),
+	}
+	if node.SpanCount > 0 {
+		node.AvgDuration = float64(total) / float64(node.SpanCount) / float64(time.Millisecond)
+	}
+	return node
+}
+
+// collectServiceCalls adds the calls between traced services: each span
+// whose parent belongs to another service. A call's latency is the
+// parent's duration when it is the client side of the call, so network
+// time is included, and the child's otherwise; its outcome is the child's.
+func (r *TraceRepository) collectServiceCalls(ctx context.Context, calls map[[2]string]*edgeCalls, startTime, endTime time.Time) error {
+	rows, err := r.db.conn.QueryContext(ctx, `
+		SELECT parent.service_name, child.service_name,
+		       CASE WHEN parent.kind IN ('client', 'producer') THEN parent.duration ELSE child.duration END,
+		       child.status = 'error'
+		FROM spans child
+		JOIN spans parent ON parent.trace_id = child.trace_id AND parent.span_id = child.parent_span_id
+		WHERE child.start_time >= ? AND child.start_time <= ?
+		  AND parent.service_name != child.service_name
+	`, startTime.UnixNano(), endTime.UnixNano())
+	if err != nil {
+		return fmt.Errorf("failed to query service dependencies: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key [2]string
+		var duration int64
+		var failed bool
+		if err := rows.Scan(&key[0], &key[1], &duration, &failed); err != nil {
+			return fmt.Errorf("failed to scan service dependency: %w", err)
+		}
+		if calls[key] == nil {
+			calls[key] = &edgeCalls{}
+		}
+		calls[key].add(duration, failed)
+	}
+	return rows.Err()
+}
+
+// collectPeerCalls adds the calls out of traced services that have no
+// traced callee: client and producer spans without a child in another
+// service, keyed by their peer attribute. Spans without one are skipped.
+func (r *TraceRepository) collectPeerCalls(ctx context.Context, calls map[[2]string]*edgeCalls, startTime, endTime time.Time) error {
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(peerAttributes)), ", ")
+	args := []interface{}{startTime.UnixNano(), endTime.UnixNano()}
+	for _, key := range peerAttributes {
+		args = append(args, key)
+	}
+	rows, err := r.db.conn.QueryContext(ctx, `
+		SELECT s.trace_id, s.span_id, s.service_name, s.duration, s.status = 'error', a.key, a.value
+		FROM spans s
+		JOIN span_attributes a ON a.trace_id = s.trace_id AND a.span_id = s.span_id
+		WHERE s.start_time >= ? AND s.start_time <= ?
+		  AND s.kind IN ('client', 'producer')
+		  AND a.key IN (`+placeholders+`) AND a.value != ''
+		  AND NOT EXISTS (
+		      SELECT 1 FROM spans child
+		      WHERE child.trace_id = s.trace_id AND child.parent_span_id = s.span_id
+		        AND child.service_name != s.service_name)
+	`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query external calls: %w", err)
+	}
+	defer rows.Close()
+
+	// A span may carry several peer attributes; the most specific names it
+	type peerCall struct {
+		service  string
+		duration int64
+		failed   bool
+		peer     string
+		rank     int
+	}
+	rank := make(map[string]int, len(peerAttributes))
+	for i, key := range peerAttributes {
+		rank[key] = i
+	}
+	spans := make(map[[2]string]*peerCall)
+	for rows.Next() {
+		var id [2]string
+		var c peerCall
+		var key string
+		if err := rows.Scan(&id[0], &id[1], &c.service, &c.duration, &c.failed, &key, &c.peer); err != nil {
+			return fmt.Errorf("failed to scan external call: %w", err)
+		}
+		c.rank = rank[key]
+		if prev, ok := spans[id]; !ok || c.rank < prev.rank {
+			spans[id] = &c
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, c := range spans {
+		if c.peer == c.service {
+			continue
+		}
+		key := [2]string{c.service, c.peer}
+		if calls[key] == nil {
+			calls[key] = &edgeCalls{}
+		}
+		calls[key].add(c.duration, c.failed)
+	}
+	return nil
+}
+
+// fillLatencyPercentiles sets the p50/p95/p99 durations of each node from the
+// span durations in the time range. SQLite has no percentile aggregate, so the
+// durations are streamed in order per service and ranked here.
+func (r *TraceRepository) fillLatencyPercentiles(ctx context.Context, nodes map[string]*domain.ServiceMapNode, startTime, endTime time.Time) error {
+	rows, err := r.db.conn.QueryContext(ctx, `
+		SELECT service_name, duration
+		FROM spans
+		WHERE start_time >= ? AND start_time <= ?
+		ORDER BY service_name, duration
+	`, startTime.UnixNano(), endTime.UnixNano())
+	if err != nil {
+		return fmt.Errorf("failed to query span durations: %w", err)
+	}
+	defer rows.Close()
+
+	durations := make(map[string][]int64)
+	for rows.Next() {
+		var service string
+		var duration int64
+		if err := rows.Scan(&service, &duration); err != nil {
+			return fmt.Errorf("failed to scan span duration: %w", err)
+		}
+		durations[service] = append(durations[service], duration)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for service, sorted := range durations {
+		node, ok := nodes[service]
+		if !ok {
+			continue
+		}
+		node.P50Duration = percentile(sorted, 50) / float64(time.Millisecond)
+		node.P95Duration = percentile(sorted, 95) / float64(time.Millisecond)
+		node.P99Duration = percentile(sorted, 99) / float64(time.Millisecond)
+	}
+	return nil
+}
+
+// percentile returns the nearest-rank p-th percentile of sorted values.
+func percentile(sorted []int64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return float64(sorted[rank-1])
+}
+
+// DeleteBefore removes traces (and their spans) that started before the given time.
+func (r *TraceRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	cutoff := before.UnixNano()
+	if _, err := tx.ExecContext(ctx,
+		"DELETE FROM span_attributes WHERE trace_id IN (SELECT trace_id FROM traces WHERE start_time < ?)", cutoff); err != nil {
+		return 0, fmt.Errorf("failed to delete span attributes: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		"DELETE FROM spans WHERE trace_id IN (SELECT trace_id FROM traces WHERE start_time < ?)", cutoff); err != nil {
+		return 0, fmt.Errorf("failed to delete spans: %w", err)
+	}
+	result, err := tx.ExecContext(ctx, "DELETE FROM traces WHERE start_time < ?", cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete traces: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTrace(row rowScanner) (*domain.Trace, error) {
+	var (
+		idBytes    []byte
+		traceIDStr string
+		startNanos int64
+		endNanos   sql.NullInt64
+		duration   int64
+		status     string
+		attrsJSON  sql.NullString
+		createdAt  int64
+		trace      domain.Trace
+	)
+
+	err := row.Scan(&idBytes, &traceIDStr, &trace.ServiceName, &trace.Name, &startNanos, &endNanos,
+		&duration, &trace.SpanCount, &trace.ErrorCount, &status, &attrsJSON, &createdAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("trace not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan trace: %w", err)
+	}
+
+	trace.ID, _ = uuid.FromBytes(idBytes)
+	trace.TraceID, _ = domain.ParseTraceID(traceIDStr)
+	trace.StartTime = time.Unix(0, startNanos)
+	if endNanos.Valid {
+		trace.EndTime = time.Unix(0, endNanos.Int64)
+	}
+	trace.Duration = time.Duration(duration)
+	trace.Status = domain.SpanStatus(status)
+	trace.Spans = []*domain.Span{}
+	trace.Attributes = make(map[string]string)
+	if attrsJSON.Valid && attrsJSON.String != "" {
+		_ = json.Unmarshal([]byte(attrsJSON.String), &trace.Attributes)
+	}
+	trace.CreatedAt = time.UnixMilli(createdAt)
+
+	return &trace, nil
+}
+
+// SpanRepository implements ports.SpanRepository using SQLite.
+type SpanRepository struct {
+	db *DB
+}
+
+// NewSpanRepository creates a new span repository.
+func NewSpanRepository(db *DB) *SpanRepository {
+	return &SpanRepository{db: db}
+}
+
+const spanColumns = `id, trace_id, span_id, parent_span_id, name, kind, service_name, service_version,
+	start_time, end_time, duration, status, status_message, attributes, events, links, created_at`
+
+// spanExecer is satisfied by *sql.DB, *sql.Tx and *sql.Stmt callers below.
+type spanExecer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Create persists a new span.
+func (r *SpanRepository) Create(ctx context.Context, span *domain.Span) error {
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertSpan(ctx, tx, span); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// CreateBatch persists multiple spans in a single transaction.
+func (r *SpanRepository) CreateBatch(ctx context.Context, spans []*domain.Span) error {
+	if len(spans) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, span := range spans {
+		if err := insertSpan(ctx, tx, span); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// CreateBatchBestEffort persists spans in a single transaction, skipping
+// and reporting the ones that cannot be written.
+func (r *SpanRepository) CreateBatchBestEffort(ctx context.Context, spans []*domain.Span) (*ports.BatchReport, error) {
+	return bestEffortBatch(ctx, r.db, len(spans), func(tx *sql.Tx, i int) error {
+		if spans[i] == nil {
+			return fmt.Errorf("span is nil")
+		}
+		return insertSpan(ctx, tx, spans[i])
+	})
+}
+
+func insertSpan(ctx context.Context, exec spanExecer, span *domain.Span) error {
+	attrsJSON, err := json.Marshal(span.Attributes)
+	if err != nil {
+		return fmt.Errorf("failed to marshal attributes: %w", err)
+	}
+	eventsJSON, _ := json.Marshal(span.Events)
+	linksJSON, _ := json.Marshal(span.Links)
+	idBytes, _ := span.ID.MarshalBinary()
+
+	var parentID *string
+	if span.ParentSpanID != nil {
+		v := span.ParentSpanID.String()
+		parentID = &v
+	}
+
+	createdAt := span.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	query := `
+		INSERT INTO spans (` + spanColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err = exec.ExecContext(ctx, query,
+		idBytes,
+		span.TraceID.String(),
+		span.SpanID.String(),
+		parentID,
+		span.Name,
+		string(span.Kind),
+		span.ServiceName,
+		span.ServiceVersion,
+		span.StartTime.UnixNano(),
+		nullableNano(span.EndTime),
+		int64(span.Duration),
+		string(span.Status),
+		span.StatusMessage,
+		attrsJSON,
+		eventsJSON,
+		linksJSON,
+		createdAt.UnixMilli(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert span: %w", err)
+	}
+
+	for key, value := range span.Attributes {
+		if _, err := exec.ExecContext(ctx,
+			"INSERT INTO span_attributes (trace_id, span_id, key, value) VALUES (?, ?, ?, ?)",
+			span.TraceID.String(), span.SpanID.String(), key, value); err != nil {
+			return fmt.Errorf("failed to index span attribute %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// GetByID retrieves a span by its UUID.
+func (r *SpanRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Span, error) {
+	idBytes, _ := id.MarshalBinary()
+	row := r.db.conn.QueryRowContext(ctx, "SELECT "+spanColumns+" FROM spans WHERE id = ?", idBytes)
+	return scanSpan(row)
+}
+
+// GetBySpanID retrieves a span by its SpanID.
+func (r *SpanRepository) GetBySpanID(ctx context.Context, traceID domain.TraceID, spanID domain.SpanID) (*domain.Span, error) {
+	row := r.db.conn.QueryRowContext(ctx,
+		"SELECT "+spanColumns+" FROM spans WHERE trace_id = ? AND span_id = ?",
+		traceID.String(), spanID.String())
+	return scanSpan(row)
+}
+
+// ListByTraceID retrieves all spans for a trace ordered by start time.
+func (r *SpanRepository) ListByTraceID(ctx context.Context, traceID domain.TraceID) ([]*domain.Span, error) {
+	return r.List(ctx, ports.SpanFilter{TraceID: traceID})
+}
+
+// List retrieves spans with optional filtering.
+func (r *SpanRepository) List(ctx context.Context, filter ports.SpanFilter) ([]*domain.Span, error) {
+	query := "SELECT " + spanColumns + " FROM spans WHERE 1=1"
+	var args []interface{}
+
+	if filter.TraceID.IsValid() {
+		query += " AND trace_id = ?"
+		args = append(args, filter.TraceID.String())
+	}
+	if filter.ServiceName != "" {
+		query += " AND service_name = ?"
+		args = append(args, filter.ServiceName)
+	}
+	if filter.Name != "" {
+		query += " AND name = ?"
+		args = append(args, filter.Name)
+	}
+	if filter.Kind != "" {
+		query += " AND kind = ?"
+		args = append(args, string(filter.Kind))
+	}
+	if filter.Status != "" {
+		query += " AND status = ?"
+		args = append(args, string(filter.Status))
+	}
+	if !filter.StartTime.IsZero() {
+		query += " AND start_time >= ?"
+		args = append(args, filter.StartTime.UnixNano())
+	}
+	if !filter.EndTime.IsZero() {
+		query += " AND start_time <= ?"
+		args = append(args, filter.EndTime.UnixNano())
+	}
+	if filter.MinDuration > 0 {
+		query += " AND duration >= ?"
+		args = append(args, int64(filter.MinDuration))
+	}
+	if filter.MaxDuration > 0 {
+		query += " AND duration <= ?"
+		args = append(args, int64(filter.MaxDuration))
+	}
+
+	keys := make([]string, 0, len(filter.Attributes))
+	for k := range filter.Attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		cond, condArgs := r.db.features.jsonFieldEquals("spans.attributes", k, filter.Attributes[k])
+		query += " AND " + cond
+		args = append(args, condArgs...)
+	}
+
+	query += " ORDER BY start_time ASC"
+	query += limitOffset(filter.Limit, filter.Offset)
+
+	rows, err := r.db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query spans: %w", err)
+	}
+	defer rows.Close()
+
+	spans := []*domain.Span{}
+	for rows.Next() {
+		span, err := scanSpan(rows)
+		if err != nil {
+			return nil, err
+		}
+		spans = append(spans, span)
+	}
+	return spans, rows.Err()
+}
+
+// Delete removes a span.
+func (r *SpanRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	idBytes, _ := id.MarshalBinary()
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM span_attributes
+		WHERE (trace_id, span_id) IN (SELECT trace_id, span_id FROM spans WHERE id = ?)`, idBytes); err != nil {
+		return fmt.Errorf("failed to delete span attributes: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM spans WHERE id = ?", idBytes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteByTraceID removes all spans for a trace.
+func (r *SpanRepository) DeleteByTraceID(ctx context.Context, traceID domain.TraceID) (int64, error) {
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM span_attributes WHERE trace_id = ?", traceID.String()); err != nil {
+		return 0, fmt.Errorf("failed to delete span attributes: %w", err)
+	}
+	result, err := tx.ExecContext(ctx, "DELETE FROM spans WHERE trace_id = ?", traceID.String())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete spans: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+func scanSpan(row rowScanner) (*domain.Span, error) {
+	var (
+		idBytes       []byte
+		traceIDStr    string
+		spanIDStr     string
+		parentIDStr   sql.NullString
+		kind          string
+		serviceVer    sql.NullString
+		startNanos    int64
+		endNanos      sql.NullInt64
+		duration      int64
+		status        string
+		statusMessage sql.NullString
+		attrsJSON     sql.NullString
+		eventsJSON    sql.NullString
+		linksJSON     sql.NullString
+		createdAt     int64
+		span          domain.Span
+	)
+
+	err := row.Scan(&idBytes, &traceIDStr, &spanIDStr, &parentIDStr, &span.Name, &kind, &span.ServiceName,
+		&serviceVer, &startNanos, &endNanos, &duration, &status, &statusMessage,
+		&attrsJSON, &eventsJSON, &linksJSON, &createdAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("span not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan span: %w", err)
+	}
+
+	span.ID, _ = uuid.FromBytes(idBytes)
+	span.TraceID, _ = domain.ParseTraceID(traceIDStr)
+	span.SpanID, _ = domain.ParseSpanID(spanIDStr)
+	if parentIDStr.Valid && parentIDStr.String != "" {
+		if parentID, err := domain.ParseSpanID(parentIDStr.String); err == nil {
+			span.ParentSpanID = &parentID
+		}
+	}
+	span.Kind = domain.SpanKind(kind)
+	span.ServiceVersion = serviceVer.String
+	span.StartTime = time.Unix(0, startNanos)
+	if endNanos.Valid {
+		span.EndTime = time.Unix(0, endNanos.Int64)
+	}
+	span.Duration = time.Duration(duration)
+	span.Status = domain.SpanStatus(status)
+	span.StatusMessage = statusMessage.String
+	span.Attributes = make(map[string]string)
+	if attrsJSON.Valid && attrsJSON.String != "" {
+		_ = json.Unmarshal([]byte(attrsJSON.String), &span.Attributes)
+	}
+	if eventsJSON.Valid && eventsJSON.String != "" {
+		_ = json.Unmarshal([]byte(eventsJSON.String), &span.Events)
+	}
+	if linksJSON.Valid && linksJSON.String != "" {
+		_ = json.Unmarshal([]byte(linksJSON.String), &span.Links)
+	}
+	span.CreatedAt = time.UnixMilli(createdAt)
+
+	return &span, nil
+}
+
+// nullableNano converts a time to nanoseconds, or nil for the zero time.
+func nullableNano(t time.Time) *int64 {
+	if t.IsZero() {
+		return nil
+	}
+	v := t.UnixNano()
+	return &v
+}
+
+// limitOffset renders LIMIT/OFFSET clauses for positive values.
+func limitOffset(limit, offset int) string {
+	clause := ""
+	if limit > 0 {
+		clause += fmt.Sprintf(" LIMIT %d", limit)
+		if offset > 0 {
+			clause += fmt.Sprintf(" OFFSET %d", offset)
+		}
+	} else if offset > 0 {
+		clause += fmt.Sprintf(" LIMIT -1 OFFSET %d", offset)
+	}
+	return clause
+}
+
+var (
+	_ ports.TraceRepository = (*TraceRepository)(nil)
+	_ ports.SpanRepository  = (*SpanRepository)(nil)
+)
@@ -0,0 +1,648 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+)
+
+func setupTestDB(t *testing.T) *DB {
+	t.Helper()
+	db, err := New(DefaultConfig(t.TempDir()))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestTraceRepository_CreateAndGet(t *testing.T) {
+	db := setupTestDB(t)
+	traceRepo := NewTraceRepository(db)
+	spanRepo := NewSpanRepository(db)
+	ctx := context.Background()
+
+	trace := domain.NewTrace("api", "GET /users")
+	if err := traceRepo.Create(ctx, trace); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	root := domain.NewSpan(trace.TraceID, "GET /users", domain.SpanKindServer, "api")
+	root.SetAttribute("http.method", "GET")
+	root.End()
+	child := domain.NewSpan(trace.TraceID, "SELECT users", domain.SpanKindClient, "db")
+	child.SetParent(root.SpanID)
+	child.SetError(errors.New("timeout"))
+	child.End()
+
+	if err := spanRepo.CreateBatch(ctx, []*domain.Span{root, child}); err != nil {
+		t.Fatalf("CreateBatch failed: %v", err)
+	}
+
+	trace.AddSpan(root)
+	trace.AddSpan(child)
+	trace.Complete()
+	if err := traceRepo.Update(ctx, trace); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	got, err := traceRepo.GetByTraceID(ctx, trace.TraceID)
+	if err != nil {
+		t.Fatalf("GetByTraceID failed: %v", err)
+	}
+	if got.ID != trace.ID {
+		t.Errorf("expected ID %s, got %s", trace.ID, got.ID)
+	}
+	if got.Status != domain.SpanStatusError {
+		t.Errorf("expected error status, got %s", got.Status)
+	}
+	if got.SpanCount != 2 || got.ErrorCount != 1 {
+		t.Errorf("expected 2 spans / 1 error, got %d / %d", got.SpanCount, got.ErrorCount)
+	}
+	if len(got.Spans) != 2 {
+		t.Fatalf("expected 2 loaded spans, got %d", len(got.Spans))
+	}
+	if got.RootSpan == nil || got.RootSpan.SpanID != root.SpanID {
+		t.Error("expected root span to be resolved")
+	}
+
+	span, err := spanRepo.GetBySpanID(ctx, trace.TraceID, child.SpanID)
+	if err != nil {
+		t.Fatalf("GetBySpanID failed: %v", err)
+	}
+	if span.ParentSpanID == nil || *span.ParentSpanID != root.SpanID {
+		t.Error("expected parent span ID to round-trip")
+	}
+	if span.StatusMessage != "timeout" {
+		t.Errorf("expected status message 'timeout', got %q", span.StatusMessage)
+	}
+	if span.Duration != child.Duration {
+		t.Errorf("expected duration %v, got %v", child.Duration, span.Duration)
+	}
+
+	if _, err := traceRepo.GetByTraceID(ctx, domain.NewTraceID()); err == nil {
+		t.Error("expected error for unknown trace")
+	}
+}
+
+func TestTraceRepository_ListAndServiceMap(t *testing.T) {
+	db := setupTestDB(t)
+	traceRepo := NewTraceRepository(db)
+	spanRepo := NewSpanRepository(db)
+	ctx := context.Background()
+
+	for i, service := range []string{"api", "api", "worker"} {
+		trace := domain.NewTrace(service, "op")
+		trace.Duration = time.Duration(i+1) * time.Second
+		if err := traceRepo.Create(ctx, trace); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		root := domain.NewSpan(trace.TraceID, "op", domain.SpanKindServer, service)
+		root.End()
+		child := domain.NewSpan(trace.TraceID, "query", domain.SpanKindClient, "db")
+		child.SetParent(root.SpanID)
+		child.End()
+		if err := spanRepo.CreateBatch(ctx, []*domain.Span{root, child}); err != nil {
+			t.Fatalf("CreateBatch failed: %v", err)
+		}
+	}
+
+	traces, err := traceRepo.List(ctx, ports.TraceFilter{ServiceName: "api"})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(traces) != 2 {
+		t.Errorf("expected 2 api traces, got %d", len(traces))
+	}
+
+	traces, err = traceRepo.List(ctx, ports.TraceFilter{MinDuration: 2 * time.Second, Limit: 10})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(traces) != 2 {
+		t.Errorf("expected 2 traces >= 2s, got %d", len(traces))
+	}
+
+	spans, err := spanRepo.List(ctx, ports.SpanFilter{ServiceName: "db"})
+	if err != nil {
+		t.Fatalf("List spans failed: %v", err)
+	}
+	if len(spans) != 3 {
+		t.Errorf("expected 3 db spans, got %d", len(spans))
+	}
+
+	serviceMap, err := traceRepo.GetServiceMap(ctx, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GetServiceMap failed: %v", err)
+	}
+	if len(serviceMap.Nodes) != 3 {
+		t.Fatalf("expected 3 service nodes, got %d", len(serviceMap.Nodes))
+	}
+	for _, node := range serviceMap.Nodes {
+		switch node.ServiceName {
+		case "api":
+			if node.SpanCount != 2 {
+				t.Errorf("expected 2 api spans, got %d", node.SpanCount)
+			}
+			if len(node.Dependencies) != 1 || node.Dependencies[0] != "db" {
+				t.Errorf("expected api -> db dependency, got %v", node.Dependencies)
+			}
+		case "db":
+			if len(node.Dependencies) != 0 {
+				t.Errorf("expected db to have no dependencies, got %v", node.Dependencies)
+			}
+		}
+	}
+
+	deleted, err := traceRepo.DeleteBefore(ctx, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("DeleteBefore failed: %v", err)
+	}
+	if deleted != 3 {
+		t.Errorf("expected 3 deleted traces, got %d", deleted)
+	}
+	spans, _ = spanRepo.List(ctx, ports.SpanFilter{})
+	if len(spans) != 0 {
+		t.Errorf("expected spans to be deleted with traces, got %d", len(spans))
+	}
+}
+
+func TestTraceRepository_ServiceMapPercentilesAndEdges(t *testing.T) {
+	db := setupTestDB(t)
+	traceRepo := NewTraceRepository(db)
+	spanRepo := NewSpanRepository(db)
+	ctx := context.Background()
+
+	traceID := domain.NewTraceID()
+	start := time.Now().Add(-time.Minute)
+	var spans []*domain.Span
+	// api spans take 1ms..100ms; the first four each call db once.
+	for i := 1; i <= 100; i++ {
+		root := domain.NewSpan(traceID, "request", domain.SpanKindServer, "api")
+		root.StartTime = start
+		root.EndTime = start.Add(time.Duration(i) * time.Millisecond)
+		root.Duration = root.EndTime.Sub(root.StartTime)
+		spans = append(spans, root)
+		if i > 4 {
+			continue
+		}
+		call := domain.NewSpan(traceID, "query", domain.SpanKindClient, "db")
+		call.SetParent(root.SpanID)
+		call.StartTime = start
+		call.EndTime = start.Add(10 * time.Millisecond)
+		call.Duration = 10 * time.Millisecond
+		if i == 1 {
+			call.SetStatus(domain.SpanStatusError, "timeout")
+		}
+		spans = append(spans, call)
+	}
+	if err := spanRepo.CreateBatch(ctx, spans); err != nil {
+		t.Fatalf("CreateBatch failed: %v", err)
+	}
+
+	serviceMap, err := traceRepo.GetServiceMap(ctx, start.Add(-time.Second), time.Now())
+	if err != nil {
+		t.Fatalf("GetServiceMap failed: %v", err)
+	}
+	if len(serviceMap.Nodes) != 2 {
+		t.Fatalf("expected 2 service nodes, got %d", len(serviceMap.Nodes))
+	}
+
+	api := serviceMap.Nodes[0]
+	if api.ServiceName != "api" {
+		t.Fatalf("expected api first, got %s", api.ServiceName)
+	}
+	if api.P50Duration != 50 || api.P95Duration != 95 || api.P99Duration != 99 {
+		t.Errorf("expected api p50/p95/p99 of 50/95/99ms, got %v/%v/%v", api.P50Duration, api.P95Duration, api.P99Duration)
+	}
+	dbNode := serviceMap.Nodes[1]
+	if dbNode.P50Duration != 10 || dbNode.P99Duration != 10 {
+		t.Errorf("expected db percentiles of 10ms, got p50=%v p99=%v", dbNode.P50Duration, dbNode.P99Duration)
+	}
+
+	if len(serviceMap.Edges) != 1 {
+		t.Fatalf("expected 1 edge, got %d", len(serviceMap.Edges))
+	}
+	edge := serviceMap.Edges[0]
+	if edge.Source != "api" || edge.Target != "db" {
+		t.Errorf("expected api -> db edge, got %s -> %s", edge.Source, edge.Target)
+	}
+	if edge.CallCount != 4 || edge.ErrorCount != 1 {
+		t.Errorf("expected 4 calls and 1 error, got %d and %d", edge.CallCount, edge.ErrorCount)
+	}
+	if edge.ErrorRate != 0.25 {
+		t.Errorf("expected error rate 0.25, got %v", edge.ErrorRate)
+	}
+}
+
+func TestTraceRepository_ServiceMapCallLatencyAndPeers(t *testing.T) {
+	db := setupTestDB(t)
+	traceRepo := NewTraceRepository(db)
+	spanRepo := NewSpanRepository(db)
+	ctx := context.Background()
+
+	start := time.Now().Add(-time.Minute)
+	span := func(traceID domain.TraceID, service string, kind domain.SpanKind, d time.Duration, parent *domain.Span) *domain.Span {
+		sp := domain.NewSpan(traceID, "op", kind, service)
+		sp.StartTime, sp.EndTime, sp.Duration = start, start.Add(d), d
+		if parent != nil {
+			sp.SetParent(parent.SpanID)
+		}
+		return sp
+	}
+
+	var spans []*domain.Span
+	// web's client spans call api; the call latency is the client side's,
+	// network time included, and its outcome api's
+	for i := 1; i <= 10; i++ {
+		traceID := domain.NewTraceID()
+		root := span(traceID, "web", domain.SpanKindServer, time.Second, nil)
+		call := span(traceID, "web", domain.SpanKindClient, time.Duration(i*10)*time.Millisecond, root)
+		served := span(traceID, "api", domain.SpanKindServer, time.Duration(i)*time.Millisecond, call)
+		if i == 10 {
+			served.SetStatus(domain.SpanStatusError, "boom")
+		}
+		spans = append(spans, root, call, served)
+
+		// api calls an untraced payment provider, named by peer.service
+		// over server.address, and a cache with only server.address
+		pay := span(traceID, "api", domain.SpanKindClient, 200*time.Millisecond, served)
+		pay.SetAttribute("server.address", "10.0.0.5")
+		pay.SetAttribute("peer.service", "payments")
+		cache := span(traceID, "api", domain.SpanKindClient, time.Millisecond, served)
+		cache.SetAttribute("server.address", "redis")
+		// Calls without a peer attribute have nowhere to point
+		spans = append(spans, pay, cache, span(traceID, "api", domain.SpanKindClient, time.Millisecond, served))
+	}
+	if err := spanRepo.CreateBatch(ctx, spans); err != nil {
+		t.Fatalf("CreateBatch failed: %v", err)
+	}
+
+	serviceMap, err := traceRepo.GetServiceMap(ctx, start.Add(-time.Second), time.Now())
+	if err != nil {
+		t.Fatalf("GetServiceMap failed: %v", err)
+	}
+
+	edges := make(map[string]domain.ServiceMapEdge)
+	for _, e := range serviceMap.Edges {
+		edges[e.Source+"->"+e.Target] = e
+	}
+	if len(edges) != 3 {
+		t.Fatalf("expected web->api, api->payments and api->redis edges, got %+v", serviceMap.Edges)
+	}
+	webAPI := edges["web->api"]
+	if webAPI.CallCount != 10 || webAPI.ErrorCount != 1 {
+		t.Errorf("expected 10 calls and 1 error, got %+v", webAPI)
+	}
+	if webAPI.P50Duration != 50 || webAPI.P95Duration != 100 || webAPI.P99Duration != 100 {
+		t.Errorf("expected client-side p50/p95/p99 of 50/100/100ms, got %v/%v/%v", webAPI.P50Duration, webAPI.P95Duration, webAPI.P99Duration)
+	}
+	if pay := edges["api->payments"]; pay.CallCount != 10 || pay.P99Duration != 200 {
+		t.Errorf("expected 10 calls of 200ms to payments, got %+v", pay)
+	}
+	if edges["api->redis"].CallCount != 10 {
+		t.Errorf("expected 10 calls to redis, got %+v", edges["api->redis"])
+	}
+
+	nodes := make(map[string]domain.ServiceMapNode)
+	for _, n := range serviceMap.Nodes {
+		nodes[n.ServiceName] = n
+	}
+	if len(nodes) != 4 || nodes["web"].External || nodes["api"].External {
+		t.Fatalf("expected web, api and 2 external nodes, got %+v", serviceMap.Nodes)
+	}
+	if pay := nodes["payments"]; !pay.External || pay.SpanCount != 10 || pay.P50Duration != 200 {
+		t.Errorf("expected an external payments node from the calls to it, got %+v", pay)
+	}
+	if deps := nodes["api"].Dependencies; !reflect.DeepEqual(deps, []string{"payments", "redis"}) {
+		t.Errorf("expected api to depend on payments and redis, got %v", deps)
+	}
+}
+
+func TestSpanRepository_CreateBatchModes(t *testing.T) {
+	repo := NewSpanRepository(setupTestDB(t))
+	ctx := context.Background()
+	traceID := domain.NewTraceID()
+
+	batch := func() []*domain.Span {
+		spans := []*domain.Span{
+			domain.NewSpan(traceID, "a", domain.SpanKindServer, "api"),
+			domain.NewSpan(traceID, "b", domain.SpanKindInternal, "api"),
+			domain.NewSpan(traceID, "c", domain.SpanKindInternal, "api"),
+		}
+		spans[1].ID = spans[0].ID
+		return spans
+	}
+
+	if err := repo.CreateBatch(ctx, batch()); err == nil {
+		t.Fatal("expected the duplicate ID to fail the batch")
+	}
+	if spans, _ := repo.ListByTraceID(ctx, traceID); len(spans) != 0 {
+		t.Fatalf("expected the strict batch to be rolled back, got %d spans", len(spans))
+	}
+
+	report, err := repo.CreateBatchBestEffort(ctx, batch())
+	if err != nil {
+		t.Fatalf("CreateBatchBestEffort failed: %v", err)
+	}
+	if report.Written != 2 || len(report.Failed) != 1 || report.Failed[0].Index != 1 {
+		t.Fatalf("expected span 1 to fail and 2 to be written, got %+v", report)
+	}
+	spans, err := repo.ListByTraceID(ctx, traceID)
+	if err != nil {
+		t.Fatalf("ListByTraceID failed: %v", err)
+	}
+	if len(spans) != 2 {
+		t.Errorf("expected 2 stored spans, got %d", len(spans))
+	}
+}
+
+func TestSpanRepository_ListByDurationAndAttributes(t *testing.T) {
+	spanRepo := NewSpanRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	span := func(traceID domain.TraceID, name string, d time.Duration, attrs map[string]string) *domain.Span {
+		s := domain.NewSpan(traceID, name, domain.SpanKindClient, "api")
+		for k, v := range attrs {
+			s.SetAttribute(k, v)
+		}
+		s.EndTime = s.StartTime.Add(d)
+		s.Duration = d
+		return s
+	}
+	first, second := domain.NewTraceID(), domain.NewTraceID()
+	spans := []*domain.Span{
+		span(first, "fast query", 20*time.Millisecond, map[string]string{"db.system": "postgres"}),
+		span(first, "slow query", 800*time.Millisecond, map[string]string{"db.system": "postgres", "db.table": "users"}),
+		span(second, "slow query", 2*time.Second, map[string]string{"db.system": "postgres", "db.table": "orders"}),
+		span(second, "slow call", time.Second, map[string]string{"http.method": "GET", `quoted "key"`: "x"}),
+	}
+	if err := spanRepo.CreateBatch(ctx, spans); err != nil {
+		t.Fatalf("CreateBatch failed: %v", err)
+	}
+
+	names := func(filter ports.SpanFilter) []string {
+		t.Helper()
+		got, err := spanRepo.List(ctx, filter)
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		var result []string
+		for _, s := range got {
+			result = append(result, s.Name+"/"+s.Attributes["db.table"])
+		}
+		return result
+	}
+
+	tests := []struct {
+		name   string
+		filter ports.SpanFilter
+		want   []string
+	}{
+		{"slow database spans across traces", ports.SpanFilter{
+			MinDuration: 500 * time.Millisecond,
+			Attributes:  map[string]string{"db.system": "postgres"},
+		}, []string{"slow query/users", "slow query/orders"}},
+		{"duration range is inclusive", ports.SpanFilter{
+			MinDuration: 800 * time.Millisecond,
+			MaxDuration: time.Second,
+		}, []string{"slow query/users", "slow call/"}},
+		{"every attribute must match", ports.SpanFilter{
+			Attributes: map[string]string{"db.system": "postgres", "db.table": "orders"},
+		}, []string{"slow query/orders"}},
+		{"keys are matched literally", ports.SpanFilter{
+			Attributes: map[string]string{`quoted "key"`: "x"},
+		}, []string{"slow call/"}},
+		{"attribute value mismatch", ports.SpanFilter{
+			Attributes: map[string]string{"db.system": "mysql"},
+		}, nil},
+	}
+	for _, tt := range tests {
+		got := names(tt.filter)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestTraceRepository_Count(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewTraceRepository(db)
+	ctx := context.Background()
+
+	base := time.Now().Add(-time.Hour)
+	for i, d := range []time.Duration{100, 200, 1500, 3000} {
+		trace := domain.NewTrace("api", "GET /")
+		trace.StartTime = base.Add(time.Duration(i) * time.Minute)
+		trace.Duration = d * time.Millisecond
+		if i%2 == 1 {
+			trace.Status = domain.SpanStatusError
+		}
+		if err := repo.Create(ctx, trace); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter ports.TraceFilter
+		want   int64
+	}{
+		{"all", ports.TraceFilter{Limit: 1}, 4},
+		{"errors", ports.TraceFilter{Status: "error"}, 2},
+		{"slow", ports.TraceFilter{MinDuration: time.Second}, 2},
+		{"slow errors", ports.TraceFilter{Status: "error", MinDuration: time.Second}, 1},
+		{"window", ports.TraceFilter{StartTime: base.Add(90 * time.Second)}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.Count(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("Count failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %d traces, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestTraceRepository_ListBySpanAttributes(t *testing.T) {
+	db := setupTestDB(t)
+	traceRepo := NewTraceRepository(db)
+	spanRepo := NewSpanRepository(db)
+	ctx := context.Background()
+
+	// seed stores a trace of d whose only child span carries attrs
+	seed := func(name string, d time.Duration, attrs map[string]string) *domain.Trace {
+		t.Helper()
+		trace := domain.NewTrace("checkout", name)
+		if err := traceRepo.Create(ctx, trace); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		root := domain.NewSpan(trace.TraceID, name, domain.SpanKindServer, "checkout")
+		root.EndTime = root.StartTime.Add(d)
+		root.Duration = d
+		child := domain.NewSpan(trace.TraceID, "query", domain.SpanKindClient, "checkout")
+		child.SetParent(root.SpanID)
+		for k, v := range attrs {
+			child.SetAttribute(k, v)
+		}
+		child.End()
+		if err := spanRepo.CreateBatch(ctx, []*domain.Span{root, child}); err != nil {
+			t.Fatalf("CreateBatch failed: %v", err)
+		}
+		trace.AddSpan(root)
+		trace.AddSpan(child)
+		trace.Complete()
+		trace.Duration = d
+		if err := traceRepo.Update(ctx, trace); err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+		return trace
+	}
+	seed("slow checkout", 3*time.Second, map[string]string{"http.route": "/checkout", "user.id": "42"})
+	seed("fast checkout", 200*time.Millisecond, map[string]string{"http.route": "/checkout"})
+	seed("slow cart", 4*time.Second, map[string]string{"http.route": "/cart"})
+	anonymous := seed("anonymous", time.Second, nil)
+
+	names := func(filter ports.TraceFilter) []string {
+		t.Helper()
+		traces, err := traceRepo.List(ctx, filter)
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		var result []string
+		for _, tr := range traces {
+			result = append(result, tr.Name)
+		}
+		sort.Strings(result)
+		return result
+	}
+
+	tests := []struct {
+		name   string
+		filter ports.TraceFilter
+		want   []string
+	}{
+		{"slow checkouts", ports.TraceFilter{
+			MinDuration: 2 * time.Second,
+			Attributes:  map[string]string{"http.route": "/checkout"},
+		}, []string{"slow checkout"}},
+		{"any route under a bound", ports.TraceFilter{
+			MaxDuration:   time.Second,
+			HasAttributes: []string{"http.route"},
+		}, []string{"fast checkout"}},
+		{"attribute exists", ports.TraceFilter{HasAttributes: []string{"user.id"}}, []string{"slow checkout"}},
+		{"every matcher applies", ports.TraceFilter{
+			Attributes:    map[string]string{"http.route": "/cart"},
+			HasAttributes: []string{"user.id"},
+		}, nil},
+		{"no matchers", ports.TraceFilter{MinDuration: time.Second}, []string{"anonymous", "slow cart", "slow checkout"}},
+	}
+	for _, tt := range tests {
+		if got := names(tt.filter); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+
+	// The matchers are answered from the attribute index, not by scanning spans
+	rows, err := db.conn.QueryContext(ctx, `EXPLAIN QUERY PLAN
+		SELECT trace_id FROM traces
+		WHERE trace_id IN (SELECT trace_id FROM span_attributes WHERE key = ? AND value = ?)`, "http.route", "/checkout")
+	if err != nil {
+		t.Fatalf("EXPLAIN failed: %v", err)
+	}
+	var plan []string
+	for rows.Next() {
+		var id, parent, notused int
+		var detail string
+		if err := rows.Scan(&id, &parent, &notused, &detail); err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		plan = append(plan, detail)
+	}
+	rows.Close()
+	if joined := strings.Join(plan, "\n"); !strings.Contains(joined, "idx_span_attributes_kv") || strings.Contains(joined, "SCAN span_attributes") {
+		t.Errorf("expected the attribute index to be used, got plan:\n%s", joined)
+	}
+
+	// Deleted spans leave nothing behind in the index
+	if _, err := spanRepo.DeleteByTraceID(ctx, anonymous.TraceID); err != nil {
+		t.Fatalf("DeleteByTraceID failed: %v", err)
+	}
+	if _, err := traceRepo.DeleteBefore(ctx, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("DeleteBefore failed: %v", err)
+	}
+	var left int
+	if err := db.conn.QueryRow("SELECT COUNT(*) FROM span_attributes").Scan(&left); err != nil || left != 0 {
+		t.Errorf("expected the attribute index to be emptied, got %d rows (%v)", left, err)
+	}
+}
+
+func TestDB_IndexesExistingSpanAttributes(t *testing.T) {
+	if !setupTestDB(t).Features().JSON1 {
+		t.Skip("json1 is not available")
+	}
+	dir := t.TempDir()
+	db, err := New(DefaultConfig(dir))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	span := domain.NewSpan(domain.NewTraceID(), "query", domain.SpanKindClient, "api")
+	span.SetAttribute("db.system", "postgres")
+	span.End()
+	if err := NewSpanRepository(db).Create(context.Background(), span); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	// Simulate a database written before spans were indexed
+	if _, err := db.conn.Exec("DELETE FROM span_attributes"); err != nil {
+		t.Fatalf("DELETE failed: %v", err)
+	}
+	if _, err := db.conn.Exec("DELETE FROM schema_migrations WHERE name = 'index_span_attributes'"); err != nil {
+		t.Fatalf("DELETE failed: %v", err)
+	}
+	db.Close()
+
+	db, err = New(DefaultConfig(dir))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	var key, value string
+	if err := db.conn.QueryRow("SELECT key, value FROM span_attributes WHERE span_id = ?", span.SpanID.String()).Scan(&key, &value); err != nil {
+		t.Fatalf("expected the span's attributes to be indexed on open: %v", err)
+	}
+	if key != "db.system" || value != "postgres" {
+		t.Errorf("expected db.system=postgres, got %s=%s", key, value)
+	}
+
+	// The backfill runs once, not on every open
+	if _, err := db.conn.Exec("DELETE FROM span_attributes"); err != nil {
+		t.Fatalf("DELETE failed: %v", err)
+	}
+	db.Close()
+	db, err = New(DefaultConfig(dir))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer db.Close()
+	var indexed int
+	if err := db.conn.QueryRow("SELECT COUNT(*) FROM span_attributes").Scan(&indexed); err != nil || indexed != 0 {
+		t.Errorf("expected the backfill not to run again, got %d rows (%v)", indexed, err)
+	}
+}
@@ -0,0 +1,269 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+	"github.com/google/uuid"
+)
+
+// UserRepository implements ports.UserRepository using SQLite.
+type UserRepository struct {
+	db *DB
+}
+
+// NewUserRepository creates a new user repository.
+func NewUserRepository(db *DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+const userColumns = `id, username, email, password_hash, role, status, display_name, metadata,
+	last_login_at, failed_logins, locked_until, mfa_enabled, mfa_secret, mfa_last_step, grants, created_at, updated_at`
+
+// Create persists a new user.
+func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
+	metadataJSON, err := json.Marshal(user.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal user metadata: %w", err)
+	}
+	grantsJSON, err := json.Marshal(user.Grants)
+	if err != nil {
+		return fmt.Errorf("failed to marshal user grants: %w", err)
+	}
+	idBytes, _ := user.ID.MarshalBinary()
+
+	query := `
+		INSERT INTO users (` + userColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err = r.db.conn.ExecContext(ctx, query,
+		idBytes,
+		user.Username,
+		user.Email,
+		user.PasswordHash,
+		string(user.Role),
+		string(user.Status),
+		user.DisplayName,
+		metadataJSON,
+		nullableMillis(user.LastLoginAt),
+		user.FailedLogins,
+		nullableMillis(user.LockedUntil),
+		user.MFAEnabled,
+		user.MFASecret,
+		user.MFALastStep,
+		grantsJSON,
+		user.CreatedAt.UnixMilli(),
+		user.UpdatedAt.UnixMilli(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert user: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a user by their ID.
+func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	idBytes, _ := id.MarshalBinary()
+	row := r.db.conn.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE id = ?", idBytes)
+	return scanUser(row)
+}
+
+// GetByUsername retrieves a user by username.
+func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*domain.User, error) {
+	row := r.db.conn.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE username = ?", username)
+	return scanUser(row)
+}
+
+// GetByEmail retrieves a user by email.
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	row := r.db.conn.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE email = ?", email)
+	return scanUser(row)
+}
+
+// Update updates an existing user.
+func (r *UserRepository) Update(ctx context.Context, user *domain.User) error {
+	metadataJSON, err := json.Marshal(user.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal user metadata: %w", err)
+	}
+	grantsJSON, err := json.Marshal(user.Grants)
+	if err != nil {
+		return fmt.Errorf("failed to marshal user grants: %w", err)
+	}
+	idBytes, _ := user.ID.MarshalBinary()
+
+	query := `
+		UPDATE users SET
+			username = ?, email = ?, password_hash = ?, role = ?, status = ?, display_name = ?,
+			metadata = ?, last_login_at = ?, failed_logins = ?, locked_until = ?,
+			mfa_enabled = ?, mfa_secret = ?, mfa_last_step = ?, grants = ?, updated_at = ?
+		WHERE id = ?
+	`
+	result, err := r.db.conn.ExecContext(ctx, query,
+		user.Username,
+		user.Email,
+		user.PasswordHash,
+		string(user.Role),
+		string(user.Status),
+		user.DisplayName,
+		metadataJSON,
+		nullableMillis(user.LastLoginAt),
+		user.FailedLogins,
+		nullableMillis(user.LockedUntil),
+		user.MFAEnabled,
+		user.MFASecret,
+		user.MFALastStep,
+		grantsJSON,
+		user.UpdatedAt.UnixMilli(),
+		idBytes,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("user not found")
+	}
+	return nil
+}
+
+// Delete removes a user.
+func (r *UserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	idBytes, _ := id.MarshalBinary()
+	result, err := r.db.conn.ExecContext(ctx, "DELETE FROM users WHERE id = ?", idBytes)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("user not found")
+	}
+	return nil
+}
+
+// List retrieves users matching the filter, ordered by username.
+func (r *UserRepository) List(ctx context.Context, filter ports.UserFilter) ([]*domain.User, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	if filter.Username != "" {
+		conditions = append(conditions, "username = ?")
+		args = append(args, filter.Username)
+	}
+	if filter.Email != "" {
+		conditions = append(conditions, "email = ?")
+		args = append(args, filter.Email)
+	}
+	if filter.Role != "" {
+		conditions = append(conditions, "role = ?")
+		args = append(args, string(filter.Role))
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, string(filter.Status))
+	}
+
+	query := "SELECT " + userColumns + " FROM users"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY username" + limitOffset(filter.Limit, filter.Offset)
+
+	rows, err := r.db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []*domain.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// Count returns the total number of users.
+func (r *UserRepository) Count(ctx context.Context) (int64, error) {
+	var count int64
+	if err := r.db.conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM users").Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count users: %w", err)
+	}
+	return count, nil
+}
+
+func scanUser(row rowScanner) (*domain.User, error) {
+	var (
+		idBytes      []byte
+		role         string
+		status       string
+		displayName  sql.NullString
+		metadataJSON sql.NullString
+		lastLoginAt  sql.NullInt64
+		lockedUntil  sql.NullInt64
+		mfaSecret    sql.NullString
+		grantsJSON   sql.NullString
+		createdAt    int64
+		updatedAt    int64
+		user         domain.User
+	)
+
+	err := row.Scan(&idBytes, &user.Username, &user.Email, &user.PasswordHash, &role, &status,
+		&displayName, &metadataJSON, &lastLoginAt, &user.FailedLogins, &lockedUntil,
+		&user.MFAEnabled, &mfaSecret, &user.MFALastStep, &grantsJSON, &createdAt, &updatedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("user not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan user: %w", err)
+	}
+
+	user.ID, _ = uuid.FromBytes(idBytes)
+	user.Role = domain.UserRole(role)
+	user.Status = domain.UserStatus(status)
+	user.DisplayName = displayName.String
+	user.MFASecret = mfaSecret.String
+	user.LastLoginAt = millisTime(lastLoginAt)
+	user.LockedUntil = millisTime(lockedUntil)
+	user.CreatedAt = time.UnixMilli(createdAt)
+	user.UpdatedAt = time.UnixMilli(updatedAt)
+
+	user.Metadata = make(map[string]string)
+	if metadataJSON.Valid && metadataJSON.String != "" && metadataJSON.String != "null" {
+		_ = json.Unmarshal([]byte(metadataJSON.String), &user.Metadata)
+	}
+	if grantsJSON.Valid && grantsJSON.String != "" && grantsJSON.String != "null" {
+		_ = json.Unmarshal([]byte(grantsJSON.String), &user.Grants)
+	}
+
+	return &user, nil
+}
+
+// nullableMillis converts an optional time to milliseconds, or nil if unset.
+func nullableMillis(t *time.Time) *int64 {
+	if t == nil {
+		return nil
+	}
+	v := t.UnixMilli()
+	return &v
+}
+
+// millisTime converts a nullable millisecond column to an optional time.
+func millisTime(v sql.NullInt64) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	t := time.UnixMilli(v.Int64)
+	return &t
+}
+
+// Ensure UserRepository implements the interface
+var _ ports.UserRepository = (*UserRepository)(nil)
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+)
+
+func TestUserRepository_CRUD(t *testing.T) {
+	repo := NewUserRepository(setupTestDB(t))
+	ctx := context.Background()
+
+	user, err := domain.NewUser("alice", "alice@example.com", "secret123", domain.RoleOperator)
+	if err != nil {
+		t.Fatalf("NewUser failed: %v", err)
+	}
+	user.Metadata["team"] = "sre"
+	if err := repo.Create(ctx, user); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	dup, _ := domain.NewUser("alice", "other@example.com", "secret123", domain.RoleViewer)
+	if err := repo.Create(ctx, dup); err == nil {
+		t.Error("expected duplicate username to be rejected")
+	}
+
+	got, err := repo.GetByUsername(ctx, "alice")
+	if err != nil {
+		t.Fatalf("GetByUsername failed: %v", err)
+	}
+	if got.ID != user.ID || got.Role != domain.RoleOperator || got.Metadata["team"] != "sre" || !got.CheckPassword("secret123") {
+		t.Errorf("unexpected user: %+v", got)
+	}
+	if got.LastLoginAt != nil || got.LockedUntil != nil {
+		t.Errorf("expected unset times to stay nil, got %v %v", got.LastLoginAt, got.LockedUntil)
+	}
+
+	now := time.Now()
+	got.LastLoginAt = &now
+	got.Role = domain.RoleAdmin
+	got.Grants = []string{"workflows:write:1234"}
+	if err := repo.Update(ctx, got); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if got, err = repo.GetByEmail(ctx, "alice@example.com"); err != nil {
+		t.Fatalf("GetByEmail failed: %v", err)
+	}
+	if got.Role != domain.RoleAdmin || got.LastLoginAt == nil || got.LastLoginAt.UnixMilli() != now.UnixMilli() ||
+		len(got.Grants) != 1 || got.Grants[0] != "workflows:write:1234" {
+		t.Errorf("expected updated user, got %+v", got)
+	}
+
+	bob, _ := domain.NewUser("bob", "bob@example.com", "secret123", domain.RoleViewer)
+	if err := repo.Create(ctx, bob); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if n, _ := repo.Count(ctx); n != 2 {
+		t.Errorf("expected 2 users, got %d", n)
+	}
+	viewers, err := repo.List(ctx, ports.UserFilter{Role: domain.RoleViewer})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(viewers) != 1 || viewers[0].Username != "bob" {
+		t.Errorf("expected only bob as viewer, got %d users", len(viewers))
+	}
+
+	if err := repo.Delete(ctx, bob.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := repo.GetByID(ctx, bob.ID); err == nil {
+		t.Error("expected deleted user to be gone")
+	}
+	if err := repo.Delete(ctx, bob.ID); err == nil {
+		t.Error("expected error deleting a missing user")
+	}
+}
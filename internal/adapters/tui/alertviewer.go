@@ -69,6 +69,7 @@ func NewAlertViewer() *AlertViewer {
 		{Title: "Rule", Width: 25},
 		{Title: "Value", Width: 12},
 		{Title: "Started", Width: 18},
+		{Title: "Silenced By", Width: 24},
 	}
 
 	t := table.New(
@@ -201,6 +202,7 @@ func (m *AlertViewer) updateTableRows() {
 			a.RuleName,
 			fmt.Sprintf("%.2f", a.Value),
 			a.StartsAt.Format("2006-01-02 15:04"),
+			a.SilencedBy,
 		}
 	}
 	m.table.SetRows(rows)
@@ -168,6 +168,9 @@ func NewDashboardModel() *DashboardModel {
 	}
 }
 
+// daemonReconnectTimeout bounds a reconnection attempt to the daemon.
+const daemonReconnectTimeout = 5 * time.Second
+
 // tickMsg is sent periodically to update the dashboard.
 type tickMsg time.Time
 
@@ -204,6 +207,7 @@ func (m *DashboardModel) connectToDaemon() tea.Cmd {
 		if err != nil {
 			return daemonStatusMsg{connected: false}
 		}
+		client.SetAPIKey(apiKey)
 
 		if err := client.Connect(); err != nil {
 			return daemonStatusMsg{connected: false}
@@ -223,6 +227,9 @@ func (m *DashboardModel) connectToDaemon() tea.Cmd {
 		}
 
 		// Store client for later use
+		if m.client != nil {
+			m.client.Close()
+		}
 		m.client = client
 		return msg
 	}
@@ -235,9 +242,17 @@ func (m *DashboardModel) fetchMetrics() tea.Cmd {
 			return daemonStatusMsg{connected: false}
 		}
 
-		// Get stats
+		// Get stats, reconnecting once if the daemon dropped the connection
 		ctx := context.Background()
 		stats, err := m.client.GetMetricStats(ctx)
+		if err != nil {
+			reconnectCtx, cancel := context.WithTimeout(ctx, daemonReconnectTimeout)
+			err = m.client.Reconnect(reconnectCtx)
+			cancel()
+			if err == nil {
+				stats, err = m.client.GetMetricStats(ctx)
+			}
+		}
 		if err != nil {
 			return daemonStatusMsg{connected: false}
 		}
@@ -306,9 +321,13 @@ func (m *DashboardModel) Update(msg tea.Msg) (*DashboardModel, tea.Cmd) {
 			return tickMsg(t)
 		}))
 
-		// Fetch stats periodically if connected
-		if m.connected && time.Now().Second()%5 == 0 {
-			cmds = append(cmds, m.fetchMetrics())
+		// Fetch stats periodically if connected, otherwise try to reconnect
+		if time.Now().Second()%5 == 0 {
+			if m.connected {
+				cmds = append(cmds, m.fetchMetrics())
+			} else {
+				cmds = append(cmds, m.connectToDaemon())
+			}
 		}
 
 		// Fetch metric values every second when connected
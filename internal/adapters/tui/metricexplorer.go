@@ -0,0 +1,183 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// metricExplorerSeriesLimit caps how many series the explorer asks for.
+// The daemon serves this from its recent series cache.
+const metricExplorerSeriesLimit = 200
+
+// SeriesItem represents a metric series in the explorer list.
+type SeriesItem struct {
+	Name     string
+	Tags     map[string]string
+	Points   int64
+	LastTime time.Time
+}
+
+func (s SeriesItem) Title() string {
+	if len(s.Tags) == 0 {
+		return s.Name
+	}
+	keys := make([]string, 0, len(s.Tags))
+	for k := range s.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + s.Tags[k]
+	}
+	return fmt.Sprintf("%s{%s}", s.Name, strings.Join(pairs, ","))
+}
+
+func (s SeriesItem) Description() string {
+	return fmt.Sprintf("Points: %d | Last: %s", s.Points, s.LastTime.Format("2006-01-02 15:04:05"))
+}
+
+func (s SeriesItem) FilterValue() string {
+	return s.Title()
+}
+
+// MetricExplorerModel lists the most recently written metric series.
+type MetricExplorerModel struct {
+	list   list.Model
+	series []SeriesItem
+	err    error
+	width  int
+	height int
+	keys   metricExplorerKeyMap
+}
+
+type metricExplorerKeyMap struct {
+	Refresh key.Binding
+}
+
+// seriesLoadedMsg carries series fetched from the daemon.
+type seriesLoadedMsg struct {
+	series []SeriesItem
+	err    error
+}
+
+// NewMetricExplorerModel creates a new metric explorer.
+func NewMetricExplorerModel() *MetricExplorerModel {
+	delegate := list.NewDefaultDelegate()
+	delegate.Styles.SelectedTitle = delegate.Styles.SelectedTitle.
+		Foreground(primaryColor).BorderForeground(primaryColor)
+	delegate.Styles.SelectedDesc = delegate.Styles.SelectedDesc.
+		Foreground(mutedColor).BorderForeground(primaryColor)
+
+	l := list.New([]list.Item{}, delegate, 80, 20)
+	l.Title = "📈 Metrics Explorer"
+	l.SetShowStatusBar(true)
+	l.SetFilteringEnabled(true)
+	l.Styles.Title = titleStyle
+
+	return &MetricExplorerModel{
+		list: l,
+		keys: metricExplorerKeyMap{
+			Refresh: key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "refresh")),
+		},
+	}
+}
+
+// Init loads the initial series list.
+func (m *MetricExplorerModel) Init() tea.Cmd {
+	return m.loadSeries
+}
+
+// Update handles metric explorer updates.
+func (m *MetricExplorerModel) Update(msg tea.Msg) (*MetricExplorerModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.list.SetWidth(msg.Width - 4)
+		m.list.SetHeight(msg.Height - 8)
+
+	case seriesLoadedMsg:
+		m.err = msg.err
+		if msg.err == nil {
+			m.series = msg.series
+			items := make([]list.Item, len(msg.series))
+			for i, s := range msg.series {
+				items[i] = s
+			}
+			m.list.SetItems(items)
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.list.FilterState() != list.Filtering && key.Matches(msg, m.keys.Refresh) {
+			return m, m.loadSeries
+		}
+	}
+
+	var cmd tea.Cmd
+	m.list, cmd = m.list.Update(msg)
+	return m, cmd
+}
+
+// View renders the metric explorer.
+func (m *MetricExplorerModel) View(width, height int) string {
+	if m.width == 0 {
+		m.width = width
+		m.height = height
+		m.list.SetWidth(width - 4)
+		m.list.SetHeight(height - 8)
+	}
+
+	status := subtitleStyle.Render(fmt.Sprintf("Showing up to %d most recently written series", metricExplorerSeriesLimit))
+	if m.err != nil {
+		status = statusErrorStyle.Render("Series: " + m.err.Error())
+	}
+	helpBar := subtitleStyle.Render("[r] refresh | [/] filter")
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		m.list.View(),
+		status,
+		helpBar,
+	)
+}
+
+// loadSeries fetches recent series from the daemon.
+func (m *MetricExplorerModel) loadSeries() tea.Msg {
+	client, err := newTUIDaemonClient()
+	if err != nil {
+		return seriesLoadedMsg{err: err}
+	}
+	defer client.Close()
+
+	infos, err := client.ListRecentSeries(context.Background(), metricExplorerSeriesLimit)
+	if err != nil {
+		return seriesLoadedMsg{err: err}
+	}
+
+	series := make([]SeriesItem, 0, len(infos))
+	for _, info := range infos {
+		item := SeriesItem{Name: getString(info, "name"), Tags: make(map[string]string)}
+		if tags, ok := info["tags"].(map[string]interface{}); ok {
+			for k, v := range tags {
+				item.Tags[k] = fmt.Sprintf("%v", v)
+			}
+		}
+		if points, ok := info["points"].(float64); ok {
+			item.Points = int64(points)
+		}
+		if t, err := time.Parse(time.RFC3339, getString(info, "last_time")); err == nil {
+			item.LastTime = t
+		}
+		series = append(series, item)
+	}
+	return seriesLoadedMsg{series: series}
+}
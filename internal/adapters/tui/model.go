@@ -2,6 +2,9 @@
 package tui
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
@@ -26,6 +29,28 @@ func (t Tab) String() string {
 	return []string{"Dashboard", "Tasks", "Workflows", "Alerts", "Metrics", "Plugins", "Logs", "AI"}[t]
 }
 
+// allTabs lists the tabs in display order.
+var allTabs = []Tab{TabDashboard, TabTasks, TabWorkflows, TabAlerts, TabMetrics, TabPlugins, TabLogs, TabAI}
+
+// TabNames returns the lowercase tab names accepted by ParseTab.
+func TabNames() []string {
+	names := make([]string, len(allTabs))
+	for i, tab := range allTabs {
+		names[i] = strings.ToLower(tab.String())
+	}
+	return names
+}
+
+// ParseTab returns the tab with the given name, ignoring case.
+func ParseTab(name string) (Tab, error) {
+	for _, tab := range allTabs {
+		if strings.EqualFold(name, tab.String()) {
+			return tab, nil
+		}
+	}
+	return TabDashboard, fmt.Errorf("unknown tab %q (valid: %s)", name, strings.Join(TabNames(), ", "))
+}
+
 // Model represents the main TUI state.
 type Model struct {
 	activeTab       Tab
@@ -40,6 +65,7 @@ type Model struct {
 	alertViewer     *AlertViewer
 	logViewer       *LogViewerModel
 	pluginManager   *PluginManagerModel
+	metricExplorer  *MetricExplorerModel
 	initialized     bool
 }
 
@@ -111,7 +137,7 @@ var defaultKeyMap = keyMap{
 func NewModel() Model {
 	return Model{
 		activeTab:       TabDashboard,
-		tabs:            []Tab{TabDashboard, TabTasks, TabWorkflows, TabAlerts, TabMetrics, TabPlugins, TabLogs, TabAI},
+		tabs:            allTabs,
 		help:            help.New(),
 		keys:            defaultKeyMap,
 		dashboard:       NewDashboardModel(),
@@ -120,9 +146,21 @@ func NewModel() Model {
 		alertViewer:     NewAlertViewer(),
 		logViewer:       NewLogViewerModel(),
 		pluginManager:   NewPluginManagerModel(),
+		metricExplorer:  NewMetricExplorerModel(),
 	}
 }
 
+// WithTab returns the model with the given tab selected.
+func (m Model) WithTab(tab Tab) Model {
+	m.activeTab = tab
+	return m
+}
+
+// ActiveTab returns the selected tab.
+func (m Model) ActiveTab() Tab {
+	return m.activeTab
+}
+
 // Init implements tea.Model.
 func (m Model) Init() tea.Cmd {
 	return tea.Batch(
@@ -133,6 +171,7 @@ func (m Model) Init() tea.Cmd {
 		m.alertViewer.Init(),
 		m.logViewer.Init(),
 		m.pluginManager.Init(),
+		m.metricExplorer.Init(),
 	)
 }
 
@@ -173,6 +212,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.logViewer, cmd = m.logViewer.Update(msg)
 	case TabPlugins:
 		m.pluginManager, cmd = m.pluginManager.Update(msg)
+	case TabMetrics:
+		m.metricExplorer, cmd = m.metricExplorer.Update(msg)
 	}
 
 	return m, cmd
@@ -202,7 +243,7 @@ func (m Model) View() string {
 		m.alertViewer.SetSize(m.width, contentHeight)
 		content = m.alertViewer.View()
 	case TabMetrics:
-		content = m.renderMetricsTab()
+		content = m.metricExplorer.View(m.width, contentHeight)
 	case TabPlugins:
 		content = m.pluginManager.View(m.width, contentHeight)
 	case TabLogs:
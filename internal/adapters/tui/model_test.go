@@ -0,0 +1,54 @@
+package tui
+
+import "testing"
+
+func TestParseTab(t *testing.T) {
+	tests := []struct {
+		name string
+		want Tab
+	}{
+		{"dashboard", TabDashboard},
+		{"Plugins", TabPlugins},
+		{"ALERTS", TabAlerts},
+		{"ai", TabAI},
+	}
+	for _, tt := range tests {
+		got, err := ParseTab(tt.name)
+		if err != nil {
+			t.Errorf("ParseTab(%q) failed: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseTab(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := ParseTab("settings"); err == nil {
+		t.Error("expected an error for an unknown tab")
+	}
+	if _, err := ParseTab(""); err == nil {
+		t.Error("expected an error for an empty tab name")
+	}
+}
+
+func TestTabNames(t *testing.T) {
+	names := TabNames()
+	if len(names) != 8 || names[0] != "dashboard" || names[7] != "ai" {
+		t.Errorf("unexpected tab names %v", names)
+	}
+	for _, name := range names {
+		if _, err := ParseTab(name); err != nil {
+			t.Errorf("TabNames returned unparseable %q", name)
+		}
+	}
+}
+
+func TestModel_WithTab(t *testing.T) {
+	m := NewModel()
+	if m.ActiveTab() != TabDashboard {
+		t.Errorf("expected dashboard by default, got %v", m.ActiveTab())
+	}
+	if got := m.WithTab(TabAlerts).ActiveTab(); got != TabAlerts {
+		t.Errorf("expected alerts tab, got %v", got)
+	}
+}
@@ -22,25 +22,8 @@ func (m Model) renderTabs() string {
 	return tabBarStyle.Width(m.width).Render(tabRow)
 }
 
-// Note: Task, Plugin, and Log tabs are now rendered by their respective models
-// (TaskManagerModel, PluginManagerModel, LogViewerModel)
-
-// renderMetricsTab renders the metrics tab content.
-func (m Model) renderMetricsTab() string {
-	header := titleStyle.Render("📈 Metrics Explorer")
-
-	content := lipgloss.JoinVertical(lipgloss.Left,
-		header,
-		"",
-		subtitleStyle.Render("Query metrics from the time-series database"),
-		"",
-		"Series: (daemon not connected)",
-		"",
-		"Use arrow keys to navigate, Enter to select",
-	)
-
-	return boxStyle.Width(m.width - 4).Render(content)
-}
+// Note: Task, Plugin, Log, and Metrics tabs are now rendered by their respective models
+// (TaskManagerModel, PluginManagerModel, LogViewerModel, MetricExplorerModel)
 
 // renderAITab renders the AI chat tab content.
 func (m Model) renderAITab() string {
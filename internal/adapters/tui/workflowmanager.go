@@ -16,14 +16,27 @@ import (
 	"github.com/google/uuid"
 )
 
+// apiKey authenticates the TUI's daemon connections; see SetAPIKey.
+var apiKey string
+
+// SetAPIKey sets the API key the TUI presents to the daemon. Once users
+// exist, the daemon refuses unauthenticated requests.
+func SetAPIKey(key string) {
+	apiKey = key
+}
+
 // newTUIDaemonClient creates a new daemon client for TUI operations.
 func newTUIDaemonClient() (*daemon.Client, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return nil, err
 	}
-	socketPath := filepath.Join(home, ".forge", "forge.sock")
-	return daemon.NewClient(socketPath)
+	client, err := daemon.NewClient(filepath.Join(home, ".forge"))
+	if err != nil {
+		return nil, err
+	}
+	client.SetAPIKey(apiKey)
+	return client, nil
 }
 
 // getString safely extracts a string from a map.
@@ -0,0 +1,125 @@
+package wasm
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/tetratelabs/wazero/api"
+)
+
+// PluginMemoryAllocator hands out scratch buffers for host function
+// responses to plugins that do not export malloc.
+//
+// ABI contract: host functions that return data (forge_get_config,
+// forge_http_request, forge_read_file) write it into memory allocated with
+// the plugin's exported malloc(size i32) -> i32 when there is one. Without
+// malloc the runtime grows the plugin's linear memory by whole pages and
+// returns a pointer into the new pages instead. Such a buffer stays valid
+// until the call into the plugin that received it returns, or until the
+// plugin passes its pointer to forge_free, after which the runtime reuses
+// it for later responses; plugins must copy out anything they keep longer.
+// Because the runtime owns the pages it grew, plugins relying on the
+// fallback must not grow linear memory themselves; allocators that do
+// should export malloc.
+type PluginMemoryAllocator struct {
+	mu      sync.Mutex
+	buffers map[string][]*scratchBuffer // Scratch buffers per module name
+}
+
+// scratchBuffer is a region of plugin memory the runtime grew for
+// responses.
+type scratchBuffer struct {
+	offset uint32
+	size   uint32 // Capacity in bytes, a whole number of pages
+	inUse  bool
+}
+
+// NewPluginMemoryAllocator creates an allocator with no buffers.
+func NewPluginMemoryAllocator() *PluginMemoryAllocator {
+	return &PluginMemoryAllocator{buffers: make(map[string][]*scratchBuffer)}
+}
+
+// Allocate returns the offset of a free scratch buffer of at least size
+// bytes in m's memory, reusing a released buffer if one is large enough and
+// growing the memory otherwise.
+func (a *PluginMemoryAllocator) Allocate(m api.Module, size uint32) (uint32, error) {
+	mem := m.Memory()
+	if mem == nil {
+		return 0, fmt.Errorf("plugin does not export memory")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// Best fit among the released buffers
+	name := m.Name()
+	var best *scratchBuffer
+	for _, buf := range a.buffers[name] {
+		if !buf.inUse && buf.size >= size && (best == nil || buf.size < best.size) {
+			best = buf
+		}
+	}
+	if best != nil {
+		best.inUse = true
+		return best.offset, nil
+	}
+
+	pages := (uint64(size) + wasmPageSize - 1) / wasmPageSize
+	previous, ok := mem.Grow(uint32(pages))
+	if !ok {
+		return 0, fmt.Errorf("cannot grow plugin memory by %d pages", pages)
+	}
+	buf := &scratchBuffer{
+		offset: previous * wasmPageSize,
+		size:   uint32(pages * wasmPageSize),
+		inUse:  true,
+	}
+	a.buffers[name] = append(a.buffers[name], buf)
+	return buf.offset, nil
+}
+
+// Release makes the buffer at offset in the named module reusable. It
+// reports whether offset was a scratch buffer.
+func (a *PluginMemoryAllocator) Release(moduleName string, offset uint32) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, buf := range a.buffers[moduleName] {
+		if buf.offset == offset {
+			buf.inUse = false
+			return true
+		}
+	}
+	return false
+}
+
+// ReleaseAll makes every buffer of the named module reusable, once the call
+// into the module that received them has returned.
+func (a *PluginMemoryAllocator) ReleaseAll(moduleName string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, buf := range a.buffers[moduleName] {
+		buf.inUse = false
+	}
+}
+
+// Forget drops the buffers of a closed module.
+func (a *PluginMemoryAllocator) Forget(moduleName string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.buffers, moduleName)
+}
+
+// Outstanding returns the offsets of the named module's buffers in use.
+func (a *PluginMemoryAllocator) Outstanding(moduleName string) []uint32 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var offsets []uint32
+	for _, buf := range a.buffers[moduleName] {
+		if buf.inUse {
+			offsets = append(offsets, buf.offset)
+		}
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+	return offsets
+}
@@ -0,0 +1,78 @@
+package wasm
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRuntime_FallbackAllocation(t *testing.T) {
+	r := newLimitedRuntime(t, RuntimeOptions{MaxMemoryPages: 4})
+	// limitsModule exports memory but no malloc
+	plugin, err := loadModule(t, r, "no-malloc", limitsModule())
+	if err != nil {
+		t.Fatalf("LoadPlugin failed: %v", err)
+	}
+	loaded := r.modules[plugin.ID.String()]
+	module := loaded.Module
+	ctx := context.Background()
+
+	r.SetConfig("greeting", "hello from forge")
+	if !module.Memory().Write(0, []byte("greeting")) {
+		t.Fatal("failed to write key")
+	}
+	ptr, length := r.hostGetConfig(ctx, module, 0, 8)
+	if ptr == 0 || length == 0 {
+		t.Fatal("expected the config value to be written without malloc")
+	}
+	if ptr < wasmPageSize {
+		t.Errorf("expected the response in grown memory, got offset %d", ptr)
+	}
+	if data, ok := module.Memory().Read(ptr, length); !ok || string(data) != "hello from forge" {
+		t.Fatalf("expected the plugin to read the value, got %q", data)
+	}
+
+	// Outstanding buffers are not handed out twice
+	other, _ := r.writeToPluginMemory(module, []byte("second"))
+	if other == 0 || other == ptr {
+		t.Fatalf("expected a second buffer, got %d (first %d)", other, ptr)
+	}
+	if got := r.allocator.Outstanding(module.Name()); len(got) != 2 {
+		t.Errorf("expected 2 outstanding buffers, got %v", got)
+	}
+
+	// forge_free releases a buffer for reuse
+	r.hostFree(ctx, module, ptr)
+	if again, _ := r.writeToPluginMemory(module, []byte("third")); again != ptr {
+		t.Errorf("expected the freed buffer to be reused, got %d want %d", again, ptr)
+	}
+	if data, _ := module.Memory().Read(ptr, 5); string(data) != "third" {
+		t.Errorf("expected reused buffer to hold the new data, got %q", data)
+	}
+
+	// Buffers are released once the call into the plugin returns
+	if _, err := r.CallFunction(ctx, plugin.ID.String(), "ping"); err != nil {
+		t.Fatalf("CallFunction failed: %v", err)
+	}
+	if got := r.allocator.Outstanding(module.Name()); len(got) != 0 {
+		t.Errorf("expected buffers released after the call, got %v", got)
+	}
+	pages := module.Memory().Size() / wasmPageSize
+	if reused, _ := r.writeToPluginMemory(module, []byte("after call")); reused != ptr && reused != other {
+		t.Errorf("expected a released buffer to be reused, got %d", reused)
+	}
+	if grown := module.Memory().Size() / wasmPageSize; grown != pages {
+		t.Errorf("expected no growth when reusing buffers, memory went from %d to %d pages", pages, grown)
+	}
+
+	// Responses that would exceed the memory limit are refused
+	if p, n := r.writeToPluginMemory(module, make([]byte, 2*wasmPageSize)); p != 0 || n != 0 {
+		t.Errorf("expected allocation beyond the memory limit to fail, got %d/%d", p, n)
+	}
+
+	if err := r.UnloadPlugin(ctx, plugin.ID.String()); err != nil {
+		t.Fatalf("UnloadPlugin failed: %v", err)
+	}
+	if got := r.allocator.Outstanding(module.Name()); len(got) != 0 {
+		t.Errorf("expected buffers dropped on unload, got %v", got)
+	}
+}
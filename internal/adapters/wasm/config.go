@@ -0,0 +1,162 @@
+package wasm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+)
+
+// Configuration exports, both optional: forge_manifest() -> i64 returns the
+// plugin's serialized manifest packed as ptr<<32 | len, and
+// on_configure(ptr, len) -> i32 receives the plugin configuration as a JSON
+// object once it has been validated against the manifest's config schema.
+const (
+	pluginManifestExport  = "forge_manifest"
+	pluginConfigureExport = "on_configure"
+)
+
+// pluginManifest is the part of the SDK manifest read by the runtime.
+type pluginManifest struct {
+	ConfigSchema string                    `json:"config_schema"`
+	Permissions  []domain.PluginPermission `json:"permissions"`
+	AllowedHosts []string                  `json:"allowed_hosts"`
+}
+
+// readManifest returns a plugin's manifest, or nil if it exports none.
+// loaded.callMu must be held.
+func (r *Runtime) readManifest(ctx context.Context, loaded *LoadedPlugin) (*pluginManifest, error) {
+	if loaded.Exports[pluginManifestExport] == nil {
+		return nil, nil
+	}
+	results, err := r.invoke(ctx, loaded, pluginManifestExport)
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w", pluginManifestExport, err)
+	}
+	if len(results) == 0 || results[0] == 0 {
+		return nil, nil
+	}
+	ptr, length := uint32(results[0]>>32), uint32(results[0])
+	data, ok := loaded.Module.Memory().Read(ptr, length)
+	if !ok {
+		return nil, fmt.Errorf("%s returned an out of range manifest", pluginManifestExport)
+	}
+
+	var manifest pluginManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	return &manifest, nil
+}
+
+// loadConfig takes a newly instantiated plugin's schema from its manifest
+// and applies the configuration it was loaded with. loaded.callMu must be
+// held.
+func (r *Runtime) loadConfig(ctx context.Context, loaded *LoadedPlugin, manifest *pluginManifest) error {
+	loaded.configSchema = nil
+	if manifest != nil && manifest.ConfigSchema != "" {
+		schema, err := domain.ParsePluginConfigSchema(manifest.ConfigSchema)
+		if err != nil {
+			return err
+		}
+		loaded.configSchema = schema
+	}
+	return r.applyConfig(ctx, loaded, loaded.Plugin.Config)
+}
+
+// applyConfig validates config against the plugin's schema and hands it to
+// the plugin's on_configure export. loaded.callMu must be held.
+func (r *Runtime) applyConfig(ctx context.Context, loaded *LoadedPlugin, config map[string]string) error {
+	var typed map[string]interface{}
+	if loaded.configSchema != nil {
+		validated, err := loaded.configSchema.Validate(config)
+		if err != nil {
+			return err
+		}
+		typed = validated
+	} else {
+		typed = make(map[string]interface{}, len(config))
+		for k, v := range config {
+			typed[k] = v
+		}
+	}
+	if loaded.Exports[pluginConfigureExport] == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(typed)
+	if err != nil {
+		return fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	ptr, length := r.writeToPluginMemory(loaded.Module, data)
+	if length == 0 {
+		return fmt.Errorf("failed to copy configuration into plugin memory")
+	}
+	results, err := r.invoke(ctx, loaded, pluginConfigureExport, uint64(ptr), uint64(length))
+	if err != nil {
+		return fmt.Errorf("%s failed: %w", pluginConfigureExport, err)
+	}
+	if len(results) > 0 {
+		if code := int32(results[0]); code != 0 {
+			return fmt.Errorf("%s returned error code %d", pluginConfigureExport, code)
+		}
+	}
+	return nil
+}
+
+// ConfigurePlugin replaces a loaded plugin's configuration. The
+// configuration is validated against the schema in the plugin's manifest
+// and passed to its on_configure export; if either rejects it, the plugin
+// keeps its previous configuration and a *domain.PluginConfigError or the
+// plugin's error is returned.
+func (r *Runtime) ConfigurePlugin(ctx context.Context, pluginID string, config map[string]string) error {
+	r.lifecycleMu.Lock()
+	defer r.lifecycleMu.Unlock()
+
+	r.mu.RLock()
+	loaded, ok := r.modules[pluginID]
+	r.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("plugin not loaded: %s", pluginID)
+	}
+
+	loaded.callMu.Lock()
+	err := r.applyConfig(ctx, loaded, config)
+	loaded.callMu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to configure plugin %s: %w", loaded.Plugin.Name, err)
+	}
+
+	r.mu.Lock()
+	loaded.Plugin.Config = copyConfig(config)
+	loaded.Plugin.UpdatedAt = time.Now()
+	r.mu.Unlock()
+	r.setPluginConfig(pluginID, config)
+	r.logger.Info("Plugin configured", "name", loaded.Plugin.Name, "keys", len(config))
+	return nil
+}
+
+// setPluginConfig makes a plugin's configuration visible to forge_get_config.
+func (r *Runtime) setPluginConfig(pluginID string, config map[string]string) {
+	r.configMu.Lock()
+	defer r.configMu.Unlock()
+	if len(config) == 0 {
+		delete(r.pluginConfigs, pluginID)
+		return
+	}
+	r.pluginConfigs[pluginID] = copyConfig(config)
+}
+
+// pluginConfigValue returns a configuration value for a plugin, preferring
+// its own configuration over the runtime-wide one.
+func (r *Runtime) pluginConfigValue(pluginID, key string) (string, bool) {
+	r.configMu.RLock()
+	defer r.configMu.RUnlock()
+	if val, ok := r.pluginConfigs[pluginID][key]; ok {
+		return val, true
+	}
+	val, ok := r.config[key]
+	return val, ok
+}
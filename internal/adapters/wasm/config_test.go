@@ -0,0 +1,169 @@
+package wasm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+)
+
+// uleb128 encodes n as an unsigned LEB128 integer.
+func uleb128(n uint64) []byte {
+	var out []byte
+	for {
+		b := byte(n & 0x7f)
+		n >>= 7
+		if n == 0 {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+// sleb128 encodes a non-negative n as a signed LEB128 integer.
+func sleb128(n uint64) []byte {
+	var out []byte
+	for {
+		b := byte(n & 0x7f)
+		n >>= 7
+		if n == 0 && b&0x40 == 0 {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+// wasmLongSection is wasmSection for content of any length.
+func wasmLongSection(id byte, content []byte) []byte {
+	return append(append([]byte{id}, uleb128(uint64(len(content)))...), content...)
+}
+
+// configurableModule returns a guest whose forge_manifest export returns
+// manifest, stored at offset 0, and whose on_configure(ptr, len) export logs
+// the configuration it receives at info level through forge_log.
+func configurableModule(manifest []byte) []byte {
+	module := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	// Types: 0 = () -> i64, 1 = (i32, i32) -> i32, 2 = (i32, i32, i32) -> ()
+	module = append(module, wasmSection(1,
+		0x03,
+		0x60, 0x00, 0x01, 0x7e,
+		0x60, 0x02, 0x7f, 0x7f, 0x01, 0x7f,
+		0x60, 0x03, 0x7f, 0x7f, 0x7f, 0x00)...)
+	imp := []byte{0x01, 0x05}
+	imp = append(imp, "forge"...)
+	imp = append(imp, 0x09)
+	imp = append(imp, "forge_log"...)
+	imp = append(imp, 0x00, 0x02)
+	module = append(module, wasmSection(2, imp...)...)
+	// Functions: 1 = forge_manifest, 2 = on_configure
+	module = append(module, wasmSection(3, 0x02, 0x00, 0x01)...)
+	module = append(module, wasmSection(5, 0x01, 0x00, 0x01)...)
+	exp := []byte{0x03, 0x06}
+	exp = append(exp, "memory"...)
+	exp = append(exp, 0x02, 0x00, 0x0e)
+	exp = append(exp, "forge_manifest"...)
+	exp = append(exp, 0x00, 0x01, 0x0c)
+	exp = append(exp, "on_configure"...)
+	exp = append(exp, 0x00, 0x02)
+	module = append(module, wasmSection(7, exp...)...)
+
+	// forge_manifest: i64.const len (the pointer is 0)
+	manifestBody := append([]byte{0x00, 0x42}, sleb128(uint64(len(manifest)))...)
+	manifestBody = append(manifestBody, 0x0b)
+	// on_configure: call forge_log(1, ptr, len); i32.const 0
+	configureBody := []byte{0x00, 0x41, 0x01, 0x20, 0x00, 0x20, 0x01, 0x10, 0x00, 0x41, 0x00, 0x0b}
+	code := []byte{0x02}
+	code = append(code, uleb128(uint64(len(manifestBody)))...)
+	code = append(code, manifestBody...)
+	code = append(code, uleb128(uint64(len(configureBody)))...)
+	code = append(code, configureBody...)
+	module = append(module, wasmLongSection(10, code)...)
+
+	data := []byte{0x01, 0x00, 0x41, 0x00, 0x0b}
+	data = append(data, uleb128(uint64(len(manifest)))...)
+	data = append(data, manifest...)
+	return append(module, wasmLongSection(11, data)...)
+}
+
+func testManifest(t *testing.T) []byte {
+	t.Helper()
+	manifest, err := json.Marshal(map[string]string{
+		"name":    "configurable",
+		"version": "1.0.0",
+		"config_schema": `{
+			"type": "object",
+			"properties": {
+				"interval": {"type": "integer", "minimum": 1},
+				"collect_cpu": {"type": "boolean"}
+			},
+			"required": ["interval"]
+		}`,
+	})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	return manifest
+}
+
+func TestRuntime_ConfigSchema(t *testing.T) {
+	ctx := context.Background()
+	logger := &capturingLogger{}
+	r, err := NewRuntimeWithOptions(ctx, logger, RuntimeOptions{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewRuntimeWithOptions failed: %v", err)
+	}
+	t.Cleanup(func() { r.Close() })
+	module := configurableModule(testManifest(t))
+
+	// A plugin whose configuration violates its schema is not loaded
+	path := filepath.Join(t.TempDir(), "configurable.wasm")
+	if err := os.WriteFile(path, module, 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	invalid := domain.NewPlugin("configurable", "1.0.0", path)
+	invalid.Config["interval"] = "0"
+	err = r.LoadPlugin(ctx, invalid)
+	var configErr *domain.PluginConfigError
+	if !errors.As(err, &configErr) || !strings.Contains(err.Error(), "interval must be >= 1") {
+		t.Fatalf("expected the config to be rejected by the schema, got %v", err)
+	}
+	if len(r.ListLoadedPlugins()) != 0 {
+		t.Fatal("expected the plugin not to be loaded")
+	}
+
+	plugin := domain.NewPlugin("configurable", "1.0.0", path)
+	plugin.Config["interval"] = "30"
+	if err := r.LoadPlugin(ctx, plugin); err != nil {
+		t.Fatalf("LoadPlugin failed: %v", err)
+	}
+	if logs := logger.String(); !strings.Contains(logs, `{"interval":30}`) {
+		t.Errorf("expected on_configure to receive typed values, got:\n%s", logs)
+	}
+	id := plugin.ID.String()
+
+	err = r.ConfigurePlugin(ctx, id, map[string]string{"interval": "soon", "collect_cpu": "yes"})
+	if !errors.As(err, &configErr) || len(configErr.Problems) != 2 {
+		t.Fatalf("expected both problems to be reported, got %v", err)
+	}
+	if current, _ := r.GetPlugin(id); current.Config["interval"] != "30" {
+		t.Errorf("expected the previous configuration to be kept, got %v", current.Config)
+	}
+
+	if err := r.ConfigurePlugin(ctx, id, map[string]string{"interval": "60", "collect_cpu": "false"}); err != nil {
+		t.Fatalf("ConfigurePlugin failed: %v", err)
+	}
+	if logs := logger.String(); !strings.Contains(logs, `{"collect_cpu":false,"interval":60}`) {
+		t.Errorf("expected on_configure to receive the new configuration, got:\n%s", logs)
+	}
+	if current, _ := r.GetPlugin(id); current.Config["interval"] != "60" {
+		t.Errorf("expected the configuration to be replaced, got %v", current.Config)
+	}
+	if value, ok := r.pluginConfigValue(id, "interval"); !ok || value != "60" {
+		t.Errorf("expected forge_get_config to see the new interval, got %q", value)
+	}
+}
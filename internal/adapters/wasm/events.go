@@ -0,0 +1,317 @@
+package wasm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tetratelabs/wazero/api"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+)
+
+// eventHandlerExport is the guest function that receives subscribed events:
+// on_event(type_ptr, type_len, payload_ptr, payload_len i32) -> i32.
+// A non-zero result is reported as a delivery failure.
+const eventHandlerExport = "on_event"
+
+// ErrEventBusFull is returned when an event cannot be queued.
+var ErrEventBusFull = errors.New("event bus full")
+
+// EventOverflowPolicy decides what happens to an event emitted while the
+// event bus is full.
+type EventOverflowPolicy string
+
+const (
+	// EventOverflowDropNewest rejects the new event (the default).
+	EventOverflowDropNewest EventOverflowPolicy = "drop-newest"
+	// EventOverflowDropOldest evicts the oldest queued event to make room.
+	EventOverflowDropOldest EventOverflowPolicy = "drop-oldest"
+	// EventOverflowBlock waits up to the block timeout for room, then
+	// rejects the event.
+	EventOverflowBlock EventOverflowPolicy = "block"
+	// EventOverflowBackpressure holds an emitting plugin until there is room
+	// for as long as its call may run, slowing it to the pace events are
+	// delivered. A plugin that runs out of time is interrupted and the call
+	// counts as a limit violation. Host events wait up to the block timeout.
+	EventOverflowBackpressure EventOverflowPolicy = "backpressure"
+)
+
+// DefaultEventBlockTimeout bounds how long an emitter waits for room on a
+// full event bus under the block and backpressure policies.
+const DefaultEventBlockTimeout = time.Second
+
+// metricEventsDropped counts events lost because the event bus was full.
+const metricEventsDropped = "forge.plugin.events.dropped"
+
+// ParseEventOverflowPolicy parses an overflow policy name. An empty name
+// selects EventOverflowDropNewest.
+func ParseEventOverflowPolicy(name string) (EventOverflowPolicy, error) {
+	switch policy := EventOverflowPolicy(name); policy {
+	case "":
+		return EventOverflowDropNewest, nil
+	case EventOverflowDropNewest, EventOverflowDropOldest, EventOverflowBlock, EventOverflowBackpressure:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown event overflow policy %q (want drop-newest, drop-oldest, block or backpressure)", name)
+	}
+}
+
+// ValidateEventPattern checks a subscription pattern. Patterns are an exact
+// event type ("alert.fired"), a prefix wildcard ("metric.*") or "*" for
+// every event.
+func ValidateEventPattern(pattern string) error {
+	if pattern == "" {
+		return fmt.Errorf("event pattern is empty")
+	}
+	if pattern == "*" {
+		return nil
+	}
+	body := strings.TrimSuffix(pattern, ".*")
+	if body == "" || strings.Contains(body, "*") {
+		return fmt.Errorf("invalid event pattern %q: wildcard must be a trailing '.*'", pattern)
+	}
+	return nil
+}
+
+// MatchEventPattern reports whether eventType matches a subscription pattern.
+// "metric.*" matches "metric.recorded" and "metric.cpu.high" but not "metric".
+func MatchEventPattern(pattern, eventType string) bool {
+	switch {
+	case pattern == "*":
+		return true
+	case strings.HasSuffix(pattern, ".*"):
+		return strings.HasPrefix(eventType, strings.TrimSuffix(pattern, "*"))
+	default:
+		return pattern == eventType
+	}
+}
+
+// Host function: forge_subscribe(type_ptr, type_len i32) -> err_code i32
+func (r *Runtime) hostSubscribe(ctx context.Context, m api.Module, typePtr, typeLen uint32) int32 {
+	data, ok := m.Memory().Read(typePtr, typeLen)
+	if !ok {
+		return -1
+	}
+	if err := r.subscribe(pluginIDOf(m), string(data)); err != nil {
+		r.logger.Warn("Rejected event subscription", "plugin", pluginIDOf(m), "error", err)
+		return -2
+	}
+	return 0
+}
+
+// subscribe registers an event pattern for a plugin. Duplicate patterns are ignored.
+func (r *Runtime) subscribe(pluginID, pattern string) error {
+	if err := ValidateEventPattern(pattern); err != nil {
+		return err
+	}
+
+	r.subMu.Lock()
+	defer r.subMu.Unlock()
+	for _, existing := range r.subscriptions[pluginID] {
+		if existing == pattern {
+			return nil
+		}
+	}
+	r.subscriptions[pluginID] = append(r.subscriptions[pluginID], pattern)
+	r.logger.Debug("Plugin subscribed to events", "plugin", pluginID, "pattern", pattern)
+	return nil
+}
+
+// Subscriptions returns the event patterns a plugin has subscribed to.
+func (r *Runtime) Subscriptions(pluginID string) []string {
+	r.subMu.RLock()
+	defer r.subMu.RUnlock()
+	return append([]string(nil), r.subscriptions[pluginID]...)
+}
+
+// PublishEvent injects a host-originated event (for example "alert.fired")
+// into the plugin event bus. Host events have an empty PluginID and are
+// delivered to every matching subscriber.
+func (r *Runtime) PublishEvent(eventType string, payload []byte) error {
+	if eventType == "" {
+		return fmt.Errorf("event type is required")
+	}
+
+	return r.queueEvent(context.Background(), PluginEvent{EventType: eventType, Payload: append([]byte(nil), payload...)})
+}
+
+// queueEvent puts an event on the bus, applying the overflow policy when the
+// bus is full. ctx is the emitting plugin's call, which bounds how long
+// backpressure may hold it.
+func (r *Runtime) queueEvent(ctx context.Context, event PluginEvent) error {
+	r.busMu.RLock()
+	defer r.busMu.RUnlock()
+	if r.busClosed {
+		return fmt.Errorf("runtime is closed")
+	}
+
+	select {
+	case r.eventBus <- event:
+		return nil
+	default:
+	}
+
+	switch r.eventOverflow {
+	case EventOverflowDropOldest:
+		for {
+			select {
+			case evicted := <-r.eventBus:
+				r.eventDropped(evicted)
+			default:
+			}
+			select {
+			case r.eventBus <- event:
+				return nil
+			default:
+			}
+		}
+	case EventOverflowBlock, EventOverflowBackpressure:
+		// Backpressure holds a plugin for as long as its call may run;
+		// everything else waits for the block timeout
+		_, bounded := ctx.Deadline()
+		var timeout <-chan time.Time
+		if r.eventOverflow == EventOverflowBlock || event.PluginID == "" || !bounded {
+			timer := time.NewTimer(r.eventBlockTimeout)
+			defer timer.Stop()
+			timeout = timer.C
+		}
+		select {
+		case r.eventBus <- event:
+			return nil
+		case <-timeout:
+		case <-ctx.Done():
+		}
+	}
+	r.eventDropped(event)
+	return ErrEventBusFull
+}
+
+// eventDropped counts an event lost to a full bus.
+func (r *Runtime) eventDropped(event PluginEvent) {
+	r.droppedEvents.Add(1)
+	r.logger.Warn("Event bus full, dropping event",
+		"type", event.EventType, "plugin", event.PluginID, "policy", r.eventOverflow)
+	if r.metricSvc == nil {
+		return
+	}
+	tags := map[string]string{"policy": string(r.eventOverflow)}
+	if err := r.metricSvc.Record(context.Background(), metricEventsDropped, domain.MetricTypeCounter, 1, tags); err != nil {
+		r.logger.Debug("Failed to record plugin metric", "name", metricEventsDropped, "error", err)
+	}
+}
+
+// DroppedEvents returns the number of events lost because the event bus
+// was full since the runtime started.
+func (r *Runtime) DroppedEvents() uint64 {
+	return r.droppedEvents.Load()
+}
+
+// EventOverflowPolicy returns the policy applied when the event bus is full.
+func (r *Runtime) EventOverflowPolicy() EventOverflowPolicy {
+	return r.eventOverflow
+}
+
+// StartEventDispatcher starts delivering events from the bus to subscribed
+// plugins until ctx is cancelled or the runtime is closed. Events consumed
+// by the dispatcher are no longer visible on Events(). Calling it more than
+// once has no effect.
+func (r *Runtime) StartEventDispatcher(ctx context.Context) {
+	r.dispatchOnce.Do(func() {
+		go r.dispatchEvents(ctx)
+	})
+}
+
+func (r *Runtime) dispatchEvents(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-r.eventBus:
+			if !ok {
+				return
+			}
+			r.deliverEvent(ctx, event)
+		}
+	}
+}
+
+// deliverEvent calls on_event on every plugin subscribed to the event,
+// except the plugin that emitted it. It returns the number of plugins that
+// handled the event successfully.
+func (r *Runtime) deliverEvent(ctx context.Context, event PluginEvent) int {
+	delivered := 0
+	for _, loaded := range r.eventTargets(event) {
+		if err := r.callEventHandler(ctx, loaded, event); err != nil {
+			r.logger.Warn("Failed to deliver event",
+				"plugin", loaded.Plugin.Name, "type", event.EventType, "error", err)
+			continue
+		}
+		delivered++
+	}
+	return delivered
+}
+
+// eventTargets returns the loaded plugins that should receive event.
+func (r *Runtime) eventTargets(event PluginEvent) []*LoadedPlugin {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	r.subMu.RLock()
+	defer r.subMu.RUnlock()
+
+	var targets []*LoadedPlugin
+	for pluginID, patterns := range r.subscriptions {
+		// Never echo an event back to its emitter
+		if pluginID == event.PluginID {
+			continue
+		}
+		loaded, ok := r.modules[pluginID]
+		if !ok || loaded.Exports[eventHandlerExport] == nil {
+			continue
+		}
+		for _, pattern := range patterns {
+			if MatchEventPattern(pattern, event.EventType) {
+				targets = append(targets, loaded)
+				break
+			}
+		}
+	}
+
+	sort.Slice(targets, func(i, j int) bool {
+		return targets[i].Plugin.Name < targets[j].Plugin.Name
+	})
+	return targets
+}
+
+// callEventHandler copies the event into plugin memory and invokes on_event.
+func (r *Runtime) callEventHandler(ctx context.Context, loaded *LoadedPlugin, event PluginEvent) error {
+	loaded.callMu.Lock()
+	defer loaded.callMu.Unlock()
+	if loaded.disabled {
+		return fmt.Errorf("plugin %s is disabled", loaded.Plugin.Name)
+	}
+
+	typePtr, typeLen := r.writeToPluginMemory(loaded.Module, []byte(event.EventType))
+	if typeLen == 0 {
+		return fmt.Errorf("failed to copy event type into plugin memory")
+	}
+	payloadPtr, payloadLen := r.writeToPluginMemory(loaded.Module, event.Payload)
+	if len(event.Payload) > 0 && payloadLen == 0 {
+		return fmt.Errorf("failed to copy event payload into plugin memory")
+	}
+
+	results, err := r.invoke(ctx, loaded, eventHandlerExport,
+		uint64(typePtr), uint64(typeLen), uint64(payloadPtr), uint64(payloadLen))
+	if err != nil {
+		return fmt.Errorf("on_event failed: %w", err)
+	}
+	if len(results) > 0 {
+		if code := int32(results[0]); code != 0 {
+			return fmt.Errorf("on_event returned error code %d", code)
+		}
+	}
+	return nil
+}
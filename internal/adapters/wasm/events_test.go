@@ -0,0 +1,432 @@
+package wasm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/services"
+)
+
+func TestMatchEventPattern(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		eventType string
+		want      bool
+	}{
+		{"*", "alert.fired", true},
+		{"alert.fired", "alert.fired", true},
+		{"alert.fired", "alert.resolved", false},
+		{"metric.*", "metric.recorded", true},
+		{"metric.*", "metric.cpu.high", true},
+		{"metric.*", "metric", false},
+		{"metric.*", "metrics.recorded", false},
+	}
+	for _, tt := range tests {
+		if got := MatchEventPattern(tt.pattern, tt.eventType); got != tt.want {
+			t.Errorf("MatchEventPattern(%q, %q) = %v, want %v", tt.pattern, tt.eventType, got, tt.want)
+		}
+	}
+}
+
+func TestValidateEventPattern(t *testing.T) {
+	for _, pattern := range []string{"*", "alert.fired", "metric.*", "a.b.*"} {
+		if err := ValidateEventPattern(pattern); err != nil {
+			t.Errorf("expected %q to be valid: %v", pattern, err)
+		}
+	}
+	for _, pattern := range []string{"", ".*", "metric*", "*.fired", "a.*.b"} {
+		if err := ValidateEventPattern(pattern); err == nil {
+			t.Errorf("expected %q to be rejected", pattern)
+		}
+	}
+}
+
+// Guest memory layout used by subscriberModule's on_event.
+const (
+	eventCountAddr   = 16
+	eventTypeLenAddr = 20
+	eventPayloadAddr = 24
+	eventPayloadLen  = 28
+	eventTypeAddr    = 32
+)
+
+// subscriberModule returns a guest that exports a bump-allocating malloc,
+// subscribe(ptr, len) and emit(type_ptr, type_len, payload_ptr, payload_len)
+// forwarding to the host, and an on_event handler that counts deliveries and
+// records the last event's pointers at fixed addresses.
+func subscriberModule() []byte {
+	module := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	// Types: 0 = (i32, i32) -> i32, 1 = (i32, i32, i32, i32) -> i32, 2 = (i32) -> i32
+	module = append(module, wasmSection(1,
+		0x03,
+		0x60, 0x02, 0x7f, 0x7f, 0x01, 0x7f,
+		0x60, 0x04, 0x7f, 0x7f, 0x7f, 0x7f, 0x01, 0x7f,
+		0x60, 0x01, 0x7f, 0x01, 0x7f)...)
+	imp := []byte{0x02, 0x05}
+	imp = append(imp, "forge"...)
+	imp = append(imp, 0x0f)
+	imp = append(imp, "forge_subscribe"...)
+	imp = append(imp, 0x00, 0x00, 0x05)
+	imp = append(imp, "forge"...)
+	imp = append(imp, 0x10)
+	imp = append(imp, "forge_emit_event"...)
+	imp = append(imp, 0x00, 0x01)
+	module = append(module, wasmSection(2, imp...)...)
+	// Functions: 2 = malloc, 3 = subscribe, 4 = emit, 5 = on_event
+	module = append(module, wasmSection(3, 0x04, 0x02, 0x00, 0x01, 0x01)...)
+	module = append(module, wasmSection(5, 0x01, 0x00, 0x01)...)
+	// Mutable heap pointer starting at 1024
+	module = append(module, wasmSection(6, 0x01, 0x7f, 0x01, 0x41, 0x80, 0x08, 0x0b)...)
+	exp := []byte{0x05, 0x06}
+	exp = append(exp, "memory"...)
+	exp = append(exp, 0x02, 0x00, 0x06)
+	exp = append(exp, "malloc"...)
+	exp = append(exp, 0x00, 0x02, 0x09)
+	exp = append(exp, "subscribe"...)
+	exp = append(exp, 0x00, 0x03, 0x04)
+	exp = append(exp, "emit"...)
+	exp = append(exp, 0x00, 0x04, 0x08)
+	exp = append(exp, "on_event"...)
+	exp = append(exp, 0x00, 0x05)
+	module = append(module, wasmSection(7, exp...)...)
+	module = append(module, wasmSection(10,
+		0x04,
+		// malloc: return heap; heap += size
+		0x0b, 0x00, 0x23, 0x00, 0x23, 0x00, 0x20, 0x00, 0x6a, 0x24, 0x00, 0x0b,
+		// subscribe
+		0x08, 0x00, 0x20, 0x00, 0x20, 0x01, 0x10, 0x00, 0x0b,
+		// emit
+		0x0c, 0x00, 0x20, 0x00, 0x20, 0x01, 0x20, 0x02, 0x20, 0x03, 0x10, 0x01, 0x0b,
+		// on_event: count++, then store type_len, payload_ptr, payload_len, type_ptr
+		0x2d, 0x00,
+		0x41, eventCountAddr, 0x41, eventCountAddr, 0x28, 0x02, 0x00, 0x41, 0x01, 0x6a, 0x36, 0x02, 0x00,
+		0x41, eventTypeLenAddr, 0x20, 0x01, 0x36, 0x02, 0x00,
+		0x41, eventPayloadAddr, 0x20, 0x02, 0x36, 0x02, 0x00,
+		0x41, eventPayloadLen, 0x20, 0x03, 0x36, 0x02, 0x00,
+		0x41, eventTypeAddr, 0x20, 0x00, 0x36, 0x02, 0x00,
+		0x41, 0x00, 0x0b)...)
+	return module
+}
+
+// loadSubscriber loads a subscriberModule plugin and subscribes it to patterns
+// through the guest's forge_subscribe import.
+func loadSubscriber(t *testing.T, r *Runtime, name string, patterns ...string) *LoadedPlugin {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name+".wasm")
+	if err := os.WriteFile(path, subscriberModule(), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	plugin := domain.NewPlugin(name, "1.0.0", path)
+	if err := r.LoadPlugin(context.Background(), plugin); err != nil {
+		t.Fatalf("LoadPlugin failed: %v", err)
+	}
+
+	loaded := r.modules[plugin.ID.String()]
+	for _, pattern := range patterns {
+		if code := callGuest(t, loaded, "subscribe", []byte(pattern)); code != 0 {
+			t.Fatalf("subscribe(%q) returned %d", pattern, code)
+		}
+	}
+	return loaded
+}
+
+// callGuest copies each argument into guest memory and calls fn with
+// (ptr, len) pairs, returning its i32 result.
+func callGuest(t *testing.T, loaded *LoadedPlugin, fn string, args ...[]byte) int32 {
+	t.Helper()
+	params := make([]uint64, 0, 2*len(args))
+	for i, arg := range args {
+		ptr := uint32(256 + 128*i)
+		if !loaded.Module.Memory().Write(ptr, arg) {
+			t.Fatalf("failed to write argument %d", i)
+		}
+		params = append(params, uint64(ptr), uint64(len(arg)))
+	}
+	results, err := loaded.Exports[fn].Call(context.Background(), params...)
+	if err != nil {
+		t.Fatalf("%s failed: %v", fn, err)
+	}
+	return int32(results[0])
+}
+
+func deliveredCount(loaded *LoadedPlugin) uint32 {
+	count, _ := loaded.Module.Memory().ReadUint32Le(eventCountAddr)
+	return count
+}
+
+// lastEvent reads the type and payload of the last event delivered to a subscriber.
+func lastEvent(t *testing.T, loaded *LoadedPlugin) (string, string) {
+	t.Helper()
+	mem := loaded.Module.Memory()
+	typePtr, _ := mem.ReadUint32Le(eventTypeAddr)
+	typeLen, _ := mem.ReadUint32Le(eventTypeLenAddr)
+	payloadPtr, _ := mem.ReadUint32Le(eventPayloadAddr)
+	payloadLen, _ := mem.ReadUint32Le(eventPayloadLen)
+	eventType, _ := mem.Read(typePtr, typeLen)
+	payload, _ := mem.Read(payloadPtr, payloadLen)
+	return string(eventType), string(payload)
+}
+
+func TestRuntime_EventSubscriptions(t *testing.T) {
+	r := newTestRuntime(t, nil)
+	emitter := loadSubscriber(t, r, "emitter", "metric.*")
+	listener := loadSubscriber(t, r, "listener", "metric.*", "metric.*")
+	other := loadSubscriber(t, r, "other", "alert.fired")
+
+	if subs := r.Subscriptions(listener.Plugin.ID.String()); len(subs) != 1 {
+		t.Errorf("expected duplicate subscription to be ignored, got %v", subs)
+	}
+	if code := callGuest(t, other, "subscribe", []byte("bad*pattern")); code != -2 {
+		t.Errorf("expected invalid pattern to be rejected with -2, got %d", code)
+	}
+
+	// A plugin-emitted event reaches other subscribers but never its emitter
+	if code := callGuest(t, emitter, "emit", []byte("metric.cpu.high"), []byte(`{"value":97}`)); code != 0 {
+		t.Fatalf("emit returned %d", code)
+	}
+	event := <-r.eventBus
+	if event.PluginID != emitter.Plugin.ID.String() {
+		t.Errorf("expected event to carry emitter ID, got %q", event.PluginID)
+	}
+	if delivered := r.deliverEvent(context.Background(), event); delivered != 1 {
+		t.Errorf("expected 1 delivery, got %d", delivered)
+	}
+	if deliveredCount(emitter) != 0 {
+		t.Error("emitter received its own event")
+	}
+	if deliveredCount(other) != 0 {
+		t.Error("non-matching subscriber received the event")
+	}
+	if deliveredCount(listener) != 1 {
+		t.Fatalf("expected listener to receive 1 event, got %d", deliveredCount(listener))
+	}
+	if eventType, payload := lastEvent(t, listener); eventType != "metric.cpu.high" || payload != `{"value":97}` {
+		t.Errorf("unexpected event delivered: %q %q", eventType, payload)
+	}
+
+	// Unloading drops the plugin's subscriptions
+	if err := r.UnloadPlugin(context.Background(), listener.Plugin.ID.String()); err != nil {
+		t.Fatalf("UnloadPlugin failed: %v", err)
+	}
+	if subs := r.Subscriptions(listener.Plugin.ID.String()); len(subs) != 0 {
+		t.Errorf("expected subscriptions to be removed on unload, got %v", subs)
+	}
+}
+
+func TestRuntime_PublishEventDispatch(t *testing.T) {
+	r := newTestRuntime(t, nil)
+	listener := loadSubscriber(t, r, "listener", "alert.*")
+	wildcard := loadSubscriber(t, r, "wildcard", "*")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r.StartEventDispatcher(ctx)
+
+	if err := r.PublishEvent("alert.fired", []byte(`{"rule":"cpu"}`)); err != nil {
+		t.Fatalf("PublishEvent failed: %v", err)
+	}
+	if err := r.PublishEvent("", nil); err == nil {
+		t.Error("expected error for empty event type")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		// Read under the call lock to avoid racing the dispatcher's writes
+		listener.callMu.Lock()
+		got := deliveredCount(listener)
+		listener.callMu.Unlock()
+		wildcard.callMu.Lock()
+		gotWildcard := deliveredCount(wildcard)
+		wildcard.callMu.Unlock()
+		if got == 1 && gotWildcard == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for delivery (listener=%d wildcard=%d)", got, gotWildcard)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	listener.callMu.Lock()
+	eventType, payload := lastEvent(t, listener)
+	listener.callMu.Unlock()
+	if eventType != "alert.fired" || payload != `{"rule":"cpu"}` {
+		t.Errorf("unexpected event delivered: %q %q", eventType, payload)
+	}
+}
+
+func TestRuntime_PublishEventBusFull(t *testing.T) {
+	r, err := NewRuntimeWithOptions(context.Background(), &services.NopLogger{}, RuntimeOptions{
+		DataDir:      t.TempDir(),
+		EventBufSize: 1,
+	})
+	if err != nil {
+		t.Fatalf("NewRuntimeWithOptions failed: %v", err)
+	}
+	defer r.Close()
+
+	if err := r.PublishEvent("a", nil); err != nil {
+		t.Fatalf("PublishEvent failed: %v", err)
+	}
+	if err := r.PublishEvent("b", nil); err != ErrEventBusFull {
+		t.Errorf("expected ErrEventBusFull, got %v", err)
+	}
+
+	r.Close()
+	if err := r.PublishEvent("c", nil); err == nil {
+		t.Error("expected error publishing to a closed runtime")
+	}
+}
+
+// saturatedRuntime returns a runtime whose two-event bus already holds
+// events "a" and "b".
+func saturatedRuntime(t *testing.T, policy EventOverflowPolicy, metrics *recordingMetricService) *Runtime {
+	t.Helper()
+	r := newLimitedRuntime(t, RuntimeOptions{
+		EventBufSize:      2,
+		EventOverflow:     policy,
+		EventBlockTimeout: 50 * time.Millisecond,
+		MetricSvc:         metrics,
+	})
+	for _, eventType := range []string{"a", "b"} {
+		if err := r.PublishEvent(eventType, nil); err != nil {
+			t.Fatalf("PublishEvent(%q) failed: %v", eventType, err)
+		}
+	}
+	return r
+}
+
+// queuedEvents drains the bus and returns the types of the events on it.
+func queuedEvents(r *Runtime) []string {
+	var types []string
+	for {
+		select {
+		case event := <-r.eventBus:
+			types = append(types, event.EventType)
+		default:
+			return types
+		}
+	}
+}
+
+func TestRuntime_EventOverflowDropNewest(t *testing.T) {
+	metrics := newRecordingMetricService()
+	r := saturatedRuntime(t, "", metrics)
+	if r.EventOverflowPolicy() != EventOverflowDropNewest {
+		t.Errorf("expected drop-newest by default, got %q", r.EventOverflowPolicy())
+	}
+
+	if err := r.PublishEvent("c", nil); err != ErrEventBusFull {
+		t.Fatalf("expected ErrEventBusFull, got %v", err)
+	}
+	if queued := queuedEvents(r); len(queued) != 2 || queued[0] != "a" || queued[1] != "b" {
+		t.Errorf("expected the queued events to be kept, got %v", queued)
+	}
+	if r.DroppedEvents() != 1 {
+		t.Errorf("expected 1 dropped event, got %d", r.DroppedEvents())
+	}
+	if metrics.counts[metricEventsDropped] != 1 || metrics.tags[metricEventsDropped]["policy"] != "drop-newest" {
+		t.Errorf("expected the drop to be recorded as a metric, got %v %v", metrics.counts, metrics.tags[metricEventsDropped])
+	}
+}
+
+func TestRuntime_EventOverflowDropOldest(t *testing.T) {
+	metrics := newRecordingMetricService()
+	r := saturatedRuntime(t, EventOverflowDropOldest, metrics)
+
+	for _, eventType := range []string{"c", "d"} {
+		if err := r.PublishEvent(eventType, nil); err != nil {
+			t.Fatalf("PublishEvent(%q) failed: %v", eventType, err)
+		}
+	}
+	if queued := queuedEvents(r); len(queued) != 2 || queued[0] != "c" || queued[1] != "d" {
+		t.Errorf("expected the oldest events to be evicted, got %v", queued)
+	}
+	if r.DroppedEvents() != 2 || metrics.counts[metricEventsDropped] != 2 {
+		t.Errorf("expected 2 dropped events, got %d (metric %d)", r.DroppedEvents(), metrics.counts[metricEventsDropped])
+	}
+}
+
+func TestRuntime_EventOverflowBlock(t *testing.T) {
+	r := saturatedRuntime(t, EventOverflowBlock, newRecordingMetricService())
+
+	// Nobody makes room: the event is dropped after the block timeout
+	start := time.Now()
+	if err := r.PublishEvent("c", nil); err != ErrEventBusFull {
+		t.Fatalf("expected ErrEventBusFull, got %v", err)
+	}
+	if waited := time.Since(start); waited < 50*time.Millisecond {
+		t.Errorf("expected to wait for the block timeout, waited %s", waited)
+	}
+
+	// Room made within the timeout: the event is queued
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		<-r.eventBus
+	}()
+	if err := r.PublishEvent("d", nil); err != nil {
+		t.Fatalf("expected the event to be queued once there was room, got %v", err)
+	}
+	if queued := queuedEvents(r); len(queued) != 2 || queued[1] != "d" {
+		t.Errorf("expected d to be queued, got %v", queued)
+	}
+	if r.DroppedEvents() != 1 {
+		t.Errorf("expected 1 dropped event, got %d", r.DroppedEvents())
+	}
+}
+
+func TestRuntime_EventOverflowBackpressure(t *testing.T) {
+	r := saturatedRuntime(t, EventOverflowBackpressure, newRecordingMetricService())
+	emitter := loadSubscriber(t, r, "emitter")
+
+	// Host events have no emitter to hold back and wait for the block timeout
+	if err := r.PublishEvent("c", nil); err != ErrEventBusFull {
+		t.Fatalf("expected ErrEventBusFull for a host event, got %v", err)
+	}
+
+	// A plugin is held well past the block timeout until there is room
+	mem := emitter.Module.Memory()
+	mem.Write(256, []byte("metric.cpu.high"))
+	go func() {
+		time.Sleep(150 * time.Millisecond)
+		<-r.eventBus
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	results, err := emitter.Exports["emit"].Call(ctx, 256, uint64(len("metric.cpu.high")), 0, 0)
+	if err != nil {
+		t.Fatalf("emit failed: %v", err)
+	}
+	if code := int32(results[0]); code != 0 {
+		t.Fatalf("expected the event to be queued, got code %d", code)
+	}
+	if waited := time.Since(start); waited < 150*time.Millisecond {
+		t.Errorf("expected the emitter to be held until there was room, waited %s", waited)
+	}
+
+	// Once its call runs out of time the event is dropped
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	results, err = emitter.Exports["emit"].Call(ctx, 256, uint64(len("metric.cpu.high")), 0, 0)
+	if err == nil && int32(results[0]) != -3 {
+		t.Errorf("expected the event to be dropped when the call ran out of time, got %d", int32(results[0]))
+	}
+	if r.DroppedEvents() != 2 {
+		t.Errorf("expected 2 dropped events, got %d", r.DroppedEvents())
+	}
+}
+
+func TestNewRuntime_InvalidEventOverflow(t *testing.T) {
+	_, err := NewRuntimeWithOptions(context.Background(), &services.NopLogger{}, RuntimeOptions{
+		DataDir:       t.TempDir(),
+		EventOverflow: "drop-everything",
+	})
+	if err == nil {
+		t.Fatal("expected an unknown overflow policy to be rejected")
+	}
+}
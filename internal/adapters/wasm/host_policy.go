@@ -0,0 +1,293 @@
+package wasm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrCodeHostNotAllowed is returned by forge_http_request when the target
+// host is rejected by the plugin's host policy.
+const ErrCodeHostNotAllowed int32 = -7
+
+// ErrHostNotAllowed is returned when a request targets a host outside the allow-list.
+var ErrHostNotAllowed = errors.New("host not allowed")
+
+// HostPolicy decides which hosts a plugin may reach over HTTP.
+//
+// Patterns may be exact hostnames ("api.example.com"), wildcard subdomains
+// ("*.example.com"), IP addresses ("10.0.0.5") or CIDR ranges ("10.0.0.0/8").
+// A lone "*" allows any public host, and so does an empty pattern list.
+// Loopback, link-local and unspecified addresses (including "localhost") are
+// always denied unless an exact host, IP or CIDR pattern names them.
+//
+// Policies can be layered with Restrict; a host must then be allowed by
+// every layer that declares patterns.
+type HostPolicy struct {
+	layers  []*hostRules
+	denyAll bool
+}
+
+// hostRules is a single parsed list of host patterns.
+type hostRules struct {
+	exact     map[string]bool
+	wildcards []string // suffixes including the leading dot, e.g. ".example.com"
+	networks  []*net.IPNet
+	any       bool
+}
+
+// NewHostPolicy builds a host policy from a list of patterns.
+func NewHostPolicy(patterns []string) (*HostPolicy, error) {
+	rules, err := parseHostRules(patterns)
+	if err != nil {
+		return nil, err
+	}
+	return &HostPolicy{layers: []*hostRules{rules}}, nil
+}
+
+// DenyAllHostPolicy returns a policy that rejects every host.
+func DenyAllHostPolicy() *HostPolicy {
+	return &HostPolicy{denyAll: true}
+}
+
+// Restrict returns a new policy that additionally requires hosts to match patterns.
+func (p *HostPolicy) Restrict(patterns []string) (*HostPolicy, error) {
+	rules, err := parseHostRules(patterns)
+	if err != nil {
+		return nil, err
+	}
+	layers := make([]*hostRules, 0, len(p.layers)+1)
+	layers = append(layers, p.layers...)
+	layers = append(layers, rules)
+	return &HostPolicy{layers: layers, denyAll: p.denyAll}, nil
+}
+
+func parseHostRules(patterns []string) (*hostRules, error) {
+	r := &hostRules{exact: make(map[string]bool)}
+	for _, raw := range patterns {
+		pattern := strings.ToLower(strings.TrimSpace(raw))
+		switch {
+		case pattern == "":
+			continue
+		case pattern == "*":
+			r.any = true
+		case strings.HasPrefix(pattern, "*."):
+			r.wildcards = append(r.wildcards, pattern[1:])
+		case strings.Contains(pattern, "/"):
+			_, network, err := net.ParseCIDR(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR %q: %w", raw, err)
+			}
+			r.networks = append(r.networks, network)
+		default:
+			if ip := net.ParseIP(strings.Trim(pattern, "[]")); ip != nil {
+				bits := 8 * net.IPv6len
+				if v4 := ip.To4(); v4 != nil {
+					ip, bits = v4, 8*net.IPv4len
+				}
+				r.networks = append(r.networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+				continue
+			}
+			if strings.Contains(pattern, "*") {
+				return nil, fmt.Errorf("invalid host pattern %q: wildcard must be a leading '*.'", raw)
+			}
+			r.exact[pattern] = true
+		}
+	}
+	return r, nil
+}
+
+// open reports whether the rule list declares no patterns at all.
+func (r *hostRules) open() bool {
+	return !r.any && len(r.exact) == 0 && len(r.wildcards) == 0 && len(r.networks) == 0
+}
+
+func (r *hostRules) matchHost(host string) bool {
+	if r.any || r.exact[host] {
+		return true
+	}
+	for _, suffix := range r.wildcards {
+		if strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *hostRules) containsIP(ip net.IP) bool {
+	for _, network := range r.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// restrictedLayers returns the layers that declare patterns.
+func (p *HostPolicy) restrictedLayers() []*hostRules {
+	var layers []*hostRules
+	for _, l := range p.layers {
+		if !l.open() {
+			layers = append(layers, l)
+		}
+	}
+	return layers
+}
+
+// AllowsHost reports whether a hostname or IP literal may be contacted.
+func (p *HostPolicy) AllowsHost(host string) bool {
+	if p.denyAll {
+		return false
+	}
+	host = normalizeHost(host)
+	if host == "" {
+		return false
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return p.allowsIP(ip, "")
+	}
+
+	layers := p.restrictedLayers()
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		if len(layers) == 0 {
+			return false
+		}
+		for _, l := range layers {
+			if !l.exact[host] {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, l := range layers {
+		if !l.matchHost(host) {
+			return false
+		}
+	}
+	return true
+}
+
+// allowsIP reports whether ip may be contacted. resolvedFrom is the hostname
+// ip was resolved from (already checked by AllowsHost), or "" for literals.
+func (p *HostPolicy) allowsIP(ip net.IP, resolvedFrom string) bool {
+	if p.denyAll {
+		return false
+	}
+	layers := p.restrictedLayers()
+
+	if isRestrictedIP(ip) {
+		if len(layers) == 0 {
+			return false
+		}
+		for _, l := range layers {
+			if !l.containsIP(ip) && (resolvedFrom == "" || !l.exact[resolvedFrom]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if resolvedFrom != "" {
+		return true
+	}
+	for _, l := range layers {
+		if !l.any && !l.containsIP(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// AllowsURL reports whether the host in rawURL may be contacted.
+func (p *HostPolicy) AllowsURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return false
+	}
+	return p.AllowsHost(u.Hostname())
+}
+
+// normalizeHost lowercases a host and strips IPv6 brackets and a trailing dot.
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.Trim(strings.ToLower(host), "[]"), ".")
+}
+
+// isRestrictedIP reports whether ip is loopback, link-local or unspecified.
+func isRestrictedIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsUnspecified()
+}
+
+type hostPolicyKey struct{}
+
+// withHostPolicy attaches a host policy to the request context so that
+// redirects and dials made on behalf of a plugin can be checked.
+func withHostPolicy(ctx context.Context, p *HostPolicy) context.Context {
+	return context.WithValue(ctx, hostPolicyKey{}, p)
+}
+
+// hostPolicyFrom returns the host policy attached to ctx, if any.
+func hostPolicyFrom(ctx context.Context) *HostPolicy {
+	p, _ := ctx.Value(hostPolicyKey{}).(*HostPolicy)
+	return p
+}
+
+// newPluginHTTPClient creates the HTTP client used by forge_http_request.
+// Every redirect hop and every resolved address is checked against the
+// host policy carried by the request context.
+func newPluginHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		policy := hostPolicyFrom(ctx)
+		if policy == nil {
+			return dialer.DialContext(ctx, network, addr)
+		}
+
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			if !policy.allowsIP(ip.IP, normalizeHost(host)) {
+				return nil, fmt.Errorf("%w: %s resolves to %s", ErrHostNotAllowed, host, ip.IP)
+			}
+		}
+		// Dial the vetted addresses directly so a second lookup cannot rebind
+		var lastErr error = fmt.Errorf("no addresses found for %s", host)
+		for _, ip := range ips {
+			conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip.IP.String(), port))
+			if err == nil {
+				return conn, nil
+			}
+			lastErr = err
+		}
+		return nil, lastErr
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			if policy := hostPolicyFrom(req.Context()); policy != nil && !policy.AllowsHost(req.URL.Hostname()) {
+				return fmt.Errorf("%w: redirect to %s", ErrHostNotAllowed, req.URL.Hostname())
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,239 @@
+package wasm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/services"
+)
+
+func TestHostPolicy_AllowsHost(t *testing.T) {
+	policy, err := NewHostPolicy([]string{
+		"api.example.com",
+		"*.internal.example.org",
+		"10.0.0.0/8",
+		"192.168.1.5",
+	})
+	if err != nil {
+		t.Fatalf("NewHostPolicy failed: %v", err)
+	}
+
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"api.example.com", true},
+		{"API.Example.com", true},
+		{"api.example.com.", true},
+		{"other.example.com", false},
+		{"svc.internal.example.org", true},
+		{"a.b.internal.example.org", true},
+		{"internal.example.org", false},
+		{"10.1.2.3", true},
+		{"11.1.2.3", false},
+		{"192.168.1.5", true},
+		{"192.168.1.6", false},
+		{"127.0.0.1", false},
+		{"169.254.169.254", false},
+		{"localhost", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := policy.AllowsHost(tt.host); got != tt.want {
+			t.Errorf("AllowsHost(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestHostPolicy_DefaultDenyRestrictedAddresses(t *testing.T) {
+	open, err := NewHostPolicy(nil)
+	if err != nil {
+		t.Fatalf("NewHostPolicy failed: %v", err)
+	}
+	wildcard, err := NewHostPolicy([]string{"*"})
+	if err != nil {
+		t.Fatalf("NewHostPolicy failed: %v", err)
+	}
+
+	for _, policy := range []*HostPolicy{open, wildcard} {
+		for _, host := range []string{"example.com", "8.8.8.8"} {
+			if !policy.AllowsHost(host) {
+				t.Errorf("expected public host %s to be allowed", host)
+			}
+		}
+		for _, host := range []string{"127.0.0.1", "::1", "[::1]", "169.254.169.254", "fe80::1", "0.0.0.0", "localhost", "db.localhost"} {
+			if policy.AllowsHost(host) {
+				t.Errorf("expected restricted host %s to be denied", host)
+			}
+		}
+	}
+
+	explicit, err := NewHostPolicy([]string{"localhost", "127.0.0.0/8", "169.254.169.254"})
+	if err != nil {
+		t.Fatalf("NewHostPolicy failed: %v", err)
+	}
+	for _, host := range []string{"localhost", "127.0.0.1", "169.254.169.254"} {
+		if !explicit.AllowsHost(host) {
+			t.Errorf("expected explicitly allowed host %s to be allowed", host)
+		}
+	}
+}
+
+func TestHostPolicy_InvalidPatterns(t *testing.T) {
+	for _, pattern := range []string{"10.0.0.0/99", "api.*.com", "foo*"} {
+		if _, err := NewHostPolicy([]string{pattern}); err == nil {
+			t.Errorf("expected error for pattern %q", pattern)
+		}
+	}
+}
+
+func TestHostPolicy_Restrict(t *testing.T) {
+	global, err := NewHostPolicy([]string{"*.example.com"})
+	if err != nil {
+		t.Fatalf("NewHostPolicy failed: %v", err)
+	}
+	plugin, err := global.Restrict([]string{"api.example.com", "api.other.com"})
+	if err != nil {
+		t.Fatalf("Restrict failed: %v", err)
+	}
+
+	if !plugin.AllowsHost("api.example.com") {
+		t.Error("expected host allowed by both layers")
+	}
+	if plugin.AllowsHost("www.example.com") {
+		t.Error("expected host outside plugin list to be denied")
+	}
+	if plugin.AllowsHost("api.other.com") {
+		t.Error("expected host outside global list to be denied")
+	}
+
+	// An open global policy defers to the plugin list
+	open, _ := NewHostPolicy(nil)
+	local, err := open.Restrict([]string{"localhost"})
+	if err != nil {
+		t.Fatalf("Restrict failed: %v", err)
+	}
+	if !local.AllowsHost("localhost") {
+		t.Error("expected plugin to reach explicitly allowed localhost")
+	}
+}
+
+func TestHostPolicy_AllowsURL(t *testing.T) {
+	policy, _ := NewHostPolicy([]string{"api.example.com"})
+
+	if !policy.AllowsURL("https://api.example.com:8443/v1/metrics") {
+		t.Error("expected URL with allowed host to be allowed")
+	}
+	if policy.AllowsURL("http://169.254.169.254/latest/meta-data/") {
+		t.Error("expected metadata endpoint to be denied")
+	}
+	if policy.AllowsURL("not a url") {
+		t.Error("expected invalid URL to be denied")
+	}
+	if DenyAllHostPolicy().AllowsURL("https://api.example.com") {
+		t.Error("expected deny-all policy to deny everything")
+	}
+}
+
+func newTestRuntime(t *testing.T, allowedHosts []string) *Runtime {
+	t.Helper()
+	r, err := NewRuntimeWithOptions(context.Background(), &services.NopLogger{}, RuntimeOptions{
+		DataDir:      t.TempDir(),
+		AllowedHosts: allowedHosts,
+	})
+	if err != nil {
+		t.Fatalf("NewRuntimeWithOptions failed: %v", err)
+	}
+	t.Cleanup(func() { r.Close() })
+	return r
+}
+
+func TestRuntime_PluginHostPolicy(t *testing.T) {
+	r := newTestRuntime(t, []string{"*.example.com"})
+
+	noNetwork := domain.NewPlugin("offline", "1.0.0", "offline.wasm")
+	policy, err := r.pluginHostPolicy(noNetwork)
+	if err != nil {
+		t.Fatalf("pluginHostPolicy failed: %v", err)
+	}
+	if policy.AllowsHost("api.example.com") {
+		t.Error("expected plugin without network permission to be denied")
+	}
+
+	networked := domain.NewPlugin("fetcher", "1.0.0", "fetcher.wasm")
+	networked.Permissions = []domain.PluginPermission{domain.PermissionNetwork}
+	networked.AllowedHosts = []string{"api.example.com"}
+	policy, err = r.pluginHostPolicy(networked)
+	if err != nil {
+		t.Fatalf("pluginHostPolicy failed: %v", err)
+	}
+	if !policy.AllowsHost("api.example.com") {
+		t.Error("expected declared host to be allowed")
+	}
+	if policy.AllowsHost("www.example.com") {
+		t.Error("expected undeclared host to be denied")
+	}
+
+	if r.policyFor("unknown-module").AllowsHost("api.example.com") {
+		t.Error("expected unknown module to have no network access")
+	}
+}
+
+func TestRuntime_DoHTTPRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	r := newTestRuntime(t, nil)
+	ctx := context.Background()
+
+	// Loopback is denied by default
+	denied, _ := NewHostPolicy(nil)
+	if code, _ := r.doHTTPRequest(ctx, denied, "GET", server.URL, nil); code != ErrCodeHostNotAllowed {
+		t.Errorf("expected code %d for loopback target, got %d", ErrCodeHostNotAllowed, code)
+	}
+
+	allowed, _ := NewHostPolicy([]string{"127.0.0.1"})
+	code, body := r.doHTTPRequest(ctx, allowed, "GET", server.URL, nil)
+	if code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", code)
+	}
+	if string(body) != "ok" {
+		t.Errorf("expected body 'ok', got %q", body)
+	}
+}
+
+func TestRuntime_DoHTTPRequestBlocksRedirectToDisallowedHost(t *testing.T) {
+	targetHit := false
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		targetHit = true
+		_, _ = w.Write([]byte("secret"))
+	}))
+	defer target.Close()
+
+	redirector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		http.Redirect(w, req, target.URL+"/latest/meta-data/", http.StatusFound)
+	}))
+	defer redirector.Close()
+
+	// Allow the redirector by name only; the redirect goes to a loopback IP literal
+	redirectorURL, _ := url.Parse(redirector.URL)
+	start := "http://localhost:" + redirectorURL.Port() + "/"
+
+	r := newTestRuntime(t, nil)
+	policy, _ := NewHostPolicy([]string{"localhost"})
+
+	code, _ := r.doHTTPRequest(context.Background(), policy, "GET", start, nil)
+	if code != ErrCodeHostNotAllowed {
+		t.Errorf("expected code %d for redirect to disallowed host, got %d", ErrCodeHostNotAllowed, code)
+	}
+	if targetHit {
+		t.Error("redirect target should not have been contacted")
+	}
+}
@@ -0,0 +1,193 @@
+package wasm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/sys"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+)
+
+// Default resource limits for plugin execution.
+const (
+	DefaultMaxMemoryPages   = 1024 // 64 MiB of linear memory
+	DefaultMaxExecutionTime = 10 * time.Second
+	DefaultMaxViolations    = 3
+)
+
+// Metrics recorded for every plugin call.
+const (
+	metricPluginExecDuration = "forge.plugin.exec.duration"
+	metricPluginMemoryPages  = "forge.plugin.memory.pages"
+)
+
+// wasmPageSize is the size of a WebAssembly linear memory page.
+const wasmPageSize = 65536
+
+// checkMemoryLimit rejects modules whose declared minimum memory already
+// exceeds the page limit. Growth beyond the limit is refused by wazero.
+func checkMemoryLimit(compiled wazero.CompiledModule, maxPages uint32) error {
+	if maxPages == 0 {
+		return nil
+	}
+	var defs []api.MemoryDefinition
+	for _, def := range compiled.ImportedMemories() {
+		defs = append(defs, def)
+	}
+	for _, def := range compiled.ExportedMemories() {
+		defs = append(defs, def)
+	}
+	for _, def := range defs {
+		if def.Min() > maxPages {
+			return fmt.Errorf("plugin requires %d memory pages, limit is %d", def.Min(), maxPages)
+		}
+	}
+	return nil
+}
+
+// invoke calls an export of a plugin instance within the execution time
+// budget and records its duration and memory use. The caller holds
+// loaded.callMu.
+//
+// wazero aborts a call whose context is done by closing the instance, so an
+// interrupted plugin is re-instantiated from its compiled module before the
+// next call. Calls that ran out of time count as violations; after
+// maxViolations of them the plugin is disabled. Lifecycle exports are
+// excluded from the restart because load, unload and reload discard the
+// instance themselves.
+func (r *Runtime) invoke(ctx context.Context, loaded *LoadedPlugin, funcName string, args ...uint64) ([]uint64, error) {
+	if loaded.disabled {
+		return nil, fmt.Errorf("plugin %s is disabled: %s", loaded.Plugin.Name, loaded.disabledReason)
+	}
+	fn := loaded.Exports[funcName]
+	if fn == nil {
+		return nil, fmt.Errorf("function not found: %s", funcName)
+	}
+
+	callCtx, cancel := ctx, context.CancelFunc(func() {})
+	if r.maxExecTime > 0 {
+		callCtx, cancel = context.WithTimeout(ctx, r.maxExecTime)
+	}
+	start := time.Now()
+	results, err := fn.Call(callCtx, args...)
+	duration := time.Since(start)
+	timedOut := errors.Is(callCtx.Err(), context.DeadlineExceeded)
+	cancel()
+	// Response buffers handed out during the call are free again
+	r.allocator.ReleaseAll(loaded.Module.Name())
+
+	r.recordExecMetrics(ctx, loaded, funcName, duration)
+
+	if err == nil {
+		return results, nil
+	}
+	var exitErr *sys.ExitError
+	if timedOut && errors.As(err, &exitErr) {
+		err = fmt.Errorf("plugin %s exceeded execution time limit in %s after %s", loaded.Plugin.Name, funcName, duration.Round(time.Millisecond))
+		r.recordViolation(loaded, err)
+	}
+	if loaded.Module.IsClosed() && !loaded.disabled && funcName != pluginInitExport && funcName != pluginCleanupExport {
+		r.restartLocked(loaded)
+	}
+	return nil, err
+}
+
+// recordViolation records a limit violation on the plugin's status and
+// disables the plugin once it has too many.
+func (r *Runtime) recordViolation(loaded *LoadedPlugin, err error) {
+	r.mu.Lock()
+	loaded.Plugin.RecordViolation(err)
+	violations := loaded.Plugin.Violations
+	r.mu.Unlock()
+
+	r.logger.Warn("Plugin exceeded resource limit", "name", loaded.Plugin.Name, "violations", violations, "error", err)
+	if r.maxViolations > 0 && violations >= r.maxViolations {
+		r.disableLocked(loaded, fmt.Errorf("disabled after %d resource limit violations: %w", violations, err))
+	}
+}
+
+// restartLocked replaces an interrupted instance with a fresh one and runs
+// its on_init again. The caller holds loaded.callMu.
+func (r *Runtime) restartLocked(loaded *LoadedPlugin) {
+	pluginID := loaded.Plugin.ID.String()
+
+	r.mu.RLock()
+	current := r.modules[pluginID] == loaded && !r.closed
+	r.mu.RUnlock()
+	if !current {
+		return
+	}
+
+	ctx := context.Background()
+	r.allocator.Forget(loaded.Module.Name())
+	name := fmt.Sprintf("%s%sr%d", pluginID, moduleGenerationSep, r.restarts.Add(1))
+	module, err := r.runtime.InstantiateModule(ctx, loaded.compiled, wazero.NewModuleConfig().WithName(name))
+	if err != nil {
+		r.disableLocked(loaded, fmt.Errorf("failed to restart plugin: %w", err))
+		return
+	}
+
+	r.mu.Lock()
+	loaded.Module = module
+	loaded.Exports = moduleExports(module)
+	r.mu.Unlock()
+
+	// on_init subscribes again, so start from a clean slate
+	subscriptions := r.takeSubscriptions(pluginID)
+	if err := r.callLifecycleLocked(ctx, loaded, pluginInitExport); err != nil {
+		r.restoreSubscriptions(pluginID, subscriptions)
+		r.disableLocked(loaded, fmt.Errorf("failed to restart plugin: %w", err))
+		return
+	}
+	r.logger.Info("Plugin restarted after interruption", "name", loaded.Plugin.Name)
+}
+
+// disableLocked closes a plugin's instance and stops all further calls into
+// it. The plugin stays listed with a disabled status until it is reloaded
+// or unloaded. The caller holds loaded.callMu.
+func (r *Runtime) disableLocked(loaded *LoadedPlugin, err error) {
+	r.allocator.Forget(loaded.Module.Name())
+	if !loaded.Module.IsClosed() {
+		loaded.Module.Close(context.Background())
+	}
+
+	r.mu.Lock()
+	loaded.disabled = true
+	loaded.disabledReason = err.Error()
+	loaded.Exports = map[string]api.Function{}
+	loaded.Plugin.MarkDisabled(err)
+	r.mu.Unlock()
+
+	r.logger.Error("Plugin disabled", "name", loaded.Plugin.Name, "error", err)
+}
+
+// recordExecMetrics records the duration of a call and the linear memory
+// the instance holds afterwards.
+func (r *Runtime) recordExecMetrics(ctx context.Context, loaded *LoadedPlugin, funcName string, duration time.Duration) {
+	if r.metricSvc == nil {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	tags := map[string]string{"plugin": loaded.Plugin.Name, "function": funcName}
+	ms := float64(duration.Microseconds()) / 1000
+	if err := r.metricSvc.Record(ctx, metricPluginExecDuration, domain.MetricTypeHistogram, ms, tags); err != nil {
+		r.logger.Debug("Failed to record plugin metric", "name", metricPluginExecDuration, "error", err)
+	}
+
+	if loaded.Module.IsClosed() {
+		return
+	}
+	mem := loaded.Module.Memory()
+	if mem == nil {
+		return
+	}
+	pages := float64(mem.Size() / wasmPageSize)
+	if err := r.metricSvc.Record(ctx, metricPluginMemoryPages, domain.MetricTypeGauge, pages, map[string]string{"plugin": loaded.Plugin.Name}); err != nil {
+		r.logger.Debug("Failed to record plugin metric", "name", metricPluginMemoryPages, "error", err)
+	}
+}
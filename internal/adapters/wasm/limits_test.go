@@ -0,0 +1,211 @@
+package wasm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/services"
+)
+
+// limitsModule returns a guest with one page of exported memory and the
+// exports spin() -> i32, which never returns, grow(pages i32) -> i32 and
+// ping() -> i32, which returns 7.
+func limitsModule() []byte {
+	module := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	// Types: 0 = () -> i32, 1 = (i32) -> i32
+	module = append(module, wasmSection(1, 0x02, 0x60, 0x00, 0x01, 0x7f, 0x60, 0x01, 0x7f, 0x01, 0x7f)...)
+	module = append(module, wasmSection(3, 0x03, 0x00, 0x01, 0x00)...)
+	module = append(module, wasmSection(5, 0x01, 0x00, 0x01)...)
+	exp := []byte{0x04, 0x06}
+	exp = append(exp, "memory"...)
+	exp = append(exp, 0x02, 0x00, 0x04)
+	exp = append(exp, "spin"...)
+	exp = append(exp, 0x00, 0x00, 0x04)
+	exp = append(exp, "grow"...)
+	exp = append(exp, 0x00, 0x01, 0x04)
+	exp = append(exp, "ping"...)
+	exp = append(exp, 0x00, 0x02)
+	module = append(module, wasmSection(7, exp...)...)
+	module = append(module, wasmSection(10,
+		0x03,
+		// spin: loop br 0 end; i32.const 0
+		0x09, 0x00, 0x03, 0x40, 0x0c, 0x00, 0x0b, 0x41, 0x00, 0x0b,
+		// grow: memory.grow(local 0)
+		0x06, 0x00, 0x20, 0x00, 0x40, 0x00, 0x0b,
+		// ping: i32.const 7
+		0x04, 0x00, 0x41, 0x07, 0x0b)...)
+	return module
+}
+
+// memoryModule returns a guest declaring memory with a minimum of pages.
+func memoryModule(pages byte, exported bool) []byte {
+	module := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	module = append(module, wasmSection(5, 0x01, 0x00, pages)...)
+	if exported {
+		exp := []byte{0x01, 0x06}
+		exp = append(exp, "memory"...)
+		exp = append(exp, 0x02, 0x00)
+		module = append(module, wasmSection(7, exp...)...)
+	}
+	return module
+}
+
+// recordingMetricService counts recorded metrics by name.
+type recordingMetricService struct {
+	mu     sync.Mutex
+	counts map[string]int
+	tags   map[string]map[string]string
+	values map[string]float64
+}
+
+func newRecordingMetricService() *recordingMetricService {
+	return &recordingMetricService{
+		counts: make(map[string]int),
+		tags:   make(map[string]map[string]string),
+		values: make(map[string]float64),
+	}
+}
+
+func (m *recordingMetricService) Record(ctx context.Context, name string, metricType domain.MetricType, value float64, tags map[string]string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counts[name]++
+	m.tags[name] = tags
+	m.values[name] = value
+	return nil
+}
+
+func newLimitedRuntime(t *testing.T, opts RuntimeOptions) *Runtime {
+	t.Helper()
+	opts.DataDir = t.TempDir()
+	r, err := NewRuntimeWithOptions(context.Background(), &services.NopLogger{}, opts)
+	if err != nil {
+		t.Fatalf("NewRuntimeWithOptions failed: %v", err)
+	}
+	t.Cleanup(func() { r.Close() })
+	return r
+}
+
+func loadModule(t *testing.T, r *Runtime, name string, module []byte) (*domain.Plugin, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name+".wasm")
+	if err := os.WriteFile(path, module, 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	plugin := domain.NewPlugin(name, "1.0.0", path)
+	return plugin, r.LoadPlugin(context.Background(), plugin)
+}
+
+func TestRuntime_ExecutionTimeLimit(t *testing.T) {
+	ctx := context.Background()
+	metrics := newRecordingMetricService()
+	r := newLimitedRuntime(t, RuntimeOptions{
+		MaxExecutionTime: 50 * time.Millisecond,
+		MaxViolations:    2,
+		MetricSvc:        metrics,
+	})
+	plugin, err := loadModule(t, r, "spinner", limitsModule())
+	if err != nil {
+		t.Fatalf("LoadPlugin failed: %v", err)
+	}
+	id := plugin.ID.String()
+
+	start := time.Now()
+	_, err = r.CallFunction(ctx, id, "spin")
+	if err == nil || !strings.Contains(err.Error(), "exceeded execution time limit") {
+		t.Fatalf("expected time limit error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected spin to be interrupted quickly, took %v", elapsed)
+	}
+
+	// The interrupted instance is replaced and the violation recorded
+	got, _ := r.GetPlugin(id)
+	if got.Violations != 1 || got.Status != domain.PluginStatusActive || got.Error == "" {
+		t.Errorf("expected one recorded violation, got %+v", got)
+	}
+	if result, err := r.CallFunction(ctx, id, "ping"); err != nil || result.(uint64) != 7 {
+		t.Fatalf("expected restarted plugin to respond, got %v, %v", result, err)
+	}
+
+	// The second violation disables the plugin
+	if _, err := r.CallFunction(ctx, id, "spin"); err == nil {
+		t.Fatal("expected second spin to fail")
+	}
+	got, _ = r.GetPlugin(id)
+	if got.Status != domain.PluginStatusDisabled || got.Violations != 2 {
+		t.Errorf("expected plugin to be disabled, got %+v", got)
+	}
+	if r.HasFunction(id, "ping") {
+		t.Error("expected disabled plugin to expose no functions")
+	}
+	if _, err := r.CallFunction(ctx, id, "ping"); err == nil || !strings.Contains(err.Error(), "disabled") {
+		t.Errorf("expected disabled error, got %v", err)
+	}
+
+	// Reloading brings a disabled plugin back even with the same binary
+	if changed, err := r.ReloadPlugin(ctx, id, ""); err != nil || !changed {
+		t.Fatalf("expected reload of disabled plugin, got changed=%v err=%v", changed, err)
+	}
+	got, _ = r.GetPlugin(id)
+	if got.Status != domain.PluginStatusActive || got.Violations != 0 {
+		t.Errorf("expected reloaded plugin to be active, got %+v", got)
+	}
+	if result, err := r.CallFunction(ctx, id, "ping"); err != nil || result.(uint64) != 7 {
+		t.Errorf("expected reloaded plugin to respond, got %v, %v", result, err)
+	}
+
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	// Calls rejected because the plugin is disabled never run
+	if metrics.counts[metricPluginExecDuration] != 4 {
+		t.Errorf("expected a duration metric per executed call, got %d", metrics.counts[metricPluginExecDuration])
+	}
+	if tags := metrics.tags[metricPluginExecDuration]; tags["plugin"] != "spinner" || tags["function"] != "ping" {
+		t.Errorf("unexpected duration tags: %v", tags)
+	}
+	if metrics.values[metricPluginMemoryPages] != 1 {
+		t.Errorf("expected 1 memory page, got %v", metrics.values[metricPluginMemoryPages])
+	}
+}
+
+func TestRuntime_MemoryLimit(t *testing.T) {
+	ctx := context.Background()
+	metrics := newRecordingMetricService()
+	r := newLimitedRuntime(t, RuntimeOptions{MaxMemoryPages: 4, MetricSvc: metrics})
+
+	plugin, err := loadModule(t, r, "grower", limitsModule())
+	if err != nil {
+		t.Fatalf("LoadPlugin failed: %v", err)
+	}
+	id := plugin.ID.String()
+
+	// Growing within the limit works; beyond it memory.grow fails with -1
+	if result, err := r.CallFunction(ctx, id, "grow", int32(2)); err != nil || result.(uint64) != 1 {
+		t.Errorf("expected grow to return previous size 1, got %v, %v", result, err)
+	}
+	metrics.mu.Lock()
+	pages := metrics.values[metricPluginMemoryPages]
+	metrics.mu.Unlock()
+	if pages != 3 {
+		t.Errorf("expected 3 memory pages to be recorded, got %v", pages)
+	}
+	if result, err := r.CallFunction(ctx, id, "grow", int32(2)); err != nil || int32(result.(uint64)) != -1 {
+		t.Errorf("expected grow past the limit to fail, got %v, %v", result, err)
+	}
+
+	for _, exported := range []bool{true, false} {
+		if _, err := loadModule(t, r, "hungry", memoryModule(8, exported)); err == nil {
+			t.Errorf("expected module requiring 8 pages to be rejected (exported=%v)", exported)
+		}
+	}
+	if _, err := loadModule(t, r, "fits", memoryModule(4, true)); err != nil {
+		t.Errorf("expected module within the limit to load, got %v", err)
+	}
+}
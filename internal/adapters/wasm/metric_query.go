@@ -0,0 +1,78 @@
+package wasm
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultMetricQueryLimit is the number of forge_metric_query calls a plugin
+// may make per MetricQueryWindow when RuntimeOptions.MetricQueryLimit is zero.
+const DefaultMetricQueryLimit = 60
+
+// MetricQueryWindow is the window the per-plugin metric query limit applies to.
+const MetricQueryWindow = time.Minute
+
+// MaxMetricQueryPoints caps the points returned by a single metric query.
+const MaxMetricQueryPoints = 10000
+
+// ErrCodeRateLimited is returned by forge_metric_query when the plugin has
+// used up its queries for the current window.
+const ErrCodeRateLimited int32 = -9
+
+// pluginMetricPoint is the JSON shape of a point returned to plugins.
+// Timestamps are Unix milliseconds, matching the query range arguments.
+type pluginMetricPoint struct {
+	Timestamp int64   `json:"timestamp"`
+	Value     float64 `json:"value"`
+}
+
+// queryLimiter allows up to limit calls per window for each plugin.
+type queryLimiter struct {
+	limit   int // Calls per window (0 = unlimited)
+	window  time.Duration
+	mu      sync.Mutex
+	windows map[string]*queryWindow
+}
+
+type queryWindow struct {
+	start time.Time
+	count int
+}
+
+func newQueryLimiter(limit int, window time.Duration) *queryLimiter {
+	return &queryLimiter{
+		limit:   limit,
+		window:  window,
+		windows: make(map[string]*queryWindow),
+	}
+}
+
+// Allow reports whether the plugin may make another call now, counting it
+// if so.
+func (l *queryLimiter) Allow(pluginID string) bool {
+	if l.limit <= 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	w, ok := l.windows[pluginID]
+	if !ok || now.Sub(w.start) >= l.window {
+		l.windows[pluginID] = &queryWindow{start: now, count: 1}
+		return true
+	}
+	if w.count >= l.limit {
+		return false
+	}
+	w.count++
+	return true
+}
+
+// Forget drops the plugin's current window.
+func (l *queryLimiter) Forget(pluginID string) {
+	l.mu.Lock()
+	delete(l.windows, pluginID)
+	l.mu.Unlock()
+}
@@ -0,0 +1,133 @@
+package wasm
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/forge-platform/forge/internal/adapters/storage"
+	"github.com/forge-platform/forge/internal/core/domain"
+)
+
+// metricQueryModule returns a guest that exports memory, but no malloc, and
+// query(name_ptr, name_len, start, end) -> (ptr, len, err_code), which
+// forwards to forge_metric_query.
+func metricQueryModule() []byte {
+	module := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	// Types: 0 = (i32, i32, i64, i64) -> (i32, i32, i32)
+	module = append(module, wasmSection(1,
+		0x01, 0x60, 0x04, 0x7f, 0x7f, 0x7e, 0x7e, 0x03, 0x7f, 0x7f, 0x7f)...)
+	imp := []byte{0x01, 0x05}
+	imp = append(imp, "forge"...)
+	imp = append(imp, 0x12)
+	imp = append(imp, "forge_metric_query"...)
+	imp = append(imp, 0x00, 0x00)
+	module = append(module, wasmSection(2, imp...)...)
+	// Functions: 1 = query
+	module = append(module, wasmSection(3, 0x01, 0x00)...)
+	module = append(module, wasmSection(5, 0x01, 0x00, 0x01)...)
+	exp := []byte{0x02, 0x06}
+	exp = append(exp, "memory"...)
+	exp = append(exp, 0x02, 0x00, 0x05)
+	exp = append(exp, "query"...)
+	exp = append(exp, 0x00, 0x01)
+	module = append(module, wasmSection(7, exp...)...)
+	module = append(module, wasmSection(10,
+		0x01,
+		// query: local.get 0..3; call 0
+		0x0c, 0x00, 0x20, 0x00, 0x20, 0x01, 0x20, 0x02, 0x20, 0x03, 0x10, 0x00, 0x0b)...)
+	return module
+}
+
+func TestRuntime_MetricQuery(t *testing.T) {
+	ctx := context.Background()
+	db, err := storage.New(storage.DefaultConfig(t.TempDir()))
+	if err != nil {
+		t.Fatalf("storage.New failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	repo := storage.NewMetricRepository(db)
+
+	base := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+	for i, value := range []float64{10, 20, 30} {
+		metric := domain.NewMetric("cpu_usage", domain.MetricTypeGauge, value, nil)
+		metric.Timestamp = base.Add(time.Duration(i) * time.Minute)
+		if err := repo.Record(ctx, metric); err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+	}
+
+	r := newLimitedRuntime(t, RuntimeOptions{MetricRepo: repo, MetricQueryLimit: 3})
+	plugin, err := loadModule(t, r, "metric-reader", metricQueryModule())
+	if err != nil {
+		t.Fatalf("LoadPlugin failed: %v", err)
+	}
+	module := r.modules[plugin.ID.String()].Module
+	query := module.ExportedFunction("query")
+
+	call := func(name string, start, end time.Time) ([]pluginMetricPoint, int32) {
+		t.Helper()
+		if !module.Memory().Write(0, []byte(name)) {
+			t.Fatal("failed to write metric name")
+		}
+		results, err := query.Call(ctx, 0, uint64(len(name)), uint64(start.UnixMilli()), uint64(end.UnixMilli()))
+		if err != nil {
+			t.Fatalf("forge_metric_query call failed: %v", err)
+		}
+		if code := int32(results[2]); code != 0 {
+			return nil, code
+		}
+		data, ok := module.Memory().Read(uint32(results[0]), uint32(results[1]))
+		if !ok {
+			t.Fatal("failed to read query response")
+		}
+		var points []pluginMetricPoint
+		if err := json.Unmarshal(data, &points); err != nil {
+			t.Fatalf("invalid query response %q: %v", data, err)
+		}
+		return points, 0
+	}
+
+	points, code := call("cpu_usage", base, base.Add(90*time.Second))
+	if code != 0 {
+		t.Fatalf("expected the query to succeed, got code %d", code)
+	}
+	want := []pluginMetricPoint{
+		{Timestamp: base.UnixMilli(), Value: 10},
+		{Timestamp: base.Add(time.Minute).UnixMilli(), Value: 20},
+	}
+	if len(points) != len(want) || points[0] != want[0] || points[1] != want[1] {
+		t.Errorf("expected %v, got %v", want, points)
+	}
+
+	if points, code := call("unknown_metric", base, time.Now()); code != 0 || len(points) != 0 {
+		t.Errorf("expected no points for an unknown metric, got %v (code %d)", points, code)
+	}
+	if _, code := call("cpu_usage", time.Now(), base); code != -2 {
+		t.Errorf("expected an inverted range to be rejected, got code %d", code)
+	}
+
+	// The limit of 3 queries per window is used up
+	if _, code := call("cpu_usage", base, time.Now()); code != 0 {
+		t.Fatalf("expected the third query to succeed, got code %d", code)
+	}
+	if _, code := call("cpu_usage", base, time.Now()); code != ErrCodeRateLimited {
+		t.Errorf("expected the fourth query to be rate limited, got code %d", code)
+	}
+}
+
+func TestRuntime_MetricQueryWithoutRepository(t *testing.T) {
+	r := newLimitedRuntime(t, RuntimeOptions{})
+	plugin, err := loadModule(t, r, "metric-reader", metricQueryModule())
+	if err != nil {
+		t.Fatalf("LoadPlugin failed: %v", err)
+	}
+	module := r.modules[plugin.ID.String()].Module
+	module.Memory().Write(0, []byte("cpu_usage"))
+
+	_, _, code := r.hostMetricQuery(context.Background(), module, 0, 9, 0, time.Now().UnixMilli())
+	if code != -3 {
+		t.Errorf("expected queries to fail without a metric repository, got code %d", code)
+	}
+}
@@ -0,0 +1,191 @@
+package wasm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+)
+
+// Lifecycle exports called by the runtime: on_init() -> i32 after a module
+// is instantiated and on_cleanup() -> i32 before it is unloaded or replaced.
+// Both are optional; a non-zero result is reported as a failure.
+const (
+	pluginInitExport    = "on_init"
+	pluginCleanupExport = "on_cleanup"
+)
+
+// moduleGenerationSep separates the plugin ID from the reload generation in
+// module names. wazero needs unique names, so a replacement module is
+// instantiated as "<id>#<n>" while the old one is still loaded.
+const moduleGenerationSep = "#"
+
+// pluginIDOf returns the ID of the plugin a module instance belongs to.
+func pluginIDOf(m api.Module) string {
+	id, _, _ := strings.Cut(m.Name(), moduleGenerationSep)
+	return id
+}
+
+// callLifecycle calls an optional lifecycle export of a loaded plugin.
+func (r *Runtime) callLifecycle(ctx context.Context, loaded *LoadedPlugin, export string) error {
+	loaded.callMu.Lock()
+	defer loaded.callMu.Unlock()
+	return r.callLifecycleLocked(ctx, loaded, export)
+}
+
+// callLifecycleLocked is callLifecycle for callers already holding loaded.callMu.
+func (r *Runtime) callLifecycleLocked(ctx context.Context, loaded *LoadedPlugin, export string) error {
+	if loaded.Exports[export] == nil {
+		return nil
+	}
+	results, err := r.invoke(ctx, loaded, export)
+	if err != nil {
+		return fmt.Errorf("%s failed: %w", export, err)
+	}
+	if len(results) > 0 {
+		if code := int32(results[0]); code != 0 {
+			return fmt.Errorf("%s returned error code %d", export, code)
+		}
+	}
+	return nil
+}
+
+// ReloadPlugin replaces a loaded plugin with the current contents of its
+// .wasm file. If expectedHash is set the file's SHA-256 must match it. It
+// returns false without reloading when the file is unchanged.
+//
+// The new binary is instantiated before the old instance is touched, so a
+// binary that fails to instantiate leaves the plugin running as before. Once
+// it is instantiated the old instance's on_cleanup is called, its event
+// subscriptions are dropped and the new instance's on_init is called; if
+// on_init fails the old instance is initialized again and kept. Host policy,
+// storage and configuration carry over since the plugin ID is unchanged.
+func (r *Runtime) ReloadPlugin(ctx context.Context, pluginID, expectedHash string) (bool, error) {
+	r.lifecycleMu.Lock()
+	defer r.lifecycleMu.Unlock()
+
+	r.mu.RLock()
+	old, ok := r.modules[pluginID]
+	disabled := ok && old.disabled
+	r.mu.RUnlock()
+	if !ok {
+		return false, fmt.Errorf("plugin not loaded: %s", pluginID)
+	}
+
+	wasmBytes, err := os.ReadFile(old.Plugin.Path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read plugin file: %w", err)
+	}
+	hash := sha256.Sum256(wasmBytes)
+	hashStr := hex.EncodeToString(hash[:])
+	if expectedHash != "" && !strings.EqualFold(expectedHash, hashStr) {
+		return false, fmt.Errorf("plugin hash mismatch: expected %s, got %s", expectedHash, hashStr)
+	}
+	// A disabled plugin is brought back even if its binary is unchanged
+	if hashStr == old.Plugin.Hash && !disabled {
+		return false, nil
+	}
+
+	compiled, err := r.runtime.CompileModule(ctx, wasmBytes)
+	if err != nil {
+		return false, fmt.Errorf("failed to instantiate new plugin binary: %w", err)
+	}
+	if err := checkMemoryLimit(compiled, r.maxMemoryPages); err != nil {
+		compiled.Close(ctx)
+		return false, fmt.Errorf("failed to instantiate new plugin binary: %w", err)
+	}
+	r.reloads++
+	name := fmt.Sprintf("%s%s%d", pluginID, moduleGenerationSep, r.reloads)
+	module, err := r.runtime.InstantiateModule(ctx, compiled, wazero.NewModuleConfig().WithName(name))
+	if err != nil {
+		compiled.Close(ctx)
+		return false, fmt.Errorf("failed to instantiate new plugin binary: %w", err)
+	}
+
+	// Hold the old instance so no call or event delivery runs during the swap
+	old.callMu.Lock()
+	defer old.callMu.Unlock()
+
+	plugin := *old.Plugin
+	plugin.Hash = hashStr
+	next := &LoadedPlugin{
+		Plugin:   &plugin,
+		Module:   module,
+		Exports:  moduleExports(module),
+		compiled: compiled,
+	}
+	// The new binary may declare a schema the current configuration violates
+	next.callMu.Lock()
+	manifest, err := r.readManifest(ctx, next)
+	if err == nil {
+		err = r.loadConfig(ctx, next, manifest)
+	}
+	next.callMu.Unlock()
+	if err != nil {
+		r.allocator.Forget(module.Name())
+		module.Close(ctx)
+		compiled.Close(ctx)
+		return false, fmt.Errorf("new plugin binary rejected its configuration: %w", err)
+	}
+
+	if err := r.callLifecycleLocked(ctx, old, pluginCleanupExport); err != nil {
+		r.logger.Warn("Plugin cleanup failed", "name", old.Plugin.Name, "error", err)
+	}
+	subscriptions := r.takeSubscriptions(pluginID)
+
+	if err := r.callLifecycle(ctx, next, pluginInitExport); err != nil {
+		r.allocator.Forget(module.Name())
+		module.Close(ctx)
+		compiled.Close(ctx)
+		r.restoreSubscriptions(pluginID, subscriptions)
+		if initErr := r.callLifecycleLocked(ctx, old, pluginInitExport); initErr != nil {
+			r.logger.Warn("Failed to re-initialize previous plugin instance", "name", old.Plugin.Name, "error", initErr)
+		}
+		return false, fmt.Errorf("new plugin binary failed to initialize: %w", err)
+	}
+
+	r.mu.Lock()
+	if r.modules[pluginID] != old {
+		r.mu.Unlock()
+		r.allocator.Forget(module.Name())
+		module.Close(ctx)
+		compiled.Close(ctx)
+		return false, fmt.Errorf("plugin not loaded: %s", pluginID)
+	}
+	plugin.MarkLoaded()
+	r.modules[pluginID] = next
+	r.mu.Unlock()
+
+	r.allocator.Forget(old.Module.Name())
+	if err := old.Module.Close(ctx); err != nil {
+		r.logger.Warn("Failed to close previous plugin instance", "name", old.Plugin.Name, "error", err)
+	}
+	old.compiled.Close(ctx)
+	r.logger.Info("Plugin reloaded", "name", plugin.Name, "hash", hashStr)
+	return true, nil
+}
+
+// takeSubscriptions removes and returns a plugin's event subscriptions.
+func (r *Runtime) takeSubscriptions(pluginID string) []string {
+	r.subMu.Lock()
+	defer r.subMu.Unlock()
+	patterns := r.subscriptions[pluginID]
+	delete(r.subscriptions, pluginID)
+	return patterns
+}
+
+// restoreSubscriptions reinstates subscriptions removed by takeSubscriptions.
+func (r *Runtime) restoreSubscriptions(pluginID string, patterns []string) {
+	r.subMu.Lock()
+	defer r.subMu.Unlock()
+	if len(patterns) == 0 {
+		delete(r.subscriptions, pluginID)
+		return
+	}
+	r.subscriptions[pluginID] = patterns
+}
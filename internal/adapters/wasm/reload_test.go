@@ -0,0 +1,237 @@
+package wasm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+)
+
+// lifecycleModule returns a guest exporting version() -> i32, on_init, which
+// subscribes to "metric.*" and returns initResult, and on_cleanup, which
+// emits a "cleanup" event.
+func lifecycleModule(version, initResult byte) []byte {
+	module := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	// Types: 0 = (i32, i32) -> i32, 1 = (i32, i32, i32, i32) -> i32, 2 = () -> i32
+	module = append(module, wasmSection(1,
+		0x03,
+		0x60, 0x02, 0x7f, 0x7f, 0x01, 0x7f,
+		0x60, 0x04, 0x7f, 0x7f, 0x7f, 0x7f, 0x01, 0x7f,
+		0x60, 0x00, 0x01, 0x7f)...)
+	imp := []byte{0x02, 0x05}
+	imp = append(imp, "forge"...)
+	imp = append(imp, 0x0f)
+	imp = append(imp, "forge_subscribe"...)
+	imp = append(imp, 0x00, 0x00, 0x05)
+	imp = append(imp, "forge"...)
+	imp = append(imp, 0x10)
+	imp = append(imp, "forge_emit_event"...)
+	imp = append(imp, 0x00, 0x01)
+	module = append(module, wasmSection(2, imp...)...)
+	// Functions: 2 = version, 3 = on_init, 4 = on_cleanup
+	module = append(module, wasmSection(3, 0x03, 0x02, 0x02, 0x02)...)
+	module = append(module, wasmSection(5, 0x01, 0x00, 0x01)...)
+	exp := []byte{0x04, 0x06}
+	exp = append(exp, "memory"...)
+	exp = append(exp, 0x02, 0x00, 0x07)
+	exp = append(exp, "version"...)
+	exp = append(exp, 0x00, 0x02, 0x07)
+	exp = append(exp, "on_init"...)
+	exp = append(exp, 0x00, 0x03, 0x0a)
+	exp = append(exp, "on_cleanup"...)
+	exp = append(exp, 0x00, 0x04)
+	module = append(module, wasmSection(7, exp...)...)
+	module = append(module, wasmSection(10,
+		0x03,
+		// version: i32.const version
+		0x04, 0x00, 0x41, version, 0x0b,
+		// on_init: drop(forge_subscribe(0, 8)); i32.const initResult
+		0x0b, 0x00, 0x41, 0x00, 0x41, 0x08, 0x10, 0x00, 0x1a, 0x41, initResult, 0x0b,
+		// on_cleanup: drop(forge_emit_event(16, 7, 0, 0)); i32.const 0
+		0x0f, 0x00, 0x41, 0x10, 0x41, 0x07, 0x41, 0x00, 0x41, 0x00, 0x10, 0x01, 0x1a, 0x41, 0x00, 0x0b)...)
+	data := []byte{0x02, 0x00, 0x41, 0x00, 0x0b, 0x08}
+	data = append(data, "metric.*"...)
+	data = append(data, 0x00, 0x41, 0x10, 0x0b, 0x07)
+	data = append(data, "cleanup"...)
+	module = append(module, wasmSection(11, data...)...)
+	return module
+}
+
+// pluginVersion calls the guest's version export.
+func pluginVersion(t *testing.T, r *Runtime, pluginID string) uint64 {
+	t.Helper()
+	result, err := r.CallFunction(context.Background(), pluginID, "version")
+	if err != nil {
+		t.Fatalf("version call failed: %v", err)
+	}
+	return result.(uint64)
+}
+
+// drainCleanupEvents returns the plugin IDs of queued "cleanup" events.
+func drainCleanupEvents(r *Runtime) []string {
+	var ids []string
+	for {
+		select {
+		case event := <-r.Events():
+			if event.EventType == "cleanup" {
+				ids = append(ids, event.PluginID)
+			}
+		default:
+			return ids
+		}
+	}
+}
+
+func TestRuntime_ReloadPlugin(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRuntime(t, nil)
+	path := filepath.Join(t.TempDir(), "dev.wasm")
+	writeModule := func(module []byte) {
+		t.Helper()
+		if err := os.WriteFile(path, module, 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+
+	writeModule(lifecycleModule(1, 0))
+	plugin := domain.NewPlugin("dev", "0.1.0", path)
+	if err := r.LoadPlugin(ctx, plugin); err != nil {
+		t.Fatalf("LoadPlugin failed: %v", err)
+	}
+	id := plugin.ID.String()
+	if subs := r.Subscriptions(id); len(subs) != 1 || subs[0] != "metric.*" {
+		t.Fatalf("expected on_init to subscribe, got %v", subs)
+	}
+
+	// An unchanged binary is not reloaded
+	if changed, err := r.ReloadPlugin(ctx, id, ""); err != nil || changed {
+		t.Fatalf("expected no-op reload, got changed=%v err=%v", changed, err)
+	}
+
+	writeModule(lifecycleModule(2, 0))
+	if changed, err := r.ReloadPlugin(ctx, id, ""); err != nil || !changed {
+		t.Fatalf("expected reload, got changed=%v err=%v", changed, err)
+	}
+	if v := pluginVersion(t, r, id); v != 2 {
+		t.Errorf("expected version 2 after reload, got %d", v)
+	}
+	if subs := r.Subscriptions(id); len(subs) != 1 {
+		t.Errorf("expected subscriptions to be re-created by on_init, got %v", subs)
+	}
+
+	// Cleanup runs on the replaced instance; a second reload replaces a
+	// generation-named module, which must still report the plugin ID
+	writeModule(lifecycleModule(3, 0))
+	if _, err := r.ReloadPlugin(ctx, id, ""); err != nil {
+		t.Fatalf("second reload failed: %v", err)
+	}
+	if ids := drainCleanupEvents(r); len(ids) != 2 || ids[0] != id || ids[1] != id {
+		t.Errorf("expected two cleanup events from %s, got %v", id, ids)
+	}
+	loaded, _ := r.GetPlugin(id)
+	if loaded.Hash == plugin.Hash || loaded.Status != domain.PluginStatusActive {
+		t.Errorf("expected updated hash and active status, got %+v", loaded)
+	}
+	if got := r.ListLoadedPlugins(); len(got) != 1 {
+		t.Errorf("expected a single loaded plugin, got %v", got)
+	}
+
+	if _, err := r.ReloadPlugin(ctx, "missing", ""); err == nil {
+		t.Error("expected error for a plugin that is not loaded")
+	}
+}
+
+func TestRuntime_ReloadPluginFailureKeepsOldModule(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRuntime(t, nil)
+	path := filepath.Join(t.TempDir(), "dev.wasm")
+	if err := os.WriteFile(path, lifecycleModule(1, 0), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	plugin := domain.NewPlugin("dev", "0.1.0", path)
+	if err := r.LoadPlugin(ctx, plugin); err != nil {
+		t.Fatalf("LoadPlugin failed: %v", err)
+	}
+	id := plugin.ID.String()
+	hash := plugin.Hash
+
+	tests := []struct {
+		name    string
+		binary  []byte
+		want    string
+		cleanup bool // whether the old instance was cleaned up and re-initialized
+	}{
+		{"invalid binary", []byte("not a wasm module"), "failed to instantiate", false},
+		{"failing on_init", lifecycleModule(2, 1), "failed to initialize", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile(path, tt.binary, 0644); err != nil {
+				t.Fatalf("WriteFile failed: %v", err)
+			}
+			changed, err := r.ReloadPlugin(ctx, id, "")
+			if err == nil || changed || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected %q error, got changed=%v err=%v", tt.want, changed, err)
+			}
+
+			if v := pluginVersion(t, r, id); v != 1 {
+				t.Errorf("expected old module to keep serving, got version %d", v)
+			}
+			if loaded, _ := r.GetPlugin(id); loaded.Hash != hash {
+				t.Error("expected plugin hash to be unchanged")
+			}
+			if subs := r.Subscriptions(id); len(subs) != 1 || subs[0] != "metric.*" {
+				t.Errorf("expected subscriptions to be kept, got %v", subs)
+			}
+			if ids := drainCleanupEvents(r); (len(ids) == 1) != tt.cleanup {
+				t.Errorf("unexpected cleanup events: %v", ids)
+			}
+		})
+	}
+
+	if err := os.WriteFile(path, lifecycleModule(2, 0), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if _, err := r.ReloadPlugin(ctx, id, strings.Repeat("0", 64)); err == nil || !strings.Contains(err.Error(), "hash mismatch") {
+		t.Errorf("expected hash mismatch error, got %v", err)
+	}
+	if v := pluginVersion(t, r, id); v != 1 {
+		t.Errorf("expected old module after hash mismatch, got version %d", v)
+	}
+}
+
+func TestRuntime_LifecycleOnLoadAndUnload(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRuntime(t, nil)
+	dir := t.TempDir()
+
+	failing := filepath.Join(dir, "failing.wasm")
+	if err := os.WriteFile(failing, lifecycleModule(1, 1), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	bad := domain.NewPlugin("failing", "0.1.0", failing)
+	if err := r.LoadPlugin(ctx, bad); err == nil {
+		t.Fatal("expected LoadPlugin to fail when on_init fails")
+	}
+	if len(r.ListLoadedPlugins()) != 0 || len(r.Subscriptions(bad.ID.String())) != 0 {
+		t.Error("expected failed plugin to leave nothing behind")
+	}
+
+	path := filepath.Join(dir, "ok.wasm")
+	if err := os.WriteFile(path, lifecycleModule(1, 0), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	plugin := domain.NewPlugin("ok", "0.1.0", path)
+	if err := r.LoadPlugin(ctx, plugin); err != nil {
+		t.Fatalf("LoadPlugin failed: %v", err)
+	}
+	if err := r.UnloadPlugin(ctx, plugin.ID.String()); err != nil {
+		t.Fatalf("UnloadPlugin failed: %v", err)
+	}
+	if ids := drainCleanupEvents(r); len(ids) != 1 || ids[0] != plugin.ID.String() {
+		t.Errorf("expected on_cleanup on unload, got %v", ids)
+	}
+}
@@ -6,13 +6,15 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
-	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/forge-platform/forge/internal/core/domain"
@@ -29,11 +31,47 @@ type Runtime struct {
 	mu         sync.RWMutex
 	logger     ports.Logger
 	httpClient *http.Client
-	dataDir    string                 // Base directory for plugin data
-	config     map[string]string      // Plugin configuration
+	dataDir    string            // Base directory for plugin data
+	config     map[string]string // Plugin configuration, guarded by configMu
+	configMu   sync.RWMutex
 	eventBus   chan PluginEvent       // Event bus for inter-plugin communication
 	allocator  *PluginMemoryAllocator // Memory allocator for plugin responses
 	metricSvc  ports.MetricService    // Metric service for recording plugin metrics
+	metricRepo ports.MetricRepository // Metric repository for plugin queries
+	queries    *queryLimiter          // Per-plugin metric query rate limit
+	secrets    *SecretStore           // Encrypted secrets read by forge_get_secret
+	redactor   *secretRedactor        // Secret values to keep out of plugin logs
+
+	pluginConfigs map[string]map[string]string // Configuration per plugin ID, guarded by configMu
+
+	lifecycleMu sync.Mutex    // Serializes loading, unloading and reloading
+	reloads     uint64        // Reload generation, used to name replacement modules
+	restarts    atomic.Uint64 // Restart generation for interrupted instances
+
+	maxMemoryPages uint32        // Linear memory limit per plugin instance
+	maxExecTime    time.Duration // Time budget for a single call into a plugin (0 = none)
+	maxViolations  int           // Violations before a plugin is disabled (0 = never)
+
+	hostPolicy     *HostPolicy            // Global HTTP host allow-list
+	pluginPolicies map[string]*HostPolicy // Effective host policy per plugin ID
+	secretScopes   map[string]string      // Plugin name its secrets are stored under, per plugin ID
+	policyMu       sync.RWMutex
+
+	subscriptions map[string][]string // Event patterns per plugin ID
+	subMu         sync.RWMutex
+	dispatchOnce  sync.Once
+	closed        bool
+
+	eventOverflow     EventOverflowPolicy // What to do with events emitted while the bus is full
+	eventBlockTimeout time.Duration       // Wait for room under the block and backpressure policies
+	droppedEvents     atomic.Uint64       // Events lost to a full bus
+	busMu             sync.RWMutex        // Held to send on the bus, exclusively to close it
+	busClosed         bool
+
+	storageQuota int64             // Per-plugin storage limit in bytes (<0 = unlimited)
+	storageDirs  map[string]string // Data directory per plugin ID
+	storageMu    sync.RWMutex
+	writeMu      sync.Mutex // Serializes quota checks with writes
 }
 
 // PluginEvent represents an event emitted by a plugin.
@@ -43,17 +81,17 @@ type PluginEvent struct {
 	Payload   []byte
 }
 
-// PluginMemoryAllocator manages memory allocation for plugin responses.
-type PluginMemoryAllocator struct {
-	memory map[uint32][]byte
-	nextID uint32
-}
-
 // LoadedPlugin represents a loaded WebAssembly plugin.
 type LoadedPlugin struct {
 	Plugin  *domain.Plugin
 	Module  api.Module
 	Exports map[string]api.Function
+
+	callMu         sync.Mutex // Serializes calls into the module
+	compiled       wazero.CompiledModule
+	configSchema   *domain.PluginConfigSchema // From the plugin's manifest, nil if none
+	disabled       bool                       // Set once the plugin exceeded its limits too often
+	disabledReason string
 }
 
 // NewRuntime creates a new WebAssembly runtime.
@@ -63,18 +101,44 @@ func NewRuntime(ctx context.Context, logger ports.Logger) (*Runtime, error) {
 
 // RuntimeOptions configures the WASM runtime.
 type RuntimeOptions struct {
-	DataDir       string            // Base directory for plugin data (default: ~/.forge/plugins/data)
-	Config        map[string]string // Plugin configuration
-	HTTPTimeout   time.Duration     // HTTP request timeout (default: 30s)
-	AllowedHosts  []string          // Allowed hosts for HTTP requests (empty = all)
-	EventBufSize  int               // Event bus buffer size (default: 100)
-	MetricSvc     ports.MetricService // Metric service
+	DataDir      string                 // Base directory for plugin data (default: ~/.forge/plugins/data)
+	StorageQuota int64                  // Per-plugin storage quota in bytes (default: 50MB, negative = unlimited)
+	Config       map[string]string      // Plugin configuration
+	HTTPTimeout  time.Duration          // HTTP request timeout (default: 30s)
+	AllowedHosts []string               // Allowed hosts for HTTP requests (empty = all public hosts)
+	EventBufSize int                    // Event bus buffer size (default: 100)
+	MetricSvc    ports.MetricService    // Metric service
+	MetricRepo   ports.MetricRepository // Metric repository read by forge_metric_query
+	Secrets      *SecretStore           // Secret store read by forge_get_secret
+
+	MetricQueryLimit int // Metric queries per plugin per minute (default: 60, negative = unlimited)
+
+	EventOverflow     EventOverflowPolicy // Policy when the event bus is full (default: drop-newest)
+	EventBlockTimeout time.Duration       // Wait for room on a full bus when blocking (default: 1s)
+
+	MaxMemoryPages   uint32        // Linear memory limit per plugin in 64KiB pages (default: 1024)
+	MaxExecutionTime time.Duration // Time budget per plugin call (default: 10s, negative = unlimited)
+	MaxViolations    int           // Time budget violations before a plugin is disabled (default: 3, negative = never)
 }
 
 // NewRuntimeWithOptions creates a new WebAssembly runtime with options.
 func NewRuntimeWithOptions(ctx context.Context, logger ports.Logger, opts RuntimeOptions) (*Runtime, error) {
-	// Create runtime with AOT compilation for better performance
-	r := wazero.NewRuntime(ctx)
+	if opts.MaxMemoryPages == 0 {
+		opts.MaxMemoryPages = DefaultMaxMemoryPages
+	}
+	if opts.MaxExecutionTime == 0 {
+		opts.MaxExecutionTime = DefaultMaxExecutionTime
+	}
+	if opts.MaxViolations == 0 {
+		opts.MaxViolations = DefaultMaxViolations
+	}
+
+	// Create runtime with AOT compilation for better performance. Guest calls
+	// are aborted when their context is done, so callers can bound slow
+	// plugins with a deadline; wazero closes the interrupted module instance.
+	r := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithCloseOnContextDone(true).
+		WithMemoryLimitPages(opts.MaxMemoryPages))
 
 	// Instantiate WASI for basic system calls
 	if _, err := wasi_snapshot_preview1.Instantiate(ctx, r); err != nil {
@@ -84,8 +148,10 @@ func NewRuntimeWithOptions(ctx context.Context, logger ports.Logger, opts Runtim
 
 	// Set defaults
 	if opts.DataDir == "" {
-		home, _ := os.UserHomeDir()
-		opts.DataDir = filepath.Join(home, ".forge", "plugins", "data")
+		opts.DataDir = DefaultPluginDataDir()
+	}
+	if opts.StorageQuota == 0 {
+		opts.StorageQuota = DefaultStorageQuota
 	}
 	if opts.HTTPTimeout == 0 {
 		opts.HTTPTimeout = 30 * time.Second
@@ -93,9 +159,26 @@ func NewRuntimeWithOptions(ctx context.Context, logger ports.Logger, opts Runtim
 	if opts.EventBufSize == 0 {
 		opts.EventBufSize = 100
 	}
+	eventOverflow, err := ParseEventOverflowPolicy(string(opts.EventOverflow))
+	if err != nil {
+		r.Close(ctx)
+		return nil, err
+	}
+	if opts.EventBlockTimeout <= 0 {
+		opts.EventBlockTimeout = DefaultEventBlockTimeout
+	}
 	if opts.Config == nil {
 		opts.Config = make(map[string]string)
 	}
+	if opts.MetricQueryLimit == 0 {
+		opts.MetricQueryLimit = DefaultMetricQueryLimit
+	}
+
+	hostPolicy, err := NewHostPolicy(opts.AllowedHosts)
+	if err != nil {
+		r.Close(ctx)
+		return nil, fmt.Errorf("invalid allowed hosts: %w", err)
+	}
 
 	// Create data directory
 	if err := os.MkdirAll(opts.DataDir, 0755); err != nil {
@@ -104,20 +187,32 @@ func NewRuntimeWithOptions(ctx context.Context, logger ports.Logger, opts Runtim
 	}
 
 	runtime := &Runtime{
-		runtime: r,
-		modules: make(map[string]*LoadedPlugin),
-		logger:  logger,
-		httpClient: &http.Client{
-			Timeout: opts.HTTPTimeout,
-		},
-		dataDir:  opts.DataDir,
-		config:   opts.Config,
-		eventBus: make(chan PluginEvent, opts.EventBufSize),
-		allocator: &PluginMemoryAllocator{
-			memory: make(map[uint32][]byte),
-			nextID: 1,
-		},
-		metricSvc: opts.MetricSvc,
+		runtime:        r,
+		modules:        make(map[string]*LoadedPlugin),
+		logger:         logger,
+		httpClient:     newPluginHTTPClient(opts.HTTPTimeout),
+		dataDir:        opts.DataDir,
+		config:         copyConfig(opts.Config),
+		pluginConfigs:  make(map[string]map[string]string),
+		eventBus:       make(chan PluginEvent, opts.EventBufSize),
+		allocator:      NewPluginMemoryAllocator(),
+		metricSvc:      opts.MetricSvc,
+		metricRepo:     opts.MetricRepo,
+		queries:        newQueryLimiter(max(opts.MetricQueryLimit, 0), MetricQueryWindow),
+		secrets:        opts.Secrets,
+		redactor:       newSecretRedactor(),
+		hostPolicy:     hostPolicy,
+		pluginPolicies: make(map[string]*HostPolicy),
+		secretScopes:   make(map[string]string),
+		storageQuota:   opts.StorageQuota,
+		storageDirs:    make(map[string]string),
+		subscriptions:  make(map[string][]string),
+		maxMemoryPages: opts.MaxMemoryPages,
+		maxExecTime:    max(opts.MaxExecutionTime, 0),
+		maxViolations:  max(opts.MaxViolations, 0),
+
+		eventOverflow:     eventOverflow,
+		eventBlockTimeout: opts.EventBlockTimeout,
 	}
 
 	// Register host functions
@@ -141,10 +236,16 @@ func (r *Runtime) registerHostFunctions(ctx context.Context) error {
 		NewFunctionBuilder().
 		WithFunc(r.hostMetricRecord).
 		Export("forge_metric_record").
+		NewFunctionBuilder().
+		WithFunc(r.hostMetricQuery).
+		Export("forge_metric_query").
 		// Configuration
 		NewFunctionBuilder().
 		WithFunc(r.hostGetConfig).
 		Export("forge_get_config").
+		NewFunctionBuilder().
+		WithFunc(r.hostGetSecret).
+		Export("forge_get_secret").
 		// HTTP (new capability)
 		NewFunctionBuilder().
 		WithFunc(r.hostHTTPRequest).
@@ -153,6 +254,9 @@ func (r *Runtime) registerHostFunctions(ctx context.Context) error {
 		NewFunctionBuilder().
 		WithFunc(r.hostEmitEvent).
 		Export("forge_emit_event").
+		NewFunctionBuilder().
+		WithFunc(r.hostSubscribe).
+		Export("forge_subscribe").
 		// Filesystem (new capability)
 		NewFunctionBuilder().
 		WithFunc(r.hostReadFile).
@@ -160,6 +264,10 @@ func (r *Runtime) registerHostFunctions(ctx context.Context) error {
 		NewFunctionBuilder().
 		WithFunc(r.hostWriteFile).
 		Export("forge_write_file").
+		// Memory
+		NewFunctionBuilder().
+		WithFunc(r.hostFree).
+		Export("forge_free").
 		Instantiate(ctx)
 
 	return err
@@ -173,7 +281,8 @@ func (r *Runtime) hostLog(ctx context.Context, m api.Module, level, ptr, length
 		return
 	}
 
-	msg := string(data)
+	// Plugins may log the secrets they were given
+	msg := r.redactor.Redact(string(data))
 	switch level {
 	case 0:
 		r.logger.Debug(msg)
@@ -195,7 +304,7 @@ func (r *Runtime) hostMetricRecord(ctx context.Context, m api.Module, keyPtr, ke
 
 	metricName := string(data)
 	r.logger.Debug("Plugin recorded metric", "name", metricName, "value", value)
-	
+
 	if r.metricSvc != nil {
 		err := r.metricSvc.Record(ctx, metricName, domain.MetricTypeGauge, value, map[string]string{"source": "plugin"})
 		if err != nil {
@@ -204,6 +313,60 @@ func (r *Runtime) hostMetricRecord(ctx context.Context, m api.Module, keyPtr, ke
 	}
 }
 
+// Host function: forge_metric_query(name_ptr i32, name_len i32, start i64, end i64)
+//
+//	-> (ptr i32, len i32, err_code i32)
+//
+// start and end are Unix milliseconds. The points are returned as a JSON
+// array of {"timestamp", "value"} objects, oldest first.
+func (r *Runtime) hostMetricQuery(ctx context.Context, m api.Module,
+	namePtr, nameLen uint32, start, end int64) (uint32, uint32, int32) {
+
+	nameData, ok := m.Memory().Read(namePtr, nameLen)
+	if !ok || len(nameData) == 0 {
+		return 0, 0, -1
+	}
+	if end < start {
+		return 0, 0, -2
+	}
+	if r.metricRepo == nil {
+		return 0, 0, -3
+	}
+
+	pluginID := pluginIDOf(m)
+	if !r.queries.Allow(pluginID) {
+		r.logger.Warn("Plugin metric query rate limited", "plugin", pluginID)
+		return 0, 0, ErrCodeRateLimited
+	}
+
+	series, err := r.metricRepo.Query(ctx, ports.MetricQuery{
+		Name:      string(nameData),
+		StartTime: time.UnixMilli(start),
+		EndTime:   time.UnixMilli(end),
+		Limit:     MaxMetricQueryPoints,
+	})
+	if err != nil {
+		r.logger.Error("Plugin metric query failed", "plugin", pluginID, "error", err)
+		return 0, 0, -4
+	}
+
+	points := make([]pluginMetricPoint, len(series.Points))
+	for i, p := range series.Points {
+		points[i] = pluginMetricPoint{Timestamp: p.Timestamp.UnixMilli(), Value: p.Value}
+	}
+	data, err := json.Marshal(points)
+	if err != nil {
+		r.logger.Error("Failed to encode plugin metric query", "plugin", pluginID, "error", err)
+		return 0, 0, -4
+	}
+
+	ptr, length := r.writeToPluginMemory(m, data)
+	if length == 0 {
+		return 0, 0, -5
+	}
+	return ptr, length, 0
+}
+
 // Host function: forge_get_config(key_ptr i32, key_len i32) -> (ptr i32, len i32)
 func (r *Runtime) hostGetConfig(ctx context.Context, m api.Module, keyPtr, keyLen uint32) (uint32, uint32) {
 	// Read config key from plugin memory
@@ -212,7 +375,7 @@ func (r *Runtime) hostGetConfig(ctx context.Context, m api.Module, keyPtr, keyLe
 		return 0, 0
 	}
 
-	value, exists := r.config[string(data)]
+	value, exists := r.pluginConfigValue(pluginIDOf(m), string(data))
 	if !exists {
 		return 0, 0
 	}
@@ -221,6 +384,38 @@ func (r *Runtime) hostGetConfig(ctx context.Context, m api.Module, keyPtr, keyLe
 	return r.writeToPluginMemory(m, []byte(value))
 }
 
+// Host function: forge_get_secret(key_ptr i32, key_len i32) -> (ptr i32, len i32)
+//
+// Plugins only see their own secrets, set as <plugin>/<key>. The secret is
+// decrypted for each call and its value is never logged.
+func (r *Runtime) hostGetSecret(ctx context.Context, m api.Module, keyPtr, keyLen uint32) (uint32, uint32) {
+	data, ok := m.Memory().Read(keyPtr, keyLen)
+	if !ok || r.secrets == nil {
+		return 0, 0
+	}
+
+	key := string(data)
+	r.policyMu.RLock()
+	scope := r.secretScopes[pluginIDOf(m)]
+	r.policyMu.RUnlock()
+	if scope == "" || !validSecretKey(key) {
+		r.logger.Warn("Rejected plugin secret read", "plugin", pluginIDOf(m), "key", key)
+		return 0, 0
+	}
+	value, exists, err := r.secrets.Get(SecretName(scope, key))
+	if err != nil {
+		r.logger.Error("Failed to read plugin secret", "plugin", pluginIDOf(m), "key", key, "error", err)
+		return 0, 0
+	}
+	if !exists {
+		return 0, 0
+	}
+	r.redactor.Add(value)
+	r.logger.Debug("Plugin read secret", "plugin", pluginIDOf(m), "key", key)
+
+	return r.writeToPluginMemory(m, []byte(value))
+}
+
 // Host function: forge_http_request(method_ptr, method_len, url_ptr, url_len, body_ptr, body_len i32)
 //
 //	-> (status_code i32, resp_ptr i32, resp_len i32)
@@ -250,6 +445,27 @@ func (r *Runtime) hostHTTPRequest(ctx context.Context, m api.Module,
 		}
 	}
 
+	policy := r.policyFor(pluginIDOf(m))
+	statusCode, respBody := r.doHTTPRequest(ctx, policy, method, url, body)
+	if statusCode < 0 {
+		return statusCode, 0, 0
+	}
+
+	// Write response to plugin memory
+	respPtr, respLen := r.writeToPluginMemory(m, respBody)
+	return statusCode, respPtr, respLen
+}
+
+// doHTTPRequest performs an HTTP request on behalf of a plugin, enforcing
+// its host policy on the initial URL, every redirect and every resolved
+// address. It returns the status code and body, or a negative error code.
+func (r *Runtime) doHTTPRequest(ctx context.Context, policy *HostPolicy, method, url string, body []byte) (int32, []byte) {
+	if !policy.AllowsURL(url) {
+		r.logger.Warn("Plugin HTTP request blocked", "url", url)
+		return ErrCodeHostNotAllowed, nil
+	}
+	ctx = withHostPolicy(ctx, policy)
+
 	// Create and execute request
 	var req *http.Request
 	var err error
@@ -260,13 +476,17 @@ func (r *Runtime) hostHTTPRequest(ctx context.Context, m api.Module,
 	}
 	if err != nil {
 		r.logger.Error("Failed to create HTTP request", "error", err)
-		return -4, 0, 0
+		return -4, nil
 	}
 
 	resp, err := r.httpClient.Do(req)
 	if err != nil {
+		if errors.Is(err, ErrHostNotAllowed) {
+			r.logger.Warn("Plugin HTTP request blocked", "url", url, "error", err)
+			return ErrCodeHostNotAllowed, nil
+		}
 		r.logger.Error("HTTP request failed", "error", err)
-		return -5, 0, 0
+		return -5, nil
 	}
 	defer resp.Body.Close()
 
@@ -274,12 +494,52 @@ func (r *Runtime) hostHTTPRequest(ctx context.Context, m api.Module,
 	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 10*1024*1024))
 	if err != nil {
 		r.logger.Error("Failed to read response", "error", err)
-		return -6, 0, 0
+		return -6, nil
 	}
 
-	// Write response to plugin memory
-	respPtr, respLen := r.writeToPluginMemory(m, respBody)
-	return int32(resp.StatusCode), respPtr, respLen
+	return int32(resp.StatusCode), respBody
+}
+
+// policyFor returns the host policy for the plugin instantiated under the
+// given module name. Unknown modules get no network access.
+func (r *Runtime) policyFor(moduleName string) *HostPolicy {
+	r.policyMu.RLock()
+	defer r.policyMu.RUnlock()
+
+	if policy, ok := r.pluginPolicies[moduleName]; ok {
+		return policy
+	}
+	return DenyAllHostPolicy()
+}
+
+// applyPermissions registers a plugin's host policy and the name its
+// secrets are scoped to. A plugin loaded without permissions, as plugins
+// installed from a path or URL are, takes the permissions and allowed hosts
+// declared in its manifest.
+func (r *Runtime) applyPermissions(plugin *domain.Plugin, manifest *pluginManifest) error {
+	if len(plugin.Permissions) == 0 && manifest != nil {
+		plugin.Permissions = append([]domain.PluginPermission{}, manifest.Permissions...)
+		plugin.AllowedHosts = append([]string(nil), manifest.AllowedHosts...)
+	}
+	policy, err := r.pluginHostPolicy(plugin)
+	if err != nil {
+		return fmt.Errorf("invalid allowed hosts: %w", err)
+	}
+	r.policyMu.Lock()
+	r.pluginPolicies[plugin.ID.String()] = policy
+	r.secretScopes[plugin.ID.String()] = plugin.Name
+	r.policyMu.Unlock()
+	return nil
+}
+
+// pluginHostPolicy derives a plugin's effective host policy from the global
+// allow-list and the permissions declared in its manifest. Plugins without
+// the network permission cannot make HTTP requests at all.
+func (r *Runtime) pluginHostPolicy(plugin *domain.Plugin) (*HostPolicy, error) {
+	if !plugin.HasPermission(domain.PermissionNetwork) {
+		return DenyAllHostPolicy(), nil
+	}
+	return r.hostPolicy.Restrict(plugin.AllowedHosts)
 }
 
 // Host function: forge_emit_event(type_ptr, type_len, payload_ptr, payload_len i32) -> err_code i32
@@ -302,15 +562,15 @@ func (r *Runtime) hostEmitEvent(ctx context.Context, m api.Module,
 		}
 	}
 
-	// Send to event bus (non-blocking)
-	select {
-	case r.eventBus <- PluginEvent{EventType: eventType, Payload: payload}:
-		r.logger.Debug("Event emitted", "type", eventType)
-		return 0
-	default:
-		r.logger.Warn("Event bus full, dropping event", "type", eventType)
+	// Send to event bus, applying the overflow policy if it is full. The
+	// payload is copied out of guest memory because it is delivered after
+	// this call returns.
+	event := PluginEvent{PluginID: pluginIDOf(m), EventType: eventType, Payload: append([]byte(nil), payload...)}
+	if err := r.queueEvent(ctx, event); err != nil {
 		return -3
 	}
+	r.logger.Debug("Event emitted", "type", eventType)
+	return 0
 }
 
 // Host function: forge_read_file(path_ptr, path_len i32) -> (data_ptr, data_len i32, err_code i32)
@@ -322,31 +582,34 @@ func (r *Runtime) hostReadFile(ctx context.Context, m api.Module,
 	if !ok {
 		return 0, 0, -1
 	}
-	path := string(pathData)
 
-	// Sanitize path - prevent directory traversal
-	cleanPath := filepath.Clean(path)
-	if strings.HasPrefix(cleanPath, "..") || filepath.IsAbs(cleanPath) {
-		r.logger.Warn("Invalid file path", "path", path)
-		return 0, 0, -2
+	data, code := r.readPluginFile(pluginIDOf(m), string(pathData))
+	if code != 0 {
+		return 0, 0, code
 	}
 
-	// Build full path within data directory
-	fullPath := filepath.Join(r.dataDir, cleanPath)
+	// Write to plugin memory
+	dataPtr, dataLen := r.writeToPluginMemory(m, data)
+	return dataPtr, dataLen, 0
+}
+
+// readPluginFile reads a file from the calling plugin's data directory.
+func (r *Runtime) readPluginFile(moduleName, path string) ([]byte, int32) {
+	fullPath, err := r.pluginFilePath(moduleName, path)
+	if err != nil {
+		r.logger.Warn("Invalid file path", "plugin", moduleName, "path", path, "error", err)
+		return nil, -2
+	}
 
-	// Read file
 	data, err := os.ReadFile(fullPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return 0, 0, -3
+			return nil, -3
 		}
 		r.logger.Error("Failed to read file", "path", fullPath, "error", err)
-		return 0, 0, -4
+		return nil, -4
 	}
-
-	// Write to plugin memory
-	dataPtr, dataLen := r.writeToPluginMemory(m, data)
-	return dataPtr, dataLen, 0
+	return data, 0
 }
 
 // Host function: forge_write_file(path_ptr, path_len, data_ptr, data_len i32) -> err_code i32
@@ -358,14 +621,6 @@ func (r *Runtime) hostWriteFile(ctx context.Context, m api.Module,
 	if !ok {
 		return -1
 	}
-	path := string(pathData)
-
-	// Sanitize path
-	cleanPath := filepath.Clean(path)
-	if strings.HasPrefix(cleanPath, "..") || filepath.IsAbs(cleanPath) {
-		r.logger.Warn("Invalid file path", "path", path)
-		return -2
-	}
 
 	// Read data
 	data, ok := m.Memory().Read(dataPtr, dataLen)
@@ -373,8 +628,37 @@ func (r *Runtime) hostWriteFile(ctx context.Context, m api.Module,
 		return -3
 	}
 
-	// Build full path
-	fullPath := filepath.Join(r.dataDir, cleanPath)
+	return r.writePluginFile(pluginIDOf(m), string(pathData), data)
+}
+
+// writePluginFile writes a file into the calling plugin's data directory,
+// enforcing the per-plugin storage quota.
+func (r *Runtime) writePluginFile(moduleName, path string, data []byte) int32 {
+	fullPath, err := r.pluginFilePath(moduleName, path)
+	if err != nil {
+		r.logger.Warn("Invalid file path", "plugin", moduleName, "path", path, "error", err)
+		return -2
+	}
+
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	if r.storageQuota > 0 {
+		used, _, err := PluginStorageUsage(r.storageDir(moduleName))
+		if err != nil {
+			r.logger.Error("Failed to compute storage usage", "plugin", moduleName, "error", err)
+			return -4
+		}
+		// Overwriting a file frees its current size
+		if info, err := os.Stat(fullPath); err == nil && info.Mode().IsRegular() {
+			used -= info.Size()
+		}
+		if used+int64(len(data)) > r.storageQuota {
+			r.logger.Warn("Plugin storage quota exceeded", "plugin", moduleName,
+				"used", used, "write", len(data), "quota", r.storageQuota)
+			return ErrCodeStorageQuotaExceeded
+		}
+	}
 
 	// Create directory if needed
 	dir := filepath.Dir(fullPath)
@@ -393,30 +677,60 @@ func (r *Runtime) hostWriteFile(ctx context.Context, m api.Module,
 	return 0
 }
 
-// writeToPluginMemory writes data to plugin memory and returns the pointer and length.
-// For simplicity, this allocates new memory in the plugin's linear memory.
-func (r *Runtime) writeToPluginMemory(m api.Module, data []byte) (uint32, uint32) {
-	if len(data) == 0 {
-		return 0, 0
+// pluginFilePath resolves a guest path inside the data directory of the
+// plugin identified by its module name. The directory is never taken from the guest.
+func (r *Runtime) pluginFilePath(moduleName, path string) (string, error) {
+	dir := r.storageDir(moduleName)
+	if dir == "" {
+		return "", fmt.Errorf("no storage registered for module %q", moduleName)
 	}
+	return resolvePluginPath(dir, path)
+}
 
-	// Try to find and call the plugin's malloc function
-	malloc := m.ExportedFunction("malloc")
-	if malloc == nil {
-		// Fallback: use a simple allocation scheme at the end of memory
-		// This is not ideal but works for simple cases
-		r.logger.Debug("Plugin does not export malloc, using fallback allocation")
-		return 0, 0
+// storageDir returns the data directory registered for a plugin module.
+func (r *Runtime) storageDir(moduleName string) string {
+	r.storageMu.RLock()
+	defer r.storageMu.RUnlock()
+	return r.storageDirs[moduleName]
+}
+
+// pluginStorageDir returns the data directory for a plugin, keyed on its
+// name and falling back to its ID when the name is not a safe directory name.
+func (r *Runtime) pluginStorageDir(plugin *domain.Plugin) string {
+	dir, err := PluginStorageDir(r.dataDir, plugin.Name)
+	if err != nil {
+		r.logger.Warn("Plugin name unsuitable for storage, using ID", "name", plugin.Name)
+		return filepath.Join(r.dataDir, plugin.ID.String())
 	}
+	return dir
+}
 
-	// Allocate memory in plugin
-	results, err := malloc.Call(context.Background(), uint64(len(data)))
-	if err != nil || len(results) == 0 {
-		r.logger.Error("Failed to allocate plugin memory", "error", err)
+// writeToPluginMemory writes data to plugin memory and returns the pointer
+// and length. Memory comes from the plugin's malloc export, or from the
+// runtime's scratch buffers for plugins without one; see
+// PluginMemoryAllocator for the ABI contract.
+func (r *Runtime) writeToPluginMemory(m api.Module, data []byte) (uint32, uint32) {
+	if len(data) == 0 {
 		return 0, 0
 	}
 
-	ptr := uint32(results[0])
+	var ptr uint32
+	if malloc := m.ExportedFunction("malloc"); malloc != nil {
+		results, err := malloc.Call(context.Background(), uint64(len(data)))
+		if err != nil || len(results) == 0 {
+			r.logger.Error("Failed to allocate plugin memory", "error", err)
+			return 0, 0
+		}
+		ptr = uint32(results[0])
+	} else {
+		offset, err := r.allocator.Allocate(m, uint32(len(data)))
+		if err != nil {
+			r.logger.Error("Failed to allocate plugin memory", "error", err)
+			return 0, 0
+		}
+		ptr = offset
+	}
+
 	if !m.Memory().Write(ptr, data) {
 		r.logger.Error("Failed to write to plugin memory")
 		return 0, 0
@@ -425,10 +739,17 @@ func (r *Runtime) writeToPluginMemory(m api.Module, data []byte) (uint32, uint32
 	return ptr, uint32(len(data))
 }
 
-// LoadPlugin loads a WebAssembly plugin.
+// Host function: forge_free(ptr i32)
+// Releases a response buffer the runtime allocated without malloc before
+// the current call returns, so it can be reused. Other pointers are ignored.
+func (r *Runtime) hostFree(ctx context.Context, m api.Module, ptr uint32) {
+	r.allocator.Release(m.Name(), ptr)
+}
+
+// LoadPlugin loads a WebAssembly plugin and calls its on_init export.
 func (r *Runtime) LoadPlugin(ctx context.Context, plugin *domain.Plugin) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
+	r.lifecycleMu.Lock()
+	defer r.lifecycleMu.Unlock()
 
 	// Read the WASM binary
 	wasmBytes, err := os.ReadFile(plugin.Path)
@@ -444,45 +765,135 @@ func (r *Runtime) LoadPlugin(ctx context.Context, plugin *domain.Plugin) error {
 	}
 	plugin.Hash = hashStr
 
-	// Compile and instantiate the module
-	module, err := r.runtime.Instantiate(ctx, wasmBytes)
+	// The module is named after the plugin ID so host functions can identify the caller
+	pluginID := plugin.ID.String()
+	r.storageMu.Lock()
+	r.storageDirs[pluginID] = r.pluginStorageDir(plugin)
+	r.storageMu.Unlock()
+
+	// Compile and instantiate the module. The compiled module is kept so an
+	// instance interrupted for running too long can be recreated.
+	compiled, err := r.runtime.CompileModule(ctx, wasmBytes)
 	if err != nil {
+		r.forgetPlugin(pluginID)
 		return fmt.Errorf("failed to instantiate plugin: %w", err)
 	}
-
-	// Collect exported functions
-	exports := make(map[string]api.Function)
-	for name, fn := range module.ExportedFunctionDefinitions() {
-		exports[name] = module.ExportedFunction(fn.Name())
+	if err := checkMemoryLimit(compiled, r.maxMemoryPages); err != nil {
+		compiled.Close(ctx)
+		r.forgetPlugin(pluginID)
+		return fmt.Errorf("failed to instantiate plugin: %w", err)
+	}
+	module, err := r.runtime.InstantiateModule(ctx, compiled, wazero.NewModuleConfig().WithName(pluginID))
+	if err != nil {
+		compiled.Close(ctx)
+		r.forgetPlugin(pluginID)
+		return fmt.Errorf("failed to instantiate plugin: %w", err)
 	}
 
-	r.modules[plugin.ID.String()] = &LoadedPlugin{
-		Plugin:  plugin,
-		Module:  module,
-		Exports: exports,
+	loaded := &LoadedPlugin{
+		Plugin:   plugin,
+		Module:   module,
+		Exports:  moduleExports(module),
+		compiled: compiled,
+	}
+	// Until its policy is registered from the manifest the plugin has no
+	// network access
+	loaded.callMu.Lock()
+	manifest, err := r.readManifest(ctx, loaded)
+	if err == nil {
+		err = r.applyPermissions(plugin, manifest)
+	}
+	if err == nil {
+		err = r.loadConfig(ctx, loaded, manifest)
+	}
+	loaded.callMu.Unlock()
+	if err != nil {
+		r.allocator.Forget(module.Name())
+		module.Close(ctx)
+		compiled.Close(ctx)
+		r.forgetPlugin(pluginID)
+		return fmt.Errorf("failed to configure plugin %s: %w", plugin.Name, err)
+	}
+	r.setPluginConfig(pluginID, plugin.Config)
+
+	if err := r.callLifecycle(ctx, loaded, pluginInitExport); err != nil {
+		r.allocator.Forget(module.Name())
+		module.Close(ctx)
+		compiled.Close(ctx)
+		r.forgetPlugin(pluginID)
+		return fmt.Errorf("failed to initialize plugin: %w", err)
 	}
 
+	r.mu.Lock()
+	r.modules[pluginID] = loaded
 	plugin.MarkLoaded()
+	r.mu.Unlock()
 	r.logger.Info("Plugin loaded", "name", plugin.Name, "version", plugin.Version)
 
 	return nil
 }
 
-// UnloadPlugin unloads a plugin from the runtime.
+// forgetPlugin drops the host policy, storage mapping, configuration and
+// event subscriptions of an unloaded plugin.
+func (r *Runtime) forgetPlugin(pluginID string) {
+	r.policyMu.Lock()
+	delete(r.pluginPolicies, pluginID)
+	delete(r.secretScopes, pluginID)
+	r.policyMu.Unlock()
+
+	r.storageMu.Lock()
+	delete(r.storageDirs, pluginID)
+	r.storageMu.Unlock()
+
+	r.subMu.Lock()
+	delete(r.subscriptions, pluginID)
+	r.subMu.Unlock()
+
+	r.configMu.Lock()
+	delete(r.pluginConfigs, pluginID)
+	r.configMu.Unlock()
+
+	r.queries.Forget(pluginID)
+}
+
+// moduleExports collects the exported functions of a module by export name.
+func moduleExports(module api.Module) map[string]api.Function {
+	exports := make(map[string]api.Function)
+	for name := range module.ExportedFunctionDefinitions() {
+		exports[name] = module.ExportedFunction(name)
+	}
+	return exports
+}
+
+// UnloadPlugin calls the plugin's on_cleanup export and unloads it from the runtime.
 func (r *Runtime) UnloadPlugin(ctx context.Context, pluginID string) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
+	r.lifecycleMu.Lock()
+	defer r.lifecycleMu.Unlock()
 
+	r.mu.RLock()
 	loaded, ok := r.modules[pluginID]
+	r.mu.RUnlock()
 	if !ok {
 		return fmt.Errorf("plugin not loaded: %s", pluginID)
 	}
 
+	// r.mu is not held during cleanup: calls into the plugin take it to
+	// record limit violations
+	loaded.callMu.Lock()
+	defer loaded.callMu.Unlock()
+	if err := r.callLifecycleLocked(ctx, loaded, pluginCleanupExport); err != nil {
+		r.logger.Warn("Plugin cleanup failed", "name", loaded.Plugin.Name, "error", err)
+	}
+	r.allocator.Forget(loaded.Module.Name())
 	if err := loaded.Module.Close(ctx); err != nil {
 		return fmt.Errorf("failed to close module: %w", err)
 	}
+	loaded.compiled.Close(ctx)
 
+	r.mu.Lock()
 	delete(r.modules, pluginID)
+	r.mu.Unlock()
+	r.forgetPlugin(pluginID)
 	r.logger.Info("Plugin unloaded", "id", pluginID)
 
 	return nil
@@ -498,11 +909,6 @@ func (r *Runtime) CallFunction(ctx context.Context, pluginID, funcName string, a
 		return nil, fmt.Errorf("plugin not loaded: %s", pluginID)
 	}
 
-	fn, ok := loaded.Exports[funcName]
-	if !ok || fn == nil {
-		return nil, fmt.Errorf("function not found: %s", funcName)
-	}
-
 	// Convert args to uint64 for wazero
 	wasmArgs := make([]uint64, len(args))
 	for i, arg := range args {
@@ -526,7 +932,9 @@ func (r *Runtime) CallFunction(ctx context.Context, pluginID, funcName string, a
 		}
 	}
 
-	results, err := fn.Call(ctx, wasmArgs...)
+	loaded.callMu.Lock()
+	results, err := r.invoke(ctx, loaded, funcName, wasmArgs...)
+	loaded.callMu.Unlock()
 	if err != nil {
 		return nil, fmt.Errorf("function call failed: %w", err)
 	}
@@ -549,38 +957,83 @@ func (r *Runtime) ListLoadedPlugins() []string {
 	return ids
 }
 
+// HasFunction reports whether a loaded plugin exports funcName.
+func (r *Runtime) HasFunction(pluginID, funcName string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	loaded, ok := r.modules[pluginID]
+	return ok && loaded.Exports[funcName] != nil
+}
+
+// GetPlugin returns a copy of the metadata of a loaded plugin.
+func (r *Runtime) GetPlugin(pluginID string) (*domain.Plugin, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	loaded, ok := r.modules[pluginID]
+	if !ok {
+		return nil, false
+	}
+	plugin := *loaded.Plugin
+	plugin.Config = copyConfig(loaded.Plugin.Config)
+	return &plugin, true
+}
+
 // Close shuts down the runtime.
 func (r *Runtime) Close() error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	if r.closed {
+		return nil
+	}
+
 	ctx := context.Background()
 	for id, loaded := range r.modules {
+		r.allocator.Forget(loaded.Module.Name())
 		loaded.Module.Close(ctx)
 		delete(r.modules, id)
 	}
 
-	// Close event bus
+	// Close event bus once no emitter is waiting to send on it
+	r.closed = true
+	r.busMu.Lock()
+	r.busClosed = true
 	close(r.eventBus)
+	r.busMu.Unlock()
 
 	return r.runtime.Close(ctx)
 }
 
 // SetConfig sets a configuration value for plugins.
+//
+// Configuration has its own lock rather than r.mu so forge_get_config
+// never contends with loading, unloading or disabling plugins.
 func (r *Runtime) SetConfig(key, value string) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
+	r.configMu.Lock()
+	defer r.configMu.Unlock()
 	r.config[key] = value
 }
 
 // GetConfig returns a configuration value.
 func (r *Runtime) GetConfig(key string) (string, bool) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
+	r.configMu.RLock()
+	defer r.configMu.RUnlock()
 	val, ok := r.config[key]
 	return val, ok
 }
 
+// copyConfig copies the caller's configuration so later changes to it
+// cannot race with plugin reads.
+func copyConfig(config map[string]string) map[string]string {
+	copied := make(map[string]string, len(config))
+	for k, v := range config {
+		copied[k] = v
+	}
+	return copied
+}
+
 // Events returns the event bus channel for receiving plugin events.
 func (r *Runtime) Events() <-chan PluginEvent {
 	return r.eventBus
@@ -591,5 +1044,62 @@ func (r *Runtime) DataDir() string {
 	return r.dataDir
 }
 
-var _ ports.WasmRuntime = (*Runtime)(nil)
+// StorageQuota returns the per-plugin storage quota in bytes.
+func (r *Runtime) StorageQuota() int64 {
+	return r.storageQuota
+}
+
+// StorageUsage reports the data stored by the named plugin, whether or not
+// it is loaded.
+func (r *Runtime) StorageUsage(name string) (*ports.PluginStorageUsage, error) {
+	dir, _, err := r.namedStorageDir(name)
+	if err != nil {
+		return nil, err
+	}
+	used, files, err := PluginStorageUsage(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &ports.PluginStorageUsage{Dir: dir, Used: used, Files: files, Quota: r.storageQuota}, nil
+}
 
+// PurgeStorage removes all data stored by the named plugin. Calls into a
+// loaded plugin wait until its data is gone, so none sees a partial purge.
+func (r *Runtime) PurgeStorage(ctx context.Context, name string) error {
+	dir, loaded, err := r.namedStorageDir(name)
+	if err != nil {
+		return err
+	}
+	if loaded != nil {
+		loaded.callMu.Lock()
+		defer loaded.callMu.Unlock()
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to purge plugin storage: %w", err)
+	}
+	return nil
+}
+
+// namedStorageDir returns the data directory of the plugin called name,
+// and the plugin if it is loaded.
+func (r *Runtime) namedStorageDir(name string) (string, *LoadedPlugin, error) {
+	r.mu.RLock()
+	var loaded *LoadedPlugin
+	for _, candidate := range r.modules {
+		if candidate.Plugin.Name == name {
+			loaded = candidate
+			break
+		}
+	}
+	r.mu.RUnlock()
+
+	if loaded != nil {
+		if dir := r.storageDir(loaded.Plugin.ID.String()); dir != "" {
+			return dir, loaded, nil
+		}
+	}
+	dir, err := PluginStorageDir(r.dataDir, name)
+	return dir, loaded, err
+}
+
+var _ ports.WasmRuntime = (*Runtime)(nil)
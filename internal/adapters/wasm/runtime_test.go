@@ -2,10 +2,16 @@
 package wasm
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/services"
 )
 
 func TestRuntimeOptions_Defaults(t *testing.T) {
@@ -72,24 +78,6 @@ func TestPluginEvent_Fields(t *testing.T) {
 	}
 }
 
-func TestPluginMemoryAllocator_Fields(t *testing.T) {
-	allocator := PluginMemoryAllocator{
-		memory: make(map[uint32][]byte),
-		nextID: 0,
-	}
-
-	// Store some data
-	allocator.memory[1] = []byte("test data")
-	allocator.nextID = 2
-
-	if len(allocator.memory) != 1 {
-		t.Error("memory map should have 1 entry")
-	}
-	if allocator.nextID != 2 {
-		t.Error("nextID should be 2")
-	}
-}
-
 func TestLoadedPlugin_Fields(t *testing.T) {
 	loaded := LoadedPlugin{
 		Plugin:  nil, // Domain plugin would be set here
@@ -105,3 +93,175 @@ func TestLoadedPlugin_Fields(t *testing.T) {
 	}
 }
 
+
+// wasmSection encodes a module section with a single-byte length prefix.
+func wasmSection(id byte, content ...byte) []byte {
+	return append([]byte{id, byte(len(content))}, content...)
+}
+
+// configReaderModule returns a minimal guest that exports memory, a bump-free
+// malloc returning offset 1024, and get(key_ptr, key_len) -> (ptr, len),
+// which forwards to forge_get_config.
+func configReaderModule() []byte {
+	module := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	// Types: 0 = (i32, i32) -> (i32, i32), 1 = (i32) -> i32
+	module = append(module, wasmSection(1,
+		0x02,
+		0x60, 0x02, 0x7f, 0x7f, 0x02, 0x7f, 0x7f,
+		0x60, 0x01, 0x7f, 0x01, 0x7f)...)
+	// Import forge.forge_get_config as function 0
+	imp := []byte{0x01, 0x05}
+	imp = append(imp, "forge"...)
+	imp = append(imp, 0x10)
+	imp = append(imp, "forge_get_config"...)
+	imp = append(imp, 0x00, 0x00)
+	module = append(module, wasmSection(2, imp...)...)
+	// Functions: 1 = malloc, 2 = get
+	module = append(module, wasmSection(3, 0x02, 0x01, 0x00)...)
+	// One page of memory
+	module = append(module, wasmSection(5, 0x01, 0x00, 0x01)...)
+	exp := []byte{0x03, 0x06}
+	exp = append(exp, "memory"...)
+	exp = append(exp, 0x02, 0x00, 0x06)
+	exp = append(exp, "malloc"...)
+	exp = append(exp, 0x00, 0x01, 0x03)
+	exp = append(exp, "get"...)
+	exp = append(exp, 0x00, 0x02)
+	module = append(module, wasmSection(7, exp...)...)
+	module = append(module, wasmSection(10,
+		0x02,
+		// malloc: i32.const 1024
+		0x05, 0x00, 0x41, 0x80, 0x08, 0x0b,
+		// get: local.get 0; local.get 1; call 0
+		0x08, 0x00, 0x20, 0x00, 0x20, 0x01, 0x10, 0x00, 0x0b)...)
+	return module
+}
+
+func TestRuntime_ConcurrentConfigAccess(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRuntime(t, nil)
+	r.SetConfig("endpoint", "initial")
+
+	mod, err := r.runtime.Instantiate(ctx, configReaderModule())
+	if err != nil {
+		t.Fatalf("failed to instantiate guest: %v", err)
+	}
+	defer mod.Close(ctx)
+
+	key := []byte("endpoint")
+	if !mod.Memory().Write(0, key) {
+		t.Fatal("failed to write key to guest memory")
+	}
+	get := mod.ExportedFunction("get")
+
+	const iterations = 500
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < iterations; i++ {
+			r.SetConfig("endpoint", fmt.Sprintf("value-%d", i))
+			r.SetConfig(fmt.Sprintf("key-%d", i), "x")
+		}
+	}()
+
+	for i := 0; i < iterations; i++ {
+		results, err := get.Call(ctx, 0, uint64(len(key)))
+		if err != nil {
+			t.Fatalf("forge_get_config call failed: %v", err)
+		}
+		value, ok := mod.Memory().Read(uint32(results[0]), uint32(results[1]))
+		if !ok || len(value) == 0 {
+			t.Fatalf("expected a config value, got ptr=%d len=%d", results[0], results[1])
+		}
+	}
+	wg.Wait()
+
+	results, err := get.Call(ctx, 0, uint64(len(key)))
+	if err != nil {
+		t.Fatalf("forge_get_config call failed: %v", err)
+	}
+	value, _ := mod.Memory().Read(uint32(results[0]), uint32(results[1]))
+	if want := fmt.Sprintf("value-%d", iterations-1); string(value) != want {
+		t.Errorf("expected %q, got %q", want, value)
+	}
+}
+
+func TestRuntime_ConfigIsCopied(t *testing.T) {
+	config := map[string]string{"key": "original"}
+	r, err := NewRuntimeWithOptions(context.Background(), &services.NopLogger{}, RuntimeOptions{
+		DataDir: t.TempDir(),
+		Config:  config,
+	})
+	if err != nil {
+		t.Fatalf("NewRuntimeWithOptions failed: %v", err)
+	}
+	defer r.Close()
+
+	config["key"] = "changed"
+	if val, _ := r.GetConfig("key"); val != "original" {
+		t.Errorf("expected runtime config to be isolated from caller map, got %q", val)
+	}
+}
+
+// spinModule returns a guest whose on_tick() -> i32 never returns.
+func spinModule() []byte {
+	module := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	module = append(module, wasmSection(1, 0x01, 0x60, 0x00, 0x01, 0x7f)...)
+	module = append(module, wasmSection(3, 0x01, 0x00)...)
+	exp := []byte{0x01, 0x07}
+	exp = append(exp, "on_tick"...)
+	exp = append(exp, 0x00, 0x00)
+	module = append(module, wasmSection(7, exp...)...)
+	// on_tick: loop br 0 end; i32.const 0
+	module = append(module, wasmSection(10,
+		0x01,
+		0x09, 0x00, 0x03, 0x40, 0x0c, 0x00, 0x0b, 0x41, 0x00, 0x0b)...)
+	return module
+}
+
+func TestRuntime_CallFunctionDeadline(t *testing.T) {
+	r := newTestRuntime(t, nil)
+	path := filepath.Join(t.TempDir(), "spin.wasm")
+	if err := os.WriteFile(path, spinModule(), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	plugin := domain.NewPlugin("spin", "1.0.0", path)
+	plugin.Config["tick_interval"] = "1s"
+	if err := r.LoadPlugin(context.Background(), plugin); err != nil {
+		t.Fatalf("LoadPlugin failed: %v", err)
+	}
+	id := plugin.ID.String()
+
+	if !r.HasFunction(id, "on_tick") {
+		t.Error("expected on_tick to be exported")
+	}
+	if r.HasFunction(id, "on_event") || r.HasFunction("missing", "on_tick") {
+		t.Error("expected HasFunction to be false for missing exports and plugins")
+	}
+	got, ok := r.GetPlugin(id)
+	if !ok || got.Name != "spin" || got.Config["tick_interval"] != "1s" {
+		t.Fatalf("unexpected plugin metadata: %+v", got)
+	}
+	got.Config["tick_interval"] = "changed"
+	if again, _ := r.GetPlugin(id); again.Config["tick_interval"] != "1s" {
+		t.Error("expected GetPlugin to return a copy")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		_, err := r.CallFunction(ctx, id, "on_tick")
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("expected deadline error from a spinning guest")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("guest call was not interrupted by its deadline")
+	}
+}
@@ -0,0 +1,275 @@
+package wasm
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// SecretsFile holds the encrypted secrets, keyed by name.
+	SecretsFile = "secrets.json"
+	// SecretsKeyFile holds the AES-256 key the secrets are encrypted with,
+	// unless SecretsKeyEnv is set.
+	SecretsKeyFile = "secrets.key"
+	// SecretsKeyEnv supplies the key instead, base64 encoded, so it need not
+	// be stored next to the secrets it protects.
+	SecretsKeyEnv = "FORGE_SECRETS_KEY"
+
+	secretKeySize = 32
+	redactedValue = "[REDACTED]"
+)
+
+// DefaultSecretsDir returns the default directory of the plugin secret store.
+func DefaultSecretsDir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".forge", "plugins")
+}
+
+// SecretStore keeps plugin secrets encrypted at rest with AES-GCM. Values
+// are only decrypted when read, and the file is re-read on every access so
+// secrets set with forge secret set are seen without a restart.
+//
+// Secrets are named <plugin>/<key>, and a plugin can only read its own; see
+// SecretName. The key is read from SecretsKeyEnv if set, and otherwise from
+// SecretsKeyFile in the same directory, where it only protects the secrets
+// from readers of secrets.json alone, such as backups.
+type SecretStore struct {
+	dir  string
+	aead cipher.AEAD
+	mu   sync.Mutex
+}
+
+// OpenSecretStore opens the secret store in dir. Without SecretsKeyEnv, its
+// key is generated on first use.
+func OpenSecretStore(dir string) (*SecretStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	var key []byte
+	var err error
+	if encoded := os.Getenv(SecretsKeyEnv); encoded != "" {
+		key, err = base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != secretKeySize {
+			return nil, fmt.Errorf("%s must be a base64 encoded %d byte key", SecretsKeyEnv, secretKeySize)
+		}
+	} else if key, err = loadOrCreateSecretKey(filepath.Join(dir, SecretsKeyFile)); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret key: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret key: %w", err)
+	}
+	return &SecretStore{dir: dir, aead: aead}, nil
+}
+
+// SecretName returns the name a plugin's secret key is stored under.
+func SecretName(plugin, key string) string {
+	return plugin + "/" + key
+}
+
+// ValidateSecretName checks that name is of the form <plugin>/<key>.
+func ValidateSecretName(name string) error {
+	plugin, key, ok := strings.Cut(name, "/")
+	if !ok || plugin == "" || !validSecretKey(key) {
+		return fmt.Errorf("secret name must be <plugin>/<key>, got %q", name)
+	}
+	return nil
+}
+
+// validSecretKey reports whether key can name a secret within a plugin's
+// scope, where a slash could reach into another plugin's.
+func validSecretKey(key string) bool {
+	return key != "" && !strings.Contains(key, "/")
+}
+
+func loadOrCreateSecretKey(path string) ([]byte, error) {
+	key, err := os.ReadFile(path)
+	if err == nil {
+		if len(key) != secretKeySize {
+			return nil, fmt.Errorf("invalid secret key in %s", path)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read secret key: %w", err)
+	}
+
+	key = make([]byte, secretKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate secret key: %w", err)
+	}
+	if err := writePrivateFile(path, key); err != nil {
+		return nil, fmt.Errorf("failed to write secret key: %w", err)
+	}
+	return key, nil
+}
+
+// Set encrypts and stores a secret, replacing any previous value.
+func (s *SecretStore) Set(key, value string) error {
+	if key == "" {
+		return fmt.Errorf("secret key is required")
+	}
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	// The key name is authenticated so values can't be swapped between keys
+	sealed := s.aead.Seal(nonce, nonce, []byte(value), []byte(key))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	secrets, err := s.load()
+	if err != nil {
+		return err
+	}
+	secrets[key] = base64.StdEncoding.EncodeToString(sealed)
+	return s.save(secrets)
+}
+
+// Get decrypts and returns a secret. ok is false when it is not set.
+func (s *SecretStore) Get(key string) (value string, ok bool, err error) {
+	s.mu.Lock()
+	secrets, err := s.load()
+	s.mu.Unlock()
+	if err != nil {
+		return "", false, err
+	}
+	encoded, ok := secrets[key]
+	if !ok {
+		return "", false, nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(data) < s.aead.NonceSize() {
+		return "", false, fmt.Errorf("failed to decode secret %q", key)
+	}
+	nonce, ciphertext := data[:s.aead.NonceSize()], data[s.aead.NonceSize():]
+	plain, err := s.aead.Open(nil, nonce, ciphertext, []byte(key))
+	if err != nil {
+		return "", false, fmt.Errorf("failed to decrypt secret %q", key)
+	}
+	return string(plain), true, nil
+}
+
+// Delete removes a secret. It reports whether the secret existed.
+func (s *SecretStore) Delete(key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	secrets, err := s.load()
+	if err != nil {
+		return false, err
+	}
+	if _, ok := secrets[key]; !ok {
+		return false, nil
+	}
+	delete(secrets, key)
+	return true, s.save(secrets)
+}
+
+// Keys returns the names of the stored secrets, sorted.
+func (s *SecretStore) Keys() ([]string, error) {
+	s.mu.Lock()
+	secrets, err := s.load()
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(secrets))
+	for key := range secrets {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (s *SecretStore) load() (map[string]string, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, SecretsFile))
+	if os.IsNotExist(err) {
+		return make(map[string]string), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets: %w", err)
+	}
+	secrets := make(map[string]string)
+	if err := json.Unmarshal(data, &secrets); err != nil {
+		return nil, fmt.Errorf("invalid secrets file: %w", err)
+	}
+	return secrets, nil
+}
+
+func (s *SecretStore) save(secrets map[string]string) error {
+	data, err := json.MarshalIndent(secrets, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode secrets: %w", err)
+	}
+	if err := writePrivateFile(filepath.Join(s.dir, SecretsFile), data); err != nil {
+		return fmt.Errorf("failed to write secrets: %w", err)
+	}
+	return nil
+}
+
+// writePrivateFile replaces path with data through a temporary file that is
+// only ever readable by the owner.
+func writePrivateFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// secretRedactor replaces secret values handed to plugins with a
+// placeholder, so a plugin logging its own secret doesn't leak it.
+type secretRedactor struct {
+	mu     sync.RWMutex
+	values map[string]struct{}
+}
+
+func newSecretRedactor() *secretRedactor {
+	return &secretRedactor{values: make(map[string]struct{})}
+}
+
+// Add registers a value to redact.
+func (r *secretRedactor) Add(value string) {
+	if value == "" {
+		return
+	}
+	r.mu.Lock()
+	r.values[value] = struct{}{}
+	r.mu.Unlock()
+}
+
+// Redact returns s with every registered value replaced.
+func (r *secretRedactor) Redact(s string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for value := range r.values {
+		s = strings.ReplaceAll(s, value, redactedValue)
+	}
+	return s
+}
@@ -0,0 +1,251 @@
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/forge-platform/forge/internal/core/ports"
+)
+
+// secretModule returns a guest that exports memory, no malloc,
+// get(key_ptr, key_len) -> (ptr, len), which forwards to forge_get_secret,
+// and log(level, ptr, len), which forwards to forge_log.
+func secretModule() []byte {
+	module := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	// Types: 0 = (i32, i32) -> (i32, i32), 1 = (i32, i32, i32) -> ()
+	module = append(module, wasmSection(1,
+		0x02,
+		0x60, 0x02, 0x7f, 0x7f, 0x02, 0x7f, 0x7f,
+		0x60, 0x03, 0x7f, 0x7f, 0x7f, 0x00)...)
+	imp := []byte{0x02, 0x05}
+	imp = append(imp, "forge"...)
+	imp = append(imp, 0x10)
+	imp = append(imp, "forge_get_secret"...)
+	imp = append(imp, 0x00, 0x00, 0x05)
+	imp = append(imp, "forge"...)
+	imp = append(imp, 0x09)
+	imp = append(imp, "forge_log"...)
+	imp = append(imp, 0x00, 0x01)
+	module = append(module, wasmSection(2, imp...)...)
+	// Functions: 2 = get, 3 = log
+	module = append(module, wasmSection(3, 0x02, 0x00, 0x01)...)
+	module = append(module, wasmSection(5, 0x01, 0x00, 0x01)...)
+	exp := []byte{0x03, 0x06}
+	exp = append(exp, "memory"...)
+	exp = append(exp, 0x02, 0x00, 0x03)
+	exp = append(exp, "get"...)
+	exp = append(exp, 0x00, 0x02, 0x03)
+	exp = append(exp, "log"...)
+	exp = append(exp, 0x00, 0x03)
+	module = append(module, wasmSection(7, exp...)...)
+	module = append(module, wasmSection(10,
+		0x02,
+		// get: local.get 0; local.get 1; call 0
+		0x08, 0x00, 0x20, 0x00, 0x20, 0x01, 0x10, 0x00, 0x0b,
+		// log: local.get 0..2; call 1
+		0x0a, 0x00, 0x20, 0x00, 0x20, 0x01, 0x20, 0x02, 0x10, 0x01, 0x0b)...)
+	return module
+}
+
+// capturingLogger records every message and its arguments.
+type capturingLogger struct {
+	mu  sync.Mutex
+	buf strings.Builder
+}
+
+func (l *capturingLogger) record(msg string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintln(&l.buf, msg, args)
+}
+
+func (l *capturingLogger) Debug(msg string, args ...interface{}) { l.record(msg, args...) }
+func (l *capturingLogger) Info(msg string, args ...interface{})  { l.record(msg, args...) }
+func (l *capturingLogger) Warn(msg string, args ...interface{})  { l.record(msg, args...) }
+func (l *capturingLogger) Error(msg string, args ...interface{}) { l.record(msg, args...) }
+func (l *capturingLogger) With(args ...interface{}) ports.Logger { return l }
+
+func (l *capturingLogger) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buf.String()
+}
+
+func TestSecretStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := OpenSecretStore(dir)
+	if err != nil {
+		t.Fatalf("OpenSecretStore failed: %v", err)
+	}
+	if err := store.Set("api_token", "s3cr3t-value"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, SecretsFile))
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if strings.Contains(string(data), "s3cr3t-value") {
+		t.Error("expected the secret to be encrypted at rest")
+	}
+	for _, name := range []string{SecretsFile, SecretsKeyFile} {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("Stat failed: %v", err)
+		}
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("expected %s to be private, got %v", name, info.Mode().Perm())
+		}
+	}
+
+	// A second store shares the key and sees the secret
+	reopened, err := OpenSecretStore(dir)
+	if err != nil {
+		t.Fatalf("OpenSecretStore failed: %v", err)
+	}
+	if value, ok, err := reopened.Get("api_token"); err != nil || !ok || value != "s3cr3t-value" {
+		t.Errorf("expected the secret back, got %q, %v, %v", value, ok, err)
+	}
+	if _, ok, err := reopened.Get("missing"); err != nil || ok {
+		t.Errorf("expected a missing secret to be reported, got %v, %v", ok, err)
+	}
+
+	if keys, err := store.Keys(); err != nil || len(keys) != 1 || keys[0] != "api_token" {
+		t.Errorf("expected [api_token], got %v, %v", keys, err)
+	}
+	if deleted, err := store.Delete("api_token"); err != nil || !deleted {
+		t.Errorf("expected the secret to be deleted, got %v, %v", deleted, err)
+	}
+	if _, ok, _ := store.Get("api_token"); ok {
+		t.Error("expected the secret to be gone after Delete")
+	}
+}
+
+func TestSecretStore_KeyFromEnv(t *testing.T) {
+	dir := t.TempDir()
+	key := bytes.Repeat([]byte{7}, secretKeySize)
+	t.Setenv(SecretsKeyEnv, base64.StdEncoding.EncodeToString(key))
+
+	store, err := OpenSecretStore(dir)
+	if err != nil {
+		t.Fatalf("OpenSecretStore failed: %v", err)
+	}
+	if err := store.Set("api/token", "s3cr3t-value"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, SecretsKeyFile)); !os.IsNotExist(err) {
+		t.Errorf("expected no key file next to the secrets, got %v", err)
+	}
+
+	// Without the key the secrets can't be read
+	t.Setenv(SecretsKeyEnv, "")
+	other, err := OpenSecretStore(dir)
+	if err != nil {
+		t.Fatalf("OpenSecretStore failed: %v", err)
+	}
+	if _, _, err := other.Get("api/token"); err == nil {
+		t.Error("expected decryption with another key to fail")
+	}
+
+	t.Setenv(SecretsKeyEnv, "dG9vIHNob3J0")
+	if _, err := OpenSecretStore(dir); err == nil {
+		t.Error("expected a short key to be rejected")
+	}
+}
+
+func TestValidateSecretName(t *testing.T) {
+	if err := ValidateSecretName("kubernetes-monitor/kubernetes_token"); err != nil {
+		t.Errorf("expected a scoped name to be valid, got %v", err)
+	}
+	for _, name := range []string{"kubernetes_token", "/token", "plugin/", "plugin/a/b"} {
+		if err := ValidateSecretName(name); err == nil {
+			t.Errorf("expected %q to be rejected", name)
+		}
+	}
+}
+
+func TestRuntime_GetSecret(t *testing.T) {
+	ctx := context.Background()
+	store, err := OpenSecretStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("OpenSecretStore failed: %v", err)
+	}
+	const secret = "k8s-token-0123456789"
+	if err := store.Set(SecretName("secret-reader", "kubernetes_token"), secret); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := store.Set(SecretName("other", "db_password"), "other-plugin-secret"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	logger := &capturingLogger{}
+	r, err := NewRuntimeWithOptions(ctx, logger, RuntimeOptions{
+		DataDir: t.TempDir(),
+		Secrets: store,
+	})
+	if err != nil {
+		t.Fatalf("NewRuntimeWithOptions failed: %v", err)
+	}
+	t.Cleanup(func() { r.Close() })
+
+	plugin, err := loadModule(t, r, "secret-reader", secretModule())
+	if err != nil {
+		t.Fatalf("LoadPlugin failed: %v", err)
+	}
+	module := r.modules[plugin.ID.String()].Module
+
+	get := func(key string) (string, bool) {
+		t.Helper()
+		module.Memory().Write(0, []byte(key))
+		results, err := module.ExportedFunction("get").Call(ctx, 0, uint64(len(key)))
+		if err != nil {
+			t.Fatalf("forge_get_secret call failed: %v", err)
+		}
+		if results[0] == 0 && results[1] == 0 {
+			return "", false
+		}
+		data, ok := module.Memory().Read(uint32(results[0]), uint32(results[1]))
+		if !ok {
+			t.Fatal("failed to read secret")
+		}
+		return string(data), true
+	}
+
+	if value, ok := get("kubernetes_token"); !ok || value != secret {
+		t.Errorf("expected %q, got %q (found %v)", secret, value, ok)
+	}
+	if _, ok := get("missing"); ok {
+		t.Error("expected no value for a missing secret")
+	}
+	// Other plugins' secrets are out of reach
+	for _, key := range []string{"db_password", "other/db_password", "../other/db_password"} {
+		if _, ok := get(key); ok {
+			t.Errorf("expected %q not to be readable", key)
+		}
+	}
+
+	// The plugin logs its secret at debug level
+	msg := "using token " + secret
+	module.Memory().Write(0, []byte(msg))
+	if _, err := module.ExportedFunction("log").Call(ctx, 0, 0, uint64(len(msg))); err != nil {
+		t.Fatalf("forge_log call failed: %v", err)
+	}
+
+	logs := logger.String()
+	if strings.Contains(logs, secret) {
+		t.Errorf("expected the secret to be absent from logs, got:\n%s", logs)
+	}
+	if !strings.Contains(logs, "using token "+redactedValue) {
+		t.Errorf("expected the plugin message with the secret redacted, got:\n%s", logs)
+	}
+	if !strings.Contains(logs, "kubernetes_token") {
+		t.Errorf("expected the secret key to be logged, got:\n%s", logs)
+	}
+}
@@ -0,0 +1,104 @@
+package wasm
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// DefaultStorageQuota is the per-plugin storage limit used when
+// RuntimeOptions.StorageQuota is zero.
+const DefaultStorageQuota int64 = 50 * 1024 * 1024
+
+// ErrCodeStorageQuotaExceeded is returned by forge_write_file when the
+// write would take the plugin's data directory over its quota.
+const ErrCodeStorageQuotaExceeded int32 = -8
+
+// ErrInvalidPluginPath is returned for guest paths that would escape the
+// plugin's data directory.
+var ErrInvalidPluginPath = errors.New("invalid plugin file path")
+
+// pluginStorageNamePattern matches names that are safe as a single directory name.
+var pluginStorageNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// DefaultPluginDataDir returns the default base directory for plugin data.
+func DefaultPluginDataDir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".forge", "plugins", "data")
+}
+
+// PluginStorageDir returns the data directory of a plugin under dataDir.
+func PluginStorageDir(dataDir, pluginName string) (string, error) {
+	if !pluginStorageNamePattern.MatchString(pluginName) {
+		return "", fmt.Errorf("invalid plugin name for storage: %q", pluginName)
+	}
+	return filepath.Join(dataDir, pluginName), nil
+}
+
+// PluginStorageUsage returns the total size in bytes and the number of
+// files stored in dir. A missing directory has zero usage.
+func PluginStorageUsage(dir string) (int64, int, error) {
+	var size int64
+	var files int
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		files++
+		return nil
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to compute storage usage: %w", err)
+	}
+	return size, files, nil
+}
+
+// resolvePluginPath maps a guest-supplied relative path to a location
+// inside baseDir. Absolute paths, parent references that leave baseDir and
+// paths through symlinks are rejected.
+func resolvePluginPath(baseDir, guestPath string) (string, error) {
+	if guestPath == "" || strings.ContainsRune(guestPath, 0) || filepath.IsAbs(guestPath) {
+		return "", ErrInvalidPluginPath
+	}
+
+	cleanPath := filepath.Clean(guestPath)
+	if cleanPath == "." || cleanPath == ".." || strings.HasPrefix(cleanPath, ".."+string(filepath.Separator)) {
+		return "", ErrInvalidPluginPath
+	}
+
+	fullPath := filepath.Join(baseDir, cleanPath)
+	rel, err := filepath.Rel(baseDir, fullPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", ErrInvalidPluginPath
+	}
+
+	// Refuse to follow symlinks planted inside the data directory
+	current := baseDir
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		current = filepath.Join(current, part)
+		info, err := os.Lstat(current)
+		if err != nil {
+			break
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return "", ErrInvalidPluginPath
+		}
+	}
+
+	return fullPath, nil
+}
@@ -0,0 +1,192 @@
+package wasm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/services"
+)
+
+func newStorageTestRuntime(t *testing.T, quota int64) *Runtime {
+	t.Helper()
+	r, err := NewRuntimeWithOptions(context.Background(), &services.NopLogger{}, RuntimeOptions{
+		DataDir:      t.TempDir(),
+		StorageQuota: quota,
+	})
+	if err != nil {
+		t.Fatalf("NewRuntimeWithOptions failed: %v", err)
+	}
+	t.Cleanup(func() { r.Close() })
+	return r
+}
+
+// registerTestPlugin maps a module name to a plugin's storage directory the
+// way LoadPlugin does, without instantiating a module.
+func registerTestPlugin(r *Runtime, moduleName, pluginName string) {
+	plugin := domain.NewPlugin(pluginName, "1.0.0", pluginName+".wasm")
+	r.storageMu.Lock()
+	r.storageDirs[moduleName] = r.pluginStorageDir(plugin)
+	r.storageMu.Unlock()
+}
+
+func TestResolvePluginPath(t *testing.T) {
+	base := t.TempDir()
+
+	valid := map[string]string{
+		"state.json":         "state.json",
+		"cache/items.db":     "cache/items.db",
+		"a/../b.txt":         "b.txt",
+		"./nested/./file":    "nested/file",
+		"..data/hidden-name": "..data/hidden-name",
+	}
+	for guest, want := range valid {
+		got, err := resolvePluginPath(base, guest)
+		if err != nil {
+			t.Errorf("resolvePluginPath(%q) failed: %v", guest, err)
+			continue
+		}
+		if got != filepath.Join(base, want) {
+			t.Errorf("resolvePluginPath(%q) = %s, want %s", guest, got, filepath.Join(base, want))
+		}
+	}
+
+	for _, guest := range []string{"", ".", "..", "../other/state.json", "a/../../b", "/etc/passwd", "a\x00b"} {
+		if _, err := resolvePluginPath(base, guest); err == nil {
+			t.Errorf("expected traversal attempt %q to be rejected", guest)
+		}
+	}
+
+	// Symlinks inside the data directory are never followed
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(base, "escape")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	if _, err := resolvePluginPath(base, "escape/secret"); err == nil {
+		t.Error("expected path through symlink to be rejected")
+	}
+}
+
+func TestPluginStorageDir(t *testing.T) {
+	dir, err := PluginStorageDir("/data", "system-metrics")
+	if err != nil {
+		t.Fatalf("PluginStorageDir failed: %v", err)
+	}
+	if dir != filepath.Join("/data", "system-metrics") {
+		t.Errorf("unexpected dir %s", dir)
+	}
+
+	for _, name := range []string{"", "..", ".hidden", "a/b", "../etc", "name with spaces"} {
+		if _, err := PluginStorageDir("/data", name); err == nil {
+			t.Errorf("expected invalid plugin name %q to be rejected", name)
+		}
+	}
+}
+
+func TestRuntime_PluginStorageIsolation(t *testing.T) {
+	r := newStorageTestRuntime(t, 0)
+	registerTestPlugin(r, "module-a", "plugin-a")
+	registerTestPlugin(r, "module-b", "plugin-b")
+
+	if code := r.writePluginFile("module-a", "state.json", []byte(`{"a":1}`)); code != 0 {
+		t.Fatalf("write failed with code %d", code)
+	}
+	if _, err := os.Stat(filepath.Join(r.DataDir(), "plugin-a", "state.json")); err != nil {
+		t.Errorf("expected file under plugin-a directory: %v", err)
+	}
+
+	data, code := r.readPluginFile("module-a", "state.json")
+	if code != 0 || string(data) != `{"a":1}` {
+		t.Errorf("expected plugin A to read its own file, got %q (code %d)", data, code)
+	}
+
+	// Plugin B sees its own empty directory
+	if _, code := r.readPluginFile("module-b", "state.json"); code != -3 {
+		t.Errorf("expected not found for plugin B, got code %d", code)
+	}
+
+	// Plugin B cannot reach plugin A's files
+	if _, code := r.readPluginFile("module-b", "../plugin-a/state.json"); code != -2 {
+		t.Errorf("expected traversal read to be rejected, got code %d", code)
+	}
+	if code := r.writePluginFile("module-b", "../plugin-a/state.json", []byte("pwned")); code != -2 {
+		t.Errorf("expected traversal write to be rejected, got code %d", code)
+	}
+	data, _ = r.readPluginFile("module-a", "state.json")
+	if string(data) != `{"a":1}` {
+		t.Errorf("plugin A state was modified: %q", data)
+	}
+
+	// Modules without registered storage get nothing
+	if code := r.writePluginFile("unknown", "state.json", []byte("x")); code != -2 {
+		t.Errorf("expected unknown module to be rejected, got code %d", code)
+	}
+}
+
+func TestRuntime_StorageQuota(t *testing.T) {
+	r := newStorageTestRuntime(t, 10)
+	registerTestPlugin(r, "module-a", "plugin-a")
+	registerTestPlugin(r, "module-b", "plugin-b")
+
+	// Exactly at the quota is allowed
+	if code := r.writePluginFile("module-a", "a.bin", make([]byte, 6)); code != 0 {
+		t.Fatalf("write failed with code %d", code)
+	}
+	if code := r.writePluginFile("module-a", "b.bin", make([]byte, 4)); code != 0 {
+		t.Fatalf("write up to quota failed with code %d", code)
+	}
+
+	// One byte over is rejected
+	if code := r.writePluginFile("module-a", "c.bin", make([]byte, 1)); code != ErrCodeStorageQuotaExceeded {
+		t.Errorf("expected quota error, got code %d", code)
+	}
+
+	// Overwriting a file only counts the size difference
+	if code := r.writePluginFile("module-a", "a.bin", make([]byte, 6)); code != 0 {
+		t.Errorf("expected same-size overwrite to succeed, got code %d", code)
+	}
+	if code := r.writePluginFile("module-a", "a.bin", make([]byte, 7)); code != ErrCodeStorageQuotaExceeded {
+		t.Errorf("expected growing overwrite to exceed quota, got code %d", code)
+	}
+
+	// Quotas are per plugin
+	if code := r.writePluginFile("module-b", "a.bin", make([]byte, 10)); code != 0 {
+		t.Errorf("expected plugin B to have its own quota, got code %d", code)
+	}
+
+	usage, err := r.StorageUsage("plugin-a")
+	if err != nil {
+		t.Fatalf("StorageUsage failed: %v", err)
+	}
+	if usage.Used != 10 || usage.Files != 2 || usage.Quota != 10 || usage.Dir != filepath.Join(r.DataDir(), "plugin-a") {
+		t.Errorf("expected 10 of 10 bytes in 2 files, got %+v", usage)
+	}
+
+	if err := r.PurgeStorage(context.Background(), "plugin-a"); err != nil {
+		t.Fatalf("PurgeStorage failed: %v", err)
+	}
+	if usage, _ := r.StorageUsage("plugin-a"); usage.Used != 0 {
+		t.Errorf("expected empty storage after purge, got %d bytes", usage.Used)
+	}
+	if _, err := r.StorageUsage("../plugin-a"); err == nil {
+		t.Error("expected a name outside the data directory to be rejected")
+	}
+	if code := r.writePluginFile("module-a", "c.bin", make([]byte, 10)); code != 0 {
+		t.Errorf("expected write to succeed after purge, got code %d", code)
+	}
+}
+
+func TestRuntime_StorageQuotaDefault(t *testing.T) {
+	r := newStorageTestRuntime(t, 0)
+	if r.StorageQuota() != DefaultStorageQuota {
+		t.Errorf("expected default quota %d, got %d", DefaultStorageQuota, r.StorageQuota())
+	}
+
+	unlimited := newStorageTestRuntime(t, -1)
+	registerTestPlugin(unlimited, "module-a", "plugin-a")
+	if code := unlimited.writePluginFile("module-a", "big.bin", make([]byte, 1024)); code != 0 {
+		t.Errorf("expected unlimited quota to accept writes, got code %d", code)
+	}
+}
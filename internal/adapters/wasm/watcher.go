@@ -0,0 +1,172 @@
+package wasm
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/forge-platform/forge/internal/core/ports"
+)
+
+// DefaultReloadDebounce is how long a plugin file must be quiet before it
+// is reloaded. Compilers usually write a binary in several steps.
+const DefaultReloadDebounce = 500 * time.Millisecond
+
+// PluginWatcher reloads plugins when their .wasm file changes on disk.
+// It watches the directories of loaded plugins rather than the files so
+// that binaries replaced by rename are picked up too.
+type PluginWatcher struct {
+	runtime  ports.WasmRuntime
+	logger   ports.Logger
+	debounce time.Duration
+	watcher  *fsnotify.Watcher
+
+	mu     sync.Mutex
+	paths  map[string]string // Cleaned plugin path -> plugin ID
+	dirs   map[string]bool   // Directories added to the watcher
+	timers map[string]*time.Timer
+	closed bool
+}
+
+// NewPluginWatcher creates a watcher that reloads plugins through runtime.
+func NewPluginWatcher(runtime ports.WasmRuntime, logger ports.Logger, debounce time.Duration) (*PluginWatcher, error) {
+	if debounce <= 0 {
+		debounce = DefaultReloadDebounce
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	return &PluginWatcher{
+		runtime:  runtime,
+		logger:   logger,
+		debounce: debounce,
+		watcher:  watcher,
+		paths:    make(map[string]string),
+		dirs:     make(map[string]bool),
+		timers:   make(map[string]*time.Timer),
+	}, nil
+}
+
+// Sync starts watching the files of all loaded plugins and forgets
+// plugins that have been unloaded.
+func (w *PluginWatcher) Sync() {
+	paths := make(map[string]string)
+	for _, id := range w.runtime.ListLoadedPlugins() {
+		plugin, ok := w.runtime.GetPlugin(id)
+		if !ok || plugin.Path == "" {
+			continue
+		}
+		path, err := filepath.Abs(plugin.Path)
+		if err != nil {
+			continue
+		}
+		paths[path] = id
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	w.paths = paths
+	for path := range paths {
+		dir := filepath.Dir(path)
+		if w.dirs[dir] {
+			continue
+		}
+		if err := w.watcher.Add(dir); err != nil {
+			w.logger.Warn("Failed to watch plugin directory", "dir", dir, "error", err)
+			continue
+		}
+		w.dirs[dir] = true
+	}
+}
+
+// Run handles file events until ctx is cancelled or the watcher is closed.
+// Loaded plugins are re-synced every syncInterval.
+func (w *PluginWatcher) Run(ctx context.Context, syncInterval time.Duration) {
+	ticker := time.NewTicker(syncInterval)
+	defer ticker.Stop()
+
+	w.Sync()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.Sync()
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Has(fsnotify.Write) || event.Has(fsnotify.Create) {
+				w.schedule(ctx, event.Name)
+			}
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			w.logger.Warn("Plugin watcher error", "error", err)
+		}
+	}
+}
+
+// schedule (re)starts the debounce timer for a changed plugin file.
+func (w *PluginWatcher) schedule(ctx context.Context, name string) {
+	path, err := filepath.Abs(name)
+	if err != nil {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	pluginID, ok := w.paths[path]
+	if !ok || w.closed {
+		return
+	}
+	if timer, ok := w.timers[path]; ok {
+		timer.Stop()
+	}
+	w.timers[path] = time.AfterFunc(w.debounce, func() {
+		w.mu.Lock()
+		delete(w.timers, path)
+		w.mu.Unlock()
+		w.reload(ctx, pluginID)
+	})
+}
+
+// reload reloads a plugin after its file settled.
+func (w *PluginWatcher) reload(ctx context.Context, pluginID string) {
+	if ctx.Err() != nil {
+		return
+	}
+	changed, err := w.runtime.ReloadPlugin(ctx, pluginID, "")
+	if err != nil {
+		w.logger.Error("Automatic plugin reload failed, keeping previous version", "plugin", pluginID, "error", err)
+		return
+	}
+	if changed {
+		w.logger.Info("Plugin reloaded after file change", "plugin", pluginID)
+	}
+}
+
+// Close stops watching and cancels pending reloads.
+func (w *PluginWatcher) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	for path, timer := range w.timers {
+		timer.Stop()
+		delete(w.timers, path)
+	}
+	w.mu.Unlock()
+	return w.watcher.Close()
+}
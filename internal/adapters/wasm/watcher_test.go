@@ -0,0 +1,69 @@
+package wasm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/services"
+)
+
+func TestPluginWatcher_ReloadsOnChange(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r := newTestRuntime(t, nil)
+	path := filepath.Join(t.TempDir(), "dev.wasm")
+	if err := os.WriteFile(path, lifecycleModule(1, 0), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	plugin := domain.NewPlugin("dev", "0.1.0", path)
+	if err := r.LoadPlugin(ctx, plugin); err != nil {
+		t.Fatalf("LoadPlugin failed: %v", err)
+	}
+	id := plugin.ID.String()
+
+	w, err := NewPluginWatcher(r, &services.NopLogger{}, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewPluginWatcher failed: %v", err)
+	}
+	defer w.Close()
+	w.Sync()
+	go w.Run(ctx, time.Hour)
+
+	// A burst of writes is debounced into a single reload of the last version
+	for _, version := range []byte{2, 3, 4} {
+		if err := os.WriteFile(path, lifecycleModule(version, 0), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for pluginVersion(t, r, id) != 4 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for plugin reload")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(200 * time.Millisecond)
+
+	r.lifecycleMu.Lock()
+	reloads := r.reloads
+	r.lifecycleMu.Unlock()
+	if reloads != 1 {
+		t.Errorf("expected a single debounced reload, got %d", reloads)
+	}
+
+	// A broken binary is reported and the previous version keeps running
+	if err := os.WriteFile(path, []byte("not a wasm module"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	time.Sleep(300 * time.Millisecond)
+	if v := pluginVersion(t, r, id); v != 4 {
+		t.Errorf("expected version 4 to keep running, got %d", v)
+	}
+}
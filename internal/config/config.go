@@ -3,8 +3,10 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
@@ -122,6 +124,28 @@ func Load() (*Config, error) {
 	return &cfg, nil
 }
 
+// LoadFile loads configuration from the given file, on top of defaults and
+// environment variables. Unlike Load, a missing or unreadable file is an
+// error.
+func LoadFile(path string) (*Config, error) {
+	v := viper.New()
+	setDefaults(v)
+	v.SetEnvPrefix("FORGE")
+	v.AutomaticEnv()
+	bindEnvVars(v)
+
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	var cfg Config
+	if err := v.Unmarshal(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+	return &cfg, nil
+}
+
 // setDefaults sets default configuration values.
 func setDefaults(v *viper.Viper) {
 	// Core defaults
@@ -260,6 +284,145 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// Diagnostic is a problem found in the configuration.
+type Diagnostic struct {
+	Key     string // Config key, e.g. "ai.ollama_url"
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	return d.Key + ": " + d.Message
+}
+
+// Diagnose checks the whole configuration and returns every problem found,
+// where Validate stops at the first. It also checks the environment the
+// configuration refers to: directories must be writable and referenced
+// files must exist.
+func (c *Config) Diagnose() []Diagnostic {
+	var diags []Diagnostic
+	add := func(key, format string, args ...interface{}) {
+		diags = append(diags, Diagnostic{Key: key, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// Core
+	if c.Core.DataDir == "" {
+		add("core.data_dir", "must be set")
+	} else if err := checkWritableDir(c.Core.DataDir); err != nil {
+		add("core.data_dir", "%v", err)
+	}
+	switch strings.ToLower(c.Core.LogLevel) {
+	case "debug", "info", "warn", "error":
+	default:
+		add("core.log_level", "must be debug, info, warn or error, got %q", c.Core.LogLevel)
+	}
+	if c.Core.HTTPPort < 1 || c.Core.HTTPPort > 65535 {
+		add("core.http_port", "must be between 1 and 65535, got %d", c.Core.HTTPPort)
+	}
+
+	// Database
+	if c.Database.Path != "" {
+		if err := checkWritableDir(filepath.Dir(c.Database.Path)); err != nil {
+			add("database.path", "%v", err)
+		}
+	}
+	if c.Database.MaxConnections <= 0 {
+		add("database.max_connections", "must be positive")
+	}
+
+	// GCP and GCS
+	if c.IsGCPEnabled() {
+		if c.GCP.BatchSize <= 0 {
+			add("gcp.batch_size", "must be positive")
+		}
+		if c.GCP.FlushInterval <= 0 {
+			add("gcp.flush_interval", "must be positive")
+		}
+	}
+	if c.GCP.CredentialsPath != "" {
+		if _, err := os.Stat(c.GCP.CredentialsPath); err != nil {
+			add("gcp.credentials_path", "credentials file not found: %s", c.GCP.CredentialsPath)
+		}
+	}
+	if c.IsGCSEnabled() && c.GCS.BackupRetentionDays <= 0 {
+		add("gcs.backup_retention_days", "must be positive")
+	}
+
+	// Auth
+	if c.Auth.SessionTimeoutHours <= 0 {
+		add("auth.session_timeout_hours", "must be positive")
+	}
+
+	// AI
+	if err := checkHTTPURL(c.AI.OllamaURL); err != nil {
+		add("ai.ollama_url", "%v", err)
+	}
+	if c.AI.Model == "" {
+		add("ai.model", "must be set")
+	}
+
+	// Alerting
+	if c.Alerting.SlackWebhookURL != "" {
+		if err := checkHTTPURL(c.Alerting.SlackWebhookURL); err != nil {
+			add("alerting.slack_webhook_url", "%v", err)
+		}
+	}
+	if smtp := c.Alerting.SMTP; smtp.Host != "" {
+		if smtp.Port < 1 || smtp.Port > 65535 {
+			add("alerting.smtp.port", "must be between 1 and 65535, got %d", smtp.Port)
+		}
+		if smtp.From == "" {
+			add("alerting.smtp.from", "must be set when alerting.smtp.host is set")
+		}
+		if smtp.Username != "" && smtp.Password == "" {
+			add("alerting.smtp.password", "must be set when alerting.smtp.username is set")
+		}
+	} else if smtp.Username != "" || smtp.From != "" {
+		add("alerting.smtp.host", "must be set when other smtp settings are")
+	}
+
+	return diags
+}
+
+// checkWritableDir reports whether files can be created in dir. A missing
+// directory is fine as long as it can be created under its nearest existing
+// parent.
+func checkWritableDir(dir string) error {
+	for {
+		info, err := os.Stat(dir)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("%s is not a directory", dir)
+			}
+			f, err := os.CreateTemp(dir, ".forge-write-check-*")
+			if err != nil {
+				return fmt.Errorf("%s is not writable", dir)
+			}
+			f.Close()
+			return os.Remove(f.Name())
+		}
+		if !os.IsNotExist(err) {
+			return fmt.Errorf("cannot access %s: %w", dir, err)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return fmt.Errorf("%s does not exist", dir)
+		}
+		dir = parent
+	}
+}
+
+// checkHTTPURL reports whether raw is an absolute http or https URL.
+func checkHTTPURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid URL %q: %w", raw, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("must be an http or https URL, got %q", raw)
+	}
+	return nil
+}
+
 // IsGCPEnabled returns true if GCP integration is configured.
 func (c *Config) IsGCPEnabled() bool {
 	return c.GCP.ProjectID != ""
@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -144,3 +145,99 @@ func TestIsGCSEnabled(t *testing.T) {
 	}
 }
 
+func validDiagnoseConfig(t *testing.T) Config {
+	t.Helper()
+	return Config{
+		Core:     CoreConfig{DataDir: t.TempDir(), LogLevel: "info", HTTPPort: 8080},
+		Database: DatabaseConfig{MaxConnections: 10},
+		Auth:     AuthConfig{SessionTimeoutHours: 24},
+		AI:       AIConfig{OllamaURL: "http://localhost:11434", Model: "llama3.2"},
+	}
+}
+
+func TestConfigDiagnose(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		want   []string // Keys expected to be reported
+	}{
+		{"valid", func(c *Config) {}, nil},
+		{"missing data dir is created under a writable parent", func(c *Config) {
+			c.Core.DataDir = filepath.Join(c.Core.DataDir, "a", "b")
+		}, nil},
+		{"data dir is a file", func(c *Config) { c.Core.DataDir = file }, []string{"core.data_dir"}},
+		{"database dir is a file", func(c *Config) {
+			c.Database.Path = filepath.Join(file, "forge.db")
+		}, []string{"database.path"}},
+		{"bad log level and port", func(c *Config) {
+			c.Core.LogLevel = "verbose"
+			c.Core.HTTPPort = 70000
+		}, []string{"core.log_level", "core.http_port"}},
+		{"non-positive durations", func(c *Config) {
+			c.GCP.ProjectID = "p"
+			c.GCP.BatchSize = 10
+			c.GCP.FlushInterval = -time.Second
+			c.Auth.SessionTimeoutHours = 0
+		}, []string{"gcp.flush_interval", "auth.session_timeout_hours"}},
+		{"malformed AI URL", func(c *Config) { c.AI.OllamaURL = "localhost:11434" }, []string{"ai.ollama_url"}},
+		{"missing referenced files and keys", func(c *Config) {
+			c.GCP.CredentialsPath = filepath.Join(t.TempDir(), "missing.json")
+			c.Alerting.SMTP = SMTPConfig{Host: "smtp.example.com", Port: 587, Username: "ops"}
+		}, []string{"gcp.credentials_path", "alerting.smtp.from", "alerting.smtp.password"}},
+		{"smtp settings without a host", func(c *Config) {
+			c.Alerting.SMTP.From = "forge@example.com"
+		}, []string{"alerting.smtp.host"}},
+		{"bad slack webhook", func(c *Config) {
+			c.Alerting.SlackWebhookURL = "hooks.slack.com/services/x"
+		}, []string{"alerting.slack_webhook_url"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validDiagnoseConfig(t)
+			tt.modify(&cfg)
+			diags := cfg.Diagnose()
+			var got []string
+			for _, d := range diags {
+				got = append(got, d.Key)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Diagnose() = %v, want keys %v", diags, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Diagnose() = %v, want keys %v", diags, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := "core:\n  log_level: debug\nai:\n  ollama_url: http://ai.internal:11434\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if cfg.Core.LogLevel != "debug" || cfg.AI.OllamaURL != "http://ai.internal:11434" {
+		t.Errorf("LoadFile() did not apply the file: %+v", cfg)
+	}
+	if cfg.Core.HTTPPort != 8080 {
+		t.Errorf("Core.HTTPPort = %v, want default 8080", cfg.Core.HTTPPort)
+	}
+
+	if _, err := LoadFile(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("LoadFile() expected an error for a missing file")
+	}
+}
@@ -2,6 +2,12 @@
 package domain
 
 import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -38,6 +44,18 @@ const (
 	ConditionAnomalyDetection  RuleConditionType = "anomaly_detection"  // Statistical anomaly detected
 	ConditionAbsenceOfData     RuleConditionType = "absence_of_data"    // No data received for duration
 	ConditionComposite         RuleConditionType = "composite"          // Multiple conditions combined
+	ConditionExpression        RuleConditionType = "expression"         // Expression over named metric queries
+)
+
+// WindowAggregation selects how the points of a threshold rule's Duration
+// window are combined before being compared with the threshold.
+type WindowAggregation string
+
+const (
+	WindowLast WindowAggregation = "last" // Latest point only
+	WindowAvg  WindowAggregation = "avg"
+	WindowMin  WindowAggregation = "min"
+	WindowMax  WindowAggregation = "max"
 )
 
 // NotificationChannelType represents the type of notification channel.
@@ -71,23 +89,59 @@ type AlertRule struct {
 	// For anomaly detection: number of standard deviations
 	AnomalyStdDev float64 `json:"anomaly_std_dev,omitempty"`
 
+	// For anomaly detection: when AnomalyWindow is set, points from the
+	// last AnomalyWindow are scored against a baseline taken from the
+	// AnomalyBaseline before them (default 4x the window). Otherwise the
+	// latest point is scored against the whole queried series.
+	AnomalyWindow   time.Duration `json:"anomaly_window,omitempty"`
+	AnomalyBaseline time.Duration `json:"anomaly_baseline,omitempty"`
+
 	// For composite conditions: list of sub-rule IDs and operator (AND/OR)
 	CompositeRules    []uuid.UUID `json:"composite_rules,omitempty"`
 	CompositeOperator string      `json:"composite_operator,omitempty"` // "and" or "or"
 
+	// For expression conditions: named metric queries and a condition over
+	// them, e.g. "a = avg(cpu.usage), b = avg(memory.usage); a > 90 && b > 80".
+	// Each query aggregates the points within Duration (Interval when zero).
+	Expression string `json:"expression,omitempty"`
+
 	// Timing
 	Duration   time.Duration `json:"duration"`    // How long condition must be true before firing
 	Interval   time.Duration `json:"interval"`    // How often to evaluate the rule
 	LastCheck  time.Time     `json:"last_check"`
 	NextCheck  time.Time     `json:"next_check"`
 
+	// For threshold conditions: the points within Duration of the latest
+	// point are combined with ForAggregation (default WindowLast) and the
+	// result compared. When ForPercent is set, at least that percentage of
+	// the points must breach the threshold instead, 100 meaning all of them.
+	ForAggregation WindowAggregation `json:"for_aggregation,omitempty"`
+	ForPercent     float64           `json:"for_percent,omitempty"`
+
+	// How long the condition must stay clear before a firing alert
+	// resolves. Anomaly and rate of change conditions, which flap as values
+	// settle, default to DefaultResolveHoldDown; others resolve at once.
+	ResolveAfter time.Duration `json:"resolve_after,omitempty"`
+
 	// Notification configuration
 	Severity AlertSeverity `json:"severity"`
 	Channels []string      `json:"channels"` // Channel IDs to notify
 
+	// While an alert stays firing it is notified again every RepeatInterval
+	// (default DefaultRepeatInterval). NotifyOnResolve sends one last
+	// notification when a notified alert resolves.
+	RepeatInterval  time.Duration `json:"repeat_interval,omitempty"`
+	NotifyOnResolve bool          `json:"notify_on_resolve"`
+
 	// Labels for routing and grouping
 	Labels map[string]string `json:"labels,omitempty"`
 
+	// Alerts from rules with the same GroupBy labels, label values and
+	// channels that fire within GroupWait (default DefaultGroupWait) of each
+	// other are sent as one notification
+	GroupBy   []string      `json:"group_by,omitempty"`
+	GroupWait time.Duration `json:"group_wait,omitempty"`
+
 	// Annotations for alert messages
 	Annotations map[string]string `json:"annotations,omitempty"`
 
@@ -116,6 +170,9 @@ func NewAlertRule(name, metricName string, condition RuleConditionType, threshol
 		CreatedAt:   now,
 		UpdatedAt:   now,
 		NextCheck:   now,
+
+		RepeatInterval:  DefaultRepeatInterval,
+		NotifyOnResolve: true,
 	}
 }
 
@@ -138,6 +195,15 @@ type Alert struct {
 	StartsAt      time.Time  `json:"starts_at"`
 	EndsAt        *time.Time `json:"ends_at,omitempty"`
 	LastEvaluated time.Time  `json:"last_evaluated"`
+	ClearSince    *time.Time `json:"clear_since,omitempty"` // Condition clear while still firing
+
+	// What suppressed a silenced alert: "silence:<id>" or
+	// "maintenance:<window name>"
+	SilencedBy string `json:"silenced_by,omitempty"`
+
+	// Notification bookkeeping, persisted so a restart doesn't re-notify
+	LastNotifiedAt    *time.Time `json:"last_notified_at,omitempty"`
+	NotificationCount int        `json:"notification_count"`
 
 	// Acknowledgement
 	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
@@ -171,6 +237,7 @@ func NewAlert(rule *AlertRule, value float64, message string) *Alert {
 // Fire transitions the alert to firing state.
 func (a *Alert) Fire() {
 	a.State = AlertStateFiring
+	a.SilencedBy = ""
 	a.LastEvaluated = time.Now()
 }
 
@@ -196,6 +263,119 @@ func (a *Alert) Silence() {
 	a.State = AlertStateSilenced
 }
 
+// RecordNotification notes that the alert was notified at now.
+func (a *Alert) RecordNotification(now time.Time) {
+	a.LastNotifiedAt = &now
+	a.NotificationCount++
+}
+
+// NotificationDue reports whether a firing alert should be notified: it
+// has not been notified yet, or not within the repeat interval.
+func (a *Alert) NotificationDue(repeat time.Duration, now time.Time) bool {
+	if a.State != AlertStateFiring {
+		return false
+	}
+	return a.LastNotifiedAt == nil || now.Sub(*a.LastNotifiedAt) >= repeat
+}
+
+// DefaultGroupWait is how long a notification group collects alerts before
+// it is sent.
+const DefaultGroupWait = 30 * time.Second
+
+// AlertGroup is a set of alerts notified together.
+type AlertGroup struct {
+	Labels map[string]string // Values of the group-by labels
+	Alerts []*Alert
+}
+
+// Summary returns the alert to notify for the group: the alert itself for a
+// group of one, otherwise a combined alert listing every member with the
+// highest member severity.
+func (g *AlertGroup) Summary() *Alert {
+	if len(g.Alerts) == 1 {
+		return g.Alerts[0]
+	}
+
+	keys := make([]string, 0, len(g.Labels))
+	for k := range g.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + g.Labels[k]
+	}
+
+	var lines []string
+	summary := &Alert{
+		ID:          uuid.New(),
+		RuleName:    fmt.Sprintf("%d alerts [%s]", len(g.Alerts), strings.Join(pairs, ", ")),
+		State:       AlertStateFiring,
+		Labels:      copyMap(g.Labels),
+		Annotations: map[string]string{"group_size": fmt.Sprintf("%d", len(g.Alerts))},
+		Fingerprint: "group:" + strings.Join(pairs, ","),
+	}
+	for i, a := range g.Alerts {
+		if i == 0 || severityRank(a.Severity) > severityRank(summary.Severity) {
+			summary.Severity = a.Severity
+		}
+		if i == 0 || a.StartsAt.Before(summary.StartsAt) {
+			summary.StartsAt = a.StartsAt
+		}
+		if a.LastEvaluated.After(summary.LastEvaluated) {
+			summary.LastEvaluated = a.LastEvaluated
+		}
+		lines = append(lines, fmt.Sprintf("- [%s] %s: %s", a.Severity, a.RuleName, a.Message))
+	}
+	summary.Message = fmt.Sprintf("%d alerts firing:\n%s", len(g.Alerts), strings.Join(lines, "\n"))
+	return summary
+}
+
+// NewDigest returns the alert to notify for notifications held during quiet
+// hours: the alert itself for one, otherwise a combined alert listing every
+// alert in its current state with the highest severity among them.
+func NewDigest(alerts []*Alert) *Alert {
+	if len(alerts) == 1 {
+		return alerts[0]
+	}
+
+	var lines []string
+	digest := &Alert{
+		ID:          uuid.New(),
+		RuleName:    fmt.Sprintf("Quiet hours digest: %d alerts", len(alerts)),
+		State:       AlertStateFiring,
+		Labels:      make(map[string]string),
+		Annotations: map[string]string{"digest_size": fmt.Sprintf("%d", len(alerts))},
+		Fingerprint: "digest",
+	}
+	for i, a := range alerts {
+		if i == 0 || severityRank(a.Severity) > severityRank(digest.Severity) {
+			digest.Severity = a.Severity
+		}
+		if i == 0 || a.StartsAt.Before(digest.StartsAt) {
+			digest.StartsAt = a.StartsAt
+		}
+		if a.LastEvaluated.After(digest.LastEvaluated) {
+			digest.LastEvaluated = a.LastEvaluated
+		}
+		lines = append(lines, fmt.Sprintf("- [%s] %s (%s): %s", a.Severity, a.RuleName, a.State, a.Message))
+	}
+	digest.Message = fmt.Sprintf("%d alerts during quiet hours:\n%s", len(alerts), strings.Join(lines, "\n"))
+	return digest
+}
+
+func severityRank(s AlertSeverity) int {
+	switch s {
+	case AlertSeverityCritical:
+		return 3
+	case AlertSeverityWarning:
+		return 2
+	case AlertSeverityInfo:
+		return 1
+	}
+	return 0
+}
+
 // NotificationChannel defines a channel for sending alert notifications.
 type NotificationChannel struct {
 	ID          uuid.UUID               `json:"id"`
@@ -221,6 +401,114 @@ func NewNotificationChannel(name string, channelType NotificationChannelType, co
 	}
 }
 
+// TemplateFor returns the message template to use for an alert of the given
+// severity. A "template.<severity>" config entry takes precedence over the
+// channel-wide "template" entry.
+func (c *NotificationChannel) TemplateFor(severity AlertSeverity) string {
+	if t := c.Config[SeverityTemplateKey(severity)]; t != "" {
+		return t
+	}
+	return c.Config["template"]
+}
+
+// SeverityTemplateKey returns the channel config key holding the template
+// for a severity.
+func SeverityTemplateKey(severity AlertSeverity) string {
+	return "template." + string(severity)
+}
+
+// Validate checks that the channel has the configuration its type needs to
+// deliver: a webhook URL for slack and webhook channels, SMTP settings for
+// email and a routing key for PagerDuty.
+func (c *NotificationChannel) Validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("channel name is required")
+	}
+	switch c.Type {
+	case ChannelSlack:
+		return validateWebhookURL("webhook_url", c.SlackWebhookURL())
+	case ChannelWebhook:
+		return validateWebhookURL("url", c.Config["url"])
+	case ChannelEmail:
+		for _, key := range []string{"smtp_host", "from", "to"} {
+			if c.Config[key] == "" {
+				return fmt.Errorf("email channel requires %s", key)
+			}
+		}
+		if port := c.Config["smtp_port"]; port != "" {
+			if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+				return fmt.Errorf("invalid smtp_port %q", port)
+			}
+		}
+		for _, addr := range append(strings.Split(c.Config["to"], ","), c.Config["from"]) {
+			if _, err := mail.ParseAddress(strings.TrimSpace(addr)); err != nil {
+				return fmt.Errorf("invalid email address %q", strings.TrimSpace(addr))
+			}
+		}
+		if (c.Config["username"] == "") != (c.Config["password"] == "") {
+			return fmt.Errorf("email channel requires both username and password for SMTP auth")
+		}
+	case ChannelPagerDuty:
+		if c.Config["routing_key"] == "" {
+			return fmt.Errorf("pagerduty channel requires routing_key")
+		}
+	default:
+		return fmt.Errorf("unknown channel type %q", c.Type)
+	}
+	return nil
+}
+
+// SlackWebhookURL returns the incoming webhook URL of a Slack channel,
+// accepting "url" as an alias of "webhook_url".
+func (c *NotificationChannel) SlackWebhookURL() string {
+	if u := c.Config["webhook_url"]; u != "" {
+		return u
+	}
+	return c.Config["url"]
+}
+
+func validateWebhookURL(key, raw string) error {
+	if raw == "" {
+		return fmt.Errorf("%s is required", key)
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%s must be an http(s) URL, got %q", key, raw)
+	}
+	return nil
+}
+
+// RedactedConfig returns a copy of the channel config safe to display.
+// Passwords, tokens, keys and headers are masked, as is a Slack webhook URL,
+// which carries its own credentials; webhook URLs keep only their password
+// masked.
+func (c *NotificationChannel) RedactedConfig() map[string]string {
+	const mask = "****"
+	result := make(map[string]string, len(c.Config))
+	for k, v := range c.Config {
+		lower := strings.ToLower(k)
+		switch {
+		case v == "":
+			result[k] = v
+		case lower == "headers" || strings.Contains(lower, "password") ||
+			strings.Contains(lower, "token") || strings.Contains(lower, "secret") ||
+			strings.HasSuffix(lower, "key"):
+			result[k] = mask
+		case c.Type == ChannelSlack && (lower == "webhook_url" || lower == "url"):
+			result[k] = mask
+		case lower == "url":
+			if u, err := url.Parse(v); err == nil {
+				result[k] = u.Redacted()
+			} else {
+				result[k] = mask
+			}
+		default:
+			result[k] = v
+		}
+	}
+	return result
+}
+
 // Silence defines a time period during which alerts matching certain criteria are silenced.
 type Silence struct {
 	ID        uuid.UUID         `json:"id"`
@@ -231,6 +519,13 @@ type Silence struct {
 	Comment   string            `json:"comment"`
 	Active    bool              `json:"active"`
 	CreatedAt time.Time         `json:"created_at"`
+
+	// Recurring silences mute alerts for Duration every time the cron
+	// expression in Schedule fires, evaluated in Timezone (default UTC).
+	// StartsAt and EndsAt, when set, bound the period the schedule applies.
+	Schedule string        `json:"schedule,omitempty"`
+	Duration time.Duration `json:"duration,omitempty"`
+	Timezone string        `json:"timezone,omitempty"`
 }
 
 // NewSilence creates a new silence.
@@ -247,15 +542,42 @@ func NewSilence(matchers map[string]string, startsAt, endsAt time.Time, createdB
 	}
 }
 
-// IsActive returns whether the silence is currently active.
+// IsActive returns whether the silence is currently active. For recurring
+// silences it reports whether the schedule is in effect; whether a window
+// is open depends on the schedule.
 func (s *Silence) IsActive() bool {
 	if !s.Active {
 		return false
 	}
 	now := time.Now()
+	if s.IsRecurring() {
+		return s.InEffect(now)
+	}
 	return now.After(s.StartsAt) && now.Before(s.EndsAt)
 }
 
+// IsRecurring reports whether the silence repeats on a schedule.
+func (s *Silence) IsRecurring() bool {
+	return s.Schedule != ""
+}
+
+// InEffect reports whether now falls within the optional StartsAt and
+// EndsAt bounds of a recurring silence.
+func (s *Silence) InEffect(now time.Time) bool {
+	if !s.StartsAt.IsZero() && now.Before(s.StartsAt) {
+		return false
+	}
+	return s.EndsAt.IsZero() || now.Before(s.EndsAt)
+}
+
+// Location returns the time zone the schedule is evaluated in.
+func (s *Silence) Location() (*time.Location, error) {
+	if s.Timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(s.Timezone)
+}
+
 // Matches checks if an alert's labels match the silence matchers.
 func (s *Silence) Matches(labels map[string]string) bool {
 	for key, value := range s.Matchers {
@@ -305,6 +627,58 @@ func copyMap(m map[string]string) map[string]string {
 	return result
 }
 
+// DefaultResolveHoldDown is how long anomaly and rate of change conditions
+// must stay clear before their alerts resolve.
+const DefaultResolveHoldDown = 5 * time.Minute
+
+// DefaultRepeatInterval is how often a firing alert is notified again.
+const DefaultRepeatInterval = 4 * time.Hour
+
+// RepeatEvery returns how often a firing alert is notified again.
+func (r *AlertRule) RepeatEvery() time.Duration {
+	if r.RepeatInterval > 0 {
+		return r.RepeatInterval
+	}
+	return DefaultRepeatInterval
+}
+
+// ResolveHoldDown returns how long the rule's condition must stay clear
+// before a firing alert resolves. Without ResolveAfter it is zero,
+// resolving immediately, for conditions other than anomaly detection and
+// rate of change.
+func (r *AlertRule) ResolveHoldDown() time.Duration {
+	if r.ResolveAfter > 0 {
+		return r.ResolveAfter
+	}
+	switch r.Condition {
+	case ConditionAnomalyDetection, ConditionRateOfChange:
+		return DefaultResolveHoldDown
+	}
+	return 0
+}
+
+// ValidateWindow checks the rule's window evaluation settings.
+func (r *AlertRule) ValidateWindow() error {
+	switch r.ForAggregation {
+	case "", WindowLast, WindowAvg, WindowMin, WindowMax:
+	default:
+		return fmt.Errorf("invalid window aggregation %q", r.ForAggregation)
+	}
+	if r.ForPercent < 0 || r.ForPercent > 100 {
+		return fmt.Errorf("for percent must be between 0 and 100, got %v", r.ForPercent)
+	}
+	return nil
+}
+
+// AnomalyBaselineWindow returns the reference window for rolling anomaly
+// detection.
+func (r *AlertRule) AnomalyBaselineWindow() time.Duration {
+	if r.AnomalyBaseline > 0 {
+		return r.AnomalyBaseline
+	}
+	return 4 * r.AnomalyWindow
+}
+
 func generateFingerprint(rule *AlertRule) string {
 	// Simple fingerprint based on rule ID and metric name
 	return rule.ID.String() + ":" + rule.MetricName
@@ -5,6 +5,8 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -42,6 +44,10 @@ type User struct {
 	LastLoginAt  *time.Time        `json:"last_login_at,omitempty"`
 	FailedLogins int               `json:"failed_logins"`
 	LockedUntil  *time.Time        `json:"locked_until,omitempty"`
+	MFAEnabled   bool              `json:"mfa_enabled"`
+	MFASecret    string            `json:"-"` // Encrypted TOTP secret, never serialize
+	MFALastStep  int64             `json:"-"` // Last accepted TOTP step, to reject replays
+	Grants       []string          `json:"grants,omitempty"` // Permissions beyond the role, see MatchGrants
 	CreatedAt    time.Time         `json:"created_at"`
 	UpdatedAt    time.Time         `json:"updated_at"`
 }
@@ -73,6 +79,17 @@ type Session struct {
 	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
 }
 
+// PasswordResetToken is a single-use token that lets a user set a new
+// password without the old one.
+type PasswordResetToken struct {
+	ID        uuid.UUID  `json:"id"`
+	UserID    uuid.UUID  `json:"user_id"`
+	TokenHash string     `json:"-"` // Never serialize
+	ExpiresAt time.Time  `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+}
+
 // AuditLog represents an audit log entry.
 type AuditLog struct {
 	ID         uuid.UUID         `json:"id"`
@@ -86,6 +103,10 @@ type AuditLog struct {
 	Success    bool              `json:"success"`
 	Error      string            `json:"error,omitempty"`
 	Timestamp  time.Time         `json:"timestamp"`
+	// PrevHash is the Hash of the entry written before this one and Hash
+	// covers this entry's contents plus PrevHash, forming a tamper-evident chain.
+	PrevHash string `json:"prev_hash,omitempty"`
+	Hash     string `json:"hash,omitempty"`
 }
 
 // NewUser creates a new user with the given credentials.
@@ -160,6 +181,16 @@ func (u *User) ResetFailedLogins() {
 	u.UpdatedAt = now
 }
 
+// Unlock clears a lock and the failed login counter.
+func (u *User) Unlock() {
+	u.FailedLogins = 0
+	u.LockedUntil = nil
+	if u.Status == UserStatusLocked {
+		u.Status = UserStatusActive
+	}
+	u.UpdatedAt = time.Now()
+}
+
 // GenerateAPIKey creates a new API key and returns both the key and the APIKey struct.
 // The returned key should be shown to the user once and never stored in plain text.
 func GenerateAPIKey(userID uuid.UUID, name string, permissions []string, expiresAt *time.Time) (*APIKey, string, error) {
@@ -283,6 +314,43 @@ func (s *Session) Extend(duration time.Duration) {
 	s.Touch()
 }
 
+// GeneratePasswordResetToken creates a reset token valid for the given
+// duration and returns it along with the token to hand to the user.
+func GeneratePasswordResetToken(userID uuid.UUID, duration time.Duration) (*PasswordResetToken, string, error) {
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return nil, "", err
+	}
+
+	token := hex.EncodeToString(tokenBytes)
+	now := time.Now()
+	reset := &PasswordResetToken{
+		ID:        uuid.Must(uuid.NewV7()),
+		UserID:    userID,
+		TokenHash: HashToken(token),
+		ExpiresAt: now.Add(duration),
+		CreatedAt: now,
+	}
+
+	return reset, token, nil
+}
+
+// HashToken returns the hash under which a secret token is stored.
+func HashToken(token string) string {
+	tokenHash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(tokenHash[:])
+}
+
+// IsExpired reports whether the reset token's validity has passed.
+func (t *PasswordResetToken) IsExpired() bool {
+	return !time.Now().Before(t.ExpiresAt)
+}
+
+// IsUsed reports whether the reset token has already been consumed.
+func (t *PasswordResetToken) IsUsed() bool {
+	return t.UsedAt != nil
+}
+
 // NewAuditLog creates a new audit log entry.
 func NewAuditLog(userID *uuid.UUID, action, resource, resourceID string) *AuditLog {
 	return &AuditLog{
@@ -319,6 +387,85 @@ func (a *AuditLog) WithContext(ipAddress, userAgent string) *AuditLog {
 	return a
 }
 
+// ComputeHash returns the SHA-256 of the entry's contents and PrevHash.
+// The stored Hash itself is not part of the input.
+func (a *AuditLog) ComputeHash() string {
+	var userID string
+	if a.UserID != nil {
+		userID = a.UserID.String()
+	}
+	// Fixed field order; json.Marshal sorts the Details map keys
+	data, _ := json.Marshal([]interface{}{
+		a.ID.String(),
+		userID,
+		a.Action,
+		a.Resource,
+		a.ResourceID,
+		a.Details,
+		a.IPAddress,
+		a.UserAgent,
+		a.Success,
+		a.Error,
+		a.Timestamp.UTC().Format(time.RFC3339Nano),
+		a.PrevHash,
+	})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Seal links the entry to the previous entry's hash and computes its own.
+func (a *AuditLog) Seal(prevHash string) {
+	a.PrevHash = prevHash
+	a.Hash = a.ComputeHash()
+}
+
+// Audit chain issue reasons.
+const (
+	AuditIssueUnsealed = "unsealed" // Entry has no hash
+	AuditIssueTampered = "tampered" // Entry contents don't match its hash
+	AuditIssueGap      = "gap"      // Entry doesn't link to the one before it
+)
+
+// AuditChainIssue describes an audit log entry that failed verification.
+type AuditChainIssue struct {
+	Index  int       `json:"index"`
+	ID     uuid.UUID `json:"id"`
+	Reason string    `json:"reason"`
+}
+
+// AuditChainReport is the result of verifying an audit log chain.
+type AuditChainReport struct {
+	Valid   bool              `json:"valid"`
+	Checked int               `json:"checked"`
+	Head    string            `json:"head,omitempty"` // Hash of the newest entry
+	Issues  []AuditChainIssue `json:"issues,omitempty"`
+}
+
+// VerifyAuditChain checks audit log entries given in the order they were
+// written. Every entry must match its own hash and link to the hash of the
+// entry before it; a broken link means an entry was removed, reordered or
+// rewritten. The first entry's PrevHash is not checked so that a chain
+// pruned by retention still verifies.
+func VerifyAuditChain(logs []*AuditLog) *AuditChainReport {
+	report := &AuditChainReport{Checked: len(logs)}
+	for i, entry := range logs {
+		switch {
+		case entry.Hash == "":
+			report.Issues = append(report.Issues, AuditChainIssue{Index: i, ID: entry.ID, Reason: AuditIssueUnsealed})
+		case entry.ComputeHash() != entry.Hash:
+			report.Issues = append(report.Issues, AuditChainIssue{Index: i, ID: entry.ID, Reason: AuditIssueTampered})
+		}
+		if i > 0 && entry.PrevHash != logs[i-1].Hash {
+			report.Issues = append(report.Issues, AuditChainIssue{Index: i, ID: entry.ID, Reason: AuditIssueGap})
+		}
+	}
+	if len(logs) > 0 {
+		report.Head = logs[len(logs)-1].Hash
+	}
+	report.Valid = len(report.Issues) == 0
+	return report
+}
+
 // ============================================================================
 // RBAC (Role-Based Access Control)
 // ============================================================================
@@ -417,10 +564,63 @@ func HasRolePermission(role UserRole, resource ResourceType, permission Permissi
 	return false
 }
 
-// CanAccess checks if a user can perform an action on a resource.
+// CanAccess checks if a user can perform an action on a resource type.
 func (u *User) CanAccess(resource ResourceType, permission Permission) bool {
+	return u.CanAccessInstance(resource, permission, "")
+}
+
+// CanAccessInstance checks if a user can perform an action on one resource.
+// The user's grants are consulted first; when they hold instance grants for
+// the resource type and permission, only those instances are allowed.
+// Otherwise the role's permissions apply.
+func (u *User) CanAccessInstance(resource ResourceType, permission Permission, resourceID string) bool {
 	if u.Status != UserStatusActive {
 		return false
 	}
+	allowed, scoped := MatchGrants(u.Grants, resource, permission, resourceID)
+	if allowed || scoped {
+		return allowed
+	}
 	return HasRolePermission(u.Role, resource, permission)
 }
+
+// InstanceGrant returns the grant allowing permission on a single resource,
+// e.g. "workflows:write:<uuid>".
+func InstanceGrant(resource ResourceType, permission Permission, resourceID string) string {
+	return string(resource) + ":" + string(permission) + ":" + resourceID
+}
+
+// MatchGrants checks permission grants of the form "resource:permission" or
+// "resource:permission:id" against an action on a resource. Any part may be
+// "*", "*" alone grants everything, and the admin permission implies the
+// others. Instance grants only apply when resourceID is set; scoped reports
+// whether one exists for the resource type and permission, in which case
+// other instances are denied rather than left to the role.
+func MatchGrants(grants []string, resource ResourceType, permission Permission, resourceID string) (allowed, scoped bool) {
+	for _, grant := range grants {
+		if grant == "*" {
+			return true, scoped
+		}
+		parts := strings.Split(grant, ":")
+		if len(parts) < 2 || len(parts) > 3 {
+			continue
+		}
+		if parts[0] != "*" && parts[0] != string(resource) {
+			continue
+		}
+		if parts[1] != "*" && parts[1] != string(permission) && parts[1] != string(PermissionAdmin) {
+			continue
+		}
+		if len(parts) == 2 || parts[2] == "*" {
+			return true, scoped
+		}
+		if resourceID == "" {
+			continue
+		}
+		scoped = true
+		if parts[2] == resourceID {
+			return true, scoped
+		}
+	}
+	return false, scoped
+}
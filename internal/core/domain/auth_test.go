@@ -361,6 +361,122 @@ func TestAuditLog_WithError(t *testing.T) {
 	}
 }
 
+// auditChain returns n sealed, linked audit log entries.
+func auditChain(n int) []*AuditLog {
+	logs := make([]*AuditLog, n)
+	prev := ""
+	for i := range logs {
+		userID := uuid.New()
+		logs[i] = NewAuditLog(&userID, "user.login", "session", "").
+			WithDetails(map[string]string{"seq": string(rune('a' + i))})
+		logs[i].Seal(prev)
+		prev = logs[i].Hash
+	}
+	return logs
+}
+
+func TestAuditLog_Seal(t *testing.T) {
+	audit := NewAuditLog(nil, "login", "session", "")
+	audit.Seal("abc")
+
+	if audit.PrevHash != "abc" {
+		t.Errorf("PrevHash = %v, want abc", audit.PrevHash)
+	}
+	if len(audit.Hash) != 64 || audit.Hash != audit.ComputeHash() {
+		t.Errorf("Hash = %v, want hex SHA-256 of contents", audit.Hash)
+	}
+
+	before := audit.Hash
+	audit.Seal("def")
+	if audit.Hash == before {
+		t.Error("Hash should depend on PrevHash")
+	}
+}
+
+func TestVerifyAuditChain(t *testing.T) {
+	if report := VerifyAuditChain(auditChain(5)); !report.Valid || report.Checked != 5 {
+		t.Errorf("intact chain: got %+v", report)
+	}
+	if report := VerifyAuditChain(nil); !report.Valid || report.Checked != 0 {
+		t.Errorf("empty chain: got %+v", report)
+	}
+
+	// A chain pruned by retention still verifies from its oldest entry
+	if report := VerifyAuditChain(auditChain(5)[2:]); !report.Valid {
+		t.Errorf("pruned chain: got %+v", report)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(logs []*AuditLog) []*AuditLog
+		want   []AuditChainIssue
+	}{
+		{
+			name: "modified middle entry",
+			mutate: func(logs []*AuditLog) []*AuditLog {
+				logs[2].Details["seq"] = "forged"
+				return logs
+			},
+			want: []AuditChainIssue{{Index: 2, Reason: AuditIssueTampered}},
+		},
+		{
+			name: "modified and rehashed middle entry",
+			mutate: func(logs []*AuditLog) []*AuditLog {
+				logs[2].Success = false
+				logs[2].Seal(logs[2].PrevHash)
+				return logs
+			},
+			want: []AuditChainIssue{{Index: 3, Reason: AuditIssueGap}},
+		},
+		{
+			name: "deleted entry",
+			mutate: func(logs []*AuditLog) []*AuditLog {
+				return append(logs[:2], logs[3:]...)
+			},
+			want: []AuditChainIssue{{Index: 2, Reason: AuditIssueGap}},
+		},
+		{
+			name: "swapped entries",
+			mutate: func(logs []*AuditLog) []*AuditLog {
+				logs[1], logs[2] = logs[2], logs[1]
+				return logs
+			},
+			want: []AuditChainIssue{
+				{Index: 1, Reason: AuditIssueGap},
+				{Index: 2, Reason: AuditIssueGap},
+				{Index: 3, Reason: AuditIssueGap},
+			},
+		},
+		{
+			name: "unsealed entry",
+			mutate: func(logs []*AuditLog) []*AuditLog {
+				logs[4].Hash = ""
+				return logs
+			},
+			want: []AuditChainIssue{{Index: 4, Reason: AuditIssueUnsealed}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := tt.mutate(auditChain(5))
+			report := VerifyAuditChain(logs)
+			if report.Valid {
+				t.Fatal("expected verification to fail")
+			}
+			if len(report.Issues) != len(tt.want) {
+				t.Fatalf("Issues = %+v, want %+v", report.Issues, tt.want)
+			}
+			for i, want := range tt.want {
+				got := report.Issues[i]
+				if got.Index != want.Index || got.Reason != want.Reason || got.ID != logs[want.Index].ID {
+					t.Errorf("Issues[%d] = %+v, want %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
 func TestAuditLog_WithContext(t *testing.T) {
 	audit := NewAuditLog(nil, "login", "session", "")
 	audit.WithContext("10.0.0.1", "curl/7.68.0")
@@ -416,3 +532,67 @@ func TestUser_CanAccess(t *testing.T) {
 	}
 }
 
+
+func TestMatchGrants(t *testing.T) {
+	tests := []struct {
+		grants      []string
+		resource    ResourceType
+		permission  Permission
+		id          string
+		wantAllowed bool
+		wantScoped  bool
+	}{
+		{[]string{"*"}, ResourceUsers, PermissionDelete, "", true, false},
+		{[]string{"workflows:write"}, ResourceWorkflows, PermissionWrite, "wf-1", true, false},
+		{[]string{"workflows:*"}, ResourceWorkflows, PermissionDelete, "", true, false},
+		{[]string{"*:read"}, ResourceLogs, PermissionRead, "", true, false},
+		{[]string{"workflows:admin"}, ResourceWorkflows, PermissionDelete, "", true, false},
+		{[]string{"workflows:write:*"}, ResourceWorkflows, PermissionWrite, "wf-2", true, false},
+		{[]string{"workflows:write:wf-1"}, ResourceWorkflows, PermissionWrite, "wf-1", true, true},
+		{[]string{"workflows:write:wf-1"}, ResourceWorkflows, PermissionWrite, "wf-2", false, true},
+		{[]string{"workflows:write:wf-1"}, ResourceWorkflows, PermissionWrite, "", false, false},
+		{[]string{"workflows:write:wf-1"}, ResourceWorkflows, PermissionDelete, "wf-1", false, false},
+		{[]string{"workflows:write:wf-1"}, ResourceTasks, PermissionWrite, "wf-1", false, false},
+		{[]string{"workflows", "a:b:c:d"}, ResourceWorkflows, PermissionRead, "", false, false},
+	}
+
+	for _, tt := range tests {
+		allowed, scoped := MatchGrants(tt.grants, tt.resource, tt.permission, tt.id)
+		if allowed != tt.wantAllowed || scoped != tt.wantScoped {
+			t.Errorf("MatchGrants(%v, %v, %v, %q) = %v, %v, want %v, %v",
+				tt.grants, tt.resource, tt.permission, tt.id, allowed, scoped, tt.wantAllowed, tt.wantScoped)
+		}
+	}
+}
+
+func TestUser_CanAccessInstance(t *testing.T) {
+	allowedID, otherID := "0191c1a4-0000-7000-8000-000000000001", "0191c1a4-0000-7000-8000-000000000002"
+
+	// An instance grant lets a viewer write one workflow but not another
+	viewer, _ := NewUser("viewer", "viewer@test.com", "pass", RoleViewer)
+	viewer.Grants = []string{InstanceGrant(ResourceWorkflows, PermissionWrite, allowedID)}
+	if !viewer.CanAccessInstance(ResourceWorkflows, PermissionWrite, allowedID) {
+		t.Error("Viewer should write the granted workflow")
+	}
+	if viewer.CanAccessInstance(ResourceWorkflows, PermissionWrite, otherID) {
+		t.Error("Viewer should not write another workflow")
+	}
+	if !viewer.CanAccessInstance(ResourceWorkflows, PermissionRead, otherID) {
+		t.Error("Viewer should still read workflows through the role")
+	}
+
+	// and limits an operator, whose role could write any workflow, to it
+	operator, _ := NewUser("operator", "operator@test.com", "pass", RoleOperator)
+	operator.Grants = []string{InstanceGrant(ResourceWorkflows, PermissionWrite, allowedID)}
+	if operator.CanAccessInstance(ResourceWorkflows, PermissionWrite, otherID) {
+		t.Error("Instance grant should restrict the operator to the granted workflow")
+	}
+	if !operator.CanAccess(ResourceWorkflows, PermissionWrite) {
+		t.Error("Type-level access should fall back to the role")
+	}
+
+	viewer.Status = UserStatusInactive
+	if viewer.CanAccessInstance(ResourceWorkflows, PermissionWrite, allowedID) {
+		t.Error("Inactive user should not access anything")
+	}
+}
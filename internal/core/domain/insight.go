@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InsightIssue is a single issue found by an analysis.
+type InsightIssue struct {
+	Severity    string `json:"severity"` // "info", "warning", "error", "critical"
+	Component   string `json:"component"`
+	Description string `json:"description"`
+	Suggestion  string `json:"suggestion,omitempty"`
+}
+
+// Insight is the persisted outcome of an AI analysis run, kept so that
+// users can see how system health evolved over time.
+type Insight struct {
+	ID          uuid.UUID      `json:"id"`
+	Timestamp   time.Time      `json:"timestamp"`
+	TimeRange   time.Duration  `json:"time_range"` // Window the analysis looked at
+	MetricCount int            `json:"metric_count"`
+	TaskCount   int            `json:"task_count"`
+	Summary     string         `json:"summary"`
+	Issues      []InsightIssue `json:"issues"`
+}
+
+// NewInsight creates a new insight recorded now.
+func NewInsight(timeRange time.Duration, summary string, issues []InsightIssue) *Insight {
+	return &Insight{
+		ID:        uuid.Must(uuid.NewV7()),
+		Timestamp: time.Now(),
+		TimeRange: timeRange,
+		Summary:   summary,
+		Issues:    issues,
+	}
+}
+
+// insightSeverityRank orders issue severities from least to most severe.
+var insightSeverityRank = map[string]int{
+	"info":     1,
+	"warning":  2,
+	"error":    3,
+	"critical": 4,
+}
+
+// MaxSeverity returns the most severe issue severity, or "" when the
+// analysis found no issues.
+func (i *Insight) MaxSeverity() string {
+	max := ""
+	for _, issue := range i.Issues {
+		if insightSeverityRank[issue.Severity] > insightSeverityRank[max] {
+			max = issue.Severity
+		}
+	}
+	return max
+}
@@ -0,0 +1,22 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInsight_MaxSeverity(t *testing.T) {
+	insight := NewInsight(time.Hour, "ok", nil)
+	if got := insight.MaxSeverity(); got != "" {
+		t.Errorf("expected no severity without issues, got %q", got)
+	}
+
+	insight.Issues = []InsightIssue{
+		{Severity: "info", Component: "cpu"},
+		{Severity: "error", Component: "disk"},
+		{Severity: "warning", Component: "task_queue"},
+	}
+	if got := insight.MaxSeverity(); got != "error" {
+		t.Errorf("expected error, got %q", got)
+	}
+}
@@ -2,7 +2,9 @@
 package domain
 
 import (
+	"fmt"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,6 +20,9 @@ const (
 	LogLevelWarning LogLevel = "warning"
 	LogLevelError   LogLevel = "error"
 	LogLevelFatal   LogLevel = "fatal"
+
+	// LogLevelUnknown marks ingested lines whose level could not be parsed.
+	LogLevelUnknown LogLevel = "unknown"
 )
 
 // LogLevelPriority returns the priority of a log level (higher = more severe).
@@ -40,6 +45,40 @@ func LogLevelPriority(level LogLevel) int {
 	}
 }
 
+// ParseLogLevel parses a log level, ignoring case. "warn" is accepted as
+// an alias for warning.
+func ParseLogLevel(s string) (LogLevel, error) {
+	level := LogLevel(strings.ToLower(strings.TrimSpace(s)))
+	switch level {
+	case LogLevelTrace, LogLevelDebug, LogLevelInfo, LogLevelWarning, LogLevelError, LogLevelFatal, LogLevelUnknown:
+		return level, nil
+	case "warn":
+		return LogLevelWarning, nil
+	}
+	return "", fmt.Errorf("invalid log level %q", s)
+}
+
+// NormalizeLogLevel maps the level spellings commonly found in log lines
+// (e.g. "WARN", "err", "CRITICAL") onto a LogLevel, returning
+// LogLevelUnknown for anything unrecognized.
+func NormalizeLogLevel(s string) LogLevel {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "trace", "trc":
+		return LogLevelTrace
+	case "debug", "dbg":
+		return LogLevelDebug
+	case "info", "inf", "information", "notice":
+		return LogLevelInfo
+	case "warning", "warn", "wrn":
+		return LogLevelWarning
+	case "error", "err", "eror":
+		return LogLevelError
+	case "fatal", "ftl", "critical", "crit", "panic", "emerg", "emergency", "alert":
+		return LogLevelFatal
+	}
+	return LogLevelUnknown
+}
+
 // LogEntry represents a single log entry.
 type LogEntry struct {
 	ID          uuid.UUID         `json:"id"`
@@ -153,29 +192,63 @@ func (p *LogParser) Compile() error {
 	return nil
 }
 
+// Validate checks that the parser type is known and that pattern-based
+// parsers have a pattern that compiles.
+func (p *LogParser) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("parser name is required")
+	}
+	switch p.Type {
+	case ParserTypeRegex, ParserTypeGrok:
+		if p.Pattern == "" {
+			return fmt.Errorf("%s parser requires a pattern", p.Type)
+		}
+	case ParserTypeJSON, ParserTypeKeyValue:
+	default:
+		return fmt.Errorf("unknown parser type %q", p.Type)
+	}
+	return p.Compile()
+}
+
 // GetCompiledRegex returns the compiled regex.
 func (p *LogParser) GetCompiledRegex() *regexp.Regexp {
 	return p.compiledRegex
 }
 
+// LogToMetricRuleStatus reports whether a rule is being evaluated.
+type LogToMetricRuleStatus string
+
+const (
+	LogToMetricRuleActive LogToMetricRuleStatus = "active"
+	LogToMetricRuleError  LogToMetricRuleStatus = "error" // Disabled because it could not be compiled
+)
+
 // LogToMetricRule defines a rule for converting logs to metrics.
 type LogToMetricRule struct {
-	ID          uuid.UUID         `json:"id"`
-	Name        string            `json:"name"`
-	Description string            `json:"description,omitempty"`
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
 	// Match condition
-	MatchField    string   `json:"match_field"`    // Field to match (e.g., "level", "message")
-	MatchPattern  string   `json:"match_pattern"`  // Regex pattern to match
-	MatchValues   []string `json:"match_values,omitempty"` // Exact values to match
+	MatchField   string   `json:"match_field"`            // Field to match (e.g., "level", "message")
+	MatchPattern string   `json:"match_pattern"`          // Regex pattern to match
+	MatchValues  []string `json:"match_values,omitempty"` // Exact values to match
+	MinLevel     LogLevel `json:"min_level,omitempty"`    // Only entries at or above this level
+	ServiceName  string   `json:"service_name,omitempty"` // Only entries from this service
+	Source       string   `json:"source,omitempty"`       // Only entries from this source
 	// Metric configuration
-	MetricName string            `json:"metric_name"`
-	MetricType MetricType        `json:"metric_type"` // gauge, counter
-	ValueField string            `json:"value_field,omitempty"` // Field to extract value from (for gauge)
-	Tags       map[string]string `json:"tags,omitempty"`
-	TagFields  []string          `json:"tag_fields,omitempty"` // Log fields to use as metric tags
-	Enabled    bool              `json:"enabled"`
-	CreatedAt  time.Time         `json:"created_at"`
-	UpdatedAt  time.Time         `json:"updated_at"`
+	MetricName string                `json:"metric_name"`
+	MetricType MetricType            `json:"metric_type"`           // gauge, counter
+	ValueField string                `json:"value_field,omitempty"` // Capture group or field to extract value from
+	Tags       map[string]string     `json:"tags,omitempty"`
+	TagFields  []string              `json:"tag_fields,omitempty"` // Log fields to use as metric tags
+	Enabled    bool                  `json:"enabled"`
+	Status     LogToMetricRuleStatus `json:"status"`
+	LastError  string                `json:"last_error,omitempty"`
+	CreatedAt  time.Time             `json:"created_at"`
+	UpdatedAt  time.Time             `json:"updated_at"`
+
+	// Compiled match pattern (not serialized)
+	compiledRegex *regexp.Regexp
 }
 
 // NewLogToMetricRule creates a new log-to-metric rule.
@@ -191,11 +264,61 @@ func NewLogToMetricRule(name, matchField, matchPattern, metricName string, metri
 		Tags:         make(map[string]string),
 		TagFields:    []string{},
 		Enabled:      true,
+		Status:       LogToMetricRuleActive,
 		CreatedAt:    now,
 		UpdatedAt:    now,
 	}
 }
 
+// Compile compiles the match pattern.
+func (r *LogToMetricRule) Compile() error {
+	if r.MatchPattern == "" {
+		r.compiledRegex = nil
+		return nil
+	}
+	re, err := regexp.Compile(r.MatchPattern)
+	if err != nil {
+		return err
+	}
+	r.compiledRegex = re
+	return nil
+}
+
+// Validate checks that the rule can be evaluated.
+func (r *LogToMetricRule) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("rule name is required")
+	}
+	if r.MetricName == "" {
+		return fmt.Errorf("metric name is required")
+	}
+	if r.MatchPattern == "" && len(r.MatchValues) == 0 {
+		return fmt.Errorf("a match pattern or match values are required")
+	}
+	switch r.MetricType {
+	case MetricTypeCounter:
+	case MetricTypeGauge:
+		if r.ValueField == "" {
+			return fmt.Errorf("gauge rules require a value field")
+		}
+	default:
+		return fmt.Errorf("unsupported metric type %q (use counter or gauge)", r.MetricType)
+	}
+	if r.MinLevel != "" {
+		level, err := ParseLogLevel(string(r.MinLevel))
+		if err != nil {
+			return err
+		}
+		r.MinLevel = level
+	}
+	return r.Compile()
+}
+
+// GetCompiledRegex returns the compiled match pattern.
+func (r *LogToMetricRule) GetCompiledRegex() *regexp.Regexp {
+	return r.compiledRegex
+}
+
 // LogQuery represents a query for searching logs.
 type LogQuery struct {
 	StartTime   time.Time         `json:"start_time"`
@@ -98,6 +98,62 @@ func TestLogLevelPriority(t *testing.T) {
 	}
 }
 
+func TestParseLogLevel(t *testing.T) {
+	tests := []struct {
+		input string
+		want  LogLevel
+	}{
+		{"error", LogLevelError},
+		{"WARNING", LogLevelWarning},
+		{"warn", LogLevelWarning},
+		{" Debug ", LogLevelDebug},
+	}
+
+	for _, tc := range tests {
+		got, err := ParseLogLevel(tc.input)
+		if err != nil || got != tc.want {
+			t.Errorf("ParseLogLevel(%q) = %v, %v, want %v", tc.input, got, err, tc.want)
+		}
+	}
+
+	if _, err := ParseLogLevel("verbose"); err == nil {
+		t.Error("expected error for unknown level")
+	}
+}
+
+func TestNormalizeLogLevel(t *testing.T) {
+	tests := map[string]LogLevel{
+		"WARN":     LogLevelWarning,
+		"err":      LogLevelError,
+		"CRITICAL": LogLevelFatal,
+		"notice":   LogLevelInfo,
+		"":         LogLevelUnknown,
+		"verbose":  LogLevelUnknown,
+	}
+	for input, want := range tests {
+		if got := NormalizeLogLevel(input); got != want {
+			t.Errorf("NormalizeLogLevel(%q) = %v, want %v", input, got, want)
+		}
+	}
+}
+
+func TestLogParser_Validate(t *testing.T) {
+	if err := NewLogParser("kv", ParserTypeKeyValue, "").Validate(); err != nil {
+		t.Errorf("expected key/value parser without pattern to be valid, got %v", err)
+	}
+	invalid := []*LogParser{
+		NewLogParser("", ParserTypeJSON, ""),
+		NewLogParser("re", ParserTypeRegex, ""),
+		NewLogParser("re", ParserTypeRegex, `(?P<level>\w+`),
+		NewLogParser("csv", LogParserType("csv"), ""),
+	}
+	for _, p := range invalid {
+		if err := p.Validate(); err == nil {
+			t.Errorf("expected parser %q (%s, %q) to be invalid", p.Name, p.Type, p.Pattern)
+		}
+	}
+}
+
 func TestNewLogParser(t *testing.T) {
 	parser := NewLogParser("apache-parser", ParserTypeRegex, `(?P<ip>\d+\.\d+\.\d+\.\d+)`)
 
@@ -237,3 +293,27 @@ func TestLogEntry_SetAttribute_NilMap(t *testing.T) {
 	}
 }
 
+func TestLogToMetricRule_Validate(t *testing.T) {
+	valid := NewLogToMetricRule("errors", "message", "ERROR", "app.error.count", MetricTypeCounter)
+	valid.MinLevel = "warn"
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("expected valid rule, got %v", err)
+	}
+	if valid.MinLevel != LogLevelWarning || valid.GetCompiledRegex() == nil {
+		t.Errorf("expected normalized level and compiled pattern, got %q", valid.MinLevel)
+	}
+
+	invalid := []*LogToMetricRule{
+		NewLogToMetricRule("", "message", "ERROR", "m", MetricTypeCounter),
+		NewLogToMetricRule("r", "message", "ERROR", "", MetricTypeCounter),
+		NewLogToMetricRule("r", "message", "", "m", MetricTypeCounter),
+		NewLogToMetricRule("r", "message", "(", "m", MetricTypeCounter),
+		NewLogToMetricRule("r", "message", "ERROR", "m", MetricTypeHistogram),
+		NewLogToMetricRule("r", "message", "took (\\d+)", "m", MetricTypeGauge), // no value field
+	}
+	for i, rule := range invalid {
+		if err := rule.Validate(); err == nil {
+			t.Errorf("case %d: expected rule %+v to be invalid", i, rule)
+		}
+	}
+}
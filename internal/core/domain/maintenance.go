@@ -0,0 +1,263 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxMaintenanceDuration bounds how long each occurrence of a maintenance
+// window lasts.
+const MaxMaintenanceDuration = 7 * 24 * time.Hour
+
+// MaintenanceWindow suppresses alerts matching its matchers during a window
+// that recurs on the given days of the week. Each occurrence starts at
+// StartTime ("HH:MM") in Timezone (default UTC) on one of Days and lasts
+// Duration, so a window may run past midnight into the next day. Days name
+// the day an occurrence starts; no days means every day.
+type MaintenanceWindow struct {
+	ID        uuid.UUID         `json:"id"`
+	Name      string            `json:"name"`
+	Matchers  map[string]string `json:"matchers"`
+	Days      []time.Weekday    `json:"days,omitempty"`
+	StartTime string            `json:"start_time"`
+	Duration  time.Duration     `json:"duration"`
+	Timezone  string            `json:"timezone,omitempty"`
+	Enabled   bool              `json:"enabled"`
+	Comment   string            `json:"comment,omitempty"`
+	CreatedBy string            `json:"created_by,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// NewMaintenanceWindow creates an enabled maintenance window.
+func NewMaintenanceWindow(name string, matchers map[string]string, days []time.Weekday, startTime string, duration time.Duration, timezone string) *MaintenanceWindow {
+	now := time.Now()
+	return &MaintenanceWindow{
+		ID:        uuid.New(),
+		Name:      name,
+		Matchers:  matchers,
+		Days:      days,
+		StartTime: startTime,
+		Duration:  duration,
+		Timezone:  timezone,
+		Enabled:   true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// Validate checks the window's name, recurrence and time zone.
+func (w *MaintenanceWindow) Validate() error {
+	if w.Name == "" {
+		return fmt.Errorf("maintenance window name is required")
+	}
+	if _, _, err := parseClock(w.StartTime); err != nil {
+		return err
+	}
+	if w.Duration <= 0 || w.Duration > MaxMaintenanceDuration {
+		return fmt.Errorf("maintenance window duration must be positive and at most %s", MaxMaintenanceDuration)
+	}
+	for _, d := range w.Days {
+		if d < time.Sunday || d > time.Saturday {
+			return fmt.Errorf("invalid day of week %d", d)
+		}
+	}
+	if _, err := w.Location(); err != nil {
+		return fmt.Errorf("invalid maintenance window time zone: %w", err)
+	}
+	return nil
+}
+
+// Location returns the time zone the window is evaluated in.
+func (w *MaintenanceWindow) Location() (*time.Location, error) {
+	return locationOrUTC(w.Timezone)
+}
+
+// locationOrUTC loads the named time zone, UTC if name is empty.
+func locationOrUTC(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(name)
+}
+
+// Matches checks if an alert's labels match the window's matchers.
+func (w *MaintenanceWindow) Matches(labels map[string]string) bool {
+	for key, value := range w.Matchers {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// ActiveAt reports whether an occurrence of an enabled window contains now.
+// Occurrences start at the wall clock StartTime on their local day, so they
+// follow daylight saving changes; a start time skipped by a transition is
+// moved forward by the length of the gap. Duration is elapsed time.
+func (w *MaintenanceWindow) ActiveAt(now time.Time) bool {
+	_, ok := w.OccurrenceAt(now)
+	return ok
+}
+
+// OccurrenceAt returns the start of the occurrence containing now, if any.
+func (w *MaintenanceWindow) OccurrenceAt(now time.Time) (time.Time, bool) {
+	if !w.Enabled {
+		return time.Time{}, false
+	}
+	loc, err := w.Location()
+	if err != nil {
+		return time.Time{}, false
+	}
+	hour, minute, err := parseClock(w.StartTime)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	local := now.In(loc)
+	// Occurrences that started on any day within Duration may still be open
+	lookback := int(w.Duration/(24*time.Hour)) + 1
+	for back := 0; back <= lookback; back++ {
+		day := time.Date(local.Year(), local.Month(), local.Day()-back, 0, 0, 0, 0, loc)
+		if !onWeekday(w.Days, day.Weekday()) {
+			continue
+		}
+		start := wallClock(day, hour, minute)
+		if !now.Before(start) && now.Before(start.Add(w.Duration)) {
+			return start, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// onWeekday reports whether day is one of days; no days means every day.
+func onWeekday(days []time.Weekday, day time.Weekday) bool {
+	if len(days) == 0 {
+		return true
+	}
+	for _, d := range days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// wallClock returns hour:minute on day in day's location. A time skipped by
+// a daylight saving transition is moved forward by the length of the gap.
+func wallClock(day time.Time, hour, minute int) time.Time {
+	t := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, day.Location())
+	if t.Hour() != hour || t.Minute() != minute {
+		// time.Date normalized the skipped time backwards
+		_, before := t.Zone()
+		_, after := t.Add(3 * time.Hour).Zone()
+		t = t.Add(time.Duration(after-before) * time.Second)
+	}
+	return t
+}
+
+// DaysString formats the window's days for display, e.g. "mon,tue".
+func (w *MaintenanceWindow) DaysString() string {
+	return formatWeekdays(w.Days)
+}
+
+// formatWeekdays formats days for display; no days means every day.
+func formatWeekdays(days []time.Weekday) string {
+	if len(days) == 0 {
+		return "daily"
+	}
+	days = append([]time.Weekday(nil), days...)
+	sort.Slice(days, func(i, j int) bool { return days[i] < days[j] })
+	names := make([]string, len(days))
+	for i, d := range days {
+		names[i] = weekdayNames[d]
+	}
+	return strings.Join(names, ",")
+}
+
+var weekdayNames = [...]string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// ParseWeekdays parses a comma-separated list of days such as
+// "mon,wed,fri", ranges such as "mon-fri", or the shorthands "weekdays",
+// "weekends" and "daily". Full day names are accepted too.
+func ParseWeekdays(s string) ([]time.Weekday, error) {
+	var days []time.Weekday
+	seen := make(map[time.Weekday]bool)
+	add := func(d time.Weekday) {
+		if !seen[d] {
+			seen[d] = true
+			days = append(days, d)
+		}
+	}
+
+	for _, part := range strings.Split(strings.ToLower(s), ",") {
+		part = strings.TrimSpace(part)
+		switch part {
+		case "":
+			continue
+		case "daily", "*":
+			return nil, nil
+		case "weekdays":
+			for d := time.Monday; d <= time.Friday; d++ {
+				add(d)
+			}
+			continue
+		case "weekends":
+			add(time.Saturday)
+			add(time.Sunday)
+			continue
+		}
+
+		if from, to, ok := strings.Cut(part, "-"); ok {
+			first, err := parseWeekday(from)
+			if err != nil {
+				return nil, err
+			}
+			last, err := parseWeekday(to)
+			if err != nil {
+				return nil, err
+			}
+			// Ranges may wrap around the week, e.g. fri-mon
+			for d := first; ; d = (d + 1) % 7 {
+				add(d)
+				if d == last {
+					break
+				}
+			}
+			continue
+		}
+
+		d, err := parseWeekday(part)
+		if err != nil {
+			return nil, err
+		}
+		add(d)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i] < days[j] })
+	return days, nil
+}
+
+func parseWeekday(s string) (time.Weekday, error) {
+	s = strings.TrimSpace(s)
+	if len(s) >= 3 {
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			if strings.HasPrefix(strings.ToLower(d.String()), s) {
+				return d, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("invalid day of week %q", s)
+}
+
+// parseClock parses an "HH:MM" time of day.
+func parseClock(s string) (int, int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid start time %q, want HH:MM", s)
+	}
+	return t.Hour(), t.Minute(), nil
+}
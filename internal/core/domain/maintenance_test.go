@@ -0,0 +1,150 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMaintenanceWindow_ActiveAt(t *testing.T) {
+	// Fridays from 22:00 for four hours, running into Saturday
+	w := NewMaintenanceWindow("nightly", nil, []time.Weekday{time.Friday}, "22:00", 4*time.Hour, "")
+
+	tests := []struct {
+		name   string
+		now    time.Time
+		active bool
+	}{
+		{"opens friday", time.Date(2024, 3, 1, 22, 0, 0, 0, time.UTC), true},
+		{"before midnight", time.Date(2024, 3, 1, 23, 59, 0, 0, time.UTC), true},
+		{"after midnight", time.Date(2024, 3, 2, 1, 30, 0, 0, time.UTC), true},
+		{"closes saturday", time.Date(2024, 3, 2, 2, 0, 0, 0, time.UTC), false},
+		{"before opening", time.Date(2024, 3, 1, 21, 59, 0, 0, time.UTC), false},
+		{"saturday night", time.Date(2024, 3, 2, 22, 30, 0, 0, time.UTC), false},
+		{"thursday overnight", time.Date(2024, 3, 1, 1, 0, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		if got := w.ActiveAt(tt.now); got != tt.active {
+			t.Errorf("%s: ActiveAt = %v, want %v", tt.name, got, tt.active)
+		}
+	}
+
+	w.Enabled = false
+	if w.ActiveAt(time.Date(2024, 3, 1, 23, 0, 0, 0, time.UTC)) {
+		t.Error("disabled window should never be active")
+	}
+}
+
+func TestMaintenanceWindow_MultiDayDuration(t *testing.T) {
+	// Saturday 00:00 for the whole weekend
+	w := NewMaintenanceWindow("weekend", nil, []time.Weekday{time.Saturday}, "00:00", 48*time.Hour, "")
+
+	if !w.ActiveAt(time.Date(2024, 3, 3, 23, 0, 0, 0, time.UTC)) {
+		t.Error("expected window to be active late on Sunday")
+	}
+	if w.ActiveAt(time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Error("expected window to be closed on Monday")
+	}
+}
+
+func TestMaintenanceWindow_DST(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	// Daily at 01:00 New York time for an hour; clocks spring forward on
+	// 2024-03-10 and fall back on 2024-11-03
+	w := NewMaintenanceWindow("backup", nil, nil, "01:00", time.Hour, "America/New_York")
+
+	tests := []struct {
+		name   string
+		now    time.Time
+		active bool
+	}{
+		{"standard time", time.Date(2024, 3, 9, 6, 30, 0, 0, time.UTC), true},
+		{"daylight time", time.Date(2024, 3, 11, 5, 30, 0, 0, time.UTC), true},
+		{"daylight time, standard offset", time.Date(2024, 3, 11, 6, 30, 0, 0, time.UTC), false},
+		{"wall clock in new york", time.Date(2024, 7, 1, 1, 15, 0, 0, ny), true},
+	}
+	for _, tt := range tests {
+		if got := w.ActiveAt(tt.now); got != tt.active {
+			t.Errorf("%s: ActiveAt = %v, want %v", tt.name, got, tt.active)
+		}
+	}
+
+	// 02:30 does not exist on the spring-forward day; the occurrence
+	// still happens, shifted past the gap
+	skipped := NewMaintenanceWindow("skipped", nil, nil, "02:30", 30*time.Minute, "America/New_York")
+	start, ok := skipped.OccurrenceAt(time.Date(2024, 3, 10, 7, 45, 0, 0, time.UTC))
+	if !ok {
+		t.Fatal("expected an occurrence on the spring-forward day")
+	}
+	if want := time.Date(2024, 3, 10, 7, 30, 0, 0, time.UTC); !start.Equal(want) {
+		t.Errorf("occurrence start = %v, want %v", start.UTC(), want)
+	}
+
+	// Duration is elapsed time, so a window spanning the fall-back
+	// transition lasts exactly as long as configured
+	overnight := NewMaintenanceWindow("overnight", nil, nil, "00:00", 3*time.Hour, "America/New_York")
+	if !overnight.ActiveAt(time.Date(2024, 11, 3, 1, 30, 0, 0, ny).Add(time.Hour)) {
+		t.Error("expected window to cover the repeated hour")
+	}
+	if overnight.ActiveAt(time.Date(2024, 11, 3, 4, 0, 0, 0, time.UTC).Add(3 * time.Hour)) {
+		t.Error("expected window to close three hours after it opened")
+	}
+}
+
+func TestMaintenanceWindow_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		window  *MaintenanceWindow
+		wantErr bool
+	}{
+		{"valid", NewMaintenanceWindow("w", nil, nil, "02:00", time.Hour, "Europe/Berlin"), false},
+		{"missing name", NewMaintenanceWindow("", nil, nil, "02:00", time.Hour, ""), true},
+		{"bad start time", NewMaintenanceWindow("w", nil, nil, "2am", time.Hour, ""), true},
+		{"zero duration", NewMaintenanceWindow("w", nil, nil, "02:00", 0, ""), true},
+		{"too long", NewMaintenanceWindow("w", nil, nil, "02:00", 8*24*time.Hour, ""), true},
+		{"bad time zone", NewMaintenanceWindow("w", nil, nil, "02:00", time.Hour, "Mars/Olympus"), true},
+		{"bad day", NewMaintenanceWindow("w", nil, []time.Weekday{9}, "02:00", time.Hour, ""), true},
+	}
+	for _, tt := range tests {
+		if err := tt.window.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestParseWeekdays(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []time.Weekday
+		wantErr bool
+	}{
+		{"mon,wed,fri", []time.Weekday{time.Monday, time.Wednesday, time.Friday}, false},
+		{"mon-fri", []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}, false},
+		{"fri-mon", []time.Weekday{time.Sunday, time.Monday, time.Friday, time.Saturday}, false},
+		{"weekends", []time.Weekday{time.Sunday, time.Saturday}, false},
+		{"Tuesday", []time.Weekday{time.Tuesday}, false},
+		{"daily", nil, false},
+		{"", nil, false},
+		{"mo", nil, true},
+		{"mon-xyz", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseWeekdays(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseWeekdays(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseWeekdays(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+
+	w := &MaintenanceWindow{Days: []time.Weekday{time.Friday, time.Monday}}
+	if got := w.DaysString(); got != "mon,fri" {
+		t.Errorf("DaysString() = %q, want mon,fri", got)
+	}
+}
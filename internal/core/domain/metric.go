@@ -2,6 +2,8 @@ package domain
 
 import (
 	"hash/fnv"
+	"math"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
@@ -48,30 +50,104 @@ func NewMetric(name string, metricType MetricType, value float64, tags map[strin
 	return m
 }
 
+// IsFiniteValue reports whether v can be stored as a metric value. NaN and
+// infinities are rejected since a single one poisons sums and averages.
+func IsFiniteValue(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
 // computeSeriesHash generates a FNV-1a hash of the metric name and tags.
 // This enables fast lookups for time-series queries.
 func (m *Metric) computeSeriesHash() uint64 {
+	return SeriesHash(m.Name, m.Tags)
+}
+
+// SeriesHash returns the FNV-1a hash identifying the series of a name and
+// tags. Tags are hashed in key order with a zero byte after every name, key
+// and value, so the same tags always give the same hash and {"ab": "c"}
+// doesn't collide with {"a": "bc"}.
+func SeriesHash(name string, tags map[string]string) uint64 {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
 	h := fnv.New64a()
-	h.Write([]byte(m.Name))
-	for k, v := range m.Tags {
+	h.Write([]byte(name))
+	h.Write([]byte{0})
+	for _, k := range keys {
 		h.Write([]byte(k))
-		h.Write([]byte(v))
+		h.Write([]byte{0})
+		h.Write([]byte(tags[k]))
+		h.Write([]byte{0})
 	}
 	return h.Sum64()
 }
 
+// SetIdentity changes a metric's name and tags and recomputes its series
+// hash to match.
+func (m *Metric) SetIdentity(name string, tags map[string]string) {
+	m.Name = name
+	m.Tags = tags
+	m.SeriesHash = m.computeSeriesHash()
+}
+
 // MetricSeries represents a collection of metrics with the same identity.
 type MetricSeries struct {
 	Name       string            `json:"name"`
 	Tags       map[string]string `json:"tags"`
 	SeriesHash uint64            `json:"series_hash"`
 	Points     []MetricPoint     `json:"points"`
+	Unit       string            `json:"unit,omitempty"`
+	Type       MetricType        `json:"type,omitempty"`
 }
 
 // MetricPoint represents a single value-timestamp pair in a series.
 type MetricPoint struct {
 	Value     float64   `json:"value"`
 	Timestamp time.Time `json:"timestamp"`
+
+	// Resolution is the data the point was read from, "raw" or a rollup
+	// resolution such as "1m". It is only set by resolution-aware queries.
+	Resolution string `json:"resolution,omitempty"`
+
+	// ID and Type identify the stored sample. They are only set for raw
+	// points when the query asks for them with IncludeIDs.
+	ID   *uuid.UUID `json:"id,omitempty"`
+	Type MetricType `json:"type,omitempty"`
+}
+
+// IsCounter reports whether the series holds cumulative counter samples.
+func (s *MetricSeries) IsCounter() bool {
+	return s.Type == MetricTypeCounter
+}
+
+// CounterRates converts cumulative counter samples into per-second rates,
+// one per consecutive pair of points. A drop in value is treated as a
+// counter reset; the interval spanning it is skipped since the increase
+// before the reset is unknown. It also returns the number of resets seen.
+func CounterRates(points []MetricPoint) ([]MetricPoint, int) {
+	if len(points) < 2 {
+		return nil, 0
+	}
+
+	rates := make([]MetricPoint, 0, len(points)-1)
+	resets := 0
+	for i := 1; i < len(points); i++ {
+		prev, cur := points[i-1], points[i]
+		increase := cur.Value - prev.Value
+		if increase < 0 {
+			resets++
+			continue
+		}
+		elapsed := cur.Timestamp.Sub(prev.Timestamp).Seconds()
+		if elapsed <= 0 {
+			continue
+		}
+		rates = append(rates, MetricPoint{Value: increase / elapsed, Timestamp: cur.Timestamp})
+	}
+	return rates, resets
 }
 
 // AggregatedMetric represents a downsampled metric for long-term storage.
@@ -106,14 +182,7 @@ func NewAggregatedMetric(name string, tags map[string]string, points []MetricPoi
 		Resolution:  resolution,
 	}
 
-	// Compute series hash
-	h := fnv.New64a()
-	h.Write([]byte(name))
-	for k, v := range tags {
-		h.Write([]byte(k))
-		h.Write([]byte(v))
-	}
-	agg.SeriesHash = h.Sum64()
+	agg.SeriesHash = SeriesHash(name, tags)
 
 	// Compute aggregations
 	agg.Min = points[0].Value
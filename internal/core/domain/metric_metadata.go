@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"path"
+)
+
+// MetricMetadata describes a metric for consumers such as the Prometheus
+// exporter, which renders it as HELP and TYPE lines.
+type MetricMetadata struct {
+	Metric      string     `json:"metric"`         // Metric name or glob pattern, e.g. "http.*"
+	Description string     `json:"description"`    // Free text, may span lines
+	Type        MetricType `json:"type,omitempty"` // Overrides the type recorded with the points
+	Unit        string     `json:"unit,omitempty"`
+}
+
+// Validate checks the metadata entry.
+func (m *MetricMetadata) Validate() error {
+	if m.Metric == "" {
+		return errors.New("metric metadata requires a metric name or pattern")
+	}
+	if _, err := path.Match(m.Metric, ""); err != nil {
+		return fmt.Errorf("invalid metric pattern %q: %w", m.Metric, err)
+	}
+	switch m.Type {
+	case "", MetricTypeGauge, MetricTypeCounter, MetricTypeHistogram:
+	default:
+		return fmt.Errorf("invalid metric type %q", m.Type)
+	}
+	return nil
+}
+
+// Matches reports whether the entry describes the named metric.
+func (m *MetricMetadata) Matches(name string) bool {
+	ok, _ := path.Match(m.Metric, name)
+	return ok
+}
@@ -0,0 +1,31 @@
+package domain
+
+import "testing"
+
+func TestMetricMetadata_Validate(t *testing.T) {
+	valid := MetricMetadata{Metric: "http.*", Description: "HTTP requests", Type: MetricTypeCounter}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	invalid := []MetricMetadata{
+		{},
+		{Metric: "http["},
+		{Metric: "http.requests", Type: "summary"},
+	}
+	for _, m := range invalid {
+		if err := m.Validate(); err == nil {
+			t.Errorf("expected error for metadata %+v", m)
+		}
+	}
+}
+
+func TestMetricMetadata_Matches(t *testing.T) {
+	m := MetricMetadata{Metric: "http.*"}
+	if !m.Matches("http.requests") {
+		t.Error("expected glob match")
+	}
+	if m.Matches("grpc.requests") {
+		t.Error("expected name mismatch to fail")
+	}
+}
@@ -0,0 +1,145 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+)
+
+// MetricRelabelAction is what a relabel rule does to a matching metric.
+type MetricRelabelAction string
+
+const (
+	// MetricRelabelRenameMetric replaces the metric name with Replacement.
+	// With a Regex, the name must match it and Replacement may refer to
+	// its capture groups ($1, ${name}).
+	MetricRelabelRenameMetric MetricRelabelAction = "rename_metric"
+	// MetricRelabelRenameTag moves the value of SourceTag to TargetTag.
+	MetricRelabelRenameTag MetricRelabelAction = "rename_tag"
+	// MetricRelabelDropTag removes SourceTag, or every tag whose key
+	// matches Regex. It is meant for high-cardinality tags such as
+	// request or user IDs.
+	MetricRelabelDropTag MetricRelabelAction = "drop_tag"
+	// MetricRelabelDeriveTag sets TargetTag from the value of SourceTag:
+	// Regex is matched against the value and Replacement expanded with
+	// its capture groups. Metrics whose value does not match are left
+	// untouched.
+	MetricRelabelDeriveTag MetricRelabelAction = "derive_tag"
+)
+
+// MetricRelabelRule rewrites the identity of metrics at ingestion, before
+// they are stored, in the manner of Prometheus relabeling. Every matching
+// rule applies, in order, each seeing the result of the ones before it.
+type MetricRelabelRule struct {
+	Name        string              `json:"name"`
+	Metric      string              `json:"metric"`         // Metric name or glob pattern; empty matches all
+	Tags        map[string]string   `json:"tags,omitempty"` // Tags that must match exactly
+	Action      MetricRelabelAction `json:"action"`
+	SourceTag   string              `json:"source_tag,omitempty"`
+	TargetTag   string              `json:"target_tag,omitempty"`
+	Regex       string              `json:"regex,omitempty"` // Anchored at both ends
+	Replacement string              `json:"replacement,omitempty"`
+
+	re *regexp.Regexp
+}
+
+// Validate checks the rule, fills in defaults and compiles its regex.
+func (r *MetricRelabelRule) Validate() error {
+	if r.Metric == "" {
+		r.Metric = "*"
+	}
+	if _, err := path.Match(r.Metric, ""); err != nil {
+		return fmt.Errorf("invalid metric pattern %q: %w", r.Metric, err)
+	}
+
+	switch r.Action {
+	case MetricRelabelRenameMetric:
+		if r.Replacement == "" {
+			return errors.New("rename_metric requires a replacement name")
+		}
+	case MetricRelabelRenameTag:
+		if r.SourceTag == "" || r.TargetTag == "" {
+			return errors.New("rename_tag requires source_tag and target_tag")
+		}
+	case MetricRelabelDropTag:
+		if (r.SourceTag == "") == (r.Regex == "") {
+			return errors.New("drop_tag requires either source_tag or regex")
+		}
+	case MetricRelabelDeriveTag:
+		if r.SourceTag == "" || r.TargetTag == "" {
+			return errors.New("derive_tag requires source_tag and target_tag")
+		}
+		if r.Regex == "" {
+			r.Regex = "(.*)"
+		}
+		if r.Replacement == "" {
+			r.Replacement = "$1"
+		}
+	case "":
+		return errors.New("metric relabel rule requires an action")
+	default:
+		return fmt.Errorf("invalid relabel action %q", r.Action)
+	}
+
+	r.re = nil
+	if r.Regex != "" {
+		re, err := regexp.Compile("^(?:" + r.Regex + ")$")
+		if err != nil {
+			return fmt.Errorf("invalid regex %q: %w", r.Regex, err)
+		}
+		r.re = re
+	}
+	return nil
+}
+
+// Matches reports whether the rule applies to a series.
+func (r *MetricRelabelRule) Matches(name string, tags map[string]string) bool {
+	if ok, _ := path.Match(r.Metric, name); !ok {
+		return false
+	}
+	for k, v := range r.Tags {
+		if tags[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply rewrites a series' name and tags, modifying tags in place, and
+// returns the new name. The rule must have been validated.
+func (r *MetricRelabelRule) Apply(name string, tags map[string]string) string {
+	switch r.Action {
+	case MetricRelabelRenameMetric:
+		if r.re == nil {
+			return r.Replacement
+		}
+		if m := r.re.FindStringSubmatchIndex(name); m != nil {
+			return string(r.re.ExpandString(nil, r.Replacement, name, m))
+		}
+	case MetricRelabelRenameTag:
+		if v, ok := tags[r.SourceTag]; ok {
+			delete(tags, r.SourceTag)
+			tags[r.TargetTag] = v
+		}
+	case MetricRelabelDropTag:
+		if r.re == nil {
+			delete(tags, r.SourceTag)
+			break
+		}
+		for k := range tags {
+			if r.re.MatchString(k) {
+				delete(tags, k)
+			}
+		}
+	case MetricRelabelDeriveTag:
+		v, ok := tags[r.SourceTag]
+		if !ok {
+			break
+		}
+		if m := r.re.FindStringSubmatchIndex(v); m != nil {
+			tags[r.TargetTag] = string(r.re.ExpandString(nil, r.Replacement, v, m))
+		}
+	}
+	return name
+}
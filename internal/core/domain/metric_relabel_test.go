@@ -0,0 +1,63 @@
+package domain
+
+import "testing"
+
+func TestMetricRelabelRule_Validate(t *testing.T) {
+	rule := MetricRelabelRule{Action: MetricRelabelDeriveTag, SourceTag: "pod", TargetTag: "app"}
+	if err := rule.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if rule.Metric != "*" || rule.Regex != "(.*)" || rule.Replacement != "$1" {
+		t.Errorf("expected defaults to be filled in, got %+v", rule)
+	}
+
+	invalid := []MetricRelabelRule{
+		{},
+		{Action: "explode"},
+		{Metric: "disk[", Action: MetricRelabelDropTag, SourceTag: "id"},
+		{Action: MetricRelabelRenameMetric},
+		{Action: MetricRelabelRenameTag, SourceTag: "hostname"},
+		{Action: MetricRelabelDropTag},
+		{Action: MetricRelabelDropTag, SourceTag: "id", Regex: "id"},
+		{Action: MetricRelabelDropTag, Regex: "("},
+	}
+	for _, r := range invalid {
+		if err := r.Validate(); err == nil {
+			t.Errorf("expected error for rule %+v", r)
+		}
+	}
+}
+
+func TestMetricRelabelRule_Apply(t *testing.T) {
+	apply := func(rule MetricRelabelRule, name string, tags map[string]string) string {
+		t.Helper()
+		if err := rule.Validate(); err != nil {
+			t.Fatalf("Validate failed: %v", err)
+		}
+		return rule.Apply(name, tags)
+	}
+
+	if got := apply(MetricRelabelRule{Action: MetricRelabelRenameMetric, Regex: `node_(.*)_bytes`, Replacement: "node.$1.bytes"}, "node_disk_bytes", nil); got != "node.disk.bytes" {
+		t.Errorf("expected the name rewritten from its capture group, got %s", got)
+	}
+	if got := apply(MetricRelabelRule{Action: MetricRelabelRenameMetric, Regex: `node_(.*)`, Replacement: "x"}, "disk_node_bytes", nil); got != "disk_node_bytes" {
+		t.Errorf("expected an unanchored match to leave the name alone, got %s", got)
+	}
+
+	tags := map[string]string{"request_id": "1", "trace_id": "2", "host": "a"}
+	apply(MetricRelabelRule{Action: MetricRelabelDropTag, Regex: `.*_id`}, "m", tags)
+	if len(tags) != 1 || tags["host"] != "a" {
+		t.Errorf("expected only host to remain, got %v", tags)
+	}
+
+	tags = map[string]string{"pod": "checkout-7d9f-x2"}
+	apply(MetricRelabelRule{Action: MetricRelabelDeriveTag, SourceTag: "pod", TargetTag: "app", Regex: `(.+)-[0-9a-f]+-[a-z0-9]+`}, "m", tags)
+	if tags["app"] != "checkout" || tags["pod"] != "checkout-7d9f-x2" {
+		t.Errorf("expected app derived from pod, got %v", tags)
+	}
+	tags = map[string]string{"pod": "standalone"}
+	apply(MetricRelabelRule{Action: MetricRelabelDeriveTag, SourceTag: "pod", TargetTag: "app", Regex: `(.+)-[0-9a-f]+-[a-z0-9]+`}, "m", tags)
+	if _, ok := tags["app"]; ok {
+		t.Errorf("expected no tag derived from a non-matching value, got %v", tags)
+	}
+}
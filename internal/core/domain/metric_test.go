@@ -35,7 +35,6 @@ func TestNewMetric(t *testing.T) {
 }
 
 func TestMetricSeriesHashConsistency(t *testing.T) {
-	// Note: Map iteration order in Go is not guaranteed, so we use a single-tag map
 	tags1 := map[string]string{"host": "localhost"}
 	tags2 := map[string]string{"host": "localhost"}
 
@@ -47,6 +46,31 @@ func TestMetricSeriesHashConsistency(t *testing.T) {
 	}
 }
 
+func TestMetricSeriesHashStableAcrossTagOrder(t *testing.T) {
+	tags := map[string]string{"host": "web-1", "region": "eu", "service": "api", "env": "prod", "zone": "b"}
+	want := SeriesHash("cpu_usage", tags)
+	// Map iteration order varies between runs and between maps built in
+	// different orders; the hash must not
+	for i := 0; i < 50; i++ {
+		copied := make(map[string]string, len(tags))
+		for k, v := range tags {
+			copied[k] = v
+		}
+		if got := NewMetric("cpu_usage", MetricTypeGauge, float64(i), copied).SeriesHash; got != want {
+			t.Fatalf("series hash changed between identical tag maps: %d != %d", got, want)
+		}
+	}
+
+	agg := NewAggregatedMetric("cpu_usage", tags, []MetricPoint{{Value: 1, Timestamp: time.Now()}}, "1m")
+	if agg.SeriesHash != want {
+		t.Errorf("expected rollups to share the raw series hash, got %d want %d", agg.SeriesHash, want)
+	}
+
+	if SeriesHash("m", map[string]string{"ab": "c"}) == SeriesHash("m", map[string]string{"a": "bc"}) {
+		t.Error("expected key and value boundaries to affect the hash")
+	}
+}
+
 func TestMetricSeriesHashDifferent(t *testing.T) {
 	tags1 := map[string]string{"host": "host1"}
 	tags2 := map[string]string{"host": "host2"}
@@ -123,3 +147,34 @@ func TestMetricNilTags(t *testing.T) {
 	}
 }
 
+func TestCounterRates(t *testing.T) {
+	base := time.Now()
+	values := []float64{0, 10, 20, 5, 15}
+	points := make([]MetricPoint, len(values))
+	for i, v := range values {
+		points[i] = MetricPoint{Value: v, Timestamp: base.Add(time.Duration(i) * time.Second)}
+	}
+
+	rates, resets := CounterRates(points)
+	if resets != 1 {
+		t.Errorf("expected 1 reset, got %d", resets)
+	}
+	// The interval across the reset is skipped
+	want := []MetricPoint{
+		{Value: 10, Timestamp: points[1].Timestamp},
+		{Value: 10, Timestamp: points[2].Timestamp},
+		{Value: 10, Timestamp: points[4].Timestamp},
+	}
+	if len(rates) != len(want) {
+		t.Fatalf("expected %d rates, got %d", len(want), len(rates))
+	}
+	for i, r := range rates {
+		if r.Value != want[i].Value || !r.Timestamp.Equal(want[i].Timestamp) {
+			t.Errorf("rate %d = %v at %v, want %v", i, r.Value, r.Timestamp, want[i])
+		}
+	}
+
+	if rates, _ := CounterRates(points[:1]); rates != nil {
+		t.Errorf("expected no rates for a single point, got %v", rates)
+	}
+}
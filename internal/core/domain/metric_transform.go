@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"path"
+)
+
+// MetricTransformStage determines when a transform rule is applied.
+type MetricTransformStage string
+
+const (
+	// MetricTransformIngest rewrites values before they are stored.
+	MetricTransformIngest MetricTransformStage = "ingest"
+	// MetricTransformQuery leaves stored values untouched and rewrites query results.
+	MetricTransformQuery MetricTransformStage = "query"
+)
+
+// MetricTransformRule converts metric values with a linear transform
+// (value*Scale + Offset) and labels the result with a unit. It is used to
+// normalize sources that report in the wrong units, e.g. bytes instead of MB.
+type MetricTransformRule struct {
+	Name   string               `json:"name"`
+	Metric string               `json:"metric"`         // Metric name or glob pattern, e.g. "disk.*.bytes"
+	Tags   map[string]string    `json:"tags,omitempty"` // Tags that must match exactly
+	Scale  float64              `json:"scale"`          // Zero is treated as 1
+	Offset float64              `json:"offset"`
+	Unit   string               `json:"unit,omitempty"`
+	Stage  MetricTransformStage `json:"stage"` // Defaults to ingest
+}
+
+// Validate checks the rule and fills in defaults.
+func (r *MetricTransformRule) Validate() error {
+	if r.Metric == "" {
+		return errors.New("metric transform rule requires a metric name or pattern")
+	}
+	if _, err := path.Match(r.Metric, ""); err != nil {
+		return fmt.Errorf("invalid metric pattern %q: %w", r.Metric, err)
+	}
+	switch r.Stage {
+	case "":
+		r.Stage = MetricTransformIngest
+	case MetricTransformIngest, MetricTransformQuery:
+	default:
+		return fmt.Errorf("invalid transform stage %q", r.Stage)
+	}
+	return nil
+}
+
+// Matches reports whether the rule applies to a series.
+func (r *MetricTransformRule) Matches(name string, tags map[string]string) bool {
+	if ok, _ := path.Match(r.Metric, name); !ok {
+		return false
+	}
+	for k, v := range r.Tags {
+		if tags[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply transforms a single value.
+func (r *MetricTransformRule) Apply(value float64) float64 {
+	scale := r.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	return value*scale + r.Offset
+}
@@ -0,0 +1,55 @@
+package domain
+
+import "testing"
+
+func TestMetricTransformRule_Validate(t *testing.T) {
+	rule := MetricTransformRule{Metric: "disk.used"}
+	if err := rule.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if rule.Stage != MetricTransformIngest {
+		t.Errorf("expected default stage ingest, got %s", rule.Stage)
+	}
+
+	invalid := []MetricTransformRule{
+		{},
+		{Metric: "disk[", Stage: MetricTransformQuery},
+		{Metric: "disk.used", Stage: "later"},
+	}
+	for _, r := range invalid {
+		if err := r.Validate(); err == nil {
+			t.Errorf("expected error for rule %+v", r)
+		}
+	}
+}
+
+func TestMetricTransformRule_Matches(t *testing.T) {
+	rule := MetricTransformRule{Metric: "disk.*.bytes", Tags: map[string]string{"source": "node"}}
+
+	if !rule.Matches("disk.used.bytes", map[string]string{"source": "node", "host": "a"}) {
+		t.Error("expected glob and tag match")
+	}
+	if rule.Matches("disk.used.bytes", map[string]string{"source": "agent"}) {
+		t.Error("expected tag mismatch to fail")
+	}
+	if rule.Matches("mem.used.bytes", map[string]string{"source": "node"}) {
+		t.Error("expected name mismatch to fail")
+	}
+}
+
+func TestMetricTransformRule_Apply(t *testing.T) {
+	toMB := MetricTransformRule{Metric: "x", Scale: 1.0 / (1024 * 1024)}
+	if got := toMB.Apply(5 * 1024 * 1024); got != 5 {
+		t.Errorf("expected 5 MB, got %v", got)
+	}
+
+	celsius := MetricTransformRule{Metric: "x", Scale: 5.0 / 9.0, Offset: -32 * 5.0 / 9.0}
+	if got := celsius.Apply(212); got < 99.999 || got > 100.001 {
+		t.Errorf("expected 100, got %v", got)
+	}
+
+	identity := MetricTransformRule{Metric: "x", Offset: 1}
+	if got := identity.Apply(2); got != 3 {
+		t.Errorf("expected zero scale to act as 1, got %v", got)
+	}
+}
@@ -14,6 +14,11 @@ const (
 	PluginStatusActive   PluginStatus = "active"
 	PluginStatusError    PluginStatus = "error"
 	PluginStatusLoading  PluginStatus = "loading"
+	// PluginStatusDegraded marks a loaded plugin whose scheduled ticks keep failing.
+	PluginStatusDegraded PluginStatus = "degraded"
+	// PluginStatusDisabled marks a plugin switched off after repeatedly
+	// exceeding its resource limits.
+	PluginStatusDisabled PluginStatus = "disabled"
 )
 
 // PluginPermission represents a capability that a plugin can request.
@@ -39,11 +44,15 @@ type Plugin struct {
 	Hash        string             `json:"hash"` // SHA256 of the .wasm binary
 	Status      PluginStatus       `json:"status"`
 	Permissions []PluginPermission `json:"permissions"`
-	Config      map[string]string  `json:"config"`
-	CreatedAt   time.Time          `json:"created_at"`
-	UpdatedAt   time.Time          `json:"updated_at"`
-	LoadedAt    *time.Time         `json:"loaded_at,omitempty"`
-	Error       string             `json:"error,omitempty"`
+	// AllowedHosts restricts outbound HTTP for plugins with the network
+	// permission. Entries may be hosts, "*.domain" wildcards or CIDR ranges.
+	AllowedHosts []string          `json:"allowed_hosts,omitempty"`
+	Config       map[string]string `json:"config"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+	LoadedAt     *time.Time        `json:"loaded_at,omitempty"`
+	Error        string            `json:"error,omitempty"`
+	Violations   int               `json:"violations,omitempty"` // Resource limit violations since load
 }
 
 // NewPlugin creates a new plugin with default values.
@@ -79,6 +88,7 @@ func (p *Plugin) MarkLoaded() {
 	p.LoadedAt = &now
 	p.UpdatedAt = now
 	p.Error = ""
+	p.Violations = 0
 }
 
 // MarkError marks the plugin as having an error.
@@ -88,16 +98,32 @@ func (p *Plugin) MarkError(err error) {
 	p.UpdatedAt = time.Now()
 }
 
+// RecordViolation records a resource limit violation. The plugin keeps
+// its status; the violation is reported through Error.
+func (p *Plugin) RecordViolation(err error) {
+	p.Violations++
+	p.Error = err.Error()
+	p.UpdatedAt = time.Now()
+}
+
+// MarkDisabled marks the plugin as disabled because of err.
+func (p *Plugin) MarkDisabled(err error) {
+	p.Status = PluginStatusDisabled
+	p.Error = err.Error()
+	p.UpdatedAt = time.Now()
+}
+
 // PluginManifest represents the plugin.yaml configuration file.
 type PluginManifest struct {
-	Name        string             `yaml:"name"`
-	Version     string             `yaml:"version"`
-	Description string             `yaml:"description"`
-	Author      string             `yaml:"author"`
-	Entrypoint  string             `yaml:"entrypoint"`
-	Permissions []PluginPermission `yaml:"permissions"`
-	Config      []PluginConfigDef  `yaml:"config"`
-	Hooks       []string           `yaml:"hooks"` // e.g., "on_tick", "handle_command"
+	Name         string             `yaml:"name"`
+	Version      string             `yaml:"version"`
+	Description  string             `yaml:"description"`
+	Author       string             `yaml:"author"`
+	Entrypoint   string             `yaml:"entrypoint"`
+	Permissions  []PluginPermission `yaml:"permissions"`
+	AllowedHosts []string           `yaml:"allowed_hosts"` // Hosts reachable with the network permission
+	Config       []PluginConfigDef  `yaml:"config"`
+	Hooks        []string           `yaml:"hooks"` // e.g., "on_tick", "handle_command"
 }
 
 // PluginConfigDef represents a configuration option definition.
@@ -108,4 +134,3 @@ type PluginConfigDef struct {
 	Description string `yaml:"description"`
 	Required    bool   `yaml:"required"`
 }
-
@@ -0,0 +1,190 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PluginConfigSchema is the subset of JSON Schema plugins use to describe
+// their configuration: an object whose properties have a type, bounds and
+// allowed values. Keywords outside this subset are ignored.
+type PluginConfigSchema struct {
+	Type                 string                           `json:"type,omitempty"`
+	Properties           map[string]*PluginConfigProperty `json:"properties,omitempty"`
+	Required             []string                         `json:"required,omitempty"`
+	AdditionalProperties *bool                            `json:"additionalProperties,omitempty"`
+}
+
+// PluginConfigProperty describes one configuration option.
+type PluginConfigProperty struct {
+	Type        string        `json:"type,omitempty"` // string, integer, number or boolean
+	Description string        `json:"description,omitempty"`
+	Default     interface{}   `json:"default,omitempty"`
+	Enum        []interface{} `json:"enum,omitempty"`
+	Minimum     *float64      `json:"minimum,omitempty"`
+	Maximum     *float64      `json:"maximum,omitempty"`
+	MinLength   *int          `json:"minLength,omitempty"`
+	MaxLength   *int          `json:"maxLength,omitempty"`
+}
+
+// ParsePluginConfigSchema parses a plugin's JSON configuration schema.
+func ParsePluginConfigSchema(data string) (*PluginConfigSchema, error) {
+	var schema PluginConfigSchema
+	if err := json.Unmarshal([]byte(data), &schema); err != nil {
+		return nil, fmt.Errorf("invalid config schema: %w", err)
+	}
+	if schema.Type != "" && schema.Type != "object" {
+		return nil, fmt.Errorf("invalid config schema: type must be object, got %q", schema.Type)
+	}
+	for name, prop := range schema.Properties {
+		if prop == nil {
+			return nil, fmt.Errorf("invalid config schema: property %q is empty", name)
+		}
+		switch prop.Type {
+		case "", "string", "integer", "number", "boolean":
+		default:
+			return nil, fmt.Errorf("invalid config schema: property %q has unsupported type %q", name, prop.Type)
+		}
+	}
+	return &schema, nil
+}
+
+// PluginConfigError lists every way a configuration violates its schema.
+type PluginConfigError struct {
+	Problems []string
+}
+
+func (e *PluginConfigError) Error() string {
+	return "invalid plugin configuration: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate checks config against the schema and returns its values
+// converted to their schema types, for handing to the plugin as JSON.
+// Configuration values are strings, so "10" satisfies an integer property.
+// All problems are reported together in a *PluginConfigError.
+func (s *PluginConfigSchema) Validate(config map[string]string) (map[string]interface{}, error) {
+	var problems []string
+	for _, name := range s.Required {
+		if _, ok := config[name]; !ok {
+			problems = append(problems, fmt.Sprintf("%s is required", name))
+		}
+	}
+
+	keys := make([]string, 0, len(config))
+	for key := range config {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	typed := make(map[string]interface{}, len(config))
+	for _, key := range keys {
+		prop, ok := s.Properties[key]
+		if !ok {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				problems = append(problems, fmt.Sprintf("%s is not a known option", key))
+			} else {
+				typed[key] = config[key]
+			}
+			continue
+		}
+		value, err := prop.convert(config[key])
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s %s", key, err))
+			continue
+		}
+		typed[key] = value
+	}
+
+	if len(problems) > 0 {
+		return nil, &PluginConfigError{Problems: problems}
+	}
+	return typed, nil
+}
+
+// convert parses raw as the property's type and checks its constraints.
+func (p *PluginConfigProperty) convert(raw string) (interface{}, error) {
+	var value interface{}
+	switch p.Type {
+	case "integer":
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("must be an integer, got %q", raw)
+		}
+		if err := p.checkRange(float64(n)); err != nil {
+			return nil, err
+		}
+		value = n
+	case "number":
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("must be a number, got %q", raw)
+		}
+		if err := p.checkRange(f); err != nil {
+			return nil, err
+		}
+		value = f
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("must be a boolean, got %q", raw)
+		}
+		value = b
+	default:
+		length := len([]rune(raw))
+		if p.MinLength != nil && length < *p.MinLength {
+			return nil, fmt.Errorf("must be at least %d characters", *p.MinLength)
+		}
+		if p.MaxLength != nil && length > *p.MaxLength {
+			return nil, fmt.Errorf("must be at most %d characters", *p.MaxLength)
+		}
+		value = raw
+	}
+
+	if len(p.Enum) > 0 && !p.allowed(value) {
+		allowed := make([]string, len(p.Enum))
+		for i, v := range p.Enum {
+			allowed[i] = fmt.Sprint(v)
+		}
+		return nil, fmt.Errorf("must be one of %s, got %q", strings.Join(allowed, ", "), raw)
+	}
+	return value, nil
+}
+
+func (p *PluginConfigProperty) checkRange(v float64) error {
+	if p.Minimum != nil && v < *p.Minimum {
+		return fmt.Errorf("must be >= %v, got %v", *p.Minimum, v)
+	}
+	if p.Maximum != nil && v > *p.Maximum {
+		return fmt.Errorf("must be <= %v, got %v", *p.Maximum, v)
+	}
+	return nil
+}
+
+// allowed reports whether value is in the property's enum. JSON numbers
+// decode as float64, so numeric values are compared as floats.
+func (p *PluginConfigProperty) allowed(value interface{}) bool {
+	for _, v := range p.Enum {
+		switch want := v.(type) {
+		case float64:
+			switch got := value.(type) {
+			case int64:
+				if float64(got) == want {
+					return true
+				}
+			case float64:
+				if got == want {
+					return true
+				}
+			}
+		default:
+			if v == value {
+				return true
+			}
+		}
+	}
+	return false
+}
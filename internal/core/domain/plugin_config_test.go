@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// systemMetricsSchema is the schema of the system-metrics example plugin,
+// with a required output and a bounded log level added.
+const systemMetricsSchema = `{
+  "type": "object",
+  "properties": {
+    "interval": {"type": "integer", "default": 10, "minimum": 1},
+    "collect_cpu": {"type": "boolean", "default": true},
+    "threshold": {"type": "number", "maximum": 100},
+    "output": {"type": "string", "minLength": 1},
+    "log_level": {"type": "string", "enum": ["debug", "info", "warn"]}
+  },
+  "required": ["output"],
+  "additionalProperties": false
+}`
+
+func TestPluginConfigSchema_Validate(t *testing.T) {
+	schema, err := ParsePluginConfigSchema(systemMetricsSchema)
+	if err != nil {
+		t.Fatalf("ParsePluginConfigSchema failed: %v", err)
+	}
+
+	typed, err := schema.Validate(map[string]string{
+		"interval":    "30",
+		"collect_cpu": "false",
+		"threshold":   "92.5",
+		"output":      "stdout",
+		"log_level":   "info",
+	})
+	if err != nil {
+		t.Fatalf("expected a valid config, got %v", err)
+	}
+	if typed["interval"] != int64(30) || typed["collect_cpu"] != false || typed["threshold"] != 92.5 || typed["output"] != "stdout" {
+		t.Errorf("expected values converted to their schema types, got %#v", typed)
+	}
+
+	_, err = schema.Validate(map[string]string{
+		"interval":    "0",
+		"collect_cpu": "sometimes",
+		"threshold":   "120",
+		"log_level":   "trace",
+		"colour":      "blue",
+	})
+	var configErr *PluginConfigError
+	if !errors.As(err, &configErr) {
+		t.Fatalf("expected a PluginConfigError, got %v", err)
+	}
+	for _, want := range []string{
+		"output is required",
+		"interval must be >= 1",
+		"collect_cpu must be a boolean",
+		"threshold must be <= 100",
+		"log_level must be one of debug, info, warn",
+		"colour is not a known option",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %q", want, err.Error())
+		}
+	}
+
+	if _, err := schema.Validate(map[string]string{"output": "x", "interval": "ten"}); err == nil || !strings.Contains(err.Error(), "interval must be an integer") {
+		t.Errorf("expected a type error for a non-integer interval, got %v", err)
+	}
+}
+
+func TestParsePluginConfigSchema_Invalid(t *testing.T) {
+	for _, schema := range []string{
+		`not json`,
+		`{"type": "array"}`,
+		`{"properties": {"interval": {"type": "duration"}}}`,
+	} {
+		if _, err := ParsePluginConfigSchema(schema); err == nil {
+			t.Errorf("expected %s to be rejected", schema)
+		}
+	}
+}
@@ -80,6 +80,30 @@ func TestPlugin_MarkError(t *testing.T) {
 	}
 }
 
+func TestPlugin_RecordViolationAndDisable(t *testing.T) {
+	plugin := NewPlugin("test", "1.0.0", "/test.wasm")
+	plugin.MarkLoaded()
+
+	plugin.RecordViolation(errors.New("execution time limit exceeded"))
+	plugin.RecordViolation(errors.New("execution time limit exceeded again"))
+	if plugin.Violations != 2 || plugin.Status != PluginStatusActive {
+		t.Errorf("Violations = %d, Status = %v, want 2 and active", plugin.Violations, plugin.Status)
+	}
+	if plugin.Error != "execution time limit exceeded again" {
+		t.Errorf("Error = %v, want last violation", plugin.Error)
+	}
+
+	plugin.MarkDisabled(errors.New("too many violations"))
+	if plugin.Status != PluginStatusDisabled || plugin.Error != "too many violations" {
+		t.Errorf("Status = %v, Error = %v, want disabled", plugin.Status, plugin.Error)
+	}
+
+	plugin.MarkLoaded()
+	if plugin.Violations != 0 {
+		t.Errorf("Violations = %d after MarkLoaded(), want 0", plugin.Violations)
+	}
+}
+
 func TestPluginStatusConstants(t *testing.T) {
 	if PluginStatusInactive != "inactive" {
 		t.Errorf("PluginStatusInactive = %v, want inactive", PluginStatusInactive)
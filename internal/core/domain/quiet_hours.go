@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// QuietHours is a daily period during which only critical alerts notify.
+// Other notifications are held and delivered as a digest when the period
+// ends. A period starts at Start ("HH:MM") in Timezone (default UTC) on one
+// of Days and ends at the next End, so "22:00" to "07:00" runs overnight.
+// Days name the day a period starts; no days means every day.
+type QuietHours struct {
+	Start    string         `json:"start"`
+	End      string         `json:"end"`
+	Days     []time.Weekday `json:"days,omitempty"`
+	Timezone string         `json:"timezone,omitempty"`
+}
+
+// Validate checks the start and end times, days and time zone.
+func (q *QuietHours) Validate() error {
+	if _, _, err := parseClock(q.Start); err != nil {
+		return fmt.Errorf("quiet hours start: %w", err)
+	}
+	if _, _, err := parseClock(q.End); err != nil {
+		return fmt.Errorf("quiet hours end: %w", err)
+	}
+	for _, d := range q.Days {
+		if d < time.Sunday || d > time.Saturday {
+			return fmt.Errorf("invalid day of week %d", d)
+		}
+	}
+	if _, err := locationOrUTC(q.Timezone); err != nil {
+		return fmt.Errorf("invalid quiet hours time zone: %w", err)
+	}
+	return nil
+}
+
+// Period returns the start and end of the quiet period containing now, if
+// any. Start and end are wall clock times, so the period follows daylight
+// saving changes; equal start and end times make a period of a whole day.
+func (q *QuietHours) Period(now time.Time) (time.Time, time.Time, bool) {
+	loc, err := locationOrUTC(q.Timezone)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	startHour, startMinute, err := parseClock(q.Start)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	endHour, endMinute, err := parseClock(q.End)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+
+	local := now.In(loc)
+	// A period that started yesterday may still be running
+	for back := 0; back <= 1; back++ {
+		day := time.Date(local.Year(), local.Month(), local.Day()-back, 0, 0, 0, 0, loc)
+		if !onWeekday(q.Days, day.Weekday()) {
+			continue
+		}
+		start := wallClock(day, startHour, startMinute)
+		end := wallClock(day, endHour, endMinute)
+		if !end.After(start) {
+			end = wallClock(day.AddDate(0, 0, 1), endHour, endMinute)
+		}
+		if !now.Before(start) && now.Before(end) {
+			return start, end, true
+		}
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+// ActiveAt reports whether now falls within quiet hours.
+func (q *QuietHours) ActiveAt(now time.Time) bool {
+	_, _, ok := q.Period(now)
+	return ok
+}
+
+// String formats the quiet hours for display, e.g. "22:00-07:00 mon,tue".
+func (q *QuietHours) String() string {
+	s := q.Start + "-" + q.End + " " + formatWeekdays(q.Days)
+	if q.Timezone != "" {
+		s += " " + q.Timezone
+	}
+	return s
+}
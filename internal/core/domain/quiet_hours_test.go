@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuietHours_Period(t *testing.T) {
+	// Overnight on weeknights
+	q := &QuietHours{Start: "22:00", End: "07:00", Days: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}}
+
+	tests := []struct {
+		name   string
+		now    time.Time
+		active bool
+	}{
+		{"friday evening", time.Date(2024, 3, 1, 23, 0, 0, 0, time.UTC), true},
+		{"saturday morning", time.Date(2024, 3, 2, 6, 59, 0, 0, time.UTC), true},
+		{"quiet hours end", time.Date(2024, 3, 2, 7, 0, 0, 0, time.UTC), false},
+		{"saturday night", time.Date(2024, 3, 2, 23, 0, 0, 0, time.UTC), false},
+		{"monday morning", time.Date(2024, 3, 4, 3, 0, 0, 0, time.UTC), false},
+		{"monday night", time.Date(2024, 3, 4, 22, 0, 0, 0, time.UTC), true},
+	}
+	for _, tt := range tests {
+		if got := q.ActiveAt(tt.now); got != tt.active {
+			t.Errorf("%s: ActiveAt = %v, want %v", tt.name, got, tt.active)
+		}
+	}
+
+	_, end, ok := q.Period(time.Date(2024, 3, 1, 23, 0, 0, 0, time.UTC))
+	if !ok || !end.Equal(time.Date(2024, 3, 2, 7, 0, 0, 0, time.UTC)) {
+		t.Errorf("Period end = %v, %v; want saturday 07:00", end, ok)
+	}
+
+	// Same-day quiet hours
+	lunch := &QuietHours{Start: "12:00", End: "13:00"}
+	if !lunch.ActiveAt(time.Date(2024, 3, 2, 12, 30, 0, 0, time.UTC)) || lunch.ActiveAt(time.Date(2024, 3, 2, 13, 30, 0, 0, time.UTC)) {
+		t.Error("expected same-day quiet hours to cover only their hour")
+	}
+
+	if err := (&QuietHours{Start: "22:00"}).Validate(); err == nil {
+		t.Error("expected missing end time to be rejected")
+	}
+}
@@ -3,6 +3,8 @@
 package domain
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -19,6 +21,16 @@ const (
 	TaskStatusDead      TaskStatus = "DEAD"
 )
 
+// ParseTaskStatus parses a task status, ignoring case.
+func ParseTaskStatus(s string) (TaskStatus, error) {
+	status := TaskStatus(strings.ToUpper(strings.TrimSpace(s)))
+	switch status {
+	case TaskStatusPending, TaskStatusRunning, TaskStatusCompleted, TaskStatusFailed, TaskStatusDead:
+		return status, nil
+	}
+	return "", fmt.Errorf("invalid task status %q", s)
+}
+
 // TaskType represents the type of task to be executed.
 type TaskType string
 
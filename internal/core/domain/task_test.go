@@ -136,3 +136,14 @@ func TestTaskIsLocked(t *testing.T) {
 	}
 }
 
+func TestParseTaskStatus(t *testing.T) {
+	if status, err := ParseTaskStatus("pending"); err != nil || status != TaskStatusPending {
+		t.Errorf("ParseTaskStatus(pending) = %q, %v", status, err)
+	}
+	if status, err := ParseTaskStatus("DEAD"); err != nil || status != TaskStatusDead {
+		t.Errorf("ParseTaskStatus(DEAD) = %q, %v", status, err)
+	}
+	if _, err := ParseTaskStatus("done"); err == nil {
+		t.Error("expected error for unknown status")
+	}
+}
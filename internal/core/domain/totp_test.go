@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the RFC 6238 SHA-1 test key "12345678901234567890" in base32.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode_RFC6238Vectors(t *testing.T) {
+	// The RFC lists 8-digit codes; 6-digit codes are their last six digits
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		got, err := TOTPCode(rfc6238Secret, TOTPStep(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("TOTPCode failed: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("TOTPCode at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+
+	if _, err := TOTPCode("not base32!", 1); err == nil {
+		t.Error("expected error for invalid secret")
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	step := TOTPStep(now)
+
+	code, _ := TOTPCode(rfc6238Secret, step)
+	if got, ok := ValidateTOTP(rfc6238Secret, code, now); !ok || got != step {
+		t.Errorf("expected current code to validate at step %d, got %d/%v", step, got, ok)
+	}
+
+	// One period of drift either way is accepted
+	previous, _ := TOTPCode(rfc6238Secret, step-1)
+	if _, ok := ValidateTOTP(rfc6238Secret, previous, now); !ok {
+		t.Error("expected previous period code to validate")
+	}
+	stale, _ := TOTPCode(rfc6238Secret, step-2)
+	if _, ok := ValidateTOTP(rfc6238Secret, stale, now); ok {
+		t.Error("expected code two periods old to be rejected")
+	}
+
+	for _, bad := range []string{"", "12345", "1234567", "abcdef"} {
+		if _, ok := ValidateTOTP(rfc6238Secret, bad, now); ok {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestGenerateTOTPSecretAndURI(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret failed: %v", err)
+	}
+	if len(secret) != 32 {
+		t.Errorf("expected 32 base32 characters for a 160-bit secret, got %d", len(secret))
+	}
+	if other, _ := GenerateTOTPSecret(); other == secret {
+		t.Error("expected unique secrets")
+	}
+	if _, err := TOTPCode(secret, 1); err != nil {
+		t.Errorf("generated secret is not usable: %v", err)
+	}
+
+	uri := TOTPURI("Forge", "alice", secret)
+	if !strings.HasPrefix(uri, "otpauth://totp/Forge:alice?") {
+		t.Errorf("unexpected URI prefix: %s", uri)
+	}
+	for _, part := range []string{"secret=" + secret, "issuer=Forge", "digits=6", "period=30"} {
+		if !strings.Contains(uri, part) {
+			t.Errorf("expected URI to contain %q: %s", part, uri)
+		}
+	}
+}
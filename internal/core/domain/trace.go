@@ -167,11 +167,35 @@ type ServiceMapNode struct {
 	ErrorCount   int64    `json:"error_count"`
 	AvgDuration  float64  `json:"avg_duration_ms"`
 	Dependencies []string `json:"dependencies"`
+	External     bool     `json:"external,omitempty"` // A peer called by traced services but not traced itself
+
+	// Latency percentiles over the service's span durations, in milliseconds.
+	// For an external node, over the durations of the calls made to it.
+	P50Duration float64 `json:"p50_duration_ms"`
+	P95Duration float64 `json:"p95_duration_ms"`
+	P99Duration float64 `json:"p99_duration_ms"`
+}
+
+// ServiceMapEdge represents calls from one service into another, derived
+// from child spans whose parent span belongs to a different service, or
+// from client spans naming an untraced peer.
+type ServiceMapEdge struct {
+	Source     string  `json:"source"`
+	Target     string  `json:"target"`
+	CallCount  int64   `json:"call_count"`
+	ErrorCount int64   `json:"error_count"`
+	ErrorRate  float64 `json:"error_rate"` // ErrorCount / CallCount
+
+	// Call latency percentiles, in milliseconds.
+	P50Duration float64 `json:"p50_duration_ms"`
+	P95Duration float64 `json:"p95_duration_ms"`
+	P99Duration float64 `json:"p99_duration_ms"`
 }
 
 // ServiceMap represents the service dependency graph.
 type ServiceMap struct {
 	Nodes     []ServiceMapNode `json:"nodes"`
+	Edges     []ServiceMapEdge `json:"edges"`
 	UpdatedAt time.Time        `json:"updated_at"`
 }
 
@@ -300,3 +324,65 @@ func (t *Trace) Complete() {
 	}
 }
 
+// Summarize recomputes the trace's derived fields from its spans: the time
+// bounds, root span, name, span and error counts and overall status. It is
+// used for traces assembled from spans that were recorded elsewhere and
+// arrive complete, e.g. over OTLP.
+func (t *Trace) Summarize() {
+	t.SpanCount = len(t.Spans)
+	t.ErrorCount = 0
+	t.RootSpan = nil
+	if len(t.Spans) == 0 {
+		return
+	}
+
+	t.StartTime = t.Spans[0].StartTime
+	t.EndTime = t.Spans[0].EndTime
+	for _, span := range t.Spans {
+		if span.Status == SpanStatusError {
+			t.ErrorCount++
+		}
+		if span.StartTime.Before(t.StartTime) {
+			t.StartTime = span.StartTime
+		}
+		if span.EndTime.After(t.EndTime) {
+			t.EndTime = span.EndTime
+		}
+		if t.RootSpan == nil || betterRoot(span, t.RootSpan) {
+			t.RootSpan = span
+		}
+	}
+	t.Duration = t.EndTime.Sub(t.StartTime)
+	t.Name = t.RootSpan.Name
+	t.ServiceName = t.RootSpan.ServiceName
+
+	if t.ErrorCount > 0 {
+		t.Status = SpanStatusError
+	} else {
+		t.Status = SpanStatusOK
+	}
+}
+
+// betterRoot reports whether candidate should replace current as the root
+// span. A span without a parent wins; among equals the earliest does, so a
+// trace whose root has not been received yet is named after its first span.
+func betterRoot(candidate, current *Span) bool {
+	candidateRoot, currentRoot := candidate.ParentSpanID == nil, current.ParentSpanID == nil
+	if candidateRoot != currentRoot {
+		return candidateRoot
+	}
+	return candidate.StartTime.Before(current.StartTime)
+}
+
+// NewTraceFromSpans builds a trace from spans that all share traceID.
+func NewTraceFromSpans(traceID TraceID, spans []*Span) *Trace {
+	t := &Trace{
+		ID:         uuid.Must(uuid.NewV7()),
+		TraceID:    traceID,
+		Spans:      spans,
+		Attributes: make(map[string]string),
+		CreatedAt:  time.Now(),
+	}
+	t.Summarize()
+	return t
+}
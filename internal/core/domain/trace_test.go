@@ -3,6 +3,7 @@ package domain
 import (
 	"errors"
 	"testing"
+	"time"
 )
 
 func TestTraceID_String(t *testing.T) {
@@ -345,3 +346,55 @@ func TestSpanStatusConstants(t *testing.T) {
 	}
 }
 
+func TestNewTraceFromSpans(t *testing.T) {
+	traceID := NewTraceID()
+	base := time.Now()
+
+	child := NewSpan(traceID, "db.query", SpanKindClient, "api")
+	child.StartTime = base.Add(10 * time.Millisecond)
+	child.EndTime = base.Add(40 * time.Millisecond)
+	child.SetStatus(SpanStatusError, "timeout")
+
+	root := NewSpan(traceID, "GET /users", SpanKindServer, "gateway")
+	root.StartTime = base
+	root.EndTime = base.Add(50 * time.Millisecond)
+	child.SetParent(root.SpanID)
+
+	trace := NewTraceFromSpans(traceID, []*Span{child, root})
+
+	if trace.RootSpan != root || trace.Name != "GET /users" || trace.ServiceName != "gateway" {
+		t.Errorf("expected root span to name the trace, got %q (%s)", trace.Name, trace.ServiceName)
+	}
+	if trace.SpanCount != 2 || trace.ErrorCount != 1 {
+		t.Errorf("expected 2 spans and 1 error, got %d and %d", trace.SpanCount, trace.ErrorCount)
+	}
+	if trace.Status != SpanStatusError {
+		t.Errorf("Status = %v, want error", trace.Status)
+	}
+	if !trace.StartTime.Equal(base) || trace.Duration != 50*time.Millisecond {
+		t.Errorf("unexpected bounds: start %v duration %v", trace.StartTime, trace.Duration)
+	}
+}
+
+func TestTrace_SummarizeWithoutRoot(t *testing.T) {
+	traceID := NewTraceID()
+	base := time.Now()
+	parent := NewSpanID()
+
+	late := NewSpan(traceID, "late", SpanKindInternal, "svc")
+	late.StartTime = base.Add(time.Second)
+	late.EndTime = base.Add(2 * time.Second)
+	late.SetParent(parent)
+	early := NewSpan(traceID, "early", SpanKindInternal, "svc")
+	early.StartTime = base
+	early.EndTime = base.Add(time.Second)
+	early.SetParent(parent)
+
+	trace := NewTraceFromSpans(traceID, []*Span{late, early})
+	if trace.Name != "early" {
+		t.Errorf("expected earliest span to stand in for the root, got %q", trace.Name)
+	}
+	if trace.Status != SpanStatusOK {
+		t.Errorf("Status = %v, want ok", trace.Status)
+	}
+}
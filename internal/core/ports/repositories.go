@@ -4,6 +4,8 @@ package ports
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/forge-platform/forge/internal/core/domain"
@@ -52,15 +54,30 @@ type MetricRepository interface {
 	// RecordBatch persists multiple metrics in a single transaction.
 	RecordBatch(ctx context.Context, metrics []*domain.Metric) error
 
+	// RecordBatchBestEffort persists the metrics that can be written and
+	// reports the ones that could not, instead of rolling back the batch.
+	RecordBatchBestEffort(ctx context.Context, metrics []*domain.Metric) (*BatchReport, error)
+
 	// Query retrieves metrics matching the given criteria.
 	Query(ctx context.Context, query MetricQuery) (*domain.MetricSeries, error)
 
 	// QueryMultiple retrieves multiple series matching the criteria.
 	QueryMultiple(ctx context.Context, query MetricQuery) ([]*domain.MetricSeries, error)
 
+	// Export calls fn for every raw point matching the criteria, ordered by
+	// series and then time, without loading the range into memory. The
+	// series passed to fn carries the name, type and tags but no points and
+	// is shared by all points of that series. An error from fn stops the
+	// export and is returned.
+	Export(ctx context.Context, query MetricQuery, fn func(series *domain.MetricSeries, point domain.MetricPoint) error) error
+
 	// QueryWithAggregation retrieves metrics with time-bucket aggregation.
 	QueryWithAggregation(ctx context.Context, query MetricQuery) ([]AggregatedResult, error)
 
+	// QueryGrouped aggregates metrics separately for each combination of
+	// the query's GroupBy tag values.
+	QueryGrouped(ctx context.Context, query MetricQuery) ([]GroupedResult, error)
+
 	// Aggregate performs aggregation on metrics.
 	Aggregate(ctx context.Context, query MetricQuery, resolution string) (*domain.AggregatedMetric, error)
 
@@ -76,12 +93,18 @@ type MetricRepository interface {
 	// DeleteBefore removes metrics older than the given timestamp.
 	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
 
+	// DeleteSeriesBefore removes one series' metrics older than the given timestamp.
+	DeleteSeriesBefore(ctx context.Context, seriesHash uint64, before time.Time) (int64, error)
+
 	// DeleteAggregatedBefore removes aggregated metrics older than the given timestamp.
 	DeleteAggregatedBefore(ctx context.Context, before time.Time, resolution string) (int64, error)
 
 	// GetDistinctSeries returns all distinct series (name + tags combinations).
 	GetDistinctSeries(ctx context.Context) ([]SeriesInfo, error)
 
+	// GetRecentSeries returns at most limit series, most recently written first.
+	GetRecentSeries(ctx context.Context, limit int) ([]SeriesInfo, error)
+
 	// GetStats returns statistics about the metric storage.
 	GetStats(ctx context.Context) (*MetricStats, error)
 }
@@ -104,6 +127,64 @@ type MetricStats struct {
 	NewestPoint      time.Time
 	StorageBytes     int64
 	AggregatedPoints map[string]int64 // resolution -> count
+	DroppedSeries    int64            // Points rejected by the series limit since startup
+	DroppedNonFinite int64            // Points rejected for a NaN or infinite value since startup
+}
+
+// ErrSeriesLimitExceeded matches errors returned when a write would create
+// more series for a metric name than the repository allows.
+var ErrSeriesLimitExceeded = errors.New("series limit exceeded")
+
+// ErrMetricNameRequired is returned by MetricRepository.Query for a query
+// with neither a Name nor a SeriesHash.
+var ErrMetricNameRequired = errors.New("metric name or series hash is required")
+
+// SeriesLimitError reports writes rejected by the per-name series limit.
+type SeriesLimitError struct {
+	Name    string // Metric name whose limit was reached
+	Limit   int
+	Dropped int // Number of points rejected
+}
+
+func (e *SeriesLimitError) Error() string {
+	return fmt.Sprintf("series limit exceeded for metric %q: at most %d series allowed, %d point(s) dropped", e.Name, e.Limit, e.Dropped)
+}
+
+// Is makes errors.Is(err, ErrSeriesLimitExceeded) match.
+func (e *SeriesLimitError) Is(target error) bool {
+	return target == ErrSeriesLimitExceeded
+}
+
+// ErrNonFiniteValue matches errors returned for metric values that are NaN
+// or infinite, which would turn every aggregate over them into NaN.
+var ErrNonFiniteValue = errors.New("metric value is NaN or infinite")
+
+// NonFiniteValueError reports points rejected for a NaN or infinite value.
+type NonFiniteValueError struct {
+	Name    string // Metric name of the first rejected point
+	Dropped int    // Number of points rejected
+}
+
+func (e *NonFiniteValueError) Error() string {
+	return fmt.Sprintf("%s for metric %q: %d point(s) dropped", ErrNonFiniteValue, e.Name, e.Dropped)
+}
+
+// Is makes errors.Is(err, ErrNonFiniteValue) match.
+func (e *NonFiniteValueError) Is(target error) bool {
+	return target == ErrNonFiniteValue
+}
+
+// BatchFailure describes an entry rejected by a best-effort batch write.
+type BatchFailure struct {
+	Index  int    `json:"index"` // Position of the entry in the batch
+	Reason string `json:"reason"`
+}
+
+// BatchReport is the outcome of a best-effort batch write. Entries not
+// listed in Failed were written.
+type BatchReport struct {
+	Written int            `json:"written"`
+	Failed  []BatchFailure `json:"failed,omitempty"`
 }
 
 // MetricQuery defines query parameters for metric retrieval.
@@ -119,6 +200,15 @@ type MetricQuery struct {
 	Aggregation AggregationType
 	GroupBy     []string // Tag keys to group by
 	Step        time.Duration // Time bucket size for aggregation
+
+	// Resolution makes a query resolution-aware: "auto" stitches rollups
+	// and raw points, "raw" or a rollup resolution reads only that data.
+	// Empty queries raw points as before.
+	Resolution string
+
+	// IncludeIDs sets the ID and Type of raw points returned by Query and
+	// QueryMultiple. It is off by default to keep results lean.
+	IncludeIDs bool
 }
 
 // AggregationType defines the type of aggregation to perform.
@@ -146,6 +236,19 @@ type AggregatedResult struct {
 	Avg       float64
 }
 
+// GroupAllTags in MetricQuery.GroupBy groups by every tag, one group per
+// series.
+const GroupAllTags = "*"
+
+// GroupNone is the group value of series without a grouping tag.
+const GroupNone = "(none)"
+
+// GroupedResult is the aggregation of one group of a grouped query.
+type GroupedResult struct {
+	Tags    map[string]string // grouping tag values
+	Results []AggregatedResult
+}
+
 // PluginRepository defines the interface for plugin persistence.
 type PluginRepository interface {
 	// Create persists a new plugin.
@@ -188,6 +291,25 @@ type ConversationRepository interface {
 	List(ctx context.Context, limit, offset int) ([]*domain.Conversation, error)
 }
 
+// InsightFilter defines filtering options for insight queries.
+type InsightFilter struct {
+	StartTime time.Time
+	EndTime   time.Time
+	Limit     int
+}
+
+// InsightRepository defines the interface for persisting analysis results.
+type InsightRepository interface {
+	// Create persists a new insight.
+	Create(ctx context.Context, insight *domain.Insight) error
+
+	// List retrieves insights in the filter's time range, newest first.
+	List(ctx context.Context, filter InsightFilter) ([]*domain.Insight, error)
+
+	// DeleteBefore removes insights recorded before the given time.
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
 // WorkflowRepository defines the interface for workflow definition persistence.
 type WorkflowRepository interface {
 	// Create persists a new workflow definition.
@@ -353,25 +475,55 @@ type SilenceRepository interface {
 	// List retrieves all silences.
 	List(ctx context.Context) ([]*domain.Silence, error)
 
-	// ListActive retrieves all active silences.
+	// ListActive retrieves the silences that may apply at now: one-shot
+	// silences whose window contains now and recurring silences in effect.
+	// Callers check recurring silences against their schedule.
 	ListActive(ctx context.Context, now time.Time) ([]*domain.Silence, error)
 }
 
+// MaintenanceWindowRepository defines the interface for maintenance window persistence.
+type MaintenanceWindowRepository interface {
+	// Create persists a new maintenance window.
+	Create(ctx context.Context, window *domain.MaintenanceWindow) error
+
+	// GetByID retrieves a maintenance window by its ID.
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.MaintenanceWindow, error)
+
+	// GetByName retrieves a maintenance window by its name.
+	GetByName(ctx context.Context, name string) (*domain.MaintenanceWindow, error)
+
+	// Update updates an existing maintenance window.
+	Update(ctx context.Context, window *domain.MaintenanceWindow) error
+
+	// Delete removes a maintenance window.
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// List retrieves all maintenance windows.
+	List(ctx context.Context) ([]*domain.MaintenanceWindow, error)
+}
+
 // ============================================================================
 // Observability Repositories (Phase 8: v0.8.0)
 // ============================================================================
 
 // TraceFilter defines filtering options for trace queries.
 type TraceFilter struct {
-	ServiceName string
-	Name        string
-	Status      string
-	MinDuration time.Duration
-	MaxDuration time.Duration
-	StartTime   time.Time
-	EndTime     time.Time
-	Limit       int
-	Offset      int
+	ServiceName  string
+	Name         string
+	Status       string
+	MinDuration  time.Duration
+	MaxDuration  time.Duration
+	StartTime    time.Time
+	EndTime      time.Time
+	SlowestFirst bool // Order by duration, longest first, instead of newest first
+	Limit        int
+	Offset       int
+
+	// Span attribute matchers. A trace matches when, for each matcher, one
+	// of its spans has the attribute; different matchers may be satisfied
+	// by different spans.
+	Attributes    map[string]string // Attributes a span must have, exactly
+	HasAttributes []string          // Attribute keys a span must have, with any value
 }
 
 // SpanFilter defines filtering options for span queries.
@@ -383,6 +535,9 @@ type SpanFilter struct {
 	Status      domain.SpanStatus
 	StartTime   time.Time
 	EndTime     time.Time
+	MinDuration time.Duration     // Zero for no lower bound
+	MaxDuration time.Duration     // Zero for no upper bound
+	Attributes  map[string]string // Attributes the span must have, exactly
 	Limit       int
 	Offset      int
 }
@@ -407,6 +562,10 @@ type TraceRepository interface {
 	// List retrieves traces with optional filtering.
 	List(ctx context.Context, filter TraceFilter) ([]*domain.Trace, error)
 
+	// Count returns the number of traces matching the filter, ignoring
+	// its limit and offset.
+	Count(ctx context.Context, filter TraceFilter) (int64, error)
+
 	// GetServiceMap retrieves the service dependency map.
 	GetServiceMap(ctx context.Context, startTime, endTime time.Time) (*domain.ServiceMap, error)
 
@@ -422,6 +581,10 @@ type SpanRepository interface {
 	// CreateBatch persists multiple spans.
 	CreateBatch(ctx context.Context, spans []*domain.Span) error
 
+	// CreateBatchBestEffort persists the spans that can be written and
+	// reports the ones that could not.
+	CreateBatchBestEffort(ctx context.Context, spans []*domain.Span) (*BatchReport, error)
+
 	// GetByID retrieves a span by its UUID.
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Span, error)
 
@@ -464,6 +627,10 @@ type LogRepository interface {
 	// CreateBatch persists multiple log entries.
 	CreateBatch(ctx context.Context, entries []*domain.LogEntry) error
 
+	// CreateBatchBestEffort persists the entries that can be written and
+	// reports the ones that could not.
+	CreateBatchBestEffort(ctx context.Context, entries []*domain.LogEntry) (*BatchReport, error)
+
 	// GetByID retrieves a log entry by its ID.
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.LogEntry, error)
 
@@ -645,6 +812,9 @@ type SessionRepository interface {
 	// GetByID retrieves a session by its ID.
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Session, error)
 
+	// GetByTokenHash retrieves a session by the SHA-256 hash of its token.
+	GetByTokenHash(ctx context.Context, tokenHash string) (*domain.Session, error)
+
 	// GetByUserID retrieves all sessions for a user.
 	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.Session, error)
 
@@ -661,6 +831,30 @@ type SessionRepository interface {
 	DeleteExpired(ctx context.Context) (int64, error)
 }
 
+// ErrResetTokenUsed is returned by PasswordResetRepository.MarkUsed for a
+// token that was already redeemed.
+var ErrResetTokenUsed = errors.New("reset token already used")
+
+// PasswordResetRepository defines the interface for password reset token
+// persistence.
+type PasswordResetRepository interface {
+	// Create persists a new reset token.
+	Create(ctx context.Context, token *domain.PasswordResetToken) error
+
+	// GetByTokenHash retrieves a reset token by the hash of its token.
+	GetByTokenHash(ctx context.Context, tokenHash string) (*domain.PasswordResetToken, error)
+
+	// MarkUsed consumes a reset token. It fails if the token was already
+	// used, so a token can only be redeemed once.
+	MarkUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error
+
+	// DeleteByUserID removes all reset tokens for a user.
+	DeleteByUserID(ctx context.Context, userID uuid.UUID) error
+
+	// DeleteExpired removes expired reset tokens.
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
 // AuditLogFilter defines filtering options for audit log queries.
 type AuditLogFilter struct {
 	UserID    *uuid.UUID
@@ -684,6 +878,12 @@ type AuditLogRepository interface {
 	// List retrieves audit log entries with optional filtering.
 	List(ctx context.Context, filter AuditLogFilter) ([]*domain.AuditLog, error)
 
+	// Latest returns the most recently written entry, or nil if there are none.
+	Latest(ctx context.Context) (*domain.AuditLog, error)
+
+	// ListChain returns all entries in the order they were written.
+	ListChain(ctx context.Context) ([]*domain.AuditLog, error)
+
 	// DeleteBefore removes audit log entries older than the given timestamp.
 	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
 }
@@ -14,16 +14,48 @@ type WasmRuntime interface {
 	// UnloadPlugin unloads a plugin from the runtime.
 	UnloadPlugin(ctx context.Context, pluginID string) error
 
+	// ReloadPlugin replaces a loaded plugin with the current binary on disk,
+	// keeping the old instance if the new one fails to start. It returns
+	// false if the binary is unchanged.
+	ReloadPlugin(ctx context.Context, pluginID, expectedHash string) (bool, error)
+
 	// CallFunction invokes a function exported by a plugin.
 	CallFunction(ctx context.Context, pluginID, funcName string, args ...interface{}) (interface{}, error)
 
 	// ListLoadedPlugins returns the IDs of all loaded plugins.
 	ListLoadedPlugins() []string
 
+	// HasFunction reports whether a loaded plugin exports funcName.
+	HasFunction(pluginID, funcName string) bool
+
+	// GetPlugin returns the metadata of a loaded plugin.
+	GetPlugin(pluginID string) (*domain.Plugin, bool)
+
+	// ConfigurePlugin replaces a loaded plugin's configuration after
+	// validating it against the plugin's config schema.
+	ConfigurePlugin(ctx context.Context, pluginID string, config map[string]string) error
+
+	// PublishEvent injects a host event into the plugin event bus.
+	PublishEvent(eventType string, payload []byte) error
+
+	// StorageUsage reports the data stored by the named plugin.
+	StorageUsage(name string) (*PluginStorageUsage, error)
+
+	// PurgeStorage removes all data stored by the named plugin.
+	PurgeStorage(ctx context.Context, name string) error
+
 	// Close shuts down the runtime and releases resources.
 	Close() error
 }
 
+// PluginStorageUsage describes the data a plugin has stored.
+type PluginStorageUsage struct {
+	Dir   string // The plugin's data directory
+	Used  int64  // Bytes stored
+	Files int
+	Quota int64 // Bytes the plugin may store (negative = unlimited)
+}
+
 // MetricService defines the interface for metric recording.
 type MetricService interface {
 	Record(ctx context.Context, name string, metricType domain.MetricType, value float64, tags map[string]string) error
@@ -0,0 +1,507 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// AlertExpression is a parsed expression rule condition: a list of named
+// metric queries followed by a condition over their values, e.g.
+//
+//	a = avg(cpu.usage{host=web}), b = avg(memory.usage{host=web}); a > 90 && b > 80
+//
+// The condition supports numbers, the query names, + - * /, the comparisons
+// > >= < <= == !=, the logical operators && || ! and the functions abs,
+// min, max, floor and ceil. Comparisons and logical operators yield 1 for
+// true and 0 for false; the condition holds when it evaluates non-zero.
+type AlertExpression struct {
+	Queries []ExpressionQuery
+	root    exprNode
+}
+
+// ExpressionQuery is a named metric query of an alert expression.
+type ExpressionQuery struct {
+	Name        string
+	Aggregation string // avg, min, max, sum, last or count
+	Metric      string
+	Tags        map[string]string
+}
+
+// expressionAggregations are the aggregations a query may apply.
+var expressionAggregations = map[string]bool{
+	"avg": true, "min": true, "max": true, "sum": true, "last": true, "count": true,
+}
+
+// expressionFuncs maps function names to their minimum and maximum arity,
+// -1 meaning unbounded.
+var expressionFuncs = map[string][2]int{
+	"abs":   {1, 1},
+	"floor": {1, 1},
+	"ceil":  {1, 1},
+	"min":   {1, -1},
+	"max":   {1, -1},
+}
+
+// ParseAlertExpression parses an alert expression, checking that every name
+// the condition uses is a defined query.
+func ParseAlertExpression(src string) (*AlertExpression, error) {
+	p := &exprParser{src: src}
+	expr := &AlertExpression{}
+	defined := make(map[string]bool)
+
+	for {
+		q, err := p.parseQuery()
+		if err != nil {
+			return nil, err
+		}
+		if defined[q.Name] {
+			return nil, fmt.Errorf("query %q defined twice", q.Name)
+		}
+		defined[q.Name] = true
+		expr.Queries = append(expr.Queries, q)
+
+		tok := p.next()
+		if tok.text == ";" {
+			break
+		}
+		if tok.text != "," {
+			return nil, p.errorf(tok, "expected \",\" or \";\" after query %q", q.Name)
+		}
+	}
+
+	root, err := p.parseOr(defined)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.next(); tok.kind != tokEOF {
+		return nil, p.errorf(tok, "unexpected %q", tok.text)
+	}
+	expr.root = root
+	return expr, nil
+}
+
+// Eval evaluates the condition with the given query values.
+func (e *AlertExpression) Eval(values map[string]float64) float64 {
+	return e.root.eval(values)
+}
+
+// exprNode is a node of a parsed condition.
+type exprNode interface {
+	eval(values map[string]float64) float64
+}
+
+type numberNode float64
+
+func (n numberNode) eval(map[string]float64) float64 { return float64(n) }
+
+type varNode string
+
+func (n varNode) eval(values map[string]float64) float64 { return values[string(n)] }
+
+type unaryNode struct {
+	op string
+	x  exprNode
+}
+
+func (n *unaryNode) eval(values map[string]float64) float64 {
+	v := n.x.eval(values)
+	if n.op == "-" {
+		return -v
+	}
+	return boolValue(v == 0 || math.IsNaN(v))
+}
+
+type binaryNode struct {
+	op   string
+	l, r exprNode
+}
+
+func (n *binaryNode) eval(values map[string]float64) float64 {
+	l := n.l.eval(values)
+	switch n.op {
+	case "&&":
+		if !truthy(l) {
+			return 0
+		}
+		return boolValue(truthy(n.r.eval(values)))
+	case "||":
+		if truthy(l) {
+			return 1
+		}
+		return boolValue(truthy(n.r.eval(values)))
+	}
+
+	r := n.r.eval(values)
+	switch n.op {
+	case "+":
+		return l + r
+	case "-":
+		return l - r
+	case "*":
+		return l * r
+	case "/":
+		return l / r
+	case ">":
+		return boolValue(l > r)
+	case ">=":
+		return boolValue(l >= r)
+	case "<":
+		return boolValue(l < r)
+	case "<=":
+		return boolValue(l <= r)
+	case "==":
+		return boolValue(l == r)
+	case "!=":
+		return boolValue(l != r)
+	}
+	return math.NaN()
+}
+
+type callNode struct {
+	fn   string
+	args []exprNode
+}
+
+func (n *callNode) eval(values map[string]float64) float64 {
+	v := n.args[0].eval(values)
+	switch n.fn {
+	case "abs":
+		return math.Abs(v)
+	case "floor":
+		return math.Floor(v)
+	case "ceil":
+		return math.Ceil(v)
+	case "min":
+		for _, arg := range n.args[1:] {
+			v = math.Min(v, arg.eval(values))
+		}
+	case "max":
+		for _, arg := range n.args[1:] {
+			v = math.Max(v, arg.eval(values))
+		}
+	}
+	return v
+}
+
+// truthy reports whether a value counts as true: non-zero and not NaN.
+func truthy(v float64) bool {
+	return v != 0 && !math.IsNaN(v)
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokIdent
+	tokOp
+)
+
+type exprToken struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// exprParser is a recursive descent parser reading tokens on demand, so
+// that tag values can be scanned raw.
+type exprParser struct {
+	src    string
+	pos    int
+	peeked *exprToken
+}
+
+func (p *exprParser) errorf(tok exprToken, format string, args ...interface{}) error {
+	return fmt.Errorf("expression position %d: %s", tok.pos+1, fmt.Sprintf(format, args...))
+}
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.src) && unicode.IsSpace(rune(p.src[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *exprParser) peek() exprToken {
+	if p.peeked == nil {
+		tok := p.scan()
+		p.peeked = &tok
+	}
+	return *p.peeked
+}
+
+func (p *exprParser) next() exprToken {
+	tok := p.peek()
+	p.peeked = nil
+	return tok
+}
+
+func (p *exprParser) scan() exprToken {
+	p.skipSpace()
+	start := p.pos
+	if p.pos >= len(p.src) {
+		return exprToken{kind: tokEOF, pos: start}
+	}
+
+	c := p.src[p.pos]
+	switch {
+	case c >= '0' && c <= '9' || c == '.' && p.pos+1 < len(p.src) && p.src[p.pos+1] >= '0' && p.src[p.pos+1] <= '9':
+		for p.pos < len(p.src) && (isDigit(p.src[p.pos]) || p.src[p.pos] == '.' ||
+			(p.src[p.pos] == 'e' || p.src[p.pos] == 'E') ||
+			(p.src[p.pos] == '+' || p.src[p.pos] == '-') && (p.src[p.pos-1] == 'e' || p.src[p.pos-1] == 'E')) {
+			p.pos++
+		}
+		return exprToken{kind: tokNumber, text: p.src[start:p.pos], pos: start}
+	case isIdentStart(c):
+		for p.pos < len(p.src) && isIdentPart(p.src[p.pos]) {
+			p.pos++
+		}
+		return exprToken{kind: tokIdent, text: p.src[start:p.pos], pos: start}
+	}
+
+	for _, op := range []string{"&&", "||", ">=", "<=", "==", "!="} {
+		if strings.HasPrefix(p.src[p.pos:], op) {
+			p.pos += len(op)
+			return exprToken{kind: tokOp, text: op, pos: start}
+		}
+	}
+	p.pos++
+	return exprToken{kind: tokOp, text: string(c), pos: start}
+}
+
+// scanTagValue reads a tag value: a double-quoted string or the raw text up
+// to the next "," or "}".
+func (p *exprParser) scanTagValue() (string, error) {
+	p.skipSpace()
+	if p.pos < len(p.src) && p.src[p.pos] == '"' {
+		end := strings.IndexByte(p.src[p.pos+1:], '"')
+		if end < 0 {
+			return "", fmt.Errorf("expression position %d: unterminated string", p.pos+1)
+		}
+		value := p.src[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+		return value, nil
+	}
+	start := p.pos
+	for p.pos < len(p.src) && p.src[p.pos] != ',' && p.src[p.pos] != '}' {
+		p.pos++
+	}
+	value := strings.TrimSpace(p.src[start:p.pos])
+	if value == "" {
+		return "", fmt.Errorf("expression position %d: empty tag value", start+1)
+	}
+	return value, nil
+}
+
+func (p *exprParser) expect(text string) error {
+	if tok := p.next(); tok.text != text {
+		return p.errorf(tok, "expected %q, got %q", text, tok.text)
+	}
+	return nil
+}
+
+// parseQuery parses name = agg(metric{tag=value, ...}).
+func (p *exprParser) parseQuery() (ExpressionQuery, error) {
+	var q ExpressionQuery
+	tok := p.next()
+	if tok.kind != tokIdent {
+		return q, p.errorf(tok, "expected query name, got %q", tok.text)
+	}
+	q.Name = tok.text
+	if err := p.expect("="); err != nil {
+		return q, err
+	}
+
+	tok = p.next()
+	if !expressionAggregations[tok.text] {
+		return q, p.errorf(tok, "unknown aggregation %q (want avg, min, max, sum, last or count)", tok.text)
+	}
+	q.Aggregation = tok.text
+	if err := p.expect("("); err != nil {
+		return q, err
+	}
+	tok = p.next()
+	if tok.kind != tokIdent {
+		return q, p.errorf(tok, "expected metric name, got %q", tok.text)
+	}
+	q.Metric = tok.text
+
+	if p.peek().text == "{" {
+		p.next()
+		q.Tags = make(map[string]string)
+		for p.peek().text != "}" {
+			tok = p.next()
+			if tok.kind != tokIdent {
+				return q, p.errorf(tok, "expected tag name, got %q", tok.text)
+			}
+			if err := p.expect("="); err != nil {
+				return q, err
+			}
+			value, err := p.scanTagValue()
+			if err != nil {
+				return q, err
+			}
+			q.Tags[tok.text] = value
+			if p.peek().text == "," {
+				p.next()
+			} else if p.peek().text != "}" {
+				return q, p.errorf(p.peek(), "expected \",\" or \"}\" in tags")
+			}
+		}
+		p.next()
+	}
+	return q, p.expect(")")
+}
+
+func (p *exprParser) parseOr(defined map[string]bool) (exprNode, error) {
+	return p.parseBinary(defined, []string{"||"}, p.parseAnd)
+}
+
+func (p *exprParser) parseAnd(defined map[string]bool) (exprNode, error) {
+	return p.parseBinary(defined, []string{"&&"}, p.parseComparison)
+}
+
+func (p *exprParser) parseComparison(defined map[string]bool) (exprNode, error) {
+	l, err := p.parseSum(defined)
+	if err != nil {
+		return nil, err
+	}
+	switch op := p.peek().text; op {
+	case ">", ">=", "<", "<=", "==", "!=":
+		p.next()
+		r, err := p.parseSum(defined)
+		if err != nil {
+			return nil, err
+		}
+		return &binaryNode{op: op, l: l, r: r}, nil
+	}
+	return l, nil
+}
+
+func (p *exprParser) parseSum(defined map[string]bool) (exprNode, error) {
+	return p.parseBinary(defined, []string{"+", "-"}, p.parseProduct)
+}
+
+func (p *exprParser) parseProduct(defined map[string]bool) (exprNode, error) {
+	return p.parseBinary(defined, []string{"*", "/"}, p.parseUnary)
+}
+
+// parseBinary parses a left-associative chain of operand ops.
+func (p *exprParser) parseBinary(defined map[string]bool, ops []string, operand func(map[string]bool) (exprNode, error)) (exprNode, error) {
+	l, err := operand(defined)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peek()
+		if op.kind != tokOp || !containsString(ops, op.text) {
+			return l, nil
+		}
+		p.next()
+		r, err := operand(defined)
+		if err != nil {
+			return nil, err
+		}
+		l = &binaryNode{op: op.text, l: l, r: r}
+	}
+}
+
+func (p *exprParser) parseUnary(defined map[string]bool) (exprNode, error) {
+	if op := p.peek().text; op == "!" || op == "-" {
+		p.next()
+		x, err := p.parseUnary(defined)
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: op, x: x}, nil
+	}
+	return p.parsePrimary(defined)
+}
+
+func (p *exprParser) parsePrimary(defined map[string]bool) (exprNode, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNumber:
+		v, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, p.errorf(tok, "invalid number %q", tok.text)
+		}
+		return numberNode(v), nil
+
+	case tokIdent:
+		if p.peek().text != "(" {
+			if !defined[tok.text] {
+				return nil, p.errorf(tok, "undefined query %q", tok.text)
+			}
+			return varNode(tok.text), nil
+		}
+		arity, ok := expressionFuncs[tok.text]
+		if !ok {
+			return nil, p.errorf(tok, "unknown function %q", tok.text)
+		}
+		p.next()
+		call := &callNode{fn: tok.text}
+		for p.peek().text != ")" {
+			arg, err := p.parseOr(defined)
+			if err != nil {
+				return nil, err
+			}
+			call.args = append(call.args, arg)
+			if p.peek().text == "," {
+				p.next()
+			} else if p.peek().text != ")" {
+				return nil, p.errorf(p.peek(), "expected \",\" or \")\" in call to %s", tok.text)
+			}
+		}
+		p.next()
+		if len(call.args) < arity[0] || arity[1] >= 0 && len(call.args) > arity[1] {
+			return nil, p.errorf(tok, "wrong number of arguments to %s", tok.text)
+		}
+		return call, nil
+
+	case tokOp:
+		if tok.text == "(" {
+			x, err := p.parseOr(defined)
+			if err != nil {
+				return nil, err
+			}
+			return x, p.expect(")")
+		}
+	}
+	if tok.kind == tokEOF {
+		return nil, p.errorf(tok, "unexpected end of expression")
+	}
+	return nil, p.errorf(tok, "unexpected %q", tok.text)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// isIdentPart allows dots so that metric names such as cpu.usage scan as
+// one identifier.
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || isDigit(c) || c == '.'
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
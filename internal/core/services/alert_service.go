@@ -5,6 +5,8 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -20,21 +22,68 @@ type AlertService struct {
 	channelRepo ports.NotificationChannelRepository
 	silenceRepo ports.SilenceRepository
 	metricRepo  ports.MetricRepository
+	metricSvc   ports.MetricService // Records the service's own metrics, optional
 	logger      ports.Logger
 
-	// Notification sender interface
-	notifiers map[domain.NotificationChannelType]Notifier
+	// Recurring maintenance windows, optional
+	maintenanceRepo ports.MaintenanceWindowRepository
+
+	// Notification sender interface, guarded by notifierMu so that
+	// notification lookups don't contend with alert-cache updates.
+	notifiers  map[domain.NotificationChannelType]Notifier
+	notifierMu sync.RWMutex
+
+	// Default message templates per severity, used when a channel has no
+	// template of its own for that severity. Guarded by notifierMu.
+	severityTemplates map[domain.AlertSeverity]string
 
 	// Active alerts cache (fingerprint -> alert)
 	activeAlerts map[string]*domain.Alert
 	mu           sync.RWMutex
 
+	// Notification groups waiting to be sent (group key -> group)
+	groups  map[string]*notificationGroup
+	groupMu sync.Mutex
+
+	// During quiet hours non-critical notifications are held in digests
+	// (channel set -> digest) until the period ends. Guarded by groupMu.
+	quietHours *domain.QuietHours
+	digests    map[string]*notificationDigest
+	quietTimer *time.Timer
+
+	// Ended silences are deleted once they are older than silenceRetention
+	silenceRetention time.Duration
+
 	// Evaluation state
 	evaluating bool
 	stopCh     chan struct{}
 	wg         sync.WaitGroup
 }
 
+// DefaultSilenceRetention is how long ended silences are kept before the
+// janitor deletes them.
+const DefaultSilenceRetention = 7 * 24 * time.Hour
+
+// silenceJanitorInterval is how often ended silences are purged.
+const silenceJanitorInterval = time.Hour
+
+// Metrics recorded for every notification delivery, tagged by channel type
+// and name.
+const (
+	metricNotificationsSent   = "forge.notifications.sent"
+	metricNotificationsFailed = "forge.notifications.failed"
+)
+
+// Metrics recorded after every evaluation pass so that Forge can alert on
+// its own alerting: the rules evaluated, how long the pass took in
+// milliseconds, the alerts firing and, tagged by rule, evaluation errors.
+const (
+	metricAlertEvaluations        = "forge.alert.evaluations"
+	metricAlertEvaluationDuration = "forge.alert.evaluation_duration"
+	metricAlertEvaluationErrors   = "forge.alert.evaluation_errors"
+	metricAlertsFiring            = "forge.alerts.firing"
+)
+
 // Notifier defines the interface for sending notifications.
 type Notifier interface {
 	Send(ctx context.Context, alert *domain.Alert, channel *domain.NotificationChannel) error
@@ -51,25 +100,66 @@ func NewAlertService(
 	logger ports.Logger,
 ) *AlertService {
 	return &AlertService{
-		ruleRepo:     ruleRepo,
-		alertRepo:    alertRepo,
-		channelRepo:  channelRepo,
-		silenceRepo:  silenceRepo,
-		metricRepo:   metricRepo,
-		logger:       logger,
-		notifiers:    make(map[domain.NotificationChannelType]Notifier),
-		activeAlerts: make(map[string]*domain.Alert),
-		stopCh:       make(chan struct{}),
+		ruleRepo:          ruleRepo,
+		alertRepo:         alertRepo,
+		channelRepo:       channelRepo,
+		silenceRepo:       silenceRepo,
+		metricRepo:        metricRepo,
+		logger:            logger,
+		notifiers:         make(map[domain.NotificationChannelType]Notifier),
+		severityTemplates: make(map[domain.AlertSeverity]string),
+		activeAlerts:      make(map[string]*domain.Alert),
+		groups:            make(map[string]*notificationGroup),
+		digests:           make(map[string]*notificationDigest),
+		silenceRetention:  DefaultSilenceRetention,
+		stopCh:            make(chan struct{}),
 	}
 }
 
+// SetSilenceRetention sets how long ended silences are kept before they are
+// deleted. It must be called before Start.
+func (s *AlertService) SetSilenceRetention(retention time.Duration) {
+	s.silenceRetention = retention
+}
+
+// SetMetricService records evaluation metrics such as
+// forge.alert.evaluations.
+func (s *AlertService) SetMetricService(svc ports.MetricService) {
+	s.metricSvc = svc
+}
+
+// SetMaintenanceWindowRepository enables maintenance windows, which silence
+// matching alerts while one of their occurrences is open.
+func (s *AlertService) SetMaintenanceWindowRepository(repo ports.MaintenanceWindowRepository) {
+	s.maintenanceRepo = repo
+}
+
+// SetQuietHours sets the quiet hours during which only critical alerts
+// notify; nil disables them. It must be called before Start.
+func (s *AlertService) SetQuietHours(quietHours *domain.QuietHours) {
+	s.quietHours = quietHours
+}
+
 // RegisterNotifier registers a notification sender for a channel type.
 func (s *AlertService) RegisterNotifier(notifier Notifier) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	s.notifierMu.Lock()
+	defer s.notifierMu.Unlock()
 	s.notifiers[notifier.Type()] = notifier
 }
 
+// SetSeverityTemplate sets the default notification template for alerts of
+// the given severity. Channels that configure "template.<severity>" keep
+// their own template; an empty text removes the default.
+func (s *AlertService) SetSeverityTemplate(severity domain.AlertSeverity, text string) {
+	s.notifierMu.Lock()
+	defer s.notifierMu.Unlock()
+	if text == "" {
+		delete(s.severityTemplates, severity)
+		return
+	}
+	s.severityTemplates[severity] = text
+}
+
 // Start begins the alert evaluation loop.
 func (s *AlertService) Start(ctx context.Context, interval time.Duration) {
 	s.mu.Lock()
@@ -96,6 +186,10 @@ func (s *AlertService) Stop() {
 	close(s.stopCh)
 	s.mu.Unlock()
 	s.wg.Wait()
+
+	// Send groups and digests still waiting rather than dropping them
+	s.flushGroups()
+	s.flushDigests()
 }
 
 // evaluationLoop periodically evaluates alert rules.
@@ -104,6 +198,13 @@ func (s *AlertService) evaluationLoop(ctx context.Context, interval time.Duratio
 
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
+	janitor := time.NewTicker(silenceJanitorInterval)
+	defer janitor.Stop()
+
+	// Pick up alerts that were active before a restart
+	if _, err := s.LoadActiveAlerts(ctx); err != nil && s.logger != nil {
+		s.logger.Error("Failed to load active alerts", "error", err)
+	}
 
 	// Initial evaluation
 	s.EvaluateAll(ctx)
@@ -116,8 +217,79 @@ func (s *AlertService) evaluationLoop(ctx context.Context, interval time.Duratio
 			return
 		case <-ticker.C:
 			s.EvaluateAll(ctx)
+		case <-janitor.C:
+			if _, err := s.PurgeSilences(ctx, time.Now()); err != nil && s.logger != nil {
+				s.logger.Error("Failed to purge ended silences", "error", err)
+			}
+		}
+	}
+}
+
+// LoadActiveAlerts fills the active alerts cache from the alert repository
+// so that alerts firing or acknowledged before a restart are updated and
+// resolved in place rather than duplicated. It returns the number of alerts
+// loaded.
+func (s *AlertService) LoadActiveAlerts(ctx context.Context) (int, error) {
+	if s.alertRepo == nil {
+		return 0, nil
+	}
+
+	active, err := s.alertRepo.ListActive(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list active alerts: %w", err)
+	}
+	acknowledgedState := domain.AlertStateAcknowledged
+	acknowledged, err := s.alertRepo.List(ctx, ports.AlertFilter{State: &acknowledgedState})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list acknowledged alerts: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	loaded := 0
+	for _, alert := range append(active, acknowledged...) {
+		if !isOpenAlert(alert) {
+			continue
+		}
+		// Keep the newest alert if a fingerprint somehow has several
+		if cached, ok := s.activeAlerts[alert.Fingerprint]; ok && !alert.StartsAt.After(cached.StartsAt) {
+			continue
 		}
+		if _, ok := s.activeAlerts[alert.Fingerprint]; !ok {
+			loaded++
+		}
+		s.activeAlerts[alert.Fingerprint] = alert
+	}
+	return loaded, nil
+}
+
+// isOpenAlert reports whether an alert can still fire or resolve.
+func isOpenAlert(alert *domain.Alert) bool {
+	switch alert.State {
+	case domain.AlertStatePending, domain.AlertStateFiring, domain.AlertStateAcknowledged, domain.AlertStateSilenced:
+		return true
 	}
+	return false
+}
+
+// persistedAlert returns the open alert stored for a fingerprint that is
+// not in the cache, caching it, or nil if there is none.
+func (s *AlertService) persistedAlert(ctx context.Context, fingerprint string) *domain.Alert {
+	if s.alertRepo == nil {
+		return nil
+	}
+	alert, err := s.alertRepo.GetByFingerprint(ctx, fingerprint)
+	if err != nil || alert == nil || !isOpenAlert(alert) {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cached, ok := s.activeAlerts[fingerprint]; ok {
+		return cached
+	}
+	s.activeAlerts[fingerprint] = alert
+	return alert
 }
 
 // EvaluateAll evaluates all enabled alert rules.
@@ -134,22 +306,63 @@ func (s *AlertService) EvaluateAll(ctx context.Context) {
 		return
 	}
 
+	start := time.Now()
 	for _, rule := range rules {
 		if err := s.EvaluateRule(ctx, rule); err != nil {
 			if s.logger != nil {
 				s.logger.Error("Failed to evaluate rule", "rule", rule.Name, "error", err)
 			}
+			s.recordMetric(ctx, metricAlertEvaluationErrors, domain.MetricTypeCounter, 1, map[string]string{"rule": rule.Name})
+		}
+	}
+	elapsed := time.Since(start)
+
+	s.mu.RLock()
+	firing := 0
+	for _, alert := range s.activeAlerts {
+		if alert.State == domain.AlertStateFiring {
+			firing++
 		}
 	}
+	s.mu.RUnlock()
+
+	s.recordMetric(ctx, metricAlertEvaluations, domain.MetricTypeCounter, float64(len(rules)), nil)
+	s.recordMetric(ctx, metricAlertEvaluationDuration, domain.MetricTypeHistogram, float64(elapsed.Microseconds())/1000, nil)
+	s.recordMetric(ctx, metricAlertsFiring, domain.MetricTypeGauge, float64(firing), nil)
+}
+
+// recordMetric records one of the service's own metrics, if a metric
+// service is configured.
+func (s *AlertService) recordMetric(ctx context.Context, name string, metricType domain.MetricType, value float64, tags map[string]string) {
+	if s.metricSvc == nil {
+		return
+	}
+	if err := s.metricSvc.Record(context.WithoutCancel(ctx), name, metricType, value, tags); err != nil && s.logger != nil {
+		s.logger.Debug("Failed to record alert metric", "name", name, "error", err)
+	}
 }
 
 // EvaluateRule evaluates a single alert rule.
 func (s *AlertService) EvaluateRule(ctx context.Context, rule *domain.AlertRule) error {
-	// Query recent metrics
+	if rule.Condition == domain.ConditionExpression {
+		firing, value, err := s.evaluateExpression(ctx, rule)
+		if err != nil {
+			return err
+		}
+		return s.processEvaluation(ctx, rule, firing, value)
+	}
+
+	// Query recent metrics, reaching back far enough for a rolling baseline
+	lookback := rule.Duration * 2
+	if rule.Condition == domain.ConditionAnomalyDetection && rule.AnomalyWindow > 0 {
+		if w := rule.AnomalyWindow + rule.AnomalyBaselineWindow(); w > lookback {
+			lookback = w
+		}
+	}
 	query := ports.MetricQuery{
 		Name:      rule.MetricName,
 		Tags:      rule.Tags,
-		StartTime: time.Now().Add(-rule.Duration * 2),
+		StartTime: time.Now().Add(-lookback),
 		EndTime:   time.Now(),
 	}
 
@@ -177,20 +390,17 @@ func (s *AlertService) evaluateCondition(rule *domain.AlertRule, series *domain.
 	latestValue := series.Points[len(series.Points)-1].Value
 
 	switch rule.Condition {
-	case domain.ConditionThresholdAbove:
-		return latestValue > rule.Threshold, latestValue
-
-	case domain.ConditionThresholdBelow:
-		return latestValue < rule.Threshold, latestValue
-
-	case domain.ConditionThresholdEqual:
-		return latestValue == rule.Threshold, latestValue
+	case domain.ConditionThresholdAbove, domain.ConditionThresholdBelow, domain.ConditionThresholdEqual:
+		return s.evaluateThreshold(rule, series.Points)
 
 	case domain.ConditionRateOfChange:
 		rate := s.calculateRateOfChange(series, rule.RateWindow)
 		return math.Abs(rate) > rule.Threshold, rate
 
 	case domain.ConditionAnomalyDetection:
+		if rule.AnomalyWindow > 0 {
+			return s.detectRollingAnomaly(series, rule.AnomalyStdDev, rule.AnomalyWindow, rule.AnomalyBaselineWindow())
+		}
 		isAnomaly, zScore := s.detectAnomaly(series, rule.AnomalyStdDev)
 		return isAnomaly, zScore
 
@@ -201,6 +411,141 @@ func (s *AlertService) evaluateCondition(rule *domain.AlertRule, series *domain.
 	return false, 0
 }
 
+// evaluateThreshold compares the points within rule.Duration of the latest
+// point against the threshold, either as one value combined with
+// rule.ForAggregation or, with rule.ForPercent set, point by point. The
+// combined value is returned either way.
+func (s *AlertService) evaluateThreshold(rule *domain.AlertRule, points []domain.MetricPoint) (bool, float64) {
+	latest := points[len(points)-1]
+	window := points[len(points)-1:]
+	if rule.Duration > 0 {
+		cutoff := latest.Timestamp.Add(-rule.Duration)
+		start := len(points) - 1
+		for start > 0 && points[start-1].Timestamp.After(cutoff) {
+			start--
+		}
+		window = points[start:]
+	}
+
+	breaches := func(v float64) bool {
+		switch rule.Condition {
+		case domain.ConditionThresholdAbove:
+			return v > rule.Threshold
+		case domain.ConditionThresholdBelow:
+			return v < rule.Threshold
+		default:
+			return v == rule.Threshold
+		}
+	}
+
+	value := latest.Value
+	switch rule.ForAggregation {
+	case domain.WindowAvg:
+		value = 0
+		for _, p := range window {
+			value += p.Value
+		}
+		value /= float64(len(window))
+	case domain.WindowMin:
+		for _, p := range window {
+			value = math.Min(value, p.Value)
+		}
+	case domain.WindowMax:
+		for _, p := range window {
+			value = math.Max(value, p.Value)
+		}
+	}
+
+	if rule.ForPercent <= 0 {
+		return breaches(value), value
+	}
+	breached := 0
+	for _, p := range window {
+		if breaches(p.Value) {
+			breached++
+		}
+	}
+	return float64(breached)*100 >= rule.ForPercent*float64(len(window)), value
+}
+
+// evaluateExpression runs the queries of an expression rule over the
+// rule's window and evaluates its condition. The reported value is that of
+// the first query. The condition is not met while any query has no data.
+func (s *AlertService) evaluateExpression(ctx context.Context, rule *domain.AlertRule) (bool, float64, error) {
+	expr, err := ParseAlertExpression(rule.Expression)
+	if err != nil {
+		return false, 0, fmt.Errorf("invalid expression: %w", err)
+	}
+
+	window := rule.Duration
+	if window <= 0 {
+		window = rule.Interval
+	}
+	if window <= 0 {
+		window = time.Minute
+	}
+	now := time.Now()
+
+	values := make(map[string]float64, len(expr.Queries))
+	for _, q := range expr.Queries {
+		series, err := s.metricRepo.Query(ctx, ports.MetricQuery{
+			Name:      q.Metric,
+			Tags:      q.Tags,
+			StartTime: now.Add(-window),
+			EndTime:   now,
+		})
+		if err != nil {
+			return false, 0, fmt.Errorf("failed to query %s: %w", q.Metric, err)
+		}
+		if series == nil || len(series.Points) == 0 {
+			return false, 0, nil
+		}
+		values[q.Name] = aggregatePoints(q.Aggregation, series.Points)
+	}
+	return truthy(expr.Eval(values)), values[expr.Queries[0].Name], nil
+}
+
+// aggregatePoints combines the values of a non-empty list of points.
+func aggregatePoints(aggregation string, points []domain.MetricPoint) float64 {
+	value := points[len(points)-1].Value
+	switch aggregation {
+	case "count":
+		return float64(len(points))
+	case "sum", "avg":
+		value = 0
+		for _, p := range points {
+			value += p.Value
+		}
+		if aggregation == "avg" {
+			value /= float64(len(points))
+		}
+	case "min":
+		for _, p := range points {
+			value = math.Min(value, p.Value)
+		}
+	case "max":
+		for _, p := range points {
+			value = math.Max(value, p.Value)
+		}
+	}
+	return value
+}
+
+// validateRule checks that an expression rule's expression parses, so that
+// mistakes are reported when the rule is saved rather than when evaluated.
+func validateRule(rule *domain.AlertRule) error {
+	if rule.Condition != domain.ConditionExpression {
+		return nil
+	}
+	if rule.Expression == "" {
+		return fmt.Errorf("expression is required for expression conditions")
+	}
+	if _, err := ParseAlertExpression(rule.Expression); err != nil {
+		return fmt.Errorf("invalid expression: %w", err)
+	}
+	return nil
+}
+
 // calculateRateOfChange calculates the rate of change over the given window.
 func (s *AlertService) calculateRateOfChange(series *domain.MetricSeries, window time.Duration) float64 {
 	if len(series.Points) < 2 {
@@ -209,11 +554,19 @@ func (s *AlertService) calculateRateOfChange(series *domain.MetricSeries, window
 
 	cutoff := time.Now().Add(-window)
 	var firstPoint, lastPoint *domain.MetricPoint
+	var counterIncrease float64
 
 	for i := range series.Points {
 		if series.Points[i].Timestamp.After(cutoff) {
 			if firstPoint == nil {
 				firstPoint = &series.Points[i]
+			} else if series.IsCounter() {
+				// A drop is a counter reset, not a negative change
+				increase := series.Points[i].Value - lastPoint.Value
+				if increase < 0 {
+					increase = series.Points[i].Value
+				}
+				counterIncrease += increase
 			}
 			lastPoint = &series.Points[i]
 		}
@@ -228,38 +581,95 @@ func (s *AlertService) calculateRateOfChange(series *domain.MetricSeries, window
 		return 0
 	}
 
+	if series.IsCounter() {
+		return counterIncrease / timeDiff
+	}
 	return (lastPoint.Value - firstPoint.Value) / timeDiff
 }
 
 // detectAnomaly uses z-score to detect anomalies.
+// Counters are checked on their per-second rate so resets are not flagged.
 func (s *AlertService) detectAnomaly(series *domain.MetricSeries, stdDevThreshold float64) (bool, float64) {
-	if len(series.Points) < 10 {
-		return false, 0
+	points := series.Points
+	if series.IsCounter() {
+		points, _ = domain.CounterRates(points)
 	}
-
-	// Calculate mean and standard deviation
-	var sum, sumSq float64
-	for _, p := range series.Points {
-		sum += p.Value
-		sumSq += p.Value * p.Value
+	if len(points) < 10 {
+		return false, 0
 	}
 
-	n := float64(len(series.Points))
-	mean := sum / n
-	variance := (sumSq / n) - (mean * mean)
-	stdDev := math.Sqrt(variance)
-
+	mean, stdDev := meanStdDev(points)
 	if stdDev == 0 {
 		return false, 0
 	}
 
 	// Check latest value
-	latest := series.Points[len(series.Points)-1].Value
+	latest := points[len(points)-1].Value
 	zScore := (latest - mean) / stdDev
 
 	return math.Abs(zScore) > stdDevThreshold, zScore
 }
 
+// detectRollingAnomaly scores the points in the last window against the
+// mean and standard deviation of the baseline window before them, so a
+// sustained shift is flagged instead of being absorbed into the baseline.
+// Windows are measured back from the latest point. The z-score with the
+// largest magnitude among the recent points is returned.
+func (s *AlertService) detectRollingAnomaly(series *domain.MetricSeries, stdDevThreshold float64, window, baseline time.Duration) (bool, float64) {
+	points := series.Points
+	if series.IsCounter() {
+		points, _ = domain.CounterRates(points)
+	}
+	if len(points) == 0 {
+		return false, 0
+	}
+
+	cutoff := points[len(points)-1].Timestamp.Add(-window)
+	baselineStart := cutoff.Add(-baseline)
+	var reference, recent []domain.MetricPoint
+	for _, p := range points {
+		switch {
+		case p.Timestamp.After(cutoff):
+			recent = append(recent, p)
+		case p.Timestamp.After(baselineStart):
+			reference = append(reference, p)
+		}
+	}
+	if len(reference) < 10 || len(recent) == 0 {
+		return false, 0
+	}
+
+	mean, stdDev := meanStdDev(reference)
+	if stdDev == 0 {
+		return false, 0
+	}
+
+	var worst float64
+	for _, p := range recent {
+		if z := (p.Value - mean) / stdDev; math.Abs(z) > math.Abs(worst) {
+			worst = z
+		}
+	}
+	return math.Abs(worst) > stdDevThreshold, worst
+}
+
+// meanStdDev returns the mean and population standard deviation of points.
+func meanStdDev(points []domain.MetricPoint) (float64, float64) {
+	var sum, sumSq float64
+	for _, p := range points {
+		sum += p.Value
+		sumSq += p.Value * p.Value
+	}
+
+	n := float64(len(points))
+	mean := sum / n
+	variance := (sumSq / n) - (mean * mean)
+	if variance < 0 {
+		variance = 0 // rounding
+	}
+	return mean, math.Sqrt(variance)
+}
+
 // processEvaluation processes the result of rule evaluation.
 func (s *AlertService) processEvaluation(ctx context.Context, rule *domain.AlertRule, firing bool, value float64) error {
 	fingerprint := rule.ID.String() + ":" + rule.MetricName
@@ -268,20 +678,20 @@ func (s *AlertService) processEvaluation(ctx context.Context, rule *domain.Alert
 	existingAlert := s.activeAlerts[fingerprint]
 	s.mu.Unlock()
 
+	if firing && existingAlert == nil {
+		// The alert may have fired before a restart
+		existingAlert = s.persistedAlert(ctx, fingerprint)
+	}
+
 	if firing {
 		if existingAlert == nil {
-			// Create new alert
+			// Create new alert, pending until the condition has held for
+			// the rule's duration
 			message := fmt.Sprintf("Alert %s: %s condition met (value: %.2f, threshold: %.2f)",
 				rule.Name, rule.Condition, value, rule.Threshold)
 			alert := domain.NewAlert(rule, value, message)
-
-			// Check if should be silenced
-			if s.shouldSilence(ctx, alert) {
-				alert.Silence()
-			} else {
-				alert.Fire()
-				// Send notifications
-				s.sendNotifications(ctx, alert, rule.Channels)
+			if rule.Duration <= 0 {
+				s.activate(ctx, rule, alert)
 			}
 
 			if s.alertRepo != nil {
@@ -295,20 +705,72 @@ func (s *AlertService) processEvaluation(ctx context.Context, rule *domain.Alert
 			s.mu.Unlock()
 
 			if s.logger != nil {
-				s.logger.Info("Alert fired", "rule", rule.Name, "value", value)
+				if alert.State == domain.AlertStatePending {
+					s.logger.Info("Alert pending", "rule", rule.Name, "value", value)
+				} else {
+					s.logger.Info("Alert fired", "rule", rule.Name, "value", value)
+				}
 			}
 		} else {
 			// Update existing alert
 			existingAlert.Value = value
 			existingAlert.LastEvaluated = time.Now()
+			existingAlert.ClearSince = nil
+			if existingAlert.State == domain.AlertStatePending && time.Since(existingAlert.StartsAt) >= rule.Duration {
+				s.activate(ctx, rule, existingAlert)
+				if s.logger != nil {
+					s.logger.Info("Alert fired", "rule", rule.Name, "value", value)
+				}
+			} else if existingAlert.State == domain.AlertStateSilenced {
+				// Fire once the silence has ended or been removed
+				s.activate(ctx, rule, existingAlert)
+				if existingAlert.State == domain.AlertStateFiring && s.logger != nil {
+					s.logger.Info("Alert fired after silence ended", "rule", rule.Name, "value", value)
+				}
+			} else if existingAlert.NotificationDue(rule.RepeatEvery(), time.Now()) {
+				// Remind while the alert keeps firing
+				s.notify(ctx, rule, existingAlert)
+				if s.logger != nil {
+					s.logger.Info("Alert still firing", "rule", rule.Name, "value", value, "notifications", existingAlert.NotificationCount)
+				}
+			}
 			if s.alertRepo != nil {
 				_ = s.alertRepo.Update(ctx, existingAlert)
 			}
 		}
 	} else {
-		if existingAlert != nil && existingAlert.State == domain.AlertStateFiring {
+		if existingAlert != nil && existingAlert.State == domain.AlertStatePending {
+			// The condition did not hold for the duration; the alert never fired
+			s.mu.Lock()
+			delete(s.activeAlerts, fingerprint)
+			s.mu.Unlock()
+			if s.alertRepo != nil {
+				_ = s.alertRepo.Delete(ctx, existingAlert.ID)
+			}
+			return nil
+		}
+		if existingAlert != nil && (existingAlert.State == domain.AlertStateFiring || existingAlert.State == domain.AlertStateAcknowledged || existingAlert.State == domain.AlertStateSilenced) {
+			// Hold transient conditions until they have been clear long enough
+			if holdDown := rule.ResolveHoldDown(); holdDown > 0 {
+				now := time.Now()
+				if existingAlert.ClearSince == nil {
+					existingAlert.ClearSince = &now
+				}
+				existingAlert.LastEvaluated = now
+				if now.Sub(*existingAlert.ClearSince) < holdDown {
+					if s.alertRepo != nil {
+						_ = s.alertRepo.Update(ctx, existingAlert)
+					}
+					return nil
+				}
+			}
+
 			// Resolve the alert
 			existingAlert.Resolve()
+			if rule.NotifyOnResolve && existingAlert.NotificationCount > 0 {
+				existingAlert.RecordNotification(time.Now())
+				s.sendNotifications(ctx, existingAlert, rule.Channels)
+			}
 			if s.alertRepo != nil {
 				_ = s.alertRepo.Update(ctx, existingAlert)
 			}
@@ -326,30 +788,98 @@ func (s *AlertService) processEvaluation(ctx context.Context, rule *domain.Alert
 	return nil
 }
 
-// shouldSilence checks if an alert should be silenced.
-func (s *AlertService) shouldSilence(ctx context.Context, alert *domain.Alert) bool {
-	if s.silenceRepo == nil {
-		return false
+// activate fires a new or pending alert and sends its notifications, or
+// silences it if a silence matches.
+func (s *AlertService) activate(ctx context.Context, rule *domain.AlertRule, alert *domain.Alert) {
+	if by := s.silencedBy(ctx, alert); by != "" {
+		alert.Silence()
+		alert.SilencedBy = by
+		return
 	}
+	alert.Fire()
+	s.notify(ctx, rule, alert)
+}
 
-	silences, err := s.silenceRepo.ListActive(ctx, time.Now())
-	if err != nil {
-		return false
+// notify sends a firing alert's notifications, grouped if the rule groups
+// alerts, and records them on the alert.
+func (s *AlertService) notify(ctx context.Context, rule *domain.AlertRule, alert *domain.Alert) {
+	alert.RecordNotification(time.Now())
+	if len(rule.GroupBy) > 0 {
+		s.notifyGrouped(ctx, rule, alert)
+	} else {
+		s.sendNotifications(ctx, alert, rule.Channels)
 	}
+}
 
-	for _, silence := range silences {
-		if silence.Matches(alert.Labels) {
-			return true
+// silencedBy returns what silences an alert now, "silence:<id>" or
+// "maintenance:<window name>", or "" if nothing does.
+func (s *AlertService) silencedBy(ctx context.Context, alert *domain.Alert) string {
+	now := time.Now()
+	if s.silenceRepo != nil {
+		if silences, err := s.ListActiveSilences(ctx, now); err == nil {
+			for _, silence := range silences {
+				if silence.Matches(alert.Labels) {
+					return "silence:" + silence.ID.String()
+				}
+			}
 		}
 	}
-	return false
+	if s.maintenanceRepo != nil {
+		if windows, err := s.ListActiveMaintenanceWindows(ctx, now); err == nil {
+			for _, window := range windows {
+				if window.Matches(alert.Labels) {
+					return "maintenance:" + window.Name
+				}
+			}
+		}
+	}
+	return ""
 }
 
-// sendNotifications sends notifications for an alert.
+// notifier returns the registered notifier for a channel type.
+func (s *AlertService) notifier(channelType domain.NotificationChannelType) (Notifier, bool) {
+	s.notifierMu.RLock()
+	defer s.notifierMu.RUnlock()
+	n, ok := s.notifiers[channelType]
+	return n, ok
+}
+
+// channelForAlert returns the channel to hand to the notifier. When the
+// service has a default template for the alert's severity and the channel
+// does not override it, a copy of the channel carrying that template is
+// returned so the stored channel is left untouched.
+func (s *AlertService) channelForAlert(channel *domain.NotificationChannel, severity domain.AlertSeverity) *domain.NotificationChannel {
+	key := domain.SeverityTemplateKey(severity)
+	if channel.Config[key] != "" {
+		return channel
+	}
+	s.notifierMu.RLock()
+	text := s.severityTemplates[severity]
+	s.notifierMu.RUnlock()
+	if text == "" {
+		return channel
+	}
+
+	clone := *channel
+	clone.Config = make(map[string]string, len(channel.Config)+1)
+	for k, v := range channel.Config {
+		clone.Config[k] = v
+	}
+	clone.Config[key] = text
+	return &clone
+}
+
+// sendNotifications sends notifications for an alert, or holds them for
+// the quiet hours digest.
 func (s *AlertService) sendNotifications(ctx context.Context, alert *domain.Alert, channelIDs []string) {
-	if s.channelRepo == nil {
+	if s.channelRepo == nil || s.holdForQuietHours(ctx, alert, channelIDs, time.Now()) {
 		return
 	}
+	s.dispatch(ctx, alert, channelIDs)
+}
+
+// dispatch sends an alert to each enabled channel now.
+func (s *AlertService) dispatch(ctx context.Context, alert *domain.Alert, channelIDs []string) {
 
 	for _, channelIDStr := range channelIDs {
 		channelID, err := uuid.Parse(channelIDStr)
@@ -362,9 +892,7 @@ func (s *AlertService) sendNotifications(ctx context.Context, alert *domain.Aler
 			continue
 		}
 
-		s.mu.RLock()
-		notifier, ok := s.notifiers[channel.Type]
-		s.mu.RUnlock()
+		notifier, ok := s.notifier(channel.Type)
 
 		if !ok {
 			if s.logger != nil {
@@ -374,17 +902,206 @@ func (s *AlertService) sendNotifications(ctx context.Context, alert *domain.Aler
 		}
 
 		go func(ch *domain.NotificationChannel) {
-			if err := notifier.Send(ctx, alert, ch); err != nil {
+			if err := s.deliver(ctx, notifier, alert, ch); err != nil {
 				if s.logger != nil {
 					s.logger.Error("Failed to send notification", "channel", ch.Name, "error", err)
 				}
 			}
-		}(channel)
+		}(s.channelForAlert(channel, alert.Severity))
+	}
+}
+
+// deliver sends an alert through notifier and records the outcome.
+func (s *AlertService) deliver(ctx context.Context, notifier Notifier, alert *domain.Alert, channel *domain.NotificationChannel) error {
+	err := notifier.Send(ctx, alert, channel)
+	name := metricNotificationsSent
+	if err != nil {
+		name = metricNotificationsFailed
+	}
+	s.recordMetric(ctx, name, domain.MetricTypeCounter, 1, map[string]string{"channel_type": string(channel.Type), "channel": channel.Name})
+	return err
+}
+
+// notificationGroup collects alerts for one group key until it is sent.
+type notificationGroup struct {
+	ctx      context.Context
+	group    domain.AlertGroup
+	channels []string
+	timer    *time.Timer
+}
+
+// notifyGrouped adds a firing alert to the notification group for its
+// rule's group-by labels and channels. The first alert in a group starts
+// the rule's group wait; the group is sent as one notification when it
+// ends.
+func (s *AlertService) notifyGrouped(ctx context.Context, rule *domain.AlertRule, alert *domain.Alert) {
+	labels := make(map[string]string, len(rule.GroupBy))
+	for _, name := range rule.GroupBy {
+		labels[name] = alert.Labels[name]
+	}
+	key := notificationGroupKey(labels, rule.Channels)
+
+	s.groupMu.Lock()
+	defer s.groupMu.Unlock()
+	if g, ok := s.groups[key]; ok {
+		g.group.Alerts = append(g.group.Alerts, alert)
+		return
+	}
+
+	wait := rule.GroupWait
+	if wait <= 0 {
+		wait = domain.DefaultGroupWait
+	}
+	g := &notificationGroup{
+		// The group outlives the evaluation that started it
+		ctx:      context.WithoutCancel(ctx),
+		group:    domain.AlertGroup{Labels: labels, Alerts: []*domain.Alert{alert}},
+		channels: rule.Channels,
+	}
+	s.groups[key] = g
+	g.timer = time.AfterFunc(wait, func() { s.flushGroup(key) })
+}
+
+// notificationGroupKey identifies a group by its label names and values
+// and the channels it notifies.
+func notificationGroupKey(labels map[string]string, channels []string) string {
+	pairs := make([]string, 0, len(labels))
+	for k, v := range labels {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	sortedChannels := append([]string(nil), channels...)
+	sort.Strings(sortedChannels)
+	return strings.Join(pairs, ",") + "|" + strings.Join(sortedChannels, ",")
+}
+
+// flushGroup sends a pending group, if it has not been sent yet.
+func (s *AlertService) flushGroup(key string) {
+	s.groupMu.Lock()
+	g, ok := s.groups[key]
+	delete(s.groups, key)
+	s.groupMu.Unlock()
+	if !ok {
+		return
+	}
+
+	g.timer.Stop()
+	if len(g.group.Alerts) > 1 && s.logger != nil {
+		s.logger.Info("Sending grouped notification", "alerts", len(g.group.Alerts), "labels", g.group.Labels)
+	}
+	s.sendNotifications(g.ctx, g.group.Summary(), g.channels)
+}
+
+// flushGroups sends every pending group now.
+func (s *AlertService) flushGroups() {
+	s.groupMu.Lock()
+	keys := make([]string, 0, len(s.groups))
+	for key := range s.groups {
+		keys = append(keys, key)
+	}
+	s.groupMu.Unlock()
+
+	for _, key := range keys {
+		s.flushGroup(key)
+	}
+}
+
+// notificationDigest collects the notifications held during quiet hours for
+// one set of channels.
+type notificationDigest struct {
+	ctx      context.Context
+	channels []string
+	alerts   []*domain.Alert
+}
+
+// add holds an alert's notification. An alert notified more than once
+// appears once, in its latest state.
+func (d *notificationDigest) add(alert *domain.Alert) {
+	for i, a := range d.alerts {
+		if a.Fingerprint == alert.Fingerprint {
+			d.alerts[i] = alert
+			return
+		}
+	}
+	d.alerts = append(d.alerts, alert)
+}
+
+// holdForQuietHours adds a non-critical alert's notification to the digest
+// for its channels if now is within quiet hours, and reports whether it did.
+// The digest is delivered when the quiet period ends.
+func (s *AlertService) holdForQuietHours(ctx context.Context, alert *domain.Alert, channelIDs []string, now time.Time) bool {
+	if s.quietHours == nil || alert.Severity == domain.AlertSeverityCritical || len(channelIDs) == 0 {
+		return false
+	}
+	_, end, ok := s.quietHours.Period(now)
+	if !ok {
+		return false
+	}
+
+	sortedChannels := append([]string(nil), channelIDs...)
+	sort.Strings(sortedChannels)
+	key := strings.Join(sortedChannels, ",")
+
+	s.groupMu.Lock()
+	defer s.groupMu.Unlock()
+	d, ok := s.digests[key]
+	if !ok {
+		d = &notificationDigest{
+			// The digest outlives the evaluation that started it
+			ctx:      context.WithoutCancel(ctx),
+			channels: channelIDs,
+		}
+		s.digests[key] = d
+	}
+	d.add(alert)
+	if s.quietTimer == nil {
+		s.quietTimer = time.AfterFunc(end.Sub(now), func() { s.releaseDigests(time.Now()) })
+	}
+	return true
+}
+
+// releaseDigests delivers the held digests unless now is still within quiet
+// hours, in which case they wait for the end of the current period.
+func (s *AlertService) releaseDigests(now time.Time) {
+	s.groupMu.Lock()
+	if s.quietHours != nil {
+		if _, end, ok := s.quietHours.Period(now); ok {
+			if s.quietTimer != nil {
+				s.quietTimer.Stop()
+			}
+			s.quietTimer = time.AfterFunc(end.Sub(now), func() { s.releaseDigests(time.Now()) })
+			s.groupMu.Unlock()
+			return
+		}
+	}
+	s.groupMu.Unlock()
+	s.flushDigests()
+}
+
+// flushDigests delivers every held digest now.
+func (s *AlertService) flushDigests() {
+	s.groupMu.Lock()
+	digests := s.digests
+	s.digests = make(map[string]*notificationDigest)
+	if s.quietTimer != nil {
+		s.quietTimer.Stop()
+		s.quietTimer = nil
+	}
+	s.groupMu.Unlock()
+
+	for _, d := range digests {
+		if s.logger != nil {
+			s.logger.Info("Sending quiet hours digest", "alerts", len(d.alerts))
+		}
+		s.dispatch(d.ctx, domain.NewDigest(d.alerts), d.channels)
 	}
 }
 
 // CreateRule creates a new alert rule.
 func (s *AlertService) CreateRule(ctx context.Context, rule *domain.AlertRule) error {
+	if err := validateRule(rule); err != nil {
+		return err
+	}
 	if s.ruleRepo == nil {
 		return fmt.Errorf("rule repository not configured")
 	}
@@ -401,6 +1118,9 @@ func (s *AlertService) GetRule(ctx context.Context, id uuid.UUID) (*domain.Alert
 
 // UpdateRule updates an existing alert rule.
 func (s *AlertService) UpdateRule(ctx context.Context, rule *domain.AlertRule) error {
+	if err := validateRule(rule); err != nil {
+		return err
+	}
 	if s.ruleRepo == nil {
 		return fmt.Errorf("rule repository not configured")
 	}
@@ -470,14 +1190,86 @@ func (s *AlertService) AcknowledgeAlert(ctx context.Context, id uuid.UUID, by, c
 	return nil
 }
 
-// CreateSilence creates a new silence.
+// CreateSilence creates a new silence. Recurring silences must have a valid
+// schedule, a positive duration and a known time zone.
 func (s *AlertService) CreateSilence(ctx context.Context, silence *domain.Silence) error {
 	if s.silenceRepo == nil {
 		return fmt.Errorf("silence repository not configured")
 	}
+	if silence.IsRecurring() {
+		if err := validateRecurringSilence(silence); err != nil {
+			return err
+		}
+	}
 	return s.silenceRepo.Create(ctx, silence)
 }
 
+// ListActiveSilences returns the silences muting alerts at now, including
+// recurring silences whose window is open.
+func (s *AlertService) ListActiveSilences(ctx context.Context, now time.Time) ([]*domain.Silence, error) {
+	if s.silenceRepo == nil {
+		return []*domain.Silence{}, nil
+	}
+	candidates, err := s.silenceRepo.ListActive(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+
+	active := make([]*domain.Silence, 0, len(candidates))
+	for _, silence := range candidates {
+		if !silence.IsRecurring() || s.recurringSilenceOpen(silence, now) {
+			active = append(active, silence)
+		}
+	}
+	return active, nil
+}
+
+// recurringSilenceOpen reports whether now falls in one of a recurring
+// silence's windows. A window opens each time the schedule fires and stays
+// open for the silence's duration, so it is open if the schedule fired in
+// (now-duration, now].
+func (s *AlertService) recurringSilenceOpen(silence *domain.Silence, now time.Time) bool {
+	if !silence.Active || !silence.InEffect(now) {
+		return false
+	}
+	schedule, loc, err := parseSilenceSchedule(silence)
+	if err != nil {
+		s.logger.Warn("Ignoring silence with invalid schedule", "silence", silence.ID, "error", err)
+		return false
+	}
+	next := schedule.Next(now.In(loc).Add(-silence.Duration))
+	return !next.IsZero() && !next.After(now)
+}
+
+// validateRecurringSilence checks a recurring silence's schedule.
+func validateRecurringSilence(silence *domain.Silence) error {
+	if _, _, err := parseSilenceSchedule(silence); err != nil {
+		return err
+	}
+	if silence.Duration <= 0 {
+		return fmt.Errorf("recurring silence duration must be positive")
+	}
+	if !silence.EndsAt.IsZero() && !silence.EndsAt.After(silence.StartsAt) {
+		return fmt.Errorf("silence must end after it starts")
+	}
+	return nil
+}
+
+func parseSilenceSchedule(silence *domain.Silence) (*CronSchedule, *time.Location, error) {
+	if strings.HasPrefix(strings.TrimSpace(silence.Schedule), "@every") {
+		return nil, nil, fmt.Errorf("silence schedules must be cron expressions, not @every")
+	}
+	schedule, err := ParseCronSpec(silence.Schedule)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid silence schedule: %w", err)
+	}
+	loc, err := silence.Location()
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid silence time zone: %w", err)
+	}
+	return schedule, loc, nil
+}
+
 // ListSilences lists all silences.
 func (s *AlertService) ListSilences(ctx context.Context) ([]*domain.Silence, error) {
 	if s.silenceRepo == nil {
@@ -486,7 +1278,8 @@ func (s *AlertService) ListSilences(ctx context.Context) ([]*domain.Silence, err
 	return s.silenceRepo.List(ctx)
 }
 
-// DeleteSilence deletes a silence.
+// DeleteSilence deletes a silence. Alerts it suppressed fire at their next
+// evaluation if they are still breaching.
 func (s *AlertService) DeleteSilence(ctx context.Context, id uuid.UUID) error {
 	if s.silenceRepo == nil {
 		return fmt.Errorf("silence repository not configured")
@@ -494,14 +1287,178 @@ func (s *AlertService) DeleteSilence(ctx context.Context, id uuid.UUID) error {
 	return s.silenceRepo.Delete(ctx, id)
 }
 
+// ExpireSilence ends a silence now, keeping it for the record. Alerts it
+// suppressed fire at their next evaluation if they are still breaching.
+func (s *AlertService) ExpireSilence(ctx context.Context, id uuid.UUID) (*domain.Silence, error) {
+	if s.silenceRepo == nil {
+		return nil, fmt.Errorf("silence repository not configured")
+	}
+	silence, err := s.silenceRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if silence == nil {
+		return nil, fmt.Errorf("silence not found: %s", id)
+	}
+
+	now := time.Now()
+	if !silence.EndsAt.IsZero() && !silence.EndsAt.After(now) {
+		return nil, fmt.Errorf("silence %s has already ended", id)
+	}
+	silence.EndsAt = now
+	if err := s.silenceRepo.Update(ctx, silence); err != nil {
+		return nil, fmt.Errorf("failed to expire silence: %w", err)
+	}
+	return silence, nil
+}
+
+// PurgeSilences deletes silences that ended longer than the silence
+// retention before now and returns how many were deleted. Open-ended
+// recurring silences are kept.
+func (s *AlertService) PurgeSilences(ctx context.Context, now time.Time) (int, error) {
+	if s.silenceRepo == nil {
+		return 0, nil
+	}
+	silences, err := s.silenceRepo.List(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list silences: %w", err)
+	}
+
+	cutoff := now.Add(-s.silenceRetention)
+	purged := 0
+	for _, silence := range silences {
+		if silence.EndsAt.IsZero() || !silence.EndsAt.Before(cutoff) {
+			continue
+		}
+		if err := s.silenceRepo.Delete(ctx, silence.ID); err != nil {
+			return purged, fmt.Errorf("failed to delete silence %s: %w", silence.ID, err)
+		}
+		purged++
+	}
+	if purged > 0 && s.logger != nil {
+		s.logger.Info("Purged ended silences", "count", purged)
+	}
+	return purged, nil
+}
+
+// CreateMaintenanceWindow creates a maintenance window.
+func (s *AlertService) CreateMaintenanceWindow(ctx context.Context, window *domain.MaintenanceWindow) error {
+	if s.maintenanceRepo == nil {
+		return fmt.Errorf("maintenance window repository not configured")
+	}
+	if err := window.Validate(); err != nil {
+		return fmt.Errorf("invalid maintenance window: %w", err)
+	}
+	return s.maintenanceRepo.Create(ctx, window)
+}
+
+// GetMaintenanceWindow retrieves a maintenance window by ID or, failing
+// that, name.
+func (s *AlertService) GetMaintenanceWindow(ctx context.Context, ref string) (*domain.MaintenanceWindow, error) {
+	if s.maintenanceRepo == nil {
+		return nil, fmt.Errorf("maintenance window repository not configured")
+	}
+	if id, err := uuid.Parse(ref); err == nil {
+		return s.maintenanceRepo.GetByID(ctx, id)
+	}
+	return s.maintenanceRepo.GetByName(ctx, ref)
+}
+
+// UpdateMaintenanceWindow updates an existing maintenance window.
+func (s *AlertService) UpdateMaintenanceWindow(ctx context.Context, window *domain.MaintenanceWindow) error {
+	if s.maintenanceRepo == nil {
+		return fmt.Errorf("maintenance window repository not configured")
+	}
+	if err := window.Validate(); err != nil {
+		return fmt.Errorf("invalid maintenance window: %w", err)
+	}
+	return s.maintenanceRepo.Update(ctx, window)
+}
+
+// DeleteMaintenanceWindow deletes a maintenance window.
+func (s *AlertService) DeleteMaintenanceWindow(ctx context.Context, id uuid.UUID) error {
+	if s.maintenanceRepo == nil {
+		return fmt.Errorf("maintenance window repository not configured")
+	}
+	return s.maintenanceRepo.Delete(ctx, id)
+}
+
+// ListMaintenanceWindows lists all maintenance windows.
+func (s *AlertService) ListMaintenanceWindows(ctx context.Context) ([]*domain.MaintenanceWindow, error) {
+	if s.maintenanceRepo == nil {
+		return []*domain.MaintenanceWindow{}, nil
+	}
+	return s.maintenanceRepo.List(ctx)
+}
+
+// ListActiveMaintenanceWindows returns the maintenance windows with an
+// occurrence open at now.
+func (s *AlertService) ListActiveMaintenanceWindows(ctx context.Context, now time.Time) ([]*domain.MaintenanceWindow, error) {
+	windows, err := s.ListMaintenanceWindows(ctx)
+	if err != nil {
+		return nil, err
+	}
+	active := make([]*domain.MaintenanceWindow, 0, len(windows))
+	for _, window := range windows {
+		if window.ActiveAt(now) {
+			active = append(active, window)
+		}
+	}
+	return active, nil
+}
+
 // CreateChannel creates a new notification channel.
 func (s *AlertService) CreateChannel(ctx context.Context, channel *domain.NotificationChannel) error {
 	if s.channelRepo == nil {
 		return fmt.Errorf("channel repository not configured")
 	}
+	if err := channel.Validate(); err != nil {
+		return fmt.Errorf("invalid channel: %w", err)
+	}
 	return s.channelRepo.Create(ctx, channel)
 }
 
+// GetChannel retrieves a notification channel by ID or, failing that, name.
+func (s *AlertService) GetChannel(ctx context.Context, ref string) (*domain.NotificationChannel, error) {
+	if s.channelRepo == nil {
+		return nil, fmt.Errorf("channel repository not configured")
+	}
+	if id, err := uuid.Parse(ref); err == nil {
+		return s.channelRepo.GetByID(ctx, id)
+	}
+	return s.channelRepo.GetByName(ctx, ref)
+}
+
+// UpdateChannel updates an existing notification channel.
+func (s *AlertService) UpdateChannel(ctx context.Context, channel *domain.NotificationChannel) error {
+	if s.channelRepo == nil {
+		return fmt.Errorf("channel repository not configured")
+	}
+	if err := channel.Validate(); err != nil {
+		return fmt.Errorf("invalid channel: %w", err)
+	}
+	return s.channelRepo.Update(ctx, channel)
+}
+
+// TestChannel sends a synthetic alert through the channel's notifier and
+// returns the delivery error, if any. Disabled channels are tested too.
+func (s *AlertService) TestChannel(ctx context.Context, channel *domain.NotificationChannel) error {
+	notifier, ok := s.notifier(channel.Type)
+	if !ok {
+		return fmt.Errorf("no notifier registered for channel type %q", channel.Type)
+	}
+
+	rule := domain.NewAlertRule("forge-test-notification", "forge.test", domain.ConditionThresholdAbove, 0, domain.AlertSeverityInfo)
+	rule.Labels["channel"] = channel.Name
+	alert := domain.NewAlert(rule, 1, fmt.Sprintf("Test notification for channel %s", channel.Name))
+	alert.Fire()
+
+	if err := s.deliver(ctx, notifier, alert, s.channelForAlert(channel, alert.Severity)); err != nil {
+		return fmt.Errorf("failed to deliver test notification: %w", err)
+	}
+	return nil
+}
+
 // ListChannels lists all notification channels.
 func (s *AlertService) ListChannels(ctx context.Context) ([]*domain.NotificationChannel, error) {
 	if s.channelRepo == nil {
@@ -3,7 +3,11 @@ package services
 
 import (
 	"context"
+	"fmt"
+	"math"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -305,7 +309,11 @@ func (m *mockSilenceRepository) ListActive(ctx context.Context, now time.Time) (
 	defer m.mu.RUnlock()
 	result := make([]*domain.Silence, 0)
 	for _, s := range m.silences {
-		if now.After(s.StartsAt) && now.Before(s.EndsAt) {
+		if s.IsRecurring() {
+			if s.InEffect(now) {
+				result = append(result, s)
+			}
+		} else if now.After(s.StartsAt) && now.Before(s.EndsAt) {
 			result = append(result, s)
 		}
 	}
@@ -338,6 +346,13 @@ func (m *mockMetricRepositoryForAlert) RecordBatch(ctx context.Context, metrics
 	return nil
 }
 
+func (m *mockMetricRepositoryForAlert) RecordBatchBestEffort(ctx context.Context, metrics []*domain.Metric) (*ports.BatchReport, error) {
+	if err := m.RecordBatch(ctx, metrics); err != nil {
+		return nil, err
+	}
+	return &ports.BatchReport{Written: len(metrics)}, nil
+}
+
 func (m *mockMetricRepositoryForAlert) Query(ctx context.Context, query ports.MetricQuery) (*domain.MetricSeries, error) {
 	return &domain.MetricSeries{Name: query.Name}, nil
 }
@@ -346,10 +361,18 @@ func (m *mockMetricRepositoryForAlert) QueryMultiple(ctx context.Context, query
 	return []*domain.MetricSeries{{Name: query.Name}}, nil
 }
 
+func (m *mockMetricRepositoryForAlert) Export(ctx context.Context, query ports.MetricQuery, fn func(series *domain.MetricSeries, point domain.MetricPoint) error) error {
+	return nil
+}
+
 func (m *mockMetricRepositoryForAlert) QueryWithAggregation(ctx context.Context, query ports.MetricQuery) ([]ports.AggregatedResult, error) {
 	return []ports.AggregatedResult{}, nil
 }
 
+func (m *mockMetricRepositoryForAlert) QueryGrouped(ctx context.Context, query ports.MetricQuery) ([]ports.GroupedResult, error) {
+	return nil, nil
+}
+
 func (m *mockMetricRepositoryForAlert) Aggregate(ctx context.Context, query ports.MetricQuery, resolution string) (*domain.AggregatedMetric, error) {
 	return &domain.AggregatedMetric{}, nil
 }
@@ -370,6 +393,10 @@ func (m *mockMetricRepositoryForAlert) DeleteBefore(ctx context.Context, before
 	return 0, nil
 }
 
+func (m *mockMetricRepositoryForAlert) DeleteSeriesBefore(ctx context.Context, seriesHash uint64, before time.Time) (int64, error) {
+	return 0, nil
+}
+
 func (m *mockMetricRepositoryForAlert) DeleteAggregatedBefore(ctx context.Context, before time.Time, resolution string) (int64, error) {
 	return 0, nil
 }
@@ -378,6 +405,10 @@ func (m *mockMetricRepositoryForAlert) GetDistinctSeries(ctx context.Context) ([
 	return []ports.SeriesInfo{}, nil
 }
 
+func (m *mockMetricRepositoryForAlert) GetRecentSeries(ctx context.Context, limit int) ([]ports.SeriesInfo, error) {
+	return []ports.SeriesInfo{}, nil
+}
+
 func (m *mockMetricRepositoryForAlert) GetStats(ctx context.Context) (*ports.MetricStats, error) {
 	return &ports.MetricStats{}, nil
 }
@@ -438,7 +469,7 @@ func TestAlertService_RegisterNotifier(t *testing.T) {
 	notifier := &mockNotifier{channelType: domain.ChannelSlack}
 	svc.RegisterNotifier(notifier)
 
-	if svc.notifiers[domain.ChannelSlack] != notifier {
+	if got, ok := svc.notifier(domain.ChannelSlack); !ok || got != notifier {
 		t.Error("notifier not registered correctly")
 	}
 }
@@ -506,3 +537,1360 @@ func TestAlertService_EvaluateAll_NoRuleRepo(t *testing.T) {
 	svc.EvaluateAll(context.Background())
 }
 
+// countingNotifier is a notifier that is safe to call from concurrent sends.
+type countingNotifier struct {
+	channelType domain.NotificationChannelType
+	sent        *atomic.Int64
+}
+
+func (n *countingNotifier) Send(ctx context.Context, alert *domain.Alert, channel *domain.NotificationChannel) error {
+	n.sent.Add(1)
+	return nil
+}
+
+func (n *countingNotifier) Type() domain.NotificationChannelType {
+	return n.channelType
+}
+
+func TestAlertService_RegisterNotifierDuringEvaluation(t *testing.T) {
+	ctx := context.Background()
+	channelRepo := newMockNotificationChannelRepository()
+	channel := domain.NewNotificationChannel("ops", domain.ChannelWebhook, nil)
+	if err := channelRepo.Create(ctx, channel); err != nil {
+		t.Fatalf("failed to create channel: %v", err)
+	}
+
+	svc := NewAlertService(nil, newMockAlertRepository(), channelRepo, nil, nil, &mockAlertLogger{})
+	sent := &atomic.Int64{}
+	svc.RegisterNotifier(&countingNotifier{channelType: domain.ChannelWebhook, sent: sent})
+
+	const workers = 8
+	const iterations = 200
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		// Each evaluator owns its rule so only the shared caches are contended
+		rule := domain.NewAlertRule("stress", "cpu.usage", domain.ConditionThresholdAbove, 90, domain.AlertSeverityWarning)
+		rule.Channels = []string{channel.ID.String()}
+		rule.Duration = 0
+
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				if err := svc.processEvaluation(ctx, rule, i%2 == 0, 95); err != nil {
+					t.Errorf("processEvaluation failed: %v", err)
+					return
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				svc.RegisterNotifier(&countingNotifier{channelType: domain.ChannelWebhook, sent: sent})
+				svc.RegisterNotifier(&countingNotifier{channelType: domain.ChannelSlack, sent: sent})
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Every evaluator fires and resolves iterations/2 times, notifying
+	// both; sends are asynchronous
+	want := int64(workers * iterations)
+	deadline := time.Now().Add(5 * time.Second)
+	for sent.Load() < want && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := sent.Load(); got != want {
+		t.Errorf("expected %d notifications, got %d", want, got)
+	}
+
+	svc.mu.RLock()
+	active := len(svc.activeAlerts)
+	svc.mu.RUnlock()
+	if active != 0 {
+		t.Errorf("expected all alerts to be resolved, got %d active", active)
+	}
+}
+
+func TestAlertService_CounterResetIsNotAnomaly(t *testing.T) {
+	svc := NewAlertService(nil, nil, nil, nil, nil, &mockAlertLogger{})
+	series := resettingCounter(30)
+
+	// Make the reset the latest sample
+	series.Points = series.Points[:21]
+	if anomaly, z := svc.detectAnomaly(series, 2); anomaly {
+		t.Errorf("expected counter reset not to be an anomaly, z=%v", z)
+	}
+	if rate := svc.calculateRateOfChange(series, time.Hour); rate < 0 {
+		t.Errorf("expected non-negative counter rate, got %v", rate)
+	}
+
+	series.Type = domain.MetricTypeGauge
+	if anomaly, _ := svc.detectAnomaly(series, 2); !anomaly {
+		t.Error("expected the drop to be an anomaly for a gauge")
+	}
+}
+
+// shiftedSeries returns a gauge that alternates around 10 for 50 minutes and
+// then holds at 12 for another 15.
+func shiftedSeries() *domain.MetricSeries {
+	base := time.Now().Add(-65 * time.Minute)
+	series := &domain.MetricSeries{Name: "queue.depth", Type: domain.MetricTypeGauge}
+	for i := 0; i < 65; i++ {
+		value := 9.5
+		if i%2 == 1 {
+			value = 10.5
+		}
+		if i >= 50 {
+			value = 12
+		}
+		series.Points = append(series.Points, domain.MetricPoint{Value: value, Timestamp: base.Add(time.Duration(i) * time.Minute)})
+	}
+	return series
+}
+
+func TestAlertService_RollingAnomalyFlagsShift(t *testing.T) {
+	svc := NewAlertService(nil, nil, nil, nil, nil, &mockAlertLogger{})
+	series := shiftedSeries()
+
+	rule := &domain.AlertRule{Condition: domain.ConditionAnomalyDetection, AnomalyStdDev: 3}
+	if anomaly, z := svc.evaluateCondition(rule, series); anomaly {
+		t.Errorf("expected the whole-series baseline to absorb the shift, z=%v", z)
+	}
+
+	rule.AnomalyWindow = 15 * time.Minute
+	anomaly, z := svc.evaluateCondition(rule, series)
+	if !anomaly {
+		t.Fatalf("expected the rolling baseline to flag the shift, z=%v", z)
+	}
+	if z != 4 {
+		t.Errorf("expected z=4 against the pre-shift baseline, got %v", z)
+	}
+}
+
+func TestAlertService_RollingAnomalyNeedsBaseline(t *testing.T) {
+	svc := NewAlertService(nil, nil, nil, nil, nil, &mockAlertLogger{})
+	series := shiftedSeries()
+
+	// Only 5 points fall in the reference window before the shift
+	if anomaly, _ := svc.detectRollingAnomaly(series, 3, 15*time.Minute, 5*time.Minute); anomaly {
+		t.Error("expected no anomaly without enough baseline points")
+	}
+
+	// A window covering the whole series leaves no baseline at all
+	if anomaly, _ := svc.detectRollingAnomaly(series, 3, 2*time.Hour, time.Hour); anomaly {
+		t.Error("expected no anomaly with an empty baseline")
+	}
+}
+
+func TestAlertService_AnomalyResolveHoldDown(t *testing.T) {
+	ctx := context.Background()
+	alertRepo := newMockAlertRepository()
+	svc := NewAlertService(nil, alertRepo, nil, nil, nil, &mockAlertLogger{})
+
+	rule := domain.NewAlertRule("queue shift", "queue.depth", domain.ConditionAnomalyDetection, 0, domain.AlertSeverityWarning)
+	rule.AnomalyStdDev = 3
+	rule.AnomalyWindow = 15 * time.Minute
+	rule.ResolveAfter = 10 * time.Minute
+	rule.Duration = 0
+	fingerprint := rule.ID.String() + ":" + rule.MetricName
+
+	evaluate := func(series *domain.MetricSeries) {
+		t.Helper()
+		firing, value := svc.evaluateCondition(rule, series)
+		if err := svc.processEvaluation(ctx, rule, firing, value); err != nil {
+			t.Fatalf("processEvaluation failed: %v", err)
+		}
+	}
+	active := func() *domain.Alert {
+		svc.mu.RLock()
+		defer svc.mu.RUnlock()
+		return svc.activeAlerts[fingerprint]
+	}
+
+	evaluate(shiftedSeries())
+	alert := active()
+	if alert == nil || alert.State != domain.AlertStateFiring {
+		t.Fatal("expected the shift to fire an alert")
+	}
+
+	// The anomaly clears once the series settles at its new level
+	settled := shiftedSeries()
+	settled.Points = settled.Points[:50]
+	evaluate(settled)
+	if active() == nil || alert.State != domain.AlertStateFiring || alert.ClearSince == nil {
+		t.Fatalf("expected the alert to keep firing during the hold-down, got state %s", alert.State)
+	}
+
+	// Firing again restarts the hold-down
+	evaluate(shiftedSeries())
+	if alert.ClearSince != nil {
+		t.Error("expected a new anomaly to reset the hold-down")
+	}
+	evaluate(settled)
+	clearSince := time.Now().Add(-9 * time.Minute)
+	alert.ClearSince = &clearSince
+	evaluate(settled)
+	if active() == nil {
+		t.Fatal("expected the alert to stay active before the hold-down elapses")
+	}
+
+	clearSince = time.Now().Add(-rule.ResolveAfter)
+	evaluate(settled)
+	if active() != nil || alert.State != domain.AlertStateResolved {
+		t.Errorf("expected the alert to resolve after the hold-down, got state %s", alert.State)
+	}
+}
+
+func TestAlertService_PendingUntilDuration(t *testing.T) {
+	ctx := context.Background()
+	alertRepo := newMockAlertRepository()
+	svc := NewAlertService(nil, alertRepo, nil, nil, nil, &mockAlertLogger{})
+
+	rule := domain.NewAlertRule("cpu", "cpu.usage", domain.ConditionThresholdAbove, 90, domain.AlertSeverityWarning)
+	rule.Duration = 5 * time.Minute
+	rule.ForPercent = 100
+	fingerprint := rule.ID.String() + ":" + rule.MetricName
+
+	// series returns one point a minute for the last ten minutes, with
+	// the given values at the end
+	series := func(tail ...float64) *domain.MetricSeries {
+		now := time.Now()
+		s := &domain.MetricSeries{Name: rule.MetricName}
+		for i := 0; i < 10; i++ {
+			value := 50.0
+			if j := i - (10 - len(tail)); j >= 0 {
+				value = tail[j]
+			}
+			s.Points = append(s.Points, domain.MetricPoint{Value: value, Timestamp: now.Add(time.Duration(i-9) * time.Minute)})
+		}
+		return s
+	}
+	evaluate := func(series *domain.MetricSeries) {
+		t.Helper()
+		firing, value := svc.evaluateCondition(rule, series)
+		if err := svc.processEvaluation(ctx, rule, firing, value); err != nil {
+			t.Fatalf("processEvaluation failed: %v", err)
+		}
+	}
+	active := func() *domain.Alert {
+		svc.mu.RLock()
+		defer svc.mu.RUnlock()
+		return svc.activeAlerts[fingerprint]
+	}
+
+	// A brief spike breaches only part of the window
+	evaluate(series(95))
+	if active() != nil {
+		t.Fatal("expected a single noisy sample not to start an alert")
+	}
+
+	// A rule that looks at the latest point goes pending, then the series
+	// recovers before the duration elapses and the alert is discarded
+	rule.ForPercent = 0
+	evaluate(series(95))
+	alert := active()
+	if alert == nil || alert.State != domain.AlertStatePending {
+		t.Fatal("expected the first breach to leave the alert pending")
+	}
+	evaluate(series(95, 60))
+	if active() != nil {
+		t.Fatal("expected the recovered alert to be dropped")
+	}
+	if len(alertRepo.alerts) != 0 {
+		t.Errorf("expected the pending alert to be removed from the repository, got %d alerts", len(alertRepo.alerts))
+	}
+
+	// A sustained breach fires once the duration has elapsed
+	evaluate(series(95))
+	alert = active()
+	evaluate(series(95, 96))
+	if alert.State != domain.AlertStatePending {
+		t.Fatalf("expected the alert to stay pending within the duration, got %s", alert.State)
+	}
+	alert.StartsAt = time.Now().Add(-rule.Duration)
+	evaluate(series(95, 96, 97))
+	if alert.State != domain.AlertStateFiring {
+		t.Errorf("expected the alert to fire after the duration, got %s", alert.State)
+	}
+}
+
+func TestAlertService_EvaluateThresholdWindow(t *testing.T) {
+	svc := NewAlertService(nil, nil, nil, nil, nil, &mockAlertLogger{})
+	now := time.Now()
+	var points []domain.MetricPoint
+	// 200 and 80 fall outside the five minute window ending at the latest point
+	for i, v := range []float64{200, 80, 100, 95, 85} {
+		points = append(points, domain.MetricPoint{Value: v, Timestamp: now.Add(time.Duration(i-4) * 2 * time.Minute)})
+	}
+
+	tests := []struct {
+		name      string
+		agg       domain.WindowAggregation
+		percent   float64
+		wantFire  bool
+		wantValue float64
+	}{
+		{"last", "", 0, false, 85},
+		{"avg", domain.WindowAvg, 0, true, 93.33333333333333},
+		{"min", domain.WindowMin, 0, false, 85},
+		{"max", domain.WindowMax, 0, true, 100},
+		{"half of the points", "", 50, true, 85},
+		{"all of the points", domain.WindowMax, 100, false, 100},
+	}
+	for _, tt := range tests {
+		rule := domain.NewAlertRule("cpu", "cpu.usage", domain.ConditionThresholdAbove, 90, domain.AlertSeverityWarning)
+		rule.Duration = 5 * time.Minute
+		rule.ForAggregation = tt.agg
+		rule.ForPercent = tt.percent
+		firing, value := svc.evaluateCondition(rule, &domain.MetricSeries{Points: points})
+		if firing != tt.wantFire {
+			t.Errorf("%s: expected firing=%v, got %v", tt.name, tt.wantFire, firing)
+		}
+		if math.Abs(value-tt.wantValue) > 1e-9 {
+			t.Errorf("%s: expected value %v, got %v", tt.name, tt.wantValue, value)
+		}
+	}
+
+	rule := domain.NewAlertRule("cpu", "cpu.usage", domain.ConditionThresholdAbove, 90, domain.AlertSeverityWarning)
+	rule.ForAggregation = "median"
+	if err := rule.ValidateWindow(); err == nil {
+		t.Error("expected an unknown window aggregation to be rejected")
+	}
+	rule.ForAggregation = domain.WindowAvg
+	rule.ForPercent = 120
+	if err := rule.ValidateWindow(); err == nil {
+		t.Error("expected a percentage over 100 to be rejected")
+	}
+}
+
+func TestAlertRule_ResolveHoldDown(t *testing.T) {
+	rule := domain.NewAlertRule("r", "m", domain.ConditionRateOfChange, 1, domain.AlertSeverityInfo)
+	if got := rule.ResolveHoldDown(); got != domain.DefaultResolveHoldDown {
+		t.Errorf("expected the default hold-down for rate of change, got %v", got)
+	}
+
+	// Threshold conditions resolve as soon as they clear
+	ctx := context.Background()
+	svc := NewAlertService(nil, newMockAlertRepository(), nil, nil, nil, &mockAlertLogger{})
+	rule = domain.NewAlertRule("cpu", "cpu.usage", domain.ConditionThresholdAbove, 90, domain.AlertSeverityWarning)
+	rule.Duration = 0
+	if got := rule.ResolveHoldDown(); got != 0 {
+		t.Errorf("expected no hold-down for a threshold rule, got %v", got)
+	}
+	for _, firing := range []bool{true, false} {
+		if err := svc.processEvaluation(ctx, rule, firing, 95); err != nil {
+			t.Fatalf("processEvaluation failed: %v", err)
+		}
+	}
+	if len(svc.activeAlerts) != 0 {
+		t.Error("expected the threshold alert to resolve immediately")
+	}
+
+	// unless a cool-down is configured
+	rule.ResolveAfter = time.Hour
+	if got := rule.ResolveHoldDown(); got != time.Hour {
+		t.Errorf("expected the configured cool-down, got %v", got)
+	}
+	for _, firing := range []bool{true, false} {
+		if err := svc.processEvaluation(ctx, rule, firing, 95); err != nil {
+			t.Fatalf("processEvaluation failed: %v", err)
+		}
+	}
+	if len(svc.activeAlerts) != 1 {
+		t.Error("expected the threshold alert to stay firing during the cool-down")
+	}
+}
+
+// templateNotifier reports the template each delivery would render.
+type templateNotifier struct {
+	templates chan string
+}
+
+func (n *templateNotifier) Send(ctx context.Context, alert *domain.Alert, channel *domain.NotificationChannel) error {
+	n.templates <- channel.TemplateFor(alert.Severity)
+	return nil
+}
+
+func (n *templateNotifier) Type() domain.NotificationChannelType {
+	return domain.ChannelWebhook
+}
+
+func TestAlertService_SeverityTemplates(t *testing.T) {
+	ctx := context.Background()
+	channelRepo := newMockNotificationChannelRepository()
+	channel := domain.NewNotificationChannel("ops", domain.ChannelWebhook, map[string]string{
+		"template":          `{"text": "generic"}`,
+		"template.critical": `{"text": "PAGE"}`,
+	})
+	if err := channelRepo.Create(ctx, channel); err != nil {
+		t.Fatalf("failed to create channel: %v", err)
+	}
+
+	svc := NewAlertService(nil, newMockAlertRepository(), channelRepo, nil, nil, &mockAlertLogger{})
+	notifier := &templateNotifier{templates: make(chan string, 1)}
+	svc.RegisterNotifier(notifier)
+	svc.SetSeverityTemplate(domain.AlertSeverityCritical, `{"text": "default critical"}`)
+	svc.SetSeverityTemplate(domain.AlertSeverityInfo, `{"text": "fyi"}`)
+
+	tests := []struct {
+		severity domain.AlertSeverity
+		want     string
+	}{
+		// The channel's own severity template wins over the service default
+		{domain.AlertSeverityCritical, `{"text": "PAGE"}`},
+		{domain.AlertSeverityInfo, `{"text": "fyi"}`},
+		{domain.AlertSeverityWarning, `{"text": "generic"}`},
+	}
+	for _, tt := range tests {
+		rule := domain.NewAlertRule("disk-"+string(tt.severity), "disk.used", domain.ConditionThresholdAbove, 90, tt.severity)
+		rule.Channels = []string{channel.ID.String()}
+		rule.Duration = 0
+		if err := svc.processEvaluation(ctx, rule, true, 95); err != nil {
+			t.Fatalf("processEvaluation failed: %v", err)
+		}
+		select {
+		case got := <-notifier.templates:
+			if got != tt.want {
+				t.Errorf("%s alert: expected template %s, got %s", tt.severity, tt.want, got)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("%s alert: no notification sent", tt.severity)
+		}
+	}
+
+	if _, ok := channel.Config["template.info"]; ok {
+		t.Error("service default should not be written into the stored channel")
+	}
+}
+
+func TestAlertService_RecurringSilence(t *testing.T) {
+	silenceRepo := newMockSilenceRepository()
+	svc := NewAlertService(nil, nil, nil, silenceRepo, nil, &NopLogger{})
+	ctx := context.Background()
+
+	// Sunday 02:00-04:00 UTC maintenance window
+	silence := domain.NewSilence(map[string]string{"team": "db"}, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Time{}, "admin", "backups")
+	silence.Schedule = "0 2 * * 0"
+	silence.Duration = 2 * time.Hour
+	if err := svc.CreateSilence(ctx, silence); err != nil {
+		t.Fatalf("CreateSilence failed: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		now    time.Time
+		active bool
+	}{
+		{"window opens", time.Date(2024, 3, 3, 2, 0, 0, 0, time.UTC), true},
+		{"inside window", time.Date(2024, 3, 3, 3, 30, 0, 0, time.UTC), true},
+		{"window closed", time.Date(2024, 3, 3, 4, 0, 0, 0, time.UTC), false},
+		{"before window", time.Date(2024, 3, 3, 1, 59, 0, 0, time.UTC), false},
+		{"wrong day", time.Date(2024, 3, 4, 3, 0, 0, 0, time.UTC), false},
+		{"before schedule starts", time.Date(2023, 12, 31, 3, 0, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		active, err := svc.ListActiveSilences(ctx, tt.now)
+		if err != nil {
+			t.Fatalf("%s: ListActiveSilences failed: %v", tt.name, err)
+		}
+		if got := len(active) == 1; got != tt.active {
+			t.Errorf("%s: active = %v, want %v", tt.name, got, tt.active)
+		}
+	}
+
+	// Windows are evaluated in the silence's time zone
+	silence.Timezone = "America/New_York"
+	if active, _ := svc.ListActiveSilences(ctx, time.Date(2024, 3, 3, 3, 0, 0, 0, time.UTC)); len(active) != 0 {
+		t.Error("expected window to follow the silence's time zone")
+	}
+	if active, _ := svc.ListActiveSilences(ctx, time.Date(2024, 3, 3, 8, 0, 0, 0, time.UTC)); len(active) != 1 {
+		t.Error("expected window at 02:00 New York time to be active")
+	}
+}
+
+func TestAlertService_RecurringSilenceMutesAlerts(t *testing.T) {
+	silenceRepo := newMockSilenceRepository()
+	svc := NewAlertService(nil, nil, nil, silenceRepo, nil, &NopLogger{})
+	ctx := context.Background()
+	alert := &domain.Alert{Labels: map[string]string{"team": "db"}}
+
+	// A window open right now and one that never opens today
+	now := time.Now().UTC()
+	open := domain.NewSilence(map[string]string{"team": "db"}, now.Add(-time.Hour), time.Time{}, "admin", "")
+	open.Schedule = fmt.Sprintf("%d %d * * *", now.Minute(), now.Hour())
+	open.Duration = time.Hour
+	if err := svc.CreateSilence(ctx, open); err != nil {
+		t.Fatalf("CreateSilence failed: %v", err)
+	}
+	if svc.silencedBy(ctx, alert) == "" {
+		t.Error("expected alert to be silenced inside the maintenance window")
+	}
+
+	open.Schedule = fmt.Sprintf("%d %d * * *", now.Minute(), (now.Hour()+12)%24)
+	if svc.silencedBy(ctx, alert) != "" {
+		t.Error("expected alert not to be silenced outside the maintenance window")
+	}
+
+	// One-shot silences keep working alongside recurring ones
+	oneShot := domain.NewSilence(map[string]string{"team": "db"}, now.Add(-time.Minute), now.Add(time.Hour), "admin", "")
+	if err := svc.CreateSilence(ctx, oneShot); err != nil {
+		t.Fatalf("CreateSilence failed: %v", err)
+	}
+	if svc.silencedBy(ctx, alert) == "" {
+		t.Error("expected one-shot silence to still apply")
+	}
+}
+
+func TestAlertService_CreateSilenceValidatesSchedule(t *testing.T) {
+	svc := NewAlertService(nil, nil, nil, newMockSilenceRepository(), nil, &NopLogger{})
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		schedule string
+		duration time.Duration
+		timezone string
+	}{
+		{"bad cron", "61 * * * *", time.Hour, ""},
+		{"every", "@every 1h", time.Hour, ""},
+		{"no duration", "0 2 * * 0", 0, ""},
+		{"bad timezone", "0 2 * * 0", time.Hour, "Mars/Olympus"},
+	}
+	for _, tt := range tests {
+		silence := domain.NewSilence(map[string]string{"team": "db"}, time.Now(), time.Time{}, "admin", "")
+		silence.Schedule = tt.schedule
+		silence.Duration = tt.duration
+		silence.Timezone = tt.timezone
+		if err := svc.CreateSilence(ctx, silence); err == nil {
+			t.Errorf("%s: expected CreateSilence to fail", tt.name)
+		}
+	}
+}
+
+// recordingNotifier keeps every alert it is asked to send.
+type recordingNotifier struct {
+	mu     sync.Mutex
+	alerts []*domain.Alert
+}
+
+func (n *recordingNotifier) Send(ctx context.Context, alert *domain.Alert, channel *domain.NotificationChannel) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.alerts = append(n.alerts, alert)
+	return nil
+}
+
+func (n *recordingNotifier) Type() domain.NotificationChannelType {
+	return domain.ChannelWebhook
+}
+
+func (n *recordingNotifier) waitFor(count int) []*domain.Alert {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		n.mu.Lock()
+		got := len(n.alerts)
+		n.mu.Unlock()
+		if got >= count {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// Give stray extra notifications a chance to show up
+	time.Sleep(50 * time.Millisecond)
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]*domain.Alert(nil), n.alerts...)
+}
+
+func TestAlertService_GroupedNotifications(t *testing.T) {
+	ctx := context.Background()
+	channelRepo := newMockNotificationChannelRepository()
+	channel := domain.NewNotificationChannel("ops", domain.ChannelWebhook, nil)
+	if err := channelRepo.Create(ctx, channel); err != nil {
+		t.Fatalf("failed to create channel: %v", err)
+	}
+	svc := NewAlertService(nil, newMockAlertRepository(), channelRepo, nil, nil, &mockAlertLogger{})
+	notifier := &recordingNotifier{}
+	svc.RegisterNotifier(notifier)
+
+	newRule := func(name, service string, severity domain.AlertSeverity) *domain.AlertRule {
+		rule := domain.NewAlertRule(name, "cpu.usage", domain.ConditionThresholdAbove, 90, severity)
+		rule.Labels["service"] = service
+		rule.GroupBy = []string{"service"}
+		rule.GroupWait = 100 * time.Millisecond
+		rule.Channels = []string{channel.ID.String()}
+		rule.Duration = 0
+		return rule
+	}
+	rules := []*domain.AlertRule{
+		newRule("high-cpu", "api", domain.AlertSeverityWarning),
+		newRule("high-latency", "api", domain.AlertSeverityCritical),
+		newRule("error-rate", "api", domain.AlertSeverityWarning),
+		newRule("disk-full", "db", domain.AlertSeverityWarning),
+	}
+	for _, rule := range rules {
+		if err := svc.processEvaluation(ctx, rule, true, 95); err != nil {
+			t.Fatalf("processEvaluation failed: %v", err)
+		}
+	}
+
+	sent := notifier.waitFor(2)
+	if len(sent) != 2 {
+		t.Fatalf("expected one notification per group, got %d", len(sent))
+	}
+	byService := map[string]*domain.Alert{}
+	for _, a := range sent {
+		byService[a.Labels["service"]] = a
+	}
+
+	api := byService["api"]
+	if api == nil || api.Annotations["group_size"] != "3" {
+		t.Fatalf("expected a combined notification for the api group, got %+v", api)
+	}
+	if api.Severity != domain.AlertSeverityCritical {
+		t.Errorf("expected the group to carry its highest severity, got %s", api.Severity)
+	}
+	for _, name := range []string{"high-cpu", "high-latency", "error-rate"} {
+		if !strings.Contains(api.Message, name) {
+			t.Errorf("expected grouped message to list %s, got %q", name, api.Message)
+		}
+	}
+	if db := byService["db"]; db == nil || db.RuleName != "disk-full" {
+		t.Errorf("expected the lone db alert to be sent as is, got %+v", db)
+	}
+}
+
+func TestAlertService_StopFlushesGroups(t *testing.T) {
+	ctx := context.Background()
+	channelRepo := newMockNotificationChannelRepository()
+	channel := domain.NewNotificationChannel("ops", domain.ChannelWebhook, nil)
+	if err := channelRepo.Create(ctx, channel); err != nil {
+		t.Fatalf("failed to create channel: %v", err)
+	}
+	svc := NewAlertService(nil, newMockAlertRepository(), channelRepo, nil, nil, &mockAlertLogger{})
+	notifier := &recordingNotifier{}
+	svc.RegisterNotifier(notifier)
+
+	for _, name := range []string{"a", "b"} {
+		rule := domain.NewAlertRule(name, "cpu.usage", domain.ConditionThresholdAbove, 90, domain.AlertSeverityWarning)
+		rule.GroupBy = []string{"service"}
+		rule.GroupWait = time.Hour
+		rule.Channels = []string{channel.ID.String()}
+		rule.Duration = 0
+		if err := svc.processEvaluation(ctx, rule, true, 95); err != nil {
+			t.Fatalf("processEvaluation failed: %v", err)
+		}
+	}
+
+	svc.Start(ctx, time.Hour)
+	svc.Stop()
+	if sent := notifier.waitFor(1); len(sent) != 1 || sent[0].Annotations["group_size"] != "2" {
+		t.Errorf("expected Stop to send the pending group, got %d notifications", len(sent))
+	}
+}
+
+func TestAlertService_ChannelValidation(t *testing.T) {
+	channelRepo := newMockNotificationChannelRepository()
+	svc := NewAlertService(nil, nil, channelRepo, nil, nil, &mockAlertLogger{})
+	ctx := context.Background()
+
+	if err := svc.CreateChannel(ctx, domain.NewNotificationChannel("oncall", domain.ChannelSlack, nil)); err == nil {
+		t.Error("expected slack channel without a webhook URL to be rejected")
+	}
+	if len(channelRepo.channels) != 0 {
+		t.Errorf("expected rejected channel not to be stored, got %d", len(channelRepo.channels))
+	}
+
+	channel := domain.NewNotificationChannel("oncall", domain.ChannelSlack, map[string]string{"url": "https://hooks.slack.com/services/x"})
+	if err := svc.CreateChannel(ctx, channel); err != nil {
+		t.Fatalf("CreateChannel failed: %v", err)
+	}
+	got, err := svc.GetChannel(ctx, "oncall")
+	if err != nil || got == nil || got.ID != channel.ID {
+		t.Fatalf("expected lookup by name to find the channel, got %v, %v", got, err)
+	}
+	if got, _ := svc.GetChannel(ctx, channel.ID.String()); got == nil || got.Name != "oncall" {
+		t.Errorf("expected lookup by ID to find the channel, got %v", got)
+	}
+
+	update := *channel
+	update.Config = map[string]string{"url": "not a url"}
+	if err := svc.UpdateChannel(ctx, &update); err == nil {
+		t.Error("expected update with an invalid URL to be rejected")
+	}
+}
+
+func TestAlertService_TestChannel(t *testing.T) {
+	svc := NewAlertService(nil, nil, nil, nil, nil, &mockAlertLogger{})
+	ctx := context.Background()
+	channel := domain.NewNotificationChannel("oncall", domain.ChannelSlack, map[string]string{"webhook_url": "https://hooks.slack.com/services/x"})
+
+	if err := svc.TestChannel(ctx, channel); err == nil {
+		t.Error("expected an error without a registered notifier")
+	}
+
+	notifier := &mockNotifier{channelType: domain.ChannelSlack}
+	svc.RegisterNotifier(notifier)
+	if err := svc.TestChannel(ctx, channel); err != nil {
+		t.Fatalf("TestChannel failed: %v", err)
+	}
+	if !notifier.sendCalled {
+		t.Error("expected the notifier to be called synchronously")
+	}
+
+	notifier.sendErr = fmt.Errorf("webhook returned status 404")
+	err := svc.TestChannel(ctx, channel)
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("expected delivery error to be reported, got %v", err)
+	}
+}
+
+func TestAlertService_NotificationMetrics(t *testing.T) {
+	metricRepo := newMockMetricRepositoryForAlert()
+	svc := NewAlertService(nil, nil, nil, nil, metricRepo, &mockAlertLogger{})
+	metricSvc := NewMetricService(metricRepo, &mockAlertLogger{}, DefaultMetricServiceConfig())
+	svc.SetMetricService(metricSvc)
+	notifier := &mockNotifier{channelType: domain.ChannelWebhook}
+	svc.RegisterNotifier(notifier)
+	channel := domain.NewNotificationChannel("ops", domain.ChannelWebhook, map[string]string{"url": "http://example.com"})
+
+	_ = svc.TestChannel(context.Background(), channel)
+	notifier.sendErr = fmt.Errorf("webhook returned error: 500")
+	_ = svc.TestChannel(context.Background(), channel)
+	metricSvc.flush(context.Background())
+
+	metricRepo.mu.Lock()
+	defer metricRepo.mu.Unlock()
+	if len(metricRepo.metrics) != 2 {
+		t.Fatalf("expected 2 notification metrics, got %d", len(metricRepo.metrics))
+	}
+	sent, failed := metricRepo.metrics[0], metricRepo.metrics[1]
+	if sent.Name != "forge.notifications.sent" || failed.Name != "forge.notifications.failed" {
+		t.Errorf("unexpected metric names %q, %q", sent.Name, failed.Name)
+	}
+	for _, m := range metricRepo.metrics {
+		if m.Type != domain.MetricTypeCounter || m.Value != 1 || m.Tags["channel_type"] != "webhook" || m.Tags["channel"] != "ops" {
+			t.Errorf("unexpected metric %+v", m)
+		}
+	}
+}
+
+func TestAlertService_RepeatAndResolveNotifications(t *testing.T) {
+	ctx := context.Background()
+	channelRepo := newMockNotificationChannelRepository()
+	channel := domain.NewNotificationChannel("ops", domain.ChannelWebhook, nil)
+	if err := channelRepo.Create(ctx, channel); err != nil {
+		t.Fatalf("failed to create channel: %v", err)
+	}
+	alertRepo := newMockAlertRepository()
+	svc := NewAlertService(nil, alertRepo, channelRepo, nil, nil, &mockAlertLogger{})
+	notifier := &recordingNotifier{}
+	svc.RegisterNotifier(notifier)
+
+	rule := domain.NewAlertRule("high-cpu", "cpu.usage", domain.ConditionThresholdAbove, 90, domain.AlertSeverityWarning)
+	rule.Channels = []string{channel.ID.String()}
+	rule.Duration = 0
+	rule.RepeatInterval = time.Hour
+	evaluate := func(firing bool) {
+		t.Helper()
+		if err := svc.processEvaluation(ctx, rule, firing, 95); err != nil {
+			t.Fatalf("processEvaluation failed: %v", err)
+		}
+	}
+
+	// Staying firing within the repeat interval notifies once
+	for i := 0; i < 3; i++ {
+		evaluate(true)
+	}
+	if sent := notifier.waitFor(1); len(sent) != 1 {
+		t.Fatalf("expected one notification while firing, got %d", len(sent))
+	}
+	alert := svc.activeAlerts[rule.ID.String()+":"+rule.MetricName]
+	if alert.NotificationCount != 1 || alert.LastNotifiedAt == nil {
+		t.Fatalf("expected the notification to be recorded, got %+v", alert)
+	}
+
+	// Once the interval has passed a reminder goes out
+	earlier := time.Now().Add(-2 * time.Hour)
+	alert.LastNotifiedAt = &earlier
+	evaluate(true)
+	if sent := notifier.waitFor(2); len(sent) != 2 {
+		t.Fatalf("expected a repeat notification, got %d", len(sent))
+	}
+
+	// and one more when it resolves
+	evaluate(false)
+	sent := notifier.waitFor(3)
+	if len(sent) != 3 || sent[2].State != domain.AlertStateResolved {
+		t.Fatalf("expected a resolve notification, got %d", len(sent))
+	}
+	stored, _ := alertRepo.GetByID(ctx, alert.ID)
+	if stored == nil || stored.NotificationCount != 3 || stored.State != domain.AlertStateResolved {
+		t.Errorf("expected bookkeeping to be persisted, got %+v", stored)
+	}
+
+	// Resolve notifications can be turned off
+	rule.NotifyOnResolve = false
+	evaluate(true)
+	evaluate(false)
+	if sent := notifier.waitFor(4); len(sent) != 4 {
+		t.Errorf("expected only the firing notification without notify-on-resolve, got %d", len(sent))
+	}
+}
+
+func TestAlertService_ActiveAlertsSurviveRestart(t *testing.T) {
+	ctx := context.Background()
+	alertRepo := newMockAlertRepository()
+	firingRule := domain.NewAlertRule("high-cpu", "cpu.usage", domain.ConditionThresholdAbove, 90, domain.AlertSeverityWarning)
+	firingRule.Duration = 0
+	ackRule := domain.NewAlertRule("high-mem", "mem.usage", domain.ConditionThresholdAbove, 90, domain.AlertSeverityWarning)
+	ackRule.Duration = 0
+
+	before := NewAlertService(nil, alertRepo, nil, nil, nil, &mockAlertLogger{})
+	for _, rule := range []*domain.AlertRule{firingRule, ackRule} {
+		if err := before.processEvaluation(ctx, rule, true, 95); err != nil {
+			t.Fatalf("processEvaluation failed: %v", err)
+		}
+	}
+	ackAlert := before.activeAlerts[ackRule.ID.String()+":"+ackRule.MetricName]
+	if err := before.AcknowledgeAlert(ctx, ackAlert.ID, "oncall", "looking"); err != nil {
+		t.Fatalf("AcknowledgeAlert failed: %v", err)
+	}
+
+	// A new service over the same repository stands in for a restart
+	after := NewAlertService(nil, alertRepo, nil, nil, nil, &mockAlertLogger{})
+	loaded, err := after.LoadActiveAlerts(ctx)
+	if err != nil {
+		t.Fatalf("LoadActiveAlerts failed: %v", err)
+	}
+	if loaded != 2 {
+		t.Errorf("expected 2 alerts loaded, got %d", loaded)
+	}
+	for _, rule := range []*domain.AlertRule{firingRule, ackRule} {
+		if err := after.processEvaluation(ctx, rule, true, 97); err != nil {
+			t.Fatalf("processEvaluation failed: %v", err)
+		}
+	}
+	if n := len(alertRepo.alerts); n != 2 {
+		t.Fatalf("expected no duplicate alerts after restart, got %d rows", n)
+	}
+	stored, _ := alertRepo.GetByID(ctx, ackAlert.ID)
+	if stored.State != domain.AlertStateAcknowledged || stored.AcknowledgedBy != "oncall" || stored.Value != 97 {
+		t.Errorf("expected the acknowledged alert to keep its state and be updated, got %+v", stored)
+	}
+
+	// Resolution updates the original rows
+	for _, rule := range []*domain.AlertRule{firingRule, ackRule} {
+		if err := after.processEvaluation(ctx, rule, false, 10); err != nil {
+			t.Fatalf("processEvaluation failed: %v", err)
+		}
+	}
+	for _, a := range alertRepo.alerts {
+		if a.State != domain.AlertStateResolved || a.EndsAt == nil {
+			t.Errorf("expected %s to be resolved in place, got %s", a.RuleName, a.State)
+		}
+	}
+	if len(after.activeAlerts) != 0 {
+		t.Errorf("expected no active alerts after resolution, got %d", len(after.activeAlerts))
+	}
+}
+
+func TestAlertService_FiringReusesPersistedAlert(t *testing.T) {
+	ctx := context.Background()
+	alertRepo := newMockAlertRepository()
+	rule := domain.NewAlertRule("high-cpu", "cpu.usage", domain.ConditionThresholdAbove, 90, domain.AlertSeverityWarning)
+	rule.Duration = 0
+
+	before := NewAlertService(nil, alertRepo, nil, nil, nil, &mockAlertLogger{})
+	if err := before.processEvaluation(ctx, rule, true, 95); err != nil {
+		t.Fatalf("processEvaluation failed: %v", err)
+	}
+
+	// Without loading the cache, firing finds the stored alert by fingerprint
+	after := NewAlertService(nil, alertRepo, nil, nil, nil, &mockAlertLogger{})
+	if err := after.processEvaluation(ctx, rule, true, 96); err != nil {
+		t.Fatalf("processEvaluation failed: %v", err)
+	}
+	if n := len(alertRepo.alerts); n != 1 {
+		t.Errorf("expected the persisted alert to be reused, got %d rows", n)
+	}
+}
+
+func TestAlertService_SilencedAlertFiresWhenSilenceEnds(t *testing.T) {
+	ctx := context.Background()
+	channelRepo := newMockNotificationChannelRepository()
+	channel := domain.NewNotificationChannel("ops", domain.ChannelWebhook, nil)
+	if err := channelRepo.Create(ctx, channel); err != nil {
+		t.Fatalf("failed to create channel: %v", err)
+	}
+	silenceRepo := newMockSilenceRepository()
+	svc := NewAlertService(nil, newMockAlertRepository(), channelRepo, silenceRepo, nil, &mockAlertLogger{})
+	notifier := &recordingNotifier{}
+	svc.RegisterNotifier(notifier)
+
+	rule := domain.NewAlertRule("high-cpu", "cpu.usage", domain.ConditionThresholdAbove, 90, domain.AlertSeverityWarning)
+	rule.Labels = map[string]string{"team": "db"}
+	rule.Channels = []string{channel.ID.String()}
+	rule.Duration = 0
+	evaluate := func(firing bool) *domain.Alert {
+		t.Helper()
+		if err := svc.processEvaluation(ctx, rule, firing, 95); err != nil {
+			t.Fatalf("processEvaluation failed: %v", err)
+		}
+		return svc.activeAlerts[rule.ID.String()+":"+rule.MetricName]
+	}
+
+	now := time.Now()
+	silence := domain.NewSilence(map[string]string{"team": "db"}, now.Add(-time.Minute), now.Add(time.Hour), "admin", "deploy")
+	if err := svc.CreateSilence(ctx, silence); err != nil {
+		t.Fatalf("CreateSilence failed: %v", err)
+	}
+
+	if alert := evaluate(true); alert == nil || alert.State != domain.AlertStateSilenced {
+		t.Fatalf("expected a silenced alert, got %+v", alert)
+	}
+	if alert := evaluate(true); alert.State != domain.AlertStateSilenced {
+		t.Fatalf("expected the alert to stay silenced, got %s", alert.State)
+	}
+
+	if _, err := svc.ExpireSilence(ctx, silence.ID); err != nil {
+		t.Fatalf("ExpireSilence failed: %v", err)
+	}
+	if _, err := svc.ExpireSilence(ctx, silence.ID); err == nil {
+		t.Error("expected expiring an ended silence to fail")
+	}
+
+	// Still breaching once the silence has ended, so the alert fires
+	alert := evaluate(true)
+	if alert.State != domain.AlertStateFiring {
+		t.Fatalf("expected the alert to fire after the silence ended, got %s", alert.State)
+	}
+	sent := notifier.waitFor(1)
+	if len(sent) != 1 || sent[0].State != domain.AlertStateFiring {
+		t.Fatalf("expected one firing notification, got %d", len(sent))
+	}
+}
+
+func TestAlertService_SilencedAlertResolvesQuietly(t *testing.T) {
+	ctx := context.Background()
+	silenceRepo := newMockSilenceRepository()
+	svc := NewAlertService(nil, nil, nil, silenceRepo, nil, &mockAlertLogger{})
+	notifier := &recordingNotifier{}
+	svc.RegisterNotifier(notifier)
+
+	rule := domain.NewAlertRule("high-cpu", "cpu.usage", domain.ConditionThresholdAbove, 90, domain.AlertSeverityWarning)
+	rule.Labels = map[string]string{"team": "db"}
+	rule.Duration = 0
+	now := time.Now()
+	silence := domain.NewSilence(map[string]string{"team": "db"}, now.Add(-time.Minute), now.Add(time.Hour), "admin", "")
+	if err := svc.CreateSilence(ctx, silence); err != nil {
+		t.Fatalf("CreateSilence failed: %v", err)
+	}
+
+	fingerprint := rule.ID.String() + ":" + rule.MetricName
+	if err := svc.processEvaluation(ctx, rule, true, 95); err != nil {
+		t.Fatalf("processEvaluation failed: %v", err)
+	}
+	alert := svc.activeAlerts[fingerprint]
+	if err := svc.processEvaluation(ctx, rule, false, 50); err != nil {
+		t.Fatalf("processEvaluation failed: %v", err)
+	}
+	if alert.State != domain.AlertStateResolved || svc.activeAlerts[fingerprint] != nil {
+		t.Errorf("expected the silenced alert to resolve, got %s", alert.State)
+	}
+	if sent := notifier.waitFor(0); len(sent) != 0 {
+		t.Errorf("expected no notifications for an alert that never fired, got %d", len(sent))
+	}
+}
+
+func TestAlertService_PurgeSilences(t *testing.T) {
+	ctx := context.Background()
+	silenceRepo := newMockSilenceRepository()
+	svc := NewAlertService(nil, nil, nil, silenceRepo, nil, &mockAlertLogger{})
+	svc.SetSilenceRetention(24 * time.Hour)
+
+	now := time.Now()
+	old := domain.NewSilence(nil, now.Add(-72*time.Hour), now.Add(-48*time.Hour), "admin", "")
+	recent := domain.NewSilence(nil, now.Add(-3*time.Hour), now.Add(-2*time.Hour), "admin", "")
+	current := domain.NewSilence(nil, now.Add(-time.Hour), now.Add(time.Hour), "admin", "")
+	recurring := domain.NewSilence(nil, now.Add(-90*24*time.Hour), time.Time{}, "admin", "")
+	recurring.Schedule = "0 2 * * 0"
+	recurring.Duration = time.Hour
+	for _, silence := range []*domain.Silence{old, recent, current, recurring} {
+		if err := svc.CreateSilence(ctx, silence); err != nil {
+			t.Fatalf("CreateSilence failed: %v", err)
+		}
+	}
+
+	purged, err := svc.PurgeSilences(ctx, now)
+	if err != nil {
+		t.Fatalf("PurgeSilences failed: %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("expected 1 silence purged, got %d", purged)
+	}
+	if s, _ := silenceRepo.GetByID(ctx, old.ID); s != nil {
+		t.Error("expected the silence that ended two days ago to be deleted")
+	}
+	for _, keep := range []*domain.Silence{recent, current, recurring} {
+		if s, _ := silenceRepo.GetByID(ctx, keep.ID); s == nil {
+			t.Errorf("expected silence %s to be kept", keep.ID)
+		}
+	}
+
+	if err := svc.DeleteSilence(ctx, current.ID); err != nil {
+		t.Fatalf("DeleteSilence failed: %v", err)
+	}
+	if s, _ := silenceRepo.GetByID(ctx, current.ID); s != nil {
+		t.Error("expected the deleted silence to be gone")
+	}
+}
+
+// seriesMetricRepo answers queries with fixed values per metric name,
+// recording the tags of each query.
+type seriesMetricRepo struct {
+	*mockMetricRepositoryForAlert
+	values map[string][]float64
+	tags   map[string]map[string]string
+}
+
+func (m *seriesMetricRepo) Query(ctx context.Context, query ports.MetricQuery) (*domain.MetricSeries, error) {
+	m.tags[query.Name] = query.Tags
+	series := &domain.MetricSeries{Name: query.Name}
+	for i, v := range m.values[query.Name] {
+		series.Points = append(series.Points, domain.MetricPoint{Value: v, Timestamp: query.EndTime.Add(time.Duration(i-len(m.values[query.Name])) * time.Second)})
+	}
+	return series, nil
+}
+
+func TestParseAlertExpression(t *testing.T) {
+	valid := []struct {
+		src    string
+		values map[string]float64
+		want   float64
+	}{
+		{"a = avg(cpu.usage{host=web}), b = avg(memory.usage{host=web}); a > 90 && b > 80", map[string]float64{"a": 95, "b": 85}, 1},
+		{"a = avg(cpu.usage{host=web}), b = avg(memory.usage{host=web}); a > 90 && b > 80", map[string]float64{"a": 95, "b": 50}, 0},
+		{"e = sum(error.count), r = sum(request.count); e / r * 100 > 5", map[string]float64{"e": 6, "r": 100}, 1},
+		{"e = sum(error.count), r = sum(request.count); e / r * 100 > 5", map[string]float64{"e": 1, "r": 0}, 1},
+		{"a = last(x); !(a >= 10) || a == 42", map[string]float64{"a": 42}, 1},
+		{"a = last(x); !(a >= 10) || a == 42", map[string]float64{"a": 12}, 0},
+		{"a = min(x), b = max(x); max(a, b, 3) - min(abs(-a), 2)", map[string]float64{"a": 1, "b": 2}, 2},
+		{"a = count(x{env=\"prod-eu, 1\", host=web-1}); -a + 2 * 3", map[string]float64{"a": 4}, 2},
+		{"a = avg(x); 1.5e1 <= a", map[string]float64{"a": 15}, 1},
+	}
+	for _, tt := range valid {
+		expr, err := ParseAlertExpression(tt.src)
+		if err != nil {
+			t.Errorf("ParseAlertExpression(%q) failed: %v", tt.src, err)
+			continue
+		}
+		if got := expr.Eval(tt.values); got != tt.want {
+			t.Errorf("%q with %v = %v, want %v", tt.src, tt.values, got, tt.want)
+		}
+	}
+
+	expr, _ := ParseAlertExpression("a = count(x{env=\"prod-eu, 1\", host=web-1}); a")
+	if q := expr.Queries[0]; q.Metric != "x" || q.Aggregation != "count" || q.Tags["env"] != "prod-eu, 1" || q.Tags["host"] != "web-1" {
+		t.Errorf("unexpected query %+v", q)
+	}
+
+	invalid := []string{
+		"",
+		"a > 90",
+		"a = avg(cpu.usage); b > 90",
+		"a = median(cpu.usage); a > 90",
+		"a = avg(cpu.usage), a = avg(mem.usage); a > 90",
+		"a = avg(cpu.usage{host}); a > 90",
+		"a = avg(cpu.usage); a > ",
+		"a = avg(cpu.usage); a > 90)",
+		"a = avg(cpu.usage); sqrt(a) > 3",
+		"a = avg(cpu.usage); abs(a, 1) > 3",
+		"a = avg(cpu.usage) a > 90",
+	}
+	for _, src := range invalid {
+		if _, err := ParseAlertExpression(src); err == nil {
+			t.Errorf("expected ParseAlertExpression(%q) to fail", src)
+		}
+	}
+}
+
+func TestAlertService_ExpressionRule(t *testing.T) {
+	ctx := context.Background()
+	metricRepo := &seriesMetricRepo{
+		mockMetricRepositoryForAlert: newMockMetricRepositoryForAlert(),
+		values: map[string][]float64{
+			"cpu.usage":    {90, 100},
+			"memory.usage": {85},
+		},
+		tags: make(map[string]map[string]string),
+	}
+	svc := NewAlertService(newMockAlertRuleRepository(), newMockAlertRepository(), nil, nil, metricRepo, &mockAlertLogger{})
+
+	rule := domain.NewAlertRule("web-saturated", "", domain.ConditionExpression, 0, domain.AlertSeverityCritical)
+	rule.Duration = 0
+	rule.Expression = "a = avg(cpu.usage{host=web}), b = avg(memory.usage{host=web}); a > 90 && b > 80"
+	if err := svc.CreateRule(ctx, rule); err != nil {
+		t.Fatalf("CreateRule failed: %v", err)
+	}
+
+	if err := svc.EvaluateRule(ctx, rule); err != nil {
+		t.Fatalf("EvaluateRule failed: %v", err)
+	}
+	alert := svc.activeAlerts[rule.ID.String()+":"]
+	if alert == nil || alert.State != domain.AlertStateFiring || alert.Value != 95 {
+		t.Fatalf("expected a firing alert with the first query's value, got %+v", alert)
+	}
+	if metricRepo.tags["cpu.usage"]["host"] != "web" {
+		t.Errorf("expected query tags to be passed through, got %v", metricRepo.tags)
+	}
+
+	// A query without data keeps the condition from holding
+	delete(metricRepo.values, "memory.usage")
+	if err := svc.EvaluateRule(ctx, rule); err != nil {
+		t.Fatalf("EvaluateRule failed: %v", err)
+	}
+	if alert.State != domain.AlertStateResolved {
+		t.Errorf("expected the alert to resolve, got %s", alert.State)
+	}
+
+	bad := domain.NewAlertRule("bad", "", domain.ConditionExpression, 0, domain.AlertSeverityWarning)
+	bad.Expression = "a = avg(cpu.usage); a >"
+	if err := svc.CreateRule(ctx, bad); err == nil || !strings.Contains(err.Error(), "invalid expression") {
+		t.Errorf("expected CreateRule to reject the expression, got %v", err)
+	}
+	bad.Expression = ""
+	if err := svc.CreateRule(ctx, bad); err == nil {
+		t.Error("expected CreateRule to require an expression")
+	}
+}
+
+func TestAlertService_EvaluationMetrics(t *testing.T) {
+	ctx := context.Background()
+	ruleRepo := newMockAlertRuleRepository()
+	metricRepo := &seriesMetricRepo{
+		mockMetricRepositoryForAlert: newMockMetricRepositoryForAlert(),
+		values:                       map[string][]float64{"cpu.usage": {95}},
+		tags:                         make(map[string]map[string]string),
+	}
+	svc := NewAlertService(ruleRepo, newMockAlertRepository(), nil, nil, metricRepo, &mockAlertLogger{})
+	metricSvc := NewMetricService(metricRepo, &mockAlertLogger{}, DefaultMetricServiceConfig())
+	svc.SetMetricService(metricSvc)
+
+	firing := domain.NewAlertRule("high-cpu", "", domain.ConditionExpression, 0, domain.AlertSeverityWarning)
+	firing.Duration = 0
+	firing.Expression = "a = last(cpu.usage); a > 90"
+	broken := domain.NewAlertRule("broken", "", domain.ConditionExpression, 0, domain.AlertSeverityWarning)
+	broken.Expression = "a = last(cpu.usage); a >"
+	_ = ruleRepo.Create(ctx, firing)
+	_ = ruleRepo.Create(ctx, broken) // Stored directly, bypassing validation
+
+	svc.EvaluateAll(ctx)
+	// Evaluation metrics are buffered by the metric service like any other
+	metricSvc.flush(ctx)
+
+	metricRepo.mu.Lock()
+	defer metricRepo.mu.Unlock()
+	recorded := make(map[string]*domain.Metric)
+	for _, m := range metricRepo.metrics {
+		recorded[m.Name] = m
+	}
+	if m := recorded["forge.alert.evaluations"]; m == nil || m.Type != domain.MetricTypeCounter || m.Value != 2 {
+		t.Errorf("expected 2 evaluations to be counted, got %+v", m)
+	}
+	if m := recorded["forge.alert.evaluation_duration"]; m == nil || m.Type != domain.MetricTypeHistogram || m.Value < 0 {
+		t.Errorf("expected the evaluation duration to be recorded, got %+v", m)
+	}
+	if m := recorded["forge.alerts.firing"]; m == nil || m.Type != domain.MetricTypeGauge || m.Value != 1 {
+		t.Errorf("expected 1 firing alert, got %+v", m)
+	}
+	if m := recorded["forge.alert.evaluation_errors"]; m == nil || m.Value != 1 || m.Tags["rule"] != "broken" {
+		t.Errorf("expected an evaluation error for the broken rule, got %+v", m)
+	}
+}
+
+// mockMaintenanceWindowRepository for testing
+type mockMaintenanceWindowRepository struct {
+	mu      sync.RWMutex
+	windows map[uuid.UUID]*domain.MaintenanceWindow
+}
+
+func newMockMaintenanceWindowRepository() *mockMaintenanceWindowRepository {
+	return &mockMaintenanceWindowRepository{
+		windows: make(map[uuid.UUID]*domain.MaintenanceWindow),
+	}
+}
+
+func (m *mockMaintenanceWindowRepository) Create(ctx context.Context, w *domain.MaintenanceWindow) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.windows[w.ID] = w
+	return nil
+}
+
+func (m *mockMaintenanceWindowRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.MaintenanceWindow, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.windows[id], nil
+}
+
+func (m *mockMaintenanceWindowRepository) GetByName(ctx context.Context, name string) (*domain.MaintenanceWindow, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, w := range m.windows {
+		if w.Name == name {
+			return w, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockMaintenanceWindowRepository) Update(ctx context.Context, w *domain.MaintenanceWindow) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.windows[w.ID] = w
+	return nil
+}
+
+func (m *mockMaintenanceWindowRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.windows, id)
+	return nil
+}
+
+func (m *mockMaintenanceWindowRepository) List(ctx context.Context) ([]*domain.MaintenanceWindow, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	result := make([]*domain.MaintenanceWindow, 0, len(m.windows))
+	for _, w := range m.windows {
+		result = append(result, w)
+	}
+	return result, nil
+}
+
+func TestAlertService_MaintenanceWindowSilencesAlerts(t *testing.T) {
+	ctx := context.Background()
+	ruleRepo := newMockAlertRuleRepository()
+	alertRepo := newMockAlertRepository()
+	metricRepo := &seriesMetricRepo{
+		mockMetricRepositoryForAlert: newMockMetricRepositoryForAlert(),
+		values:                       map[string][]float64{"cpu.usage": {95}},
+		tags:                         make(map[string]map[string]string),
+	}
+	svc := NewAlertService(ruleRepo, alertRepo, nil, nil, metricRepo, &mockAlertLogger{})
+	svc.SetMaintenanceWindowRepository(newMockMaintenanceWindowRepository())
+
+	// An occurrence that opened an hour ago and runs for another hour
+	start := time.Now().UTC().Add(-time.Hour)
+	window := domain.NewMaintenanceWindow("deploys", map[string]string{"team": "db"}, nil, start.Format("15:04"), 2*time.Hour, "")
+	if err := svc.CreateMaintenanceWindow(ctx, window); err != nil {
+		t.Fatalf("CreateMaintenanceWindow failed: %v", err)
+	}
+	if got, err := svc.GetMaintenanceWindow(ctx, "deploys"); err != nil || got == nil || got.ID != window.ID {
+		t.Fatalf("GetMaintenanceWindow by name = %v, %v", got, err)
+	}
+
+	rule := domain.NewAlertRule("high-cpu", "", domain.ConditionExpression, 0, domain.AlertSeverityWarning)
+	rule.Duration = 0
+	rule.Expression = "a = last(cpu.usage); a > 90"
+	rule.Labels = map[string]string{"team": "db"}
+	_ = ruleRepo.Create(ctx, rule)
+
+	svc.EvaluateAll(ctx)
+
+	alerts, _ := alertRepo.List(ctx, ports.AlertFilter{})
+	if len(alerts) != 1 {
+		t.Fatalf("expected 1 alert, got %d", len(alerts))
+	}
+	if alerts[0].State != domain.AlertStateSilenced || alerts[0].SilencedBy != "maintenance:deploys" {
+		t.Errorf("expected alert silenced by the window, got state %s silenced by %q", alerts[0].State, alerts[0].SilencedBy)
+	}
+
+	// Disabled windows and windows whose matchers differ do not apply
+	window.Enabled = false
+	if by := svc.silencedBy(ctx, alerts[0]); by != "" {
+		t.Errorf("expected disabled window not to silence, got %q", by)
+	}
+	window.Enabled = true
+	window.Matchers = map[string]string{"team": "web"}
+	if by := svc.silencedBy(ctx, alerts[0]); by != "" {
+		t.Errorf("expected non-matching window not to silence, got %q", by)
+	}
+
+	invalid := domain.NewMaintenanceWindow("bad", nil, nil, "25:00", time.Hour, "")
+	if err := svc.CreateMaintenanceWindow(ctx, invalid); err == nil {
+		t.Error("expected invalid start time to be rejected")
+	}
+}
+
+func TestAlertService_QuietHours(t *testing.T) {
+	ctx := context.Background()
+	channelRepo := newMockNotificationChannelRepository()
+	channel := domain.NewNotificationChannel("ops", domain.ChannelWebhook, nil)
+	if err := channelRepo.Create(ctx, channel); err != nil {
+		t.Fatalf("failed to create channel: %v", err)
+	}
+	svc := NewAlertService(nil, newMockAlertRepository(), channelRepo, nil, nil, &mockAlertLogger{})
+	notifier := &recordingNotifier{}
+	svc.RegisterNotifier(notifier)
+
+	// Quiet hours that began an hour ago and end in two
+	now := time.Now().UTC()
+	svc.SetQuietHours(&domain.QuietHours{
+		Start: now.Add(-time.Hour).Format("15:04"),
+		End:   now.Add(2 * time.Hour).Format("15:04"),
+	})
+
+	newRule := func(name string, severity domain.AlertSeverity) *domain.AlertRule {
+		rule := domain.NewAlertRule(name, name, domain.ConditionThresholdAbove, 90, severity)
+		rule.Channels = []string{channel.ID.String()}
+		rule.Duration = 0
+		return rule
+	}
+	critical := newRule("disk-full", domain.AlertSeverityCritical)
+	highCPU := newRule("high-cpu", domain.AlertSeverityWarning)
+	errorRate := newRule("error-rate", domain.AlertSeverityWarning)
+	for _, rule := range []*domain.AlertRule{highCPU, critical, errorRate} {
+		if err := svc.processEvaluation(ctx, rule, true, 95); err != nil {
+			t.Fatalf("processEvaluation failed: %v", err)
+		}
+	}
+	// A held alert that resolves is listed once, as resolved
+	if err := svc.processEvaluation(ctx, errorRate, false, 10); err != nil {
+		t.Fatalf("processEvaluation failed: %v", err)
+	}
+
+	sent := notifier.waitFor(1)
+	if len(sent) != 1 || sent[0].RuleName != "disk-full" {
+		t.Fatalf("expected only the critical alert to notify during quiet hours, got %d notifications", len(sent))
+	}
+
+	// Still within quiet hours: the digest keeps waiting
+	svc.releaseDigests(now)
+	if sent := notifier.waitFor(2); len(sent) != 1 {
+		t.Fatalf("expected the digest to be held until quiet hours end, got %d notifications", len(sent))
+	}
+
+	// Across the boundary the held warnings are delivered as one digest
+	svc.releaseDigests(now.Add(3 * time.Hour))
+	sent = notifier.waitFor(2)
+	if len(sent) != 2 {
+		t.Fatalf("expected the digest after quiet hours, got %d notifications", len(sent))
+	}
+	digest := sent[1]
+	if digest.Annotations["digest_size"] != "2" || digest.Severity != domain.AlertSeverityWarning {
+		t.Errorf("unexpected digest %+v", digest)
+	}
+	if !strings.Contains(digest.Message, "high-cpu (firing)") || !strings.Contains(digest.Message, "error-rate (resolved)") {
+		t.Errorf("expected the digest to list each held alert in its latest state, got %q", digest.Message)
+	}
+
+	// Nothing is held once quiet hours are over
+	svc.releaseDigests(now.Add(3 * time.Hour))
+	if sent := notifier.waitFor(3); len(sent) != 2 {
+		t.Errorf("expected no further notifications, got %d", len(sent))
+	}
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrTooManyAPIKeyAttempts is returned while a source is locked out after
+// presenting too many invalid API keys.
+var ErrTooManyAPIKeyAttempts = errors.New("too many failed API key attempts, try again later")
+
+// APIKeyBruteForceMetric counts sources locked out for guessing API keys.
+const APIKeyBruteForceMetric = "forge.auth.apikey.bruteforce"
+
+// maxAPIKeyLimiterEntries bounds the sources tracked at once, so a caller
+// cycling through addresses cannot grow the limiter without limit.
+const maxAPIKeyLimiterEntries = 10000
+
+// apiKeyLimiter counts invalid API keys per source, whatever their prefix,
+// so guessing random prefixes is throttled like guessing one. After
+// maxFailures within window, the source is locked out for lockout. Failures
+// per prefix are kept alongside as a signal of targeted guessing, and the
+// prefixes guessed before a lockout are refused outright while it lasts.
+// State is kept in memory, pruned as it expires and capped at maxEntries
+// sources.
+type apiKeyLimiter struct {
+	maxFailures int // Failures before a lockout (0 = unlimited)
+	window      time.Duration
+	lockout     time.Duration
+	maxEntries  int
+	now         func() time.Time
+
+	mu          sync.Mutex
+	entries     map[string]*apiKeyFailures
+	lastCleanup time.Time
+}
+
+type apiKeyFailures struct {
+	windowStart time.Time
+	count       int
+	prefixes    map[string]int // Failures per key prefix in the window
+	lockedUntil time.Time
+	// Prefixes guessed before the current lockout
+	lockedPrefixes map[string]struct{}
+}
+
+// apiKeyFailureReport describes the failures that started a lockout.
+type apiKeyFailureReport struct {
+	Failures       int // Failures from the source in the window
+	PrefixFailures int // Of which against the prefix that locked it out
+	Prefixes       int // Distinct prefixes tried
+}
+
+func newAPIKeyLimiter(maxFailures int, window, lockout time.Duration) *apiKeyLimiter {
+	return &apiKeyLimiter{
+		maxFailures: maxFailures,
+		window:      window,
+		lockout:     lockout,
+		maxEntries:  maxAPIKeyLimiterEntries,
+		now:         time.Now,
+		entries:     make(map[string]*apiKeyFailures),
+	}
+}
+
+// Locked reports whether source is locked out.
+func (l *apiKeyLimiter) Locked(source string) bool {
+	if l.maxFailures <= 0 {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entry, ok := l.entries[source]
+	return ok && l.now().Before(entry.lockedUntil)
+}
+
+// Refused reports whether source is locked out after guessing at prefix,
+// in which case keys with that prefix are refused without being checked.
+func (l *apiKeyLimiter) Refused(source, prefix string) bool {
+	if l.maxFailures <= 0 {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entry, ok := l.entries[source]
+	if !ok || !l.now().Before(entry.lockedUntil) {
+		return false
+	}
+	_, guessed := entry.lockedPrefixes[prefix]
+	return guessed
+}
+
+// Fail records an invalid attempt from source against prefix. It reports
+// whether the attempt started a lockout and, if so, the failures behind it.
+func (l *apiKeyLimiter) Fail(source, prefix string) (bool, apiKeyFailureReport) {
+	if l.maxFailures <= 0 {
+		return false, apiKeyFailureReport{}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.cleanup(now, false)
+	entry, ok := l.entries[source]
+	if !ok || now.Sub(entry.windowStart) >= l.window {
+		if !ok && len(l.entries) >= l.maxEntries {
+			l.evict(now)
+		}
+		entry = &apiKeyFailures{windowStart: now, prefixes: make(map[string]int)}
+		l.entries[source] = entry
+	}
+	entry.count++
+	entry.prefixes[prefix]++
+	if entry.count >= l.maxFailures && !now.Before(entry.lockedUntil) {
+		report := apiKeyFailureReport{
+			Failures:       entry.count,
+			PrefixFailures: entry.prefixes[prefix],
+			Prefixes:       len(entry.prefixes),
+		}
+		entry.lockedUntil = now.Add(l.lockout)
+		entry.lockedPrefixes = make(map[string]struct{}, len(entry.prefixes))
+		for guessed := range entry.prefixes {
+			entry.lockedPrefixes[guessed] = struct{}{}
+		}
+		// Count afresh once the lockout ends
+		entry.windowStart = entry.lockedUntil
+		entry.count = 0
+		entry.prefixes = make(map[string]int)
+		return true, report
+	}
+	return false, apiKeyFailureReport{}
+}
+
+// Succeed forgets the failures recorded from source against prefix. Its
+// other failures still count, so a source holding one valid key cannot use
+// it to reset guesses at others.
+func (l *apiKeyLimiter) Succeed(source, prefix string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if entry, ok := l.entries[source]; ok {
+		entry.count -= entry.prefixes[prefix]
+		delete(entry.prefixes, prefix)
+	}
+}
+
+// cleanup drops entries whose window and lockout have both passed, at most
+// once per window unless forced. l.mu must be held.
+func (l *apiKeyLimiter) cleanup(now time.Time, force bool) {
+	if !force && now.Sub(l.lastCleanup) < l.window {
+		return
+	}
+	l.lastCleanup = now
+	for key, entry := range l.entries {
+		if now.Sub(entry.windowStart) >= l.window && !now.Before(entry.lockedUntil) {
+			delete(l.entries, key)
+		}
+	}
+}
+
+// evict makes room for a new source when the limiter is full: expired
+// entries go first, then the oldest source not locked out, then the
+// lockout that ends soonest. l.mu must be held.
+func (l *apiKeyLimiter) evict(now time.Time) {
+	l.cleanup(now, true)
+	if len(l.entries) < l.maxEntries {
+		return
+	}
+	var victim string
+	var victimEntry *apiKeyFailures
+	for key, entry := range l.entries {
+		locked := now.Before(entry.lockedUntil)
+		switch {
+		case victimEntry == nil:
+		case now.Before(victimEntry.lockedUntil) != locked:
+			if locked {
+				continue
+			}
+		case locked:
+			if !entry.lockedUntil.Before(victimEntry.lockedUntil) {
+				continue
+			}
+		case !entry.windowStart.Before(victimEntry.windowStart):
+			continue
+		}
+		victim, victimEntry = key, entry
+	}
+	delete(l.entries, victim)
+}
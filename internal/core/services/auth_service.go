@@ -5,6 +5,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/forge-platform/forge/internal/core/domain"
@@ -23,6 +25,8 @@ var (
 	ErrUserExists = errors.New("user already exists")
 	// ErrSessionExpired is returned when the session has expired.
 	ErrSessionExpired = errors.New("session expired")
+	// ErrSessionRevoked is returned when the session was logged out or revoked.
+	ErrSessionRevoked = errors.New("session revoked")
 	// ErrInvalidToken is returned when the token is invalid.
 	ErrInvalidToken = errors.New("invalid token")
 	// ErrAPIKeyRevoked is returned when the API key has been revoked.
@@ -31,6 +35,14 @@ var (
 	ErrAPIKeyExpired = errors.New("API key expired")
 	// ErrPermissionDenied is returned when the user lacks permission.
 	ErrPermissionDenied = errors.New("permission denied")
+	// ErrMFARequired is returned when a login needs a TOTP code.
+	ErrMFARequired = errors.New("MFA code required")
+	// ErrInvalidMFACode is returned when a TOTP code is wrong or already used.
+	ErrInvalidMFACode = errors.New("invalid MFA code")
+	// ErrMFANotEnrolled is returned when verifying MFA for a user without a secret.
+	ErrMFANotEnrolled = errors.New("MFA not enrolled")
+	// ErrTokenExpired is returned when a password reset token has expired.
+	ErrTokenExpired = errors.New("token expired")
 )
 
 // AuthConfig contains configuration for the auth service.
@@ -38,7 +50,20 @@ type AuthConfig struct {
 	MaxLoginAttempts int           // Max failed login attempts before lock
 	LockDuration     time.Duration // Duration to lock account
 	SessionDuration  time.Duration // Session expiration time
+	// SessionSlidingWindow extends a session by SessionDuration every time
+	// it is used, so only idle sessions expire.
+	SessionSlidingWindow bool
 	APIKeyDuration   time.Duration // Default API key expiration
+	ResetDuration    time.Duration // Password reset token expiration
+	MFAIssuer        string        // Issuer shown in authenticator apps
+	MFAEncryptionKey []byte        // AES key (16, 24 or 32 bytes) for stored TOTP secrets
+
+	// After MaxAPIKeyFailures invalid API keys from one source within
+	// APIKeyFailureWindow, whatever their prefixes, further invalid keys from
+	// it are refused for APIKeyLockDuration (0 failures disables the lockout)
+	MaxAPIKeyFailures   int
+	APIKeyFailureWindow time.Duration
+	APIKeyLockDuration  time.Duration
 }
 
 // DefaultAuthConfig returns sensible defaults for auth configuration.
@@ -48,17 +73,32 @@ func DefaultAuthConfig() AuthConfig {
 		LockDuration:     15 * time.Minute,
 		SessionDuration:  24 * time.Hour,
 		APIKeyDuration:   90 * 24 * time.Hour, // 90 days
+		ResetDuration:    time.Hour,
+		MFAIssuer:        "Forge",
+
+		MaxAPIKeyFailures:   10,
+		APIKeyFailureWindow: 5 * time.Minute,
+		APIKeyLockDuration:  15 * time.Minute,
 	}
 }
 
 // AuthService handles authentication and authorization.
 type AuthService struct {
-	userRepo     ports.UserRepository
-	sessionRepo  ports.SessionRepository
-	apiKeyRepo   ports.APIKeyRepository
-	auditRepo    ports.AuditLogRepository
-	config       AuthConfig
-	logger       ports.Logger
+	userRepo    ports.UserRepository
+	sessionRepo ports.SessionRepository
+	apiKeyRepo  ports.APIKeyRepository
+	auditRepo   ports.AuditLogRepository
+	resetRepo   ports.PasswordResetRepository
+	metricSvc   ports.MetricService
+	config      AuthConfig
+	logger      ports.Logger
+	apiKeyFails *apiKeyLimiter
+
+	// Hash of the newest audit entry, loaded from the repository on first use.
+	// auditMu serializes sealing so concurrent entries can't fork the chain.
+	auditHead       string
+	auditHeadLoaded bool
+	auditMu         sync.Mutex
 }
 
 // NewAuthService creates a new authentication service.
@@ -77,9 +117,21 @@ func NewAuthService(
 		auditRepo:   auditRepo,
 		config:      config,
 		logger:      logger,
+		apiKeyFails: newAPIKeyLimiter(config.MaxAPIKeyFailures, config.APIKeyFailureWindow, config.APIKeyLockDuration),
 	}
 }
 
+// SetMetricService records security metrics such as
+// APIKeyBruteForceMetric.
+func (s *AuthService) SetMetricService(svc ports.MetricService) {
+	s.metricSvc = svc
+}
+
+// SetPasswordResetRepository enables RequestPasswordReset and ResetPassword.
+func (s *AuthService) SetPasswordResetRepository(repo ports.PasswordResetRepository) {
+	s.resetRepo = repo
+}
+
 // CreateUser creates a new user account.
 func (s *AuthService) CreateUser(ctx context.Context, username, email, password string, role domain.UserRole) (*domain.User, error) {
 	// Check if user exists
@@ -112,7 +164,15 @@ func (s *AuthService) CreateUser(ctx context.Context, username, email, password
 }
 
 // Login authenticates a user and returns a session token.
+// Users with MFA enabled must log in with LoginWithMFA; Login returns
+// ErrMFARequired for them once the password has been verified.
 func (s *AuthService) Login(ctx context.Context, username, password, ipAddress, userAgent string) (*domain.Session, string, error) {
+	return s.LoginWithMFA(ctx, username, password, "", ipAddress, userAgent)
+}
+
+// LoginWithMFA authenticates a user with a password and, when MFA is
+// enabled for the user, a TOTP code.
+func (s *AuthService) LoginWithMFA(ctx context.Context, username, password, totpCode, ipAddress, userAgent string) (*domain.Session, string, error) {
 	var user *domain.User
 	var err error
 
@@ -142,6 +202,22 @@ func (s *AuthService) Login(ctx context.Context, username, password, ipAddress,
 		return nil, "", ErrInvalidCredentials
 	}
 
+	// Second factor
+	if user.MFAEnabled {
+		if totpCode == "" {
+			s.audit(ctx, &user.ID, "user.login", "user", user.ID.String(), nil, ErrMFARequired)
+			return nil, "", ErrMFARequired
+		}
+		if err := s.checkTOTP(user, totpCode); err != nil {
+			user.RecordFailedLogin(s.config.MaxLoginAttempts, s.config.LockDuration)
+			if s.userRepo != nil {
+				_ = s.userRepo.Update(ctx, user)
+			}
+			s.audit(ctx, &user.ID, "user.login", "user", user.ID.String(), nil, err)
+			return nil, "", err
+		}
+	}
+
 	// Reset failed logins and create session
 	user.ResetFailedLogins()
 	if s.userRepo != nil {
@@ -187,17 +263,40 @@ func (s *AuthService) Logout(ctx context.Context, sessionID uuid.UUID) error {
 }
 
 // ValidateSession checks if a session token is valid and returns the user.
+// The session is looked up by the SHA-256 hash of the token, so the token
+// itself is never stored. Each successful validation records activity and,
+// with SessionSlidingWindow set, pushes the expiry out by SessionDuration.
 func (s *AuthService) ValidateSession(ctx context.Context, token string) (*domain.User, *domain.Session, error) {
-	if s.sessionRepo == nil || s.userRepo == nil {
+	if s.sessionRepo == nil || s.userRepo == nil || token == "" {
 		return nil, nil, ErrInvalidToken
 	}
 
-	// We need to find the session by token - this is inefficient but secure
-	// In production, you'd use a Redis cache or similar
-	// For now, we'll need to hash the token and search
+	session, err := s.sessionRepo.GetByTokenHash(ctx, domain.HashToken(token))
+	if err != nil {
+		return nil, nil, ErrInvalidToken
+	}
+	if session.RevokedAt != nil {
+		return nil, nil, ErrSessionRevoked
+	}
+	if !session.IsValid() {
+		return nil, nil, ErrSessionExpired
+	}
 
-	// This is a simplified implementation - in production you'd want a token index
-	return nil, nil, ErrInvalidToken
+	user, err := s.userRepo.GetByID(ctx, session.UserID)
+	if err != nil {
+		return nil, nil, ErrInvalidToken
+	}
+
+	if s.config.SessionSlidingWindow && s.config.SessionDuration > 0 {
+		session.Extend(s.config.SessionDuration)
+	} else {
+		session.Touch()
+	}
+	if err := s.sessionRepo.Update(ctx, session); err != nil {
+		s.logger.Warn("Failed to record session activity", "session_id", session.ID, "error", err)
+	}
+
+	return user, session, nil
 }
 
 // CreateAPIKey creates a new API key for a user.
@@ -230,7 +329,59 @@ func (s *AuthService) CreateAPIKey(ctx context.Context, userID uuid.UUID, name s
 }
 
 // ValidateAPIKey validates an API key and returns the associated user.
+//
+// Invalid keys are counted per source across all key prefixes; see
+// AuthConfig.MaxAPIKeyFailures. A locked out source gets
+// ErrTooManyAPIKeyAttempts for any key with a prefix it guessed at, valid or
+// not, and for invalid keys with other prefixes.
 func (s *AuthService) ValidateAPIKey(ctx context.Context, key string) (*domain.User, *domain.APIKey, error) {
+	prefix := key
+	if len(prefix) > 8 {
+		prefix = prefix[:8]
+	}
+	source := AuditSourceFromContext(ctx).rateLimitKey()
+
+	// Checking a guessed prefix while locked out would tell a right guess
+	if s.apiKeyFails.Refused(source, prefix) {
+		return nil, nil, ErrTooManyAPIKeyAttempts
+	}
+	locked := s.apiKeyFails.Locked(source)
+	user, apiKey, err := s.validateAPIKey(ctx, key)
+	switch {
+	case err == nil:
+		s.apiKeyFails.Succeed(source, prefix)
+	case errors.Is(err, ErrInvalidToken):
+		// Revoked and expired keys are real keys, not guesses
+		if locked {
+			return nil, nil, ErrTooManyAPIKeyAttempts
+		}
+		if lockedNow, report := s.apiKeyFails.Fail(source, prefix); lockedNow {
+			s.reportAPIKeyBruteForce(ctx, prefix, source, report)
+			return nil, nil, ErrTooManyAPIKeyAttempts
+		}
+	}
+	return user, apiKey, err
+}
+
+// reportAPIKeyBruteForce records a lockout in the audit log and metrics.
+func (s *AuthService) reportAPIKeyBruteForce(ctx context.Context, prefix, source string, report apiKeyFailureReport) {
+	s.logger.Warn("Locking out repeated invalid API keys", "prefix", prefix, "source", source,
+		"failures", report.Failures, "prefixes", report.Prefixes)
+	s.audit(ctx, nil, "apikey.bruteforce", "apikey", prefix, map[string]string{
+		"source":          source,
+		"failures":        strconv.Itoa(report.Failures),
+		"prefix_failures": strconv.Itoa(report.PrefixFailures),
+		"prefixes":        strconv.Itoa(report.Prefixes),
+		"lockout":         s.config.APIKeyLockDuration.String(),
+	}, ErrTooManyAPIKeyAttempts)
+	if s.metricSvc != nil {
+		if err := s.metricSvc.Record(ctx, APIKeyBruteForceMetric, domain.MetricTypeCounter, 1, nil); err != nil {
+			s.logger.Error("Failed to record API key brute force metric", "error", err)
+		}
+	}
+}
+
+func (s *AuthService) validateAPIKey(ctx context.Context, key string) (*domain.User, *domain.APIKey, error) {
 	if s.apiKeyRepo == nil || s.userRepo == nil || len(key) < 8 {
 		return nil, nil, ErrInvalidToken
 	}
@@ -378,13 +529,52 @@ func (s *AuthService) ChangePassword(ctx context.Context, userID uuid.UUID, oldP
 	return nil
 }
 
+// AuditSource identifies who made a request and from where, for the audit
+// entries written while serving it.
+type AuditSource struct {
+	UserID    *uuid.UUID // Authenticated user; nil before authentication
+	IPAddress string     // Client address
+	UserAgent string
+	Peer      string // Stable client identity for rate limiting, e.g. IP without port
+}
+
+// rateLimitKey identifies the source for rate limiting, falling back to
+// its address.
+func (s AuditSource) rateLimitKey() string {
+	if s.Peer != "" {
+		return s.Peer
+	}
+	return s.IPAddress
+}
+
+type auditSourceKey struct{}
+
+// WithAuditSource returns a context whose audit entries are attributed to
+// source. Its user, when set, replaces the user an entry is about, so an
+// administrator deleting an account is recorded as the actor.
+func WithAuditSource(ctx context.Context, source AuditSource) context.Context {
+	return context.WithValue(ctx, auditSourceKey{}, source)
+}
+
+// AuditSourceFromContext returns the audit source attached with
+// WithAuditSource, or the zero value.
+func AuditSourceFromContext(ctx context.Context) AuditSource {
+	source, _ := ctx.Value(auditSourceKey{}).(AuditSource)
+	return source
+}
+
 // audit creates an audit log entry.
 func (s *AuthService) audit(ctx context.Context, userID *uuid.UUID, action, resource, resourceID string, details map[string]string, err error) {
 	if s.auditRepo == nil {
 		return
 	}
 
+	source := AuditSourceFromContext(ctx)
+	if source.UserID != nil {
+		userID = source.UserID
+	}
 	log := domain.NewAuditLog(userID, action, resource, resourceID)
+	log.WithContext(source.IPAddress, source.UserAgent)
 	if details != nil {
 		log.WithDetails(details)
 	}
@@ -392,10 +582,45 @@ func (s *AuthService) audit(ctx context.Context, userID *uuid.UUID, action, reso
 		log.WithError(err)
 	}
 
-	_ = s.auditRepo.Create(ctx, log)
+	s.auditMu.Lock()
+	defer s.auditMu.Unlock()
+
+	if !s.auditHeadLoaded {
+		// If the head can't be loaded the entry is still written; it will
+		// show up as a gap when the chain is verified.
+		latest, err := s.auditRepo.Latest(ctx)
+		if err != nil {
+			s.logger.Error("Failed to load audit chain head", "error", err)
+		} else {
+			if latest != nil {
+				s.auditHead = latest.Hash
+			}
+			s.auditHeadLoaded = true
+		}
+	}
+
+	log.Seal(s.auditHead)
+	if err := s.auditRepo.Create(ctx, log); err != nil {
+		s.logger.Error("Failed to write audit log", "action", action, "error", err)
+		return
+	}
+	s.auditHead = log.Hash
 }
 
-// CleanupExpired removes expired sessions and API keys.
+// VerifyAuditLog checks the audit log hash chain for gaps or tampering.
+func (s *AuthService) VerifyAuditLog(ctx context.Context) (*domain.AuditChainReport, error) {
+	if s.auditRepo == nil {
+		return domain.VerifyAuditChain(nil), nil
+	}
+
+	logs, err := s.auditRepo.ListChain(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit logs: %w", err)
+	}
+	return domain.VerifyAuditChain(logs), nil
+}
+
+// CleanupExpired removes expired sessions, API keys and reset tokens.
 func (s *AuthService) CleanupExpired(ctx context.Context) error {
 	if s.sessionRepo != nil {
 		if _, err := s.sessionRepo.DeleteExpired(ctx); err != nil {
@@ -407,6 +632,11 @@ func (s *AuthService) CleanupExpired(ctx context.Context) error {
 			s.logger.Error("Failed to cleanup expired API keys", "error", err)
 		}
 	}
+	if s.resetRepo != nil {
+		if _, err := s.resetRepo.DeleteExpired(ctx); err != nil {
+			s.logger.Error("Failed to cleanup expired password reset tokens", "error", err)
+		}
+	}
 	return nil
 }
 
@@ -444,11 +674,22 @@ func (s *AuthService) CheckPermission(ctx context.Context, userID uuid.UUID, res
 
 // CheckAPIKeyPermission verifies if an API key has permission to perform an action.
 func (s *AuthService) CheckAPIKeyPermission(ctx context.Context, apiKey *domain.APIKey, resource domain.ResourceType, permission domain.Permission) error {
+	return s.CheckAPIKeyInstancePermission(ctx, apiKey, resource, permission, "")
+}
+
+// CheckAPIKeyInstancePermission verifies if an API key has permission to
+// perform an action on one resource. A key with instance grants for the
+// resource type and permission is limited to those instances; otherwise
+// the key's owner's permissions apply.
+func (s *AuthService) CheckAPIKeyInstancePermission(ctx context.Context, apiKey *domain.APIKey, resource domain.ResourceType, permission domain.Permission, resourceID string) error {
 	// Check if API key has explicit permission
-	permStr := string(resource) + ":" + string(permission)
-	if apiKey.HasPermission(permStr) || apiKey.HasPermission(string(resource)+":*") || apiKey.HasPermission("*") {
+	allowed, scoped := domain.MatchGrants(apiKey.Permissions, resource, permission, resourceID)
+	if allowed {
 		return nil
 	}
+	if scoped {
+		return ErrPermissionDenied
+	}
 
 	// Fall back to user's role permissions
 	if s.userRepo == nil {
@@ -460,7 +701,7 @@ func (s *AuthService) CheckAPIKeyPermission(ctx context.Context, apiKey *domain.
 		return ErrUserNotFound
 	}
 
-	if !user.CanAccess(resource, permission) {
+	if !user.CanAccessInstance(resource, permission, resourceID) {
 		return ErrPermissionDenied
 	}
 
@@ -2,6 +2,8 @@ package services
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"testing"
 	"time"
 
@@ -73,6 +75,7 @@ func (m *mockUserRepository) Count(_ context.Context) (int64, error) {
 }
 
 type mockSessionRepository struct {
+	mu       sync.Mutex
 	sessions map[uuid.UUID]*domain.Session
 }
 
@@ -81,24 +84,48 @@ func newMockSessionRepository() *mockSessionRepository {
 }
 
 func (m *mockSessionRepository) Create(_ context.Context, s *domain.Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.sessions[s.ID] = s
 	return nil
 }
 
+// GetByID and GetByTokenHash return copies, as a database-backed repository
+// would, so concurrent callers don't share a session.
 func (m *mockSessionRepository) GetByID(_ context.Context, id uuid.UUID) (*domain.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	s, ok := m.sessions[id]
 	if !ok {
 		return nil, ErrInvalidToken
 	}
-	return s, nil
+	session := *s
+	return &session, nil
+}
+
+func (m *mockSessionRepository) GetByTokenHash(_ context.Context, tokenHash string) (*domain.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, s := range m.sessions {
+		if s.TokenHash == tokenHash {
+			session := *s
+			return &session, nil
+		}
+	}
+	return nil, ErrInvalidToken
 }
 
 func (m *mockSessionRepository) Update(_ context.Context, s *domain.Session) error {
-	m.sessions[s.ID] = s
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	session := *s
+	m.sessions[s.ID] = &session
 	return nil
 }
 
 func (m *mockSessionRepository) Delete(_ context.Context, id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	delete(m.sessions, id)
 	return nil
 }
@@ -107,7 +134,14 @@ func (m *mockSessionRepository) GetByUserID(_ context.Context, _ uuid.UUID) ([]*
 	return []*domain.Session{}, nil
 }
 
-func (m *mockSessionRepository) DeleteByUserID(_ context.Context, _ uuid.UUID) error {
+func (m *mockSessionRepository) DeleteByUserID(_ context.Context, userID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, s := range m.sessions {
+		if s.UserID == userID {
+			delete(m.sessions, id)
+		}
+	}
 	return nil
 }
 
@@ -140,8 +174,14 @@ func (m *mockAPIKeyRepository) GetByUserID(_ context.Context, _ uuid.UUID) ([]*d
 	return []*domain.APIKey{}, nil
 }
 
-func (m *mockAPIKeyRepository) GetByPrefix(_ context.Context, _ string) ([]*domain.APIKey, error) {
-	return []*domain.APIKey{}, nil
+func (m *mockAPIKeyRepository) GetByPrefix(_ context.Context, prefix string) ([]*domain.APIKey, error) {
+	keys := []*domain.APIKey{}
+	for _, k := range m.keys {
+		if k.KeyPrefix == prefix {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
 }
 
 func (m *mockAPIKeyRepository) Update(_ context.Context, k *domain.APIKey) error {
@@ -192,6 +232,17 @@ func (m *mockAuditLogRepository) DeleteBefore(_ context.Context, _ time.Time) (i
 	return 0, nil
 }
 
+func (m *mockAuditLogRepository) Latest(_ context.Context) (*domain.AuditLog, error) {
+	if len(m.logs) == 0 {
+		return nil, nil
+	}
+	return m.logs[len(m.logs)-1], nil
+}
+
+func (m *mockAuditLogRepository) ListChain(_ context.Context) ([]*domain.AuditLog, error) {
+	return m.logs, nil
+}
+
 // Tests
 func TestDefaultAuthConfig(t *testing.T) {
 	cfg := DefaultAuthConfig()
@@ -341,6 +392,130 @@ func TestAuthService_Logout(t *testing.T) {
 	}
 }
 
+func TestAuthService_ValidateSession(t *testing.T) {
+	ctx := context.Background()
+	sessionRepo := newMockSessionRepository()
+	svc := NewAuthService(
+		newMockUserRepository(),
+		sessionRepo,
+		newMockAPIKeyRepository(),
+		newMockAuditLogRepository(),
+		DefaultAuthConfig(),
+		&mockLogger{},
+	)
+
+	created, _ := svc.CreateUser(ctx, "testuser", "test@example.com", "password123", domain.RoleOperator)
+	session, token, err := svc.Login(ctx, "testuser", "password123", "127.0.0.1", "TestAgent")
+	if err != nil {
+		t.Fatalf("Login error: %v", err)
+	}
+
+	user, validated, err := svc.ValidateSession(ctx, token)
+	if err != nil {
+		t.Fatalf("ValidateSession error: %v", err)
+	}
+	if user.ID != created.ID {
+		t.Errorf("Expected user %s, got %s", created.ID, user.ID)
+	}
+	if validated.ID != session.ID {
+		t.Errorf("Expected session %s, got %s", session.ID, validated.ID)
+	}
+	if !validated.ExpiresAt.Equal(session.ExpiresAt) {
+		t.Error("Expected a fixed expiry without a sliding window")
+	}
+
+	if _, _, err := svc.ValidateSession(ctx, "not-a-token"); err != ErrInvalidToken {
+		t.Errorf("Expected ErrInvalidToken for an unknown token, got %v", err)
+	}
+
+	// Expired
+	sessionRepo.sessions[session.ID].ExpiresAt = time.Now().Add(-time.Minute)
+	if _, _, err := svc.ValidateSession(ctx, token); err != ErrSessionExpired {
+		t.Errorf("Expected ErrSessionExpired, got %v", err)
+	}
+
+	// Revoked takes precedence over expired
+	_, token2, _ := svc.Login(ctx, "testuser", "password123", "127.0.0.1", "TestAgent")
+	_, session2, err := svc.ValidateSession(ctx, token2)
+	if err != nil {
+		t.Fatalf("ValidateSession error: %v", err)
+	}
+	if err := svc.Logout(ctx, session2.ID); err != nil {
+		t.Fatalf("Logout error: %v", err)
+	}
+	if _, _, err := svc.ValidateSession(ctx, token2); err != ErrSessionRevoked {
+		t.Errorf("Expected ErrSessionRevoked, got %v", err)
+	}
+}
+
+func TestAuthService_ValidateSession_SlidingWindow(t *testing.T) {
+	ctx := context.Background()
+	sessionRepo := newMockSessionRepository()
+	config := DefaultAuthConfig()
+	config.SessionDuration = time.Hour
+	config.SessionSlidingWindow = true
+	svc := NewAuthService(
+		newMockUserRepository(),
+		sessionRepo,
+		newMockAPIKeyRepository(),
+		newMockAuditLogRepository(),
+		config,
+		&mockLogger{},
+	)
+
+	_, _ = svc.CreateUser(ctx, "testuser", "test@example.com", "password123", domain.RoleOperator)
+	session, token, _ := svc.Login(ctx, "testuser", "password123", "127.0.0.1", "TestAgent")
+
+	// Nearly idle for the full hour
+	sessionRepo.sessions[session.ID].ExpiresAt = time.Now().Add(time.Minute)
+
+	_, validated, err := svc.ValidateSession(ctx, token)
+	if err != nil {
+		t.Fatalf("ValidateSession error: %v", err)
+	}
+	if time.Until(validated.ExpiresAt) < 59*time.Minute {
+		t.Errorf("Expected expiry to slide an hour ahead, got %s", time.Until(validated.ExpiresAt))
+	}
+	if !sessionRepo.sessions[session.ID].ExpiresAt.Equal(validated.ExpiresAt) {
+		t.Error("Expected the extended expiry to be persisted")
+	}
+}
+
+func TestAuthService_ValidateSession_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	config := DefaultAuthConfig()
+	config.SessionSlidingWindow = true
+	svc := NewAuthService(
+		newMockUserRepository(),
+		newMockSessionRepository(),
+		newMockAPIKeyRepository(),
+		newMockAuditLogRepository(),
+		config,
+		&mockLogger{},
+	)
+
+	_, _ = svc.CreateUser(ctx, "testuser", "test@example.com", "password123", domain.RoleOperator)
+	_, token, _ := svc.Login(ctx, "testuser", "password123", "127.0.0.1", "TestAgent")
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := svc.ValidateSession(ctx, token); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("ValidateSession error: %v", err)
+	}
+}
+
 func TestAuthService_CreateAPIKey(t *testing.T) {
 	userRepo := newMockUserRepository()
 	svc := NewAuthService(
@@ -393,3 +568,321 @@ func TestAuthService_GetUser(t *testing.T) {
 	}
 }
 
+
+func TestAuthService_AuditChain(t *testing.T) {
+	ctx := context.Background()
+	auditRepo := newMockAuditLogRepository()
+	newService := func() *AuthService {
+		return NewAuthService(
+			newMockUserRepository(),
+			newMockSessionRepository(),
+			newMockAPIKeyRepository(),
+			auditRepo,
+			DefaultAuthConfig(),
+			&mockLogger{},
+		)
+	}
+
+	svc := newService()
+	for _, name := range []string{"alice", "bob", "carol"} {
+		if _, err := svc.CreateUser(ctx, name, name+"@example.com", "password123", domain.RoleViewer); err != nil {
+			t.Fatalf("CreateUser error: %v", err)
+		}
+	}
+
+	// A restarted service continues the chain from the stored head
+	if _, err := newService().CreateUser(ctx, "dave", "dave@example.com", "password123", domain.RoleViewer); err != nil {
+		t.Fatalf("CreateUser error: %v", err)
+	}
+
+	if len(auditRepo.logs) != 4 {
+		t.Fatalf("expected 4 audit entries, got %d", len(auditRepo.logs))
+	}
+	if auditRepo.logs[0].PrevHash != "" {
+		t.Error("expected first entry to start the chain")
+	}
+
+	report, err := svc.VerifyAuditLog(ctx)
+	if err != nil {
+		t.Fatalf("VerifyAuditLog error: %v", err)
+	}
+	if !report.Valid || report.Checked != 4 {
+		t.Errorf("expected intact chain of 4 entries, got %+v", report)
+	}
+	if report.Head != auditRepo.logs[3].Hash {
+		t.Errorf("expected head %s, got %s", auditRepo.logs[3].Hash, report.Head)
+	}
+
+	auditRepo.logs[1].Action = "user.delete"
+	report, err = svc.VerifyAuditLog(ctx)
+	if err != nil {
+		t.Fatalf("VerifyAuditLog error: %v", err)
+	}
+	if report.Valid || len(report.Issues) != 1 || report.Issues[0].Index != 1 {
+		t.Errorf("expected tampering at entry 1, got %+v", report)
+	}
+}
+
+func TestAuthService_AuditSource(t *testing.T) {
+	ctx := context.Background()
+	auditRepo := newMockAuditLogRepository()
+	svc := NewAuthService(
+		newMockUserRepository(),
+		newMockSessionRepository(),
+		newMockAPIKeyRepository(),
+		auditRepo,
+		DefaultAuthConfig(),
+		&mockLogger{},
+	)
+
+	admin, _ := svc.CreateUser(ctx, "admin", "admin@example.com", "password123", domain.RoleAdmin)
+	alice, _ := svc.CreateUser(ctx, "alice", "alice@example.com", "password123", domain.RoleViewer)
+
+	// An administrator deleting alice is recorded as the actor
+	adminCtx := WithAuditSource(ctx, AuditSource{UserID: &admin.ID, IPAddress: "10.0.0.7"})
+	if err := svc.DeleteUser(adminCtx, alice.ID); err != nil {
+		t.Fatalf("DeleteUser error: %v", err)
+	}
+	entry := auditRepo.logs[len(auditRepo.logs)-1]
+	if entry.Action != "user.delete" || entry.UserID == nil || *entry.UserID != admin.ID {
+		t.Errorf("expected user.delete by admin, got %+v", entry)
+	}
+	if entry.ResourceID != alice.ID.String() || entry.IPAddress != "10.0.0.7" {
+		t.Errorf("expected alice's id and the client address, got %+v", entry)
+	}
+
+	// Before authentication the entry keeps the user it is about
+	anonCtx := WithAuditSource(ctx, AuditSource{IPAddress: "10.0.0.8"})
+	if _, _, err := svc.Login(anonCtx, "admin", "password123", "", ""); err != nil {
+		t.Fatalf("Login error: %v", err)
+	}
+	entry = auditRepo.logs[len(auditRepo.logs)-1]
+	if entry.Action != "user.login" || *entry.UserID != admin.ID || entry.IPAddress != "10.0.0.8" {
+		t.Errorf("expected login by admin from 10.0.0.8, got %+v", entry)
+	}
+}
+
+func TestAuthService_CheckAPIKeyInstancePermission(t *testing.T) {
+	ctx := context.Background()
+	svc := NewAuthService(
+		newMockUserRepository(),
+		newMockSessionRepository(),
+		newMockAPIKeyRepository(),
+		newMockAuditLogRepository(),
+		DefaultAuthConfig(),
+		&mockLogger{},
+	)
+	user, _ := svc.CreateUser(ctx, "testuser", "test@example.com", "password123", domain.RoleOperator)
+	allowed, other := uuid.New().String(), uuid.New().String()
+	grant := domain.InstanceGrant(domain.ResourceWorkflows, domain.PermissionWrite, allowed)
+	apiKey, _, err := svc.CreateAPIKey(ctx, user.ID, "deploy", []string{grant}, nil)
+	if err != nil {
+		t.Fatalf("CreateAPIKey error: %v", err)
+	}
+
+	if err := svc.CheckAPIKeyInstancePermission(ctx, apiKey, domain.ResourceWorkflows, domain.PermissionWrite, allowed); err != nil {
+		t.Errorf("expected the granted workflow to be writable, got %v", err)
+	}
+	if err := svc.CheckAPIKeyInstancePermission(ctx, apiKey, domain.ResourceWorkflows, domain.PermissionWrite, other); err != ErrPermissionDenied {
+		t.Errorf("expected another workflow to be denied, got %v", err)
+	}
+	// Without an instance grant for the action the owner's role applies
+	if err := svc.CheckAPIKeyInstancePermission(ctx, apiKey, domain.ResourceWorkflows, domain.PermissionDelete, other); err != nil {
+		t.Errorf("expected the role to allow deleting workflows, got %v", err)
+	}
+	if err := svc.CheckAPIKeyPermission(ctx, apiKey, domain.ResourceUsers, domain.PermissionWrite); err != ErrPermissionDenied {
+		t.Errorf("expected the role to deny writing users, got %v", err)
+	}
+}
+
+// countingMetricService counts recorded metrics by name.
+type countingMetricService struct {
+	counts map[string]float64
+}
+
+func (m *countingMetricService) Record(_ context.Context, name string, _ domain.MetricType, value float64, _ map[string]string) error {
+	m.counts[name] += value
+	return nil
+}
+
+func TestAuthService_APIKeyBruteForceLockout(t *testing.T) {
+	auditRepo := newMockAuditLogRepository()
+	cfg := DefaultAuthConfig()
+	cfg.MaxAPIKeyFailures = 3
+	svc := NewAuthService(newMockUserRepository(), newMockSessionRepository(), newMockAPIKeyRepository(), auditRepo, cfg, &mockLogger{})
+	metrics := &countingMetricService{counts: make(map[string]float64)}
+	svc.SetMetricService(metrics)
+
+	now := time.Now()
+	svc.apiKeyFails.now = func() time.Time { return now }
+
+	bg := context.Background()
+	user, _ := svc.CreateUser(bg, "deployer", "deploy@example.com", "password123", domain.RoleOperator)
+	_, key, err := svc.CreateAPIKey(bg, user.ID, "ci", []string{"read"}, nil)
+	if err != nil {
+		t.Fatalf("CreateAPIKey error: %v", err)
+	}
+	// Guesses share the prefix of the real key, as they would when it leaked
+	guess := key[:8] + "-not-the-right-key"
+
+	attacker := WithAuditSource(bg, AuditSource{IPAddress: "tcp:203.0.113.9:51234", Peer: "tcp:203.0.113.9"})
+	other := WithAuditSource(bg, AuditSource{IPAddress: "tcp:198.51.100.7:40000", Peer: "tcp:198.51.100.7"})
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := svc.ValidateAPIKey(attacker, guess); !errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("attempt %d: expected ErrInvalidToken, got %v", i+1, err)
+		}
+	}
+	if _, _, err := svc.ValidateAPIKey(attacker, guess); !errors.Is(err, ErrTooManyAPIKeyAttempts) {
+		t.Fatalf("expected the third failure to lock the source out, got %v", err)
+	}
+	if _, _, err := svc.ValidateAPIKey(attacker, guess); !errors.Is(err, ErrTooManyAPIKeyAttempts) {
+		t.Errorf("expected further guesses to be refused, got %v", err)
+	}
+
+	// Other sources are unaffected
+	if _, _, err := svc.ValidateAPIKey(other, guess); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected another source not to be locked out, got %v", err)
+	}
+	if _, _, err := svc.ValidateAPIKey(other, key); err != nil {
+		t.Errorf("expected the valid key to work from another source, got %v", err)
+	}
+	if _, _, err := svc.ValidateAPIKey(attacker, key); !errors.Is(err, ErrTooManyAPIKeyAttempts) {
+		t.Errorf("expected the guessed prefix to be refused even with the valid key, got %v", err)
+	}
+
+	if metrics.counts[APIKeyBruteForceMetric] != 1 {
+		t.Errorf("expected one brute force metric, got %v", metrics.counts)
+	}
+	var lockouts []*domain.AuditLog
+	for _, log := range auditRepo.logs {
+		if log.Action == "apikey.bruteforce" {
+			lockouts = append(lockouts, log)
+		}
+	}
+	if len(lockouts) != 1 || lockouts[0].Details["source"] != "tcp:203.0.113.9" || lockouts[0].ResourceID != key[:8] ||
+		lockouts[0].Details["prefixes"] != "1" {
+		t.Errorf("expected one brute force audit entry, got %+v", lockouts)
+	}
+
+	// Guessing random prefixes is counted like guessing one
+	sprayer := WithAuditSource(bg, AuditSource{Peer: "tcp:192.0.2.44"})
+	for i, guess := range []string{"forge_a1-guess", "forge_b2-guess"} {
+		if _, _, err := svc.ValidateAPIKey(sprayer, guess); !errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("spray %d: expected ErrInvalidToken, got %v", i+1, err)
+		}
+	}
+	if _, _, err := svc.ValidateAPIKey(sprayer, "forge_c3-guess"); !errors.Is(err, ErrTooManyAPIKeyAttempts) {
+		t.Errorf("expected guesses across prefixes to lock the source out, got %v", err)
+	}
+
+	// After the lockout, guesses are counted afresh
+	now = now.Add(cfg.APIKeyLockDuration)
+	if _, _, err := svc.ValidateAPIKey(attacker, guess); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected the lockout to end, got %v", err)
+	}
+	if _, _, err := svc.ValidateAPIKey(attacker, key); err != nil {
+		t.Errorf("expected the valid key to work once the lockout ends, got %v", err)
+	}
+}
+
+func TestAPIKeyLimiter_Cleanup(t *testing.T) {
+	l := newAPIKeyLimiter(3, time.Minute, 5*time.Minute)
+	now := time.Now()
+	l.now = func() time.Time { return now }
+
+	l.Fail("a", "forge_aa")
+	for i := 0; i < 3; i++ {
+		l.Fail("b", "forge_bb")
+	}
+
+	// a's window has passed; b is still locked out
+	now = now.Add(2 * time.Minute)
+	l.Fail("c", "forge_cc")
+	if _, ok := l.entries["a"]; ok {
+		t.Error("expected the expired entry to be dropped")
+	}
+	if !l.Locked("b") {
+		t.Error("expected the lockout to be kept")
+	}
+
+	now = now.Add(10 * time.Minute)
+	l.Fail("c", "forge_cc")
+	if len(l.entries) != 1 {
+		t.Errorf("expected only the fresh entry to remain, got %d", len(l.entries))
+	}
+}
+
+func TestAPIKeyLimiter_CountsAcrossPrefixes(t *testing.T) {
+	l := newAPIKeyLimiter(3, time.Minute, 5*time.Minute)
+
+	l.Fail("local", "forge_aa")
+	l.Fail("local", "forge_bb")
+	locked, report := l.Fail("local", "forge_bb")
+	if !locked {
+		t.Fatal("expected guesses at different prefixes to lock the source out")
+	}
+	if report.Failures != 3 || report.PrefixFailures != 2 || report.Prefixes != 2 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+
+	// A valid key only forgives failures against its own prefix
+	l = newAPIKeyLimiter(3, time.Minute, 5*time.Minute)
+	l.Fail("local", "forge_aa")
+	l.Fail("local", "forge_bb")
+	l.Succeed("local", "forge_aa")
+	l.Fail("local", "forge_cc")
+	if l.Locked("local") {
+		t.Error("expected the forgiven failure not to count")
+	}
+	if locked, _ := l.Fail("local", "forge_dd"); !locked {
+		t.Error("expected the other failures to keep counting after a success")
+	}
+}
+
+func TestAPIKeyLimiter_RefusesGuessedPrefixes(t *testing.T) {
+	l := newAPIKeyLimiter(2, time.Minute, 5*time.Minute)
+	now := time.Now()
+	l.now = func() time.Time { return now }
+
+	l.Fail("local", "forge_aa")
+	l.Fail("local", "forge_bb")
+	if !l.Refused("local", "forge_aa") || !l.Refused("local", "forge_bb") {
+		t.Error("expected the guessed prefixes to be refused during the lockout")
+	}
+	if l.Refused("local", "forge_cc") {
+		t.Error("expected a prefix never guessed at not to be refused")
+	}
+	if l.Refused("remote", "forge_aa") {
+		t.Error("expected other sources not to be refused")
+	}
+
+	now = now.Add(5 * time.Minute)
+	if l.Refused("local", "forge_aa") {
+		t.Error("expected the refusal to end with the lockout")
+	}
+}
+
+func TestAPIKeyLimiter_MaxEntries(t *testing.T) {
+	l := newAPIKeyLimiter(2, time.Minute, 5*time.Minute)
+	l.maxEntries = 3
+	now := time.Now()
+	l.now = func() time.Time { return now }
+
+	l.Fail("locked", "forge_aa")
+	l.Fail("locked", "forge_aa")
+	for _, source := range []string{"old", "new", "newer"} {
+		now = now.Add(time.Second)
+		l.Fail(source, "forge_aa")
+	}
+
+	if len(l.entries) != 3 {
+		t.Fatalf("expected the limiter to stay at 3 entries, got %d", len(l.entries))
+	}
+	if _, ok := l.entries["old"]; ok {
+		t.Error("expected the oldest source to be evicted")
+	}
+	if !l.Locked("locked") {
+		t.Error("expected a locked out source to be kept over unlocked ones")
+	}
+}
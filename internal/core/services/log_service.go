@@ -6,6 +6,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -20,41 +22,100 @@ type LogService struct {
 	logRepo         ports.LogRepository
 	parserRepo      ports.LogParserRepository
 	logToMetricRepo ports.LogToMetricRuleRepository
-	metricRepo      ports.MetricRepository
+	metrics         MetricBatchRecorder // Where log-to-metric rules record
 	logger          ports.Logger
 
-	// Cached parsers
-	mu      sync.RWMutex
-	parsers []*domain.LogParser
+	// Cached parsers, highest priority first, and log-to-metric rules
+	mu          sync.RWMutex
+	parsers     []*domain.LogParser
+	metricRules []*domain.LogToMetricRule
+
+	// Running totals of counter rules, by metric series
+	counterMu sync.Mutex
+	counters  map[string]float64
+
+	// Parser match counters for debugging patterns
+	statsMu       sync.Mutex
+	parserMatches map[uuid.UUID]int64
+	unparsed      int64
 
 	// In-memory buffer for batch ingestion
 	bufferMu      sync.Mutex
 	buffer        []*domain.LogEntry
 	bufferSize    int
 	flushInterval time.Duration
+
+	// Live subscribers for log tailing
+	subMu       sync.RWMutex
+	subscribers map[int]*logSubscriber
+	nextSubID   int
+
+	stopCh chan struct{}
+}
+
+// logSubscriber is a live tail registered through Subscribe.
+type logSubscriber struct {
+	filter ports.LogFilter
+	ch     chan *domain.LogEntry
 }
 
-// NewLogService creates a new log service.
+// NewLogService creates a new log service. Log-to-metric rules record into
+// metrics, normally the MetricService so its validation, relabeling,
+// transforms and query cache apply.
 func NewLogService(
 	logRepo ports.LogRepository,
 	parserRepo ports.LogParserRepository,
 	logToMetricRepo ports.LogToMetricRuleRepository,
-	metricRepo ports.MetricRepository,
+	metrics MetricBatchRecorder,
 	logger ports.Logger,
 ) *LogService {
 	return &LogService{
 		logRepo:         logRepo,
 		parserRepo:      parserRepo,
 		logToMetricRepo: logToMetricRepo,
-		metricRepo:      metricRepo,
+		metrics:         metrics,
 		logger:          logger,
 		parsers:         []*domain.LogParser{},
+		parserMatches:   make(map[uuid.UUID]int64),
+		counters:        make(map[string]float64),
 		buffer:          []*domain.LogEntry{},
 		bufferSize:      1000,
 		flushInterval:   5 * time.Second,
+		subscribers:     make(map[int]*logSubscriber),
+		stopCh:          make(chan struct{}),
 	}
 }
 
+// Start periodically reloads parsers and log-to-metric rules so changes
+// made outside this service are picked up without a restart.
+func (s *LogService) Start(ctx context.Context, refreshInterval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(refreshInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.stopCh:
+				return
+			case <-ticker.C:
+				if err := s.RefreshParsers(ctx); err != nil {
+					s.logger.Warn("failed to refresh log parsers", "error", err)
+				}
+				if err := s.RefreshMetricRules(ctx); err != nil {
+					s.logger.Warn("failed to refresh log-to-metric rules", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop stops the background refresh.
+func (s *LogService) Stop() {
+	close(s.stopCh)
+}
+
 // RefreshParsers reloads parsers from the repository.
 func (s *LogService) RefreshParsers(ctx context.Context) error {
 	if s.parserRepo == nil {
@@ -72,6 +133,9 @@ func (s *LogService) RefreshParsers(ctx context.Context) error {
 			s.logger.Warn("failed to compile parser", "parser", p.Name, "error", err)
 		}
 	}
+	sort.SliceStable(parsers, func(i, j int) bool {
+		return parsers[i].Priority > parsers[j].Priority
+	})
 
 	s.mu.Lock()
 	s.parsers = parsers
@@ -85,30 +149,67 @@ func (s *LogService) RefreshParsers(ctx context.Context) error {
 func (s *LogService) Ingest(ctx context.Context, entry *domain.LogEntry) error {
 	// Parse the log entry
 	s.parseEntry(entry)
-
-	// Check log-to-metric rules
-	if err := s.applyLogToMetricRules(ctx, entry); err != nil {
-		s.logger.Warn("failed to apply log-to-metric rules", "error", err)
-	}
-
-	// Persist
-	if s.logRepo != nil {
-		if err := s.logRepo.Create(ctx, entry); err != nil {
-			return fmt.Errorf("failed to persist log entry: %w", err)
-		}
+	if entry.Level == "" {
+		entry.Level = domain.LogLevelUnknown
 	}
 
-	return nil
+	return s.store(ctx, []*domain.LogEntry{entry})
 }
 
 // IngestBatch ingests multiple log entries.
 func (s *LogService) IngestBatch(ctx context.Context, entries []*domain.LogEntry) error {
 	for _, entry := range entries {
 		s.parseEntry(entry)
-		if err := s.applyLogToMetricRules(ctx, entry); err != nil {
-			s.logger.Warn("failed to apply log-to-metric rules", "error", err)
+		if entry.Level == "" {
+			entry.Level = domain.LogLevelUnknown
+		}
+	}
+	return s.store(ctx, entries)
+}
+
+// LogIngestResult summarizes an IngestLines call.
+type LogIngestResult struct {
+	Ingested int `json:"ingested"`
+	Parsed   int `json:"parsed"`
+	Unparsed int `json:"unparsed"`
+}
+
+// IngestLines ingests raw log lines from source. Each line is run through
+// the enabled parsers, highest priority first, and the first match extracts
+// the level, timestamp, service and attributes. Lines no parser matches are
+// stored as-is with level unknown.
+func (s *LogService) IngestLines(ctx context.Context, source string, lines []string) (*LogIngestResult, error) {
+	result := &LogIngestResult{}
+	entries := make([]*domain.LogEntry, 0, len(lines))
+	for _, line := range lines {
+		entry := domain.NewLogEntry("", line, source, "")
+		entry.Raw = line
+		if s.parseEntry(entry) {
+			result.Parsed++
+		} else {
+			result.Unparsed++
+		}
+		if entry.Level == "" {
+			entry.Level = domain.LogLevelUnknown
 		}
+		entries = append(entries, entry)
+	}
+
+	s.statsMu.Lock()
+	s.unparsed += int64(result.Unparsed)
+	s.statsMu.Unlock()
+
+	if err := s.store(ctx, entries); err != nil {
+		return nil, err
 	}
+	result.Ingested = len(entries)
+	return result, nil
+}
+
+// store applies log-to-metric rules, persists entries in one batch and
+// notifies live subscribers.
+func (s *LogService) store(ctx context.Context, entries []*domain.LogEntry) error {
+	s.recordRuleMetrics(ctx, entries)
 
 	if s.logRepo != nil {
 		if err := s.logRepo.CreateBatch(ctx, entries); err != nil {
@@ -116,9 +217,100 @@ func (s *LogService) IngestBatch(ctx context.Context, entries []*domain.LogEntry
 		}
 	}
 
+	for _, entry := range entries {
+		s.publish(entry)
+	}
 	return nil
 }
 
+// ParserStats returns how many entries each parser has matched and how many
+// raw lines matched no parser since the service started.
+func (s *LogService) ParserStats() (map[uuid.UUID]int64, int64) {
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+
+	matches := make(map[uuid.UUID]int64, len(s.parserMatches))
+	for id, n := range s.parserMatches {
+		matches[id] = n
+	}
+	return matches, s.unparsed
+}
+
+// Subscribe registers a live subscriber that receives newly ingested entries
+// matching filter. Slow subscribers drop entries rather than block ingestion.
+// The returned cancel function unregisters the subscriber and closes the channel.
+func (s *LogService) Subscribe(filter ports.LogFilter, bufferSize int) (<-chan *domain.LogEntry, func()) {
+	if bufferSize <= 0 {
+		bufferSize = 100
+	}
+	sub := &logSubscriber{
+		filter: filter,
+		ch:     make(chan *domain.LogEntry, bufferSize),
+	}
+
+	s.subMu.Lock()
+	id := s.nextSubID
+	s.nextSubID++
+	s.subscribers[id] = sub
+	s.subMu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			s.subMu.Lock()
+			delete(s.subscribers, id)
+			s.subMu.Unlock()
+			close(sub.ch)
+		})
+	}
+	return sub.ch, cancel
+}
+
+// publish fans an ingested entry out to matching subscribers.
+func (s *LogService) publish(entry *domain.LogEntry) {
+	s.subMu.RLock()
+	defer s.subMu.RUnlock()
+
+	for _, sub := range s.subscribers {
+		if !MatchesLogFilter(entry, sub.filter) {
+			continue
+		}
+		select {
+		case sub.ch <- entry:
+		default:
+			s.logger.Debug("dropping log entry for slow subscriber", "entry_id", entry.ID)
+		}
+	}
+}
+
+// MatchesLogFilter reports whether entry satisfies the non-time fields of filter.
+func MatchesLogFilter(entry *domain.LogEntry, filter ports.LogFilter) bool {
+	if filter.Level != "" && entry.Level != filter.Level {
+		return false
+	}
+	if filter.MinLevel != "" && domain.LogLevelPriority(entry.Level) < domain.LogLevelPriority(filter.MinLevel) {
+		return false
+	}
+	if filter.Source != "" && entry.Source != filter.Source {
+		return false
+	}
+	if filter.ServiceName != "" && entry.ServiceName != filter.ServiceName {
+		return false
+	}
+	if filter.TraceID != "" && entry.TraceID != filter.TraceID {
+		return false
+	}
+	if filter.Search != "" && !strings.Contains(strings.ToLower(entry.Message), strings.ToLower(filter.Search)) {
+		return false
+	}
+	for k, v := range filter.Attributes {
+		if entry.Attributes[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
 // BufferEntry adds an entry to the buffer for batch processing.
 func (s *LogService) BufferEntry(entry *domain.LogEntry) {
 	s.bufferMu.Lock()
@@ -151,8 +343,10 @@ func (s *LogService) FlushBuffer(ctx context.Context) error {
 	return s.IngestBatch(ctx, entries)
 }
 
-// parseEntry applies parsing rules to extract structured fields.
-func (s *LogService) parseEntry(entry *domain.LogEntry) {
+// parseEntry runs entry through the parsers in priority order. The first
+// parser that matches extracts its fields into the entry and no further
+// parsers are tried. It reports whether any parser matched.
+func (s *LogService) parseEntry(entry *domain.LogEntry) bool {
 	s.mu.RLock()
 	parsers := s.parsers
 	s.mu.RUnlock()
@@ -162,162 +356,402 @@ func (s *LogService) parseEntry(entry *domain.LogEntry) {
 			continue
 		}
 
+		var fields map[string]interface{}
 		switch parser.Type {
 		case domain.ParserTypeJSON:
-			s.parseJSON(entry)
-		case domain.ParserTypeRegex:
-			s.parseRegex(entry, parser)
+			fields = s.parseJSON(entry)
+		case domain.ParserTypeRegex, domain.ParserTypeGrok:
+			fields = s.parseRegex(entry, parser)
 		case domain.ParserTypeKeyValue:
-			s.parseKeyValue(entry)
+			fields = s.parseKeyValue(entry)
+		}
+		if fields == nil {
+			continue
+		}
+
+		entry.ParsedFields = fields
+		applyParsedFields(entry, parser, fields)
+
+		s.statsMu.Lock()
+		s.parserMatches[parser.ID]++
+		s.statsMu.Unlock()
+		return true
+	}
+	return false
+}
+
+// applyParsedFields promotes well-known parsed fields into the entry and
+// stores the rest as attributes. A parser's FieldMappings rename fields
+// first, e.g. {"lvl": "level"}.
+func applyParsedFields(entry *domain.LogEntry, parser *domain.LogParser, fields map[string]interface{}) {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := fields[name]
+		target := name
+		if mapped, ok := parser.FieldMappings[name]; ok {
+			target = mapped
+		}
+
+		str, ok := value.(string)
+		if !ok {
+			data, _ := json.Marshal(value)
+			str = string(data)
+		}
+
+		switch strings.ToLower(target) {
+		case "level", "severity", "lvl", "log.level":
+			entry.Level = domain.NormalizeLogLevel(str)
+		case "timestamp", "time", "ts", "@timestamp":
+			if t, ok := parseLogTimestamp(value); ok {
+				entry.Timestamp = t
+			} else {
+				entry.SetAttribute(target, str)
+			}
+		case "service", "service_name", "service.name":
+			entry.ServiceName = str
+		case "message", "msg":
+			entry.Message = str
+		case "trace_id", "traceid", "trace.id":
+			entry.TraceID = str
+		case "span_id", "spanid", "span.id":
+			entry.SpanID = str
+		default:
+			entry.SetAttribute(target, str)
 		}
 	}
 }
 
-// parseJSON parses JSON log messages.
-func (s *LogService) parseJSON(entry *domain.LogEntry) {
+// logTimestampLayouts are the timestamp formats recognized in parsed fields.
+var logTimestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"02/Jan/2006:15:04:05 -0700",
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+// parseLogTimestamp parses a timestamp field, either a string in one of the
+// known layouts or a Unix time in seconds, milliseconds or nanoseconds.
+func parseLogTimestamp(value interface{}) (time.Time, bool) {
+	var unix float64
+	switch v := value.(type) {
+	case float64:
+		unix = v
+	case string:
+		for _, layout := range logTimestampLayouts {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, true
+			}
+		}
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return time.Time{}, false
+		}
+		unix = n
+	default:
+		return time.Time{}, false
+	}
+
+	switch {
+	case unix <= 0:
+		return time.Time{}, false
+	case unix < 1e11:
+		return time.Unix(0, int64(unix*1e9)), true
+	case unix < 1e14:
+		return time.UnixMilli(int64(unix)), true
+	default:
+		return time.Unix(0, int64(unix)), true
+	}
+}
+
+// parseJSON parses messages that are JSON objects. Nested objects are
+// flattened into dotted keys, so {"log":{"level":"warn"}} yields
+// "log.level"; arrays are kept whole.
+func (s *LogService) parseJSON(entry *domain.LogEntry) map[string]interface{} {
 	var parsed map[string]interface{}
-	if err := json.Unmarshal([]byte(entry.Message), &parsed); err != nil {
-		return
+	if err := json.Unmarshal([]byte(strings.TrimSpace(entry.Message)), &parsed); err != nil || parsed == nil {
+		return nil
 	}
-	entry.ParsedFields = parsed
+	fields := make(map[string]interface{}, len(parsed))
+	flattenJSON("", parsed, fields)
+	return fields
 }
 
-// parseRegex parses log messages using regex.
-func (s *LogService) parseRegex(entry *domain.LogEntry, parser *domain.LogParser) {
+func flattenJSON(prefix string, object map[string]interface{}, fields map[string]interface{}) {
+	for key, value := range object {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if nested, ok := value.(map[string]interface{}); ok && len(nested) > 0 {
+			flattenJSON(key, nested, fields)
+			continue
+		}
+		fields[key] = value
+	}
+}
+
+// parseRegex extracts the named capture groups of the parser's pattern.
+func (s *LogService) parseRegex(entry *domain.LogEntry, parser *domain.LogParser) map[string]interface{} {
 	re := parser.GetCompiledRegex()
 	if re == nil {
-		return
+		return nil
 	}
 
 	matches := re.FindStringSubmatch(entry.Message)
 	if matches == nil {
-		return
-	}
-
-	names := re.SubexpNames()
-	if entry.ParsedFields == nil {
-		entry.ParsedFields = make(map[string]interface{})
+		return nil
 	}
 
-	for i, name := range names {
+	fields := make(map[string]interface{})
+	for i, name := range re.SubexpNames() {
 		if i > 0 && name != "" && i < len(matches) {
-			entry.ParsedFields[name] = matches[i]
+			fields[name] = matches[i]
 		}
 	}
+	return fields
 }
 
-// parseKeyValue parses key=value formatted logs.
-func (s *LogService) parseKeyValue(entry *domain.LogEntry) {
-	re := regexp.MustCompile(`(\w+)=("[^"]*"|\S+)`)
-	matches := re.FindAllStringSubmatch(entry.Message, -1)
+// keyValuePattern matches key=value pairs, with optionally quoted values.
+var keyValuePattern = regexp.MustCompile(`(\w+)=("[^"]*"|\S+)`)
 
-	if entry.ParsedFields == nil {
-		entry.ParsedFields = make(map[string]interface{})
+// parseKeyValue parses key=value formatted messages.
+func (s *LogService) parseKeyValue(entry *domain.LogEntry) map[string]interface{} {
+	matches := keyValuePattern.FindAllStringSubmatch(entry.Message, -1)
+	if len(matches) == 0 {
+		return nil
 	}
 
+	fields := make(map[string]interface{}, len(matches))
 	for _, match := range matches {
-		if len(match) >= 3 {
-			key := match[1]
-			value := strings.Trim(match[2], `"`)
-			entry.ParsedFields[key] = value
-		}
+		fields[match[1]] = strings.Trim(match[2], `"`)
 	}
+	return fields
 }
 
-// applyLogToMetricRules applies log-to-metric conversion rules.
-func (s *LogService) applyLogToMetricRules(ctx context.Context, entry *domain.LogEntry) error {
-	if s.logToMetricRepo == nil || s.metricRepo == nil {
+// RefreshMetricRules reloads the enabled log-to-metric rules. Rules whose
+// pattern no longer compiles are disabled with an error status instead of
+// being evaluated.
+func (s *LogService) RefreshMetricRules(ctx context.Context) error {
+	if s.logToMetricRepo == nil {
 		return nil
 	}
 
 	rules, err := s.logToMetricRepo.ListEnabled(ctx)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to load log-to-metric rules: %w", err)
 	}
 
+	active := make([]*domain.LogToMetricRule, 0, len(rules))
 	for _, rule := range rules {
-		if s.matchesRule(entry, rule) {
-			metric := s.createMetricFromLog(entry, rule)
-			if metric != nil {
-				if err := s.metricRepo.Record(ctx, metric); err != nil {
-					s.logger.Warn("failed to record log-to-metric", "rule", rule.Name, "error", err)
-				}
-			}
+		if err := rule.Compile(); err != nil {
+			s.disableMetricRule(ctx, rule, err)
+			continue
 		}
+		active = append(active, rule)
 	}
 
+	s.mu.Lock()
+	s.metricRules = active
+	s.mu.Unlock()
+
+	s.logger.Debug("refreshed log-to-metric rules", "count", len(active))
 	return nil
 }
 
-// matchesRule checks if a log entry matches a log-to-metric rule.
-func (s *LogService) matchesRule(entry *domain.LogEntry, rule *domain.LogToMetricRule) bool {
-	var fieldValue string
+// disableMetricRule marks a rule that cannot be evaluated as failed.
+func (s *LogService) disableMetricRule(ctx context.Context, rule *domain.LogToMetricRule, cause error) {
+	s.logger.Warn("disabling log-to-metric rule", "rule", rule.Name, "error", cause)
+	rule.Enabled = false
+	rule.Status = domain.LogToMetricRuleError
+	rule.LastError = cause.Error()
+	if err := s.logToMetricRepo.Update(ctx, rule); err != nil {
+		s.logger.Warn("failed to save log-to-metric rule status", "rule", rule.Name, "error", err)
+	}
+}
+
+// recordRuleMetrics evaluates the cached log-to-metric rules against
+// entries and records the resulting metrics in one batch.
+func (s *LogService) recordRuleMetrics(ctx context.Context, entries []*domain.LogEntry) {
+	if s.metrics == nil {
+		return
+	}
 
-	switch rule.MatchField {
-	case "level":
-		fieldValue = string(entry.Level)
-	case "message":
-		fieldValue = entry.Message
-	case "source":
-		fieldValue = entry.Source
-	case "service_name":
-		fieldValue = entry.ServiceName
-	default:
-		if entry.Attributes != nil {
-			fieldValue = entry.Attributes[rule.MatchField]
+	s.mu.RLock()
+	rules := s.metricRules
+	s.mu.RUnlock()
+	if len(rules) == 0 {
+		return
+	}
+
+	var metrics []*domain.Metric
+	for _, entry := range entries {
+		for _, rule := range rules {
+			if metric := s.evaluateRule(entry, rule); metric != nil {
+				metrics = append(metrics, metric)
+			}
+		}
+	}
+	if len(metrics) == 0 {
+		return
+	}
+	if err := s.metrics.RecordBatch(ctx, metrics); err != nil {
+		s.logger.Warn("failed to record log-to-metric metrics", "error", err)
+	}
+}
+
+// evaluateRule returns the metric rule produces for entry, or nil if the
+// entry does not match or carries no usable value. Counter rules record a
+// running total that grows by one per match, or by the extracted value
+// when the rule has a value field; gauge rules record the extracted value.
+func (s *LogService) evaluateRule(entry *domain.LogEntry, rule *domain.LogToMetricRule) *domain.Metric {
+	submatches, ok := s.matchesRule(entry, rule)
+	if !ok {
+		return nil
+	}
+
+	value := 1.0
+	if rule.ValueField != "" {
+		if value, ok = ruleValue(entry, rule, submatches); !ok {
+			return nil
 		}
 	}
 
+	tags := make(map[string]string)
+	for k, v := range rule.Tags {
+		tags[k] = v
+	}
+	for _, field := range rule.TagFields {
+		if v := logFieldValue(entry, field); v != "" {
+			tags[field] = v
+		}
+	}
+	if entry.Source != "" {
+		tags["source"] = entry.Source
+	}
+	if entry.ServiceName != "" {
+		tags["service"] = entry.ServiceName
+	}
+
+	if rule.MetricType == domain.MetricTypeCounter {
+		key := seriesKey(rule.MetricName, tags)
+		s.counterMu.Lock()
+		s.counters[key] += value
+		value = s.counters[key]
+		s.counterMu.Unlock()
+		return domain.NewMetric(rule.MetricName, rule.MetricType, value, tags)
+	}
+
+	// Gauges take the time of the log line so backfilled files plot correctly
+	metric := domain.NewMetric(rule.MetricName, rule.MetricType, value, tags)
+	if !entry.Timestamp.IsZero() {
+		metric.Timestamp = entry.Timestamp
+	}
+	return metric
+}
+
+// matchesRule checks if a log entry matches a log-to-metric rule and
+// returns the pattern's submatches, if any.
+func (s *LogService) matchesRule(entry *domain.LogEntry, rule *domain.LogToMetricRule) ([]string, bool) {
+	if rule.MinLevel != "" && domain.LogLevelPriority(entry.Level) < domain.LogLevelPriority(rule.MinLevel) {
+		return nil, false
+	}
+	if rule.ServiceName != "" && entry.ServiceName != rule.ServiceName {
+		return nil, false
+	}
+	if rule.Source != "" && entry.Source != rule.Source {
+		return nil, false
+	}
+
+	fieldValue := logFieldValue(entry, rule.MatchField)
+
 	// Check exact match values
 	for _, v := range rule.MatchValues {
 		if fieldValue == v {
-			return true
+			return nil, true
 		}
 	}
 
 	// Check regex pattern
-	if rule.MatchPattern != "" {
-		matched, _ := regexp.MatchString(rule.MatchPattern, fieldValue)
-		return matched
+	if re := rule.GetCompiledRegex(); re != nil {
+		submatches := re.FindStringSubmatch(fieldValue)
+		return submatches, submatches != nil
 	}
 
-	return false
+	return nil, false
 }
 
-// createMetricFromLog creates a metric from a log entry.
-func (s *LogService) createMetricFromLog(entry *domain.LogEntry, rule *domain.LogToMetricRule) *domain.Metric {
-	var value float64 = 1 // Default: count
-
-	// Extract value from field if specified
-	if rule.ValueField != "" && entry.ParsedFields != nil {
-		if v, ok := entry.ParsedFields[rule.ValueField]; ok {
-			switch t := v.(type) {
-			case float64:
-				value = t
-			case int:
-				value = float64(t)
-			case int64:
-				value = float64(t)
-			}
-		}
+// logFieldValue returns a named field of entry, falling back to its
+// attributes. An empty name means the message.
+func logFieldValue(entry *domain.LogEntry, field string) string {
+	switch field {
+	case "", "message":
+		return entry.Message
+	case "level":
+		return string(entry.Level)
+	case "source":
+		return entry.Source
+	case "service_name", "service":
+		return entry.ServiceName
+	default:
+		return entry.Attributes[field]
 	}
+}
 
-	// Build tags
-	tags := make(map[string]string)
-	for k, v := range rule.Tags {
-		tags[k] = v
+// ruleValue extracts the numeric value of a rule from a capture group of
+// its pattern (by name or number), a parsed field or an attribute.
+func ruleValue(entry *domain.LogEntry, rule *domain.LogToMetricRule, submatches []string) (float64, bool) {
+	if re := rule.GetCompiledRegex(); re != nil && submatches != nil {
+		index := re.SubexpIndex(rule.ValueField)
+		if n, err := strconv.Atoi(rule.ValueField); err == nil {
+			index = n
+		}
+		if index > 0 && index < len(submatches) {
+			v, err := strconv.ParseFloat(submatches[index], 64)
+			return v, err == nil
+		}
 	}
-	for _, field := range rule.TagFields {
-		if entry.Attributes != nil {
-			if v, ok := entry.Attributes[field]; ok {
-				tags[field] = v
-			}
+
+	if v, ok := entry.ParsedFields[rule.ValueField]; ok {
+		switch t := v.(type) {
+		case float64:
+			return t, true
+		case int:
+			return float64(t), true
+		case int64:
+			return float64(t), true
+		case string:
+			f, err := strconv.ParseFloat(t, 64)
+			return f, err == nil
 		}
 	}
-	tags["source"] = entry.Source
-	tags["service"] = entry.ServiceName
+	if v, ok := entry.Attributes[rule.ValueField]; ok {
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// seriesKey identifies a metric series by name and sorted tags.
+func seriesKey(name string, tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
 
-	return domain.NewMetric(rule.MetricName, rule.MetricType, value, tags)
+	var b strings.Builder
+	b.WriteString(name)
+	for _, k := range keys {
+		b.WriteString("|" + k + "=" + tags[k])
+	}
+	return b.String()
 }
 
 // Query searches for log entries.
@@ -370,10 +804,13 @@ func (s *LogService) CreateParser(ctx context.Context, parser *domain.LogParser)
 	if s.parserRepo == nil {
 		return fmt.Errorf("parser repository not configured")
 	}
-	if err := parser.Compile(); err != nil {
-		return fmt.Errorf("invalid parser pattern: %w", err)
+	if err := parser.Validate(); err != nil {
+		return fmt.Errorf("invalid parser: %w", err)
+	}
+	if err := s.parserRepo.Create(ctx, parser); err != nil {
+		return err
 	}
-	return s.parserRepo.Create(ctx, parser)
+	return s.RefreshParsers(ctx)
 }
 
 // ListParsers lists all log parsers.
@@ -384,11 +821,48 @@ func (s *LogService) ListParsers(ctx context.Context) ([]*domain.LogParser, erro
 	return s.parserRepo.List(ctx)
 }
 
+// CreateMetricRule validates and stores a log-to-metric rule. It takes
+// effect for entries ingested after it returns.
+func (s *LogService) CreateMetricRule(ctx context.Context, rule *domain.LogToMetricRule) error {
+	if s.logToMetricRepo == nil {
+		return fmt.Errorf("log-to-metric rule repository not configured")
+	}
+	if err := rule.Validate(); err != nil {
+		return fmt.Errorf("invalid rule: %w", err)
+	}
+	if err := s.logToMetricRepo.Create(ctx, rule); err != nil {
+		return err
+	}
+	return s.RefreshMetricRules(ctx)
+}
+
+// ListMetricRules lists all log-to-metric rules, including disabled ones.
+func (s *LogService) ListMetricRules(ctx context.Context) ([]*domain.LogToMetricRule, error) {
+	if s.logToMetricRepo == nil {
+		return []*domain.LogToMetricRule{}, nil
+	}
+	return s.logToMetricRepo.List(ctx)
+}
+
+// DeleteMetricRule deletes a log-to-metric rule.
+func (s *LogService) DeleteMetricRule(ctx context.Context, id uuid.UUID) error {
+	if s.logToMetricRepo == nil {
+		return fmt.Errorf("log-to-metric rule repository not configured")
+	}
+	if err := s.logToMetricRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	return s.RefreshMetricRules(ctx)
+}
+
 // DeleteParser deletes a log parser.
 func (s *LogService) DeleteParser(ctx context.Context, id uuid.UUID) error {
 	if s.parserRepo == nil {
 		return fmt.Errorf("parser repository not configured")
 	}
-	return s.parserRepo.Delete(ctx, id)
+	if err := s.parserRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	return s.RefreshParsers(ctx)
 }
 
@@ -47,6 +47,13 @@ func (m *mockLogRepository) CreateBatch(ctx context.Context, entries []*domain.L
 	return nil
 }
 
+func (m *mockLogRepository) CreateBatchBestEffort(ctx context.Context, entries []*domain.LogEntry) (*ports.BatchReport, error) {
+	if err := m.CreateBatch(ctx, entries); err != nil {
+		return nil, err
+	}
+	return &ports.BatchReport{Written: len(entries)}, nil
+}
+
 func (m *mockLogRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.LogEntry, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -137,8 +144,10 @@ func (m *mockLogParserRepository) ListEnabled(ctx context.Context) ([]*domain.Lo
 
 // mockLogToMetricRuleRepository for testing
 type mockLogToMetricRuleRepository struct {
-	mu    sync.RWMutex
-	rules []*domain.LogToMetricRule
+	mu               sync.RWMutex
+	rules            []*domain.LogToMetricRule
+	listEnabledCalls int
+	updates          int
 }
 
 func newMockLogToMetricRuleRepository() *mockLogToMetricRuleRepository {
@@ -148,6 +157,9 @@ func newMockLogToMetricRuleRepository() *mockLogToMetricRuleRepository {
 }
 
 func (m *mockLogToMetricRuleRepository) Create(ctx context.Context, r *domain.LogToMetricRule) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rules = append(m.rules, r)
 	return nil
 }
 
@@ -156,6 +168,9 @@ func (m *mockLogToMetricRuleRepository) GetByID(ctx context.Context, id uuid.UUI
 }
 
 func (m *mockLogToMetricRuleRepository) Update(ctx context.Context, r *domain.LogToMetricRule) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.updates++
 	return nil
 }
 
@@ -170,7 +185,16 @@ func (m *mockLogToMetricRuleRepository) List(ctx context.Context) ([]*domain.Log
 }
 
 func (m *mockLogToMetricRuleRepository) ListEnabled(ctx context.Context) ([]*domain.LogToMetricRule, error) {
-	return []*domain.LogToMetricRule{}, nil
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.listEnabledCalls++
+	result := make([]*domain.LogToMetricRule, 0)
+	for _, r := range m.rules {
+		if r.Enabled {
+			result = append(result, r)
+		}
+	}
+	return result, nil
 }
 
 func TestNewLogService(t *testing.T) {
@@ -178,9 +202,9 @@ func TestNewLogService(t *testing.T) {
 	logRepo := newMockLogRepository()
 	parserRepo := newMockLogParserRepository()
 	logToMetricRepo := newMockLogToMetricRuleRepository()
-	metricRepo := newMockMetricRepositoryForAlert()
+	metricSvc := NewMetricService(newMockMetricRepositoryForAlert(), logger, DefaultMetricServiceConfig())
 
-	svc := NewLogService(logRepo, parserRepo, logToMetricRepo, metricRepo, logger)
+	svc := NewLogService(logRepo, parserRepo, logToMetricRepo, metricSvc, logger)
 
 	if svc == nil {
 		t.Fatal("expected non-nil service")
@@ -194,8 +218,8 @@ func TestNewLogService(t *testing.T) {
 	if svc.logToMetricRepo == nil {
 		t.Error("log to metric repo not set correctly")
 	}
-	if svc.metricRepo == nil {
-		t.Error("metric repo not set correctly")
+	if svc.metrics == nil {
+		t.Error("metric service not set correctly")
 	}
 	if svc.logger == nil {
 		t.Error("logger not set correctly")
@@ -287,3 +311,388 @@ func TestLogService_Ingest_NoRepo(t *testing.T) {
 	}
 }
 
+
+func TestLogService_Subscribe(t *testing.T) {
+	svc := NewLogService(newMockLogRepository(), nil, nil, nil, &mockLogLogger{})
+
+	ch, cancel := svc.Subscribe(ports.LogFilter{ServiceName: "api", MinLevel: domain.LogLevelWarning}, 10)
+	defer cancel()
+
+	ctx := context.Background()
+	_ = svc.Ingest(ctx, domain.NewLogEntry(domain.LogLevelError, "other service", "stdout", "worker"))
+	_ = svc.Ingest(ctx, domain.NewLogEntry(domain.LogLevelInfo, "too quiet", "stdout", "api"))
+	want := domain.NewLogEntry(domain.LogLevelError, "connection refused", "stdout", "api")
+	if err := svc.Ingest(ctx, want); err != nil {
+		t.Fatalf("Ingest failed: %v", err)
+	}
+
+	select {
+	case got := <-ch:
+		if got.ID != want.ID {
+			t.Errorf("expected entry %s, got %s (%q)", want.ID, got.ID, got.Message)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("subscriber did not receive the published entry")
+	}
+
+	select {
+	case got := <-ch:
+		t.Errorf("unexpected extra entry %q", got.Message)
+	default:
+	}
+}
+
+func TestLogService_SubscribeCancel(t *testing.T) {
+	svc := NewLogService(nil, nil, nil, nil, &mockLogLogger{})
+
+	ch, cancel := svc.Subscribe(ports.LogFilter{}, 1)
+	cancel()
+	cancel() // safe to call twice
+
+	if _, ok := <-ch; ok {
+		t.Error("expected channel to be closed after cancel")
+	}
+
+	// Publishing after cancel must not panic or block
+	_ = svc.IngestBatch(context.Background(), []*domain.LogEntry{
+		domain.NewLogEntry(domain.LogLevelInfo, "a", "stdout", "api"),
+		domain.NewLogEntry(domain.LogLevelInfo, "b", "stdout", "api"),
+	})
+}
+
+func TestMatchesLogFilter(t *testing.T) {
+	entry := domain.NewLogEntry(domain.LogLevelWarning, "Disk usage HIGH", "syslog", "node")
+	entry.Attributes["host"] = "web-1"
+
+	tests := []struct {
+		name   string
+		filter ports.LogFilter
+		want   bool
+	}{
+		{"empty", ports.LogFilter{}, true},
+		{"level match", ports.LogFilter{Level: domain.LogLevelWarning}, true},
+		{"level mismatch", ports.LogFilter{Level: domain.LogLevelError}, false},
+		{"min level below", ports.LogFilter{MinLevel: domain.LogLevelInfo}, true},
+		{"min level above", ports.LogFilter{MinLevel: domain.LogLevelError}, false},
+		{"source", ports.LogFilter{Source: "stdout"}, false},
+		{"search case-insensitive", ports.LogFilter{Search: "disk usage high"}, true},
+		{"attribute match", ports.LogFilter{Attributes: map[string]string{"host": "web-1"}}, true},
+		{"attribute mismatch", ports.LogFilter{Attributes: map[string]string{"host": "web-2"}}, false},
+	}
+
+	for _, tt := range tests {
+		if got := MatchesLogFilter(entry, tt.filter); got != tt.want {
+			t.Errorf("%s: MatchesLogFilter = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLogService_IngestLines(t *testing.T) {
+	logRepo := newMockLogRepository()
+	parserRepo := newMockLogParserRepository()
+	ctx := context.Background()
+
+	access := domain.NewLogParser("access", domain.ParserTypeRegex,
+		`^(?P<ts>\S+) (?P<level>[A-Z]+) \[(?P<service>[^\]]+)\] (?P<message>.*)$`)
+	access.Priority = 10
+	kv := domain.NewLogParser("kv", domain.ParserTypeKeyValue, "")
+	kv.FieldMappings = map[string]string{"sev": "level"}
+	jsonParser := domain.NewLogParser("json", domain.ParserTypeJSON, "")
+	jsonParser.Priority = 5
+	for _, p := range []*domain.LogParser{kv, access, jsonParser} {
+		parserRepo.Create(ctx, p)
+	}
+
+	svc := NewLogService(logRepo, parserRepo, nil, nil, &mockLogLogger{})
+	if err := svc.RefreshParsers(ctx); err != nil {
+		t.Fatalf("RefreshParsers failed: %v", err)
+	}
+
+	result, err := svc.IngestLines(ctx, "app", []string{
+		"2024-01-01T10:00:00Z WARN [checkout] payment slow",
+		`{"level":"error","msg":"boom","service":"api","user":"42"}`,
+		"sev=Err user=7 msg=\"retry failed\"",
+		"just some text",
+	})
+	if err != nil {
+		t.Fatalf("IngestLines failed: %v", err)
+	}
+	if result.Ingested != 4 || result.Parsed != 3 || result.Unparsed != 1 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if len(logRepo.entries) != 4 {
+		t.Fatalf("expected 4 stored entries, got %d", len(logRepo.entries))
+	}
+
+	regex := logRepo.entries[0]
+	if regex.Level != domain.LogLevelWarning || regex.ServiceName != "checkout" || regex.Message != "payment slow" {
+		t.Errorf("unexpected regex entry: %+v", regex)
+	}
+	if want := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC); !regex.Timestamp.Equal(want) {
+		t.Errorf("expected timestamp %v, got %v", want, regex.Timestamp)
+	}
+	if regex.Raw != "2024-01-01T10:00:00Z WARN [checkout] payment slow" {
+		t.Errorf("expected raw line to be kept, got %q", regex.Raw)
+	}
+
+	js := logRepo.entries[1]
+	if js.Level != domain.LogLevelError || js.Message != "boom" || js.ServiceName != "api" || js.Attributes["user"] != "42" {
+		t.Errorf("unexpected json entry: %+v", js)
+	}
+
+	// The kv parser has the lowest priority, so it only sees lines the others rejected
+	if kvEntry := logRepo.entries[2]; kvEntry.Level != domain.LogLevelError || kvEntry.Message != "retry failed" {
+		t.Errorf("unexpected key/value entry: %+v", kvEntry)
+	}
+
+	if unknown := logRepo.entries[3]; unknown.Level != domain.LogLevelUnknown || unknown.Message != "just some text" {
+		t.Errorf("expected unparsed line with level unknown, got %+v", unknown)
+	}
+
+	matches, unparsed := svc.ParserStats()
+	if matches[access.ID] != 1 || matches[jsonParser.ID] != 1 || matches[kv.ID] != 1 || unparsed != 1 {
+		t.Errorf("unexpected parser stats %v, unparsed %d", matches, unparsed)
+	}
+}
+
+func TestLogService_JSONParserFieldPromotion(t *testing.T) {
+	logRepo := newMockLogRepository()
+	parserRepo := newMockLogParserRepository()
+	ctx := context.Background()
+
+	jsonParser := domain.NewLogParser("json", domain.ParserTypeJSON, "")
+	jsonParser.SourceFilter = "api"
+	jsonParser.Priority = 10
+	kv := domain.NewLogParser("kv", domain.ParserTypeKeyValue, "")
+	parserRepo.Create(ctx, kv)
+	parserRepo.Create(ctx, jsonParser)
+
+	svc := NewLogService(logRepo, parserRepo, nil, nil, &mockLogLogger{})
+	if err := svc.RefreshParsers(ctx); err != nil {
+		t.Fatalf("RefreshParsers failed: %v", err)
+	}
+
+	lines := []string{
+		`{"@timestamp":"2024-03-01T12:00:00Z","log":{"level":"warn"},"message":"slow query id=7","trace":{"id":"abc"},"span":{"id":"def"},"http":{"status":503},"tags":["a","b"]}`,
+		` {"level":"info","msg":"ok","traceId":"t1","spanId":"s1","ts":1709294400000} `,
+		"plain text line",
+		`["not","an","object"]`,
+	}
+	if _, err := svc.IngestLines(ctx, "api", lines); err != nil {
+		t.Fatalf("IngestLines failed: %v", err)
+	}
+	// The JSON parser only applies to its source; kv sees the same line elsewhere
+	if _, err := svc.IngestLines(ctx, "worker", []string{`{"msg":"a=b c"}`}); err != nil {
+		t.Fatalf("IngestLines failed: %v", err)
+	}
+	if len(logRepo.entries) != 5 {
+		t.Fatalf("expected 5 stored entries, got %d", len(logRepo.entries))
+	}
+
+	ecs := logRepo.entries[0]
+	if ecs.Level != domain.LogLevelWarning || ecs.Message != "slow query id=7" || ecs.TraceID != "abc" || ecs.SpanID != "def" {
+		t.Errorf("unexpected promoted fields: %+v", ecs)
+	}
+	if want := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC); !ecs.Timestamp.Equal(want) {
+		t.Errorf("expected timestamp %v, got %v", want, ecs.Timestamp)
+	}
+	if ecs.Attributes["http.status"] != "503" || ecs.Attributes["tags"] != `["a","b"]` {
+		t.Errorf("expected remaining fields as attributes, got %v", ecs.Attributes)
+	}
+	if _, ok := ecs.Attributes["message"]; ok {
+		t.Errorf("promoted fields must not be duplicated in attributes: %v", ecs.Attributes)
+	}
+	if ecs.Attributes["id"] != "" {
+		t.Errorf("JSON line must not fall through to the key/value parser: %v", ecs.Attributes)
+	}
+
+	short := logRepo.entries[1]
+	if short.Level != domain.LogLevelInfo || short.Message != "ok" || short.TraceID != "t1" || short.SpanID != "s1" ||
+		!short.Timestamp.Equal(time.UnixMilli(1709294400000)) {
+		t.Errorf("unexpected promoted fields: %+v", short)
+	}
+
+	if plain := logRepo.entries[2]; plain.Message != "plain text line" || plain.Level != domain.LogLevelUnknown || len(plain.Attributes) != 0 {
+		t.Errorf("expected plaintext line to be stored unparsed, got %+v", plain)
+	}
+	if array := logRepo.entries[3]; array.Message != lines[3] || array.ParsedFields != nil {
+		t.Errorf("expected non-object JSON to fall back unparsed, got %+v", array)
+	}
+	if worker := logRepo.entries[4]; worker.Attributes["a"] != "b" || worker.Message != `{"msg":"a=b c"}` {
+		t.Errorf("expected key/value parsing outside the JSON parser's source, got %+v", worker)
+	}
+}
+
+func TestLogService_IngestLinesSourceFilter(t *testing.T) {
+	logRepo := newMockLogRepository()
+	parserRepo := newMockLogParserRepository()
+	ctx := context.Background()
+
+	nginx := domain.NewLogParser("nginx", domain.ParserTypeKeyValue, "")
+	nginx.SourceFilter = "nginx"
+	parserRepo.Create(ctx, nginx)
+
+	svc := NewLogService(logRepo, parserRepo, nil, nil, &mockLogLogger{})
+	if err := svc.RefreshParsers(ctx); err != nil {
+		t.Fatalf("RefreshParsers failed: %v", err)
+	}
+
+	if result, _ := svc.IngestLines(ctx, "api", []string{"level=info"}); result.Parsed != 0 {
+		t.Errorf("expected parser to skip other sources, got %+v", result)
+	}
+	if result, _ := svc.IngestLines(ctx, "nginx-edge", []string{"level=info"}); result.Parsed != 1 {
+		t.Errorf("expected parser to match its source, got %+v", result)
+	}
+}
+
+func TestLogService_CreateParserValidates(t *testing.T) {
+	svc := NewLogService(nil, newMockLogParserRepository(), nil, nil, &mockLogLogger{})
+	ctx := context.Background()
+
+	if err := svc.CreateParser(ctx, domain.NewLogParser("bad", domain.ParserTypeRegex, "(unclosed")); err == nil {
+		t.Error("expected invalid regex to be rejected")
+	}
+	if err := svc.CreateParser(ctx, domain.NewLogParser("kv", domain.ParserTypeKeyValue, "")); err != nil {
+		t.Fatalf("CreateParser failed: %v", err)
+	}
+
+	// Created parsers take effect without a manual refresh
+	if result, _ := svc.IngestLines(ctx, "app", []string{"level=warn"}); result.Parsed != 1 {
+		t.Errorf("expected new parser to be active, got %+v", result)
+	}
+}
+
+func TestLogService_MetricRules(t *testing.T) {
+	ruleRepo := newMockLogToMetricRuleRepository()
+	metricRepo := &mockMetricRepository{}
+	metricSvc := NewMetricService(metricRepo, &mockLogLogger{}, DefaultMetricServiceConfig())
+	// Rule metrics go through the metric service, so its ingest transforms apply
+	if err := metricSvc.SetTransformRules([]domain.MetricTransformRule{{
+		Metric: "app.latency_ms",
+		Scale:  2,
+		Stage:  domain.MetricTransformIngest,
+	}}); err != nil {
+		t.Fatalf("SetTransformRules failed: %v", err)
+	}
+	svc := NewLogService(newMockLogRepository(), nil, ruleRepo, metricSvc, &mockLogLogger{})
+	ctx := context.Background()
+
+	errRule := domain.NewLogToMetricRule("errors", "message", "ERROR", "app.error.count", domain.MetricTypeCounter)
+	errRule.Tags["team"] = "core"
+	if err := svc.CreateMetricRule(ctx, errRule); err != nil {
+		t.Fatalf("CreateMetricRule failed: %v", err)
+	}
+	latency := domain.NewLogToMetricRule("latency", "message", `took (?P<ms>\d+)ms`, "app.latency_ms", domain.MetricTypeGauge)
+	latency.ValueField = "ms"
+	latency.ServiceName = "api"
+	if err := svc.CreateMetricRule(ctx, latency); err != nil {
+		t.Fatalf("CreateMetricRule failed: %v", err)
+	}
+	fatal := domain.NewLogToMetricRule("fatal", "level", ".*", "app.fatal.count", domain.MetricTypeCounter)
+	fatal.MinLevel = "fatal"
+	if err := svc.CreateMetricRule(ctx, fatal); err != nil {
+		t.Fatalf("CreateMetricRule failed: %v", err)
+	}
+	calls := ruleRepo.listEnabledCalls
+
+	old := time.Now().Add(-time.Hour)
+	slow := domain.NewLogEntry(domain.LogLevelInfo, "request took 250ms", "stdout", "api")
+	slow.Timestamp = old
+	entries := []*domain.LogEntry{
+		domain.NewLogEntry(domain.LogLevelError, "ERROR disk full", "stdout", "api"),
+		domain.NewLogEntry(domain.LogLevelError, "ERROR disk still full", "stdout", "api"),
+		slow,
+		domain.NewLogEntry(domain.LogLevelInfo, "request took 90ms", "stdout", "worker"),
+		domain.NewLogEntry(domain.LogLevelError, "ERROR bad", "stdout", "api"),
+	}
+	if err := svc.IngestBatch(ctx, entries); err != nil {
+		t.Fatalf("IngestBatch failed: %v", err)
+	}
+
+	if ruleRepo.listEnabledCalls != calls {
+		t.Errorf("expected rules to be served from the cache, got %d extra loads", ruleRepo.listEnabledCalls-calls)
+	}
+	if metricRepo.recordBatchCalls != 1 {
+		t.Errorf("expected one batch write, got %d", metricRepo.recordBatchCalls)
+	}
+
+	var counts []float64
+	var gauges []*domain.Metric
+	for _, m := range metricRepo.metrics {
+		switch m.Name {
+		case "app.error.count":
+			counts = append(counts, m.Value)
+			if m.Tags["team"] != "core" || m.Tags["service"] != "api" {
+				t.Errorf("unexpected counter tags: %v", m.Tags)
+			}
+		case "app.latency_ms":
+			gauges = append(gauges, m)
+		case "app.fatal.count":
+			t.Errorf("expected fatal rule not to match error entries")
+		}
+	}
+	// Counters record a running total
+	if len(counts) != 3 || counts[0] != 1 || counts[1] != 2 || counts[2] != 3 {
+		t.Errorf("expected counter totals [1 2 3], got %v", counts)
+	}
+	// The worker entry is filtered out by the rule's service
+	if len(gauges) != 1 || gauges[0].Value != 500 || !gauges[0].Timestamp.Equal(old) {
+		t.Errorf("expected one 250ms gauge, transformed to 500, at the log's time, got %+v", gauges)
+	}
+}
+
+func TestLogService_MetricRuleSourceFilter(t *testing.T) {
+	metricRepo := &mockMetricRepository{}
+	svc := NewLogService(newMockLogRepository(), nil, newMockLogToMetricRuleRepository(), metricRepo, &mockLogLogger{})
+	ctx := context.Background()
+
+	rule := domain.NewLogToMetricRule("5xx", "message", `status=5\d\d`, "http.errors", domain.MetricTypeCounter)
+	rule.Source = "nginx"
+	if err := svc.CreateMetricRule(ctx, rule); err != nil {
+		t.Fatalf("CreateMetricRule failed: %v", err)
+	}
+
+	entries := []*domain.LogEntry{
+		domain.NewLogEntry(domain.LogLevelInfo, "GET / status=502", "nginx", ""),
+		domain.NewLogEntry(domain.LogLevelInfo, "GET / status=503", "haproxy", ""),
+		domain.NewLogEntry(domain.LogLevelInfo, "GET / status=200", "nginx", ""),
+	}
+	if err := svc.IngestBatch(ctx, entries); err != nil {
+		t.Fatalf("IngestBatch failed: %v", err)
+	}
+
+	if len(metricRepo.metrics) != 1 {
+		t.Fatalf("expected one metric from the nginx entry, got %d", len(metricRepo.metrics))
+	}
+	if m := metricRepo.metrics[0]; m.Value != 1 || m.Tags["source"] != "nginx" {
+		t.Errorf("unexpected metric %+v", m)
+	}
+}
+
+func TestLogService_BrokenMetricRuleIsDisabled(t *testing.T) {
+	ruleRepo := newMockLogToMetricRuleRepository()
+	metricRepo := &mockMetricRepository{}
+	svc := NewLogService(nil, nil, ruleRepo, metricRepo, &mockLogLogger{})
+	ctx := context.Background()
+
+	if err := svc.CreateMetricRule(ctx, domain.NewLogToMetricRule("bad", "message", "(", "x", domain.MetricTypeCounter)); err == nil {
+		t.Error("expected invalid pattern to be rejected on create")
+	}
+
+	// A rule that was stored with a broken pattern is disabled on load
+	broken := domain.NewLogToMetricRule("broken", "message", "([", "x", domain.MetricTypeCounter)
+	ruleRepo.Create(ctx, broken)
+	if err := svc.RefreshMetricRules(ctx); err != nil {
+		t.Fatalf("RefreshMetricRules failed: %v", err)
+	}
+	if broken.Enabled || broken.Status != domain.LogToMetricRuleError || broken.LastError == "" || ruleRepo.updates != 1 {
+		t.Errorf("expected rule to be disabled with an error, got %+v", broken)
+	}
+
+	if err := svc.Ingest(ctx, domain.NewLogEntry(domain.LogLevelInfo, "([", "stdout", "api")); err != nil {
+		t.Errorf("expected ingestion to continue, got %v", err)
+	}
+	if len(metricRepo.metrics) != 0 {
+		t.Errorf("expected no metrics from a disabled rule, got %d", len(metricRepo.metrics))
+	}
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/ports"
+)
+
+// ComparisonPoint is one bucket of a series comparison. Left or Right is
+// nil when that series has no samples in the bucket, and Diff is only set
+// when both do.
+type ComparisonPoint struct {
+	Timestamp time.Time
+	Left      *float64
+	Right     *float64
+	Diff      *float64 // Left - Right
+}
+
+// SeriesComparison is the result of CompareSeries.
+type SeriesComparison struct {
+	Step   time.Duration
+	Points []ComparisonPoint
+}
+
+// CompareSeries aggregates two series over the same range and step and
+// aligns them by bucket, for example one metric in prod and in staging. Both
+// queries use the left query's time range, step and aggregation, so samples
+// taken at different times within a step land in the same bucket. When no
+// step is given one is chosen from the length of the range.
+func (s *MetricService) CompareSeries(ctx context.Context, left, right ports.MetricQuery) (*SeriesComparison, error) {
+	if left.EndTime.IsZero() {
+		left.EndTime = time.Now()
+	}
+	if !left.EndTime.After(left.StartTime) {
+		return nil, fmt.Errorf("end time must be after start time")
+	}
+	if left.Step <= 0 {
+		left.Step = stepForRange(left.EndTime.Sub(left.StartTime))
+	}
+	if left.Aggregation == ports.AggregationNone {
+		left.Aggregation = ports.AggregationAvg
+	}
+	if right.Name == "" {
+		right.Name = left.Name
+	}
+	right.StartTime, right.EndTime = left.StartTime, left.EndTime
+	right.Step, right.Aggregation = left.Step, left.Aggregation
+	left.Limit, right.Limit = 0, 0
+
+	leftResults, err := s.QueryWithAggregation(ctx, left)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query left series: %w", err)
+	}
+	rightResults, err := s.QueryWithAggregation(ctx, right)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query right series: %w", err)
+	}
+
+	return &SeriesComparison{
+		Step:   left.Step,
+		Points: alignSeries(leftResults, rightResults),
+	}, nil
+}
+
+// alignSeries merges two bucketed series into points ordered by timestamp.
+func alignSeries(left, right []ports.AggregatedResult) []ComparisonPoint {
+	byTime := make(map[int64]*ComparisonPoint)
+	point := func(ts time.Time) *ComparisonPoint {
+		p, ok := byTime[ts.UnixMilli()]
+		if !ok {
+			p = &ComparisonPoint{Timestamp: ts}
+			byTime[ts.UnixMilli()] = p
+		}
+		return p
+	}
+	for _, r := range left {
+		v := r.Value
+		point(r.Timestamp).Left = &v
+	}
+	for _, r := range right {
+		v := r.Value
+		point(r.Timestamp).Right = &v
+	}
+
+	points := make([]ComparisonPoint, 0, len(byTime))
+	for _, p := range byTime {
+		if p.Left != nil && p.Right != nil {
+			diff := *p.Left - *p.Right
+			p.Diff = &diff
+		}
+		points = append(points, *p)
+	}
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Timestamp.Before(points[j].Timestamp)
+	})
+	return points
+}
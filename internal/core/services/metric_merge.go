@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+)
+
+// rollupResolutions lists the downsampling resolutions, coarsest first.
+var rollupResolutions = []string{"1d", "1h", "5m", "1m"}
+
+// QueryMerged queries a time range that may reach past raw retention. Older
+// buckets are served from downsampled rollups and the rest from raw points,
+// combined into one series with a single result per step.
+//
+// Rollup windows are only used up to the first raw bucket in the range, so
+// history that exists both raw and downsampled is never counted twice. When
+// no step is given one is chosen from the length of the range.
+func (s *MetricService) QueryMerged(ctx context.Context, query ports.MetricQuery) ([]ports.AggregatedResult, error) {
+	if query.EndTime.IsZero() {
+		query.EndTime = time.Now()
+	}
+	if !query.EndTime.After(query.StartTime) {
+		return nil, fmt.Errorf("end time must be after start time")
+	}
+	if query.Step <= 0 {
+		query.Step = stepForRange(query.EndTime.Sub(query.StartTime))
+	}
+	if query.Aggregation == ports.AggregationNone {
+		query.Aggregation = ports.AggregationAvg
+	}
+
+	s.flush(ctx)
+	rollups, _, raw, err := s.mergedBuckets(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	results := rebucket(append(rollups, raw...), query.Step, query.Aggregation)
+	if query.Limit > 0 && len(results) > query.Limit {
+		results = results[:query.Limit]
+	}
+	s.applyAggregatedTransform(query.Name, query.Tags, query.Aggregation, results)
+	return results, nil
+}
+
+// mergedBuckets reads the buckets QueryMerged combines: rollups up to the
+// first raw bucket in the range, the resolution they were read at, and the
+// raw buckets. The query must already have an end time and a step.
+func (s *MetricService) mergedBuckets(ctx context.Context, query ports.MetricQuery) ([]ports.AggregatedResult, string, []ports.AggregatedResult, error) {
+	// Raw buckets are read at the rollup granularity (or the step, if finer)
+	// so the raw/rollup boundary falls on a rollup window edge.
+	candidates := rollupCandidates(query.Step)
+	fineStep, _ := parseResolution(candidates[len(candidates)-1])
+	if query.Step < fineStep {
+		fineStep = query.Step
+	}
+
+	rawQuery := query
+	rawQuery.Step = fineStep
+	rawQuery.Limit = 0
+	raw, err := s.cachedAggregation(ctx, rawQuery)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("failed to query raw metrics: %w", err)
+	}
+
+	rawStart := query.EndTime
+	if len(raw) > 0 {
+		rawStart = raw[0].Timestamp
+	}
+
+	var buckets []ports.AggregatedResult
+	var source string
+	if rawStart.After(query.StartTime) {
+		rollupQuery := query
+		rollupQuery.EndTime = rawStart
+		rollupQuery.Limit = 0
+		for _, resolution := range candidates {
+			aggs, err := s.repo.QueryAggregated(ctx, rollupQuery, resolution)
+			if err != nil {
+				return nil, "", nil, fmt.Errorf("failed to query %s rollups: %w", resolution, err)
+			}
+			if len(aggs) == 0 {
+				continue
+			}
+			buckets = rollupBuckets(aggs)
+			source = resolution
+			break
+		}
+	}
+	return buckets, source, raw, nil
+}
+
+// rollupBuckets converts rollup windows to buckets.
+func rollupBuckets(aggs []*domain.AggregatedMetric) []ports.AggregatedResult {
+	buckets := make([]ports.AggregatedResult, 0, len(aggs))
+	for _, agg := range aggs {
+		buckets = append(buckets, ports.AggregatedResult{
+			Timestamp: agg.WindowStart,
+			Value:     agg.Avg,
+			Count:     agg.Count,
+			Min:       agg.Min,
+			Max:       agg.Max,
+			Sum:       agg.Sum,
+			Avg:       agg.Avg,
+		})
+	}
+	return buckets
+}
+
+// stepForRange picks a rollup resolution that keeps a range to a few
+// hundred buckets.
+func stepForRange(r time.Duration) time.Duration {
+	switch {
+	case r <= 6*time.Hour:
+		return time.Minute
+	case r <= 24*time.Hour:
+		return 5 * time.Minute
+	case r <= 30*24*time.Hour:
+		return time.Hour
+	default:
+		return 24 * time.Hour
+	}
+}
+
+// rollupCandidates returns the resolutions whose windows fit evenly into
+// step, coarsest first. Steps finer than a minute fall back to 1m rollups.
+func rollupCandidates(step time.Duration) []string {
+	var candidates []string
+	for _, resolution := range rollupResolutions {
+		d, _ := parseResolution(resolution)
+		if d <= step && step%d == 0 {
+			candidates = append(candidates, resolution)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = []string{"1m"}
+	}
+	return candidates
+}
+
+// rebucket combines time-ordered buckets into buckets of the given step.
+func rebucket(buckets []ports.AggregatedResult, step time.Duration, agg ports.AggregationType) []ports.AggregatedResult {
+	stepMs := step.Milliseconds()
+	var results []ports.AggregatedResult
+	for _, b := range buckets {
+		ts := time.UnixMilli((b.Timestamp.UnixMilli() / stepMs) * stepMs)
+		n := len(results)
+		if n == 0 || !results[n-1].Timestamp.Equal(ts) {
+			b.Timestamp = ts
+			results = append(results, b)
+			continue
+		}
+
+		r := &results[n-1]
+		r.Count += b.Count
+		r.Sum += b.Sum
+		if b.Min < r.Min {
+			r.Min = b.Min
+		}
+		if b.Max > r.Max {
+			r.Max = b.Max
+		}
+		if agg == ports.AggregationLast {
+			r.Value = b.Value
+		}
+	}
+
+	for i := range results {
+		r := &results[i]
+		if r.Count > 0 {
+			r.Avg = r.Sum / float64(r.Count)
+		}
+		switch agg {
+		case ports.AggregationSum:
+			r.Value = r.Sum
+		case ports.AggregationMin:
+			r.Value = r.Min
+		case ports.AggregationMax:
+			r.Value = r.Max
+		case ports.AggregationCount:
+			r.Value = float64(r.Count)
+		case ports.AggregationFirst, ports.AggregationLast:
+		default:
+			r.Value = r.Avg
+		}
+	}
+	return results
+}
+
+// applyAggregatedTransform applies the query stage transform for a metric to
+// aggregated results in place. The aggregates are transformed and Value is
+// taken from the one agg asks for; counts are left alone.
+func (s *MetricService) applyAggregatedTransform(name string, tags map[string]string, agg ports.AggregationType, results []ports.AggregatedResult) {
+	rule := s.transformFor(domain.MetricTransformQuery, name, tags)
+	if rule == nil {
+		return
+	}
+	for i := range results {
+		r := &results[i]
+		r.Avg = rule.Apply(r.Avg)
+		r.Sum = rule.Apply(r.Sum) + rule.Offset*float64(r.Count-1)
+		r.Min, r.Max = transformBounds(rule, r.Min, r.Max)
+		switch agg {
+		case ports.AggregationSum:
+			r.Value = r.Sum
+		case ports.AggregationMin:
+			r.Value = r.Min
+		case ports.AggregationMax:
+			r.Value = r.Max
+		case ports.AggregationCount:
+		case ports.AggregationFirst, ports.AggregationLast:
+			// A single point's value
+			r.Value = rule.Apply(r.Value)
+		default:
+			r.Value = r.Avg
+		}
+	}
+}
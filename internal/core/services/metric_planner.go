@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+)
+
+// Query resolutions besides the rollup resolutions accepted by
+// parseResolution.
+const (
+	ResolutionAuto = "auto"
+	ResolutionRaw  = "raw"
+)
+
+// autoRawSpan is the longest range an auto query without a step returns at
+// native resolution. Longer ranges are bucketed with stepForRange so the
+// number of points stays bounded however far back the range reaches.
+const autoRawSpan = 6 * time.Hour
+
+// queryPlanned serves a query with a Resolution set.
+//
+// "raw" reads raw points and a rollup resolution reads only that rollup
+// table; either is re-bucketed when the step is coarser than the data.
+// "auto" serves the part of the range older than the first raw point from
+// rollups and the rest from raw points, stitched into one series. Every
+// point is marked with the resolution it was read from.
+func (s *MetricService) queryPlanned(ctx context.Context, query ports.MetricQuery) (*domain.MetricSeries, error) {
+	if query.EndTime.IsZero() {
+		query.EndTime = time.Now()
+	}
+	if query.Aggregation == ports.AggregationNone {
+		query.Aggregation = ports.AggregationAvg
+	}
+	resolution := query.Resolution
+	query.Resolution = ""
+
+	s.flush(ctx)
+
+	series := &domain.MetricSeries{
+		Name:       query.Name,
+		Tags:       query.Tags,
+		SeriesHash: seriesHashOf(query),
+		Points:     []domain.MetricPoint{},
+	}
+
+	var err error
+	switch resolution {
+	case ResolutionRaw:
+		err = s.planRaw(ctx, query, series)
+	case ResolutionAuto:
+		err = s.planAuto(ctx, query, series)
+	default:
+		var step time.Duration
+		if step, err = parseResolution(resolution); err != nil {
+			return nil, fmt.Errorf("invalid resolution: %w", err)
+		}
+		var buckets []ports.AggregatedResult
+		if buckets, err = s.rollupRange(ctx, query, resolution); err == nil {
+			series.Points = appendBuckets(series.Points, rebucket(buckets, max(query.Step, step), query.Aggregation), resolution)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if query.Limit > 0 && len(series.Points) > query.Limit {
+		series.Points = series.Points[:query.Limit]
+	}
+	s.finishSeries(query, series)
+	return series, nil
+}
+
+// planRaw fills series with raw points, bucketed when the query has a step.
+func (s *MetricService) planRaw(ctx context.Context, query ports.MetricQuery, series *domain.MetricSeries) error {
+	if query.Step > 0 {
+		results, err := s.cachedAggregation(ctx, query)
+		if err != nil {
+			return fmt.Errorf("failed to query raw metrics: %w", err)
+		}
+		series.Points = appendBuckets(series.Points, results, ResolutionRaw)
+		return nil
+	}
+
+	raw, err := s.cachedQuery(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to query raw metrics: %w", err)
+	}
+	if raw == nil {
+		return nil
+	}
+	series.SeriesHash = raw.SeriesHash
+	series.Type = raw.Type
+	if len(series.Tags) == 0 {
+		series.Tags = raw.Tags
+	}
+	for _, p := range raw.Points {
+		p.Resolution = ResolutionRaw
+		series.Points = append(series.Points, p)
+	}
+	return nil
+}
+
+// planAuto fills series with rollups for the part of the range before the
+// first raw point and raw points after it.
+func (s *MetricService) planAuto(ctx context.Context, query ports.MetricQuery, series *domain.MetricSeries) error {
+	// Without a start there is no history to plan for
+	if query.StartTime.IsZero() {
+		return s.planRaw(ctx, query, series)
+	}
+	if !query.EndTime.After(query.StartTime) {
+		return fmt.Errorf("end time must be after start time")
+	}
+	if query.Step <= 0 && query.EndTime.Sub(query.StartTime) > autoRawSpan {
+		query.Step = stepForRange(query.EndTime.Sub(query.StartTime))
+	}
+
+	if query.Step > 0 {
+		rollups, source, raw, err := s.mergedBuckets(ctx, query)
+		if err != nil {
+			return err
+		}
+		rollups = rebucket(rollups, query.Step, query.Aggregation)
+		raw = rebucket(raw, query.Step, query.Aggregation)
+
+		// The step bucket holding the boundary can have both rollup and raw
+		// data; it is combined and counted as rollup data
+		if n := len(rollups); n > 0 && len(raw) > 0 && rollups[n-1].Timestamp.Equal(raw[0].Timestamp) {
+			rollups[n-1] = rebucket([]ports.AggregatedResult{rollups[n-1], raw[0]}, query.Step, query.Aggregation)[0]
+			raw = raw[1:]
+		}
+		series.Points = appendBuckets(series.Points, rollups, source)
+		series.Points = appendBuckets(series.Points, raw, ResolutionRaw)
+		return nil
+	}
+
+	rawQuery := query
+	rawQuery.Limit = 0
+	var rawPoints domain.MetricSeries
+	if err := s.planRaw(ctx, rawQuery, &rawPoints); err != nil {
+		return err
+	}
+	rawStart := query.EndTime
+	if len(rawPoints.Points) > 0 {
+		rawStart = rawPoints.Points[0].Timestamp
+	}
+
+	// Native resolution wants the finest rollups that cover the gap
+	if rawStart.After(query.StartTime) {
+		rollupQuery := query
+		rollupQuery.EndTime = rawStart
+		for i := len(rollupResolutions) - 1; i >= 0; i-- {
+			resolution := rollupResolutions[i]
+			buckets, err := s.rollupRange(ctx, rollupQuery, resolution)
+			if err != nil {
+				return err
+			}
+			if len(buckets) == 0 {
+				continue
+			}
+			step, _ := parseResolution(resolution)
+			series.Points = appendBuckets(series.Points, rebucket(buckets, step, query.Aggregation), resolution)
+			break
+		}
+	}
+
+	series.Points = append(series.Points, rawPoints.Points...)
+	if rawPoints.SeriesHash != 0 {
+		series.SeriesHash = rawPoints.SeriesHash
+		series.Type = rawPoints.Type
+	}
+	if len(series.Tags) == 0 {
+		series.Tags = rawPoints.Tags
+	}
+	return nil
+}
+
+// rollupRange reads the query's range from one rollup resolution.
+func (s *MetricService) rollupRange(ctx context.Context, query ports.MetricQuery, resolution string) ([]ports.AggregatedResult, error) {
+	query.Limit = 0
+	aggs, err := s.repo.QueryAggregated(ctx, query, resolution)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s rollups: %w", resolution, err)
+	}
+	return rollupBuckets(aggs), nil
+}
+
+// appendBuckets appends buckets to points, marked with their resolution.
+func appendBuckets(points []domain.MetricPoint, buckets []ports.AggregatedResult, resolution string) []domain.MetricPoint {
+	for _, b := range buckets {
+		points = append(points, domain.MetricPoint{
+			Value:      b.Value,
+			Timestamp:  b.Timestamp,
+			Resolution: resolution,
+		})
+	}
+	return points
+}
+
+func seriesHashOf(query ports.MetricQuery) uint64 {
+	if query.SeriesHash == nil {
+		return 0
+	}
+	return *query.SeriesHash
+}
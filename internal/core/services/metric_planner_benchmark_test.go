@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+)
+
+// rowCountingRepository counts the rows the planner reads from storage.
+type rowCountingRepository struct {
+	*mockMetricRepository
+	rows int
+}
+
+func (r *rowCountingRepository) Query(ctx context.Context, query ports.MetricQuery) (*domain.MetricSeries, error) {
+	series, err := r.mockMetricRepository.Query(ctx, query)
+	if series != nil {
+		r.rows += len(series.Points)
+	}
+	return series, err
+}
+
+func (r *rowCountingRepository) QueryWithAggregation(ctx context.Context, query ports.MetricQuery) ([]ports.AggregatedResult, error) {
+	results, err := r.mockMetricRepository.QueryWithAggregation(ctx, query)
+	r.rows += len(results)
+	return results, err
+}
+
+func (r *rowCountingRepository) QueryAggregated(ctx context.Context, query ports.MetricQuery, resolution string) ([]*domain.AggregatedMetric, error) {
+	aggs, err := r.mockMetricRepository.QueryAggregated(ctx, query, resolution)
+	r.rows += len(aggs)
+	return aggs, err
+}
+
+// setupPlannerBenchmark stores 30 days of one series the way the default
+// retention tiers leave it: 7 days of raw points every 10 seconds, 1m
+// rollups for the 23 days before and 1h rollups for the whole range.
+func setupPlannerBenchmark(b *testing.B) (*MetricService, *rowCountingRepository, time.Time, time.Time) {
+	b.Helper()
+	end := time.Now().Truncate(time.Hour)
+	start := end.Add(-30 * 24 * time.Hour)
+	rawStart := end.Add(-7 * 24 * time.Hour)
+
+	repo := &rowCountingRepository{mockMetricRepository: &mockMetricRepository{}}
+	for w := start; w.Before(rawStart); w = w.Add(time.Minute) {
+		repo.rollups = append(repo.rollups, &domain.AggregatedMetric{
+			Name: "bench.cpu", Resolution: "1m", WindowStart: w, WindowEnd: w.Add(time.Minute),
+			Count: 6, Sum: 6, Min: 1, Max: 1, Avg: 1,
+		})
+	}
+	for w := start; w.Before(rawStart); w = w.Add(time.Hour) {
+		repo.rollups = append(repo.rollups, &domain.AggregatedMetric{
+			Name: "bench.cpu", Resolution: "1h", WindowStart: w, WindowEnd: w.Add(time.Hour),
+			Count: 360, Sum: 360, Min: 1, Max: 1, Avg: 1,
+		})
+	}
+	for ts := rawStart; ts.Before(end); ts = ts.Add(10 * time.Second) {
+		repo.metrics = append(repo.metrics, &domain.Metric{Name: "bench.cpu", Value: 1, Timestamp: ts})
+	}
+
+	config := DefaultMetricServiceConfig()
+	config.QueryCacheSize = 0 // measure the planner, not the cache
+	return NewMetricService(repo, &NopLogger{}, config), repo, start, end
+}
+
+// BenchmarkMetricQueryPlanner30Days benchmarks an auto resolution query over
+// 30 days of history. The planner must keep the result to one point per
+// hour however many raw points and rollups the range holds.
+func BenchmarkMetricQueryPlanner30Days(b *testing.B) {
+	svc, repo, start, end := setupPlannerBenchmark(b)
+	ctx := context.Background()
+	query := ports.MetricQuery{
+		Name:       "bench.cpu",
+		StartTime:  start,
+		EndTime:    end.Add(-time.Millisecond),
+		Resolution: ResolutionAuto,
+	}
+	maxPoints := 30 * 24
+
+	b.ResetTimer()
+	b.ReportAllocs()
+
+	var points int
+	for i := 0; i < b.N; i++ {
+		series, err := svc.Query(ctx, query)
+		if err != nil {
+			b.Fatalf("Query failed: %v", err)
+		}
+		points = len(series.Points)
+		if points > maxPoints {
+			b.Fatalf("Query returned %d points, want at most %d", points, maxPoints)
+		}
+	}
+
+	b.StopTimer()
+	b.ReportMetric(float64(points), "points/query")
+	b.ReportMetric(float64(repo.rows)/float64(b.N), "rows/query")
+}
+
+// BenchmarkMetricQueryRaw30Days is the same query against raw points only,
+// for comparison with the planner.
+func BenchmarkMetricQueryRaw30Days(b *testing.B) {
+	svc, repo, start, end := setupPlannerBenchmark(b)
+	ctx := context.Background()
+	query := ports.MetricQuery{
+		Name:       "bench.cpu",
+		StartTime:  start,
+		EndTime:    end.Add(-time.Millisecond),
+		Resolution: ResolutionRaw,
+	}
+
+	b.ResetTimer()
+	b.ReportAllocs()
+
+	var points int
+	for i := 0; i < b.N; i++ {
+		series, err := svc.Query(ctx, query)
+		if err != nil {
+			b.Fatalf("Query failed: %v", err)
+		}
+		points = len(series.Points)
+	}
+
+	b.StopTimer()
+	b.ReportMetric(float64(points), "points/query")
+	b.ReportMetric(float64(repo.rows)/float64(b.N), "rows/query")
+}
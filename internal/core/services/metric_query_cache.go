@@ -0,0 +1,145 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+)
+
+// queryCache holds recent metric query results. Every write bumps a
+// generation counter for its series and its metric name; a cached result
+// remembers the generation it was read at and is discarded once a write
+// lands in any series the query covers.
+type queryCache struct {
+	mu         sync.Mutex
+	entries    map[string]*queryCacheEntry
+	seriesGen  map[uint64]uint64 // series hash -> write generation
+	nameGen    map[string]uint64 // metric name -> writes to any of its series
+	clears     uint64            // bumped by clear so in-flight reads are not stored
+	maxEntries int
+	ttl        time.Duration
+}
+
+type queryCacheEntry struct {
+	query    ports.MetricQuery
+	gen      uint64
+	storedAt time.Time
+	value    interface{}
+}
+
+func newQueryCache(maxEntries int, ttl time.Duration) *queryCache {
+	return &queryCache{
+		entries:    make(map[string]*queryCacheEntry),
+		seriesGen:  make(map[uint64]uint64),
+		nameGen:    make(map[string]uint64),
+		maxEntries: maxEntries,
+		ttl:        ttl,
+	}
+}
+
+// generation returns the write generation of the series covered by query.
+// Queries without a series hash span every series of the name.
+func (c *queryCache) generation(query ports.MetricQuery) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generationLocked(query)
+}
+
+func (c *queryCache) generationLocked(query ports.MetricQuery) uint64 {
+	if query.SeriesHash != nil {
+		return c.clears + c.seriesGen[*query.SeriesHash]
+	}
+	return c.clears + c.nameGen[query.Name]
+}
+
+// get returns a cached result that is neither expired nor overtaken by a
+// write to one of its series.
+func (c *queryCache) get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if entry.gen != c.generationLocked(entry.query) || time.Since(entry.storedAt) > c.ttl {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// put stores a result read at generation gen. Results that were already
+// stale by the time the read finished are not stored.
+func (c *queryCache) put(key string, query ports.MetricQuery, gen uint64, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if gen != c.generationLocked(query) {
+		return
+	}
+	if _, exists := c.entries[key]; !exists && len(c.entries) >= c.maxEntries {
+		c.evictLocked()
+	}
+	c.entries[key] = &queryCacheEntry{query: query, gen: gen, storedAt: time.Now(), value: value}
+}
+
+// evictLocked drops the oldest entry to make room for a new one.
+func (c *queryCache) evictLocked() {
+	var oldestKey string
+	var oldest time.Time
+	for key, entry := range c.entries {
+		if oldestKey == "" || entry.storedAt.Before(oldest) {
+			oldestKey, oldest = key, entry.storedAt
+		}
+	}
+	delete(c.entries, oldestKey)
+}
+
+// recordWrites bumps the generation of every series written to.
+func (c *queryCache) recordWrites(metrics []*domain.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, m := range metrics {
+		c.seriesGen[m.SeriesHash]++
+		c.nameGen[m.Name]++
+	}
+}
+
+// clear drops all cached results, e.g. after data was deleted.
+func (c *queryCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*queryCacheEntry)
+	c.clears++
+}
+
+// queryCacheKey identifies a query by everything that shapes its result.
+func queryCacheKey(kind string, query ports.MetricQuery) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s|%s|%d|%d|%d|%s|%d", kind, query.Name,
+		query.StartTime.UnixNano(), query.EndTime.UnixNano(), query.Limit, query.Aggregation, query.Step)
+	if query.SeriesHash != nil {
+		fmt.Fprintf(&b, "|h=%d", *query.SeriesHash)
+	}
+	keys := make([]string, 0, len(query.Tags))
+	for k := range query.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "|%s=%s", k, query.Tags[k])
+	}
+	b.WriteString("|by=" + strings.Join(query.GroupBy, ","))
+	b.WriteString("|res=" + query.Resolution)
+	if query.IncludeIDs {
+		b.WriteString("|ids")
+	}
+	return b.String()
+}
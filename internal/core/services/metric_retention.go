@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RetentionScheduleName is the schedule entry the retention job reports to.
+const RetentionScheduleName = "metrics.downsample"
+
+// retentionStartupDelay is the longest the first retention run waits after
+// Start, so a restarting daemon does not compete with its own startup work.
+const retentionStartupDelay = 5 * time.Minute
+
+// RetentionPolicy is how long metric data is kept at each resolution.
+// Every Interval, raw points older than RawRetention are rolled up into each
+// tier and deleted, and each tier's aggregates older than its retention are
+// deleted. Without tiers raw points are simply deleted.
+type RetentionPolicy struct {
+	RawRetention time.Duration
+	Tiers        []RetentionTier
+	Interval     time.Duration
+}
+
+// DefaultRetentionPolicy keeps raw points for 7 days, 1-minute aggregates
+// for 30 days and 1-hour aggregates for a year, enforced hourly.
+func DefaultRetentionPolicy() RetentionPolicy {
+	return RetentionPolicy{
+		RawRetention: 7 * 24 * time.Hour,
+		Tiers:        DefaultRetentionTiers(),
+		Interval:     time.Hour,
+	}
+}
+
+// Validate checks the policy's durations and tiers.
+func (p RetentionPolicy) Validate() error {
+	if p.RawRetention <= 0 || p.Interval <= 0 {
+		return fmt.Errorf("raw retention and downsample interval must be positive")
+	}
+	seen := make(map[string]bool, len(p.Tiers))
+	for _, tier := range p.Tiers {
+		if err := tier.Validate(); err != nil {
+			return fmt.Errorf("invalid retention tier: %w", err)
+		}
+		if seen[tier.Resolution] {
+			return fmt.Errorf("duplicate retention tier %s", tier.Resolution)
+		}
+		seen[tier.Resolution] = true
+		// Rollups are made from points at least RawRetention old
+		if tier.Retention <= p.RawRetention {
+			return fmt.Errorf("retention for %s aggregates must be longer than raw retention", tier.Resolution)
+		}
+	}
+	return nil
+}
+
+// RetentionResult summarizes one enforcement of a retention policy.
+type RetentionResult struct {
+	Downsample *DownsampleResult `json:"downsample,omitempty"`
+	PrunedRaw  int64             `json:"pruned_raw"` // raw points deleted without rollup
+}
+
+// ApplyRetention enforces policy once. Series that fail to downsample keep
+// their raw points and are retried on the next run.
+func (s *MetricService) ApplyRetention(ctx context.Context, policy RetentionPolicy) (*RetentionResult, error) {
+	result := &RetentionResult{}
+	var runErr error
+
+	if len(policy.Tiers) == 0 {
+		s.flush(ctx)
+		deleted, err := s.repo.DeleteBefore(ctx, time.Now().Add(-policy.RawRetention))
+		s.clearQueryCache()
+		if err != nil {
+			return result, fmt.Errorf("failed to prune raw metrics: %w", err)
+		}
+		result.PrunedRaw = deleted
+	} else {
+		resolutions := make([]string, len(policy.Tiers))
+		for i, tier := range policy.Tiers {
+			resolutions[i] = tier.Resolution
+		}
+		downsampled, err := s.DownsampleTiers(ctx, policy.RawRetention, resolutions)
+		result.Downsample = downsampled
+		if err != nil {
+			s.logger.Error("Failed to downsample raw metrics", "error", err)
+			runErr = err
+		} else if downsampled.Failed > 0 {
+			runErr = fmt.Errorf("%d series could not be downsampled", downsampled.Failed)
+		}
+	}
+
+	if err := s.CleanupAggregatedTiers(ctx, policy.Tiers); err != nil && runErr == nil {
+		runErr = err
+	}
+	return result, runErr
+}
+
+// SetRetentionPolicy makes Start run a background job enforcing policy.
+// When sched is non-nil the job registers with it and records its runs.
+func (s *MetricService) SetRetentionPolicy(policy RetentionPolicy, sched *ScheduleService) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	if sched != nil {
+		if err := sched.Register(RetentionScheduleName, ScheduleKindCron, "@every "+policy.Interval.String()); err != nil {
+			return fmt.Errorf("failed to register retention schedule: %w", err)
+		}
+	}
+	s.retention = &policy
+	s.retentionSched = sched
+	return nil
+}
+
+// retentionLoop enforces the retention policy every interval until the
+// service stops.
+func (s *MetricService) retentionLoop(ctx context.Context) {
+	defer s.retentionWG.Done()
+	policy := *s.retention
+
+	delay := min(policy.Interval, retentionStartupDelay)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	if s.retentionSched != nil {
+		_ = s.retentionSched.SetNextRun(RetentionScheduleName, time.Now().Add(delay))
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.stopCh:
+			return
+		case <-timer.C:
+			s.runRetention(ctx, policy)
+			timer.Reset(policy.Interval)
+		}
+	}
+}
+
+// runRetention is one scheduled run of the retention job.
+func (s *MetricService) runRetention(ctx context.Context, policy RetentionPolicy) {
+	s.logger.Info("Starting scheduled retention run")
+	startedAt := time.Now()
+	if s.retentionSched != nil {
+		_ = s.retentionSched.MarkRunning(RetentionScheduleName, startedAt)
+	}
+
+	_, err := s.ApplyRetention(ctx, policy)
+	if err != nil {
+		s.logger.Error("Retention run failed", "error", err)
+	}
+
+	if s.retentionSched != nil {
+		_ = s.retentionSched.RecordRun(RetentionScheduleName, startedAt, err)
+	}
+	s.logger.Info("Scheduled retention run completed", "duration", time.Since(startedAt))
+}
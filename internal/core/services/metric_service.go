@@ -2,8 +2,11 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/forge-platform/forge/internal/core/domain"
@@ -21,36 +24,103 @@ type MetricService struct {
 	bufferSize int
 	flushCh    chan struct{}
 	stopCh     chan struct{}
+
+	// Unit/scale transform rules, first match wins per stage
+	transformMu sync.RWMutex
+	transforms  []domain.MetricTransformRule
+
+	// Relabel rules rewriting names and tags at ingestion, all applied in
+	// order before the ingest transforms
+	relabelMu sync.RWMutex
+	relabels  []domain.MetricRelabelRule
+
+	// Descriptions and types of metrics, first match wins
+	metadataMu sync.RWMutex
+	metadata   []domain.MetricMetadata
+
+	// Most recently written series for UI components, refreshed in the
+	// background so listing them never scans the whole metrics table
+	seriesCacheMu      sync.RWMutex
+	seriesCache        []ports.SeriesInfo
+	seriesCacheAt      time.Time
+	seriesCacheSize    int
+	seriesCacheRefresh time.Duration
+
+	// Recent query results, invalidated by writes to the queried series
+	queryCache *queryCache
+
+	// Retention job started by Start when a policy is set
+	retention      *RetentionPolicy
+	retentionSched *ScheduleService
+	retentionWG    sync.WaitGroup
+
+	// Points rejected by Record for a NaN or infinite value, before they
+	// reached the buffer
+	droppedNonFinite atomic.Int64
 }
 
+// DefaultRecentSeriesLimit is the number of series returned by
+// GetRecentSeries when no limit is given.
+const DefaultRecentSeriesLimit = 50
+
 // MetricServiceConfig holds configuration for the metric service.
 type MetricServiceConfig struct {
 	BufferSize    int
 	FlushInterval time.Duration
+
+	// SeriesCacheSize is how many recent series are kept in memory for
+	// GetRecentSeries; zero disables the cache.
+	SeriesCacheSize    int
+	SeriesCacheRefresh time.Duration
+
+	// QueryCacheSize is how many query results are cached; zero disables
+	// the cache. Entries expire after QueryCacheTTL or as soon as a point
+	// is written to one of the series they cover.
+	QueryCacheSize int
+	QueryCacheTTL  time.Duration
 }
 
 // DefaultMetricServiceConfig returns the default configuration.
 func DefaultMetricServiceConfig() MetricServiceConfig {
 	return MetricServiceConfig{
-		BufferSize:    1000,
-		FlushInterval: time.Second,
+		BufferSize:         1000,
+		FlushInterval:      time.Second,
+		SeriesCacheSize:    500,
+		SeriesCacheRefresh: 30 * time.Second,
+		QueryCacheSize:     256,
+		QueryCacheTTL:      time.Minute,
 	}
 }
 
 // NewMetricService creates a new metric service.
 func NewMetricService(repo ports.MetricRepository, logger ports.Logger, config MetricServiceConfig) *MetricService {
-	return &MetricService{
-		repo:       repo,
-		logger:     logger,
-		buffer:     make([]*domain.Metric, 0, config.BufferSize),
-		bufferSize: config.BufferSize,
-		flushCh:    make(chan struct{}, 1),
-		stopCh:     make(chan struct{}),
+	svc := &MetricService{
+		repo:               repo,
+		logger:             logger,
+		buffer:             make([]*domain.Metric, 0, config.BufferSize),
+		bufferSize:         config.BufferSize,
+		flushCh:            make(chan struct{}, 1),
+		stopCh:             make(chan struct{}),
+		seriesCacheSize:    config.SeriesCacheSize,
+		seriesCacheRefresh: config.SeriesCacheRefresh,
 	}
+	if config.QueryCacheSize > 0 && config.QueryCacheTTL > 0 {
+		svc.queryCache = newQueryCache(config.QueryCacheSize, config.QueryCacheTTL)
+	}
+	return svc
 }
 
-// Record records a new metric.
+// Record records a new metric. NaN and infinite values, before or after
+// the ingest transform, are rejected with a *ports.NonFiniteValueError.
 func (s *MetricService) Record(ctx context.Context, name string, metricType domain.MetricType, value float64, tags map[string]string) error {
+	name, tags = s.relabel(name, tags)
+	if rule := s.transformFor(domain.MetricTransformIngest, name, tags); rule != nil {
+		value = rule.Apply(value)
+	}
+	if !domain.IsFiniteValue(value) {
+		s.droppedNonFinite.Add(1)
+		return &ports.NonFiniteValueError{Name: name, Dropped: 1}
+	}
 	metric := domain.NewMetric(name, metricType, value, tags)
 
 	s.bufferMu.Lock()
@@ -68,12 +138,144 @@ func (s *MetricService) Record(ctx context.Context, name string, metricType doma
 	return nil
 }
 
-// Query retrieves metrics matching the given criteria.
+// RecordBatch applies relabel rules and ingest transforms and writes
+// metrics straight to the repository, bypassing the buffer, so callers
+// learn whether the write succeeded. Metrics over the series limit or with
+// a NaN or infinite value are dropped and reported as
+// ports.ErrSeriesLimitExceeded or ports.ErrNonFiniteValue after the rest
+// were written.
+func (s *MetricService) RecordBatch(ctx context.Context, metrics []*domain.Metric) error {
+	for _, m := range metrics {
+		s.relabelMetric(m)
+		if rule := s.transformFor(domain.MetricTransformIngest, m.Name, m.Tags); rule != nil {
+			m.Value = rule.Apply(m.Value)
+		}
+	}
+
+	err := s.repo.RecordBatch(ctx, metrics)
+	if s.queryCache != nil && (err == nil || partiallyWritten(err)) {
+		s.queryCache.recordWrites(metrics)
+	}
+	return err
+}
+
+// partiallyWritten reports whether a batch write error only dropped some
+// points, so the rest were written and retrying would drop them again.
+func partiallyWritten(err error) bool {
+	return errors.Is(err, ports.ErrSeriesLimitExceeded) || errors.Is(err, ports.ErrNonFiniteValue)
+}
+
+// RecordBatchBestEffort is like RecordBatch but writes every metric it can,
+// returning a report of the ones that were rejected instead of failing the
+// whole batch.
+func (s *MetricService) RecordBatchBestEffort(ctx context.Context, metrics []*domain.Metric) (*ports.BatchReport, error) {
+	for _, m := range metrics {
+		if m == nil {
+			continue
+		}
+		s.relabelMetric(m)
+		if rule := s.transformFor(domain.MetricTransformIngest, m.Name, m.Tags); rule != nil {
+			m.Value = rule.Apply(m.Value)
+		}
+	}
+
+	report, err := s.repo.RecordBatchBestEffort(ctx, metrics)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record batch: %w", err)
+	}
+	if s.queryCache != nil && report.Written > 0 {
+		failed := make(map[int]bool, len(report.Failed))
+		for _, f := range report.Failed {
+			failed[f.Index] = true
+		}
+		written := make([]*domain.Metric, 0, report.Written)
+		for i, m := range metrics {
+			if m != nil && !failed[i] {
+				written = append(written, m)
+			}
+		}
+		s.queryCache.recordWrites(written)
+	}
+	return report, nil
+}
+
+// Query retrieves metrics matching the given criteria. Queries with a
+// Resolution are planned across raw points and rollups.
 func (s *MetricService) Query(ctx context.Context, query ports.MetricQuery) (*domain.MetricSeries, error) {
+	if query.Resolution != "" {
+		return s.queryPlanned(ctx, query)
+	}
+
 	// Flush buffer first to ensure we have latest data
 	s.flush(ctx)
 
-	return s.repo.Query(ctx, query)
+	series, err := s.cachedQuery(ctx, query)
+	if err != nil || series == nil {
+		return series, err
+	}
+	s.finishSeries(query, series)
+	return series, nil
+}
+
+// finishSeries applies the query stage transform and unit to a series.
+func (s *MetricService) finishSeries(query ports.MetricQuery, series *domain.MetricSeries) {
+	tags := series.Tags
+	if len(tags) == 0 {
+		tags = query.Tags
+	}
+	if rule := s.transformFor(domain.MetricTransformQuery, query.Name, tags); rule != nil {
+		for i := range series.Points {
+			series.Points[i].Value = rule.Apply(series.Points[i].Value)
+		}
+	}
+	series.Unit = s.UnitFor(query.Name, tags)
+}
+
+// cachedQuery serves raw query results from the query cache when possible.
+// Results are copied in and out since callers transform points in place.
+func (s *MetricService) cachedQuery(ctx context.Context, query ports.MetricQuery) (*domain.MetricSeries, error) {
+	if s.queryCache == nil {
+		return s.repo.Query(ctx, query)
+	}
+
+	key := queryCacheKey("query", query)
+	if cached, ok := s.queryCache.get(key); ok {
+		return copySeries(cached.(*domain.MetricSeries)), nil
+	}
+
+	gen := s.queryCache.generation(query)
+	series, err := s.repo.Query(ctx, query)
+	if err != nil || series == nil {
+		return series, err
+	}
+	s.queryCache.put(key, query, gen, copySeries(series))
+	return series, nil
+}
+
+// cachedAggregation is cachedQuery for QueryWithAggregation.
+func (s *MetricService) cachedAggregation(ctx context.Context, query ports.MetricQuery) ([]ports.AggregatedResult, error) {
+	if s.queryCache == nil {
+		return s.repo.QueryWithAggregation(ctx, query)
+	}
+
+	key := queryCacheKey("aggregation", query)
+	if cached, ok := s.queryCache.get(key); ok {
+		return append([]ports.AggregatedResult(nil), cached.([]ports.AggregatedResult)...), nil
+	}
+
+	gen := s.queryCache.generation(query)
+	results, err := s.repo.QueryWithAggregation(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	s.queryCache.put(key, query, gen, append([]ports.AggregatedResult(nil), results...))
+	return results, nil
+}
+
+func copySeries(series *domain.MetricSeries) *domain.MetricSeries {
+	cp := *series
+	cp.Points = append([]domain.MetricPoint(nil), series.Points...)
+	return &cp
 }
 
 // QueryRange retrieves metrics for a time range.
@@ -87,14 +289,24 @@ func (s *MetricService) QueryRange(ctx context.Context, name string, start, end
 	return s.Query(ctx, query)
 }
 
-// Start starts the background flusher.
+// Start starts the background flusher, the recent series cache and, when a
+// retention policy is set, the retention job.
 func (s *MetricService) Start(ctx context.Context, flushInterval time.Duration) {
 	go s.flusher(ctx, flushInterval)
+	if s.seriesCacheSize > 0 && s.seriesCacheRefresh > 0 {
+		go s.seriesCacheRefresher(ctx)
+	}
+	if s.retention != nil {
+		s.retentionWG.Add(1)
+		go s.retentionLoop(ctx)
+	}
 }
 
-// Stop stops the metric service and flushes remaining data.
+// Stop stops the metric service and flushes remaining data. A retention run
+// in progress is waited for.
 func (s *MetricService) Stop(ctx context.Context) {
 	close(s.stopCh)
+	s.retentionWG.Wait()
 	s.flush(ctx)
 }
 
@@ -129,7 +341,14 @@ func (s *MetricService) flush(ctx context.Context) {
 	s.buffer = make([]*domain.Metric, 0, s.bufferSize)
 	s.bufferMu.Unlock()
 
-	if err := s.repo.RecordBatch(ctx, metrics); err != nil {
+	err := s.repo.RecordBatch(ctx, metrics)
+	if s.queryCache != nil && (err == nil || partiallyWritten(err)) {
+		s.queryCache.recordWrites(metrics)
+	}
+	if partiallyWritten(err) {
+		// The accepted points were written; retrying would only drop the rest again
+		s.logger.Warn("Dropped metrics", "count", len(metrics), "error", err)
+	} else if err != nil {
 		s.logger.Error("Failed to flush metrics", "count", len(metrics), "error", err)
 		// Re-add to buffer on failure
 		s.bufferMu.Lock()
@@ -140,108 +359,147 @@ func (s *MetricService) flush(ctx context.Context) {
 	}
 }
 
+// downsampleChunkSize bounds the aggregates written per batch.
+const downsampleChunkSize = 500
+
+// DownsampleResult summarizes a downsampling run.
+type DownsampleResult struct {
+	Series     int   `json:"series"`      // series with raw points past the cutoff
+	Aggregated int   `json:"aggregated"`  // aggregate rows written
+	Deleted    int64 `json:"deleted_raw"` // raw points removed
+	Failed     int   `json:"failed"`      // series left raw because a step failed
+}
+
 // Downsample aggregates old metrics into lower resolution.
-// Resolution can be "1m", "1h", or "1d".
+// Resolution can be "1m", "5m", "1h", or "1d".
 // Retention policies (from ForgePlatform.md):
 // - Raw data: 7 days
 // - 1-minute aggregates: 30 days
 // - 1-hour aggregates: 1 year
 func (s *MetricService) Downsample(ctx context.Context, olderThan time.Duration, resolution string) error {
-	s.logger.Info("Starting downsampling", "older_than", olderThan, "resolution", resolution)
+	_, err := s.DownsampleTiers(ctx, olderThan, []string{resolution})
+	return err
+}
+
+// DownsampleTiers rolls raw points older than olderThan up into each of the
+// given resolutions, then deletes them. The cutoff is aligned down to the
+// coarsest resolution, so raw points are only deleted once every window
+// they fall in has been aggregated.
+//
+// Series are handled one at a time and a series' raw points are deleted
+// only after all its aggregates were written, so an interrupted run is
+// resumed by running again. Aggregates are keyed by series, resolution and
+// window, and re-aggregating a window replaces its row.
+func (s *MetricService) DownsampleTiers(ctx context.Context, olderThan time.Duration, resolutions []string) (*DownsampleResult, error) {
+	if len(resolutions) == 0 {
+		return nil, fmt.Errorf("at least one resolution is required")
+	}
+	steps := make([]time.Duration, len(resolutions))
+	var coarsest time.Duration
+	for i, resolution := range resolutions {
+		step, err := parseResolution(resolution)
+		if err != nil {
+			return nil, fmt.Errorf("invalid resolution: %w", err)
+		}
+		steps[i] = step
+		coarsest = max(coarsest, step)
+	}
+
+	s.logger.Info("Starting downsampling", "older_than", olderThan, "resolutions", resolutions)
 
 	// Flush buffer first to ensure we have all data
 	s.flush(ctx)
 
-	// Parse resolution to get step duration
-	step, err := parseResolution(resolution)
-	if err != nil {
-		return fmt.Errorf("invalid resolution: %w", err)
-	}
-
-	// Get all distinct series
 	series, err := s.repo.GetDistinctSeries(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get distinct series: %w", err)
+		return nil, fmt.Errorf("failed to get distinct series: %w", err)
 	}
 
-	threshold := time.Now().Add(-olderThan)
-	totalAggregated := 0
-	totalDeleted := int64(0)
+	cutoff := time.Now().Add(-olderThan).Truncate(coarsest)
+	result := &DownsampleResult{}
+	defer s.clearQueryCache()
 
-	for _, seriesInfo := range series {
-		// Skip series with no data older than threshold
-		if seriesInfo.LastTime.After(threshold) && seriesInfo.FirstTime.After(threshold) {
-			continue
+	for _, info := range series {
+		if err := ctx.Err(); err != nil {
+			return result, err
 		}
-
-		// Query with aggregation for this series
-		endTime := threshold
-		if seriesInfo.LastTime.Before(threshold) {
-			endTime = seriesInfo.LastTime
+		if !info.FirstTime.Before(cutoff) {
+			continue
 		}
+		result.Series++
 
-		query := ports.MetricQuery{
-			Name:        seriesInfo.Name,
-			SeriesHash:  &seriesInfo.SeriesHash,
-			StartTime:   seriesInfo.FirstTime,
-			EndTime:     endTime,
-			Aggregation: ports.AggregationAvg,
-			Step:        step,
+		written, err := s.downsampleSeries(ctx, info, cutoff, resolutions, steps)
+		result.Aggregated += written
+		if err != nil {
+			s.logger.Error("Failed to downsample series", "series", info.Name, "error", err)
+			result.Failed++
+			continue
 		}
 
-		results, err := s.repo.QueryWithAggregation(ctx, query)
+		deleted, err := s.repo.DeleteSeriesBefore(ctx, info.SeriesHash, cutoff)
 		if err != nil {
-			s.logger.Error("Failed to aggregate series", "series", seriesInfo.Name, "error", err)
+			s.logger.Error("Failed to delete downsampled points", "series", info.Name, "error", err)
+			result.Failed++
 			continue
 		}
+		result.Deleted += deleted
+	}
 
-		if len(results) == 0 {
-			continue
+	s.logger.Info("Downsampling complete",
+		"resolutions", resolutions,
+		"series", result.Series,
+		"aggregated_buckets", result.Aggregated,
+		"deleted_raw", result.Deleted,
+		"failed", result.Failed,
+	)
+	return result, nil
+}
+
+// downsampleSeries writes the aggregates of one series' raw points before
+// cutoff at each resolution and returns how many it wrote.
+func (s *MetricService) downsampleSeries(ctx context.Context, info ports.SeriesInfo, cutoff time.Time, resolutions []string, steps []time.Duration) (int, error) {
+	seriesHash := info.SeriesHash
+	written := 0
+	for i, resolution := range resolutions {
+		results, err := s.repo.QueryWithAggregation(ctx, ports.MetricQuery{
+			Name:        info.Name,
+			SeriesHash:  &seriesHash,
+			StartTime:   info.FirstTime,
+			EndTime:     cutoff.Add(-time.Millisecond), // the end bound is inclusive
+			Aggregation: ports.AggregationAvg,
+			Step:        steps[i],
+		})
+		if err != nil {
+			return written, fmt.Errorf("failed to aggregate to %s: %w", resolution, err)
 		}
 
-		// Convert aggregated results to domain objects
-		var aggregatedMetrics []*domain.AggregatedMetric
-		for _, result := range results {
-			agg := &domain.AggregatedMetric{
+		aggs := make([]*domain.AggregatedMetric, 0, len(results))
+		for _, r := range results {
+			aggs = append(aggs, &domain.AggregatedMetric{
 				ID:          domain.NewUUIDv7(),
-				Name:        seriesInfo.Name,
-				Tags:        seriesInfo.Tags,
-				SeriesHash:  seriesInfo.SeriesHash,
-				WindowStart: result.Timestamp,
-				WindowEnd:   result.Timestamp.Add(step),
-				Count:       result.Count,
-				Sum:         result.Sum,
-				Min:         result.Min,
-				Max:         result.Max,
-				Avg:         result.Avg,
+				Name:        info.Name,
+				Tags:        info.Tags,
+				SeriesHash:  info.SeriesHash,
+				WindowStart: r.Timestamp,
+				WindowEnd:   r.Timestamp.Add(steps[i]),
+				Count:       r.Count,
+				Sum:         r.Sum,
+				Min:         r.Min,
+				Max:         r.Max,
+				Avg:         r.Avg,
 				Resolution:  resolution,
-			}
-			aggregatedMetrics = append(aggregatedMetrics, agg)
+			})
 		}
 
-		// Batch insert aggregated metrics
-		if err := s.repo.RecordAggregatedBatch(ctx, aggregatedMetrics); err != nil {
-			s.logger.Error("Failed to record aggregated metrics", "series", seriesInfo.Name, "error", err)
-			continue
+		for start := 0; start < len(aggs); start += downsampleChunkSize {
+			end := min(start+downsampleChunkSize, len(aggs))
+			if err := s.repo.RecordAggregatedBatch(ctx, aggs[start:end]); err != nil {
+				return written, fmt.Errorf("failed to record %s aggregates: %w", resolution, err)
+			}
+			written += end - start
 		}
-
-		totalAggregated += len(aggregatedMetrics)
 	}
-
-	// Delete raw metrics older than threshold
-	deleted, err := s.repo.DeleteBefore(ctx, threshold)
-	if err != nil {
-		return fmt.Errorf("failed to delete old metrics: %w", err)
-	}
-	totalDeleted = deleted
-
-	s.logger.Info("Downsampling complete",
-		"resolution", resolution,
-		"aggregated_buckets", totalAggregated,
-		"deleted_raw", totalDeleted,
-	)
-
-	return nil
+	return written, nil
 }
 
 // parseResolution converts resolution string to duration.
@@ -264,17 +522,235 @@ func parseResolution(resolution string) (time.Duration, error) {
 func (s *MetricService) QueryWithAggregation(ctx context.Context, query ports.MetricQuery) ([]ports.AggregatedResult, error) {
 	// Flush buffer first
 	s.flush(ctx)
-	return s.repo.QueryWithAggregation(ctx, query)
+	results, err := s.cachedAggregation(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	s.applyAggregatedTransform(query.Name, query.Tags, query.Aggregation, results)
+	return results, nil
+}
+
+// QueryGrouped aggregates metrics per combination of the query's GroupBy
+// tag values.
+func (s *MetricService) QueryGrouped(ctx context.Context, query ports.MetricQuery) ([]ports.GroupedResult, error) {
+	if query.EndTime.IsZero() {
+		query.EndTime = time.Now()
+	}
+	if query.Aggregation == ports.AggregationNone {
+		query.Aggregation = ports.AggregationAvg
+	}
+	s.flush(ctx)
+	groups, err := s.repo.QueryGrouped(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, g := range groups {
+		s.applyAggregatedTransform(query.Name, g.Tags, query.Aggregation, g.Results)
+	}
+	return groups, nil
+}
+
+// Export streams the raw points matching query to fn, series by series,
+// after flushing buffered writes. Query transforms apply as they do to
+// Query, so exported values match what the CLI shows.
+func (s *MetricService) Export(ctx context.Context, query ports.MetricQuery, fn func(series *domain.MetricSeries, point domain.MetricPoint) error) error {
+	if query.EndTime.IsZero() {
+		query.EndTime = time.Now()
+	}
+	s.flush(ctx)
+
+	var (
+		current *domain.MetricSeries
+		rule    *domain.MetricTransformRule
+	)
+	return s.repo.Export(ctx, query, func(series *domain.MetricSeries, point domain.MetricPoint) error {
+		if series != current {
+			current = series
+			rule = s.transformFor(domain.MetricTransformQuery, series.Name, series.Tags)
+			series.Unit = s.UnitFor(series.Name, series.Tags)
+		}
+		if rule != nil {
+			point.Value = rule.Apply(point.Value)
+		}
+		return fn(series, point)
+	})
 }
 
 // QueryAggregated retrieves pre-aggregated metrics.
 func (s *MetricService) QueryAggregated(ctx context.Context, query ports.MetricQuery, resolution string) ([]*domain.AggregatedMetric, error) {
-	return s.repo.QueryAggregated(ctx, query, resolution)
+	aggs, err := s.repo.QueryAggregated(ctx, query, resolution)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, agg := range aggs {
+		rule := s.transformFor(domain.MetricTransformQuery, agg.Name, agg.Tags)
+		if rule == nil {
+			continue
+		}
+		agg.Avg = rule.Apply(agg.Avg)
+		agg.Sum = rule.Apply(agg.Sum) + rule.Offset*float64(agg.Count-1)
+		agg.Min, agg.Max = transformBounds(rule, agg.Min, agg.Max)
+	}
+	return aggs, nil
+}
+
+// SetTransformRules validates and replaces the metric transform rules.
+func (s *MetricService) SetTransformRules(rules []domain.MetricTransformRule) error {
+	validated := make([]domain.MetricTransformRule, len(rules))
+	for i, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("invalid transform rule %d (%s): %w", i, rule.Name, err)
+		}
+		validated[i] = rule
+	}
+
+	s.transformMu.Lock()
+	s.transforms = validated
+	s.transformMu.Unlock()
+	return nil
+}
+
+// TransformRules returns the configured metric transform rules.
+func (s *MetricService) TransformRules() []domain.MetricTransformRule {
+	s.transformMu.RLock()
+	defer s.transformMu.RUnlock()
+	return append([]domain.MetricTransformRule(nil), s.transforms...)
+}
+
+// SetRelabelRules validates and replaces the metric relabel rules.
+func (s *MetricService) SetRelabelRules(rules []domain.MetricRelabelRule) error {
+	validated := make([]domain.MetricRelabelRule, len(rules))
+	for i, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("invalid relabel rule %d (%s): %w", i, rule.Name, err)
+		}
+		validated[i] = rule
+	}
+
+	s.relabelMu.Lock()
+	s.relabels = validated
+	s.relabelMu.Unlock()
+	return nil
+}
+
+// RelabelRules returns the configured metric relabel rules.
+func (s *MetricService) RelabelRules() []domain.MetricRelabelRule {
+	s.relabelMu.RLock()
+	defer s.relabelMu.RUnlock()
+	return append([]domain.MetricRelabelRule(nil), s.relabels...)
+}
+
+// relabel applies every matching relabel rule in order to a series' name
+// and tags. The caller's tags are copied before the first change.
+func (s *MetricService) relabel(name string, tags map[string]string) (string, map[string]string) {
+	s.relabelMu.RLock()
+	defer s.relabelMu.RUnlock()
+
+	copied := false
+	for i := range s.relabels {
+		rule := &s.relabels[i]
+		if !rule.Matches(name, tags) {
+			continue
+		}
+		if !copied {
+			own := make(map[string]string, len(tags)+1)
+			for k, v := range tags {
+				own[k] = v
+			}
+			tags, copied = own, true
+		}
+		name = rule.Apply(name, tags)
+	}
+	return name, tags
+}
+
+// relabelMetric applies the relabel rules to a metric, updating its series
+// hash when its identity changes.
+func (s *MetricService) relabelMetric(m *domain.Metric) {
+	name, tags := s.relabel(m.Name, m.Tags)
+	if name != m.Name || !maps.Equal(tags, m.Tags) {
+		m.SetIdentity(name, tags)
+	}
+}
+
+// SetMetadata validates and replaces the metric metadata registry.
+func (s *MetricService) SetMetadata(entries []domain.MetricMetadata) error {
+	validated := make([]domain.MetricMetadata, len(entries))
+	for i, m := range entries {
+		if err := m.Validate(); err != nil {
+			return fmt.Errorf("invalid metadata entry %d (%s): %w", i, m.Metric, err)
+		}
+		validated[i] = m
+	}
+
+	s.metadataMu.Lock()
+	s.metadata = validated
+	s.metadataMu.Unlock()
+	return nil
+}
+
+// MetadataFor returns the first metadata entry matching a metric name.
+func (s *MetricService) MetadataFor(name string) (domain.MetricMetadata, bool) {
+	s.metadataMu.RLock()
+	defer s.metadataMu.RUnlock()
+
+	for _, m := range s.metadata {
+		if m.Matches(name) {
+			return m, true
+		}
+	}
+	return domain.MetricMetadata{}, false
+}
+
+// UnitFor returns the unit label reported for a series, set by a transform
+// rule or else by the metric's metadata.
+func (s *MetricService) UnitFor(name string, tags map[string]string) string {
+	if rule := s.transformFor(domain.MetricTransformQuery, name, tags); rule != nil && rule.Unit != "" {
+		return rule.Unit
+	}
+	if rule := s.transformFor(domain.MetricTransformIngest, name, tags); rule != nil && rule.Unit != "" {
+		return rule.Unit
+	}
+	if m, ok := s.MetadataFor(name); ok {
+		return m.Unit
+	}
+	return ""
+}
+
+// transformFor returns the first rule of the given stage matching a series.
+func (s *MetricService) transformFor(stage domain.MetricTransformStage, name string, tags map[string]string) *domain.MetricTransformRule {
+	s.transformMu.RLock()
+	defer s.transformMu.RUnlock()
+
+	for i := range s.transforms {
+		rule := &s.transforms[i]
+		if rule.Stage == stage && rule.Matches(name, tags) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// transformBounds applies rule to a min/max pair, swapping them for negative scales.
+func transformBounds(rule *domain.MetricTransformRule, minValue, maxValue float64) (float64, float64) {
+	lo, hi := rule.Apply(minValue), rule.Apply(maxValue)
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+	return lo, hi
 }
 
 // GetStats returns storage statistics.
 func (s *MetricService) GetStats(ctx context.Context) (*ports.MetricStats, error) {
-	return s.repo.GetStats(ctx)
+	stats, err := s.repo.GetStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stats.DroppedNonFinite += s.droppedNonFinite.Load()
+	return stats, nil
 }
 
 // GetDistinctSeries returns all distinct metric series.
@@ -282,38 +758,176 @@ func (s *MetricService) GetDistinctSeries(ctx context.Context) ([]ports.SeriesIn
 	return s.repo.GetDistinctSeries(ctx)
 }
 
+// LatestValues returns the most recent point of every distinct series, with
+// the query stage transform applied.
+func (s *MetricService) LatestValues(ctx context.Context) ([]*domain.Metric, error) {
+	s.flush(ctx)
+
+	series, err := s.repo.GetDistinctSeries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get series: %w", err)
+	}
+
+	latest := make([]*domain.Metric, 0, len(series))
+	for _, info := range series {
+		hash := info.SeriesHash
+		result, err := s.repo.Query(ctx, ports.MetricQuery{
+			Name:       info.Name,
+			SeriesHash: &hash,
+			StartTime:  info.LastTime,
+			EndTime:    info.LastTime,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to query latest value of %s: %w", info.Name, err)
+		}
+		if result == nil || len(result.Points) == 0 {
+			continue
+		}
+
+		point := result.Points[len(result.Points)-1]
+		m := domain.NewMetric(info.Name, result.Type, point.Value, info.Tags)
+		m.Timestamp = point.Timestamp
+		m.SeriesHash = info.SeriesHash
+		if rule := s.transformFor(domain.MetricTransformQuery, info.Name, info.Tags); rule != nil {
+			m.Value = rule.Apply(m.Value)
+		}
+		latest = append(latest, m)
+	}
+	return latest, nil
+}
+
+// GetRecentSeries returns at most limit series, most recently written
+// first. Requests that fit the cache are served from it and may be up to
+// one refresh interval stale; larger ones go to the repository.
+func (s *MetricService) GetRecentSeries(ctx context.Context, limit int) ([]ports.SeriesInfo, error) {
+	if limit <= 0 {
+		limit = DefaultRecentSeriesLimit
+	}
+
+	s.seriesCacheMu.RLock()
+	cached, cachedAt := s.seriesCache, s.seriesCacheAt
+	s.seriesCacheMu.RUnlock()
+
+	if !cachedAt.IsZero() && limit <= s.seriesCacheSize {
+		if limit > len(cached) {
+			limit = len(cached)
+		}
+		result := make([]ports.SeriesInfo, limit)
+		copy(result, cached)
+		return result, nil
+	}
+
+	series, err := s.repo.GetRecentSeries(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recent series: %w", err)
+	}
+	return series, nil
+}
+
+// seriesCacheRefresher keeps the recent series cache up to date.
+func (s *MetricService) seriesCacheRefresher(ctx context.Context) {
+	ticker := time.NewTicker(s.seriesCacheRefresh)
+	defer ticker.Stop()
+
+	for {
+		if err := s.refreshSeriesCache(ctx); err != nil {
+			s.logger.Warn("Failed to refresh series cache", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refreshSeriesCache reloads the cache from the repository. The previous
+// contents are kept if loading fails.
+func (s *MetricService) refreshSeriesCache(ctx context.Context) error {
+	series, err := s.repo.GetRecentSeries(ctx, s.seriesCacheSize)
+	if err != nil {
+		return err
+	}
+
+	s.seriesCacheMu.Lock()
+	s.seriesCache = series
+	s.seriesCacheAt = time.Now()
+	s.seriesCacheMu.Unlock()
+	return nil
+}
+
+// RetentionTier is a resolution raw points are rolled up into and how long
+// its aggregates are kept.
+type RetentionTier struct {
+	Resolution string
+	Retention  time.Duration
+}
+
+// Validate checks the tier's resolution and retention.
+func (t RetentionTier) Validate() error {
+	if _, err := parseResolution(t.Resolution); err != nil {
+		return err
+	}
+	if t.Retention <= 0 {
+		return fmt.Errorf("retention for %s aggregates must be positive", t.Resolution)
+	}
+	return nil
+}
+
+// DefaultRetentionTiers keeps 1-minute aggregates for 30 days and 1-hour
+// aggregates for a year.
+func DefaultRetentionTiers() []RetentionTier {
+	return []RetentionTier{
+		{Resolution: "1m", Retention: 30 * 24 * time.Hour},
+		{Resolution: "1h", Retention: 365 * 24 * time.Hour},
+	}
+}
+
 // CleanupAggregated removes old aggregated metrics based on retention policy.
 func (s *MetricService) CleanupAggregated(ctx context.Context) error {
 	// Retention policies from ForgePlatform.md:
 	// - 1-minute aggregates: 30 days
 	// - 1-hour aggregates: 1 year
 	// - 1-day aggregates: forever (no cleanup)
+	return s.CleanupAggregatedTiers(ctx, []RetentionTier{
+		{Resolution: "1m", Retention: 30 * 24 * time.Hour},
+		{Resolution: "5m", Retention: 60 * 24 * time.Hour},
+		{Resolution: "1h", Retention: 365 * 24 * time.Hour},
+	})
+}
 
-	retentionPolicies := map[string]time.Duration{
-		"1m": 30 * 24 * time.Hour,      // 30 days
-		"5m": 60 * 24 * time.Hour,      // 60 days
-		"1h": 365 * 24 * time.Hour,     // 1 year
-	}
-
-	for resolution, retention := range retentionPolicies {
-		before := time.Now().Add(-retention)
-		deleted, err := s.repo.DeleteAggregatedBefore(ctx, before, resolution)
+// CleanupAggregatedTiers removes each tier's aggregates older than its
+// retention.
+func (s *MetricService) CleanupAggregatedTiers(ctx context.Context, tiers []RetentionTier) error {
+	for _, tier := range tiers {
+		before := time.Now().Add(-tier.Retention)
+		deleted, err := s.repo.DeleteAggregatedBefore(ctx, before, tier.Resolution)
 		if err != nil {
-			s.logger.Error("Failed to cleanup aggregated metrics", "resolution", resolution, "error", err)
+			s.logger.Error("Failed to cleanup aggregated metrics", "resolution", tier.Resolution, "error", err)
 			continue
 		}
 		if deleted > 0 {
-			s.logger.Info("Cleaned up aggregated metrics", "resolution", resolution, "deleted", deleted)
+			s.logger.Info("Cleaned up aggregated metrics", "resolution", tier.Resolution, "deleted", deleted)
 		}
 	}
 
 	return nil
 }
 
+// clearQueryCache drops cached query results after raw data was deleted.
+func (s *MetricService) clearQueryCache() {
+	if s.queryCache != nil {
+		s.queryCache.clear()
+	}
+}
+
 // Cleanup removes metrics older than the retention period.
 func (s *MetricService) Cleanup(ctx context.Context, retention time.Duration) (int64, error) {
 	before := time.Now().Add(-retention)
 	deleted, err := s.repo.DeleteBefore(ctx, before)
+	s.clearQueryCache()
 	if err != nil {
 		return 0, fmt.Errorf("failed to cleanup metrics: %w", err)
 	}
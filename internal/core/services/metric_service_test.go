@@ -2,6 +2,9 @@ package services
 
 import (
 	"context"
+	"errors"
+	"math"
+	"strings"
 	"testing"
 	"time"
 
@@ -14,6 +17,14 @@ type mockMetricRepository struct {
 	metrics          []*domain.Metric
 	recordBatchCalls int
 	queryCalls       int
+	aggResults       []ports.AggregatedResult
+	rollups          []*domain.AggregatedMetric
+	batchErr         error
+	recent           []ports.SeriesInfo
+	recentCalls      int
+	recentErr        error
+	deletedBefore    []time.Time
+	groups           []ports.GroupedResult
 }
 
 func (m *mockMetricRepository) Record(ctx context.Context, metric *domain.Metric) error {
@@ -23,10 +34,32 @@ func (m *mockMetricRepository) Record(ctx context.Context, metric *domain.Metric
 
 func (m *mockMetricRepository) RecordBatch(ctx context.Context, metrics []*domain.Metric) error {
 	m.recordBatchCalls++
+	if m.batchErr != nil {
+		return m.batchErr
+	}
 	m.metrics = append(m.metrics, metrics...)
 	return nil
 }
 
+// RecordBatchBestEffort rejects NaN values like the SQLite repository's
+// NOT NULL value column does.
+func (m *mockMetricRepository) RecordBatchBestEffort(ctx context.Context, metrics []*domain.Metric) (*ports.BatchReport, error) {
+	m.recordBatchCalls++
+	if m.batchErr != nil {
+		return nil, m.batchErr
+	}
+	report := &ports.BatchReport{}
+	for i, metric := range metrics {
+		if math.IsNaN(metric.Value) {
+			report.Failed = append(report.Failed, ports.BatchFailure{Index: i, Reason: "NOT NULL constraint failed: metrics.value"})
+			continue
+		}
+		m.metrics = append(m.metrics, metric)
+		report.Written++
+	}
+	return report, nil
+}
+
 func (m *mockMetricRepository) Query(ctx context.Context, query ports.MetricQuery) (*domain.MetricSeries, error) {
 	m.queryCalls++
 	points := make([]domain.MetricPoint, len(m.metrics))
@@ -45,8 +78,47 @@ func (m *mockMetricRepository) QueryMultiple(ctx context.Context, query ports.Me
 	return []*domain.MetricSeries{series}, nil
 }
 
+func (m *mockMetricRepository) Export(ctx context.Context, query ports.MetricQuery, fn func(series *domain.MetricSeries, point domain.MetricPoint) error) error {
+	series, _ := m.Query(ctx, query)
+	for _, point := range series.Points {
+		if err := fn(series, point); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (m *mockMetricRepository) QueryWithAggregation(ctx context.Context, query ports.MetricQuery) ([]ports.AggregatedResult, error) {
-	return nil, nil
+	if m.aggResults != nil || query.Step <= 0 {
+		return m.aggResults, nil
+	}
+
+	// Bucket the recorded metrics like the storage repository does
+	stepMs := query.Step.Milliseconds()
+	var results []ports.AggregatedResult
+	for _, metric := range m.metrics {
+		if metric.Timestamp.Before(query.StartTime) || metric.Timestamp.After(query.EndTime) {
+			continue
+		}
+		ts := time.UnixMilli((metric.Timestamp.UnixMilli() / stepMs) * stepMs)
+		n := len(results)
+		if n == 0 || !results[n-1].Timestamp.Equal(ts) {
+			results = append(results, ports.AggregatedResult{Timestamp: ts, Min: metric.Value, Max: metric.Value})
+			n++
+		}
+		r := &results[n-1]
+		r.Count++
+		r.Sum += metric.Value
+		r.Min = min(r.Min, metric.Value)
+		r.Max = max(r.Max, metric.Value)
+		r.Avg = r.Sum / float64(r.Count)
+		r.Value = r.Avg
+	}
+	return results, nil
+}
+
+func (m *mockMetricRepository) QueryGrouped(ctx context.Context, query ports.MetricQuery) ([]ports.GroupedResult, error) {
+	return m.groups, nil
 }
 
 func (m *mockMetricRepository) Aggregate(ctx context.Context, query ports.MetricQuery, resolution string) (*domain.AggregatedMetric, error) {
@@ -62,10 +134,21 @@ func (m *mockMetricRepository) RecordAggregatedBatch(ctx context.Context, metric
 }
 
 func (m *mockMetricRepository) QueryAggregated(ctx context.Context, query ports.MetricQuery, resolution string) ([]*domain.AggregatedMetric, error) {
-	return nil, nil
+	var results []*domain.AggregatedMetric
+	for _, agg := range m.rollups {
+		if agg.Resolution == resolution && !agg.WindowStart.Before(query.StartTime) && !agg.WindowEnd.After(query.EndTime) {
+			results = append(results, agg)
+		}
+	}
+	return results, nil
 }
 
 func (m *mockMetricRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	m.deletedBefore = append(m.deletedBefore, before)
+	return 0, nil
+}
+
+func (m *mockMetricRepository) DeleteSeriesBefore(ctx context.Context, seriesHash uint64, before time.Time) (int64, error) {
 	return 0, nil
 }
 
@@ -77,6 +160,17 @@ func (m *mockMetricRepository) GetDistinctSeries(ctx context.Context) ([]ports.S
 	return nil, nil
 }
 
+func (m *mockMetricRepository) GetRecentSeries(ctx context.Context, limit int) ([]ports.SeriesInfo, error) {
+	m.recentCalls++
+	if m.recentErr != nil {
+		return nil, m.recentErr
+	}
+	if limit > len(m.recent) {
+		limit = len(m.recent)
+	}
+	return append([]ports.SeriesInfo(nil), m.recent[:limit]...), nil
+}
+
 func (m *mockMetricRepository) GetStats(ctx context.Context) (*ports.MetricStats, error) {
 	return &ports.MetricStats{TotalPoints: int64(len(m.metrics))}, nil
 }
@@ -145,6 +239,141 @@ func TestMetricService_RecordTriggersFlush(t *testing.T) {
 	}
 }
 
+func TestMetricService_FlushSeriesLimit(t *testing.T) {
+	repo := &mockMetricRepository{}
+	svc := NewMetricService(repo, &mockLogger{}, MetricServiceConfig{BufferSize: 10, FlushInterval: time.Minute})
+	ctx := context.Background()
+
+	// Ordinary failures keep the points buffered for the next flush
+	repo.batchErr = errors.New("database is locked")
+	_ = svc.Record(ctx, "test.metric", domain.MetricTypeGauge, 1.0, nil)
+	svc.flush(ctx)
+	if len(svc.buffer) != 1 {
+		t.Fatalf("len(buffer) = %d, want 1 after failed flush", len(svc.buffer))
+	}
+
+	// Series limit rejections are final
+	repo.batchErr = &ports.SeriesLimitError{Name: "test.metric", Limit: 1, Dropped: 1}
+	svc.flush(ctx)
+	if len(svc.buffer) != 0 {
+		t.Errorf("len(buffer) = %d, want 0 after series limit rejection", len(svc.buffer))
+	}
+}
+
+func TestMetricService_RecordNonFinite(t *testing.T) {
+	repo := &mockMetricRepository{}
+	svc := NewMetricService(repo, &mockLogger{}, MetricServiceConfig{BufferSize: 10, FlushInterval: time.Minute})
+	ctx := context.Background()
+
+	for _, v := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		err := svc.Record(ctx, "test.metric", domain.MetricTypeGauge, v, nil)
+		if !errors.Is(err, ports.ErrNonFiniteValue) {
+			t.Errorf("Record(%v) error = %v, want ErrNonFiniteValue", v, err)
+		}
+	}
+	if err := svc.Record(ctx, "test.metric", domain.MetricTypeGauge, 1.0, nil); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	if len(svc.buffer) != 1 {
+		t.Errorf("len(buffer) = %d, want only the finite point", len(svc.buffer))
+	}
+
+	stats, err := svc.GetStats(ctx)
+	if err != nil {
+		t.Fatalf("GetStats() error = %v", err)
+	}
+	if stats.DroppedNonFinite != 3 {
+		t.Errorf("DroppedNonFinite = %d, want 3", stats.DroppedNonFinite)
+	}
+
+	// Non-finite rejections by the repository are final, like series limits
+	repo.batchErr = &ports.NonFiniteValueError{Name: "test.metric", Dropped: 1}
+	svc.flush(ctx)
+	if len(svc.buffer) != 0 {
+		t.Errorf("len(buffer) = %d, want 0 after non-finite rejection", len(svc.buffer))
+	}
+}
+
+func TestMetricService_GetRecentSeriesCache(t *testing.T) {
+	repo := &mockMetricRepository{recent: []ports.SeriesInfo{{Name: "a"}, {Name: "b"}, {Name: "c"}}}
+	config := MetricServiceConfig{BufferSize: 10, FlushInterval: time.Minute, SeriesCacheSize: 2}
+	svc := NewMetricService(repo, &mockLogger{}, config)
+	ctx := context.Background()
+
+	// Before the first refresh requests go to the repository
+	series, err := svc.GetRecentSeries(ctx, 1)
+	if err != nil || len(series) != 1 || repo.recentCalls != 1 {
+		t.Fatalf("expected uncached lookup, got %v, %v after %d calls", series, err, repo.recentCalls)
+	}
+
+	if err := svc.refreshSeriesCache(ctx); err != nil {
+		t.Fatalf("refreshSeriesCache failed: %v", err)
+	}
+	repo.recentCalls = 0
+
+	// Requests that fit the cache are served from it, even if stale
+	repo.recent = []ports.SeriesInfo{{Name: "d"}, {Name: "a"}, {Name: "b"}}
+	series, _ = svc.GetRecentSeries(ctx, 2)
+	if len(series) != 2 || series[0].Name != "a" || series[1].Name != "b" || repo.recentCalls != 0 {
+		t.Errorf("expected cached [a b], got %v after %d calls", series, repo.recentCalls)
+	}
+
+	// Larger requests bypass the cache
+	series, _ = svc.GetRecentSeries(ctx, 3)
+	if len(series) != 3 || series[0].Name != "d" || repo.recentCalls != 1 {
+		t.Errorf("expected fresh lookup of 3 series, got %v after %d calls", series, repo.recentCalls)
+	}
+
+	// A refresh picks up the new ordering
+	if err := svc.refreshSeriesCache(ctx); err != nil {
+		t.Fatalf("refreshSeriesCache failed: %v", err)
+	}
+	series, _ = svc.GetRecentSeries(ctx, 2)
+	if len(series) != 2 || series[0].Name != "d" {
+		t.Errorf("expected refreshed cache starting with d, got %v", series)
+	}
+
+	// A failed refresh keeps the previous contents
+	repo.recentErr = errors.New("database is locked")
+	if err := svc.refreshSeriesCache(ctx); err == nil {
+		t.Error("expected refresh error")
+	}
+	series, err = svc.GetRecentSeries(ctx, 2)
+	if err != nil || len(series) != 2 || series[0].Name != "d" {
+		t.Errorf("expected previous cache to be kept, got %v, %v", series, err)
+	}
+}
+
+func TestMetricService_SeriesCacheRefresher(t *testing.T) {
+	repo := &mockMetricRepository{recent: []ports.SeriesInfo{{Name: "a"}}}
+	config := MetricServiceConfig{BufferSize: 10, FlushInterval: time.Minute, SeriesCacheSize: 10, SeriesCacheRefresh: time.Hour}
+	svc := NewMetricService(repo, &mockLogger{}, config)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	svc.Start(ctx, time.Hour)
+	defer svc.Stop(ctx)
+
+	// The cache is filled as soon as the service starts
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		svc.seriesCacheMu.RLock()
+		filled := !svc.seriesCacheAt.IsZero()
+		svc.seriesCacheMu.RUnlock()
+		if filled {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for series cache")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	series, err := svc.GetRecentSeries(ctx, 5)
+	if err != nil || len(series) != 1 || series[0].Name != "a" {
+		t.Errorf("expected cached series [a], got %v, %v", series, err)
+	}
+}
+
 func TestMetricService_Query(t *testing.T) {
 	repo := &mockMetricRepository{}
 	logger := &mockLogger{}
@@ -167,6 +396,113 @@ func TestMetricService_Query(t *testing.T) {
 	}
 }
 
+func TestMetricService_QueryCache(t *testing.T) {
+	repo := &mockMetricRepository{}
+	svc := NewMetricService(repo, &mockLogger{}, DefaultMetricServiceConfig())
+	ctx := context.Background()
+	query := ports.MetricQuery{Name: "cpu", EndTime: time.Now().Add(time.Hour)}
+
+	_ = svc.Record(ctx, "cpu", domain.MetricTypeGauge, 1, map[string]string{"host": "a"})
+	first, err := svc.Query(ctx, query)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	// Callers may modify results without affecting the cache
+	first.Points[0].Value = 100
+
+	second, _ := svc.Query(ctx, query)
+	if repo.queryCalls != 1 {
+		t.Errorf("expected repeated query to be cached, got %d repo calls", repo.queryCalls)
+	}
+	if second.Points[0].Value != 1 {
+		t.Errorf("expected cached value 1, got %v", second.Points[0].Value)
+	}
+
+	// A new point in the series invalidates the cached result
+	_ = svc.Record(ctx, "cpu", domain.MetricTypeGauge, 2, map[string]string{"host": "a"})
+	third, _ := svc.Query(ctx, query)
+	if repo.queryCalls != 2 || len(third.Points) != 2 {
+		t.Errorf("expected fresh result with 2 points, got %d points after %d repo calls", len(third.Points), repo.queryCalls)
+	}
+
+	// Writes to other metrics leave it alone
+	_ = svc.Record(ctx, "mem", domain.MetricTypeGauge, 3, nil)
+	_, _ = svc.Query(ctx, query)
+	if repo.queryCalls != 2 {
+		t.Errorf("expected write to another metric to keep the cache, got %d repo calls", repo.queryCalls)
+	}
+
+	// Deleting data drops everything
+	if _, err := svc.Cleanup(ctx, time.Hour); err != nil {
+		t.Fatalf("Cleanup failed: %v", err)
+	}
+	_, _ = svc.Query(ctx, query)
+	if repo.queryCalls != 3 {
+		t.Errorf("expected cleanup to invalidate the cache, got %d repo calls", repo.queryCalls)
+	}
+}
+
+func TestMetricService_QueryCachePerSeries(t *testing.T) {
+	repo := &mockMetricRepository{}
+	svc := NewMetricService(repo, &mockLogger{}, DefaultMetricServiceConfig())
+	ctx := context.Background()
+
+	hostA := domain.NewMetric("cpu", domain.MetricTypeGauge, 1, map[string]string{"host": "a"})
+	query := ports.MetricQuery{Name: "cpu", SeriesHash: &hostA.SeriesHash}
+	nameQuery := ports.MetricQuery{Name: "cpu"}
+
+	_, _ = svc.Query(ctx, query)
+	_, _ = svc.Query(ctx, nameQuery)
+	if repo.queryCalls != 2 {
+		t.Fatalf("expected 2 repo calls, got %d", repo.queryCalls)
+	}
+
+	// Another series of the same metric only invalidates name-wide queries
+	_ = svc.Record(ctx, "cpu", domain.MetricTypeGauge, 1, map[string]string{"host": "b"})
+	_, _ = svc.Query(ctx, query)
+	if repo.queryCalls != 2 {
+		t.Errorf("expected series query to stay cached, got %d repo calls", repo.queryCalls)
+	}
+	_, _ = svc.Query(ctx, nameQuery)
+	if repo.queryCalls != 3 {
+		t.Errorf("expected name query to be invalidated, got %d repo calls", repo.queryCalls)
+	}
+
+	_ = svc.Record(ctx, "cpu", domain.MetricTypeGauge, 2, map[string]string{"host": "a"})
+	_, _ = svc.Query(ctx, query)
+	if repo.queryCalls != 4 {
+		t.Errorf("expected series query to be invalidated, got %d repo calls", repo.queryCalls)
+	}
+}
+
+func TestQueryCache_StaleRead(t *testing.T) {
+	cache := newQueryCache(2, time.Minute)
+	query := ports.MetricQuery{Name: "cpu"}
+	key := queryCacheKey("query", query)
+
+	// A write landing while the result was being read keeps it out
+	gen := cache.generation(query)
+	cache.recordWrites([]*domain.Metric{domain.NewMetric("cpu", domain.MetricTypeGauge, 1, nil)})
+	cache.put(key, query, gen, "stale")
+	if _, ok := cache.get(key); ok {
+		t.Error("expected stale result not to be cached")
+	}
+
+	cache.put(key, query, cache.generation(query), "fresh")
+	if v, ok := cache.get(key); !ok || v != "fresh" {
+		t.Errorf("expected fresh result, got %v, %v", v, ok)
+	}
+
+	// The oldest entry makes room once full
+	for _, name := range []string{"mem", "disk"} {
+		q := ports.MetricQuery{Name: name}
+		cache.put(queryCacheKey("query", q), q, cache.generation(q), name)
+	}
+	if _, ok := cache.get(key); ok || len(cache.entries) != 2 {
+		t.Errorf("expected oldest entry to be evicted, have %d entries", len(cache.entries))
+	}
+}
+
 func TestParseResolution(t *testing.T) {
 	tests := []struct {
 		input   string
@@ -195,3 +531,752 @@ func TestParseResolution(t *testing.T) {
 	}
 }
 
+
+const bytesPerMB = 1024 * 1024
+
+func TestMetricService_IngestTransform(t *testing.T) {
+	repo := &mockMetricRepository{}
+	svc := NewMetricService(repo, &mockLogger{}, DefaultMetricServiceConfig())
+	err := svc.SetTransformRules([]domain.MetricTransformRule{{
+		Name:   "bytes-to-mb",
+		Metric: "disk.used",
+		Tags:   map[string]string{"source": "node"},
+		Scale:  1.0 / bytesPerMB,
+		Unit:   "MB",
+	}})
+	if err != nil {
+		t.Fatalf("SetTransformRules failed: %v", err)
+	}
+
+	ctx := context.Background()
+	tags := map[string]string{"source": "node"}
+	_ = svc.Record(ctx, "disk.used", domain.MetricTypeGauge, 512*bytesPerMB, tags)
+	_ = svc.Record(ctx, "disk.used", domain.MetricTypeGauge, 2*bytesPerMB, tags)
+
+	series, err := svc.Query(ctx, ports.MetricQuery{Name: "disk.used", Tags: tags})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	// Stored and queried values are both in MB
+	if repo.metrics[0].Value != 512 || repo.metrics[1].Value != 2 {
+		t.Errorf("expected stored values [512 2], got [%v %v]", repo.metrics[0].Value, repo.metrics[1].Value)
+	}
+	if series.Points[0].Value != 512 || series.Points[1].Value != 2 {
+		t.Errorf("expected queried values [512 2], got [%v %v]", series.Points[0].Value, series.Points[1].Value)
+	}
+	if series.Unit != "MB" {
+		t.Errorf("expected unit MB, got %q", series.Unit)
+	}
+
+	// Series not matching the tag matcher are left alone
+	_ = svc.Record(ctx, "disk.used", domain.MetricTypeGauge, 100, map[string]string{"source": "agent"})
+	svc.flush(ctx)
+	if got := repo.metrics[2].Value; got != 100 {
+		t.Errorf("expected unmatched series to be stored as-is, got %v", got)
+	}
+}
+
+func TestMetricService_Relabel(t *testing.T) {
+	repo := &mockMetricRepository{}
+	svc := NewMetricService(repo, &mockLogger{}, DefaultMetricServiceConfig())
+	err := svc.SetRelabelRules([]domain.MetricRelabelRule{
+		{Name: "host", Metric: "http.*", Action: domain.MetricRelabelRenameTag, SourceTag: "hostname", TargetTag: "host"},
+		{Name: "no-request-ids", Action: domain.MetricRelabelDropTag, SourceTag: "request_id"},
+	})
+	if err != nil {
+		t.Fatalf("SetRelabelRules failed: %v", err)
+	}
+
+	ctx := context.Background()
+	tags := map[string]string{"hostname": "web-1", "request_id": "4f1c", "route": "/orders"}
+	if err := svc.Record(ctx, "http.requests", domain.MetricTypeCounter, 1, tags); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	batch := []*domain.Metric{
+		domain.NewMetric("http.requests", domain.MetricTypeCounter, 1, map[string]string{"hostname": "web-2", "request_id": "9a0e"}),
+	}
+	if err := svc.RecordBatch(ctx, batch); err != nil {
+		t.Fatalf("RecordBatch failed: %v", err)
+	}
+	svc.flush(ctx)
+
+	if len(repo.metrics) != 2 {
+		t.Fatalf("expected 2 stored metrics, got %d", len(repo.metrics))
+	}
+	for _, m := range repo.metrics {
+		if _, ok := m.Tags["request_id"]; ok {
+			t.Errorf("expected request_id to be dropped before storage, got %v", m.Tags)
+		}
+		if _, ok := m.Tags["hostname"]; ok || m.Tags["host"] == "" {
+			t.Errorf("expected hostname to be renamed to host, got %v", m.Tags)
+		}
+	}
+	// Batches are written straight away, ahead of the buffered point
+	batched, buffered := repo.metrics[0], repo.metrics[1]
+	if buffered.Tags["route"] != "/orders" {
+		t.Errorf("expected other tags to be kept, got %v", buffered.Tags)
+	}
+
+	// The series hash follows the new identity, so relabeled points of
+	// the same host land in one series
+	want := domain.NewMetric("http.requests", domain.MetricTypeCounter, 1, map[string]string{"host": "web-2"})
+	if batched.SeriesHash != want.SeriesHash {
+		t.Errorf("expected the series hash of the relabeled identity")
+	}
+
+	// The caller's tags are not modified
+	if tags["hostname"] != "web-1" || tags["request_id"] != "4f1c" {
+		t.Errorf("expected the caller's tags to be untouched, got %v", tags)
+	}
+
+	if err := svc.SetRelabelRules([]domain.MetricRelabelRule{{Action: "explode"}}); err == nil {
+		t.Error("expected an invalid rule to be rejected")
+	}
+	if len(svc.RelabelRules()) != 2 {
+		t.Errorf("expected a rejected update to keep the previous rules")
+	}
+}
+
+func TestMetricService_QueryTransform(t *testing.T) {
+	repo := &mockMetricRepository{
+		aggResults: []ports.AggregatedResult{{
+			Count: 2, Sum: 3 * bytesPerMB, Min: bytesPerMB, Max: 2 * bytesPerMB, Avg: 1.5 * bytesPerMB,
+		}},
+	}
+	svc := NewMetricService(repo, &mockLogger{}, DefaultMetricServiceConfig())
+	err := svc.SetTransformRules([]domain.MetricTransformRule{{
+		Metric: "net.*.bytes",
+		Scale:  1.0 / bytesPerMB,
+		Unit:   "MB",
+		Stage:  domain.MetricTransformQuery,
+	}})
+	if err != nil {
+		t.Fatalf("SetTransformRules failed: %v", err)
+	}
+
+	ctx := context.Background()
+	_ = svc.Record(ctx, "net.rx.bytes", domain.MetricTypeGauge, 8*bytesPerMB, nil)
+
+	series, err := svc.Query(ctx, ports.MetricQuery{Name: "net.rx.bytes"})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	// Stored values stay raw; only the query result is converted
+	if repo.metrics[0].Value != 8*bytesPerMB {
+		t.Errorf("expected raw stored value, got %v", repo.metrics[0].Value)
+	}
+	if series.Points[0].Value != 8 {
+		t.Errorf("expected queried value 8, got %v", series.Points[0].Value)
+	}
+	if series.Unit != "MB" {
+		t.Errorf("expected unit MB, got %q", series.Unit)
+	}
+
+	results, err := svc.QueryWithAggregation(ctx, ports.MetricQuery{Name: "net.rx.bytes"})
+	if err != nil {
+		t.Fatalf("QueryWithAggregation failed: %v", err)
+	}
+	r := results[0]
+	if r.Sum != 3 || r.Min != 1 || r.Max != 2 || r.Avg != 1.5 {
+		t.Errorf("expected aggregates in MB (sum 3, min 1, max 2, avg 1.5), got %+v", r)
+	}
+
+	repo.groups = []ports.GroupedResult{
+		{Tags: map[string]string{"host": "a"}, Results: []ports.AggregatedResult{{Value: 4 * bytesPerMB, Avg: 4 * bytesPerMB, Count: 1}}},
+		{Tags: map[string]string{"host": "b"}, Results: []ports.AggregatedResult{{Value: 6 * bytesPerMB, Avg: 6 * bytesPerMB, Count: 1}}},
+	}
+	groups, err := svc.QueryGrouped(ctx, ports.MetricQuery{Name: "net.rx.bytes", GroupBy: []string{"host"}})
+	if err != nil {
+		t.Fatalf("QueryGrouped failed: %v", err)
+	}
+	if groups[0].Results[0].Value != 4 || groups[1].Results[0].Value != 6 {
+		t.Errorf("expected every group converted to MB, got %+v", groups)
+	}
+}
+
+func TestMetricService_QueryTransformPerAggregation(t *testing.T) {
+	// Three points, 1, 2 and 6, in one bucket
+	raw := ports.AggregatedResult{Count: 3, Sum: 9, Min: 1, Max: 6, Avg: 3}
+	tests := []struct {
+		name  string
+		agg   ports.AggregationType
+		value float64 // Raw value the repository picks for agg
+		scale float64
+		want  ports.AggregatedResult
+	}{
+		// value*2 + 10 maps the points to 12, 14 and 22
+		{"avg", ports.AggregationAvg, 3, 2, ports.AggregatedResult{Value: 16, Count: 3, Sum: 48, Min: 12, Max: 22, Avg: 16}},
+		{"sum", ports.AggregationSum, 9, 2, ports.AggregatedResult{Value: 48, Count: 3, Sum: 48, Min: 12, Max: 22, Avg: 16}},
+		{"min", ports.AggregationMin, 1, 2, ports.AggregatedResult{Value: 12, Count: 3, Sum: 48, Min: 12, Max: 22, Avg: 16}},
+		{"max", ports.AggregationMax, 6, 2, ports.AggregatedResult{Value: 22, Count: 3, Sum: 48, Min: 12, Max: 22, Avg: 16}},
+		{"count", ports.AggregationCount, 3, 2, ports.AggregatedResult{Value: 3, Count: 3, Sum: 48, Min: 12, Max: 22, Avg: 16}},
+		{"last", ports.AggregationLast, 2, 2, ports.AggregatedResult{Value: 14, Count: 3, Sum: 48, Min: 12, Max: 22, Avg: 16}},
+		// value*-1 + 10 maps the points to 9, 8 and 4, so min and max swap
+		{"min with a negative scale", ports.AggregationMin, 1, -1, ports.AggregatedResult{Value: 4, Count: 3, Sum: 21, Min: 4, Max: 9, Avg: 7}},
+		{"max with a negative scale", ports.AggregationMax, 6, -1, ports.AggregatedResult{Value: 9, Count: 3, Sum: 21, Min: 4, Max: 9, Avg: 7}},
+		{"sum with a negative scale", ports.AggregationSum, 9, -1, ports.AggregatedResult{Value: 21, Count: 3, Sum: 21, Min: 4, Max: 9, Avg: 7}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := raw
+			result.Value = tt.value
+			svc := NewMetricService(&mockMetricRepository{aggResults: []ports.AggregatedResult{result}}, &mockLogger{}, DefaultMetricServiceConfig())
+			err := svc.SetTransformRules([]domain.MetricTransformRule{{
+				Metric: "temp",
+				Scale:  tt.scale,
+				Offset: 10,
+				Stage:  domain.MetricTransformQuery,
+			}})
+			if err != nil {
+				t.Fatalf("SetTransformRules failed: %v", err)
+			}
+
+			results, err := svc.QueryWithAggregation(context.Background(), ports.MetricQuery{Name: "temp", Aggregation: tt.agg})
+			if err != nil {
+				t.Fatalf("QueryWithAggregation failed: %v", err)
+			}
+			if results[0] != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, results[0])
+			}
+		})
+	}
+}
+
+func TestMetricService_RecordBatch(t *testing.T) {
+	repo := &mockMetricRepository{}
+	svc := NewMetricService(repo, &mockLogger{}, DefaultMetricServiceConfig())
+	err := svc.SetTransformRules([]domain.MetricTransformRule{{
+		Metric: "mem_bytes",
+		Scale:  1.0 / bytesPerMB,
+		Stage:  domain.MetricTransformIngest,
+	}})
+	if err != nil {
+		t.Fatalf("SetTransformRules failed: %v", err)
+	}
+	ctx := context.Background()
+	query := ports.MetricQuery{Name: "mem_bytes", EndTime: time.Now().Add(time.Hour)}
+
+	if _, err := svc.Query(ctx, query); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	metrics := []*domain.Metric{
+		domain.NewMetric("mem_bytes", domain.MetricTypeGauge, 4*bytesPerMB, nil),
+		domain.NewMetric("mem_bytes", domain.MetricTypeGauge, 6*bytesPerMB, nil),
+	}
+	if err := svc.RecordBatch(ctx, metrics); err != nil {
+		t.Fatalf("RecordBatch failed: %v", err)
+	}
+
+	// Written immediately, with ingest transforms applied
+	if len(repo.metrics) != 2 || repo.metrics[0].Value != 4 || repo.metrics[1].Value != 6 {
+		t.Errorf("expected transformed values [4 6] in the repository, got %d metrics", len(repo.metrics))
+	}
+	series, err := svc.Query(ctx, query)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if repo.queryCalls != 2 || len(series.Points) != 2 {
+		t.Errorf("expected the write to invalidate the cached query, got %d points after %d repo calls", len(series.Points), repo.queryCalls)
+	}
+
+	repo.batchErr = errors.New("disk full")
+	if err := svc.RecordBatch(ctx, metrics); err == nil {
+		t.Error("expected repository errors to be returned")
+	}
+}
+
+func TestMetricService_RecordBatchBestEffort(t *testing.T) {
+	repo := &mockMetricRepository{}
+	svc := NewMetricService(repo, &mockLogger{}, DefaultMetricServiceConfig())
+	ctx := context.Background()
+	query := ports.MetricQuery{Name: "queue.depth", EndTime: time.Now().Add(time.Hour)}
+
+	if _, err := svc.Query(ctx, query); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	metrics := []*domain.Metric{
+		domain.NewMetric("queue.depth", domain.MetricTypeGauge, 1, nil),
+		domain.NewMetric("queue.depth", domain.MetricTypeGauge, math.NaN(), nil),
+		domain.NewMetric("queue.depth", domain.MetricTypeGauge, 3, nil),
+	}
+	report, err := svc.RecordBatchBestEffort(ctx, metrics)
+	if err != nil {
+		t.Fatalf("RecordBatchBestEffort failed: %v", err)
+	}
+	if report.Written != 2 || len(report.Failed) != 1 || report.Failed[0].Index != 1 || report.Failed[0].Reason == "" {
+		t.Errorf("expected entry 1 to fail and 2 to be written, got %+v", report)
+	}
+	if len(repo.metrics) != 2 {
+		t.Errorf("expected 2 metrics in the repository, got %d", len(repo.metrics))
+	}
+
+	// The valid writes invalidate cached queries
+	if _, err := svc.Query(ctx, query); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if repo.queryCalls != 2 {
+		t.Errorf("expected the write to invalidate the cached query, got %d repo calls", repo.queryCalls)
+	}
+
+	repo.batchErr = errors.New("disk full")
+	if _, err := svc.RecordBatchBestEffort(ctx, metrics); err == nil {
+		t.Error("expected repository errors to be returned")
+	}
+}
+
+func TestMetricService_SetTransformRulesInvalid(t *testing.T) {
+	svc := NewMetricService(&mockMetricRepository{}, &mockLogger{}, DefaultMetricServiceConfig())
+	if err := svc.SetTransformRules([]domain.MetricTransformRule{{Metric: "a", Stage: "bogus"}}); err == nil {
+		t.Error("expected invalid stage to be rejected")
+	}
+	if len(svc.TransformRules()) != 0 {
+		t.Error("expected rules to be unchanged after a failed update")
+	}
+}
+
+func TestMetricService_Metadata(t *testing.T) {
+	svc := NewMetricService(&mockMetricRepository{}, &mockLogger{}, DefaultMetricServiceConfig())
+	err := svc.SetMetadata([]domain.MetricMetadata{
+		{Metric: "disk.used", Description: "Disk space used.", Unit: "bytes"},
+		{Metric: "disk.*", Description: "Disk statistics."},
+	})
+	if err != nil {
+		t.Fatalf("SetMetadata failed: %v", err)
+	}
+
+	if m, ok := svc.MetadataFor("disk.used"); !ok || m.Description != "Disk space used." {
+		t.Errorf("expected the first matching entry, got %+v", m)
+	}
+	if m, ok := svc.MetadataFor("disk.free"); !ok || m.Description != "Disk statistics." {
+		t.Errorf("expected the glob entry, got %+v", m)
+	}
+	if _, ok := svc.MetadataFor("cpu.usage"); ok {
+		t.Error("expected no metadata for cpu.usage")
+	}
+
+	// Metadata units apply when no transform rule sets one
+	if unit := svc.UnitFor("disk.used", nil); unit != "bytes" {
+		t.Errorf("expected unit bytes from metadata, got %q", unit)
+	}
+	_ = svc.SetTransformRules([]domain.MetricTransformRule{{Metric: "disk.used", Scale: 1.0 / bytesPerMB, Unit: "MB"}})
+	if unit := svc.UnitFor("disk.used", nil); unit != "MB" {
+		t.Errorf("expected the transform unit to win, got %q", unit)
+	}
+
+	if err := svc.SetMetadata([]domain.MetricMetadata{{Metric: "x", Type: "summary"}}); err == nil {
+		t.Error("expected an invalid type to be rejected")
+	}
+	if _, ok := svc.MetadataFor("disk.used"); !ok {
+		t.Error("expected metadata to be unchanged after a failed update")
+	}
+}
+
+func TestMetricService_QueryMerged(t *testing.T) {
+	ctx := context.Background()
+	base := time.Now().Truncate(time.Hour).Add(-6 * time.Hour)
+	repo := &mockMetricRepository{}
+
+	// 5m rollups with value 1 cover the first two and a half hours
+	for w := base; w.Before(base.Add(150 * time.Minute)); w = w.Add(5 * time.Minute) {
+		repo.rollups = append(repo.rollups, &domain.AggregatedMetric{
+			Name: "cpu", Resolution: "5m", WindowStart: w, WindowEnd: w.Add(5 * time.Minute),
+			Count: 5, Sum: 5, Min: 1, Max: 1, Avg: 1,
+		})
+	}
+	// A rollup of data that is still raw must not be counted again
+	repo.rollups = append(repo.rollups, &domain.AggregatedMetric{
+		Name: "cpu", Resolution: "5m", WindowStart: base.Add(160 * time.Minute), WindowEnd: base.Add(165 * time.Minute),
+		Count: 100, Sum: 5000, Min: 50, Max: 50, Avg: 50,
+	})
+	// Raw points with value 10 every 10 minutes from 2h30m on
+	for ts := base.Add(150 * time.Minute); ts.Before(base.Add(6 * time.Hour)); ts = ts.Add(10 * time.Minute) {
+		repo.metrics = append(repo.metrics, &domain.Metric{Name: "cpu", Value: 10, Timestamp: ts})
+	}
+
+	svc := NewMetricService(repo, &NopLogger{}, DefaultMetricServiceConfig())
+	results, err := svc.QueryMerged(ctx, ports.MetricQuery{
+		Name:        "cpu",
+		StartTime:   base,
+		EndTime:     base.Add(6 * time.Hour),
+		Step:        time.Hour,
+		Aggregation: ports.AggregationAvg,
+	})
+	if err != nil {
+		t.Fatalf("QueryMerged failed: %v", err)
+	}
+
+	if len(results) != 6 {
+		t.Fatalf("Expected 6 hourly buckets, got %d", len(results))
+	}
+	for i, r := range results {
+		if want := base.Add(time.Duration(i) * time.Hour); !r.Timestamp.Equal(want) {
+			t.Errorf("Bucket %d: expected timestamp %v, got %v", i, want, r.Timestamp)
+		}
+	}
+
+	expected := []struct {
+		count int64
+		sum   float64
+	}{
+		{60, 60}, {60, 60},
+		{33, 60}, // 30 from rollups before 2h30m, 3 raw points after
+		{6, 60}, {6, 60}, {6, 60},
+	}
+	for i, want := range expected {
+		r := results[i]
+		if r.Count != want.count || r.Sum != want.sum {
+			t.Errorf("Bucket %d: expected count %d sum %v, got count %d sum %v", i, want.count, want.sum, r.Count, r.Sum)
+		}
+		if r.Value != want.sum/float64(want.count) {
+			t.Errorf("Bucket %d: expected avg %v, got %v", i, want.sum/float64(want.count), r.Value)
+		}
+	}
+	if results[2].Max != 10 {
+		t.Errorf("Expected boundary bucket max 10, got %v", results[2].Max)
+	}
+}
+
+func TestMetricService_QueryMergedRollupsOnly(t *testing.T) {
+	ctx := context.Background()
+	base := time.Now().Truncate(24 * time.Hour).Add(-10 * 24 * time.Hour)
+	repo := &mockMetricRepository{}
+	for i := 0; i < 48; i++ {
+		w := base.Add(time.Duration(i) * time.Hour)
+		repo.rollups = append(repo.rollups, &domain.AggregatedMetric{
+			Name: "mem", Resolution: "1h", WindowStart: w, WindowEnd: w.Add(time.Hour),
+			Count: 60, Sum: 60 * float64(i), Min: float64(i), Max: float64(i), Avg: float64(i),
+		})
+	}
+
+	svc := NewMetricService(repo, &NopLogger{}, DefaultMetricServiceConfig())
+	results, err := svc.QueryMerged(ctx, ports.MetricQuery{
+		Name:        "mem",
+		StartTime:   base,
+		EndTime:     base.Add(2 * 24 * time.Hour),
+		Step:        24 * time.Hour,
+		Aggregation: ports.AggregationMax,
+	})
+	if err != nil {
+		t.Fatalf("QueryMerged failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 daily buckets, got %d", len(results))
+	}
+	if results[0].Value != 23 || results[1].Value != 47 {
+		t.Errorf("Expected daily max 23 and 47, got %v and %v", results[0].Value, results[1].Value)
+	}
+	if results[0].Count != 24*60 {
+		t.Errorf("Expected count %d, got %d", 24*60, results[0].Count)
+	}
+}
+
+func TestRollupCandidates(t *testing.T) {
+	tests := []struct {
+		step time.Duration
+		want []string
+	}{
+		{24 * time.Hour, []string{"1d", "1h", "5m", "1m"}},
+		{time.Hour, []string{"1h", "5m", "1m"}},
+		{10 * time.Minute, []string{"5m", "1m"}},
+		{90 * time.Second, []string{"1m"}},
+		{30 * time.Second, []string{"1m"}},
+	}
+	for _, tt := range tests {
+		got := rollupCandidates(tt.step)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("rollupCandidates(%v) = %v, want %v", tt.step, got, tt.want)
+		}
+	}
+}
+
+func TestMetricService_QueryPlannedAuto(t *testing.T) {
+	ctx := context.Background()
+	base := time.Now().Truncate(time.Hour).Add(-30 * 24 * time.Hour)
+	boundary := base.Add(23 * 24 * time.Hour)
+	repo := &mockMetricRepository{}
+
+	// Hourly rollups with value 1 for the first 23 days, then raw points
+	// with value 10 every 10 minutes
+	for w := base; w.Before(boundary); w = w.Add(time.Hour) {
+		repo.rollups = append(repo.rollups, &domain.AggregatedMetric{
+			Name: "cpu", Resolution: "1h", WindowStart: w, WindowEnd: w.Add(time.Hour),
+			Count: 60, Sum: 60, Min: 1, Max: 1, Avg: 1,
+		})
+	}
+	for ts := boundary; ts.Before(base.Add(30 * 24 * time.Hour)); ts = ts.Add(10 * time.Minute) {
+		repo.metrics = append(repo.metrics, &domain.Metric{Name: "cpu", Value: 10, Timestamp: ts})
+	}
+
+	svc := NewMetricService(repo, &NopLogger{}, DefaultMetricServiceConfig())
+	series, err := svc.Query(ctx, ports.MetricQuery{
+		Name:       "cpu",
+		StartTime:  base,
+		EndTime:    base.Add(30*24*time.Hour - time.Millisecond),
+		Resolution: ResolutionAuto,
+	})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	// Without a step a 30 day range is bucketed hourly
+	if len(series.Points) != 30*24 {
+		t.Fatalf("Expected %d hourly points, got %d", 30*24, len(series.Points))
+	}
+	for i, p := range series.Points {
+		want := base.Add(time.Duration(i) * time.Hour)
+		if !p.Timestamp.Equal(want) {
+			t.Fatalf("Point %d: expected timestamp %v, got %v", i, want, p.Timestamp)
+		}
+		wantRes, wantValue := "1h", 1.0
+		if !p.Timestamp.Before(boundary) {
+			wantRes, wantValue = ResolutionRaw, 10
+		}
+		if p.Resolution != wantRes || p.Value != wantValue {
+			t.Fatalf("Point %d: expected %v from %s, got %v from %s", i, wantValue, wantRes, p.Value, p.Resolution)
+		}
+	}
+}
+
+func TestMetricService_QueryPlannedNative(t *testing.T) {
+	ctx := context.Background()
+	base := time.Now().Truncate(time.Minute).Add(-3 * time.Hour)
+	repo := &mockMetricRepository{}
+
+	// One hour of 1m rollups and 5m rollups, then raw points every 30s
+	for w := base; w.Before(base.Add(time.Hour)); w = w.Add(time.Minute) {
+		repo.rollups = append(repo.rollups, &domain.AggregatedMetric{
+			Name: "cpu", Resolution: "1m", WindowStart: w, WindowEnd: w.Add(time.Minute),
+			Count: 2, Sum: 4, Min: 2, Max: 2, Avg: 2,
+		})
+	}
+	for w := base; w.Before(base.Add(time.Hour)); w = w.Add(5 * time.Minute) {
+		repo.rollups = append(repo.rollups, &domain.AggregatedMetric{
+			Name: "cpu", Resolution: "5m", WindowStart: w, WindowEnd: w.Add(5 * time.Minute),
+			Count: 10, Sum: 20, Min: 2, Max: 2, Avg: 2,
+		})
+	}
+	for ts := base.Add(time.Hour); ts.Before(base.Add(3 * time.Hour)); ts = ts.Add(30 * time.Second) {
+		repo.metrics = append(repo.metrics, &domain.Metric{Name: "cpu", Value: 7, Timestamp: ts})
+	}
+
+	svc := NewMetricService(repo, &NopLogger{}, DefaultMetricServiceConfig())
+	series, err := svc.Query(ctx, ports.MetricQuery{
+		Name:       "cpu",
+		StartTime:  base,
+		EndTime:    base.Add(3 * time.Hour),
+		Resolution: ResolutionAuto,
+	})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	// Short ranges keep native resolution: the finest rollups, then every
+	// raw point
+	if len(series.Points) != 60+240 {
+		t.Fatalf("Expected %d points, got %d", 60+240, len(series.Points))
+	}
+	if p := series.Points[0]; p.Resolution != "1m" || p.Value != 2 || !p.Timestamp.Equal(base) {
+		t.Errorf("Expected first point 2 at %v from 1m, got %+v", base, p)
+	}
+	if p := series.Points[60]; p.Resolution != ResolutionRaw || p.Value != 7 || !p.Timestamp.Equal(base.Add(time.Hour)) {
+		t.Errorf("Expected first raw point 7 at %v, got %+v", base.Add(time.Hour), p)
+	}
+
+	limited, err := svc.Query(ctx, ports.MetricQuery{
+		Name:       "cpu",
+		StartTime:  base,
+		EndTime:    base.Add(3 * time.Hour),
+		Limit:      10,
+		Resolution: ResolutionAuto,
+	})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(limited.Points) != 10 || limited.Points[9].Resolution != "1m" {
+		t.Errorf("Expected the 10 oldest points, got %+v", limited.Points)
+	}
+}
+
+func TestMetricService_QueryPlannedExplicit(t *testing.T) {
+	ctx := context.Background()
+	base := time.Now().Truncate(24 * time.Hour).Add(-10 * 24 * time.Hour)
+	repo := &mockMetricRepository{}
+	for i := 0; i < 48; i++ {
+		w := base.Add(time.Duration(i) * time.Hour)
+		repo.rollups = append(repo.rollups, &domain.AggregatedMetric{
+			Name: "mem", Resolution: "1h", WindowStart: w, WindowEnd: w.Add(time.Hour),
+			Count: 60, Sum: 60 * float64(i), Min: float64(i), Max: float64(i), Avg: float64(i),
+		})
+	}
+	repo.metrics = append(repo.metrics, &domain.Metric{Name: "mem", Value: 99, Timestamp: base.Add(time.Hour)})
+	svc := NewMetricService(repo, &NopLogger{}, DefaultMetricServiceConfig())
+
+	// A step coarser than the rollups re-buckets them
+	series, err := svc.Query(ctx, ports.MetricQuery{
+		Name:        "mem",
+		StartTime:   base,
+		EndTime:     base.Add(2 * 24 * time.Hour),
+		Step:        24 * time.Hour,
+		Aggregation: ports.AggregationMax,
+		Resolution:  "1h",
+	})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(series.Points) != 2 {
+		t.Fatalf("Expected 2 daily points, got %d", len(series.Points))
+	}
+	if series.Points[0].Value != 23 || series.Points[1].Value != 47 || series.Points[0].Resolution != "1h" {
+		t.Errorf("Expected daily max 23 and 47 from 1h, got %+v", series.Points)
+	}
+
+	// raw never reads rollups
+	raw, err := svc.Query(ctx, ports.MetricQuery{
+		Name:       "mem",
+		StartTime:  base,
+		EndTime:    base.Add(2 * 24 * time.Hour),
+		Resolution: ResolutionRaw,
+	})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(raw.Points) != 1 || raw.Points[0].Value != 99 || raw.Points[0].Resolution != ResolutionRaw {
+		t.Errorf("Expected the single raw point, got %+v", raw.Points)
+	}
+
+	if _, err := svc.Query(ctx, ports.MetricQuery{Name: "mem", Resolution: "10s"}); err == nil {
+		t.Error("Expected an error for an unsupported resolution")
+	}
+}
+
+func TestRetentionPolicy_Validate(t *testing.T) {
+	if err := DefaultRetentionPolicy().Validate(); err != nil {
+		t.Errorf("default policy should be valid: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		policy RetentionPolicy
+	}{
+		{"no raw retention", RetentionPolicy{Interval: time.Hour}},
+		{"no interval", RetentionPolicy{RawRetention: time.Hour}},
+		{"unsupported resolution", RetentionPolicy{RawRetention: time.Hour, Interval: time.Hour,
+			Tiers: []RetentionTier{{Resolution: "2m", Retention: 24 * time.Hour}}}},
+		{"duplicate tier", RetentionPolicy{RawRetention: time.Hour, Interval: time.Hour,
+			Tiers: []RetentionTier{{Resolution: "1m", Retention: 24 * time.Hour}, {Resolution: "1m", Retention: 48 * time.Hour}}}},
+		{"tier shorter than raw", RetentionPolicy{RawRetention: 48 * time.Hour, Interval: time.Hour,
+			Tiers: []RetentionTier{{Resolution: "5m", Retention: 24 * time.Hour}}}},
+	}
+	for _, tt := range tests {
+		if err := tt.policy.Validate(); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+func TestMetricService_RetentionJob(t *testing.T) {
+	repo := &mockMetricRepository{}
+	svc := NewMetricService(repo, &NopLogger{}, DefaultMetricServiceConfig())
+	sched := NewScheduleService(&NopLogger{})
+
+	// Without tiers the job prunes raw points past retention
+	policy := RetentionPolicy{RawRetention: time.Hour, Interval: 20 * time.Millisecond}
+	if err := svc.SetRetentionPolicy(policy, sched); err != nil {
+		t.Fatalf("SetRetentionPolicy failed: %v", err)
+	}
+	if err := svc.SetRetentionPolicy(RetentionPolicy{}, nil); err == nil {
+		t.Error("expected an invalid policy to be rejected")
+	}
+
+	ctx := context.Background()
+	svc.Start(ctx, time.Hour)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		entry, ok := sched.Get(RetentionScheduleName)
+		if !ok {
+			t.Fatal("expected the retention job to be registered")
+		}
+		if entry.LastRun != nil {
+			if entry.LastStatus != ScheduleStatusSuccess {
+				t.Errorf("expected a successful run, got %s: %s", entry.LastStatus, entry.LastError)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("retention job did not run")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	svc.Stop(ctx)
+
+	if len(repo.deletedBefore) == 0 {
+		t.Fatal("expected raw points to be pruned")
+	}
+	if age := time.Since(repo.deletedBefore[0]); age < time.Hour || age > time.Hour+time.Minute {
+		t.Errorf("expected points older than an hour to be pruned, got cutoff %v ago", age)
+	}
+}
+
+func TestMetricService_CompareSeries(t *testing.T) {
+	base := time.Now().Truncate(time.Hour).Add(-time.Hour)
+	repo := &mockMetricRepository{}
+	for _, offset := range []time.Duration{2 * time.Minute, 17 * time.Minute, 48 * time.Minute} {
+		repo.metrics = append(repo.metrics, &domain.Metric{Name: "cpu", Value: 5, Timestamp: base.Add(offset)})
+	}
+	svc := NewMetricService(repo, &NopLogger{}, DefaultMetricServiceConfig())
+
+	comparison, err := svc.CompareSeries(context.Background(),
+		ports.MetricQuery{Name: "cpu", StartTime: base, EndTime: base.Add(time.Hour), Step: 15 * time.Minute},
+		ports.MetricQuery{Tags: map[string]string{"env": "staging"}},
+	)
+	if err != nil {
+		t.Fatalf("CompareSeries failed: %v", err)
+	}
+	if comparison.Step != 15*time.Minute {
+		t.Errorf("Expected step 15m, got %v", comparison.Step)
+	}
+	// The mock ignores tags, so both sides see the same buckets
+	if len(comparison.Points) != 3 {
+		t.Fatalf("Expected 3 buckets, got %d", len(comparison.Points))
+	}
+	for _, p := range comparison.Points {
+		if p.Diff == nil || *p.Diff != 0 {
+			t.Errorf("Expected zero diff at %v, got %v", p.Timestamp, p.Diff)
+		}
+	}
+
+	if _, err := svc.CompareSeries(context.Background(),
+		ports.MetricQuery{Name: "cpu", StartTime: base, EndTime: base}, ports.MetricQuery{}); err == nil {
+		t.Error("Expected an empty range to be rejected")
+	}
+}
+
+func TestAlignSeries(t *testing.T) {
+	base := time.Unix(1700000000, 0).Truncate(time.Minute)
+	left := []ports.AggregatedResult{
+		{Timestamp: base, Value: 10},
+		{Timestamp: base.Add(2 * time.Minute), Value: 30},
+	}
+	right := []ports.AggregatedResult{
+		{Timestamp: base, Value: 4},
+		{Timestamp: base.Add(time.Minute), Value: 7},
+	}
+
+	points := alignSeries(left, right)
+	if len(points) != 3 {
+		t.Fatalf("Expected 3 points, got %d", len(points))
+	}
+	if p := points[0]; p.Diff == nil || *p.Diff != 6 {
+		t.Errorf("Expected diff 6 in the shared bucket, got %v", p.Diff)
+	}
+	if p := points[1]; p.Left != nil || p.Right == nil || *p.Right != 7 || p.Diff != nil {
+		t.Errorf("Expected a right-only bucket at +1m, got %+v", p)
+	}
+	if p := points[2]; p.Left == nil || *p.Left != 30 || p.Right != nil || p.Diff != nil {
+		t.Errorf("Expected a left-only bucket at +2m, got %+v", p)
+	}
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+)
+
+func newMFATestService(t *testing.T) (*AuthService, *domain.User) {
+	t.Helper()
+	config := DefaultAuthConfig()
+	config.MFAEncryptionKey = []byte("0123456789abcdef0123456789abcdef")
+	svc := NewAuthService(
+		newMockUserRepository(),
+		newMockSessionRepository(),
+		newMockAPIKeyRepository(),
+		newMockAuditLogRepository(),
+		config,
+		&mockLogger{},
+	)
+	user, err := svc.CreateUser(context.Background(), "alice", "alice@example.com", "password123", domain.RoleAdmin)
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	return svc, user
+}
+
+// enrollMFA enables MFA for user and returns the plaintext TOTP secret.
+func enrollMFA(t *testing.T, svc *AuthService, user *domain.User) string {
+	t.Helper()
+	uri, err := svc.EnableMFA(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("EnableMFA failed: %v", err)
+	}
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		t.Fatalf("invalid otpauth URI %q: %v", uri, err)
+	}
+	return parsed.Query().Get("secret")
+}
+
+func totpCodeAt(t *testing.T, secret string, offset int64) string {
+	t.Helper()
+	code, err := domain.TOTPCode(secret, domain.TOTPStep(time.Now())+offset)
+	if err != nil {
+		t.Fatalf("TOTPCode failed: %v", err)
+	}
+	return code
+}
+
+func TestAuthService_EnableMFA(t *testing.T) {
+	svc, user := newMFATestService(t)
+	ctx := context.Background()
+
+	secret := enrollMFA(t, svc, user)
+	if secret == "" {
+		t.Fatal("expected secret in otpauth URI")
+	}
+	if user.MFASecret == "" || strings.Contains(user.MFASecret, secret) {
+		t.Error("expected stored secret to be encrypted")
+	}
+	if user.MFAEnabled {
+		t.Error("expected MFA to stay disabled until a code is verified")
+	}
+
+	// Enrollment is not enforced at login until verified
+	if _, _, err := svc.Login(ctx, "alice", "password123", "127.0.0.1", "test"); err != nil {
+		t.Errorf("expected login to succeed before MFA is confirmed, got %v", err)
+	}
+
+	if err := svc.VerifyMFA(ctx, user.ID, totpCodeAt(t, secret, 5)); err != ErrInvalidMFACode {
+		t.Errorf("expected ErrInvalidMFACode, got %v", err)
+	}
+	if err := svc.VerifyMFA(ctx, user.ID, totpCodeAt(t, secret, 0)); err != nil {
+		t.Fatalf("VerifyMFA failed: %v", err)
+	}
+	if !user.MFAEnabled {
+		t.Error("expected MFA to be enabled after verification")
+	}
+
+	if _, err := svc.EnableMFA(ctx, user.ID); err == nil {
+		t.Error("expected error re-enrolling a user with MFA enabled")
+	}
+}
+
+func TestAuthService_LoginWithMFA(t *testing.T) {
+	svc, user := newMFATestService(t)
+	ctx := context.Background()
+
+	secret := enrollMFA(t, svc, user)
+	current := totpCodeAt(t, secret, 0)
+	if err := svc.VerifyMFA(ctx, user.ID, current); err != nil {
+		t.Fatalf("VerifyMFA failed: %v", err)
+	}
+
+	if _, _, err := svc.Login(ctx, "alice", "password123", "127.0.0.1", "test"); err != ErrMFARequired {
+		t.Errorf("expected ErrMFARequired, got %v", err)
+	}
+
+	// Wrong code is rejected and counts as a failed login
+	if _, _, err := svc.LoginWithMFA(ctx, "alice", "password123", totpCodeAt(t, secret, 5), "127.0.0.1", "test"); err != ErrInvalidMFACode {
+		t.Errorf("expected ErrInvalidMFACode for wrong code, got %v", err)
+	}
+	if user.FailedLogins != 1 {
+		t.Errorf("expected 1 failed login, got %d", user.FailedLogins)
+	}
+
+	// A code already used for verification cannot be replayed
+	if _, _, err := svc.LoginWithMFA(ctx, "alice", "password123", current, "127.0.0.1", "test"); err != ErrInvalidMFACode {
+		t.Errorf("expected replayed code to be rejected, got %v", err)
+	}
+
+	session, token, err := svc.LoginWithMFA(ctx, "alice", "password123", totpCodeAt(t, secret, 1), "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("LoginWithMFA failed: %v", err)
+	}
+	if session == nil || token == "" {
+		t.Error("expected session and token")
+	}
+	if user.FailedLogins != 0 {
+		t.Errorf("expected failed logins to reset, got %d", user.FailedLogins)
+	}
+
+	// The password is still checked before the second factor
+	if _, _, err := svc.LoginWithMFA(ctx, "alice", "wrong", totpCodeAt(t, secret, 1), "127.0.0.1", "test"); err != ErrInvalidCredentials {
+		t.Errorf("expected ErrInvalidCredentials, got %v", err)
+	}
+}
+
+func TestAuthService_MFARequiresEncryptionKey(t *testing.T) {
+	svc := NewAuthService(
+		newMockUserRepository(),
+		newMockSessionRepository(),
+		newMockAPIKeyRepository(),
+		newMockAuditLogRepository(),
+		DefaultAuthConfig(),
+		&mockLogger{},
+	)
+	user, _ := svc.CreateUser(context.Background(), "bob", "bob@example.com", "password123", domain.RoleViewer)
+
+	if _, err := svc.EnableMFA(context.Background(), user.ID); err == nil {
+		t.Error("expected error without an MFA encryption key")
+	}
+	if err := svc.VerifyMFA(context.Background(), user.ID, "123456"); err != ErrMFANotEnrolled {
+		t.Errorf("expected ErrMFANotEnrolled, got %v", err)
+	}
+}
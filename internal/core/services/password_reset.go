@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+)
+
+// RequestPasswordReset issues a single-use reset token for the user with the
+// given email and returns it for delivery to the user. Only its hash is
+// stored, and any earlier unused token for the user stops working.
+func (s *AuthService) RequestPasswordReset(ctx context.Context, email string) (string, error) {
+	if s.userRepo == nil || s.resetRepo == nil {
+		return "", fmt.Errorf("password reset not configured")
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		s.audit(ctx, nil, "user.password_reset_request", "user", "", map[string]string{"email": email}, ErrUserNotFound)
+		return "", ErrUserNotFound
+	}
+
+	reset, token, err := domain.GeneratePasswordResetToken(user.ID, s.config.ResetDuration)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate reset token: %w", err)
+	}
+	if err := s.resetRepo.DeleteByUserID(ctx, user.ID); err != nil {
+		return "", fmt.Errorf("failed to replace reset tokens: %w", err)
+	}
+	if err := s.resetRepo.Create(ctx, reset); err != nil {
+		return "", fmt.Errorf("failed to save reset token: %w", err)
+	}
+
+	s.audit(ctx, &user.ID, "user.password_reset_request", "user", user.ID.String(), nil, nil)
+	s.logger.Info("Password reset requested", "username", user.Username)
+	return token, nil
+}
+
+// ResetPassword redeems a reset token and sets a new password. The token is
+// consumed first so it cannot be used twice, then the account is unlocked
+// and all of the user's sessions are revoked.
+func (s *AuthService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	if s.userRepo == nil || s.resetRepo == nil {
+		return fmt.Errorf("password reset not configured")
+	}
+	if newPassword == "" {
+		return fmt.Errorf("new password is required")
+	}
+
+	reset, err := s.resetRepo.GetByTokenHash(ctx, domain.HashToken(token))
+	if err != nil {
+		s.audit(ctx, nil, "user.password_reset", "user", "", nil, ErrInvalidToken)
+		return ErrInvalidToken
+	}
+	userID := reset.UserID
+	fail := func(err error) error {
+		s.audit(ctx, &userID, "user.password_reset", "user", userID.String(), nil, err)
+		return err
+	}
+	if reset.IsUsed() {
+		return fail(ErrInvalidToken)
+	}
+	if reset.IsExpired() {
+		return fail(ErrTokenExpired)
+	}
+	if err := s.resetRepo.MarkUsed(ctx, reset.ID, time.Now()); err != nil {
+		if errors.Is(err, ports.ErrResetTokenUsed) {
+			return fail(ErrInvalidToken)
+		}
+		return fmt.Errorf("failed to consume reset token: %w", err)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fail(ErrUserNotFound)
+	}
+	if err := user.SetPassword(newPassword); err != nil {
+		return err
+	}
+	user.Unlock()
+	delete(user.Metadata, passwordChangeRequiredKey)
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	if s.sessionRepo != nil {
+		_ = s.sessionRepo.DeleteByUserID(ctx, userID)
+	}
+
+	s.audit(ctx, &userID, "user.password_reset", "user", userID.String(), nil, nil)
+	s.logger.Info("Password reset", "username", user.Username)
+	return nil
+}
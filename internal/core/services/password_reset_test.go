@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+	"github.com/google/uuid"
+)
+
+type mockPasswordResetRepository struct {
+	tokens map[uuid.UUID]*domain.PasswordResetToken
+}
+
+func newMockPasswordResetRepository() *mockPasswordResetRepository {
+	return &mockPasswordResetRepository{tokens: make(map[uuid.UUID]*domain.PasswordResetToken)}
+}
+
+func (m *mockPasswordResetRepository) Create(_ context.Context, t *domain.PasswordResetToken) error {
+	m.tokens[t.ID] = t
+	return nil
+}
+
+func (m *mockPasswordResetRepository) GetByTokenHash(_ context.Context, tokenHash string) (*domain.PasswordResetToken, error) {
+	for _, t := range m.tokens {
+		if t.TokenHash == tokenHash {
+			cp := *t
+			return &cp, nil
+		}
+	}
+	return nil, errors.New("password reset token not found")
+}
+
+func (m *mockPasswordResetRepository) MarkUsed(_ context.Context, id uuid.UUID, usedAt time.Time) error {
+	t, ok := m.tokens[id]
+	if !ok || t.UsedAt != nil {
+		return ports.ErrResetTokenUsed
+	}
+	t.UsedAt = &usedAt
+	return nil
+}
+
+func (m *mockPasswordResetRepository) DeleteByUserID(_ context.Context, userID uuid.UUID) error {
+	for id, t := range m.tokens {
+		if t.UserID == userID {
+			delete(m.tokens, id)
+		}
+	}
+	return nil
+}
+
+func (m *mockPasswordResetRepository) DeleteExpired(_ context.Context) (int64, error) {
+	return 0, nil
+}
+
+func newResetTestService(config AuthConfig) (*AuthService, *mockSessionRepository, *mockAuditLogRepository) {
+	sessionRepo := newMockSessionRepository()
+	auditRepo := newMockAuditLogRepository()
+	svc := NewAuthService(
+		newMockUserRepository(),
+		sessionRepo,
+		newMockAPIKeyRepository(),
+		auditRepo,
+		config,
+		&mockLogger{},
+	)
+	svc.SetPasswordResetRepository(newMockPasswordResetRepository())
+	return svc, sessionRepo, auditRepo
+}
+
+func TestAuthService_ResetPassword(t *testing.T) {
+	ctx := context.Background()
+	config := DefaultAuthConfig()
+	svc, sessionRepo, auditRepo := newResetTestService(config)
+
+	user, err := svc.CreateUser(ctx, "alice", "alice@example.com", "oldpassword", domain.RoleOperator)
+	if err != nil {
+		t.Fatalf("CreateUser error: %v", err)
+	}
+	if _, _, err := svc.Login(ctx, "alice", "oldpassword", "127.0.0.1", "test"); err != nil {
+		t.Fatalf("Login error: %v", err)
+	}
+	// Lock the account with failed logins
+	for i := 0; i < config.MaxLoginAttempts; i++ {
+		_, _, _ = svc.Login(ctx, "alice", "wrong", "127.0.0.1", "test")
+	}
+	if !user.IsLocked() {
+		t.Fatal("Expected the account to be locked")
+	}
+
+	token, err := svc.RequestPasswordReset(ctx, "alice@example.com")
+	if err != nil {
+		t.Fatalf("RequestPasswordReset error: %v", err)
+	}
+	if token == "" {
+		t.Fatal("Expected a reset token")
+	}
+
+	if err := svc.ResetPassword(ctx, token, "newpassword"); err != nil {
+		t.Fatalf("ResetPassword error: %v", err)
+	}
+	if user.IsLocked() || user.FailedLogins != 0 {
+		t.Errorf("Expected the account to be unlocked, status %s failed logins %d", user.Status, user.FailedLogins)
+	}
+	if !user.CheckPassword("newpassword") || user.CheckPassword("oldpassword") {
+		t.Error("Expected the password to be replaced")
+	}
+	if len(sessionRepo.sessions) != 0 {
+		t.Errorf("Expected sessions to be revoked, %d left", len(sessionRepo.sessions))
+	}
+	if _, _, err := svc.Login(ctx, "alice", "newpassword", "127.0.0.1", "test"); err != nil {
+		t.Errorf("Login with the new password failed: %v", err)
+	}
+
+	var requested, reset bool
+	for _, log := range auditRepo.logs {
+		if log.Success && log.Action == "user.password_reset_request" {
+			requested = true
+		}
+		if log.Success && log.Action == "user.password_reset" {
+			reset = true
+		}
+	}
+	if !requested || !reset {
+		t.Errorf("Expected both reset actions to be audited (request %v, reset %v)", requested, reset)
+	}
+}
+
+func TestAuthService_ResetPassword_TokenReuse(t *testing.T) {
+	ctx := context.Background()
+	svc, _, _ := newResetTestService(DefaultAuthConfig())
+	user, _ := svc.CreateUser(ctx, "bob", "bob@example.com", "password1", domain.RoleViewer)
+
+	token, err := svc.RequestPasswordReset(ctx, "bob@example.com")
+	if err != nil {
+		t.Fatalf("RequestPasswordReset error: %v", err)
+	}
+	if err := svc.ResetPassword(ctx, token, "password2"); err != nil {
+		t.Fatalf("ResetPassword error: %v", err)
+	}
+	if err := svc.ResetPassword(ctx, token, "password3"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected ErrInvalidToken on reuse, got %v", err)
+	}
+	if !user.CheckPassword("password2") {
+		t.Error("Expected the reused token not to change the password")
+	}
+
+	// A new request replaces an earlier unused token
+	first, _ := svc.RequestPasswordReset(ctx, "bob@example.com")
+	second, _ := svc.RequestPasswordReset(ctx, "bob@example.com")
+	if err := svc.ResetPassword(ctx, first, "password4"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected a superseded token to be rejected, got %v", err)
+	}
+	if err := svc.ResetPassword(ctx, second, "password4"); err != nil {
+		t.Errorf("Expected the latest token to work, got %v", err)
+	}
+}
+
+func TestAuthService_ResetPassword_Expired(t *testing.T) {
+	ctx := context.Background()
+	config := DefaultAuthConfig()
+	config.ResetDuration = -time.Minute
+	svc, _, _ := newResetTestService(config)
+	user, _ := svc.CreateUser(ctx, "carol", "carol@example.com", "password1", domain.RoleViewer)
+
+	token, err := svc.RequestPasswordReset(ctx, "carol@example.com")
+	if err != nil {
+		t.Fatalf("RequestPasswordReset error: %v", err)
+	}
+	if err := svc.ResetPassword(ctx, token, "password2"); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("Expected ErrTokenExpired, got %v", err)
+	}
+	if !user.CheckPassword("password1") {
+		t.Error("Expected the password to be unchanged")
+	}
+
+	if _, err := svc.RequestPasswordReset(ctx, "nobody@example.com"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound for an unknown email, got %v", err)
+	}
+	if err := svc.ResetPassword(ctx, "not-a-token", "password2"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected ErrInvalidToken for an unknown token, got %v", err)
+	}
+}
@@ -10,8 +10,11 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
 	"sync"
@@ -32,6 +35,7 @@ type PluginManifest struct {
 	Repository   string            `json:"repository,omitempty"`
 	Tags         []string          `json:"tags,omitempty"`
 	Permissions  []string          `json:"permissions"`
+	AllowedHosts []string          `json:"allowed_hosts,omitempty"`
 	Dependencies []PluginDep       `json:"dependencies,omitempty"`
 	SHA256       string            `json:"sha256"`
 	Signature    string            `json:"signature,omitempty"`
@@ -48,6 +52,18 @@ type PluginDep struct {
 	Optional   bool   `json:"optional,omitempty"`
 }
 
+// DefaultMaxPluginSize is the largest plugin binary downloaded when
+// RegistryConfig.MaxPluginSize is zero.
+const DefaultMaxPluginSize int64 = 64 << 20
+
+// maxPluginRedirects is the number of redirects followed for a download.
+const maxPluginRedirects = 5
+
+var (
+	sha256Pattern     = regexp.MustCompile(`^[0-9a-f]{64}$`)
+	pluginFilePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+)
+
 // RegistryIndex contains the list of available plugins.
 type RegistryIndex struct {
 	Version   string           `json:"version"`
@@ -65,6 +81,7 @@ type PluginRegistry struct {
 	installed    map[string]*domain.Plugin
 	publicKeys   []ed25519.PublicKey
 	httpClient   *http.Client
+	maxSize      int64
 	logger       ports.Logger
 }
 
@@ -74,6 +91,8 @@ type RegistryConfig struct {
 	CacheDir    string   // Local cache directory
 	PluginsDir  string   // Plugins installation directory
 	PublicKeys  []string // Trusted public keys (hex-encoded)
+
+	MaxPluginSize int64 // Largest plugin binary downloaded (default: 64MB)
 }
 
 // NewPluginRegistry creates a new plugin registry.
@@ -90,6 +109,9 @@ func NewPluginRegistry(cfg RegistryConfig, logger ports.Logger) (*PluginRegistry
 		home, _ := os.UserHomeDir()
 		cfg.PluginsDir = filepath.Join(home, ".forge", "plugins")
 	}
+	if cfg.MaxPluginSize == 0 {
+		cfg.MaxPluginSize = DefaultMaxPluginSize
+	}
 
 	// Create directories
 	for _, dir := range []string{cfg.CacheDir, cfg.PluginsDir} {
@@ -118,9 +140,11 @@ func NewPluginRegistry(cfg RegistryConfig, logger ports.Logger) (*PluginRegistry
 		installed:   make(map[string]*domain.Plugin),
 		publicKeys:  publicKeys,
 		httpClient: &http.Client{
-			Timeout: 60 * time.Second,
+			Timeout:       60 * time.Second,
+			CheckRedirect: checkPluginRedirect,
 		},
-		logger: logger,
+		maxSize: cfg.MaxPluginSize,
+		logger:  logger,
 	}, nil
 }
 
@@ -233,25 +257,9 @@ func (r *PluginRegistry) Install(ctx context.Context, name, version string) (*do
 	// Download plugin
 	r.logger.Info("Downloading plugin", "name", name, "version", manifest.Version)
 
-	req, err := http.NewRequestWithContext(ctx, "GET", manifest.DownloadURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	resp, err := r.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to download plugin: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("download failed with status %d", resp.StatusCode)
-	}
-
-	// Read plugin data
-	data, err := io.ReadAll(resp.Body)
+	data, err := r.download(ctx, manifest.DownloadURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read plugin data: %w", err)
+		return nil, err
 	}
 
 	// Verify hash
@@ -274,9 +282,15 @@ func (r *PluginRegistry) Install(ctx context.Context, name, version string) (*do
 		return nil, fmt.Errorf("failed to save plugin: %w", err)
 	}
 
-	// Create domain plugin
+	// Create domain plugin with the access its manifest grants
 	plugin := domain.NewPlugin(name, manifest.Version, pluginPath)
 	plugin.Hash = hashStr
+	plugin.Description = manifest.Description
+	plugin.Author = manifest.Author
+	for _, perm := range manifest.Permissions {
+		plugin.Permissions = append(plugin.Permissions, domain.PluginPermission(perm))
+	}
+	plugin.AllowedHosts = append([]string(nil), manifest.AllowedHosts...)
 
 	r.mu.Lock()
 	r.installed[name] = plugin
@@ -286,6 +300,128 @@ func (r *PluginRegistry) Install(ctx context.Context, name, version string) (*do
 	return plugin, nil
 }
 
+// InstallFromURL downloads a plugin binary from an HTTP(S) URL into the
+// plugins directory. The binary must match sha256 (hex-encoded) and, when a
+// signature is given, be signed by one of the trusted public keys. Nothing
+// is written unless both checks pass.
+func (r *PluginRegistry) InstallFromURL(ctx context.Context, rawURL, sha256Hex, signature string) (*domain.Plugin, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid plugin URL: %s", rawURL)
+	}
+	sha256Hex = strings.ToLower(sha256Hex)
+	if !sha256Pattern.MatchString(sha256Hex) {
+		return nil, fmt.Errorf("a SHA-256 checksum of 64 hex characters is required")
+	}
+	if signature != "" && len(r.publicKeys) == 0 {
+		return nil, fmt.Errorf("cannot verify signature: no trusted public keys configured")
+	}
+
+	file := path.Base(u.Path)
+	name := strings.TrimSuffix(file, ".wasm")
+	if !strings.HasSuffix(file, ".wasm") || !pluginFilePattern.MatchString(name) {
+		return nil, fmt.Errorf("plugin URL must name a .wasm file: %s", rawURL)
+	}
+
+	r.logger.Info("Downloading plugin", "name", name, "url", u.Redacted())
+	data, err := r.download(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.Sum256(data)
+	hashStr := hex.EncodeToString(hash[:])
+	if hashStr != sha256Hex {
+		return nil, fmt.Errorf("hash mismatch: expected %s, got %s", sha256Hex, hashStr)
+	}
+	if signature != "" {
+		if err := r.verifySignature(data, signature); err != nil {
+			return nil, fmt.Errorf("signature verification failed: %w", err)
+		}
+	}
+
+	pluginPath := filepath.Join(r.pluginsDir, file)
+	if err := writePluginFile(pluginPath, data); err != nil {
+		return nil, fmt.Errorf("failed to save plugin: %w", err)
+	}
+
+	plugin := domain.NewPlugin(name, "", pluginPath)
+	plugin.Hash = hashStr
+
+	r.mu.Lock()
+	r.installed[name] = plugin
+	r.mu.Unlock()
+
+	r.logger.Info("Plugin installed", "name", name, "path", pluginPath)
+	return plugin, nil
+}
+
+// download fetches a plugin binary, refusing anything larger than the
+// configured maximum size.
+func (r *PluginRegistry) download(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download plugin: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download failed with status %d", resp.StatusCode)
+	}
+	if resp.ContentLength > r.maxSize {
+		return nil, fmt.Errorf("plugin is %d bytes, larger than the %d byte limit", resp.ContentLength, r.maxSize)
+	}
+
+	// Read one byte past the limit to detect bodies without a length
+	data, err := io.ReadAll(io.LimitReader(resp.Body, r.maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugin data: %w", err)
+	}
+	if int64(len(data)) > r.maxSize {
+		return nil, fmt.Errorf("plugin is larger than the %d byte limit", r.maxSize)
+	}
+	return data, nil
+}
+
+// checkPluginRedirect follows a limited number of redirects and refuses to
+// downgrade from HTTPS to plain HTTP.
+func checkPluginRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxPluginRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxPluginRedirects)
+	}
+	if via[0].URL.Scheme == "https" && req.URL.Scheme != "https" {
+		return fmt.Errorf("refusing redirect from HTTPS to %s", req.URL.Redacted())
+	}
+	return nil
+}
+
+// writePluginFile replaces path with data through a temporary file, so a
+// failed write never leaves a truncated plugin behind.
+func writePluginFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
 // verifySignature verifies the plugin signature using trusted public keys.
 func (r *PluginRegistry) verifySignature(data []byte, signatureHex string) error {
 	signature, err := hex.DecodeString(signatureHex)
@@ -2,10 +2,20 @@
 package services
 
 import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/forge-platform/forge/internal/core/domain"
 	"github.com/forge-platform/forge/internal/core/ports"
 )
 
@@ -194,3 +204,154 @@ func TestPluginRegistry_CheckUpdates_Empty(t *testing.T) {
 	}
 }
 
+
+func TestPluginRegistry_InstallCopiesManifestAccess(t *testing.T) {
+	wasmBytes := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	sum := sha256.Sum256(wasmBytes)
+
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	mux.HandleFunc("/index.json", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(RegistryIndex{Plugins: []PluginManifest{{
+			Name:         "fetcher",
+			Version:      "1.2.0",
+			Description:  "Fetches things",
+			Permissions:  []string{"network", "metrics:write"},
+			AllowedHosts: []string{"api.example.com"},
+			DownloadURL:  srv.URL + "/fetcher.wasm",
+			SHA256:       hex.EncodeToString(sum[:]),
+		}}})
+	})
+	mux.HandleFunc("/fetcher.wasm", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(wasmBytes)
+	})
+
+	tmpDir := t.TempDir()
+	registry, err := NewPluginRegistry(RegistryConfig{
+		RegistryURL: srv.URL,
+		CacheDir:    filepath.Join(tmpDir, "cache"),
+		PluginsDir:  filepath.Join(tmpDir, "plugins"),
+	}, &mockPluginRegistryLogger{})
+	if err != nil {
+		t.Fatalf("NewPluginRegistry failed: %v", err)
+	}
+	ctx := context.Background()
+	if err := registry.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+
+	plugin, err := registry.Install(ctx, "fetcher", "latest")
+	if err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	if !plugin.HasPermission(domain.PermissionNetwork) || !plugin.HasPermission(domain.PermissionMetricsWrite) {
+		t.Errorf("expected the manifest's permissions, got %v", plugin.Permissions)
+	}
+	if len(plugin.AllowedHosts) != 1 || plugin.AllowedHosts[0] != "api.example.com" {
+		t.Errorf("expected the manifest's allowed hosts, got %v", plugin.AllowedHosts)
+	}
+	if plugin.Description != "Fetches things" {
+		t.Errorf("unexpected description %q", plugin.Description)
+	}
+}
+
+func TestPluginRegistry_InstallFromURL(t *testing.T) {
+	wasmBytes := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	sum := sha256.Sum256(wasmBytes)
+	checksum := hex.EncodeToString(sum[:])
+
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	signature := hex.EncodeToString(ed25519.Sign(priv, wasmBytes))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/plugins/hello.wasm", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(wasmBytes)
+	})
+	mux.HandleFunc("/latest/hello.wasm", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/plugins/hello.wasm", http.StatusFound)
+	})
+	mux.HandleFunc("/loop/hello.wasm", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop/hello.wasm", http.StatusFound)
+	})
+	mux.HandleFunc("/big/hello.wasm", func(w http.ResponseWriter, r *http.Request) {
+		// No Content-Length, so the limit is enforced while reading
+		w.(http.Flusher).Flush()
+		w.Write(make([]byte, 2048))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	tmpDir := t.TempDir()
+	pluginsDir := filepath.Join(tmpDir, "plugins")
+	registry, err := NewPluginRegistry(RegistryConfig{
+		CacheDir:      filepath.Join(tmpDir, "cache"),
+		PluginsDir:    pluginsDir,
+		PublicKeys:    []string{hex.EncodeToString(pub)},
+		MaxPluginSize: 1024,
+	}, &mockPluginRegistryLogger{})
+	if err != nil {
+		t.Fatalf("NewPluginRegistry failed: %v", err)
+	}
+	ctx := context.Background()
+
+	plugin, err := registry.InstallFromURL(ctx, srv.URL+"/plugins/hello.wasm", checksum, signature)
+	if err != nil {
+		t.Fatalf("InstallFromURL failed: %v", err)
+	}
+	if plugin.Name != "hello" || plugin.Hash != checksum || plugin.Path != filepath.Join(pluginsDir, "hello.wasm") {
+		t.Errorf("unexpected plugin: %+v", plugin)
+	}
+	if data, err := os.ReadFile(plugin.Path); err != nil || !bytes.Equal(data, wasmBytes) {
+		t.Errorf("expected the plugin to be saved: %v", err)
+	}
+	os.Remove(plugin.Path)
+
+	if _, err := registry.InstallFromURL(ctx, srv.URL+"/latest/hello.wasm", strings.ToUpper(checksum), ""); err != nil {
+		t.Errorf("expected the redirect to be followed, got %v", err)
+	}
+	os.Remove(plugin.Path)
+
+	tests := []struct {
+		name      string
+		url       string
+		checksum  string
+		signature string
+		wantErr   string
+	}{
+		{"mismatching checksum", "/plugins/hello.wasm", strings.Repeat("0", 64), "", "hash mismatch"},
+		{"missing checksum", "/plugins/hello.wasm", "", "", "checksum"},
+		{"bad signature", "/plugins/hello.wasm", checksum, strings.Repeat("00", ed25519.SignatureSize), "signature verification failed"},
+		{"too large", "/big/hello.wasm", checksum, "", "byte limit"},
+		{"redirect loop", "/loop/hello.wasm", checksum, "", "redirects"},
+		{"not found", "/missing/hello.wasm", checksum, "", "status 404"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := registry.InstallFromURL(ctx, srv.URL+tt.url, tt.checksum, tt.signature)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+			if _, statErr := os.Stat(filepath.Join(pluginsDir, "hello.wasm")); !os.IsNotExist(statErr) {
+				t.Error("expected nothing to be saved")
+			}
+		})
+	}
+
+	for _, bad := range []string{"ftp://example.com/hello.wasm", srv.URL + "/plugins/hello.txt"} {
+		if _, err := registry.InstallFromURL(ctx, bad, checksum, ""); err == nil {
+			t.Errorf("expected %s to be rejected", bad)
+		}
+	}
+}
+
+func TestCheckPluginRedirect_RefusesDowngrade(t *testing.T) {
+	from, _ := http.NewRequest("GET", "https://example.com/hello.wasm", nil)
+	to, _ := http.NewRequest("GET", "http://example.com/hello.wasm", nil)
+	if err := checkPluginRedirect(to, []*http.Request{from}); err == nil {
+		t.Error("expected a redirect from HTTPS to HTTP to be refused")
+	}
+}
@@ -0,0 +1,432 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/ports"
+)
+
+// DefaultPluginTickInterval is used for plugins that don't configure one.
+const DefaultPluginTickInterval = 10 * time.Second
+
+const (
+	// pluginTickExport is the guest function called on every tick.
+	pluginTickExport = "on_tick"
+	// tickNotImplemented is returned by on_tick when the plugin has no
+	// TickHandler; such plugins are no longer scheduled.
+	tickNotImplemented int32 = -1
+)
+
+// PluginSchedulerConfig configures the plugin tick scheduler.
+type PluginSchedulerConfig struct {
+	DefaultInterval time.Duration // Tick interval when the plugin sets none (default: 10s)
+	Timeout         time.Duration // Deadline for a single on_tick call (default: 5s)
+	SyncInterval    time.Duration // How often loaded plugins are rediscovered (default: 5s)
+	MaxBackoff      time.Duration // Upper bound for the delay after failures (default: 5m)
+	DegradedAfter   int           // Consecutive failures before a plugin is degraded (default: 3)
+}
+
+// PluginTickStatus is a snapshot of the tick state of one plugin.
+type PluginTickStatus struct {
+	PluginID            string        `json:"plugin_id"`
+	Interval            time.Duration `json:"interval"`
+	LastTick            *time.Time    `json:"last_tick,omitempty"`
+	LastDuration        time.Duration `json:"last_duration"`
+	LastError           string        `json:"last_error,omitempty"`
+	ErrorCount          int64         `json:"error_count"`
+	ConsecutiveFailures int           `json:"consecutive_failures"`
+	NextTick            time.Time     `json:"next_tick"`
+	Degraded            bool          `json:"degraded"`
+}
+
+// pluginTicker is the scheduling state of one plugin.
+type pluginTicker struct {
+	status PluginTickStatus
+	cancel context.CancelFunc
+}
+
+// PluginScheduler calls on_tick on every loaded plugin that exports it.
+// Each plugin ticks on its own goroutine so a slow plugin never delays the
+// others, and a panicking runtime call fails only that tick. First ticks are
+// spread over each plugin's interval so plugins loaded together don't all
+// tick at once. Failed ticks back off exponentially and, after
+// DegradedAfter consecutive failures, the plugin is reported as degraded
+// until a tick succeeds again.
+type PluginScheduler struct {
+	runtime ports.WasmRuntime
+	logger  ports.Logger
+	cfg     PluginSchedulerConfig
+
+	mu       sync.RWMutex
+	tickers  map[string]*pluginTicker
+	disabled map[string]bool // Plugins whose on_tick reported no TickHandler
+	running  bool
+	stopCh   chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewPluginScheduler creates a new plugin tick scheduler.
+func NewPluginScheduler(runtime ports.WasmRuntime, logger ports.Logger, cfg PluginSchedulerConfig) *PluginScheduler {
+	if cfg.DefaultInterval <= 0 {
+		cfg.DefaultInterval = DefaultPluginTickInterval
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	if cfg.SyncInterval <= 0 {
+		cfg.SyncInterval = 5 * time.Second
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 5 * time.Minute
+	}
+	if cfg.DegradedAfter <= 0 {
+		cfg.DegradedAfter = 3
+	}
+
+	return &PluginScheduler{
+		runtime:  runtime,
+		logger:   logger,
+		cfg:      cfg,
+		tickers:  make(map[string]*pluginTicker),
+		disabled: make(map[string]bool),
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start begins scheduling ticks for loaded plugins.
+func (s *PluginScheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	s.stopCh = make(chan struct{})
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go s.syncLoop(ctx)
+}
+
+// Stop stops all plugin tickers and waits for in-flight ticks to finish.
+func (s *PluginScheduler) Stop() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = false
+	close(s.stopCh)
+	for id, t := range s.tickers {
+		t.cancel()
+		delete(s.tickers, id)
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// syncLoop periodically picks up newly loaded and unloaded plugins.
+func (s *PluginScheduler) syncLoop(ctx context.Context) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.cfg.SyncInterval)
+	defer ticker.Stop()
+
+	s.Sync(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+			s.Sync(ctx)
+		}
+	}
+}
+
+// Sync starts tickers for loaded plugins exporting on_tick, stops the
+// tickers of plugins that are no longer loaded or no longer export it, and
+// reschedules plugins whose configured interval changed.
+func (s *PluginScheduler) Sync(ctx context.Context) {
+	loaded := make(map[string]bool)
+	for _, id := range s.runtime.ListLoadedPlugins() {
+		loaded[id] = true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.running {
+		return
+	}
+
+	// Plugins disabled by the runtime stop exporting on_tick
+	for id, t := range s.tickers {
+		if !loaded[id] || !s.runtime.HasFunction(id, pluginTickExport) {
+			t.cancel()
+			delete(s.tickers, id)
+			continue
+		}
+		// Reschedule plugins reconfigured with a new interval
+		if interval, _ := s.interval(id); interval != t.status.Interval {
+			t.cancel()
+			status := t.status
+			status.Interval = interval
+			s.schedule(ctx, id, status)
+		}
+	}
+	for id := range s.disabled {
+		if !loaded[id] {
+			delete(s.disabled, id)
+		}
+	}
+
+	for id := range loaded {
+		if _, ok := s.tickers[id]; ok || s.disabled[id] {
+			continue
+		}
+		if !s.runtime.HasFunction(id, pluginTickExport) {
+			continue
+		}
+		interval, err := s.interval(id)
+		if err != nil && s.logger != nil {
+			s.logger.Warn("Invalid plugin tick interval, using default",
+				"plugin", id, "default", s.cfg.DefaultInterval, "error", err)
+		}
+		s.schedule(ctx, id, PluginTickStatus{PluginID: id, Interval: interval})
+	}
+}
+
+// interval returns the tick interval configured for a plugin.
+func (s *PluginScheduler) interval(pluginID string) (time.Duration, error) {
+	plugin, ok := s.runtime.GetPlugin(pluginID)
+	if !ok {
+		return s.cfg.DefaultInterval, nil
+	}
+	return pluginTickInterval(plugin.Config, s.cfg.DefaultInterval)
+}
+
+// schedule starts ticking a plugin, first after its stagger offset. s.mu
+// must be held.
+func (s *PluginScheduler) schedule(ctx context.Context, pluginID string, status PluginTickStatus) {
+	first := tickOffset(pluginID, status.Interval)
+	status.NextTick = time.Now().Add(first)
+
+	tickCtx, cancel := context.WithCancel(ctx)
+	s.tickers[pluginID] = &pluginTicker{status: status, cancel: cancel}
+	s.wg.Add(1)
+	go s.run(tickCtx, pluginID, first)
+}
+
+// run ticks a single plugin, first after delay, until ctx is cancelled.
+func (s *PluginScheduler) run(ctx context.Context, pluginID string, delay time.Duration) {
+	defer s.wg.Done()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			next, ok := s.tick(ctx, pluginID)
+			if !ok {
+				return
+			}
+			timer.Reset(next)
+		}
+	}
+}
+
+// tick calls on_tick once and records the outcome. It returns the delay
+// before the next tick, or false if the plugin should no longer be ticked.
+func (s *PluginScheduler) tick(ctx context.Context, pluginID string) (time.Duration, bool) {
+	tickCtx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	start := time.Now()
+	result, err := s.callTick(tickCtx, pluginID)
+	cancel()
+	duration := time.Since(start)
+
+	if ctx.Err() != nil {
+		// The scheduler is stopping or the plugin was unloaded mid-tick
+		return 0, false
+	}
+
+	if err == nil {
+		if code, ok := result.(uint64); ok {
+			switch int32(code) {
+			case 0:
+			case tickNotImplemented:
+				s.disable(pluginID)
+				return 0, false
+			default:
+				err = fmt.Errorf("on_tick returned error code %d", int32(code))
+			}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tickers[pluginID]
+	if !ok {
+		return 0, false
+	}
+
+	status := &t.status
+	status.LastTick = &start
+	status.LastDuration = duration
+
+	next := status.Interval
+	if err != nil {
+		status.ErrorCount++
+		status.ConsecutiveFailures++
+		status.LastError = err.Error()
+		next = tickBackoff(status.Interval, status.ConsecutiveFailures, s.cfg.MaxBackoff)
+
+		if !status.Degraded && status.ConsecutiveFailures >= s.cfg.DegradedAfter {
+			status.Degraded = true
+			if s.logger != nil {
+				s.logger.Warn("Plugin degraded after repeated tick failures",
+					"plugin", pluginID, "failures", status.ConsecutiveFailures, "error", err)
+			}
+		} else if s.logger != nil {
+			s.logger.Debug("Plugin tick failed", "plugin", pluginID, "retry_in", next, "error", err)
+		}
+	} else {
+		if status.Degraded && s.logger != nil {
+			s.logger.Info("Plugin recovered", "plugin", pluginID)
+		}
+		status.ConsecutiveFailures = 0
+		status.LastError = ""
+		status.Degraded = false
+	}
+	status.NextTick = start.Add(duration + next)
+
+	return next, true
+}
+
+// callTick calls on_tick, turning a panic in the runtime into an error so
+// it fails only this tick.
+func (s *PluginScheduler) callTick(ctx context.Context, pluginID string) (result interface{}, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("on_tick panicked: %v", p)
+			if s.logger != nil {
+				s.logger.Error("Plugin tick panicked", "plugin", pluginID, "panic", p)
+			}
+		}
+	}()
+	return s.runtime.CallFunction(ctx, pluginID, pluginTickExport)
+}
+
+// disable stops scheduling a plugin that does not handle ticks.
+func (s *PluginScheduler) disable(pluginID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if t, ok := s.tickers[pluginID]; ok {
+		t.cancel()
+		delete(s.tickers, pluginID)
+	}
+	s.disabled[pluginID] = true
+	if s.logger != nil {
+		s.logger.Debug("Plugin does not handle ticks", "plugin", pluginID)
+	}
+}
+
+// Status returns the tick state of a plugin.
+func (s *PluginScheduler) Status(pluginID string) (PluginTickStatus, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, ok := s.tickers[pluginID]
+	if !ok {
+		return PluginTickStatus{}, false
+	}
+	return t.status.snapshot(), true
+}
+
+// Statuses returns the tick state of every scheduled plugin.
+func (s *PluginScheduler) Statuses() []PluginTickStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	statuses := make([]PluginTickStatus, 0, len(s.tickers))
+	for _, t := range s.tickers {
+		statuses = append(statuses, t.status.snapshot())
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].PluginID < statuses[j].PluginID
+	})
+	return statuses
+}
+
+// snapshot returns a copy that doesn't share LastTick with the live state.
+func (st PluginTickStatus) snapshot() PluginTickStatus {
+	if st.LastTick != nil {
+		last := *st.LastTick
+		st.LastTick = &last
+	}
+	return st
+}
+
+// tickOffset spreads first ticks over the interval. The offset is derived
+// from the plugin ID, so a plugin keeps its place across restarts.
+func tickOffset(pluginID string, interval time.Duration) time.Duration {
+	if interval <= 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	h.Write([]byte(pluginID))
+	return time.Duration(h.Sum64() % uint64(interval))
+}
+
+// tickBackoff doubles the interval for every consecutive failure, up to max.
+func tickBackoff(interval time.Duration, failures int, max time.Duration) time.Duration {
+	if interval >= max {
+		return interval
+	}
+	delay := interval
+	for i := 0; i < failures && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
+
+// pluginTickInterval reads the tick interval from plugin config. The
+// "tick_interval" key takes a Go duration or whole seconds; the older
+// "interval" key is read as seconds. Invalid values fall back to def.
+func pluginTickInterval(config map[string]string, def time.Duration) (time.Duration, error) {
+	for _, key := range []string{"tick_interval", "interval"} {
+		raw := strings.TrimSpace(config[key])
+		if raw == "" {
+			continue
+		}
+
+		var interval time.Duration
+		if seconds, err := strconv.Atoi(raw); err == nil {
+			interval = time.Duration(seconds) * time.Second
+		} else if d, err := time.ParseDuration(raw); err == nil {
+			interval = d
+		} else {
+			return def, fmt.Errorf("invalid %s %q", key, raw)
+		}
+		if interval <= 0 {
+			return def, fmt.Errorf("%s must be positive, got %q", key, raw)
+		}
+		return interval, nil
+	}
+	return def, nil
+}
@@ -0,0 +1,437 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+)
+
+// mockTickRuntime is a WasmRuntime whose on_tick results are scripted per plugin.
+type mockTickRuntime struct {
+	mu      sync.Mutex
+	plugins map[string]*domain.Plugin
+	exports map[string]bool
+	ticks   map[string]int
+	tickFn  map[string]func(ctx context.Context) (interface{}, error)
+}
+
+func newMockTickRuntime() *mockTickRuntime {
+	return &mockTickRuntime{
+		plugins: make(map[string]*domain.Plugin),
+		exports: make(map[string]bool),
+		ticks:   make(map[string]int),
+		tickFn:  make(map[string]func(ctx context.Context) (interface{}, error)),
+	}
+}
+
+func (m *mockTickRuntime) add(id string, config map[string]string, tickFn func(ctx context.Context) (interface{}, error)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	plugin := domain.NewPlugin(id, "1.0.0", "")
+	plugin.Config = config
+	m.plugins[id] = plugin
+	m.exports[id] = tickFn != nil
+	m.tickFn[id] = tickFn
+}
+
+func (m *mockTickRuntime) configure(id string, config map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.plugins[id].Config = config
+}
+
+func (m *mockTickRuntime) remove(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.plugins, id)
+}
+
+func (m *mockTickRuntime) disable(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.exports[id] = false
+}
+
+func (m *mockTickRuntime) tickCount(id string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.ticks[id]
+}
+
+func (m *mockTickRuntime) LoadPlugin(ctx context.Context, plugin *domain.Plugin) error { return nil }
+func (m *mockTickRuntime) UnloadPlugin(ctx context.Context, pluginID string) error     { return nil }
+func (m *mockTickRuntime) ReloadPlugin(ctx context.Context, pluginID, expectedHash string) (bool, error) {
+	return false, nil
+}
+func (m *mockTickRuntime) PublishEvent(eventType string, payload []byte) error { return nil }
+func (m *mockTickRuntime) Close() error                                        { return nil }
+func (m *mockTickRuntime) ConfigurePlugin(ctx context.Context, pluginID string, config map[string]string) error {
+	return nil
+}
+func (m *mockTickRuntime) StorageUsage(name string) (*ports.PluginStorageUsage, error) {
+	return &ports.PluginStorageUsage{}, nil
+}
+func (m *mockTickRuntime) PurgeStorage(ctx context.Context, name string) error { return nil }
+
+func (m *mockTickRuntime) CallFunction(ctx context.Context, pluginID, funcName string, args ...interface{}) (interface{}, error) {
+	m.mu.Lock()
+	fn := m.tickFn[pluginID]
+	m.ticks[pluginID]++
+	m.mu.Unlock()
+	if funcName != pluginTickExport || fn == nil {
+		return nil, fmt.Errorf("function not found: %s", funcName)
+	}
+	return fn(ctx)
+}
+
+func (m *mockTickRuntime) ListLoadedPlugins() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ids := make([]string, 0, len(m.plugins))
+	for id := range m.plugins {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func (m *mockTickRuntime) HasFunction(pluginID, funcName string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return funcName == pluginTickExport && m.exports[pluginID]
+}
+
+func (m *mockTickRuntime) GetPlugin(pluginID string) (*domain.Plugin, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, ok := m.plugins[pluginID]
+	return p, ok
+}
+
+func tickOK(ctx context.Context) (interface{}, error) { return uint64(0), nil }
+
+// waitFor polls cond until it holds or the deadline passes.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestPluginTickInterval(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  map[string]string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"default", nil, 10 * time.Second, false},
+		{"duration", map[string]string{"tick_interval": "1m"}, time.Minute, false},
+		{"seconds", map[string]string{"tick_interval": "30"}, 30 * time.Second, false},
+		{"legacy interval", map[string]string{"interval": "5"}, 5 * time.Second, false},
+		{"tick_interval wins", map[string]string{"tick_interval": "2s", "interval": "5"}, 2 * time.Second, false},
+		{"invalid", map[string]string{"tick_interval": "soon"}, 10 * time.Second, true},
+		{"non-positive", map[string]string{"interval": "0"}, 10 * time.Second, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pluginTickInterval(tt.config, DefaultPluginTickInterval)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestTickBackoff(t *testing.T) {
+	interval := time.Second
+	max := 10 * time.Second
+	want := []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for i, w := range want {
+		if got := tickBackoff(interval, i+1, max); got != w {
+			t.Errorf("failures=%d: expected %v, got %v", i+1, w, got)
+		}
+	}
+	if got := tickBackoff(time.Minute, 3, max); got != time.Minute {
+		t.Errorf("expected interval above max to be kept, got %v", got)
+	}
+}
+
+func TestPluginScheduler_TicksLoadedPlugins(t *testing.T) {
+	rt := newMockTickRuntime()
+	rt.add("fast", map[string]string{"tick_interval": "10ms"}, tickOK)
+	rt.add("no-export", nil, nil)
+
+	sched := NewPluginScheduler(rt, &mockAgentLogger{}, PluginSchedulerConfig{
+		DefaultInterval: time.Hour,
+		SyncInterval:    10 * time.Millisecond,
+	})
+	sched.Start(context.Background())
+	defer sched.Stop()
+
+	waitFor(t, "ticks", func() bool { return rt.tickCount("fast") >= 3 })
+
+	status, ok := sched.Status("fast")
+	if !ok {
+		t.Fatal("expected status for fast plugin")
+	}
+	if status.Interval != 10*time.Millisecond {
+		t.Errorf("expected configured interval, got %v", status.Interval)
+	}
+	if status.LastTick == nil || status.ErrorCount != 0 || status.Degraded {
+		t.Errorf("unexpected status: %+v", status)
+	}
+	if _, ok := sched.Status("no-export"); ok {
+		t.Error("expected plugin without on_tick to be skipped")
+	}
+	if rt.tickCount("no-export") != 0 {
+		t.Error("expected plugin without on_tick not to be called")
+	}
+
+	// Plugins loaded later are picked up; unloaded plugins stop ticking
+	rt.add("late", map[string]string{"tick_interval": "10ms"}, tickOK)
+	waitFor(t, "late plugin tick", func() bool { return rt.tickCount("late") >= 1 })
+
+	rt.remove("fast")
+	waitFor(t, "unloaded plugin to be dropped", func() bool {
+		_, ok := sched.Status("fast")
+		return !ok
+	})
+
+	// A plugin disabled by the runtime no longer exports on_tick
+	rt.disable("late")
+	waitFor(t, "disabled plugin to be dropped", func() bool {
+		_, ok := sched.Status("late")
+		return !ok
+	})
+}
+
+func TestPluginScheduler_SlowPluginDoesNotBlockOthers(t *testing.T) {
+	rt := newMockTickRuntime()
+	rt.add("slow", map[string]string{"tick_interval": "10ms"}, func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	rt.add("fast", map[string]string{"tick_interval": "10ms"}, tickOK)
+
+	sched := NewPluginScheduler(rt, &mockAgentLogger{}, PluginSchedulerConfig{
+		Timeout:       200 * time.Millisecond,
+		SyncInterval:  time.Hour,
+		DegradedAfter: 1,
+	})
+	sched.Start(context.Background())
+	defer sched.Stop()
+
+	// fast keeps ticking while slow is stuck in its first call
+	waitFor(t, "fast ticks", func() bool { return rt.tickCount("fast") >= 5 })
+	if status, _ := sched.Status("slow"); status.LastTick != nil {
+		t.Error("expected slow plugin to still be in its first tick")
+	}
+
+	// The deadline eventually cancels the slow tick
+	waitFor(t, "slow tick timeout", func() bool {
+		status, _ := sched.Status("slow")
+		return status.ErrorCount >= 1
+	})
+	status, _ := sched.Status("slow")
+	if status.LastDuration < 200*time.Millisecond {
+		t.Errorf("expected tick to run until the timeout, got %v", status.LastDuration)
+	}
+	if !status.Degraded {
+		t.Error("expected slow plugin to be degraded")
+	}
+}
+
+func TestPluginScheduler_BackoffAndDegraded(t *testing.T) {
+	rt := newMockTickRuntime()
+	var mu sync.Mutex
+	failing := true
+	rt.add("flaky", map[string]string{"tick_interval": "5ms"}, func(ctx context.Context) (interface{}, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if failing {
+			return uint64(0xfffffffe), nil // -2: OnTick returned an error
+		}
+		return uint64(0), nil
+	})
+
+	sched := NewPluginScheduler(rt, &mockAgentLogger{}, PluginSchedulerConfig{
+		SyncInterval:  time.Hour,
+		MaxBackoff:    20 * time.Millisecond,
+		DegradedAfter: 3,
+	})
+	sched.Start(context.Background())
+	defer sched.Stop()
+
+	waitFor(t, "degraded", func() bool {
+		status, _ := sched.Status("flaky")
+		return status.Degraded
+	})
+	status, _ := sched.Status("flaky")
+	if status.ConsecutiveFailures < 3 || status.ErrorCount < 3 {
+		t.Errorf("expected at least 3 failures, got %+v", status)
+	}
+	if status.LastError == "" {
+		t.Error("expected last error to be recorded")
+	}
+	if next := status.NextTick.Sub(*status.LastTick); next < 10*time.Millisecond {
+		t.Errorf("expected backoff delay after failures, got %v", next)
+	}
+
+	mu.Lock()
+	failing = false
+	mu.Unlock()
+
+	waitFor(t, "recovery", func() bool {
+		status, _ := sched.Status("flaky")
+		return !status.Degraded
+	})
+	status, _ = sched.Status("flaky")
+	if status.ConsecutiveFailures != 0 || status.LastError != "" {
+		t.Errorf("expected failures to reset, got %+v", status)
+	}
+	if status.ErrorCount < 3 {
+		t.Errorf("expected error count to be kept, got %d", status.ErrorCount)
+	}
+}
+
+func TestPluginScheduler_StopsPluginsWithoutTickHandler(t *testing.T) {
+	rt := newMockTickRuntime()
+	rt.add("plain", map[string]string{"tick_interval": "5ms"}, func(ctx context.Context) (interface{}, error) {
+		return uint64(0xffffffff), nil // -1: no TickHandler
+	})
+
+	sched := NewPluginScheduler(rt, &mockAgentLogger{}, PluginSchedulerConfig{SyncInterval: 5 * time.Millisecond})
+	sched.Start(context.Background())
+	defer sched.Stop()
+
+	waitFor(t, "first tick", func() bool { return rt.tickCount("plain") >= 1 })
+	time.Sleep(50 * time.Millisecond)
+
+	if n := rt.tickCount("plain"); n != 1 {
+		t.Errorf("expected a single tick, got %d", n)
+	}
+	if _, ok := sched.Status("plain"); ok {
+		t.Error("expected plugin to be unscheduled")
+	}
+	if len(sched.Statuses()) != 0 {
+		t.Error("expected no scheduled plugins")
+	}
+}
+
+func TestTickOffset(t *testing.T) {
+	offsets := make(map[time.Duration]bool)
+	for i := 0; i < 10; i++ {
+		id := fmt.Sprintf("plugin-%d", i)
+		offset := tickOffset(id, 10*time.Second)
+		if offset < 0 || offset >= 10*time.Second {
+			t.Fatalf("expected offset within the interval, got %v", offset)
+		}
+		if again := tickOffset(id, 10*time.Second); again != offset {
+			t.Errorf("expected a stable offset for %s, got %v then %v", id, offset, again)
+		}
+		offsets[offset] = true
+	}
+	if len(offsets) < 5 {
+		t.Errorf("expected first ticks to be spread out, got %d distinct offsets", len(offsets))
+	}
+	if tickOffset("plugin", 0) != 0 {
+		t.Error("expected no offset without an interval")
+	}
+}
+
+func TestPluginScheduler_TickCadence(t *testing.T) {
+	rt := newMockTickRuntime()
+	var mu sync.Mutex
+	var ticks []time.Time
+	rt.add("metronome", map[string]string{"tick_interval": "20ms"}, func(ctx context.Context) (interface{}, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		ticks = append(ticks, time.Now())
+		return uint64(0), nil
+	})
+
+	sched := NewPluginScheduler(rt, &mockAgentLogger{}, PluginSchedulerConfig{SyncInterval: time.Hour})
+	start := time.Now()
+	sched.Start(context.Background())
+	defer sched.Stop()
+
+	waitFor(t, "ticks", func() bool { return rt.tickCount("metronome") >= 6 })
+	mu.Lock()
+	defer mu.Unlock()
+	if first := ticks[0].Sub(start); first >= 70*time.Millisecond {
+		t.Errorf("expected the first tick within the interval, got %v", first)
+	}
+	for i := 1; i < len(ticks); i++ {
+		if gap := ticks[i].Sub(ticks[i-1]); gap < 20*time.Millisecond {
+			t.Errorf("tick %d came %v after the previous one, expected at least 20ms", i, gap)
+		}
+	}
+	// Generous bound for loaded machines; the point is ticks don't pile up
+	if elapsed := ticks[5].Sub(ticks[0]); elapsed > 500*time.Millisecond {
+		t.Errorf("expected 5 intervals to take about 100ms, took %v", elapsed)
+	}
+}
+
+func TestPluginScheduler_PanicIsolation(t *testing.T) {
+	rt := newMockTickRuntime()
+	rt.add("crashy", map[string]string{"tick_interval": "5ms"}, func(ctx context.Context) (interface{}, error) {
+		panic("guest blew up")
+	})
+	rt.add("steady", map[string]string{"tick_interval": "5ms"}, tickOK)
+
+	sched := NewPluginScheduler(rt, &mockAgentLogger{}, PluginSchedulerConfig{
+		SyncInterval: time.Hour,
+		MaxBackoff:   5 * time.Millisecond,
+	})
+	sched.Start(context.Background())
+	defer sched.Stop()
+
+	waitFor(t, "repeated panics", func() bool {
+		status, _ := sched.Status("crashy")
+		return status.ErrorCount >= 2
+	})
+	waitFor(t, "steady ticks", func() bool { return rt.tickCount("steady") >= 5 })
+
+	status, _ := sched.Status("crashy")
+	if !strings.Contains(status.LastError, "on_tick panicked: guest blew up") {
+		t.Errorf("expected the panic to be recorded as the tick error, got %q", status.LastError)
+	}
+	if status, _ := sched.Status("steady"); status.ErrorCount != 0 {
+		t.Errorf("expected steady to be unaffected, got %+v", status)
+	}
+}
+
+func TestPluginScheduler_IntervalChange(t *testing.T) {
+	rt := newMockTickRuntime()
+	rt.add("tuned", map[string]string{"tick_interval": "1h"}, tickOK)
+
+	sched := NewPluginScheduler(rt, &mockAgentLogger{}, PluginSchedulerConfig{SyncInterval: time.Hour})
+	sched.Start(context.Background())
+	defer sched.Stop()
+
+	waitFor(t, "plugin to be scheduled", func() bool {
+		_, ok := sched.Status("tuned")
+		return ok
+	})
+	rt.configure("tuned", map[string]string{"tick_interval": "10ms"})
+	sched.Sync(context.Background())
+
+	waitFor(t, "ticks at the new interval", func() bool { return rt.tickCount("tuned") >= 3 })
+	if status, _ := sched.Status("tuned"); status.Interval != 10*time.Millisecond {
+		t.Errorf("expected the new interval, got %v", status.Interval)
+	}
+}
@@ -3,19 +3,26 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"runtime"
 	"runtime/pprof"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/forge-platform/forge/internal/core/domain"
 	"github.com/forge-platform/forge/internal/core/ports"
+	pprofile "github.com/google/pprof/profile"
 	"github.com/google/uuid"
 )
 
+// ErrProfileDataMissing is returned when a completed profile has no pprof data on disk.
+var ErrProfileDataMissing = errors.New("profile data missing")
+
 // ProfileService provides profiling capabilities.
 type ProfileService struct {
 	profileRepo ports.ProfileRepository
@@ -282,6 +289,173 @@ func (s *ProfileService) DeleteProfile(ctx context.Context, id uuid.UUID) error
 	return nil
 }
 
+// GetFlameGraph returns the stored flame graph for a profile, building it
+// from the profile's pprof data on first use.
+func (s *ProfileService) GetFlameGraph(ctx context.Context, id uuid.UUID) (*domain.FlameGraph, error) {
+	if s.profileRepo != nil {
+		if fg, err := s.profileRepo.GetFlameGraph(ctx, id); err == nil && fg != nil && fg.Root != nil {
+			return fg, nil
+		}
+	}
+	return s.BuildFlameGraph(ctx, id)
+}
+
+// OpenProfileData opens the raw pprof data of a completed profile. The
+// caller must close the returned reader.
+func (s *ProfileService) OpenProfileData(ctx context.Context, id uuid.UUID) (*domain.Profile, io.ReadCloser, error) {
+	profile, err := s.GetProfile(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if profile == nil {
+		return nil, nil, fmt.Errorf("profile not found: %s", id)
+	}
+	if profile.Status != domain.ProfileStatusCompleted {
+		return nil, nil, fmt.Errorf("profile %s is not completed (status: %s)", id, profile.Status)
+	}
+	if profile.FilePath == "" {
+		return nil, nil, fmt.Errorf("%w: profile %s has no data file", ErrProfileDataMissing, id)
+	}
+
+	f, err := os.Open(profile.FilePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, fmt.Errorf("%w: %s", ErrProfileDataMissing, profile.FilePath)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open profile data: %w", err)
+	}
+	return profile, f, nil
+}
+
+// BuildFlameGraph parses a completed profile's pprof file, aggregates its
+// stacks into a flame graph and persists the result.
+func (s *ProfileService) BuildFlameGraph(ctx context.Context, id uuid.UUID) (*domain.FlameGraph, error) {
+	profile, f, err := s.OpenProfileData(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fg, err := BuildFlameGraphFromPprof(f, profile.ID, profile.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.profileRepo != nil {
+		if err := s.profileRepo.SaveFlameGraph(ctx, fg); err != nil {
+			return nil, fmt.Errorf("failed to save flame graph: %w", err)
+		}
+	}
+
+	s.logger.Debug("built flame graph", "profile_id", id, "total", fg.TotalValue, "depth", fg.MaxDepth)
+	return fg, nil
+}
+
+// BuildFlameGraphFromPprof parses pprof data (gzipped or not) and aggregates
+// its samples into a flame graph rooted at "root". Node values are
+// cumulative; Self holds the value of samples whose leaf frame is the node.
+func BuildFlameGraphFromPprof(r io.Reader, profileID uuid.UUID, profileType domain.ProfileType) (*domain.FlameGraph, error) {
+	p, err := pprofile.Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse pprof data: %w", err)
+	}
+
+	fg := domain.NewFlameGraph(profileID, profileType)
+	valueIndex := flameGraphValueIndex(p)
+	children := make(map[*domain.FlameGraphNode]map[string]*domain.FlameGraphNode)
+
+	for _, sample := range p.Sample {
+		if valueIndex >= len(sample.Value) {
+			continue
+		}
+		value := sample.Value[valueIndex]
+		if value == 0 {
+			continue
+		}
+
+		node := fg.Root
+		node.Value += value
+		frames := sampleFrames(sample)
+		for _, name := range frames {
+			byName := children[node]
+			if byName == nil {
+				byName = make(map[string]*domain.FlameGraphNode)
+				children[node] = byName
+			}
+			child, ok := byName[name]
+			if !ok {
+				child = &domain.FlameGraphNode{Name: name}
+				byName[name] = child
+				node.Children = append(node.Children, child)
+			}
+			child.Value += value
+			node = child
+		}
+		node.Self += value
+
+		if len(frames) > fg.MaxDepth {
+			fg.MaxDepth = len(frames)
+		}
+	}
+
+	sortFlameGraph(fg.Root)
+	fg.TotalValue = fg.Root.Value
+	return fg, nil
+}
+
+// flameGraphValueIndex picks the sample value to aggregate: sample counts
+// when the profile has them, otherwise its default or last sample type.
+func flameGraphValueIndex(p *pprofile.Profile) int {
+	for i, st := range p.SampleType {
+		if st.Type == "samples" {
+			return i
+		}
+	}
+	if p.DefaultSampleType != "" {
+		for i, st := range p.SampleType {
+			if st.Type == p.DefaultSampleType {
+				return i
+			}
+		}
+	}
+	return len(p.SampleType) - 1
+}
+
+// sampleFrames returns the function names of a sample from root to leaf,
+// expanding inlined frames.
+func sampleFrames(sample *pprofile.Sample) []string {
+	var frames []string
+	// Locations and their lines are stored leaf first
+	for i := len(sample.Location) - 1; i >= 0; i-- {
+		loc := sample.Location[i]
+		if len(loc.Line) == 0 {
+			frames = append(frames, fmt.Sprintf("0x%x", loc.Address))
+			continue
+		}
+		for j := len(loc.Line) - 1; j >= 0; j-- {
+			name := "unknown"
+			if fn := loc.Line[j].Function; fn != nil && fn.Name != "" {
+				name = fn.Name
+			}
+			frames = append(frames, name)
+		}
+	}
+	return frames
+}
+
+// sortFlameGraph orders children by value (descending), then name.
+func sortFlameGraph(node *domain.FlameGraphNode) {
+	sort.Slice(node.Children, func(i, j int) bool {
+		if node.Children[i].Value != node.Children[j].Value {
+			return node.Children[i].Value > node.Children[j].Value
+		}
+		return node.Children[i].Name < node.Children[j].Name
+	})
+	for _, child := range node.Children {
+		sortFlameGraph(child)
+	}
+}
+
 // GetActiveProfiles returns the list of active profiles.
 func (s *ProfileService) GetActiveProfiles() []*domain.Profile {
 	s.mu.RLock()
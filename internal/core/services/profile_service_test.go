@@ -2,7 +2,11 @@
 package services
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sync"
@@ -11,6 +15,7 @@ import (
 
 	"github.com/forge-platform/forge/internal/core/domain"
 	"github.com/forge-platform/forge/internal/core/ports"
+	pprofile "github.com/google/pprof/profile"
 	"github.com/google/uuid"
 )
 
@@ -25,13 +30,15 @@ func (m *mockProfileLogger) With(args ...interface{}) ports.Logger { return m }
 
 // mockProfileRepository for testing
 type mockProfileRepository struct {
-	mu       sync.RWMutex
-	profiles map[uuid.UUID]*domain.Profile
+	mu          sync.RWMutex
+	profiles    map[uuid.UUID]*domain.Profile
+	flameGraphs map[uuid.UUID]*domain.FlameGraph
 }
 
 func newMockProfileRepository() *mockProfileRepository {
 	return &mockProfileRepository{
-		profiles: make(map[uuid.UUID]*domain.Profile),
+		profiles:    make(map[uuid.UUID]*domain.Profile),
+		flameGraphs: make(map[uuid.UUID]*domain.FlameGraph),
 	}
 }
 
@@ -81,11 +88,19 @@ func (m *mockProfileRepository) GetProfileData(ctx context.Context, profileID uu
 }
 
 func (m *mockProfileRepository) SaveFlameGraph(ctx context.Context, fg *domain.FlameGraph) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.flameGraphs[fg.ProfileID] = fg
 	return nil
 }
 
 func (m *mockProfileRepository) GetFlameGraph(ctx context.Context, profileID uuid.UUID) (*domain.FlameGraph, error) {
-	return &domain.FlameGraph{ProfileID: profileID}, nil
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if fg, ok := m.flameGraphs[profileID]; ok {
+		return fg, nil
+	}
+	return nil, fmt.Errorf("flame graph not found")
 }
 
 func (m *mockProfileRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
@@ -154,3 +169,194 @@ func TestProfileService_CaptureHeapProfile(t *testing.T) {
 	}
 }
 
+
+// writePprofFixture writes a small CPU profile with the stacks
+//
+//	main;handle;parse      3 samples
+//	main;handle;encode     2 samples
+//	main;handle            1 sample
+//	main;gc;sweep          4 samples (sweep inlined into gc)
+func writePprofFixture(t *testing.T, path string) {
+	t.Helper()
+
+	fn := map[string]*pprofile.Function{}
+	var functions []*pprofile.Function
+	for i, name := range []string{"main", "handle", "parse", "encode", "gc", "sweep"} {
+		f := &pprofile.Function{ID: uint64(i + 1), Name: name, SystemName: name, Filename: "main.go"}
+		fn[name] = f
+		functions = append(functions, f)
+	}
+
+	loc := func(id uint64, names ...string) *pprofile.Location {
+		l := &pprofile.Location{ID: id, Address: 0x1000 + id}
+		for _, name := range names {
+			l.Line = append(l.Line, pprofile.Line{Function: fn[name], Line: 1})
+		}
+		return l
+	}
+	mainLoc := loc(1, "main")
+	handleLoc := loc(2, "handle")
+	parseLoc := loc(3, "parse")
+	encodeLoc := loc(4, "encode")
+	gcLoc := loc(5, "sweep", "gc") // leaf-first: sweep inlined into gc
+
+	sample := func(count int64, locs ...*pprofile.Location) *pprofile.Sample {
+		return &pprofile.Sample{Location: locs, Value: []int64{count, count * 10_000_000}}
+	}
+
+	p := &pprofile.Profile{
+		SampleType: []*pprofile.ValueType{
+			{Type: "samples", Unit: "count"},
+			{Type: "cpu", Unit: "nanoseconds"},
+		},
+		PeriodType: &pprofile.ValueType{Type: "cpu", Unit: "nanoseconds"},
+		Period:     10_000_000,
+		Sample: []*pprofile.Sample{
+			sample(3, parseLoc, handleLoc, mainLoc),
+			sample(2, encodeLoc, handleLoc, mainLoc),
+			sample(1, handleLoc, mainLoc),
+			sample(4, gcLoc, mainLoc),
+		},
+		Location: []*pprofile.Location{mainLoc, handleLoc, parseLoc, encodeLoc, gcLoc},
+		Function: functions,
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("failed to create fixture: %v", err)
+	}
+	defer f.Close()
+	if err := p.Write(f); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+}
+
+func findFlameNode(node *domain.FlameGraphNode, path ...string) *domain.FlameGraphNode {
+	for _, name := range path {
+		var next *domain.FlameGraphNode
+		for _, child := range node.Children {
+			if child.Name == name {
+				next = child
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		node = next
+	}
+	return node
+}
+
+func TestProfileService_BuildFlameGraph(t *testing.T) {
+	tmpDir := t.TempDir()
+	repo := newMockProfileRepository()
+	svc := NewProfileService(repo, tmpDir, &mockProfileLogger{})
+	ctx := context.Background()
+
+	profile := domain.NewProfile("fixture", domain.ProfileTypeCPU, "api", time.Second)
+	path := filepath.Join(tmpDir, "fixture.pprof")
+	writePprofFixture(t, path)
+	profile.Start()
+	profile.Complete(1, path)
+	_ = repo.Create(ctx, profile)
+
+	fg, err := svc.GetFlameGraph(ctx, profile.ID)
+	if err != nil {
+		t.Fatalf("GetFlameGraph failed: %v", err)
+	}
+
+	if fg.TotalValue != 10 || fg.Root.Value != 10 {
+		t.Errorf("expected total 10 samples, got total=%d root=%d", fg.TotalValue, fg.Root.Value)
+	}
+	if fg.MaxDepth != 3 {
+		t.Errorf("expected max depth 3, got %d", fg.MaxDepth)
+	}
+	if len(fg.Root.Children) != 1 || fg.Root.Children[0].Name != "main" {
+		t.Fatalf("expected single root child 'main', got %+v", fg.Root.Children)
+	}
+
+	tests := []struct {
+		path  []string
+		value int64
+		self  int64
+	}{
+		{[]string{"main"}, 10, 0},
+		{[]string{"main", "handle"}, 6, 1},
+		{[]string{"main", "handle", "parse"}, 3, 3},
+		{[]string{"main", "handle", "encode"}, 2, 2},
+		{[]string{"main", "gc"}, 4, 0},
+		{[]string{"main", "gc", "sweep"}, 4, 4},
+	}
+	for _, tt := range tests {
+		node := findFlameNode(fg.Root, tt.path...)
+		if node == nil {
+			t.Errorf("missing node %v", tt.path)
+			continue
+		}
+		if node.Value != tt.value || node.Self != tt.self {
+			t.Errorf("node %v: expected value=%d self=%d, got value=%d self=%d",
+				tt.path, tt.value, tt.self, node.Value, node.Self)
+		}
+	}
+
+	// Children are ordered by cumulative value
+	mainNode := fg.Root.Children[0]
+	if mainNode.Children[0].Name != "handle" || mainNode.Children[1].Name != "gc" {
+		t.Errorf("expected children ordered [handle gc], got [%s %s]", mainNode.Children[0].Name, mainNode.Children[1].Name)
+	}
+
+	if _, ok := repo.flameGraphs[profile.ID]; !ok {
+		t.Error("expected flame graph to be persisted")
+	}
+}
+
+func TestProfileService_BuildFlameGraphIncomplete(t *testing.T) {
+	repo := newMockProfileRepository()
+	svc := NewProfileService(repo, t.TempDir(), &mockProfileLogger{})
+
+	profile := domain.NewProfile("pending", domain.ProfileTypeCPU, "api", time.Second)
+	_ = repo.Create(context.Background(), profile)
+
+	if _, err := svc.BuildFlameGraph(context.Background(), profile.ID); err == nil {
+		t.Error("expected error for profile that is not completed")
+	}
+}
+
+func TestProfileService_OpenProfileData(t *testing.T) {
+	tmpDir := t.TempDir()
+	repo := newMockProfileRepository()
+	svc := NewProfileService(repo, tmpDir, &mockProfileLogger{})
+	ctx := context.Background()
+
+	path := filepath.Join(tmpDir, "fixture.pprof")
+	writePprofFixture(t, path)
+	want, _ := os.ReadFile(path)
+
+	profile := domain.NewProfile("fixture", domain.ProfileTypeCPU, "api", time.Second)
+	profile.Start()
+	profile.Complete(int64(len(want)), path)
+	_ = repo.Create(ctx, profile)
+
+	_, r, err := svc.OpenProfileData(ctx, profile.ID)
+	if err != nil {
+		t.Fatalf("OpenProfileData failed: %v", err)
+	}
+	got, _ := io.ReadAll(r)
+	r.Close()
+	if !bytes.Equal(got, want) {
+		t.Errorf("expected %d bytes of pprof data, got %d", len(want), len(got))
+	}
+
+	// A completed profile whose file was removed reports missing data
+	os.Remove(path)
+	if _, _, err := svc.OpenProfileData(ctx, profile.ID); !errors.Is(err, ErrProfileDataMissing) {
+		t.Errorf("expected ErrProfileDataMissing, got %v", err)
+	}
+
+	pending := domain.NewProfile("pending", domain.ProfileTypeCPU, "api", time.Second)
+	_ = repo.Create(ctx, pending)
+	if _, _, err := svc.OpenProfileData(ctx, pending.ID); err == nil {
+		t.Error("expected error for profile that is not completed")
+	}
+}
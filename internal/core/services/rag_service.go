@@ -10,14 +10,17 @@ import (
 
 	"github.com/forge-platform/forge/internal/core/domain"
 	"github.com/forge-platform/forge/internal/core/ports"
+	"github.com/google/uuid"
 )
 
 // RAGService provides Retrieval-Augmented Generation capabilities.
 type RAGService struct {
-	metricRepo ports.MetricRepository
-	taskRepo   ports.TaskRepository
-	logger     ports.Logger
-	maxContext int // Maximum context window size in tokens (approximate)
+	metricRepo  ports.MetricRepository
+	taskRepo    ports.TaskRepository
+	insightRepo ports.InsightRepository // optional; persists AnalyzeMetrics results
+	logRepo     ports.LogRepository     // optional; source of IncludeLogs context
+	logger      ports.Logger
+	maxContext  int // Maximum context window size in tokens (approximate)
 }
 
 // RAGConfig configures the RAG service.
@@ -38,6 +41,24 @@ func NewRAGService(metricRepo ports.MetricRepository, taskRepo ports.TaskReposit
 	}
 }
 
+// SetInsightRepository makes AnalyzeMetrics persist its results so past
+// analyses can be listed with ListInsights.
+func (s *RAGService) SetInsightRepository(repo ports.InsightRepository) {
+	s.insightRepo = repo
+}
+
+// SetLogRepository enables IncludeLogs, which adds recent warnings and
+// errors to the built context.
+func (s *RAGService) SetLogRepository(repo ports.LogRepository) {
+	s.logRepo = repo
+}
+
+// maxContextLogs bounds how many log entries are read for a context.
+const maxContextLogs = 200
+
+// maxLogMessageLen truncates long log messages in the context.
+const maxLogMessageLen = 200
+
 // ContextRequest specifies what context to retrieve.
 type ContextRequest struct {
 	TimeRange     time.Duration
@@ -68,6 +89,11 @@ type MetricSummary struct {
 	Count     int
 	Trend     string // "increasing", "decreasing", "stable"
 	Anomalies []string
+
+	// Counters are summarized as per-second rates so that resets do not
+	// read as drops; Resets counts the resets seen in the window.
+	Rate   bool
+	Resets int
 }
 
 // TaskSummary summarizes task data for context.
@@ -79,12 +105,14 @@ type TaskSummary struct {
 	Error     string
 }
 
-// LogEntry represents a log entry for context.
+// LogEntry represents a log entry for context. Repeated messages are
+// collapsed into one entry; Timestamp is the latest occurrence.
 type LogEntry struct {
 	Timestamp time.Time
 	Level     string
 	Message   string
 	Source    string
+	Count     int
 }
 
 // BuildContext retrieves and formats context for AI consumption.
@@ -126,6 +154,21 @@ func (s *RAGService) BuildContext(ctx context.Context, req ContextRequest) (*Con
 		}
 	}
 
+	// Logs go last and only get the budget metrics and tasks left over
+	if req.IncludeLogs {
+		logs, err := s.retrieveLogs(ctx, startTime, now)
+		if err != nil {
+			s.logger.Warn("Failed to retrieve logs", "error", err)
+		} else {
+			budget := s.maxContext - s.estimateTokens(s.buildSystemPrompt(contextParts))
+			section, included := s.formatLogsContext(logs, budget)
+			result.Logs = logs[:included]
+			if section != "" {
+				contextParts = append(contextParts, section)
+			}
+		}
+	}
+
 	// Build system prompt
 	result.SystemPrompt = s.buildSystemPrompt(contextParts)
 	result.TokenCount = s.estimateTokens(result.SystemPrompt)
@@ -192,6 +235,50 @@ func (s *RAGService) retrieveTasks(ctx context.Context, since time.Time) ([]Task
 	return summaries, nil
 }
 
+// retrieveLogs fetches recent warnings and errors and collapses repeated
+// messages, most recent first.
+func (s *RAGService) retrieveLogs(ctx context.Context, since, until time.Time) ([]LogEntry, error) {
+	if s.logRepo == nil {
+		return nil, fmt.Errorf("log repository not configured")
+	}
+
+	entries, err := s.logRepo.List(ctx, ports.LogFilter{
+		MinLevel:  domain.LogLevelWarning,
+		StartTime: since,
+		EndTime:   until,
+		Limit:     maxContextLogs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var summaries []LogEntry
+	index := make(map[string]int)
+	for _, e := range entries {
+		key := string(e.Level) + "\x00" + e.Source + "\x00" + e.Message
+		if i, ok := index[key]; ok {
+			summaries[i].Count++
+			if e.Timestamp.After(summaries[i].Timestamp) {
+				summaries[i].Timestamp = e.Timestamp
+			}
+			continue
+		}
+		index[key] = len(summaries)
+		summaries = append(summaries, LogEntry{
+			Timestamp: e.Timestamp,
+			Level:     string(e.Level),
+			Message:   e.Message,
+			Source:    e.Source,
+			Count:     1,
+		})
+	}
+
+	sort.SliceStable(summaries, func(i, j int) bool {
+		return summaries[i].Timestamp.After(summaries[j].Timestamp)
+	})
+	return summaries, nil
+}
+
 // summarizeMetricSeries computes statistics for a metric series.
 func (s *RAGService) summarizeMetricSeries(series *domain.MetricSeries) MetricSummary {
 	if series == nil || len(series.Points) == 0 {
@@ -200,13 +287,20 @@ func (s *RAGService) summarizeMetricSeries(series *domain.MetricSeries) MetricSu
 
 	points := series.Points
 	summary := MetricSummary{
-		Name:   series.Name,
-		Tags:   series.Tags,
-		Count:  len(points),
-		Min:    points[0].Value,
-		Max:    points[0].Value,
-		Latest: points[len(points)-1].Value,
+		Name:  series.Name,
+		Tags:  series.Tags,
+		Count: len(points),
+	}
+	if series.IsCounter() {
+		if rates, resets := domain.CounterRates(points); len(rates) > 0 {
+			points = rates
+			summary.Rate = true
+			summary.Resets = resets
+		}
 	}
+	summary.Min = points[0].Value
+	summary.Max = points[0].Value
+	summary.Latest = points[len(points)-1].Value
 
 	// Calculate min, max, sum for avg
 	var sum float64
@@ -334,8 +428,16 @@ func (s *RAGService) formatMetricsContext(metrics []MetricSummary) string {
 		}
 		sb.WriteString("\n")
 
-		sb.WriteString(fmt.Sprintf("- Current: %.2f\n", m.Latest))
-		sb.WriteString(fmt.Sprintf("- Range: %.2f - %.2f (avg: %.2f)\n", m.Min, m.Max, m.Avg))
+		if m.Rate {
+			sb.WriteString(fmt.Sprintf("- Current rate: %.2f/s\n", m.Latest))
+			sb.WriteString(fmt.Sprintf("- Rate range: %.2f/s - %.2f/s (avg: %.2f/s)\n", m.Min, m.Max, m.Avg))
+			if m.Resets > 0 {
+				sb.WriteString(fmt.Sprintf("- Counter resets: %d\n", m.Resets))
+			}
+		} else {
+			sb.WriteString(fmt.Sprintf("- Current: %.2f\n", m.Latest))
+			sb.WriteString(fmt.Sprintf("- Range: %.2f - %.2f (avg: %.2f)\n", m.Min, m.Max, m.Avg))
+		}
 		sb.WriteString(fmt.Sprintf("- Trend: %s\n", m.Trend))
 		sb.WriteString(fmt.Sprintf("- Data points: %d\n", m.Count))
 
@@ -381,6 +483,49 @@ func (s *RAGService) formatTasksContext(tasks []TaskSummary) string {
 	return sb.String()
 }
 
+// formatLogsContext formats logs for LLM consumption within a budget of
+// tokens. It returns the section and how many logs it includes.
+func (s *RAGService) formatLogsContext(logs []LogEntry, budget int) (string, int) {
+	header := "## Recent Logs\n\n"
+	if len(logs) == 0 {
+		return "No recent warnings or errors in logs.", 0
+	}
+	if budget < s.estimateTokens(header) {
+		return "", 0
+	}
+
+	var sb strings.Builder
+	sb.WriteString(header)
+
+	included := 0
+	for _, l := range logs {
+		msg := l.Message
+		if len(msg) > maxLogMessageLen {
+			msg = msg[:maxLogMessageLen] + "..."
+		}
+		line := fmt.Sprintf("- [%s] %s %s: %s", l.Timestamp.Format("15:04:05"), strings.ToUpper(l.Level), l.Source, msg)
+		if l.Count > 1 {
+			line += fmt.Sprintf(" (x%d)", l.Count)
+		}
+		line += "\n"
+
+		// Reserve room for the omitted-entries note
+		if s.estimateTokens(sb.String()+line)+10 > budget {
+			break
+		}
+		sb.WriteString(line)
+		included++
+	}
+
+	if included == 0 {
+		return "", 0
+	}
+	if omitted := len(logs) - included; omitted > 0 {
+		sb.WriteString(fmt.Sprintf("... and %d more\n", omitted))
+	}
+	return sb.String(), included
+}
+
 // buildSystemPrompt combines context parts into a system prompt.
 func (s *RAGService) buildSystemPrompt(contextParts []string) string {
 	var sb strings.Builder
@@ -470,11 +615,34 @@ func (s *RAGService) AnalyzeMetrics(ctx context.Context, timeRange time.Duration
 			result.MetricCount, result.TaskCount, len(result.Issues))
 	}
 
+	if s.insightRepo != nil {
+		insight := result.toInsight(timeRange)
+		// The analysis is still useful to the caller if it can't be stored
+		if err := s.insightRepo.Create(ctx, insight); err != nil {
+			s.logger.Warn("failed to persist insight", "error", err)
+		} else {
+			result.ID = insight.ID
+		}
+	}
+
 	return result, nil
 }
 
+// ListInsights returns persisted analysis results, newest first.
+func (s *RAGService) ListInsights(ctx context.Context, filter ports.InsightFilter) ([]*domain.Insight, error) {
+	if s.insightRepo == nil {
+		return nil, fmt.Errorf("insight store not configured")
+	}
+	insights, err := s.insightRepo.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list insights: %w", err)
+	}
+	return insights, nil
+}
+
 // AnalysisResult contains the result of AI analysis.
 type AnalysisResult struct {
+	ID           uuid.UUID // Set once the result is persisted as an insight
 	Timestamp    time.Time
 	MetricCount  int
 	TaskCount    int
@@ -490,3 +658,21 @@ type Issue struct {
 	Description string
 	Suggestion  string
 }
+
+// toInsight converts the result into an insight covering timeRange.
+func (r *AnalysisResult) toInsight(timeRange time.Duration) *domain.Insight {
+	issues := make([]domain.InsightIssue, len(r.Issues))
+	for i, issue := range r.Issues {
+		issues[i] = domain.InsightIssue{
+			Severity:    issue.Severity,
+			Component:   issue.Component,
+			Description: issue.Description,
+			Suggestion:  issue.Suggestion,
+		}
+	}
+	insight := domain.NewInsight(timeRange, r.Summary, issues)
+	insight.Timestamp = r.Timestamp
+	insight.MetricCount = r.MetricCount
+	insight.TaskCount = r.TaskCount
+	return insight
+}
@@ -2,8 +2,15 @@
 package services
 
 import (
+	"context"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+	"github.com/google/uuid"
 )
 
 func TestRAGConfig_Defaults(t *testing.T) {
@@ -142,3 +149,187 @@ func TestLogEntry_Fields(t *testing.T) {
 	}
 }
 
+// resettingCounter returns a counter growing by 10/s that restarts from zero
+// two thirds of the way through.
+func resettingCounter(n int) *domain.MetricSeries {
+	base := time.Now().Add(-time.Duration(n) * time.Second)
+	series := &domain.MetricSeries{Name: "http_requests_total", Type: domain.MetricTypeCounter}
+	value := 1000.0
+	for i := 0; i < n; i++ {
+		if i == 2*n/3 {
+			value = 0
+		}
+		series.Points = append(series.Points, domain.MetricPoint{Value: value, Timestamp: base.Add(time.Duration(i) * time.Second)})
+		value += 10
+	}
+	return series
+}
+
+func TestRAGService_SummarizeResettingCounter(t *testing.T) {
+	svc := &RAGService{}
+	series := resettingCounter(30)
+
+	summary := svc.summarizeMetricSeries(series)
+	if !summary.Rate || summary.Resets != 1 {
+		t.Errorf("expected a rate summary with 1 reset, got rate=%v resets=%d", summary.Rate, summary.Resets)
+	}
+	if summary.Trend != "stable" {
+		t.Errorf("expected stable trend for a steady counter, got %s", summary.Trend)
+	}
+	if len(summary.Anomalies) != 0 {
+		t.Errorf("expected no anomalies, got %v", summary.Anomalies)
+	}
+	if summary.Latest != 10 || summary.Count != 30 {
+		t.Errorf("expected current rate 10/s over 30 samples, got %v over %d", summary.Latest, summary.Count)
+	}
+
+	// The same samples read as a gauge do look like a drop
+	series.Type = domain.MetricTypeGauge
+	if trend := svc.summarizeMetricSeries(series).Trend; trend != "decreasing" {
+		t.Errorf("expected gauge interpretation to be decreasing, got %s", trend)
+	}
+}
+
+type mockInsightRepository struct {
+	insights []*domain.Insight
+	err      error
+}
+
+func (m *mockInsightRepository) Create(ctx context.Context, insight *domain.Insight) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.insights = append(m.insights, insight)
+	return nil
+}
+
+func (m *mockInsightRepository) List(ctx context.Context, filter ports.InsightFilter) ([]*domain.Insight, error) {
+	return m.insights, nil
+}
+
+func (m *mockInsightRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestRAGService_AnalyzeMetricsPersistsInsight(t *testing.T) {
+	taskRepo := newMockTaskRepository()
+	failed := domain.NewTask("backup", nil)
+	failed.Status = domain.TaskStatusFailed
+	taskRepo.Create(context.Background(), failed)
+
+	svc := NewRAGService(&mockMetricRepository{}, taskRepo, &mockLogger{}, RAGConfig{})
+	ctx := context.Background()
+
+	if _, err := svc.ListInsights(ctx, ports.InsightFilter{}); err == nil {
+		t.Error("expected ListInsights to fail without an insight store")
+	}
+
+	insights := &mockInsightRepository{}
+	svc.SetInsightRepository(insights)
+
+	result, err := svc.AnalyzeMetrics(ctx, 2*time.Hour)
+	if err != nil {
+		t.Fatalf("AnalyzeMetrics failed: %v", err)
+	}
+	if len(insights.insights) != 1 {
+		t.Fatalf("expected 1 persisted insight, got %d", len(insights.insights))
+	}
+	insight := insights.insights[0]
+	if insight.ID != result.ID || insight.TimeRange != 2*time.Hour || insight.Summary != result.Summary {
+		t.Errorf("insight does not match result: %+v vs %+v", insight, result)
+	}
+	if len(insight.Issues) != len(result.Issues) || insight.MaxSeverity() != "warning" {
+		t.Errorf("expected the failed task issue to be stored, got %+v", insight.Issues)
+	}
+
+	// A store failure does not fail the analysis
+	insights.err = fmt.Errorf("disk full")
+	if result, err := svc.AnalyzeMetrics(ctx, time.Hour); err != nil || result.ID != uuid.Nil {
+		t.Errorf("expected unpersisted result without error, got %v, %v", result, err)
+	}
+}
+
+func TestRAGService_BuildContextIncludesLogs(t *testing.T) {
+	ctx := context.Background()
+	logs := newMockLogRepository()
+	for i := 0; i < 2; i++ {
+		entry := domain.NewLogEntry(domain.LogLevelError, "connection refused to db-primary", "api", "api")
+		entry.Timestamp = time.Now().Add(-time.Duration(i+1) * time.Minute)
+		logs.Create(ctx, entry)
+	}
+	logs.Create(ctx, domain.NewLogEntry(domain.LogLevelWarning, "slow query took 2.3s", "db", "db"))
+
+	svc := NewRAGService(&mockMetricRepository{}, newMockTaskRepository(), &mockLogger{}, RAGConfig{})
+	req := ContextRequest{TimeRange: time.Hour, IncludeLogs: true}
+
+	// Without a log repository the flag is a no-op
+	result, err := svc.BuildContext(ctx, req)
+	if err != nil {
+		t.Fatalf("BuildContext failed: %v", err)
+	}
+	if strings.Contains(result.SystemPrompt, "## Recent Logs") || len(result.Logs) != 0 {
+		t.Errorf("expected no logs without a log repository")
+	}
+
+	svc.SetLogRepository(logs)
+	result, err = svc.BuildContext(ctx, req)
+	if err != nil {
+		t.Fatalf("BuildContext failed: %v", err)
+	}
+	if len(result.Logs) != 2 {
+		t.Fatalf("expected 2 summarized logs, got %+v", result.Logs)
+	}
+	if result.Logs[0].Message != "slow query took 2.3s" || result.Logs[1].Count != 2 {
+		t.Errorf("expected newest first with repeats collapsed, got %+v", result.Logs)
+	}
+	for _, want := range []string{"## Recent Logs", "ERROR api: connection refused to db-primary (x2)", "WARNING db: slow query took 2.3s"} {
+		if !strings.Contains(result.SystemPrompt, want) {
+			t.Errorf("expected system prompt to contain %q:\n%s", want, result.SystemPrompt)
+		}
+	}
+
+	result, err = svc.BuildContext(ctx, ContextRequest{TimeRange: time.Hour})
+	if err != nil {
+		t.Fatalf("BuildContext failed: %v", err)
+	}
+	if strings.Contains(result.SystemPrompt, "## Recent Logs") {
+		t.Error("expected no log section when IncludeLogs is false")
+	}
+}
+
+func TestRAGService_BuildContextLogBudget(t *testing.T) {
+	ctx := context.Background()
+	logs := newMockLogRepository()
+	for i := 0; i < 50; i++ {
+		entry := domain.NewLogEntry(domain.LogLevelError, fmt.Sprintf("request %d failed: %s", i, strings.Repeat("x", 300)), "api", "api")
+		entry.Timestamp = time.Now().Add(-time.Duration(i) * time.Second)
+		logs.Create(ctx, entry)
+	}
+
+	// Leave room for a few log lines on top of the base prompt
+	base, err := NewRAGService(&mockMetricRepository{}, newMockTaskRepository(), &mockLogger{}, RAGConfig{}).
+		BuildContext(ctx, ContextRequest{TimeRange: time.Hour})
+	if err != nil {
+		t.Fatalf("BuildContext failed: %v", err)
+	}
+	maxTokens := base.TokenCount + 200
+
+	svc := NewRAGService(&mockMetricRepository{}, newMockTaskRepository(), &mockLogger{}, RAGConfig{MaxContextTokens: maxTokens})
+	svc.SetLogRepository(logs)
+	result, err := svc.BuildContext(ctx, ContextRequest{TimeRange: time.Hour, IncludeLogs: true})
+	if err != nil {
+		t.Fatalf("BuildContext failed: %v", err)
+	}
+	if result.TokenCount > maxTokens {
+		t.Errorf("expected context within %d tokens, got %d", maxTokens, result.TokenCount)
+	}
+	if len(result.Logs) == 0 || len(result.Logs) >= 50 {
+		t.Fatalf("expected the log section to be trimmed, got %d logs", len(result.Logs))
+	}
+	if !strings.Contains(result.SystemPrompt, fmt.Sprintf("... and %d more", 50-len(result.Logs))) {
+		t.Errorf("expected omitted logs to be noted:\n%s", result.SystemPrompt)
+	}
+	if !strings.Contains(result.SystemPrompt, "request 0 failed") {
+		t.Error("expected the most recent log to be kept")
+	}
+}
@@ -0,0 +1,347 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/ports"
+)
+
+// ScheduleKind identifies what a scheduled entry triggers.
+type ScheduleKind string
+
+const (
+	ScheduleKindCron     ScheduleKind = "cron"
+	ScheduleKindTask     ScheduleKind = "task"
+	ScheduleKindWorkflow ScheduleKind = "workflow"
+)
+
+// ScheduleStatus is the outcome of the most recent run of a schedule.
+type ScheduleStatus string
+
+const (
+	ScheduleStatusNever   ScheduleStatus = "never"
+	ScheduleStatusRunning ScheduleStatus = "running"
+	ScheduleStatusSuccess ScheduleStatus = "success"
+	ScheduleStatusFailed  ScheduleStatus = "failed"
+)
+
+// ScheduleEntry is a snapshot of a registered schedule.
+type ScheduleEntry struct {
+	Name       string         `json:"name"`
+	Kind       ScheduleKind   `json:"kind"`
+	Spec       string         `json:"spec"`
+	NextRun    time.Time      `json:"next_run"`
+	LastRun    *time.Time     `json:"last_run,omitempty"`
+	LastStatus ScheduleStatus `json:"last_status"`
+	LastError  string         `json:"last_error,omitempty"`
+	Duration   time.Duration  `json:"duration,omitempty"`
+}
+
+// CronSchedule is a parsed schedule specification.
+// It supports standard five-field cron expressions (minute hour day-of-month
+// month day-of-week) as well as the @hourly, @daily, @weekly and
+// "@every <duration>" shorthands.
+type CronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool
+	every                         time.Duration
+}
+
+// ParseCronSpec parses a cron expression or shorthand.
+func ParseCronSpec(spec string) (*CronSchedule, error) {
+	spec = strings.TrimSpace(spec)
+	switch spec {
+	case "@hourly":
+		spec = "0 * * * *"
+	case "@daily", "@midnight":
+		spec = "0 0 * * *"
+	case "@weekly":
+		spec = "0 0 * * 0"
+	case "@monthly":
+		spec = "0 0 1 * *"
+	}
+
+	if strings.HasPrefix(spec, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid @every duration: %w", err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("@every duration must be positive")
+		}
+		return &CronSchedule{every: d}, nil
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression must have 5 fields, got %d", len(fields))
+	}
+
+	s := &CronSchedule{}
+	var err error
+	if s.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("invalid minute field: %w", err)
+	}
+	if s.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("invalid hour field: %w", err)
+	}
+	if s.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("invalid day-of-month field: %w", err)
+	}
+	if s.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("invalid month field: %w", err)
+	}
+	if s.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("invalid day-of-week field: %w", err)
+	}
+	// Both 0 and 7 mean Sunday
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domStar = fields[2] == "*"
+	s.dowStar = fields[4] == "*"
+	return s, nil
+}
+
+// parseCronField parses a single comma-separated cron field into a bitset.
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if idx := strings.Index(part, "/"); idx >= 0 {
+			n, err := strconv.Atoi(part[idx+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", part[idx+1:])
+			}
+			step = n
+			part = part[:idx]
+		}
+
+		lo, hi := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("invalid range %q", part)
+			}
+			if hi, err = strconv.Atoi(bounds[1]); err != nil {
+				return 0, fmt.Errorf("invalid range %q", part)
+			}
+		default:
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q", part)
+			}
+			lo, hi = n, n
+			if step > 1 {
+				hi = max
+			}
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("value out of range [%d-%d]: %q", min, max, part)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// Next returns the first fire time strictly after the given time.
+func (c *CronSchedule) Next(after time.Time) time.Time {
+	if c.every > 0 {
+		return after.Add(c.every)
+	}
+
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	// Bound the search to five years to guard against impossible dates (e.g. Feb 30)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches applies the cron rule that when both day fields are restricted,
+// a day matches if either of them matches.
+func (c *CronSchedule) dayMatches(t time.Time) bool {
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domStar || c.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// scheduleState holds the mutable state for a registered schedule.
+type scheduleState struct {
+	entry    ScheduleEntry
+	schedule *CronSchedule
+}
+
+// ScheduleService keeps track of recurring jobs run by the daemon so that
+// operators can see what will run next and how the last run went.
+type ScheduleService struct {
+	mu        sync.RWMutex
+	schedules map[string]*scheduleState
+	logger    ports.Logger
+	now       func() time.Time
+}
+
+// NewScheduleService creates a new schedule service.
+func NewScheduleService(logger ports.Logger) *ScheduleService {
+	return &ScheduleService{
+		schedules: make(map[string]*scheduleState),
+		logger:    logger,
+		now:       time.Now,
+	}
+}
+
+// Register adds or replaces a schedule. Jobs register where they are run,
+// as MetricService.SetRetentionPolicy does for metrics.downsample, so every
+// listed entry has something behind it.
+func (s *ScheduleService) Register(name string, kind ScheduleKind, spec string) error {
+	if name == "" {
+		return fmt.Errorf("schedule name is required")
+	}
+	sched, err := ParseCronSpec(spec)
+	if err != nil {
+		return fmt.Errorf("failed to parse schedule %q: %w", name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.schedules[name] = &scheduleState{
+		entry: ScheduleEntry{
+			Name:       name,
+			Kind:       kind,
+			Spec:       spec,
+			NextRun:    sched.Next(s.now()),
+			LastStatus: ScheduleStatusNever,
+		},
+		schedule: sched,
+	}
+	s.logger.Debug("Schedule registered", "name", name, "spec", spec)
+	return nil
+}
+
+// Unregister removes a schedule.
+func (s *ScheduleService) Unregister(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.schedules, name)
+}
+
+// MarkRunning records that a scheduled run has started.
+func (s *ScheduleService) MarkRunning(name string, startedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.schedules[name]
+	if !ok {
+		return fmt.Errorf("schedule not found: %s", name)
+	}
+	st.entry.LastRun = &startedAt
+	st.entry.LastStatus = ScheduleStatusRunning
+	st.entry.LastError = ""
+	return nil
+}
+
+// RecordRun records the outcome of a scheduled run and advances the next
+// fire time past the run's start.
+func (s *ScheduleService) RecordRun(name string, startedAt time.Time, runErr error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.schedules[name]
+	if !ok {
+		return fmt.Errorf("schedule not found: %s", name)
+	}
+
+	st.entry.LastRun = &startedAt
+	st.entry.Duration = s.now().Sub(startedAt)
+	if runErr != nil {
+		st.entry.LastStatus = ScheduleStatusFailed
+		st.entry.LastError = runErr.Error()
+	} else {
+		st.entry.LastStatus = ScheduleStatusSuccess
+		st.entry.LastError = ""
+	}
+	st.entry.NextRun = st.schedule.Next(startedAt)
+	return nil
+}
+
+// SetNextRun overrides the next fire time, e.g. for a one-off startup delay.
+func (s *ScheduleService) SetNextRun(name string, next time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.schedules[name]
+	if !ok {
+		return fmt.Errorf("schedule not found: %s", name)
+	}
+	st.entry.NextRun = next
+	return nil
+}
+
+// Get returns a single schedule entry.
+func (s *ScheduleService) Get(name string) (ScheduleEntry, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	st, ok := s.schedules[name]
+	if !ok {
+		return ScheduleEntry{}, false
+	}
+	return st.entry, true
+}
+
+// List returns all schedules ordered by their next fire time.
+// Entries whose next run has already passed are advanced to the next fire
+// time after now, so the list always shows upcoming runs.
+func (s *ScheduleService) List() []ScheduleEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	entries := make([]ScheduleEntry, 0, len(s.schedules))
+	for _, st := range s.schedules {
+		if st.entry.LastStatus != ScheduleStatusRunning && st.entry.NextRun.Before(now) {
+			st.entry.NextRun = st.schedule.Next(now)
+		}
+		entries = append(entries, st.entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].NextRun.Equal(entries[j].NextRun) {
+			return entries[i].Name < entries[j].Name
+		}
+		return entries[i].NextRun.Before(entries[j].NextRun)
+	})
+	return entries
+}
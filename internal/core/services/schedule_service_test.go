@@ -0,0 +1,197 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseCronSpec_Invalid(t *testing.T) {
+	specs := []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"@every nope",
+		"@every -1m",
+	}
+	for _, spec := range specs {
+		if _, err := ParseCronSpec(spec); err == nil {
+			t.Errorf("expected error for spec %q", spec)
+		}
+	}
+}
+
+func TestCronSchedule_Next(t *testing.T) {
+	base := time.Date(2025, 3, 14, 10, 7, 30, 0, time.UTC) // Friday
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2025, 3, 14, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2025, 3, 14, 10, 15, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2025, 3, 14, 11, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2025, 3, 14, 11, 0, 0, 0, time.UTC)},
+		{"30 2 * * *", time.Date(2025, 3, 15, 2, 30, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2025, 3, 17, 9, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2025, 3, 16, 0, 0, 0, 0, time.UTC)},
+		{"5,10 10 * * *", time.Date(2025, 3, 14, 10, 10, 0, 0, time.UTC)},
+		{"@every 90s", base.Add(90 * time.Second)},
+	}
+
+	for _, tt := range tests {
+		sched, err := ParseCronSpec(tt.spec)
+		if err != nil {
+			t.Fatalf("ParseCronSpec(%q) failed: %v", tt.spec, err)
+		}
+		if got := sched.Next(base); !got.Equal(tt.want) {
+			t.Errorf("Next(%q) = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestCronSchedule_NextImpossibleDate(t *testing.T) {
+	sched, err := ParseCronSpec("0 0 30 2 *")
+	if err != nil {
+		t.Fatalf("ParseCronSpec failed: %v", err)
+	}
+	if got := sched.Next(time.Now()); !got.IsZero() {
+		t.Errorf("expected zero time for impossible date, got %v", got)
+	}
+}
+
+func newTestScheduleService(now time.Time) *ScheduleService {
+	svc := NewScheduleService(&NopLogger{})
+	svc.now = func() time.Time { return now }
+	return svc
+}
+
+func TestScheduleService_ListOrdersByNextRun(t *testing.T) {
+	now := time.Date(2025, 3, 14, 10, 7, 0, 0, time.UTC)
+	svc := newTestScheduleService(now)
+
+	if err := svc.Register("daily-report", ScheduleKindWorkflow, "0 6 * * *"); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if err := svc.Register("downsample", ScheduleKindCron, "@hourly"); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if err := svc.Register("cleanup", ScheduleKindTask, "*/5 * * * *"); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if err := svc.Register("bad", ScheduleKindCron, "not a cron"); err == nil {
+		t.Error("expected error registering invalid spec")
+	}
+
+	entries := svc.List()
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+
+	wantOrder := []string{"cleanup", "downsample", "daily-report"}
+	wantNext := []time.Time{
+		time.Date(2025, 3, 14, 10, 10, 0, 0, time.UTC),
+		time.Date(2025, 3, 14, 11, 0, 0, 0, time.UTC),
+		time.Date(2025, 3, 15, 6, 0, 0, 0, time.UTC),
+	}
+	for i, e := range entries {
+		if e.Name != wantOrder[i] {
+			t.Errorf("entry %d: expected %s, got %s", i, wantOrder[i], e.Name)
+		}
+		if !e.NextRun.Equal(wantNext[i]) {
+			t.Errorf("entry %s: expected next run %v, got %v", e.Name, wantNext[i], e.NextRun)
+		}
+		if e.LastStatus != ScheduleStatusNever {
+			t.Errorf("entry %s: expected status never, got %s", e.Name, e.LastStatus)
+		}
+		if e.LastRun != nil {
+			t.Errorf("entry %s: expected no last run", e.Name)
+		}
+	}
+}
+
+func TestScheduleService_RecordRun(t *testing.T) {
+	now := time.Date(2025, 3, 14, 11, 0, 0, 0, time.UTC)
+	svc := newTestScheduleService(now)
+
+	if err := svc.Register("downsample", ScheduleKindCron, "@hourly"); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if err := svc.Register("cleanup", ScheduleKindTask, "*/5 * * * *"); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	started := now.Add(-2 * time.Second)
+	if err := svc.MarkRunning("downsample", started); err != nil {
+		t.Fatalf("MarkRunning failed: %v", err)
+	}
+	entry, _ := svc.Get("downsample")
+	if entry.LastStatus != ScheduleStatusRunning {
+		t.Errorf("expected running status, got %s", entry.LastStatus)
+	}
+
+	if err := svc.RecordRun("downsample", started, nil); err != nil {
+		t.Fatalf("RecordRun failed: %v", err)
+	}
+	if err := svc.RecordRun("cleanup", started, errors.New("disk full")); err != nil {
+		t.Fatalf("RecordRun failed: %v", err)
+	}
+	if err := svc.RecordRun("missing", started, nil); err == nil {
+		t.Error("expected error for unknown schedule")
+	}
+
+	entry, ok := svc.Get("downsample")
+	if !ok {
+		t.Fatal("expected downsample schedule")
+	}
+	if entry.LastStatus != ScheduleStatusSuccess {
+		t.Errorf("expected success status, got %s", entry.LastStatus)
+	}
+	if entry.LastRun == nil || !entry.LastRun.Equal(started) {
+		t.Errorf("expected last run %v, got %v", started, entry.LastRun)
+	}
+	if entry.Duration != 2*time.Second {
+		t.Errorf("expected duration 2s, got %v", entry.Duration)
+	}
+	if want := time.Date(2025, 3, 14, 11, 0, 0, 0, time.UTC); !entry.NextRun.Equal(want) {
+		t.Errorf("expected next run %v, got %v", want, entry.NextRun)
+	}
+
+	entry, _ = svc.Get("cleanup")
+	if entry.LastStatus != ScheduleStatusFailed {
+		t.Errorf("expected failed status, got %s", entry.LastStatus)
+	}
+	if entry.LastError != "disk full" {
+		t.Errorf("expected last error 'disk full', got %q", entry.LastError)
+	}
+}
+
+func TestScheduleService_ListAdvancesStaleNextRun(t *testing.T) {
+	now := time.Date(2025, 3, 14, 10, 7, 0, 0, time.UTC)
+	svc := newTestScheduleService(now)
+
+	if err := svc.Register("cleanup", ScheduleKindTask, "*/5 * * * *"); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	// Simulate the clock moving on without the job running
+	svc.now = func() time.Time { return now.Add(time.Hour) }
+
+	entries := svc.List()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	if want := time.Date(2025, 3, 14, 11, 10, 0, 0, time.UTC); !entries[0].NextRun.Equal(want) {
+		t.Errorf("expected next run %v, got %v", want, entries[0].NextRun)
+	}
+
+	svc.Unregister("cleanup")
+	if len(svc.List()) != 0 {
+		t.Error("expected no entries after Unregister")
+	}
+}
@@ -0,0 +1,434 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+)
+
+// DefaultSystemCollectorInterval is how often host metrics are collected.
+const DefaultSystemCollectorInterval = 15 * time.Second
+
+// diskSectorSize is the unit of the sector counts in /proc/diskstats,
+// whatever the device's real sector size.
+const diskSectorSize = 512
+
+// MetricBatchRecorder stores metrics in one batch. MetricService and the
+// metric repositories implement it.
+type MetricBatchRecorder interface {
+	RecordBatch(ctx context.Context, metrics []*domain.Metric) error
+}
+
+// SystemCollector records the host's CPU, memory, load, disk and network
+// metrics, read from /proc on Linux, so Forge has real data without any
+// plugin installed. Rates (CPU usage, disk and network throughput) are
+// computed between consecutive collections, so they appear from the second
+// collection on.
+//
+// Metrics recorded, all gauges:
+//
+//	system.cpu.usage                    percent of CPU time not idle
+//	system.load.1, .5, .15              load averages
+//	system.memory.total, .used, .available  bytes
+//	system.memory.usage                 percent of memory used
+//	system.swap.total, .used            bytes
+//	system.disk.read_bytes, .write_bytes    bytes/s, tagged device
+//	system.disk.total, .used            bytes, tagged mount
+//	system.disk.usage                   percent, tagged mount
+//	system.network.rx_bytes, .tx_bytes  bytes/s, tagged interface
+type SystemCollector struct {
+	recorder MetricBatchRecorder
+	logger   ports.Logger
+	interval time.Duration
+	procRoot string   // Where procfs is mounted
+	mounts   []string // Filesystems whose space usage is reported
+
+	prev *systemSample
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewSystemCollector creates a collector recording into recorder every
+// interval (DefaultSystemCollectorInterval if zero).
+func NewSystemCollector(recorder MetricBatchRecorder, logger ports.Logger, interval time.Duration) *SystemCollector {
+	if interval <= 0 {
+		interval = DefaultSystemCollectorInterval
+	}
+	return &SystemCollector{
+		recorder: recorder,
+		logger:   logger,
+		interval: interval,
+		procRoot: "/proc",
+		mounts:   []string{"/"},
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start collects immediately and then every interval until ctx is
+// cancelled or Stop is called. It does nothing on systems without procfs.
+func (c *SystemCollector) Start(ctx context.Context) {
+	if runtime.GOOS != "linux" {
+		c.logger.Info("System metrics collector needs Linux /proc, not collecting", "os", runtime.GOOS)
+		return
+	}
+	c.wg.Add(1)
+	go c.run(ctx)
+}
+
+// Stop ends collection and waits for an in-flight collection to finish.
+func (c *SystemCollector) Stop() {
+	c.stopOnce.Do(func() { close(c.stopCh) })
+	c.wg.Wait()
+}
+
+func (c *SystemCollector) run(ctx context.Context) {
+	defer c.wg.Done()
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	c.collectAndLog(ctx, time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.stopCh:
+			return
+		case now := <-ticker.C:
+			c.collectAndLog(ctx, now)
+		}
+	}
+}
+
+func (c *SystemCollector) collectAndLog(ctx context.Context, now time.Time) {
+	if err := c.Collect(ctx, now); err != nil {
+		c.logger.Warn("Failed to collect system metrics", "error", err)
+	}
+}
+
+// Collect reads the current system stats and records them. Sources that
+// can't be read are skipped; an error is returned only when none could be
+// read or the metrics could not be recorded.
+func (c *SystemCollector) Collect(ctx context.Context, now time.Time) error {
+	sample, errs := c.readSample(now)
+	if sample.empty() {
+		return fmt.Errorf("no system stats readable: %v", errs)
+	}
+	for _, err := range errs {
+		c.logger.Debug("System stats source unavailable", "error", err)
+	}
+
+	metrics := sample.metrics(c.prev)
+	c.prev = sample
+	for _, m := range metrics {
+		m.Timestamp = now
+	}
+	if len(metrics) == 0 {
+		return nil
+	}
+	if err := c.recorder.RecordBatch(ctx, metrics); err != nil {
+		return fmt.Errorf("failed to record system metrics: %w", err)
+	}
+	return nil
+}
+
+// cpuTimes are the aggregate CPU times from /proc/stat, in clock ticks.
+type cpuTimes struct {
+	total uint64
+	idle  uint64 // idle and iowait
+}
+
+// ioCounters are cumulative byte counts of a disk or network interface.
+type ioCounters struct {
+	read, write uint64
+}
+
+// filesystemSpace is the size and free space of a mounted filesystem.
+type filesystemSpace struct {
+	total, free uint64
+}
+
+// systemSample is one reading of every source.
+type systemSample struct {
+	at          time.Time
+	cpu         *cpuTimes
+	load        []float64
+	memory      map[string]uint64
+	disks       map[string]ioCounters
+	network     map[string]ioCounters
+	filesystems map[string]filesystemSpace
+}
+
+func (s *systemSample) empty() bool {
+	return s.cpu == nil && s.load == nil && s.memory == nil && s.disks == nil && s.network == nil && len(s.filesystems) == 0
+}
+
+// readSample reads every source, collecting the errors of those that fail.
+func (c *SystemCollector) readSample(now time.Time) (*systemSample, []error) {
+	sample := &systemSample{at: now, filesystems: make(map[string]filesystemSpace)}
+	var errs []error
+	read := func(name string, parse func(io.Reader) error) {
+		f, err := os.Open(filepath.Join(c.procRoot, name))
+		if err != nil {
+			errs = append(errs, err)
+			return
+		}
+		defer f.Close()
+		if err := parse(f); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	read("stat", func(r io.Reader) (err error) { sample.cpu, err = parseCPUStat(r); return })
+	read("loadavg", func(r io.Reader) (err error) { sample.load, err = parseLoadAvg(r); return })
+	read("meminfo", func(r io.Reader) (err error) { sample.memory, err = parseMemInfo(r); return })
+	read("diskstats", func(r io.Reader) (err error) { sample.disks, err = parseDiskStats(r); return })
+	read("net/dev", func(r io.Reader) (err error) { sample.network, err = parseNetDev(r); return })
+	for _, mount := range c.mounts {
+		space, err := statFilesystem(mount)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", mount, err))
+			continue
+		}
+		sample.filesystems[mount] = space
+	}
+	return sample, errs
+}
+
+// metrics converts a sample to metrics, computing rates against prev when
+// it is given.
+func (s *systemSample) metrics(prev *systemSample) []*domain.Metric {
+	var metrics []*domain.Metric
+	gauge := func(name string, value float64, tags map[string]string) {
+		metrics = append(metrics, domain.NewMetric(name, domain.MetricTypeGauge, value, tags))
+	}
+
+	if s.load != nil {
+		gauge("system.load.1", s.load[0], nil)
+		gauge("system.load.5", s.load[1], nil)
+		gauge("system.load.15", s.load[2], nil)
+	}
+
+	if mem := s.memory; mem != nil {
+		total := mem["MemTotal"]
+		available, ok := mem["MemAvailable"]
+		if !ok {
+			// Kernels before 3.14 lack MemAvailable
+			available = mem["MemFree"] + mem["Buffers"] + mem["Cached"]
+		}
+		if total > 0 {
+			used := total - min(available, total)
+			gauge("system.memory.total", float64(total), nil)
+			gauge("system.memory.available", float64(available), nil)
+			gauge("system.memory.used", float64(used), nil)
+			gauge("system.memory.usage", 100*float64(used)/float64(total), nil)
+		}
+		if swap := mem["SwapTotal"]; swap > 0 {
+			gauge("system.swap.total", float64(swap), nil)
+			gauge("system.swap.used", float64(swap-min(mem["SwapFree"], swap)), nil)
+		}
+	}
+
+	mounts := make([]string, 0, len(s.filesystems))
+	for mount := range s.filesystems {
+		mounts = append(mounts, mount)
+	}
+	sort.Strings(mounts)
+	for _, mount := range mounts {
+		fs := s.filesystems[mount]
+		if fs.total == 0 {
+			continue
+		}
+		used := fs.total - min(fs.free, fs.total)
+		tags := map[string]string{"mount": mount}
+		gauge("system.disk.total", float64(fs.total), tags)
+		gauge("system.disk.used", float64(used), tags)
+		gauge("system.disk.usage", 100*float64(used)/float64(fs.total), tags)
+	}
+
+	if prev == nil {
+		return metrics
+	}
+	elapsed := s.at.Sub(prev.at).Seconds()
+	if elapsed <= 0 {
+		return metrics
+	}
+
+	if s.cpu != nil && prev.cpu != nil && s.cpu.total > prev.cpu.total && s.cpu.idle >= prev.cpu.idle {
+		total := float64(s.cpu.total - prev.cpu.total)
+		idle := float64(s.cpu.idle - prev.cpu.idle)
+		gauge("system.cpu.usage", 100*(1-min(idle/total, 1)), nil)
+	}
+	rates := func(cur, old map[string]ioCounters, tag, readName, writeName string) {
+		names := make([]string, 0, len(cur))
+		for name := range cur {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			c, o := cur[name], old[name]
+			if _, seen := old[name]; !seen || c.read < o.read || c.write < o.write {
+				continue // New device or reset counters
+			}
+			tags := map[string]string{tag: name}
+			gauge(readName, float64(c.read-o.read)/elapsed, tags)
+			gauge(writeName, float64(c.write-o.write)/elapsed, tags)
+		}
+	}
+	rates(s.disks, prev.disks, "device", "system.disk.read_bytes", "system.disk.write_bytes")
+	rates(s.network, prev.network, "interface", "system.network.rx_bytes", "system.network.tx_bytes")
+	return metrics
+}
+
+// parseCPUStat reads the aggregate "cpu" line of /proc/stat. Guest time is
+// already counted in user time, so only the first eight fields are summed.
+func parseCPUStat(r io.Reader) (*cpuTimes, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 || fields[0] != "cpu" {
+			continue
+		}
+		var times cpuTimes
+		for i, f := range fields[1:min(len(fields), 9)] {
+			v, err := strconv.ParseUint(f, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid cpu time %q", f)
+			}
+			times.total += v
+			if i == 3 || i == 4 { // idle, iowait
+				times.idle += v
+			}
+		}
+		return &times, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("no cpu line")
+}
+
+// parseLoadAvg reads the 1, 5 and 15 minute load averages.
+func parseLoadAvg(r io.Reader) ([]float64, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("expected 3 load averages, got %q", data)
+	}
+	load := make([]float64, 3)
+	for i := range load {
+		if load[i], err = strconv.ParseFloat(fields[i], 64); err != nil {
+			return nil, fmt.Errorf("invalid load average %q", fields[i])
+		}
+	}
+	return load, nil
+}
+
+// parseMemInfo reads /proc/meminfo into bytes by field name.
+func parseMemInfo(r io.Reader) (map[string]uint64, error) {
+	info := make(map[string]uint64)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		name, rest, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s value %q", name, fields[0])
+		}
+		if len(fields) > 1 && fields[1] == "kB" {
+			v *= 1024
+		}
+		info[name] = v
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if _, ok := info["MemTotal"]; !ok {
+		return nil, fmt.Errorf("no MemTotal")
+	}
+	return info, nil
+}
+
+// parseDiskStats reads bytes read and written per block device from
+// /proc/diskstats. Loop and RAM devices are skipped, as are partitions of
+// a listed device, which it already counts.
+func parseDiskStats(r io.Reader) (map[string]ioCounters, error) {
+	disks := make(map[string]ioCounters)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+		name := fields[2]
+		if strings.HasPrefix(name, "loop") || strings.HasPrefix(name, "ram") {
+			continue
+		}
+		read, err1 := strconv.ParseUint(fields[5], 10, 64)
+		written, err2 := strconv.ParseUint(fields[9], 10, 64)
+		if err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("invalid sector counts for %s", name)
+		}
+		disks[name] = ioCounters{read: read * diskSectorSize, write: written * diskSectorSize}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for name := range disks {
+		for other := range disks {
+			if other != name && strings.HasPrefix(name, other) {
+				delete(disks, name)
+				break
+			}
+		}
+	}
+	return disks, nil
+}
+
+// parseNetDev reads bytes received and sent per interface from
+// /proc/net/dev, skipping the loopback interface.
+func parseNetDev(r io.Reader) (map[string]ioCounters, error) {
+	ifaces := make(map[string]ioCounters)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		name, rest, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue // Header lines
+		}
+		name = strings.TrimSpace(name)
+		fields := strings.Fields(rest)
+		if name == "lo" || len(fields) < 9 {
+			continue
+		}
+		rx, err1 := strconv.ParseUint(fields[0], 10, 64)
+		tx, err2 := strconv.ParseUint(fields[8], 10, 64)
+		if err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("invalid byte counts for %s", name)
+		}
+		ifaces[name] = ioCounters{read: rx, write: tx}
+	}
+	return ifaces, scanner.Err()
+}
@@ -0,0 +1,15 @@
+//go:build linux
+
+package services
+
+import "syscall"
+
+// statFilesystem returns the size and the space available to unprivileged
+// users of the filesystem mounted at path.
+func statFilesystem(path string) (filesystemSpace, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return filesystemSpace{}, err
+	}
+	return filesystemSpace{total: st.Blocks * uint64(st.Bsize), free: st.Bavail * uint64(st.Bsize)}, nil
+}
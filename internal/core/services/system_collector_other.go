@@ -0,0 +1,10 @@
+//go:build !linux
+
+package services
+
+import "errors"
+
+// statFilesystem is only implemented on Linux, where the collector runs.
+func statFilesystem(path string) (filesystemSpace, error) {
+	return filesystemSpace{}, errors.New("filesystem stats unsupported on this platform")
+}
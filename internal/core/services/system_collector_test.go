@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+)
+
+const sampleProcStat = `cpu  4705 356 584 3699 23 23 0 0 0 0
+cpu0 1393 80 290 1831 10 19 0 0 0 0
+cpu1 3312 276 294 1868 13 4 0 0 0 0
+intr 114930548 113199788 3 0 5 263 0 4 [... lots more numbers ...]
+ctxt 1990473
+btime 1062191376
+processes 2915
+procs_running 1
+procs_blocked 0
+`
+
+const sampleMemInfo = `MemTotal:       16384000 kB
+MemFree:         2048000 kB
+MemAvailable:    4096000 kB
+Buffers:          512000 kB
+Cached:          3072000 kB
+SwapCached:            0 kB
+SwapTotal:       2000000 kB
+SwapFree:        1500000 kB
+HugePages_Total:       0
+Hugepagesize:       2048 kB
+`
+
+const sampleDiskStats = `   7       0 loop0 52 0 2100 12 0 0 0 0 0 20 12 0 0 0 0 0 0
+ 259       0 nvme0n1 1000 10 20000 500 2000 20 40000 900 0 800 1400 0 0 0 0 0 0
+ 259       1 nvme0n1p1 900 10 18000 450 1900 20 38000 850 0 750 1300 0 0 0 0 0 0
+   8       0 sda 10 0 80 5 0 0 0 0 0 5 5 0 0 0 0 0 0
+`
+
+const sampleNetDev = `Inter-|   Receive                                                |  Transmit
+ face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed
+    lo: 1000000    1000    0    0    0     0          0         0  1000000    1000    0    0    0     0       0          0
+  eth0: 5000000    4000    0    0    0     0          0         0  2000000    3000    0    0    0     0       0          0
+`
+
+func TestParseCPUStat(t *testing.T) {
+	times, err := parseCPUStat(strings.NewReader(sampleProcStat))
+	if err != nil {
+		t.Fatalf("parseCPUStat failed: %v", err)
+	}
+	if times.total != 4705+356+584+3699+23+23 {
+		t.Errorf("expected total of the aggregate line, got %d", times.total)
+	}
+	if times.idle != 3699+23 {
+		t.Errorf("expected idle plus iowait, got %d", times.idle)
+	}
+
+	if _, err := parseCPUStat(strings.NewReader("intr 1 2 3\n")); err == nil {
+		t.Error("expected an error without a cpu line")
+	}
+	if _, err := parseCPUStat(strings.NewReader("cpu 1 2 x 4\n")); err == nil {
+		t.Error("expected an error for a malformed cpu line")
+	}
+}
+
+func TestParseMemInfo(t *testing.T) {
+	info, err := parseMemInfo(strings.NewReader(sampleMemInfo))
+	if err != nil {
+		t.Fatalf("parseMemInfo failed: %v", err)
+	}
+	if info["MemTotal"] != 16384000*1024 || info["MemAvailable"] != 4096000*1024 {
+		t.Errorf("expected kB values converted to bytes, got %d and %d", info["MemTotal"], info["MemAvailable"])
+	}
+	if info["HugePages_Total"] != 0 {
+		t.Errorf("expected unitless values kept, got %d", info["HugePages_Total"])
+	}
+
+	if _, err := parseMemInfo(strings.NewReader("MemFree: 10 kB\n")); err == nil {
+		t.Error("expected an error without MemTotal")
+	}
+}
+
+func TestParseLoadAvg(t *testing.T) {
+	load, err := parseLoadAvg(strings.NewReader("0.52 1.25 2.00 2/345 6789\n"))
+	if err != nil {
+		t.Fatalf("parseLoadAvg failed: %v", err)
+	}
+	if load[0] != 0.52 || load[1] != 1.25 || load[2] != 2 {
+		t.Errorf("unexpected load averages %v", load)
+	}
+}
+
+func TestParseDiskStatsAndNetDev(t *testing.T) {
+	disks, err := parseDiskStats(strings.NewReader(sampleDiskStats))
+	if err != nil {
+		t.Fatalf("parseDiskStats failed: %v", err)
+	}
+	if len(disks) != 2 {
+		t.Fatalf("expected loop devices and partitions skipped, got %v", disks)
+	}
+	if d := disks["nvme0n1"]; d.read != 20000*512 || d.write != 40000*512 {
+		t.Errorf("expected sectors converted to bytes, got %+v", d)
+	}
+
+	ifaces, err := parseNetDev(strings.NewReader(sampleNetDev))
+	if err != nil {
+		t.Fatalf("parseNetDev failed: %v", err)
+	}
+	if len(ifaces) != 1 || ifaces["eth0"] != (ioCounters{read: 5000000, write: 2000000}) {
+		t.Errorf("expected only eth0 with its byte counts, got %v", ifaces)
+	}
+}
+
+// writeProc writes procfs files under root.
+func writeProc(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSystemCollector_Collect(t *testing.T) {
+	root := t.TempDir()
+	repo := &mockMetricRepository{}
+	collector := NewSystemCollector(repo, &NopLogger{}, 0)
+	collector.procRoot = root
+	collector.mounts = nil
+	ctx := context.Background()
+
+	writeProc(t, root, map[string]string{
+		"stat":      "cpu  100 0 100 800 0 0 0 0 0 0\n",
+		"meminfo":   sampleMemInfo,
+		"loadavg":   "0.50 0.40 0.30 1/100 42\n",
+		"diskstats": sampleDiskStats,
+		"net/dev":   sampleNetDev,
+	})
+	start := time.Now()
+	if err := collector.Collect(ctx, start); err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+	first := metricsByName(repo.metrics)
+	if _, ok := first["system.cpu.usage"]; ok {
+		t.Error("expected no rates from the first collection")
+	}
+	if m := first["system.memory.usage"]; m == nil || math.Abs(m.Value-75) > 1e-9 {
+		t.Errorf("expected 75%% memory usage, got %v", m)
+	}
+	if m := first["system.swap.used"]; m == nil || m.Value != 500000*1024 {
+		t.Errorf("expected swap used in bytes, got %v", m)
+	}
+
+	// 10s later: 100 of 200 ticks busy, 1 MB read from nvme0n1, 500 kB received
+	writeProc(t, root, map[string]string{
+		"stat":      "cpu  150 0 150 900 0 0 0 0 0 0\n",
+		"diskstats": strings.Replace(sampleDiskStats, "nvme0n1 1000 10 20000", "nvme0n1 1000 10 40000", 1),
+		"net/dev":   strings.Replace(sampleNetDev, "eth0: 5000000", "eth0: 5500000", 1),
+	})
+	repo.metrics = nil
+	if err := collector.Collect(ctx, start.Add(10*time.Second)); err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+	second := metricsByName(repo.metrics)
+	if m := second["system.cpu.usage"]; m == nil || m.Value != 50 {
+		t.Errorf("expected 50%% CPU usage, got %v", m)
+	}
+	if m := findMetric(repo.metrics, "system.disk.read_bytes", "device", "nvme0n1"); m == nil || m.Value != 20000*512/10 {
+		t.Errorf("expected nvme0n1 read rate, got %v", m)
+	}
+	if m := findMetric(repo.metrics, "system.disk.read_bytes", "device", "sda"); m == nil || m.Value != 0 {
+		t.Errorf("expected an idle sda to report a zero rate, got %v", m)
+	}
+	if m := findMetric(repo.metrics, "system.network.rx_bytes", "interface", "eth0"); m == nil || m.Value != 50000 {
+		t.Errorf("expected eth0 receive rate, got %v", m)
+	}
+	if m := second["system.load.1"]; m == nil || !m.Timestamp.Equal(start.Add(10*time.Second)) {
+		t.Errorf("expected metrics stamped with the collection time, got %v", m)
+	}
+
+	empty := NewSystemCollector(repo, &NopLogger{}, 0)
+	empty.procRoot = t.TempDir()
+	empty.mounts = nil
+	if err := empty.Collect(ctx, start); err == nil {
+		t.Error("expected an error when no source is readable")
+	}
+}
+
+// findMetric returns the metric with name and the tag key=value.
+func findMetric(metrics []*domain.Metric, name, key, value string) *domain.Metric {
+	for _, m := range metrics {
+		if m.Name == name && m.Tags[key] == value {
+			return m
+		}
+	}
+	return nil
+}
+
+// metricsByName indexes untagged metrics by name.
+func metricsByName(metrics []*domain.Metric) map[string]*domain.Metric {
+	byName := make(map[string]*domain.Metric)
+	for _, m := range metrics {
+		if len(m.Tags) == 0 {
+			byName[m.Name] = m
+		}
+	}
+	return byName
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/forge-platform/forge/internal/core/domain"
@@ -12,6 +13,12 @@ import (
 	"github.com/google/uuid"
 )
 
+// Page sizes for task listings.
+const (
+	DefaultTaskListLimit = 50
+	MaxTaskListLimit     = 1000
+)
+
 // TaskService handles task queue operations.
 type TaskService struct {
 	repo       ports.TaskRepository
@@ -20,6 +27,17 @@ type TaskService struct {
 	handlersMu sync.RWMutex
 	workerWg   sync.WaitGroup
 	stopCh     chan struct{}
+
+	// Task runs since the service was created
+	completed atomic.Uint64
+	failed    atomic.Uint64
+}
+
+// TaskStats counts the tasks workers have run. Failed counts attempts, so
+// a task that is retried counts once per failure.
+type TaskStats struct {
+	Completed uint64
+	Failed    uint64
 }
 
 // TaskHandler is a function that processes a task.
@@ -44,13 +62,27 @@ func (s *TaskService) RegisterHandler(taskType domain.TaskType, handler TaskHand
 
 // CreateTask creates a new task in the queue.
 func (s *TaskService) CreateTask(ctx context.Context, taskType domain.TaskType, payload map[string]interface{}) (*domain.Task, error) {
+	return s.CreateTaskWithPriority(ctx, taskType, payload, 0)
+}
+
+// CreateTaskWithPriority creates a new task that workers claim ahead of
+// pending tasks with a lower priority.
+func (s *TaskService) CreateTaskWithPriority(ctx context.Context, taskType domain.TaskType, payload map[string]interface{}, priority int) (*domain.Task, error) {
+	if taskType == "" {
+		return nil, fmt.Errorf("task type is required")
+	}
+	if payload == nil {
+		payload = make(map[string]interface{})
+	}
+
 	task := domain.NewTask(taskType, payload)
+	task.Priority = priority
 
 	if err := s.repo.Create(ctx, task); err != nil {
 		return nil, fmt.Errorf("failed to create task: %w", err)
 	}
 
-	s.logger.Info("Task created", "id", task.ID, "type", taskType)
+	s.logger.Info("Task created", "id", task.ID, "type", taskType, "priority", priority)
 	return task, nil
 }
 
@@ -59,9 +91,25 @@ func (s *TaskService) GetTask(ctx context.Context, id uuid.UUID) (*domain.Task,
 	return s.repo.GetByID(ctx, id)
 }
 
-// ListTasks lists tasks with optional filtering.
+// ListTasks lists tasks with optional filtering, in the order workers claim
+// them: highest priority first, then earliest run time. A zero limit
+// returns DefaultTaskListLimit tasks.
 func (s *TaskService) ListTasks(ctx context.Context, filter ports.TaskFilter) ([]*domain.Task, error) {
-	return s.repo.List(ctx, filter)
+	if filter.Limit < 0 || filter.Offset < 0 {
+		return nil, fmt.Errorf("limit and offset must not be negative")
+	}
+	if filter.Limit == 0 {
+		filter.Limit = DefaultTaskListLimit
+	}
+	if filter.Limit > MaxTaskListLimit {
+		filter.Limit = MaxTaskListLimit
+	}
+
+	tasks, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+	return tasks, nil
 }
 
 // CancelTask cancels a pending task.
@@ -143,6 +191,7 @@ func (s *TaskService) processNextTask(ctx context.Context) {
 	if !ok {
 		s.logger.Error("No handler for task type", "type", task.Type)
 		task.MarkFailed(fmt.Errorf("no handler for task type: %s", task.Type))
+		s.failed.Add(1)
 		_ = s.repo.Update(ctx, task)
 		return
 	}
@@ -151,14 +200,21 @@ func (s *TaskService) processNextTask(ctx context.Context) {
 	if err := handler(ctx, task); err != nil {
 		s.logger.Error("Task failed", "id", task.ID, "error", err)
 		task.MarkFailed(err)
+		s.failed.Add(1)
 	} else {
 		s.logger.Info("Task completed", "id", task.ID)
 		task.MarkCompleted()
+		s.completed.Add(1)
 	}
 
 	_ = s.repo.Update(ctx, task)
 }
 
+// Stats returns the number of task runs that completed and failed so far.
+func (s *TaskService) Stats() TaskStats {
+	return TaskStats{Completed: s.completed.Load(), Failed: s.failed.Load()}
+}
+
 // releaseExpiredLocks periodically releases expired task locks.
 func (s *TaskService) releaseExpiredLocks(ctx context.Context) {
 	ticker := time.NewTicker(30 * time.Second)
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"errors"
+	"sort"
 	"testing"
 	"time"
 
@@ -17,6 +18,7 @@ type mockTaskRepository struct {
 	createError error
 	getError    error
 	claimError  error
+	lastFilter  ports.TaskFilter
 }
 
 func newMockTaskRepository() *mockTaskRepository {
@@ -54,11 +56,32 @@ func (m *mockTaskRepository) Delete(_ context.Context, id uuid.UUID) error {
 	return nil
 }
 
-func (m *mockTaskRepository) List(_ context.Context, _ ports.TaskFilter) ([]*domain.Task, error) {
+// List filters, orders and pages tasks like the SQLite repository.
+func (m *mockTaskRepository) List(_ context.Context, filter ports.TaskFilter) ([]*domain.Task, error) {
+	m.lastFilter = filter
 	result := make([]*domain.Task, 0, len(m.tasks))
 	for _, task := range m.tasks {
+		if filter.Status != nil && task.Status != *filter.Status {
+			continue
+		}
+		if filter.Type != nil && task.Type != *filter.Type {
+			continue
+		}
 		result = append(result, task)
 	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Priority != result[j].Priority {
+			return result[i].Priority > result[j].Priority
+		}
+		return result[i].RunAt.Before(result[j].RunAt)
+	})
+	if filter.Offset >= len(result) {
+		return nil, nil
+	}
+	result = result[filter.Offset:]
+	if filter.Limit > 0 && filter.Limit < len(result) {
+		result = result[:filter.Limit]
+	}
 	return result, nil
 }
 
@@ -164,3 +187,129 @@ func TestTaskService_ListTasks(t *testing.T) {
 	}
 }
 
+func TestTaskService_CreateTaskWithPriority(t *testing.T) {
+	repo := newMockTaskRepository()
+	svc := NewTaskService(repo, &mockLogger{})
+
+	task, err := svc.CreateTaskWithPriority(context.Background(), domain.TaskTypeMaintenance, nil, 7)
+	if err != nil {
+		t.Fatalf("CreateTaskWithPriority error: %v", err)
+	}
+	if task.Priority != 7 || repo.tasks[task.ID].Priority != 7 {
+		t.Errorf("Priority = %d, want 7", task.Priority)
+	}
+	if task.Payload == nil {
+		t.Error("expected nil payload to be replaced by an empty map")
+	}
+
+	if _, err := svc.CreateTaskWithPriority(context.Background(), "", nil, 0); err == nil {
+		t.Error("expected error for empty task type")
+	}
+}
+
+func TestTaskService_ListTasksFilterAndOrder(t *testing.T) {
+	repo := newMockTaskRepository()
+	svc := NewTaskService(repo, &mockLogger{})
+	ctx := context.Background()
+
+	base := time.Now()
+	add := func(taskType domain.TaskType, priority int, runAt time.Duration, status domain.TaskStatus) *domain.Task {
+		task, err := svc.CreateTaskWithPriority(ctx, taskType, nil, priority)
+		if err != nil {
+			t.Fatalf("CreateTaskWithPriority error: %v", err)
+		}
+		task.RunAt = base.Add(runAt)
+		task.Status = status
+		return task
+	}
+	late := add(domain.TaskTypeMetricIngest, 0, 2*time.Minute, domain.TaskStatusPending)
+	early := add(domain.TaskTypeMetricIngest, 0, time.Minute, domain.TaskStatusPending)
+	urgent := add(domain.TaskTypeAIAnalysis, 5, 3*time.Minute, domain.TaskStatusPending)
+	done := add(domain.TaskTypeMetricIngest, 9, 0, domain.TaskStatusCompleted)
+
+	ids := func(tasks []*domain.Task) []uuid.UUID {
+		out := make([]uuid.UUID, len(tasks))
+		for i, task := range tasks {
+			out[i] = task.ID
+		}
+		return out
+	}
+	pending := domain.TaskStatusPending
+	ingest := domain.TaskTypeMetricIngest
+
+	tests := []struct {
+		name   string
+		filter ports.TaskFilter
+		want   []uuid.UUID
+	}{
+		{"all by priority then run time", ports.TaskFilter{}, []uuid.UUID{done.ID, urgent.ID, early.ID, late.ID}},
+		{"status", ports.TaskFilter{Status: &pending}, []uuid.UUID{urgent.ID, early.ID, late.ID}},
+		{"status and type", ports.TaskFilter{Status: &pending, Type: &ingest}, []uuid.UUID{early.ID, late.ID}},
+		{"limit and offset", ports.TaskFilter{Limit: 2, Offset: 1}, []uuid.UUID{urgent.ID, early.ID}},
+		{"offset past end", ports.TaskFilter{Offset: 10}, []uuid.UUID{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tasks, err := svc.ListTasks(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("ListTasks error: %v", err)
+			}
+			got := ids(tasks)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d tasks, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("task %d = %s, want %s", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTaskService_ListTasksLimits(t *testing.T) {
+	repo := newMockTaskRepository()
+	svc := NewTaskService(repo, &mockLogger{})
+	ctx := context.Background()
+
+	if _, err := svc.ListTasks(ctx, ports.TaskFilter{}); err != nil {
+		t.Fatalf("ListTasks error: %v", err)
+	}
+	if repo.lastFilter.Limit != DefaultTaskListLimit {
+		t.Errorf("Limit = %d, want default %d", repo.lastFilter.Limit, DefaultTaskListLimit)
+	}
+
+	if _, err := svc.ListTasks(ctx, ports.TaskFilter{Limit: MaxTaskListLimit + 1}); err != nil {
+		t.Fatalf("ListTasks error: %v", err)
+	}
+	if repo.lastFilter.Limit != MaxTaskListLimit {
+		t.Errorf("Limit = %d, want capped %d", repo.lastFilter.Limit, MaxTaskListLimit)
+	}
+
+	if _, err := svc.ListTasks(ctx, ports.TaskFilter{Offset: -1}); err == nil {
+		t.Error("expected error for negative offset")
+	}
+}
+
+func TestTaskService_Stats(t *testing.T) {
+	repo := newMockTaskRepository()
+	svc := NewTaskService(repo, &mockLogger{})
+	ctx := context.Background()
+
+	svc.RegisterHandler("ok", func(ctx context.Context, task *domain.Task) error { return nil })
+	svc.RegisterHandler("bad", func(ctx context.Context, task *domain.Task) error { return errors.New("boom") })
+	for _, taskType := range []domain.TaskType{"ok", "ok", "bad", "unhandled"} {
+		if _, err := svc.CreateTask(ctx, taskType, nil); err != nil {
+			t.Fatalf("CreateTask error: %v", err)
+		}
+	}
+	// The mock ignores retry backoff, so failing tasks run until they are dead
+	for i := 0; i < 20; i++ {
+		svc.processNextTask(ctx)
+	}
+
+	// Every failed attempt counts, including those that were retried
+	if stats := svc.Stats(); stats.Completed != 2 || stats.Failed != 6 {
+		t.Errorf("Stats = %+v, want 2 completed and 6 failed", stats)
+	}
+}
@@ -0,0 +1,464 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/forge-platform/forge/internal/core/ports"
+)
+
+// MetricTracesSampled counts sampling decisions, tagged with the decision
+// (kept or dropped) and its reason (error, latency or rate).
+const MetricTracesSampled = "forge.traces.sampled"
+
+// Sampling decision reasons.
+const (
+	sampleReasonError   = "error"
+	sampleReasonLatency = "latency"
+	sampleReasonRate    = "rate"
+)
+
+const (
+	// traceJanitorTick is how often pending traces are checked for a
+	// decision and sampling counts are reported.
+	traceJanitorTick = time.Second
+
+	// traceDecisionTTL is how long a decision is remembered, so spans
+	// arriving after it follow the rest of their trace.
+	traceDecisionTTL = 10 * time.Minute
+
+	// tracePendingSpanLimit caps the spans held back waiting for a
+	// decision. Beyond it the oldest traces are decided early.
+	tracePendingSpanLimit = 50000
+)
+
+// TraceConfig controls which imported traces are stored and for how long.
+//
+// With a SampleRate below 1, spans are held back until their trace is
+// complete (its root span has arrived) or DecisionWait has passed since its
+// first span, then the whole trace is kept or dropped: traces with an error
+// (when KeepErrors is set) or lasting at least LatencyThreshold are always
+// kept, others are kept at SampleRate. Every Retention interval, traces
+// older than Retention are deleted.
+type TraceConfig struct {
+	SampleRate        float64       `json:"sample_rate"`        // Fraction of ordinary traces kept, 0 to 1
+	KeepErrors        bool          `json:"keep_errors"`        // Always keep traces with an error span
+	LatencyThreshold  time.Duration `json:"latency_threshold"`  // Always keep traces at least this long (0 disables)
+	DecisionWait      time.Duration `json:"decision_wait"`      // Longest a trace waits for its root span
+	Retention         time.Duration `json:"retention"`          // Age at which traces are deleted (0 keeps them)
+	RetentionInterval time.Duration `json:"retention_interval"` // How often old traces are deleted
+}
+
+// DefaultTraceConfig keeps every trace for 7 days, enforced hourly.
+func DefaultTraceConfig() TraceConfig {
+	return TraceConfig{
+		SampleRate:        1,
+		KeepErrors:        true,
+		DecisionWait:      30 * time.Second,
+		Retention:         7 * 24 * time.Hour,
+		RetentionInterval: time.Hour,
+	}
+}
+
+// Validate checks the configuration's rate and durations.
+func (c TraceConfig) Validate() error {
+	if math.IsNaN(c.SampleRate) || c.SampleRate < 0 || c.SampleRate > 1 {
+		return fmt.Errorf("sample rate must be between 0 and 1, got %v", c.SampleRate)
+	}
+	if c.LatencyThreshold < 0 || c.Retention < 0 {
+		return fmt.Errorf("latency threshold and retention must not be negative")
+	}
+	if c.DecisionWait <= 0 || c.RetentionInterval <= 0 {
+		return fmt.Errorf("decision wait and retention interval must be positive")
+	}
+	return nil
+}
+
+// Sampling reports whether traces are sampled rather than all kept.
+func (c TraceConfig) Sampling() bool {
+	return c.SampleRate < 1
+}
+
+// decide returns whether a finished trace is kept and why.
+func (c TraceConfig) decide(trace *domain.Trace) (bool, string) {
+	if c.KeepErrors && trace.ErrorCount > 0 {
+		return true, sampleReasonError
+	}
+	if c.LatencyThreshold > 0 && trace.Duration >= c.LatencyThreshold {
+		return true, sampleReasonLatency
+	}
+	return sampledByRate(trace.TraceID, c.SampleRate), sampleReasonRate
+}
+
+// sampledByRate makes the rate decision from the trace ID alone, so every
+// batch of a trace gets the same answer.
+func sampledByRate(traceID domain.TraceID, rate float64) bool {
+	if rate >= 1 {
+		return true
+	}
+	if rate <= 0 {
+		return false
+	}
+	h := fnv.New64a()
+	h.Write(traceID[:])
+	return float64(h.Sum64()) < rate*float64(math.MaxUint64)
+}
+
+// pendingTrace is a trace whose spans are held back until it is decided.
+type pendingTrace struct {
+	spans     []*domain.Span
+	firstSeen time.Time
+}
+
+// traceDecision is a remembered sampling decision.
+type traceDecision struct {
+	keep bool
+	at   time.Time
+}
+
+// sampleKey identifies a sampling count by decision and reason.
+type sampleKey struct {
+	kept   bool
+	reason string
+}
+
+// TraceSamplingStats summarizes sampling decisions since the daemon started.
+type TraceSamplingStats struct {
+	Kept          int64 // Traces stored
+	Dropped       int64 // Traces discarded
+	PendingTraces int   // Traces waiting for a decision
+	PendingSpans  int
+}
+
+// EffectiveRate is the fraction of decided traces that were kept, which
+// exceeds the configured rate by the traces always kept. It is rate when
+// nothing has been decided yet.
+func (st TraceSamplingStats) EffectiveRate(rate float64) float64 {
+	if st.Kept+st.Dropped == 0 {
+		return rate
+	}
+	return float64(st.Kept) / float64(st.Kept+st.Dropped)
+}
+
+// TraceCountEstimate is how many traces started in a time range before
+// sampling, estimated from those stored.
+type TraceCountEstimate struct {
+	Stored     int64 // Traces stored
+	AlwaysKept int64 // Stored traces kept for an error or their latency
+	Estimated  int64 // Traces before sampling, valid only when Known
+	Known      bool  // False at a sample rate of 0, when nothing stored stands for the dropped traces
+}
+
+// EstimateTraceCount estimates how many traces started between startTime
+// and endTime, counting each stored trace kept by the sample rate as
+// 1/SampleRate traces and each always-kept trace as one. It assumes the
+// current configuration applied throughout the range.
+func (s *TraceService) EstimateTraceCount(ctx context.Context, startTime, endTime time.Time) (TraceCountEstimate, error) {
+	if s.traceRepo == nil {
+		return TraceCountEstimate{}, fmt.Errorf("trace repository not configured")
+	}
+	cfg := s.Config()
+	count := func(filter ports.TraceFilter) (int64, error) {
+		filter.StartTime, filter.EndTime = startTime, endTime
+		return s.traceRepo.Count(ctx, filter)
+	}
+
+	stored, err := count(ports.TraceFilter{})
+	if err != nil {
+		return TraceCountEstimate{}, err
+	}
+	est := TraceCountEstimate{Stored: stored, Estimated: stored, Known: true}
+	if !cfg.Sampling() {
+		return est, nil
+	}
+
+	// Always-kept traces are the errors plus the slow ones, less those
+	// that are both
+	errorStatus := string(domain.SpanStatusError)
+	var filters []ports.TraceFilter
+	var signs []int64
+	if cfg.KeepErrors {
+		filters, signs = append(filters, ports.TraceFilter{Status: errorStatus}), append(signs, 1)
+	}
+	if cfg.LatencyThreshold > 0 {
+		filters, signs = append(filters, ports.TraceFilter{MinDuration: cfg.LatencyThreshold}), append(signs, 1)
+		if cfg.KeepErrors {
+			filters = append(filters, ports.TraceFilter{Status: errorStatus, MinDuration: cfg.LatencyThreshold})
+			signs = append(signs, -1)
+		}
+	}
+	for i, filter := range filters {
+		n, err := count(filter)
+		if err != nil {
+			return TraceCountEstimate{}, err
+		}
+		est.AlwaysKept += signs[i] * n
+	}
+
+	if cfg.SampleRate <= 0 {
+		est.Known = false
+		return est, nil
+	}
+	sampled := float64(stored - est.AlwaysKept)
+	est.Estimated = est.AlwaysKept + int64(math.Round(sampled/cfg.SampleRate))
+	return est, nil
+}
+
+// holdSpans buffers spans of undecided traces. It returns the spans to
+// store now, which belong to traces already kept, and the traces ready for
+// a decision because their root span arrived or too many spans are held.
+// Spans of traces already dropped are discarded.
+func (s *TraceService) holdSpans(spans []*domain.Span, now time.Time) (store []*domain.Span, ready []domain.TraceID) {
+	s.samplingMu.Lock()
+	defer s.samplingMu.Unlock()
+
+	readySet := make(map[domain.TraceID]bool)
+	for _, span := range spans {
+		if d, ok := s.decided[span.TraceID]; ok {
+			if d.keep {
+				store = append(store, span)
+			}
+			continue
+		}
+		p, ok := s.pending[span.TraceID]
+		if !ok {
+			p = &pendingTrace{firstSeen: now}
+			s.pending[span.TraceID] = p
+		}
+		p.spans = append(p.spans, span)
+		s.pendingSpans++
+		if span.ParentSpanID == nil && !readySet[span.TraceID] {
+			readySet[span.TraceID] = true
+			ready = append(ready, span.TraceID)
+		}
+	}
+
+	// Decide the longest-waiting traces early rather than grow without bound
+	if s.pendingSpans > tracePendingSpanLimit {
+		oldest := make([]domain.TraceID, 0, len(s.pending))
+		for id := range s.pending {
+			if !readySet[id] {
+				oldest = append(oldest, id)
+			}
+		}
+		sort.Slice(oldest, func(i, j int) bool {
+			return s.pending[oldest[i]].firstSeen.Before(s.pending[oldest[j]].firstSeen)
+		})
+		held := s.pendingSpans
+		for _, id := range ready {
+			held -= len(s.pending[id].spans)
+		}
+		for _, id := range oldest {
+			if held <= tracePendingSpanLimit {
+				break
+			}
+			held -= len(s.pending[id].spans)
+			ready = append(ready, id)
+		}
+	}
+	return store, ready
+}
+
+// pendingTraceIDs returns every trace waiting for a decision.
+func (s *TraceService) pendingTraceIDs() []domain.TraceID {
+	s.samplingMu.Lock()
+	defer s.samplingMu.Unlock()
+
+	ids := make([]domain.TraceID, 0, len(s.pending))
+	for id := range s.pending {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// expiredTraces returns the pending traces that have waited at least wait,
+// and forgets decisions older than traceDecisionTTL.
+func (s *TraceService) expiredTraces(now time.Time, wait time.Duration) []domain.TraceID {
+	s.samplingMu.Lock()
+	defer s.samplingMu.Unlock()
+
+	var expired []domain.TraceID
+	for id, p := range s.pending {
+		if now.Sub(p.firstSeen) >= wait {
+			expired = append(expired, id)
+		}
+	}
+	for id, d := range s.decided {
+		if now.Sub(d.at) >= traceDecisionTTL {
+			delete(s.decided, id)
+		}
+	}
+	return expired
+}
+
+// decidePending makes the sampling decision for pending traces and returns
+// the spans of the ones kept.
+func (s *TraceService) decidePending(ids []domain.TraceID, now time.Time) []*domain.Span {
+	cfg := s.Config()
+
+	s.samplingMu.Lock()
+	defer s.samplingMu.Unlock()
+
+	var store []*domain.Span
+	for _, id := range ids {
+		p, ok := s.pending[id]
+		if !ok {
+			continue
+		}
+		delete(s.pending, id)
+		s.pendingSpans -= len(p.spans)
+
+		keep, reason := cfg.decide(domain.NewTraceFromSpans(id, p.spans))
+		s.decided[id] = traceDecision{keep: keep, at: now}
+		s.countDecisionLocked(keep, reason)
+		if keep {
+			store = append(store, p.spans...)
+		}
+	}
+	return store
+}
+
+// countDecision records a sampling decision made outside decidePending.
+func (s *TraceService) countDecision(keep bool, reason string) {
+	s.samplingMu.Lock()
+	s.countDecisionLocked(keep, reason)
+	s.samplingMu.Unlock()
+}
+
+func (s *TraceService) countDecisionLocked(keep bool, reason string) {
+	key := sampleKey{kept: keep, reason: reason}
+	s.sampleCounts[key]++
+	s.unreported[key]++
+}
+
+// reportSampling records the decisions made since the last report as
+// MetricTracesSampled counters.
+func (s *TraceService) reportSampling(ctx context.Context) {
+	s.samplingMu.Lock()
+	unreported := s.unreported
+	s.unreported = make(map[sampleKey]int64)
+	s.samplingMu.Unlock()
+
+	if s.metrics == nil {
+		return
+	}
+	for key, n := range unreported {
+		decision := "dropped"
+		if key.kept {
+			decision = "kept"
+		}
+		tags := map[string]string{"decision": decision, "reason": key.reason}
+		if err := s.metrics.Record(ctx, MetricTracesSampled, domain.MetricTypeCounter, float64(n), tags); err != nil {
+			s.logger.Debug("failed to record sampling metric", "error", err)
+		}
+	}
+}
+
+// SamplingStats returns the sampling decisions made since the service
+// started and what is still waiting for one.
+func (s *TraceService) SamplingStats() TraceSamplingStats {
+	s.samplingMu.Lock()
+	defer s.samplingMu.Unlock()
+
+	stats := TraceSamplingStats{PendingTraces: len(s.pending), PendingSpans: s.pendingSpans}
+	for key, n := range s.sampleCounts {
+		if key.kept {
+			stats.Kept += n
+		} else {
+			stats.Dropped += n
+		}
+	}
+	return stats
+}
+
+// SetConfig validates and applies a new sampling and retention
+// configuration. It takes effect for traces decided from then on.
+func (s *TraceService) SetConfig(cfg TraceConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	s.configMu.Lock()
+	s.config = cfg
+	s.configMu.Unlock()
+	return nil
+}
+
+// Config returns the sampling and retention configuration.
+func (s *TraceService) Config() TraceConfig {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	return s.config
+}
+
+// ApplyRetention deletes traces, and their spans, that started more than
+// the configured retention ago. It does nothing when retention is off.
+func (s *TraceService) ApplyRetention(ctx context.Context) (int64, error) {
+	cfg := s.Config()
+	if cfg.Retention <= 0 || s.traceRepo == nil {
+		return 0, nil
+	}
+	deleted, err := s.traceRepo.DeleteBefore(ctx, time.Now().Add(-cfg.Retention))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old traces: %w", err)
+	}
+	if deleted > 0 {
+		s.logger.Info("deleted traces past retention", "count", deleted, "retention", cfg.Retention)
+	}
+	return deleted, nil
+}
+
+// Start runs the janitor, which decides traces that stopped waiting for
+// their root span, reports sampling counts and enforces retention, until
+// ctx is cancelled or Stop is called.
+func (s *TraceService) Start(ctx context.Context) {
+	s.janitorWG.Add(1)
+	go s.janitor(ctx)
+}
+
+// Stop ends the janitor, then decides and stores every pending trace so
+// nothing held back is lost.
+func (s *TraceService) Stop(ctx context.Context) {
+	s.stopOnce.Do(func() { close(s.stopCh) })
+	s.janitorWG.Wait()
+
+	if err := s.storeSpans(ctx, s.decidePending(s.pendingTraceIDs(), time.Now())); err != nil {
+		s.logger.Error("failed to store pending traces", "error", err)
+	}
+	s.reportSampling(ctx)
+}
+
+func (s *TraceService) janitor(ctx context.Context) {
+	defer s.janitorWG.Done()
+	ticker := time.NewTicker(traceJanitorTick)
+	defer ticker.Stop()
+	lastRetention := time.Now()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.stopCh:
+			return
+		case now := <-ticker.C:
+			cfg := s.Config()
+			if expired := s.expiredTraces(now, cfg.DecisionWait); len(expired) > 0 {
+				if err := s.storeSpans(ctx, s.decidePending(expired, now)); err != nil {
+					s.logger.Error("failed to store sampled traces", "error", err)
+				}
+			}
+			s.reportSampling(ctx)
+
+			if cfg.Retention > 0 && now.Sub(lastRetention) >= cfg.RetentionInterval {
+				lastRetention = now
+				if _, err := s.ApplyRetention(ctx); err != nil {
+					s.logger.Error("trace retention failed", "error", err)
+				}
+			}
+		}
+	}
+}
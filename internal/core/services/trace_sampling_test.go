@@ -0,0 +1,293 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+)
+
+// mockSamplingMetrics records the sampling counters by decision and reason.
+type mockSamplingMetrics struct {
+	mu     sync.Mutex
+	counts map[string]float64
+}
+
+func (m *mockSamplingMetrics) Record(ctx context.Context, name string, metricType domain.MetricType, value float64, tags map[string]string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if name == MetricTracesSampled && metricType == domain.MetricTypeCounter {
+		m.counts[tags["decision"]+"/"+tags["reason"]] += value
+	}
+	return nil
+}
+
+// sampledSpans returns a root span lasting d and a child, both of a new trace.
+func sampledSpans(d time.Duration, childErr bool) (*domain.Span, *domain.Span) {
+	traceID := domain.NewTraceID()
+	base := time.Now()
+	root := domain.NewSpan(traceID, "GET /", domain.SpanKindServer, "gateway")
+	root.StartTime, root.EndTime = base, base.Add(d)
+	child := domain.NewSpan(traceID, "query", domain.SpanKindClient, "db")
+	child.StartTime, child.EndTime = base, base.Add(d/2)
+	child.SetParent(root.SpanID)
+	if childErr {
+		child.SetStatus(domain.SpanStatusError, "failed")
+	}
+	return root, child
+}
+
+func TestTraceConfig_Decide(t *testing.T) {
+	cfg := TraceConfig{SampleRate: 0, KeepErrors: true, LatencyThreshold: time.Second}
+
+	tests := []struct {
+		name   string
+		trace  domain.Trace
+		keep   bool
+		reason string
+	}{
+		{"error", domain.Trace{TraceID: domain.NewTraceID(), ErrorCount: 1}, true, sampleReasonError},
+		{"slow", domain.Trace{TraceID: domain.NewTraceID(), Duration: 2 * time.Second}, true, sampleReasonLatency},
+		{"ordinary", domain.Trace{TraceID: domain.NewTraceID(), Duration: time.Millisecond}, false, sampleReasonRate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keep, reason := cfg.decide(&tt.trace)
+			if keep != tt.keep || reason != tt.reason {
+				t.Errorf("expected (%v, %s), got (%v, %s)", tt.keep, tt.reason, keep, reason)
+			}
+		})
+	}
+
+	// Rate decisions depend only on the trace ID and roughly follow the rate
+	kept := 0
+	for i := 0; i < 10000; i++ {
+		id := domain.NewTraceID()
+		if sampledByRate(id, 0.25) != sampledByRate(id, 0.25) {
+			t.Fatal("expected the same decision for the same trace ID")
+		}
+		if sampledByRate(id, 0.25) {
+			kept++
+		}
+	}
+	if kept < 2000 || kept > 3000 {
+		t.Errorf("expected about 2500 of 10000 traces kept at 0.25, got %d", kept)
+	}
+
+	for _, bad := range []TraceConfig{
+		{SampleRate: 1.5, DecisionWait: time.Second, RetentionInterval: time.Hour},
+		{SampleRate: 1, DecisionWait: 0, RetentionInterval: time.Hour},
+		{SampleRate: 1, DecisionWait: time.Second, RetentionInterval: time.Hour, Retention: -time.Hour},
+	} {
+		if err := bad.Validate(); err == nil {
+			t.Errorf("expected %+v to be rejected", bad)
+		}
+	}
+	if err := DefaultTraceConfig().Validate(); err != nil {
+		t.Errorf("expected the default config to be valid: %v", err)
+	}
+}
+
+func TestTraceService_ImportSpans_Sampling(t *testing.T) {
+	traceRepo := newMockTraceRepository()
+	spanRepo := newMockSpanRepository()
+	metrics := &mockSamplingMetrics{counts: make(map[string]float64)}
+	svc := NewTraceService(traceRepo, spanRepo, &mockTraceLogger{})
+	svc.SetMetricRecorder(metrics)
+	ctx := context.Background()
+
+	cfg := DefaultTraceConfig()
+	cfg.SampleRate = 0
+	cfg.LatencyThreshold = time.Second
+	if err := svc.SetConfig(cfg); err != nil {
+		t.Fatalf("SetConfig failed: %v", err)
+	}
+
+	failedRoot, failedChild := sampledSpans(10*time.Millisecond, true)
+	fastRoot, fastChild := sampledSpans(10*time.Millisecond, false)
+	slowRoot, _ := sampledSpans(2*time.Second, false)
+	_, orphan := sampledSpans(10*time.Millisecond, false)
+
+	// Children are held until their root arrives
+	if err := svc.ImportSpans(ctx, []*domain.Span{failedChild, orphan}); err != nil {
+		t.Fatalf("ImportSpans failed: %v", err)
+	}
+	if len(spanRepo.spans) != 0 {
+		t.Fatalf("expected spans to be held for a decision, got %d stored", len(spanRepo.spans))
+	}
+	if stats := svc.SamplingStats(); stats.PendingTraces != 2 || stats.PendingSpans != 2 {
+		t.Errorf("expected 2 pending traces, got %+v", stats)
+	}
+
+	if err := svc.ImportSpans(ctx, []*domain.Span{failedRoot, fastRoot, slowRoot}); err != nil {
+		t.Fatalf("ImportSpans failed: %v", err)
+	}
+	if trace, _ := traceRepo.GetByTraceID(ctx, failedRoot.TraceID); trace == nil || trace.SpanCount != 2 {
+		t.Errorf("expected the failed trace to be kept with both spans, got %+v", trace)
+	}
+	if trace, _ := traceRepo.GetByTraceID(ctx, slowRoot.TraceID); trace == nil {
+		t.Error("expected the slow trace to be kept")
+	}
+	if trace, _ := traceRepo.GetByTraceID(ctx, fastRoot.TraceID); trace != nil {
+		t.Error("expected the ordinary trace to be dropped at rate 0")
+	}
+
+	// A straggler follows the decision already made for its trace
+	if err := svc.ImportSpans(ctx, []*domain.Span{fastChild}); err != nil {
+		t.Fatalf("ImportSpans failed: %v", err)
+	}
+	if trace, _ := traceRepo.GetByTraceID(ctx, fastRoot.TraceID); trace != nil {
+		t.Error("expected the straggler of a dropped trace to be discarded")
+	}
+	if stats := svc.SamplingStats(); stats.PendingTraces != 1 {
+		t.Errorf("expected only the orphan to be pending, got %+v", stats)
+	}
+
+	// The orphan never gets its root and is decided after DecisionWait
+	if expired := svc.expiredTraces(time.Now(), cfg.DecisionWait); len(expired) != 0 {
+		t.Errorf("expected no trace to have waited long enough yet, got %d", len(expired))
+	}
+	expired := svc.expiredTraces(time.Now().Add(cfg.DecisionWait), cfg.DecisionWait)
+	if len(expired) != 1 || expired[0] != orphan.TraceID {
+		t.Fatalf("expected the orphan to expire, got %v", expired)
+	}
+	if kept := svc.decidePending(expired, time.Now()); len(kept) != 0 {
+		t.Errorf("expected the orphan to be dropped, got %d spans kept", len(kept))
+	}
+
+	stats := svc.SamplingStats()
+	if stats.Kept != 2 || stats.Dropped != 2 || stats.PendingTraces != 0 || stats.PendingSpans != 0 {
+		t.Errorf("unexpected sampling stats %+v", stats)
+	}
+
+	svc.reportSampling(ctx)
+	want := map[string]float64{"kept/error": 1, "kept/latency": 1, "dropped/rate": 2}
+	for key, n := range want {
+		if metrics.counts[key] != n {
+			t.Errorf("expected %s = %v, got %v", key, n, metrics.counts[key])
+		}
+	}
+	svc.reportSampling(ctx)
+	if metrics.counts["dropped/rate"] != 2 {
+		t.Error("expected counts to be reported only once")
+	}
+}
+
+func TestTraceService_StopStoresPending(t *testing.T) {
+	traceRepo := newMockTraceRepository()
+	spanRepo := newMockSpanRepository()
+	svc := NewTraceService(traceRepo, spanRepo, &mockTraceLogger{})
+	ctx := context.Background()
+
+	cfg := DefaultTraceConfig()
+	cfg.SampleRate = 0
+	if err := svc.SetConfig(cfg); err != nil {
+		t.Fatalf("SetConfig failed: %v", err)
+	}
+	svc.Start(ctx)
+
+	_, child := sampledSpans(10*time.Millisecond, true)
+	if err := svc.ImportSpans(ctx, []*domain.Span{child}); err != nil {
+		t.Fatalf("ImportSpans failed: %v", err)
+	}
+	svc.Stop(ctx)
+
+	if trace, _ := traceRepo.GetByTraceID(ctx, child.TraceID); trace == nil {
+		t.Error("expected a pending failed trace to be stored on stop")
+	}
+	if stats := svc.SamplingStats(); stats.PendingTraces != 0 || stats.Kept != 1 {
+		t.Errorf("unexpected sampling stats after stop %+v", stats)
+	}
+}
+
+func TestTraceService_ApplyRetention(t *testing.T) {
+	traceRepo := newMockTraceRepository()
+	svc := NewTraceService(traceRepo, newMockSpanRepository(), &mockTraceLogger{})
+	ctx := context.Background()
+
+	old := domain.NewTrace("svc", "old")
+	old.StartTime = time.Now().Add(-48 * time.Hour)
+	recent := domain.NewTrace("svc", "recent")
+	_ = traceRepo.Create(ctx, old)
+	_ = traceRepo.Create(ctx, recent)
+
+	cfg := DefaultTraceConfig()
+	cfg.Retention = 24 * time.Hour
+	if err := svc.SetConfig(cfg); err != nil {
+		t.Fatalf("SetConfig failed: %v", err)
+	}
+	deleted, err := svc.ApplyRetention(ctx)
+	if err != nil {
+		t.Fatalf("ApplyRetention failed: %v", err)
+	}
+	if deleted != 1 || len(traceRepo.traces) != 1 {
+		t.Errorf("expected the old trace to be deleted, deleted %d, left %d", deleted, len(traceRepo.traces))
+	}
+	if age := time.Since(traceRepo.deletedBefore); age < 24*time.Hour || age > 25*time.Hour {
+		t.Errorf("expected a cutoff 24h ago, got %v ago", age)
+	}
+
+	// Zero retention keeps everything
+	cfg.Retention = 0
+	_ = svc.SetConfig(cfg)
+	if deleted, _ := svc.ApplyRetention(ctx); deleted != 0 {
+		t.Errorf("expected nothing deleted without retention, got %d", deleted)
+	}
+}
+
+func TestTraceService_EstimateTraceCount(t *testing.T) {
+	traceRepo := newMockTraceRepository()
+	svc := NewTraceService(traceRepo, newMockSpanRepository(), &mockTraceLogger{})
+	ctx := context.Background()
+
+	// 3 ordinary traces, 1 failed, 1 slow and 1 both
+	add := func(status domain.SpanStatus, d time.Duration) {
+		trace := domain.NewTrace("svc", "op")
+		trace.Status, trace.Duration = status, d
+		_ = traceRepo.Create(ctx, trace)
+	}
+	for i := 0; i < 3; i++ {
+		add(domain.SpanStatusOK, 10*time.Millisecond)
+	}
+	add(domain.SpanStatusError, 10*time.Millisecond)
+	add(domain.SpanStatusOK, 5*time.Second)
+	add(domain.SpanStatusError, 5*time.Second)
+	start, end := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+
+	tests := []struct {
+		rate       float64
+		alwaysKept int64
+		estimated  int64
+		known      bool
+	}{
+		{1, 0, 6, true},
+		{0.5, 3, 9, true},
+		{0.25, 3, 15, true},
+		{0, 3, 6, false},
+	}
+	for _, tt := range tests {
+		cfg := DefaultTraceConfig()
+		cfg.SampleRate = tt.rate
+		cfg.LatencyThreshold = time.Second
+		if err := svc.SetConfig(cfg); err != nil {
+			t.Fatalf("SetConfig failed: %v", err)
+		}
+		est, err := svc.EstimateTraceCount(ctx, start, end)
+		if err != nil {
+			t.Fatalf("EstimateTraceCount failed: %v", err)
+		}
+		if est.Stored != 6 || est.AlwaysKept != tt.alwaysKept || est.Known != tt.known || (tt.known && est.Estimated != tt.estimated) {
+			t.Errorf("rate %v: expected %d always kept and %d estimated (known %v), got %+v",
+				tt.rate, tt.alwaysKept, tt.estimated, tt.known, est)
+		}
+	}
+
+	if rate := (TraceSamplingStats{Kept: 1, Dropped: 3}).EffectiveRate(0.1); rate != 0.25 {
+		t.Errorf("expected an effective rate of 0.25, got %v", rate)
+	}
+	if rate := (TraceSamplingStats{}).EffectiveRate(0.1); rate != 0.1 {
+		t.Errorf("expected the configured rate before any decision, got %v", rate)
+	}
+}
@@ -21,6 +21,28 @@ type TraceService struct {
 	// Active traces cache
 	mu           sync.RWMutex
 	activeTraces map[domain.TraceID]*domain.Trace
+
+	// importMu serializes ImportSpans so concurrent batches for the same
+	// trace don't both try to create it
+	importMu sync.Mutex
+
+	// Sampling and retention, see TraceConfig
+	configMu sync.RWMutex
+	config   TraceConfig
+	metrics  ports.MetricService
+
+	// Traces held back for a sampling decision, recent decisions, and
+	// decision counts in total and since the last metric report
+	samplingMu   sync.Mutex
+	pending      map[domain.TraceID]*pendingTrace
+	pendingSpans int
+	decided      map[domain.TraceID]traceDecision
+	sampleCounts map[sampleKey]int64
+	unreported   map[sampleKey]int64
+
+	stopCh    chan struct{}
+	stopOnce  sync.Once
+	janitorWG sync.WaitGroup
 }
 
 // NewTraceService creates a new trace service.
@@ -30,9 +52,21 @@ func NewTraceService(traceRepo ports.TraceRepository, spanRepo ports.SpanReposit
 		spanRepo:     spanRepo,
 		logger:       logger,
 		activeTraces: make(map[domain.TraceID]*domain.Trace),
+		config:       DefaultTraceConfig(),
+		pending:      make(map[domain.TraceID]*pendingTrace),
+		decided:      make(map[domain.TraceID]traceDecision),
+		sampleCounts: make(map[sampleKey]int64),
+		unreported:   make(map[sampleKey]int64),
+		stopCh:       make(chan struct{}),
 	}
 }
 
+// SetMetricRecorder sets where sampling decisions are recorded as the
+// MetricTracesSampled counter.
+func (s *TraceService) SetMetricRecorder(metrics ports.MetricService) {
+	s.metrics = metrics
+}
+
 // StartTrace creates a new trace.
 func (s *TraceService) StartTrace(ctx context.Context, serviceName, operationName string) (*domain.Trace, error) {
 	trace := domain.NewTrace(serviceName, operationName)
@@ -84,10 +118,19 @@ func (s *TraceService) EndSpan(ctx context.Context, span *domain.Span) error {
 		}
 	}
 
-	// Update trace
-	s.mu.RLock()
+	// Update trace; the span was added when started, so fold in its outcome now
+	s.mu.Lock()
 	trace := s.activeTraces[span.TraceID]
-	s.mu.RUnlock()
+	if trace != nil {
+		if span.Status == domain.SpanStatusError {
+			trace.ErrorCount++
+		}
+		if span.EndTime.After(trace.EndTime) {
+			trace.EndTime = span.EndTime
+			trace.Duration = trace.EndTime.Sub(trace.StartTime)
+		}
+	}
+	s.mu.Unlock()
 
 	if trace != nil && s.traceRepo != nil {
 		if err := s.traceRepo.Update(ctx, trace); err != nil {
@@ -99,7 +142,8 @@ func (s *TraceService) EndSpan(ctx context.Context, span *domain.Span) error {
 	return nil
 }
 
-// EndTrace marks a trace as completed.
+// EndTrace marks a trace as completed. When sampling, a trace that is not
+// kept is deleted along with its spans.
 func (s *TraceService) EndTrace(ctx context.Context, traceID domain.TraceID) error {
 	s.mu.Lock()
 	trace, exists := s.activeTraces[traceID]
@@ -122,9 +166,31 @@ func (s *TraceService) EndTrace(ctx context.Context, traceID domain.TraceID) err
 	}
 
 	s.logger.Debug("ended trace", "trace_id", traceID.String(), "duration", trace.Duration, "spans", trace.SpanCount)
+
+	if cfg := s.Config(); cfg.Sampling() {
+		keep, reason := cfg.decide(trace)
+		s.countDecision(keep, reason)
+		if !keep {
+			s.discardTrace(ctx, trace)
+		}
+	}
 	return nil
 }
 
+// discardTrace deletes a stored trace that sampling did not keep.
+func (s *TraceService) discardTrace(ctx context.Context, trace *domain.Trace) {
+	if s.spanRepo != nil {
+		if _, err := s.spanRepo.DeleteByTraceID(ctx, trace.TraceID); err != nil {
+			s.logger.Error("failed to delete sampled-out spans", "trace_id", trace.TraceID.String(), "error", err)
+		}
+	}
+	if s.traceRepo != nil {
+		if err := s.traceRepo.Delete(ctx, trace.ID); err != nil {
+			s.logger.Error("failed to delete sampled-out trace", "trace_id", trace.TraceID.String(), "error", err)
+		}
+	}
+}
+
 // GetTrace retrieves a trace by ID.
 func (s *TraceService) GetTrace(ctx context.Context, id uuid.UUID) (*domain.Trace, error) {
 	if s.traceRepo == nil {
@@ -157,6 +223,22 @@ func (s *TraceService) ListTraces(ctx context.Context, filter ports.TraceFilter)
 	return s.traceRepo.List(ctx, filter)
 }
 
+// SlowestTraces returns the n longest traces started in [startTime, endTime],
+// longest first. Empty serviceName or name match any service or operation.
+func (s *TraceService) SlowestTraces(ctx context.Context, serviceName, name string, startTime, endTime time.Time, n int) ([]*domain.Trace, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("n must be positive")
+	}
+	return s.ListTraces(ctx, ports.TraceFilter{
+		ServiceName:  serviceName,
+		Name:         name,
+		StartTime:    startTime,
+		EndTime:      endTime,
+		SlowestFirst: true,
+		Limit:        n,
+	})
+}
+
 // GetSpansByTraceID retrieves all spans for a trace.
 func (s *TraceService) GetSpansByTraceID(ctx context.Context, traceID domain.TraceID) ([]*domain.Span, error) {
 	if s.spanRepo == nil {
@@ -165,6 +247,14 @@ func (s *TraceService) GetSpansByTraceID(ctx context.Context, traceID domain.Tra
 	return s.spanRepo.ListByTraceID(ctx, traceID)
 }
 
+// ListSpans retrieves spans with optional filtering.
+func (s *TraceService) ListSpans(ctx context.Context, filter ports.SpanFilter) ([]*domain.Span, error) {
+	if s.spanRepo == nil {
+		return []*domain.Span{}, nil
+	}
+	return s.spanRepo.List(ctx, filter)
+}
+
 // GetServiceMap retrieves the service dependency map.
 func (s *TraceService) GetServiceMap(ctx context.Context, startTime, endTime time.Time) (*domain.ServiceMap, error) {
 	if s.traceRepo == nil {
@@ -241,14 +331,110 @@ func (s *TraceService) IngestSpanBatch(ctx context.Context, spans []*domain.Span
 	return nil
 }
 
-// GetTraceStats returns tracing statistics.
-func (s *TraceService) GetTraceStats(ctx context.Context) (map[string]interface{}, error) {
+// ImportSpans persists finished spans recorded by another system, such as
+// an OpenTelemetry SDK. Spans are written in one batch, then each affected
+// trace is created or re-summarized from all of its stored spans, since a
+// trace's spans commonly arrive across several export requests.
+//
+// When sampling, spans of undecided traces are held back until their trace
+// is decided (see TraceConfig); spans of traces already decided are stored
+// or discarded to match.
+func (s *TraceService) ImportSpans(ctx context.Context, spans []*domain.Span) error {
+	if len(spans) == 0 {
+		return nil
+	}
+	if s.spanRepo == nil || s.traceRepo == nil {
+		return fmt.Errorf("trace repository not configured")
+	}
+
+	now := time.Now()
+	if !s.Config().Sampling() {
+		// Traces held back before sampling was turned off are kept now
+		return s.storeSpans(ctx, append(s.decidePending(s.pendingTraceIDs(), now), spans...))
+	}
+	store, ready := s.holdSpans(spans, now)
+	return s.storeSpans(ctx, append(store, s.decidePending(ready, now)...))
+}
+
+// storeSpans writes spans and creates or re-summarizes their traces.
+func (s *TraceService) storeSpans(ctx context.Context, spans []*domain.Span) error {
+	if len(spans) == 0 {
+		return nil
+	}
+
+	s.importMu.Lock()
+	defer s.importMu.Unlock()
+
+	if err := s.spanRepo.CreateBatch(ctx, spans); err != nil {
+		return fmt.Errorf("failed to persist spans: %w", err)
+	}
+
+	byTrace := make(map[domain.TraceID][]*domain.Span)
+	var order []domain.TraceID
+	for _, span := range spans {
+		if _, ok := byTrace[span.TraceID]; !ok {
+			order = append(order, span.TraceID)
+		}
+		byTrace[span.TraceID] = append(byTrace[span.TraceID], span)
+	}
+
+	for _, traceID := range order {
+		trace, err := s.traceRepo.GetByTraceID(ctx, traceID)
+		if err != nil || trace == nil {
+			trace = domain.NewTraceFromSpans(traceID, byTrace[traceID])
+			if err := s.traceRepo.Create(ctx, trace); err != nil {
+				return fmt.Errorf("failed to create trace %s: %w", traceID, err)
+			}
+			continue
+		}
+
+		stored, err := s.spanRepo.ListByTraceID(ctx, traceID)
+		if err != nil {
+			return fmt.Errorf("failed to load spans for trace %s: %w", traceID, err)
+		}
+		trace.Spans = stored
+		trace.Summarize()
+		if err := s.traceRepo.Update(ctx, trace); err != nil {
+			return fmt.Errorf("failed to update trace %s: %w", traceID, err)
+		}
+	}
+	return nil
+}
+
+// GetTraceStats returns tracing statistics. Traces stored between
+// startTime and endTime are counted, along with an estimate of how many
+// there were before sampling; the estimate is nil when it can't be made
+// because the sample rate is 0.
+func (s *TraceService) GetTraceStats(ctx context.Context, startTime, endTime time.Time) (map[string]interface{}, error) {
 	s.mu.RLock()
 	activeCount := len(s.activeTraces)
 	s.mu.RUnlock()
 
+	cfg := s.Config()
+	sampling := s.SamplingStats()
 	stats := map[string]interface{}{
 		"active_traces": activeCount,
+		"sampling": map[string]interface{}{
+			"enabled":        cfg.Sampling(),
+			"sample_rate":    cfg.SampleRate,
+			"effective_rate": sampling.EffectiveRate(cfg.SampleRate),
+			"kept":           sampling.Kept,
+			"dropped":        sampling.Dropped,
+			"pending_traces": sampling.PendingTraces,
+			"pending_spans":  sampling.PendingSpans,
+		},
+	}
+
+	if s.traceRepo != nil {
+		est, err := s.EstimateTraceCount(ctx, startTime, endTime)
+		if err != nil {
+			return nil, err
+		}
+		stats["stored_traces"] = est.Stored
+		stats["estimated_total_traces"] = nil
+		if est.Known {
+			stats["estimated_total_traces"] = est.Estimated
+		}
 	}
 
 	return stats, nil
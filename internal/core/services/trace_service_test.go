@@ -3,6 +3,7 @@ package services
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"testing"
 	"time"
@@ -23,8 +24,9 @@ func (m *mockTraceLogger) With(args ...interface{}) ports.Logger { return m }
 
 // mockTraceRepository for testing
 type mockTraceRepository struct {
-	mu     sync.RWMutex
-	traces map[uuid.UUID]*domain.Trace
+	mu            sync.RWMutex
+	traces        map[uuid.UUID]*domain.Trace
+	deletedBefore time.Time
 }
 
 func newMockTraceRepository() *mockTraceRepository {
@@ -81,8 +83,38 @@ func (m *mockTraceRepository) List(ctx context.Context, filter ports.TraceFilter
 	return result, nil
 }
 
+func (m *mockTraceRepository) Count(ctx context.Context, filter ports.TraceFilter) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var count int64
+	for _, t := range m.traces {
+		if filter.Status != "" && string(t.Status) != filter.Status {
+			continue
+		}
+		if filter.MinDuration > 0 && t.Duration < filter.MinDuration {
+			continue
+		}
+		if (!filter.StartTime.IsZero() && t.StartTime.Before(filter.StartTime)) ||
+			(!filter.EndTime.IsZero() && t.StartTime.After(filter.EndTime)) {
+			continue
+		}
+		count++
+	}
+	return count, nil
+}
+
 func (m *mockTraceRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
-	return 0, nil
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deletedBefore = before
+	var deleted int64
+	for id, t := range m.traces {
+		if t.StartTime.Before(before) {
+			delete(m.traces, id)
+			deleted++
+		}
+	}
+	return deleted, nil
 }
 
 func (m *mockTraceRepository) GetServiceMap(ctx context.Context, startTime, endTime time.Time) (*domain.ServiceMap, error) {
@@ -120,6 +152,13 @@ func (m *mockSpanRepository) CreateBatch(ctx context.Context, spans []*domain.Sp
 	return nil
 }
 
+func (m *mockSpanRepository) CreateBatchBestEffort(ctx context.Context, spans []*domain.Span) (*ports.BatchReport, error) {
+	if err := m.CreateBatch(ctx, spans); err != nil {
+		return nil, err
+	}
+	return &ports.BatchReport{Written: len(spans)}, nil
+}
+
 func (m *mockSpanRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Span, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -306,3 +345,78 @@ func TestTraceService_EndSpan(t *testing.T) {
 	}
 }
 
+func TestTraceService_EndSpan_ErrorMarksTrace(t *testing.T) {
+	logger := &mockTraceLogger{}
+	traceRepo := newMockTraceRepository()
+	svc := NewTraceService(traceRepo, newMockSpanRepository(), logger)
+	ctx := context.Background()
+
+	trace, _ := svc.StartTrace(ctx, "test-service", "test-operation")
+	span, _ := svc.StartSpan(ctx, trace.TraceID, "test-span", domain.SpanKindServer, "test-service", nil)
+	span.SetError(errors.New("boom"))
+
+	if err := svc.EndSpan(ctx, span); err != nil {
+		t.Fatalf("EndSpan failed: %v", err)
+	}
+	if err := svc.EndTrace(ctx, trace.TraceID); err != nil {
+		t.Fatalf("EndTrace failed: %v", err)
+	}
+
+	if trace.ErrorCount != 1 {
+		t.Errorf("expected error count 1, got %d", trace.ErrorCount)
+	}
+	if trace.Status != domain.SpanStatusError {
+		t.Errorf("expected trace status error, got %s", trace.Status)
+	}
+}
+
+func TestTraceService_ImportSpans(t *testing.T) {
+	traceRepo := newMockTraceRepository()
+	spanRepo := newMockSpanRepository()
+	svc := NewTraceService(traceRepo, spanRepo, &mockTraceLogger{})
+	ctx := context.Background()
+
+	traceID := domain.NewTraceID()
+	base := time.Now()
+	root := domain.NewSpan(traceID, "GET /orders", domain.SpanKindServer, "gateway")
+	root.StartTime, root.EndTime = base, base.Add(100*time.Millisecond)
+
+	// The child arrives first, in its own export request
+	child := domain.NewSpan(traceID, "SELECT orders", domain.SpanKindClient, "orders")
+	child.StartTime, child.EndTime = base.Add(10*time.Millisecond), base.Add(60*time.Millisecond)
+	child.SetParent(root.SpanID)
+	child.SetStatus(domain.SpanStatusError, "deadlock")
+
+	if err := svc.ImportSpans(ctx, []*domain.Span{child}); err != nil {
+		t.Fatalf("ImportSpans failed: %v", err)
+	}
+	trace, _ := traceRepo.GetByTraceID(ctx, traceID)
+	if trace == nil || trace.Name != "SELECT orders" || trace.SpanCount != 1 {
+		t.Fatalf("expected a provisional trace named after the child, got %+v", trace)
+	}
+
+	if err := svc.ImportSpans(ctx, []*domain.Span{root}); err != nil {
+		t.Fatalf("ImportSpans failed: %v", err)
+	}
+	trace, _ = traceRepo.GetByTraceID(ctx, traceID)
+	if len(traceRepo.traces) != 1 {
+		t.Fatalf("expected spans to share one trace, got %d traces", len(traceRepo.traces))
+	}
+	if trace.Name != "GET /orders" || trace.ServiceName != "gateway" {
+		t.Errorf("expected root span to name the trace, got %q (%s)", trace.Name, trace.ServiceName)
+	}
+	if trace.SpanCount != 2 || trace.ErrorCount != 1 || trace.Status != domain.SpanStatusError {
+		t.Errorf("unexpected summary: spans %d errors %d status %s", trace.SpanCount, trace.ErrorCount, trace.Status)
+	}
+	if trace.Duration != 100*time.Millisecond {
+		t.Errorf("expected 100ms trace, got %v", trace.Duration)
+	}
+}
+
+func TestTraceService_ImportSpans_NoRepo(t *testing.T) {
+	svc := NewTraceService(nil, nil, &mockTraceLogger{})
+	span := domain.NewSpan(domain.NewTraceID(), "op", domain.SpanKindInternal, "svc")
+	if err := svc.ImportSpans(context.Background(), []*domain.Span{span}); err == nil {
+		t.Error("expected error without repositories")
+	}
+}
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"strconv"
+	"strings"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+	"github.com/google/uuid"
+)
+
+// UserImport is one user to provision with ImportUsers.
+type UserImport struct {
+	Username string          `json:"username"`
+	Email    string          `json:"email"`
+	Role     domain.UserRole `json:"role,omitempty"` // Default viewer
+	Password string          `json:"password,omitempty"`
+
+	// Invite generates a temporary password to send to the user. A
+	// temporary password is also generated when Password is empty.
+	Invite bool `json:"invite,omitempty"`
+}
+
+// ImportedUser is a user created by ImportUsers.
+type ImportedUser struct {
+	ID       uuid.UUID       `json:"id"`
+	Username string          `json:"username"`
+	Email    string          `json:"email"`
+	Role     domain.UserRole `json:"role"`
+
+	// TempPassword is set when the password was generated. It is only
+	// available here and must be changed at first login.
+	TempPassword string `json:"temp_password,omitempty"`
+	Invite       bool   `json:"invite,omitempty"`
+}
+
+// SkippedUser is an import entry that was not created.
+type SkippedUser struct {
+	Entry    int    `json:"entry"` // 1-based position in the import
+	Username string `json:"username"`
+	Reason   string `json:"reason"`
+}
+
+// UserImportReport is the outcome of ImportUsers.
+type UserImportReport struct {
+	Created []ImportedUser `json:"created"`
+	Skipped []SkippedUser  `json:"skipped"`
+}
+
+// passwordChangeRequiredKey marks users that must replace a generated
+// temporary password.
+const passwordChangeRequiredKey = "password_change_required"
+
+// ImportUsers provisions users in bulk. Every entry is validated first and
+// nothing is created if any is invalid. Entries whose username or email is
+// already taken, by an existing user or an earlier entry, are skipped and
+// reported. If creating a user fails, the users created so far are removed
+// again so the import applies all or nothing.
+func (s *AuthService) ImportUsers(ctx context.Context, entries []UserImport) (*UserImportReport, error) {
+	if s.userRepo == nil {
+		return nil, fmt.Errorf("user repository not configured")
+	}
+	if err := validateUserImports(entries); err != nil {
+		return nil, err
+	}
+
+	report := &UserImportReport{Created: []ImportedUser{}, Skipped: []SkippedUser{}}
+	usernames := make(map[string]bool)
+	emails := make(map[string]bool)
+	var users []*domain.User
+	for i, entry := range entries {
+		skip := func(reason string) {
+			report.Skipped = append(report.Skipped, SkippedUser{Entry: i + 1, Username: entry.Username, Reason: reason})
+		}
+		if usernames[entry.Username] {
+			skip("duplicate username in import")
+			continue
+		}
+		if emails[entry.Email] {
+			skip("duplicate email in import")
+			continue
+		}
+		if existing, _ := s.userRepo.GetByUsername(ctx, entry.Username); existing != nil {
+			skip("username already exists")
+			continue
+		}
+		if existing, _ := s.userRepo.GetByEmail(ctx, entry.Email); existing != nil {
+			skip("email already exists")
+			continue
+		}
+		usernames[entry.Username] = true
+		emails[entry.Email] = true
+
+		password, temp := entry.Password, ""
+		if password == "" || entry.Invite {
+			generated, err := generateTempPassword()
+			if err != nil {
+				return nil, err
+			}
+			password, temp = generated, generated
+		}
+		user, err := domain.NewUser(entry.Username, entry.Email, password, importRole(entry))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create user %s: %w", entry.Username, err)
+		}
+		if temp != "" {
+			user.Metadata[passwordChangeRequiredKey] = "true"
+		}
+		users = append(users, user)
+		report.Created = append(report.Created, ImportedUser{
+			ID:           user.ID,
+			Username:     user.Username,
+			Email:        user.Email,
+			Role:         user.Role,
+			TempPassword: temp,
+			Invite:       entry.Invite,
+		})
+	}
+
+	for i, user := range users {
+		if err := s.userRepo.Create(ctx, user); err != nil {
+			for _, created := range users[:i] {
+				_ = s.userRepo.Delete(ctx, created.ID)
+			}
+			s.audit(ctx, nil, "user.import", "user", "", map[string]string{"failed_at": user.Username}, err)
+			return nil, fmt.Errorf("failed to save user %s, import rolled back: %w", user.Username, err)
+		}
+	}
+
+	for _, user := range users {
+		s.audit(ctx, &user.ID, "user.create", "user", user.ID.String(), map[string]string{"source": "import"}, nil)
+	}
+	s.audit(ctx, nil, "user.import", "user", "", map[string]string{
+		"created": strconv.Itoa(len(report.Created)),
+		"skipped": strconv.Itoa(len(report.Skipped)),
+	}, nil)
+	s.logger.Info("Users imported", "created", len(report.Created), "skipped", len(report.Skipped))
+
+	return report, nil
+}
+
+// validateUserImports checks every entry and reports all problems at once.
+func validateUserImports(entries []UserImport) error {
+	if len(entries) == 0 {
+		return fmt.Errorf("no users to import")
+	}
+	var problems []string
+	for i, entry := range entries {
+		if err := validateUserImport(entry); err != nil {
+			problems = append(problems, fmt.Sprintf("entry %d: %v", i+1, err))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid user import: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func validateUserImport(entry UserImport) error {
+	if entry.Username == "" {
+		return errors.New("username is required")
+	}
+	if strings.ContainsAny(entry.Username, " \t\n") {
+		return fmt.Errorf("username %q must not contain whitespace", entry.Username)
+	}
+	if entry.Email == "" {
+		return errors.New("email is required")
+	}
+	if addr, err := mail.ParseAddress(entry.Email); err != nil || addr.Address != entry.Email {
+		return fmt.Errorf("invalid email %q", entry.Email)
+	}
+	if _, ok := domain.RolePermissions[importRole(entry)]; !ok {
+		return fmt.Errorf("unknown role %q", entry.Role)
+	}
+	return nil
+}
+
+func importRole(entry UserImport) domain.UserRole {
+	if entry.Role == "" {
+		return domain.RoleViewer
+	}
+	return entry.Role
+}
+
+// generateTempPassword returns a random password for a new account.
+func generateTempPassword() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate password: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// ParseUserImport reads users to import from JSON, an array of UserImport,
+// or CSV with a header row naming the username, email, role, password and
+// invite columns. Only username and email are required.
+func ParseUserImport(r io.Reader, format string) ([]UserImport, error) {
+	switch format {
+	case "json":
+		var entries []UserImport
+		if err := json.NewDecoder(r).Decode(&entries); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON user import: %w", err)
+		}
+		return entries, nil
+	case "csv":
+		return parseUserImportCSV(r)
+	default:
+		return nil, fmt.Errorf("unsupported user import format %q", format)
+	}
+}
+
+func parseUserImportCSV(r io.Reader) ([]UserImport, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV user import: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("CSV user import is empty")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"username", "email"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("CSV user import has no %s column", required)
+		}
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	entries := make([]UserImport, 0, len(records)-1)
+	for n, record := range records[1:] {
+		entry := UserImport{
+			Username: field(record, "username"),
+			Email:    field(record, "email"),
+			Role:     domain.UserRole(field(record, "role")),
+			Password: field(record, "password"),
+		}
+		if v := field(record, "invite"); v != "" {
+			invite, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid invite value %q", n+2, v)
+			}
+			entry.Invite = invite
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
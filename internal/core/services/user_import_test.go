@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/forge-platform/forge/internal/core/domain"
+)
+
+// failingUserRepository fails to create the user with the given username.
+type failingUserRepository struct {
+	*mockUserRepository
+	failOn string
+}
+
+func (r *failingUserRepository) Create(ctx context.Context, user *domain.User) error {
+	if user.Username == r.failOn {
+		return errors.New("disk full")
+	}
+	return r.mockUserRepository.Create(ctx, user)
+}
+
+func newImportTestService(userRepo *mockUserRepository) (*AuthService, *mockAuditLogRepository) {
+	auditRepo := newMockAuditLogRepository()
+	svc := NewAuthService(
+		userRepo,
+		newMockSessionRepository(),
+		newMockAPIKeyRepository(),
+		auditRepo,
+		DefaultAuthConfig(),
+		&mockLogger{},
+	)
+	return svc, auditRepo
+}
+
+func TestAuthService_ImportUsers(t *testing.T) {
+	ctx := context.Background()
+	userRepo := newMockUserRepository()
+	svc, auditRepo := newImportTestService(userRepo)
+
+	if _, err := svc.CreateUser(ctx, "alice", "alice@example.com", "password123", domain.RoleOperator); err != nil {
+		t.Fatalf("CreateUser error: %v", err)
+	}
+
+	report, err := svc.ImportUsers(ctx, []UserImport{
+		{Username: "alice", Email: "alice2@example.com"},
+		{Username: "bob", Email: "bob@example.com", Role: domain.RoleOperator, Password: "bobsecret1"},
+		{Username: "carol", Email: "carol@example.com", Invite: true},
+		{Username: "dave", Email: "alice@example.com"},
+		{Username: "bob", Email: "bob2@example.com"},
+		{Username: "erin", Email: "erin@example.com", Role: domain.RoleAdmin},
+	})
+	if err != nil {
+		t.Fatalf("ImportUsers error: %v", err)
+	}
+
+	if len(report.Created) != 3 {
+		t.Fatalf("Created = %d, want 3", len(report.Created))
+	}
+	wantSkipped := map[int]string{
+		1: "username already exists",
+		4: "email already exists",
+		5: "duplicate username in import",
+	}
+	if len(report.Skipped) != len(wantSkipped) {
+		t.Fatalf("Skipped = %+v, want %d entries", report.Skipped, len(wantSkipped))
+	}
+	for _, skipped := range report.Skipped {
+		if reason := wantSkipped[skipped.Entry]; skipped.Reason != reason {
+			t.Errorf("entry %d reason = %q, want %q", skipped.Entry, skipped.Reason, reason)
+		}
+	}
+
+	bob, err := userRepo.GetByUsername(ctx, "bob")
+	if err != nil {
+		t.Fatalf("bob was not created: %v", err)
+	}
+	if bob.Role != domain.RoleOperator || !bob.CheckPassword("bobsecret1") {
+		t.Errorf("bob should be an operator with the given password")
+	}
+	if bob.Metadata[passwordChangeRequiredKey] != "" {
+		t.Errorf("bob has a chosen password and should not be asked to change it")
+	}
+
+	var carol ImportedUser
+	for _, created := range report.Created {
+		if created.Username == "carol" {
+			carol = created
+		}
+	}
+	if carol.TempPassword == "" || !carol.Invite {
+		t.Fatalf("carol should be invited with a temporary password, got %+v", carol)
+	}
+	stored, _ := userRepo.GetByUsername(ctx, "carol")
+	if stored.Role != domain.RoleViewer {
+		t.Errorf("carol role = %s, want viewer", stored.Role)
+	}
+	if !stored.CheckPassword(carol.TempPassword) {
+		t.Error("temporary password does not match the stored hash")
+	}
+	if stored.Metadata[passwordChangeRequiredKey] != "true" {
+		t.Error("carol should be required to change the temporary password")
+	}
+
+	var imported, summaries int
+	for _, log := range auditRepo.logs {
+		switch {
+		case log.Action == "user.create" && log.Details["source"] == "import":
+			imported++
+		case log.Action == "user.import":
+			summaries++
+			if log.Details["created"] != "3" || log.Details["skipped"] != "3" {
+				t.Errorf("import audit details = %v", log.Details)
+			}
+		}
+	}
+	if imported != 3 || summaries != 1 {
+		t.Errorf("audit logs: %d imported users and %d summaries, want 3 and 1", imported, summaries)
+	}
+}
+
+func TestAuthService_ImportUsers_InvalidEntriesCreateNothing(t *testing.T) {
+	userRepo := newMockUserRepository()
+	svc, _ := newImportTestService(userRepo)
+
+	_, err := svc.ImportUsers(context.Background(), []UserImport{
+		{Username: "frank", Email: "frank@example.com"},
+		{Username: "grace", Email: "not-an-email"},
+		{Username: "heidi", Email: "heidi@example.com", Role: "superuser"},
+	})
+	if err == nil {
+		t.Fatal("expected a validation error")
+	}
+	for _, want := range []string{"entry 2", "entry 3"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %s", err, want)
+		}
+	}
+	if len(userRepo.users) != 0 {
+		t.Errorf("created %d users, want none", len(userRepo.users))
+	}
+}
+
+func TestAuthService_ImportUsers_RollsBackOnFailure(t *testing.T) {
+	userRepo := newMockUserRepository()
+	svc, _ := newImportTestService(userRepo)
+	svc.userRepo = &failingUserRepository{mockUserRepository: userRepo, failOn: "judy"}
+
+	_, err := svc.ImportUsers(context.Background(), []UserImport{
+		{Username: "ivan", Email: "ivan@example.com"},
+		{Username: "judy", Email: "judy@example.com"},
+	})
+	if err == nil {
+		t.Fatal("expected the import to fail")
+	}
+	if len(userRepo.users) != 0 {
+		t.Errorf("%d users left after a failed import, want none", len(userRepo.users))
+	}
+}
+
+func TestParseUserImport(t *testing.T) {
+	csvData := "username,email,role,invite\n" +
+		"alice,alice@example.com,admin,true\n" +
+		"bob, bob@example.com,,\n"
+	entries, err := ParseUserImport(strings.NewReader(csvData), "csv")
+	if err != nil {
+		t.Fatalf("ParseUserImport(csv) error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("entries = %d, want 2", len(entries))
+	}
+	if entries[0].Role != domain.RoleAdmin || !entries[0].Invite {
+		t.Errorf("alice = %+v", entries[0])
+	}
+	if entries[1].Email != "bob@example.com" || entries[1].Role != "" || entries[1].Invite {
+		t.Errorf("bob = %+v", entries[1])
+	}
+
+	jsonData := `[{"username":"carol","email":"carol@example.com","password":"secret123"}]`
+	entries, err = ParseUserImport(strings.NewReader(jsonData), "json")
+	if err != nil {
+		t.Fatalf("ParseUserImport(json) error: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Password != "secret123" {
+		t.Errorf("entries = %+v", entries)
+	}
+
+	if _, err := ParseUserImport(strings.NewReader("name,mail\nx,y\n"), "csv"); err == nil {
+		t.Error("expected an error for a CSV without a username column")
+	}
+	if _, err := ParseUserImport(strings.NewReader(""), "xml"); err == nil {
+		t.Error("expected an error for an unsupported format")
+	}
+}
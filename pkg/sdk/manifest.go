@@ -0,0 +1,121 @@
+package sdk
+
+import "encoding/json"
+
+// Manifest declares a plugin's metadata in one place. The runtime reads it
+// through the forge_manifest export, so it is available for validation and
+// registry listings without calling Init.
+//
+//	func main() {
+//	    sdk.SetManifest(sdk.Manifest{
+//	        Name:        "my-plugin",
+//	        Version:     "1.0.0",
+//	        Permissions: []string{"metrics:write", "network"},
+//	        Config: []sdk.ConfigField{
+//	            {Name: "endpoint", Type: "string", Required: true},
+//	        },
+//	    })
+//	    sdk.Register(&MyPlugin{})
+//	}
+type Manifest struct {
+	Name         string        `json:"name"`
+	Version      string        `json:"version"`
+	Description  string        `json:"description,omitempty"`
+	Author       string        `json:"author,omitempty"`
+	Permissions  []string      `json:"permissions,omitempty"`
+	AllowedHosts []string      `json:"allowed_hosts,omitempty"`
+	Config       []ConfigField `json:"config,omitempty"`
+
+	// ConfigSchema is an optional JSON schema for the plugin configuration,
+	// for plugins that describe it more precisely than Config can.
+	ConfigSchema string `json:"config_schema,omitempty"`
+}
+
+// ConfigField describes one plugin configuration option, matching the
+// config entries of plugin.yaml.
+type ConfigField struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"` // string, int, bool
+	Default     string `json:"default,omitempty"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// Validate checks that the manifest names the plugin and its version.
+func (m *Manifest) Validate() error {
+	if m.Name == "" {
+		return &PluginError{Code: -1, Message: "manifest name is required"}
+	}
+	if m.Version == "" {
+		return &PluginError{Code: -1, Message: "manifest version is required"}
+	}
+	for _, field := range m.Config {
+		if field.Name == "" {
+			return &PluginError{Code: -1, Message: "manifest config field name is required"}
+		}
+	}
+	return nil
+}
+
+var (
+	manifest     *Manifest
+	manifestJSON []byte // Kept alive while the host reads it
+)
+
+// SetManifest declares the plugin's manifest. It should be called from
+// main(), alongside Register.
+func SetManifest(m Manifest) {
+	manifest = &m
+}
+
+// GetManifest returns the declared manifest. Without SetManifest, a
+// manifest is derived from the registered plugin's Name, Version and, for a
+// ConfigProvider, ConfigSchema.
+func GetManifest() (Manifest, bool) {
+	if manifest != nil {
+		return *manifest, true
+	}
+	if registeredPlugin == nil {
+		return Manifest{}, false
+	}
+	m := Manifest{Name: registeredPlugin.Name(), Version: registeredPlugin.Version()}
+	if provider, ok := registeredPlugin.(ConfigProvider); ok {
+		m.ConfigSchema = provider.ConfigSchema()
+	}
+	return m, true
+}
+
+// MarshalManifest serializes a manifest as the host reads it.
+func MarshalManifest(m Manifest) ([]byte, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(m)
+}
+
+// ParseManifest parses a manifest serialized by MarshalManifest.
+func ParseManifest(data []byte) (Manifest, error) {
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return Manifest{}, &PluginError{Code: -1, Message: "invalid manifest: " + err.Error()}
+	}
+	if err := m.Validate(); err != nil {
+		return Manifest{}, err
+	}
+	return m, nil
+}
+
+// dispatchManifest serializes the plugin's manifest for the forge_manifest
+// export. It returns nil if there is no valid manifest.
+func dispatchManifest() []byte {
+	m, ok := GetManifest()
+	if !ok {
+		return nil
+	}
+	data, err := MarshalManifest(m)
+	if err != nil {
+		return nil
+	}
+	manifestJSON = data
+	return manifestJSON
+}
@@ -0,0 +1,88 @@
+package sdk
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestManifest_RoundTrip(t *testing.T) {
+	want := Manifest{
+		Name:         "collector",
+		Version:      "2.1.0",
+		Description:  "Collects things",
+		Author:       "ops",
+		Permissions:  []string{"metrics:write", "network"},
+		AllowedHosts: []string{"api.example.com"},
+		Config: []ConfigField{
+			{Name: "endpoint", Type: "string", Required: true, Description: "API endpoint"},
+			{Name: "interval", Type: "int", Default: "30"},
+		},
+		ConfigSchema: `{"type":"object"}`,
+	}
+
+	data, err := MarshalManifest(want)
+	if err != nil {
+		t.Fatalf("MarshalManifest failed: %v", err)
+	}
+	got, err := ParseManifest(data)
+	if err != nil {
+		t.Fatalf("ParseManifest failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip mismatch:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestManifest_Validate(t *testing.T) {
+	if _, err := MarshalManifest(Manifest{Version: "1.0.0"}); err == nil {
+		t.Error("expected manifest without a name to be rejected")
+	}
+	if _, err := ParseManifest([]byte(`{"name":"x"}`)); err == nil {
+		t.Error("expected manifest without a version to be rejected")
+	}
+	if _, err := ParseManifest([]byte(`{"name":"x","version":"1","config":[{"type":"int"}]}`)); err == nil {
+		t.Error("expected config field without a name to be rejected")
+	}
+	if _, err := ParseManifest([]byte(`not json`)); err == nil {
+		t.Error("expected malformed manifest to be rejected")
+	}
+}
+
+type schemaPlugin struct{ noEventPlugin }
+
+func (p *schemaPlugin) ConfigSchema() string          { return `{"type":"object"}` }
+func (p *schemaPlugin) Configure(config []byte) error { return nil }
+
+func TestDispatchManifest(t *testing.T) {
+	previousPlugin, previousManifest := registeredPlugin, manifest
+	defer func() { registeredPlugin, manifest = previousPlugin, previousManifest }()
+
+	registeredPlugin, manifest = nil, nil
+	if data := dispatchManifest(); data != nil {
+		t.Errorf("expected no manifest without a plugin, got %s", data)
+	}
+
+	// Without SetManifest the manifest comes from the plugin's methods
+	Register(&schemaPlugin{})
+	got, err := ParseManifest(dispatchManifest())
+	if err != nil {
+		t.Fatalf("ParseManifest failed: %v", err)
+	}
+	if got.Name != "plain" || got.Version != "1.0.0" || got.ConfigSchema != `{"type":"object"}` {
+		t.Errorf("unexpected derived manifest: %+v", got)
+	}
+
+	SetManifest(Manifest{Name: "declared", Version: "3.0.0", Permissions: []string{"logs:read"}})
+	got, err = ParseManifest(dispatchManifest())
+	if err != nil {
+		t.Fatalf("ParseManifest failed: %v", err)
+	}
+	if got.Name != "declared" || len(got.Permissions) != 1 || got.Permissions[0] != "logs:read" {
+		t.Errorf("expected declared manifest to take precedence, got %+v", got)
+	}
+
+	SetManifest(Manifest{Name: "broken"})
+	if data := dispatchManifest(); data != nil {
+		t.Errorf("expected invalid manifest not to be exported, got %s", data)
+	}
+}
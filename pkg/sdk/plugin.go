@@ -12,9 +12,10 @@
 //	func (p *MyPlugin) Init() error     { return nil }
 //	func (p *MyPlugin) Cleanup() error  { return nil }
 //
-// Then register it in main():
+// Then register it in main(), declaring its metadata with SetManifest:
 //
 //	func main() {
+//	    sdk.SetManifest(sdk.Manifest{Name: "my-plugin", Version: "1.0.0"})
 //	    sdk.Register(&MyPlugin{})
 //	}
 //
@@ -23,6 +24,11 @@
 //	tinygo build -o plugin.wasm -target=wasi main.go
 package sdk
 
+import (
+	"encoding/json"
+	"time"
+)
+
 // LogLevel represents the severity of a log message.
 type LogLevel int32
 
@@ -51,7 +57,8 @@ type Plugin interface {
 // TickHandler is implemented by plugins that want periodic callbacks.
 type TickHandler interface {
 	// OnTick is called periodically by the Forge runtime.
-	// The interval is configured in the plugin manifest.
+	// The interval defaults to 10s and can be overridden with the
+	// "tick_interval" (or "interval", in seconds) plugin config key.
 	OnTick() error
 }
 
@@ -86,7 +93,10 @@ type ConfigProvider interface {
 	// ConfigSchema returns the JSON schema for plugin configuration.
 	ConfigSchema() string
 
-	// Configure is called with the plugin configuration.
+	// Configure is called with the plugin configuration as a JSON object
+	// when the plugin is loaded and whenever it is reconfigured. The runtime
+	// validates the configuration against ConfigSchema first, converting
+	// values to the schema's types, and rejects it if it does not match.
 	Configure(config []byte) error
 }
 
@@ -100,11 +110,15 @@ type ConfigProvider interface {
 // Available host functions:
 //   - forgeLog(level, ptr, length) - Write log message
 //   - forgeMetricRecord(keyPtr, keyLen, value) - Record metric
+//   - forgeMetricQuery(namePtr, nameLen, start, end) -> (ptr, length, errCode) - Query metric points
 //   - forgeGetConfig(keyPtr, keyLen) -> (ptr, length) - Get config value
+//   - forgeGetSecret(keyPtr, keyLen) -> (ptr, length) - Get secret value
 //   - forgeHTTPRequest(...) -> (status, respPtr, respLen) - HTTP request
 //   - forgeEmitEvent(...) -> errCode - Emit event
+//   - forgeSubscribe(typePtr, typeLen) -> errCode - Subscribe to events
 //   - forgeReadFile(pathPtr, pathLen) -> (dataPtr, dataLen, errCode) - Read file
 //   - forgeWriteFile(pathPtr, pathLen, dataPtr, dataLen) -> errCode - Write file
+//   - forgeFree(ptr) - Release a response buffer once its data is copied
 
 // ========================================
 // Logging Functions
@@ -164,6 +178,36 @@ func RecordMetricWithTags(name string, value float64, tags map[string]string) {
 	RecordMetric(name, value)
 }
 
+// MetricPoint is a single value of a queried metric. Timestamp is in Unix
+// milliseconds.
+type MetricPoint struct {
+	Timestamp int64   `json:"timestamp"`
+	Value     float64 `json:"value"`
+}
+
+// QueryMetric returns the points recorded for a metric between start and
+// end (inclusive), oldest first. Queries are rate limited per plugin; once
+// the limit is reached it fails with ErrCodeRateLimited until the window
+// resets.
+func QueryMetric(name string, start, end time.Time) ([]MetricPoint, error) {
+	namePtr, nameLen := stringToPtr(name)
+	ptr, length, errCode := forgeMetricQuery(namePtr, nameLen, start.UnixMilli(), end.UnixMilli())
+	if errCode == ErrCodeRateLimited {
+		return nil, &PluginError{Code: int(errCode), Message: "metric query rate limit exceeded"}
+	}
+	if errCode != 0 {
+		return nil, &PluginError{Code: int(errCode), Message: "failed to query metric " + name}
+	}
+
+	data := ptrToBytes(ptr, length)
+	forgeFree(ptr)
+	var points []MetricPoint
+	if err := json.Unmarshal(data, &points); err != nil {
+		return nil, &PluginError{Code: -1, Message: "invalid metric query response"}
+	}
+	return points, nil
+}
+
 // ========================================
 // Configuration Functions
 // ========================================
@@ -175,7 +219,24 @@ func GetConfig(key string) (string, bool) {
 	if ptr == 0 && length == 0 {
 		return "", false
 	}
-	return ptrToString(ptr, length), true
+	value := ptrToString(ptr, length)
+	forgeFree(ptr)
+	return value, true
+}
+
+// GetSecret retrieves a secret set with forge secret set <plugin>/<key>.
+// Plugins only see their own secrets. Secrets are kept encrypted by the
+// host, apart from plugin configuration, and are redacted from plugin log
+// messages.
+func GetSecret(key string) (string, bool) {
+	keyPtr, keyLen := stringToPtr(key)
+	ptr, length := forgeGetSecret(keyPtr, keyLen)
+	if ptr == 0 && length == 0 {
+		return "", false
+	}
+	value := ptrToString(ptr, length)
+	forgeFree(ptr)
+	return value, true
 }
 
 // ========================================
@@ -217,6 +278,9 @@ func httpRequest(method, url string, body []byte) (*HTTPResponse, error) {
 	}
 
 	statusCode, respPtr, respLen := forgeHTTPRequest(methodPtr, methodLen, urlPtr, urlLen, bodyPtr, bodyLen)
+	if statusCode == ErrCodeHostNotAllowed {
+		return nil, &PluginError{Code: int(statusCode), Message: "host not allowed"}
+	}
 	if statusCode < 0 {
 		return nil, &PluginError{Code: int(statusCode), Message: "HTTP request failed"}
 	}
@@ -224,6 +288,7 @@ func httpRequest(method, url string, body []byte) (*HTTPResponse, error) {
 	var respBody []byte
 	if respPtr != 0 && respLen != 0 {
 		respBody = ptrToBytes(respPtr, respLen)
+		forgeFree(respPtr)
 	}
 
 	return &HTTPResponse{
@@ -247,6 +312,91 @@ func EmitEvent(eventType string, payload []byte) error {
 	return nil
 }
 
+// Subscribe registers interest in events of the given type. A pattern may
+// end in ".*" to match a family of events ("metric.*") or be "*" to match
+// every event. Matching events are delivered to the plugin's EventHandler;
+// a plugin never receives events it emitted itself.
+func Subscribe(eventType string) error {
+	typePtr, typeLen := stringToPtr(eventType)
+	result := forgeSubscribe(typePtr, typeLen)
+	if result != 0 {
+		return &PluginError{Code: int(result), Message: "failed to subscribe to " + eventType}
+	}
+	return nil
+}
+
+// dispatchInit runs the registered plugin's Init when the runtime loads or
+// reloads the module. It returns 0 on success, -1 if no plugin is registered
+// and -2 if Init fails.
+func dispatchInit() int32 {
+	if registeredPlugin == nil {
+		return -1
+	}
+	if err := registeredPlugin.Init(); err != nil {
+		Error("init failed: " + err.Error())
+		return -2
+	}
+	return 0
+}
+
+// dispatchCleanup runs the registered plugin's Cleanup before the runtime
+// unloads or replaces the module. Its return codes match dispatchInit.
+func dispatchCleanup() int32 {
+	if registeredPlugin == nil {
+		return -1
+	}
+	if err := registeredPlugin.Cleanup(); err != nil {
+		Error("cleanup failed: " + err.Error())
+		return -2
+	}
+	return 0
+}
+
+// dispatchTick runs the registered plugin's TickHandler. It returns 0 on
+// success, -1 if the plugin does not handle ticks (the runtime then stops
+// scheduling it) and -2 if OnTick fails.
+func dispatchTick() int32 {
+	handler, ok := registeredPlugin.(TickHandler)
+	if !ok {
+		return -1
+	}
+	if err := handler.OnTick(); err != nil {
+		Error("tick handler failed: " + err.Error())
+		return -2
+	}
+	return 0
+}
+
+// dispatchEvent hands an event delivered by the host to the registered
+// plugin's EventHandler. It returns 0 on success and a negative code if the
+// plugin does not handle events or its handler fails.
+func dispatchEvent(eventType string, payload []byte) int32 {
+	handler, ok := registeredPlugin.(EventHandler)
+	if !ok {
+		return -1
+	}
+	if err := handler.OnEvent(eventType, payload); err != nil {
+		Error("event handler failed for " + eventType + ": " + err.Error())
+		return -2
+	}
+	return 0
+}
+
+// dispatchConfigure hands the configuration delivered by the host to the
+// registered plugin's ConfigProvider. Plugins without one accept any
+// configuration. It returns 0 on success and -2 if Configure fails.
+func dispatchConfigure(config []byte) int32 {
+	provider, ok := registeredPlugin.(ConfigProvider)
+	if !ok {
+		return 0
+	}
+	if err := provider.Configure(config); err != nil {
+		Error("configure failed: " + err.Error())
+		return -2
+	}
+	return 0
+}
+
 // ========================================
 // File System Functions (Scoped)
 // ========================================
@@ -258,7 +408,9 @@ func ReadFile(path string) ([]byte, error) {
 	if errCode != 0 {
 		return nil, &PluginError{Code: int(errCode), Message: "failed to read file"}
 	}
-	return ptrToBytes(dataPtr, dataLen), nil
+	data := ptrToBytes(dataPtr, dataLen)
+	forgeFree(dataPtr)
+	return data, nil
 }
 
 // WriteFile writes data to a file in the plugin's data directory.
@@ -266,6 +418,9 @@ func WriteFile(path string, data []byte) error {
 	pathPtr, pathLen := stringToPtr(path)
 	dataPtr, dataLen := bytesToPtr(data)
 	errCode := forgeWriteFile(pathPtr, pathLen, dataPtr, dataLen)
+	if errCode == ErrCodeStorageQuotaExceeded {
+		return &PluginError{Code: int(errCode), Message: "storage quota exceeded"}
+	}
 	if errCode != 0 {
 		return &PluginError{Code: int(errCode), Message: "failed to write file"}
 	}
@@ -276,6 +431,18 @@ func WriteFile(path string, data []byte) error {
 // Error Types
 // ========================================
 
+// ErrCodeHostNotAllowed is returned when the target host is not in the
+// plugin's allow-list or the plugin lacks the network permission.
+const ErrCodeHostNotAllowed = -7
+
+// ErrCodeStorageQuotaExceeded is returned when a write would exceed the
+// plugin's storage quota.
+const ErrCodeStorageQuotaExceeded = -8
+
+// ErrCodeRateLimited is returned when the plugin has made too many metric
+// queries in the current window.
+const ErrCodeRateLimited = -9
+
 // PluginError represents an error from the Forge runtime.
 type PluginError struct {
 	Code    int
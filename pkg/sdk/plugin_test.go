@@ -92,6 +92,17 @@ func TestGetConfig(t *testing.T) {
 	}
 }
 
+func TestGetSecret(t *testing.T) {
+	// Should return empty string with stub implementation
+	value, ok := GetSecret("api_token")
+	if ok {
+		t.Error("expected ok=false from stub")
+	}
+	if value != "" {
+		t.Errorf("expected empty string from stub, got %q", value)
+	}
+}
+
 func TestHTTPGet(t *testing.T) {
 	// Stub returns error
 	resp, err := HTTPGet("http://example.com")
@@ -149,6 +160,180 @@ func TestEmitEvent(t *testing.T) {
 	}
 }
 
+func TestSubscribe(t *testing.T) {
+	// Stub returns error
+	if err := Subscribe("metric.*"); err == nil {
+		t.Error("expected error from stub implementation")
+	}
+}
+
+type eventRecorder struct {
+	eventType string
+	payload   []byte
+	err       error
+}
+
+func (p *eventRecorder) Name() string    { return "recorder" }
+func (p *eventRecorder) Version() string { return "1.0.0" }
+func (p *eventRecorder) Init() error     { return nil }
+func (p *eventRecorder) Cleanup() error  { return nil }
+
+func (p *eventRecorder) OnEvent(eventType string, payload []byte) error {
+	p.eventType = eventType
+	p.payload = payload
+	return p.err
+}
+
+type noEventPlugin struct{}
+
+func (p *noEventPlugin) Name() string    { return "plain" }
+func (p *noEventPlugin) Version() string { return "1.0.0" }
+func (p *noEventPlugin) Init() error     { return nil }
+func (p *noEventPlugin) Cleanup() error  { return nil }
+
+func TestDispatchEvent(t *testing.T) {
+	previous := registeredPlugin
+	defer func() { registeredPlugin = previous }()
+
+	recorder := &eventRecorder{}
+	Register(recorder)
+	if code := dispatchEvent("alert.fired", []byte(`{"rule":"cpu"}`)); code != 0 {
+		t.Errorf("expected code 0, got %d", code)
+	}
+	if recorder.eventType != "alert.fired" || string(recorder.payload) != `{"rule":"cpu"}` {
+		t.Errorf("unexpected event received: %q %q", recorder.eventType, recorder.payload)
+	}
+
+	recorder.err = &PluginError{Code: 1, Message: "boom"}
+	if code := dispatchEvent("alert.fired", nil); code != -2 {
+		t.Errorf("expected code -2 for handler error, got %d", code)
+	}
+
+	Register(&noEventPlugin{})
+	if code := dispatchEvent("alert.fired", nil); code != -1 {
+		t.Errorf("expected code -1 for plugin without EventHandler, got %d", code)
+	}
+}
+
+type tickCounter struct {
+	ticks int
+	err   error
+}
+
+func (p *tickCounter) Name() string    { return "ticker" }
+func (p *tickCounter) Version() string { return "1.0.0" }
+func (p *tickCounter) Init() error     { return nil }
+func (p *tickCounter) Cleanup() error  { return nil }
+
+func (p *tickCounter) OnTick() error {
+	p.ticks++
+	return p.err
+}
+
+func TestDispatchTick(t *testing.T) {
+	previous := registeredPlugin
+	defer func() { registeredPlugin = previous }()
+
+	counter := &tickCounter{}
+	Register(counter)
+	if code := dispatchTick(); code != 0 {
+		t.Errorf("expected code 0, got %d", code)
+	}
+	if counter.ticks != 1 {
+		t.Errorf("expected 1 tick, got %d", counter.ticks)
+	}
+
+	counter.err = &PluginError{Code: 1, Message: "boom"}
+	if code := dispatchTick(); code != -2 {
+		t.Errorf("expected code -2 for handler error, got %d", code)
+	}
+
+	Register(&noEventPlugin{})
+	if code := dispatchTick(); code != -1 {
+		t.Errorf("expected code -1 for plugin without TickHandler, got %d", code)
+	}
+}
+
+type lifecyclePlugin struct {
+	inits, cleanups int
+	err             error
+}
+
+func (p *lifecyclePlugin) Name() string    { return "lifecycle" }
+func (p *lifecyclePlugin) Version() string { return "1.0.0" }
+func (p *lifecyclePlugin) Init() error     { p.inits++; return p.err }
+func (p *lifecyclePlugin) Cleanup() error  { p.cleanups++; return p.err }
+
+func TestDispatchLifecycle(t *testing.T) {
+	previous := registeredPlugin
+	defer func() { registeredPlugin = previous }()
+
+	registeredPlugin = nil
+	if code := dispatchInit(); code != -1 {
+		t.Errorf("expected code -1 without a registered plugin, got %d", code)
+	}
+	if code := dispatchCleanup(); code != -1 {
+		t.Errorf("expected code -1 without a registered plugin, got %d", code)
+	}
+
+	p := &lifecyclePlugin{}
+	Register(p)
+	if code := dispatchInit(); code != 0 {
+		t.Errorf("expected init code 0, got %d", code)
+	}
+	if code := dispatchCleanup(); code != 0 {
+		t.Errorf("expected cleanup code 0, got %d", code)
+	}
+	if p.inits != 1 || p.cleanups != 1 {
+		t.Errorf("expected 1 init and 1 cleanup, got %d and %d", p.inits, p.cleanups)
+	}
+
+	p.err = &PluginError{Code: 1, Message: "boom"}
+	if code := dispatchInit(); code != -2 {
+		t.Errorf("expected init code -2 for failure, got %d", code)
+	}
+	if code := dispatchCleanup(); code != -2 {
+		t.Errorf("expected cleanup code -2 for failure, got %d", code)
+	}
+}
+
+type configPlugin struct {
+	lifecyclePlugin
+	config []byte
+	err    error
+}
+
+func (p *configPlugin) ConfigSchema() string { return `{"type": "object"}` }
+
+func (p *configPlugin) Configure(config []byte) error {
+	p.config = config
+	return p.err
+}
+
+func TestDispatchConfigure(t *testing.T) {
+	previous := registeredPlugin
+	defer func() { registeredPlugin = previous }()
+
+	p := &configPlugin{}
+	Register(p)
+	if code := dispatchConfigure([]byte(`{"interval":30}`)); code != 0 {
+		t.Errorf("expected code 0, got %d", code)
+	}
+	if string(p.config) != `{"interval":30}` {
+		t.Errorf("expected the configuration to be passed through, got %s", p.config)
+	}
+
+	p.err = &PluginError{Code: 1, Message: "bad interval"}
+	if code := dispatchConfigure([]byte(`{}`)); code != -2 {
+		t.Errorf("expected code -2 for a failed Configure, got %d", code)
+	}
+
+	Register(&lifecyclePlugin{})
+	if code := dispatchConfigure([]byte(`{}`)); code != 0 {
+		t.Errorf("expected plugins without a ConfigProvider to accept configuration, got %d", code)
+	}
+}
+
 func TestReadFile(t *testing.T) {
 	// Stub returns error
 	data, err := ReadFile("/test/path")
@@ -20,11 +20,22 @@ func forgeLog(level int32, ptr, length uint32)
 //go:wasmimport forge forge_metric_record
 func forgeMetricRecord(keyPtr, keyLen uint32, value float64)
 
+// forgeMetricQuery returns the points of a metric between two Unix
+// millisecond timestamps as JSON.
+//
+//go:wasmimport forge forge_metric_query
+func forgeMetricQuery(namePtr, nameLen uint32, start, end int64) (ptr, length uint32, errCode int32)
+
 // forgeGetConfig retrieves a configuration value.
 //
 //go:wasmimport forge forge_get_config
 func forgeGetConfig(keyPtr, keyLen uint32) (ptr, length uint32)
 
+// forgeGetSecret retrieves a decrypted secret value.
+//
+//go:wasmimport forge forge_get_secret
+func forgeGetSecret(keyPtr, keyLen uint32) (ptr, length uint32)
+
 // forgeHTTPRequest performs an HTTP request.
 //
 //go:wasmimport forge forge_http_request
@@ -35,6 +46,11 @@ func forgeHTTPRequest(methodPtr, methodLen, urlPtr, urlLen, bodyPtr, bodyLen uin
 //go:wasmimport forge forge_emit_event
 func forgeEmitEvent(typePtr, typeLen, payloadPtr, payloadLen uint32) int32
 
+// forgeSubscribe subscribes the plugin to an event type or pattern.
+//
+//go:wasmimport forge forge_subscribe
+func forgeSubscribe(typePtr, typeLen uint32) int32
+
 // forgeReadFile reads a file from the plugin's data directory.
 //
 //go:wasmimport forge forge_read_file
@@ -45,6 +61,60 @@ func forgeReadFile(pathPtr, pathLen uint32) (dataPtr, dataLen uint32, errCode in
 //go:wasmimport forge forge_write_file
 func forgeWriteFile(pathPtr, pathLen, dataPtr, dataLen uint32) int32
 
+// forgeFree releases a response buffer the runtime allocated because the
+// plugin does not export malloc. Other pointers are ignored.
+//
+//go:wasmimport forge forge_free
+func forgeFree(ptr uint32)
+
+// ========================================
+// Guest Exports (called by the Forge runtime)
+// ========================================
+
+// onEvent receives events the plugin subscribed to with Subscribe.
+//
+//export on_event
+func onEvent(typePtr, typeLen, payloadPtr, payloadLen uint32) int32 {
+	return dispatchEvent(ptrToString(typePtr, typeLen), ptrToBytes(payloadPtr, payloadLen))
+}
+
+// onInit is called after the module is instantiated, including on reload.
+//
+//export on_init
+func onInit() int32 {
+	return dispatchInit()
+}
+
+// onCleanup is called before the module is unloaded or replaced.
+//
+//export on_cleanup
+func onCleanup() int32 {
+	return dispatchCleanup()
+}
+
+// onTick is called periodically by the runtime's plugin scheduler.
+//
+//export on_tick
+func onTick() int32 {
+	return dispatchTick()
+}
+
+// onConfigure receives the plugin configuration as a JSON object.
+//
+//export on_configure
+func onConfigure(ptr, length uint32) int32 {
+	return dispatchConfigure(ptrToBytes(ptr, length))
+}
+
+// forgeManifest returns the plugin's serialized Manifest, packed as
+// ptr<<32 | len, or 0 if the plugin declares none.
+//
+//export forge_manifest
+func forgeManifest() uint64 {
+	ptr, length := bytesToPtr(dispatchManifest())
+	return uint64(ptr)<<32 | uint64(length)
+}
+
 // ========================================
 // Memory Helpers (TinyGo WASM)
 // ========================================
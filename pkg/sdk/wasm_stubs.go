@@ -17,11 +17,21 @@ func forgeMetricRecord(keyPtr, keyLen uint32, value float64) {
 	// Stub - no-op in non-WASM builds
 }
 
+func forgeMetricQuery(namePtr, nameLen uint32, start, end int64) (ptr, length uint32, errCode int32) {
+	// Stub - returns error in non-WASM builds
+	return 0, 0, -1
+}
+
 func forgeGetConfig(keyPtr, keyLen uint32) (ptr, length uint32) {
 	// Stub - returns empty in non-WASM builds
 	return 0, 0
 }
 
+func forgeGetSecret(keyPtr, keyLen uint32) (ptr, length uint32) {
+	// Stub - returns empty in non-WASM builds
+	return 0, 0
+}
+
 func forgeHTTPRequest(methodPtr, methodLen, urlPtr, urlLen, bodyPtr, bodyLen uint32) (statusCode int32, respPtr, respLen uint32) {
 	// Stub - returns error in non-WASM builds
 	return -1, 0, 0
@@ -32,6 +42,11 @@ func forgeEmitEvent(typePtr, typeLen, payloadPtr, payloadLen uint32) int32 {
 	return -1
 }
 
+func forgeSubscribe(typePtr, typeLen uint32) int32 {
+	// Stub - returns error in non-WASM builds
+	return -1
+}
+
 func forgeReadFile(pathPtr, pathLen uint32) (dataPtr, dataLen uint32, errCode int32) {
 	// Stub - returns error in non-WASM builds
 	return 0, 0, -1
@@ -42,6 +57,10 @@ func forgeWriteFile(pathPtr, pathLen, dataPtr, dataLen uint32) int32 {
 	return -1
 }
 
+func forgeFree(ptr uint32) {
+	// Stub - no-op in non-WASM builds
+}
+
 // ========================================
 // Memory Helpers (stub implementations)
 // ========================================
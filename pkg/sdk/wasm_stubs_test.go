@@ -96,6 +96,13 @@ func TestForgeGetConfig_Stub(t *testing.T) {
 	}
 }
 
+func TestForgeGetSecret_Stub(t *testing.T) {
+	ptr, length := forgeGetSecret(0, 0)
+	if ptr != 0 || length != 0 {
+		t.Errorf("expected 0,0 from stub, got %d,%d", ptr, length)
+	}
+}
+
 func TestForgeHTTPRequest_Stub(t *testing.T) {
 	status, respPtr, respLen := forgeHTTPRequest(0, 0, 0, 0, 0, 0)
 	if status != -1 {